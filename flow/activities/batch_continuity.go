@@ -0,0 +1,80 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// checkBatchContinuity asserts the offset the destination resumes from is where the catalog saw its last batch end,
+// pausing the mirror on a gap since pulling on would skip the changes in it
+func (a *FlowableActivity) checkBatchContinuity(
+	ctx context.Context,
+	logger log.Logger,
+	config *protos.FlowConnectionConfigs,
+	dstConn connectors.CDCSyncConnectorCore,
+	lastOffset int64,
+) error {
+	if enabled, err := peerdbenv.PeerDBCDCGapDetection(ctx, config.Env); err != nil {
+		return err
+	} else if !enabled {
+		return nil
+	}
+
+	dstBatchID, err := dstConn.GetLastSyncBatchID(ctx, config.FlowJobName)
+	if err != nil {
+		return fmt.Errorf("failed to get last sync batch from destination: %w", err)
+	}
+	catalogBatchID, err := monitoring.GetLastCDCBatchID(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		return err
+	}
+	// a batch that failed may have saved partial progress, and a destination behind the catalog is left to reconciliation
+	if dstBatchID == 0 || catalogBatchID != dstBatchID {
+		return nil
+	}
+	batchEndLSN, err := monitoring.GetCDCBatchEndLSN(ctx, a.CatalogPool, config.FlowJobName, dstBatchID)
+	if err != nil {
+		return err
+	}
+	if lastOffset < batchEndLSN {
+		logger.Warn("destination offset is behind the end of its last batch, changes will be synced again",
+			slog.Int64("batchID", dstBatchID), slog.Int64("batchEndLSN", batchEndLSN), slog.Int64("lastOffset", lastOffset))
+	}
+
+	gapErr := utils.CheckBatchContinuity(config.FlowJobName, dstBatchID, batchEndLSN, lastOffset)
+	if gapErr == nil {
+		return nil
+	}
+	logger.Error("gap in changes between batches, pausing mirror", slog.Any("error", gapErr))
+	a.Alerter.LogFlowError(ctx, config.FlowJobName, gapErr)
+	if err := a.pauseMirror(ctx, config.FlowJobName); err != nil {
+		logger.Error("failed to pause mirror with gap in changes", slog.Any("error", err))
+		gapErr = errors.Join(gapErr, err)
+	}
+	return temporal.NewNonRetryableApplicationError(gapErr.Error(), "gap", gapErr)
+}
+
+func (a *FlowableActivity) pauseMirror(ctx context.Context, flowJobName string) error {
+	if a.TemporalClient == nil {
+		return errors.New("no temporal client to signal pause with")
+	}
+	var workflowID string
+	if err := a.CatalogPool.QueryRow(ctx,
+		"SELECT workflow_id FROM flows WHERE name = $1", flowJobName,
+	).Scan(&workflowID); err != nil {
+		return fmt.Errorf("unable to get workflowID for flow job %s: %w", flowJobName, err)
+	}
+	return model.FlowSignal.SignalClientWorkflow(ctx, a.TemporalClient, workflowID, "", model.PauseSignal)
+}
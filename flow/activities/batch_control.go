@@ -0,0 +1,31 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+)
+
+// recordBatchControl writes a committed normalize to the batch control table of the destination,
+// a missing row is alerted on rather than failing the normalize as the next one covers later batches
+func (a *FlowableActivity) recordBatchControl(
+	ctx context.Context,
+	flowName string,
+	dstConn connectors.Connector,
+	startBatchID int64,
+	endBatchID int64,
+) {
+	batchConn, ok := dstConn.(connectors.BatchControlConnector)
+	if !ok {
+		return
+	}
+	batch, err := monitoring.GetNormalizedBatch(ctx, a.CatalogPool, flowName, startBatchID, endBatchID)
+	if err == nil {
+		err = batchConn.RecordNormalizedBatch(ctx, flowName, batch)
+	}
+	if err != nil {
+		a.Alerter.LogFlowError(ctx, flowName, fmt.Errorf("failed to write batch %d to batch control table: %w", endBatchID, err))
+	}
+}
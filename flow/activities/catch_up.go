@@ -0,0 +1,50 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+type CatchUpGrant struct {
+	// when the mirror entered catch up mode, before this grant if it already was in it
+	StartedAt time.Time
+	Granted   bool
+}
+
+// AcquireCatchUp takes a place for the mirror in the catch up budget of its destination peer,
+// mirrors not granted one keep syncing with steady state settings
+func (a *FlowableActivity) AcquireCatchUp(ctx context.Context, flowName string, peerName string,
+	env map[string]string,
+) (*CatchUpGrant, error) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, flowName)
+	maxPerPeer, err := peerdbenv.PeerDBCatchUpMaxMirrorsPerPeer(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catch up max mirrors per peer: %w", err)
+	}
+	maxDuration, err := peerdbenv.PeerDBCatchUpMaxDuration(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catch up max duration: %w", err)
+	}
+	granted, startedAt, err := monitoring.AcquireCatchUp(ctx, a.CatalogPool, flowName, peerName, maxPerPeer, maxDuration)
+	if err != nil {
+		return nil, err
+	}
+	if !granted {
+		activity.GetLogger(ctx).Info("catch up budget of peer exhausted, syncing with steady state settings",
+			slog.String("peer", peerName), slog.Uint64("maxMirrors", uint64(maxPerPeer)))
+	}
+	return &CatchUpGrant{Granted: granted, StartedAt: startedAt}, nil
+}
+
+func (a *FlowableActivity) ReleaseCatchUp(ctx context.Context, flowName string) error {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, flowName)
+	return monitoring.ReleaseCatchUp(ctx, a.CatalogPool, flowName)
+}
@@ -0,0 +1,73 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// columnCiphers returns ciphers of encrypted source columns by their name
+func (a *FlowableActivity) columnCiphers(
+	ctx context.Context,
+	encrypted map[string]*protos.ColumnEncryption,
+) (map[string]*shared.ColumnCipher, error) {
+	if len(encrypted) == 0 {
+		return nil, nil
+	}
+	keys := make(map[string][]byte)
+	ciphers := make(map[string]*shared.ColumnCipher, len(encrypted))
+	for column, encryption := range encrypted {
+		key, ok := keys[encryption.KeyName]
+		if !ok {
+			var err error
+			if key, err = monitoring.GetColumnEncryptionKey(ctx, a.CatalogPool, encryption.KeyName); err != nil {
+				return nil, err
+			}
+			keys[encryption.KeyName] = key
+		}
+		columnCipher, err := shared.NewColumnCipher(key, encryption.Mode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid column encryption key %s: %w", encryption.KeyName, err)
+		}
+		ciphers[column] = columnCipher
+	}
+	return ciphers, nil
+}
+
+// encryptValue encrypts the text of a value, raw bytes for bytea, leaving nulls as they are
+func encryptValue(columnCipher *shared.ColumnCipher, qv qvalue.QValue) (qvalue.QValue, error) {
+	if qv == nil || qv.Value() == nil {
+		return qv, nil
+	}
+	var plaintext []byte
+	switch v := qv.(type) {
+	case qvalue.QValueString:
+		plaintext = []byte(v.Val)
+	case qvalue.QValueBytes:
+		plaintext = v.Val
+	default:
+		plaintext = []byte(fmt.Sprint(qv.Value()))
+	}
+	ciphertext, err := columnCipher.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return qvalue.QValueString{Val: ciphertext}, nil
+}
+
+// encryptTransform encrypts values of a column, which lands on the destination as text
+type encryptTransform struct {
+	cipher *shared.ColumnCipher
+}
+
+func (t encryptTransform) transformValue(qv qvalue.QValue) (qvalue.QValue, error) {
+	return encryptValue(t.cipher, qv)
+}
+
+func (t encryptTransform) columnType(system protos.TypeSystem) string {
+	return shared.TextColumnType(system)
+}
@@ -0,0 +1,99 @@
+package activities
+
+import (
+	"context"
+	"log/slog"
+
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+type qrepSyncRecordsFunc = func(
+	connectors.QRepSyncConnector, context.Context, *protos.QRepConfig, *protos.QRepPartition, *model.QRecordStream,
+) (int, error)
+
+type snapshotColumnStats struct {
+	collector *model.ColumnStatsCollector
+}
+
+// collectSnapshotColumnStats wraps syncRecords to collect column statistics of what it syncs,
+// only for partitions of an initial snapshot. Returned stats are nil when not collecting.
+func (a *FlowableActivity) collectSnapshotColumnStats(
+	ctx context.Context,
+	logger log.Logger,
+	config *protos.QRepConfig,
+	syncRecords qrepSyncRecordsFunc,
+) (qrepSyncRecordsFunc, *snapshotColumnStats) {
+	if !config.InitialCopyOnly || config.ParentMirrorName == "" {
+		return syncRecords, nil
+	}
+	enabled, err := peerdbenv.PeerDBSnapshotColumnStats(ctx, config.Env)
+	if err != nil {
+		logger.Warn("failed to check if snapshot column stats are enabled", slog.Any("error", err))
+		return syncRecords, nil
+	} else if !enabled {
+		return syncRecords, nil
+	}
+
+	stats := &snapshotColumnStats{}
+	return func(
+		conn connectors.QRepSyncConnector,
+		ctx context.Context,
+		config *protos.QRepConfig,
+		partition *protos.QRepPartition,
+		stream *model.QRecordStream,
+	) (int, error) {
+		// syncRecords stops reading output when it fails, sending is abandoned once it returned
+		teeCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		output := model.NewQRecordStream(0)
+		go func() {
+			schema := stream.Schema()
+			output.SetSchema(schema)
+			stats.collector = model.NewColumnStatsCollector(schema)
+			for record := range stream.Records {
+				stats.collector.AddRecord(record)
+				select {
+				case output.Records <- record:
+				case <-teeCtx.Done():
+					output.Close(teeCtx.Err())
+					for range stream.Records {
+						// still read records so the pull doesn't block
+					}
+					return
+				}
+			}
+			output.Close(stream.Err())
+		}()
+		return syncRecords(conn, ctx, config, partition, output)
+	}, stats
+}
+
+// saveSnapshotColumnStats is best effort, statistics are only hints for destinations
+func (a *FlowableActivity) saveSnapshotColumnStats(
+	ctx context.Context,
+	logger log.Logger,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stats *snapshotColumnStats,
+) {
+	if stats.collector == nil {
+		// partition was already synced
+		return
+	}
+	if err := monitoring.UpsertSnapshotColumnStats(ctx, a.CatalogPool, config.ParentMirrorName, partition.PartitionId,
+		&protos.TableColumnStatistics{
+			SourceTable:      config.WatermarkTable,
+			DestinationTable: config.DestinationTableIdentifier,
+			Columns:          stats.collector.Stats(),
+		},
+	); err != nil {
+		logger.Warn("failed to save snapshot column stats", slog.String("partitionId", partition.PartitionId),
+			slog.Any("error", err))
+	}
+}
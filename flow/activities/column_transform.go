@@ -0,0 +1,206 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils/anonymize"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// columnTransform replaces values of a source column before they're synced
+type columnTransform interface {
+	transformValue(qvalue.QValue) (qvalue.QValue, error)
+	// type of the destination column in a type system, empty keeps the type of the source column
+	columnType(protos.TypeSystem) string
+}
+
+// anonymizeTransform replaces values of a column by synthetic ones of the same type
+type anonymizeTransform struct {
+	anonymizer *anonymize.Anonymizer
+	value      protos.SyntheticValue
+}
+
+func (t anonymizeTransform) transformValue(qv qvalue.QValue) (qvalue.QValue, error) {
+	return t.anonymizer.Value(t.value, qv)
+}
+
+func (t anonymizeTransform) columnType(protos.TypeSystem) string {
+	return ""
+}
+
+// columnTransforms returns transforms of source columns by their name, validation makes sure
+// columns aren't both encrypted and anonymized
+func (a *FlowableActivity) columnTransforms(
+	ctx context.Context,
+	encrypted map[string]*protos.ColumnEncryption,
+	anonymized map[string]protos.SyntheticValue,
+	anonymizationSeed string,
+) (map[string]columnTransform, error) {
+	ciphers, err := a.columnCiphers(ctx, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphers) == 0 && len(anonymized) == 0 {
+		return nil, nil
+	}
+	transforms := make(map[string]columnTransform, len(ciphers)+len(anonymized))
+	if len(anonymized) > 0 {
+		anonymizer := anonymize.NewAnonymizer(anonymizationSeed)
+		for column, value := range anonymized {
+			transforms[column] = anonymizeTransform{anonymizer: anonymizer, value: value}
+		}
+	}
+	for column, columnCipher := range ciphers {
+		transforms[column] = encryptTransform{cipher: columnCipher}
+	}
+	return transforms, nil
+}
+
+// tableColumnTransforms returns transforms of source columns by their destination table
+func (a *FlowableActivity) tableColumnTransforms(
+	ctx context.Context,
+	tableMappings []*protos.TableMapping,
+	anonymization *protos.AnonymizationProfile,
+) (map[string]map[string]columnTransform, error) {
+	var tableTransforms map[string]map[string]columnTransform
+	for _, mapping := range tableMappings {
+		transforms, err := a.columnTransforms(ctx, shared.EncryptedColumns(mapping),
+			shared.AnonymizedColumns(anonymization, mapping), anonymization.GetSeed())
+		if err != nil {
+			return nil, err
+		} else if len(transforms) == 0 {
+			continue
+		}
+		if tableTransforms == nil {
+			tableTransforms = make(map[string]map[string]columnTransform)
+		}
+		tableTransforms[mapping.DestinationTableIdentifier] = transforms
+	}
+	return tableTransforms, nil
+}
+
+func transformItems(transforms map[string]columnTransform, items model.RecordItems) error {
+	for column, transform := range transforms {
+		// unchanged TOAST columns are missing from updates
+		qv, ok := items.ColToVal[column]
+		if !ok {
+			continue
+		}
+		transformed, err := transform.transformValue(qv)
+		if err != nil {
+			return fmt.Errorf("failed to transform column %s: %w", column, err)
+		}
+		items.ColToVal[column] = transformed
+	}
+	return nil
+}
+
+// attachColumnTransforms transforms columns of records before they're synced,
+// columns added at source with a transform are added with the type the transform gives them
+func attachColumnTransforms(
+	ctx context.Context,
+	stream *model.CDCStream[model.RecordItems],
+	tableTransforms map[string]map[string]columnTransform,
+	onErr context.CancelCauseFunc,
+) *model.CDCStream[model.RecordItems] {
+	outstream := model.NewCDCStream[model.RecordItems](0)
+
+	handleErr := func(err error) {
+		onErr(err)
+		<-ctx.Done()
+		for range stream.GetRecords() {
+			// still read records to make sure input closes first
+		}
+	}
+
+	go func() {
+		if stream.WaitAndCheckEmpty() {
+			outstream.SignalAsEmpty()
+			<-stream.GetRecords() // needed because empty signal comes before Close
+		} else {
+			outstream.SignalAsNotEmpty()
+			for record := range stream.GetRecords() {
+				if transforms, ok := tableTransforms[record.GetDestinationTableName()]; ok {
+					var err error
+					switch r := record.(type) {
+					case *model.InsertRecord[model.RecordItems]:
+						err = transformItems(transforms, r.Items)
+					case *model.UpdateRecord[model.RecordItems]:
+						if err = transformItems(transforms, r.NewItems); err == nil {
+							err = transformItems(transforms, r.OldItems)
+						}
+					case *model.DeleteRecord[model.RecordItems]:
+						err = transformItems(transforms, r.Items)
+					}
+					if err != nil {
+						handleErr(err)
+						break
+					}
+				}
+				if err := outstream.AddRecord(ctx, record); err != nil {
+					handleErr(err)
+					break
+				}
+			}
+		}
+		for _, schemaDelta := range stream.SchemaDeltas {
+			transforms := tableTransforms[schemaDelta.DstTableName]
+			for _, column := range schemaDelta.AddedColumns {
+				if transform, ok := transforms[column.Name]; ok {
+					if columnType := transform.columnType(schemaDelta.System); columnType != "" {
+						column.Type = columnType
+						column.TypeModifier = -1
+					}
+				}
+			}
+		}
+		outstream.SchemaDeltas = stream.SchemaDeltas
+		outstream.AddTruncatedTables(stream.TruncatedTables...)
+		outstream.UpdateLatestCheckpoint(stream.GetLastCheckpoint())
+		outstream.Close()
+	}()
+	return outstream
+}
+
+// attachQRepColumnTransforms transforms columns of records copied by the initial snapshot
+func attachQRepColumnTransforms(stream *model.QRecordStream, transforms map[string]columnTransform) *model.QRecordStream {
+	output := model.NewQRecordStream(0)
+	go func() {
+		schema := stream.Schema()
+		fields := make([]qvalue.QField, len(schema.Fields))
+		fieldTransforms := make([]columnTransform, len(schema.Fields))
+		for i, field := range schema.Fields {
+			fields[i] = field
+			if transform, ok := transforms[field.Name]; ok {
+				if columnType := transform.columnType(protos.TypeSystem_Q); columnType != "" {
+					fields[i].Type = qvalue.QValueKind(columnType)
+				}
+				fieldTransforms[i] = transform
+			}
+		}
+		output.SetSchema(qvalue.NewQRecordSchema(fields))
+		for record := range stream.Records {
+			for i, transform := range fieldTransforms {
+				if transform == nil {
+					continue
+				}
+				transformed, err := transform.transformValue(record[i])
+				if err != nil {
+					output.Close(fmt.Errorf("failed to transform column %s: %w", fields[i].Name, err))
+					for range stream.Records {
+						// still read records so the pull doesn't block
+					}
+					return
+				}
+				record[i] = transformed
+			}
+			output.Records <- record
+		}
+		output.Close(stream.Err())
+	}()
+	return output
+}
@@ -0,0 +1,332 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.temporal.io/sdk/activity"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/otel_metrics"
+	"github.com/PeerDB-io/peer-flow/otel_metrics/peerdb_gauges"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const maxRecordedDataDiffMismatches = 20
+
+// SampleDataDiffs compares random rows of source tables against the destination for running mirrors opting into it,
+// rows still differing once the mirror had time to sync them are recorded and alerted on
+func (a *FlowableActivity) SampleDataDiffs(ctx context.Context) error {
+	rows, err := a.CatalogPool.Query(ctx,
+		"SELECT DISTINCT ON (name) workflow_id, config_proto FROM flows WHERE query_string IS NULL")
+	if err != nil {
+		return err
+	}
+	mirrors, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (reconcileMirror, error) {
+		var mirror reconcileMirror
+		var configProto []byte
+		if err := row.Scan(&mirror.workflowID, &configProto); err != nil {
+			return mirror, err
+		}
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configProto, &config); err != nil {
+			return mirror, err
+		}
+		mirror.config = &config
+		return mirror, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var sampled atomic.Int64
+	shutdown := heartbeatRoutine(ctx, func() string {
+		return fmt.Sprintf("sampled %d tables", sampled.Load())
+	})
+	defer shutdown()
+
+	logger := activity.GetLogger(ctx)
+	for _, mirror := range mirrors {
+		if mirror.config.DataDiff == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// mirrors paused or still snapshotting are behind the source by design
+		status, err := a.getFlowStatus(ctx, mirror.workflowID)
+		if err != nil {
+			logger.Warn("failed to get status of mirror", slog.String("flowName", mirror.config.FlowJobName),
+				slog.Any("error", err))
+			continue
+		}
+		if status != protos.FlowStatus_STATUS_RUNNING {
+			continue
+		}
+		if err := a.sampleDataDiff(ctx, mirror.config, &sampled); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Warn("failed to sample data diff", slog.String("flowName", mirror.config.FlowJobName),
+				slog.Any("error", err))
+			a.Alerter.LogFlowError(ctx, mirror.config.FlowJobName, fmt.Errorf("data diff sampling failed: %w", err))
+		}
+	}
+	return nil
+}
+
+func (a *FlowableActivity) getFlowStatus(ctx context.Context, workflowID string) (protos.FlowStatus, error) {
+	res, err := a.TemporalClient.QueryWorkflow(ctx, workflowID, "", shared.FlowStatusQuery)
+	if err != nil {
+		return protos.FlowStatus_STATUS_UNKNOWN, err
+	}
+	var status protos.FlowStatus
+	if err := res.Get(&status); err != nil {
+		return protos.FlowStatus_STATUS_UNKNOWN, err
+	}
+	return status, nil
+}
+
+// dataDiffTable is a source table along with how its columns are named at the destination
+type dataDiffTable struct {
+	tableMapping *protos.TableMapping
+	keyColumns   []string
+	columns      map[string]string
+}
+
+func newDataDiffTable(tableMapping *protos.TableMapping, tableSchema *protos.TableSchema) dataDiffTable {
+	renames := make(map[string]string, len(tableMapping.Columns))
+	for _, col := range tableMapping.Columns {
+		if col.DestinationName != "" {
+			renames[col.SourceName] = col.DestinationName
+		}
+	}
+	columns := make(map[string]string, len(tableSchema.Columns))
+	for _, col := range tableSchema.Columns {
+		if slices.Contains(tableMapping.Exclude, col.Name) {
+			continue
+		}
+		if rename, ok := renames[col.Name]; ok {
+			columns[col.Name] = rename
+		} else {
+			columns[col.Name] = col.Name
+		}
+	}
+	return dataDiffTable{
+		tableMapping: tableMapping,
+		keyColumns:   tableSchema.PrimaryKeyColumns,
+		columns:      columns,
+	}
+}
+
+func (t dataDiffTable) destinationKeyColumns() []string {
+	keyColumns := make([]string, 0, len(t.keyColumns))
+	for _, keyColumn := range t.keyColumns {
+		keyColumns = append(keyColumns, t.columns[keyColumn])
+	}
+	return keyColumns
+}
+
+func (a *FlowableActivity) sampleDataDiff(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	sampled *atomic.Int64,
+) error {
+	sampleSize := int(config.DataDiff.SampleSize)
+	if sampleSize == 0 {
+		sampleSize = 100
+	}
+	interval := time.Duration(config.DataDiff.IntervalMinutes) * time.Minute
+	if interval == 0 {
+		interval = time.Hour
+	}
+	lastSampled, err := monitoring.GetLastDataDiffSamples(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		return err
+	}
+	schemas, err := monitoring.GetLatestSourceSchemas(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		return err
+	}
+	var due []dataDiffTable
+	for _, tableMapping := range config.TableMappings {
+		// rows can only be matched up by primary key
+		tableSchema := schemas[tableMapping.SourceTableIdentifier]
+		if tableSchema == nil || len(tableSchema.PrimaryKeyColumns) == 0 {
+			continue
+		}
+		if last, ok := lastSampled[tableMapping.SourceTableIdentifier]; !ok || time.Since(last) >= interval {
+			due = append(due, newDataDiffTable(tableMapping, tableSchema))
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	srcConn, err := connectors.GetByNameAs[connectors.RowSamplingConnector](ctx, config.Env, a.CatalogPool, config.SourceName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return fmt.Errorf("failed to connect to source %s: %w", config.SourceName, err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+	dstConn, err := connectors.GetByNameAs[connectors.RowLookupConnector](ctx, config.Env, a.CatalogPool, config.DestinationName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return fmt.Errorf("failed to connect to destination %s: %w", config.DestinationName, err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	mismatchedGauge := a.getDataDiffGauge(ctx)
+	logger := activity.GetLogger(ctx)
+	var tableErrs []error
+	for _, table := range due {
+		tableName := table.tableMapping.SourceTableIdentifier
+		rowsSampled, mismatches, err := a.diffTable(ctx, config, srcConn, dstConn, table, sampleSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			tableErrs = append(tableErrs, fmt.Errorf("failed to diff %s: %w", tableName, err))
+			continue
+		}
+		sampled.Add(1)
+		if err := monitoring.AppendDataDiffSample(ctx, a.CatalogPool, config.FlowJobName, tableName,
+			rowsSampled, mismatches, maxRecordedDataDiffMismatches); err != nil {
+			return err
+		}
+		if mismatchedGauge != nil {
+			mismatchedGauge.Set(int64(len(mismatches)), attribute.NewSet(append(
+				peerdb_gauges.MirrorLabelAttributes(config.Labels),
+				attribute.String(peerdb_gauges.FlowNameKey, config.FlowJobName),
+				attribute.String(peerdb_gauges.TableNameKey, tableName),
+				attribute.String(peerdb_gauges.DeploymentUidKey, peerdbenv.PeerDBDeploymentUID()))...))
+		}
+		if len(mismatches) > 0 {
+			logger.Warn("source and destination rows differ", slog.String("flowName", config.FlowJobName),
+				slog.String("table", tableName), slog.Int("rowsSampled", rowsSampled),
+				slog.Int("rowsDiffering", len(mismatches)))
+			a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf(
+				"%d of %d sampled rows of %s differ between source and destination, first differing row: %v",
+				len(mismatches), rowsSampled, tableName, mismatches[0].Key))
+		}
+	}
+	return errors.Join(tableErrs...)
+}
+
+// diffTable samples rows of a table, rows differing are looked up again on both sides once the mirror
+// had time to sync changes made since, so only rows that stay different count as mismatches
+func (a *FlowableActivity) diffTable(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	srcConn connectors.RowSamplingConnector,
+	dstConn connectors.RowLookupConnector,
+	table dataDiffTable,
+	sampleSize int,
+) (int, []model.DataDiffMismatch, error) {
+	srcTable := table.tableMapping.SourceTableIdentifier
+	dstTable := table.tableMapping.DestinationTableIdentifier
+	srcRows, err := srcConn.SampleRows(ctx, config.FlowJobName, srcTable, sampleSize)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(srcRows) == 0 {
+		return 0, nil, nil
+	}
+	keys := dataDiffKeys(srcRows, table.keyColumns)
+	dstRows, err := dstConn.LookupRows(ctx, config.FlowJobName, dstTable, table.destinationKeyColumns(), keys)
+	if err != nil {
+		return 0, nil, err
+	}
+	mismatches := model.CompareSampledRows(srcRows, dstRows, table.keyColumns, table.columns)
+	if len(mismatches) == 0 {
+		return len(srcRows), nil, nil
+	}
+
+	wait := max(time.Minute, 2*time.Duration(config.IdleTimeoutSeconds)*time.Second)
+	select {
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	case <-time.After(wait):
+	}
+
+	mismatchedKeys := make(map[string]struct{}, len(mismatches))
+	for _, mismatch := range mismatches {
+		mismatchedKeys[fmt.Sprint(mismatch.Key)] = struct{}{}
+	}
+	var recheck [][]any
+	for i, row := range srcRows {
+		if _, ok := mismatchedKeys[fmt.Sprint(model.SampledRowKey(row, table.keyColumns))]; ok {
+			recheck = append(recheck, keys[i])
+		}
+	}
+	if len(recheck) == 0 {
+		return len(srcRows), mismatches, nil
+	}
+	// rows deleted at the source in the meantime drop out of the recheck
+	srcRows, err = srcConn.LookupRows(ctx, config.FlowJobName, srcTable, table.keyColumns, recheck)
+	if err != nil {
+		return 0, nil, err
+	}
+	dstRows, err = dstConn.LookupRows(ctx, config.FlowJobName, dstTable, table.destinationKeyColumns(),
+		dataDiffKeys(srcRows, table.keyColumns))
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(keys), model.CompareSampledRows(srcRows, dstRows, table.keyColumns, table.columns), nil
+}
+
+// dataDiffKeys extracts keys of rows as arguments any database driver accepts
+func dataDiffKeys(rows []model.SampledRow, keyColumns []string) [][]any {
+	keys := make([][]any, 0, len(rows))
+	for _, row := range rows {
+		key := make([]any, 0, len(keyColumns))
+		for _, keyColumn := range keyColumns {
+			switch v := row[keyColumn].(type) {
+			case [16]byte:
+				key = append(key, uuid.UUID(v).String())
+			case uuid.UUID:
+				key = append(key, v.String())
+			case decimal.Decimal:
+				key = append(key, v.String())
+			default:
+				key = append(key, v)
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (a *FlowableActivity) getDataDiffGauge(ctx context.Context) *otel_metrics.Int64SyncGauge {
+	if a.OtelManager == nil {
+		return nil
+	}
+	gauge, err := otel_metrics.GetOrInitInt64SyncGauge(a.OtelManager.Meter,
+		a.OtelManager.Int64GaugesCache,
+		peerdb_gauges.DataDiffMismatchedRowsGaugeName,
+		metric.WithDescription("Sampled rows differing between source and destination"))
+	if err != nil {
+		activity.GetLogger(ctx).Error("Failed to get data diff mismatched rows gauge", slog.Any("error", err))
+		return nil
+	}
+	return gauge
+}
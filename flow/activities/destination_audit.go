@@ -0,0 +1,44 @@
+package activities
+
+import (
+	"context"
+	"log/slog"
+
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// withDestinationAudit captures statements executed against the destination with the returned context,
+// returned log is nil when PEERDB_DESTINATION_AUDIT_LOG is disabled
+func withDestinationAudit(ctx context.Context, logger log.Logger, env map[string]string) (context.Context, *audit.Log) {
+	enabled, err := peerdbenv.PeerDBDestinationAuditLog(ctx, env)
+	if err != nil {
+		logger.Warn("failed to check if destination audit log is enabled", slog.Any("error", err))
+		return ctx, nil
+	} else if !enabled {
+		return ctx, nil
+	}
+	return audit.WithLog(ctx)
+}
+
+// saveDestinationAudit stores captured statements under batchID, setup statements use batch 0.
+// The statements were already run by then, so failing to store them is logged
+// rather than running them again on retry.
+func (a *FlowableActivity) saveDestinationAudit(
+	ctx context.Context,
+	logger log.Logger,
+	flowName string,
+	batchID int64,
+	auditLog *audit.Log,
+) {
+	statements := auditLog.Statements()
+	if len(statements) == 0 {
+		return
+	}
+	if err := monitoring.AppendDestinationStatements(ctx, a.CatalogPool, flowName, batchID, statements); err != nil {
+		logger.Warn("failed to save destination audit log", slog.Int64("batchID", batchID), slog.Any("error", err))
+	}
+}
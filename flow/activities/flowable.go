@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,16 +16,20 @@ import (
 	lua "github.com/yuin/gopher-lua"
 	"go.opentelemetry.io/otel/metric"
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/temporal"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peer-flow/alerting"
+	"github.com/PeerDB-io/peer-flow/cdcsync"
 	"github.com/PeerDB-io/peer-flow/connectors"
 	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/faults"
 	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/lineage"
 	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/otel_metrics"
 	"github.com/PeerDB-io/peer-flow/otel_metrics/peerdb_gauges"
@@ -43,11 +49,13 @@ type CdcCacheEntry struct {
 }
 
 type FlowableActivity struct {
-	CatalogPool *pgxpool.Pool
-	Alerter     *alerting.Alerter
-	CdcCache    map[string]CdcCacheEntry
-	OtelManager *otel_metrics.OtelManager
-	CdcCacheRw  sync.RWMutex
+	CatalogPool    *pgxpool.Pool
+	Alerter        *alerting.Alerter
+	CdcCache       map[string]CdcCacheEntry
+	OtelManager    *otel_metrics.OtelManager
+	ResourceUsage  *ResourceUsageTracker
+	TemporalClient client.Client
+	CdcCacheRw     sync.RWMutex
 }
 
 func (a *FlowableActivity) CheckConnection(
@@ -56,7 +64,19 @@ func (a *FlowableActivity) CheckConnection(
 ) (*CheckConnectionResult, error) {
 	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowName)
 	dstConn, err := connectors.GetByNameAs[connectors.CDCSyncConnector](ctx, config.Env, a.CatalogPool, config.PeerName)
-	if err != nil {
+	if errors.Is(err, errors.ErrUnsupported) {
+		// source only connectors, like synthetic peers, have no metadata tables
+		conn, err := connectors.GetByNameAs[connectors.Connector](ctx, config.Env, a.CatalogPool, config.PeerName)
+		if err != nil {
+			a.Alerter.LogFlowError(ctx, config.FlowName, err)
+			return nil, fmt.Errorf("failed to get connector: %w", err)
+		}
+		defer connectors.CloseConnector(ctx, conn)
+		if err := conn.ConnectionActive(ctx); err != nil {
+			return nil, fmt.Errorf("failed to check connection: %w", err)
+		}
+		return &CheckConnectionResult{}, nil
+	} else if err != nil {
 		a.Alerter.LogFlowError(ctx, config.FlowName, err)
 		return nil, fmt.Errorf("failed to get connector: %w", err)
 	}
@@ -111,17 +131,20 @@ func (a *FlowableActivity) CreateRawTable(
 	config *protos.CreateRawTableInput,
 ) (*protos.CreateRawTableOutput, error) {
 	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
+	logger := activity.GetLogger(ctx)
 	dstConn, err := connectors.GetByNameAs[connectors.CDCSyncConnector](ctx, nil, a.CatalogPool, config.PeerName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get connector: %w", err)
 	}
 	defer connectors.CloseConnector(ctx, dstConn)
 
-	res, err := dstConn.CreateRawTable(ctx, config)
+	auditCtx, auditLog := withDestinationAudit(ctx, logger, nil)
+	res, err := dstConn.CreateRawTable(auditCtx, config)
 	if err != nil {
 		a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 		return nil, err
 	}
+	a.saveDestinationAudit(ctx, logger, config.FlowJobName, 0, auditLog)
 	if err := monitoring.InitializeCDCFlow(ctx, a.CatalogPool, config.FlowJobName); err != nil {
 		return nil, err
 	}
@@ -145,7 +168,16 @@ func (a *FlowableActivity) GetTableSchema(
 		return "getting table schema"
 	})
 
-	return srcConn.GetTableSchema(ctx, config)
+	res, err := srcConn.GetTableSchema(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.FlowName != "" {
+		if err := monitoring.RecordSourceSchemas(ctx, a.CatalogPool, config.FlowName, res.TableNameSchemaMapping); err != nil {
+			activity.GetLogger(ctx).Warn("failed to record source schema history", slog.Any("error", err))
+		}
+	}
+	return res, nil
 }
 
 // CreateNormalizedTable creates normalized tables in destination.
@@ -154,7 +186,7 @@ func (a *FlowableActivity) CreateNormalizedTable(
 	config *protos.SetupNormalizedTableBatchInput,
 ) (*protos.SetupNormalizedTableBatchOutput, error) {
 	logger := activity.GetLogger(ctx)
-	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowName)
+	ctx = shared.WithMirrorLabels(context.WithValue(ctx, shared.FlowNameKey, config.FlowName), config.Labels)
 	conn, err := connectors.GetByNameAs[connectors.NormalizedTablesConnector](ctx, config.Env, a.CatalogPool, config.PeerName)
 	if err != nil {
 		if errors.Is(err, errors.ErrUnsupported) {
@@ -179,10 +211,11 @@ func (a *FlowableActivity) CreateNormalizedTable(
 	})
 	defer shutdown()
 
+	auditCtx, auditLog := withDestinationAudit(ctx, logger, config.Env)
 	tableExistsMapping := make(map[string]bool, len(config.TableNameSchemaMapping))
 	for tableIdentifier := range config.TableNameSchemaMapping {
 		existing, err := conn.SetupNormalizedTable(
-			ctx,
+			auditCtx,
 			tx,
 			config,
 			tableIdentifier,
@@ -204,6 +237,15 @@ func (a *FlowableActivity) CreateNormalizedTable(
 	if err := conn.FinishSetupNormalizedTables(ctx, tx); err != nil {
 		return nil, fmt.Errorf("failed to commit normalized tables tx: %w", err)
 	}
+	a.saveDestinationAudit(ctx, logger, config.FlowName, 0, auditLog)
+	if lineage.CatalogPushEnabled() {
+		if sourceName, err := a.flowSourceName(ctx, config.FlowName); err != nil {
+			logger.Warn("failed to get source of mirror for catalog push", slog.Any("error", err))
+		} else {
+			a.pushCatalogTables(ctx, logger, lineage.CatalogMirrorCreated, config.FlowName, sourceName, config.PeerName,
+				config.TableMappings, nil, time.Time{})
+		}
+	}
 
 	return &protos.SetupNormalizedTableBatchOutput{
 		TableExistsMapping: tableExistsMapping,
@@ -240,6 +282,14 @@ func (a *FlowableActivity) MaintainPull(
 		select {
 		case <-ticker.C:
 			activity.RecordHeartbeat(ctx, "keep session alive")
+			// batches normalized since the last sync advance the slot while it waits for the next one
+			if config.FlushDurability == protos.FlushDurability_FLUSH_DURABILITY_NORMALIZE {
+				if flushedOffset, err := cdcsync.FlushedOffset(ctx, a.CatalogPool, config, 0); err != nil {
+					activity.GetLogger(ctx).Warn("failed to get offset flushed to source", slog.Any("error", err))
+				} else if flushedOffset > 0 {
+					srcConn.UpdateReplStateLastOffset(flushedOffset)
+				}
+			}
 			if err := srcConn.ReplPing(ctx); err != nil {
 				a.CdcCacheRw.Lock()
 				delete(a.CdcCache, sessionID)
@@ -303,6 +353,40 @@ func (a *FlowableActivity) SyncRecords(
 			return stream, nil
 		}
 	}
+	tableTransforms, err := a.tableColumnTransforms(ctx, options.TableMappings, config.Anonymization)
+	if err != nil {
+		a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+		return nil, err
+	}
+	if len(tableTransforms) > 0 {
+		// scripts see values before they're encrypted or anonymized
+		scriptStream := adaptStream
+		var onErr context.CancelCauseFunc
+		ctx, onErr = context.WithCancelCause(ctx)
+		adaptStream = func(stream *model.CDCStream[model.RecordItems]) (*model.CDCStream[model.RecordItems], error) {
+			if scriptStream != nil {
+				var err error
+				if stream, err = scriptStream(stream); err != nil {
+					return nil, err
+				}
+			}
+			return attachColumnTransforms(ctx, stream, tableTransforms, onErr), nil
+		}
+	}
+	if tableSecurity := tableRowSecurity(options.TableMappings); len(tableSecurity) > 0 {
+		untaggedStream := adaptStream
+		var onErr context.CancelCauseFunc
+		ctx, onErr = context.WithCancelCause(ctx)
+		adaptStream = func(stream *model.CDCStream[model.RecordItems]) (*model.CDCStream[model.RecordItems], error) {
+			if untaggedStream != nil {
+				var err error
+				if stream, err = untaggedStream(stream); err != nil {
+					return nil, err
+				}
+			}
+			return attachRowTags(ctx, stream, tableSecurity, onErr), nil
+		}
+	}
 	return syncCore(ctx, a, config, options, sessionID, adaptStream,
 		connectors.CDCPullConnector.PullRecords,
 		connectors.CDCSyncConnector.SyncRecords)
@@ -324,8 +408,9 @@ func (a *FlowableActivity) StartNormalize(
 	input *protos.StartNormalizeInput,
 ) (*model.NormalizeResponse, error) {
 	conn := input.FlowConnectionConfigs
-	ctx = context.WithValue(ctx, shared.FlowNameKey, conn.FlowJobName)
+	ctx = shared.WithMirrorLabels(context.WithValue(ctx, shared.FlowNameKey, conn.FlowJobName), conn.Labels)
 	logger := activity.GetLogger(ctx)
+	defer a.ResourceUsage.Track(conn.FlowJobName, conn.Labels)()
 
 	dstConn, err := connectors.GetByNameAs[connectors.CDCNormalizeConnector](
 		ctx,
@@ -346,8 +431,10 @@ func (a *FlowableActivity) StartNormalize(
 		return "normalizing records from batch for job"
 	})
 	defer shutdown()
+	defer a.normalizeWatchdog(ctx, conn, input.SyncBatchID)()
 
-	res, err := dstConn.NormalizeRecords(ctx, &model.NormalizeRecordsRequest{
+	auditCtx, auditLog := withDestinationAudit(ctx, logger, input.FlowConnectionConfigs.Env)
+	req := &model.NormalizeRecordsRequest{
 		FlowJobName:            input.FlowConnectionConfigs.FlowJobName,
 		Env:                    input.FlowConnectionConfigs.Env,
 		TableNameSchemaMapping: input.TableNameSchemaMapping,
@@ -355,14 +442,123 @@ func (a *FlowableActivity) StartNormalize(
 		SyncBatchID:            input.SyncBatchID,
 		SoftDeleteColName:      input.FlowConnectionConfigs.SoftDeleteColName,
 		SyncedAtColName:        input.FlowConnectionConfigs.SyncedAtColName,
-	})
+		TruncatedTables:        input.TruncatedTables,
+		TruncatePolicy:         input.FlowConnectionConfigs.TruncatePolicy,
+		ReplicationOrigin:      input.FlowConnectionConfigs.ReplicationOrigin,
+		ConflictPolicy:         input.FlowConnectionConfigs.ConflictPolicy,
+		ConflictColumn:         input.FlowConnectionConfigs.ConflictColumn,
+		ConflictCondition:      input.FlowConnectionConfigs.ConflictCondition,
+	}
+	quarantined, err := monitoring.GetQuarantinedTables(ctx, a.CatalogPool, conn.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range quarantined {
+		if _, ok := input.TableNameSchemaMapping[table.TableName]; !ok {
+			continue
+		}
+		if !table.Released {
+			if req.SkippedTables == nil {
+				req.SkippedTables = make(map[string]struct{}, len(quarantined))
+			}
+			req.SkippedTables[table.TableName] = struct{}{}
+		} else if table.Policy == protos.QuarantinePolicy_QUARANTINE_POLICY_BUFFER {
+			if req.ReplayTables == nil {
+				req.ReplayTables = make(map[string]int64, len(quarantined))
+			}
+			req.ReplayTables[table.TableName] = table.BatchId
+		}
+	}
+	skippedBatches, replayedBatches, err := monitoring.GetNormalizeBatchOperations(ctx, a.CatalogPool, conn.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	req.SkippedBatches = skippedBatches
+	// later batches are normalized again too, so rows they changed since end at their latest version
+	for _, batchID := range replayedBatches {
+		for table := range input.TableNameSchemaMapping {
+			if _, skipped := req.SkippedTables[table]; skipped {
+				continue
+			}
+			if replayBatchID, ok := req.ReplayTables[table]; !ok || batchID-1 < replayBatchID {
+				if req.ReplayTables == nil {
+					req.ReplayTables = make(map[string]int64, len(input.TableNameSchemaMapping))
+				}
+				req.ReplayTables[table] = batchID - 1
+			}
+		}
+	}
+	if len(req.SkippedBatches) > 0 || len(replayedBatches) > 0 {
+		logger.Info("normalizing around skipped and replayed batches",
+			slog.Any("skipped", req.SkippedBatches), slog.Any("replayed", replayedBatches))
+	}
+	if len(req.SkippedTables) > 0 || len(req.ReplayTables) > 0 {
+		logger.Info("normalizing around quarantined tables",
+			slog.Any("skipped", slices.Sorted(maps.Keys(req.SkippedTables))),
+			slog.Any("replayed", slices.Sorted(maps.Keys(req.ReplayTables))))
+	}
+
+	res, err := dstConn.NormalizeRecords(auditCtx, req)
 	if err != nil {
-		a.Alerter.LogFlowError(ctx, input.FlowConnectionConfigs.FlowJobName, err)
-		return nil, fmt.Errorf("failed to normalized records: %w", err)
+		// only look for tables dropped out of band once normalize fails, to not check every table on every batch
+		missingTables, missingErr := a.missingNormalizedTables(ctx, dstConn, input.TableNameSchemaMapping)
+		if missingErr != nil {
+			logger.Warn("failed to check for missing destination tables", slog.Any("error", missingErr))
+		}
+		if len(missingTables) == 0 {
+			a.Alerter.LogFlowError(ctx, input.FlowConnectionConfigs.FlowJobName, err)
+			return nil, fmt.Errorf("failed to normalized records: %w", err)
+		}
+
+		policy := input.FlowConnectionConfigs.MissingTablePolicy
+		a.Alerter.LogFlowError(ctx, input.FlowConnectionConfigs.FlowJobName,
+			fmt.Errorf("destination tables %v no longer exist, applying %s", missingTables, policy))
+		if policy == protos.MissingTablePolicy_MISSING_TABLE_POLICY_PAUSE {
+			return &model.NormalizeResponse{MissingTables: missingTables}, nil
+		}
+
+		if req.SkippedTables == nil {
+			req.SkippedTables = make(map[string]struct{}, len(missingTables))
+		}
+		for _, table := range missingTables {
+			req.SkippedTables[table] = struct{}{}
+			delete(req.ReplayTables, table)
+		}
+		res, err = faults.Call(ctx, conn.Env, faults.NormalizeRecords, func() (*model.NormalizeResponse, error) {
+			return dstConn.NormalizeRecords(auditCtx, req)
+		})
+		if err != nil {
+			a.Alerter.LogFlowError(ctx, input.FlowConnectionConfigs.FlowJobName, err)
+			return nil, fmt.Errorf("failed to normalized records: %w", err)
+		}
+		if policy == protos.MissingTablePolicy_MISSING_TABLE_POLICY_RESYNC {
+			res.MissingTables = missingTables
+		}
 	}
 
 	// normalize flow did not run due to no records, no need to update end time.
 	if res.Done {
+		if len(req.ReplayTables) > 0 {
+			if err := monitoring.FinishQuarantineReplay(ctx, a.CatalogPool, conn.FlowJobName,
+				slices.Sorted(maps.Keys(req.ReplayTables))); err != nil {
+				return nil, err
+			}
+		}
+		if len(req.SkippedBatches) > 0 || len(replayedBatches) > 0 {
+			if err := monitoring.FinishBatchOperations(ctx, a.CatalogPool, conn.FlowJobName,
+				res.EndBatchID, replayedBatches); err != nil {
+				return nil, err
+			}
+		}
+		a.saveDestinationAudit(ctx, logger, conn.FlowJobName, res.EndBatchID, auditLog)
+		// a missing manifest only widens the boundary consumers read at up to the next one
+		if err := monitoring.RecordBatchManifest(ctx, a.CatalogPool, conn.FlowJobName,
+			res.StartBatchID, res.EndBatchID); err != nil {
+			logger.Warn("failed to record batch manifest", slog.Any("error", err))
+		}
+		if conn.BatchControlTable {
+			a.recordBatchControl(ctx, conn.FlowJobName, dstConn, res.StartBatchID, res.EndBatchID)
+		}
 		err = monitoring.UpdateEndTimeForCDCBatch(
 			ctx,
 			a.CatalogPool,
@@ -372,6 +568,14 @@ func (a *FlowableActivity) StartNormalize(
 		if err != nil {
 			return nil, err
 		}
+		if err := monitoring.AppendMirrorEvent(ctx, a.CatalogPool, &protos.MirrorEvent{
+			FlowJobName: conn.FlowJobName,
+			EventType:   protos.MirrorEventType_MIRROR_EVENT_BATCH_NORMALIZED,
+			BatchId:     res.EndBatchID,
+			Message:     fmt.Sprintf("normalized batches %d to %d", res.StartBatchID, res.EndBatchID),
+		}); err != nil {
+			logger.Warn("failed to append batch normalized event", slog.Any("error", err))
+		}
 	}
 
 	// log the number of batches normalized
@@ -381,6 +585,19 @@ func (a *FlowableActivity) StartNormalize(
 	return res, nil
 }
 
+func (a *FlowableActivity) missingNormalizedTables(
+	ctx context.Context,
+	dstConn connectors.CDCNormalizeConnector,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) ([]string, error) {
+	existConn, ok := dstConn.(connectors.NormalizedTablesExistConnector)
+	if !ok {
+		return nil, nil
+	}
+	tables := slices.Sorted(maps.Keys(tableNameSchemaMapping))
+	return existConn.MissingNormalizedTables(ctx, tables)
+}
+
 // SetupQRepMetadataTables sets up the metadata tables for QReplication.
 func (a *FlowableActivity) SetupQRepMetadataTables(ctx context.Context, config *protos.QRepConfig) error {
 	conn, err := connectors.GetByNameAs[connectors.QRepSyncConnector](ctx, config.Env, a.CatalogPool, config.DestinationName)
@@ -409,7 +626,7 @@ func (a *FlowableActivity) GetQRepPartitions(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
-	srcConn, err := connectors.GetByNameAs[connectors.QRepPullConnector](ctx, config.Env, a.CatalogPool, config.SourceName)
+	srcConn, err := connectors.GetQRepSourceAs[connectors.QRepPullConnector](ctx, a.CatalogPool, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get qrep pull connector: %w", err)
 	}
@@ -424,6 +641,9 @@ func (a *FlowableActivity) GetQRepPartitions(ctx context.Context,
 		a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 		return nil, fmt.Errorf("failed to get partitions from source: %w", err)
 	}
+	if config.InitialCopyOnly && len(partitions) > 0 {
+		a.estimateQRepRows(ctx, srcConn, config, runUUID)
+	}
 	if len(partitions) > 0 {
 		err = monitoring.InitializeQRepRun(
 			ctx,
@@ -485,10 +705,27 @@ func (a *FlowableActivity) ReplicateQRepPartitions(ctx context.Context,
 					outstream = pua.AttachToStream(ls, fn, stream)
 				}
 			}
+			transforms, err := a.columnTransforms(ctx, config.ColumnEncryption,
+				config.AnonymizedColumns, config.AnonymizationSeed)
+			if err != nil {
+				a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+				return err
+			}
+			if len(transforms) > 0 {
+				outstream = attachQRepColumnTransforms(outstream, transforms)
+			}
+			if config.RowSecurity.GetTagColumn() != "" {
+				outstream = attachQRepRowTags(outstream, config.RowSecurity)
+			}
+			syncRecords, collector := a.collectSnapshotColumnStats(ctx, logger, config,
+				connectors.QRepSyncConnector.SyncQRepRecords)
 			err = replicateQRepPartition(ctx, a, config, p, runUUID, stream, outstream,
 				connectors.QRepPullConnector.PullQRepRecords,
-				connectors.QRepSyncConnector.SyncQRepRecords,
+				syncRecords,
 			)
+			if err == nil && collector != nil {
+				a.saveSnapshotColumnStats(ctx, logger, config, p, collector)
+			}
 		case protos.TypeSystem_PG:
 			read, write := connpostgres.NewPgCopyPipe()
 			err = replicateQRepPartition(ctx, a, config, p, runUUID, write, read,
@@ -567,7 +804,14 @@ func (a *FlowableActivity) DropFlowDestination(ctx context.Context, req *protos.
 	}
 	defer connectors.CloseConnector(ctx, dstConn)
 
-	return dstConn.SyncFlowCleanup(ctx, req.FlowJobName)
+	if err := dstConn.SyncFlowCleanup(ctx, req.FlowJobName); err != nil {
+		return err
+	}
+	// buffered changes of quarantined tables and skipped batches went with the raw table
+	if err := monitoring.DeleteBatchOperations(ctx, a.CatalogPool, req.FlowJobName); err != nil {
+		return err
+	}
+	return monitoring.DeleteQuarantinedTables(ctx, a.CatalogPool, req.FlowJobName)
 }
 
 func (a *FlowableActivity) SendWALHeartbeat(ctx context.Context) error {
@@ -663,6 +907,8 @@ func (a *FlowableActivity) RecordSlotSizes(ctx context.Context) error {
 			}
 			peerName := config.SourceName
 
+			// slot lag is recorded for the mirror as well as the peer
+			ctx := context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
 			activity.RecordHeartbeat(ctx, fmt.Sprintf("checking %s on %s", slotName, peerName))
 			if ctx.Err() != nil {
 				return
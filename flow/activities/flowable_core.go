@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log/slog"
 	"reflect"
-	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgerrcode"
@@ -18,10 +17,13 @@ import (
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/PeerDB-io/peer-flow/cdcsync"
 	"github.com/PeerDB-io/peer-flow/connectors"
 	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/faults"
 	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/lineage"
 	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/peerdbenv"
 	"github.com/PeerDB-io/peer-flow/shared"
@@ -87,18 +89,14 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 	sync func(TSync, context.Context, *model.SyncRecordsRequest[Items]) (*model.SyncResponse, error),
 ) (*model.SyncCompositeResponse, error) {
 	flowName := config.FlowJobName
-	ctx = context.WithValue(ctx, shared.FlowNameKey, flowName)
+	ctx = shared.WithMirrorLabels(context.WithValue(ctx, shared.FlowNameKey, flowName), config.Labels)
 	logger := activity.GetLogger(ctx)
+	defer a.ResourceUsage.Track(flowName, config.Labels)()
 	shutdown := heartbeatRoutine(ctx, func() string {
 		return "transferring records for job"
 	})
 	defer shutdown()
 
-	tblNameMapping := make(map[string]model.NameAndExclude, len(options.TableMappings))
-	for _, v := range options.TableMappings {
-		tblNameMapping[v.SourceTableIdentifier] = model.NewNameAndExclude(v.DestinationTableIdentifier, v.Exclude)
-	}
-
 	srcConn, err := waitForCdcCache[TPull](ctx, a, sessionID)
 	if err != nil {
 		return nil, err
@@ -107,207 +105,105 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 		return nil, temporal.NewNonRetryableApplicationError("connection to source down", "disconnect", nil)
 	}
 
-	batchSize := options.BatchSize
-	if batchSize == 0 {
-		batchSize = 1_000_000
-	}
-
-	lastOffset, err := func() (int64, error) {
-		dstConn, err := connectors.GetByNameAs[TSync](ctx, config.Env, a.CatalogPool, config.DestinationName)
-		if err != nil {
-			return 0, fmt.Errorf("failed to get destination connector: %w", err)
-		}
-		defer connectors.CloseConnector(ctx, dstConn)
-
-		return dstConn.GetLastOffset(ctx, config.FlowJobName)
-	}()
+	batch, err := cdcsync.SyncBatch(ctx, logger, a.CatalogPool, config, options, srcConn, pull, sync,
+		cdcsync.Hooks[TSync, Items]{
+			CheckLastOffset: func(ctx context.Context, dstConn TSync, lastOffset int64) error {
+				return a.checkBatchContinuity(ctx, logger, config, dstConn, lastOffset)
+			},
+			AdaptStream: func(stream *model.CDCStream[Items]) (*model.CDCStream[Items], error) {
+				if adaptStream != nil {
+					var err error
+					if stream, err = adaptStream(stream); err != nil {
+						return nil, err
+					}
+				}
+				return attachRecordSampler(ctx, a, logger, flowName, stream), nil
+			},
+			Audit: func(ctx context.Context) (context.Context, func(int64)) {
+				auditCtx, auditLog := withDestinationAudit(ctx, logger, config.Env)
+				if auditLog == nil {
+					return auditCtx, nil
+				}
+				return auditCtx, func(batchID int64) {
+					a.saveDestinationAudit(ctx, logger, flowName, batchID, auditLog)
+				}
+			},
+			SyncError: func(ctx context.Context, dstConn TSync, err error) error {
+				a.Alerter.LogFlowError(ctx, flowName, err)
+				if a.isDestinationPressure(ctx, config.Env, dstConn, err) {
+					return temporal.NewNonRetryableApplicationError("destination pushed back on records", shared.DestinationPressureError, err)
+				}
+				return fmt.Errorf("failed to push records: %w", err)
+			},
+			PullError: func(ctx context.Context, err error) error {
+				// don't log flow error for "replState changed" and "slot is already active"
+				if !(temporal.IsApplicationError(err) ||
+					shared.IsSQLStateError(err, pgerrcode.ObjectInUse)) {
+					a.Alerter.LogFlowError(ctx, flowName, err)
+				}
+				if temporal.IsApplicationError(err) {
+					return err
+				}
+				return fmt.Errorf("failed to pull records: %w", err)
+			},
+			CatalogError: func(ctx context.Context, err error) {
+				a.Alerter.LogFlowError(ctx, flowName, err)
+			},
+		})
 	if err != nil {
 		return nil, err
 	}
+	res := batch.Response
 
-	logger.Info("pulling records...", slog.Int64("LastOffset", lastOffset))
-	consumedOffset := atomic.Int64{}
-	consumedOffset.Store(lastOffset)
-
-	channelBufferSize, err := peerdbenv.PeerDBCDCChannelBufferSize(ctx, config.Env)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get CDC channel buffer size: %w", err)
-	}
-	recordBatchPull := model.NewCDCStream[Items](int(channelBufferSize))
-	recordBatchSync := recordBatchPull
-	if adaptStream != nil {
-		var err error
-		if recordBatchSync, err = adaptStream(recordBatchPull); err != nil {
-			return nil, err
+	if res.CurrentSyncBatchID == -1 {
+		if len(res.TableSchemaDeltas) > 0 {
+			a.pushSchemaChanges(ctx, logger, config, options.TableMappings, res.TableSchemaDeltas, time.Time{})
+			a.alertRenamedTables(ctx, config, res.TableSchemaDeltas)
 		}
-	}
-	startTime := time.Now()
-
-	errGroup, errCtx := errgroup.WithContext(ctx)
-	errGroup.Go(func() error {
-		return pull(srcConn, errCtx, a.CatalogPool, &model.PullRecordsRequest[Items]{
-			FlowJobName:           flowName,
-			SrcTableIDNameMapping: options.SrcTableIdNameMapping,
-			TableNameMapping:      tblNameMapping,
-			LastOffset:            lastOffset,
-			ConsumedOffset:        &consumedOffset,
-			MaxBatchSize:          batchSize,
-			IdleTimeout: peerdbenv.PeerDBCDCIdleTimeoutSeconds(
-				int(options.IdleTimeoutSeconds),
-			),
-			TableNameSchemaMapping:      options.TableNameSchemaMapping,
-			OverridePublicationName:     config.PublicationName,
-			OverrideReplicationSlotName: config.ReplicationSlotName,
-			RecordStream:                recordBatchPull,
-			Env:                         config.Env,
-		})
-	})
-
-	hasRecords := !recordBatchSync.WaitAndCheckEmpty()
-	logger.Info("current sync flow has records?", slog.Bool("hasRecords", hasRecords))
-
-	if !hasRecords {
-		// wait for the pull goroutine to finish
-		if err := errGroup.Wait(); err != nil {
-			// don't log flow error for "replState changed" and "slot is already active"
-			if !(temporal.IsApplicationError(err) ||
-				shared.IsSQLStateError(err, pgerrcode.ObjectInUse)) {
-				a.Alerter.LogFlowError(ctx, flowName, err)
-			}
-			if temporal.IsApplicationError(err) {
-				return nil, err
-			} else {
-				return nil, fmt.Errorf("failed in pull records when: %w", err)
-			}
-		}
-		logger.Info("no records to push")
-
-		dstConn, err := connectors.GetByNameAs[TSync](ctx, config.Env, a.CatalogPool, config.DestinationName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to recreate destination connector: %w", err)
-		}
-		defer connectors.CloseConnector(ctx, dstConn)
-
-		if err := dstConn.ReplayTableSchemaDeltas(ctx, flowName, recordBatchSync.SchemaDeltas); err != nil {
-			return nil, fmt.Errorf("failed to sync schema: %w", err)
-		}
-
 		return &model.SyncCompositeResponse{
-			SyncResponse: &model.SyncResponse{
-				CurrentSyncBatchID: -1,
-				TableSchemaDeltas:  recordBatchSync.SchemaDeltas,
-			},
+			SyncResponse:   res,
 			NeedsNormalize: false,
 		}, nil
 	}
 
-	var syncStartTime time.Time
-	var res *model.SyncResponse
-	errGroup.Go(func() error {
-		dstConn, err := connectors.GetByNameAs[TSync](ctx, config.Env, a.CatalogPool, config.DestinationName)
-		if err != nil {
-			return fmt.Errorf("failed to recreate destination connector: %w", err)
-		}
-		defer connectors.CloseConnector(ctx, dstConn)
-
-		syncBatchID, err := dstConn.GetLastSyncBatchID(errCtx, flowName)
-		if err != nil {
-			return err
-		}
-		syncBatchID += 1
-
-		err = monitoring.AddCDCBatchForFlow(errCtx, a.CatalogPool, flowName,
-			monitoring.CDCBatchInfo{
-				BatchID:     syncBatchID,
-				RowsInBatch: 0,
-				BatchEndlSN: 0,
-				StartTime:   startTime,
-			})
-		if err != nil {
-			a.Alerter.LogFlowError(ctx, flowName, err)
-			return err
-		}
-
-		syncStartTime = time.Now()
-		res, err = sync(dstConn, errCtx, &model.SyncRecordsRequest[Items]{
-			SyncBatchID:            syncBatchID,
-			Records:                recordBatchSync,
-			ConsumedOffset:         &consumedOffset,
-			FlowJobName:            flowName,
-			TableMappings:          options.TableMappings,
-			StagingPath:            config.CdcStagingPath,
-			Script:                 config.Script,
-			TableNameSchemaMapping: options.TableNameSchemaMapping,
-		})
-		if err != nil {
-			a.Alerter.LogFlowError(ctx, flowName, err)
-			return fmt.Errorf("failed to push records: %w", err)
-		}
-
-		return nil
-	})
-
-	if err := errGroup.Wait(); err != nil {
-		// don't log flow error for "replState changed" and "slot is already active"
-		if !(temporal.IsApplicationError(err) ||
-			shared.IsSQLStateError(err, pgerrcode.ObjectInUse)) {
-			a.Alerter.LogFlowError(ctx, flowName, err)
-		}
-		if temporal.IsApplicationError(err) {
-			return nil, err
-		} else {
-			return nil, fmt.Errorf("failed to pull records: %w", err)
-		}
-	}
-
-	numRecords := res.NumRecordsSynced
-	syncDuration := time.Since(syncStartTime)
-
-	logger.Info(fmt.Sprintf("pushed %d records in %d seconds", numRecords, int(syncDuration.Seconds())))
-
-	lastCheckpoint := recordBatchSync.GetLastCheckpoint()
-	srcConn.UpdateReplStateLastOffset(lastCheckpoint)
-
-	err = monitoring.UpdateNumRowsAndEndLSNForCDCBatch(
-		ctx,
-		a.CatalogPool,
-		flowName,
-		res.CurrentSyncBatchID,
-		uint32(numRecords),
-		lastCheckpoint,
-	)
-	if err != nil {
-		a.Alerter.LogFlowError(ctx, flowName, err)
-		return nil, err
-	}
-
-	err = monitoring.UpdateLatestLSNAtTargetForCDCFlow(ctx, a.CatalogPool, flowName, lastCheckpoint)
-	if err != nil {
-		a.Alerter.LogFlowError(ctx, flowName, err)
-		return nil, err
-	}
-	if res.TableNameRowsMapping != nil {
-		err = monitoring.AddCDCBatchTablesForFlow(ctx, a.CatalogPool, flowName,
-			res.CurrentSyncBatchID, res.TableNameRowsMapping)
-		if err != nil {
-			return nil, err
-		}
-	}
-	if err != nil {
-		a.Alerter.LogFlowError(ctx, flowName, err)
-		return nil, err
+	if len(res.TableSchemaDeltas) > 0 {
+		a.pushSchemaChanges(ctx, logger, config, options.TableMappings, res.TableSchemaDeltas, time.Now())
+		a.alertRenamedTables(ctx, config, res.TableSchemaDeltas)
 	}
+	a.emitCDCLineage(ctx, logger, config, options.TableMappings, lineage.CDCBatch{
+		BatchID:    res.CurrentSyncBatchID,
+		NumRecords: res.NumRecordsSynced,
+		StartLSN:   batch.StartOffset,
+		EndLSN:     batch.EndOffset,
+	})
 
-	pushedRecordsWithCount := fmt.Sprintf("pushed %d records", numRecords)
+	pushedRecordsWithCount := fmt.Sprintf("pushed %d records", res.NumRecordsSynced)
 	activity.RecordHeartbeat(ctx, pushedRecordsWithCount)
 	a.Alerter.LogFlowInfo(ctx, flowName, pushedRecordsWithCount)
 
 	return &model.SyncCompositeResponse{
 		SyncResponse:   res,
-		NeedsNormalize: recordBatchSync.NeedsNormalize(),
+		NeedsNormalize: batch.NeedsNormalize,
 	}, nil
 }
 
+// isDestinationPressure tells whether err pushing records comes from the destination not keeping up,
+// only with batch tuning enabled so other mirrors keep retrying syncs as they are
+func (a *FlowableActivity) isDestinationPressure(
+	ctx context.Context, env map[string]string, dstConn connectors.Connector, err error,
+) bool {
+	pressureConn, ok := dstConn.(connectors.DestinationPressureConnector)
+	if !ok || !pressureConn.IsDestinationPressure(err) {
+		return false
+	}
+	targetLatency, err := peerdbenv.PeerDBBatchTuningTargetLatency(ctx, env)
+	if err != nil {
+		activity.GetLogger(ctx).Warn("failed to get batch tuning target latency", slog.Any("error", err))
+		return false
+	}
+	return targetLatency > 0
+}
+
 func (a *FlowableActivity) getPostgresPeerConfigs(ctx context.Context) ([]*protos.Peer, error) {
 	optionRows, err := a.CatalogPool.Query(ctx, `
 		SELECT p.name, p.options, p.enc_key_id
@@ -360,8 +256,9 @@ func replicateQRepPartition[TRead any, TWrite any, TSync connectors.QRepSyncConn
 	) (int, error),
 	syncRecords func(TSync, context.Context, *protos.QRepConfig, *protos.QRepPartition, TRead) (int, error),
 ) error {
-	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
+	ctx = shared.WithMirrorLabels(context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName), config.Labels)
 	logger := log.With(activity.GetLogger(ctx), slog.String(string(shared.FlowNameKey), config.FlowJobName))
+	defer a.ResourceUsage.Track(config.FlowJobName, config.Labels)()
 
 	dstConn, err := connectors.GetByNameAs[TSync](ctx, config.Env, a.CatalogPool, config.DestinationName)
 	if err != nil {
@@ -395,14 +292,16 @@ func replicateQRepPartition[TRead any, TWrite any, TSync connectors.QRepSyncConn
 	var rowsSynced int
 	errGroup, errCtx := errgroup.WithContext(ctx)
 	errGroup.Go(func() error {
-		srcConn, err := connectors.GetByNameAs[TPull](ctx, config.Env, a.CatalogPool, config.SourceName)
+		srcConn, err := connectors.GetQRepSourceAs[TPull](ctx, a.CatalogPool, config)
 		if err != nil {
 			a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 			return fmt.Errorf("failed to get qrep source connector: %w", err)
 		}
 		defer connectors.CloseConnector(ctx, srcConn)
 
-		tmp, err := pullRecords(srcConn, errCtx, config, partition, stream)
+		tmp, err := faults.Call(errCtx, config.Env, faults.QRepPull, func() (int, error) {
+			return pullRecords(srcConn, errCtx, config, partition, stream)
+		})
 		if err != nil {
 			a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 			return fmt.Errorf("failed to pull records: %w", err)
@@ -417,7 +316,9 @@ func replicateQRepPartition[TRead any, TWrite any, TSync connectors.QRepSyncConn
 	})
 
 	errGroup.Go(func() error {
-		rowsSynced, err = syncRecords(dstConn, errCtx, config, partition, outstream)
+		rowsSynced, err = faults.Call(errCtx, config.Env, faults.QRepSync, func() (int, error) {
+			return syncRecords(dstConn, errCtx, config, partition, outstream)
+		})
 		if err != nil {
 			a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 			return fmt.Errorf("failed to sync records: %w", err)
@@ -436,6 +337,7 @@ func replicateQRepPartition[TRead any, TWrite any, TSync connectors.QRepSyncConn
 		if err != nil {
 			return err
 		}
+		a.emitQRepLineage(ctx, logger, config, partition.PartitionId, int64(rowsSynced))
 	}
 
 	return monitoring.UpdateEndTimeForPartition(ctx, a.CatalogPool, runUUID, partition)
@@ -558,3 +460,17 @@ func replicateXminPartition[TRead any, TWrite any, TSync connectors.QRepSyncConn
 
 	return currentSnapshotXmin, nil
 }
+
+// alertRenamedTables alerts on source tables deltas show renamed, CDCFlowWorkflow applies the rename policy
+// once the sync flow stops
+func (a *FlowableActivity) alertRenamedTables(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	deltas []*protos.TableSchemaDelta,
+) {
+	for _, delta := range deltas {
+		if delta.RenamedTo != "" {
+			a.Alerter.AlertTableRenamed(ctx, config.FlowJobName, delta.SrcTableName, delta.RenamedTo, config.TableRenamePolicy)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/activity"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// EvaluateFreshnessSLAs measures staleness of running mirrors with a freshness SLA,
+// alerting when a mirror breaches its SLA and again once it recovers
+func (a *FlowableActivity) EvaluateFreshnessSLAs(ctx context.Context) error {
+	rows, err := a.CatalogPool.Query(ctx,
+		"SELECT DISTINCT ON (name) workflow_id, config_proto FROM flows WHERE query_string IS NULL")
+	if err != nil {
+		return err
+	}
+	mirrors, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (reconcileMirror, error) {
+		var mirror reconcileMirror
+		var configProto []byte
+		if err := row.Scan(&mirror.workflowID, &configProto); err != nil {
+			return mirror, err
+		}
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configProto, &config); err != nil {
+			return mirror, err
+		}
+		mirror.config = &config
+		return mirror, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger := activity.GetLogger(ctx)
+	for _, mirror := range mirrors {
+		if mirror.config.FreshnessSlaSeconds == 0 {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		flowName := mirror.config.FlowJobName
+		// paused mirrors are behind by choice and snapshots aren't measured by batches
+		status, err := a.getFlowStatus(ctx, mirror.workflowID)
+		if err != nil {
+			logger.Warn("failed to get status of mirror", slog.String("flowName", flowName), slog.Any("error", err))
+			continue
+		}
+		if status != protos.FlowStatus_STATUS_RUNNING {
+			continue
+		}
+
+		staleness, err := monitoring.GetFreshnessStaleness(ctx, a.CatalogPool, flowName, time.Now())
+		if err != nil {
+			return err
+		}
+		eventType, err := monitoring.RecordFreshnessSLAEvaluation(ctx, a.CatalogPool, flowName,
+			mirror.config.FreshnessSlaSeconds, staleness)
+		if err != nil {
+			return err
+		}
+		if eventType == "" {
+			continue
+		}
+		sla := time.Duration(mirror.config.FreshnessSlaSeconds) * time.Second
+		staleness = staleness.Round(time.Second)
+		recovered := eventType == monitoring.FreshnessSLARecovery
+		a.Alerter.AlertFreshnessSLA(ctx, flowName, recovered, staleness, sla)
+		if recovered {
+			a.Alerter.LogFlowInfo(ctx, flowName, fmt.Sprintf("within freshness SLA of %s again, %s behind the source",
+				sla, staleness))
+		} else {
+			a.Alerter.LogFlowInfo(ctx, flowName, fmt.Sprintf("breached freshness SLA of %s, %s behind the source",
+				sla, staleness))
+		}
+	}
+	return nil
+}
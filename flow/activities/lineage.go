@@ -0,0 +1,152 @@
+package activities
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/lineage"
+)
+
+// emitCDCLineage sends an OpenLineage event for a synced batch when an endpoint is configured.
+// The batch is already written to the destination, errors are logged as a retry would sync it again
+// only to re-send the event.
+func (a *FlowableActivity) emitCDCLineage(
+	ctx context.Context,
+	logger log.Logger,
+	config *protos.FlowConnectionConfigs,
+	tableMappings []*protos.TableMapping,
+	batch lineage.CDCBatch,
+) {
+	if !lineage.Enabled() {
+		return
+	}
+	source, destination, err := a.loadLineagePeers(ctx, config.SourceName, config.DestinationName)
+	if err != nil {
+		logger.Warn("failed to load peers for lineage", slog.Any("error", err))
+		return
+	}
+	schemas, err := monitoring.GetLatestSourceSchemas(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		logger.Warn("failed to get source schemas for lineage", slog.Any("error", err))
+		return
+	}
+	if err := lineage.Emit(ctx, lineage.CDCBatchEvent(config, tableMappings, schemas, source, destination, batch)); err != nil {
+		logger.Warn("failed to emit lineage of batch", slog.Int64("batchID", batch.BatchID), slog.Any("error", err))
+	}
+}
+
+// emitQRepLineage sends an OpenLineage event for a replicated partition when an endpoint is configured
+func (a *FlowableActivity) emitQRepLineage(
+	ctx context.Context,
+	logger log.Logger,
+	config *protos.QRepConfig,
+	partitionID string,
+	numRecords int64,
+) {
+	if !lineage.Enabled() {
+		return
+	}
+	source, destination, err := a.loadLineagePeers(ctx, config.SourceName, config.DestinationName)
+	if err != nil {
+		logger.Warn("failed to load peers for lineage", slog.Any("error", err))
+		return
+	}
+	if err := lineage.Emit(ctx, lineage.QRepPartitionEvent(config, source, destination, partitionID, numRecords)); err != nil {
+		logger.Warn("failed to emit lineage of partition", slog.String("partitionID", partitionID), slog.Any("error", err))
+	}
+}
+
+func (a *FlowableActivity) loadLineagePeers(
+	ctx context.Context,
+	sourceName string,
+	destinationName string,
+) (*protos.Peer, *protos.Peer, error) {
+	source, err := connectors.LoadPeer(ctx, a.CatalogPool, sourceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	destination, err := connectors.LoadPeer(ctx, a.CatalogPool, destinationName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return source, destination, nil
+}
+
+// pushCatalogTables registers destination tables of tableMappings with the configured data catalog,
+// schema changes are applied over the latest recorded source schemas as those are recorded on setup.
+// Errors are only logged, a data catalog being unreachable shouldn't hold back setup or syncs of the mirror,
+// and the next push after a schema change registers the tables again.
+func (a *FlowableActivity) pushCatalogTables(
+	ctx context.Context,
+	logger log.Logger,
+	event string,
+	flowName string,
+	sourceName string,
+	destinationName string,
+	tableMappings []*protos.TableMapping,
+	deltas []*protos.TableSchemaDelta,
+	lastSyncedAt time.Time,
+) {
+	if !lineage.CatalogPushEnabled() {
+		return
+	}
+	source, destination, err := a.loadLineagePeers(ctx, sourceName, destinationName)
+	if err != nil {
+		logger.Warn("failed to load peers for catalog push", slog.Any("error", err))
+		return
+	}
+	schemas, err := monitoring.GetLatestSourceSchemas(ctx, a.CatalogPool, flowName)
+	if err != nil {
+		logger.Warn("failed to get source schemas for catalog push", slog.Any("error", err))
+		return
+	}
+	for _, delta := range deltas {
+		if tableSchema, ok := schemas[delta.SrcTableName]; ok {
+			tableSchema = proto.Clone(tableSchema).(*protos.TableSchema)
+			tableSchema.Columns = append(tableSchema.Columns, delta.AddedColumns...)
+			schemas[delta.SrcTableName] = tableSchema
+		}
+	}
+	tables := lineage.MirrorTables(flowName, tableMappings, schemas, source, destination, lastSyncedAt)
+	if err := lineage.PushTables(ctx, event, tables); err != nil {
+		logger.Warn("failed to push tables to catalog", slog.String("event", event), slog.Any("error", err))
+	}
+}
+
+func (a *FlowableActivity) flowSourceName(ctx context.Context, flowName string) (string, error) {
+	var sourceName string
+	err := a.CatalogPool.QueryRow(ctx,
+		"SELECT sp.name FROM flows f JOIN peers sp ON sp.id = f.source_peer WHERE f.name = $1", flowName,
+	).Scan(&sourceName)
+	return sourceName, err
+}
+
+// pushSchemaChanges registers destination tables whose schema changed in a sync
+func (a *FlowableActivity) pushSchemaChanges(
+	ctx context.Context,
+	logger log.Logger,
+	config *protos.FlowConnectionConfigs,
+	tableMappings []*protos.TableMapping,
+	deltas []*protos.TableSchemaDelta,
+	lastSyncedAt time.Time,
+) {
+	changed := make([]*protos.TableMapping, 0, len(deltas))
+	for _, tableMapping := range tableMappings {
+		for _, delta := range deltas {
+			if delta.DstTableName == tableMapping.DestinationTableIdentifier {
+				changed = append(changed, tableMapping)
+				break
+			}
+		}
+	}
+	a.pushCatalogTables(ctx, logger, lineage.CatalogSchemaChanged, config.FlowJobName, config.SourceName,
+		config.DestinationName, changed, deltas, lastSyncedAt)
+}
@@ -0,0 +1,195 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/activity"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// inMaintenanceWindow reports whether hour falls in [start, end), wrapping past midnight, start equal to end is always open
+func inMaintenanceWindow(config *protos.MaintenanceConfig, hour uint32) bool {
+	start, end := config.WindowStartHour%24, config.WindowEndHour%24
+	switch {
+	case start == end:
+		return true
+	case start < end:
+		return hour >= start && hour < end
+	default:
+		return hour >= start || hour < end
+	}
+}
+
+// MaintainDestinations runs maintenance on destination tables of mirrors opting into it,
+// for mirrors inside their window whose tables were not maintained within their interval
+func (a *FlowableActivity) MaintainDestinations(ctx context.Context) error {
+	rows, err := a.CatalogPool.Query(ctx,
+		"SELECT DISTINCT ON (name) config_proto FROM flows WHERE query_string IS NULL")
+	if err != nil {
+		return err
+	}
+	configs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.FlowConnectionConfigs, error) {
+		var configProto []byte
+		if err := row.Scan(&configProto); err != nil {
+			return nil, err
+		}
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configProto, &config); err != nil {
+			return nil, err
+		}
+		return &config, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	maxMirrors, err := peerdbenv.PeerDBMaintenanceMaxConcurrentMirrors(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var maintained atomic.Int64
+	shutdown := heartbeatRoutine(ctx, func() string {
+		return fmt.Sprintf("maintained %d tables", maintained.Load())
+	})
+	defer shutdown()
+
+	hour := uint32(time.Now().UTC().Hour())
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(int(max(maxMirrors, 1)))
+	for _, config := range configs {
+		if config.Maintenance == nil || !inMaintenanceWindow(config.Maintenance, hour) {
+			continue
+		}
+		group.Go(func() error {
+			if err := a.maintainDestination(groupCtx, config, &maintained); err != nil {
+				if groupCtx.Err() != nil {
+					return groupCtx.Err()
+				}
+				activity.GetLogger(ctx).Warn("failed to maintain destination",
+					slog.String("flowName", config.FlowJobName), slog.Any("error", err))
+				a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("destination maintenance failed: %w", err))
+			}
+			return nil
+		})
+	}
+	return group.Wait()
+}
+
+func (a *FlowableActivity) maintainDestination(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	maintained *atomic.Int64,
+) error {
+	interval := time.Duration(config.Maintenance.IntervalHours) * time.Hour
+	if interval == 0 {
+		interval = 24 * time.Hour
+	}
+	lastMaintained, err := monitoring.GetLastDestinationMaintenance(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		return err
+	}
+	var due []*protos.TableMapping
+	for _, tableMapping := range config.TableMappings {
+		if last, ok := lastMaintained[tableMapping.DestinationTableIdentifier]; !ok || time.Since(last) >= interval {
+			due = append(due, tableMapping)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	dstConn, err := connectors.GetByNameAs[connectors.MaintenanceConnector](ctx, config.Env, a.CatalogPool, config.DestinationName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return fmt.Errorf("failed to connect to destination %s: %w", config.DestinationName, err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	schemas, err := monitoring.GetLatestSourceSchemas(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		return err
+	}
+
+	var srcConn connectors.RangePartitionsConnector
+	dropConn, canDrop := dstConn.(connectors.DropPartitionsConnector)
+	if config.Maintenance.DropDetachedPartitions && canDrop {
+		srcConn, err = connectors.GetByNameAs[connectors.RangePartitionsConnector](ctx, config.Env, a.CatalogPool, config.SourceName)
+		if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+			return fmt.Errorf("failed to connect to source %s: %w", config.SourceName, err)
+		}
+		if srcConn != nil {
+			defer connectors.CloseConnector(ctx, srcConn)
+		}
+	}
+
+	logger := activity.GetLogger(ctx)
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(int(max(config.Maintenance.MaxConcurrentTables, 1)))
+	results := make([]error, len(due))
+	for i, tableMapping := range due {
+		group.Go(func() error {
+			tableName := tableMapping.DestinationTableIdentifier
+			id, err := monitoring.StartDestinationMaintenance(groupCtx, a.CatalogPool, config.FlowJobName, tableName)
+			if err != nil {
+				return err
+			}
+			logger.Info("maintaining destination table",
+				slog.String("flowName", config.FlowJobName), slog.String("table", tableName))
+			schema := schemas[tableMapping.SourceTableIdentifier]
+			maintenanceErr := dstConn.MaintainTable(groupCtx, tableName, schema)
+			if maintenanceErr == nil && srcConn != nil && schema != nil && schema.RangePartitionColumn != "" {
+				maintenanceErr = dropDetachedPartitions(groupCtx, srcConn, dropConn, tableMapping)
+			}
+			if groupCtx.Err() != nil {
+				return groupCtx.Err()
+			}
+			if maintenanceErr != nil {
+				results[i] = fmt.Errorf("failed to maintain %s: %w", tableName, maintenanceErr)
+			}
+			maintained.Add(1)
+			return monitoring.FinishDestinationMaintenance(groupCtx, a.CatalogPool, id, maintenanceErr)
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return err
+	}
+	return errors.Join(results...)
+}
+
+// dropDetachedPartitions drops partitions of the destination table no longer attached to its range partitioned source
+func dropDetachedPartitions(
+	ctx context.Context,
+	srcConn connectors.RangePartitionsConnector,
+	dstConn connectors.DropPartitionsConnector,
+	tableMapping *protos.TableMapping,
+) error {
+	ranges, complete, err := srcConn.RangePartitions(ctx, tableMapping.SourceTableIdentifier)
+	if err != nil {
+		return err
+	}
+	// without every source row in a known range, or without partitions to compare against, nothing is provably detached
+	if !complete || len(ranges) == 0 {
+		return nil
+	}
+	dropped, err := dstConn.DropDetachedPartitions(ctx, tableMapping.DestinationTableIdentifier, ranges)
+	if len(dropped) > 0 {
+		activity.GetLogger(ctx).Info("dropped partitions detached on source",
+			slog.String("table", tableMapping.DestinationTableIdentifier), slog.Any("partitions", dropped))
+	}
+	return err
+}
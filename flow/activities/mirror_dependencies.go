@@ -0,0 +1,84 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/activity"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const mirrorDependencyPollInterval = 30 * time.Second
+
+// WaitForMirrorDependencies blocks until every mirror of dependsOn finished its initial snapshot,
+// mirrors that were dropped since don't hold it back
+func (a *FlowableActivity) WaitForMirrorDependencies(ctx context.Context, flowName string, dependsOn []string) error {
+	logger := activity.GetLogger(ctx)
+	var mu sync.Mutex
+	pending := dependsOn
+	shutdown := heartbeatRoutine(ctx, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return "waiting for snapshot of mirrors " + strings.Join(pending, ", ")
+	})
+	defer shutdown()
+
+	for {
+		stillPending := make([]string, 0, len(dependsOn))
+		for _, dependency := range dependsOn {
+			done, err := a.mirrorSnapshotDone(ctx, dependency)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				logger.Warn("failed to get status of mirror dependency", slog.String("dependency", dependency),
+					slog.Any("error", err))
+			}
+			if !done {
+				stillPending = append(stillPending, dependency)
+			}
+		}
+		if len(stillPending) == 0 {
+			logger.Info("mirror dependencies finished their snapshot", slog.Any("dependsOn", dependsOn))
+			return nil
+		}
+		mu.Lock()
+		pending = stillPending
+		mu.Unlock()
+		logger.Info("waiting for mirror dependencies to finish their snapshot",
+			slog.String("flowName", flowName), slog.Any("pending", stillPending))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(mirrorDependencyPollInterval):
+		}
+	}
+}
+
+func (a *FlowableActivity) mirrorSnapshotDone(ctx context.Context, flowName string) (bool, error) {
+	var workflowID string
+	if err := a.CatalogPool.QueryRow(ctx, "SELECT workflow_id FROM flows WHERE name = $1", flowName).Scan(&workflowID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to get workflow of mirror %s: %w", flowName, err)
+	}
+	status, err := a.getFlowStatus(ctx, workflowID)
+	if err != nil {
+		return false, err
+	}
+	switch status {
+	case protos.FlowStatus_STATUS_UNKNOWN, protos.FlowStatus_STATUS_SETUP, protos.FlowStatus_STATUS_SNAPSHOT:
+		return false, nil
+	default:
+		return true, nil
+	}
+}
@@ -0,0 +1,93 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// normalizeWatchdog captures diagnostics of the destination into mirror logs each time normalize runs
+// another PEERDB_NORMALIZE_WATCHDOG_SECONDS, so a stuck normalize leaves evidence of what it waits on.
+// The returned function stops it once normalize is done
+func (a *FlowableActivity) normalizeWatchdog(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	syncBatchID int64,
+) func() {
+	seconds, err := peerdbenv.PeerDBNormalizeWatchdogSeconds(ctx, config.Env)
+	if err != nil {
+		activity.GetLogger(ctx).Warn("failed to get normalize watchdog interval", slog.Any("error", err))
+		return func() {}
+	}
+	if seconds <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		start := time.Now()
+		ticker := time.NewTicker(time.Duration(seconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.captureNormalizeDiagnostics(ctx, config, syncBatchID, time.Since(start))
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// captureNormalizeDiagnostics queries the destination on a connection of its own,
+// the connection of normalize is busy with whatever it is stuck on
+func (a *FlowableActivity) captureNormalizeDiagnostics(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	syncBatchID int64,
+	elapsed time.Duration,
+) {
+	var message strings.Builder
+	fmt.Fprintf(&message, "normalize of batches up to %d running for %s", syncBatchID, elapsed.Round(time.Second))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	dstConn, err := connectors.GetByNameAs[connectors.NormalizeDiagnosticsConnector](
+		ctx, config.Env, a.CatalogPool, config.DestinationName)
+	if errors.Is(err, errors.ErrUnsupported) {
+		a.Alerter.LogFlowInfo(ctx, config.FlowJobName, message.String())
+		return
+	} else if err != nil {
+		activity.GetLogger(ctx).Warn("failed to connect to destination for normalize diagnostics", slog.Any("error", err))
+		a.Alerter.LogFlowInfo(ctx, config.FlowJobName, message.String())
+		return
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	lines, err := dstConn.NormalizeDiagnostics(ctx, config.FlowJobName)
+	if err != nil {
+		activity.GetLogger(ctx).Warn("failed to capture normalize diagnostics", slog.Any("error", err))
+	}
+	if len(lines) == 0 && err == nil {
+		message.WriteString(", nothing in progress on the destination")
+	} else if len(lines) > 0 {
+		message.WriteString(", in progress on the destination:")
+		for _, line := range lines {
+			message.WriteString("\n")
+			message.WriteString(line)
+		}
+	}
+	a.Alerter.LogFlowInfo(ctx, config.FlowJobName, message.String())
+}
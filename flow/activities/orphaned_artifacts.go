@@ -0,0 +1,88 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/activity"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// CleanupOrphanedArtifacts drops raw tables, stages and staging tables of mirrors no longer in the catalog,
+// in dry run mode they are only logged
+func (a *FlowableActivity) CleanupOrphanedArtifacts(ctx context.Context) error {
+	dryRun, err := peerdbenv.PeerDBOrphanedArtifactsDryRun(ctx, nil)
+	if err != nil {
+		return err
+	}
+	rows, err := a.CatalogPool.Query(ctx, "SELECT name FROM peers")
+	if err != nil {
+		return err
+	}
+	peerNames, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	var dropped atomic.Int64
+	shutdown := heartbeatRoutine(ctx, func() string {
+		return fmt.Sprintf("dropped %d orphaned artifacts", dropped.Load())
+	})
+	defer shutdown()
+
+	logger := activity.GetLogger(ctx)
+	for _, peerName := range peerNames {
+		if err := a.cleanupOrphanedArtifacts(ctx, peerName, dryRun, &dropped); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Warn("failed to clean up orphaned artifacts", slog.String("peerName", peerName), slog.Any("error", err))
+		}
+	}
+	return nil
+}
+
+func (a *FlowableActivity) cleanupOrphanedArtifacts(
+	ctx context.Context,
+	peerName string,
+	dryRun bool,
+	dropped *atomic.Int64,
+) error {
+	conn, err := connectors.GetByNameAs[connectors.StagingArtifactsConnector](ctx, nil, a.CatalogPool, peerName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return fmt.Errorf("failed to connect to peer: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, conn)
+
+	artifacts, err := connectors.OrphanedArtifacts(ctx, conn, a.CatalogPool)
+	if err != nil {
+		return err
+	}
+	logger := activity.GetLogger(ctx)
+	for _, artifact := range artifacts {
+		artifactLog := []any{
+			slog.String("peerName", peerName),
+			slog.String("artifact", artifact.Name),
+			slog.String("kind", artifact.Kind.String()),
+		}
+		if dryRun {
+			logger.Info("found orphaned artifact, not dropping it in dry run mode", artifactLog...)
+			continue
+		}
+		logger.Info("dropping orphaned artifact", artifactLog...)
+		if err := conn.DropStagingArtifact(ctx, artifact); err != nil {
+			return err
+		}
+		dropped.Add(1)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/activity"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+type reconcileMirror struct {
+	config     *protos.FlowConnectionConfigs
+	workflowID string
+}
+
+// ReconcileMirrors cross-checks every CDC mirror in the catalog against its workflow, replication slot and
+// destination metadata, repairing a catalog offset lagging the destination and alerting on drift it cannot repair
+func (a *FlowableActivity) ReconcileMirrors(ctx context.Context) error {
+	rows, err := a.CatalogPool.Query(ctx,
+		"SELECT DISTINCT ON (name) workflow_id, config_proto FROM flows WHERE query_string IS NULL")
+	if err != nil {
+		return err
+	}
+
+	mirrors, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (reconcileMirror, error) {
+		var mirror reconcileMirror
+		var configProto []byte
+		if err := row.Scan(&mirror.workflowID, &configProto); err != nil {
+			return mirror, err
+		}
+
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configProto, &config); err != nil {
+			return mirror, err
+		}
+		mirror.config = &config
+		return mirror, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger := activity.GetLogger(ctx)
+	for _, mirror := range mirrors {
+		activity.RecordHeartbeat(ctx, "reconciling "+mirror.config.FlowJobName)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if drift := a.reconcileMirror(ctx, mirror); drift != nil {
+			logger.Warn("mirror drifted from catalog", slog.String("flowName", mirror.config.FlowJobName),
+				slog.Any("error", drift))
+			a.Alerter.LogFlowError(ctx, mirror.config.FlowJobName, fmt.Errorf("reconciliation found drift: %w", drift))
+		}
+	}
+
+	return nil
+}
+
+func (a *FlowableActivity) reconcileMirror(ctx context.Context, mirror reconcileMirror) error {
+	config := mirror.config
+	var drift []error
+
+	workflowRunning, err := a.isWorkflowRunning(ctx, mirror.workflowID)
+	if err != nil {
+		return fmt.Errorf("failed to describe workflow %s: %w", mirror.workflowID, err)
+	}
+	// snapshot only mirrors finish once the snapshot is done
+	if !workflowRunning && !config.InitialSnapshotOnly {
+		drift = append(drift, fmt.Errorf("workflow %s is not running", mirror.workflowID))
+	}
+
+	if !config.InitialSnapshotOnly {
+		slotExists, err := a.reconcileSlotExists(ctx, config)
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			// source without replication slots
+		case err != nil:
+			drift = append(drift, err)
+		case slotExists && !workflowRunning:
+			drift = append(drift, errors.New("replication slot retains WAL while no workflow consumes it"))
+		case !slotExists && workflowRunning:
+			drift = append(drift, errors.New("replication slot does not exist"))
+		}
+	}
+
+	if err := a.reconcileDestinationMetadata(ctx, config); err != nil {
+		drift = append(drift, err)
+	}
+
+	return errors.Join(drift...)
+}
+
+func (a *FlowableActivity) isWorkflowRunning(ctx context.Context, workflowID string) (bool, error) {
+	desc, err := a.TemporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return desc.GetWorkflowExecutionInfo().GetStatus() == enums.WORKFLOW_EXECUTION_STATUS_RUNNING, nil
+}
+
+func (a *FlowableActivity) reconcileSlotExists(ctx context.Context, config *protos.FlowConnectionConfigs) (bool, error) {
+	srcConn, err := connectors.GetByNameAs[connectors.CDCPullConnectorCore](ctx, config.Env, a.CatalogPool, config.SourceName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return false, err
+		}
+		return false, fmt.Errorf("failed to connect to source %s: %w", config.SourceName, err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	slotName := "peerflow_slot_" + config.FlowJobName
+	if config.ReplicationSlotName != "" {
+		slotName = config.ReplicationSlotName
+	}
+	slotInfo, err := srcConn.GetSlotInfo(ctx, slotName)
+	if err != nil {
+		return false, fmt.Errorf("failed to get replication slot %s: %w", slotName, err)
+	}
+	return len(slotInfo) > 0, nil
+}
+
+func (a *FlowableActivity) reconcileDestinationMetadata(ctx context.Context, config *protos.FlowConnectionConfigs) error {
+	dstConn, err := connectors.GetByNameAs[connectors.CDCSyncConnectorCore](ctx, config.Env, a.CatalogPool, config.DestinationName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return fmt.Errorf("failed to connect to destination %s: %w", config.DestinationName, err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	// recreating metadata tables would restart batches from scratch, so that is left to resync
+	if dstConn.NeedsSetupMetadataTables(ctx) {
+		return errors.New("destination metadata tables do not exist")
+	}
+
+	dstOffset, err := dstConn.GetLastOffset(ctx, config.FlowJobName)
+	if err != nil {
+		return fmt.Errorf("failed to get last offset from destination: %w", err)
+	}
+	catalogOffset, err := monitoring.GetLatestLSNAtTargetForCDCFlow(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		return err
+	}
+	// destination metadata is the source of truth, a lagging catalog only misreports lag
+	if dstOffset > catalogOffset {
+		if err := monitoring.UpdateLatestLSNAtTargetForCDCFlow(ctx, a.CatalogPool, config.FlowJobName, dstOffset); err != nil {
+			return err
+		}
+		a.Alerter.LogFlowEvent(ctx, config.FlowJobName,
+			fmt.Sprintf("reconciliation moved catalog offset from %d to destination offset %d", catalogOffset, dstOffset))
+	}
+
+	dstBatchID, err := dstConn.GetLastSyncBatchID(ctx, config.FlowJobName)
+	if err != nil {
+		return fmt.Errorf("failed to get last sync batch from destination: %w", err)
+	}
+	catalogBatchID, err := monitoring.GetLastCDCBatchID(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		return err
+	}
+	// the catalog records a batch before syncing it, so it may be one ahead while a sync is in flight
+	if catalogBatchID > dstBatchID+1 {
+		return fmt.Errorf("destination metadata is at batch %d while catalog has synced up to batch %d",
+			dstBatchID, catalogBatchID)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+
+	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+// attachRecordSampler tees records headed for the destination into a pending sample request, if there is one.
+// Sampling is best effort, failures are logged and never fail the sync.
+func attachRecordSampler[Items model.Items](
+	ctx context.Context,
+	a *FlowableActivity,
+	logger log.Logger,
+	flowName string,
+	stream *model.CDCStream[Items],
+) *model.CDCStream[Items] {
+	sampleID, remaining, err := monitoring.GetPendingRecordSample(ctx, a.CatalogPool, flowName)
+	if err != nil {
+		logger.Warn("failed to check for record sample requests", slog.Any("error", err))
+		return stream
+	} else if sampleID == 0 || remaining <= 0 {
+		return stream
+	}
+
+	logger.Info("sampling records for mirror", slog.Int64("sampleID", sampleID), slog.Int("remaining", int(remaining)))
+	outstream := model.NewCDCStream[Items](0)
+	go func() {
+		samples := make([][]byte, 0, remaining)
+		flush := func(complete bool) {
+			if len(samples) == 0 && !complete {
+				return
+			}
+			records := append(append([]byte{'['}, bytes.Join(samples, []byte{','})...), ']')
+			if err := monitoring.AppendRecordSamples(ctx, a.CatalogPool, sampleID, records, complete); err != nil {
+				logger.Warn("failed to save sampled records", slog.Any("error", err))
+			}
+			samples = samples[:0]
+		}
+
+		if stream.WaitAndCheckEmpty() {
+			outstream.SignalAsEmpty()
+			<-stream.GetRecords() // needed because empty signal comes before Close
+		} else {
+			outstream.SignalAsNotEmpty()
+			for record := range stream.GetRecords() {
+				if remaining > 0 {
+					if sample := sampleRecord(record); sample != nil {
+						if encoded, err := protojson.Marshal(sample); err != nil {
+							logger.Warn("failed to encode sampled record", slog.Any("error", err))
+						} else {
+							samples = append(samples, encoded)
+							remaining -= 1
+							if remaining == 0 {
+								flush(true)
+							}
+						}
+					}
+				}
+				if err := outstream.AddRecord(ctx, record); err != nil {
+					for range stream.GetRecords() {
+						// still read records to make sure input closes first
+					}
+					break
+				}
+			}
+		}
+		flush(false)
+		outstream.SchemaDeltas = stream.SchemaDeltas
+		outstream.AddTruncatedTables(stream.TruncatedTables...)
+		outstream.UpdateLatestCheckpoint(stream.GetLastCheckpoint())
+		outstream.Close()
+	}()
+	return outstream
+}
+
+func sampleRecord[Items model.Items](record model.Record[Items]) *protos.SampledRecord {
+	sample := &protos.SampledRecord{
+		Kind:             record.Kind(),
+		SourceTable:      record.GetSourceTableName(),
+		DestinationTable: record.GetDestinationTableName(),
+		CheckpointId:     record.GetCheckpointID(),
+	}
+	switch r := record.(type) {
+	case *model.InsertRecord[Items], *model.DeleteRecord[Items]:
+	case *model.UpdateRecord[Items]:
+		oldItems, err := model.ItemsToJSON(r.OldItems)
+		if err != nil {
+			return nil
+		}
+		sample.OldItems = oldItems
+	default:
+		return nil
+	}
+	items, err := model.ItemsToJSON(record.GetItems())
+	if err != nil {
+		return nil
+	}
+	sample.Items = items
+	return sample
+}
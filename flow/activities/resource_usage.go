@@ -0,0 +1,223 @@
+package activities
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/otel_metrics"
+	"github.com/PeerDB-io/peer-flow/otel_metrics/peerdb_gauges"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+const resourceUsageSampleInterval = 30 * time.Second
+
+// ResourceUsageTracker attributes worker resource consumption to mirrors.
+// Go has no per-goroutine accounting, so each sample of process CPU time, heap in use and goroutine count
+// is split between mirrors proportionally to the number of activities they have running on this worker.
+type ResourceUsageTracker struct {
+	catalogPool *pgxpool.Pool
+	otelManager *otel_metrics.OtelManager
+	gauges      *peerdb_gauges.FlowResourceGauges
+	active      map[string]int32
+	labels      map[string]map[string]string
+	// mirrors with temporary files in the spill directory at the last sample
+	spilled  map[string]int64
+	workerID string
+	lastCPU  time.Duration
+	mu       sync.Mutex
+}
+
+func NewResourceUsageTracker(catalogPool *pgxpool.Pool, otelManager *otel_metrics.OtelManager) *ResourceUsageTracker {
+	workerID, err := os.Hostname()
+	if err != nil {
+		workerID = "unknown"
+	}
+	return &ResourceUsageTracker{
+		catalogPool: catalogPool,
+		otelManager: otelManager,
+		active:      make(map[string]int32),
+		labels:      make(map[string]map[string]string),
+		workerID:    workerID,
+		lastCPU:     processCPUTime(),
+	}
+}
+
+// Track registers an activity for flowName as running, returned function unregisters it,
+// labels of the mirror are attached to its gauges
+func (t *ResourceUsageTracker) Track(flowName string, labels map[string]string) func() {
+	if t == nil {
+		return func() {}
+	}
+	t.mu.Lock()
+	t.active[flowName] += 1
+	t.labels[flowName] = labels
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.active[flowName] <= 1 {
+			delete(t.active, flowName)
+			delete(t.labels, flowName)
+		} else {
+			t.active[flowName] -= 1
+		}
+	}
+}
+
+// Run samples resource usage until ctx is done
+func (t *ResourceUsageTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(resourceUsageSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.sample(ctx)
+		}
+	}
+}
+
+func (t *ResourceUsageTracker) sample(ctx context.Context) {
+	t.mu.Lock()
+	cpu := processCPUTime()
+	cpuDelta := cpu - t.lastCPU
+	t.lastCPU = cpu
+	active := make(map[string]int32, len(t.active))
+	labels := make(map[string]map[string]string, len(t.labels))
+	var totalActive int32
+	for flowName, count := range t.active {
+		active[flowName] = count
+		labels[flowName] = t.labels[flowName]
+		totalActive += count
+	}
+	t.mu.Unlock()
+
+	gauges := t.getGauges()
+	if gauges != nil {
+		t.sampleSpill(gauges, labels)
+	}
+	if totalActive == 0 {
+		return
+	}
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	goroutines := runtime.NumGoroutine()
+	sampledAt := time.Now()
+
+	for flowName, count := range active {
+		share := float64(count) / float64(totalActive)
+		sample := monitoring.ResourceUsageSample{
+			SampledAt:        sampledAt,
+			WorkerID:         t.workerID,
+			CPUSeconds:       cpuDelta.Seconds() * share,
+			MemoryBytes:      int64(float64(memStats.HeapInuse) * share),
+			Goroutines:       float64(goroutines) * share,
+			ActiveActivities: count,
+		}
+		if err := monitoring.AppendFlowResourceUsage(ctx, t.catalogPool, flowName, sample); err != nil {
+			slog.Warn("failed to record resource usage", slog.String("flowName", flowName), slog.Any("error", err))
+		}
+		if gauges != nil {
+			attrs := flowAttributes(flowName, labels[flowName])
+			gauges.CPUUsageGauge.Set(sample.CPUSeconds/resourceUsageSampleInterval.Seconds(), attrs)
+			gauges.MemoryUsageGauge.Set(sample.MemoryBytes, attrs)
+			gauges.GoroutinesGauge.Set(sample.Goroutines, attrs)
+		}
+	}
+}
+
+// sampleSpill records bytes of temporary files of mirrors in the spill directory,
+// mirrors that removed all of theirs since the last sample drop to zero
+func (t *ResourceUsageTracker) sampleSpill(gauges *peerdb_gauges.FlowResourceGauges, labels map[string]map[string]string) {
+	usage, err := utils.SpillUsage()
+	if err != nil {
+		slog.Warn("failed to measure spill usage", slog.Any("error", err))
+		return
+	}
+	for flowName := range t.spilled {
+		if _, ok := usage[flowName]; !ok {
+			gauges.SpillBytesGauge.Set(0, flowAttributes(flowName, labels[flowName]))
+		}
+	}
+	for flowName, bytes := range usage {
+		gauges.SpillBytesGauge.Set(bytes, flowAttributes(flowName, labels[flowName]))
+	}
+	t.spilled = usage
+}
+
+func flowAttributes(flowName string, labels map[string]string) attribute.Set {
+	return attribute.NewSet(append(peerdb_gauges.MirrorLabelAttributes(labels),
+		attribute.String(peerdb_gauges.FlowNameKey, flowName),
+		attribute.String(peerdb_gauges.DeploymentUidKey, peerdbenv.PeerDBDeploymentUID()))...)
+}
+
+func (t *ResourceUsageTracker) getGauges() *peerdb_gauges.FlowResourceGauges {
+	if t.otelManager == nil || t.gauges != nil {
+		return t.gauges
+	}
+
+	cpuGauge, err := otel_metrics.GetOrInitFloat64SyncGauge(t.otelManager.Meter,
+		t.otelManager.Float64GaugesCache,
+		peerdb_gauges.FlowCPUUsageGaugeName,
+		metric.WithUnit("{cpu}"),
+		metric.WithDescription("CPU cores used by mirror activities on this worker"))
+	if err != nil {
+		slog.Error("Failed to get flow cpu usage gauge", slog.Any("error", err))
+		return nil
+	}
+	memoryGauge, err := otel_metrics.GetOrInitInt64SyncGauge(t.otelManager.Meter,
+		t.otelManager.Int64GaugesCache,
+		peerdb_gauges.FlowMemoryUsageGaugeName,
+		metric.WithUnit("By"),
+		metric.WithDescription("Heap memory attributed to mirror activities on this worker"))
+	if err != nil {
+		slog.Error("Failed to get flow memory usage gauge", slog.Any("error", err))
+		return nil
+	}
+	goroutinesGauge, err := otel_metrics.GetOrInitFloat64SyncGauge(t.otelManager.Meter,
+		t.otelManager.Float64GaugesCache,
+		peerdb_gauges.FlowGoroutinesGaugeName,
+		metric.WithDescription("Goroutines attributed to mirror activities on this worker"))
+	if err != nil {
+		slog.Error("Failed to get flow goroutines gauge", slog.Any("error", err))
+		return nil
+	}
+	spillBytesGauge, err := otel_metrics.GetOrInitInt64SyncGauge(t.otelManager.Meter,
+		t.otelManager.Int64GaugesCache,
+		peerdb_gauges.FlowSpillBytesGaugeName,
+		metric.WithUnit("By"),
+		metric.WithDescription("Temporary files of the mirror in the spill directory of this worker"))
+	if err != nil {
+		slog.Error("Failed to get flow spill bytes gauge", slog.Any("error", err))
+		return nil
+	}
+
+	t.gauges = &peerdb_gauges.FlowResourceGauges{
+		CPUUsageGauge:    cpuGauge,
+		MemoryUsageGauge: memoryGauge,
+		GoroutinesGauge:  goroutinesGauge,
+		SpillBytesGauge:  spillBytesGauge,
+	}
+	return t.gauges
+}
+
+func processCPUTime() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
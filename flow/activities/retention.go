@@ -0,0 +1,103 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/activity"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// EnforceRetention deletes rows older than the retention policies of tables from destinations of mirrors,
+// a table failing is alerted on and left for the next run
+func (a *FlowableActivity) EnforceRetention(ctx context.Context) error {
+	rows, err := a.CatalogPool.Query(ctx,
+		"SELECT DISTINCT ON (name) config_proto FROM flows WHERE query_string IS NULL")
+	if err != nil {
+		return err
+	}
+	configs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.FlowConnectionConfigs, error) {
+		var configProto []byte
+		if err := row.Scan(&configProto); err != nil {
+			return nil, err
+		}
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configProto, &config); err != nil {
+			return nil, err
+		}
+		return &config, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var enforced atomic.Int64
+	shutdown := heartbeatRoutine(ctx, func() string {
+		return fmt.Sprintf("enforced retention on %d tables", enforced.Load())
+	})
+	defer shutdown()
+
+	now := time.Now()
+	for _, config := range configs {
+		if err := a.enforceRetention(ctx, config, now, &enforced); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			activity.GetLogger(ctx).Warn("failed to enforce retention",
+				slog.String("flowName", config.FlowJobName), slog.Any("error", err))
+			a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("retention enforcement failed: %w", err))
+		}
+	}
+	return nil
+}
+
+func (a *FlowableActivity) enforceRetention(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	now time.Time,
+	enforced *atomic.Int64,
+) error {
+	var retained []*protos.TableMapping
+	for _, tableMapping := range config.TableMappings {
+		if tableMapping.Retention.GetDays() > 0 {
+			retained = append(retained, tableMapping)
+		}
+	}
+	if len(retained) == 0 {
+		return nil
+	}
+
+	dstConn, err := connectors.GetByNameAs[connectors.RetentionConnector](ctx, config.Env, a.CatalogPool, config.DestinationName)
+	if err != nil {
+		return fmt.Errorf("failed to connect to destination %s: %w", config.DestinationName, err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	var tableErrs []error
+	for _, tableMapping := range retained {
+		cutoff := now.AddDate(0, 0, -int(tableMapping.Retention.Days))
+		activity.GetLogger(ctx).Info("deleting expired rows",
+			slog.String("flowName", config.FlowJobName),
+			slog.String("table", tableMapping.DestinationTableIdentifier),
+			slog.Time("cutoff", cutoff))
+		if err := dstConn.EnforceRetention(ctx, tableMapping.DestinationTableIdentifier,
+			tableMapping.Retention.Column, cutoff,
+		); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			tableErrs = append(tableErrs, err)
+			continue
+		}
+		enforced.Add(1)
+	}
+	return errors.Join(tableErrs...)
+}
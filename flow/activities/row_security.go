@@ -0,0 +1,134 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+// tableRowSecurity returns row security of destination tables tagging rows
+func tableRowSecurity(tableMappings []*protos.TableMapping) map[string]*protos.RowSecurity {
+	var tableSecurity map[string]*protos.RowSecurity
+	for _, mapping := range tableMappings {
+		if mapping.RowSecurity.GetTagColumn() == "" {
+			continue
+		}
+		if tableSecurity == nil {
+			tableSecurity = make(map[string]*protos.RowSecurity)
+		}
+		tableSecurity[mapping.DestinationTableIdentifier] = mapping.RowSecurity
+	}
+	return tableSecurity
+}
+
+// rowTag returns the tag of a row with value in the source column of security, null for a null value
+func rowTag(security *protos.RowSecurity, qv qvalue.QValue) qvalue.QValue {
+	if security.SourceColumn == "" {
+		return qvalue.QValueString{Val: security.Classification}
+	}
+	if qv == nil || qv.Value() == nil {
+		return qvalue.QValueNull(qvalue.QValueKindString)
+	}
+	if v, ok := qv.(qvalue.QValueString); ok {
+		return v
+	}
+	return qvalue.QValueString{Val: fmt.Sprint(qv.Value())}
+}
+
+// tagItems adds the tag column to items, returning false when the value tagging the row is missing
+func tagItems(security *protos.RowSecurity, items model.RecordItems) bool {
+	var qv qvalue.QValue
+	if security.SourceColumn != "" {
+		var ok bool
+		if qv, ok = items.ColToVal[security.SourceColumn]; !ok {
+			return false
+		}
+	}
+	items.AddColumn(security.TagColumn, rowTag(security, qv))
+	return true
+}
+
+// attachRowTags tags records of tables with row security before they're synced,
+// tags of updates leaving their source column unchanged in TOAST stay unchanged too
+func attachRowTags(
+	ctx context.Context,
+	stream *model.CDCStream[model.RecordItems],
+	tableSecurity map[string]*protos.RowSecurity,
+	onErr context.CancelCauseFunc,
+) *model.CDCStream[model.RecordItems] {
+	outstream := model.NewCDCStream[model.RecordItems](0)
+
+	go func() {
+		if stream.WaitAndCheckEmpty() {
+			outstream.SignalAsEmpty()
+			<-stream.GetRecords() // needed because empty signal comes before Close
+		} else {
+			outstream.SignalAsNotEmpty()
+			for record := range stream.GetRecords() {
+				if security, ok := tableSecurity[record.GetDestinationTableName()]; ok {
+					switch r := record.(type) {
+					case *model.InsertRecord[model.RecordItems]:
+						tagItems(security, r.Items)
+					case *model.UpdateRecord[model.RecordItems]:
+						if !tagItems(security, r.NewItems) {
+							if _, unchanged := r.UnchangedToastColumns[security.SourceColumn]; unchanged {
+								r.UnchangedToastColumns[security.TagColumn] = struct{}{}
+							}
+						}
+						tagItems(security, r.OldItems)
+					case *model.DeleteRecord[model.RecordItems]:
+						tagItems(security, r.Items)
+					}
+				}
+				if err := outstream.AddRecord(ctx, record); err != nil {
+					onErr(err)
+					<-ctx.Done()
+					for range stream.GetRecords() {
+						// still read records to make sure input closes first
+					}
+					break
+				}
+			}
+		}
+		outstream.SchemaDeltas = stream.SchemaDeltas
+		outstream.AddTruncatedTables(stream.TruncatedTables...)
+		outstream.UpdateLatestCheckpoint(stream.GetLastCheckpoint())
+		outstream.Close()
+	}()
+	return outstream
+}
+
+// attachQRepRowTags tags records copied by the initial snapshot, adding the tag column last
+func attachQRepRowTags(stream *model.QRecordStream, security *protos.RowSecurity) *model.QRecordStream {
+	output := model.NewQRecordStream(0)
+	go func() {
+		schema := stream.Schema()
+		sourceIdx := -1
+		for i, field := range schema.Fields {
+			if field.Name == security.SourceColumn {
+				sourceIdx = i
+			}
+		}
+		if security.SourceColumn != "" && sourceIdx == -1 {
+			output.Close(fmt.Errorf("row security source column %s is not part of the snapshot", security.SourceColumn))
+			for range stream.Records {
+				// still read records so the pull doesn't block
+			}
+			return
+		}
+		output.SetSchema(qvalue.NewQRecordSchema(append(schema.Fields[:len(schema.Fields):len(schema.Fields)],
+			qvalue.QField{Name: security.TagColumn, Type: qvalue.QValueKindString, Nullable: true})))
+		for record := range stream.Records {
+			var qv qvalue.QValue
+			if sourceIdx != -1 {
+				qv = record[sourceIdx]
+			}
+			output.Records <- append(record, rowTag(security, qv))
+		}
+		output.Close(stream.Err())
+	}()
+	return output
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/PeerDB-io/peer-flow/alerting"
 	"github.com/PeerDB-io/peer-flow/connectors"
+	connmysql "github.com/PeerDB-io/peer-flow/connectors/mysql"
 	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/shared"
@@ -64,8 +65,7 @@ func (a *SnapshotActivity) SetupReplication(
 	conn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, nil, a.CatalogPool, config.PeerName)
 	if err != nil {
 		if errors.Is(err, errors.ErrUnsupported) {
-			logger.Info("setup replication is no-op for non-postgres source")
-			return nil, nil
+			return a.setupNonPostgresReplication(ctx, config)
 		}
 		return nil, fmt.Errorf("failed to get connector: %w", err)
 	}
@@ -119,6 +119,32 @@ func (a *SnapshotActivity) SetupReplication(
 	}, nil
 }
 
+// setupNonPostgresReplication pins where replication of mysql sources starts before tables are cloned,
+// it is a no-op for other sources
+func (a *SnapshotActivity) setupNonPostgresReplication(
+	ctx context.Context,
+	config *protos.SetupReplicationInput,
+) (*protos.SetupReplicationOutput, error) {
+	logger := activity.GetLogger(ctx)
+	conn, err := connectors.GetByNameAs[*connmysql.MySqlConnector](ctx, nil, a.CatalogPool, config.PeerName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			logger.Info("setup replication is no-op for non-postgres source")
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, conn)
+
+	startOffset, err := conn.PinStartOffset(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+		return nil, fmt.Errorf("failed to pin start offset: %w", err)
+	}
+	logger.Info("pinned binlog offset replication starts from", slog.Int64("offset", startOffset))
+	return &protos.SetupReplicationOutput{}, nil
+}
+
 func (a *SnapshotActivity) MaintainTx(ctx context.Context, sessionID string, peer string) error {
 	conn, err := connectors.GetByNameAs[connectors.CDCPullConnector](ctx, nil, a.CatalogPool, peer)
 	if err != nil {
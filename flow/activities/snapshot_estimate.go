@@ -0,0 +1,36 @@
+package activities
+
+import (
+	"context"
+	"log/slog"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// estimateQRepRows is best effort, the estimate only feeds the completion time shown for snapshots
+func (a *FlowableActivity) estimateQRepRows(
+	ctx context.Context,
+	srcConn connectors.QRepPullConnectorCore,
+	config *protos.QRepConfig,
+	runUUID string,
+) {
+	estimator, ok := srcConn.(connectors.QRepEstimateRowsConnector)
+	if !ok {
+		return
+	}
+	logger := activity.GetLogger(ctx)
+	estimatedRows, err := estimator.EstimateQRepRows(ctx, config)
+	if err != nil {
+		logger.Warn("failed to estimate rows of snapshot", slog.Any("error", err))
+		return
+	} else if estimatedRows == 0 {
+		return
+	}
+	if err := monitoring.UpdateEstimatedRowsForQRepRun(ctx, a.CatalogPool, runUUID, estimatedRows); err != nil {
+		logger.Warn("failed to save estimated rows of snapshot", slog.Any("error", err))
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/logger"
 	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
 	"github.com/PeerDB-io/peer-flow/shared/telemetry"
 )
 
@@ -168,6 +169,70 @@ func (a *Alerter) AlertIfSlotLag(ctx context.Context, peerName string, slotInfo
 	}
 }
 
+// AlertIfSlotGrowth alerts when a slot growing as it currently does is predicted to exceed
+// the slot lag threshold of a sender within horizon, so there's time to act before the source runs out of disk
+func (a *Alerter) AlertIfSlotGrowth(ctx context.Context, peerName string, slotInfo *protos.SlotInfo,
+	growth shared.SlotGrowth, horizon time.Duration,
+) {
+	if growth.MBPerHour() <= 0 {
+		return
+	}
+	alertSenderConfigs, err := a.registerSendersFromPool(ctx)
+	if err != nil {
+		logger.LoggerFromCtx(ctx).Warn("failed to set alert senders", slog.Any("error", err))
+		return
+	}
+
+	deploymentUIDPrefix := ""
+	if peerdbenv.PeerDBDeploymentUID() != "" {
+		deploymentUIDPrefix = fmt.Sprintf("[%s] ", peerdbenv.PeerDBDeploymentUID())
+	}
+
+	defaultSlotLagMBAlertThreshold, err := peerdbenv.PeerDBSlotLagMBAlertThreshold(ctx, nil)
+	if err != nil {
+		logger.LoggerFromCtx(ctx).Warn("failed to get slot lag alert threshold from catalog", slog.Any("error", err))
+		return
+	}
+
+	alertKey := fmt.Sprintf("%s Slot Growth Predicted for Slot %s on Peer %s", deploymentUIDPrefix, slotInfo.SlotName, peerName)
+	for _, alertSenderConfig := range alertSenderConfigs {
+		threshold := alertSenderConfig.Sender.getSlotLagMBAlertThreshold()
+		if threshold == 0 {
+			threshold = defaultSlotLagMBAlertThreshold
+		}
+		if threshold == 0 {
+			continue
+		}
+		eta, ok := growth.TimeToExceed(float64(slotInfo.LagInMb), float64(threshold))
+		if !ok || eta > horizon {
+			continue
+		}
+		alertMessage := fmt.Sprintf("%sSlot `%s` on peer `%s` will exceed %s in %s at current rates, "+
+			"currently at %.2fMB with WAL generated at %.2fMB/h and consumed at %.2fMB/h",
+			deploymentUIDPrefix, slotInfo.SlotName, peerName, formatMB(threshold), approxDuration(eta),
+			slotInfo.LagInMb, growth.GeneratedMBPerHour, growth.ConsumedMBPerHour)
+		if a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, alertKey, alertMessage) {
+			a.alertToProvider(ctx, alertSenderConfig, alertKey, alertMessage)
+		}
+	}
+}
+
+// formatMB formats a size in MB, as GB when it's a whole number of them
+func formatMB(mb uint32) string {
+	if mb >= 1024 && mb%1024 == 0 {
+		return fmt.Sprintf("%dGB", mb/1024)
+	}
+	return fmt.Sprintf("%dMB", mb)
+}
+
+// approxDuration rounds a prediction to hours, or minutes when under a couple of hours
+func approxDuration(d time.Duration) string {
+	if d >= 2*time.Hour {
+		return fmt.Sprintf("~%dh", int64(d.Round(time.Hour)/time.Hour))
+	}
+	return fmt.Sprintf("~%dm", max(int64(d.Round(time.Minute)/time.Minute), 1))
+}
+
 func (a *Alerter) AlertIfOpenConnections(ctx context.Context, peerName string,
 	openConnections *protos.GetOpenConnectionsForUserResult,
 ) {
@@ -220,6 +285,72 @@ func (a *Alerter) AlertIfOpenConnections(ctx context.Context, peerName string,
 	}
 }
 
+// AlertFreshnessSLA sends breach and recovery events of the freshness SLA of a mirror to every alert sender,
+// each event is sent once as evaluations only report changes of state
+func (a *Alerter) AlertFreshnessSLA(ctx context.Context, flowName string, recovered bool,
+	staleness time.Duration, sla time.Duration,
+) {
+	deploymentUIDPrefix := ""
+	if peerdbenv.PeerDBDeploymentUID() != "" {
+		deploymentUIDPrefix = fmt.Sprintf("[%s] ", peerdbenv.PeerDBDeploymentUID())
+	}
+	var alertKey, alertMessage string
+	level := telemetry.WARN
+	if recovered {
+		alertKey = fmt.Sprintf("%sFreshness SLA Recovered for Mirror %s", deploymentUIDPrefix, flowName)
+		alertMessage = fmt.Sprintf("%sMirror `%s` is within its freshness SLA of %s again, %s behind the source",
+			deploymentUIDPrefix, flowName, sla, staleness)
+		level = telemetry.INFO
+	} else {
+		alertKey = fmt.Sprintf("%sFreshness SLA Breached for Mirror %s", deploymentUIDPrefix, flowName)
+		alertMessage = fmt.Sprintf("%sMirror `%s` has breached its freshness SLA of %s, %s behind the source",
+			deploymentUIDPrefix, flowName, sla, staleness)
+	}
+	a.sendTelemetryMessage(ctx, flowName, alertMessage, level)
+	a.alertAllSenders(ctx, alertKey, alertMessage)
+}
+
+// AlertTableRenamed alerts that a source table of a mirror was renamed, along with what the mirror does about it
+func (a *Alerter) AlertTableRenamed(ctx context.Context, flowName string, srcTableName string, renamedTo string,
+	policy protos.TableRenamePolicy,
+) {
+	deploymentUIDPrefix := ""
+	if peerdbenv.PeerDBDeploymentUID() != "" {
+		deploymentUIDPrefix = fmt.Sprintf("[%s] ", peerdbenv.PeerDBDeploymentUID())
+	}
+	var action string
+	switch policy {
+	case protos.TableRenamePolicy_TABLE_RENAME_POLICY_RENAME_DESTINATION:
+		action = "its destination table is renamed along with it"
+	case protos.TableRenamePolicy_TABLE_RENAME_POLICY_PAUSE:
+		action = "the mirror is paused, resuming keeps the destination table under its name"
+	default:
+		action = "the destination table keeps its name"
+	}
+	alertKey := fmt.Sprintf("%sTable Renamed for Mirror %s", deploymentUIDPrefix, flowName)
+	alertMessage := fmt.Sprintf("%sSource table `%s` of mirror `%s` was renamed to `%s`, %s",
+		deploymentUIDPrefix, srcTableName, flowName, renamedTo, action)
+	a.sendTelemetryMessage(ctx, flowName, alertMessage, telemetry.WARN)
+	a.alertAllSenders(ctx, alertKey, alertMessage)
+}
+
+// alertAllSenders sends an alert to every configured sender, regardless of when they last got one
+func (a *Alerter) alertAllSenders(ctx context.Context, alertKey string, alertMessage string) {
+	alertSenderConfigs, err := a.registerSendersFromPool(ctx)
+	if err != nil {
+		logger.LoggerFromCtx(ctx).Warn("failed to set alert senders", slog.Any("error", err))
+		return
+	}
+	for _, alertSenderConfig := range alertSenderConfigs {
+		if _, err := a.catalogPool.Exec(ctx,
+			"INSERT INTO peerdb_stats.alerts_v1(alert_key,alert_message,alert_config_id) VALUES($1,$2,$3)",
+			alertKey, alertMessage, alertSenderConfig.Id); err != nil {
+			logger.LoggerFromCtx(ctx).Warn("failed to insert alert", slog.Any("error", err))
+		}
+		a.alertToProvider(ctx, alertSenderConfig, alertKey, alertMessage)
+	}
+}
+
 func (a *Alerter) alertToProvider(ctx context.Context, alertSenderConfig AlertSenderConfig, alertKey string, alertMessage string) {
 	err := alertSenderConfig.Sender.sendAlert(ctx, alertKey, alertMessage)
 	if err != nil {
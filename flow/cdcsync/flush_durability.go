@@ -0,0 +1,29 @@
+package cdcsync
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// FlushedOffset returns the offset to confirm to the source slot as flushed, which lets it release WAL before it.
+// With FLUSH_DURABILITY_NORMALIZE that's the end of the last normalized batch rather than syncedOffset,
+// so batches failing to normalize can be pulled again from the slot
+func FlushedOffset(
+	ctx context.Context,
+	catalogPool *pgxpool.Pool,
+	config *protos.FlowConnectionConfigs,
+	syncedOffset int64,
+) (int64, error) {
+	if config.FlushDurability != protos.FlushDurability_FLUSH_DURABILITY_NORMALIZE {
+		return syncedOffset, nil
+	}
+	batchID, err := monitoring.GetLastNormalizedCDCBatchID(ctx, catalogPool, config.FlowJobName)
+	if err != nil || batchID == 0 {
+		return 0, err
+	}
+	return monitoring.GetCDCBatchEndLSN(ctx, catalogPool, config.FlowJobName, batchID)
+}
@@ -0,0 +1,332 @@
+package cdcsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.temporal.io/sdk/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/faults"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// Hooks adds to what SyncBatch does around pulling and syncing a batch, nil hooks are skipped
+type Hooks[TSync connectors.CDCSyncConnectorCore, Items model.Items] struct {
+	// CheckLastOffset vets the offset dstConn last synced before pulling after it
+	CheckLastOffset func(ctx context.Context, dstConn TSync, lastOffset int64) error
+	// AdaptStream changes records between pulling and syncing them
+	AdaptStream func(stream *model.CDCStream[Items]) (*model.CDCStream[Items], error)
+	// Audit returns the context capturing statements against the destination,
+	// along with saving them under a batch or nil when not capturing
+	Audit func(ctx context.Context) (context.Context, func(batchID int64))
+	// SyncError maps errors of dstConn syncing records, which by default only get wrapped
+	SyncError func(ctx context.Context, dstConn TSync, err error) error
+	// PullError maps errors of pulling or syncing records once both ended, which by default only get wrapped
+	PullError func(ctx context.Context, err error) error
+	// CatalogError is told of errors recording the batch in the catalog before they are returned
+	CatalogError func(ctx context.Context, err error)
+}
+
+// Batch is what SyncBatch synced, CurrentSyncBatchID of the response is -1 when there were no records
+type Batch struct {
+	Response       *model.SyncResponse
+	NeedsNormalize bool
+	// offsets the batch started after and ended at
+	StartOffset int64
+	EndOffset   int64
+}
+
+// SyncBatch pulls records from srcConn after what the destination last synced and syncs them as the next batch,
+// recording the batch in the catalog and confirming to the source what FlushedOffset returns for it.
+// Schema changes without records are only replayed to the destination
+func SyncBatch[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncConnectorCore, Items model.Items](
+	ctx context.Context,
+	logger log.Logger,
+	catalogPool *pgxpool.Pool,
+	config *protos.FlowConnectionConfigs,
+	options *protos.SyncFlowOptions,
+	srcConn TPull,
+	pull func(TPull, context.Context, *pgxpool.Pool, *model.PullRecordsRequest[Items]) error,
+	sync func(TSync, context.Context, *model.SyncRecordsRequest[Items]) (*model.SyncResponse, error),
+	hooks Hooks[TSync, Items],
+) (*Batch, error) {
+	flowName := config.FlowJobName
+	catalogError := func(err error) error {
+		if hooks.CatalogError != nil {
+			hooks.CatalogError(ctx, err)
+		}
+		return err
+	}
+	pullError := func(err error) error {
+		if hooks.PullError != nil {
+			return hooks.PullError(ctx, err)
+		}
+		return fmt.Errorf("failed to pull records: %w", err)
+	}
+	audit := func(ctx context.Context) (context.Context, func(int64)) {
+		if hooks.Audit != nil {
+			return hooks.Audit(ctx)
+		}
+		return ctx, nil
+	}
+
+	tblNameMapping := make(map[string]model.NameAndExclude, len(options.TableMappings))
+	for _, v := range options.TableMappings {
+		tblNameMapping[v.SourceTableIdentifier] = model.NewNameAndExclude(v.DestinationTableIdentifier, v.Exclude)
+	}
+
+	batchSize := options.BatchSize
+	if batchSize == 0 {
+		batchSize = shared.DefaultMaxBatchSize
+	}
+
+	lastOffset, err := func() (int64, error) {
+		dstConn, err := connectors.GetByNameAs[TSync](ctx, config.Env, catalogPool, config.DestinationName)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get destination connector: %w", err)
+		}
+		defer connectors.CloseConnector(ctx, dstConn)
+
+		lastOffset, err := dstConn.GetLastOffset(ctx, flowName)
+		if err != nil {
+			return 0, err
+		}
+		if hooks.CheckLastOffset != nil {
+			if err := hooks.CheckLastOffset(ctx, dstConn, lastOffset); err != nil {
+				return 0, err
+			}
+		}
+		return lastOffset, nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	startFlushedOffset, err := FlushedOffset(ctx, catalogPool, config, lastOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("pulling records...", slog.Int64("LastOffset", lastOffset), slog.Int64("FlushedOffset", startFlushedOffset))
+	consumedOffset := atomic.Int64{}
+	consumedOffset.Store(startFlushedOffset)
+
+	channelBufferSize, err := peerdbenv.PeerDBCDCChannelBufferSize(ctx, config.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CDC channel buffer size: %w", err)
+	}
+	recordBatchPull := model.NewCDCStream[Items](int(channelBufferSize))
+	recordBatchSync := recordBatchPull
+	if hooks.AdaptStream != nil {
+		if recordBatchSync, err = hooks.AdaptStream(recordBatchPull); err != nil {
+			return nil, err
+		}
+	}
+	startTime := time.Now()
+
+	var pullEndTime time.Time
+	errGroup, errCtx := errgroup.WithContext(ctx)
+	errGroup.Go(func() error {
+		defer func() {
+			pullEndTime = time.Now()
+		}()
+		_, err := faults.Call(errCtx, config.Env, faults.PullRecords, func() (struct{}, error) {
+			return struct{}{}, pull(srcConn, errCtx, catalogPool, &model.PullRecordsRequest[Items]{
+				FlowJobName:           flowName,
+				SrcTableIDNameMapping: options.SrcTableIdNameMapping,
+				TableNameMapping:      tblNameMapping,
+				LastOffset:            lastOffset,
+				ConsumedOffset:        &consumedOffset,
+				MaxBatchSize:          batchSize,
+				IdleTimeout: peerdbenv.PeerDBCDCIdleTimeoutSeconds(
+					int(options.IdleTimeoutSeconds),
+				),
+				TableNameSchemaMapping:      options.TableNameSchemaMapping,
+				OverridePublicationName:     config.PublicationName,
+				OverrideReplicationSlotName: config.ReplicationSlotName,
+				RecordStream:                recordBatchPull,
+				Env:                         config.Env,
+				TypeWideningPolicy:          config.TypeWideningPolicy,
+				TruncatePolicy:              config.TruncatePolicy,
+				LogicalMessageDestination:   config.LogicalMessageDestination,
+				ExcludedOrigins:             config.ExcludedOrigins,
+				SourceIdentifier:            config.SourceIdentifier,
+			})
+		})
+		if errors.Is(err, faults.ErrInjected) {
+			// pull closes the stream once done, which it never started on when the fault came first
+			recordBatchPull.Close()
+		}
+		return err
+	})
+
+	hasRecords := !recordBatchSync.WaitAndCheckEmpty()
+	logger.Info("current sync flow has records?", slog.Bool("hasRecords", hasRecords))
+
+	if !hasRecords {
+		// wait for the pull goroutine to finish
+		if err := errGroup.Wait(); err != nil {
+			return nil, pullError(err)
+		}
+		logger.Info("no records to push")
+
+		dstConn, err := connectors.GetByNameAs[TSync](ctx, config.Env, catalogPool, config.DestinationName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recreate destination connector: %w", err)
+		}
+		defer connectors.CloseConnector(ctx, dstConn)
+
+		auditCtx, saveAudit := audit(ctx)
+		if err := dstConn.ReplayTableSchemaDeltas(auditCtx, flowName, recordBatchSync.SchemaDeltas); err != nil {
+			return nil, fmt.Errorf("failed to sync schema: %w", err)
+		}
+		if saveAudit != nil {
+			// no new batch, attribute schema changes to the last one
+			if lastBatchID, err := dstConn.GetLastSyncBatchID(ctx, flowName); err != nil {
+				logger.Warn("failed to get last sync batch id for destination audit log", slog.Any("error", err))
+			} else {
+				saveAudit(lastBatchID)
+			}
+		}
+
+		return &Batch{
+			Response: &model.SyncResponse{
+				CurrentSyncBatchID: -1,
+				TableSchemaDeltas:  recordBatchSync.SchemaDeltas,
+			},
+			StartOffset: lastOffset,
+			EndOffset:   lastOffset,
+		}, nil
+	}
+
+	var syncStartTime time.Time
+	var res *model.SyncResponse
+	errGroup.Go(func() error {
+		dstConn, err := connectors.GetByNameAs[TSync](ctx, config.Env, catalogPool, config.DestinationName)
+		if err != nil {
+			return fmt.Errorf("failed to recreate destination connector: %w", err)
+		}
+		defer connectors.CloseConnector(ctx, dstConn)
+
+		syncBatchID, err := dstConn.GetLastSyncBatchID(errCtx, flowName)
+		if err != nil {
+			return err
+		}
+		syncBatchID += 1
+
+		if err := monitoring.AddCDCBatchForFlow(errCtx, catalogPool, flowName,
+			monitoring.CDCBatchInfo{
+				BatchID:       syncBatchID,
+				RowsInBatch:   0,
+				BatchStartLSN: lastOffset,
+				BatchEndlSN:   0,
+				StartTime:     startTime,
+			}); err != nil {
+			return catalogError(err)
+		}
+
+		syncStartTime = time.Now()
+		auditCtx, saveAudit := audit(errCtx)
+		res, err = faults.Call(errCtx, config.Env, faults.SyncRecords, func() (*model.SyncResponse, error) {
+			return sync(dstConn, auditCtx, &model.SyncRecordsRequest[Items]{
+				SyncBatchID:            syncBatchID,
+				Records:                recordBatchSync,
+				ConsumedOffset:         &consumedOffset,
+				FlowJobName:            flowName,
+				TableMappings:          options.TableMappings,
+				StagingPath:            config.CdcStagingPath,
+				Script:                 config.Script,
+				QueueEncoding:          config.QueueEncoding,
+				CloudEventsMode:        config.CloudEventsMode,
+				TableNameSchemaMapping: options.TableNameSchemaMapping,
+				SoftDeleteColName:      config.SoftDeleteColName,
+				SyncedAtColName:        config.SyncedAtColName,
+				ChangelogMode:          config.ChangelogMode,
+			})
+		})
+		if err != nil {
+			if hooks.SyncError != nil {
+				return hooks.SyncError(ctx, dstConn, err)
+			}
+			return fmt.Errorf("failed to push records: %w", err)
+		}
+		if saveAudit != nil {
+			saveAudit(syncBatchID)
+		}
+		return nil
+	})
+
+	if err := errGroup.Wait(); err != nil {
+		return nil, pullError(err)
+	}
+
+	numRecords := res.NumRecordsSynced
+	res.TruncatedTables = recordBatchSync.TruncatedTables
+	syncDuration := time.Since(syncStartTime)
+	// destinations write while records stream in, what's left once pulling ends is waiting on the destination
+	if pullEndTime.After(syncStartTime) {
+		res.DestinationLatency = time.Since(pullEndTime)
+	} else {
+		res.DestinationLatency = syncDuration
+	}
+
+	if commitTime := recordBatchPull.LastCommitTime(); !commitTime.IsZero() && pullEndTime.After(commitTime) {
+		res.SourceLag = pullEndTime.Sub(commitTime)
+	}
+	logger.Info(fmt.Sprintf("pushed %d records in %d seconds", numRecords, int(syncDuration.Seconds())))
+
+	lastCheckpoint := recordBatchSync.GetLastCheckpoint()
+	if flushedOffset, err := FlushedOffset(ctx, catalogPool, config, lastCheckpoint); err != nil {
+		logger.Warn("failed to get offset flushed to source", slog.Any("error", err))
+	} else {
+		srcConn.UpdateReplStateLastOffset(flushedOffset)
+	}
+
+	if err := monitoring.UpdateNumRowsAndEndLSNForCDCBatch(
+		ctx,
+		catalogPool,
+		flowName,
+		res.CurrentSyncBatchID,
+		uint32(numRecords),
+		lastCheckpoint,
+	); err != nil {
+		return nil, catalogError(err)
+	}
+	if err := monitoring.AppendMirrorEvent(ctx, catalogPool, &protos.MirrorEvent{
+		FlowJobName: flowName,
+		EventType:   protos.MirrorEventType_MIRROR_EVENT_BATCH_SYNCED,
+		BatchId:     res.CurrentSyncBatchID,
+		NumRows:     numRecords,
+		EndLsn:      lastCheckpoint,
+	}); err != nil {
+		logger.Warn("failed to append batch synced event", slog.Any("error", err))
+	}
+
+	if err := monitoring.UpdateLatestLSNAtTargetForCDCFlow(ctx, catalogPool, flowName, lastCheckpoint); err != nil {
+		return nil, catalogError(err)
+	}
+	if res.TableNameRowsMapping != nil {
+		if err := monitoring.AddCDCBatchTablesForFlow(ctx, catalogPool, flowName,
+			res.CurrentSyncBatchID, res.TableNameRowsMapping); err != nil {
+			return nil, catalogError(err)
+		}
+	}
+
+	return &Batch{
+		Response:       res,
+		NeedsNormalize: recordBatchSync.NeedsNormalize(),
+		StartOffset:    lastOffset,
+		EndOffset:      lastCheckpoint,
+	}, nil
+}
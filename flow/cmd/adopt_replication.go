@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// AdoptReplication inspects replication slots and publications other tools set up on a postgres source,
+// generating a mirror which takes over a slot and publication in place so changes resume without a snapshot
+func (h *FlowRequestHandler) AdoptReplication(
+	ctx context.Context,
+	req *protos.AdoptReplicationRequest,
+) (*protos.AdoptReplicationResponse, error) {
+	sourcePeer, err := connectors.LoadPeer(ctx, h.pool, req.SourceName)
+	if err != nil {
+		return nil, err
+	}
+	sourcePeerConfig := sourcePeer.GetPostgresConfig()
+	if sourcePeerConfig == nil {
+		return nil, errors.New("adopting replication is only supported for postgres sources")
+	}
+	pgPeer, err := connpostgres.NewPostgresConnector(ctx, sourcePeerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create postgres connector: %w", err)
+	}
+	defer pgPeer.Close()
+
+	slots, publications, err := pgPeer.ExistingReplication(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect replication of source: %w", err)
+	}
+
+	res := &protos.AdoptReplicationResponse{
+		Slots:        make([]*protos.ExistingReplicationSlot, 0, len(slots)),
+		Publications: make([]*protos.ExistingPublication, 0, len(publications)),
+	}
+	var adoptable []connpostgres.ExistingSlot
+	for _, slot := range slots {
+		blocker := slot.AdoptionBlocker()
+		if blocker == "" {
+			adoptable = append(adoptable, slot)
+		}
+		res.Slots = append(res.Slots, &protos.ExistingReplicationSlot{
+			SlotName:          slot.Name,
+			Plugin:            slot.Plugin,
+			Active:            slot.Active,
+			ConfirmedFlushLsn: slot.ConfirmedFlushLSN,
+			Blocker:           blocker,
+		})
+	}
+	for _, publication := range publications {
+		res.Publications = append(res.Publications, &protos.ExistingPublication{
+			PublicationName: publication.Name,
+			Tables:          publication.Tables,
+			AllTables:       publication.AllTables,
+		})
+	}
+
+	slot, publication, warnings := chooseAdoptedReplication(req, slots, adoptable, publications)
+	res.Warnings = warnings
+	if slot == nil || publication == nil {
+		return res, nil
+	}
+
+	flowJobName := req.FlowJobName
+	if flowJobName == "" {
+		flowJobName = slot.Name
+	}
+	if !shared.IsValidReplicationName(flowJobName) {
+		res.Warnings = append(res.Warnings,
+			fmt.Sprintf("slot name %s isn't a valid mirror name, choose one matching ^[a-z_][a-z0-9_]*$", flowJobName))
+		return res, nil
+	}
+	tableMappings := make([]*protos.TableMapping, 0, len(publication.Tables))
+	for _, table := range publication.Tables {
+		tableMappings = append(tableMappings, &protos.TableMapping{
+			SourceTableIdentifier:      table,
+			DestinationTableIdentifier: table,
+		})
+	}
+	res.ConnectionConfigs = &protos.FlowConnectionConfigs{
+		FlowJobName:         flowJobName,
+		SourceName:          req.SourceName,
+		DestinationName:     req.DestinationName,
+		TableMappings:       tableMappings,
+		ReplicationSlotName: slot.Name,
+		PublicationName:     publication.Name,
+		DoInitialSnapshot:   false,
+	}
+	res.Warnings = append(res.Warnings,
+		fmt.Sprintf("changes before %s, where slot %s was confirmed, are taken to be in destination tables already",
+			slot.ConfirmedFlushLSN, slot.Name),
+		"offsets kept by the previous consumer, like Debezium connector offsets, are ignored; stop it before creating the mirror",
+		"dropping the mirror keeps the slot and publication, drop them on the source once they aren't needed")
+	if slot.Active {
+		res.Warnings = append(res.Warnings,
+			fmt.Sprintf("slot %s is in use by another consumer, stop it before creating the mirror", slot.Name))
+		return res, nil
+	}
+
+	if req.Create {
+		if _, err := h.CreateCDCFlow(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: res.ConnectionConfigs}); err != nil {
+			return nil, err
+		}
+		res.Created = true
+		slog.Info("created mirror adopting replication slot", slog.String(string(shared.FlowNameKey), flowJobName),
+			slog.String("slotName", slot.Name), slog.String("publicationName", publication.Name))
+	}
+	return res, nil
+}
+
+// chooseAdoptedReplication picks the slot and publication a request names, or the only candidate when it names none,
+// returning warnings for why none could be picked
+func chooseAdoptedReplication(
+	req *protos.AdoptReplicationRequest,
+	slots []connpostgres.ExistingSlot,
+	adoptable []connpostgres.ExistingSlot,
+	publications []connpostgres.ExistingPublication,
+) (*connpostgres.ExistingSlot, *connpostgres.ExistingPublication, []string) {
+	var warnings []string
+	var slot *connpostgres.ExistingSlot
+	if req.SlotName != "" {
+		for i := range slots {
+			if slots[i].Name == req.SlotName {
+				if blocker := slots[i].AdoptionBlocker(); blocker != "" {
+					warnings = append(warnings, fmt.Sprintf("slot %s can't be adopted: %s", req.SlotName, blocker))
+				} else {
+					slot = &slots[i]
+				}
+				break
+			}
+		}
+		if slot == nil && len(warnings) == 0 {
+			warnings = append(warnings, fmt.Sprintf("slot %s not found", req.SlotName))
+		}
+	} else if len(adoptable) == 1 {
+		slot = &adoptable[0]
+	} else if len(adoptable) == 0 {
+		warnings = append(warnings, "no replication slot on the source can be adopted")
+	} else {
+		warnings = append(warnings, "source has several replication slots which can be adopted, choose one")
+	}
+
+	var publication *connpostgres.ExistingPublication
+	if req.PublicationName != "" {
+		for i := range publications {
+			if publications[i].Name == req.PublicationName {
+				publication = &publications[i]
+				break
+			}
+		}
+		if publication == nil {
+			warnings = append(warnings, fmt.Sprintf("publication %s not found", req.PublicationName))
+		}
+	} else if len(publications) == 1 {
+		publication = &publications[0]
+	} else if len(publications) == 0 {
+		warnings = append(warnings, "source has no publication to adopt")
+	} else {
+		warnings = append(warnings, "source has several publications, choose one")
+	}
+	if publication != nil {
+		// mirrors need every change of their tables, those left out would leave destinations diverged
+		if !publication.Insert || !publication.Update || !publication.Delete {
+			warnings = append(warnings,
+				fmt.Sprintf("publication %s has to publish inserts, updates and deletes", publication.Name))
+			publication = nil
+		} else if len(publication.Tables) == 0 {
+			warnings = append(warnings, fmt.Sprintf("publication %s has no tables", publication.Name))
+			publication = nil
+		}
+	}
+	return slot, publication, warnings
+}
@@ -19,6 +19,8 @@ import (
 	"go.temporal.io/sdk/client"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	// registered so clients of the flow API can compress with gzip, or zstd registered by grpczstd below
+	_ "google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
@@ -27,6 +29,7 @@ import (
 	"github.com/PeerDB-io/peer-flow/logger"
 	"github.com/PeerDB-io/peer-flow/peerdbenv"
 	"github.com/PeerDB-io/peer-flow/shared"
+	_ "github.com/PeerDB-io/peer-flow/shared/grpczstd"
 	peerflow "github.com/PeerDB-io/peer-flow/workflows"
 )
 
@@ -144,7 +147,7 @@ func setupGRPCGatewayServer(args *APIServerParams) (*http.Server, error) {
 		return nil, fmt.Errorf("unable to dial grpc server: %w", err)
 	}
 
-	gwmux := runtime.NewServeMux()
+	gwmux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(environmentHeaderMatcher))
 	err = protos.RegisterFlowServiceHandler(context.Background(), gwmux, conn)
 	if err != nil {
 		return nil, fmt.Errorf("unable to register gateway: %w", err)
@@ -184,10 +187,50 @@ func killExistingScheduleFlows(
 	return nil
 }
 
-func APIMain(ctx context.Context, args *APIServerParams) error {
+func recryptCatalog(ctx context.Context, catalogPool *pgxpool.Pool) {
+	recryptDatabase(
+		ctx,
+		catalogPool,
+		"peer",
+		"SELECT id, options, enc_key_id FROM peers WHERE enc_key_id <> $1 FOR UPDATE",
+		"UPDATE peers SET options = $2, enc_key_id = $3 WHERE id = $1",
+	)
+	recryptDatabase(
+		ctx,
+		catalogPool,
+		"alert config",
+		"SELECT id, service_config, enc_key_id FROM peerdb_stats.alerting_config WHERE enc_key_id <> $1 FOR UPDATE",
+		"UPDATE peerdb_stats.alerting_config SET service_config = $2, enc_key_id = $3 WHERE id = $1",
+	)
+	recryptDatabase(
+		ctx,
+		catalogPool,
+		"column encryption key",
+		"SELECT id, key_data, enc_key_id FROM column_encryption_keys WHERE enc_key_id <> $1 FOR UPDATE",
+		"UPDATE column_encryption_keys SET key_data = $2, enc_key_id = $3 WHERE id = $1",
+	)
+}
+
+// startScheduler replaces scheduler flows of taskQueue with a new one
+func startScheduler(ctx context.Context, tc client.Client, namespace string, taskQueue string) error {
+	if err := killExistingScheduleFlows(ctx, tc, namespace, taskQueue); err != nil {
+		return fmt.Errorf("unable to kill existing scheduler flows: %w", err)
+	}
+
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        fmt.Sprintf("scheduler-%s", uuid.New()),
+		TaskQueue: taskQueue,
+	}
+	if _, err := tc.ExecuteWorkflow(ctx, workflowOptions, peerflow.GlobalScheduleManagerWorkflow); err != nil {
+		return fmt.Errorf("unable to start scheduler workflow: %w", err)
+	}
+	return nil
+}
+
+func dialTemporal(hostPort string, namespace string) (client.Client, error) {
 	clientOptions := client.Options{
-		HostPort:  args.TemporalHostPort,
-		Namespace: args.TemporalNamespace,
+		HostPort:  hostPort,
+		Namespace: namespace,
 		Logger:    slog.New(logger.NewHandler(slog.NewJSONHandler(os.Stdout, nil))),
 	}
 
@@ -196,7 +239,7 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 
 		certs, err := parseTemporalCertAndKey()
 		if err != nil {
-			return fmt.Errorf("unable to base64 decode certificate and key: %w", err)
+			return nil, fmt.Errorf("unable to base64 decode certificate and key: %w", err)
 		}
 
 		connOptions := client.ConnectionOptions{
@@ -210,10 +253,16 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 
 	tc, err := client.Dial(clientOptions)
 	if err != nil {
-		return fmt.Errorf("unable to create Temporal client: %w", err)
+		return nil, fmt.Errorf("unable to create Temporal client: %w", err)
 	}
+	return tc, nil
+}
 
-	grpcServer := grpc.NewServer()
+func APIMain(ctx context.Context, args *APIServerParams) error {
+	tc, err := dialTemporal(args.TemporalHostPort, args.TemporalNamespace)
+	if err != nil {
+		return err
+	}
 
 	catalogPool, err := peerdbenv.GetCatalogConnectionPoolFromEnv(ctx)
 	if err != nil {
@@ -222,26 +271,25 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 
 	taskQueue := peerdbenv.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
 	flowHandler := NewFlowRequestHandler(tc, catalogPool, taskQueue)
-
-	err = killExistingScheduleFlows(ctx, tc, args.TemporalNamespace, taskQueue)
-	if err != nil {
-		return fmt.Errorf("unable to kill existing scheduler flows: %w", err)
+	if err := startScheduler(ctx, tc, args.TemporalNamespace, taskQueue); err != nil {
+		return err
 	}
 
-	workflowID := fmt.Sprintf("scheduler-%s", uuid.New())
-	workflowOptions := client.StartWorkflowOptions{
-		ID:        workflowID,
-		TaskQueue: taskQueue,
-	}
-
-	_, err = flowHandler.temporalClient.ExecuteWorkflow(
-		ctx,
-		workflowOptions,
-		peerflow.GlobalScheduleManagerWorkflow,
-	)
-	if err != nil {
-		return fmt.Errorf("unable to start scheduler workflow: %w", err)
+	var serverOptions []grpc.ServerOption
+	if environments := peerdbenv.PeerDBCatalogEnvironments(); len(environments) > 0 {
+		router, err := newEnvironmentRouter(ctx, tc, taskQueue, environments)
+		if err != nil {
+			return err
+		}
+		for _, envHandler := range router.handlers {
+			if err := startScheduler(ctx, tc, args.TemporalNamespace, envHandler.peerflowTaskQueueID); err != nil {
+				return err
+			}
+			go recryptCatalog(ctx, envHandler.pool)
+		}
+		serverOptions = append(serverOptions, grpc.UnaryInterceptor(router.intercept))
 	}
+	grpcServer := grpc.NewServer(serverOptions...)
 
 	protos.RegisterFlowServiceServer(grpcServer, flowHandler)
 	grpc_health_v1.RegisterHealthServer(grpcServer, health.NewServer())
@@ -272,20 +320,7 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 	}()
 
 	// somewhat unrelated here, but needed a process which isn't replicated
-	go recryptDatabase(
-		ctx,
-		catalogPool,
-		"peer",
-		"SELECT id, options, enc_key_id FROM peers WHERE enc_key_id <> $1 FOR UPDATE",
-		"UPDATE peers SET options = $2, enc_key_id = $3 WHERE id = $1",
-	)
-	go recryptDatabase(
-		ctx,
-		catalogPool,
-		"alert config",
-		"SELECT id, service_config, enc_key_id FROM peerdb_stats.alerting_config WHERE enc_key_id <> $1 FOR UPDATE",
-		"UPDATE peerdb_stats.alerting_config SET service_config = $2, enc_key_id = $3 WHERE id = $1",
-	)
+	go recryptCatalog(ctx, catalogPool)
 
 	<-ctx.Done()
 	grpcServer.GracefulStop()
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// SkipBatch leaves the changes of a batch which keeps failing to normalize in the raw table,
+// where they stay for inspection until dropping the mirror or replaying the batch
+func (h *FlowRequestHandler) SkipBatch(
+	ctx context.Context,
+	req *protos.SkipBatchRequest,
+) (*protos.SkipBatchResponse, error) {
+	slog.Info("skipping batch", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+		slog.Int64("batchID", req.BatchId))
+	if err := h.validateBatchOperation(ctx, req.FlowJobName); err != nil {
+		return nil, err
+	}
+	normalizedBatchID, err := monitoring.GetLastNormalizedCDCBatchID(ctx, h.pool, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	lastBatchID, err := monitoring.GetLastCDCBatchID(ctx, h.pool, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	if req.BatchId <= normalizedBatchID || req.BatchId > lastBatchID {
+		return nil, fmt.Errorf("only batches synced and not yet normalized can be skipped, batch %d is not in %d to %d",
+			req.BatchId, normalizedBatchID+1, lastBatchID)
+	}
+
+	if err := monitoring.AddBatchOperation(ctx, h.pool, req.FlowJobName, req.BatchId,
+		monitoring.BatchOperationSkip, req.Reason); err != nil {
+		slog.Error("unable to skip batch", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return &protos.SkipBatchResponse{}, nil
+}
+
+// ReplayBatch normalizes an applied batch again along with the next batch, like after restoring a destination table,
+// batches after it are normalized again too so rows end at their latest version
+func (h *FlowRequestHandler) ReplayBatch(
+	ctx context.Context,
+	req *protos.ReplayBatchRequest,
+) (*protos.ReplayBatchResponse, error) {
+	slog.Info("replaying batch", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+		slog.Int64("batchID", req.BatchId))
+	if err := h.validateBatchOperation(ctx, req.FlowJobName); err != nil {
+		return nil, err
+	}
+	normalizedBatchID, err := monitoring.GetLastNormalizedCDCBatchID(ctx, h.pool, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	if req.BatchId <= 0 || req.BatchId > normalizedBatchID {
+		return nil, fmt.Errorf("only normalized batches can be replayed, batch %d is not in 1 to %d",
+			req.BatchId, normalizedBatchID)
+	}
+
+	if err := monitoring.AddBatchOperation(ctx, h.pool, req.FlowJobName, req.BatchId,
+		monitoring.BatchOperationReplay, req.Reason); err != nil {
+		slog.Error("unable to replay batch", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return &protos.ReplayBatchResponse{}, nil
+}
+
+// ListBatchManifests lists the boundaries of normalized batches, downstream readers pick a manifest
+// and read its tables once it's listed so none of them show changes of batches after it
+func (h *FlowRequestHandler) ListBatchManifests(
+	ctx context.Context,
+	req *protos.ListBatchManifestsRequest,
+) (*protos.ListBatchManifestsResponse, error) {
+	manifests, err := monitoring.GetBatchManifests(ctx, h.pool, req.FlowJobName, req.AfterBatchId)
+	if err != nil {
+		slog.Error("unable to list batch manifests", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return &protos.ListBatchManifestsResponse{Manifests: manifests}, nil
+}
+
+func (h *FlowRequestHandler) validateBatchOperation(ctx context.Context, flowJobName string) error {
+	isCDC, err := h.isCDCFlow(ctx, flowJobName)
+	if err != nil {
+		return err
+	}
+	if !isCDC {
+		return errors.New("batches can only be skipped or replayed for CDC mirrors")
+	}
+	config, err := h.getFlowConfigFromCatalog(ctx, flowJobName)
+	if err != nil {
+		return err
+	}
+	dstType, err := connectors.LoadPeerType(ctx, h.pool, config.DestinationName)
+	if err != nil {
+		return fmt.Errorf("unable to load destination peer type: %w", err)
+	}
+	if !slices.Contains(normalizeReplayDestinations, dstType) {
+		return fmt.Errorf("batches of %s destinations cannot be skipped or replayed", dstType)
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+type BenchOptions struct {
+	TemporalHostPort  string
+	TemporalNamespace string
+	SourceName        string
+	DestinationName   string
+	// destination table of the benchmark mirror, derived from the source table when empty
+	DestinationTable string
+	Rows             int64
+	CDCDuration      time.Duration
+	// changes a second the CDC workload makes, split 60/30/10 between inserts, updates and deletes
+	CDCRate int64
+	JSON    bool
+}
+
+// BenchReport is the outcome of a benchmark run, durations are in seconds and rates in rows a second
+type BenchReport struct {
+	Source                 string   `json:"source"`
+	Destination            string   `json:"destination"`
+	Rows                   int64    `json:"rows"`
+	SourceRoundTripMs      float64  `json:"sourceRoundTripMs"`
+	DestinationRoundTripMs float64  `json:"destinationRoundTripMs"`
+	SourceScanMBps         float64  `json:"sourceScanMBps"`
+	SourceTransferMBps     float64  `json:"sourceTransferMBps"`
+	SnapshotSeconds        float64  `json:"snapshotSeconds"`
+	SnapshotRowsPerSecond  float64  `json:"snapshotRowsPerSecond"`
+	SnapshotPullSeconds    float64  `json:"snapshotPullSeconds"`
+	SnapshotWriteSeconds   float64  `json:"snapshotWriteSeconds"`
+	CDCChanges             int64    `json:"cdcChanges"`
+	CDCChangesPerSecond    float64  `json:"cdcChangesPerSecond"`
+	CDCCatchUpSeconds      float64  `json:"cdcCatchUpSeconds"`
+	CDCBatches             int64    `json:"cdcBatches"`
+	Hints                  []string `json:"hints"`
+}
+
+const benchTableSQL = `CREATE TABLE %s (id BIGINT PRIMARY KEY, amount NUMERIC(12,2) NOT NULL, status TEXT NOT NULL,
+	payload TEXT NOT NULL, attrs JSONB NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT now())`
+
+// benchRowsSQL inserts rows $1 through $2 of the standard workload, about 200 bytes a row
+const benchRowsSQL = `INSERT INTO %s (id, amount, status, payload, attrs)
+	SELECT g, (random() * 10000)::NUMERIC(12,2), (ARRAY['new','paid','shipped'])[1 + g %% 3],
+		repeat(md5(g::TEXT), 4), jsonb_build_object('k', g, 'tag', md5(g::TEXT))
+	FROM generate_series($1::BIGINT, $2::BIGINT) g`
+
+// BenchMain runs a snapshot of a standard table from a postgres source followed by a mix of changes to it,
+// through a mirror on the workers of the deployment, reporting throughput with hints on what limited it.
+// The source table, mirror and destination table are named after the run, the destination table is left behind
+func BenchMain(ctx context.Context, opts *BenchOptions) error {
+	// the report goes to stdout, keep logs out of it
+	slog.SetDefault(slog.New(logger.NewHandler(slog.NewJSONHandler(os.Stderr, nil))))
+	tc, err := dialTemporal(opts.TemporalHostPort, opts.TemporalNamespace)
+	if err != nil {
+		return err
+	}
+	defer tc.Close()
+	catalogPool, err := peerdbenv.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get catalog connection pool: %w", err)
+	}
+	h := NewFlowRequestHandler(tc, catalogPool, peerdbenv.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue))
+
+	sourcePeer, err := connectors.LoadPeer(ctx, catalogPool, opts.SourceName)
+	if err != nil {
+		return err
+	}
+	sourcePeerConfig := sourcePeer.GetPostgresConfig()
+	if sourcePeerConfig == nil {
+		return errors.New("benchmarks are only supported for postgres sources")
+	}
+	dstPeer, err := connectors.LoadPeer(ctx, catalogPool, opts.DestinationName)
+	if err != nil {
+		return err
+	}
+	pgPeer, err := connpostgres.NewPostgresConnector(ctx, sourcePeerConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create postgres connector: %w", err)
+	}
+	defer pgPeer.Close()
+	dstConn, err := connectors.GetAs[connectors.Connector](ctx, nil, dstPeer)
+	if err != nil {
+		return fmt.Errorf("failed to create destination connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	runName := "peerdb_bench_" + strings.ToLower(shared.RandomString(8))
+	sourceTable := "public." + runName
+	destinationTable := opts.DestinationTable
+	if destinationTable == "" {
+		destinationTable = runName
+		if dstPeer.Type == protos.DBType_POSTGRES || dstPeer.Type == protos.DBType_SNOWFLAKE {
+			destinationTable = sourceTable
+		}
+	}
+	report := &BenchReport{Source: opts.SourceName, Destination: opts.DestinationName, Rows: opts.Rows}
+	conn := pgPeer.Conn()
+
+	slog.Info("creating benchmark table", slog.String("table", sourceTable), slog.Int64("rows", opts.Rows))
+	if _, err := conn.Exec(ctx, fmt.Sprintf(benchTableSQL, sourceTable)); err != nil {
+		return fmt.Errorf("failed to create benchmark table: %w", err)
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if _, err := h.FlowStateChange(cleanupCtx, &protos.FlowStateChangeRequest{
+			FlowJobName:        runName,
+			RequestedFlowState: protos.FlowStatus_STATUS_TERMINATED,
+			DropMirrorStats:    true,
+		}); err != nil {
+			slog.Warn("failed to drop benchmark mirror", slog.String("mirror", runName), slog.Any("error", err))
+		}
+		if _, err := conn.Exec(cleanupCtx, "DROP TABLE IF EXISTS "+sourceTable); err != nil {
+			slog.Warn("failed to drop benchmark table", slog.String("table", sourceTable), slog.Any("error", err))
+		}
+	}()
+	if _, err := conn.Exec(ctx, fmt.Sprintf(benchRowsSQL, sourceTable), 1, opts.Rows); err != nil {
+		return fmt.Errorf("failed to fill benchmark table: %w", err)
+	}
+	if _, err := conn.Exec(ctx, "ANALYZE "+sourceTable); err != nil {
+		return fmt.Errorf("failed to analyze benchmark table: %w", err)
+	}
+
+	if report.SourceRoundTripMs, err = benchRoundTrip(ctx, pgPeer.ConnectionActive); err != nil {
+		return err
+	}
+	if report.DestinationRoundTripMs, err = benchRoundTrip(ctx, dstConn.ConnectionActive); err != nil {
+		return err
+	}
+	if report.SourceScanMBps, report.SourceTransferMBps, err = benchSourceRead(ctx, conn, sourceTable); err != nil {
+		return err
+	}
+
+	slog.Info("creating benchmark mirror", slog.String("mirror", runName))
+	snapshotStart := time.Now()
+	if _, err := h.CreateCDCFlow(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: &protos.FlowConnectionConfigs{
+		FlowJobName:     runName,
+		SourceName:      opts.SourceName,
+		DestinationName: opts.DestinationName,
+		TableMappings: []*protos.TableMapping{{
+			SourceTableIdentifier:      sourceTable,
+			DestinationTableIdentifier: destinationTable,
+		}},
+		DoInitialSnapshot:           true,
+		SnapshotNumRowsPerPartition: uint32(max(opts.Rows/8, 10000)),
+		IdleTimeoutSeconds:          5,
+	}}); err != nil {
+		return fmt.Errorf("failed to create benchmark mirror: %w", err)
+	}
+	if err := benchWaitForState(ctx, h, runName, protos.FlowStatus_STATUS_RUNNING); err != nil {
+		return err
+	}
+	report.SnapshotSeconds = time.Since(snapshotStart).Seconds()
+	report.SnapshotRowsPerSecond = float64(opts.Rows) / report.SnapshotSeconds
+	if err := catalogPool.QueryRow(ctx, `SELECT
+		coalesce(sum(extract(epoch FROM pull_end_time - start_time)), 0)::FLOAT8,
+		coalesce(sum(extract(epoch FROM end_time - pull_end_time)), 0)::FLOAT8
+		FROM peerdb_stats.qrep_partitions WHERE parent_mirror_name = $1 AND end_time IS NOT NULL`,
+		runName).Scan(&report.SnapshotPullSeconds, &report.SnapshotWriteSeconds); err != nil {
+		return fmt.Errorf("failed to get snapshot partitions: %w", err)
+	}
+
+	slog.Info("running benchmark changes", slog.Duration("duration", opts.CDCDuration), slog.Int64("rate", opts.CDCRate))
+	cdcStart := time.Now()
+	lastLSN, changes, err := benchChanges(ctx, conn, sourceTable, opts)
+	if err != nil {
+		return err
+	}
+	report.CDCChanges = changes
+	caughtUp, batches, err := benchWaitForLSN(ctx, h, runName, lastLSN)
+	if err != nil {
+		return err
+	}
+	report.CDCBatches = batches
+	report.CDCChangesPerSecond = float64(changes) / caughtUp.Sub(cdcStart).Seconds()
+	report.CDCCatchUpSeconds = max(caughtUp.Sub(cdcStart).Seconds()-opts.CDCDuration.Seconds(), 0)
+
+	report.Hints = benchBottleneckHints(report)
+	return writeBenchReport(os.Stdout, report, opts.JSON)
+}
+
+// benchRoundTrip returns the median of a few pings in milliseconds
+func benchRoundTrip(ctx context.Context, ping func(context.Context) error) (float64, error) {
+	const pings = 5
+	durations := make([]time.Duration, 0, pings)
+	for range pings {
+		start := time.Now()
+		if err := ping(ctx); err != nil {
+			return 0, fmt.Errorf("failed to ping peer: %w", err)
+		}
+		durations = append(durations, time.Since(start))
+	}
+	slices.Sort(durations)
+	return float64(durations[pings/2].Microseconds()) / 1000, nil
+}
+
+// benchSourceRead compares how fast the source scans table with how fast it reaches this process,
+// telling source reads apart from the network between them
+func benchSourceRead(ctx context.Context, conn *pgx.Conn, table string) (float64, float64, error) {
+	var tableBytes int64
+	if err := conn.QueryRow(ctx, "SELECT pg_table_size($1::regclass)", table).Scan(&tableBytes); err != nil {
+		return 0, 0, fmt.Errorf("failed to get size of benchmark table: %w", err)
+	}
+	var plan []map[string]any
+	if err := conn.QueryRow(ctx, "EXPLAIN (ANALYZE, FORMAT JSON) SELECT * FROM "+table).Scan(&plan); err != nil {
+		return 0, 0, fmt.Errorf("failed to time scan of benchmark table: %w", err)
+	}
+	var scanMs float64
+	if len(plan) > 0 {
+		scanMs, _ = plan[0]["Execution Time"].(float64)
+	}
+
+	start := time.Now()
+	tag, err := conn.PgConn().CopyTo(ctx, io.Discard, fmt.Sprintf("COPY (SELECT * FROM %s) TO STDOUT", table))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to copy benchmark table: %w", err)
+	}
+	transferSeconds := time.Since(start).Seconds()
+	slog.Info("copied benchmark table", slog.Int64("rows", tag.RowsAffected()), slog.Float64("seconds", transferSeconds))
+
+	mb := float64(tableBytes) / (1 << 20)
+	var scanMBps float64
+	if scanMs > 0 {
+		scanMBps = mb / (scanMs / 1000)
+	}
+	return scanMBps, mb / transferSeconds, nil
+}
+
+// benchChanges makes the CDC workload, returning how many changes it made and a position before the last of them
+func benchChanges(ctx context.Context, conn *pgx.Conn, table string, opts *BenchOptions) (pglogrepl.LSN, int64, error) {
+	inserts := max(opts.CDCRate*6/10, 1)
+	updates := opts.CDCRate * 3 / 10
+	deletes := opts.CDCRate / 10
+	nextID := opts.Rows + 1
+	var changes int64
+	var lastLSN pglogrepl.LSN
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	deadline := time.Now().Add(opts.CDCDuration)
+	for time.Now().Before(deadline) {
+		if err := conn.QueryRow(ctx, "SELECT pg_current_wal_lsn()").Scan(&lastLSN); err != nil {
+			return 0, 0, fmt.Errorf("failed to get current lsn: %w", err)
+		}
+		batch := &pgx.Batch{}
+		batch.Queue(fmt.Sprintf(benchRowsSQL, table), nextID, nextID+inserts-1)
+		// ids are spread over the table so updates and deletes don't only touch recent rows
+		batch.Queue(fmt.Sprintf(`UPDATE %s SET amount = amount + 1, status = 'paid'
+			WHERE id IN (SELECT (random() * $1)::BIGINT + 1 FROM generate_series(1, $2::BIGINT))`, table),
+			nextID-1, updates)
+		batch.Queue(fmt.Sprintf(`DELETE FROM %s
+			WHERE id IN (SELECT (random() * $1)::BIGINT + 1 FROM generate_series(1, $2::BIGINT))`, table),
+			nextID-1, deletes)
+		results := conn.SendBatch(ctx, batch)
+		for range batch.Len() {
+			tag, err := results.Exec()
+			if err != nil {
+				results.Close()
+				return 0, 0, fmt.Errorf("failed to change benchmark table: %w", err)
+			}
+			changes += tag.RowsAffected()
+		}
+		if err := results.Close(); err != nil {
+			return 0, 0, fmt.Errorf("failed to change benchmark table: %w", err)
+		}
+		nextID += inserts
+
+		select {
+		case <-ctx.Done():
+			return 0, 0, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return lastLSN, changes, nil
+}
+
+func benchWaitForState(ctx context.Context, h *FlowRequestHandler, flowJobName string, state protos.FlowStatus) error {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		status, err := h.MirrorStatus(ctx, &protos.MirrorStatusRequest{FlowJobName: flowJobName})
+		if err != nil {
+			return err
+		}
+		if status.CurrentFlowState == state {
+			return nil
+		} else if status.CurrentFlowState == protos.FlowStatus_STATUS_TERMINATING ||
+			status.CurrentFlowState == protos.FlowStatus_STATUS_TERMINATED {
+			return errors.New("benchmark mirror was dropped")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// benchWaitForLSN waits for changes up to lsn to be normalized, returning when they were with the batches it took
+func benchWaitForLSN(
+	ctx context.Context,
+	h *FlowRequestHandler,
+	flowJobName string,
+	lsn pglogrepl.LSN,
+) (time.Time, int64, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		var caughtUp *time.Time
+		var batches int64
+		if err := h.pool.QueryRow(ctx, `SELECT min(end_time) FILTER (WHERE batch_end_lsn > $2), count(*)
+			FROM peerdb_stats.cdc_batches WHERE flow_name = $1 AND end_time IS NOT NULL`,
+			flowJobName, int64(lsn)).Scan(&caughtUp, &batches); err != nil {
+			return time.Time{}, 0, fmt.Errorf("failed to get benchmark batches: %w", err)
+		}
+		if caughtUp != nil {
+			return *caughtUp, batches, nil
+		}
+		select {
+		case <-ctx.Done():
+			return time.Time{}, 0, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// benchBottleneckHints points at what limited a run, comparing time spent reading the source with time spent writing
+// to the destination, and how fast the source scans with how fast its rows get here
+func benchBottleneckHints(report *BenchReport) []string {
+	var hints []string
+	if report.SourceRoundTripMs > 20 || report.DestinationRoundTripMs > 20 {
+		hints = append(hints, fmt.Sprintf("network: round trips take %.1f ms to the source and %.1f ms "+
+			"to the destination, run PeerDB in the region of its peers", report.SourceRoundTripMs,
+			report.DestinationRoundTripMs))
+	}
+	switch {
+	case report.SnapshotPullSeconds > 2*report.SnapshotWriteSeconds:
+		if report.SourceScanMBps > 0 && report.SourceTransferMBps < report.SourceScanMBps/4 {
+			hints = append(hints, fmt.Sprintf("network: the source scans at %.0f MB/s but rows arrive at %.0f MB/s, "+
+				"bandwidth to the source limits snapshots", report.SourceScanMBps, report.SourceTransferMBps))
+		} else {
+			hints = append(hints, "source read: snapshots spend most of their time reading the source, "+
+				"read from a replica or raise snapshot parallelism if the source has spare capacity")
+		}
+	case report.SnapshotWriteSeconds > 2*report.SnapshotPullSeconds:
+		hints = append(hints, "destination write: snapshots spend most of their time writing to the destination, "+
+			"size up its compute or lower snapshot parallelism to avoid contention")
+	}
+	if report.CDCCatchUpSeconds > 60 {
+		hints = append(hints, fmt.Sprintf("destination write: changes took %.0f s to reach destination tables after "+
+			"the workload stopped, raise max batch size so batches amortize destination overhead", report.CDCCatchUpSeconds))
+	}
+	if len(hints) == 0 {
+		hints = append(hints, "no bottleneck stood out, raise rows or CDC rate for a heavier workload")
+	}
+	return hints
+}
+
+func writeBenchReport(w io.Writer, report *BenchReport, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "PeerDB benchmark %s -> %s\n", report.Source, report.Destination)
+	fmt.Fprintf(&sb, "  round trip        source %.1f ms, destination %.1f ms\n",
+		report.SourceRoundTripMs, report.DestinationRoundTripMs)
+	fmt.Fprintf(&sb, "  source read       scan %.0f MB/s, transfer %.0f MB/s\n",
+		report.SourceScanMBps, report.SourceTransferMBps)
+	fmt.Fprintf(&sb, "  snapshot          %d rows in %.1f s, %.0f rows/s (pulling %.1f s, writing %.1f s)\n",
+		report.Rows, report.SnapshotSeconds, report.SnapshotRowsPerSecond,
+		report.SnapshotPullSeconds, report.SnapshotWriteSeconds)
+	fmt.Fprintf(&sb, "  cdc               %d changes in %d batches, %.0f changes/s, caught up %.1f s after the workload\n",
+		report.CDCChanges, report.CDCBatches, report.CDCChangesPerSecond, report.CDCCatchUpSeconds)
+	sb.WriteString("  hints\n")
+	for _, hint := range report.Hints {
+		fmt.Fprintf(&sb, "    - %s\n", hint)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// setupBidirectionalMirror tags changes applied by cfg with a replication origin both sides skip,
+// returning config of the mirror replicating the other way around
+func setupBidirectionalMirror(cfg *protos.FlowConnectionConfigs) *protos.FlowConnectionConfigs {
+	// origins are per database, so both mirrors can share one
+	origin := cfg.ReplicationOrigin
+	if origin == "" {
+		origin = "peerdb_" + cfg.FlowJobName
+	}
+	cfg.ReplicationOrigin = origin
+	if !slices.Contains(cfg.ExcludedOrigins, origin) {
+		cfg.ExcludedOrigins = append(cfg.ExcludedOrigins, origin)
+	}
+	cfg.BidirectionalMirror = cfg.FlowJobName + "_reverse"
+
+	reverse := proto.Clone(cfg).(*protos.FlowConnectionConfigs)
+	reverse.FlowJobName = cfg.BidirectionalMirror
+	reverse.BidirectionalMirror = cfg.FlowJobName
+	reverse.Bidirectional = false
+	reverse.SourceName, reverse.DestinationName = cfg.DestinationName, cfg.SourceName
+	reverse.TableMappings = make([]*protos.TableMapping, 0, len(cfg.TableMappings))
+	for _, tm := range cfg.TableMappings {
+		reverse.TableMappings = append(reverse.TableMappings, &protos.TableMapping{
+			SourceTableIdentifier:      tm.DestinationTableIdentifier,
+			DestinationTableIdentifier: tm.SourceTableIdentifier,
+			PartitionKey:               tm.PartitionKey,
+		})
+	}
+	// rows copied by the initial snapshot already exist on both sides
+	reverse.DoInitialSnapshot = false
+	reverse.InitialSnapshotOnly = false
+	reverse.PublicationName = ""
+	reverse.ReplicationSlotName = ""
+	reverse.LogicalMessageDestination = ""
+	return reverse
+}
+
+func validateBidirectionalMirror(cfg *protos.FlowConnectionConfigs, dstPeerType protos.DBType) error {
+	if !cfg.Bidirectional && cfg.BidirectionalMirror == "" {
+		return nil
+	}
+	if dstPeerType != protos.DBType_POSTGRES {
+		return fmt.Errorf("bidirectional mirrors are not supported for %s destinations", dstPeerType)
+	}
+	// the mirror replicating back would find these columns missing on the other side
+	if cfg.SoftDeleteColName != "" || cfg.SyncedAtColName != "" || cfg.SourceIdentifier != "" {
+		return errors.New("bidirectional mirrors do not support soft delete, synced at or source identifier columns")
+	}
+	return nil
+}
+
+func validateConflictPolicy(cfg *protos.FlowConnectionConfigs, dstPeerType protos.DBType) error {
+	switch cfg.ConflictPolicy {
+	case protos.ConflictPolicy_CONFLICT_POLICY_SOURCE_WINS:
+		return nil
+	case protos.ConflictPolicy_CONFLICT_POLICY_LAST_WRITE_WINS:
+		if cfg.ConflictColumn == "" {
+			return errors.New("conflict policy last_write_wins requires a conflict column")
+		}
+	case protos.ConflictPolicy_CONFLICT_POLICY_CUSTOM:
+		if cfg.ConflictCondition == "" {
+			return errors.New("conflict policy custom requires a conflict condition")
+		}
+	}
+	if dstPeerType != protos.DBType_POSTGRES {
+		return fmt.Errorf("conflict policies are not supported for %s destinations", dstPeerType)
+	}
+	return nil
+}
+
+// getBidirectionalMirror returns the other mirror of a bidirectional pair, empty if there is none
+func (h *FlowRequestHandler) getBidirectionalMirror(ctx context.Context, flowJobName string) (string, error) {
+	isCDC, err := h.isCDCFlow(ctx, flowJobName)
+	if err != nil || !isCDC {
+		return "", err
+	}
+	config, err := h.getFlowConfigFromCatalog(ctx, flowJobName)
+	if err != nil || config.BidirectionalMirror == "" {
+		return "", err
+	}
+	exists, err := h.CheckIfMirrorNameExists(ctx, config.BidirectionalMirror)
+	if err != nil || !exists {
+		return "", err
+	}
+	return config.BidirectionalMirror, nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+
+	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+type citusNode struct {
+	host    string
+	groupID int32
+	port    int32
+}
+
+// discoverCitusNodes returns a peer for every primary node of the Citus cluster holding shards,
+// nodes are reached at the address the coordinator knows them by, with the coordinator's credentials
+func (h *FlowRequestHandler) discoverCitusNodes(ctx context.Context, coordinator string) ([]string, error) {
+	coordinatorConfig, err := h.getPGPeerConfig(ctx, coordinator)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load Citus coordinator peer %s: %w", coordinator, err)
+	}
+	tunnel, err := connpostgres.NewSSHTunnel(ctx, coordinatorConfig.SshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tunnel to Citus coordinator %s: %w", coordinator, err)
+	}
+	defer tunnel.Close()
+	conn, err := tunnel.NewPostgresConnFromPostgresConfig(ctx, coordinatorConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to Citus coordinator %s: %w", coordinator, err)
+	}
+	defer conn.Close(ctx)
+
+	// without it, logical decoding on nodes emits changes of shards instead of distributed tables
+	var cdcEnabled string
+	if err := conn.QueryRow(ctx, "SHOW citus.enable_change_data_capture").Scan(&cdcEnabled); err != nil {
+		return nil, fmt.Errorf("unable to check change data capture of Citus coordinator %s: %w", coordinator, err)
+	} else if cdcEnabled != "on" {
+		return nil, fmt.Errorf("citus.enable_change_data_capture is not enabled on Citus coordinator %s", coordinator)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT groupid, nodename, nodeport FROM pg_dist_node
+		WHERE noderole='primary' AND isactive AND shouldhaveshards ORDER BY groupid`)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list nodes of Citus coordinator %s: %w", coordinator, err)
+	}
+	nodes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (citusNode, error) {
+		var node citusNode
+		err := row.Scan(&node.groupID, &node.host, &node.port)
+		return node, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read nodes of Citus coordinator %s: %w", coordinator, err)
+	}
+
+	peers := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		// peers are named by group, so they follow a failover of the node
+		peerName := fmt.Sprintf("%s_node_%d", coordinator, node.groupID)
+		nodeConfig := proto.Clone(coordinatorConfig).(*protos.PostgresConfig)
+		nodeConfig.Host = node.host
+		nodeConfig.Port = uint32(node.port)
+		res, err := h.CreatePeer(ctx, &protos.CreatePeerRequest{
+			Peer: &protos.Peer{
+				Name:   peerName,
+				Type:   protos.DBType_POSTGRES,
+				Config: &protos.Peer_PostgresConfig{PostgresConfig: nodeConfig},
+			},
+			AllowUpdate: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to create peer for Citus node %s:%d: %w", node.host, node.port, err)
+		} else if res.Status != protos.CreatePeerStatus_CREATED {
+			return nil, fmt.Errorf("unable to create peer for Citus node %s:%d: %s", node.host, node.port, res.Message)
+		}
+		slog.Info("discovered Citus node", slog.String("coordinator", coordinator), slog.String("peer", peerName))
+		peers = append(peers, peerName)
+	}
+	return peers, nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const columnEncryptionKeyLength = 32
+
+// PostColumnEncryptionKey adds a key for encrypting columns of mirrors, generating one when none is given.
+// Generated keys are only returned here so they can be kept outside PeerDB for decrypting values.
+func (h *FlowRequestHandler) PostColumnEncryptionKey(
+	ctx context.Context,
+	req *protos.PostColumnEncryptionKeyRequest,
+) (*protos.PostColumnEncryptionKeyResponse, error) {
+	if req.Name == "" {
+		return nil, errors.New("column encryption key needs a name")
+	}
+	res := &protos.PostColumnEncryptionKeyResponse{}
+	var key []byte
+	if req.Key == "" {
+		key = make([]byte, columnEncryptionKeyLength)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate column encryption key: %w", err)
+		}
+		res.Key = base64.StdEncoding.EncodeToString(key)
+	} else {
+		var err error
+		if key, err = base64.StdEncoding.DecodeString(req.Key); err != nil {
+			return nil, fmt.Errorf("column encryption key must be base64: %w", err)
+		}
+		if len(key) != columnEncryptionKeyLength {
+			return nil, fmt.Errorf("column encryption key must be %d bytes, got %d", columnEncryptionKeyLength, len(key))
+		}
+	}
+	if err := monitoring.AddColumnEncryptionKey(ctx, h.pool, req.Name, key); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListColumnEncryptionKeys returns keys for encrypting columns with the mirrors encrypting columns with them,
+// never the keys themselves
+func (h *FlowRequestHandler) ListColumnEncryptionKeys(
+	ctx context.Context,
+	req *protos.ListColumnEncryptionKeysRequest,
+) (*protos.ListColumnEncryptionKeysResponse, error) {
+	keys, err := monitoring.ListColumnEncryptionKeys(ctx, h.pool)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.pool.Query(ctx, "SELECT name, config_proto FROM flows WHERE coalesce(query_string, '') = ''")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query flows: %w", err)
+	}
+	keyFlows := make(map[string][]string)
+	var flowJobName string
+	var configBytes []byte
+	if _, err := pgx.ForEachRow(rows, []any{&flowJobName, &configBytes}, func() error {
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configBytes, &config); err != nil {
+			return fmt.Errorf("unable to unmarshal config of mirror %s: %w", flowJobName, err)
+		}
+		keyNames := make(map[string]struct{})
+		for _, mapping := range config.TableMappings {
+			for _, encryption := range shared.EncryptedColumns(mapping) {
+				keyNames[encryption.KeyName] = struct{}{}
+			}
+		}
+		for keyName := range keyNames {
+			keyFlows[keyName] = append(keyFlows[keyName], flowJobName)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	res := &protos.ListColumnEncryptionKeysResponse{}
+	for _, name := range slices.Sorted(maps.Keys(keys)) {
+		flowJobNames := keyFlows[name]
+		slices.Sort(flowJobNames)
+		res.Keys = append(res.Keys, &protos.ColumnEncryptionKey{
+			Name:         name,
+			CreatedAt:    timestamppb.New(keys[name]),
+			FlowJobNames: flowJobNames,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// rows sampled per source table to check row size limits
+const destinationLimitsRowSample = 1000
+
+// zero means no limit
+type destinationLimits struct {
+	maxColumns          int
+	maxIdentifierLength int
+	maxRowBytes         int64
+}
+
+var destinationLimitsByType = map[protos.DBType]destinationLimits{
+	protos.DBType_POSTGRES:   {maxColumns: 1600, maxIdentifierLength: 63},
+	protos.DBType_CLICKHOUSE: {maxColumns: 1000},
+	protos.DBType_BIGQUERY:   {maxColumns: 10000, maxIdentifierLength: 300, maxRowBytes: 10 << 20},
+	// rows go through a VARIANT of the raw table
+	protos.DBType_SNOWFLAKE:   {maxIdentifierLength: 255, maxRowBytes: 16 << 20},
+	protos.DBType_FABRIC:      {maxColumns: 1024, maxIdentifierLength: 128},
+	protos.DBType_SINGLESTORE: {maxColumns: 4096, maxIdentifierLength: 64},
+	protos.DBType_STARROCKS:   {maxColumns: 10000, maxIdentifierLength: 64},
+	// SQLITE_MAX_COLUMN of default builds, libSQL included
+	protos.DBType_SQLITE: {maxColumns: 2000},
+	// broker default of message.max.bytes
+	protos.DBType_KAFKA:     {maxRowBytes: 1 << 20},
+	protos.DBType_EVENTHUBS: {maxRowBytes: 1 << 20},
+	protos.DBType_PUBSUB:    {maxRowBytes: 10 << 20},
+	protos.DBType_KINESIS:   {maxRowBytes: 1 << 20},
+}
+
+// validateDestinationLimits reports every table of cfg not fitting hard limits of the destination
+func validateDestinationLimits(
+	ctx context.Context,
+	pgPeer *connpostgres.PostgresConnector,
+	cfg *protos.FlowConnectionConfigs,
+	dstPeerType protos.DBType,
+	tableSchemas map[string]*protos.TableSchema,
+) error {
+	limits, ok := destinationLimitsByType[dstPeerType]
+	if !ok {
+		return nil
+	}
+
+	var violations []error
+	for _, tm := range cfg.TableMappings {
+		schema, ok := tableSchemas[tm.SourceTableIdentifier]
+		if !ok {
+			continue
+		}
+
+		excluded := make(map[string]struct{}, len(tm.Exclude))
+		for _, col := range tm.Exclude {
+			excluded[col] = struct{}{}
+		}
+		renamed := make(map[string]string, len(tm.Columns))
+		for _, col := range tm.Columns {
+			if col.DestinationName != "" {
+				renamed[col.SourceName] = col.DestinationName
+			}
+		}
+
+		numColumns := 0
+		for _, col := range schema.Columns {
+			if _, ok := excluded[col.Name]; ok {
+				continue
+			}
+			numColumns += 1
+			name := getColName(renamed, col.Name)
+			if limits.maxIdentifierLength > 0 && len(name) > limits.maxIdentifierLength {
+				violations = append(violations, fmt.Errorf("column %s of %s is longer than %d characters allowed by %s",
+					name, tm.DestinationTableIdentifier, limits.maxIdentifierLength, dstPeerType))
+			}
+		}
+		if limits.maxColumns > 0 && numColumns > limits.maxColumns {
+			violations = append(violations, fmt.Errorf("%s has %d columns, more than %d allowed by %s",
+				tm.DestinationTableIdentifier, numColumns, limits.maxColumns, dstPeerType))
+		}
+
+		if limits.maxIdentifierLength > 0 {
+			if dstTable, err := utils.ParseSchemaTable(tm.DestinationTableIdentifier); err == nil &&
+				len(dstTable.Table) > limits.maxIdentifierLength {
+				violations = append(violations, fmt.Errorf("table name %s is longer than %d characters allowed by %s",
+					dstTable.Table, limits.maxIdentifierLength, dstPeerType))
+			}
+		}
+
+		if limits.maxRowBytes > 0 {
+			srcTable, err := utils.ParseSchemaTable(tm.SourceTableIdentifier)
+			if err != nil {
+				return fmt.Errorf("invalid source table identifier: %w", err)
+			}
+			rowSize, err := pgPeer.MaxSampledRowSize(ctx, srcTable, destinationLimitsRowSample)
+			if err != nil {
+				return err
+			}
+			if rowSize > limits.maxRowBytes {
+				violations = append(violations, fmt.Errorf("%s has rows of %d bytes, more than %d bytes allowed by %s",
+					tm.SourceTableIdentifier, rowSize, limits.maxRowBytes, dstPeerType))
+			}
+		}
+	}
+	return errors.Join(violations...)
+}
+
+func getColName(overrides map[string]string, name string) string {
+	if newName, ok := overrides[name]; ok {
+		return newName
+	}
+	return name
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// environmentHeader selects the environment a request is for, requests without it are for the catalog
+// of the API itself. The gateway forwards it as is
+const environmentHeader = "x-peerdb-environment"
+
+// environmentRouter serves requests for environments of PEERDB_CATALOG_ENVIRONMENTS with handlers of their own.
+// Mirrors of an environment are started on task queues of its deployment, so only its workers run them
+type environmentRouter struct {
+	handlers map[string]*FlowRequestHandler
+	methods  map[string]grpc.MethodDesc
+}
+
+func newEnvironmentRouter(
+	ctx context.Context,
+	tc client.Client,
+	defaultTaskQueue string,
+	environments []peerdbenv.CatalogEnvironment,
+) (*environmentRouter, error) {
+	router := &environmentRouter{
+		handlers: make(map[string]*FlowRequestHandler, len(environments)),
+		methods:  make(map[string]grpc.MethodDesc, len(protos.FlowService_ServiceDesc.Methods)),
+	}
+	for _, method := range protos.FlowService_ServiceDesc.Methods {
+		router.methods["/"+protos.FlowService_ServiceDesc.ServiceName+"/"+method.MethodName] = method
+	}
+
+	taskQueues := map[string]struct{}{defaultTaskQueue: {}}
+	for _, environment := range environments {
+		if environment.Name == "" {
+			return nil, fmt.Errorf("environment of catalog %s has no name", environment.Host)
+		}
+		if _, ok := router.handlers[environment.Name]; ok {
+			return nil, fmt.Errorf("environment %s configured more than once", environment.Name)
+		}
+		taskQueue := peerdbenv.DeploymentTaskQueueName(environment.DeploymentUID, shared.PeerFlowTaskQueue)
+		if _, ok := taskQueues[taskQueue]; ok {
+			return nil, fmt.Errorf("environment %s shares task queue %s with another environment, "+
+				"workers would run mirrors of both", environment.Name, taskQueue)
+		}
+		taskQueues[taskQueue] = struct{}{}
+
+		catalogPool, err := pgxpool.New(ctx, shared.GetPGConnectionString(environment.PostgresConfig()))
+		if err != nil {
+			return nil, fmt.Errorf("unable to establish connection with catalog of environment %s: %w", environment.Name, err)
+		}
+		if err := catalogPool.Ping(ctx); err != nil {
+			catalogPool.Close()
+			return nil, fmt.Errorf("unable to establish connection with catalog of environment %s: %w", environment.Name, err)
+		}
+		router.handlers[environment.Name] = NewFlowRequestHandler(tc, catalogPool, taskQueue)
+		slog.Info("serving environment", slog.String("environment", environment.Name), slog.String("taskQueue", taskQueue))
+	}
+	return router, nil
+}
+
+// intercept serves requests for an environment with the handler of its catalog,
+// catalog lookups deeper down like of dynamic settings use its catalog too
+func (r *environmentRouter) intercept(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	names := md.Get(environmentHeader)
+	if len(names) == 0 || names[0] == "" {
+		return handler(ctx, req)
+	}
+	method, ok := r.methods[info.FullMethod]
+	if !ok {
+		return handler(ctx, req)
+	}
+	envHandler, ok := r.handlers[names[0]]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown environment %s", names[0])
+	}
+
+	ctx = peerdbenv.WithCatalogPool(ctx, envHandler.pool)
+	return method.Handler(envHandler, ctx, func(v any) error {
+		proto.Merge(v.(proto.Message), req.(proto.Message))
+		return nil
+	}, nil)
+}
+
+func environmentHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, environmentHeader) {
+		return environmentHeader, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
@@ -0,0 +1,355 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// fan-in mirrors replicate every shard peer into the same destination tables,
+// each shard has its own CDC mirror which tags rows with the shard peer as source identifier
+
+type fanInMirror struct {
+	config           *protos.FlowConnectionConfigs
+	discoveryQuery   string
+	citusCoordinator string
+}
+
+type fanInShard struct {
+	peerName string
+	flowName string
+}
+
+// shards needing attention come first when shards of a fan-in mirror disagree
+var fanInStatusPrecedence = []protos.FlowStatus{
+	protos.FlowStatus_STATUS_UNKNOWN,
+	protos.FlowStatus_STATUS_TERMINATING,
+	protos.FlowStatus_STATUS_TERMINATED,
+	protos.FlowStatus_STATUS_PAUSING,
+	protos.FlowStatus_STATUS_PAUSED,
+	protos.FlowStatus_STATUS_SETUP,
+	protos.FlowStatus_STATUS_SNAPSHOT,
+	protos.FlowStatus_STATUS_RUNNING,
+}
+
+func (m *fanInMirror) shardConfig(peerName string) *protos.FlowConnectionConfigs {
+	cfg := proto.Clone(m.config).(*protos.FlowConnectionConfigs)
+	cfg.FlowJobName = m.config.FlowJobName + "_" + peerName
+	cfg.SourceName = peerName
+	// primary keys of distributed tables include the distribution column, so rows of nodes never collide
+	if m.citusCoordinator == "" {
+		cfg.SourceIdentifier = peerName
+	}
+	cfg.FanInMirror = m.config.FlowJobName
+	return cfg
+}
+
+func (h *FlowRequestHandler) CreateFanInMirror(
+	ctx context.Context,
+	req *protos.CreateFanInMirrorRequest,
+) (*protos.CreateFanInMirrorResponse, error) {
+	cfg := req.ConnectionConfigs
+	if cfg == nil {
+		return nil, errors.New("connection configs is nil")
+	}
+	if cfg.Bidirectional {
+		return nil, errors.New("fan-in mirrors cannot be bidirectional")
+	}
+	mirrorExists, err := h.CheckIfMirrorNameExists(ctx, cfg.FlowJobName)
+	if err != nil {
+		return nil, err
+	} else if mirrorExists {
+		return nil, fmt.Errorf("mirror with name %s already exists", cfg.FlowJobName)
+	}
+
+	if req.CitusCoordinator != "" && cfg.DoInitialSnapshot {
+		// every node would snapshot whole distributed tables, each at a different point in time
+		return nil, errors.New("initial snapshot is not supported for Citus fan-in mirrors, " +
+			"copy existing rows from the coordinator with a query replication mirror instead")
+	}
+
+	cfg.SourceName = ""
+	fanIn := &fanInMirror{
+		config:           cfg,
+		discoveryQuery:   req.ShardDiscoveryQuery,
+		citusCoordinator: req.CitusCoordinator,
+	}
+	discoveredPeers, err := h.discoverFanInShards(ctx, fanIn)
+	if err != nil {
+		return nil, err
+	}
+	shardPeers := append(slices.Clone(req.ShardPeers), discoveredPeers...)
+	slices.Sort(shardPeers)
+	shardPeers = slices.Compact(shardPeers)
+	if len(shardPeers) == 0 {
+		return nil, errors.New("fan-in mirror has no shard peers")
+	}
+
+	configBytes, err := proto.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal fan-in mirror config: %w", err)
+	}
+	if _, err := h.pool.Exec(ctx,
+		"INSERT INTO fan_in_mirrors (name, config_proto, shard_discovery_query, citus_coordinator) VALUES ($1, $2, $3, $4)",
+		cfg.FlowJobName, configBytes, req.ShardDiscoveryQuery, req.CitusCoordinator,
+	); err != nil {
+		return nil, fmt.Errorf("unable to create fan-in mirror %s: %w", cfg.FlowJobName, err)
+	}
+
+	shardMirrors, err := h.addFanInShards(ctx, fanIn, shardPeers)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.CreateFanInMirrorResponse{ShardMirrors: shardMirrors}, nil
+}
+
+func (h *FlowRequestHandler) FanInMirrorStatus(
+	ctx context.Context,
+	req *protos.FanInMirrorStatusRequest,
+) (*protos.FanInMirrorStatusResponse, error) {
+	shards, err := h.getFanInShards(ctx, req.FanInMirror)
+	if err != nil {
+		return nil, err
+	}
+
+	shardStatuses := make([]*protos.FanInShardStatus, 0, len(shards))
+	statuses := make([]protos.FlowStatus, 0, len(shards))
+	for _, shard := range shards {
+		status := protos.FlowStatus_STATUS_UNKNOWN
+		if workflowID, err := h.getWorkflowID(ctx, shard.flowName); err != nil {
+			slog.Warn("unable to get workflow of fan-in shard", slog.String("flowName", shard.flowName), slog.Any("error", err))
+		} else if status, err = h.getWorkflowStatus(ctx, workflowID); err != nil {
+			slog.Warn("unable to get status of fan-in shard", slog.String("flowName", shard.flowName), slog.Any("error", err))
+		}
+		statuses = append(statuses, status)
+		shardStatuses = append(shardStatuses, &protos.FanInShardStatus{
+			PeerName:    shard.peerName,
+			FlowJobName: shard.flowName,
+			Status:      status,
+		})
+	}
+
+	status := protos.FlowStatus_STATUS_UNKNOWN
+	for _, candidate := range fanInStatusPrecedence {
+		if slices.Contains(statuses, candidate) {
+			status = candidate
+			break
+		}
+	}
+	return &protos.FanInMirrorStatusResponse{
+		Status: status,
+		Shards: shardStatuses,
+	}, nil
+}
+
+func (h *FlowRequestHandler) AddFanInShard(
+	ctx context.Context,
+	req *protos.AddFanInShardRequest,
+) (*protos.AddFanInShardResponse, error) {
+	fanIn, err := h.getFanInMirror(ctx, req.FanInMirror)
+	if err != nil {
+		return nil, err
+	} else if fanIn == nil {
+		return nil, fmt.Errorf("fan-in mirror %s does not exist", req.FanInMirror)
+	}
+	shards, err := h.getFanInShards(ctx, req.FanInMirror)
+	if err != nil {
+		return nil, err
+	}
+
+	var shardPeers []string
+	if req.PeerName != "" {
+		shardPeers = []string{req.PeerName}
+	} else if fanIn.discoveryQuery == "" && fanIn.citusCoordinator == "" {
+		return nil, fmt.Errorf("fan-in mirror %s has no shard discovery, specify the peer to add", req.FanInMirror)
+	} else if shardPeers, err = h.discoverFanInShards(ctx, fanIn); err != nil {
+		return nil, err
+	}
+
+	newPeers := make([]string, 0, len(shardPeers))
+	for _, peerName := range shardPeers {
+		if !slices.ContainsFunc(shards, func(shard fanInShard) bool { return shard.peerName == peerName }) &&
+			!slices.Contains(newPeers, peerName) {
+			newPeers = append(newPeers, peerName)
+		}
+	}
+	if req.PeerName != "" && len(newPeers) == 0 {
+		return nil, fmt.Errorf("peer %s is already a shard of fan-in mirror %s", req.PeerName, req.FanInMirror)
+	}
+
+	shardMirrors, err := h.addFanInShards(ctx, fanIn, newPeers)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.AddFanInShardResponse{ShardMirrors: shardMirrors}, nil
+}
+
+func (h *FlowRequestHandler) RemoveFanInShard(
+	ctx context.Context,
+	req *protos.RemoveFanInShardRequest,
+) (*protos.RemoveFanInShardResponse, error) {
+	shards, err := h.getFanInShards(ctx, req.FanInMirror)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(shards, func(shard fanInShard) bool { return shard.peerName == req.PeerName })
+	if idx == -1 {
+		return nil, fmt.Errorf("peer %s is not a shard of fan-in mirror %s", req.PeerName, req.FanInMirror)
+	}
+
+	if _, err := h.flowStateChange(ctx, &protos.FlowStateChangeRequest{
+		FlowJobName:        shards[idx].flowName,
+		RequestedFlowState: protos.FlowStatus_STATUS_TERMINATED,
+		DropMirrorStats:    req.DropMirrorStats,
+	}); err != nil {
+		return nil, err
+	}
+	if err := h.removeFanInShardEntry(ctx, shards[idx].flowName); err != nil {
+		return nil, err
+	}
+	return &protos.RemoveFanInShardResponse{Ok: true}, nil
+}
+
+// fanInStateChange applies a state change of a fan-in mirror to every shard
+func (h *FlowRequestHandler) fanInStateChange(
+	ctx context.Context,
+	req *protos.FlowStateChangeRequest,
+) (*protos.FlowStateChangeResponse, error) {
+	shards, err := h.getFanInShards(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	for _, shard := range shards {
+		if _, err := h.flowStateChange(ctx, &protos.FlowStateChangeRequest{
+			FlowJobName:        shard.flowName,
+			RequestedFlowState: req.RequestedFlowState,
+			FlowConfigUpdate:   req.FlowConfigUpdate,
+			DropMirrorStats:    req.DropMirrorStats,
+		}); err != nil {
+			return nil, fmt.Errorf("unable to change state of fan-in shard %s: %w", shard.peerName, err)
+		}
+		if req.RequestedFlowState == protos.FlowStatus_STATUS_TERMINATED {
+			if err := h.removeFanInShardEntry(ctx, shard.flowName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if req.RequestedFlowState == protos.FlowStatus_STATUS_TERMINATED {
+		if _, err := h.pool.Exec(ctx, "DELETE FROM fan_in_mirrors WHERE name=$1", req.FlowJobName); err != nil {
+			return nil, fmt.Errorf("unable to remove fan-in mirror entry in catalog: %w", err)
+		}
+	}
+	return &protos.FlowStateChangeResponse{
+		Ok: true,
+	}, nil
+}
+
+func (h *FlowRequestHandler) addFanInShards(
+	ctx context.Context,
+	fanIn *fanInMirror,
+	shardPeers []string,
+) ([]string, error) {
+	shardMirrors := make([]string, 0, len(shardPeers))
+	for _, peerName := range shardPeers {
+		cfg := fanIn.shardConfig(peerName)
+		if _, err := h.CreateCDCFlow(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: cfg}); err != nil {
+			slog.Error("unable to create fan-in shard mirror", slog.String("flowName", cfg.FlowJobName), slog.Any("error", err))
+			return shardMirrors, fmt.Errorf("unable to create mirror for shard %s: %w", peerName, err)
+		}
+		if _, err := h.pool.Exec(ctx,
+			"INSERT INTO fan_in_shards (fan_in_mirror, peer_name, flow_name) VALUES ($1, $2, $3)",
+			fanIn.config.FlowJobName, peerName, cfg.FlowJobName,
+		); err != nil {
+			return shardMirrors, fmt.Errorf("unable to add shard %s to fan-in mirror: %w", peerName, err)
+		}
+		shardMirrors = append(shardMirrors, cfg.FlowJobName)
+	}
+	return shardMirrors, nil
+}
+
+// discoverFanInShards returns shard peers found by the discovery query and Citus coordinator of fanIn
+func (h *FlowRequestHandler) discoverFanInShards(ctx context.Context, fanIn *fanInMirror) ([]string, error) {
+	var shardPeers []string
+	if fanIn.discoveryQuery != "" {
+		peers, err := h.runShardDiscoveryQuery(ctx, fanIn.discoveryQuery)
+		if err != nil {
+			return nil, err
+		}
+		shardPeers = append(shardPeers, peers...)
+	}
+	if fanIn.citusCoordinator != "" {
+		peers, err := h.discoverCitusNodes(ctx, fanIn.citusCoordinator)
+		if err != nil {
+			return nil, err
+		}
+		shardPeers = append(shardPeers, peers...)
+	}
+	return shardPeers, nil
+}
+
+// runShardDiscoveryQuery runs query against catalog in a read-only transaction, every row is a shard peer name
+func (h *FlowRequestHandler) runShardDiscoveryQuery(ctx context.Context, query string) ([]string, error) {
+	tx, err := h.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("unable to begin shard discovery transaction: %w", err)
+	}
+	defer shared.RollbackTx(tx, slog.Default())
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to run shard discovery query: %w", err)
+	}
+	peers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("unable to read shard discovery query results: %w", err)
+	}
+	return peers, nil
+}
+
+// getFanInMirror returns nil when flowJobName is not a fan-in mirror
+func (h *FlowRequestHandler) getFanInMirror(ctx context.Context, flowJobName string) (*fanInMirror, error) {
+	var configBytes []byte
+	fanIn := &fanInMirror{config: &protos.FlowConnectionConfigs{}}
+	if err := h.pool.QueryRow(ctx,
+		"SELECT config_proto, shard_discovery_query, citus_coordinator FROM fan_in_mirrors WHERE name=$1", flowJobName,
+	).Scan(&configBytes, &fanIn.discoveryQuery, &fanIn.citusCoordinator); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to query fan-in mirror from catalog: %w", err)
+	}
+
+	if err := proto.Unmarshal(configBytes, fanIn.config); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal fan-in mirror config: %w", err)
+	}
+	return fanIn, nil
+}
+
+func (h *FlowRequestHandler) getFanInShards(ctx context.Context, fanInMirror string) ([]fanInShard, error) {
+	rows, err := h.pool.Query(ctx,
+		"SELECT peer_name, flow_name FROM fan_in_shards WHERE fan_in_mirror=$1 ORDER BY peer_name", fanInMirror)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query fan-in shards: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (fanInShard, error) {
+		var shard fanInShard
+		err := row.Scan(&shard.peerName, &shard.flowName)
+		return shard, err
+	})
+}
+
+func (h *FlowRequestHandler) removeFanInShardEntry(ctx context.Context, flowName string) error {
+	if _, err := h.pool.Exec(ctx, "DELETE FROM fan_in_shards WHERE flow_name=$1", flowName); err != nil {
+		return fmt.Errorf("unable to remove fan-in shard entry in catalog: %w", err)
+	}
+	return nil
+}
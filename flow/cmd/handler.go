@@ -127,6 +127,14 @@ func (h *FlowRequestHandler) CreateCDCFlow(
 	ctx context.Context, req *protos.CreateCDCFlowRequest,
 ) (*protos.CreateCDCFlowResponse, error) {
 	cfg := req.ConnectionConfigs
+	if cfg.ShadowMode {
+		shared.ApplyShadowMode(cfg.FlowJobName, cfg.TableMappings)
+	}
+
+	var reverseReq *protos.CreateCDCFlowRequest
+	if cfg.Bidirectional && !cfg.Resync {
+		reverseReq = &protos.CreateCDCFlowRequest{ConnectionConfigs: setupBidirectionalMirror(cfg)}
+	}
 
 	// For resync, we validate the mirror before dropping it and getting to this step.
 	// There is no point validating again here if it's a resync - the mirror is dropped already
@@ -136,8 +144,31 @@ func (h *FlowRequestHandler) CreateCDCFlow(
 			slog.Error("validate mirror error", slog.Any("error", validateErr))
 			return nil, fmt.Errorf("invalid mirror: %w", validateErr)
 		}
+		if reverseReq != nil {
+			if _, validateErr := h.ValidateCDCMirror(ctx, reverseReq); validateErr != nil {
+				slog.Error("validate reverse mirror error", slog.Any("error", validateErr))
+				return nil, fmt.Errorf("invalid reverse mirror: %w", validateErr)
+			}
+		}
+	}
+
+	workflowID, err := h.startCDCFlow(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if reverseReq != nil {
+		if _, err := h.startCDCFlow(ctx, reverseReq); err != nil {
+			return nil, fmt.Errorf("unable to start reverse mirror: %w", err)
+		}
 	}
 
+	return &protos.CreateCDCFlowResponse{
+		WorkflowId: workflowID,
+	}, nil
+}
+
+func (h *FlowRequestHandler) startCDCFlow(ctx context.Context, req *protos.CreateCDCFlowRequest) (string, error) {
+	cfg := req.ConnectionConfigs
 	workflowID := fmt.Sprintf("%s-peerflow-%s", cfg.FlowJobName, uuid.New())
 	workflowOptions := client.StartWorkflowOptions{
 		ID:        workflowID,
@@ -150,24 +181,27 @@ func (h *FlowRequestHandler) CreateCDCFlow(
 	err := h.createCdcJobEntry(ctx, req, workflowID)
 	if err != nil {
 		slog.Error("unable to create flow job entry", slog.Any("error", err))
-		return nil, fmt.Errorf("unable to create flow job entry: %w", err)
+		return "", fmt.Errorf("unable to create flow job entry: %w", err)
 	}
 
 	err = h.updateFlowConfigInCatalog(ctx, cfg)
 	if err != nil {
 		slog.Error("unable to update flow config in catalog", slog.Any("error", err))
-		return nil, fmt.Errorf("unable to update flow config in catalog: %w", err)
+		return "", fmt.Errorf("unable to update flow config in catalog: %w", err)
 	}
 
 	_, err = h.temporalClient.ExecuteWorkflow(ctx, workflowOptions, peerflow.CDCFlowWorkflow, cfg, nil)
 	if err != nil {
 		slog.Error("unable to start PeerFlow workflow", slog.Any("error", err))
-		return nil, fmt.Errorf("unable to start PeerFlow workflow: %w", err)
+		return "", fmt.Errorf("unable to start PeerFlow workflow: %w", err)
 	}
+	h.appendMirrorEvent(ctx, &protos.MirrorEvent{
+		FlowJobName: cfg.FlowJobName,
+		EventType:   protos.MirrorEventType_MIRROR_EVENT_CREATED,
+		Message:     "cdc mirror created",
+	})
 
-	return &protos.CreateCDCFlowResponse{
-		WorkflowId: workflowID,
-	}, nil
+	return workflowID, nil
 }
 
 func (h *FlowRequestHandler) updateFlowConfigInCatalog(
@@ -193,6 +227,17 @@ func (h *FlowRequestHandler) CreateQRepFlow(
 	ctx context.Context, req *protos.CreateQRepFlowRequest,
 ) (*protos.CreateQRepFlowResponse, error) {
 	cfg := req.QrepConfig
+	if err := shared.ValidateMirrorLabels(cfg.Labels); err != nil {
+		return nil, fmt.Errorf("invalid mirror labels: %w", err)
+	}
+	defaults, err := h.loadPeerMirrorDefaults(ctx, cfg.DestinationName)
+	if err != nil {
+		return nil, err
+	}
+	shared.ApplyQRepMirrorDefaults(cfg, defaults)
+	if err := h.validateStagingRegions(ctx, cfg.Env, cfg.DestinationName, cfg.StagingPath); err != nil {
+		return nil, err
+	}
 	workflowID := fmt.Sprintf("%s-qrepflow-%s", cfg.FlowJobName, uuid.New())
 	workflowOptions := client.StartWorkflowOptions{
 		ID:        workflowID,
@@ -219,7 +264,7 @@ func (h *FlowRequestHandler) CreateQRepFlow(
 		workflowFn = peerflow.QRepFlowWorkflow
 	}
 
-	if req.QrepConfig.SyncedAtColName == "" {
+	if cfg.SyncedAtColName == "" {
 		cfg.SyncedAtColName = "_PEERDB_SYNCED_AT"
 	}
 
@@ -230,6 +275,11 @@ func (h *FlowRequestHandler) CreateQRepFlow(
 			slog.Any("error", err), slog.String("flowName", cfg.FlowJobName))
 		return nil, fmt.Errorf("unable to start QRepFlow workflow: %w", err)
 	}
+	h.appendMirrorEvent(ctx, &protos.MirrorEvent{
+		FlowJobName: cfg.FlowJobName,
+		EventType:   protos.MirrorEventType_MIRROR_EVENT_CREATED,
+		Message:     "qrep mirror created",
+	})
 
 	err = h.updateQRepConfigInCatalog(ctx, cfg)
 	if err != nil {
@@ -358,6 +408,52 @@ func (h *FlowRequestHandler) shutdownFlow(
 func (h *FlowRequestHandler) FlowStateChange(
 	ctx context.Context,
 	req *protos.FlowStateChangeRequest,
+) (*protos.FlowStateChangeResponse, error) {
+	fanIn, err := h.getFanInMirror(ctx, req.FlowJobName)
+	if err != nil {
+		slog.Error("[flow-state-change]unable to get fan-in mirror", slog.Any("error", err))
+		return nil, err
+	} else if fanIn != nil {
+		return h.fanInStateChange(ctx, req)
+	}
+
+	// both mirrors of a bidirectional pair are paused, resumed and dropped together
+	var bidirectionalMirror string
+	if req.RequestedFlowState != protos.FlowStatus_STATUS_UNKNOWN {
+		bidirectionalMirror, err = h.getBidirectionalMirror(ctx, req.FlowJobName)
+		if err != nil {
+			slog.Error("[flow-state-change]unable to get bidirectional mirror", slog.Any("error", err))
+			return nil, err
+		}
+	}
+
+	res, err := h.flowStateChange(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.RequestedFlowState == protos.FlowStatus_STATUS_TERMINATED {
+		if err := h.removeFanInShardEntry(ctx, req.FlowJobName); err != nil {
+			return nil, err
+		}
+	}
+	// mirrors depending on this one follow it when it is paused or resumed
+	if err := h.propagateStateChange(ctx, req.FlowJobName, req.RequestedFlowState); err != nil {
+		slog.Error("[flow-state-change]unable to propagate state change to dependent mirrors", slog.Any("error", err))
+		return nil, err
+	}
+	if bidirectionalMirror == "" {
+		return res, nil
+	}
+	return h.flowStateChange(ctx, &protos.FlowStateChangeRequest{
+		FlowJobName:        bidirectionalMirror,
+		RequestedFlowState: req.RequestedFlowState,
+		DropMirrorStats:    req.DropMirrorStats,
+	})
+}
+
+func (h *FlowRequestHandler) flowStateChange(
+	ctx context.Context,
+	req *protos.FlowStateChangeRequest,
 ) (*protos.FlowStateChangeResponse, error) {
 	slog.Info("FlowStateChange called", slog.String("flowJobName", req.FlowJobName), slog.Any("req", req))
 	workflowID, err := h.getWorkflowID(ctx, req.FlowJobName)
@@ -421,6 +517,17 @@ func (h *FlowRequestHandler) FlowStateChange(
 			return nil, fmt.Errorf("unable to signal workflow: %w", err)
 		}
 	}
+	// events of mirrors dropped along with their stats would outlive them
+	requested := req.RequestedFlowState != protos.FlowStatus_STATUS_UNKNOWN && req.RequestedFlowState != currState
+	dropsStats := req.RequestedFlowState == protos.FlowStatus_STATUS_TERMINATED && req.DropMirrorStats
+	if (req.FlowConfigUpdate != nil || requested) && !dropsStats {
+		h.appendMirrorEvent(ctx, &protos.MirrorEvent{
+			FlowJobName: req.FlowJobName,
+			EventType:   protos.MirrorEventType_MIRROR_EVENT_STATE_CHANGE_REQUESTED,
+			FlowStatus:  req.RequestedFlowState,
+			Message:     fmt.Sprintf("requested %s while %s", req.RequestedFlowState, currState),
+		})
+	}
 
 	return &protos.FlowStateChangeResponse{
 		Ok: true,
@@ -479,6 +586,46 @@ func (h *FlowRequestHandler) CreatePeer(
 	return utils.CreatePeerNoValidate(ctx, h.pool, req.Peer, req.AllowUpdate)
 }
 
+// ImportPeer validates the peer a connection URI describes, creating it when asked to
+func (h *FlowRequestHandler) ImportPeer(
+	ctx context.Context,
+	req *protos.ImportPeerRequest,
+) (*protos.ImportPeerResponse, error) {
+	peer, err := shared.PeerFromURI(req.Name, req.Uri)
+	if err != nil {
+		return &protos.ImportPeerResponse{
+			Status:  protos.ValidatePeerStatus_INVALID,
+			Message: err.Error(),
+		}, nil
+	}
+
+	if req.Create {
+		created, err := h.CreatePeer(ctx, &protos.CreatePeerRequest{Peer: peer, AllowUpdate: req.AllowUpdate})
+		if err != nil {
+			return nil, err
+		}
+		status := protos.ValidatePeerStatus_INVALID
+		if created.Status == protos.CreatePeerStatus_CREATED {
+			status = protos.ValidatePeerStatus_VALID
+		}
+		return &protos.ImportPeerResponse{
+			Peer:    peer,
+			Status:  status,
+			Message: created.Message,
+		}, nil
+	}
+
+	validated, err := h.ValidatePeer(ctx, &protos.ValidatePeerRequest{Peer: peer})
+	if err != nil {
+		return nil, err
+	}
+	return &protos.ImportPeerResponse{
+		Peer:    peer,
+		Status:  validated.Status,
+		Message: validated.Message,
+	}, nil
+}
+
 func (h *FlowRequestHandler) DropPeer(
 	ctx context.Context,
 	req *protos.DropPeerRequest,
@@ -558,6 +705,9 @@ func (h *FlowRequestHandler) ResyncMirror(
 	if err != nil {
 		return nil, err
 	}
+	if config.BidirectionalMirror != "" {
+		return nil, errors.New("resync is not supported for bidirectional mirrors, drop and recreate them instead")
+	}
 
 	config.Resync = true
 	config.DoInitialSnapshot = true
@@ -584,3 +734,31 @@ func (h *FlowRequestHandler) ResyncMirror(
 		Ok: true,
 	}, nil
 }
+
+// MigrateMirrorWorkflow has a CDC mirror continue as new, so it runs the workflow definition of the current workers
+// and stops recording default versions of workflow.GetVersion gates
+func (h *FlowRequestHandler) MigrateMirrorWorkflow(
+	ctx context.Context,
+	req *protos.MigrateMirrorWorkflowRequest,
+) (*protos.MigrateMirrorWorkflowResponse, error) {
+	isCDC, err := h.isCDCFlow(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	if !isCDC {
+		// query replication continues as new after every run already
+		return nil, errors.New("workflow migration is only supported for CDC mirrors")
+	}
+	workflowID, err := h.getWorkflowID(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("migrating mirror workflow", slog.String("flowJobName", req.FlowJobName))
+	if err := model.MigrateWorkflowSignal.SignalClientWorkflow(ctx, h.temporalClient, workflowID, "", struct{}{}); err != nil {
+		return nil, fmt.Errorf("unable to signal workflow: %w", err)
+	}
+	return &protos.MigrateMirrorWorkflowResponse{
+		Ok: true,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// mirrorDependencyGraph returns the mirrors each CDC mirror depends on, for those depending on any
+func (h *FlowRequestHandler) mirrorDependencyGraph(ctx context.Context) (map[string][]string, error) {
+	rows, err := h.pool.Query(ctx,
+		"SELECT name, config_proto FROM flows WHERE coalesce(query_string, '') = '' AND config_proto IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query mirror configs: %w", err)
+	}
+	dependsOn := make(map[string][]string)
+	var name string
+	var configProto []byte
+	if _, err := pgx.ForEachRow(rows, []any{&name, &configProto}, func() error {
+		var config protos.FlowConnectionConfigs
+		if err := proto.Unmarshal(configProto, &config); err != nil {
+			return fmt.Errorf("unable to unmarshal config of mirror %s: %w", name, err)
+		}
+		if len(config.DependsOn) > 0 {
+			dependsOn[name] = config.DependsOn
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to read mirror configs: %w", err)
+	}
+	return dependsOn, nil
+}
+
+// validateMirrorDependencies checks mirrors cfg depends on are existing CDC mirrors,
+// none of which depends on cfg in turn
+func (h *FlowRequestHandler) validateMirrorDependencies(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	if len(cfg.DependsOn) == 0 {
+		return nil
+	}
+	for i, dependency := range cfg.DependsOn {
+		if dependency == cfg.FlowJobName {
+			return fmt.Errorf("mirror %s can't depend on itself", dependency)
+		}
+		if slices.Contains(cfg.DependsOn[:i], dependency) {
+			return fmt.Errorf("mirror %s is depended on more than once", dependency)
+		}
+		isCDC, err := h.isCDCFlow(ctx, dependency)
+		if err != nil {
+			return err
+		}
+		if !isCDC {
+			return fmt.Errorf("mirror %s depended on is not an existing CDC mirror", dependency)
+		}
+	}
+	dependsOn, err := h.mirrorDependencyGraph(ctx)
+	if err != nil {
+		return err
+	}
+	dependsOn[cfg.FlowJobName] = cfg.DependsOn
+	if cycle := shared.MirrorDependencyCycle(dependsOn, cfg.FlowJobName); cycle != nil {
+		return fmt.Errorf("mirror dependencies form a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// propagateStateChange pauses or resumes mirrors depending on flowJobName along with it, dependencies first.
+// Mirrors still snapshotting or already in the requested state are left alone,
+// as are mirrors that stay paused because another of their dependencies is
+func (h *FlowRequestHandler) propagateStateChange(
+	ctx context.Context,
+	flowJobName string,
+	requested protos.FlowStatus,
+) error {
+	if requested != protos.FlowStatus_STATUS_PAUSED && requested != protos.FlowStatus_STATUS_RUNNING {
+		return nil
+	}
+	dependsOn, err := h.mirrorDependencyGraph(ctx)
+	if err != nil {
+		return err
+	}
+	dependents := shared.MirrorDependents(dependsOn, flowJobName)
+	if len(dependents) == 0 {
+		return nil
+	}
+
+	// signals are processed asynchronously, so statuses of mirrors resumed here can still read paused
+	resumed := map[string]struct{}{flowJobName: {}}
+	stillPaused := func(mirror string) (bool, error) {
+		if _, ok := resumed[mirror]; ok {
+			return false, nil
+		}
+		status, err := h.mirrorStatus(ctx, mirror)
+		return status == protos.FlowStatus_STATUS_PAUSED || status == protos.FlowStatus_STATUS_PAUSING, err
+	}
+
+	for _, dependent := range dependents {
+		status, err := h.mirrorStatus(ctx, dependent)
+		if err != nil {
+			return err
+		}
+		switch requested {
+		case protos.FlowStatus_STATUS_PAUSED:
+			if status != protos.FlowStatus_STATUS_RUNNING {
+				continue
+			}
+		case protos.FlowStatus_STATUS_RUNNING:
+			if status != protos.FlowStatus_STATUS_PAUSED {
+				continue
+			}
+			blocked := false
+			for _, dependency := range dependsOn[dependent] {
+				paused, err := stillPaused(dependency)
+				if err != nil {
+					return err
+				}
+				if paused {
+					slog.Info("mirror stays paused as a mirror it depends on is paused",
+						slog.String("flowJobName", dependent), slog.String("dependency", dependency))
+					blocked = true
+					break
+				}
+			}
+			if blocked {
+				continue
+			}
+			resumed[dependent] = struct{}{}
+		}
+		slog.Info("propagating state change to dependent mirror", slog.String("flowJobName", flowJobName),
+			slog.String("dependent", dependent), slog.Any("requestedFlowState", requested))
+		if _, err := h.flowStateChange(ctx, &protos.FlowStateChangeRequest{
+			FlowJobName:        dependent,
+			RequestedFlowState: requested,
+		}); err != nil {
+			return fmt.Errorf("unable to change state of dependent mirror %s: %w", dependent, err)
+		}
+	}
+	return nil
+}
+
+// mirrorStatus is the status of mirror, terminated once it was dropped
+func (h *FlowRequestHandler) mirrorStatus(ctx context.Context, mirror string) (protos.FlowStatus, error) {
+	exists, err := h.isCDCFlow(ctx, mirror)
+	if err != nil {
+		return protos.FlowStatus_STATUS_UNKNOWN, err
+	}
+	if !exists {
+		return protos.FlowStatus_STATUS_TERMINATED, nil
+	}
+	workflowID, err := h.getWorkflowID(ctx, mirror)
+	if err != nil {
+		return protos.FlowStatus_STATUS_UNKNOWN, err
+	}
+	return h.getWorkflowStatus(ctx, workflowID)
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// rough bytes of destination storage per byte of Postgres heap, columnar destinations compress rows,
+// BigQuery bills logical bytes uncompressed
+var destinationStorageRatio = map[protos.DBType]float64{
+	protos.DBType_SNOWFLAKE:  0.3,
+	protos.DBType_CLICKHOUSE: 0.2,
+	protos.DBType_BIGQUERY:   1,
+	protos.DBType_STARROCKS:  0.3,
+	protos.DBType_FABRIC:     0.3,
+}
+
+// Snowflake bills warehouses at least a minute per resume, X-Small warehouses are a credit per hour
+const snowflakeMinimumResumeSeconds = 60
+
+// EstimateMirrorCost estimates storage, batch frequency and warehouse compute of a CDC mirror from a Postgres source,
+// sampling WAL written and rows changed for a few seconds
+func (h *FlowRequestHandler) EstimateMirrorCost(
+	ctx context.Context,
+	req *protos.EstimateMirrorCostRequest,
+) (*protos.EstimateMirrorCostResponse, error) {
+	if req.ConnectionConfigs == nil {
+		return nil, errors.New("connection configs is nil")
+	}
+	cfg := shared.CloneProto(req.ConnectionConfigs)
+	mirrorDefaults, err := h.loadPeerMirrorDefaults(ctx, cfg.DestinationName)
+	if err != nil {
+		return nil, err
+	}
+	shared.ApplyMirrorDefaults(cfg, mirrorDefaults)
+
+	sourceTables := make([]*utils.SchemaTable, 0, len(cfg.TableMappings))
+	for _, tableMapping := range cfg.TableMappings {
+		parsedTable, err := utils.ParseSchemaTable(tableMapping.SourceTableIdentifier)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source table identifier: %w", err)
+		}
+		sourceTables = append(sourceTables, parsedTable)
+	}
+	if len(sourceTables) == 0 {
+		return nil, errors.New("no tables to estimate")
+	}
+
+	sourcePeer, err := connectors.LoadPeer(ctx, h.pool, cfg.SourceName)
+	if err != nil {
+		return nil, err
+	}
+	sourcePeerConfig := sourcePeer.GetPostgresConfig()
+	if sourcePeerConfig == nil {
+		return nil, errors.New("estimates are only supported for postgres sources")
+	}
+	dstPeer, err := connectors.LoadPeer(ctx, h.pool, cfg.DestinationName)
+	if err != nil {
+		return nil, err
+	}
+
+	pgPeer, err := connpostgres.NewPostgresConnector(ctx, sourcePeerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create postgres connector: %w", err)
+	}
+	defer pgPeer.Close()
+
+	sampleSeconds := req.SampleSeconds
+	if sampleSeconds == 0 {
+		sampleSeconds = 10
+	}
+	activity, err := pgPeer.SampleActivity(ctx, sourceTables, time.Duration(min(sampleSeconds, 60))*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample activity of source: %w", err)
+	}
+
+	return estimateMirrorCost(cfg, dstPeer.Type, sourceTables, activity), nil
+}
+
+func estimateMirrorCost(
+	cfg *protos.FlowConnectionConfigs,
+	dstPeerType protos.DBType,
+	sourceTables []*utils.SchemaTable,
+	activity *connpostgres.SourceActivity,
+) *protos.EstimateMirrorCostResponse {
+	window := activity.Window.Seconds()
+	res := &protos.EstimateMirrorCostResponse{
+		Assumptions: []string{
+			fmt.Sprintf("changes sampled over %.0f seconds are typical of the source", window),
+			"rows and bytes of tables are from statistics of the source, as of their last analyze",
+		},
+	}
+
+	var changes, netRowGrowth float64
+	for i, table := range sourceTables {
+		tableActivity := activity.Tables[*table]
+		res.Tables = append(res.Tables, &protos.TableCostEstimate{
+			SourceTableIdentifier: cfg.TableMappings[i].SourceTableIdentifier,
+			Rows:                  tableActivity.Rows,
+			Bytes:                 tableActivity.Bytes,
+			ChangesPerSecond:      float64(tableActivity.Changes()) / window,
+		})
+		if cfg.DoInitialSnapshot {
+			res.SnapshotBytes += tableActivity.Bytes
+		}
+		changes += float64(tableActivity.Changes())
+		if tableActivity.Rows > 0 {
+			bytesPerRow := float64(tableActivity.Bytes) / float64(tableActivity.Rows)
+			netRowGrowth += float64(tableActivity.Inserts-tableActivity.Deletes) * bytesPerRow
+		}
+	}
+	res.ChangesPerSecond = changes / window
+	if activity.Changes > 0 {
+		share := min(changes/float64(activity.Changes), 1)
+		res.ChangeBytesPerDay = int64(float64(activity.WALBytes) / window * share * 86400)
+	}
+
+	storageRatio, ok := destinationStorageRatio[dstPeerType]
+	if !ok {
+		storageRatio = 1
+	}
+	res.DestinationBytes = int64(float64(res.SnapshotBytes) * storageRatio)
+	res.DestinationBytesMonth = max(res.DestinationBytes+int64(netRowGrowth/window*86400*30*storageRatio), 0)
+	res.Assumptions = append(res.Assumptions,
+		fmt.Sprintf("destination tables take %.1f bytes per byte of source table", storageRatio))
+
+	if res.ChangesPerSecond > 0 {
+		batchSize := cfg.MaxBatchSize
+		if batchSize == 0 {
+			batchSize = 1_000_000
+		}
+		idleTimeout := peerdbenv.PeerDBCDCIdleTimeoutSeconds(int(cfg.IdleTimeoutSeconds)).Seconds()
+		batchSeconds := min(idleTimeout, float64(batchSize)/res.ChangesPerSecond)
+		res.BatchesPerHour = 3600 / max(batchSeconds, 1)
+	}
+
+	switch dstPeerType {
+	case protos.DBType_SNOWFLAKE:
+		resumedHoursPerDay := min(res.BatchesPerHour*snowflakeMinimumResumeSeconds/3600, 1) * 24
+		res.SnowflakeCreditsPerDay = resumedHoursPerDay
+		res.Assumptions = append(res.Assumptions,
+			"each normalize resumes an X-Small warehouse for its minimum billed minute, larger warehouses multiply credits")
+	case protos.DBType_BIGQUERY:
+		res.BigqueryBytesPerDay = int64(res.BatchesPerHour * 24 * float64(res.DestinationBytes))
+		res.Assumptions = append(res.Assumptions,
+			"each normalize merges into whole destination tables, partitioned and clustered tables scan less")
+	}
+	return res
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// statuses live in workflows rather than the catalog, so they're checked this often for changes
+const mirrorEventsStatusInterval = 5 * time.Second
+
+// appendMirrorEvent keeps an event for subscribers, failing to do so doesn't fail the request it's about
+func (h *FlowRequestHandler) appendMirrorEvent(ctx context.Context, event *protos.MirrorEvent) {
+	if err := monitoring.AppendMirrorEvent(ctx, h.pool, event); err != nil {
+		slog.Warn("failed to append mirror event", slog.String(string(shared.FlowNameKey), event.FlowJobName),
+			slog.Any("error", err))
+	}
+}
+
+// StreamMirrorEvents pushes events of mirrors as they happen until the subscriber goes away,
+// kept events after the requested id are replayed first
+func (h *FlowRequestHandler) StreamMirrorEvents(
+	req *protos.StreamMirrorEventsRequest,
+	stream protos.FlowService_StreamMirrorEventsServer,
+) error {
+	ctx := stream.Context()
+	conn, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to acquire catalog connection: %w", err)
+	}
+	defer func() {
+		// connection goes back to the pool, so it mustn't keep listening
+		if _, err := conn.Exec(context.Background(), "UNLISTEN *"); err != nil {
+			slog.Warn("failed to unlisten mirror events", slog.Any("error", err))
+		}
+		conn.Release()
+	}()
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{monitoring.MirrorEventsChannel}.Sanitize()); err != nil {
+		return fmt.Errorf("unable to listen for mirror events: %w", err)
+	}
+
+	// listening before reading the last id means no event appended in between is missed
+	lastID := req.AfterId
+	if lastID == 0 {
+		if lastID, err = monitoring.GetLastMirrorEventID(ctx, h.pool); err != nil {
+			return err
+		}
+	}
+
+	statuses := make(map[string]protos.FlowStatus)
+	nextStatusCheck := time.Now()
+	for {
+		for {
+			events, err := monitoring.GetMirrorEvents(ctx, h.pool, req.FlowJobNames, lastID)
+			if err != nil {
+				return err
+			}
+			for _, event := range events {
+				if err := stream.Send(event); err != nil {
+					return err
+				}
+				lastID = event.Id
+			}
+			if len(events) < 1000 {
+				break
+			}
+		}
+
+		if !time.Now().Before(nextStatusCheck) {
+			if err := h.sendStatusChanges(ctx, stream, req.FlowJobNames, statuses); err != nil {
+				return err
+			}
+			nextStatusCheck = time.Now().Add(mirrorEventsStatusInterval)
+		}
+
+		waitCtx, cancel := context.WithDeadline(ctx, nextStatusCheck)
+		_, err := conn.Conn().WaitForNotification(waitCtx)
+		cancel()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		} else if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("error while waiting for mirror events: %w", err)
+		}
+	}
+}
+
+// sendStatusChanges sends statuses of mirrors which changed since they were last checked,
+// the first check of a mirror sends its current status
+func (h *FlowRequestHandler) sendStatusChanges(
+	ctx context.Context,
+	stream protos.FlowService_StreamMirrorEventsServer,
+	flowJobNames []string,
+	statuses map[string]protos.FlowStatus,
+) error {
+	rows, err := h.pool.Query(ctx, `SELECT name, workflow_id FROM flows
+		WHERE workflow_id IS NOT NULL AND (cardinality($1::text[]) = 0 OR name = ANY($1))`, flowJobNames)
+	if err != nil {
+		return fmt.Errorf("unable to list mirrors: %w", err)
+	}
+	var name, workflowID string
+	workflowIDs := make(map[string]string)
+	if _, err := pgx.ForEachRow(rows, []any{&name, &workflowID}, func() error {
+		workflowIDs[name] = workflowID
+		return nil
+	}); err != nil {
+		return fmt.Errorf("unable to list mirrors: %w", err)
+	}
+
+	for flowName, workflowID := range workflowIDs {
+		status, err := h.getWorkflowStatus(ctx, workflowID)
+		if err != nil {
+			// mirrors whose workflow isn't queryable keep their last status until it is
+			continue
+		}
+		if previous, ok := statuses[flowName]; ok && previous == status {
+			continue
+		}
+		statuses[flowName] = status
+		if err := stream.Send(&protos.MirrorEvent{
+			FlowJobName: flowName,
+			EventType:   protos.MirrorEventType_MIRROR_EVENT_STATUS_CHANGED,
+			CreatedAt:   timestamppb.Now(),
+			FlowStatus:  status,
+		}); err != nil {
+			return err
+		}
+	}
+	// dropped mirrors are forgotten, so one created again under the same name has its status sent
+	for flowName := range statuses {
+		if _, ok := workflowIDs[flowName]; !ok {
+			delete(statuses, flowName)
+		}
+	}
+	return nil
+}
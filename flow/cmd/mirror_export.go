@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"gopkg.in/yaml.v3"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const mirrorExportVersion = 1
+
+// mirrorExport is the document ExportMirrors writes, peers and mirrors as protojson objects
+type mirrorExport struct {
+	Peers   []any `yaml:"peers"`
+	Mirrors []any `yaml:"mirrors"`
+	Version int   `yaml:"version"`
+}
+
+// withSecretReferences replaces credentials of message, fields marked peerdb_redacted, with references
+// named after the peer or mirror and the path of the field, like ${secret:prod_pg/ssh_config.password}
+func withSecretReferences(message protoreflect.Message, prefix string) {
+	message.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap() {
+			withSecretReferences(v.Message(), prefix+string(fd.Name())+".")
+		} else if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() && v.String() != "" {
+			if proto.GetExtension(fd.Options().(*descriptorpb.FieldOptions), protos.E_PeerdbRedacted).(bool) {
+				message.Set(fd, protoreflect.ValueOfString("${secret:"+prefix+string(fd.Name())+"}"))
+			}
+		}
+		return true
+	})
+}
+
+// lookupSecret reads a secret from files in PEERDB_SECRETS_DIR, like mounted by Kubernetes or Vault agents,
+// or without one from environment variable PEERDB_SECRET_<NAME>, prod_pg/password being PEERDB_SECRET_PROD_PG_PASSWORD
+func lookupSecret(name string) (string, error) {
+	if dir := peerdbenv.PeerDBSecretsDir(); dir != "" {
+		if !filepath.IsLocal(name) {
+			return "", fmt.Errorf("invalid secret reference %s", name)
+		}
+		secret, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+		}
+		return strings.TrimRight(string(secret), "\r\n"), nil
+	}
+
+	envName := "PEERDB_SECRET_" + strings.ToUpper(strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name))
+	secret, ok := os.LookupEnv(envName)
+	if !ok {
+		return "", fmt.Errorf("secret %s not found, set it as %s", name, envName)
+	}
+	return secret, nil
+}
+
+func protoToYAMLValue(message proto.Message) (any, error) {
+	data, err := protojson.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func yamlValueToProto(value any, message proto.Message) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return protojson.Unmarshal(data, message)
+}
+
+// ExportMirrors writes CDC mirrors and their peers as YAML to be imported into another environment,
+// without credentials so the YAML can be kept in version control
+func (h *FlowRequestHandler) ExportMirrors(
+	ctx context.Context,
+	req *protos.ExportMirrorsRequest,
+) (*protos.ExportMirrorsResponse, error) {
+	if len(req.FlowJobNames) == 0 {
+		return nil, errors.New("no mirrors to export")
+	}
+
+	export := mirrorExport{Version: mirrorExportVersion}
+	var peerNames []string
+	for _, flowJobName := range req.FlowJobNames {
+		isCDC, err := h.isCDCFlow(ctx, flowJobName)
+		if err != nil {
+			return nil, err
+		}
+		if !isCDC {
+			return nil, fmt.Errorf("mirror %s is not a CDC mirror, only CDC mirrors can be exported", flowJobName)
+		}
+		config, err := h.getFlowConfigFromCatalog(ctx, flowJobName)
+		if err != nil {
+			return nil, err
+		}
+		config.Resync = false
+		withSecretReferences(config.ProtoReflect(), flowJobName+"/")
+		mirror, err := protoToYAMLValue(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode mirror %s: %w", flowJobName, err)
+		}
+		export.Mirrors = append(export.Mirrors, mirror)
+		for _, peerName := range []string{config.SourceName, config.DestinationName} {
+			if !slices.Contains(peerNames, peerName) {
+				peerNames = append(peerNames, peerName)
+			}
+		}
+	}
+
+	for _, peerName := range peerNames {
+		peer, err := connectors.LoadPeer(ctx, h.pool, peerName)
+		if err != nil {
+			return nil, err
+		}
+		withSecretReferences(peer.ProtoReflect(), peerName+"/")
+		value, err := protoToYAMLValue(peer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode peer %s: %w", peerName, err)
+		}
+		export.Peers = append(export.Peers, value)
+	}
+
+	data, err := yaml.Marshal(export)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mirrors as yaml: %w", err)
+	}
+	return &protos.ExportMirrorsResponse{Yaml: string(data)}, nil
+}
+
+// ImportMirrors creates peers and mirrors exported by ExportMirrors, peers that exist already are kept as they are
+// unless allow_update is set, so credentials of peers of the environment only need to be resolved when creating them
+func (h *FlowRequestHandler) ImportMirrors(
+	ctx context.Context,
+	req *protos.ImportMirrorsRequest,
+) (*protos.ImportMirrorsResponse, error) {
+	var export mirrorExport
+	if err := yaml.Unmarshal([]byte(req.Yaml), &export); err != nil {
+		return nil, fmt.Errorf("failed to parse yaml: %w", err)
+	}
+	if export.Version != mirrorExportVersion {
+		return nil, fmt.Errorf("unsupported export version %d", export.Version)
+	}
+
+	peers := make([]*protos.Peer, 0, len(export.Peers))
+	for i, value := range export.Peers {
+		var peer protos.Peer
+		if err := yamlValueToProto(value, &peer); err != nil {
+			return nil, fmt.Errorf("failed to parse peer %d: %w", i, err)
+		}
+		peers = append(peers, &peer)
+	}
+	mirrors := make([]*protos.FlowConnectionConfigs, 0, len(export.Mirrors))
+	for i, value := range export.Mirrors {
+		var config protos.FlowConnectionConfigs
+		if err := yamlValueToProto(value, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse mirror %d: %w", i, err)
+		}
+		mirrors = append(mirrors, &config)
+	}
+
+	res := &protos.ImportMirrorsResponse{}
+	for _, peer := range peers {
+		if !req.AllowUpdate {
+			var exists bool
+			if err := h.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM peers WHERE name = $1)", peer.Name).Scan(&exists); err != nil {
+				return nil, fmt.Errorf("failed to check for peer %s: %w", peer.Name, err)
+			} else if exists {
+				continue
+			}
+		}
+		if err := shared.ResolveSecretReferences(peer.ProtoReflect(), lookupSecret); err != nil {
+			return nil, fmt.Errorf("failed to resolve secrets of peer %s: %w", peer.Name, err)
+		}
+		created, err := h.CreatePeer(ctx, &protos.CreatePeerRequest{Peer: peer, AllowUpdate: req.AllowUpdate})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create peer %s: %w", peer.Name, err)
+		}
+		if created.Status != protos.CreatePeerStatus_CREATED {
+			return nil, fmt.Errorf("failed to create peer %s: %s", peer.Name, created.Message)
+		}
+		res.Peers = append(res.Peers, peer.Name)
+	}
+	for _, config := range mirrors {
+		if err := shared.ResolveSecretReferences(config.ProtoReflect(), lookupSecret); err != nil {
+			return nil, fmt.Errorf("failed to resolve secrets of mirror %s: %w", config.FlowJobName, err)
+		}
+		if _, err := h.CreateCDCFlow(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: config}); err != nil {
+			return nil, fmt.Errorf("failed to create mirror %s: %w", config.FlowJobName, err)
+		}
+		res.Mirrors = append(res.Mirrors, config.FlowJobName)
+	}
+	return res, nil
+}
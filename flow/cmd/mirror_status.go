@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -10,10 +11,13 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/shared"
 	peerflow "github.com/PeerDB-io/peer-flow/workflows"
@@ -56,6 +60,46 @@ func (h *FlowRequestHandler) ListMirrors(
 	}, nil
 }
 
+const (
+	maxMirrorStatusesPerRequest = 1000
+	// statuses are gathered from catalog and Temporal, bounded so one batch does not exhaust the catalog pool
+	mirrorStatusesConcurrency = 16
+)
+
+// MirrorStatuses answers for many mirrors at once, sparing clients polling every mirror a request each
+func (h *FlowRequestHandler) MirrorStatuses(
+	ctx context.Context,
+	req *protos.MirrorStatusesRequest,
+) (*protos.MirrorStatusesResponse, error) {
+	if len(req.FlowJobNames) > maxMirrorStatusesPerRequest {
+		return nil, fmt.Errorf("at most %d mirrors per request, got %d", maxMirrorStatusesPerRequest, len(req.FlowJobNames))
+	}
+	statuses := make([]*protos.MirrorStatusResponse, len(req.FlowJobNames))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(mirrorStatusesConcurrency)
+	for i, flowJobName := range req.FlowJobNames {
+		group.Go(func() error {
+			status, err := h.MirrorStatus(groupCtx, &protos.MirrorStatusRequest{
+				FlowJobName:     flowJobName,
+				IncludeFlowInfo: req.IncludeFlowInfo,
+			})
+			if err != nil {
+				status = &protos.MirrorStatusResponse{
+					FlowJobName:      flowJobName,
+					CurrentFlowState: protos.FlowStatus_STATUS_UNKNOWN,
+					ErrorMessage:     err.Error(),
+				}
+			}
+			statuses[i] = status
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return &protos.MirrorStatusesResponse{Statuses: statuses}, nil
+}
+
 func (h *FlowRequestHandler) MirrorStatus(
 	ctx context.Context,
 	req *protos.MirrorStatusRequest,
@@ -210,14 +254,42 @@ func (h *FlowRequestHandler) cdcFlowStatus(
 		return nil, err
 	}
 
+	quarantinedTables, err := monitoring.GetQuarantinedTables(ctx, h.pool, req.FlowJobName)
+	if err != nil {
+		slog.Error("unable to query quarantined tables", slog.Any("error", err))
+		return nil, err
+	}
+
+	batchOperations, err := monitoring.GetBatchOperations(ctx, h.pool, req.FlowJobName)
+	if err != nil {
+		slog.Error("unable to query batch operations", slog.Any("error", err))
+		return nil, err
+	}
+
+	var freshnessSLA *protos.FreshnessSLAStatus
+	if config.FreshnessSlaSeconds > 0 {
+		freshnessSLA, err = monitoring.GetFreshnessSLAStatus(ctx, h.pool, req.FlowJobName)
+		if err != nil {
+			slog.Error("unable to query freshness sla", slog.Any("error", err))
+			return nil, err
+		}
+		if freshnessSLA == nil {
+			freshnessSLA = &protos.FreshnessSLAStatus{SlaSeconds: config.FreshnessSlaSeconds}
+		}
+	}
+
 	return &protos.CDCMirrorStatus{
 		Config:          config,
 		SourceType:      srcType,
 		DestinationType: dstType,
 		SnapshotStatus: &protos.SnapshotStatus{
-			Clones: cloneStatuses,
+			Clones:                  cloneStatuses,
+			EstimatedCompletionTime: estimateSnapshotCompletion(cloneStatuses),
 		},
-		CdcBatches: cdcBatches,
+		CdcBatches:        cdcBatches,
+		QuarantinedTables: quarantinedTables,
+		BatchOperations:   batchOperations,
+		FreshnessSla:      freshnessSLA,
 	}, nil
 }
 
@@ -236,11 +308,13 @@ func (h *FlowRequestHandler) cloneTableSummary(
 		COUNT(CASE WHEN qp.flow_name IS NOT NULL THEN 1 END) AS NumPartitionsTotal,
 		COUNT(CASE WHEN qp.end_time IS NOT NULL THEN 1 END) AS NumPartitionsCompleted,
 		SUM(qp.rows_in_partition) FILTER (WHERE qp.end_time IS NOT NULL) AS NumRowsSynced,
-		AVG(EXTRACT(EPOCH FROM (qp.end_time - qp.start_time)) * 1000) FILTER (WHERE qp.end_time IS NOT NULL) AS AvgTimePerPartitionMs
+		AVG(EXTRACT(EPOCH FROM (qp.end_time - qp.start_time)) * 1000) FILTER (WHERE qp.end_time IS NOT NULL) AS AvgTimePerPartitionMs,
+		qr.estimated_rows AS EstimatedRows
 	FROM peerdb_stats.qrep_partitions qp
 	RIGHT JOIN peerdb_stats.qrep_runs qr ON qp.flow_name = qr.flow_name
 	WHERE qr.parent_mirror_name = $1
-	GROUP BY qr.flow_name, qr.destination_table, qr.source_table, qr.start_time, qr.fetch_complete, qr.consolidate_complete;
+	GROUP BY qr.flow_name, qr.destination_table, qr.source_table, qr.start_time, qr.fetch_complete, qr.consolidate_complete,
+		qr.estimated_rows;
 	`
 	var flowName pgtype.Text
 	var destinationTable pgtype.Text
@@ -252,6 +326,7 @@ func (h *FlowRequestHandler) cloneTableSummary(
 	var numPartitionsCompleted pgtype.Int8
 	var numRowsSynced pgtype.Int8
 	var avgTimePerPartitionMs pgtype.Float8
+	var estimatedRows pgtype.Int8
 
 	rows, err := h.pool.Query(ctx, q, parentMirrorName)
 	if err != nil {
@@ -262,6 +337,7 @@ func (h *FlowRequestHandler) cloneTableSummary(
 
 	defer rows.Close()
 
+	now := time.Now()
 	cloneStatuses := []*protos.CloneTableSummary{}
 	for rows.Next() {
 		if err := rows.Scan(
@@ -275,6 +351,7 @@ func (h *FlowRequestHandler) cloneTableSummary(
 			&numPartitionsCompleted,
 			&numRowsSynced,
 			&avgTimePerPartitionMs,
+			&estimatedRows,
 		); err != nil {
 			return nil, fmt.Errorf("unable to scan initial load partition - %s: %w", parentMirrorName, err)
 		}
@@ -321,13 +398,56 @@ func (h *FlowRequestHandler) cloneTableSummary(
 			res.AvgTimePerPartitionMs = int64(avgTimePerPartitionMs.Float64)
 		}
 
+		if estimatedRows.Valid {
+			res.EstimatedRows = estimatedRows.Int64
+		}
+
 		res.MirrorName = parentMirrorName
+		res.EstimatedCompletionTime = estimateCloneCompletion(&res, now)
 
 		cloneStatuses = append(cloneStatuses, &res)
 	}
 	return cloneStatuses, nil
 }
 
+// estimateCloneCompletion extrapolates elapsed time of a table snapshot by its progress,
+// measured in rows against source statistics when they are not stale, otherwise in partitions
+func estimateCloneCompletion(summary *protos.CloneTableSummary, now time.Time) *timestamppb.Timestamp {
+	if summary.StartTime == nil || summary.ConsolidateCompleted {
+		return nil
+	}
+	var progress float64
+	if summary.NumPartitionsTotal > 0 {
+		progress = float64(summary.NumPartitionsCompleted) / float64(summary.NumPartitionsTotal)
+	}
+	if summary.EstimatedRows > 0 && summary.NumRowsSynced < summary.EstimatedRows && progress < 1 {
+		progress = float64(summary.NumRowsSynced) / float64(summary.EstimatedRows)
+	}
+	if progress <= 0 {
+		return nil
+	} else if progress >= 1 {
+		return timestamppb.New(now)
+	}
+	startTime := summary.StartTime.AsTime()
+	elapsed := now.Sub(startTime)
+	return timestamppb.New(startTime.Add(time.Duration(float64(elapsed) / progress)))
+}
+
+// estimateSnapshotCompletion is unset while any table snapshot in progress has no estimate
+func estimateSnapshotCompletion(clones []*protos.CloneTableSummary) *timestamppb.Timestamp {
+	var latest *timestamppb.Timestamp
+	for _, clone := range clones {
+		if clone.ConsolidateCompleted {
+			continue
+		} else if clone.EstimatedCompletionTime == nil {
+			return nil
+		} else if latest == nil || clone.EstimatedCompletionTime.AsTime().After(latest.AsTime()) {
+			latest = clone.EstimatedCompletionTime
+		}
+	}
+	return latest
+}
+
 func (h *FlowRequestHandler) qrepFlowStatus(
 	ctx context.Context,
 	req *protos.MirrorStatusRequest,
@@ -633,3 +753,218 @@ func (h *FlowRequestHandler) ListMirrorLogs(
 		Total:  total,
 	}, nil
 }
+
+func (h *FlowRequestHandler) GetMirrorResourceUsage(
+	ctx context.Context,
+	req *protos.GetMirrorResourceUsageRequest,
+) (*protos.GetMirrorResourceUsageResponse, error) {
+	rows, err := h.pool.Query(ctx, `select sampled_at, worker_id, cpu_seconds, memory_bytes, goroutines, active_activities
+		from peerdb_stats.flow_resource_usage
+		where flow_name = $1
+			and sampled_at > (now()-$2::INTERVAL)
+		order by sampled_at limit 2880`, req.FlowJobName, req.TimeSince)
+	if err != nil {
+		slog.Error("unable to query resource usage", slog.String("flowName", req.FlowJobName), slog.Any("error", err))
+		return nil, err
+	}
+	points, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.ResourceUsagePoint, error) {
+		var sampledAt time.Time
+		point := &protos.ResourceUsagePoint{}
+		if err := row.Scan(
+			&sampledAt, &point.WorkerId, &point.CpuSeconds, &point.MemoryBytes, &point.Goroutines, &point.ActiveActivities,
+		); err != nil {
+			return nil, err
+		}
+		point.SampledAt = float64(sampledAt.UnixMilli())
+		return point, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &protos.GetMirrorResourceUsageResponse{Data: points}, nil
+}
+
+func (h *FlowRequestHandler) SampleMirrorRecords(
+	ctx context.Context,
+	req *protos.SampleMirrorRecordsRequest,
+) (*protos.SampleMirrorRecordsResponse, error) {
+	if req.NumRecords <= 0 || req.NumRecords > 1000 {
+		return nil, errors.New("num_records must be between 1 and 1000")
+	}
+	timeout := 60 * time.Second
+	if req.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(req.TimeoutSeconds)*time.Second, 5*time.Minute)
+	}
+
+	var sampleID int64
+	if err := h.pool.QueryRow(ctx,
+		"INSERT INTO peerdb_stats.flow_record_samples (flow_name, num_records) VALUES ($1, $2) RETURNING id",
+		req.FlowJobName, req.NumRecords,
+	).Scan(&sampleID); err != nil {
+		slog.Error("unable to create record sample request", slog.String("flowName", req.FlowJobName), slog.Any("error", err))
+		return nil, err
+	}
+
+	// records are only teed by sync activities, poll catalog until they have been collected
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	var records [][]byte
+	var complete bool
+	for !complete {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			// mark request done so workers stop sampling for it
+			if err := h.pool.QueryRow(ctx,
+				`UPDATE peerdb_stats.flow_record_samples SET completed_at = now()
+				WHERE id = $1 RETURNING (SELECT array_agg(r) FROM jsonb_array_elements(records) r)`,
+				sampleID,
+			).Scan(&records); err != nil {
+				return nil, err
+			}
+			return buildSampleMirrorRecordsResponse(records, false)
+		case <-ticker.C:
+			if err := h.pool.QueryRow(ctx,
+				`SELECT completed_at IS NOT NULL, (SELECT array_agg(r) FROM jsonb_array_elements(records) r)
+				FROM peerdb_stats.flow_record_samples WHERE id = $1`,
+				sampleID,
+			).Scan(&complete, &records); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buildSampleMirrorRecordsResponse(records, true)
+}
+
+func buildSampleMirrorRecordsResponse(records [][]byte, complete bool) (*protos.SampleMirrorRecordsResponse, error) {
+	samples := make([]*protos.SampledRecord, 0, len(records))
+	for _, record := range records {
+		var sample protos.SampledRecord
+		if err := protojson.Unmarshal(record, &sample); err != nil {
+			return nil, fmt.Errorf("failed to decode sampled record: %w", err)
+		}
+		samples = append(samples, &sample)
+	}
+	return &protos.SampleMirrorRecordsResponse{Records: samples, Complete: complete}, nil
+}
+
+func (h *FlowRequestHandler) GetBatchDestinationStatements(
+	ctx context.Context,
+	req *protos.GetBatchDestinationStatementsRequest,
+) (*protos.GetBatchDestinationStatementsResponse, error) {
+	rows, err := h.pool.Query(ctx, `select executed_at, kind, statement
+		from peerdb_stats.destination_statements
+		where flow_name = $1 and batch_id = $2
+		order by id`, req.FlowJobName, req.BatchId)
+	if err != nil {
+		slog.Error("unable to query destination statements", slog.String("flowName", req.FlowJobName), slog.Any("error", err))
+		return nil, err
+	}
+	statements, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.DestinationStatement, error) {
+		var executedAt time.Time
+		statement := &protos.DestinationStatement{}
+		if err := row.Scan(&executedAt, &statement.Kind, &statement.Statement); err != nil {
+			return nil, err
+		}
+		statement.ExecutedAt = float64(executedAt.UnixMilli())
+		return statement, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &protos.GetBatchDestinationStatementsResponse{Statements: statements}, nil
+}
+
+func (h *FlowRequestHandler) GetSchemaHistory(
+	ctx context.Context,
+	req *protos.GetSchemaHistoryRequest,
+) (*protos.GetSchemaHistoryResponse, error) {
+	rows, err := h.pool.Query(ctx, `select table_name, detected_at, schema
+		from peerdb_stats.source_schema_history
+		where flow_name = $1 and ($2 = '' or table_name = $2)
+		order by id`, req.FlowJobName, req.TableName)
+	if err != nil {
+		slog.Error("unable to query schema history", slog.String("flowName", req.FlowJobName), slog.Any("error", err))
+		return nil, err
+	}
+
+	previous := make(map[string]*protos.TableSchema)
+	versions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.SchemaVersion, error) {
+		var detectedAt time.Time
+		var schema []byte
+		version := &protos.SchemaVersion{Schema: &protos.TableSchema{}}
+		if err := row.Scan(&version.TableName, &detectedAt, &schema); err != nil {
+			return nil, err
+		}
+		if err := protojson.Unmarshal(schema, version.Schema); err != nil {
+			return nil, fmt.Errorf("failed to decode schema of %s: %w", version.TableName, err)
+		}
+		version.DetectedAt = float64(detectedAt.UnixMilli())
+		if prev, ok := previous[version.TableName]; ok {
+			version.Changes = diffSchemaColumns(prev, version.Schema)
+		}
+		previous[version.TableName] = version.Schema
+		return version, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &protos.GetSchemaHistoryResponse{Versions: versions}, nil
+}
+
+func diffSchemaColumns(prev *protos.TableSchema, next *protos.TableSchema) []*protos.SchemaColumnChange {
+	prevColumns := make(map[string]*protos.FieldDescription, len(prev.Columns))
+	for _, column := range prev.Columns {
+		prevColumns[column.Name] = column
+	}
+
+	var changes []*protos.SchemaColumnChange
+	for _, column := range next.Columns {
+		prevColumn, ok := prevColumns[column.Name]
+		if !ok {
+			changes = append(changes, &protos.SchemaColumnChange{
+				ColumnName: column.Name, Change: "added", NewType: column.Type, NewTypeModifier: column.TypeModifier,
+			})
+			continue
+		}
+		delete(prevColumns, column.Name)
+		if prevColumn.Type != column.Type || prevColumn.TypeModifier != column.TypeModifier {
+			changes = append(changes, &protos.SchemaColumnChange{
+				ColumnName: column.Name, Change: "type_changed",
+				OldType: prevColumn.Type, OldTypeModifier: prevColumn.TypeModifier,
+				NewType: column.Type, NewTypeModifier: column.TypeModifier,
+			})
+		} else if prevColumn.Nullable != column.Nullable {
+			changes = append(changes, &protos.SchemaColumnChange{
+				ColumnName: column.Name, Change: "nullability_changed",
+				OldType: prevColumn.Type, OldTypeModifier: prevColumn.TypeModifier,
+				NewType: column.Type, NewTypeModifier: column.TypeModifier,
+			})
+		}
+	}
+	for _, column := range prev.Columns {
+		if _, ok := prevColumns[column.Name]; ok {
+			changes = append(changes, &protos.SchemaColumnChange{
+				ColumnName: column.Name, Change: "dropped", OldType: column.Type, OldTypeModifier: column.TypeModifier,
+			})
+		}
+	}
+	return changes
+}
+
+func (h *FlowRequestHandler) GetSnapshotColumnStats(
+	ctx context.Context,
+	req *protos.GetSnapshotColumnStatsRequest,
+) (*protos.GetSnapshotColumnStatsResponse, error) {
+	tables, err := monitoring.GetSnapshotColumnStats(ctx, h.pool, req.FlowJobName)
+	if err != nil {
+		slog.Error("unable to get snapshot column stats", slog.String("flowName", req.FlowJobName), slog.Any("error", err))
+		return nil, err
+	}
+	return &protos.GetSnapshotColumnStatsResponse{Tables: tables}, nil
+}
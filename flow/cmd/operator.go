@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/operator"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	_ "github.com/PeerDB-io/peer-flow/shared/grpczstd"
+)
+
+type OperatorOptions struct {
+	// host:port of the gRPC flow API
+	FlowAPIAddress string
+	// namespace of Peer and Mirror resources, that of the service account when empty
+	Namespace      string
+	ResyncInterval time.Duration
+	LagThresholdMB float64
+}
+
+// OperatorMain reconciles Peer and Mirror resources of the cluster it runs in against the flow API
+func OperatorMain(ctx context.Context, opts *OperatorOptions) error {
+	dialOptions := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if compression := peerdbenv.PeerDBGRPCCompression(); compression != "" {
+		if encoding.GetCompressor(compression) == nil {
+			return fmt.Errorf("unknown gRPC compression %s, expected zstd or gzip", compression)
+		}
+		dialOptions = append(dialOptions, grpc.WithDefaultCallOptions(grpc.UseCompressor(compression)))
+	}
+	conn, err := grpc.NewClient(opts.FlowAPIAddress, dialOptions...)
+	if err != nil {
+		return fmt.Errorf("unable to dial flow API: %w", err)
+	}
+	defer conn.Close()
+
+	controller, err := operator.NewController(opts.Namespace, protos.NewFlowServiceClient(conn),
+		opts.ResyncInterval, opts.LagThresholdMB)
+	if err != nil {
+		return fmt.Errorf("unable to create operator: %w", err)
+	}
+	return controller.Run(ctx)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/PeerDB-io/peer-flow/connectors"
 	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/peerdbenv"
 	"github.com/PeerDB-io/peer-flow/shared"
@@ -473,3 +475,119 @@ func (h *FlowRequestHandler) GetPublications(
 	}
 	return &protos.PeerPublicationsResponse{PublicationNames: publications}, nil
 }
+
+func (h *FlowRequestHandler) GetSourceSetupScript(
+	ctx context.Context,
+	req *protos.SourceSetupScriptRequest,
+) (*protos.SourceSetupScriptResponse, error) {
+	config := req.ConnectionConfigs
+	if config == nil {
+		return nil, errors.New("connection configs is nil")
+	}
+	if len(config.TableMappings) == 0 {
+		return nil, errors.New("mirror has no tables")
+	}
+	tables := make([]*utils.SchemaTable, 0, len(config.TableMappings))
+	for _, tableMapping := range config.TableMappings {
+		table, err := utils.ParseSchemaTable(tableMapping.SourceTableIdentifier)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source table %s: %w", tableMapping.SourceTableIdentifier, err)
+		}
+		tables = append(tables, table)
+	}
+
+	pgConfig, err := h.getPGPeerConfig(ctx, config.SourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Postgres peer %s: %w", config.SourceName, err)
+	}
+	user := req.User
+	if user == "" {
+		user = pgConfig.User
+	}
+
+	pgConnector, err := connpostgres.NewPostgresConnector(ctx, pgConfig)
+	if err != nil {
+		slog.Error("Failed to create postgres connector", slog.Any("error", err))
+		return nil, err
+	}
+	defer pgConnector.Close()
+
+	platform, script, err := pgConnector.SourceSetupScript(ctx, user, config.FlowJobName, config.PublicationName, tables)
+	if err != nil {
+		slog.Error("Failed to generate source setup script", slog.Any("error", err))
+		return nil, err
+	}
+	return &protos.SourceSetupScriptResponse{
+		Platform: platform,
+		Script:   script,
+	}, nil
+}
+
+// ListOrphanedArtifacts lists what scheduled cleanup drops from a peer, or only logs in dry run mode
+func (h *FlowRequestHandler) ListOrphanedArtifacts(
+	ctx context.Context,
+	req *protos.ListOrphanedArtifactsRequest,
+) (*protos.ListOrphanedArtifactsResponse, error) {
+	conn, err := connectors.GetByNameAs[connectors.StagingArtifactsConnector](ctx, nil, h.pool, req.PeerName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, fmt.Errorf("peer %s has no staging artifacts to clean up", req.PeerName)
+		}
+		slog.Error("Failed to connect to peer", slog.String("peerName", req.PeerName), slog.Any("error", err))
+		return nil, err
+	}
+	defer connectors.CloseConnector(ctx, conn)
+
+	artifacts, err := connectors.OrphanedArtifacts(ctx, conn, h.pool)
+	if err != nil {
+		slog.Error("Failed to list orphaned artifacts", slog.String("peerName", req.PeerName), slog.Any("error", err))
+		return nil, err
+	}
+	return &protos.ListOrphanedArtifactsResponse{Artifacts: artifacts}, nil
+}
+
+// SetPeerMirrorDefaults replaces the defaults mirrors targeting a peer are created with, existing mirrors keep theirs
+func (h *FlowRequestHandler) SetPeerMirrorDefaults(
+	ctx context.Context,
+	req *protos.SetPeerMirrorDefaultsRequest,
+) (*protos.SetPeerMirrorDefaultsResponse, error) {
+	if req.Defaults != nil && req.Defaults.TruncatePolicy == protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE &&
+		req.Defaults.SoftDeleteColName == "" {
+		return nil, errors.New("truncate policy soft_delete requires a soft delete column")
+	}
+	defaults, err := proto.Marshal(req.Defaults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mirror defaults: %w", err)
+	}
+	tag, err := h.pool.Exec(ctx, "UPDATE peers SET mirror_defaults = $2 WHERE name = $1", req.PeerName, defaults)
+	if err != nil {
+		slog.Error("Failed to update mirror defaults", slog.String("peerName", req.PeerName), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to update mirror defaults of peer %s: %w", req.PeerName, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("peer %s not found", req.PeerName)
+	}
+	return &protos.SetPeerMirrorDefaultsResponse{}, nil
+}
+
+func (h *FlowRequestHandler) GetPeerMirrorDefaults(
+	ctx context.Context,
+	req *protos.GetPeerMirrorDefaultsRequest,
+) (*protos.MirrorDefaults, error) {
+	return h.loadPeerMirrorDefaults(ctx, req.PeerName)
+}
+
+func (h *FlowRequestHandler) loadPeerMirrorDefaults(ctx context.Context, peerName string) (*protos.MirrorDefaults, error) {
+	var encoded []byte
+	if err := h.pool.QueryRow(ctx, "SELECT mirror_defaults FROM peers WHERE name = $1", peerName).Scan(&encoded); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("peer %s not found", peerName)
+		}
+		return nil, fmt.Errorf("failed to load mirror defaults of peer %s: %w", peerName, err)
+	}
+	var defaults protos.MirrorDefaults
+	if err := proto.Unmarshal(encoded, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to decode mirror defaults of peer %s: %w", peerName, err)
+	}
+	return &defaults, nil
+}
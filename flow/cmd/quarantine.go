@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// destinations normalizing changes from the raw table again, for released quarantined tables and replayed batches
+var normalizeReplayDestinations = []protos.DBType{
+	protos.DBType_POSTGRES,
+	protos.DBType_CLICKHOUSE,
+	protos.DBType_SNOWFLAKE,
+	protos.DBType_BIGQUERY,
+}
+
+// QuarantineTable stops normalizing changes to one destination table of a mirror while the rest keeps replicating,
+// like when the table keeps failing on a bad type or constraint until it is fixed
+func (h *FlowRequestHandler) QuarantineTable(
+	ctx context.Context,
+	req *protos.QuarantineTableRequest,
+) (*protos.QuarantineTableResponse, error) {
+	slog.Info("quarantining table", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+		slog.String("table", req.TableName), slog.String("policy", req.Policy.String()))
+	config, err := h.getQuarantineMirrorConfig(ctx, req.FlowJobName, req.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if req.Policy == protos.QuarantinePolicy_QUARANTINE_POLICY_BUFFER {
+		dstType, err := connectors.LoadPeerType(ctx, h.pool, config.DestinationName)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load destination peer type: %w", err)
+		}
+		if !slices.Contains(normalizeReplayDestinations, dstType) {
+			return nil, fmt.Errorf("%s destinations cannot buffer changes of quarantined tables, use %s instead",
+				dstType, protos.QuarantinePolicy_QUARANTINE_POLICY_SKIP)
+		}
+	}
+
+	if err := monitoring.QuarantineTable(ctx, h.pool, req.FlowJobName, req.TableName, req.Policy, req.Reason); err != nil {
+		slog.Error("unable to quarantine table", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return &protos.QuarantineTableResponse{}, nil
+}
+
+// ReleaseQuarantinedTable resumes normalizing changes to a quarantined destination table,
+// changes buffered meanwhile are normalized along with the next batch
+func (h *FlowRequestHandler) ReleaseQuarantinedTable(
+	ctx context.Context,
+	req *protos.ReleaseQuarantinedTableRequest,
+) (*protos.ReleaseQuarantinedTableResponse, error) {
+	slog.Info("releasing quarantined table", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+		slog.String("table", req.TableName))
+	released, err := monitoring.ReleaseQuarantinedTable(ctx, h.pool, req.FlowJobName, req.TableName)
+	if err != nil {
+		slog.Error("unable to release quarantined table", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+			slog.Any("error", err))
+		return nil, err
+	}
+	if !released {
+		return nil, fmt.Errorf("table %s of mirror %s is not quarantined", req.TableName, req.FlowJobName)
+	}
+	return &protos.ReleaseQuarantinedTableResponse{}, nil
+}
+
+func (h *FlowRequestHandler) getQuarantineMirrorConfig(
+	ctx context.Context,
+	flowJobName string,
+	tableName string,
+) (*protos.FlowConnectionConfigs, error) {
+	isCDC, err := h.isCDCFlow(ctx, flowJobName)
+	if err != nil {
+		return nil, err
+	}
+	if !isCDC {
+		return nil, errors.New("quarantine is only supported for CDC mirrors")
+	}
+	config, err := h.getFlowConfigFromCatalog(ctx, flowJobName)
+	if err != nil {
+		return nil, err
+	}
+	// tables added since creation are only in the workflow state
+	tableMappings := config.TableMappings
+	if workflowID, err := h.getWorkflowID(ctx, flowJobName); err == nil {
+		if state, err := h.getCDCWorkflowState(ctx, workflowID); err == nil && state.SyncFlowOptions != nil {
+			tableMappings = state.SyncFlowOptions.TableMappings
+		}
+	}
+	if !slices.ContainsFunc(tableMappings, func(tm *protos.TableMapping) bool {
+		return tm.DestinationTableIdentifier == tableName
+	}) {
+		return nil, fmt.Errorf("mirror %s has no destination table %s", flowJobName, tableName)
+	}
+	return config, nil
+}
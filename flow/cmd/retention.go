@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// destinations rows past retention policies can be deleted from
+var retentionDestinations = []protos.DBType{
+	protos.DBType_POSTGRES,
+	protos.DBType_SNOWFLAKE,
+	protos.DBType_BIGQUERY,
+	protos.DBType_CLICKHOUSE,
+}
+
+func validateRetentionPolicies(cfg *protos.FlowConnectionConfigs, dstPeerType protos.DBType) error {
+	for _, tm := range cfg.TableMappings {
+		if tm.Retention == nil {
+			continue
+		}
+		if tm.Retention.Days == 0 || tm.Retention.Column == "" {
+			return fmt.Errorf("retention policy of %s needs a column and a number of days", tm.DestinationTableIdentifier)
+		}
+		if !slices.Contains(retentionDestinations, dstPeerType) {
+			return fmt.Errorf("retention policies are not supported for %s destinations", dstPeerType)
+		}
+	}
+	return nil
+}
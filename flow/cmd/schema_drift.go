@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// CompareSchemas diffs destination tables of a mirror against how it would create them from current source schemas,
+// so changes made to destination tables out of band show up before syncs or normalizes fail on them
+func (h *FlowRequestHandler) CompareSchemas(
+	ctx context.Context,
+	req *protos.CompareSchemasRequest,
+) (*protos.CompareSchemasResponse, error) {
+	cfg, err := h.getFlowConfigFromCatalog(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, shared.FlowNameKey, cfg.FlowJobName)
+	logger := slog.With(slog.String(string(shared.FlowNameKey), cfg.FlowJobName))
+
+	dstConn, err := connectors.GetByNameAs[connectors.SchemaDriftConnector](ctx, cfg.Env, h.pool, cfg.DestinationName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, fmt.Errorf("destination %s does not support comparing schemas", cfg.DestinationName)
+		}
+		return nil, fmt.Errorf("failed to connect to destination %s: %w", cfg.DestinationName, err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	srcConn, err := connectors.GetByNameAs[connectors.GetTableSchemaConnector](ctx, cfg.Env, h.pool, cfg.SourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to source %s: %w", cfg.SourceName, err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	srcTables := make([]string, 0, len(cfg.TableMappings))
+	for _, tableMapping := range cfg.TableMappings {
+		srcTables = append(srcTables, tableMapping.SourceTableIdentifier)
+	}
+	srcSchemas, err := srcConn.GetTableSchema(ctx, &protos.GetTableSchemaBatchInput{
+		PeerName:         cfg.SourceName,
+		TableIdentifiers: srcTables,
+		FlowName:         cfg.FlowJobName,
+		System:           cfg.System,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source schemas: %w", err)
+	}
+	tableSchemas := shared.BuildProcessedSchemaMapping(cfg.TableMappings, srcSchemas.TableNameSchemaMapping, logger)
+	if cfg.SourceIdentifier != "" {
+		for dstTableName, tableSchema := range tableSchemas {
+			tableSchemas[dstTableName] = model.WithSourceIdentifierColumn(tableSchema)
+		}
+	}
+
+	drifts, err := dstConn.CompareTableSchemas(ctx, cfg, tableSchemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare destination tables: %w", err)
+	}
+	if len(drifts) > 0 {
+		logger.Warn("destination tables drifted from source schemas", slog.Int("drifts", len(drifts)))
+	}
+	return &protos.CompareSchemasResponse{Drifts: drifts, TablesCompared: int32(len(tableSchemas))}, nil
+}
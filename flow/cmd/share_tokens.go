@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const (
+	defaultShareTokenTTL = 24 * time.Hour
+	maxShareTokenTTL     = 30 * 24 * time.Hour
+)
+
+func shareTokenSecret() ([]byte, error) {
+	secret, err := peerdbenv.PeerDBShareTokenSecret()
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, errors.New("share tokens are disabled, set PEERDB_SHARE_TOKEN_SECRET to enable them")
+	}
+	return []byte(secret), nil
+}
+
+// CreateMirrorShareToken signs a token granting read-only access to status of a mirror through SharedMirrorStatus
+func (h *FlowRequestHandler) CreateMirrorShareToken(
+	ctx context.Context,
+	req *protos.CreateMirrorShareTokenRequest,
+) (*protos.CreateMirrorShareTokenResponse, error) {
+	secret, err := shareTokenSecret()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.getWorkflowID(ctx, req.FlowJobName); err != nil {
+		return nil, fmt.Errorf("mirror %s not found: %w", req.FlowJobName, err)
+	}
+
+	ttl := defaultShareTokenTTL
+	if req.TtlSeconds > 0 {
+		ttl = time.Duration(req.TtlSeconds) * time.Second
+	}
+	if ttl > maxShareTokenTTL {
+		return nil, fmt.Errorf("share tokens expire within %s", maxShareTokenTTL)
+	}
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	claims := shared.ShareTokenClaims{ID: uuid.NewString(), FlowJobName: req.FlowJobName, ExpiresAt: expiresAt.Unix()}
+	token, err := shared.SignShareToken(secret, claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign share token: %w", err)
+	}
+	if _, err := h.pool.Exec(ctx, "INSERT INTO mirror_share_tokens (id, flow_name, expires_at) VALUES ($1, $2, $3)",
+		claims.ID, claims.FlowJobName, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to save share token: %w", err)
+	}
+	slog.Info("created share token", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+		slog.String("tokenID", claims.ID), slog.Time("expiresAt", expiresAt))
+	return &protos.CreateMirrorShareTokenResponse{
+		Token:     token,
+		TokenId:   claims.ID,
+		ExpiresAt: timestamppb.New(expiresAt),
+	}, nil
+}
+
+func (h *FlowRequestHandler) RevokeMirrorShareToken(
+	ctx context.Context,
+	req *protos.RevokeMirrorShareTokenRequest,
+) (*protos.RevokeMirrorShareTokenResponse, error) {
+	tag, err := h.pool.Exec(ctx, "UPDATE mirror_share_tokens SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL",
+		req.TokenId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke share token: %w", err)
+	}
+	return &protos.RevokeMirrorShareTokenResponse{Revoked: tag.RowsAffected() > 0}, nil
+}
+
+// SharedMirrorStatus serves status of the mirror a share token is for, leaving out its configuration.
+// Tokens are for a mirror name so keep working across resyncs, revoke them to cut access before they expire
+func (h *FlowRequestHandler) SharedMirrorStatus(
+	ctx context.Context,
+	req *protos.SharedMirrorStatusRequest,
+) (*protos.SharedMirrorStatusResponse, error) {
+	secret, err := shareTokenSecret()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	claims, err := shared.VerifyShareToken(secret, req.Token, time.Now())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	var revoked bool
+	if err := h.pool.QueryRow(ctx,
+		"SELECT revoked_at IS NOT NULL FROM mirror_share_tokens WHERE id = $1 AND flow_name = $2",
+		claims.ID, claims.FlowJobName,
+	).Scan(&revoked); errors.Is(err, pgx.ErrNoRows) || revoked {
+		return nil, status.Error(codes.Unauthenticated, "share token revoked")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to check share token: %w", err)
+	}
+
+	mirrorStatus, err := h.MirrorStatus(ctx, &protos.MirrorStatusRequest{FlowJobName: claims.FlowJobName, IncludeFlowInfo: true})
+	if err != nil {
+		return nil, err
+	}
+	if !mirrorStatus.Ok {
+		return nil, status.Error(codes.Unavailable, mirrorStatus.ErrorMessage)
+	}
+	res := &protos.SharedMirrorStatusResponse{
+		FlowJobName:      claims.FlowJobName,
+		CurrentFlowState: mirrorStatus.CurrentFlowState,
+		CreatedAt:        mirrorStatus.CreatedAt,
+		TokenExpiresAt:   timestamppb.New(time.Unix(claims.ExpiresAt, 0)),
+	}
+	switch s := mirrorStatus.Status.(type) {
+	case *protos.MirrorStatusResponse_CdcStatus:
+		res.SnapshotStatus = s.CdcStatus.SnapshotStatus
+		res.CdcBatches = s.CdcStatus.CdcBatches
+		res.FreshnessSla = s.CdcStatus.FreshnessSla
+		res.TableCounts, err = h.CDCTableTotalCounts(ctx, &protos.CDCTableTotalCountsRequest{FlowJobName: claims.FlowJobName})
+		if err != nil {
+			return nil, err
+		}
+	case *protos.MirrorStatusResponse_QrepStatus:
+		res.QrepStatus = s.QrepStatus
+	}
+	return res, nil
+}
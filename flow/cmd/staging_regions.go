@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// validateStagingRegions refuses mirrors that would stage data on the destination peer outside the regions
+// PEERDB_ALLOWED_STAGING_REGIONS pins the deployment to, regions being named as their clouds name them,
+// like us-east-1 on AWS or europe-west1 and EU on GCP. Peers that stage nothing are not checked
+func (h *FlowRequestHandler) validateStagingRegions(
+	ctx context.Context,
+	env map[string]string,
+	peerName string,
+	stagingPaths ...string,
+) error {
+	allowedRegions := peerdbenv.PeerDBAllowedStagingRegions()
+	if len(allowedRegions) == 0 {
+		return nil
+	}
+
+	conn, err := connectors.GetByNameAs[connectors.StagingRegionsConnector](ctx, env, h.pool, peerName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return fmt.Errorf("failed to connect to peer %s: %w", peerName, err)
+	}
+	defer connectors.CloseConnector(ctx, conn)
+
+	// peers stage in their own buckets without a staging path
+	if len(stagingPaths) == 0 {
+		stagingPaths = []string{""}
+	}
+	var violations []error
+	reported := make(map[string]struct{})
+	for _, stagingPath := range slices.Compact(stagingPaths) {
+		regions, err := conn.StagingRegions(ctx, stagingPath)
+		if err != nil {
+			return fmt.Errorf("failed to get staging regions of peer %s: %w", peerName, err)
+		}
+		for _, region := range regions {
+			if _, ok := reported[region]; !ok && !slices.Contains(allowedRegions, region) {
+				reported[region] = struct{}{}
+				violations = append(violations, fmt.Errorf("peer %s stages data in region %s", peerName, region))
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("staging is only allowed in regions %v: %w", allowedRegions, errors.Join(violations...))
+	}
+	return nil
+}
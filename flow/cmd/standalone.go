@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/standalone"
+)
+
+type StandaloneOptions struct {
+	// JSON encoded protos.StandaloneConfig
+	ConfigPath string
+}
+
+// StandaloneMain creates peers of the config file and runs its mirrors in this process without Temporal,
+// the catalog has to be migrated by nexus beforehand
+func StandaloneMain(ctx context.Context, opts *StandaloneOptions) error {
+	configBytes, err := os.ReadFile(opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read standalone config: %w", err)
+	}
+	var config protos.StandaloneConfig
+	if err := protojson.Unmarshal(configBytes, &config); err != nil {
+		return fmt.Errorf("failed to parse standalone config: %w", err)
+	}
+	if len(config.Mirrors) == 0 {
+		return errors.New("standalone config has no mirrors")
+	}
+
+	catalogPool, err := peerdbenv.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get catalog connection pool: %w", err)
+	}
+
+	for _, peer := range config.Peers {
+		res, err := utils.CreatePeerNoValidate(ctx, catalogPool, peer, true)
+		if err != nil {
+			return fmt.Errorf("failed to create peer %s: %w", peer.Name, err)
+		}
+		if res.Status != protos.CreatePeerStatus_CREATED {
+			return fmt.Errorf("failed to create peer %s: %s", peer.Name, res.Message)
+		}
+	}
+
+	slog.Info("running standalone mirrors", slog.Int("mirrors", len(config.Mirrors)))
+	return standalone.NewRunner(catalogPool).Run(ctx, config.Mirrors)
+}
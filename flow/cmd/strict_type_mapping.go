@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+// postgres types that are text already, values of others read as string kind are stringified
+var postgresTextTypes = map[string]struct{}{
+	"text":    {},
+	"varchar": {},
+	"bpchar":  {},
+	"name":    {},
+	"citext":  {},
+}
+
+// warehouses mapping kinds to their own types, other destinations serialize values as they come
+var strictTypeMappingDestinations = map[protos.DBType]struct{}{
+	protos.DBType_SNOWFLAKE:   {},
+	protos.DBType_CLICKHOUSE:  {},
+	protos.DBType_BIGQUERY:    {},
+	protos.DBType_FABRIC:      {},
+	protos.DBType_SINGLESTORE: {},
+	protos.DBType_STARROCKS:   {},
+}
+
+// validateStrictTypeMapping reports every column of cfg that would be mapped lossily to the destination,
+// qSchemas being the source schemas in the Q type system and pgSchemas their postgres types, nil for other sources.
+// Columns with a custom destination type are left to the type chosen for them
+func validateStrictTypeMapping(
+	cfg *protos.FlowConnectionConfigs,
+	dstPeerType protos.DBType,
+	qSchemas map[string]*protos.TableSchema,
+	pgSchemas map[string]*protos.TableSchema,
+) error {
+	if !cfg.StrictTypeMapping || cfg.System != protos.TypeSystem_Q {
+		return nil
+	}
+	if _, ok := strictTypeMappingDestinations[dstPeerType]; !ok {
+		return nil
+	}
+
+	var violations []error
+	for _, tm := range cfg.TableMappings {
+		schema, ok := qSchemas[tm.SourceTableIdentifier]
+		if !ok {
+			continue
+		}
+
+		skipped := make(map[string]struct{}, len(tm.Exclude)+len(tm.Columns))
+		for _, col := range tm.Exclude {
+			skipped[col] = struct{}{}
+		}
+		for _, col := range tm.Columns {
+			if col.DestinationType != "" {
+				skipped[col.SourceName] = struct{}{}
+			}
+		}
+		pgTypes := make(map[string]string)
+		if pgSchema, ok := pgSchemas[tm.SourceTableIdentifier]; ok {
+			for _, col := range pgSchema.Columns {
+				pgTypes[col.Name] = col.Type
+			}
+		}
+
+		for _, col := range schema.Columns {
+			if _, ok := skipped[col.Name]; ok {
+				continue
+			}
+			kind := qvalue.QValueKind(col.Type)
+			reason := qvalue.LossyMapping(kind, col.TypeModifier, dstPeerType)
+			if pgType, ok := pgTypes[col.Name]; reason == "" && ok && kind == qvalue.QValueKindString {
+				if _, isText := postgresTextTypes[pgType]; !isText {
+					reason = fmt.Sprintf("postgres type %s is stored as text", pgType)
+				}
+			}
+			if reason != "" {
+				violations = append(violations, fmt.Errorf("column %s of %s: %s", col.Name, tm.SourceTableIdentifier, reason))
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("strict type mapping refuses %d lossily mapped columns: %w", len(violations), errors.Join(violations...))
+	}
+	return nil
+}
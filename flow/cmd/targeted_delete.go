@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// TargetedDelete deletes rows from the destinations of every CDC mirror replicating a table from the mirror's source,
+// for rows which have to be forgotten by replicas too, like ones of a user asking for their data to be erased.
+// Rows are expected to be gone from the source as well, else the next change to them replicates them again
+func (h *FlowRequestHandler) TargetedDelete(
+	ctx context.Context,
+	req *protos.TargetedDeleteRequest,
+) (*protos.TargetedDeleteResponse, error) {
+	if req.SourceTableIdentifier == "" {
+		return nil, errors.New("source table is required")
+	}
+	if (len(req.Keys) == 0) == (req.Predicate == "") {
+		return nil, errors.New("exactly one of keys or predicate is required")
+	}
+
+	cfg, err := h.getFlowConfigFromCatalog(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := h.pool.Query(ctx, `SELECT f.name FROM flows f JOIN peers p ON p.id = f.source_peer
+		WHERE p.name = $1 AND f.query_string IS NULL ORDER BY f.name`, cfg.SourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mirrors of source %s: %w", cfg.SourceName, err)
+	}
+	flowNames, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mirrors of source %s: %w", cfg.SourceName, err)
+	}
+
+	digest := targetedDeleteDigest(req)
+	auditID, err := monitoring.RecordTargetedDelete(ctx, h.pool, req.FlowJobName, req.SourceTableIdentifier,
+		req.RequestedBy, len(req.Keys), digest)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("deleting rows from destinations", slog.String(string(shared.FlowNameKey), req.FlowJobName),
+		slog.String("table", req.SourceTableIdentifier), slog.Int64("auditID", auditID),
+		slog.Int("keys", len(req.Keys)), slog.String("requestedBy", req.RequestedBy))
+
+	var results []*protos.TargetedDeleteResult
+	for _, flowName := range flowNames {
+		flowCfg, err := h.getFlowConfigFromCatalog(ctx, flowName)
+		if err != nil {
+			return nil, err
+		}
+		for _, tableMapping := range flowCfg.TableMappings {
+			if tableMapping.SourceTableIdentifier != req.SourceTableIdentifier {
+				continue
+			}
+			deleteErr := h.targetedDeleteTable(ctx, flowCfg, tableMapping, req)
+			if err := monitoring.FinishTargetedDelete(ctx, h.pool, auditID, flowName, flowCfg.DestinationName,
+				tableMapping.DestinationTableIdentifier, deleteErr); err != nil {
+				return nil, err
+			}
+			result := &protos.TargetedDeleteResult{
+				FlowJobName:                flowName,
+				DestinationTableIdentifier: tableMapping.DestinationTableIdentifier,
+				DestinationPeerName:        flowCfg.DestinationName,
+				Ok:                         deleteErr == nil,
+			}
+			if deleteErr != nil {
+				slog.Error("failed to delete rows from destination", slog.String(string(shared.FlowNameKey), flowName),
+					slog.String("table", tableMapping.DestinationTableIdentifier), slog.Any("error", deleteErr))
+				result.Message = deleteErr.Error()
+			}
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no mirror of source %s replicates %s", cfg.SourceName, req.SourceTableIdentifier)
+	}
+	return &protos.TargetedDeleteResponse{AuditId: auditID, Digest: digest, Results: results}, nil
+}
+
+func (h *FlowRequestHandler) targetedDeleteTable(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	tableMapping *protos.TableMapping,
+	req *protos.TargetedDeleteRequest,
+) error {
+	var keyColumns []string
+	keys := make([][]any, 0, len(req.Keys))
+	if len(req.Keys) > 0 {
+		schemas, err := monitoring.GetLatestSourceSchemas(ctx, h.pool, cfg.FlowJobName)
+		if err != nil {
+			return err
+		}
+		tableSchema := schemas[tableMapping.SourceTableIdentifier]
+		if tableSchema == nil || len(tableSchema.PrimaryKeyColumns) == 0 {
+			return fmt.Errorf("%s has no primary key, rows can only be deleted by predicate", req.SourceTableIdentifier)
+		}
+		renames := make(map[string]string, len(tableMapping.Columns))
+		for _, col := range tableMapping.Columns {
+			if col.DestinationName != "" {
+				renames[col.SourceName] = col.DestinationName
+			}
+		}
+		for _, keyColumn := range tableSchema.PrimaryKeyColumns {
+			if rename, ok := renames[keyColumn]; ok {
+				keyColumn = rename
+			}
+			keyColumns = append(keyColumns, keyColumn)
+		}
+		for _, key := range req.Keys {
+			if len(key.Values) != len(keyColumns) {
+				return fmt.Errorf("keys of %s have %d values, one for each of %v", req.SourceTableIdentifier,
+					len(keyColumns), tableSchema.PrimaryKeyColumns)
+			}
+			values := make([]any, 0, len(key.Values))
+			for _, value := range key.Values {
+				values = append(values, value)
+			}
+			keys = append(keys, values)
+		}
+	}
+
+	dstConn, err := connectors.GetByNameAs[connectors.RowDeleteConnector](ctx, cfg.Env, h.pool, cfg.DestinationName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return fmt.Errorf("destination %s does not support targeted deletes", cfg.DestinationName)
+		}
+		return fmt.Errorf("failed to connect to destination %s: %w", cfg.DestinationName, err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+	return dstConn.DeleteRows(ctx, tableMapping.DestinationTableIdentifier, keyColumns, keys, req.Predicate)
+}
+
+// targetedDeleteDigest identifies what was asked to be deleted without keeping it,
+// hashing the same keys or predicate again shows whether a request is in the audit trail
+func targetedDeleteDigest(req *protos.TargetedDeleteRequest) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.SourceTableIdentifier))
+	hash.Write([]byte{0})
+	if len(req.Keys) == 0 {
+		hash.Write([]byte(req.Predicate))
+	}
+	for _, key := range req.Keys {
+		for _, value := range key.Values {
+			hash.Write([]byte(value))
+			hash.Write([]byte{0})
+		}
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
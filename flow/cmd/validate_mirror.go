@@ -6,22 +6,40 @@ import (
 	"fmt"
 	"log/slog"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/PeerDB-io/peer-flow/connectors"
 	connclickhouse "github.com/PeerDB-io/peer-flow/connectors/clickhouse"
+	connmysql "github.com/PeerDB-io/peer-flow/connectors/mysql"
+	connoracle "github.com/PeerDB-io/peer-flow/connectors/oracle"
 	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/anonymize"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
 	"github.com/PeerDB-io/peer-flow/shared/telemetry"
 )
 
 var (
 	CustomColumnTypeRegex = regexp.MustCompile(`^$|^[a-zA-Z][a-zA-Z0-9(),]*$`)
 	CustomColumnNameRegex = regexp.MustCompile(`^$|^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// codecs like Delta, ZSTD(3) chained by commas
+	CustomColumnCodecRegex = regexp.MustCompile(`^$|^[a-zA-Z][a-zA-Z0-9]*(\([0-9]+\))?(, *[a-zA-Z][a-zA-Z0-9]*(\([0-9]+\))?)*$`)
 )
 
+// destinations normalized batches can be written to a control table of
+var batchControlDestinations = []protos.DBType{
+	protos.DBType_POSTGRES,
+	protos.DBType_SNOWFLAKE,
+	protos.DBType_BIGQUERY,
+	protos.DBType_CLICKHOUSE,
+}
+
 func (h *FlowRequestHandler) ValidateCDCMirror(
 	ctx context.Context, req *protos.CreateCDCFlowRequest,
 ) (*protos.ValidateCDCMirrorResponse, error) {
@@ -51,6 +69,86 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 			Ok: false,
 		}, errors.New("connection configs is nil")
 	}
+	// applied here rather than at creation so validation sees the mirror as CreateCDCFlow will start it,
+	// options already set are left alone so applying again is harmless
+	mirrorDefaults, err := h.loadPeerMirrorDefaults(ctx, req.ConnectionConfigs.DestinationName)
+	if err != nil {
+		slog.Error("/validatecdc failed to load mirror defaults of destination peer", slog.Any("error", err))
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+	shared.ApplyMirrorDefaults(req.ConnectionConfigs, mirrorDefaults)
+	if req.ConnectionConfigs.InitialSnapshotOnly && !req.ConnectionConfigs.DoInitialSnapshot {
+		displayErr := errors.New("initial snapshot only mirrors need initial snapshot enabled")
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+	if maintenance := req.ConnectionConfigs.Maintenance; maintenance != nil &&
+		(maintenance.WindowStartHour > 23 || maintenance.WindowEndHour > 23) {
+		displayErr := errors.New("maintenance window hours must be between 0 and 23")
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+	if err := shared.ValidateMirrorLabels(req.ConnectionConfigs.Labels); err != nil {
+		displayErr := fmt.Errorf("invalid mirror labels: %w", err)
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+	if err := h.validateMirrorDependencies(ctx, req.ConnectionConfigs); err != nil {
+		displayErr := fmt.Errorf("invalid mirror dependencies: %w", err)
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+	if err := shared.ValidateTruncatePolicy(req.ConnectionConfigs); err != nil {
+		displayErr := fmt.Errorf("invalid truncate policy: %w", err)
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+	if messageTable := req.ConnectionConfigs.LogicalMessageDestination; messageTable != "" {
+		for _, tm := range req.ConnectionConfigs.TableMappings {
+			if tm.DestinationTableIdentifier == messageTable {
+				displayErr := fmt.Errorf("logical message destination %s is also the destination of table %s",
+					messageTable, tm.SourceTableIdentifier)
+				h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+					fmt.Sprint(displayErr),
+				)
+				return &protos.ValidateCDCMirrorResponse{
+					Ok: false,
+				}, displayErr
+			}
+		}
+	}
+	if err := h.validateStagingRegions(ctx, req.ConnectionConfigs.Env, req.ConnectionConfigs.DestinationName,
+		req.ConnectionConfigs.SnapshotStagingPath, req.ConnectionConfigs.CdcStagingPath,
+	); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
 	sourcePeer, err := connectors.LoadPeer(ctx, h.pool, req.ConnectionConfigs.SourceName)
 	if err != nil {
 		slog.Error("/validatecdc failed to load source peer", slog.String("peer", req.ConnectionConfigs.SourceName))
@@ -59,6 +157,48 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 		}, err
 	}
 
+	if syntheticConfig := sourcePeer.GetSyntheticConfig(); syntheticConfig != nil {
+		if err := h.validateSyntheticSourceMirror(ctx, req.ConnectionConfigs, syntheticConfig); err != nil {
+			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+				fmt.Sprint(err),
+			)
+			return &protos.ValidateCDCMirrorResponse{
+				Ok: false,
+			}, err
+		}
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: true,
+		}, nil
+	}
+
+	if oracleConfig := sourcePeer.GetOracleConfig(); oracleConfig != nil {
+		if err := h.validateOracleSourceMirror(ctx, req.ConnectionConfigs, oracleConfig); err != nil {
+			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+				fmt.Sprint(err),
+			)
+			return &protos.ValidateCDCMirrorResponse{
+				Ok: false,
+			}, err
+		}
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: true,
+		}, nil
+	}
+
+	if mysqlConfig := sourcePeer.GetMysqlConfig(); mysqlConfig != nil {
+		if err := h.validateMySqlSourceMirror(ctx, req.ConnectionConfigs, mysqlConfig); err != nil {
+			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+				fmt.Sprint(err),
+			)
+			return &protos.ValidateCDCMirrorResponse{
+				Ok: false,
+			}, err
+		}
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: true,
+		}, nil
+	}
+
 	sourcePeerConfig := sourcePeer.GetPostgresConfig()
 	if sourcePeerConfig == nil {
 		slog.Error("/validatecdc source peer config is not postgres", slog.String("peer", req.ConnectionConfigs.SourceName))
@@ -122,11 +262,52 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 		srcTableNames = append(srcTableNames, tableMapping.SourceTableIdentifier)
 	}
 
+	nonReplicable, err := pgPeer.NonReplicableTables(ctx, sourceTables)
+	if err != nil {
+		displayErr := fmt.Errorf("failed to check source tables: %v", err)
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+	sourceTables, excludedTables := excludeNonReplicableTables(req.ConnectionConfigs, sourceTables, nonReplicable, noCDC)
+	for _, excludedTable := range excludedTables {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprintf("excluding source table %s from mirror: %s", excludedTable.SourceTableIdentifier, excludedTable.Reason),
+		)
+	}
+	srcTableNames = srcTableNames[:0]
+	for _, tableMapping := range req.ConnectionConfigs.TableMappings {
+		srcTableNames = append(srcTableNames, tableMapping.SourceTableIdentifier)
+	}
+	// tables copied by the snapshot only are left out of publication checks
+	replicatedTables := make([]*utils.SchemaTable, 0, len(sourceTables))
+	snapshotOnlyTables := make([]*utils.SchemaTable, 0)
+	for i, tableMapping := range req.ConnectionConfigs.TableMappings {
+		if tableMapping.SnapshotOnly {
+			snapshotOnlyTables = append(snapshotOnlyTables, sourceTables[i])
+		} else {
+			replicatedTables = append(replicatedTables, sourceTables[i])
+		}
+	}
+	if len(replicatedTables) == 0 && (!noCDC || len(snapshotOnlyTables) == 0) {
+		displayErr := errors.New("none of the source tables can be replicated")
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok:             false,
+			ExcludedTables: excludedTables,
+		}, displayErr
+	}
+
 	pubName := req.ConnectionConfigs.PublicationName
 
 	if pubName == "" && !noCDC {
-		srcTableNames := make([]string, 0, len(sourceTables))
-		for _, srcTable := range sourceTables {
+		srcTableNames := make([]string, 0, len(replicatedTables))
+		for _, srcTable := range replicatedTables {
 			srcTableNames = append(srcTableNames, fmt.Sprintf(`%s.%s`,
 				connpostgres.QuoteIdentifier(srcTable.Schema),
 				connpostgres.QuoteIdentifier(srcTable.Table)),
@@ -134,17 +315,33 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 		}
 
 		if err := pgPeer.CheckPublicationCreationPermissions(ctx, srcTableNames); err != nil {
-			displayErr := fmt.Errorf("invalid publication creation permissions: %v", err)
-			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
-				fmt.Sprint(displayErr),
-			)
-			return &protos.ValidateCDCMirrorResponse{
-				Ok: false,
-			}, displayErr
+			// mirrors fall back to decoding with wal2json when it's installed, which needs no publication
+			if fallback, fallbackErr := pgPeer.FallbackDecodingPlugin(ctx, err); fallbackErr == nil && fallback != "" {
+				slog.Info("publication can't be created, mirror will decode with "+fallback,
+					slog.String("flowName", req.ConnectionConfigs.FlowJobName), slog.Any("error", err))
+			} else {
+				displayErr := fmt.Errorf("invalid publication creation permissions: %v", err)
+				h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+					fmt.Sprint(displayErr),
+				)
+				return &protos.ValidateCDCMirrorResponse{
+					Ok: false,
+				}, displayErr
+			}
 		}
 	}
 
-	if err := pgPeer.CheckSourceTables(ctx, sourceTables, pubName, noCDC); err != nil {
+	if err := pgPeer.CheckSourceTables(ctx, replicatedTables, pubName, noCDC); err != nil {
+		displayErr := fmt.Errorf("provided source tables invalidated: %v", err)
+		slog.Error(displayErr.Error())
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+	if err := pgPeer.CheckSourceTables(ctx, snapshotOnlyTables, "", true); err != nil {
 		displayErr := fmt.Errorf("provided source tables invalidated: %v", err)
 		slog.Error(displayErr.Error())
 		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
@@ -167,6 +364,11 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 					Ok: false,
 				}, fmt.Errorf("invalid custom column name %s", col.DestinationName)
 			}
+			if !CustomColumnCodecRegex.MatchString(col.Codec) {
+				return &protos.ValidateCDCMirrorResponse{
+					Ok: false,
+				}, fmt.Errorf("invalid custom column codec %s", col.Codec)
+			}
 		}
 	}
 
@@ -177,10 +379,203 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 			Ok: false,
 		}, err
 	}
-	if dstPeer.GetClickhouseConfig() != nil {
-		chPeer, err := connclickhouse.NewClickhouseConnector(ctx, nil, dstPeer.GetClickhouseConfig())
+	if err := validateShadowMode(req.ConnectionConfigs, dstPeer.Type); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+	if err := validateTypeWidening(req.ConnectionConfigs, dstPeer.Type); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+	if err := validateBidirectionalMirror(req.ConnectionConfigs, dstPeer.Type); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+	if err := validateConflictPolicy(req.ConnectionConfigs, dstPeer.Type); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+	if err := validateRetentionPolicies(req.ConnectionConfigs, dstPeer.Type); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+	if req.ConnectionConfigs.BatchControlTable && !slices.Contains(batchControlDestinations, dstPeer.Type) {
+		displayErr := fmt.Errorf("batch control table is not supported for %s destinations", dstPeer.Type)
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+	res, err := pgPeer.GetTableSchema(ctx, &protos.GetTableSchemaBatchInput{
+		TableIdentifiers: srcTableNames,
+		System:           protos.TypeSystem_PG,
+	})
+	if err != nil {
+		displayErr := fmt.Errorf("failed to get source table schema: %v", err)
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+
+	if err := validateDictionaries(req.ConnectionConfigs, dstPeer.Type, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateSnapshotExport(req.ConnectionConfigs, dstPeer.Type); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateDependentViews(req.ConnectionConfigs, dstPeer.Type); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateProjections(req.ConnectionConfigs, dstPeer.Type); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateTimeSeriesMappings(req.ConnectionConfigs, dstPeer.Type, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateGraphMappings(req.ConnectionConfigs, dstPeer.Type, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validatePrimaryKeyOverrides(req.ConnectionConfigs, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateTenantRouting(req.ConnectionConfigs, dstPeer.Type, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := h.validateColumnEncryption(ctx, req.ConnectionConfigs, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateAnonymization(req.ConnectionConfigs, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateRowSecurity(req.ConnectionConfigs, dstPeer.Type, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateVectorMappings(req.ConnectionConfigs, dstPeer, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateQueueEncoding(req.ConnectionConfigs, dstPeer.Type, res.TableNameSchemaMapping); err != nil {
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(err),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, err
+	}
+
+	if err := validateDestinationLimits(ctx, pgPeer, req.ConnectionConfigs, dstPeer.Type, res.TableNameSchemaMapping); err != nil {
+		displayErr := fmt.Errorf("source tables exceed limits of destination: %w", err)
+		h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+			fmt.Sprint(displayErr),
+		)
+		return &protos.ValidateCDCMirrorResponse{
+			Ok: false,
+		}, displayErr
+	}
+
+	if req.ConnectionConfigs.StrictTypeMapping && req.ConnectionConfigs.System == protos.TypeSystem_Q {
+		qRes, err := pgPeer.GetTableSchema(ctx, &protos.GetTableSchemaBatchInput{
+			TableIdentifiers: srcTableNames,
+			System:           protos.TypeSystem_Q,
+		})
 		if err != nil {
-			displayErr := fmt.Errorf("failed to create clickhouse connector: %v", err)
+			displayErr := fmt.Errorf("failed to get source table schema: %v", err)
 			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
 				fmt.Sprint(displayErr),
 			)
@@ -188,14 +583,22 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 				Ok: false,
 			}, displayErr
 		}
-		defer chPeer.Close()
+		if err := validateStrictTypeMapping(
+			req.ConnectionConfigs, dstPeer.Type, qRes.TableNameSchemaMapping, res.TableNameSchemaMapping,
+		); err != nil {
+			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+				fmt.Sprint(err),
+			)
+			return &protos.ValidateCDCMirrorResponse{
+				Ok: false,
+			}, err
+		}
+	}
 
-		res, err := pgPeer.GetTableSchema(ctx, &protos.GetTableSchemaBatchInput{
-			TableIdentifiers: srcTableNames,
-			System:           protos.TypeSystem_PG,
-		})
+	if dstPeer.GetClickhouseConfig() != nil {
+		chPeer, err := connclickhouse.NewClickhouseConnector(ctx, nil, dstPeer.GetClickhouseConfig())
 		if err != nil {
-			displayErr := fmt.Errorf("failed to get source table schema: %v", err)
+			displayErr := fmt.Errorf("failed to create clickhouse connector: %v", err)
 			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
 				fmt.Sprint(displayErr),
 			)
@@ -203,6 +606,7 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 				Ok: false,
 			}, displayErr
 		}
+		defer chPeer.Close()
 
 		err = chPeer.CheckDestinationTables(ctx, req.ConnectionConfigs, res.TableNameSchemaMapping)
 		if err != nil {
@@ -216,13 +620,719 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 	}
 
 	return &protos.ValidateCDCMirrorResponse{
-		Ok: true,
+		Ok:             true,
+		ExcludedTables: excludedTables,
 	}, nil
 }
 
+// excludeNonReplicableTables removes tables logical replication can't replicate from the mirror, returning the
+// tables of remaining table mappings. Without CDC only temporary tables are removed as the others can be copied,
+// unlogged tables are kept to be copied by the snapshot only and materialized views and foreign tables
+// to be copied again on an interval when the mirror asks for it
+func excludeNonReplicableTables(
+	cfg *protos.FlowConnectionConfigs,
+	sourceTables []*utils.SchemaTable,
+	nonReplicable map[utils.SchemaTable]string,
+	noCDC bool,
+) ([]*utils.SchemaTable, []*protos.ExcludedTable) {
+	if len(nonReplicable) == 0 {
+		return sourceTables, nil
+	}
+	var excludedTables []*protos.ExcludedTable
+	tableMappings := make([]*protos.TableMapping, 0, len(cfg.TableMappings))
+	keptTables := make([]*utils.SchemaTable, 0, len(sourceTables))
+	for i, tableMapping := range cfg.TableMappings {
+		kind, ok := nonReplicable[*sourceTables[i]]
+		if ok && !(noCDC && kind != "temporary table") {
+			if kind == "unlogged table" && cfg.SnapshotUnloggedTables && cfg.DoInitialSnapshot {
+				tableMapping.SnapshotOnly = true
+			} else if (kind == "materialized view" || kind == "foreign table") && cfg.RelationRefreshIntervalSeconds > 0 {
+				tableMapping.SnapshotOnly = true
+				tableMapping.RefreshIntervalSeconds = cfg.RelationRefreshIntervalSeconds
+			} else {
+				excludedTables = append(excludedTables, &protos.ExcludedTable{
+					SourceTableIdentifier: tableMapping.SourceTableIdentifier,
+					Reason:                fmt.Sprintf("%ss can't be logically replicated", kind),
+				})
+				continue
+			}
+		}
+		tableMappings = append(tableMappings, tableMapping)
+		keptTables = append(keptTables, sourceTables[i])
+	}
+	cfg.TableMappings = tableMappings
+	return keptTables, excludedTables
+}
+
+func validateShadowMode(cfg *protos.FlowConnectionConfigs, dstPeerType protos.DBType) error {
+	if !cfg.ShadowMode {
+		return nil
+	}
+	switch dstPeerType {
+	case protos.DBType_POSTGRES, protos.DBType_SNOWFLAKE, protos.DBType_BIGQUERY:
+		return nil
+	default:
+		return fmt.Errorf("shadow mode is not supported for %s destinations", dstPeerType)
+	}
+}
+
+// Fabric warehouses can't change the type of columns, so widened columns would be left as they are
+func validateTypeWidening(cfg *protos.FlowConnectionConfigs, dstPeerType protos.DBType) error {
+	if cfg.TypeWideningPolicy == protos.TypeWideningPolicy_TYPE_WIDENING_POLICY_LOSSLESS && dstPeerType == protos.DBType_FABRIC {
+		return fmt.Errorf("type widening is not supported for %s destinations", dstPeerType)
+	}
+	return nil
+}
+
+// dictionaries are keyed by primary key, and only ClickHouse has them
+func validateDictionaries(
+	cfg *protos.FlowConnectionConfigs,
+	dstPeerType protos.DBType,
+	tableSchemas map[string]*protos.TableSchema,
+) error {
+	for _, tableMapping := range cfg.TableMappings {
+		if tableMapping.Dictionary == nil {
+			continue
+		}
+		if dstPeerType != protos.DBType_CLICKHOUSE {
+			return fmt.Errorf("dictionaries are not supported for %s destinations", dstPeerType)
+		}
+		if schema := tableSchemas[tableMapping.SourceTableIdentifier]; schema != nil && len(schema.PrimaryKeyColumns) == 0 {
+			return fmt.Errorf("dictionary of %s needs a primary key on %s",
+				tableMapping.DestinationTableIdentifier, tableMapping.SourceTableIdentifier)
+		}
+	}
+	return nil
+}
+
+// snapshots are exported as Native parts for ClickHouse and staged Avro for Snowflake,
+// loading them later only keeps changes replicated meanwhile when ClickHouse versions resolve rows
+func validateSnapshotExport(cfg *protos.FlowConnectionConfigs, dstPeerType protos.DBType) error {
+	if cfg.SnapshotExportPath == "" {
+		return nil
+	}
+	if !strings.HasPrefix(cfg.SnapshotExportPath, "s3://") {
+		return fmt.Errorf("snapshot export path %s is not an s3:// path", cfg.SnapshotExportPath)
+	}
+	if !cfg.DoInitialSnapshot {
+		return errors.New("snapshot export path needs initial snapshot enabled")
+	}
+	switch dstPeerType {
+	case protos.DBType_CLICKHOUSE:
+		return nil
+	case protos.DBType_SNOWFLAKE:
+		if !cfg.InitialSnapshotOnly {
+			return errors.New("snapshot export to snowflake needs an initial snapshot only mirror, " +
+				"as loading the export later would overwrite changes replicated meanwhile")
+		}
+		return nil
+	default:
+		return fmt.Errorf("snapshot export is not supported for %s destinations", dstPeerType)
+	}
+}
+
+// dependent views are looked up by name when refreshed or swapped, Postgres ones needing their schema
+func validateDependentViews(cfg *protos.FlowConnectionConfigs, dstPeerType protos.DBType) error {
+	views := make(map[string]struct{})
+	for _, tableMapping := range cfg.TableMappings {
+		if len(tableMapping.DependentViews) == 0 {
+			continue
+		}
+		if dstPeerType != protos.DBType_POSTGRES && dstPeerType != protos.DBType_CLICKHOUSE {
+			return fmt.Errorf("dependent views are not supported for %s destinations", dstPeerType)
+		}
+		for _, view := range tableMapping.DependentViews {
+			if view.Name == "" || strings.ContainsAny(view.Name, "`\"") {
+				return fmt.Errorf("invalid dependent view name %s of %s", view.Name, tableMapping.DestinationTableIdentifier)
+			}
+			if dstPeerType == protos.DBType_POSTGRES {
+				if _, err := utils.ParseSchemaTable(view.Name); err != nil {
+					return fmt.Errorf("dependent view %s of %s is not schema qualified",
+						view.Name, tableMapping.DestinationTableIdentifier)
+				}
+			}
+			if _, ok := views[view.Name]; ok {
+				return fmt.Errorf("dependent view %s configured more than once", view.Name)
+			}
+			views[view.Name] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// projections are named in DDL and their query is checked by ClickHouse when adding them after the initial load,
+// so only obviously broken ones are refused here
+func validateProjections(cfg *protos.FlowConnectionConfigs, dstPeerType protos.DBType) error {
+	for _, tableMapping := range cfg.TableMappings {
+		if len(tableMapping.Projections) == 0 {
+			continue
+		}
+		if dstPeerType != protos.DBType_CLICKHOUSE {
+			return fmt.Errorf("projections are not supported for %s destinations", dstPeerType)
+		}
+		names := make(map[string]struct{}, len(tableMapping.Projections))
+		for _, projection := range tableMapping.Projections {
+			if projection.Name == "" || !CustomColumnNameRegex.MatchString(projection.Name) {
+				return fmt.Errorf("invalid projection name %s of %s", projection.Name, tableMapping.DestinationTableIdentifier)
+			}
+			if _, ok := names[projection.Name]; ok {
+				return fmt.Errorf("projection %s of %s configured more than once",
+					projection.Name, tableMapping.DestinationTableIdentifier)
+			}
+			names[projection.Name] = struct{}{}
+			if query := strings.TrimSpace(projection.Query); len(query) < 6 || !strings.EqualFold(query[:6], "SELECT") {
+				return fmt.Errorf("query of projection %s of %s must be a SELECT",
+					projection.Name, tableMapping.DestinationTableIdentifier)
+			}
+		}
+	}
+	return nil
+}
+
+// time series mappings name source columns, which must exist for points to have them
+func validateTimeSeriesMappings(
+	cfg *protos.FlowConnectionConfigs,
+	dstPeerType protos.DBType,
+	tableSchemas map[string]*protos.TableSchema,
+) error {
+	for _, tableMapping := range cfg.TableMappings {
+		mapping := tableMapping.TimeSeries
+		if mapping == nil {
+			continue
+		}
+		if dstPeerType != protos.DBType_TIMESERIES {
+			return fmt.Errorf("time series mappings are not supported for %s destinations", dstPeerType)
+		}
+		schema := tableSchemas[tableMapping.SourceTableIdentifier]
+		if schema == nil {
+			continue
+		}
+		// schemas are of the PG type system
+		columns := make(map[string]string, len(schema.Columns))
+		for _, column := range schema.Columns {
+			columns[column.Name] = column.Type
+		}
+		if mapping.TimestampColumn != "" {
+			typeName, ok := columns[mapping.TimestampColumn]
+			if !ok {
+				return fmt.Errorf("timestamp column %s is not a column of %s",
+					mapping.TimestampColumn, tableMapping.SourceTableIdentifier)
+			}
+			if typeName != "timestamp" && typeName != "timestamptz" && typeName != "date" {
+				return fmt.Errorf("timestamp column %s of %s is not a date or timestamp",
+					mapping.TimestampColumn, tableMapping.SourceTableIdentifier)
+			}
+		}
+		for _, column := range slices.Concat(mapping.TagColumns, mapping.FieldColumns) {
+			if _, ok := columns[column]; !ok {
+				return fmt.Errorf("column %s is not a column of %s", column, tableMapping.SourceTableIdentifier)
+			}
+		}
+	}
+	return nil
+}
+
+// nodes are merged by primary key, relationships need columns to match target nodes by
+func validateGraphMappings(
+	cfg *protos.FlowConnectionConfigs,
+	dstPeerType protos.DBType,
+	tableSchemas map[string]*protos.TableSchema,
+) error {
+	for _, tableMapping := range cfg.TableMappings {
+		if dstPeerType != protos.DBType_NEO4J {
+			if tableMapping.Graph != nil {
+				return fmt.Errorf("graph mappings are not supported for %s destinations", dstPeerType)
+			}
+			continue
+		}
+		schema := tableSchemas[tableMapping.SourceTableIdentifier]
+		if schema == nil {
+			continue
+		}
+		if len(schema.PrimaryKeyColumns) == 0 {
+			return fmt.Errorf("nodes of %s need a primary key on %s",
+				tableMapping.DestinationTableIdentifier, tableMapping.SourceTableIdentifier)
+		}
+		for _, rel := range tableMapping.Graph.GetRelationships() {
+			if rel.Type == "" || rel.TargetLabel == "" || len(rel.Columns) == 0 {
+				return fmt.Errorf("relationships of %s need a type, a target label and columns",
+					tableMapping.DestinationTableIdentifier)
+			}
+			if len(rel.TargetProperties) != 0 && len(rel.TargetProperties) != len(rel.Columns) {
+				return fmt.Errorf("relationship %s of %s has %d target properties for %d columns",
+					rel.Type, tableMapping.DestinationTableIdentifier, len(rel.TargetProperties), len(rel.Columns))
+			}
+			for _, column := range rel.Columns {
+				if !slices.ContainsFunc(schema.Columns, func(fd *protos.FieldDescription) bool { return fd.Name == column }) {
+					return fmt.Errorf("column %s of relationship %s is not a column of %s",
+						column, rel.Type, tableMapping.SourceTableIdentifier)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// deletes of tenant rows only find their tenant when the old row has the tenant column
+func validateTenantRouting(
+	cfg *protos.FlowConnectionConfigs,
+	dstPeerType protos.DBType,
+	tableSchemas map[string]*protos.TableSchema,
+) error {
+	for _, tableMapping := range cfg.TableMappings {
+		routing := tableMapping.TenantRouting
+		if routing == nil {
+			continue
+		}
+		if dstPeerType != protos.DBType_POSTGRES {
+			return fmt.Errorf("tenant routing is not supported for %s destinations", dstPeerType)
+		}
+		if routing.TenantColumn == "" {
+			return fmt.Errorf("tenant routing of %s has no tenant column", tableMapping.SourceTableIdentifier)
+		}
+		if slices.Contains(tableMapping.Exclude, routing.TenantColumn) {
+			return fmt.Errorf("tenant column %s of %s is excluded", routing.TenantColumn, tableMapping.SourceTableIdentifier)
+		}
+		template := utils.TenantDestinationTemplate(routing, tableMapping.DestinationTableIdentifier)
+		if strings.Count(template, "{tenant}") != 1 {
+			return fmt.Errorf("tenant destination template %s needs {tenant} once", template)
+		}
+		if _, err := utils.ParseSchemaTable(template); err != nil {
+			return fmt.Errorf("tenant destination template %s is not a schema qualified table", template)
+		}
+		schema := tableSchemas[tableMapping.SourceTableIdentifier]
+		if schema == nil {
+			continue
+		}
+		if !slices.ContainsFunc(schema.Columns, func(column *protos.FieldDescription) bool {
+			return column.Name == routing.TenantColumn
+		}) {
+			return fmt.Errorf("tenant column %s is not a column of %s", routing.TenantColumn, tableMapping.SourceTableIdentifier)
+		}
+		if !schema.IsReplicaIdentityFull && !slices.Contains(schema.PrimaryKeyColumns, routing.TenantColumn) {
+			return fmt.Errorf("tenant column %s of %s needs to be part of its primary key or replica identity full",
+				routing.TenantColumn, tableMapping.SourceTableIdentifier)
+		}
+	}
+	return nil
+}
+
+// overriding keys identify rows in place of the primary key, so they need values in every change,
+// which old rows of deletes and updates only carry for key columns unless the source has replica identity full
+func validatePrimaryKeyOverrides(cfg *protos.FlowConnectionConfigs, tableSchemas map[string]*protos.TableSchema) error {
+	for _, tableMapping := range cfg.TableMappings {
+		if len(tableMapping.PrimaryKeyOverride) == 0 {
+			continue
+		}
+		encrypted := shared.EncryptedColumns(tableMapping)
+		schema := tableSchemas[tableMapping.SourceTableIdentifier]
+		seen := make(map[string]struct{}, len(tableMapping.PrimaryKeyOverride))
+		for _, column := range tableMapping.PrimaryKeyOverride {
+			if _, ok := seen[column]; ok {
+				return fmt.Errorf("primary key override of %s has column %s more than once",
+					tableMapping.SourceTableIdentifier, column)
+			}
+			seen[column] = struct{}{}
+			if slices.Contains(tableMapping.Exclude, column) {
+				return fmt.Errorf("primary key override column %s of %s is excluded", column, tableMapping.SourceTableIdentifier)
+			}
+			if encryption, ok := encrypted[column]; ok &&
+				encryption.Mode != protos.ColumnEncryptionMode_COLUMN_ENCRYPTION_DETERMINISTIC {
+				return fmt.Errorf("primary key override column %s of %s is randomly encrypted",
+					column, tableMapping.SourceTableIdentifier)
+			}
+			if schema == nil {
+				continue
+			}
+			idx := slices.IndexFunc(schema.Columns, func(field *protos.FieldDescription) bool {
+				return field.Name == column
+			})
+			if idx == -1 {
+				return fmt.Errorf("primary key override column %s is not a column of %s",
+					column, tableMapping.SourceTableIdentifier)
+			}
+			if schema.Columns[idx].Nullable {
+				return fmt.Errorf("primary key override column %s of %s is nullable",
+					column, tableMapping.SourceTableIdentifier)
+			}
+			if !schema.IsReplicaIdentityFull && !slices.Contains(schema.PrimaryKeyColumns, column) {
+				return fmt.Errorf("primary key override column %s of %s needs to be part of its primary key "+
+					"or replica identity full", column, tableMapping.SourceTableIdentifier)
+			}
+		}
+	}
+	return nil
+}
+
+// encrypted values are transformed by PeerDB, which rows of the PG type system are copied past,
+// and randomized ones would no longer identify rows or their tenant
+func (h *FlowRequestHandler) validateColumnEncryption(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	tableSchemas map[string]*protos.TableSchema,
+) error {
+	keys := make(map[string]struct{})
+	for _, tableMapping := range cfg.TableMappings {
+		encrypted := shared.EncryptedColumns(tableMapping)
+		if len(encrypted) == 0 {
+			continue
+		}
+		if cfg.System == protos.TypeSystem_PG {
+			return errors.New("column encryption is not supported with the PG type system")
+		}
+		schema := tableSchemas[tableMapping.SourceTableIdentifier]
+		for column, encryption := range encrypted {
+			if encryption.KeyName == "" {
+				return fmt.Errorf("encrypted column %s of %s has no key", column, tableMapping.SourceTableIdentifier)
+			}
+			if _, ok := keys[encryption.KeyName]; !ok {
+				if _, err := monitoring.GetColumnEncryptionKey(ctx, h.pool, encryption.KeyName); err != nil {
+					return err
+				}
+				keys[encryption.KeyName] = struct{}{}
+			}
+			if tableMapping.TenantRouting.GetTenantColumn() == column {
+				return fmt.Errorf("tenant column %s of %s can't be encrypted", column, tableMapping.SourceTableIdentifier)
+			}
+			if schema == nil {
+				continue
+			}
+			if !slices.ContainsFunc(schema.Columns, func(field *protos.FieldDescription) bool {
+				return field.Name == column
+			}) {
+				return fmt.Errorf("encrypted column %s is not a column of %s", column, tableMapping.SourceTableIdentifier)
+			}
+			if encryption.Mode == protos.ColumnEncryptionMode_COLUMN_ENCRYPTION_RANDOMIZED &&
+				slices.Contains(schema.PrimaryKeyColumns, column) {
+				return fmt.Errorf("primary key column %s of %s needs deterministic encryption",
+					column, tableMapping.SourceTableIdentifier)
+			}
+		}
+	}
+	return nil
+}
+
+// rules of every table only apply to tables having the column, synthetic values need to fit the column
+// and anonymized values of keys are derived alike, but nulls would no longer identify rows
+func validateAnonymization(cfg *protos.FlowConnectionConfigs, tableSchemas map[string]*protos.TableSchema) error {
+	rules := cfg.Anonymization.GetRules()
+	if len(rules) == 0 {
+		return nil
+	}
+	if cfg.Anonymization.Seed == "" {
+		return errors.New("anonymization profile needs a seed")
+	}
+	if cfg.System == protos.TypeSystem_PG {
+		return errors.New("anonymization is not supported with the PG type system")
+	}
+	for _, rule := range rules {
+		if rule.Column == "" || rule.Value == protos.SyntheticValue_SYNTHETIC_NONE {
+			return errors.New("anonymization rules need a column and a synthetic value")
+		}
+		if rule.SourceTableIdentifier != "" && !slices.ContainsFunc(cfg.TableMappings, func(mapping *protos.TableMapping) bool {
+			return mapping.SourceTableIdentifier == rule.SourceTableIdentifier
+		}) {
+			return fmt.Errorf("anonymized table %s is not part of the mirror", rule.SourceTableIdentifier)
+		}
+	}
+	for _, tableMapping := range cfg.TableMappings {
+		encrypted := shared.EncryptedColumns(tableMapping)
+		schema := tableSchemas[tableMapping.SourceTableIdentifier]
+		for column, value := range shared.AnonymizedColumns(cfg.Anonymization, tableMapping) {
+			if _, ok := encrypted[column]; ok {
+				return fmt.Errorf("column %s of %s can't be both encrypted and anonymized", column, tableMapping.SourceTableIdentifier)
+			}
+			if tableMapping.TenantRouting.GetTenantColumn() == column {
+				return fmt.Errorf("tenant column %s of %s can't be anonymized", column, tableMapping.SourceTableIdentifier)
+			}
+			if schema == nil {
+				continue
+			}
+			fieldIdx := slices.IndexFunc(schema.Columns, func(field *protos.FieldDescription) bool {
+				return field.Name == column
+			})
+			if fieldIdx == -1 {
+				if slices.ContainsFunc(rules, func(rule *protos.AnonymizationRule) bool {
+					return rule.SourceTableIdentifier == tableMapping.SourceTableIdentifier && rule.Column == column
+				}) {
+					return fmt.Errorf("anonymized column %s is not a column of %s", column, tableMapping.SourceTableIdentifier)
+				}
+				continue
+			}
+			field := schema.Columns[fieldIdx]
+			if !anonymize.Supports(value, qvalue.QValueKind(field.Type)) {
+				return fmt.Errorf("synthetic value %s is not supported for column %s of %s with type %s",
+					value, column, tableMapping.SourceTableIdentifier, field.Type)
+			}
+			if value == protos.SyntheticValue_SYNTHETIC_NULL && slices.Contains(schema.PrimaryKeyColumns, column) {
+				return fmt.Errorf("column %s of %s can't be anonymized as null", column, tableMapping.SourceTableIdentifier)
+			}
+		}
+	}
+	return nil
+}
+
+// tags come from plain values of the source column, and tenant tables are created without the policy
+func validateRowSecurity(
+	cfg *protos.FlowConnectionConfigs,
+	dstPeerType protos.DBType,
+	tableSchemas map[string]*protos.TableSchema,
+) error {
+	for _, tableMapping := range cfg.TableMappings {
+		security := tableMapping.RowSecurity
+		if security == nil {
+			continue
+		}
+		if dstPeerType != protos.DBType_POSTGRES && dstPeerType != protos.DBType_SNOWFLAKE {
+			return fmt.Errorf("row security is not supported for %s destinations", dstPeerType)
+		}
+		if cfg.System == protos.TypeSystem_PG {
+			return errors.New("row security is not supported with the PG type system")
+		}
+		if security.TagColumn == "" {
+			return fmt.Errorf("row security of %s has no tag column", tableMapping.SourceTableIdentifier)
+		}
+		if (security.SourceColumn == "") == (security.Classification == "") {
+			return fmt.Errorf("row security of %s needs either a source column or a classification",
+				tableMapping.SourceTableIdentifier)
+		}
+		if security.TagColumn == cfg.SoftDeleteColName || security.TagColumn == cfg.SyncedAtColName {
+			return fmt.Errorf("tag column %s of %s is a column PeerDB writes already", security.TagColumn,
+				tableMapping.SourceTableIdentifier)
+		}
+		if tableMapping.TenantRouting != nil {
+			return fmt.Errorf("row security of %s is not supported with tenant routing", tableMapping.SourceTableIdentifier)
+		}
+		if security.SourceColumn != "" {
+			if slices.Contains(tableMapping.Exclude, security.SourceColumn) {
+				return fmt.Errorf("row security source column %s of %s is excluded",
+					security.SourceColumn, tableMapping.SourceTableIdentifier)
+			}
+			if _, ok := shared.EncryptedColumns(tableMapping)[security.SourceColumn]; ok {
+				return fmt.Errorf("row security source column %s of %s can't be encrypted",
+					security.SourceColumn, tableMapping.SourceTableIdentifier)
+			}
+			if _, ok := shared.AnonymizedColumns(cfg.Anonymization, tableMapping)[security.SourceColumn]; ok {
+				return fmt.Errorf("row security source column %s of %s can't be anonymized",
+					security.SourceColumn, tableMapping.SourceTableIdentifier)
+			}
+		}
+		schema := tableSchemas[tableMapping.SourceTableIdentifier]
+		if schema == nil {
+			continue
+		}
+		hasColumn := func(name string) bool {
+			return slices.ContainsFunc(schema.Columns, func(field *protos.FieldDescription) bool {
+				return field.Name == name
+			})
+		}
+		if hasColumn(security.TagColumn) {
+			return fmt.Errorf("tag column %s is already a column of %s", security.TagColumn, tableMapping.SourceTableIdentifier)
+		}
+		if security.SourceColumn != "" && !hasColumn(security.SourceColumn) {
+			return fmt.Errorf("row security source column %s is not a column of %s",
+				security.SourceColumn, tableMapping.SourceTableIdentifier)
+		}
+	}
+	return nil
+}
+
+// points are identified by primary keys and need a vector column or text to embed
+func validateVectorMappings(
+	cfg *protos.FlowConnectionConfigs,
+	dstPeer *protos.Peer,
+	tableSchemas map[string]*protos.TableSchema,
+) error {
+	for _, tableMapping := range cfg.TableMappings {
+		if dstPeer.Type != protos.DBType_VECTOR {
+			if tableMapping.Vector != nil {
+				return fmt.Errorf("vector mappings are not supported for %s destinations", dstPeer.Type)
+			}
+			continue
+		}
+		mapping := tableMapping.Vector
+		if mapping.GetVectorColumn() == "" && len(mapping.GetTextColumns()) == 0 {
+			return fmt.Errorf("points of %s need a vector column or text columns", tableMapping.DestinationTableIdentifier)
+		}
+		if mapping.GetVectorColumn() == "" && dstPeer.GetVectorConfig().GetEmbeddingUrl() == "" {
+			return fmt.Errorf("text columns of %s need an embedding url on peer %s",
+				tableMapping.DestinationTableIdentifier, dstPeer.Name)
+		}
+		schema := tableSchemas[tableMapping.SourceTableIdentifier]
+		if schema == nil {
+			continue
+		}
+		if len(schema.PrimaryKeyColumns) == 0 {
+			return fmt.Errorf("points of %s need a primary key on %s",
+				tableMapping.DestinationTableIdentifier, tableMapping.SourceTableIdentifier)
+		}
+		columns := slices.Concat(mapping.GetTextColumns(), mapping.GetPayloadColumns())
+		if mapping.GetVectorColumn() != "" {
+			columns = append(columns, mapping.GetVectorColumn())
+		}
+		for _, column := range columns {
+			if !slices.ContainsFunc(schema.Columns, func(fd *protos.FieldDescription) bool { return fd.Name == column }) {
+				return fmt.Errorf("column %s of vector mapping is not a column of %s", column, tableMapping.SourceTableIdentifier)
+			}
+		}
+	}
+	return nil
+}
+
+// keyed encodings rely on Kafka record keys and tombstones, which scripts would replace
+func validateQueueEncoding(
+	cfg *protos.FlowConnectionConfigs,
+	dstPeerType protos.DBType,
+	tableSchemas map[string]*protos.TableSchema,
+) error {
+	if cfg.QueueEncoding != protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM &&
+		cfg.QueueEncoding != protos.QueueEncoding_QUEUE_ENCODING_UPSERT {
+		return nil
+	}
+	if dstPeerType != protos.DBType_KAFKA {
+		return fmt.Errorf("queue encoding %s is not supported for %s destinations", cfg.QueueEncoding, dstPeerType)
+	}
+	if cfg.Script != "" {
+		return fmt.Errorf("queue encoding %s cannot be combined with a script", cfg.QueueEncoding)
+	}
+	if cfg.QueueEncoding == protos.QueueEncoding_QUEUE_ENCODING_UPSERT {
+		for _, tableMapping := range cfg.TableMappings {
+			if schema := tableSchemas[tableMapping.SourceTableIdentifier]; schema != nil && len(schema.PrimaryKeyColumns) == 0 {
+				return fmt.Errorf("upsert queue encoding needs a primary key on %s", tableMapping.SourceTableIdentifier)
+			}
+		}
+	}
+	return nil
+}
+
+// synthetic sources generate their own traffic, so only the mirror config needs checking
+func (h *FlowRequestHandler) validateSyntheticSourceMirror(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	syntheticConfig *protos.SyntheticConfig,
+) error {
+	if cfg.DoInitialSnapshot {
+		return errors.New("synthetic sources do not support initial snapshot")
+	}
+	if cfg.SourceIdentifier != "" {
+		return errors.New("synthetic sources do not support source identifier")
+	}
+
+	tables := make(map[string]struct{}, len(syntheticConfig.Tables))
+	for _, table := range syntheticConfig.Tables {
+		tables[table.Name] = struct{}{}
+	}
+	for _, tableMapping := range cfg.TableMappings {
+		if _, ok := tables[tableMapping.SourceTableIdentifier]; !ok {
+			return fmt.Errorf("synthetic source %s has no table %s", cfg.SourceName, tableMapping.SourceTableIdentifier)
+		}
+	}
+
+	dstPeerType, err := connectors.LoadPeerType(ctx, h.pool, cfg.DestinationName)
+	if err != nil {
+		return fmt.Errorf("failed to load destination peer: %w", err)
+	}
+	return validateShadowMode(cfg, dstPeerType)
+}
+
+// oracle sources are mined with LogMiner, which needs archived redo and complete rows in it
+func (h *FlowRequestHandler) validateOracleSourceMirror(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	oracleConfig *protos.OracleConfig,
+) error {
+	if cfg.DoInitialSnapshot {
+		return errors.New("oracle sources do not support initial snapshot")
+	}
+	if cfg.System != protos.TypeSystem_Q {
+		return errors.New("oracle sources only support the Q type system")
+	}
+
+	oracleConn, err := connoracle.NewOracleConnector(ctx, oracleConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create oracle connector: %w", err)
+	}
+	defer oracleConn.Close()
+
+	sourceTables := make([]string, 0, len(cfg.TableMappings))
+	for _, tableMapping := range cfg.TableMappings {
+		sourceTables = append(sourceTables, tableMapping.SourceTableIdentifier)
+	}
+	if _, err := oracleConn.EnsurePullability(ctx, &protos.EnsurePullabilityBatchInput{
+		FlowJobName:            cfg.FlowJobName,
+		SourceTableIdentifiers: sourceTables,
+		PeerName:               cfg.SourceName,
+	}); err != nil {
+		return err
+	}
+
+	dstPeerType, err := connectors.LoadPeerType(ctx, h.pool, cfg.DestinationName)
+	if err != nil {
+		return fmt.Errorf("failed to load destination peer: %w", err)
+	}
+	return validateShadowMode(cfg, dstPeerType)
+}
+
+// mysql sources replicate binlog, which needs entire rows with their column names
+func (h *FlowRequestHandler) validateMySqlSourceMirror(
+	ctx context.Context,
+	cfg *protos.FlowConnectionConfigs,
+	mysqlConfig *protos.MySqlConfig,
+) error {
+	if cfg.System != protos.TypeSystem_Q {
+		return errors.New("mysql sources only support the Q type system")
+	}
+	if cfg.DoInitialSnapshot && cfg.SourceIdentifier != "" {
+		return errors.New("mysql sources do not support a source identifier with initial snapshot")
+	}
+
+	mysqlConn, err := connmysql.NewMySqlConnector(ctx, mysqlConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create mysql connector: %w", err)
+	}
+	defer mysqlConn.Close()
+
+	if err := mysqlConn.ValidateCheck(ctx); err != nil {
+		return err
+	}
+	sourceTables := make([]string, 0, len(cfg.TableMappings))
+	for _, tableMapping := range cfg.TableMappings {
+		sourceTables = append(sourceTables, tableMapping.SourceTableIdentifier)
+	}
+	if _, err := mysqlConn.EnsurePullability(ctx, &protos.EnsurePullabilityBatchInput{
+		FlowJobName:            cfg.FlowJobName,
+		SourceTableIdentifiers: sourceTables,
+		PeerName:               cfg.SourceName,
+	}); err != nil {
+		return err
+	}
+
+	dstPeerType, err := connectors.LoadPeerType(ctx, h.pool, cfg.DestinationName)
+	if err != nil {
+		return fmt.Errorf("failed to load destination peer: %w", err)
+	}
+	if err := validateShadowMode(cfg, dstPeerType); err != nil {
+		return err
+	}
+	if !cfg.StrictTypeMapping {
+		return nil
+	}
+	res, err := mysqlConn.GetTableSchema(ctx, &protos.GetTableSchemaBatchInput{
+		Env:              cfg.Env,
+		TableIdentifiers: sourceTables,
+		System:           protos.TypeSystem_Q,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get source table schema: %w", err)
+	}
+	return validateStrictTypeMapping(cfg, dstPeerType, res.TableNameSchemaMapping, nil)
+}
+
 func (h *FlowRequestHandler) CheckIfMirrorNameExists(ctx context.Context, mirrorName string) (bool, error) {
 	var nameExists pgtype.Bool
-	err := h.pool.QueryRow(ctx, "SELECT EXISTS(SELECT * FROM flows WHERE name = $1)", mirrorName).Scan(&nameExists)
+	err := h.pool.QueryRow(ctx,
+		"SELECT EXISTS(SELECT * FROM flows WHERE name = $1) OR EXISTS(SELECT * FROM fan_in_mirrors WHERE name = $1)",
+		mirrorName).Scan(&nameExists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if mirror name exists: %v", err)
 	}
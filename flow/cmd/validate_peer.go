@@ -30,6 +30,11 @@ func (h *FlowRequestHandler) ValidatePeer(
 		}, nil
 	}
 
+	if pgConfig, ok := req.Peer.Config.(*protos.Peer_PostgresConfig); ok {
+		// adjusted config is what gets stored by CreatePeer
+		connpostgres.ApplyPostgresPlatformPreset(pgConfig.PostgresConfig)
+	}
+
 	conn, err := connectors.GetConnector(ctx, nil, req.Peer)
 	if err != nil {
 		displayErr := fmt.Sprintf("%s peer %s was invalidated: %v", req.Peer.Type, req.Peer.Name, err)
@@ -55,6 +60,51 @@ func (h *FlowRequestHandler) ValidatePeer(
 					req.Peer.Name, pgversion),
 			}, nil
 		}
+
+		pooled, err := conn.(*connpostgres.PostgresConnector).IsTransactionPooled(ctx)
+		if err != nil {
+			slog.Error("/peer/validate: pg pooler check", slog.Any("error", err))
+			return nil, err
+		}
+		if pooled {
+			pgConfig := req.Peer.GetPostgresConfig()
+			if pgConfig.DirectHost == "" ||
+				(pgConfig.Host == pgConfig.DirectHost && (pgConfig.DirectPort == 0 || pgConfig.Port == pgConfig.DirectPort)) {
+				displayErr := fmt.Sprintf("Postgres peer %s is behind a transaction pooler such as PgBouncer, "+
+					"which breaks replication and COPY. Connect to Postgres directly or configure a direct host", req.Peer.Name)
+				h.alerter.LogNonFlowWarning(ctx, telemetry.CreatePeer, req.Peer.Name, displayErr)
+				return &protos.ValidatePeerResponse{
+					Status:  protos.ValidatePeerStatus_INVALID,
+					Message: displayErr,
+				}, nil
+			}
+
+			slog.Info("/peer/validate: switching to direct host of transaction pooled peer",
+				slog.String("peer", req.Peer.Name), slog.String("host", pgConfig.DirectHost))
+			pgConfig.Host = pgConfig.DirectHost
+			if pgConfig.DirectPort != 0 {
+				pgConfig.Port = pgConfig.DirectPort
+			}
+			return h.ValidatePeer(ctx, req)
+		}
+
+		if fraction := req.Peer.GetPostgresConfig().SnapshotCursorTupleFraction; fraction != nil && (*fraction < 0 || *fraction > 1) {
+			return &protos.ValidatePeerResponse{
+				Status:  protos.ValidatePeerStatus_INVALID,
+				Message: fmt.Sprintf("snapshot cursor tuple fraction of Postgres peer %s must be between 0 and 1", req.Peer.Name),
+			}, nil
+		}
+
+		if replicaConfig := connpostgres.ReadReplicaConfig(req.Peer.GetPostgresConfig()); replicaConfig != nil {
+			if err := validateReadReplica(ctx, replicaConfig); err != nil {
+				displayErr := fmt.Sprintf("failed to validate read replica of Postgres peer %s: %v", req.Peer.Name, err)
+				h.alerter.LogNonFlowWarning(ctx, telemetry.CreatePeer, req.Peer.Name, displayErr)
+				return &protos.ValidatePeerResponse{
+					Status:  protos.ValidatePeerStatus_INVALID,
+					Message: displayErr,
+				}, nil
+			}
+		}
 	}
 
 	if validationConn, ok := conn.(connectors.ValidationConnector); ok {
@@ -87,3 +137,12 @@ func (h *FlowRequestHandler) ValidatePeer(
 			req.Peer.Type, req.Peer.Name),
 	}, nil
 }
+
+func validateReadReplica(ctx context.Context, replicaConfig *protos.PostgresConfig) error {
+	replicaConn, err := connpostgres.NewPostgresConnector(ctx, replicaConfig)
+	if err != nil {
+		return err
+	}
+	defer replicaConn.Close()
+	return replicaConn.ConnectionActive(ctx)
+}
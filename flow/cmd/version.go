@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
+	"log/slog"
 
+	"github.com/PeerDB-io/peer-flow/connectors"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/peerdbenv"
 )
@@ -12,5 +14,14 @@ func (h *FlowRequestHandler) GetVersion(
 	req *protos.PeerDBVersionRequest,
 ) (*protos.PeerDBVersionResponse, error) {
 	version := peerdbenv.PeerDBVersionShaShort()
-	return &protos.PeerDBVersionResponse{Version: version}, nil
+	featureFlags, err := peerdbenv.PeerDBFeatureFlags(ctx)
+	if err != nil {
+		slog.Error("/version: failed to evaluate feature flags", slog.Any("error", err))
+		return nil, err
+	}
+	return &protos.PeerDBVersionResponse{
+		Version:      version,
+		PeerTypes:    connectors.PeerTypeCapabilities(),
+		FeatureFlags: featureFlags,
+	}, nil
 }
@@ -3,22 +3,31 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/grafana/pyroscope-go"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
 	"go.temporal.io/sdk/worker"
 
 	"github.com/PeerDB-io/peer-flow/activities"
 	"github.com/PeerDB-io/peer-flow/alerting"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
 	"github.com/PeerDB-io/peer-flow/logger"
 	"github.com/PeerDB-io/peer-flow/otel_metrics"
+	"github.com/PeerDB-io/peer-flow/otel_metrics/peerdb_gauges"
 	"github.com/PeerDB-io/peer-flow/peerdbenv"
 	"github.com/PeerDB-io/peer-flow/shared"
+	"github.com/PeerDB-io/peer-flow/shared/metrics"
 	peerflow "github.com/PeerDB-io/peer-flow/workflows"
 )
 
@@ -30,6 +39,9 @@ type WorkerSetupOptions struct {
 	TemporalMaxConcurrentWorkflowTasks int
 	EnableProfiling                    bool
 	EnableOtelMetrics                  bool
+	// serves metrics for Prometheus to scrape at /metrics, 0 disables
+	PrometheusMetricsPort    int
+	EnableFlowMetricsCatalog bool
 }
 
 type workerSetupResponse struct {
@@ -116,28 +128,39 @@ func WorkerSetup(opts *WorkerSetupOptions) (*workerSetupResponse, error) {
 	}
 	slog.Info("Created temporal client")
 
-	taskQueue := peerdbenv.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
-	slog.Info(
-		fmt.Sprintf("Creating temporal worker for queue %v: %v workflow workers %v activity workers",
-			taskQueue,
-			opts.TemporalMaxConcurrentWorkflowTasks,
-			opts.TemporalMaxConcurrentActivities,
-		),
-	)
-	w := worker.New(c, taskQueue, worker.Options{
-		EnableSessionWorker:                    true,
-		MaxConcurrentActivityExecutionSize:     opts.TemporalMaxConcurrentActivities,
-		MaxConcurrentWorkflowTaskExecutionSize: opts.TemporalMaxConcurrentWorkflowTasks,
-		OnFatalError: func(err error) {
-			slog.Error("Peerflow Worker failed", slog.Any("error", err))
-		},
-	})
-	peerflow.RegisterFlowWorkerWorkflows(w)
-
 	cleanupOtelManagerFunc := func() {}
 	var otelManager *otel_metrics.OtelManager
-	if opts.EnableOtelMetrics {
-		metricsProvider, metricErr := otel_metrics.SetupOtelMetricsExporter("flow-worker")
+	if opts.EnableOtelMetrics || opts.PrometheusMetricsPort != 0 {
+		var readers []sdkmetric.Reader
+		var metricsServer *http.Server
+		if opts.PrometheusMetricsPort != 0 {
+			reader, handler, err := otel_metrics.SetupPrometheusMetricsReader()
+			if err != nil {
+				return nil, err
+			}
+			readers = append(readers, reader)
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", handler)
+			metricsServer = &http.Server{
+				Addr:              fmt.Sprintf(":%d", opts.PrometheusMetricsPort),
+				Handler:           mux,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			go func() {
+				slog.Info("Serving Prometheus metrics", slog.Int("port", opts.PrometheusMetricsPort))
+				if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					slog.Error("Failed to serve Prometheus metrics", slog.Any("error", err))
+				}
+			}()
+		}
+
+		var metricsProvider *sdkmetric.MeterProvider
+		var metricErr error
+		if opts.EnableOtelMetrics {
+			metricsProvider, metricErr = otel_metrics.SetupOtelMetricsExporter("flow-worker", readers...)
+		} else {
+			metricsProvider, metricErr = otel_metrics.NewMeterProvider("flow-worker", readers...)
+		}
 		if metricErr != nil {
 			return nil, metricErr
 		}
@@ -148,23 +171,86 @@ func WorkerSetup(opts *WorkerSetupOptions) (*workerSetupResponse, error) {
 			Int64GaugesCache:   make(map[string]*otel_metrics.Int64SyncGauge),
 		}
 		cleanupOtelManagerFunc = func() {
+			if metricsServer != nil {
+				if err := metricsServer.Close(); err != nil {
+					slog.Error("Failed to close Prometheus metrics server", slog.Any("error", err))
+				}
+			}
 			shutDownErr := otelManager.MetricsProvider.Shutdown(context.Background())
 			if shutDownErr != nil {
 				slog.Error("Failed to shutdown metrics provider", slog.Any("error", shutDownErr))
 			}
 		}
 	}
+
+	// connectors and activities record health of mirrors through the context of activities
+	var recorders []metrics.Recorder
+	if otelManager != nil {
+		flowMetrics, err := peerdb_gauges.NewFlowMetrics(otelManager.Meter)
+		if err != nil {
+			return nil, err
+		}
+		recorders = append(recorders, flowMetrics)
+	}
+	if opts.EnableFlowMetricsCatalog {
+		recorders = append(recorders, monitoring.NewCatalogFlowMetrics(conn))
+	}
+	activityCtx := context.Background()
+	if len(recorders) > 0 {
+		activityCtx = metrics.WithRecorder(activityCtx, metrics.Multi(recorders...))
+	}
+
+	// temporary files of activities that were running when the worker last stopped are of no use to anything
+	if err := utils.CleanSpillDir(); err != nil {
+		slog.Warn("Failed to clean spill directory", slog.Any("error", err))
+	}
+
+	taskQueue := peerdbenv.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
+	slog.Info(
+		fmt.Sprintf("Creating temporal worker for queue %v: %v workflow workers %v activity workers",
+			taskQueue,
+			opts.TemporalMaxConcurrentWorkflowTasks,
+			opts.TemporalMaxConcurrentActivities,
+		),
+	)
+	var interceptors []interceptor.WorkerInterceptor
+	if otelManager != nil {
+		mirrorMetrics, err := newMirrorMetricsInterceptor(otelManager.Meter)
+		if err != nil {
+			return nil, err
+		}
+		interceptors = append(interceptors, mirrorMetrics)
+	}
+	w := worker.New(c, taskQueue, worker.Options{
+		Interceptors:                           interceptors,
+		BackgroundActivityContext:              activityCtx,
+		EnableSessionWorker:                    true,
+		MaxConcurrentActivityExecutionSize:     opts.TemporalMaxConcurrentActivities,
+		MaxConcurrentWorkflowTaskExecutionSize: opts.TemporalMaxConcurrentWorkflowTasks,
+		OnFatalError: func(err error) {
+			slog.Error("Peerflow Worker failed", slog.Any("error", err))
+		},
+	})
+	peerflow.RegisterFlowWorkerWorkflows(w)
+
+	resourceUsage := activities.NewResourceUsageTracker(conn, otelManager)
+	resourceUsageCtx, cancelResourceUsage := context.WithCancel(context.Background())
+	go resourceUsage.Run(resourceUsageCtx)
+
 	w.RegisterActivity(&activities.FlowableActivity{
-		CatalogPool: conn,
-		Alerter:     alerting.NewAlerter(context.Background(), conn),
-		CdcCache:    make(map[string]activities.CdcCacheEntry),
-		OtelManager: otelManager,
+		CatalogPool:    conn,
+		Alerter:        alerting.NewAlerter(context.Background(), conn),
+		CdcCache:       make(map[string]activities.CdcCacheEntry),
+		OtelManager:    otelManager,
+		ResourceUsage:  resourceUsage,
+		TemporalClient: c,
 	})
 
 	return &workerSetupResponse{
 		Client: c,
 		Worker: w,
 		Cleanup: func() {
+			cancelResourceUsage()
 			cleanupOtelManagerFunc()
 			c.Close()
 		},
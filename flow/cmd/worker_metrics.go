@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/PeerDB-io/peer-flow/otel_metrics/peerdb_gauges"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const (
+	activityRetriesCounterName      = "activity_retries"
+	workflowRetriesCounterName      = "workflow_retries"
+	continueAsNewCounterName        = "workflow_continue_as_new"
+	workflowTaskFailuresCounterName = "workflow_task_failures"
+
+	// activities have no search attributes, so workflows pass the mirror along in a header
+	mirrorNameHeader = "peerdb-mirror-name"
+)
+
+// mirrorMetricsInterceptor counts retries, restarts and failures of workflows and activities by mirror,
+// making mirrors that keep retrying visible before they show up as lag
+type mirrorMetricsInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	activityRetries      metric.Int64Counter
+	workflowRetries      metric.Int64Counter
+	continueAsNew        metric.Int64Counter
+	workflowTaskFailures metric.Int64Counter
+}
+
+func newMirrorMetricsInterceptor(meter metric.Meter) (*mirrorMetricsInterceptor, error) {
+	activityRetries, err := meter.Int64Counter(activityRetriesCounterName,
+		metric.WithDescription("Activity attempts after the first one"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create activity retries counter: %w", err)
+	}
+	workflowRetries, err := meter.Int64Counter(workflowRetriesCounterName,
+		metric.WithDescription("Workflow runs retried after failing"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workflow retries counter: %w", err)
+	}
+	continueAsNew, err := meter.Int64Counter(continueAsNewCounterName,
+		metric.WithDescription("Workflow runs continued as new"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create continue as new counter: %w", err)
+	}
+	workflowTaskFailures, err := meter.Int64Counter(workflowTaskFailuresCounterName,
+		metric.WithDescription("Workflow tasks failed by a panic in workflow code"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workflow task failures counter: %w", err)
+	}
+	return &mirrorMetricsInterceptor{
+		activityRetries:      activityRetries,
+		workflowRetries:      workflowRetries,
+		continueAsNew:        continueAsNew,
+		workflowTaskFailures: workflowTaskFailures,
+	}, nil
+}
+
+func (m *mirrorMetricsInterceptor) InterceptActivity(
+	ctx context.Context,
+	next interceptor.ActivityInboundInterceptor,
+) interceptor.ActivityInboundInterceptor {
+	return &mirrorMetricsActivityInterceptor{
+		ActivityInboundInterceptorBase: interceptor.ActivityInboundInterceptorBase{Next: next},
+		root:                           m,
+	}
+}
+
+func (m *mirrorMetricsInterceptor) InterceptWorkflow(
+	ctx workflow.Context,
+	next interceptor.WorkflowInboundInterceptor,
+) interceptor.WorkflowInboundInterceptor {
+	return &mirrorMetricsWorkflowInterceptor{
+		WorkflowInboundInterceptorBase: interceptor.WorkflowInboundInterceptorBase{Next: next},
+		root:                           m,
+	}
+}
+
+type mirrorMetricsActivityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+	root *mirrorMetricsInterceptor
+}
+
+func (a *mirrorMetricsActivityInterceptor) ExecuteActivity(
+	ctx context.Context,
+	in *interceptor.ExecuteActivityInput,
+) (interface{}, error) {
+	if info := activity.GetInfo(ctx); info.Attempt > 1 {
+		var flowName string
+		if payload, ok := interceptor.Header(ctx)[mirrorNameHeader]; ok {
+			_ = converter.GetDefaultDataConverter().FromPayload(payload, &flowName)
+		}
+		a.root.activityRetries.Add(ctx, 1, metric.WithAttributes(
+			attribute.String(peerdb_gauges.FlowNameKey, flowName),
+			attribute.String("activityType", info.ActivityType.Name),
+		))
+	}
+	return a.Next.ExecuteActivity(ctx, in)
+}
+
+type mirrorMetricsWorkflowInterceptor struct {
+	interceptor.WorkflowInboundInterceptorBase
+	root *mirrorMetricsInterceptor
+}
+
+func (w *mirrorMetricsWorkflowInterceptor) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	return w.Next.Init(&mirrorMetricsWorkflowOutboundInterceptor{
+		WorkflowOutboundInterceptorBase: interceptor.WorkflowOutboundInterceptorBase{Next: outbound},
+	})
+}
+
+func (w *mirrorMetricsWorkflowInterceptor) ExecuteWorkflow(
+	ctx workflow.Context,
+	in *interceptor.ExecuteWorkflowInput,
+) (interface{}, error) {
+	info := workflow.GetInfo(ctx)
+	attrs := metric.WithAttributes(
+		attribute.String(peerdb_gauges.FlowNameKey, workflowMirrorName(ctx)),
+		attribute.String("workflowType", info.WorkflowType.Name),
+	)
+	// replays run workflow code again, only count what happens for the first time
+	if info.Attempt > 1 && !workflow.IsReplaying(ctx) {
+		w.root.workflowRetries.Add(context.Background(), 1, attrs)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			w.root.workflowTaskFailures.Add(context.Background(), 1, attrs)
+			panic(r)
+		}
+	}()
+
+	result, err := w.Next.ExecuteWorkflow(ctx, in)
+	if workflow.IsContinueAsNewError(err) && !workflow.IsReplaying(ctx) {
+		w.root.continueAsNew.Add(context.Background(), 1, attrs)
+	}
+	return result, err
+}
+
+type mirrorMetricsWorkflowOutboundInterceptor struct {
+	interceptor.WorkflowOutboundInterceptorBase
+}
+
+func (w *mirrorMetricsWorkflowOutboundInterceptor) ExecuteActivity(
+	ctx workflow.Context,
+	activityType string,
+	args ...interface{},
+) workflow.Future {
+	if header := interceptor.WorkflowHeader(ctx); header != nil {
+		if payload, err := converter.GetDefaultDataConverter().ToPayload(workflowMirrorName(ctx)); err == nil {
+			header[mirrorNameHeader] = payload
+		}
+	}
+	return w.Next.ExecuteActivity(ctx, activityType, args...)
+}
+
+func workflowMirrorName(ctx workflow.Context) string {
+	var flowName string
+	if payload, ok := workflow.GetInfo(ctx).SearchAttributes.GetIndexedFields()[shared.MirrorNameSearchAttribute]; ok {
+		_ = converter.GetDefaultDataConverter().FromPayload(payload, &flowName)
+	}
+	return flowName
+}
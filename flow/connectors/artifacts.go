@@ -0,0 +1,54 @@
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// OrphanedArtifacts lists staging artifacts on a peer whose mirror is no longer in the catalog,
+// like after dropping a mirror failed to clean up its destination or a mirror was renamed
+func OrphanedArtifacts(
+	ctx context.Context,
+	conn StagingArtifactsConnector,
+	catalogPool *pgxpool.Pool,
+) ([]*protos.StagingArtifact, error) {
+	artifacts, err := conn.ListStagingArtifacts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staging artifacts: %w", err)
+	}
+	if len(artifacts) == 0 {
+		return nil, nil
+	}
+
+	rows, err := catalogPool.Query(ctx, "SELECT DISTINCT name FROM flows")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mirrors: %w", err)
+	}
+	flowNames, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mirrors: %w", err)
+	}
+	return orphanedArtifacts(artifacts, flowNames), nil
+}
+
+func orphanedArtifacts(artifacts []*protos.StagingArtifact, flowNames []string) []*protos.StagingArtifact {
+	// artifact names are folded to one case by some peers
+	mirrors := make(map[string]struct{}, len(flowNames))
+	for _, flowName := range flowNames {
+		mirrors[strings.ToLower(shared.ReplaceIllegalCharactersWithUnderscores(flowName))] = struct{}{}
+	}
+	var orphaned []*protos.StagingArtifact
+	for _, artifact := range artifacts {
+		if _, ok := mirrors[strings.ToLower(artifact.FlowName)]; !ok {
+			orphaned = append(orphaned, artifact)
+		}
+	}
+	return orphaned
+}
@@ -0,0 +1,20 @@
+package connectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestOrphanedArtifacts(t *testing.T) {
+	live := &protos.StagingArtifact{Name: "_PEERDB_RAW_ORDERS_CDC", FlowName: "ORDERS_CDC"}
+	dropped := &protos.StagingArtifact{Name: "_peerdb_raw_old_mirror", FlowName: "old_mirror"}
+	renamed := &protos.StagingArtifact{
+		Kind: protos.StagingArtifactKind_STAGING_ARTIFACT_STAGE, Name: "PEERDB_STAGE_USERS", FlowName: "USERS",
+	}
+	require.Equal(t, []*protos.StagingArtifact{dropped, renamed},
+		orphanedArtifacts([]*protos.StagingArtifact{live, dropped, renamed}, []string{"orders-cdc", "users_v2"}))
+	require.Nil(t, orphanedArtifacts([]*protos.StagingArtifact{live}, []string{"orders-cdc"}))
+}
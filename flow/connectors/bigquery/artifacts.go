@@ -0,0 +1,57 @@
+package connbigquery
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/iterator"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const rawTablePrefix = "_peerdb_raw_"
+
+// staging tables of raw tables are named after the raw table and sync batch,
+// left behind when dropping them after a sync failed
+var stagingTableRe = regexp.MustCompile(`^_peerdb_raw_(.+)_\d+_staging$`)
+
+func (c *BigQueryConnector) ListStagingArtifacts(ctx context.Context) ([]*protos.StagingArtifact, error) {
+	var artifacts []*protos.StagingArtifact
+	it := c.client.DatasetInProject(c.projectID, c.rawDatasetID).Tables(ctx)
+	for {
+		table, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tables of dataset %s: %w", c.rawDatasetID, err)
+		}
+		if !strings.HasPrefix(table.TableID, rawTablePrefix) {
+			continue
+		}
+		artifact := &protos.StagingArtifact{
+			Kind:     protos.StagingArtifactKind_STAGING_ARTIFACT_RAW_TABLE,
+			Name:     c.rawDatasetID + "." + table.TableID,
+			FlowName: strings.TrimPrefix(table.TableID, rawTablePrefix),
+		}
+		if match := stagingTableRe.FindStringSubmatch(table.TableID); match != nil {
+			artifact.Kind = protos.StagingArtifactKind_STAGING_ARTIFACT_STAGING_TABLE
+			artifact.FlowName = match[1]
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, nil
+}
+
+func (c *BigQueryConnector) DropStagingArtifact(ctx context.Context, artifact *protos.StagingArtifact) error {
+	datasetTable, err := c.convertToDatasetTable(artifact.Name)
+	if err != nil {
+		return err
+	}
+	if err := c.client.DatasetInProject(c.projectID, datasetTable.dataset).Table(datasetTable.table).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete table %s: %w", artifact.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package connbigquery
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/bigquery"
+
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+const batchControlTable = "peerdb_batches"
+
+// RecordNormalizedBatch writes batch to peerdb_batches in the raw dataset, a retried normalize keeps the first row
+func (c *BigQueryConnector) RecordNormalizedBatch(ctx context.Context, flowJobName string, batch *model.NormalizedBatch) error {
+	table := fmt.Sprintf("`%s.%s.%s`", c.projectID, c.rawDatasetID, batchControlTable)
+	create := c.queryWithLogging(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		flow_name STRING NOT NULL,
+		batch_id INT64 NOT NULL,
+		start_batch_id INT64 NOT NULL,
+		start_lsn INT64 NOT NULL,
+		end_lsn INT64 NOT NULL,
+		tables JSON,
+		num_rows INT64 NOT NULL,
+		normalized_at TIMESTAMP NOT NULL)`, table))
+	if _, err := c.readQuery(ctx, create); err != nil {
+		return fmt.Errorf("failed to create batch control table: %w", err)
+	}
+
+	tables, err := batch.TablesJSON()
+	if err != nil {
+		return err
+	}
+	insert := c.queryWithLogging(ctx, fmt.Sprintf(`MERGE %s t
+		USING (SELECT @flow_name AS flow_name, @batch_id AS batch_id) s
+		ON t.flow_name = s.flow_name AND t.batch_id = s.batch_id
+		WHEN NOT MATCHED THEN INSERT (flow_name, batch_id, start_batch_id, start_lsn, end_lsn, tables, num_rows, normalized_at)
+		VALUES (@flow_name, @batch_id, @start_batch_id, @start_lsn, @end_lsn, PARSE_JSON(@tables), @num_rows, @normalized_at)`,
+		table))
+	insert.Parameters = []bigquery.QueryParameter{
+		{Name: "flow_name", Value: flowJobName},
+		{Name: "batch_id", Value: batch.EndBatchID},
+		{Name: "start_batch_id", Value: batch.StartBatchID},
+		{Name: "start_lsn", Value: batch.StartLSN},
+		{Name: "end_lsn", Value: batch.EndLSN},
+		{Name: "tables", Value: tables},
+		{Name: "num_rows", Value: batch.NumRows()},
+		{Name: "normalized_at", Value: batch.NormalizedAt},
+	}
+	if _, err := c.readQuery(ctx, insert); err != nil {
+		return fmt.Errorf("failed to record batch %d in batch control table: %w", batch.EndBatchID, err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"reflect"
 	"slices"
 	"strings"
@@ -18,6 +19,7 @@ import (
 
 	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/logger"
 	"github.com/PeerDB-io/peer-flow/model"
@@ -31,13 +33,15 @@ const (
 
 type BigQueryConnector struct {
 	*metadataStore.PostgresMetadata
-	logger        log.Logger
-	bqConfig      *protos.BigqueryConfig
-	client        *bigquery.Client
-	storageClient *storage.Client
-	catalogPool   *pgxpool.Pool
-	datasetID     string
-	projectID     string
+	logger           log.Logger
+	bqConfig         *protos.BigqueryConfig
+	client           *bigquery.Client
+	storageClient    *storage.Client
+	catalogPool      *pgxpool.Pool
+	datasetID        string
+	rawDatasetID     string
+	projectID        string
+	statementTimeout time.Duration
 }
 
 func NewBigQueryServiceAccount(bqConfig *protos.BigqueryConfig) (*utils.GcpServiceAccount, error) {
@@ -136,6 +140,11 @@ func NewBigQueryConnector(ctx context.Context, config *protos.BigqueryConfig) (*
 		return nil, fmt.Errorf("failed to get dataset metadata: %v", datasetErr)
 	}
 
+	rawDatasetID := datasetID
+	if config.RawDataset != "" {
+		rawDatasetID = config.RawDataset
+	}
+
 	storageClient, err := bqsa.CreateStorageClient(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Storage client: %v", err)
@@ -146,15 +155,22 @@ func NewBigQueryConnector(ctx context.Context, config *protos.BigqueryConfig) (*
 		return nil, fmt.Errorf("failed to create catalog connection pool: %v", err)
 	}
 
+	statementTimeout, err := peerdbenv.PeerDBStatementTimeout(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement timeout: %w", err)
+	}
+
 	return &BigQueryConnector{
 		bqConfig:         config,
 		client:           client,
 		datasetID:        datasetID,
+		rawDatasetID:     rawDatasetID,
 		projectID:        projectID,
 		PostgresMetadata: metadataStore.NewPostgresMetadataFromCatalog(logger, catalogPool),
 		storageClient:    storageClient,
 		catalogPool:      catalogPool,
 		logger:           logger,
+		statementTimeout: statementTimeout,
 	}, nil
 }
 
@@ -176,6 +192,23 @@ func (c *BigQueryConnector) ConnectionActive(ctx context.Context) error {
 	return nil
 }
 
+// StagingRegions are the locations of the raw dataset and of the GCS bucket of stagingPath, like us-central1 or us
+func (c *BigQueryConnector) StagingRegions(ctx context.Context, stagingPath string) ([]string, error) {
+	metadata, err := c.client.DatasetInProject(c.projectID, c.rawDatasetID).Metadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raw dataset metadata: %w", err)
+	}
+	regions := []string{strings.ToLower(metadata.Location)}
+	if stagingPath != "" {
+		attrs, err := c.storageClient.Bucket(stagingPath).Attrs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get location of bucket %s: %w", stagingPath, err)
+		}
+		regions = append(regions, strings.ToLower(attrs.Location))
+	}
+	return regions, nil
+}
+
 func (c *BigQueryConnector) waitForTableReady(ctx context.Context, datasetTable *datasetTable) error {
 	table := c.client.DatasetInProject(c.projectID, datasetTable.dataset).Table(datasetTable.table)
 	maxDuration := 5 * time.Minute
@@ -208,7 +241,7 @@ func (c *BigQueryConnector) ReplayTableSchemaDeltas(
 	schemaDeltas []*protos.TableSchemaDelta,
 ) error {
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil || (len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.WidenedColumns) == 0) {
 			continue
 		}
 
@@ -231,12 +264,12 @@ func (c *BigQueryConnector) ReplayTableSchemaDeltas(
 			}
 
 			addedColumnBigQueryType := qValueKindToBigQueryTypeString(addedColumn, schemaDelta.NullableEnabled, false)
-			query := c.queryWithLogging(fmt.Sprintf(
+			query := c.queryWithLogging(ctx, fmt.Sprintf(
 				"ALTER TABLE %s ADD COLUMN IF NOT EXISTS `%s` %s",
 				dstDatasetTable.table, addedColumn.Name, addedColumnBigQueryType))
 			query.DefaultProjectID = c.projectID
 			query.DefaultDatasetID = dstDatasetTable.dataset
-			_, err := query.Read(ctx)
+			_, err := c.readQuery(ctx, query)
 			if err != nil {
 				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
 					schemaDelta.DstTableName, err)
@@ -244,6 +277,32 @@ func (c *BigQueryConnector) ReplayTableSchemaDeltas(
 			c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s to table %s",
 				addedColumn.Name, addedColumnBigQueryType, schemaDelta.DstTableName))
 		}
+
+		for _, widenedColumn := range schemaDelta.WidenedColumns {
+			// integers and floats share one BigQuery type regardless of width, so mostly numerics get here
+			oldBigQueryType := qValueKindToBigQueryTypeString(&protos.FieldDescription{
+				Name: widenedColumn.Name, Type: widenedColumn.OldType, TypeModifier: widenedColumn.OldTypeModifier,
+			}, false, false)
+			newBigQueryType := qValueKindToBigQueryTypeString(&protos.FieldDescription{
+				Name: widenedColumn.Name, Type: widenedColumn.NewType, TypeModifier: widenedColumn.NewTypeModifier,
+			}, false, false)
+			if oldBigQueryType == newBigQueryType {
+				continue
+			}
+
+			dstDatasetTable, _ := c.convertToDatasetTable(schemaDelta.DstTableName)
+			query := c.queryWithLogging(ctx, fmt.Sprintf(
+				"ALTER TABLE %s ALTER COLUMN `%s` SET DATA TYPE %s",
+				dstDatasetTable.table, widenedColumn.Name, newBigQueryType))
+			query.DefaultProjectID = c.projectID
+			query.DefaultDatasetID = dstDatasetTable.dataset
+			if _, err := c.readQuery(ctx, query); err != nil {
+				return fmt.Errorf("failed to widen column %s for table %s: %w", widenedColumn.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] widened column %s from %s to %s in table %s",
+				widenedColumn.Name, oldBigQueryType, newBigQueryType, schemaDelta.DstTableName))
+		}
 	}
 
 	return nil
@@ -263,8 +322,8 @@ func (c *BigQueryConnector) getDistinctTableNamesInBatch(
 	// Run the query
 	q := c.client.Query(query)
 	q.DefaultProjectID = c.projectID
-	q.DefaultDatasetID = c.datasetID
-	it, err := q.Read(ctx)
+	q.DefaultDatasetID = c.rawDatasetID
+	it, err := c.readQuery(ctx, q)
 	if err != nil {
 		err = fmt.Errorf("failed to run query %s on BigQuery:\n %w", query, err)
 		return nil, err
@@ -308,9 +367,9 @@ func (c *BigQueryConnector) getTableNametoUnchangedCols(
 		rawTableName, batchId)
 	// Run the query
 	q := c.client.Query(query)
-	q.DefaultDatasetID = c.datasetID
+	q.DefaultDatasetID = c.rawDatasetID
 	q.DefaultProjectID = c.projectID
-	it, err := q.Read(ctx)
+	it, err := c.readQuery(ctx, q)
 	if err != nil {
 		err = fmt.Errorf("failed to run query %s on BigQuery:\n %w", query, err)
 		return nil, err
@@ -342,14 +401,14 @@ func (c *BigQueryConnector) getTableNametoUnchangedCols(
 func (c *BigQueryConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
 	rawTableName := c.getRawTableName(req.FlowJobName)
 
-	c.logger.Info(fmt.Sprintf("pushing records to %s.%s...", c.datasetID, rawTableName))
+	c.logger.Info(fmt.Sprintf("pushing records to %s.%s...", c.rawDatasetID, rawTableName))
 
 	res, err := c.syncRecordsViaAvro(ctx, req, rawTableName, req.SyncBatchID)
 	if err != nil {
 		return nil, err
 	}
 
-	c.logger.Info(fmt.Sprintf("pushed %d records to %s.%s", res.NumRecordsSynced, c.datasetID, rawTableName))
+	c.logger.Info(fmt.Sprintf("pushed %d records to %s.%s", res.NumRecordsSynced, c.rawDatasetID, rawTableName))
 	return res, nil
 }
 
@@ -361,13 +420,16 @@ func (c *BigQueryConnector) syncRecordsViaAvro(
 ) (*model.SyncResponse, error) {
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
 	streamReq := model.NewRecordsToStreamRequest(req.Records.GetRecords(), tableNameRowsMapping, syncBatchID)
+	if err := utils.SetCollapseWindow(ctx, req, streamReq); err != nil {
+		return nil, err
+	}
 	stream, err := utils.RecordsToRawTableStream(streamReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
 	}
 
 	avroSync := NewQRepAvroSyncMethod(c, req.StagingPath, req.FlowJobName)
-	rawTableMetadata, err := c.client.DatasetInProject(c.projectID, c.datasetID).Table(rawTableName).Metadata(ctx)
+	rawTableMetadata, err := c.client.DatasetInProject(c.projectID, c.rawDatasetID).Table(rawTableName).Metadata(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get metadata of destination table: %w", err)
 	}
@@ -400,13 +462,47 @@ func (c *BigQueryConnector) NormalizeRecords(ctx context.Context, req *model.Nor
 		}, nil
 	}
 
+	peerdbCols := &protos.PeerDBColumns{
+		SoftDeleteColName: req.SoftDeleteColName,
+		SyncedAtColName:   req.SyncedAtColName,
+	}
+	// changes buffered while quarantined or of replayed batches are merged again,
+	// without moving the normalized batch back
+	replayTables := utils.ReplayTablesToNormalize(req.ReplayTables, req.TruncatedTables, normBatchID)
+	for batchId := utils.EarliestReplayBatchID(replayTables, normBatchID) + 1; batchId <= normBatchID; batchId++ {
+		if slices.Contains(req.SkippedBatches, batchId) {
+			continue
+		}
+		if err := c.mergeTablesInThisBatch(ctx, batchId, req.FlowJobName, rawTableName, req.TableNameSchemaMapping,
+			utils.TablesNotReplayed(req.TableNameSchemaMapping, replayTables, batchId), peerdbCols); err != nil {
+			return nil, err
+		}
+	}
+
+	truncatedTables := utils.TruncatedTablesToNormalize(req.TruncatedTables, normBatchID, req.SyncBatchID)
 	for batchId := normBatchID + 1; batchId <= req.SyncBatchID; batchId++ {
+		for _, tableName := range slices.Sorted(maps.Keys(truncatedTables)) {
+			if truncatedTables[tableName] != batchId {
+				continue
+			}
+			dstDatasetTable, _ := c.convertToDatasetTable(tableName)
+			if truncateStmt := generateTruncateStmt(dstDatasetTable, req.TruncatePolicy, peerdbCols); truncateStmt != "" {
+				if err := c.runMergeStatement(ctx, dstDatasetTable.dataset, truncateStmt); err != nil {
+					return nil, fmt.Errorf("failed to apply truncate to %s: %w", tableName, err)
+				}
+			}
+		}
+
+		if slices.Contains(req.SkippedBatches, batchId) {
+			c.logger.Warn("skipping batch", slog.Int64("batchId", batchId))
+			if err := c.UpdateNormalizeBatchID(ctx, req.FlowJobName, batchId); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		mergeErr := c.mergeTablesInThisBatch(ctx, batchId,
-			req.FlowJobName, rawTableName, req.TableNameSchemaMapping,
-			&protos.PeerDBColumns{
-				SoftDeleteColName: req.SoftDeleteColName,
-				SyncedAtColName:   req.SyncedAtColName,
-			})
+			req.FlowJobName, rawTableName, req.TableNameSchemaMapping, req.SkippedTables, peerdbCols)
 		if mergeErr != nil {
 			return nil, mergeErr
 		}
@@ -425,10 +521,11 @@ func (c *BigQueryConnector) NormalizeRecords(ctx context.Context, req *model.Nor
 }
 
 func (c *BigQueryConnector) runMergeStatement(ctx context.Context, datasetID string, mergeStmt string) error {
+	audit.Record(ctx, mergeStmt)
 	q := c.client.Query(mergeStmt)
 	q.DefaultProjectID = c.projectID
 	q.DefaultDatasetID = datasetID
-	if _, err := q.Read(ctx); err != nil {
+	if _, err := c.readQuery(ctx, q); err != nil {
 		return fmt.Errorf("failed to execute merge statement %s: %v", mergeStmt, err)
 	}
 	return nil
@@ -440,6 +537,7 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 	flowName string,
 	rawTableName string,
 	tableToSchema map[string]*protos.TableSchema,
+	skippedTables map[string]struct{},
 	peerdbColumns *protos.PeerDBColumns,
 ) error {
 	tableNames, err := c.getDistinctTableNamesInBatch(
@@ -450,6 +548,7 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 	if err != nil {
 		return fmt.Errorf("couldn't get distinct table names to normalize: %w", err)
 	}
+	tableNames = utils.WithoutSkippedTables(tableNames, skippedTables)
 
 	tableNametoUnchangedToastCols, err := c.getTableNametoUnchangedCols(
 		ctx,
@@ -463,7 +562,7 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 	mergeGen := &mergeStmtGenerator{
 		rawDatasetTable: datasetTable{
 			project: c.projectID,
-			dataset: c.datasetID,
+			dataset: c.rawDatasetID,
 			table:   rawTableName,
 		},
 		tableSchemaMapping: tableToSchema,
@@ -501,7 +600,7 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 
 	// append all the statements to one list
 	c.logger.Info(fmt.Sprintf("merged raw records to corresponding tables: %s %s %v",
-		c.datasetID, rawTableName, tableNames))
+		c.rawDatasetID, rawTableName, tableNames))
 	return nil
 }
 
@@ -526,8 +625,12 @@ func (c *BigQueryConnector) CreateRawTable(ctx context.Context, req *protos.Crea
 		{Name: "_peerdb_unchanged_toast_columns", Type: bigquery.StringFieldType},
 	}
 
+	if err := c.ensureDataset(ctx, c.rawDatasetID); err != nil {
+		return nil, err
+	}
+
 	// create the table
-	table := c.client.DatasetInProject(c.projectID, c.datasetID).Table(rawTableName)
+	table := c.client.DatasetInProject(c.projectID, c.rawDatasetID).Table(rawTableName)
 
 	// check if the table exists
 	tableRef, err := table.Metadata(ctx)
@@ -538,7 +641,7 @@ func (c *BigQueryConnector) CreateRawTable(ctx context.Context, req *protos.Crea
 				slog.String("table", rawTableName),
 				slog.Any("existingSchema", tableRef.Schema),
 				slog.Any("schema", schema))
-			return nil, fmt.Errorf("raw table %s.%s already exists with different schema", c.datasetID, rawTableName)
+			return nil, fmt.Errorf("raw table %s.%s already exists with different schema", c.rawDatasetID, rawTableName)
 		} else {
 			return &protos.CreateRawTableOutput{
 				TableIdentifier: rawTableName,
@@ -576,7 +679,7 @@ func (c *BigQueryConnector) CreateRawTable(ctx context.Context, req *protos.Crea
 		slog.Any("metadata", metadata))
 	err = table.Create(ctx, metadata)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create table %s.%s: %w", c.datasetID, rawTableName, err)
+		return nil, fmt.Errorf("failed to create table %s.%s: %w", c.rawDatasetID, rawTableName, err)
 	}
 
 	return &protos.CreateRawTableOutput{
@@ -596,7 +699,42 @@ func (c *BigQueryConnector) FinishSetupNormalizedTables(_ context.Context, _ int
 func (c *BigQueryConnector) CleanupSetupNormalizedTables(_ context.Context, _ interface{}) {
 }
 
+func (c *BigQueryConnector) MissingNormalizedTables(ctx context.Context, tables []string) ([]string, error) {
+	var missing []string
+	for _, table := range tables {
+		datasetTable, err := c.convertToDatasetTable(table)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := c.client.DatasetInProject(c.projectID, datasetTable.dataset).Table(datasetTable.table).Metadata(ctx); err != nil {
+			if !strings.Contains(err.Error(), "notFound") {
+				return nil, fmt.Errorf("error while checking metadata for BigQuery table %s: %w", datasetTable.string(), err)
+			}
+			missing = append(missing, table)
+		}
+	}
+	return missing, nil
+}
+
 // This runs CREATE TABLE IF NOT EXISTS on bigquery, using the schema and table name provided.
+// ensureDataset creates datasetID when it doesn't exist
+func (c *BigQueryConnector) ensureDataset(ctx context.Context, datasetID string) error {
+	dataset := c.client.DatasetInProject(c.projectID, datasetID)
+	_, err := dataset.Metadata(ctx)
+	// just assume this means dataset don't exist, and create it
+	if err != nil {
+		// if err message does not contain `notFound`, then other error happened.
+		if !strings.Contains(err.Error(), "notFound") {
+			return fmt.Errorf("error while checking metadata for BigQuery dataset %s: %w", datasetID, err)
+		}
+		c.logger.Info(fmt.Sprintf("creating dataset %s...", datasetID))
+		if err := dataset.Create(ctx, nil); err != nil {
+			return fmt.Errorf("failed to create BigQuery dataset %s: %w", datasetID, err)
+		}
+	}
+	return nil
+}
+
 func (c *BigQueryConnector) SetupNormalizedTable(
 	ctx context.Context,
 	tx interface{},
@@ -617,21 +755,10 @@ func (c *BigQueryConnector) SetupNormalizedTable(
 			datasetTable.string())
 	}
 	datasetTablesSet[datasetTable] = struct{}{}
-	dataset := c.client.DatasetInProject(c.projectID, datasetTable.dataset)
-	_, err = dataset.Metadata(ctx)
-	// just assume this means dataset don't exist, and create it
-	if err != nil {
-		// if err message does not contain `notFound`, then other error happened.
-		if !strings.Contains(err.Error(), "notFound") {
-			return false, fmt.Errorf("error while checking metadata for BigQuery dataset %s: %w",
-				datasetTable.dataset, err)
-		}
-		c.logger.Info(fmt.Sprintf("creating dataset %s...", dataset.DatasetID))
-		if err := dataset.Create(ctx, nil); err != nil {
-			return false, fmt.Errorf("failed to create BigQuery dataset %s: %w", dataset.DatasetID, err)
-		}
+	if err := c.ensureDataset(ctx, datasetTable.dataset); err != nil {
+		return false, err
 	}
-	table := dataset.Table(datasetTable.table)
+	table := c.client.DatasetInProject(c.projectID, datasetTable.dataset).Table(datasetTable.table)
 
 	// check if the table exists
 	existingMetadata, err := table.Metadata(ctx)
@@ -698,6 +825,10 @@ func (c *BigQueryConnector) SetupNormalizedTable(
 		Clustering:       clustering,
 		TimePartitioning: timePartitioning,
 	}
+	if len(config.Labels) > 0 {
+		metadata.Description = shared.MirrorQueryTag(config.FlowName, config.Labels)
+		metadata.Labels = mirrorTableLabels(config.FlowName, config.Labels)
+	}
 
 	if config.IsResync {
 		_, existsErr := table.Metadata(ctx)
@@ -732,7 +863,7 @@ func (c *BigQueryConnector) SyncFlowCleanup(ctx context.Context, jobName string)
 		return fmt.Errorf("unable to clear metadata for sync flow cleanup: %w", err)
 	}
 
-	dataset := c.client.DatasetInProject(c.projectID, c.datasetID)
+	dataset := c.client.DatasetInProject(c.projectID, c.rawDatasetID)
 	rawTableHandle := dataset.Table(c.getRawTableName(jobName))
 	// check if exists, then delete
 	_, err = rawTableHandle.Metadata(ctx)
@@ -749,7 +880,7 @@ func (c *BigQueryConnector) SyncFlowCleanup(ctx context.Context, jobName string)
 
 // getRawTableName returns the raw table name for the given table identifier.
 func (c *BigQueryConnector) getRawTableName(flowJobName string) string {
-	return "_peerdb_raw_" + shared.ReplaceIllegalCharactersWithUnderscores(flowJobName)
+	return rawTablePrefix + shared.ReplaceIllegalCharactersWithUnderscores(flowJobName)
 }
 
 func (c *BigQueryConnector) RenameTables(ctx context.Context, req *protos.RenameTablesInput) (*protos.RenameTablesOutput, error) {
@@ -849,11 +980,11 @@ func (c *BigQueryConnector) RenameTables(ctx context.Context, req *protos.Rename
 					allColsWithAlias, req.SoftDeleteColName, dstDatasetTable.string(),
 					leftJoin)
 
-				query := c.queryWithLogging(q)
+				query := c.queryWithLogging(ctx, q)
 
 				query.DefaultProjectID = c.projectID
 				query.DefaultDatasetID = c.datasetID
-				_, err := query.Read(ctx)
+				_, err := c.readQuery(ctx, query)
 				if err != nil {
 					return nil, fmt.Errorf("unable to handle soft-deletes for table %s: %w", dstDatasetTable.string(), err)
 				}
@@ -869,27 +1000,27 @@ func (c *BigQueryConnector) RenameTables(ctx context.Context, req *protos.Rename
 
 			query.DefaultProjectID = c.projectID
 			query.DefaultDatasetID = c.datasetID
-			_, err := query.Read(ctx)
+			_, err := c.readQuery(ctx, query)
 			if err != nil {
 				return nil, fmt.Errorf("unable to set synced at column for table %s: %w", srcDatasetTable.string(), err)
 			}
 		}
 
 		// drop the dst table if exists
-		dropQuery := c.queryWithLogging("DROP TABLE IF EXISTS " + dstDatasetTable.string())
+		dropQuery := c.queryWithLogging(ctx, "DROP TABLE IF EXISTS "+dstDatasetTable.string())
 		dropQuery.DefaultProjectID = c.projectID
 		dropQuery.DefaultDatasetID = c.datasetID
-		_, err = dropQuery.Read(ctx)
+		_, err = c.readQuery(ctx, dropQuery)
 		if err != nil {
 			return nil, fmt.Errorf("unable to drop table %s: %w", dstDatasetTable.string(), err)
 		}
 
 		// rename the src table to dst
-		query := c.queryWithLogging(fmt.Sprintf("ALTER TABLE %s RENAME TO %s",
+		query := c.queryWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s RENAME TO %s",
 			srcDatasetTable.string(), dstDatasetTable.table))
 		query.DefaultProjectID = c.projectID
 		query.DefaultDatasetID = c.datasetID
-		_, err = query.Read(ctx)
+		_, err = c.readQuery(ctx, query)
 		if err != nil {
 			return nil, fmt.Errorf("unable to rename table %s to %s: %w", srcDatasetTable.string(),
 				dstDatasetTable.string(), err)
@@ -914,11 +1045,11 @@ func (c *BigQueryConnector) CreateTablesFromExisting(
 		c.logger.Info(fmt.Sprintf("creating table '%s' similar to '%s'", newTable, existingTable))
 
 		// rename the src table to dst
-		query := c.queryWithLogging(fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` LIKE `%s`",
+		query := c.queryWithLogging(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` LIKE `%s`",
 			newDatasetTable.string(), existingDatasetTable.string()))
 		query.DefaultProjectID = c.projectID
 		query.DefaultDatasetID = c.datasetID
-		_, err := query.Read(ctx)
+		_, err := c.readQuery(ctx, query)
 		if err != nil {
 			return nil, fmt.Errorf("unable to create table %s: %w", newTable, err)
 		}
@@ -967,7 +1098,85 @@ func (c *BigQueryConnector) convertToDatasetTable(tableName string) (datasetTabl
 	}
 }
 
-func (c *BigQueryConnector) queryWithLogging(query string) *bigquery.Query {
+func (c *BigQueryConnector) queryWithLogging(ctx context.Context, query string) *bigquery.Query {
 	c.logger.Info("[biguery] executing DDL statement", slog.String("query", query))
+	audit.Record(ctx, query)
 	return c.client.Query(query)
 }
+
+// readQuery runs q as a job and waits for its result, BigQuery keeps running jobs nobody waits for
+// so the job is canceled when ctx is done before it finishes
+func (c *BigQueryConnector) readQuery(ctx context.Context, q *bigquery.Query) (*bigquery.RowIterator, error) {
+	if c.statementTimeout > 0 {
+		q.JobTimeout = c.statementTimeout
+	}
+	job, err := q.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	it, err := job.Read(ctx)
+	if err != nil {
+		c.cancelJob(ctx, job)
+		return nil, err
+	}
+	return it, nil
+}
+
+func (c *BigQueryConnector) cancelJob(ctx context.Context, job *bigquery.Job) {
+	if ctx.Err() == nil {
+		return
+	}
+	cancelCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := job.Cancel(cancelCtx); err != nil {
+		c.logger.Warn("failed to cancel BigQuery job", slog.String("jobID", job.ID()), slog.Any("error", err))
+	}
+}
+
+// MaintainTable realigns clustering with the supported primary key columns of the latest source schema,
+// BigQuery reclusters on its own once the clustering specification changes
+func (c *BigQueryConnector) MaintainTable(ctx context.Context, tableIdentifier string, tableSchema *protos.TableSchema) error {
+	if tableSchema == nil {
+		return nil
+	}
+	datasetTable, err := c.convertToDatasetTable(tableIdentifier)
+	if err != nil {
+		return err
+	}
+	table := c.client.DatasetInProject(c.projectID, datasetTable.dataset).Table(datasetTable.table)
+	metadata, err := table.Metadata(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get metadata of table %s: %w", datasetTable.string(), err)
+	}
+
+	var fields []string
+	if supportedPkeyCols := obtainClusteringColumns(tableSchema); len(supportedPkeyCols) < 4 {
+		fields = supportedPkeyCols
+	}
+	existing := make(map[string]struct{}, len(metadata.Schema))
+	for _, field := range metadata.Schema {
+		existing[field.Name] = struct{}{}
+	}
+	for _, field := range fields {
+		// renamed or excluded columns, clustering is left as is
+		if _, ok := existing[field]; !ok {
+			return nil
+		}
+	}
+	var current []string
+	if metadata.Clustering != nil {
+		current = metadata.Clustering.Fields
+	}
+	if slices.Equal(current, fields) {
+		return nil
+	}
+
+	c.logger.Info("[bigquery] updating clustering", slog.String("table", datasetTable.string()),
+		slog.Any("from", current), slog.Any("to", fields))
+	if _, err := table.Update(ctx, bigquery.TableMetadataToUpdate{
+		Clustering: &bigquery.Clustering{Fields: fields},
+	}, metadata.ETag); err != nil {
+		return fmt.Errorf("failed to update clustering of table %s: %w", datasetTable.string(), err)
+	}
+	return nil
+}
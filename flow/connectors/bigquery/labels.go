@@ -0,0 +1,29 @@
+package connbigquery
+
+import (
+	"regexp"
+	"strings"
+)
+
+var reIllegalLabelCharacters = regexp.MustCompile("[^a-z0-9_-]+")
+
+// mirrorTableLabels adapts mirror labels to BigQuery, where values are restricted like keys
+// and keys must start with a letter, labels can then be used to filter billing exports
+func mirrorTableLabels(flowName string, labels map[string]string) map[string]string {
+	tableLabels := make(map[string]string, len(labels)+1)
+	for key, value := range labels {
+		if !strings.HasPrefix(key, "_") {
+			tableLabels[key] = bigQueryLabelValue(value)
+		}
+	}
+	tableLabels["peerdb_mirror"] = bigQueryLabelValue(flowName)
+	return tableLabels
+}
+
+func bigQueryLabelValue(value string) string {
+	value = reIllegalLabelCharacters.ReplaceAllString(strings.ToLower(value), "_")
+	if len(value) > 63 {
+		return value[:63]
+	}
+	return value
+}
@@ -0,0 +1,19 @@
+package connbigquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorTableLabels(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"team":          "data-platform",
+		"cost_center":   "eu_west_42",
+		"peerdb_mirror": "orders_mirror",
+	}, mirrorTableLabels("orders_mirror", map[string]string{
+		"team":        "data-platform",
+		"cost_center": "EU West/42",
+		"_internal":   "x",
+	}))
+}
@@ -261,3 +261,27 @@ func (m *mergeStmtGenerator) generateUpdateStatements(allCols []string, unchange
 	}
 	return updateStmts
 }
+
+// generateTruncateStmt applies a source TRUNCATE to dstDatasetTable, empty when there is nothing to do
+func generateTruncateStmt(
+	dstDatasetTable datasetTable,
+	policy protos.TruncatePolicy,
+	peerdbCols *protos.PeerDBColumns,
+) string {
+	switch policy {
+	case protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE:
+		return fmt.Sprintf("TRUNCATE TABLE `%s`", dstDatasetTable.table)
+	case protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE:
+		if peerdbCols.SoftDeleteColName == "" {
+			return ""
+		}
+		stmt := fmt.Sprintf("UPDATE `%s` SET `%s`=TRUE", dstDatasetTable.table, peerdbCols.SoftDeleteColName)
+		if peerdbCols.SyncedAtColName != "" {
+			stmt += fmt.Sprintf(",`%s`=CURRENT_TIMESTAMP", peerdbCols.SyncedAtColName)
+		}
+		// UPDATE requires a WHERE clause in BigQuery
+		return stmt + fmt.Sprintf(" WHERE `%s` IS NOT TRUE", peerdbCols.SoftDeleteColName)
+	default:
+		return ""
+	}
+}
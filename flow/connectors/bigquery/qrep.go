@@ -93,10 +93,10 @@ func (c *BigQueryConnector) replayTableSchemaDeltasQRep(
 
 func (c *BigQueryConnector) SetupQRepMetadataTables(ctx context.Context, config *protos.QRepConfig) error {
 	if config.WriteMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_OVERWRITE {
-		query := c.queryWithLogging("TRUNCATE TABLE " + config.DestinationTableIdentifier)
+		query := c.queryWithLogging(ctx, "TRUNCATE TABLE "+config.DestinationTableIdentifier)
 		query.DefaultDatasetID = c.datasetID
 		query.DefaultProjectID = c.projectID
-		_, err := query.Read(ctx)
+		_, err := c.readQuery(ctx, query)
 		if err != nil {
 			return fmt.Errorf("failed to TRUNCATE table before query replication: %w", err)
 		}
@@ -12,6 +12,7 @@ import (
 
 	"cloud.google.com/go/bigquery"
 
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
 	avro "github.com/PeerDB-io/peer-flow/connectors/utils/avro"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/model"
@@ -58,7 +59,7 @@ func (s *QRepAvroSyncMethod) SyncRecords(
 	numRecords, err := s.writeToStage(ctx, strconv.FormatInt(syncBatchID, 10), rawTableName, avroSchema,
 		&datasetTable{
 			project: s.connector.projectID,
-			dataset: s.connector.datasetID,
+			dataset: s.connector.rawDatasetID,
 			table:   stagingTable,
 		}, stream, req.FlowJobName)
 	if err != nil {
@@ -66,14 +67,14 @@ func (s *QRepAvroSyncMethod) SyncRecords(
 	}
 
 	bqClient := s.connector.client
-	datasetID := s.connector.datasetID
+	datasetID := s.connector.rawDatasetID
 	insertStmt := fmt.Sprintf("INSERT INTO `%s` SELECT * FROM `%s`;",
 		rawTableName, stagingTable)
 
 	query := bqClient.Query(insertStmt)
-	query.DefaultDatasetID = s.connector.datasetID
+	query.DefaultDatasetID = datasetID
 	query.DefaultProjectID = s.connector.projectID
-	_, err = query.Read(ctx)
+	_, err = s.connector.readQuery(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute statements in a transaction: %w", err)
 	}
@@ -161,9 +162,13 @@ func (s *QRepAvroSyncMethod) SyncQRepRecords(
 	s.connector.logger.Info("Obtained Avro schema for destination table", flowLog, slog.Any("avroSchema", avroSchema))
 	// create a staging table name with partitionID replace hyphens with underscores
 	dstDatasetTable, _ := s.connector.convertToDatasetTable(dstTableName)
+	stagingDataset := dstDatasetTable.dataset
+	if s.connector.bqConfig.RawDataset != "" {
+		stagingDataset = s.connector.rawDatasetID
+	}
 	stagingDatasetTable := &datasetTable{
 		project: s.connector.projectID,
-		dataset: dstDatasetTable.dataset,
+		dataset: stagingDataset,
 		table: fmt.Sprintf("%s_%s_staging", dstDatasetTable.table,
 			strings.ReplaceAll(partition.PartitionId, "-", "_")),
 	}
@@ -194,7 +199,7 @@ func (s *QRepAvroSyncMethod) SyncQRepRecords(
 		query := bqClient.Query(insertStmt)
 		query.DefaultDatasetID = s.connector.datasetID
 		query.DefaultProjectID = s.connector.projectID
-		_, err = query.Read(ctx)
+		_, err = s.connector.readQuery(ctx, query)
 		if err != nil {
 			return -1, fmt.Errorf("SyncQRepRecords: failed to execute statements in a transaction: %w", err)
 		}
@@ -407,6 +412,7 @@ func (s *QRepAvroSyncMethod) writeToStage(
 		avroFilePath := fmt.Sprintf("%s/%s.avro", objectFolder, syncID)
 		obj := bucket.Object(avroFilePath)
 		w := obj.NewWriter(ctx)
+		w.KMSKeyName = s.connector.bqConfig.KmsKeyName
 
 		numRecords, err := ocfWriter.WriteOCF(ctx, w)
 		if err != nil {
@@ -418,10 +424,9 @@ func (s *QRepAvroSyncMethod) writeToStage(
 			FilePath:        avroFilePath,
 		}
 	} else {
-		tmpDir := fmt.Sprintf("%s/peerdb-avro-%s", os.TempDir(), s.flowJobName)
-		err := os.MkdirAll(tmpDir, os.ModePerm)
+		tmpDir, err := utils.SpillDir(s.flowJobName)
 		if err != nil {
-			return 0, fmt.Errorf("failed to create temp dir: %w", err)
+			return 0, err
 		}
 
 		avroFilePath := fmt.Sprintf("%s/%s.avro", tmpDir, syncID)
@@ -459,6 +464,9 @@ func (s *QRepAvroSyncMethod) writeToStage(
 	loader.UseAvroLogicalTypes = true
 	loader.DecimalTargetTypes = []bigquery.DecimalTargetType{bigquery.BigNumericTargetType}
 	loader.WriteDisposition = bigquery.WriteTruncate
+	if s.connector.statementTimeout > 0 {
+		loader.JobTimeout = s.connector.statementTimeout
+	}
 	job, err := loader.Run(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("failed to run BigQuery load job: %w", err)
@@ -466,6 +474,7 @@ func (s *QRepAvroSyncMethod) writeToStage(
 
 	status, err := job.Wait(ctx)
 	if err != nil {
+		s.connector.cancelJob(ctx, job)
 		return 0, fmt.Errorf("failed to wait for BigQuery load job: %w", err)
 	}
 
@@ -0,0 +1,25 @@
+package connbigquery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+)
+
+// EnforceRetention casts column to TIMESTAMP so DATE and DATETIME columns compare too
+func (c *BigQueryConnector) EnforceRetention(ctx context.Context, tableIdentifier string, column string, cutoff time.Time) error {
+	datasetTable, err := c.convertToDatasetTable(tableIdentifier)
+	if err != nil {
+		return err
+	}
+	query := c.queryWithLogging(ctx, fmt.Sprintf("DELETE FROM `%s` WHERE CAST(`%s` AS TIMESTAMP) < TIMESTAMP '%s UTC'",
+		datasetTable.string(), column, utils.RetentionCutoffLiteral(cutoff)))
+	query.DefaultProjectID = c.projectID
+	query.DefaultDatasetID = c.datasetID
+	if _, err := c.readQuery(ctx, query); err != nil {
+		return fmt.Errorf("failed to delete expired rows of %s: %w", datasetTable.string(), err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package connectors
+
+import (
+	"cmp"
+	"slices"
+
+	connbigquery "github.com/PeerDB-io/peer-flow/connectors/bigquery"
+	connclickhouse "github.com/PeerDB-io/peer-flow/connectors/clickhouse"
+	connelasticsearch "github.com/PeerDB-io/peer-flow/connectors/connelasticsearch"
+	conneventhub "github.com/PeerDB-io/peer-flow/connectors/eventhub"
+	connfabric "github.com/PeerDB-io/peer-flow/connectors/fabric"
+	connkafka "github.com/PeerDB-io/peer-flow/connectors/kafka"
+	connkinesis "github.com/PeerDB-io/peer-flow/connectors/kinesis"
+	connmysql "github.com/PeerDB-io/peer-flow/connectors/mysql"
+	connneo4j "github.com/PeerDB-io/peer-flow/connectors/neo4j"
+	connoracle "github.com/PeerDB-io/peer-flow/connectors/oracle"
+	connplugin "github.com/PeerDB-io/peer-flow/connectors/plugin"
+	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	connpubsub "github.com/PeerDB-io/peer-flow/connectors/pubsub"
+	conns3 "github.com/PeerDB-io/peer-flow/connectors/s3"
+	connsinglestore "github.com/PeerDB-io/peer-flow/connectors/singlestore"
+	connsnowflake "github.com/PeerDB-io/peer-flow/connectors/snowflake"
+	connsqlite "github.com/PeerDB-io/peer-flow/connectors/sqlite"
+	connsqlserver "github.com/PeerDB-io/peer-flow/connectors/sqlserver"
+	connstarrocks "github.com/PeerDB-io/peer-flow/connectors/starrocks"
+	connsynthetic "github.com/PeerDB-io/peer-flow/connectors/synthetic"
+	conntimeseries "github.com/PeerDB-io/peer-flow/connectors/timeseries"
+	connvector "github.com/PeerDB-io/peer-flow/connectors/vector"
+	connwebhook "github.com/PeerDB-io/peer-flow/connectors/webhook"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// connectorsByPeerType has a zero connector for every peer type GetConnector supports,
+// only used to check which interfaces a peer type implements
+var connectorsByPeerType = map[protos.DBType]Connector{
+	protos.DBType_POSTGRES:      &connpostgres.PostgresConnector{},
+	protos.DBType_BIGQUERY:      &connbigquery.BigQueryConnector{},
+	protos.DBType_SNOWFLAKE:     &connsnowflake.SnowflakeConnector{},
+	protos.DBType_EVENTHUBS:     &conneventhub.EventHubConnector{},
+	protos.DBType_S3:            &conns3.S3Connector{},
+	protos.DBType_SQLSERVER:     &connsqlserver.SQLServerConnector{},
+	protos.DBType_MYSQL:         &connmysql.MySqlConnector{},
+	protos.DBType_CLICKHOUSE:    &connclickhouse.ClickhouseConnector{},
+	protos.DBType_KAFKA:         &connkafka.KafkaConnector{},
+	protos.DBType_PUBSUB:        &connpubsub.PubSubConnector{},
+	protos.DBType_ELASTICSEARCH: &connelasticsearch.ElasticsearchConnector{},
+	protos.DBType_SYNTHETIC:     &connsynthetic.SyntheticConnector{},
+	protos.DBType_WEBHOOK:       &connwebhook.WebhookConnector{},
+	protos.DBType_KINESIS:       &connkinesis.KinesisConnector{},
+	protos.DBType_FABRIC:        &connfabric.FabricConnector{},
+	protos.DBType_SINGLESTORE:   &connsinglestore.SingleStoreConnector{},
+	protos.DBType_ORACLE:        &connoracle.OracleConnector{},
+	protos.DBType_PLUGIN:        &connplugin.PluginConnector{},
+	protos.DBType_STARROCKS:     &connstarrocks.StarRocksConnector{},
+	protos.DBType_TIMESERIES:    &conntimeseries.TimeSeriesConnector{},
+	protos.DBType_NEO4J:         &connneo4j.Neo4jConnector{},
+	protos.DBType_VECTOR:        &connvector.VectorConnector{},
+	protos.DBType_SQLITE:        &connsqlite.SQLiteConnector{},
+}
+
+func PeerTypeCapabilities() []*protos.PeerTypeCapabilities {
+	capabilities := make([]*protos.PeerTypeCapabilities, 0, len(connectorsByPeerType))
+	for peerType, conn := range connectorsByPeerType {
+		_, cdcSource := conn.(CDCPullConnector)
+		_, cdcDestination := conn.(CDCSyncConnector)
+		_, qrepSource := conn.(QRepPullConnector)
+		_, qrepDestination := conn.(QRepSyncConnector)
+		_, validation := conn.(ValidationConnector)
+		capabilities = append(capabilities, &protos.PeerTypeCapabilities{
+			Type:            peerType,
+			CdcSource:       cdcSource,
+			CdcDestination:  cdcDestination,
+			QrepSource:      qrepSource,
+			QrepDestination: qrepDestination,
+			Validation:      validation,
+		})
+	}
+	slices.SortFunc(capabilities, func(a, b *protos.PeerTypeCapabilities) int {
+		return cmp.Compare(a.Type, b.Type)
+	})
+	return capabilities
+}
@@ -0,0 +1,30 @@
+package connectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestPeerTypeCapabilities(t *testing.T) {
+	capabilities := make(map[protos.DBType]*protos.PeerTypeCapabilities)
+	for _, capability := range PeerTypeCapabilities() {
+		capabilities[capability.Type] = capability
+	}
+	require.NotContains(t, capabilities, protos.DBType_MONGO)
+
+	postgres := capabilities[protos.DBType_POSTGRES]
+	require.True(t, postgres.CdcSource)
+	require.True(t, postgres.CdcDestination)
+	require.True(t, postgres.QrepSource)
+	require.True(t, postgres.QrepDestination)
+
+	kafka := capabilities[protos.DBType_KAFKA]
+	require.False(t, kafka.CdcSource)
+	require.True(t, kafka.CdcDestination)
+
+	require.True(t, capabilities[protos.DBType_SQLSERVER].QrepSource)
+	require.True(t, capabilities[protos.DBType_CLICKHOUSE].Validation)
+}
@@ -0,0 +1,44 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func (c *ClickhouseConnector) ListStagingArtifacts(ctx context.Context) ([]*protos.StagingArtifact, error) {
+	rawDatabase := c.config.RawDatabase
+	if rawDatabase == "" {
+		rawDatabase = c.config.Database
+	}
+	rows, err := c.database.Query(ctx,
+		"SELECT name FROM system.tables WHERE database = ? AND startsWith(name, ?)", rawDatabase, rawTablePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list raw tables: %w", err)
+	}
+	defer rows.Close()
+
+	var artifacts []*protos.StagingArtifact
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, &protos.StagingArtifact{
+			Kind:     protos.StagingArtifactKind_STAGING_ARTIFACT_RAW_TABLE,
+			Name:     rawDatabase + "." + name,
+			FlowName: strings.TrimPrefix(name, rawTablePrefix),
+		})
+	}
+	return artifacts, rows.Err()
+}
+
+func (c *ClickhouseConnector) DropStagingArtifact(ctx context.Context, artifact *protos.StagingArtifact) error {
+	database, table, _ := strings.Cut(artifact.Name, ".")
+	if err := c.execWithLogging(ctx, fmt.Sprintf("DROP TABLE IF EXISTS `%s`.`%s`%s", database, table, onCluster(c.config))); err != nil {
+		return fmt.Errorf("failed to drop raw table %s: %w", artifact.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+const batchControlTable = "peerdb_batches"
+
+// RecordNormalizedBatch writes batch to peerdb_batches, replicated to every node in cluster mode,
+// rows of a retried normalize are collapsed by ReplacingMergeTree
+func (c *ClickhouseConnector) RecordNormalizedBatch(ctx context.Context, flowJobName string, batch *model.NormalizedBatch) error {
+	if err := c.execWithLogging(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s%s (
+		flow_name String,
+		batch_id Int64,
+		start_batch_id Int64,
+		start_lsn Int64,
+		end_lsn Int64,
+		tables String,
+		num_rows Int64,
+		normalized_at DateTime64(6, 'UTC')
+	) ENGINE = %s ORDER BY (flow_name, batch_id)`,
+		batchControlTable, onCluster(c.config), allNodesEngine(c.config, "ReplacingMergeTree"))); err != nil {
+		return fmt.Errorf("failed to create batch control table: %w", err)
+	}
+
+	tables, err := batch.TablesJSON()
+	if err != nil {
+		return err
+	}
+	if err := c.database.Exec(ctx, "INSERT INTO "+batchControlTable+
+		" (flow_name, batch_id, start_batch_id, start_lsn, end_lsn, tables, num_rows, normalized_at)"+
+		" VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		flowJobName, batch.EndBatchID, batch.StartBatchID, batch.StartLSN, batch.EndLSN, tables,
+		batch.NumRows(), batch.NormalizedAt,
+	); err != nil {
+		return fmt.Errorf("failed to record batch %d in batch control table: %w", batch.EndBatchID, err)
+	}
+	return nil
+}
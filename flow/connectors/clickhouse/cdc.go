@@ -6,26 +6,34 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 
 	_ "github.com/ClickHouse/clickhouse-go/v2"
 	_ "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/model"
-	"github.com/PeerDB-io/peer-flow/model/qvalue"
 	"github.com/PeerDB-io/peer-flow/shared"
+	"github.com/PeerDB-io/peer-flow/shared/metrics"
 )
 
 const (
 	checkIfTableExistsSQL = `SELECT exists(SELECT 1 FROM system.tables WHERE database = ? AND name = ?) AS table_exists;`
-	dropTableIfExistsSQL  = `DROP TABLE IF EXISTS %s;`
+	dropTableIfExistsSQL  = `DROP TABLE IF EXISTS %s%s;`
+	rawTablePrefix        = "_peerdb_raw_"
 )
 
-// getRawTableName returns the raw table name for the given table identifier.
+// getRawTableName returns the raw table name for the given table identifier,
+// qualified with the raw database when one is configured.
 func (c *ClickhouseConnector) getRawTableName(flowJobName string) string {
-	return "_peerdb_raw_" + shared.ReplaceIllegalCharactersWithUnderscores(flowJobName)
+	rawTableName := rawTablePrefix + shared.ReplaceIllegalCharactersWithUnderscores(flowJobName)
+	if c.config.RawDatabase != "" {
+		return c.config.RawDatabase + "." + rawTableName
+	}
+	return rawTableName
 }
 
 func (c *ClickhouseConnector) checkIfTableExists(ctx context.Context, databaseName string, tableIdentifier string) (bool, error) {
@@ -44,8 +52,11 @@ func (c *ClickhouseConnector) checkIfTableExists(ctx context.Context, databaseNa
 
 func (c *ClickhouseConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
 	rawTableName := c.getRawTableName(req.FlowJobName)
+	if err := c.createRawDatabase(ctx); err != nil {
+		return nil, err
+	}
 
-	createRawTableSQL := `CREATE TABLE IF NOT EXISTS %s (
+	createRawTableSQL := `CREATE TABLE IF NOT EXISTS %s%s (
 		_peerdb_uid String NOT NULL,
 		_peerdb_timestamp Int64 NOT NULL,
 		_peerdb_destination_table_name String NOT NULL,
@@ -54,10 +65,10 @@ func (c *ClickhouseConnector) CreateRawTable(ctx context.Context, req *protos.Cr
 		_peerdb_match_data String,
 		_peerdb_batch_id Int,
 		_peerdb_unchanged_toast_columns String
-	) ENGINE = ReplacingMergeTree ORDER BY _peerdb_uid;`
+	) ENGINE = %s ORDER BY _peerdb_uid;`
 
-	err := c.execWithLogging(ctx,
-		fmt.Sprintf(createRawTableSQL, rawTableName))
+	err := c.execWithLogging(ctx, fmt.Sprintf(createRawTableSQL,
+		rawTableName, onCluster(c.config), allNodesEngine(c.config, "ReplacingMergeTree")))
 	if err != nil {
 		return nil, fmt.Errorf("unable to create raw table: %w", err)
 	}
@@ -82,6 +93,9 @@ func (c *ClickhouseConnector) syncRecordsViaAvro(
 ) (*model.SyncResponse, error) {
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
 	streamReq := model.NewRecordsToStreamRequest(req.Records.GetRecords(), tableNameRowsMapping, syncBatchID)
+	if err := utils.SetCollapseWindow(ctx, req, streamReq); err != nil {
+		return nil, err
+	}
 	stream, err := utils.RecordsToRawTableStream(streamReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
@@ -93,7 +107,8 @@ func (c *ClickhouseConnector) syncRecordsViaAvro(
 		return nil, err
 	}
 
-	err = c.ReplayTableSchemaDeltas(ctx, req.FlowJobName, req.Records.SchemaDeltas)
+	err = c.replayTableSchemaDeltas(ctx, req.FlowJobName, req.Records.SchemaDeltas,
+		req.TableMappings, req.TableNameSchemaMapping)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
 	}
@@ -118,6 +133,7 @@ func (c *ClickhouseConnector) SyncRecords(ctx context.Context, req *model.SyncRe
 		c.logger.Error("failed to increment id", slog.Any("error", err))
 		return nil, err
 	}
+	metrics.Synced(ctx, req.FlowJobName, res.NumRecordsSynced)
 
 	return res, nil
 }
@@ -125,30 +141,71 @@ func (c *ClickhouseConnector) SyncRecords(ctx context.Context, req *model.SyncRe
 func (c *ClickhouseConnector) ReplayTableSchemaDeltas(ctx context.Context, flowJobName string,
 	schemaDeltas []*protos.TableSchemaDelta,
 ) error {
-	if len(schemaDeltas) == 0 {
-		return nil
-	}
+	return c.replayTableSchemaDeltas(ctx, flowJobName, schemaDeltas, nil, nil)
+}
 
+// replayTableSchemaDeltas adds columns added at the source to the normalized tables and widens those widened there,
+// typed like the columns of created tables as per column settings of the table mappings when known.
+// Added columns are also added to tableNameSchemaMapping, so later steps of the batch see them.
+// Dropped columns are never part of deltas, they stay in the destination and get their default from then on.
+func (c *ClickhouseConnector) replayTableSchemaDeltas(
+	ctx context.Context,
+	flowJobName string,
+	schemaDeltas []*protos.TableSchemaDelta,
+	tableMappings []*protos.TableMapping,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) error {
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil {
 			continue
 		}
 
+		var tableMapping *protos.TableMapping
+		for _, tm := range tableMappings {
+			if tm.DestinationTableIdentifier == schemaDelta.DstTableName {
+				tableMapping = tm
+				break
+			}
+		}
+		if len(schemaDelta.WidenedColumns) > 0 {
+			if err := c.widenColumns(ctx, schemaDelta, tableMapping); err != nil {
+				return err
+			}
+		}
+		tableSchema := tableNameSchemaMapping[schemaDelta.DstTableName]
+
 		for _, addedColumn := range schemaDelta.AddedColumns {
-			clickhouseColType, err := qvalue.QValueKind(addedColumn.Type).ToDWHColumnType(protos.DBType_CLICKHOUSE)
-			if err != nil {
-				return fmt.Errorf("failed to convert column type %s to clickhouse type: %w",
-					addedColumn.Type, err)
+			// relation messages carry no nullability, rows from before the column was added have no value either
+			column := proto.Clone(addedColumn).(*protos.FieldDescription)
+			column.Nullable = true
+			columnSetting := columnSettingFor(tableMapping, column.Name)
+			dstColName := column.Name
+			if columnSetting != nil && columnSetting.DestinationName != "" {
+				dstColName = columnSetting.DestinationName
 			}
-			err = c.execWithLogging(ctx,
-				fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS \"%s\" %s",
-					schemaDelta.DstTableName, addedColumn.Name, clickhouseColType))
+			clickhouseColType, err := clickhouseColumnType(column, columnSetting, schemaDelta.NullableEnabled, false)
 			if err != nil {
-				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
-					schemaDelta.DstTableName, err)
+				return fmt.Errorf("failed to convert column type %s to clickhouse type: %w", column.Type, err)
+			}
+
+			alterTables := []string{localTable(c.config, schemaDelta.DstTableName)}
+			if isDistributed(c.config) {
+				alterTables = append(alterTables, schemaDelta.DstTableName)
 			}
-			c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s", addedColumn.Name,
-				addedColumn.Type),
+			for _, alterTable := range alterTables {
+				if err := c.execWithLogging(ctx,
+					fmt.Sprintf("ALTER TABLE `%s`%s ADD COLUMN IF NOT EXISTS `%s` %s",
+						alterTable, onCluster(c.config), dstColName, clickhouseColType)); err != nil {
+					return fmt.Errorf("failed to add column %s for table %s: %w", dstColName, alterTable, err)
+				}
+			}
+			if tableSchema != nil && !slices.ContainsFunc(tableSchema.Columns, func(col *protos.FieldDescription) bool {
+				return col.Name == column.Name
+			}) {
+				tableSchema.Columns = append(tableSchema.Columns, column)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s", dstColName,
+				clickhouseColType),
 				"destination table name", schemaDelta.DstTableName,
 				"source table name", schemaDelta.SrcTableName)
 		}
@@ -157,6 +214,100 @@ func (c *ClickhouseConnector) ReplayTableSchemaDeltas(ctx context.Context, flowJ
 	return nil
 }
 
+// widenColumns modifies columns widened at the source to their wider type, Nullable if the destination column is.
+// Columns typed by the table mapping keep their type, so do sorting key columns which ClickHouse can't alter
+func (c *ClickhouseConnector) widenColumns(
+	ctx context.Context,
+	schemaDelta *protos.TableSchemaDelta,
+	tableMapping *protos.TableMapping,
+) error {
+	type destinationColumn struct {
+		typ        string
+		sortingKey bool
+	}
+	rows, err := c.database.Query(ctx,
+		"SELECT name, type, is_in_sorting_key FROM system.columns WHERE database = ? AND table = ?",
+		c.config.Database, localTable(c.config, schemaDelta.DstTableName))
+	if err != nil {
+		return fmt.Errorf("failed to get columns of %s: %w", schemaDelta.DstTableName, err)
+	}
+	defer rows.Close()
+	columns := make(map[string]destinationColumn)
+	for rows.Next() {
+		var name string
+		var column destinationColumn
+		var sortingKey uint8
+		if err := rows.Scan(&name, &column.typ, &sortingKey); err != nil {
+			return fmt.Errorf("failed to scan columns of %s: %w", schemaDelta.DstTableName, err)
+		}
+		column.sortingKey = sortingKey != 0
+		columns[name] = column
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", schemaDelta.DstTableName, err)
+	}
+
+	alterTables := []string{localTable(c.config, schemaDelta.DstTableName)}
+	if isDistributed(c.config) {
+		alterTables = append(alterTables, schemaDelta.DstTableName)
+	}
+	for _, widenedColumn := range schemaDelta.WidenedColumns {
+		columnSetting := columnSettingFor(tableMapping, widenedColumn.Name)
+		if columnSetting != nil && columnSetting.DestinationType != "" {
+			continue
+		}
+		dstColName := widenedColumn.Name
+		if columnSetting != nil && columnSetting.DestinationName != "" {
+			dstColName = columnSetting.DestinationName
+		}
+		column, ok := columns[dstColName]
+		if !ok {
+			continue
+		}
+		clickhouseColType, err := widenedColumnType(widenedColumn, column.typ)
+		if err != nil {
+			return err
+		}
+		if sameClickHouseType(clickhouseColType, column.typ) {
+			continue
+		}
+		if column.sortingKey {
+			c.logger.Warn(fmt.Sprintf("[schema delta replay] cannot widen sorting key column %s from %s to %s",
+				dstColName, column.typ, clickhouseColType),
+				"destination table name", schemaDelta.DstTableName)
+			continue
+		}
+		for _, alterTable := range alterTables {
+			if err := c.execWithLogging(ctx,
+				fmt.Sprintf("ALTER TABLE `%s`%s MODIFY COLUMN `%s` %s",
+					alterTable, onCluster(c.config), dstColName, clickhouseColType)); err != nil {
+				return fmt.Errorf("failed to widen column %s for table %s: %w", dstColName, alterTable, err)
+			}
+		}
+		c.logger.Info(fmt.Sprintf("[schema delta replay] widened column %s from %s to %s",
+			dstColName, column.typ, clickhouseColType),
+			"destination table name", schemaDelta.DstTableName,
+			"source table name", schemaDelta.SrcTableName)
+	}
+	return nil
+}
+
+// widenedColumnType is the ClickHouse type of widenedColumn after widening, Nullable like currentType
+func widenedColumnType(widenedColumn *protos.WidenedColumn, currentType string) (string, error) {
+	clickhouseColType, err := clickhouseColumnType(&protos.FieldDescription{
+		Name:         widenedColumn.Name,
+		Type:         widenedColumn.NewType,
+		TypeModifier: widenedColumn.NewTypeModifier,
+	}, nil, false, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert column type %s to clickhouse type: %w", widenedColumn.NewType, err)
+	}
+	if strings.HasPrefix(currentType, "Nullable(") {
+		clickhouseColType = fmt.Sprintf("Nullable(%s)", clickhouseColType)
+	}
+	return clickhouseColType, nil
+}
+
 func (c *ClickhouseConnector) RenameTables(ctx context.Context, req *protos.RenameTablesInput) (*protos.RenameTablesOutput, error) {
 	for _, renameRequest := range req.RenameTableOptions {
 		resyncTableExists, err := c.checkIfTableExists(ctx, c.config.Database, renameRequest.CurrentName)
@@ -183,8 +334,9 @@ func (c *ClickhouseConnector) RenameTables(ctx context.Context, req *protos.Rena
 			allCols := strings.Join(columnNames, ",")
 			c.logger.Info(fmt.Sprintf("handling soft-deletes for table '%s'...", renameRequest.NewName))
 			err = c.execWithLogging(ctx,
-				fmt.Sprintf("INSERT INTO %s(%s,%s) SELECT %s,true FROM %s WHERE %s  = 1",
-					renameRequest.CurrentName, allCols, signColName, allCols, renameRequest.NewName, signColName))
+				fmt.Sprintf("INSERT INTO %s(%s,%s) SELECT %s,true FROM %s WHERE %s  = 1%s",
+					renameRequest.CurrentName, allCols, signColName, allCols, renameRequest.NewName, signColName,
+					distributedInsertSettings(c.config)))
 			if err != nil {
 				return nil, fmt.Errorf("unable to handle soft-deletes for table %s: %w", renameRequest.NewName, err)
 			}
@@ -193,20 +345,34 @@ func (c *ClickhouseConnector) RenameTables(ctx context.Context, req *protos.Rena
 		}
 
 		// drop the dst table if exists
-		err = c.execWithLogging(ctx, "DROP TABLE IF EXISTS "+renameRequest.NewName)
+		err = c.execWithLogging(ctx,
+			fmt.Sprintf(dropTableIfExistsSQL, localTable(c.config, renameRequest.NewName), onCluster(c.config)))
 		if err != nil {
 			return nil, fmt.Errorf("unable to drop table %s: %w", renameRequest.NewName, err)
 		}
 
 		// rename the src table to dst
-		err = c.execWithLogging(ctx, fmt.Sprintf("RENAME TABLE %s TO %s",
-			renameRequest.CurrentName,
-			renameRequest.NewName))
+		err = c.execWithLogging(ctx, fmt.Sprintf("RENAME TABLE %s TO %s%s",
+			localTable(c.config, renameRequest.CurrentName),
+			localTable(c.config, renameRequest.NewName),
+			onCluster(c.config)))
 		if err != nil {
 			return nil, fmt.Errorf("unable to rename table %s to %s: %w",
 				renameRequest.CurrentName, renameRequest.NewName, err)
 		}
 
+		// the Distributed table over the resync tables is replaced by one over the renamed local tables
+		if isDistributed(c.config) {
+			if err := c.execWithLogging(ctx,
+				fmt.Sprintf(dropTableIfExistsSQL, renameRequest.CurrentName, onCluster(c.config))); err != nil {
+				return nil, fmt.Errorf("unable to drop table %s: %w", renameRequest.CurrentName, err)
+			}
+			if err := c.execWithLogging(ctx, createDistributedTableSQL(c.config, renameRequest.NewName,
+				destinationKeyColumns(nil, renameRequest.TableSchema), true)); err != nil {
+				return nil, fmt.Errorf("unable to create distributed table %s: %w", renameRequest.NewName, err)
+			}
+		}
+
 		c.logger.Info(fmt.Sprintf("successfully renamed table '%s' to '%s'",
 			renameRequest.CurrentName, renameRequest.NewName))
 	}
@@ -217,14 +383,14 @@ func (c *ClickhouseConnector) RenameTables(ctx context.Context, req *protos.Rena
 }
 
 func (c *ClickhouseConnector) SyncFlowCleanup(ctx context.Context, jobName string) error {
-	err := c.PostgresMetadata.SyncFlowCleanup(ctx, jobName)
+	err := c.MetadataStore.SyncFlowCleanup(ctx, jobName)
 	if err != nil {
 		return fmt.Errorf("[clickhouse] unable to clear metadata for sync flow cleanup: %w", err)
 	}
 
 	// delete raw table if exists
 	rawTableIdentifier := c.getRawTableName(jobName)
-	err = c.execWithLogging(ctx, fmt.Sprintf(dropTableIfExistsSQL, rawTableIdentifier))
+	err = c.execWithLogging(ctx, fmt.Sprintf(dropTableIfExistsSQL, rawTableIdentifier, onCluster(c.config)))
 	if err != nil {
 		return fmt.Errorf("[clickhouse] unable to drop raw table: %w", err)
 	}
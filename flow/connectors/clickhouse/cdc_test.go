@@ -0,0 +1,66 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestRawDatabase(t *testing.T) {
+	c := &ClickhouseConnector{config: &protos.ClickhouseConfig{Database: "analytics"}}
+	require.Equal(t, "_peerdb_raw_my_flow", c.getRawTableName("my-flow"))
+	require.Equal(t, qRepMetadataTableName, c.qRepMetadataTable())
+
+	c.config.RawDatabase = "peerdb_internal"
+	require.Equal(t, "peerdb_internal._peerdb_raw_my_flow", c.getRawTableName("my-flow"))
+	require.Equal(t, "peerdb_internal._peerdb_query_replication_metadata", c.qRepMetadataTable())
+}
+
+func TestAddedColumnType(t *testing.T) {
+	amount := &protos.FieldDescription{Name: "amount", Type: "numeric", TypeModifier: (10<<16 | 2) + 4, Nullable: true}
+	colType, err := clickhouseColumnType(amount, nil, false, false)
+	require.NoError(t, err)
+	require.Equal(t, "Nullable(DECIMAL(10, 2))", colType)
+
+	qty := &protos.FieldDescription{Name: "qty", Type: "int64", Nullable: true}
+	colType, err = clickhouseColumnType(qty, nil, false, false)
+	require.NoError(t, err)
+	require.Equal(t, "Int64", colType)
+	colType, err = clickhouseColumnType(qty, nil, true, false)
+	require.NoError(t, err)
+	require.Equal(t, "Nullable(Int64)", colType)
+	colType, err = clickhouseColumnType(qty, nil, true, true)
+	require.NoError(t, err)
+	require.Equal(t, "Int64", colType)
+
+	tags := &protos.FieldDescription{Name: "tags", Type: "array_int32", Nullable: true}
+	colType, err = clickhouseColumnType(tags, nil, true, false)
+	require.NoError(t, err)
+	require.Equal(t, "Array(Int32)", colType)
+
+	tableMapping := &protos.TableMapping{Columns: []*protos.ColumnSetting{{SourceName: "qty", DestinationType: "UInt32"}}}
+	colType, err = clickhouseColumnType(qty, columnSettingFor(tableMapping, "qty"), true, false)
+	require.NoError(t, err)
+	require.Equal(t, "Nullable(UInt32)", colType)
+	require.Nil(t, columnSettingFor(tableMapping, "amount"))
+}
+
+func TestWidenedColumnType(t *testing.T) {
+	qty := &protos.WidenedColumn{Name: "qty", OldType: "int32", NewType: "int64"}
+	colType, err := widenedColumnType(qty, "Int32")
+	require.NoError(t, err)
+	require.Equal(t, "Int64", colType)
+	colType, err = widenedColumnType(qty, "Nullable(Int32)")
+	require.NoError(t, err)
+	require.Equal(t, "Nullable(Int64)", colType)
+
+	amount := &protos.WidenedColumn{
+		Name: "amount", OldType: "numeric", OldTypeModifier: (10<<16 | 2) + 4,
+		NewType: "numeric", NewTypeModifier: (12<<16 | 2) + 4,
+	}
+	colType, err = widenedColumnType(amount, "Nullable(Decimal(10, 2))")
+	require.NoError(t, err)
+	require.Equal(t, "Nullable(DECIMAL(12, 2))", colType)
+}
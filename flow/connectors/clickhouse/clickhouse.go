@@ -8,26 +8,31 @@ import (
 	"fmt"
 	"log/slog"
 	"maps"
+	"net"
 	"net/url"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/google/uuid"
 	"go.temporal.io/sdk/log"
 	"golang.org/x/mod/semver"
 
 	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/peerdbenv"
 	"github.com/PeerDB-io/peer-flow/shared"
 )
 
 type ClickhouseConnector struct {
-	*metadataStore.PostgresMetadata
+	metadataStore.MetadataStore
 	database      clickhouse.Conn
 	logger        log.Logger
 	config        *protos.ClickhouseConfig
@@ -35,7 +40,7 @@ type ClickhouseConnector struct {
 	s3Stage       *ClickHouseS3Stage
 }
 
-func ValidateS3(ctx context.Context, creds *utils.ClickHouseS3Credentials) error {
+func ValidateS3(ctx context.Context, creds *utils.ClickHouseS3Credentials, kmsKeyID string) error {
 	// for validation purposes
 	s3Client, err := utils.CreateS3Client(ctx, creds.Provider)
 	if err != nil {
@@ -47,7 +52,20 @@ func ValidateS3(ctx context.Context, creds *utils.ClickHouseS3Credentials) error
 		return fmt.Errorf("failed to create S3 bucket and prefix: %w", err)
 	}
 
-	return utils.PutAndRemoveS3(ctx, s3Client, object.Bucket, object.Prefix)
+	return utils.PutAndRemoveS3(ctx, s3Client, object.Bucket, object.Prefix, kmsKeyID)
+}
+
+// StagingRegions is the region of the bucket Avro files are staged in before ClickHouse inserts them
+func (c *ClickhouseConnector) StagingRegions(ctx context.Context, _ string) ([]string, error) {
+	object, err := utils.NewS3BucketAndPrefix(c.credsProvider.BucketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 bucket and prefix: %w", err)
+	}
+	region, err := utils.S3BucketRegion(ctx, c.credsProvider.Provider, object.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	return []string{strings.ToLower(region)}, nil
 }
 
 func ValidateClickhouseHost(ctx context.Context, chHost string, allowedDomainString string) error {
@@ -72,6 +90,18 @@ func (c *ClickhouseConnector) ValidateCheck(ctx context.Context) error {
 	if err := ValidateClickhouseHost(ctx, c.config.Host, allowedDomains); err != nil {
 		return err
 	}
+	for _, addr := range clickhouseAddrs(c.config)[1:] {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return fmt.Errorf("invalid failover host %s: %w", addr, err)
+		}
+		if err := ValidateClickhouseHost(ctx, host, allowedDomains); err != nil {
+			return err
+		}
+	}
+	if err := c.checkGrants(ctx); err != nil {
+		return err
+	}
 	validateDummyTableName := "peerdb_validation_" + shared.RandomString(4)
 	// create a table
 	err := c.database.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
@@ -109,7 +139,7 @@ func (c *ClickhouseConnector) ValidateCheck(ctx context.Context) error {
 	}
 
 	// validate s3 stage
-	validateErr := ValidateS3(ctx, c.credsProvider)
+	validateErr := ValidateS3(ctx, c.credsProvider, c.config.GetKmsKeyId())
 	if validateErr != nil {
 		return fmt.Errorf("failed to validate S3 bucket: %w", validateErr)
 	}
@@ -123,16 +153,38 @@ func NewClickhouseConnector(
 	config *protos.ClickhouseConfig,
 ) (*ClickhouseConnector, error) {
 	logger := logger.LoggerFromCtx(ctx)
-	database, err := Connect(ctx, config)
+	database, err := connectCreatingDatabase(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open connection to Clickhouse peer: %w", err)
 	}
-
-	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	cloud, err := isCloud(ctx, database)
 	if err != nil {
-		logger.Error("failed to create postgres metadata store", "error", err)
+		database.Close()
 		return nil, err
 	}
+	if cloud {
+		if config.Cluster != "" {
+			logger.Warn("[clickhouse] ignoring cluster on ClickHouse Cloud, services replicate tables themselves",
+				slog.String("cluster", config.Cluster))
+		}
+		config = cloudConfig(config)
+		database.Close()
+		if database, err = connect(ctx, config, cloudSettings); err != nil {
+			return nil, fmt.Errorf("failed to open connection to Clickhouse peer: %w", err)
+		}
+	}
+
+	var metadata metadataStore.MetadataStore
+	if config.CatalogMetadata {
+		pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+		if err != nil {
+			logger.Error("failed to create postgres metadata store", "error", err)
+			return nil, err
+		}
+		metadata = pgMetadata
+	} else {
+		metadata = NewClickhouseMetadata(logger, database, config)
+	}
 
 	credentialsProvider, err := utils.GetAWSCredentialsProvider(ctx, "clickhouse", utils.PeerAWSCredentials{
 		Credentials: aws.Credentials{
@@ -197,16 +249,21 @@ func NewClickhouseConnector(
 	}
 
 	return &ClickhouseConnector{
-		database:         database,
-		PostgresMetadata: pgMetadata,
-		config:           config,
-		logger:           logger,
-		credsProvider:    &clickHouseS3CredentialsNew,
-		s3Stage:          NewClickHouseS3Stage(),
+		database:      database,
+		MetadataStore: metadata,
+		config:        config,
+		logger:        logger,
+		credsProvider: &clickHouseS3CredentialsNew,
+		s3Stage:       NewClickHouseS3Stage(),
 	}, nil
 }
 
 func Connect(ctx context.Context, config *protos.ClickhouseConfig) (clickhouse.Conn, error) {
+	return connect(ctx, config, nil)
+}
+
+// connect opens a connection running every query with extraSettings
+func connect(ctx context.Context, config *protos.ClickhouseConfig, extraSettings clickhouse.Settings) (clickhouse.Conn, error) {
 	var tlsSetting *tls.Config
 	if !config.DisableTls {
 		tlsSetting = &tls.Config{MinVersion: tls.VersionTLS13}
@@ -229,8 +286,41 @@ func Connect(ctx context.Context, config *protos.ClickhouseConfig) (clickhouse.C
 		tlsSetting.RootCAs = caPool
 	}
 
+	// tags queries of mirrors in system.query_log for cost attribution
+	settings := maps.Clone(extraSettings)
+	if flowName, ok := ctx.Value(shared.FlowNameKey).(string); ok && flowName != "" {
+		if settings == nil {
+			settings = make(clickhouse.Settings, 1)
+		}
+		settings["log_comment"] = shared.MirrorQueryTag(flowName, shared.MirrorLabels(ctx))
+	}
+
+	open := func(ctx context.Context, addr string) (clickhouse.Conn, error) {
+		return openAddr(ctx, addr, config, tlsSetting, settings)
+	}
+	addrs := clickhouseAddrs(config)
+	if len(addrs) == 1 {
+		return open(ctx, addrs[0])
+	}
+	reconnect := func(ctx context.Context, start int) (clickhouse.Conn, int, error) {
+		return openWritable(ctx, addrs, start, open)
+	}
+	conn, addr, err := reconnect(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &failoverConn{conn: conn, reconnect: reconnect, addr: addr}, nil
+}
+
+func openAddr(
+	ctx context.Context,
+	addr string,
+	config *protos.ClickhouseConfig,
+	tlsSetting *tls.Config,
+	settings clickhouse.Settings,
+) (clickhouse.Conn, error) {
 	conn, err := clickhouse.Open(&clickhouse.Options{
-		Addr: []string{fmt.Sprintf("%s:%d", config.Host, config.Port)},
+		Addr: []string{addr},
 		Auth: clickhouse.Auth{
 			Database: config.Database,
 			Username: config.User,
@@ -246,6 +336,7 @@ func Connect(ctx context.Context, config *protos.ClickhouseConfig) (clickhouse.C
 				{Name: "peerdb"},
 			},
 		},
+		Settings:    settings,
 		DialTimeout: 3600 * time.Second,
 		ReadTimeout: 3600 * time.Second,
 	})
@@ -277,15 +368,144 @@ func (c *ClickhouseConnector) ConnectionActive(ctx context.Context) error {
 }
 
 func (c *ClickhouseConnector) execWithLogging(ctx context.Context, query string) error {
+	return c.execWithLoggingAndTimeout(ctx, query, 0)
+}
+
+func (c *ClickhouseConnector) execWithLoggingAndTimeout(ctx context.Context, query string, timeout time.Duration) error {
 	c.logger.Info("[clickhouse] executing DDL statement", slog.String("query", query))
-	return c.database.Exec(ctx, query)
+	audit.Record(ctx, query)
+	return c.execWithTimeout(ctx, query, timeout)
+}
+
+// clickhouseTableRe matches a table name, optionally qualified by its database, either part quoted or not
+const clickhouseTableRe = "(?:`[^`]+`|\\w+)(?:\\.(?:`[^`]+`|\\w+))?"
+
+// mutationRe matches statements ClickHouse runs as mutations, capturing the table and kind of ALTER TABLE mutations,
+// or the table of lightweight deletes
+var mutationRe = regexp.MustCompile(`(?is)^\s*(?:ALTER\s+TABLE\s+(` + clickhouseTableRe + `)(?:\s+ON\s+CLUSTER\s+\S+)?` +
+	`\s+(DELETE|UPDATE|MODIFY\s+COLUMN|MATERIALIZE)\b|DELETE\s+FROM\s+(` + clickhouseTableRe + `))`)
+
+// mutation is the mutation ClickHouse runs for a statement
+type mutation struct {
+	// empty for the database of the connection
+	database string
+	table    string
+	// starts of the command system.mutations lists it with
+	commandPrefixes []string
+}
+
+// mutationOf returns the mutation query runs as, if ClickHouse runs it as a mutation
+func mutationOf(query string) (mutation, bool) {
+	match := mutationRe.FindStringSubmatch(query)
+	if match == nil {
+		return mutation{}, false
+	}
+	var m mutation
+	if match[1] != "" {
+		m.database, m.table = splitTableName(match[1])
+		m.commandPrefixes = []string{strings.ToUpper(strings.Join(strings.Fields(match[2]), " "))}
+	} else {
+		m.database, m.table = splitTableName(match[3])
+		// lightweight deletes mask rows, listed as updates of _row_exists by older releases
+		m.commandPrefixes = []string{"DELETE", "UPDATE _row_exists = 0"}
+	}
+	return m, true
+}
+
+// splitTableName splits a table name matched by clickhouseTableRe into its database and table, unquoted
+func splitTableName(name string) (string, string) {
+	var parts []string
+	for name != "" {
+		var part string
+		if strings.HasPrefix(name, "`") {
+			end := strings.IndexByte(name[1:], '`') + 1
+			part, name = name[1:end], name[end+1:]
+		} else if dot := strings.IndexByte(name, '.'); dot >= 0 {
+			part, name = name[:dot], name[dot:]
+		} else {
+			part, name = name, ""
+		}
+		parts = append(parts, part)
+		name = strings.TrimPrefix(name, ".")
+	}
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return parts[0], parts[1]
 }
 
-func (c *ClickhouseConnector) checkTablesEmptyAndEngine(ctx context.Context, tables []string) error {
+// execWithTimeout kills the query on the server once ctx is done or timeout passes, 0 for no timeout.
+// Canceling only closes the connection, leaving statements like INSERT SELECT running server side.
+// Mutations keep running after their query is killed, so the mutation of the statement is killed as well
+func (c *ClickhouseConnector) execWithTimeout(ctx context.Context, query string, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	queryID := uuid.NewString()
+	start := time.Now()
+	err := c.database.Exec(clickhouse.Context(ctx, clickhouse.WithQueryID(queryID)), query)
+	if err != nil && ctx.Err() != nil {
+		killCtx, cancelKill := context.WithTimeout(context.Background(), time.Minute)
+		defer cancelKill()
+		if killErr := c.database.Exec(killCtx, "KILL QUERY WHERE query_id = ?", queryID); killErr != nil {
+			c.logger.Warn("[clickhouse] failed to kill query", slog.String("queryID", queryID), slog.Any("error", killErr))
+		}
+		if m, ok := mutationOf(query); ok {
+			if killErr := c.killMutation(killCtx, m, time.Since(start)); killErr != nil {
+				c.logger.Warn("[clickhouse] failed to kill mutation", slog.String("table", m.table), slog.Any("error", killErr))
+			}
+		}
+		if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("query %s timed out after %s: %w", queryID, timeout, err)
+		}
+	}
+	return err
+}
+
+// killMutation kills m if it is still running, telling it apart from other mutations of its table
+// by its command and being created while its statement ran, elapsed ago at most
+func (c *ClickhouseConnector) killMutation(ctx context.Context, m mutation, elapsed time.Duration) error {
+	database := m.database
+	if database == "" {
+		database = c.config.Database
+	}
+	// create_time is in seconds of the server clock
+	rows, err := c.database.Query(ctx, "SELECT mutation_id FROM system.mutations WHERE database = ? AND table = ?"+
+		" AND NOT is_done AND create_time >= now() - toIntervalSecond(?)"+
+		" AND arrayExists(prefix -> startsWith(command, prefix), ?)",
+		database, m.table, int64(elapsed.Seconds())+1, m.commandPrefixes)
+	if err != nil {
+		return fmt.Errorf("failed to look up mutation: %w", err)
+	}
+	defer rows.Close()
+	var mutationIDs []string
+	for rows.Next() {
+		var mutationID string
+		if err := rows.Scan(&mutationID); err != nil {
+			return fmt.Errorf("failed to look up mutation: %w", err)
+		}
+		mutationIDs = append(mutationIDs, mutationID)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to look up mutation: %w", err)
+	}
+	if len(mutationIDs) == 0 {
+		return nil
+	}
+	c.logger.Info("[clickhouse] killing mutation", slog.String("table", m.table), slog.Any("mutationIDs", mutationIDs))
+	return c.database.Exec(ctx, "KILL MUTATION"+onCluster(c.config)+" WHERE database = ? AND table = ? AND has(?, mutation_id)",
+		database, m.table, mutationIDs)
+}
+
+func (c *ClickhouseConnector) checkTablesEmptyAndEngine(ctx context.Context, tables []string, allowNonEmpty bool) error {
 	queryInput := make([]interface{}, 0, len(tables)+1)
 	queryInput = append(queryInput, c.config.Database)
+	// rows of Distributed tables are in their local tables
 	for _, table := range tables {
-		queryInput = append(queryInput, table)
+		queryInput = append(queryInput, localTable(c.config, table))
 	}
 	rows, err := c.database.Query(ctx,
 		fmt.Sprintf("SELECT name,engine,total_rows FROM system.tables WHERE database=? AND table IN (%s)",
@@ -302,7 +522,7 @@ func (c *ClickhouseConnector) checkTablesEmptyAndEngine(ctx context.Context, tab
 		if err != nil {
 			return fmt.Errorf("failed to scan information for tables: %w", err)
 		}
-		if totalRows != 0 {
+		if totalRows != 0 && !allowNonEmpty {
 			return fmt.Errorf("table %s exists and is not empty", tableName)
 		}
 		if !slices.Contains(acceptableTableEngines, engine) {
@@ -394,6 +614,9 @@ func (c *ClickhouseConnector) CheckDestinationTables(ctx context.Context, req *p
 	if req.SyncedAtColName != "" {
 		peerDBColumns = append(peerDBColumns, strings.ToLower(req.SyncedAtColName))
 	}
+	if req.SourceIdentifier != "" {
+		peerDBColumns = append(peerDBColumns, model.SourceIdentifierColName)
+	}
 	// this is for handling column exclusion, processed schema does that in a step
 	processedMapping := shared.BuildProcessedSchemaMapping(req.TableMappings, tableNameSchemaMapping, c.logger)
 	dstTableNames := slices.Collect(maps.Keys(processedMapping))
@@ -401,7 +624,8 @@ func (c *ClickhouseConnector) CheckDestinationTables(ctx context.Context, req *p
 	// In the case of resync, we don't need to check the content or structure of the original tables;
 	// they'll anyways get swapped out with the _resync tables which we CREATE OR REPLACE
 	if !req.Resync {
-		err := c.checkTablesEmptyAndEngine(ctx, dstTableNames)
+		// mirrors with a source identifier share destination tables with mirrors of other sources
+		err := c.checkTablesEmptyAndEngine(ctx, dstTableNames, req.SourceIdentifier != "")
 		if err != nil {
 			return err
 		}
@@ -431,3 +655,8 @@ func (c *ClickhouseConnector) CheckDestinationTables(ctx context.Context, req *p
 	}
 	return nil
 }
+
+// MaintainTable merges all parts of a table so ReplacingMergeTree collapses rows replaced by normalization
+func (c *ClickhouseConnector) MaintainTable(ctx context.Context, tableIdentifier string, _ *protos.TableSchema) error {
+	return c.execWithLoggingAndTimeout(ctx, fmt.Sprintf("OPTIMIZE TABLE `%s`%s FINAL", localTable(c.config, tableIdentifier), onCluster(c.config)), 0)
+}
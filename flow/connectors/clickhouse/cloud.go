@@ -0,0 +1,41 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// cloudSettings of connections to ClickHouse Cloud, where every query may land on another replica of the service.
+// SharedMergeTree replicas pick up parts committed by others lazily, so normalize could miss raw rows
+// synced through another replica and sync state reads could go back without waiting for the latest parts
+var cloudSettings = clickhouse.Settings{"select_sequential_consistency": 1}
+
+// isCloud reports whether conn is to ClickHouse Cloud, detected by SharedMergeTree,
+// the engine Cloud services store every MergeTree family table with
+func isCloud(ctx context.Context, conn clickhouse.Conn) (bool, error) {
+	var count uint64
+	if err := conn.QueryRow(ctx,
+		"SELECT count() FROM system.table_engines WHERE name = 'SharedMergeTree'",
+	).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check for ClickHouse Cloud: %w", err)
+	}
+	return count > 0, nil
+}
+
+// cloudConfig drops the cluster of config on ClickHouse Cloud, where services replicate DDL and tables
+// themselves: DDL runs without ON CLUSTER and MergeTree engines are created without Replicated paths,
+// which Cloud turns into SharedMergeTree. Tables are never Distributed as a service is a single shard
+func cloudConfig(config *protos.ClickhouseConfig) *protos.ClickhouseConfig {
+	if config.Cluster == "" {
+		return config
+	}
+	withoutCluster := proto.Clone(config).(*protos.ClickhouseConfig)
+	withoutCluster.Cluster = ""
+	withoutCluster.Distributed = false
+	return withoutCluster
+}
@@ -0,0 +1,82 @@
+package connclickhouse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const (
+	localTableSuffix = "_local"
+	// raw and metadata tables replicate to every node whatever its shard, so normalize can read them from any node
+	allNodesReplicaPath = "'/clickhouse/tables/{uuid}', '{shard}-{replica}'"
+)
+
+// onCluster returns the ON CLUSTER clause of DDL in cluster mode
+func onCluster(config *protos.ClickhouseConfig) string {
+	if config.Cluster == "" {
+		return ""
+	}
+	return " ON CLUSTER `" + config.Cluster + "`"
+}
+
+// isDistributed reports whether destination tables are Distributed tables over sharded local tables
+func isDistributed(config *protos.ClickhouseConfig) bool {
+	return config.Cluster != "" && config.Distributed
+}
+
+// localTable returns the table rows of a destination table are stored in,
+// which is the sharded table behind it in distributed mode
+func localTable(config *protos.ClickhouseConfig, table string) string {
+	if isDistributed(config) {
+		return table + localTableSuffix
+	}
+	return table
+}
+
+// destinationEngine returns engine, like ReplacingMergeTree(`_peerdb_version`), in its Replicated variant in cluster mode
+func destinationEngine(config *protos.ClickhouseConfig, engine string) string {
+	if config.Cluster == "" {
+		return engine
+	}
+	return "Replicated" + engine
+}
+
+// allNodesEngine returns the engine of raw and metadata tables, like MergeTree, replicated to every node in cluster mode
+func allNodesEngine(config *protos.ClickhouseConfig, engine string, args ...string) string {
+	if config.Cluster == "" {
+		return engine + "(" + strings.Join(args, ", ") + ")"
+	}
+	return "Replicated" + engine + "(" + strings.Join(append([]string{allNodesReplicaPath}, args...), ", ") + ")"
+}
+
+// createDistributedTableSQL creates the Distributed table of a destination table over its local tables,
+// sharded by key so every version of a row lands on the same shard for ReplacingMergeTree to collapse
+func createDistributedTableSQL(config *protos.ClickhouseConfig, table string, keyColumns []string, replace bool) string {
+	shardingKey := "rand()"
+	if len(keyColumns) > 0 {
+		quotedKeys := make([]string, 0, len(keyColumns))
+		for _, key := range keyColumns {
+			quotedKeys = append(quotedKeys, "`"+key+"`")
+		}
+		shardingKey = "cityHash64(" + strings.Join(quotedKeys, ",") + ")"
+	}
+	create := "CREATE TABLE IF NOT EXISTS"
+	if replace {
+		create = "CREATE OR REPLACE TABLE"
+	}
+	local := localTable(config, table)
+	return fmt.Sprintf("%s `%s`%s AS `%s` ENGINE = Distributed('%s', '%s', '%s', %s)",
+		create, table, onCluster(config), local, stringLiteralEscaper.Replace(config.Cluster),
+		stringLiteralEscaper.Replace(config.Database), stringLiteralEscaper.Replace(local), shardingKey)
+}
+
+// distributedInsertSettings waits for inserts into Distributed tables to reach every shard,
+// instead of queueing them on the node so a normalized batch could still be lost
+func distributedInsertSettings(config *protos.ClickhouseConfig) string {
+	if isDistributed(config) {
+		return " SETTINGS insert_distributed_sync=1"
+	}
+	return ""
+}
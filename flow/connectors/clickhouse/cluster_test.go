@@ -0,0 +1,92 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestClusterClauses(t *testing.T) {
+	single := &protos.ClickhouseConfig{Database: "db"}
+	require.Empty(t, onCluster(single))
+	require.Equal(t, "events", localTable(single, "events"))
+	require.Equal(t, "MergeTree()", destinationEngine(single, "MergeTree()"))
+	require.Equal(t, "ReplacingMergeTree()", allNodesEngine(single, "ReplacingMergeTree"))
+	require.Empty(t, distributedInsertSettings(single))
+
+	replicated := &protos.ClickhouseConfig{Database: "db", Cluster: "main"}
+	require.Equal(t, " ON CLUSTER `main`", onCluster(replicated))
+	require.Equal(t, "events", localTable(replicated, "events"))
+	require.Equal(t, "ReplicatedReplacingMergeTree(`_peerdb_version`)",
+		destinationEngine(replicated, "ReplacingMergeTree(`_peerdb_version`)"))
+	require.Equal(t, "ReplicatedMergeTree('/clickhouse/tables/{uuid}', '{shard}-{replica}')",
+		allNodesEngine(replicated, "MergeTree"))
+	require.Equal(t, "ReplicatedReplacingMergeTree('/clickhouse/tables/{uuid}', '{shard}-{replica}', value)",
+		allNodesEngine(replicated, "ReplacingMergeTree", "value"))
+
+	// distributed is ignored without a cluster
+	require.False(t, isDistributed(&protos.ClickhouseConfig{Distributed: true}))
+}
+
+func TestCreateDistributedTableSQL(t *testing.T) {
+	config := &protos.ClickhouseConfig{Database: "db", Cluster: "main", Distributed: true}
+	require.Equal(t, "events_local", localTable(config, "events"))
+	require.Equal(t, " SETTINGS insert_distributed_sync=1", distributedInsertSettings(config))
+	require.Equal(t, "CREATE TABLE IF NOT EXISTS `events` ON CLUSTER `main` AS `events_local` "+
+		"ENGINE = Distributed('main', 'db', 'events_local', cityHash64(`id`,`region`))",
+		createDistributedTableSQL(config, "events", []string{"id", "region"}, false))
+	require.Equal(t, "CREATE OR REPLACE TABLE `events_resync` ON CLUSTER `main` AS `events_resync_local` "+
+		"ENGINE = Distributed('main', 'db', 'events_resync_local', rand())",
+		createDistributedTableSQL(config, "events_resync", nil, true))
+}
+
+func TestCloudConfig(t *testing.T) {
+	config := &protos.ClickhouseConfig{Database: "db", Cluster: "main", Distributed: true}
+	cloud := cloudConfig(config)
+	require.Empty(t, onCluster(cloud))
+	require.Equal(t, "events", localTable(cloud, "events"))
+	require.Equal(t, "ReplacingMergeTree(`_peerdb_version`)", destinationEngine(cloud, "ReplacingMergeTree(`_peerdb_version`)"))
+	// config of the peer is left as is
+	require.Equal(t, "main", config.Cluster)
+
+	single := &protos.ClickhouseConfig{Database: "db"}
+	require.Same(t, single, cloudConfig(single))
+}
+
+func TestMutationOf(t *testing.T) {
+	for query, expected := range map[string]mutation{
+		"ALTER TABLE `events_local` ON CLUSTER `main` DELETE WHERE `ts` < now()": {
+			table: "events_local", commandPrefixes: []string{"DELETE"},
+		},
+		"ALTER TABLE `events` UPDATE `v` = 1 WHERE true": {table: "events", commandPrefixes: []string{"UPDATE"}},
+		"alter table raw.events modify  column `v` Int64": {
+			database: "raw", table: "events", commandPrefixes: []string{"MODIFY COLUMN"},
+		},
+		"ALTER TABLE `peerdb internal`.`events.v2` DELETE WHERE 1": {
+			database: "peerdb internal", table: "events.v2", commandPrefixes: []string{"DELETE"},
+		},
+		"ALTER TABLE db.`events` MATERIALIZE INDEX idx": {
+			database: "db", table: "events", commandPrefixes: []string{"MATERIALIZE"},
+		},
+		"DELETE FROM events_local ON CLUSTER `main` WHERE (`id`) IN (SELECT 1)": {
+			table: "events_local", commandPrefixes: []string{"DELETE", "UPDATE _row_exists = 0"},
+		},
+		"DELETE FROM `raw`.events WHERE `id` = 1": {
+			database: "raw", table: "events", commandPrefixes: []string{"DELETE", "UPDATE _row_exists = 0"},
+		},
+	} {
+		m, ok := mutationOf(query)
+		require.True(t, ok, query)
+		require.Equal(t, expected, m, query)
+	}
+	for _, query := range []string{
+		"INSERT INTO `events` SELECT * FROM `events_resync`",
+		"ALTER TABLE `events` ON CLUSTER `main` DROP PARTITION ID '202401'",
+		"OPTIMIZE TABLE `events` FINAL",
+	} {
+		_, ok := mutationOf(query)
+		require.False(t, ok, query)
+	}
+}
@@ -0,0 +1,49 @@
+package connclickhouse
+
+import (
+	"math"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+// columns whose values follow the order of rows this closely are delta encoded
+const sequentialCorrelation = 0.9
+
+// columns with at most this many bits of information per value repeat values
+const repetitiveEntropyBits = 12
+
+// columnCodec returns the codec of a column, the one of its column setting if set or else one suiting the profile
+// of its source column. Empty keeps the LZ4 default, which suits columns without a profile or pattern to exploit
+func columnCodec(column *protos.FieldDescription, columnSetting *protos.ColumnSetting) string {
+	if columnSetting != nil && columnSetting.Codec != "" {
+		return columnSetting.Codec
+	}
+	profile := column.Profile
+	if profile == nil {
+		return ""
+	}
+
+	sequential := math.Abs(profile.Correlation) >= sequentialCorrelation
+	switch qvalue.QValueKind(column.Type) {
+	case qvalue.QValueKindInt16, qvalue.QValueKindInt32, qvalue.QValueKindInt64, qvalue.QValueKindDate:
+		if sequential {
+			return "Delta, ZSTD"
+		}
+	case qvalue.QValueKindTimestamp, qvalue.QValueKindTimestampTZ:
+		// timestamps of inserts mostly grow by similar steps
+		if sequential {
+			return "DoubleDelta, ZSTD"
+		}
+	case qvalue.QValueKindFloat32, qvalue.QValueKindFloat64:
+		if sequential {
+			return "Gorilla, ZSTD"
+		}
+	case qvalue.QValueKindString, qvalue.QValueKindJSON, qvalue.QValueKindBytes:
+		// wide values of columns with a few thousand values at most repeat a lot, which ZSTD compresses far better
+		if profile.AverageWidth >= 16 && profile.EntropyBits <= repetitiveEntropyBits {
+			return "ZSTD(3)"
+		}
+	}
+	return ""
+}
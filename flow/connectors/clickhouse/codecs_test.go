@@ -0,0 +1,32 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestColumnCodec(t *testing.T) {
+	column := func(kind qvalue.QValueKind, profile *protos.ColumnProfile) *protos.FieldDescription {
+		return &protos.FieldDescription{Name: "c", Type: string(kind), Profile: profile}
+	}
+
+	require.Equal(t, "Delta, ZSTD", columnCodec(column(qvalue.QValueKindInt64,
+		&protos.ColumnProfile{Correlation: 1, EntropyBits: 20, AverageWidth: 8}), nil))
+	require.Equal(t, "DoubleDelta, ZSTD", columnCodec(column(qvalue.QValueKindTimestampTZ,
+		&protos.ColumnProfile{Correlation: 0.97, EntropyBits: 20, AverageWidth: 8}), nil))
+	require.Empty(t, columnCodec(column(qvalue.QValueKindInt64,
+		&protos.ColumnProfile{Correlation: 0.1, EntropyBits: 20, AverageWidth: 8}), nil))
+	require.Equal(t, "ZSTD(3)", columnCodec(column(qvalue.QValueKindString,
+		&protos.ColumnProfile{EntropyBits: 3, AverageWidth: 40}), nil))
+	// uuids as text carry information in every byte
+	require.Empty(t, columnCodec(column(qvalue.QValueKindString,
+		&protos.ColumnProfile{EntropyBits: 20, AverageWidth: 37}), nil))
+	require.Empty(t, columnCodec(column(qvalue.QValueKindInt64, nil), nil))
+
+	require.Equal(t, "T64, LZ4", columnCodec(column(qvalue.QValueKindInt64,
+		&protos.ColumnProfile{Correlation: 1}), &protos.ColumnSetting{SourceName: "c", Codec: "T64, LZ4"}))
+}
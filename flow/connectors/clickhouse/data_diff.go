@@ -0,0 +1,57 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+func (c *ClickhouseConnector) LookupRows(
+	ctx context.Context,
+	flowJobName string,
+	table string,
+	keyColumns []string,
+	keys [][]any,
+) ([]model.SampledRow, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	quotedKeyColumns := make([]string, 0, len(keyColumns))
+	for _, keyColumn := range keyColumns {
+		quotedKeyColumns = append(quotedKeyColumns, fmt.Sprintf("`%s`", keyColumn))
+	}
+	filter := utils.SampledKeysFilter(quotedKeyColumns, len(keys), func(int) string { return "?" })
+
+	// final applies to ReplacingMergeTree tables and is ignored for others
+	rows, err := c.database.Query(ctx,
+		fmt.Sprintf("SELECT * FROM `%s` WHERE %s AND `%s` = 0 SETTINGS final = 1", table, filter, signColName),
+		utils.SampledKeyArgs(keys)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up rows of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columnTypes := rows.ColumnTypes()
+	var sampled []model.SampledRow
+	for rows.Next() {
+		values := make([]any, len(columnTypes))
+		for i, columnType := range columnTypes {
+			values[i] = reflect.New(columnType.ScanType()).Interface()
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, fmt.Errorf("failed to scan row of %s: %w", table, err)
+		}
+		row := make(model.SampledRow, len(columnTypes))
+		for i, columnType := range columnTypes {
+			row[columnType.Name()] = reflect.ValueOf(values[i]).Elem().Interface()
+		}
+		sampled = append(sampled, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to look up rows of %s: %w", table, err)
+	}
+	return sampled, nil
+}
@@ -0,0 +1,77 @@
+package connclickhouse
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+var (
+	// 'value'::character varying, trailing casts are dropped as the column type applies
+	pgCastRe          = regexp.MustCompile(`^(.*?)(::[a-zA-Z_ ]+(\([0-9, ]+\))?(\[\])?)+$`)
+	pgNumberLiteralRe = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	pgStringLiteralRe = regexp.MustCompile(`^'((?:[^']|'')*)'$`)
+)
+
+// translateDefaultExpression translates Postgres column defaults made of constants or current time to ClickHouse,
+// returning false for anything else like sequences or function calls
+func translateDefaultExpression(expression string, kind qvalue.QValueKind) (string, bool) {
+	expression = strings.TrimSpace(expression)
+	if match := pgCastRe.FindStringSubmatch(expression); match != nil {
+		expression = match[1]
+	}
+	if strings.HasPrefix(expression, "(") && strings.HasSuffix(expression, ")") {
+		expression = expression[1 : len(expression)-1]
+	}
+
+	switch strings.ToLower(expression) {
+	case "now()", "current_timestamp", "statement_timestamp()", "transaction_timestamp()", "clock_timestamp()", "localtimestamp":
+		switch kind {
+		case qvalue.QValueKindTimestamp, qvalue.QValueKindTimestampTZ:
+			return "now64(6)", true
+		case qvalue.QValueKindDate:
+			return "today()", true
+		}
+		return "", false
+	case "current_date":
+		if kind == qvalue.QValueKindDate {
+			return "today()", true
+		}
+		return "", false
+	case "true", "false":
+		if kind == qvalue.QValueKindBoolean {
+			return strings.ToLower(expression), true
+		}
+		return "", false
+	}
+
+	if pgNumberLiteralRe.MatchString(expression) {
+		return expression, true
+	}
+	if match := pgStringLiteralRe.FindStringSubmatch(expression); match != nil {
+		value := strings.ReplaceAll(match[1], "''", "'")
+		switch kind {
+		case qvalue.QValueKindInt16, qvalue.QValueKindInt32, qvalue.QValueKindInt64,
+			qvalue.QValueKindFloat32, qvalue.QValueKindFloat64, qvalue.QValueKindNumeric:
+			// '-1'::integer is how Postgres prints negative defaults
+			if pgNumberLiteralRe.MatchString(value) {
+				return value, true
+			}
+			return "", false
+		case qvalue.QValueKindBoolean:
+			switch strings.ToLower(value) {
+			case "t", "true":
+				return "true", true
+			case "f", "false":
+				return "false", true
+			}
+			return "", false
+		case qvalue.QValueKindString, qvalue.QValueKindQChar, qvalue.QValueKindJSON, qvalue.QValueKindUUID, qvalue.QValueKindDate:
+			return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'", true
+		}
+		// timestamps print with offsets and intervals in units ClickHouse has no literal for
+		return "", false
+	}
+	return "", false
+}
@@ -0,0 +1,36 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestTranslateDefaultExpression(t *testing.T) {
+	for _, tc := range []struct {
+		expression string
+		kind       qvalue.QValueKind
+		expected   string
+		ok         bool
+	}{
+		{"0", qvalue.QValueKindInt32, "0", true},
+		{"'-1'::integer", qvalue.QValueKindInt32, "-1", true},
+		{"1.5", qvalue.QValueKindNumeric, "1.5", true},
+		{"'pending'::character varying", qvalue.QValueKindString, "'pending'", true},
+		{"'it''s'::text", qvalue.QValueKindString, `'it\'s'`, true},
+		{"'a::b'::text", qvalue.QValueKindString, "'a::b'", true},
+		{"true", qvalue.QValueKindBoolean, "true", true},
+		{"now()", qvalue.QValueKindTimestampTZ, "now64(6)", true},
+		{"CURRENT_TIMESTAMP", qvalue.QValueKindTimestamp, "now64(6)", true},
+		{"CURRENT_DATE", qvalue.QValueKindDate, "today()", true},
+		{"nextval('users_id_seq'::regclass)", qvalue.QValueKindInt64, "", false},
+		{"gen_random_uuid()", qvalue.QValueKindUUID, "", false},
+		{"'2020-01-01 00:00:00+00'::timestamp with time zone", qvalue.QValueKindTimestampTZ, "", false},
+	} {
+		expected, ok := translateDefaultExpression(tc.expression, tc.kind)
+		if expected != tc.expected || ok != tc.ok {
+			t.Errorf("translateDefaultExpression(%q, %s) = %q, %t, expected %q, %t",
+				tc.expression, tc.kind, expected, ok, tc.expected, tc.ok)
+		}
+	}
+}
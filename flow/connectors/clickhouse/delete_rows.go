@@ -0,0 +1,40 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
+)
+
+// DeleteRows deletes every version of matching rows through a mutation, including ones marked deleted
+// which ReplacingMergeTree keeps until merged, mutations_sync waits for it so rows are gone once this returns
+func (c *ClickhouseConnector) DeleteRows(
+	ctx context.Context,
+	tableIdentifier string,
+	keyColumns []string,
+	keys [][]any,
+	predicate string,
+) error {
+	quotedKeyColumns := make([]string, 0, len(keyColumns))
+	for _, keyColumn := range keyColumns {
+		quotedKeyColumns = append(quotedKeyColumns, fmt.Sprintf("`%s`", keyColumn))
+	}
+	filter, args := utils.RowDeleteFilter(quotedKeyColumns, keys, predicate, func(int) string { return "?" })
+
+	table := localTable(c.config, tableIdentifier)
+	query := fmt.Sprintf("ALTER TABLE `%s`%s DELETE WHERE %s",
+		table, onCluster(c.config), filter)
+	c.logger.Info("[clickhouse] deleting rows", slog.String("query", audit.Redact(query)))
+	audit.Record(ctx, query)
+	if err := c.database.Exec(
+		clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 2})), query, args...,
+	); err != nil {
+		return fmt.Errorf("failed to delete rows of %s: %w", table, err)
+	}
+	return nil
+}
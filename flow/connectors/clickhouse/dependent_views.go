@@ -0,0 +1,39 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// refreshDependentViews refreshes refreshable materialized views over tables changed by a normalize,
+// other views reading the table as it is queried
+func (c *ClickhouseConnector) refreshDependentViews(
+	ctx context.Context,
+	tableMappings []*protos.TableMapping,
+	tables []string,
+) error {
+	for _, tbl := range tables {
+		tableMapping := utils.FindTableMapping(tableMappings, tbl)
+		for _, view := range tableMapping.GetDependentViews() {
+			if !view.Materialized {
+				continue
+			}
+			if err := c.execWithLogging(ctx,
+				fmt.Sprintf("SYSTEM REFRESH VIEW %s%s", quoteQualifiedIdentifier(view.Name), onCluster(c.config))); err != nil {
+				return fmt.Errorf("failed to refresh dependent view %s of %s: %w", view.Name, tbl, err)
+			}
+		}
+	}
+	return nil
+}
+
+func quoteQualifiedIdentifier(name string) string {
+	if database, table, ok := strings.Cut(name, "."); ok {
+		return "`" + database + "`.`" + table + "`"
+	}
+	return "`" + name + "`"
+}
@@ -0,0 +1,12 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteQualifiedIdentifier(t *testing.T) {
+	require.Equal(t, "`analytics`.`daily_orders`", quoteQualifiedIdentifier("analytics.daily_orders"))
+	require.Equal(t, "`daily_orders`", quoteQualifiedIdentifier("daily_orders"))
+}
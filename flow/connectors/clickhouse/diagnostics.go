@@ -0,0 +1,54 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+)
+
+// NormalizeDiagnostics describes queries running on the server, and merges and mutations unfinished
+// on tables of the database, normalize waits on mutations and merges slow down inserts
+func (c *ClickhouseConnector) NormalizeDiagnostics(ctx context.Context, flowJobName string) ([]string, error) {
+	processes, merges, mutations := "system.processes", "system.merges", "system.mutations"
+	if c.config.Cluster != "" {
+		processes = fmt.Sprintf("clusterAllReplicas('%s', system.processes)", c.config.Cluster)
+		merges = fmt.Sprintf("clusterAllReplicas('%s', system.merges)", c.config.Cluster)
+		mutations = fmt.Sprintf("clusterAllReplicas('%s', system.mutations)", c.config.Cluster)
+	}
+
+	var lines []string
+	for _, diagnostic := range []struct {
+		name  string
+		query string
+		args  []any
+	}{
+		{"running queries", `SELECT format('query {} running {}s, read {} rows using {}: {}', query_id,
+			toString(round(elapsed)), toString(read_rows), formatReadableSize(memory_usage), substring(query, 1, 500))
+			FROM ` + processes + ` WHERE query_id != queryID() ORDER BY elapsed DESC LIMIT 20`, nil},
+		{"merges", `SELECT format('merge of {} parts of {} {}% done after {}s, {} compressed', toString(num_parts),
+			table, toString(round(progress * 100)), toString(round(elapsed)), formatReadableSize(total_size_bytes_compressed))
+			FROM ` + merges + ` WHERE database = ? ORDER BY elapsed DESC LIMIT 20`, []any{c.config.Database}},
+		{"mutations", `SELECT format('mutation {} of {} created {} with {} parts to do{}: {}', mutation_id, table,
+			toString(create_time), toString(parts_to_do), if(latest_fail_reason = '', '', ', failing with ' || latest_fail_reason),
+			substring(command, 1, 500))
+			FROM ` + mutations + ` WHERE database = ? AND NOT is_done ORDER BY create_time LIMIT 20`, []any{c.config.Database}},
+	} {
+		rows, err := c.database.Query(ctx, diagnostic.query, diagnostic.args...)
+		if err != nil {
+			return lines, fmt.Errorf("failed to read %s: %w", diagnostic.name, err)
+		}
+		for rows.Next() {
+			var line string
+			if err := rows.Scan(&line); err != nil {
+				rows.Close()
+				return lines, fmt.Errorf("failed to scan %s: %w", diagnostic.name, err)
+			}
+			lines = append(lines, line)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return lines, fmt.Errorf("failed to read %s: %w", diagnostic.name, err)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,144 @@
+package connclickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const defaultDictionaryLayout = "COMPLEX_KEY_HASHED"
+
+var (
+	reDictionaryLayout   = regexp.MustCompile(`^[A-Z_]+$`)
+	stringLiteralEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+)
+
+type dictionaryColumn struct {
+	name     string
+	typeName string
+}
+
+func dictionaryName(tableIdentifier string, dictionary *protos.ClickhouseDictionary) string {
+	if dictionary.Name != "" {
+		return dictionary.Name
+	}
+	return tableIdentifier + "_dict"
+}
+
+// createDictionarySQL generates a dictionary loading the latest version of live rows,
+// password is part of the statement so it must not be logged
+func createDictionarySQL(
+	config *protos.ClickhouseConfig,
+	tableIdentifier string,
+	dictionary *protos.ClickhouseDictionary,
+	engine protos.TableEngine,
+	columns []dictionaryColumn,
+	keyColumns []string,
+) (string, error) {
+	if len(keyColumns) == 0 {
+		return "", errors.New("dictionary needs a primary key")
+	}
+	layout := dictionary.Layout
+	if layout == "" {
+		layout = defaultDictionaryLayout
+	}
+	if !reDictionaryLayout.MatchString(layout) {
+		return "", fmt.Errorf("invalid dictionary layout %s", layout)
+	}
+
+	attributes := make([]string, 0, len(columns))
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column.name == signColName || column.name == versionColName {
+			continue
+		}
+		attributes = append(attributes, fmt.Sprintf("`%s` %s", column.name, column.typeName))
+		names = append(names, fmt.Sprintf("`%s`", column.name))
+	}
+	quotedKeys := make([]string, 0, len(keyColumns))
+	for _, key := range keyColumns {
+		quotedKeys = append(quotedKeys, fmt.Sprintf("`%s`", key))
+	}
+
+	// FINAL collapses versions of ReplacingMergeTree tables that are yet to be merged
+	var final string
+	if engine == protos.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE {
+		final = " FINAL"
+	}
+	query := fmt.Sprintf("SELECT %s FROM `%s`.`%s`%s WHERE `%s` = 0",
+		strings.Join(names, ","), config.Database, tableIdentifier, final, signColName)
+
+	// LIFETIME(0) never reloads on its own, normalize reloads it once the table changed
+	return fmt.Sprintf("CREATE DICTIONARY IF NOT EXISTS `%s`%s (%s) PRIMARY KEY %s "+
+		"SOURCE(CLICKHOUSE(QUERY '%s' USER '%s' PASSWORD '%s')) LIFETIME(0) LAYOUT(%s())",
+		dictionaryName(tableIdentifier, dictionary), onCluster(config), strings.Join(attributes, ", "),
+		strings.Join(quotedKeys, ","), stringLiteralEscaper.Replace(query), stringLiteralEscaper.Replace(config.User),
+		stringLiteralEscaper.Replace(config.Password), layout), nil
+}
+
+func (c *ClickhouseConnector) setupDictionary(
+	ctx context.Context,
+	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
+) error {
+	tableMapping := utils.FindTableMapping(config.TableMappings, tableIdentifier)
+	if tableMapping == nil || tableMapping.Dictionary == nil {
+		return nil
+	}
+
+	// columns as created, after renames and type overrides of the table mapping
+	rows, err := c.database.Query(ctx,
+		"SELECT name, type FROM system.columns WHERE database = ? AND table = ? ORDER BY position",
+		c.config.Database, tableIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to get columns of %s: %w", tableIdentifier, err)
+	}
+	defer rows.Close()
+	var columns []dictionaryColumn
+	for rows.Next() {
+		var column dictionaryColumn
+		if err := rows.Scan(&column.name, &column.typeName); err != nil {
+			return fmt.Errorf("failed to scan column of %s: %w", tableIdentifier, err)
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get columns of %s: %w", tableIdentifier, err)
+	}
+
+	keyColumns := destinationKeyColumns(tableMapping, config.TableNameSchemaMapping[tableIdentifier])
+	stmt, err := createDictionarySQL(c.config, tableIdentifier, tableMapping.Dictionary, tableMapping.Engine, columns, keyColumns)
+	if err != nil {
+		return fmt.Errorf("failed to create dictionary for %s: %w", tableIdentifier, err)
+	}
+	c.logger.Info("[clickhouse] creating dictionary",
+		"table", tableIdentifier, "dictionary", dictionaryName(tableIdentifier, tableMapping.Dictionary))
+	if err := c.execWithTimeout(ctx, stmt, 0); err != nil {
+		return fmt.Errorf("failed to create dictionary for %s: %w", tableIdentifier, err)
+	}
+	return nil
+}
+
+// reloadDictionaries reloads dictionaries over tables changed by a normalize
+func (c *ClickhouseConnector) reloadDictionaries(
+	ctx context.Context,
+	tableMappings []*protos.TableMapping,
+	tables []string,
+) error {
+	for _, tbl := range tables {
+		tableMapping := utils.FindTableMapping(tableMappings, tbl)
+		if tableMapping == nil || tableMapping.Dictionary == nil {
+			continue
+		}
+		if err := c.execWithLogging(ctx,
+			fmt.Sprintf("SYSTEM RELOAD DICTIONARY `%s`%s", dictionaryName(tbl, tableMapping.Dictionary), onCluster(c.config))); err != nil {
+			return fmt.Errorf("failed to reload dictionary of %s: %w", tbl, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestCreateDictionarySQL(t *testing.T) {
+	columns := []dictionaryColumn{
+		{name: "id", typeName: "Int64"},
+		{name: "name", typeName: "Nullable(String)"},
+		{name: signColName, typeName: "UInt8"},
+		{name: versionColName, typeName: "Int64"},
+	}
+	config := &protos.ClickhouseConfig{Database: "db", User: "peerdb", Password: "it's"}
+	stmt, err := createDictionarySQL(config, "countries", &protos.ClickhouseDictionary{},
+		protos.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE, columns, []string{"id"})
+	require.NoError(t, err)
+	require.Equal(t, "CREATE DICTIONARY IF NOT EXISTS `countries_dict` (`id` Int64, `name` Nullable(String)) PRIMARY KEY `id` "+
+		"SOURCE(CLICKHOUSE(QUERY 'SELECT `id`,`name` FROM `db`.`countries` FINAL WHERE `_peerdb_is_deleted` = 0' "+
+		"USER 'peerdb' PASSWORD 'it\\'s')) LIFETIME(0) LAYOUT(COMPLEX_KEY_HASHED())", stmt)
+
+	stmt, err = createDictionarySQL(config, "countries",
+		&protos.ClickhouseDictionary{Name: "country_lookup", Layout: "HASHED"},
+		protos.TableEngine_CH_ENGINE_MERGE_TREE, columns, []string{"id"})
+	require.NoError(t, err)
+	require.Contains(t, stmt, "`country_lookup`")
+	require.Contains(t, stmt, "FROM `db`.`countries` WHERE")
+	require.Contains(t, stmt, "LAYOUT(HASHED())")
+
+	stmt, err = createDictionarySQL(&protos.ClickhouseConfig{Database: "db", Cluster: "main"}, "countries",
+		&protos.ClickhouseDictionary{}, protos.TableEngine_CH_ENGINE_MERGE_TREE, columns, []string{"id"})
+	require.NoError(t, err)
+	require.Contains(t, stmt, "CREATE DICTIONARY IF NOT EXISTS `countries_dict` ON CLUSTER `main` (")
+
+	_, err = createDictionarySQL(config, "countries", &protos.ClickhouseDictionary{},
+		protos.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE, columns, nil)
+	require.Error(t, err)
+	_, err = createDictionarySQL(config, "countries", &protos.ClickhouseDictionary{Layout: "HASHED()) --"},
+		protos.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE, columns, []string{"id"})
+	require.Error(t, err)
+}
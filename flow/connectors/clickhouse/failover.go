@@ -0,0 +1,203 @@
+package connclickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+)
+
+// error codes of ClickHouse refusing writes, like replicas of managed services during maintenance
+const (
+	clickhouseReadonly        = 164
+	clickhouseTableIsReadOnly = 242
+)
+
+func isReadOnlyError(err error) bool {
+	var exception *clickhouse.Exception
+	return errors.As(err, &exception) &&
+		(exception.Code == clickhouseReadonly || exception.Code == clickhouseTableIsReadOnly)
+}
+
+// clickhouseAddrs is host followed by failover hosts, which take the port of host unless they have one
+func clickhouseAddrs(config *protos.ClickhouseConfig) []string {
+	port := strconv.FormatUint(uint64(config.Port), 10)
+	addrs := make([]string, 0, len(config.FailoverHosts)+1)
+	addrs = append(addrs, net.JoinHostPort(config.Host, port))
+	for _, host := range config.FailoverHosts {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, port)
+		}
+		addrs = append(addrs, host)
+	}
+	return addrs
+}
+
+func isReadOnly(ctx context.Context, conn clickhouse.Conn) (bool, error) {
+	var readonly uint64
+	if err := conn.QueryRow(ctx, "SELECT toUInt64(getSetting('readonly'))").Scan(&readonly); err != nil {
+		return false, fmt.Errorf("failed to check whether ClickHouse host is read-only: %w", err)
+	}
+	return readonly > 0, nil
+}
+
+// openWritable connects to the first of addrs from start on accepting writes, or else the first reachable one
+// as reads keep working while every host is read-only, returning the index of the address connected to
+func openWritable(
+	ctx context.Context,
+	addrs []string,
+	start int,
+	open func(ctx context.Context, addr string) (clickhouse.Conn, error),
+) (clickhouse.Conn, int, error) {
+	logger := logger.LoggerFromCtx(ctx)
+	var readOnlyConn clickhouse.Conn
+	readOnlyIdx := -1
+	var errs []error
+	for i := range addrs {
+		idx := (start + i) % len(addrs)
+		addr := addrs[idx]
+		conn, err := open(ctx, addr)
+		if err != nil {
+			logger.Warn("[clickhouse] failed to connect to host, trying next one", slog.String("addr", addr), slog.Any("error", err))
+			errs = append(errs, err)
+			continue
+		}
+		readOnly, err := isReadOnly(ctx, conn)
+		if err != nil {
+			conn.Close()
+			errs = append(errs, err)
+			continue
+		}
+		if !readOnly {
+			if readOnlyConn != nil {
+				readOnlyConn.Close()
+			}
+			return conn, idx, nil
+		}
+		logger.Warn("[clickhouse] host is read-only, trying next one", slog.String("addr", addr))
+		if readOnlyConn == nil {
+			readOnlyConn = conn
+			readOnlyIdx = idx
+		} else {
+			conn.Close()
+		}
+	}
+	if readOnlyConn != nil {
+		logger.Warn("[clickhouse] every host is read-only, connecting for reads")
+		return readOnlyConn, readOnlyIdx, nil
+	}
+	return nil, -1, errors.Join(errs...)
+}
+
+// failoverConn moves to the next writable host when writes are refused because the host turned read-only,
+// retrying the write there once
+type failoverConn struct {
+	conn      clickhouse.Conn
+	reconnect func(ctx context.Context, start int) (clickhouse.Conn, int, error)
+	// connections failed over from, closed along with conn as rows may still be read from them
+	retired []clickhouse.Conn
+	// index of the address conn is connected to, failing over starts from the one after
+	addr int
+	mu   sync.RWMutex
+}
+
+func (c *failoverConn) current() clickhouse.Conn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn
+}
+
+// failover replaces failed, unless a concurrent write already did
+func (c *failoverConn) failover(ctx context.Context, failed clickhouse.Conn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != failed {
+		return nil
+	}
+	conn, addr, err := c.reconnect(ctx, c.addr+1)
+	if err != nil {
+		return fmt.Errorf("failed to fail over to writable ClickHouse host: %w", err)
+	}
+	c.addr = addr
+	logger.LoggerFromCtx(ctx).Warn("[clickhouse] host turned read-only, failed over to next writable host")
+	c.retired = append(c.retired, c.conn)
+	c.conn = conn
+	return nil
+}
+
+func (c *failoverConn) retryWrite(ctx context.Context, write func(conn clickhouse.Conn) error) error {
+	conn := c.current()
+	err := write(conn)
+	if !isReadOnlyError(err) {
+		return err
+	}
+	if failoverErr := c.failover(ctx, conn); failoverErr != nil {
+		return errors.Join(err, failoverErr)
+	}
+	return write(c.current())
+}
+
+func (c *failoverConn) Exec(ctx context.Context, query string, args ...any) error {
+	return c.retryWrite(ctx, func(conn clickhouse.Conn) error {
+		return conn.Exec(ctx, query, args...)
+	})
+}
+
+func (c *failoverConn) AsyncInsert(ctx context.Context, query string, wait bool, args ...any) error {
+	return c.retryWrite(ctx, func(conn clickhouse.Conn) error {
+		return conn.AsyncInsert(ctx, query, wait, args...)
+	})
+}
+
+func (c *failoverConn) Contributors() []string {
+	return c.current().Contributors()
+}
+
+func (c *failoverConn) ServerVersion() (*driver.ServerVersion, error) {
+	return c.current().ServerVersion()
+}
+
+func (c *failoverConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	return c.current().Select(ctx, dest, query, args...)
+}
+
+func (c *failoverConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	return c.current().Query(ctx, query, args...)
+}
+
+func (c *failoverConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	return c.current().QueryRow(ctx, query, args...)
+}
+
+func (c *failoverConn) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	return c.current().PrepareBatch(ctx, query, opts...)
+}
+
+func (c *failoverConn) Ping(ctx context.Context) error {
+	return c.current().Ping(ctx)
+}
+
+func (c *failoverConn) Stats() driver.Stats {
+	return c.current().Stats()
+}
+
+func (c *failoverConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	errs := make([]error, 0, len(c.retired)+1)
+	for _, conn := range c.retired {
+		errs = append(errs, conn.Close())
+	}
+	c.retired = nil
+	errs = append(errs, c.conn.Close())
+	return errors.Join(errs...)
+}
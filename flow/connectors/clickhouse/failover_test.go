@@ -0,0 +1,28 @@
+package connclickhouse
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestClickhouseAddrs(t *testing.T) {
+	config := &protos.ClickhouseConfig{Host: "primary", Port: 9440}
+	require.Equal(t, []string{"primary:9440"}, clickhouseAddrs(config))
+
+	config.FailoverHosts = []string{"replica-1", "replica-2:9000", "::1"}
+	require.Equal(t, []string{"primary:9440", "replica-1:9440", "replica-2:9000", "[::1]:9440"}, clickhouseAddrs(config))
+}
+
+func TestIsReadOnlyError(t *testing.T) {
+	require.True(t, isReadOnlyError(fmt.Errorf("insert failed: %w", &clickhouse.Exception{Code: clickhouseReadonly})))
+	require.True(t, isReadOnlyError(&clickhouse.Exception{Code: clickhouseTableIsReadOnly}))
+	require.False(t, isReadOnlyError(&clickhouse.Exception{Code: 60}))
+	require.False(t, isReadOnlyError(errors.New("connection reset")))
+	require.False(t, isReadOnlyError(nil))
+}
@@ -0,0 +1,173 @@
+package connclickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// clickhouseUnknownDatabase is the error code of ClickHouse for queries against a missing database
+const clickhouseUnknownDatabase = 81
+
+type clickhouseGrant struct {
+	database      *string
+	table         *string
+	column        *string
+	accessType    string
+	partialRevoke bool
+}
+
+type requiredGrant struct {
+	accessType string
+	// granted on *.* instead of the database of the peer
+	global bool
+}
+
+// requiredGrants are what mirrors run on the database of the peer, plus the s3 table function staged inserts use
+var requiredGrants = []requiredGrant{
+	{accessType: "SELECT"},
+	{accessType: "INSERT"},
+	{accessType: "CREATE TABLE"},
+	{accessType: "ALTER TABLE"},
+	{accessType: "DROP TABLE"},
+	{accessType: "OPTIMIZE"},
+	{accessType: "S3", global: true},
+}
+
+// grantParents maps access types to the ones implying them, under ALL implying everything
+var grantParents = map[string]string{
+	"CREATE TABLE": "CREATE",
+	"ALTER TABLE":  "ALTER",
+	"DROP TABLE":   "DROP",
+	"S3":           "SOURCES",
+}
+
+func grantImplies(accessType string, required string) bool {
+	for current := required; current != ""; current = grantParents[current] {
+		if accessType == current {
+			return true
+		}
+	}
+	return accessType == "ALL"
+}
+
+// missingGrants returns the required grants not held on database, grants on single tables or columns don't count
+func missingGrants(grants []clickhouseGrant, database string) []string {
+	var missing []string
+	for _, required := range requiredGrants {
+		granted, revoked := false, false
+		for _, grant := range grants {
+			if grant.table != nil || grant.column != nil || !grantImplies(grant.accessType, required.accessType) {
+				continue
+			}
+			if grant.database != nil && (required.global || *grant.database != database) {
+				continue
+			}
+			if grant.partialRevoke {
+				revoked = true
+			} else {
+				granted = true
+			}
+		}
+		if !granted || revoked {
+			on := "`" + database + "`.*"
+			if required.global {
+				on = "*.*"
+			}
+			missing = append(missing, fmt.Sprintf("%s ON %s", required.accessType, on))
+		}
+	}
+	return missing
+}
+
+// checkGrants validates the user holds every grant mirrors need, directly or through its enabled roles
+func (c *ClickhouseConnector) checkGrants(ctx context.Context) error {
+	rows, err := c.database.Query(ctx, `SELECT toString(access_type), database, table, column, is_partial_revoke
+		FROM system.grants
+		WHERE user_name = currentUser() OR role_name IN (SELECT role_name FROM system.enabled_roles)`)
+	if err != nil {
+		// reading system.grants may need SHOW USERS, the validation table still exercises the grants
+		c.logger.Warn("[clickhouse] failed to query grants, skipping grants check", slog.Any("error", err))
+		return nil
+	}
+	defer rows.Close()
+
+	var grants []clickhouseGrant
+	for rows.Next() {
+		var grant clickhouseGrant
+		var partialRevoke uint8
+		if err := rows.Scan(&grant.accessType, &grant.database, &grant.table, &grant.column, &partialRevoke); err != nil {
+			return fmt.Errorf("failed to scan grant: %w", err)
+		}
+		grant.partialRevoke = partialRevoke != 0
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query grants: %w", err)
+	}
+
+	missing := missingGrants(grants, c.config.Database)
+	if c.config.RawDatabase != "" && c.config.RawDatabase != c.config.Database {
+		for _, grant := range missingGrants(grants, c.config.RawDatabase) {
+			if !slices.Contains(missing, grant) {
+				missing = append(missing, grant)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("user %s is missing grants, run GRANT %s TO %s",
+			c.config.User, strings.Join(missing, ", "), c.config.User)
+	}
+	return nil
+}
+
+// createRawDatabase creates the raw database when one is configured,
+// least privilege mode expects it to exist like the database of the peer
+func (c *ClickhouseConnector) createRawDatabase(ctx context.Context) error {
+	if c.config.RawDatabase == "" || c.config.LeastPrivilege {
+		return nil
+	}
+	if err := c.execWithLogging(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`%s", c.config.RawDatabase, onCluster(c.config))); err != nil {
+		return fmt.Errorf("failed to create raw database %s: %w", c.config.RawDatabase, err)
+	}
+	return nil
+}
+
+// connectCreatingDatabase connects to the database of config, creating it first when missing
+// unless least privilege mode expects it to exist
+func connectCreatingDatabase(ctx context.Context, config *protos.ClickhouseConfig) (clickhouse.Conn, error) {
+	conn, err := Connect(ctx, config)
+	var exception *clickhouse.Exception
+	if err == nil || !errors.As(err, &exception) || exception.Code != clickhouseUnknownDatabase {
+		return conn, err
+	}
+	if config.LeastPrivilege {
+		return nil, fmt.Errorf("database %s does not exist, least privilege mode needs it created beforehand: %w",
+			config.Database, err)
+	}
+
+	withoutDatabase := proto.Clone(config).(*protos.ClickhouseConfig)
+	withoutDatabase.Database = ""
+	serverConn, err := Connect(ctx, withoutDatabase)
+	if err != nil {
+		return nil, err
+	}
+	defer serverConn.Close()
+	if cloud, err := isCloud(ctx, serverConn); err != nil {
+		return nil, err
+	} else if cloud {
+		config = cloudConfig(config)
+	}
+	if err := serverConn.Exec(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`%s", config.Database, onCluster(config))); err != nil {
+		return nil, fmt.Errorf("failed to create database %s: %w", config.Database, err)
+	}
+	return Connect(ctx, config)
+}
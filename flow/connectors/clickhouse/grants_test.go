@@ -0,0 +1,36 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingGrants(t *testing.T) {
+	db := "analytics"
+	other := "other"
+	table := "t"
+
+	require.Empty(t, missingGrants([]clickhouseGrant{{accessType: "ALL"}}, db))
+
+	grants := []clickhouseGrant{
+		{accessType: "SELECT", database: &db},
+		{accessType: "INSERT", database: &db},
+		{accessType: "CREATE", database: &db},
+		{accessType: "ALTER TABLE", database: &db},
+		{accessType: "DROP TABLE", database: &other},
+		{accessType: "OPTIMIZE", database: &db, table: &table},
+		{accessType: "SOURCES"},
+	}
+	require.Equal(t, []string{"DROP TABLE ON `analytics`.*", "OPTIMIZE ON `analytics`.*"}, missingGrants(grants, db))
+
+	grants = append(grants,
+		clickhouseGrant{accessType: "DROP", database: &db},
+		clickhouseGrant{accessType: "OPTIMIZE"},
+		clickhouseGrant{accessType: "INSERT", database: &db, partialRevoke: true},
+	)
+	require.Equal(t, []string{"INSERT ON `analytics`.*"}, missingGrants(grants, db))
+
+	// the s3 table function is only granted globally
+	require.Equal(t, []string{"S3 ON *.*"}, missingGrants([]clickhouseGrant{{accessType: "ALL", database: &db}}, db))
+}
@@ -0,0 +1,209 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const (
+	syncStateTableName = "_peerdb_sync_state"
+
+	syncStateLastOffset       = "last_offset"
+	syncStateSyncBatchID      = "sync_batch_id"
+	syncStateNormalizeBatchID = "normalize_batch_id"
+)
+
+var _ metadataStore.MetadataStore = &ClickhouseMetadata{}
+
+// ClickhouseMetadata keeps the sync state of mirrors in a table of the raw database, one row per value.
+// ReplacingMergeTree versioned by the value keeps the greatest value, so values never go back
+// and sync and normalize each write their own rows without reading each other's.
+// Mirrors created while state was kept in the catalog read values missing from the table from the catalog.
+type ClickhouseMetadata struct {
+	database clickhouse.Conn
+	config   *protos.ClickhouseConfig
+	logger   log.Logger
+	catalog  *metadataStore.PostgresMetadata
+	isSetup  bool
+}
+
+func NewClickhouseMetadata(
+	logger log.Logger,
+	database clickhouse.Conn,
+	config *protos.ClickhouseConfig,
+) *ClickhouseMetadata {
+	return &ClickhouseMetadata{
+		database: database,
+		config:   config,
+		logger:   logger,
+	}
+}
+
+func (m *ClickhouseMetadata) table() string {
+	database := m.config.RawDatabase
+	if database == "" {
+		database = m.config.Database
+	}
+	return fmt.Sprintf("`%s`.`%s`", database, syncStateTableName)
+}
+
+func (m *ClickhouseMetadata) tableExists(ctx context.Context) (bool, error) {
+	database := m.config.RawDatabase
+	if database == "" {
+		database = m.config.Database
+	}
+	var exists uint8
+	if err := m.database.QueryRow(ctx,
+		"SELECT exists(SELECT 1 FROM system.tables WHERE database = ? AND name = ?)",
+		database, syncStateTableName,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check if sync state table exists: %w", err)
+	}
+	return exists == 1, nil
+}
+
+func (m *ClickhouseMetadata) NeedsSetupMetadataTables(ctx context.Context) bool {
+	exists, err := m.tableExists(ctx)
+	if err != nil {
+		m.logger.Warn("failed to check for sync state table", "error", err)
+	}
+	return !exists
+}
+
+func (m *ClickhouseMetadata) SetupMetadataTables(ctx context.Context) error {
+	if m.config.RawDatabase != "" && !m.config.LeastPrivilege {
+		if err := m.database.Exec(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`%s",
+			m.config.RawDatabase, onCluster(m.config))); err != nil {
+			return fmt.Errorf("failed to create raw database %s: %w", m.config.RawDatabase, err)
+		}
+	}
+	if err := m.database.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s%s (
+		job_name String,
+		key LowCardinality(String),
+		value Int64,
+		updated_at DateTime64(9) DEFAULT now64()
+	) ENGINE = %s ORDER BY (job_name, key)`,
+		m.table(), onCluster(m.config), allNodesEngine(m.config, "ReplacingMergeTree", "value"))); err != nil {
+		return fmt.Errorf("failed to create sync state table: %w", err)
+	}
+	m.isSetup = true
+	return nil
+}
+
+// catalogMetadata connects to the catalog lazily, only for values missing from the table
+func (m *ClickhouseMetadata) catalogMetadata(ctx context.Context) (*metadataStore.PostgresMetadata, error) {
+	if m.catalog == nil {
+		catalog, err := metadataStore.NewPostgresMetadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.catalog = catalog
+	}
+	return m.catalog, nil
+}
+
+// get returns a value of the sync state, from the catalog for mirrors which never wrote it to the table
+func (m *ClickhouseMetadata) get(
+	ctx context.Context,
+	jobName string,
+	key string,
+	fromCatalog func(*metadataStore.PostgresMetadata, context.Context, string) (int64, error),
+) (int64, error) {
+	exists, err := m.tableExists(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		var value int64
+		var count uint64
+		if err := m.database.QueryRow(ctx,
+			fmt.Sprintf("SELECT max(value), count() FROM %s WHERE job_name = ? AND key = ?", m.table()),
+			jobName, key,
+		).Scan(&value, &count); err != nil {
+			return 0, fmt.Errorf("failed to get %s of %s: %w", key, jobName, err)
+		}
+		if count > 0 {
+			return value, nil
+		}
+	}
+
+	catalog, err := m.catalogMetadata(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s of %s from catalog: %w", key, jobName, err)
+	}
+	return fromCatalog(catalog, ctx, jobName)
+}
+
+func (m *ClickhouseMetadata) set(ctx context.Context, jobName string, values map[string]int64) error {
+	if !m.isSetup {
+		if err := m.SetupMetadataTables(ctx); err != nil {
+			return err
+		}
+	}
+	batch, err := m.database.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s (job_name, key, value)", m.table()))
+	if err != nil {
+		return fmt.Errorf("failed to prepare sync state insert: %w", err)
+	}
+	for key, value := range values {
+		if err := batch.Append(jobName, key, value); err != nil {
+			return fmt.Errorf("failed to append %s of %s: %w", key, jobName, err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to update sync state of %s: %w", jobName, err)
+	}
+	return nil
+}
+
+func (m *ClickhouseMetadata) GetLastOffset(ctx context.Context, jobName string) (int64, error) {
+	return m.get(ctx, jobName, syncStateLastOffset, (*metadataStore.PostgresMetadata).GetLastOffset)
+}
+
+func (m *ClickhouseMetadata) GetLastSyncBatchID(ctx context.Context, jobName string) (int64, error) {
+	return m.get(ctx, jobName, syncStateSyncBatchID, (*metadataStore.PostgresMetadata).GetLastSyncBatchID)
+}
+
+func (m *ClickhouseMetadata) GetLastNormalizeBatchID(ctx context.Context, jobName string) (int64, error) {
+	return m.get(ctx, jobName, syncStateNormalizeBatchID, (*metadataStore.PostgresMetadata).GetLastNormalizeBatchID)
+}
+
+func (m *ClickhouseMetadata) SetLastOffset(ctx context.Context, jobName string, offset int64) error {
+	m.logger.Info("updating last offset", "offset", offset)
+	return m.set(ctx, jobName, map[string]int64{syncStateLastOffset: offset})
+}
+
+func (m *ClickhouseMetadata) FinishBatch(ctx context.Context, jobName string, syncBatchID int64, offset int64) error {
+	m.logger.Info("finishing batch", "SyncBatchID", syncBatchID, "offset", offset)
+	return m.set(ctx, jobName, map[string]int64{syncStateLastOffset: offset, syncStateSyncBatchID: syncBatchID})
+}
+
+func (m *ClickhouseMetadata) UpdateNormalizeBatchID(ctx context.Context, jobName string, batchID int64) error {
+	m.logger.Info("updating normalize batch id for job")
+	return m.set(ctx, jobName, map[string]int64{syncStateNormalizeBatchID: batchID})
+}
+
+// SyncFlowCleanup removes the sync state of a mirror, from the catalog too so a mirror created again
+// with the same name doesn't pick up the state it had there
+func (m *ClickhouseMetadata) SyncFlowCleanup(ctx context.Context, jobName string) error {
+	exists, err := m.tableExists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := m.database.Exec(ctx,
+			fmt.Sprintf("DELETE FROM %s WHERE job_name = ?", m.table()), jobName); err != nil {
+			return fmt.Errorf("failed to delete sync state of %s: %w", jobName, err)
+		}
+	}
+	catalog, err := m.catalogMetadata(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete sync state of %s from catalog: %w", jobName, err)
+	}
+	return catalog.SyncFlowCleanup(ctx, jobName)
+}
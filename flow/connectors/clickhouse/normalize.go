@@ -6,15 +6,21 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
 	"github.com/PeerDB-io/peer-flow/datatypes"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+	"github.com/PeerDB-io/peer-flow/shared/metrics"
 )
 
 const (
@@ -24,7 +30,10 @@ const (
 	versionColType = "Int64"
 )
 
-var acceptableTableEngines = []string{"ReplacingMergeTree", "MergeTree", "SharedReplacingMergeTree"}
+var acceptableTableEngines = []string{
+	"ReplacingMergeTree", "MergeTree", "SharedReplacingMergeTree", "SharedMergeTree",
+	"ReplicatedReplacingMergeTree", "ReplicatedMergeTree",
+}
 
 func (c *ClickhouseConnector) StartSetupNormalizedTables(_ context.Context) (interface{}, error) {
 	return nil, nil
@@ -37,6 +46,20 @@ func (c *ClickhouseConnector) FinishSetupNormalizedTables(_ context.Context, _ i
 func (c *ClickhouseConnector) CleanupSetupNormalizedTables(_ context.Context, _ interface{}) {
 }
 
+func (c *ClickhouseConnector) MissingNormalizedTables(ctx context.Context, tables []string) ([]string, error) {
+	var missing []string
+	for _, table := range tables {
+		exists, err := c.checkIfTableExists(ctx, c.config.Database, table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, table)
+		}
+	}
+	return missing, nil
+}
+
 func (c *ClickhouseConnector) SetupNormalizedTable(
 	ctx context.Context,
 	tx interface{},
@@ -49,12 +72,17 @@ func (c *ClickhouseConnector) SetupNormalizedTable(
 	}
 	if tableAlreadyExists && !config.IsResync {
 		c.logger.Info("[ch] normalized table already exists, skipping", "table", tableIdentifier)
+		// dictionary may have been added to the table mapping since
+		if err := c.setupDictionary(ctx, config, tableIdentifier); err != nil {
+			return false, err
+		}
 		return true, nil
 	}
 
 	normalizedTableCreateSQL, err := generateCreateTableSQLForNormalizedTable(
 		config,
 		tableIdentifier,
+		c.config,
 	)
 	if err != nil {
 		return false, fmt.Errorf("error while generating create table sql for normalized table: %w", err)
@@ -63,6 +91,20 @@ func (c *ClickhouseConnector) SetupNormalizedTable(
 	if err := c.execWithLogging(ctx, normalizedTableCreateSQL); err != nil {
 		return false, fmt.Errorf("[ch] error while creating normalized table: %w", err)
 	}
+	if isDistributed(c.config) {
+		keyColumns := destinationKeyColumns(utils.FindTableMapping(config.TableMappings, tableIdentifier),
+			config.TableNameSchemaMapping[tableIdentifier])
+		if err := c.execWithLogging(ctx,
+			createDistributedTableSQL(c.config, tableIdentifier, keyColumns, config.IsResync)); err != nil {
+			return false, fmt.Errorf("[ch] error while creating distributed table: %w", err)
+		}
+	}
+	// resync tables get renamed over the original table, whose dictionary then reads from them
+	if !config.IsResync {
+		if err := c.setupDictionary(ctx, config, tableIdentifier); err != nil {
+			return false, err
+		}
+	}
 	return false, nil
 }
 
@@ -73,9 +115,74 @@ func getColName(overrides map[string]string, name string) string {
 	return name
 }
 
+// destinationKeyColumns returns the primary key columns of a destination table, after renames of its table mapping
+func destinationKeyColumns(tableMapping *protos.TableMapping, tableSchema *protos.TableSchema) []string {
+	if tableSchema == nil {
+		return nil
+	}
+	colNameMap := make(map[string]string)
+	if tableMapping != nil {
+		for _, col := range tableMapping.Columns {
+			if col.DestinationName != "" {
+				colNameMap[col.SourceName] = col.DestinationName
+			}
+		}
+	}
+	keyColumns := make([]string, 0, len(tableSchema.PrimaryKeyColumns))
+	for _, pkey := range tableSchema.PrimaryKeyColumns {
+		keyColumns = append(keyColumns, getColName(colNameMap, pkey))
+	}
+	return keyColumns
+}
+
+// columnSettingFor returns the settings of a source column in a table mapping, nil when it has none
+func columnSettingFor(tableMapping *protos.TableMapping, colName string) *protos.ColumnSetting {
+	if tableMapping == nil {
+		return nil
+	}
+	for _, col := range tableMapping.Columns {
+		if col.SourceName == colName {
+			return col
+		}
+	}
+	return nil
+}
+
+// clickhouseColumnType maps a source column to the type of its destination column,
+// shared by table creation and columns added by schema changes so both end up with the same type
+func clickhouseColumnType(
+	column *protos.FieldDescription,
+	columnSetting *protos.ColumnSetting,
+	nullableEnabled bool,
+	sortingKey bool,
+) (string, error) {
+	colType := qvalue.QValueKind(column.Type)
+	var clickhouseType string
+	if columnSetting != nil && columnSetting.DestinationType != "" {
+		// TODO can we restrict this to avoid injection?
+		clickhouseType = columnSetting.DestinationType
+	} else {
+		var err error
+		clickhouseType, err = colType.ToDWHColumnType(protos.DBType_CLICKHOUSE)
+		if err != nil {
+			return "", fmt.Errorf("error while converting column type to clickhouse type: %w", err)
+		}
+	}
+
+	if colType == qvalue.QValueKindNumeric {
+		precision, scale := datatypes.GetNumericTypeForWarehouse(column.TypeModifier, datatypes.ClickHouseNumericCompatibility{})
+		clickhouseType = fmt.Sprintf("DECIMAL(%d, %d)", precision, scale)
+	}
+	if column.Nullable && (nullableEnabled || colType == qvalue.QValueKindNumeric) && !colType.IsArray() && !sortingKey {
+		clickhouseType = fmt.Sprintf("Nullable(%s)", clickhouseType)
+	}
+	return clickhouseType, nil
+}
+
 func generateCreateTableSQLForNormalizedTable(
 	config *protos.SetupNormalizedTableBatchInput,
 	tableIdentifier string,
+	chConfig *protos.ClickhouseConfig,
 ) (string, error) {
 	tableSchema := config.TableNameSchemaMapping[tableIdentifier]
 
@@ -97,53 +204,54 @@ func generateCreateTableSQLForNormalizedTable(
 		stmtBuilder.WriteString("IF NOT EXISTS ")
 	}
 	stmtBuilder.WriteString("`")
-	stmtBuilder.WriteString(tableIdentifier)
-	stmtBuilder.WriteString("` (")
+	stmtBuilder.WriteString(localTable(chConfig, tableIdentifier))
+	stmtBuilder.WriteString("`")
+	stmtBuilder.WriteString(onCluster(chConfig))
+	stmtBuilder.WriteString(" (")
+
+	// columns of sorting key cannot be Nullable
+	sortingKeyColumns := slices.Clone(tableSchema.PrimaryKeyColumns)
+	if tableMapping != nil {
+		for _, col := range tableMapping.Columns {
+			if col.Ordering > 0 {
+				sortingKeyColumns = append(sortingKeyColumns, col.SourceName)
+			}
+		}
+	}
 
 	colNameMap := make(map[string]string)
+	var partitionBy string
 	for _, column := range tableSchema.Columns {
 		colName := column.Name
 		dstColName := colName
 		colType := qvalue.QValueKind(column.Type)
-		var clickhouseType string
-		var columnSetting *protos.ColumnSetting
-		if tableMapping != nil {
-			for _, col := range tableMapping.Columns {
-				if col.SourceName == colName {
-					columnSetting = col
-					if columnSetting.DestinationName != "" {
-						dstColName = columnSetting.DestinationName
-						colNameMap[colName] = dstColName
-					}
-					if columnSetting.DestinationType != "" {
-						// TODO can we restrict this to avoid injection?
-						clickhouseType = columnSetting.DestinationType
-					}
-					break
-				}
-			}
+		columnSetting := columnSettingFor(tableMapping, colName)
+		if columnSetting != nil && columnSetting.DestinationName != "" {
+			dstColName = columnSetting.DestinationName
+			colNameMap[colName] = dstColName
 		}
 
-		if clickhouseType == "" {
-			var err error
-			clickhouseType, err = colType.ToDWHColumnType(protos.DBType_CLICKHOUSE)
-			if err != nil {
-				return "", fmt.Errorf("error while converting column type to clickhouse type: %w", err)
-			}
+		clickhouseType, err := clickhouseColumnType(column, columnSetting, tableSchema.NullableEnabled,
+			slices.Contains(sortingKeyColumns, colName))
+		if err != nil {
+			return "", err
 		}
-
-		if colType == qvalue.QValueKindNumeric {
-			precision, scale := datatypes.GetNumericTypeForWarehouse(column.TypeModifier, datatypes.ClickHouseNumericCompatibility{})
-			if column.Nullable {
-				stmtBuilder.WriteString(fmt.Sprintf("`%s` Nullable(DECIMAL(%d, %d)), ", dstColName, precision, scale))
-			} else {
-				stmtBuilder.WriteString(fmt.Sprintf("`%s` DECIMAL(%d, %d), ", dstColName, precision, scale))
+		stmtBuilder.WriteString(fmt.Sprintf("`%s` %s", dstColName, clickhouseType))
+		if colName == tableSchema.RangePartitionColumn {
+			partitionBy = partitionByMonth(dstColName, strings.HasPrefix(clickhouseType, "Nullable("))
+		}
+		if column.DefaultExpression != "" && !colType.IsArray() {
+			if defaultExpression, ok := translateDefaultExpression(column.DefaultExpression, colType); ok {
+				stmtBuilder.WriteString(" DEFAULT ")
+				stmtBuilder.WriteString(defaultExpression)
 			}
-		} else if tableSchema.NullableEnabled && column.Nullable && !colType.IsArray() {
-			stmtBuilder.WriteString(fmt.Sprintf("`%s` Nullable(%s), ", dstColName, clickhouseType))
-		} else {
-			stmtBuilder.WriteString(fmt.Sprintf("`%s` %s, ", dstColName, clickhouseType))
 		}
+		if codec := columnCodec(column, columnSetting); codec != "" {
+			stmtBuilder.WriteString(" CODEC(")
+			stmtBuilder.WriteString(codec)
+			stmtBuilder.WriteString(")")
+		}
+		stmtBuilder.WriteString(", ")
 	}
 	// TODO support soft delete
 	// synced at column will be added to all normalized tables
@@ -158,11 +266,17 @@ func generateCreateTableSQLForNormalizedTable(
 	} else {
 		engine = fmt.Sprintf("ReplacingMergeTree(`%s`)", versionColName)
 	}
+	engine = destinationEngine(chConfig, engine)
 
 	// add sign and version columns
 	stmtBuilder.WriteString(fmt.Sprintf(
 		"`%s` %s, `%s` %s) ENGINE = %s",
 		signColName, signColType, versionColName, versionColType, engine))
+	if partitionBy != "" {
+		stmtBuilder.WriteString(" PARTITION BY ")
+		stmtBuilder.WriteString(partitionBy)
+		stmtBuilder.WriteRune(' ')
+	}
 
 	var pkeyStr string
 	pkeys := tableSchema.PrimaryKeyColumns
@@ -207,15 +321,32 @@ func generateCreateTableSQLForNormalizedTable(
 		stmtBuilder.WriteRune(')')
 	}
 
+	if len(config.Labels) > 0 {
+		stmtBuilder.WriteString(" COMMENT '")
+		stmtBuilder.WriteString(stringLiteralEscaper.Replace(shared.MirrorQueryTag(config.FlowName, config.Labels)))
+		stmtBuilder.WriteRune('\'')
+	}
+
 	return stmtBuilder.String(), nil
 }
 
+// partitionByMonth partitions like the monthly range partitions of the source table,
+// so partitions detached on the source can be dropped whole
+func partitionByMonth(column string, nullable bool) string {
+	if nullable {
+		// keys of Nullable columns need allow_nullable_key, rows with NULL can only be in a default partition on the source
+		return fmt.Sprintf("toYYYYMM(assumeNotNull(`%s`))", column)
+	}
+	return fmt.Sprintf("toYYYYMM(`%s`)", column)
+}
+
 func (c *ClickhouseConnector) NormalizeRecords(
 	ctx context.Context,
 	req *model.NormalizeRecordsRequest,
 ) (*model.NormalizeResponse, error) {
 	// fix for potential consistency issues
 	time.Sleep(3 * time.Second)
+	startTime := time.Now()
 
 	normBatchID, err := c.GetLastNormalizeBatchID(ctx, req.FlowJobName)
 	if err != nil {
@@ -247,11 +378,53 @@ func (c *ClickhouseConnector) NormalizeRecords(
 		c.logger.Error("[clickhouse] error while getting distinct table names in batch", "error", err)
 		return nil, err
 	}
+	destinationTableNames = utils.WithoutSkippedTables(destinationTableNames, req.SkippedTables)
+	// changes buffered while quarantined or of replayed batches are inserted along with the current batches
+	replayTables := utils.ReplayTablesToNormalize(req.ReplayTables, req.TruncatedTables, normBatchID)
+	destinationTableNames = utils.WithReplayedTables(destinationTableNames, replayTables)
 
 	rawTbl := c.getRawTableName(req.FlowJobName)
 
+	lightweightDelete := false
+	if req.SoftDeleteColName == "" {
+		lightweightDelete, err = peerdbenv.PeerDBClickhouseLightweightDelete(ctx, req.Env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	normalizeTimeout, err := peerdbenv.PeerDBClickhouseNormalizeTimeout(ctx, req.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	truncatedTables := utils.TruncatedTablesToNormalize(req.TruncatedTables, normBatchID, req.SyncBatchID)
+	for _, tbl := range slices.Sorted(maps.Keys(truncatedTables)) {
+		switch req.TruncatePolicy {
+		case protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE:
+			if err := c.execWithLogging(ctx,
+				"TRUNCATE TABLE IF EXISTS "+localTable(c.config, tbl)+onCluster(c.config)); err != nil {
+				return nil, fmt.Errorf("error while applying truncate to %s: %w", tbl, err)
+			}
+		case protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE:
+			if err := c.execWithLoggingAndTimeout(ctx, softDeleteTruncateQuery(c.config, tbl), normalizeTimeout); err != nil {
+				return nil, fmt.Errorf("error while applying truncate to %s as soft delete: %w", tbl, err)
+			}
+		default:
+			delete(truncatedTables, tbl)
+		}
+	}
+
 	// model the raw table data as inserts.
 	for _, tbl := range destinationTableNames {
+		startBatchID := normBatchID
+		if replayBatchID, ok := replayTables[tbl]; ok {
+			startBatchID = replayBatchID
+		}
+		if truncateBatchID, ok := truncatedTables[tbl]; ok {
+			startBatchID = max(normBatchID, truncateBatchID-1)
+		}
+
 		// SELECT projection FROM raw_table WHERE _peerdb_batch_id > normalize_batch_id AND _peerdb_batch_id <= sync_batch_id
 		selectQuery := strings.Builder{}
 		selectQuery.WriteString("SELECT ")
@@ -270,6 +443,8 @@ func (c *ClickhouseConnector) NormalizeRecords(
 		}
 
 		projection := strings.Builder{}
+		keyColumns := make([]string, 0, len(schema.PrimaryKeyColumns))
+		keyProjection := make([]string, 0, len(schema.PrimaryKeyColumns))
 
 		for _, column := range schema.Columns {
 			colName := column.Name
@@ -301,21 +476,28 @@ func (c *ClickhouseConnector) NormalizeRecords(
 				}
 			}
 
+			var columnProjection string
 			switch clickhouseType {
 			case "Date":
-				projection.WriteString(fmt.Sprintf(
-					"toDate(parseDateTime64BestEffortOrNull(JSONExtractString(_peerdb_data, '%s'))) AS `%s`,",
+				columnProjection = fmt.Sprintf(
+					"toDate(parseDateTime64BestEffortOrNull(JSONExtractString(_peerdb_data, '%s'))) AS `%s`",
 					colName,
 					dstColName,
-				))
+				)
 			case "DateTime64(6)":
-				projection.WriteString(fmt.Sprintf(
-					"parseDateTime64BestEffortOrNull(JSONExtractString(_peerdb_data, '%s')) AS `%s`,",
+				columnProjection = fmt.Sprintf(
+					"parseDateTime64BestEffortOrNull(JSONExtractString(_peerdb_data, '%s')) AS `%s`",
 					colName,
 					dstColName,
-				))
+				)
 			default:
-				projection.WriteString(fmt.Sprintf("JSONExtract(_peerdb_data, '%s', '%s') AS `%s`,", colName, clickhouseType, dstColName))
+				columnProjection = fmt.Sprintf("JSONExtract(_peerdb_data, '%s', '%s') AS `%s`", colName, clickhouseType, dstColName)
+			}
+			projection.WriteString(columnProjection)
+			projection.WriteString(",")
+			if slices.Contains(schema.PrimaryKeyColumns, colName) {
+				keyColumns = append(keyColumns, fmt.Sprintf("`%s`", dstColName))
+				keyProjection = append(keyProjection, columnProjection)
 			}
 		}
 
@@ -331,34 +513,61 @@ func (c *ClickhouseConnector) NormalizeRecords(
 		selectQuery.WriteString(projection.String())
 		selectQuery.WriteString(" FROM ")
 		selectQuery.WriteString(rawTbl)
-		selectQuery.WriteString(" WHERE _peerdb_batch_id > ")
-		selectQuery.WriteString(strconv.FormatInt(normBatchID, 10))
-		selectQuery.WriteString(" AND _peerdb_batch_id <= ")
-		selectQuery.WriteString(strconv.FormatInt(req.SyncBatchID, 10))
-		selectQuery.WriteString(" AND _peerdb_destination_table_name = '")
-		selectQuery.WriteString(tbl)
-		selectQuery.WriteString("'")
-
-		selectQuery.WriteString(" ORDER BY _peerdb_timestamp")
-
-		insertIntoSelectQuery := strings.Builder{}
-		insertIntoSelectQuery.WriteString("INSERT INTO ")
-		insertIntoSelectQuery.WriteString(tbl)
-		insertIntoSelectQuery.WriteString(colSelector.String())
-		insertIntoSelectQuery.WriteString(selectQuery.String())
-
-		q := insertIntoSelectQuery.String()
-
-		if err := c.execWithLogging(ctx, q); err != nil {
-			return nil, fmt.Errorf("error while inserting into normalized table: %w", err)
+
+		for _, batchRange := range utils.NormalizeBatchRanges(startBatchID, req.SyncBatchID, req.SkippedBatches) {
+			insertIntoSelectQuery := strings.Builder{}
+			insertIntoSelectQuery.WriteString("INSERT INTO ")
+			insertIntoSelectQuery.WriteString(tbl)
+			insertIntoSelectQuery.WriteString(colSelector.String())
+			insertIntoSelectQuery.WriteString(selectQuery.String())
+			insertIntoSelectQuery.WriteString(" WHERE _peerdb_batch_id > ")
+			insertIntoSelectQuery.WriteString(strconv.FormatInt(batchRange.Start, 10))
+			insertIntoSelectQuery.WriteString(" AND _peerdb_batch_id <= ")
+			insertIntoSelectQuery.WriteString(strconv.FormatInt(batchRange.End, 10))
+			insertIntoSelectQuery.WriteString(" AND _peerdb_destination_table_name = '")
+			insertIntoSelectQuery.WriteString(tbl)
+			insertIntoSelectQuery.WriteString("'")
+			insertIntoSelectQuery.WriteString(" ORDER BY _peerdb_timestamp")
+			insertIntoSelectQuery.WriteString(distributedInsertSettings(c.config))
+
+			q := insertIntoSelectQuery.String()
+
+			if err := c.execWithLoggingAndTimeout(ctx, q, normalizeTimeout); err != nil {
+				return nil, fmt.Errorf("error while inserting into normalized table: %w", err)
+			}
+
+			if lightweightDelete {
+				if len(keyColumns) == 0 {
+					c.logger.Warn("[clickhouse] lightweight delete needs a primary key, keeping deleted rows", slog.String("table", tbl))
+					break
+				}
+				if err := c.execWithLoggingAndTimeout(ctx, lightweightDeleteQuery(tbl, localTable(c.config, tbl)+onCluster(c.config),
+					rawTbl, keyColumns, keyProjection, batchRange.Start, batchRange.End), normalizeTimeout); err != nil {
+					return nil, fmt.Errorf("error while deleting from normalized table: %w", err)
+				}
+			}
 		}
 	}
 
+	changedTables := slices.Clone(destinationTableNames)
+	for tbl := range truncatedTables {
+		if !slices.Contains(changedTables, tbl) {
+			changedTables = append(changedTables, tbl)
+		}
+	}
+	if err := c.reloadDictionaries(ctx, req.TableMappings, changedTables); err != nil {
+		return nil, err
+	}
+	if err := c.refreshDependentViews(ctx, req.TableMappings, changedTables); err != nil {
+		return nil, err
+	}
+
 	err = c.UpdateNormalizeBatchID(ctx, req.FlowJobName, req.SyncBatchID)
 	if err != nil {
 		c.logger.Error("[clickhouse] error while updating normalize batch id", "error", err)
 		return nil, err
 	}
+	metrics.NormalizeBatchDuration(ctx, req.FlowJobName, time.Since(startTime))
 
 	return &model.NormalizeResponse{
 		Done:         true,
@@ -367,6 +576,34 @@ func (c *ClickhouseConnector) NormalizeRecords(
 	}, nil
 }
 
+// lightweightDeleteQuery physically removes rows of keys whose last change in the batches was a delete,
+// otherwise left as tombstones for ReplacingMergeTree to collapse. Rows are deleted from deleteFrom,
+// the local tables of tbl on every node in distributed mode as Distributed tables can't be deleted from
+func lightweightDeleteQuery(
+	tbl string,
+	deleteFrom string,
+	rawTbl string,
+	keyColumns []string,
+	keyProjection []string,
+	startBatchID int64,
+	syncBatchID int64,
+) string {
+	keys := strings.Join(keyColumns, ",")
+	return fmt.Sprintf("DELETE FROM %[7]s WHERE (%[2]s) IN (SELECT %[2]s FROM (SELECT %[3]s,"+
+		"_peerdb_record_type,_peerdb_timestamp FROM %[4]s WHERE _peerdb_batch_id > %[5]d AND _peerdb_batch_id <= %[6]d"+
+		" AND _peerdb_destination_table_name = '%[1]s') GROUP BY %[2]s"+
+		" HAVING argMax(_peerdb_record_type, _peerdb_timestamp) = 2)",
+		tbl, keys, strings.Join(keyProjection, ","), rawTbl, startBatchID, syncBatchID, deleteFrom)
+}
+
+// softDeleteTruncateQuery marks every row of tbl deleted by inserting tombstones one version past the row,
+// so ReplacingMergeTree keeps them over the row but not over changes replicated after the TRUNCATE
+func softDeleteTruncateQuery(config *protos.ClickhouseConfig, tbl string) string {
+	return fmt.Sprintf("INSERT INTO %[1]s SELECT * REPLACE (1 AS `%[2]s`, `%[3]s` + 1 AS `%[3]s`)"+
+		" FROM %[1]s FINAL WHERE `%[2]s` = 0%[4]s",
+		tbl, signColName, versionColName, distributedInsertSettings(config))
+}
+
 func (c *ClickhouseConnector) getDistinctTableNamesInBatch(
 	ctx context.Context,
 	flowJobName string,
@@ -0,0 +1,20 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestSoftDeleteTruncateQuery(t *testing.T) {
+	require.Equal(t,
+		"INSERT INTO orders SELECT * REPLACE (1 AS `_peerdb_is_deleted`, `_peerdb_version` + 1 AS `_peerdb_version`)"+
+			" FROM orders FINAL WHERE `_peerdb_is_deleted` = 0",
+		softDeleteTruncateQuery(&protos.ClickhouseConfig{}, "orders"))
+	require.Equal(t,
+		"INSERT INTO orders SELECT * REPLACE (1 AS `_peerdb_is_deleted`, `_peerdb_version` + 1 AS `_peerdb_version`)"+
+			" FROM orders FINAL WHERE `_peerdb_is_deleted` = 0 SETTINGS insert_distributed_sync=1",
+		softDeleteTruncateQuery(&protos.ClickhouseConfig{Cluster: "main", Distributed: true}, "orders"))
+}
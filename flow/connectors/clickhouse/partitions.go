@@ -0,0 +1,73 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+)
+
+// DropDetachedPartitions drops monthly partitions of a table no range of sourceRanges overlaps,
+// tables not partitioned by month, like ones created before partitioning followed the source, are left alone
+func (c *ClickhouseConnector) DropDetachedPartitions(
+	ctx context.Context,
+	tableIdentifier string,
+	sourceRanges []utils.TimeRange,
+) ([]string, error) {
+	table := localTable(c.config, tableIdentifier)
+	var partitionKey string
+	if err := c.database.QueryRow(ctx,
+		"SELECT partition_key FROM system.tables WHERE database = ? AND name = ?", c.config.Database, table,
+	).Scan(&partitionKey); err != nil {
+		return nil, fmt.Errorf("failed to get partition key of %s: %w", table, err)
+	}
+	if !strings.HasPrefix(partitionKey, "toYYYYMM(") {
+		return nil, nil
+	}
+
+	// parts of local tables are spread over the nodes of the cluster
+	parts := "system.parts"
+	if c.config.Cluster != "" {
+		parts = fmt.Sprintf("clusterAllReplicas('%s', system.parts)", c.config.Cluster)
+	}
+	rows, err := c.database.Query(ctx,
+		"SELECT DISTINCT partition_id FROM "+parts+" WHERE database = ? AND table = ? AND active ORDER BY partition_id",
+		c.config.Database, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions of %s: %w", table, err)
+	}
+	defer rows.Close()
+	var partitionIDs []string
+	for rows.Next() {
+		var partitionID string
+		if err := rows.Scan(&partitionID); err != nil {
+			return nil, fmt.Errorf("failed to scan partition of %s: %w", table, err)
+		}
+		partitionIDs = append(partitionIDs, partitionID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read partitions of %s: %w", table, err)
+	}
+
+	var dropped []string
+	for _, partitionID := range partitionIDs {
+		covered, err := utils.MonthCovered(sourceRanges, partitionID)
+		if err != nil {
+			return dropped, err
+		}
+		if covered {
+			continue
+		}
+		c.logger.Info("dropping partition detached on source",
+			slog.String("table", table), slog.String("partition", partitionID))
+		if err := c.execWithLoggingAndTimeout(ctx,
+			fmt.Sprintf("ALTER TABLE `%s`%s DROP PARTITION ID '%s'", table, onCluster(c.config), partitionID), 0,
+		); err != nil {
+			return dropped, fmt.Errorf("failed to drop partition %s of %s: %w", partitionID, table, err)
+		}
+		dropped = append(dropped, partitionID)
+	}
+	return dropped, nil
+}
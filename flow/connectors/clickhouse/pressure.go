@@ -0,0 +1,20 @@
+package connclickhouse
+
+import (
+	"errors"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// error codes of ClickHouse rejecting inserts and queries until it catches up
+const (
+	clickhouseTooManySimultaneousQueries = 202
+	clickhouseTooManyParts               = 252
+)
+
+// IsDestinationPressure is true for inserts rejected because merges fall behind or too many queries run
+func (c *ClickhouseConnector) IsDestinationPressure(err error) bool {
+	var exception *clickhouse.Exception
+	return errors.As(err, &exception) &&
+		(exception.Code == clickhouseTooManyParts || exception.Code == clickhouseTooManySimultaneousQueries)
+}
@@ -0,0 +1,54 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func addProjectionSQL(config *protos.ClickhouseConfig, table string, projection *protos.ClickhouseProjection) string {
+	return fmt.Sprintf("ALTER TABLE `%s`%s ADD PROJECTION IF NOT EXISTS `%s` (%s)",
+		localTable(config, table), onCluster(config), projection.Name, projection.Query)
+}
+
+// materializeProjectionSQL builds the projection for parts written before it was added, as a mutation in the background
+func materializeProjectionSQL(config *protos.ClickhouseConfig, table string, projection *protos.ClickhouseProjection) string {
+	return fmt.Sprintf("ALTER TABLE `%s`%s MATERIALIZE PROJECTION `%s`",
+		localTable(config, table), onCluster(config), projection.Name)
+}
+
+// setupProjections adds projections to a destination table and materializes them for rows already loaded.
+// Merges of ReplacingMergeTree tables drop rows, so their projections are rebuilt by merges,
+// which needs ClickHouse 24.8 or later
+func (c *ClickhouseConnector) setupProjections(
+	ctx context.Context,
+	tableIdentifier string,
+	projections []*protos.ClickhouseProjection,
+) error {
+	if len(projections) == 0 {
+		return nil
+	}
+	var engine string
+	if err := c.database.QueryRow(ctx, "SELECT engine FROM system.tables WHERE database = ? AND name = ?",
+		c.config.Database, localTable(c.config, tableIdentifier)).Scan(&engine); err != nil {
+		return fmt.Errorf("failed to get engine of %s: %w", tableIdentifier, err)
+	}
+	if strings.Contains(engine, "ReplacingMergeTree") {
+		if err := c.execWithLogging(ctx, fmt.Sprintf(
+			"ALTER TABLE `%s`%s MODIFY SETTING deduplicate_merge_projection_mode = 'rebuild'",
+			localTable(c.config, tableIdentifier), onCluster(c.config))); err != nil {
+			return fmt.Errorf("failed to allow projections on %s: %w", tableIdentifier, err)
+		}
+	}
+	for _, projection := range projections {
+		if err := c.execWithLogging(ctx, addProjectionSQL(c.config, tableIdentifier, projection)); err != nil {
+			return fmt.Errorf("failed to add projection %s to %s: %w", projection.Name, tableIdentifier, err)
+		}
+		if err := c.execWithLogging(ctx, materializeProjectionSQL(c.config, tableIdentifier, projection)); err != nil {
+			return fmt.Errorf("failed to materialize projection %s of %s: %w", projection.Name, tableIdentifier, err)
+		}
+	}
+	return nil
+}
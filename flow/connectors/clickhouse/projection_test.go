@@ -0,0 +1,23 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestProjectionSQL(t *testing.T) {
+	projection := &protos.ClickhouseProjection{Name: "by_customer", Query: "SELECT * ORDER BY customer_id"}
+
+	single := &protos.ClickhouseConfig{Database: "db"}
+	require.Equal(t, "ALTER TABLE `orders` ADD PROJECTION IF NOT EXISTS `by_customer` (SELECT * ORDER BY customer_id)",
+		addProjectionSQL(single, "orders", projection))
+	require.Equal(t, "ALTER TABLE `orders` MATERIALIZE PROJECTION `by_customer`",
+		materializeProjectionSQL(single, "orders", projection))
+
+	distributed := &protos.ClickhouseConfig{Database: "db", Cluster: "main", Distributed: true}
+	require.Equal(t, "ALTER TABLE `orders_resync_local` ON CLUSTER `main` ADD PROJECTION IF NOT EXISTS `by_customer` "+
+		"(SELECT * ORDER BY customer_id)", addProjectionSQL(distributed, "orders_resync", projection))
+}
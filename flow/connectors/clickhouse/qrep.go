@@ -20,6 +20,14 @@ import (
 
 const qRepMetadataTableName = "_peerdb_query_replication_metadata"
 
+// qRepMetadataTable returns the query replication metadata table, in the raw database when one is configured
+func (c *ClickhouseConnector) qRepMetadataTable() string {
+	if c.config.RawDatabase != "" {
+		return c.config.RawDatabase + "." + qRepMetadataTableName
+	}
+	return qRepMetadataTableName
+}
+
 func (c *ClickhouseConnector) SyncQRepRecords(
 	ctx context.Context,
 	config *protos.QRepConfig,
@@ -62,7 +70,7 @@ func (c *ClickhouseConnector) createMetadataInsertStatement(
 		`INSERT INTO %s
 			(flowJobName, partitionID, syncPartition, syncStartTime, syncFinishTime)
 			VALUES ('%s', '%s', '%s', '%s', NOW());`,
-		qRepMetadataTableName, jobName, partition.PartitionId,
+		c.qRepMetadataTable(), jobName, partition.PartitionId,
 		partitionJSON, startTime.Format("2006-01-02 15:04:05.000000"))
 
 	return insertMetadataStmt, nil
@@ -82,7 +90,7 @@ func (c *ClickhouseConnector) getTableSchema(ctx context.Context, tableName stri
 func (c *ClickhouseConnector) IsQRepPartitionSynced(ctx context.Context,
 	req *protos.IsQRepPartitionSyncedInput,
 ) (bool, error) {
-	queryString := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE partitionID = '%s'`, qRepMetadataTableName, req.PartitionId)
+	queryString := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE partitionID = '%s'`, c.qRepMetadataTable(), req.PartitionId)
 
 	var count uint64
 	if err := c.database.QueryRow(ctx, queryString).Scan(&count); err != nil {
@@ -98,7 +106,8 @@ func (c *ClickhouseConnector) SetupQRepMetadataTables(ctx context.Context, confi
 	}
 
 	if config.WriteMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_OVERWRITE {
-		err = c.execWithLogging(ctx, "TRUNCATE TABLE "+config.DestinationTableIdentifier)
+		err = c.execWithLogging(ctx,
+			"TRUNCATE TABLE "+localTable(c.config, config.DestinationTableIdentifier)+onCluster(c.config))
 		if err != nil {
 			return fmt.Errorf("failed to TRUNCATE table before query replication: %w", err)
 		}
@@ -108,32 +117,37 @@ func (c *ClickhouseConnector) SetupQRepMetadataTables(ctx context.Context, confi
 }
 
 func (c *ClickhouseConnector) createQRepMetadataTable(ctx context.Context) error {
+	if err := c.createRawDatabase(ctx); err != nil {
+		return err
+	}
+
 	// Define the schema
 	schemaStatement := `
-	CREATE TABLE IF NOT EXISTS %s (
+	CREATE TABLE IF NOT EXISTS %s%s (
 		flowJobName String,
 		partitionID String,
 		syncPartition String,
 		syncStartTime DateTime64,
 		syncFinishTime DateTime64
-		) ENGINE = MergeTree()
+		) ENGINE = %s
 		ORDER BY partitionID;
 	`
-	queryString := fmt.Sprintf(schemaStatement, qRepMetadataTableName)
+	metadataTable := c.qRepMetadataTable()
+	queryString := fmt.Sprintf(schemaStatement, metadataTable, onCluster(c.config), allNodesEngine(c.config, "MergeTree"))
 	err := c.execWithLogging(ctx, queryString)
 	if err != nil {
-		c.logger.Error("failed to create table "+qRepMetadataTableName,
+		c.logger.Error("failed to create table "+metadataTable,
 			slog.Any("error", err))
 
-		return fmt.Errorf("failed to create table %s: %w", qRepMetadataTableName, err)
+		return fmt.Errorf("failed to create table %s: %w", metadataTable, err)
 	}
-	c.logger.Info("Created table " + qRepMetadataTableName)
+	c.logger.Info("Created table " + metadataTable)
 	return nil
 }
 
-func (c *ClickhouseConnector) ConsolidateQRepPartitions(_ context.Context, config *protos.QRepConfig) error {
-	c.logger.Info("Consolidating partitions noop")
-	return nil
+// ConsolidateQRepPartitions adds projections of initial loads, which are cheaper to build once rows are loaded
+func (c *ClickhouseConnector) ConsolidateQRepPartitions(ctx context.Context, config *protos.QRepConfig) error {
+	return c.setupProjections(ctx, config.DestinationTableIdentifier, config.Projections)
 }
 
 // CleanupQRepFlow function for clickhouse connector
@@ -14,6 +14,7 @@ import (
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
 	"github.com/PeerDB-io/peer-flow/shared"
 )
 
@@ -55,7 +56,11 @@ func (s *ClickhouseAvroSyncMethod) CopyStageToDestination(ctx context.Context, a
 		s.config.DestinationTableIdentifier, avroFileUrl,
 		creds.AWS.AccessKeyID, creds.AWS.SecretAccessKey, sessionTokenPart)
 
-	return s.connector.database.Exec(ctx, query)
+	insertTimeout, err := peerdbenv.PeerDBClickhouseInsertTimeout(ctx, s.config.Env)
+	if err != nil {
+		return err
+	}
+	return s.connector.execWithTimeout(ctx, query, insertTimeout)
 }
 
 func (s *ClickhouseAvroSyncMethod) SyncRecords(
@@ -130,6 +135,7 @@ func (s *ClickhouseAvroSyncMethod) SyncQRepRecords(
 	region := s.connector.credsProvider.Provider.GetRegion()
 	avroFileUrl := utils.FileURLForS3Service(endpoint, region, s3o.Bucket, avroFile.FilePath)
 	selector := make([]string, 0, len(dstTableSchema))
+	structure := make([]string, 0, len(dstTableSchema))
 	for _, col := range dstTableSchema {
 		colName := col.Name()
 		if strings.EqualFold(colName, config.SoftDeleteColName) ||
@@ -140,6 +146,7 @@ func (s *ClickhouseAvroSyncMethod) SyncQRepRecords(
 		}
 
 		selector = append(selector, "`"+colName+"`")
+		structure = append(structure, "`"+colName+"` "+col.DatabaseTypeName())
 	}
 	selectorStr := strings.Join(selector, ",")
 
@@ -147,11 +154,36 @@ func (s *ClickhouseAvroSyncMethod) SyncQRepRecords(
 	if creds.AWS.SessionToken != "" {
 		sessionTokenPart = fmt.Sprintf(", '%s'", creds.AWS.SessionToken)
 	}
-	query := fmt.Sprintf("INSERT INTO %s(%s) SELECT %s FROM s3('%s','%s','%s'%s, 'Avro')",
+	query := fmt.Sprintf("INSERT INTO %s(%s) SELECT %s FROM s3('%s','%s','%s'%s, 'Avro')%s",
 		config.DestinationTableIdentifier, selectorStr, selectorStr, avroFileUrl,
-		creds.AWS.AccessKeyID, creds.AWS.SecretAccessKey, sessionTokenPart)
+		creds.AWS.AccessKeyID, creds.AWS.SecretAccessKey, sessionTokenPart, distributedInsertSettings(s.connector.config))
+	if config.ExportPath != "" {
+		export, err := utils.NewSnapshotExport(config.ExportPath, config.ParentMirrorName, dstTableName)
+		if err != nil {
+			return 0, err
+		}
+		// converted to Native by ClickHouse itself so parts have exactly the types of the destination table,
+		// truncating on insert so retried partitions replace what they exported before
+		nativeFileUrl := utils.FileURLForS3Service(endpoint, region, export.Bucket,
+			export.Key(partition.PartitionId+".native.zst"))
+		structureStr := strings.Join(structure, ",")
+		query = fmt.Sprintf("INSERT INTO FUNCTION s3('%s','%s','%s'%s, 'Native', '%s', 'zstd') "+
+			"SELECT %s FROM s3('%s','%s','%s'%s, 'Avro', '%s') SETTINGS s3_truncate_on_insert=1",
+			nativeFileUrl, creds.AWS.AccessKeyID, creds.AWS.SecretAccessKey, sessionTokenPart, structureStr,
+			selectorStr, avroFileUrl, creds.AWS.AccessKeyID, creds.AWS.SecretAccessKey, sessionTokenPart, structureStr)
+		if err := export.PutLoadScript(ctx, s.connector.credsProvider.Provider,
+			nativeLoadScript(dstTableName, selectorStr, utils.FileURLForS3Service(endpoint, region, export.Bucket,
+				export.Key("*.native.zst"))),
+			s.connector.config.GetKmsKeyId()); err != nil {
+			return 0, err
+		}
+	}
 
-	err = s.connector.database.Exec(ctx, query)
+	insertTimeout, err := peerdbenv.PeerDBClickhouseInsertTimeout(ctx, config.Env)
+	if err != nil {
+		return 0, err
+	}
+	err = s.connector.execWithTimeout(ctx, query, insertTimeout)
 	if err != nil {
 		s.connector.logger.Error("Failed to insert into select for Clickhouse: ", err)
 		return 0, err
@@ -165,6 +197,15 @@ func (s *ClickhouseAvroSyncMethod) SyncQRepRecords(
 	return avroFile.NumRecords, nil
 }
 
+// nativeLoadScript loads Native files exported by an initial snapshot into the destination table,
+// credentials are left out of s3() as they are those of whoever loads them
+func nativeLoadScript(dstTableName string, selector string, filesUrl string) string {
+	return fmt.Sprintf(`-- alternatively load downloaded files with clickhouse-client:
+-- INSERT INTO %[1]s(%[2]s) FROM INFILE '*.native.zst' COMPRESSION 'zstd' FORMAT Native
+INSERT INTO %[1]s(%[2]s) SELECT %[2]s FROM s3('%[3]s', 'Native');
+`, dstTableName, selector, filesUrl)
+}
+
 func (s *ClickhouseAvroSyncMethod) getAvroSchema(
 	dstTableName string,
 	schema qvalue.QRecordSchema,
@@ -192,7 +233,8 @@ func (s *ClickhouseAvroSyncMethod) writeToAvroFile(
 
 	s3AvroFileKey := fmt.Sprintf("%s/%s/%s.avro.zst", s3o.Prefix, flowJobName, identifierForFile)
 	s3AvroFileKey = strings.Trim(s3AvroFileKey, "/")
-	avroFile, err := ocfWriter.WriteRecordsToS3(ctx, s3o.Bucket, s3AvroFileKey, s.connector.credsProvider.Provider)
+	avroFile, err := ocfWriter.WriteRecordsToS3(ctx, s3o.Bucket, s3AvroFileKey, s.connector.credsProvider.Provider,
+		s.connector.config.GetKmsKeyId())
 	if err != nil {
 		return nil, fmt.Errorf("failed to write records to S3: %w", err)
 	}
@@ -0,0 +1,15 @@
+package connclickhouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+)
+
+// EnforceRetention deletes through a mutation, which ClickHouse applies in the background
+func (c *ClickhouseConnector) EnforceRetention(ctx context.Context, tableIdentifier string, column string, cutoff time.Time) error {
+	return c.execWithLoggingAndTimeout(ctx, fmt.Sprintf("ALTER TABLE `%s`%s DELETE WHERE `%s` < toDateTime64('%s', 6, 'UTC')",
+		localTable(c.config, tableIdentifier), onCluster(c.config), column, utils.RetentionCutoffLiteral(cutoff)), 0)
+}
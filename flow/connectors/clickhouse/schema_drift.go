@@ -0,0 +1,85 @@
+package connclickhouse
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// CompareTableSchemas reports Nullable wrapping types as nullability rather than as a type mismatch
+func (c *ClickhouseConnector) CompareTableSchemas(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	tableSchemas map[string]*protos.TableSchema,
+) ([]*protos.SchemaDrift, error) {
+	tables := slices.Sorted(maps.Keys(tableSchemas))
+	tableColumns, err := c.getTableColumnsMapping(ctx, tables)
+	if err != nil {
+		return nil, err
+	}
+	mirrorColumns := []string{signColName, versionColName}
+	if config.SyncedAtColName != "" {
+		mirrorColumns = append(mirrorColumns, strings.ToLower(config.SyncedAtColName))
+	}
+
+	var drifts []*protos.SchemaDrift
+	for _, table := range tables {
+		tableSchema := tableSchemas[table]
+		var tableMapping *protos.TableMapping
+		for _, tm := range config.TableMappings {
+			if tm.DestinationTableIdentifier == table {
+				tableMapping = tm
+				break
+			}
+		}
+		sortingKeyColumns := slices.Clone(tableSchema.PrimaryKeyColumns)
+		if tableMapping != nil {
+			for _, col := range tableMapping.Columns {
+				if col.Ordering > 0 {
+					sortingKeyColumns = append(sortingKeyColumns, col.SourceName)
+				}
+			}
+		}
+
+		expected := make([]utils.DestinationColumn, 0, len(tableSchema.Columns))
+		for _, column := range tableSchema.Columns {
+			columnSetting := columnSettingFor(tableMapping, column.Name)
+			dstColName := column.Name
+			if columnSetting != nil && columnSetting.DestinationName != "" {
+				dstColName = columnSetting.DestinationName
+			}
+			clickhouseType, err := clickhouseColumnType(column, columnSetting, tableSchema.NullableEnabled,
+				slices.Contains(sortingKeyColumns, column.Name))
+			if err != nil {
+				return nil, err
+			}
+			expected = append(expected, clickhouseDestinationColumn(dstColName, clickhouseType))
+		}
+
+		var actual []utils.DestinationColumn
+		if columns, ok := tableColumns[table]; ok {
+			actual = make([]utils.DestinationColumn, 0, len(columns))
+			for _, col := range columns {
+				actual = append(actual, clickhouseDestinationColumn(col.Name, col.Type))
+			}
+		}
+		drifts = append(drifts, utils.DiffTableSchema(table, expected, actual, mirrorColumns, sameClickHouseType)...)
+	}
+	return drifts, nil
+}
+
+func clickhouseDestinationColumn(name string, clickhouseType string) utils.DestinationColumn {
+	if inner, ok := strings.CutPrefix(clickhouseType, "Nullable("); ok {
+		return utils.DestinationColumn{Name: name, Type: strings.TrimSuffix(inner, ")"), Nullable: true}
+	}
+	return utils.DestinationColumn{Name: name, Type: clickhouseType}
+}
+
+// sameClickHouseType ignores case and spacing, ClickHouse reports DECIMAL(76, 38) mirrors create as Decimal(76, 38)
+func sameClickHouseType(expected string, actual string) bool {
+	return strings.EqualFold(strings.ReplaceAll(expected, " ", ""), strings.ReplaceAll(actual, " ", ""))
+}
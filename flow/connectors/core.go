@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"google.golang.org/protobuf/proto"
@@ -14,13 +15,26 @@ import (
 	connclickhouse "github.com/PeerDB-io/peer-flow/connectors/clickhouse"
 	connelasticsearch "github.com/PeerDB-io/peer-flow/connectors/connelasticsearch"
 	conneventhub "github.com/PeerDB-io/peer-flow/connectors/eventhub"
+	connfabric "github.com/PeerDB-io/peer-flow/connectors/fabric"
 	connkafka "github.com/PeerDB-io/peer-flow/connectors/kafka"
+	connkinesis "github.com/PeerDB-io/peer-flow/connectors/kinesis"
 	connmysql "github.com/PeerDB-io/peer-flow/connectors/mysql"
+	connneo4j "github.com/PeerDB-io/peer-flow/connectors/neo4j"
+	connoracle "github.com/PeerDB-io/peer-flow/connectors/oracle"
+	connplugin "github.com/PeerDB-io/peer-flow/connectors/plugin"
 	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
 	connpubsub "github.com/PeerDB-io/peer-flow/connectors/pubsub"
 	conns3 "github.com/PeerDB-io/peer-flow/connectors/s3"
+	connsinglestore "github.com/PeerDB-io/peer-flow/connectors/singlestore"
 	connsnowflake "github.com/PeerDB-io/peer-flow/connectors/snowflake"
+	connsqlite "github.com/PeerDB-io/peer-flow/connectors/sqlite"
 	connsqlserver "github.com/PeerDB-io/peer-flow/connectors/sqlserver"
+	connstarrocks "github.com/PeerDB-io/peer-flow/connectors/starrocks"
+	connsynthetic "github.com/PeerDB-io/peer-flow/connectors/synthetic"
+	conntimeseries "github.com/PeerDB-io/peer-flow/connectors/timeseries"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	connvector "github.com/PeerDB-io/peer-flow/connectors/vector"
+	connwebhook "github.com/PeerDB-io/peer-flow/connectors/webhook"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/logger"
 	"github.com/PeerDB-io/peer-flow/model"
@@ -183,6 +197,21 @@ type CDCNormalizeConnector interface {
 	NormalizeRecords(ctx context.Context, req *model.NormalizeRecordsRequest) (*model.NormalizeResponse, error)
 }
 
+type NormalizedTablesExistConnector interface {
+	Connector
+
+	// MissingNormalizedTables returns which of the destination tables do not exist, like when dropped out of band.
+	MissingNormalizedTables(ctx context.Context, tables []string) ([]string, error)
+}
+
+type NormalizeDiagnosticsConnector interface {
+	Connector
+
+	// NormalizeDiagnostics describes work in progress on the destination, like running queries and merges,
+	// one line each, for when normalizing flowJobName takes longer than expected.
+	NormalizeDiagnostics(ctx context.Context, flowJobName string) ([]string, error)
+}
+
 type CreateTablesFromExistingConnector interface {
 	Connector
 
@@ -210,6 +239,13 @@ type QRepPullPgConnector interface {
 	PullPgQRepRecords(context.Context, *protos.QRepConfig, *protos.QRepPartition, connpostgres.PgCopyWriter) (int, error)
 }
 
+type QRepEstimateRowsConnector interface {
+	QRepPullConnectorCore
+
+	// EstimateQRepRows returns a cheap estimate of rows in the watermark table, 0 when unknown.
+	EstimateQRepRows(ctx context.Context, config *protos.QRepConfig) (int64, error)
+}
+
 type QRepSyncConnectorCore interface {
 	Connector
 
@@ -254,6 +290,104 @@ type RenameTablesConnector interface {
 	RenameTables(context.Context, *protos.RenameTablesInput) (*protos.RenameTablesOutput, error)
 }
 
+type MaintenanceConnector interface {
+	Connector
+
+	// MaintainTable reclaims space and refreshes statistics or layout of a destination table after heavy writes,
+	// tableSchema is the latest source schema of the table and may be nil when none was recorded.
+	MaintainTable(ctx context.Context, tableIdentifier string, tableSchema *protos.TableSchema) error
+}
+
+type RetentionConnector interface {
+	Connector
+
+	// EnforceRetention deletes rows of a destination table whose column is before cutoff.
+	EnforceRetention(ctx context.Context, tableIdentifier string, column string, cutoff time.Time) error
+}
+
+type RangePartitionsConnector interface {
+	Connector
+
+	// RangePartitions returns the ranges of partitions attached to a range partitioned table,
+	// complete is false when rows outside them may exist, like in a default partition.
+	RangePartitions(ctx context.Context, table string) ([]utils.TimeRange, bool, error)
+}
+
+type DropPartitionsConnector interface {
+	Connector
+
+	// DropDetachedPartitions drops partitions of a destination table holding rows of no range in sourceRanges,
+	// returning the partitions dropped.
+	DropDetachedPartitions(ctx context.Context, tableIdentifier string, sourceRanges []utils.TimeRange) ([]string, error)
+}
+
+type BatchControlConnector interface {
+	Connector
+
+	// RecordNormalizedBatch writes a row describing a committed normalize to the batch control table,
+	// creating the table when missing.
+	RecordNormalizedBatch(ctx context.Context, flowJobName string, batch *model.NormalizedBatch) error
+}
+
+type RowDeleteConnector interface {
+	Connector
+
+	// DeleteRows deletes rows of a destination table with the given keys, in the order of keyColumns,
+	// or rows matching predicate when there are no keys.
+	DeleteRows(ctx context.Context, tableIdentifier string, keyColumns []string, keys [][]any, predicate string) error
+}
+
+type RowLookupConnector interface {
+	Connector
+
+	// LookupRows reads the rows of table with the given keys, keys are in the order of keyColumns.
+	LookupRows(ctx context.Context, flowJobName string, table string, keyColumns []string, keys [][]any) ([]model.SampledRow, error)
+}
+
+type RowSamplingConnector interface {
+	RowLookupConnector
+
+	// SampleRows reads about sampleSize random rows of table.
+	SampleRows(ctx context.Context, flowJobName string, table string, sampleSize int) ([]model.SampledRow, error)
+}
+
+type StagingRegionsConnector interface {
+	Connector
+
+	// StagingRegions returns the regions data staged on the peer is kept in, lowercased as its cloud names them,
+	// including buckets of stagingPath when mirrors stage through one.
+	StagingRegions(ctx context.Context, stagingPath string) ([]string, error)
+}
+
+type StagingArtifactsConnector interface {
+	Connector
+
+	// ListStagingArtifacts lists raw tables, stages and staging tables mirrors created on the peer.
+	ListStagingArtifacts(ctx context.Context) ([]*protos.StagingArtifact, error)
+
+	// DropStagingArtifact drops an artifact returned by ListStagingArtifacts.
+	DropStagingArtifact(ctx context.Context, artifact *protos.StagingArtifact) error
+}
+
+type DestinationPressureConnector interface {
+	Connector
+
+	// IsDestinationPressure tells whether a sync failed with err because the destination couldn't keep up with syncs.
+	IsDestinationPressure(err error) bool
+}
+
+type SchemaDriftConnector interface {
+	Connector
+
+	// CompareTableSchemas diffs destination tables against how SetupNormalizedTable creates them for the mirror
+	// of config from tableSchemas, which are keyed by destination table, reporting drift like columns altered out of band.
+	CompareTableSchemas(
+		ctx context.Context,
+		config *protos.FlowConnectionConfigs,
+		tableSchemas map[string]*protos.TableSchema,
+	) ([]*protos.SchemaDrift, error)
+}
+
 func LoadPeerType(ctx context.Context, catalogPool *pgxpool.Pool, peerName string) (protos.DBType, error) {
 	row := catalogPool.QueryRow(ctx, "SELECT type FROM peers WHERE name = $1", peerName)
 	var dbtype protos.DBType
@@ -352,6 +486,78 @@ func LoadPeer(ctx context.Context, catalogPool *pgxpool.Pool, peerName string) (
 			return nil, fmt.Errorf("failed to unmarshal Elasticsearch config: %w", err)
 		}
 		peer.Config = &protos.Peer_ElasticsearchConfig{ElasticsearchConfig: &config}
+	case protos.DBType_SYNTHETIC:
+		var config protos.SyntheticConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal synthetic config: %w", err)
+		}
+		peer.Config = &protos.Peer_SyntheticConfig{SyntheticConfig: &config}
+	case protos.DBType_WEBHOOK:
+		var config protos.WebhookConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook config: %w", err)
+		}
+		peer.Config = &protos.Peer_WebhookConfig{WebhookConfig: &config}
+	case protos.DBType_KINESIS:
+		var config protos.KinesisConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal kinesis config: %w", err)
+		}
+		peer.Config = &protos.Peer_KinesisConfig{KinesisConfig: &config}
+	case protos.DBType_FABRIC:
+		var config protos.FabricConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fabric config: %w", err)
+		}
+		peer.Config = &protos.Peer_FabricConfig{FabricConfig: &config}
+	case protos.DBType_SINGLESTORE:
+		var config protos.SingleStoreConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal singlestore config: %w", err)
+		}
+		peer.Config = &protos.Peer_SinglestoreConfig{SinglestoreConfig: &config}
+	case protos.DBType_ORACLE:
+		var config protos.OracleConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal oracle config: %w", err)
+		}
+		peer.Config = &protos.Peer_OracleConfig{OracleConfig: &config}
+	case protos.DBType_PLUGIN:
+		var config protos.PluginConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal plugin config: %w", err)
+		}
+		peer.Config = &protos.Peer_PluginConfig{PluginConfig: &config}
+	case protos.DBType_STARROCKS:
+		var config protos.StarRocksConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal starrocks config: %w", err)
+		}
+		peer.Config = &protos.Peer_StarrocksConfig{StarrocksConfig: &config}
+	case protos.DBType_TIMESERIES:
+		var config protos.TimeSeriesConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal timeseries config: %w", err)
+		}
+		peer.Config = &protos.Peer_TimeseriesConfig{TimeseriesConfig: &config}
+	case protos.DBType_NEO4J:
+		var config protos.Neo4JConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal neo4j config: %w", err)
+		}
+		peer.Config = &protos.Peer_Neo4JConfig{Neo4JConfig: &config}
+	case protos.DBType_VECTOR:
+		var config protos.VectorConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal vector config: %w", err)
+		}
+		peer.Config = &protos.Peer_VectorConfig{VectorConfig: &config}
+	case protos.DBType_SQLITE:
+		var config protos.SqliteConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sqlite config: %w", err)
+		}
+		peer.Config = &protos.Peer_SqliteConfig{SqliteConfig: &config}
 	default:
 		return nil, fmt.Errorf("unsupported peer type: %s", peer.Type)
 	}
@@ -374,7 +580,7 @@ func GetConnector(ctx context.Context, env map[string]string, config *protos.Pee
 	case *protos.Peer_SqlserverConfig:
 		return connsqlserver.NewSQLServerConnector(ctx, inner.SqlserverConfig)
 	case *protos.Peer_MysqlConfig:
-		return connmysql.MySqlConnector{}, nil
+		return connmysql.NewMySqlConnector(ctx, inner.MysqlConfig)
 	case *protos.Peer_ClickhouseConfig:
 		return connclickhouse.NewClickhouseConnector(ctx, env, inner.ClickhouseConfig)
 	case *protos.Peer_KafkaConfig:
@@ -383,6 +589,30 @@ func GetConnector(ctx context.Context, env map[string]string, config *protos.Pee
 		return connpubsub.NewPubSubConnector(ctx, env, inner.PubsubConfig)
 	case *protos.Peer_ElasticsearchConfig:
 		return connelasticsearch.NewElasticsearchConnector(ctx, inner.ElasticsearchConfig)
+	case *protos.Peer_SyntheticConfig:
+		return connsynthetic.NewSyntheticConnector(ctx, inner.SyntheticConfig)
+	case *protos.Peer_WebhookConfig:
+		return connwebhook.NewWebhookConnector(ctx, inner.WebhookConfig)
+	case *protos.Peer_KinesisConfig:
+		return connkinesis.NewKinesisConnector(ctx, inner.KinesisConfig)
+	case *protos.Peer_FabricConfig:
+		return connfabric.NewFabricConnector(ctx, inner.FabricConfig)
+	case *protos.Peer_SinglestoreConfig:
+		return connsinglestore.NewSingleStoreConnector(ctx, inner.SinglestoreConfig)
+	case *protos.Peer_OracleConfig:
+		return connoracle.NewOracleConnector(ctx, inner.OracleConfig)
+	case *protos.Peer_PluginConfig:
+		return connplugin.NewPluginConnector(ctx, config.Name, inner.PluginConfig)
+	case *protos.Peer_StarrocksConfig:
+		return connstarrocks.NewStarRocksConnector(ctx, inner.StarrocksConfig)
+	case *protos.Peer_TimeseriesConfig:
+		return conntimeseries.NewTimeSeriesConnector(ctx, inner.TimeseriesConfig)
+	case *protos.Peer_Neo4JConfig:
+		return connneo4j.NewNeo4jConnector(ctx, inner.Neo4JConfig)
+	case *protos.Peer_VectorConfig:
+		return connvector.NewVectorConnector(ctx, inner.VectorConfig)
+	case *protos.Peer_SqliteConfig:
+		return connsqlite.NewSQLiteConnector(ctx, inner.SqliteConfig)
 	default:
 		return nil, errors.ErrUnsupported
 	}
@@ -411,6 +641,22 @@ func GetByNameAs[T Connector](ctx context.Context, env map[string]string, catalo
 	return GetAs[T](ctx, env, peer)
 }
 
+// GetQRepSourceAs loads the source peer of config, reading from the read replica of Postgres peers when set.
+// Reads from a snapshot exported by the primary stay on the primary.
+func GetQRepSourceAs[T Connector](ctx context.Context, catalogPool *pgxpool.Pool, config *protos.QRepConfig) (T, error) {
+	peer, err := LoadPeer(ctx, catalogPool, config.SourceName)
+	if err != nil {
+		var none T
+		return none, err
+	}
+	if pgConfig := peer.GetPostgresConfig(); pgConfig != nil && config.SnapshotName == "" {
+		if replicaConfig := connpostgres.ReadReplicaConfig(pgConfig); replicaConfig != nil {
+			peer.Config = &protos.Peer_PostgresConfig{PostgresConfig: replicaConfig}
+		}
+	}
+	return GetAs[T](ctx, config.Env, peer)
+}
+
 func CloseConnector(ctx context.Context, conn Connector) {
 	err := conn.Close()
 	if err != nil {
@@ -421,6 +667,9 @@ func CloseConnector(ctx context.Context, conn Connector) {
 // create type assertions to cause compile time error if connector interface not implemented
 var (
 	_ CDCPullConnector = &connpostgres.PostgresConnector{}
+	_ CDCPullConnector = &connsynthetic.SyntheticConnector{}
+	_ CDCPullConnector = &connoracle.OracleConnector{}
+	_ CDCPullConnector = &connmysql.MySqlConnector{}
 
 	_ CDCPullPgConnector = &connpostgres.PostgresConnector{}
 
@@ -433,6 +682,16 @@ var (
 	_ CDCSyncConnector = &conns3.S3Connector{}
 	_ CDCSyncConnector = &connclickhouse.ClickhouseConnector{}
 	_ CDCSyncConnector = &connelasticsearch.ElasticsearchConnector{}
+	_ CDCSyncConnector = &connwebhook.WebhookConnector{}
+	_ CDCSyncConnector = &connkinesis.KinesisConnector{}
+	_ CDCSyncConnector = &connfabric.FabricConnector{}
+	_ CDCSyncConnector = &connsinglestore.SingleStoreConnector{}
+	_ CDCSyncConnector = &connstarrocks.StarRocksConnector{}
+	_ CDCSyncConnector = &conntimeseries.TimeSeriesConnector{}
+	_ CDCSyncConnector = &connneo4j.Neo4jConnector{}
+	_ CDCSyncConnector = &connvector.VectorConnector{}
+	_ CDCSyncConnector = &connsqlite.SQLiteConnector{}
+	_ CDCSyncConnector = &connplugin.PluginConnector{}
 
 	_ CDCSyncPgConnector = &connpostgres.PostgresConnector{}
 
@@ -440,23 +699,51 @@ var (
 	_ CDCNormalizeConnector = &connbigquery.BigQueryConnector{}
 	_ CDCNormalizeConnector = &connsnowflake.SnowflakeConnector{}
 	_ CDCNormalizeConnector = &connclickhouse.ClickhouseConnector{}
+	_ CDCNormalizeConnector = &connfabric.FabricConnector{}
+	_ CDCNormalizeConnector = &connsinglestore.SingleStoreConnector{}
+	_ CDCNormalizeConnector = &connstarrocks.StarRocksConnector{}
 
 	_ GetTableSchemaConnector = &connpostgres.PostgresConnector{}
 	_ GetTableSchemaConnector = &connsnowflake.SnowflakeConnector{}
+	_ GetTableSchemaConnector = &connsynthetic.SyntheticConnector{}
+	_ GetTableSchemaConnector = &connoracle.OracleConnector{}
+	_ GetTableSchemaConnector = &connmysql.MySqlConnector{}
 
 	_ NormalizedTablesConnector = &connpostgres.PostgresConnector{}
 	_ NormalizedTablesConnector = &connbigquery.BigQueryConnector{}
 	_ NormalizedTablesConnector = &connsnowflake.SnowflakeConnector{}
 	_ NormalizedTablesConnector = &connclickhouse.ClickhouseConnector{}
+	_ NormalizedTablesConnector = &connfabric.FabricConnector{}
+	_ NormalizedTablesConnector = &connsinglestore.SingleStoreConnector{}
+	_ NormalizedTablesConnector = &connstarrocks.StarRocksConnector{}
+	_ NormalizedTablesConnector = &connplugin.PluginConnector{}
+	_ NormalizedTablesConnector = &connneo4j.Neo4jConnector{}
+	_ NormalizedTablesConnector = &connsqlite.SQLiteConnector{}
+
+	_ NormalizedTablesExistConnector = &connpostgres.PostgresConnector{}
+	_ NormalizedTablesExistConnector = &connbigquery.BigQueryConnector{}
+	_ NormalizedTablesExistConnector = &connsnowflake.SnowflakeConnector{}
+	_ NormalizedTablesExistConnector = &connclickhouse.ClickhouseConnector{}
+	_ NormalizedTablesExistConnector = &connfabric.FabricConnector{}
+	_ NormalizedTablesExistConnector = &connsinglestore.SingleStoreConnector{}
+	_ NormalizedTablesExistConnector = &connstarrocks.StarRocksConnector{}
+	_ NormalizedTablesExistConnector = &connsqlite.SQLiteConnector{}
+
+	_ NormalizeDiagnosticsConnector = &connpostgres.PostgresConnector{}
+	_ NormalizeDiagnosticsConnector = &connsnowflake.SnowflakeConnector{}
+	_ NormalizeDiagnosticsConnector = &connclickhouse.ClickhouseConnector{}
 
 	_ CreateTablesFromExistingConnector = &connbigquery.BigQueryConnector{}
 	_ CreateTablesFromExistingConnector = &connsnowflake.SnowflakeConnector{}
 
 	_ QRepPullConnector = &connpostgres.PostgresConnector{}
 	_ QRepPullConnector = &connsqlserver.SQLServerConnector{}
+	_ QRepPullConnector = &connmysql.MySqlConnector{}
 
 	_ QRepPullPgConnector = &connpostgres.PostgresConnector{}
 
+	_ QRepEstimateRowsConnector = &connpostgres.PostgresConnector{}
+
 	_ QRepSyncConnector = &connpostgres.PostgresConnector{}
 	_ QRepSyncConnector = &connbigquery.BigQueryConnector{}
 	_ QRepSyncConnector = &connsnowflake.SnowflakeConnector{}
@@ -464,6 +751,14 @@ var (
 	_ QRepSyncConnector = &conns3.S3Connector{}
 	_ QRepSyncConnector = &connclickhouse.ClickhouseConnector{}
 	_ QRepSyncConnector = &connelasticsearch.ElasticsearchConnector{}
+	_ QRepSyncConnector = &connfabric.FabricConnector{}
+	_ QRepSyncConnector = &connsinglestore.SingleStoreConnector{}
+	_ QRepSyncConnector = &connstarrocks.StarRocksConnector{}
+	_ QRepSyncConnector = &conntimeseries.TimeSeriesConnector{}
+	_ QRepSyncConnector = &connneo4j.Neo4jConnector{}
+	_ QRepSyncConnector = &connvector.VectorConnector{}
+	_ QRepSyncConnector = &connsqlite.SQLiteConnector{}
+	_ QRepSyncConnector = &connplugin.PluginConnector{}
 
 	_ QRepSyncPgConnector = &connpostgres.PostgresConnector{}
 
@@ -475,10 +770,55 @@ var (
 	_ RenameTablesConnector = &connpostgres.PostgresConnector{}
 	_ RenameTablesConnector = &connclickhouse.ClickhouseConnector{}
 
+	_ MaintenanceConnector = &connclickhouse.ClickhouseConnector{}
+	_ MaintenanceConnector = &connpostgres.PostgresConnector{}
+	_ MaintenanceConnector = &connbigquery.BigQueryConnector{}
+
+	_ RetentionConnector = &connpostgres.PostgresConnector{}
+	_ RetentionConnector = &connsnowflake.SnowflakeConnector{}
+	_ RetentionConnector = &connbigquery.BigQueryConnector{}
+	_ RetentionConnector = &connclickhouse.ClickhouseConnector{}
+
+	_ RangePartitionsConnector = &connpostgres.PostgresConnector{}
+
+	_ DropPartitionsConnector = &connclickhouse.ClickhouseConnector{}
+
+	_ BatchControlConnector = &connpostgres.PostgresConnector{}
+	_ BatchControlConnector = &connsnowflake.SnowflakeConnector{}
+	_ BatchControlConnector = &connbigquery.BigQueryConnector{}
+	_ BatchControlConnector = &connclickhouse.ClickhouseConnector{}
+
+	_ DestinationPressureConnector = &connsnowflake.SnowflakeConnector{}
+	_ DestinationPressureConnector = &connclickhouse.ClickhouseConnector{}
+
+	_ SchemaDriftConnector = &connpostgres.PostgresConnector{}
+	_ SchemaDriftConnector = &connsnowflake.SnowflakeConnector{}
+	_ SchemaDriftConnector = &connclickhouse.ClickhouseConnector{}
+
+	_ RowDeleteConnector = &connpostgres.PostgresConnector{}
+	_ RowDeleteConnector = &connsnowflake.SnowflakeConnector{}
+	_ RowDeleteConnector = &connclickhouse.ClickhouseConnector{}
+
+	_ RowSamplingConnector = &connpostgres.PostgresConnector{}
+
+	_ RowLookupConnector = &connpostgres.PostgresConnector{}
+	_ RowLookupConnector = &connsnowflake.SnowflakeConnector{}
+	_ RowLookupConnector = &connclickhouse.ClickhouseConnector{}
+
+	_ StagingRegionsConnector = &connsnowflake.SnowflakeConnector{}
+	_ StagingRegionsConnector = &connbigquery.BigQueryConnector{}
+	_ StagingRegionsConnector = &connclickhouse.ClickhouseConnector{}
+	_ StagingRegionsConnector = &conns3.S3Connector{}
+
+	_ StagingArtifactsConnector = &connpostgres.PostgresConnector{}
+	_ StagingArtifactsConnector = &connsnowflake.SnowflakeConnector{}
+	_ StagingArtifactsConnector = &connbigquery.BigQueryConnector{}
+	_ StagingArtifactsConnector = &connclickhouse.ClickhouseConnector{}
+
 	_ ValidationConnector = &connsnowflake.SnowflakeConnector{}
 	_ ValidationConnector = &connclickhouse.ClickhouseConnector{}
 	_ ValidationConnector = &connbigquery.BigQueryConnector{}
 	_ ValidationConnector = &conns3.S3Connector{}
-
-	_ Connector = &connmysql.MySqlConnector{}
+	_ ValidationConnector = &connmysql.MySqlConnector{}
+	_ ValidationConnector = &connplugin.PluginConnector{}
 )
@@ -176,6 +176,27 @@ type ScopedEventhubData struct {
 	Hub  ScopedEventhub
 }
 
+// applyCloudEvent follows the AMQP protocol binding of CloudEvents
+func applyCloudEvent(data *azeventhubs.EventData, event utils.CloudEvent, mode protos.CloudEventsMode) error {
+	if mode == protos.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED {
+		body, err := event.Structured(data.Body)
+		if err != nil {
+			return fmt.Errorf("failed to create CloudEvents envelope: %w", err)
+		}
+		data.Body = body
+	} else {
+		if data.Properties == nil {
+			data.Properties = make(map[string]any)
+		}
+		for k, v := range event.Attributes() {
+			data.Properties["cloudEvents:"+k] = v
+		}
+	}
+	contentType := event.ContentType(mode)
+	data.ContentType = &contentType
+	return nil
+}
+
 // returns the number of records synced
 func (c *EventHubConnector) processBatch(
 	ctx context.Context,
@@ -191,6 +212,13 @@ func (c *EventHubConnector) processBatch(
 	ticker := time.NewTicker(flushTimeout)
 	defer ticker.Stop()
 
+	idempotencyKey, err := peerdbenv.PeerDBQueueIdempotencyKey(ctx, req.Env)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get idempotency key setting: %w", err)
+	}
+
+	encoder := utils.NewQueueEncoder(req.QueueEncoding, req.TableNameSchemaMapping)
+
 	lastSeenLSN := int64(0)
 
 	numRecords := atomic.Uint32{}
@@ -265,6 +293,26 @@ func (c *EventHubConnector) processBatch(
 					}
 				}
 				ls.SetTop(0)
+			} else if encoder != nil {
+				data, err := encoder.Encode(record)
+				if err != nil {
+					return 0, fmt.Errorf("failed to encode record: %w", err)
+				}
+				if data != nil {
+					scopedHub, err := NewScopedEventhub(destinationString)
+					if err != nil {
+						c.logger.Error("failed to get topic name", slog.Any("error", err))
+						return 0, err
+					}
+					eventData := &azeventhubs.EventData{Body: data}
+					if headers := encoder.Headers(record); len(headers) > 0 {
+						eventData.Properties = make(map[string]any, len(headers))
+						for k, v := range headers {
+							eventData.Properties[k] = v
+						}
+					}
+					events = []ScopedEventhubData{{Hub: scopedHub, Data: eventData}}
+				}
 			} else {
 				json, err := record.GetItems().ToJSONWithOptions(toJSONOpts)
 				if err != nil {
@@ -279,7 +327,22 @@ func (c *EventHubConnector) processBatch(
 				events = []ScopedEventhubData{{Hub: scopedHub, Data: &azeventhubs.EventData{Body: []byte(json)}}}
 			}
 
-			for _, event := range events {
+			pkeyCols := req.TableNameSchemaMapping[destinationString].GetPrimaryKeyColumns()
+			for i, event := range events {
+				if idempotencyKey {
+					if event.Data.Properties == nil {
+						event.Data.Properties = make(map[string]any, 1)
+					}
+					event.Data.Properties[model.IdempotencyKeyHeader] = model.IdempotencyKey(record, pkeyCols, i)
+				}
+				if req.CloudEventsMode != protos.CloudEventsMode_CLOUD_EVENTS_MODE_NONE {
+					cloudEvent := utils.NewCloudEvent(req.FlowJobName, record, pkeyCols, i,
+						utils.QueueContentType(req.Script, req.QueueEncoding, event.Data.Body))
+					if err := applyCloudEvent(event.Data, cloudEvent, req.CloudEventsMode); err != nil {
+						return 0, err
+					}
+				}
+
 				ehConfig, ok := c.hubManager.namespaceToEventhubMap.Get(event.Hub.NamespaceName)
 				if !ok {
 					c.logger.Error("failed to get eventhub config", slog.String("namespace", event.Hub.NamespaceName))
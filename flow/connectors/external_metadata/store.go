@@ -22,6 +22,21 @@ const (
 	qrepTableName          = "metadata_qrep_partitions"
 )
 
+// MetadataStore keeps the sync state of mirrors, connectors embed one for the state methods of CDC connectors
+type MetadataStore interface {
+	NeedsSetupMetadataTables(ctx context.Context) bool
+	SetupMetadataTables(ctx context.Context) error
+	GetLastOffset(ctx context.Context, jobName string) (int64, error)
+	SetLastOffset(ctx context.Context, jobName string, offset int64) error
+	GetLastSyncBatchID(ctx context.Context, jobName string) (int64, error)
+	GetLastNormalizeBatchID(ctx context.Context, jobName string) (int64, error)
+	FinishBatch(ctx context.Context, jobName string, syncBatchID int64, offset int64) error
+	UpdateNormalizeBatchID(ctx context.Context, jobName string, batchID int64) error
+	SyncFlowCleanup(ctx context.Context, jobName string) error
+}
+
+var _ MetadataStore = &PostgresMetadata{}
+
 type PostgresMetadata struct {
 	pool   *pgxpool.Pool
 	logger log.Logger
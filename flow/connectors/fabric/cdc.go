@@ -0,0 +1,132 @@
+package connfabric
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+func (c *FabricConnector) getRawTableName(flowJobName string) string {
+	return c.rawSchema + "._peerdb_raw_" + shared.ReplaceIllegalCharactersWithUnderscores(flowJobName)
+}
+
+func (c *FabricConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	rawTableName := c.getRawTableName(req.FlowJobName)
+
+	exists, err := c.tableExists(ctx, rawTableName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := c.execWithLogging(ctx, fmt.Sprintf(`CREATE TABLE %s (
+			_peerdb_uid VARCHAR(64) NOT NULL,
+			_peerdb_timestamp BIGINT NOT NULL,
+			_peerdb_destination_table_name VARCHAR(512) NOT NULL,
+			_peerdb_data VARCHAR(MAX) NOT NULL,
+			_peerdb_record_type INT NOT NULL,
+			_peerdb_match_data VARCHAR(MAX),
+			_peerdb_batch_id BIGINT,
+			_peerdb_unchanged_toast_columns VARCHAR(MAX)
+		)`, quoteTable(rawTableName))); err != nil {
+			return nil, fmt.Errorf("unable to create raw table: %w", err)
+		}
+	}
+	return &protos.CreateRawTableOutput{
+		TableIdentifier: rawTableName,
+	}, nil
+}
+
+func (c *FabricConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	rawTableName := c.getRawTableName(req.FlowJobName)
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	streamReq := model.NewRecordsToStreamRequest(req.Records.GetRecords(), tableNameRowsMapping, req.SyncBatchID)
+	if err := utils.SetCollapseWindow(ctx, req, streamReq); err != nil {
+		return nil, err
+	}
+	stream, err := utils.RecordsToRawTableStream(streamReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
+	}
+
+	// COPY INTO only appends, a retried batch would otherwise load its records twice
+	if err := c.execWithLogging(ctx, fmt.Sprintf("DELETE FROM %s WHERE _peerdb_batch_id = %d",
+		quoteTable(rawTableName), req.SyncBatchID)); err != nil {
+		return nil, fmt.Errorf("failed to clear raw table of batch %d: %w", req.SyncBatchID, err)
+	}
+	numRecords, err := c.copyStream(ctx, req.FlowJobName, strconv.FormatInt(req.SyncBatchID, 10), quoteTable(rawTableName), stream)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.ReplayTableSchemaDeltas(ctx, req.FlowJobName, req.Records.SchemaDeltas); err != nil {
+		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		c.logger.Error("failed to increment id", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpointID: lastCheckpoint,
+		NumRecordsSynced:       int64(numRecords),
+		CurrentSyncBatchID:     req.SyncBatchID,
+		TableNameRowsMapping:   tableNameRowsMapping,
+		TableSchemaDeltas:      req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *FabricConnector) ReplayTableSchemaDeltas(ctx context.Context, flowJobName string,
+	schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil {
+			continue
+		}
+		if len(schemaDelta.WidenedColumns) > 0 {
+			c.logger.Warn("[schema delta replay] widening columns is not supported for Fabric, skipping",
+				"destination table name", schemaDelta.DstTableName,
+				"widened columns", schemaDelta.WidenedColumns)
+		}
+
+		for _, addedColumn := range schemaDelta.AddedColumns {
+			fabricColType, err := qvalue.QValueKind(addedColumn.Type).ToDWHColumnType(protos.DBType_FABRIC)
+			if err != nil {
+				return fmt.Errorf("failed to convert column type %s to fabric type: %w", addedColumn.Type, err)
+			}
+			if err := c.execWithLogging(ctx, fmt.Sprintf(
+				"IF COL_LENGTH(%s, %s) IS NULL ALTER TABLE %s ADD %s %s NULL",
+				quoteString(schemaDelta.DstTableName), quoteString(addedColumn.Name),
+				quoteTable(schemaDelta.DstTableName), quoteIdentifier(addedColumn.Name), fabricColType,
+			)); err != nil {
+				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s", addedColumn.Name,
+				addedColumn.Type),
+				"destination table name", schemaDelta.DstTableName,
+				"source table name", schemaDelta.SrcTableName)
+		}
+	}
+
+	return nil
+}
+
+func (c *FabricConnector) SyncFlowCleanup(ctx context.Context, jobName string) error {
+	if err := c.PostgresMetadata.SyncFlowCleanup(ctx, jobName); err != nil {
+		return fmt.Errorf("[fabric] unable to clear metadata for sync flow cleanup: %w", err)
+	}
+
+	if err := c.execWithLogging(ctx, "DROP TABLE IF EXISTS "+quoteTable(c.getRawTableName(jobName))); err != nil {
+		return fmt.Errorf("[fabric] unable to drop raw table: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package connfabric
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	_ "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/azuread"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+)
+
+const defaultRawSchema = "dbo"
+
+// FabricConnector replicates to Microsoft Fabric Warehouse and Azure Synapse dedicated SQL pools,
+// which only bulk load from storage, so batches are staged in ADLS and loaded with COPY INTO
+type FabricConnector struct {
+	*metadataStore.PostgresMetadata
+	db        *sql.DB
+	blob      *azblob.Client
+	config    *protos.FabricConfig
+	logger    log.Logger
+	rawSchema string
+}
+
+func connectionURL(config *protos.FabricConfig) string {
+	port := config.Port
+	if port == 0 {
+		port = 1433
+	}
+	query := url.Values{}
+	query.Set("database", config.Database)
+	query.Set("encrypt", "true")
+	if config.Authentication != "" {
+		query.Set("fedauth", config.Authentication)
+	}
+	connURL := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     config.Host + ":" + strconv.FormatUint(uint64(port), 10),
+		RawQuery: query.Encode(),
+	}
+	return connURL.String()
+}
+
+func NewFabricConnector(ctx context.Context, config *protos.FabricConfig) (*FabricConnector, error) {
+	if config.StorageAccount == "" || config.StorageContainer == "" {
+		return nil, errors.New("fabric peer requires a storage account and container for staging")
+	}
+
+	driverName := "sqlserver"
+	if config.Authentication != "" {
+		driverName = azuread.DriverName
+	}
+	db, err := sql.Open(driverName, connectionURL(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fabric connection: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to fabric: %w", err)
+	}
+
+	credential, err := azblob.NewSharedKeyCredential(config.StorageAccount, config.StorageAccountKey)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("invalid storage account credentials: %w", err)
+	}
+	blob, err := azblob.NewClientWithSharedKeyCredential(storageURL(config.StorageAccount), credential, nil)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	rawSchema := config.RawSchema
+	if rawSchema == "" {
+		rawSchema = defaultRawSchema
+	}
+
+	return &FabricConnector{
+		PostgresMetadata: pgMetadata,
+		db:               db,
+		blob:             blob,
+		config:           config,
+		logger:           logger.LoggerFromCtx(ctx),
+		rawSchema:        rawSchema,
+	}, nil
+}
+
+func storageURL(account string) string {
+	return fmt.Sprintf("https://%s.blob.core.windows.net/", account)
+}
+
+func (c *FabricConnector) Close() error {
+	if c != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+func (c *FabricConnector) ConnectionActive(ctx context.Context) error {
+	if err := c.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping fabric: %w", err)
+	}
+	return nil
+}
+
+// quoteIdentifier brackets a name, T-SQL escapes ] by doubling it
+func quoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteTable quotes schema qualified names, unqualified names go to the default schema
+func quoteTable(table string) string {
+	if schemaTable, err := utils.ParseSchemaTable(table); err == nil {
+		return quoteIdentifier(schemaTable.Schema) + "." + quoteIdentifier(schemaTable.Table)
+	}
+	return quoteIdentifier(table)
+}
+
+func (c *FabricConnector) execWithLogging(ctx context.Context, query string) error {
+	c.logger.Info("[fabric] executing statement", slog.String("query", audit.Redact(query)))
+	audit.Record(ctx, query)
+	if _, err := c.db.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *FabricConnector) tableExists(ctx context.Context, table string) (bool, error) {
+	schema := "dbo"
+	name := table
+	if schemaTable, err := utils.ParseSchemaTable(table); err == nil {
+		schema = schemaTable.Schema
+		name = schemaTable.Table
+	}
+	var exists int
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = @p1 AND TABLE_NAME = @p2",
+		schema, name,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check if table %s exists: %w", table, err)
+	}
+	return exists > 0, nil
+}
@@ -0,0 +1,63 @@
+package connfabric
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestWriteCSV(t *testing.T) {
+	stream := model.NewQRecordStream(2)
+	stream.Records <- []qvalue.QValue{
+		qvalue.QValueInt64{Val: 1},
+		qvalue.QValueString{Val: `say "hi"`},
+		qvalue.QValueNull(qvalue.QValueKindString),
+		qvalue.QValueBoolean{Val: true},
+		qvalue.QValueTimestampTZ{Val: time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.FixedZone("", 3600))},
+	}
+	stream.Records <- []qvalue.QValue{
+		qvalue.QValueInt64{Val: 2},
+		qvalue.QValueString{Val: ""},
+		qvalue.QValueArrayInt32{Val: []int32{1, 2}},
+		qvalue.QValueBoolean{Val: false},
+		qvalue.QValueBytes{Val: []byte("ab")},
+	}
+	stream.Close(nil)
+
+	var sb strings.Builder
+	numRecords, err := writeCSV(&sb, stream)
+	require.NoError(t, err)
+	require.Equal(t, 2, numRecords)
+	require.Equal(t, `"1","say ""hi""",,"1","2024-01-02 02:04:05.6"`+"\n"+`"2","","[1,2]","0","YWI="`+"\n", sb.String())
+}
+
+func TestNormalizeStatements(t *testing.T) {
+	generator := &normalizeStmtGenerator{
+		rawTableName:    "dbo._peerdb_raw_flow",
+		dstTableName:    "sales.orders",
+		syncedAtColName: "_peerdb_synced_at",
+		columns: []normalizedColumn{
+			{source: "id", name: "id", fabricType: "BIGINT", kind: qvalue.QValueKindInt64, primaryKey: true},
+			{source: "note", name: "comment", fabricType: "VARCHAR(MAX)", kind: qvalue.QValueKindString},
+			{source: "at", name: "at", fabricType: "DATETIME2(6)", kind: qvalue.QValueKindTimestampTZ},
+		},
+		startBatchID: 3,
+		syncBatchID:  5,
+	}
+	stmts := generator.statements()
+	require.Len(t, stmts, 3)
+	require.True(t, strings.HasPrefix(stmts[0], "DELETE t FROM [sales].[orders] t INNER JOIN"))
+	require.Contains(t, stmts[0], "ROW_NUMBER() OVER (PARTITION BY JSON_VALUE(_peerdb_data,'$.\"id\"') ORDER BY _peerdb_timestamp DESC)")
+	require.Contains(t, stmts[0], "_peerdb_batch_id > 3 AND _peerdb_batch_id <= 5 AND _peerdb_destination_table_name = 'sales.orders'")
+	require.Contains(t, stmts[0], "WITH ([id] BIGINT '$.\"id\"',[comment] VARCHAR(MAX) '$.\"note\"',[at] VARCHAR(64) '$.\"at\"')")
+	require.Contains(t, stmts[1], "[comment] = CASE WHEN CHARINDEX(',note,',','+s._peerdb_unchanged_toast_columns+',') > 0")
+	require.Contains(t, stmts[1], "[_peerdb_synced_at] = SYSUTCDATETIME()")
+	require.NotContains(t, stmts[1], "[id] = CASE")
+	require.True(t, strings.HasPrefix(stmts[2], "INSERT INTO [sales].[orders] ([id],[comment],[at],[_peerdb_synced_at])"))
+	require.True(t, strings.HasSuffix(stmts[2], "NOT EXISTS (SELECT 1 FROM [sales].[orders] t WHERE t.[id] = s.[id])"))
+}
@@ -0,0 +1,326 @@
+package connfabric
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
+	"github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func (c *FabricConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *FabricConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *FabricConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (c *FabricConnector) MissingNormalizedTables(ctx context.Context, tables []string) ([]string, error) {
+	return utils.MissingTables(ctx, tables, c.tableExists)
+}
+
+func (c *FabricConnector) SetupNormalizedTable(
+	ctx context.Context,
+	tx any,
+	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
+) (bool, error) {
+	exists, err := c.tableExists(ctx, tableIdentifier)
+	if err != nil {
+		return false, err
+	}
+	if exists && !config.IsResync {
+		c.logger.Info("[fabric] normalized table already exists, skipping", slog.String("table", tableIdentifier))
+		return true, nil
+	}
+	if exists {
+		if err := c.execWithLogging(ctx, "DROP TABLE "+quoteTable(tableIdentifier)); err != nil {
+			return false, fmt.Errorf("[fabric] error while dropping normalized table for resync: %w", err)
+		}
+	}
+
+	columns, err := normalizedColumns(config.TableNameSchemaMapping[tableIdentifier],
+		utils.FindTableMapping(config.TableMappings, tableIdentifier))
+	if err != nil {
+		return false, err
+	}
+	definitions := make([]string, 0, len(columns)+2)
+	for _, column := range columns {
+		definitions = append(definitions, quoteIdentifier(column.name)+" "+column.fabricType+" NULL")
+	}
+	if config.SoftDeleteColName != "" {
+		definitions = append(definitions, quoteIdentifier(config.SoftDeleteColName)+" BIT NOT NULL")
+	}
+	if config.SyncedAtColName != "" {
+		definitions = append(definitions, quoteIdentifier(config.SyncedAtColName)+" DATETIME2(6) NULL")
+	}
+	// warehouse tables can't enforce keys, keys only live in the mirror's table schema
+	if err := c.execWithLogging(ctx, fmt.Sprintf("CREATE TABLE %s (%s)",
+		quoteTable(tableIdentifier), strings.Join(definitions, ","))); err != nil {
+		return false, fmt.Errorf("[fabric] error while creating normalized table: %w", err)
+	}
+	return false, nil
+}
+
+type normalizedColumn struct {
+	source     string
+	name       string
+	fabricType string
+	kind       qvalue.QValueKind
+	primaryKey bool
+}
+
+func normalizedColumns(schema *protos.TableSchema, tableMapping *protos.TableMapping) ([]normalizedColumn, error) {
+	columns := make([]normalizedColumn, 0, len(schema.Columns))
+	for _, column := range schema.Columns {
+		kind := qvalue.QValueKind(column.Type)
+		normalized := normalizedColumn{
+			source:     column.Name,
+			name:       column.Name,
+			kind:       kind,
+			primaryKey: slices.Contains(schema.PrimaryKeyColumns, column.Name),
+		}
+		if tableMapping != nil {
+			for _, col := range tableMapping.Columns {
+				if col.SourceName == column.Name {
+					if col.DestinationName != "" {
+						normalized.name = col.DestinationName
+					}
+					normalized.fabricType = col.DestinationType
+					break
+				}
+			}
+		}
+		if normalized.fabricType == "" {
+			if kind == qvalue.QValueKindNumeric {
+				precision, scale := datatypes.GetNumericTypeForWarehouse(column.TypeModifier, datatypes.DefaultNumericCompatibility{})
+				normalized.fabricType = fmt.Sprintf("DECIMAL(%d, %d)", precision, scale)
+			} else {
+				fabricType, err := kind.ToDWHColumnType(protos.DBType_FABRIC)
+				if err != nil {
+					return nil, fmt.Errorf("error while converting column type to fabric type: %w", err)
+				}
+				normalized.fabricType = fabricType
+			}
+		}
+		columns = append(columns, normalized)
+	}
+	return columns, nil
+}
+
+// jsonPath addresses a top level key of _peerdb_data, quoted so any column name is a valid path
+func jsonPath(key string) string {
+	return quoteString(`$."` + strings.ReplaceAll(strings.ReplaceAll(key, `\`, `\\`), `"`, `\"`) + `"`)
+}
+
+// openJSONColumn is how a column is read out of _peerdb_data and the expression converting it to its table type,
+// timestamptz carries an offset DATETIME2 can't parse and qchar is the character code
+func openJSONColumn(column normalizedColumn, alias string) (string, string) {
+	ref := alias + "." + quoteIdentifier(column.name)
+	path := jsonPath(column.source)
+	switch {
+	case column.kind == qvalue.QValueKindTimestampTZ:
+		return fmt.Sprintf("%s VARCHAR(64) %s", quoteIdentifier(column.name), path),
+			fmt.Sprintf("CAST(SWITCHOFFSET(CAST(STUFF(%[1]s,LEN(%[1]s)-1,0,':') AS DATETIMEOFFSET(6)),'+00:00') AS DATETIME2(6))", ref)
+	case column.kind == qvalue.QValueKindQChar:
+		return fmt.Sprintf("%s INT %s", quoteIdentifier(column.name), path), fmt.Sprintf("CHAR(%s)", ref)
+	case column.kind.IsArray() || column.kind == qvalue.QValueKindHStore:
+		return fmt.Sprintf("%s NVARCHAR(MAX) %s AS JSON", quoteIdentifier(column.name), path),
+			fmt.Sprintf("CAST(%s AS VARCHAR(MAX))", ref)
+	default:
+		return fmt.Sprintf("%s %s %s", quoteIdentifier(column.name), column.fabricType, path), ref
+	}
+}
+
+type normalizeStmtGenerator struct {
+	rawTableName      string
+	dstTableName      string
+	softDeleteColName string
+	syncedAtColName   string
+	columns           []normalizedColumn
+	startBatchID      int64
+	syncBatchID       int64
+}
+
+// latestRowsQuery has the last change of every key in the batches, typed as the destination columns
+func (g *normalizeStmtGenerator) latestRowsQuery() string {
+	withColumns := make([]string, 0, len(g.columns))
+	projection := make([]string, 0, len(g.columns)+2)
+	partitionBy := make([]string, 0, len(g.columns))
+	for _, column := range g.columns {
+		withColumn, expr := openJSONColumn(column, "d")
+		withColumns = append(withColumns, withColumn)
+		projection = append(projection, expr+" AS "+quoteIdentifier(column.name))
+		if column.primaryKey {
+			partitionBy = append(partitionBy, "JSON_VALUE(_peerdb_data,"+jsonPath(column.source)+")")
+		}
+	}
+	projection = append(projection, "r._peerdb_record_type", "r._peerdb_unchanged_toast_columns")
+
+	return fmt.Sprintf("SELECT %s FROM (SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,"+
+		"ROW_NUMBER() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank"+
+		" FROM %s WHERE _peerdb_batch_id > %d AND _peerdb_batch_id <= %d AND _peerdb_destination_table_name = %s) r"+
+		" CROSS APPLY OPENJSON(r._peerdb_data) WITH (%s) d WHERE r._peerdb_rank = 1",
+		strings.Join(projection, ","), strings.Join(partitionBy, ","),
+		quoteTable(g.rawTableName), g.startBatchID, g.syncBatchID, quoteString(g.dstTableName),
+		strings.Join(withColumns, ","))
+}
+
+func (g *normalizeStmtGenerator) keyCondition() string {
+	conditions := make([]string, 0, len(g.columns))
+	for _, column := range g.columns {
+		if column.primaryKey {
+			name := quoteIdentifier(column.name)
+			conditions = append(conditions, "t."+name+" = s."+name)
+		}
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// statements applies the batches as deletes, then updates of existing rows, then inserts of new rows,
+// since MERGE isn't generally available on warehouses
+func (g *normalizeStmtGenerator) statements() []string {
+	dst := quoteTable(g.dstTableName)
+	latest := g.latestRowsQuery()
+	keyCondition := g.keyCondition()
+
+	var deleteStmt string
+	if g.softDeleteColName != "" {
+		set := "t." + quoteIdentifier(g.softDeleteColName) + " = 1"
+		if g.syncedAtColName != "" {
+			set += ",t." + quoteIdentifier(g.syncedAtColName) + " = SYSUTCDATETIME()"
+		}
+		deleteStmt = fmt.Sprintf("UPDATE t SET %s FROM %s t INNER JOIN (%s) s ON %s WHERE s._peerdb_record_type = 2",
+			set, dst, latest, keyCondition)
+	} else {
+		deleteStmt = fmt.Sprintf("DELETE t FROM %s t INNER JOIN (%s) s ON %s WHERE s._peerdb_record_type = 2",
+			dst, latest, keyCondition)
+	}
+
+	sets := make([]string, 0, len(g.columns)+2)
+	insertColumns := make([]string, 0, len(g.columns)+2)
+	insertValues := make([]string, 0, len(g.columns)+2)
+	for _, column := range g.columns {
+		name := quoteIdentifier(column.name)
+		insertColumns = append(insertColumns, name)
+		insertValues = append(insertValues, "s."+name)
+		if !column.primaryKey {
+			// unchanged TOAST columns keep their existing value
+			sets = append(sets, fmt.Sprintf("%[1]s = CASE WHEN CHARINDEX(%[2]s,','+s._peerdb_unchanged_toast_columns+',') > 0"+
+				" THEN t.%[1]s ELSE s.%[1]s END", name, quoteString(","+column.source+",")))
+		}
+	}
+	if g.softDeleteColName != "" {
+		name := quoteIdentifier(g.softDeleteColName)
+		sets = append(sets, name+" = 0")
+		insertColumns = append(insertColumns, name)
+		insertValues = append(insertValues, "0")
+	}
+	if g.syncedAtColName != "" {
+		name := quoteIdentifier(g.syncedAtColName)
+		sets = append(sets, name+" = SYSUTCDATETIME()")
+		insertColumns = append(insertColumns, name)
+		insertValues = append(insertValues, "SYSUTCDATETIME()")
+	}
+
+	stmts := []string{deleteStmt}
+	if len(sets) > 0 {
+		stmts = append(stmts, fmt.Sprintf("UPDATE t SET %s FROM %s t INNER JOIN (%s) s ON %s WHERE s._peerdb_record_type != 2",
+			strings.Join(sets, ","), dst, latest, keyCondition))
+	}
+	return append(stmts, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM (%s) s"+
+		" WHERE s._peerdb_record_type != 2 AND NOT EXISTS (SELECT 1 FROM %s t WHERE %s)",
+		dst, strings.Join(insertColumns, ","), strings.Join(insertValues, ","), latest, dst, keyCondition))
+}
+
+// appendStatement reads columns of raw records with OPENJSON, typed as the destination columns
+func (g *normalizeStmtGenerator) appendStatement() string {
+	withColumns := make([]string, 0, len(g.columns))
+	columns := make([]utils.AppendColumn, 0, len(g.columns))
+	for _, column := range g.columns {
+		withColumn, expr := openJSONColumn(column, "d")
+		withColumns = append(withColumns, withColumn)
+		columns = append(columns, utils.AppendColumn{Name: column.name, Expr: expr})
+	}
+	stmt := &utils.AppendStatement{
+		QuoteIdentifier: quoteIdentifier,
+		DstTable:        quoteTable(g.dstTableName),
+		From: fmt.Sprintf("%s r CROSS APPLY OPENJSON(r._peerdb_data) WITH (%s) d"+
+			" WHERE r._peerdb_batch_id > %d AND r._peerdb_batch_id <= %d AND r._peerdb_destination_table_name = %s"+
+			" AND r._peerdb_record_type != 2",
+			quoteTable(g.rawTableName), strings.Join(withColumns, ","),
+			g.startBatchID, g.syncBatchID, quoteString(g.dstTableName)),
+		Columns:           columns,
+		SoftDeleteColName: g.softDeleteColName,
+		NotDeleted:        "0",
+		SyncedAtColName:   g.syncedAtColName,
+		Now:               "SYSUTCDATETIME()",
+	}
+	return stmt.SQL()
+}
+
+func (c *FabricConnector) NormalizeRecords(ctx context.Context, req *model.NormalizeRecordsRequest) (*model.NormalizeResponse, error) {
+	rawTableName := c.getRawTableName(req.FlowJobName)
+	normalizer := &utils.SQLNormalizer{
+		Logger:     c.logger,
+		Name:       "fabric",
+		DB:         c.db,
+		Metadata:   c,
+		QuoteTable: quoteTable,
+		Exec:       c.execWithLogging,
+		TableStatements: func(tbl string, tableMapping *protos.TableMapping, startBatchID int64) ([]string, error) {
+			columns, err := normalizedColumns(req.TableNameSchemaMapping[tbl], tableMapping)
+			if err != nil {
+				return nil, err
+			}
+			generator := &normalizeStmtGenerator{
+				rawTableName:      rawTableName,
+				dstTableName:      tbl,
+				softDeleteColName: req.SoftDeleteColName,
+				syncedAtColName:   req.SyncedAtColName,
+				columns:           columns,
+				startBatchID:      startBatchID,
+				syncBatchID:       req.SyncBatchID,
+			}
+			if len(req.TableNameSchemaMapping[tbl].PrimaryKeyColumns) == 0 {
+				return []string{generator.appendStatement()}, nil
+			}
+			return generator.statements(), nil
+		},
+		ExecTable: c.execInTx,
+	}
+	return normalizer.NormalizeRecords(ctx, req, rawTableName)
+}
+
+// execInTx applies the statements of a table together so a failed batch doesn't leave it half normalized
+func (c *FabricConnector) execInTx(ctx context.Context, stmts []string) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			c.logger.Error("[fabric] failed to rollback transaction", slog.Any("error", err))
+		}
+	}()
+	for _, stmt := range stmts {
+		c.logger.Info("[fabric] executing statement", slog.String("query", audit.Redact(stmt)))
+		audit.Record(ctx, stmt)
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,38 @@
+package connfabric
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+func (c *FabricConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *FabricConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int, error) {
+	startTime := time.Now()
+	c.logger.Info("[fabric] syncing partition",
+		slog.String(string(shared.PartitionIDKey), partition.PartitionId),
+		slog.String("destinationTable", config.DestinationTableIdentifier))
+
+	numRecords, err := c.copyStream(ctx, config.FlowJobName, partition.PartitionId,
+		quoteTable(config.DestinationTableIdentifier), stream)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
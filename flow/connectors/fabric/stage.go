@@ -0,0 +1,164 @@
+package connfabric
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// writeCSV writes rows for COPY INTO, every value is quoted so empty strings survive and null is an empty field
+func writeCSV(w io.Writer, stream *model.QRecordStream) (int, error) {
+	bw := bufio.NewWriter(w)
+	numRecords := 0
+	for record := range stream.Records {
+		for i, value := range record {
+			if i > 0 {
+				if err := bw.WriteByte(','); err != nil {
+					return 0, err
+				}
+			}
+			field, null, err := csvField(value)
+			if err != nil {
+				return 0, err
+			}
+			if null {
+				continue
+			}
+			if _, err := bw.WriteString(`"` + strings.ReplaceAll(field, `"`, `""`) + `"`); err != nil {
+				return 0, err
+			}
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return 0, err
+		}
+		numRecords += 1
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	return numRecords, bw.Flush()
+}
+
+func csvField(value qvalue.QValue) (string, bool, error) {
+	raw := value.Value()
+	if raw == nil {
+		return "", true, nil
+	}
+	switch v := raw.(type) {
+	case string:
+		return v, false, nil
+	case bool:
+		if v {
+			return "1", false, nil
+		}
+		return "0", false, nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), false, nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), false, nil
+	case int64:
+		return strconv.FormatInt(v, 10), false, nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), false, nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), false, nil
+	case uint8:
+		return string(rune(v)), false, nil
+	case decimal.Decimal:
+		return v.String(), false, nil
+	case [16]byte:
+		return uuid.UUID(v).String(), false, nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), false, nil
+	case time.Time:
+		switch value.Kind() {
+		case qvalue.QValueKindDate:
+			return v.Format(time.DateOnly), false, nil
+		case qvalue.QValueKindTime, qvalue.QValueKindTimeTZ:
+			return v.Format("15:04:05.999999"), false, nil
+		default:
+			// DATETIME2 has no offset, timestamptz lands in UTC
+			return v.UTC().Format("2006-01-02 15:04:05.999999"), false, nil
+		}
+	default:
+		// arrays and anything structured are loaded as JSON text
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to encode %s value for fabric: %w", value.Kind(), err)
+		}
+		return shared.UnsafeFastReadOnlyBytesToString(b), false, nil
+	}
+}
+
+func (c *FabricConnector) stagePath(flowJobName string, id string) string {
+	path := shared.ReplaceIllegalCharactersWithUnderscores(flowJobName) + "/" + id + ".csv"
+	if prefix := strings.Trim(c.config.StoragePrefix, "/"); prefix != "" {
+		path = prefix + "/" + path
+	}
+	return path
+}
+
+// copyStream stages the stream as a CSV blob and loads it into table with COPY INTO,
+// the blob is removed afterwards whether the load succeeded or not
+func (c *FabricConnector) copyStream(
+	ctx context.Context,
+	flowJobName string,
+	id string,
+	table string,
+	stream *model.QRecordStream,
+) (int, error) {
+	schema := stream.Schema()
+	blobPath := c.stagePath(flowJobName, id)
+
+	reader, writer := io.Pipe()
+	numRecords := 0
+	go func() {
+		var err error
+		numRecords, err = writeCSV(writer, stream)
+		writer.CloseWithError(err)
+	}()
+	if _, err := c.blob.UploadStream(ctx, c.config.StorageContainer, blobPath, reader, nil); err != nil {
+		reader.CloseWithError(err)
+		return 0, fmt.Errorf("failed to stage %s: %w", blobPath, err)
+	}
+	defer func() {
+		if _, err := c.blob.DeleteBlob(context.WithoutCancel(ctx), c.config.StorageContainer, blobPath, nil); err != nil {
+			c.logger.Warn("[fabric] failed to delete staged file", slog.String("path", blobPath), slog.Any("error", err))
+		}
+	}()
+	if numRecords == 0 {
+		return 0, nil
+	}
+
+	columns := make([]string, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		columns = append(columns, quoteIdentifier(field.Name))
+	}
+	if err := c.execWithLogging(ctx, c.copyIntoQuery(table, columns, blobPath)); err != nil {
+		return 0, fmt.Errorf("failed to copy %s into %s: %w", blobPath, table, err)
+	}
+	return numRecords, nil
+}
+
+func (c *FabricConnector) copyIntoQuery(table string, columns []string, blobPath string) string {
+	return fmt.Sprintf("COPY INTO %s (%s) FROM %s WITH (FILE_TYPE='CSV',"+
+		"CREDENTIAL=(IDENTITY='Storage Account Key',SECRET=%s),"+
+		"FIELDQUOTE='\"',FIELDTERMINATOR=',',ROWTERMINATOR='0x0A',ENCODING='UTF8')",
+		table, strings.Join(columns, ","),
+		quoteString(storageURL(c.config.StorageAccount)+c.config.StorageContainer+"/"+blobPath),
+		quoteString(c.config.StorageAccountKey))
+}
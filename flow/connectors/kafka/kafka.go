@@ -172,6 +172,23 @@ func lvalueToKafkaRecord(ls *lua.LState, value lua.LValue) (*kgo.Record, error)
 	return kr, nil
 }
 
+// applyCloudEvent follows the Kafka protocol binding of CloudEvents
+func applyCloudEvent(kr *kgo.Record, event utils.CloudEvent, mode protos.CloudEventsMode) error {
+	if mode == protos.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED {
+		value, err := event.Structured(kr.Value)
+		if err != nil {
+			return fmt.Errorf("failed to create CloudEvents envelope: %w", err)
+		}
+		kr.Value = value
+	} else {
+		for k, v := range event.Attributes() {
+			kr.Headers = append(kr.Headers, kgo.RecordHeader{Key: "ce_" + k, Value: []byte(v)})
+		}
+	}
+	kr.Headers = append(kr.Headers, kgo.RecordHeader{Key: "content-type", Value: []byte(event.ContentType(mode))})
+	return nil
+}
+
 type poolResult struct {
 	records []*kgo.Record
 	lsn     int64
@@ -182,7 +199,8 @@ func (c *KafkaConnector) createPool(
 	env map[string]string,
 	script string,
 	flowJobName string,
-	lastSeenLSN *atomic.Int64,
+	progress *utils.DurableOffset,
+	encoder utils.QueueEncoder,
 	queueErr func(error),
 ) (*utils.LPool[poolResult], error) {
 	maxSize, err := peerdbenv.PeerDBQueueParallelism(ctx, env)
@@ -198,15 +216,21 @@ func (c *KafkaConnector) createPool(
 			return nil, err
 		}
 		if script == "" {
-			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DefaultOnRecord))
+			onRecord := utils.DefaultOnRecord
+			if encoder != nil {
+				onRecord = utils.QueueEncoderOnRecord(encoder)
+			}
+			ls.Env.RawSetString("onRecord", ls.NewFunction(onRecord))
 		}
 		return ls, nil
 	}, func(result poolResult) {
+		ack := func() {}
+		if progress != nil {
+			ack = progress.Track(result.lsn)
+		}
 		lenRecords := int32(len(result.records))
 		if lenRecords == 0 {
-			if lastSeenLSN != nil {
-				shared.AtomicInt64Max(lastSeenLSN, result.lsn)
-			}
+			ack()
 		} else {
 			recordCounter := atomic.Int32{}
 			recordCounter.Store(lenRecords)
@@ -235,8 +259,8 @@ func (c *KafkaConnector) createPool(
 					} else {
 						queueErr(err)
 					}
-				} else if recordCounter.Add(-1) == 0 && lastSeenLSN != nil {
-					shared.AtomicInt64Max(lastSeenLSN, result.lsn)
+				} else if recordCounter.Add(-1) == 0 {
+					ack()
 				}
 			}
 			for _, kr := range result.records {
@@ -248,11 +272,18 @@ func (c *KafkaConnector) createPool(
 
 func (c *KafkaConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
 	numRecords := atomic.Int64{}
-	lastSeenLSN := atomic.Int64{}
+	// partitions ack independently, only what was acked without gaps may be recorded as synced
+	progress := utils.NewDurableOffset(req.ConsumedOffset.Load())
+
+	idempotencyKey, err := peerdbenv.PeerDBQueueIdempotencyKey(ctx, req.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key setting: %w", err)
+	}
 
 	queueCtx, queueErr := context.WithCancelCause(ctx)
 
-	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, &lastSeenLSN, queueErr)
+	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, progress,
+		utils.NewQueueEncoder(req.QueueEncoding, req.TableNameSchemaMapping), queueErr)
 	if err != nil {
 		return nil, err
 	}
@@ -277,18 +308,7 @@ func (c *KafkaConnector) SyncRecords(ctx context.Context, req *model.SyncRecords
 				return
 			// flush loop doesn't block processing new messages
 			case <-ticker.C:
-				lastSeen := lastSeenLSN.Load()
-				if err := c.client.Flush(ctx); err != nil {
-					c.logger.Warn("[kafka] flush error", slog.Any("error", err))
-					continue
-				} else if lastSeen > req.ConsumedOffset.Load() {
-					if err := c.SetLastOffset(ctx, req.FlowJobName, lastSeen); err != nil {
-						c.logger.Warn("[kafka] SetLastOffset error", slog.Any("error", err))
-					} else {
-						shared.AtomicInt64Max(req.ConsumedOffset, lastSeen)
-						c.logger.Info("processBatch", slog.Int64("updated last offset", lastSeen))
-					}
-				}
+				utils.SaveLastSeenOffset(ctx, c.logger, req, progress.Durable(), c.SetLastOffset)
 			}
 		}
 	}()
@@ -335,6 +355,25 @@ Loop:
 					}
 				}
 				ls.SetTop(0)
+				pkeyCols := req.TableNameSchemaMapping[record.GetDestinationTableName()].GetPrimaryKeyColumns()
+				if idempotencyKey {
+					for i, kr := range results {
+						kr.Headers = append(kr.Headers, kgo.RecordHeader{
+							Key:   model.IdempotencyKeyHeader,
+							Value: []byte(model.IdempotencyKey(record, pkeyCols, i)),
+						})
+					}
+				}
+				if req.CloudEventsMode != protos.CloudEventsMode_CLOUD_EVENTS_MODE_NONE {
+					for i, kr := range results {
+						event := utils.NewCloudEvent(req.FlowJobName, record, pkeyCols, i,
+							utils.QueueContentType(req.Script, req.QueueEncoding, kr.Value))
+						if err := applyCloudEvent(kr, event, req.CloudEventsMode); err != nil {
+							queueErr(err)
+							return poolResult{}
+						}
+					}
+				}
 				numRecords.Add(1)
 				return poolResult{
 					records: results,
@@ -349,9 +388,11 @@ Loop:
 
 	close(flushLoopDone)
 	if err := pool.Wait(queueCtx); err != nil {
+		utils.SaveLastSeenOffset(ctx, c.logger, req, progress.Durable(), c.SetLastOffset)
 		return nil, err
 	}
 	if err := c.client.Flush(queueCtx); err != nil {
+		utils.SaveLastSeenOffset(ctx, c.logger, req, progress.Durable(), c.SetLastOffset)
 		return nil, fmt.Errorf("[kafka] final flush error: %w", err)
 	}
 
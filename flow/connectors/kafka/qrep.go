@@ -30,7 +30,7 @@ func (c *KafkaConnector) SyncQRepRecords(
 	schema := stream.Schema()
 
 	queueCtx, queueErr := context.WithCancelCause(ctx)
-	pool, err := c.createPool(queueCtx, config.Env, config.Script, config.FlowJobName, nil, queueErr)
+	pool, err := c.createPool(queueCtx, config.Env, config.Script, config.FlowJobName, nil, nil, queueErr)
 	if err != nil {
 		return 0, err
 	}
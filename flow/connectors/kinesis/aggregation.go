@@ -0,0 +1,87 @@
+package connkinesis
+
+import (
+	"crypto/md5"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// records put to Kinesis are limited to 1 MiB of data and partition key
+const maxRecordSize = 1 << 20
+
+// kplMagic prefixes records aggregated in the format of the Kinesis Producer Library,
+// which the KCL and the deaggregation libraries unpack back into the records they hold
+var kplMagic = []byte{0xF3, 0x89, 0x9A, 0xC2}
+
+// aggregation packs records into an AggregatedRecord message:
+// partition_key_table = 1, records = 3 of Record{partition_key_index = 1, data = 3}
+type aggregation struct {
+	partitionKeys map[string]uint64
+	message       []byte
+	acks          []func()
+	partitionKey  string
+}
+
+func newAggregation() *aggregation {
+	return &aggregation{partitionKeys: make(map[string]uint64)}
+}
+
+func (a *aggregation) len() int {
+	return len(a.acks)
+}
+
+// encodedSize is the size of the record once the given record is added, used to flush before exceeding maxRecordSize
+func (a *aggregation) encodedSize(partitionKey string, data []byte) int {
+	size := len(kplMagic) + len(a.message) + md5.Size
+	index := uint64(len(a.partitionKeys))
+	if existing, ok := a.partitionKeys[partitionKey]; ok {
+		index = existing
+	} else {
+		size += protowire.SizeTag(1) + protowire.SizeBytes(len(partitionKey))
+	}
+	recordSize := protowire.SizeTag(1) + protowire.SizeVarint(index) + protowire.SizeTag(3) + protowire.SizeBytes(len(data))
+	size += protowire.SizeTag(3) + protowire.SizeBytes(recordSize)
+	// the aggregated record is put with the partition key of its first record
+	if a.partitionKey == "" {
+		size += len(partitionKey)
+	} else {
+		size += len(a.partitionKey)
+	}
+	return size
+}
+
+func (a *aggregation) add(partitionKey string, data []byte, ack func()) {
+	index, ok := a.partitionKeys[partitionKey]
+	if !ok {
+		index = uint64(len(a.partitionKeys))
+		a.partitionKeys[partitionKey] = index
+		a.message = protowire.AppendTag(a.message, 1, protowire.BytesType)
+		a.message = protowire.AppendString(a.message, partitionKey)
+	}
+	if a.partitionKey == "" {
+		a.partitionKey = partitionKey
+	}
+
+	recordSize := protowire.SizeTag(1) + protowire.SizeVarint(index) + protowire.SizeTag(3) + protowire.SizeBytes(len(data))
+	a.message = protowire.AppendTag(a.message, 3, protowire.BytesType)
+	a.message = protowire.AppendVarint(a.message, uint64(recordSize))
+	a.message = protowire.AppendTag(a.message, 1, protowire.VarintType)
+	a.message = protowire.AppendVarint(a.message, index)
+	a.message = protowire.AppendTag(a.message, 3, protowire.BytesType)
+	a.message = protowire.AppendBytes(a.message, data)
+	a.acks = append(a.acks, ack)
+}
+
+// entry finishes the aggregation, fields of the same number may be repeated in any order
+// so partition keys and records are interleaved as they were added
+func (a *aggregation) entry() pendingEntry {
+	data := make([]byte, 0, len(kplMagic)+len(a.message)+md5.Size)
+	data = append(data, kplMagic...)
+	data = append(data, a.message...)
+	checksum := md5.Sum(a.message)
+	data = append(data, checksum[:]...)
+	return pendingEntry{
+		putRecordsEntry: putRecordsEntry{Data: data, PartitionKey: a.partitionKey},
+		acks:            a.acks,
+	}
+}
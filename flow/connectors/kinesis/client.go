@@ -0,0 +1,168 @@
+package connkinesis
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// kinesisClient speaks the JSON protocol of the Kinesis Data Streams API for the few operations mirrors need
+type kinesisClient struct {
+	http        *http.Client
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	endpoint    string
+	region      string
+}
+
+type apiError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+	status  int
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("kinesis %s (status %d): %s", e.Type, e.status, e.Message)
+}
+
+// throttling and server errors are worth retrying, the rest like missing streams or permissions are not
+func (e *apiError) retryable() bool {
+	return e.status >= 500 || e.Type == "LimitExceededException" || e.Type == "ProvisionedThroughputExceededException" ||
+		e.Type == "ThrottlingException"
+}
+
+func (c *kinesisClient) call(ctx context.Context, operation string, input any, output any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Kinesis_20131202."+operation)
+
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "kinesis", c.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign kinesis request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &apiError{status: resp.StatusCode}
+		_ = json.Unmarshal(respBody, apiErr)
+		if apiErr.Message == "" {
+			var upper struct {
+				Message string `json:"Message"`
+			}
+			_ = json.Unmarshal(respBody, &upper)
+			apiErr.Message = upper.Message
+		}
+		// types may be qualified like com.amazonaws.kinesis.v20131202#ResourceNotFoundException
+		if idx := strings.LastIndexByte(apiErr.Type, '#'); idx != -1 {
+			apiErr.Type = apiErr.Type[idx+1:]
+		}
+		return apiErr
+	}
+	if output == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, output)
+}
+
+type putRecordsEntry struct {
+	Data            []byte `json:"Data"`
+	PartitionKey    string `json:"PartitionKey"`
+	ExplicitHashKey string `json:"ExplicitHashKey,omitempty"`
+}
+
+type putRecordsResultEntry struct {
+	SequenceNumber string `json:"SequenceNumber"`
+	ShardID        string `json:"ShardId"`
+	ErrorCode      string `json:"ErrorCode"`
+	ErrorMessage   string `json:"ErrorMessage"`
+}
+
+type putRecordsOutput struct {
+	Records           []putRecordsResultEntry `json:"Records"`
+	FailedRecordCount int                     `json:"FailedRecordCount"`
+}
+
+// putRecords returns a result for each entry in order, failed entries have ErrorCode set
+func (c *kinesisClient) putRecords(ctx context.Context, stream string, entries []putRecordsEntry) (putRecordsOutput, error) {
+	var output putRecordsOutput
+	err := c.call(ctx, "PutRecords", struct {
+		StreamName string            `json:"StreamName"`
+		Records    []putRecordsEntry `json:"Records"`
+	}{StreamName: stream, Records: entries}, &output)
+	if err == nil && len(output.Records) != len(entries) {
+		err = fmt.Errorf("kinesis returned %d results for %d records", len(output.Records), len(entries))
+	}
+	return output, err
+}
+
+type shardDescription struct {
+	ShardID      string `json:"ShardId"`
+	HashKeyRange struct {
+		StartingHashKey string `json:"StartingHashKey"`
+		EndingHashKey   string `json:"EndingHashKey"`
+	} `json:"HashKeyRange"`
+}
+
+// listOpenShards returns the shards of stream currently accepting writes
+func (c *kinesisClient) listOpenShards(ctx context.Context, stream string) ([]shardDescription, error) {
+	type shardFilter struct {
+		Type string `json:"Type"`
+	}
+	type listShardsInput struct {
+		StreamName  string       `json:"StreamName,omitempty"`
+		NextToken   string       `json:"NextToken,omitempty"`
+		ShardFilter *shardFilter `json:"ShardFilter,omitempty"`
+	}
+	var shards []shardDescription
+	// stream name and filter may only be sent with the first page
+	input := listShardsInput{StreamName: stream, ShardFilter: &shardFilter{Type: "AT_LATEST"}}
+	for {
+		var output struct {
+			NextToken string             `json:"NextToken"`
+			Shards    []shardDescription `json:"Shards"`
+		}
+		if err := c.call(ctx, "ListShards", input, &output); err != nil {
+			return nil, err
+		}
+		shards = append(shards, output.Shards...)
+		if output.NextToken == "" {
+			return shards, nil
+		}
+		input = listShardsInput{NextToken: output.NextToken}
+	}
+}
+
+func (c *kinesisClient) listStreams(ctx context.Context) error {
+	return c.call(ctx, "ListStreams", struct {
+		Limit int `json:"Limit"`
+	}{Limit: 1}, nil)
+}
@@ -0,0 +1,360 @@
+package connkinesis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	lua "github.com/yuin/gopher-lua"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/pua"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// partition keys are limited to 256 characters
+const maxPartitionKeyLength = 256
+
+type KinesisConnector struct {
+	*metadataStore.PostgresMetadata
+	client    *kinesisClient
+	logger    log.Logger
+	aggregate bool
+}
+
+func NewKinesisConnector(ctx context.Context, config *protos.KinesisConfig) (*KinesisConnector, error) {
+	provider, err := utils.GetAWSCredentialsProvider(ctx, "kinesis", utils.PeerAWSCredentials{
+		Credentials: aws.Credentials{
+			AccessKeyID:     config.GetAccessKeyId(),
+			SecretAccessKey: config.GetSecretAccessKey(),
+		},
+		RoleArn:     config.RoleArn,
+		EndpointUrl: config.Endpoint,
+		Region:      config.GetRegion(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	region := provider.GetRegion()
+	if region == "" {
+		return nil, errors.New("kinesis peer requires a region")
+	}
+	endpoint := provider.GetEndpointURL()
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kinesis.%s.amazonaws.com", region)
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KinesisConnector{
+		PostgresMetadata: pgMetadata,
+		client: &kinesisClient{
+			http:        &http.Client{Timeout: time.Minute},
+			signer:      v4.NewSigner(),
+			credentials: aws.NewCredentialsCache(provider.GetUnderlyingProvider()),
+			endpoint:    endpoint,
+			region:      region,
+		},
+		logger:    logger.LoggerFromCtx(ctx),
+		aggregate: config.Aggregation,
+	}, nil
+}
+
+func (c *KinesisConnector) Close() error {
+	if c != nil {
+		c.client.http.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (c *KinesisConnector) ConnectionActive(ctx context.Context) error {
+	if err := c.client.listStreams(ctx); err != nil {
+		return fmt.Errorf("kinesis connection active check failure: %w", err)
+	}
+	return nil
+}
+
+func (c *KinesisConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *KinesisConnector) ReplayTableSchemaDeltas(_ context.Context, flowJobName string, schemaDeltas []*protos.TableSchemaDelta) error {
+	return nil
+}
+
+type kinesisMessage struct {
+	stream       string
+	partitionKey string
+	data         []byte
+}
+
+type poolResult struct {
+	messages []kinesisMessage
+	lsn      int64
+}
+
+// lvalueToKinesisMessage reads results of scripts like other queues do, topic names the stream
+func lvalueToKinesisMessage(ls *lua.LState, value lua.LValue) (*kinesisMessage, error) {
+	switch v := value.(type) {
+	case lua.LString:
+		return &kinesisMessage{data: shared.UnsafeFastStringToReadOnlyBytes(string(v))}, nil
+	case *lua.LTable:
+		key, err := utils.LVAsStringOrNil(ls, ls.GetField(v, "key"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key, %w", err)
+		}
+		value, err := utils.LVAsReadOnlyBytes(ls, ls.GetField(v, "value"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value, %w", err)
+		}
+		stream, err := utils.LVAsStringOrNil(ls, ls.GetField(v, "topic"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid topic, %w", err)
+		}
+		return &kinesisMessage{stream: stream, partitionKey: key, data: value}, nil
+	case *lua.LNilType:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("script returned invalid value: %s", value)
+	}
+}
+
+// primaryKeyPartitionKey keeps changes to a row on one shard, in order, by partitioning on its primary key.
+// Tables without a primary key spread over shards
+func primaryKeyPartitionKey(record model.Record[model.RecordItems], pkeyCols []string) string {
+	if len(pkeyCols) == 0 {
+		return model.IdempotencyKey(record, nil, 0)
+	}
+	items := record.GetItems()
+	values := make([]string, 0, len(pkeyCols))
+	for _, col := range pkeyCols {
+		if value := items.GetColumnValue(col); value != nil {
+			values = append(values, fmt.Sprint(value.Value()))
+		} else {
+			values = append(values, "")
+		}
+	}
+	key := strings.Join(values, "|")
+	if key == "" || utf8.RuneCountInString(key) > maxPartitionKeyLength {
+		sum := sha256.Sum256([]byte(key))
+		key = hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+func (c *KinesisConnector) createPool(
+	ctx context.Context,
+	env map[string]string,
+	script string,
+	flowJobName string,
+	producers map[string]*streamProducer,
+	progress *utils.DurableOffset,
+	encoder utils.QueueEncoder,
+	queueErr func(error),
+) (*utils.LPool[poolResult], error) {
+	maxSize, err := peerdbenv.PeerDBQueueParallelism(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parallelism: %w", err)
+	}
+
+	return utils.LuaPool(int(maxSize), func() (*lua.LState, error) {
+		ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
+			_ = c.LogFlowInfo(ctx, flowJobName, s)
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("[kinesis] error loading script: %w", err)
+		}
+		if script == "" {
+			onRecord := utils.DefaultOnRecord
+			if encoder != nil {
+				onRecord = utils.QueueEncoderOnRecord(encoder)
+			}
+			ls.Env.RawSetString("onRecord", ls.NewFunction(onRecord))
+		}
+		return ls, nil
+	}, func(result poolResult) {
+		// results are merged in order by a single goroutine, so producers need no locking
+		ack := progress.Track(result.lsn)
+		if len(result.messages) == 0 {
+			ack()
+			return
+		}
+		remaining := atomic.Int32{}
+		remaining.Store(int32(len(result.messages)))
+		ackMessage := func() {
+			if remaining.Add(-1) == 0 {
+				ack()
+			}
+		}
+		for _, message := range result.messages {
+			producer, ok := producers[message.stream]
+			if !ok {
+				var err error
+				producer, err = newStreamProducer(ctx, c.client, c.logger, message.stream, c.aggregate)
+				if err != nil {
+					queueErr(err)
+					return
+				}
+				producers[message.stream] = producer
+			}
+			if err := producer.add(ctx, message.partitionKey, message.data, ackMessage); err != nil {
+				queueErr(err)
+				return
+			}
+		}
+	})
+}
+
+func (c *KinesisConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	if req.CloudEventsMode == protos.CloudEventsMode_CLOUD_EVENTS_MODE_BINARY {
+		return nil, errors.New("[kinesis] records have no headers for binary CloudEvents, use structured mode")
+	}
+
+	numRecords := atomic.Int64{}
+	// shards ack independently, only what was acked without gaps may be recorded as synced
+	progress := utils.NewDurableOffset(req.ConsumedOffset.Load())
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	producers := make(map[string]*streamProducer)
+
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+
+	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, producers, progress,
+		utils.NewQueueEncoder(req.QueueEncoding, req.TableNameSchemaMapping), queueErr)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	flushLoopDone := make(chan struct{})
+	go func() {
+		flushTimeout, err := peerdbenv.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
+		if err != nil {
+			c.logger.Warn("[kinesis] failed to get flush timeout, no periodic flushing", slog.Any("error", err))
+			return
+		}
+		ticker := time.NewTicker(flushTimeout)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-flushLoopDone:
+				return
+			case <-ticker.C:
+				utils.SaveLastSeenOffset(ctx, c.logger, req, progress.Durable(), c.SetLastOffset)
+			}
+		}
+	}()
+
+Loop:
+	for {
+		select {
+		case record, ok := <-req.Records.GetRecords():
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			pool.Run(func(ls *lua.LState) poolResult {
+				lfn := ls.Env.RawGetString("onRecord")
+				fn, ok := lfn.(*lua.LFunction)
+				if !ok {
+					queueErr(fmt.Errorf("script should define `onRecord` as function, not %s", lfn))
+					return poolResult{}
+				}
+
+				ls.Push(fn)
+				ls.Push(pua.LuaRecord.New(ls, record))
+				err := ls.PCall(1, -1, nil)
+				if err != nil {
+					queueErr(fmt.Errorf("script failed: %w", err))
+					return poolResult{}
+				}
+
+				pkeyCols := req.TableNameSchemaMapping[record.GetDestinationTableName()].GetPrimaryKeyColumns()
+				args := ls.GetTop()
+				results := make([]kinesisMessage, 0, args)
+				for i := range args {
+					msg, err := lvalueToKinesisMessage(ls, ls.Get(i-args))
+					if err != nil {
+						queueErr(fmt.Errorf("[kinesis] error creating record: %w", err))
+						return poolResult{}
+					}
+					if msg != nil {
+						if msg.stream == "" {
+							msg.stream = record.GetDestinationTableName()
+						}
+						if msg.partitionKey == "" {
+							msg.partitionKey = primaryKeyPartitionKey(record, pkeyCols)
+						}
+						if req.CloudEventsMode == protos.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED {
+							event := utils.NewCloudEvent(req.FlowJobName, record, pkeyCols, len(results),
+								utils.QueueContentType(req.Script, req.QueueEncoding, msg.data))
+							msg.data, err = event.Structured(msg.data)
+							if err != nil {
+								queueErr(fmt.Errorf("failed to create CloudEvents envelope: %w", err))
+								return poolResult{}
+							}
+						}
+						results = append(results, *msg)
+						record.PopulateCountMap(tableNameRowsMapping)
+					}
+				}
+				ls.SetTop(0)
+				numRecords.Add(1)
+				return poolResult{
+					messages: results,
+					lsn:      record.GetCheckpointID(),
+				}
+			})
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	close(flushLoopDone)
+	if err := pool.Wait(queueCtx); err != nil {
+		utils.SaveLastSeenOffset(ctx, c.logger, req, progress.Durable(), c.SetLastOffset)
+		return nil, fmt.Errorf("[kinesis] pool.Wait error: %w", err)
+	}
+	for _, producer := range producers {
+		if err := producer.flush(queueCtx); err != nil {
+			utils.SaveLastSeenOffset(ctx, c.logger, req, progress.Durable(), c.SetLastOffset)
+			return nil, fmt.Errorf("[kinesis] final flush error: %w", err)
+		}
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[kinesis] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:     req.SyncBatchID,
+		LastSyncedCheckpointID: lastCheckpoint,
+		NumRecordsSynced:       numRecords.Load(),
+		TableNameRowsMapping:   tableNameRowsMapping,
+		TableSchemaDeltas:      req.Records.SchemaDeltas,
+	}, nil
+}
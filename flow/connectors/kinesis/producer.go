@@ -0,0 +1,240 @@
+package connkinesis
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"time"
+
+	"go.temporal.io/sdk/log"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// limits of a single PutRecords request
+	maxPutRecordsEntries = 500
+	maxPutRecordsSize    = 5 << 20
+	// write limits of a shard
+	shardBytesPerSecond   = 1 << 20
+	shardRecordsPerSecond = 1000
+
+	maxPutAttempts = 10
+	maxPutBackoff  = 5 * time.Second
+	// buffered records are put at least this often while records keep coming
+	lingerTime = time.Second
+)
+
+type pendingEntry struct {
+	putRecordsEntry
+	acks []func()
+}
+
+func (e *pendingEntry) size() int {
+	return len(e.Data) + len(e.PartitionKey)
+}
+
+type shard struct {
+	startingHashKey *big.Int
+	bytes           *rate.Limiter
+	records         *rate.Limiter
+	id              string
+}
+
+// streamProducer buffers records for a stream and puts them in batches, pacing writes to the limits of
+// the shard each record is routed to so throttling is the exception instead of the steady state
+type streamProducer struct {
+	client       *kinesisClient
+	logger       log.Logger
+	aggregations map[*shard]*aggregation
+	lastPut      time.Time
+	stream       string
+	shards       []*shard
+	pending      []pendingEntry
+	pendingSize  int
+	aggregate    bool
+}
+
+func newStreamProducer(
+	ctx context.Context,
+	client *kinesisClient,
+	logger log.Logger,
+	stream string,
+	aggregate bool,
+) (*streamProducer, error) {
+	descriptions, err := client.listOpenShards(ctx, stream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shards of stream %s: %w", stream, err)
+	}
+	if len(descriptions) == 0 {
+		return nil, fmt.Errorf("stream %s has no open shards", stream)
+	}
+	shards := make([]*shard, 0, len(descriptions))
+	for _, description := range descriptions {
+		startingHashKey, ok := new(big.Int).SetString(description.HashKeyRange.StartingHashKey, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid starting hash key of shard %s: %s",
+				description.ShardID, description.HashKeyRange.StartingHashKey)
+		}
+		shards = append(shards, &shard{
+			id:              description.ShardID,
+			startingHashKey: startingHashKey,
+			bytes:           rate.NewLimiter(shardBytesPerSecond, maxRecordSize),
+			records:         rate.NewLimiter(shardRecordsPerSecond, shardRecordsPerSecond),
+		})
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i].startingHashKey.Cmp(shards[j].startingHashKey) < 0
+	})
+
+	return &streamProducer{
+		client:       client,
+		logger:       logger,
+		stream:       stream,
+		shards:       shards,
+		aggregations: make(map[*shard]*aggregation),
+		lastPut:      time.Now(),
+		aggregate:    aggregate,
+	}, nil
+}
+
+// shardFor finds the shard Kinesis routes partitionKey to, by the MD5 of the key as a 128 bit integer
+func (p *streamProducer) shardFor(partitionKey string) *shard {
+	sum := md5.Sum([]byte(partitionKey))
+	hashKey := new(big.Int).SetBytes(sum[:])
+	idx := sort.Search(len(p.shards), func(i int) bool {
+		return p.shards[i].startingHashKey.Cmp(hashKey) > 0
+	})
+	return p.shards[max(idx-1, 0)]
+}
+
+func (p *streamProducer) add(ctx context.Context, partitionKey string, data []byte, ack func()) error {
+	if !p.aggregate {
+		return p.enqueue(ctx, pendingEntry{
+			putRecordsEntry: putRecordsEntry{Data: data, PartitionKey: partitionKey},
+			acks:            []func(){ack},
+		})
+	}
+
+	sh := p.shardFor(partitionKey)
+	agg, ok := p.aggregations[sh]
+	if ok && agg.encodedSize(partitionKey, data) > maxRecordSize {
+		delete(p.aggregations, sh)
+		if err := p.enqueue(ctx, agg.entry()); err != nil {
+			return err
+		}
+		ok = false
+	}
+	if !ok {
+		agg = newAggregation()
+		p.aggregations[sh] = agg
+	}
+	if agg.encodedSize(partitionKey, data) > maxRecordSize {
+		return fmt.Errorf("record of %d bytes exceeds the kinesis record limit", len(data))
+	}
+	agg.add(partitionKey, data, ack)
+	if time.Since(p.lastPut) >= lingerTime {
+		return p.flush(ctx)
+	}
+	return nil
+}
+
+func (p *streamProducer) enqueue(ctx context.Context, entry pendingEntry) error {
+	if entry.size() > maxRecordSize {
+		return fmt.Errorf("record of %d bytes exceeds the kinesis record limit", len(entry.Data))
+	}
+	if len(p.pending) >= maxPutRecordsEntries || p.pendingSize+entry.size() > maxPutRecordsSize {
+		if err := p.put(ctx); err != nil {
+			return err
+		}
+	}
+	p.pending = append(p.pending, entry)
+	p.pendingSize += entry.size()
+	if len(p.pending) >= maxPutRecordsEntries || time.Since(p.lastPut) >= lingerTime {
+		return p.put(ctx)
+	}
+	return nil
+}
+
+// flush puts every buffered record, including aggregations still filling up
+func (p *streamProducer) flush(ctx context.Context) error {
+	for sh, agg := range p.aggregations {
+		delete(p.aggregations, sh)
+		if agg.len() == 0 {
+			continue
+		}
+		if err := p.enqueue(ctx, agg.entry()); err != nil {
+			return err
+		}
+	}
+	return p.put(ctx)
+}
+
+// put sends pending entries, retrying the ones Kinesis rejected as throttled or failed internally with backoff
+func (p *streamProducer) put(ctx context.Context) error {
+	p.lastPut = time.Now()
+	entries := p.pending
+	p.pending = nil
+	p.pendingSize = 0
+
+	for attempt := 1; len(entries) > 0; attempt++ {
+		for _, entry := range entries {
+			sh := p.shardFor(entry.PartitionKey)
+			if err := sh.bytes.WaitN(ctx, entry.size()); err != nil {
+				return err
+			}
+			if err := sh.records.Wait(ctx); err != nil {
+				return err
+			}
+		}
+
+		input := make([]putRecordsEntry, 0, len(entries))
+		for _, entry := range entries {
+			input = append(input, entry.putRecordsEntry)
+		}
+		output, err := p.client.putRecords(ctx, p.stream, input)
+		var retry []pendingEntry
+		if err != nil {
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) || !apiErr.retryable() {
+				return fmt.Errorf("[kinesis] failed to put records to %s: %w", p.stream, err)
+			}
+			retry = entries
+		} else {
+			for i, result := range output.Records {
+				switch result.ErrorCode {
+				case "":
+					for _, ack := range entries[i].acks {
+						ack()
+					}
+				case "ProvisionedThroughputExceededException", "InternalFailure":
+					retry = append(retry, entries[i])
+				default:
+					return fmt.Errorf("[kinesis] failed to put record to %s: %s %s", p.stream, result.ErrorCode, result.ErrorMessage)
+				}
+			}
+		}
+		if len(retry) == 0 {
+			return nil
+		}
+		if attempt >= maxPutAttempts {
+			return fmt.Errorf("[kinesis] failed to put %d records to %s after %d attempts", len(retry), p.stream, attempt)
+		}
+
+		delay := min(100*time.Millisecond<<min(attempt-1, 6), maxPutBackoff)
+		p.logger.Warn("[kinesis] records throttled, retrying",
+			slog.String("stream", p.stream), slog.Int("records", len(retry)), slog.Duration("delay", delay))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Cause(ctx)
+		case <-timer.C:
+		}
+		entries = retry
+	}
+	return nil
+}
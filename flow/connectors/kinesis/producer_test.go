@@ -0,0 +1,122 @@
+package connkinesis
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/PeerDB-io/peer-flow/logger"
+)
+
+func TestAggregation(t *testing.T) {
+	agg := newAggregation()
+	agg.add("a", []byte("one"), func() {})
+	agg.add("b", []byte("two"), func() {})
+	predicted := agg.encodedSize("a", []byte("three"))
+	agg.add("a", []byte("three"), func() {})
+	entry := agg.entry()
+	require.Equal(t, predicted, len(entry.Data)+len(entry.PartitionKey))
+	require.Equal(t, "a", entry.PartitionKey)
+	require.Len(t, entry.acks, 3)
+
+	require.Equal(t, kplMagic, entry.Data[:len(kplMagic)])
+	message := entry.Data[len(kplMagic) : len(entry.Data)-md5.Size]
+	checksum := md5.Sum(message)
+	require.Equal(t, checksum[:], entry.Data[len(entry.Data)-md5.Size:])
+
+	var keys []string
+	var records [][]byte
+	for len(message) > 0 {
+		num, _, n := protowire.ConsumeTag(message)
+		require.Positive(t, n)
+		message = message[n:]
+		value, n := protowire.ConsumeBytes(message)
+		require.Positive(t, n)
+		message = message[n:]
+		if num == 1 {
+			keys = append(keys, string(value))
+		} else {
+			records = append(records, value)
+		}
+	}
+	require.Equal(t, []string{"a", "b"}, keys)
+	require.Len(t, records, 3)
+	// the third record refers to the first partition key
+	index, n := protowire.ConsumeVarint(records[2][1:])
+	require.Positive(t, n)
+	require.Equal(t, uint64(0), index)
+}
+
+func testShard(start int64) *shard {
+	return &shard{
+		startingHashKey: big.NewInt(start),
+		bytes:           rate.NewLimiter(rate.Inf, maxRecordSize),
+		records:         rate.NewLimiter(rate.Inf, 1),
+	}
+}
+
+func TestPutRetriesThrottledRecords(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Kinesis_20131202.PutRecords", r.Header.Get("X-Amz-Target"))
+		require.Contains(t, r.Header.Get("Authorization"), "AWS4-HMAC-SHA256")
+		var input struct {
+			Records []putRecordsEntry
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		results := make([]putRecordsResultEntry, len(input.Records))
+		if calls.Add(1) == 1 {
+			results[1].ErrorCode = "ProvisionedThroughputExceededException"
+		} else {
+			require.Len(t, input.Records, 1)
+			require.Equal(t, "b", input.Records[0].PartitionKey)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(putRecordsOutput{Records: results}))
+	}))
+	defer server.Close()
+
+	producer := &streamProducer{
+		client: &kinesisClient{
+			http:        server.Client(),
+			signer:      v4.NewSigner(),
+			credentials: aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider("key", "secret", "")),
+			endpoint:    server.URL,
+			region:      "us-east-1",
+		},
+		logger:       logger.LoggerFromCtx(context.Background()),
+		stream:       "users",
+		shards:       []*shard{testShard(0)},
+		aggregations: make(map[*shard]*aggregation),
+		lastPut:      time.Now(),
+	}
+
+	var acked atomic.Int32
+	ack := func() { acked.Add(1) }
+	require.NoError(t, producer.add(context.Background(), "a", []byte("one"), ack))
+	require.NoError(t, producer.add(context.Background(), "b", []byte("two"), ack))
+	require.NoError(t, producer.flush(context.Background()))
+	require.Equal(t, int32(2), calls.Load())
+	require.Equal(t, int32(2), acked.Load())
+}
+
+func TestShardFor(t *testing.T) {
+	producer := &streamProducer{shards: []*shard{testShard(0), testShard(1)}}
+	producer.shards[1].startingHashKey = new(big.Int).Lsh(big.NewInt(1), 127)
+	// md5 of "a" starts with 0x0c, of "c" with 0x4a, of "b" with 0x92
+	require.Same(t, producer.shards[0], producer.shardFor("a"))
+	require.Same(t, producer.shards[0], producer.shardFor("c"))
+	require.Same(t, producer.shards[1], producer.shardFor("b"))
+}
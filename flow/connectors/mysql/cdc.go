@@ -0,0 +1,511 @@
+package connmysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/alerting"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/otel_metrics/peerdb_gauges"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// binlogPosition is where in which binlog file an event ends
+type binlogPosition struct {
+	file string
+	pos  uint32
+}
+
+// binlogFileSequence is the number binlog files are suffixed with, like 123 of mysql-bin.000123
+func binlogFileSequence(file string) (uint32, error) {
+	dot := strings.LastIndexByte(file, '.')
+	if dot < 0 {
+		return 0, fmt.Errorf("binlog file %s has no sequence number", file)
+	}
+	sequence, err := strconv.ParseUint(file[dot+1:], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("binlog file %s has no sequence number: %w", file, err)
+	}
+	return uint32(sequence), nil
+}
+
+// offset packs the sequence number of the file in the high half and the position in the low half,
+// so offsets grow with the binlog
+func (p binlogPosition) offset() (int64, error) {
+	sequence, err := binlogFileSequence(p.file)
+	if err != nil {
+		return 0, err
+	}
+	return int64(sequence)<<32 | int64(p.pos), nil
+}
+
+// positionFromOffset recovers the file of an offset from the name of a file of the same binlog
+func positionFromOffset(offset int64, binlogFile string) (binlogPosition, error) {
+	dot := strings.LastIndexByte(binlogFile, '.')
+	if dot < 0 {
+		return binlogPosition{}, fmt.Errorf("binlog file %s has no sequence number", binlogFile)
+	}
+	return binlogPosition{
+		file: fmt.Sprintf("%s.%06d", binlogFile[:dot], uint32(offset>>32)),
+		pos:  uint32(offset),
+	}, nil
+}
+
+// PinStartOffset pins where replication of a mirror without a checkpoint starts,
+// before tables are snapshotted so changes made meanwhile are replicated after
+func (c *MySqlConnector) PinStartOffset(ctx context.Context, catalogPool *pgxpool.Pool, flowJobName string) (int64, error) {
+	position, err := c.binlogPosition(ctx)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := position.offset()
+	if err != nil {
+		return 0, err
+	}
+	var startOffset int64
+	if err := catalogPool.QueryRow(ctx,
+		`INSERT INTO mysql_start_offset(flow_name, start_offset) VALUES($1, $2)
+		ON CONFLICT(flow_name) DO UPDATE SET start_offset = mysql_start_offset.start_offset RETURNING start_offset`,
+		flowJobName, offset,
+	).Scan(&startOffset); err != nil {
+		return 0, fmt.Errorf("failed to pin start offset of %s: %w", flowJobName, err)
+	}
+	return startOffset, nil
+}
+
+func (c *MySqlConnector) advanceStartOffset(
+	ctx context.Context,
+	catalogPool *pgxpool.Pool,
+	flowJobName string,
+	offset int64,
+) error {
+	if _, err := catalogPool.Exec(ctx,
+		"UPDATE mysql_start_offset SET start_offset = GREATEST(start_offset, $2), updated_at = now() WHERE flow_name = $1",
+		flowJobName, offset,
+	); err != nil {
+		return fmt.Errorf("failed to advance start offset of %s: %w", flowJobName, err)
+	}
+	return nil
+}
+
+func (c *MySqlConnector) binlogSyncer(flowJobName string) *replication.BinlogSyncer {
+	return replication.NewBinlogSyncer(replication.BinlogSyncerConfig{
+		ServerID:                c.serverID(flowJobName),
+		Flavor:                  c.flavor(),
+		Host:                    c.config.Host,
+		Port:                    uint16(port(c.config)),
+		User:                    c.config.User,
+		Password:                c.config.Password,
+		TLSConfig:               tlsConfig(c.config),
+		UseDecimal:              true,
+		TimestampStringLocation: time.UTC,
+		HeartbeatPeriod:         30 * time.Second,
+		ReadTimeout:             90 * time.Second,
+		MaxReconnectAttempts:    5,
+	})
+}
+
+// PullRecords streams changes of transactions committed after the last offset,
+// stopping at the first transaction boundary after the batch is full or the idle timeout passed
+func (c *MySqlConnector) PullRecords(
+	ctx context.Context,
+	catalogPool *pgxpool.Pool,
+	req *model.PullRecordsRequest[model.RecordItems],
+) error {
+	records := req.RecordStream
+	records.UpdateLatestCheckpoint(req.LastOffset)
+	var numRecords uint32
+	defer func() {
+		if numRecords == 0 {
+			records.SignalAsEmpty()
+		}
+		records.Close()
+		c.logger.Info(fmt.Sprintf("[finished] PullRecords streamed %d records", numRecords))
+	}()
+
+	checkpoint := req.LastOffset
+	if checkpoint == 0 {
+		var err error
+		if checkpoint, err = c.PinStartOffset(ctx, catalogPool, req.FlowJobName); err != nil {
+			return err
+		}
+	}
+	current, err := c.binlogPosition(ctx)
+	if err != nil {
+		return err
+	}
+	position, err := positionFromOffset(checkpoint, current.file)
+	if err != nil {
+		return err
+	}
+
+	syncer := c.binlogSyncer(req.FlowJobName)
+	defer syncer.Close()
+	streamer, err := syncer.StartSync(mysql.Position{Name: position.file, Pos: position.pos})
+	if err != nil {
+		return fmt.Errorf("failed to start binlog replication at %s:%d: %w", position.file, position.pos, err)
+	}
+	c.logger.Info("replicating binlog", slog.String("file", position.file), slog.Uint64("pos", uint64(position.pos)))
+
+	// schemas of tables with columns added while pulling
+	schemas := make(map[string]*protos.TableSchema, len(req.TableNameSchemaMapping))
+	for table, schema := range req.TableNameSchemaMapping {
+		schemas[table] = schema
+	}
+	deadline := time.Now().Add(req.IdleTimeout)
+	inTx := false
+	for {
+		// a transaction is always read to its end, so the batch ends on a boundary
+		eventCtx, cancel := ctx, context.CancelFunc(func() {})
+		if !inTx {
+			eventCtx, cancel = context.WithDeadline(ctx, deadline)
+		}
+		event, err := streamer.GetEvent(eventCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				c.logger.Info("idle timeout reached", slog.Int64("offset", checkpoint))
+				if numRecords == 0 && req.LastOffset == 0 {
+					return c.advanceStartOffset(ctx, catalogPool, req.FlowJobName, checkpoint)
+				}
+				return nil
+			}
+			return fmt.Errorf("failed to read binlog: %w", err)
+		}
+
+		if rotate, ok := event.Event.(*replication.RotateEvent); ok {
+			position = binlogPosition{file: string(rotate.NextLogName), pos: uint32(rotate.Position)}
+			continue
+		}
+		if event.Header.LogPos > 0 {
+			position.pos = event.Header.LogPos
+		}
+
+		boundary := false
+		switch ev := event.Event.(type) {
+		case *replication.GTIDEvent, *replication.MariadbGTIDEvent:
+			inTx = true
+		case *replication.QueryEvent:
+			// DDL commits implicitly, non-transactional tables commit with a COMMIT statement
+			if string(ev.Query) == "BEGIN" {
+				inTx = true
+			} else {
+				boundary = true
+			}
+		case *replication.XIDEvent:
+			boundary = true
+		case *replication.RowsEvent:
+			inTx = true
+			offset, err := position.offset()
+			if err != nil {
+				return err
+			}
+			baseRecord := model.BaseRecord{
+				CheckpointID:   offset,
+				CommitTimeNano: time.Unix(int64(event.Header.Timestamp), 0).UnixNano(),
+			}
+			n, err := c.processRowsEvent(ctx, req, schemas, event.Header.EventType, ev, baseRecord)
+			if err != nil {
+				return fmt.Errorf("failed to process rows event at %s:%d: %w", position.file, position.pos, err)
+			}
+			if n > 0 && numRecords == 0 {
+				records.SignalAsNotEmpty()
+			}
+			numRecords += n
+		}
+
+		if boundary {
+			inTx = false
+			if checkpoint, err = position.offset(); err != nil {
+				return err
+			}
+			records.UpdateLatestCheckpoint(checkpoint)
+			if numRecords >= req.MaxBatchSize || time.Now().After(deadline) {
+				return nil
+			}
+		}
+	}
+}
+
+// binlogColumns describes columns of the table of a rows event,
+// the names of which binlog only has with binlog_row_metadata = FULL
+func binlogColumns(table *replication.TableMapEvent) ([]binlogColumn, error) {
+	names := table.ColumnNameString()
+	if len(names) != len(table.ColumnType) {
+		return nil, fmt.Errorf("binlog has no column names of %s.%s, binlog_row_metadata needs to be FULL",
+			table.Schema, table.Table)
+	}
+	unsigned := table.UnsignedMap()
+	enumValues := table.EnumStrValueMap()
+	setValues := table.SetStrValueMap()
+	columns := make([]binlogColumn, len(names))
+	for i, name := range names {
+		columns[i] = binlogColumn{
+			name:       name,
+			columnType: table.ColumnType[i],
+			unsigned:   unsigned[i],
+			set:        table.IsSetColumn(i),
+			values:     enumValues[i],
+		}
+		if columns[i].set {
+			columns[i].values = setValues[i]
+		}
+	}
+	return columns, nil
+}
+
+// processRowsEvent adds records of the rows of an event, returning how many were added,
+// nothing for tables not in this mirror
+func (c *MySqlConnector) processRowsEvent(
+	ctx context.Context,
+	req *model.PullRecordsRequest[model.RecordItems],
+	schemas map[string]*protos.TableSchema,
+	eventType replication.EventType,
+	ev *replication.RowsEvent,
+	baseRecord model.BaseRecord,
+) (uint32, error) {
+	sourceTable := string(ev.Table.Schema) + "." + string(ev.Table.Table)
+	destination, ok := req.TableNameMapping[sourceTable]
+	if !ok {
+		return 0, nil
+	}
+	schema, ok := schemas[destination.Name]
+	if !ok {
+		return 0, fmt.Errorf("no schema for destination table %s", destination.Name)
+	}
+	columns, err := binlogColumns(ev.Table)
+	if err != nil {
+		return 0, err
+	}
+	if schema, err = c.addColumns(ctx, req, sourceTable, destination, schema, columns); err != nil {
+		return 0, err
+	}
+	schemas[destination.Name] = schema
+
+	items := func(row []any) (model.RecordItems, error) {
+		return rowItems(schema, destination, req.SourceIdentifier, columns, row)
+	}
+	var n uint32
+	switch eventType {
+	case replication.WRITE_ROWS_EVENTv0, replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2,
+		replication.MARIADB_WRITE_ROWS_COMPRESSED_EVENT_V1:
+		for _, row := range ev.Rows {
+			recordItems, err := items(row)
+			if err != nil {
+				return n, err
+			}
+			if err := req.RecordStream.AddRecord(ctx, &model.InsertRecord[model.RecordItems]{
+				BaseRecord:           baseRecord,
+				Items:                recordItems,
+				SourceTableName:      sourceTable,
+				DestinationTableName: destination.Name,
+				CommitID:             baseRecord.CheckpointID,
+			}); err != nil {
+				return n, err
+			}
+			n += 1
+		}
+	case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2,
+		replication.MARIADB_UPDATE_ROWS_COMPRESSED_EVENT_V1:
+		// rows alternate between before and after images
+		for i := 0; i+1 < len(ev.Rows); i += 2 {
+			oldItems, err := items(ev.Rows[i])
+			if err != nil {
+				return n, err
+			}
+			newItems, err := items(ev.Rows[i+1])
+			if err != nil {
+				return n, err
+			}
+			if err := req.RecordStream.AddRecord(ctx, &model.UpdateRecord[model.RecordItems]{
+				BaseRecord:            baseRecord,
+				OldItems:              oldItems,
+				NewItems:              newItems,
+				UnchangedToastColumns: make(map[string]struct{}),
+				SourceTableName:       sourceTable,
+				DestinationTableName:  destination.Name,
+			}); err != nil {
+				return n, err
+			}
+			n += 1
+		}
+	case replication.DELETE_ROWS_EVENTv0, replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2,
+		replication.MARIADB_DELETE_ROWS_COMPRESSED_EVENT_V1:
+		for _, row := range ev.Rows {
+			recordItems, err := items(row)
+			if err != nil {
+				return n, err
+			}
+			if err := req.RecordStream.AddRecord(ctx, &model.DeleteRecord[model.RecordItems]{
+				BaseRecord:            baseRecord,
+				Items:                 recordItems,
+				UnchangedToastColumns: make(map[string]struct{}),
+				SourceTableName:       sourceTable,
+				DestinationTableName:  destination.Name,
+			}); err != nil {
+				return n, err
+			}
+			n += 1
+		}
+	default:
+		return 0, fmt.Errorf("unsupported rows event %s, binlog_row_value_options needs to be empty", eventType)
+	}
+	return n, nil
+}
+
+// addColumns detects columns added to a source table from the columns of its rows events,
+// adding a schema delta typed like the column in the current schema of the table
+func (c *MySqlConnector) addColumns(
+	ctx context.Context,
+	req *model.PullRecordsRequest[model.RecordItems],
+	sourceTable string,
+	destination model.NameAndExclude,
+	schema *protos.TableSchema,
+	columns []binlogColumn,
+) (*protos.TableSchema, error) {
+	var added []string
+	for _, column := range columns {
+		if _, excluded := destination.Exclude[column.name]; excluded {
+			continue
+		}
+		if !slices.ContainsFunc(schema.Columns, func(fd *protos.FieldDescription) bool {
+			return fd.Name == column.name
+		}) {
+			added = append(added, column.name)
+		}
+	}
+	if len(added) == 0 {
+		return schema, nil
+	}
+
+	currentSchema, err := c.getTableSchemaForTable(ctx, sourceTable, schema.NullableEnabled)
+	if err != nil {
+		return nil, err
+	}
+	delta := &protos.TableSchemaDelta{
+		SrcTableName:    sourceTable,
+		DstTableName:    destination.Name,
+		System:          schema.System,
+		NullableEnabled: schema.NullableEnabled,
+	}
+	for _, column := range currentSchema.Columns {
+		if slices.Contains(added, column.Name) {
+			delta.AddedColumns = append(delta.AddedColumns, column)
+		}
+	}
+	if len(delta.AddedColumns) == 0 {
+		// dropped again since, values of these columns have nowhere to go
+		c.logger.Warn("columns in binlog are no longer in table, ignoring them",
+			slog.String("table", sourceTable), slog.Any("columns", added))
+		return schema, nil
+	}
+	c.logger.Info(fmt.Sprintf("Detected schema change for table %s, addedColumns: %v",
+		sourceTable, delta.AddedColumns))
+	req.RecordStream.AddSchemaDelta(req.TableNameMapping, delta)
+
+	updated := proto.Clone(schema).(*protos.TableSchema)
+	updated.Columns = append(updated.Columns, delta.AddedColumns...)
+	return updated, nil
+}
+
+// rowItems converts a binlog row, columns of the schema dropped from the table are null
+func rowItems(
+	schema *protos.TableSchema,
+	destination model.NameAndExclude,
+	sourceIdentifier string,
+	columns []binlogColumn,
+	row []any,
+) (model.RecordItems, error) {
+	items := model.NewRecordItems(len(schema.Columns))
+	for _, column := range schema.Columns {
+		if column.Name == model.SourceIdentifierColName && sourceIdentifier != "" {
+			continue
+		}
+		if _, excluded := destination.Exclude[column.Name]; excluded {
+			continue
+		}
+		kind := qvalue.QValueKind(column.Type)
+		i := slices.IndexFunc(columns, func(bc binlogColumn) bool {
+			return bc.name == column.Name
+		})
+		if i < 0 || i >= len(row) {
+			items.AddColumn(column.Name, qvalue.QValueNull(kind))
+			continue
+		}
+		qv, err := binlogQValue(kind, row[i], columns[i])
+		if err != nil {
+			return model.RecordItems{}, fmt.Errorf("failed to convert column %s: %w", column.Name, err)
+		}
+		items.AddColumn(column.Name, qv)
+	}
+	if sourceIdentifier != "" {
+		items.AddColumn(model.SourceIdentifierColName, qvalue.QValueString{Val: sourceIdentifier})
+	}
+	return items, nil
+}
+
+// ExportTxSnapshot exports nothing, MySQL snapshots can't be shared between sessions
+// so tables of initial snapshot only mirrors are each read in one consistent query
+func (c *MySqlConnector) ExportTxSnapshot(context.Context) (*protos.ExportTxSnapshotOutput, any, error) {
+	return &protos.ExportTxSnapshotOutput{}, nil, nil
+}
+
+func (c *MySqlConnector) FinishExport(any) error {
+	return nil
+}
+
+func (c *MySqlConnector) SetupReplConn(context.Context) error {
+	return nil
+}
+
+func (c *MySqlConnector) ReplPing(context.Context) error {
+	return nil
+}
+
+func (c *MySqlConnector) UpdateReplStateLastOffset(int64) {
+}
+
+// PullFlowCleanup forgets the offset replication of a mirror without a checkpoint starts from,
+// replicas leave nothing to drop on the source
+func (c *MySqlConnector) PullFlowCleanup(ctx context.Context, jobName string) error {
+	catalogPool, err := peerdbenv.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create catalog connection pool: %w", err)
+	}
+	if _, err := catalogPool.Exec(ctx, "DELETE FROM mysql_start_offset WHERE flow_name = $1", jobName); err != nil {
+		return fmt.Errorf("failed to delete start offset of %s: %w", jobName, err)
+	}
+	return nil
+}
+
+func (c *MySqlConnector) HandleSlotInfo(
+	context.Context,
+	*alerting.Alerter,
+	*pgxpool.Pool,
+	string,
+	string,
+	peerdb_gauges.SlotMetricGauges,
+) error {
+	return nil
+}
+
+func (c *MySqlConnector) GetSlotInfo(context.Context, string) ([]*protos.SlotInfo, error) {
+	return nil, nil
+}
+
+func (c *MySqlConnector) AddTablesToPublication(context.Context, *protos.AddTablesToPublicationInput) error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package connmysql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestBinlogOffset(t *testing.T) {
+	offset, err := binlogPosition{file: "mysql-bin.000123", pos: 4567}.offset()
+	require.NoError(t, err)
+	require.Equal(t, int64(123)<<32|4567, offset)
+
+	next, err := binlogPosition{file: "mysql-bin.000124", pos: 4}.offset()
+	require.NoError(t, err)
+	require.Greater(t, next, offset)
+
+	position, err := positionFromOffset(offset, "mysql-bin.000130")
+	require.NoError(t, err)
+	require.Equal(t, binlogPosition{file: "mysql-bin.000123", pos: 4567}, position)
+
+	_, err = binlogPosition{file: "binlog", pos: 4}.offset()
+	require.Error(t, err)
+}
+
+func TestMySqlKind(t *testing.T) {
+	null := sql.NullInt64{}
+	kind, _ := mysqlKind("int", false, null, null)
+	require.Equal(t, qvalue.QValueKindInt32, kind)
+	kind, _ = mysqlKind("int", true, null, null)
+	require.Equal(t, qvalue.QValueKindInt64, kind)
+	kind, typmod := mysqlKind("bigint", true, null, null)
+	require.Equal(t, qvalue.QValueKindNumeric, kind)
+	require.Equal(t, datatypes.MakeNumericTypmod(20, 0), typmod)
+	kind, typmod = mysqlKind("decimal", false, sql.NullInt64{Int64: 10, Valid: true}, sql.NullInt64{Int64: 2, Valid: true})
+	require.Equal(t, qvalue.QValueKindNumeric, kind)
+	require.Equal(t, datatypes.MakeNumericTypmod(10, 2), typmod)
+	kind, _ = mysqlKind("timestamp", false, null, null)
+	require.Equal(t, qvalue.QValueKindTimestampTZ, kind)
+	kind, _ = mysqlKind("mediumblob", false, null, null)
+	require.Equal(t, qvalue.QValueKindBytes, kind)
+	kind, _ = mysqlKind("enum", false, null, null)
+	require.Equal(t, qvalue.QValueKindString, kind)
+}
+
+func TestBinlogQValue(t *testing.T) {
+	qv, err := binlogQValue(qvalue.QValueKindInt16, int8(-1), binlogColumn{unsigned: true})
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueInt16{Val: 255}, qv)
+
+	qv, err = binlogQValue(qvalue.QValueKindInt32, int32(-1), binlogColumn{unsigned: true, columnType: mysql.MYSQL_TYPE_INT24})
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueInt32{Val: 1<<24 - 1}, qv)
+
+	qv, err = binlogQValue(qvalue.QValueKindNumeric, int64(-1), binlogColumn{unsigned: true})
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueNumeric{Val: decimal.NewFromUint64(1<<64 - 1)}, qv)
+
+	qv, err = binlogQValue(qvalue.QValueKindString, int64(2), binlogColumn{values: []string{"small", "large"}})
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueString{Val: "large"}, qv)
+
+	qv, err = binlogQValue(qvalue.QValueKindString, int64(5), binlogColumn{set: true, values: []string{"a", "b", "c"}})
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueString{Val: "a,c"}, qv)
+
+	qv, err = binlogQValue(qvalue.QValueKindTimestamp, "2024-03-01 12:30:45.250000", binlogColumn{})
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueTimestamp{Val: time.Date(2024, 3, 1, 12, 30, 45, 250000000, time.UTC)}, qv)
+
+	// zero dates have no time to map to
+	qv, err = binlogQValue(qvalue.QValueKindTimestamp, "0000-00-00 00:00:00", binlogColumn{})
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueNull(qvalue.QValueKindTimestamp), qv)
+
+	qv, err = binlogQValue(qvalue.QValueKindJSON, []byte{}, binlogColumn{})
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueJSON{Val: "null"}, qv)
+}
+
+func TestTextQValue(t *testing.T) {
+	qv, err := textQValue(qvalue.QValueKindInt64, "bit", []byte{0x01, 0x02})
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueInt64{Val: 258}, qv)
+
+	qv, err = textQValue(qvalue.QValueKindDate, "date", []byte("2024-03-01"))
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueDate{Val: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, qv)
+
+	qv, err = textQValue(qvalue.QValueKindString, "varchar", nil)
+	require.NoError(t, err)
+	require.Equal(t, qvalue.QValueNull(qvalue.QValueKindString), qv)
+}
@@ -1,15 +1,206 @@
-// stub to bypass validation
+package connmysql
 
-package mysql
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"strconv"
+	"strings"
 
-import "context"
+	"github.com/go-sql-driver/mysql"
+	"go.temporal.io/sdk/log"
 
-type MySqlConnector struct{}
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+)
 
-func (MySqlConnector) Close() error {
+// MySqlConnector is a CDC source replicating row based binlog by file and position.
+// Offsets pack the sequence number of the binlog file with the position in it,
+// failing over to a server with different binlog files is not supported.
+// Tables are identified as database.table, the schema of a table being its database.
+type MySqlConnector struct {
+	db     *sql.DB
+	config *protos.MySqlConfig
+	logger log.Logger
+}
+
+func NewMySqlConnector(ctx context.Context, config *protos.MySqlConfig) (*MySqlConnector, error) {
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = net.JoinHostPort(config.Host, strconv.FormatUint(uint64(port(config)), 10))
+	mysqlConfig.User = config.User
+	mysqlConfig.Passwd = config.Password
+	mysqlConfig.DBName = config.Database
+	mysqlConfig.TLS = tlsConfig(config)
+	// arguments are interpolated so every query returns text, which converts the same whatever the query
+	mysqlConfig.InterpolateParams = true
+	// queries shared with postgres sources quote identifiers with double quotes,
+	// timestamps are read in UTC like binlog decodes them
+	mysqlConfig.Params = map[string]string{
+		"sql_mode":  "CONCAT(@@sql_mode,',ANSI_QUOTES')",
+		"time_zone": "'+00:00'",
+	}
+	connector, err := mysql.NewConnector(mysqlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mysql connector: %w", err)
+	}
+	db := sql.OpenDB(connector)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to mysql: %w", err)
+	}
+
+	return &MySqlConnector{
+		db:     db,
+		config: config,
+		logger: logger.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func port(config *protos.MySqlConfig) uint32 {
+	if config.Port == 0 {
+		return 3306
+	}
+	return config.Port
+}
+
+func tlsConfig(config *protos.MySqlConfig) *tls.Config {
+	if config.DisableTls {
+		return nil
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12, ServerName: config.Host}
+}
+
+func (c *MySqlConnector) Close() error {
+	if c != nil && c.db != nil {
+		return c.db.Close()
+	}
 	return nil
 }
 
-func (MySqlConnector) ConnectionActive(context.Context) error {
+func (c *MySqlConnector) ConnectionActive(ctx context.Context) error {
+	if err := c.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping mysql: %w", err)
+	}
 	return nil
 }
+
+// conn is a session running the setup statements of the peer
+func (c *MySqlConnector) conn(ctx context.Context) (*sql.Conn, error) {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire mysql connection: %w", err)
+	}
+	for _, stmt := range c.config.Setup {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to run setup statement %q: %w", stmt, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *MySqlConnector) flavor() string {
+	if c.config.Flavor == protos.MySqlFlavor_MYSQL_FLAVOR_MARIADB {
+		return "mariadb"
+	}
+	return "mysql"
+}
+
+// serverID is the server id of the mirror as a replica, one per mirror unless configured.
+// The high bit keeps derived ids away from the small ids replicas are usually numbered with.
+func (c *MySqlConnector) serverID(flowJobName string) uint32 {
+	if c.config.ServerId != 0 {
+		return c.config.ServerId
+	}
+	return crc32.ChecksumIEEE([]byte(flowJobName)) | 1<<31
+}
+
+// ValidateCheck checks the server writes binlog with entire rows and column names,
+// and that the user may read it
+func (c *MySqlConnector) ValidateCheck(ctx context.Context) error {
+	if err := c.checkBinlogSettings(ctx); err != nil {
+		return err
+	}
+	if _, err := c.binlogPosition(ctx); err != nil {
+		return fmt.Errorf("%w, the user needs the REPLICATION CLIENT and REPLICATION SLAVE privileges", err)
+	}
+	return nil
+}
+
+func (c *MySqlConnector) checkBinlogSettings(ctx context.Context) error {
+	expected := []struct {
+		variable string
+		value    string
+	}{
+		{"log_bin", "ON"},
+		{"binlog_format", "ROW"},
+		{"binlog_row_image", "FULL"},
+		{"binlog_row_metadata", "FULL"},
+	}
+	for _, setting := range expected {
+		var value string
+		if err := c.db.QueryRowContext(ctx, "SELECT @@"+setting.variable).Scan(&value); err != nil {
+			return fmt.Errorf("failed to check %s of mysql: %w", setting.variable, err)
+		}
+		if value == "1" {
+			value = "ON"
+		}
+		if !strings.EqualFold(value, setting.value) {
+			return fmt.Errorf("mysql needs %s set to %s for replication, it is %s", setting.variable, setting.value, value)
+		}
+	}
+	return nil
+}
+
+// binlogPosition is the end of the binlog written so far
+func (c *MySqlConnector) binlogPosition(ctx context.Context) (binlogPosition, error) {
+	rows, err := c.db.QueryContext(ctx, "SHOW BINARY LOG STATUS")
+	if err != nil {
+		// renamed in MySQL 8.2, MariaDB only knows the old name
+		rows, err = c.db.QueryContext(ctx, "SHOW MASTER STATUS")
+	}
+	if err != nil {
+		return binlogPosition{}, fmt.Errorf("failed to get binlog position: %w", err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return binlogPosition{}, fmt.Errorf("failed to get binlog position: %w", err)
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return binlogPosition{}, fmt.Errorf("failed to get binlog position: %w", err)
+		}
+		return binlogPosition{}, errors.New("failed to get binlog position, binary logging is disabled")
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return binlogPosition{}, fmt.Errorf("failed to scan binlog position: %w", err)
+	}
+	var position binlogPosition
+	for i, column := range columns {
+		switch column {
+		case "File":
+			position.file = string(values[i])
+		case "Position":
+			pos, err := strconv.ParseUint(string(values[i]), 10, 32)
+			if err != nil {
+				return binlogPosition{}, fmt.Errorf("failed to parse binlog position %q: %w", values[i], err)
+			}
+			position.pos = uint32(pos)
+		}
+	}
+	return position, rows.Err()
+}
@@ -0,0 +1,240 @@
+package connmysql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	utils "github.com/PeerDB-io/peer-flow/connectors/utils/partition"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+// GetQRepPartitions splits the watermark table into ranges of the watermark column,
+// initial snapshots partition nothing and read each table whole
+func (c *MySqlConnector) GetQRepPartitions(
+	ctx context.Context, config *protos.QRepConfig, last *protos.QRepPartition,
+) ([]*protos.QRepPartition, error) {
+	if config.WatermarkTable == "" || config.WatermarkColumn == "" {
+		c.logger.Info("watermark table or column is empty, doing full table refresh")
+		return []*protos.QRepPartition{
+			{
+				PartitionId:        uuid.New().String(),
+				FullTablePartition: true,
+			},
+		}, nil
+	}
+	if config.NumRowsPerPartition <= 0 {
+		return nil, errors.New("num rows per partition must be greater than 0 for mysql")
+	}
+
+	quotedWatermarkColumn := `"` + strings.ReplaceAll(config.WatermarkColumn, `"`, `""`) + `"`
+	whereClause := ""
+	var args []any
+	if last != nil && last.Range != nil {
+		whereClause = fmt.Sprintf("WHERE %s > ?", quotedWatermarkColumn)
+		switch lastRange := last.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			args = append(args, lastRange.IntRange.End)
+		case *protos.PartitionRange_TimestampRange:
+			args = append(args, lastRange.TimestampRange.End.AsTime())
+		default:
+			return nil, fmt.Errorf("unsupported partition range %T for mysql", lastRange)
+		}
+	}
+
+	var totalRows int64
+	if err := c.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT COUNT(*) FROM %s %s", config.WatermarkTable, whereClause), args...,
+	).Scan(&totalRows); err != nil {
+		return nil, fmt.Errorf("failed to query for total rows: %w", err)
+	}
+	if totalRows == 0 {
+		c.logger.Warn("no records to replicate, returning")
+		return make([]*protos.QRepPartition, 0), nil
+	}
+
+	numRowsPerPartition := int64(config.NumRowsPerPartition)
+	numPartitions := (totalRows + numRowsPerPartition - 1) / numRowsPerPartition
+	c.logger.Info(fmt.Sprintf("total rows: %d, num partitions: %d, num rows per partition: %d",
+		totalRows, numPartitions, numRowsPerPartition))
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(
+		`SELECT bucket_v, MIN(v_from) AS start_v, MAX(v_from) AS end_v
+		FROM (
+			SELECT NTILE(%d) OVER (ORDER BY %s) AS bucket_v, %s AS v_from FROM %s %s
+		) AS subquery
+		GROUP BY bucket_v
+		ORDER BY start_v`,
+		numPartitions, quotedWatermarkColumn, quotedWatermarkColumn, config.WatermarkTable, whereClause,
+	), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for partitions: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types of partitions: %w", err)
+	}
+	watermarkType := strings.ToLower(columnTypes[1].DatabaseTypeName())
+	partitionHelper := utils.NewPartitionHelper()
+	for rows.Next() {
+		var bucket int64
+		var rawStart, rawEnd sql.RawBytes
+		if err := rows.Scan(&bucket, &rawStart, &rawEnd); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		start, err := watermarkValue(watermarkType, rawStart)
+		if err != nil {
+			return nil, err
+		}
+		end, err := watermarkValue(watermarkType, rawEnd)
+		if err != nil {
+			return nil, err
+		}
+		if err := partitionHelper.AddPartition(start, end); err != nil {
+			return nil, fmt.Errorf("failed to add partition: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+	return partitionHelper.GetPartitions(), nil
+}
+
+// watermarkValue parses a bound of a partition, partitions are ranges of integers or timestamps
+func watermarkValue(dataType string, raw []byte) (any, error) {
+	dataType = strings.TrimPrefix(dataType, "unsigned ")
+	switch dataType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint", "year":
+		return strconv.ParseInt(string(raw), 10, 64)
+	case "date", "datetime", "timestamp":
+		layout := mysqlDateTimeLayout
+		if dataType == "date" {
+			layout = mysqlDateLayout
+		}
+		return time.Parse(layout, string(raw))
+	default:
+		return nil, fmt.Errorf("watermark column of type %s is not supported for mysql, it needs to be an integer or timestamp",
+			dataType)
+	}
+}
+
+func buildQuery(query string) (string, error) {
+	tmpl, err := template.New("query").Parse(query)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, map[string]any{"start": "?", "end": "?"}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (c *MySqlConnector) PullQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int, error) {
+	query, err := buildQuery(config.Query)
+	if err != nil {
+		stream.Close(err)
+		return 0, err
+	}
+	var args []any
+	if !partition.FullTablePartition {
+		switch x := partition.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			args = []any{x.IntRange.Start, x.IntRange.End}
+		case *protos.PartitionRange_TimestampRange:
+			args = []any{x.TimestampRange.Start.AsTime(), x.TimestampRange.End.AsTime()}
+		default:
+			err := fmt.Errorf("unknown range type: %v", x)
+			stream.Close(err)
+			return 0, err
+		}
+	}
+
+	numRecords, err := c.streamQuery(ctx, query, args, stream)
+	if err != nil {
+		stream.Close(err)
+		return 0, err
+	}
+	stream.Close(nil)
+	return numRecords, nil
+}
+
+// streamQuery streams rows of a query over the text protocol, typed like GetTableSchema types columns
+func (c *MySqlConnector) streamQuery(ctx context.Context, query string, args []any, stream *model.QRecordStream) (int, error) {
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to run query: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get column types: %w", err)
+	}
+	dataTypes := make([]string, 0, len(columnTypes))
+	fields := make([]qvalue.QField, 0, len(columnTypes))
+	for _, columnType := range columnTypes {
+		databaseTypeName := strings.ToLower(columnType.DatabaseTypeName())
+		dataType, unsigned := strings.CutPrefix(databaseTypeName, "unsigned ")
+		precision, scale, ok := columnType.DecimalSize()
+		kind, _ := mysqlKind(dataType, unsigned,
+			sql.NullInt64{Int64: precision, Valid: ok}, sql.NullInt64{Int64: scale, Valid: ok})
+		nullable, _ := columnType.Nullable()
+		dataTypes = append(dataTypes, dataType)
+		fields = append(fields, qvalue.QField{
+			Name:      columnType.Name(),
+			Type:      kind,
+			Precision: int16(precision),
+			Scale:     int16(scale),
+			Nullable:  nullable,
+		})
+	}
+	stream.SetSchema(qvalue.NewQRecordSchema(fields))
+
+	values := make([]sql.RawBytes, len(columnTypes))
+	dest := make([]any, len(columnTypes))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	numRecords := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return numRecords, fmt.Errorf("failed to scan row: %w", err)
+		}
+		record := make([]qvalue.QValue, len(values))
+		for i, value := range values {
+			qv, err := textQValue(fields[i].Type, dataTypes[i], value)
+			if err != nil {
+				return numRecords, fmt.Errorf("failed to convert column %s: %w", fields[i].Name, err)
+			}
+			record[i] = qv
+		}
+		stream.Records <- record
+		numRecords += 1
+	}
+	if err := rows.Err(); err != nil {
+		return numRecords, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return numRecords, nil
+}
@@ -0,0 +1,260 @@
+package connmysql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+const (
+	mysqlDateLayout     = "2006-01-02"
+	mysqlDateTimeLayout = "2006-01-02 15:04:05.999999"
+	mysqlTimeLayout     = "15:04:05.999999"
+)
+
+// binlogColumn is what a table map event tells about a column
+type binlogColumn struct {
+	name       string
+	columnType byte
+	unsigned   bool
+	set        bool
+	// names of enum members or set bits, in order
+	values []string
+}
+
+// parseMySqlTime parses dates and times rendered by MySQL, zero dates are null
+func parseMySqlTime(layout string, text string) (time.Time, bool, error) {
+	if strings.HasPrefix(text, "0000-00-00") {
+		return time.Time{}, false, nil
+	}
+	t, err := time.Parse(layout, text)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+func timeQValue(kind qvalue.QValueKind, text string) (qvalue.QValue, error) {
+	var layout string
+	switch kind {
+	case qvalue.QValueKindDate:
+		layout = mysqlDateLayout
+	case qvalue.QValueKindTime:
+		layout = mysqlTimeLayout
+	default:
+		layout = mysqlDateTimeLayout
+	}
+	t, ok, err := parseMySqlTime(layout, text)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return qvalue.QValueNull(kind), nil
+	}
+	switch kind {
+	case qvalue.QValueKindDate:
+		return qvalue.QValueDate{Val: t}, nil
+	case qvalue.QValueKindTime:
+		return qvalue.QValueTime{Val: t}, nil
+	case qvalue.QValueKindTimestampTZ:
+		return qvalue.QValueTimestampTZ{Val: t}, nil
+	default:
+		return qvalue.QValueTimestamp{Val: t}, nil
+	}
+}
+
+func intQValue(kind qvalue.QValueKind, v int64) qvalue.QValue {
+	switch kind {
+	case qvalue.QValueKindInt16:
+		return qvalue.QValueInt16{Val: int16(v)}
+	case qvalue.QValueKindInt32:
+		return qvalue.QValueInt32{Val: int32(v)}
+	case qvalue.QValueKindNumeric:
+		return qvalue.QValueNumeric{Val: decimal.NewFromInt(v)}
+	default:
+		return qvalue.QValueInt64{Val: v}
+	}
+}
+
+// binlogInt widens an integer decoded from binlog, which is signed whatever the column is
+func binlogInt(kind qvalue.QValueKind, value any, column binlogColumn) (qvalue.QValue, error) {
+	switch v := value.(type) {
+	case int8:
+		if column.unsigned {
+			return intQValue(kind, int64(uint8(v))), nil
+		}
+		return intQValue(kind, int64(v)), nil
+	case int16:
+		if column.unsigned {
+			return intQValue(kind, int64(uint16(v))), nil
+		}
+		return intQValue(kind, int64(v)), nil
+	case int32:
+		if column.unsigned {
+			if column.columnType == mysql.MYSQL_TYPE_INT24 {
+				return intQValue(kind, int64(uint32(v)&0xFFFFFF)), nil
+			}
+			return intQValue(kind, int64(uint32(v))), nil
+		}
+		return intQValue(kind, int64(v)), nil
+	case int64:
+		if column.unsigned && kind == qvalue.QValueKindNumeric {
+			return qvalue.QValueNumeric{Val: decimal.NewFromUint64(uint64(v))}, nil
+		}
+		return intQValue(kind, v), nil
+	case int:
+		// years
+		return intQValue(kind, int64(v)), nil
+	default:
+		return nil, fmt.Errorf("unexpected %T for integer column", value)
+	}
+}
+
+// binlogString renders strings, blobs, enums and sets, which binlog has as indexes and bitmaps
+func binlogString(value any, column binlogColumn) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.Clone(v), nil
+	case []byte:
+		return string(v), nil
+	case int64:
+		if column.values == nil {
+			return strconv.FormatInt(v, 10), nil
+		}
+		if column.set {
+			members := make([]string, 0, len(column.values))
+			for i, member := range column.values {
+				if v&(1<<i) != 0 {
+					members = append(members, member)
+				}
+			}
+			return strings.Join(members, ","), nil
+		}
+		// 0 is the empty string stored for invalid values
+		if v <= 0 || int(v) > len(column.values) {
+			return "", nil
+		}
+		return column.values[v-1], nil
+	default:
+		return "", fmt.Errorf("unexpected %T for string column", value)
+	}
+}
+
+// binlogQValue converts a value of a binlog row event to the kind of its column
+func binlogQValue(kind qvalue.QValueKind, value any, column binlogColumn) (qvalue.QValue, error) {
+	if value == nil {
+		return qvalue.QValueNull(kind), nil
+	}
+	switch kind {
+	case qvalue.QValueKindInt16, qvalue.QValueKindInt32, qvalue.QValueKindInt64:
+		return binlogInt(kind, value, column)
+	case qvalue.QValueKindNumeric:
+		switch v := value.(type) {
+		case decimal.Decimal:
+			return qvalue.QValueNumeric{Val: v}, nil
+		case string:
+			d, err := decimal.NewFromString(v)
+			if err != nil {
+				return nil, err
+			}
+			return qvalue.QValueNumeric{Val: d}, nil
+		default:
+			return binlogInt(kind, value, column)
+		}
+	case qvalue.QValueKindFloat32:
+		if v, ok := value.(float32); ok {
+			return qvalue.QValueFloat32{Val: v}, nil
+		}
+	case qvalue.QValueKindFloat64:
+		switch v := value.(type) {
+		case float64:
+			return qvalue.QValueFloat64{Val: v}, nil
+		case float32:
+			return qvalue.QValueFloat64{Val: float64(v)}, nil
+		}
+	case qvalue.QValueKindDate, qvalue.QValueKindTime, qvalue.QValueKindTimestamp, qvalue.QValueKindTimestampTZ:
+		if v, ok := value.(string); ok {
+			return timeQValue(kind, v)
+		}
+	case qvalue.QValueKindBytes:
+		switch v := value.(type) {
+		case []byte:
+			return qvalue.QValueBytes{Val: bytes.Clone(v)}, nil
+		case string:
+			return qvalue.QValueBytes{Val: []byte(v)}, nil
+		}
+	case qvalue.QValueKindJSON:
+		switch v := value.(type) {
+		case string:
+			return qvalue.QValueJSON{Val: strings.Clone(v)}, nil
+		case []byte:
+			// empty documents are what MySQL stores for invalid JSON in non-strict mode
+			if len(v) == 0 {
+				return qvalue.QValueJSON{Val: "null"}, nil
+			}
+			return qvalue.QValueJSON{Val: string(v)}, nil
+		}
+	default:
+		s, err := binlogString(value, column)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueString{Val: s}, nil
+	}
+	return nil, fmt.Errorf("unexpected %T for %s column", value, kind)
+}
+
+// textQValue converts a value of the text protocol to kind, bits come as big endian bytes
+func textQValue(kind qvalue.QValueKind, dataType string, raw []byte) (qvalue.QValue, error) {
+	if raw == nil {
+		return qvalue.QValueNull(kind), nil
+	}
+	text := string(raw)
+	switch kind {
+	case qvalue.QValueKindInt16, qvalue.QValueKindInt32, qvalue.QValueKindInt64:
+		if dataType == "bit" {
+			var buf [8]byte
+			copy(buf[8-min(len(raw), 8):], raw[max(len(raw)-8, 0):])
+			return qvalue.QValueInt64{Val: int64(binary.BigEndian.Uint64(buf[:]))}, nil
+		}
+		v, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return intQValue(kind, v), nil
+	case qvalue.QValueKindNumeric:
+		v, err := decimal.NewFromString(text)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueNumeric{Val: v}, nil
+	case qvalue.QValueKindFloat32:
+		v, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueFloat32{Val: float32(v)}, nil
+	case qvalue.QValueKindFloat64:
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueFloat64{Val: v}, nil
+	case qvalue.QValueKindDate, qvalue.QValueKindTime, qvalue.QValueKindTimestamp, qvalue.QValueKindTimestampTZ:
+		return timeQValue(kind, text)
+	case qvalue.QValueKindBytes:
+		return qvalue.QValueBytes{Val: bytes.Clone(raw)}, nil
+	case qvalue.QValueKindJSON:
+		return qvalue.QValueJSON{Val: text}, nil
+	default:
+		return qvalue.QValueString{Val: text}, nil
+	}
+}
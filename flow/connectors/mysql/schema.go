@@ -0,0 +1,200 @@
+package connmysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// mysqlKind maps a data type of INFORMATION_SCHEMA.COLUMNS or the driver, in lower case,
+// to a QValueKind and the type modifier of numerics.
+// Unsigned types map to the next wider kind so every value fits.
+func mysqlKind(dataType string, unsigned bool, precision sql.NullInt64, scale sql.NullInt64) (qvalue.QValueKind, int32) {
+	switch dataType {
+	case "tinyint", "year":
+		return qvalue.QValueKindInt16, -1
+	case "smallint":
+		if unsigned {
+			return qvalue.QValueKindInt32, -1
+		}
+		return qvalue.QValueKindInt16, -1
+	case "mediumint":
+		return qvalue.QValueKindInt32, -1
+	case "int", "integer":
+		if unsigned {
+			return qvalue.QValueKindInt64, -1
+		}
+		return qvalue.QValueKindInt32, -1
+	case "bigint":
+		if unsigned {
+			return qvalue.QValueKindNumeric, datatypes.MakeNumericTypmod(20, 0)
+		}
+		return qvalue.QValueKindInt64, -1
+	case "bit":
+		return qvalue.QValueKindInt64, -1
+	case "decimal", "numeric":
+		if precision.Valid && scale.Valid {
+			return qvalue.QValueKindNumeric, datatypes.MakeNumericTypmod(int32(precision.Int64), int32(scale.Int64))
+		}
+		return qvalue.QValueKindNumeric, -1
+	case "float":
+		return qvalue.QValueKindFloat32, -1
+	case "double", "real":
+		return qvalue.QValueKindFloat64, -1
+	case "date":
+		return qvalue.QValueKindDate, -1
+	case "datetime":
+		return qvalue.QValueKindTimestamp, -1
+	case "timestamp":
+		return qvalue.QValueKindTimestampTZ, -1
+	case "time":
+		return qvalue.QValueKindTime, -1
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		// spatial values are an SRID followed by WKB
+		return qvalue.QValueKindBytes, -1
+	case "json":
+		return qvalue.QValueKindJSON, -1
+	default:
+		// character types, enums and sets
+		return qvalue.QValueKindString, -1
+	}
+}
+
+func (c *MySqlConnector) GetTableSchema(
+	ctx context.Context,
+	req *protos.GetTableSchemaBatchInput,
+) (*protos.GetTableSchemaBatchOutput, error) {
+	if req.System != protos.TypeSystem_Q {
+		return nil, fmt.Errorf("mysql peers do not support type system %s", req.System)
+	}
+	nullableEnabled, err := peerdbenv.PeerDBNullable(ctx, req.Env)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]*protos.TableSchema, len(req.TableIdentifiers))
+	for _, tableName := range req.TableIdentifiers {
+		tableSchema, err := c.getTableSchemaForTable(ctx, tableName, nullableEnabled)
+		if err != nil {
+			return nil, err
+		}
+		res[tableName] = tableSchema
+	}
+	return &protos.GetTableSchemaBatchOutput{TableNameSchemaMapping: res}, nil
+}
+
+func (c *MySqlConnector) getTableSchemaForTable(
+	ctx context.Context,
+	tableName string,
+	nullableEnabled bool,
+) (*protos.TableSchema, error) {
+	schemaTable, err := utils.ParseSchemaTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx,
+		`SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, NUMERIC_PRECISION, NUMERIC_SCALE, IS_NULLABLE
+		FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION`,
+		schemaTable.Schema, schemaTable.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+	var columns []*protos.FieldDescription
+	for rows.Next() {
+		var name, dataType, columnType, nullable string
+		var precision, scale sql.NullInt64
+		if err := rows.Scan(&name, &dataType, &columnType, &precision, &scale, &nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan column of table %s: %w", tableName, err)
+		}
+		kind, typmod := mysqlKind(strings.ToLower(dataType), strings.Contains(columnType, "unsigned"), precision, scale)
+		columns = append(columns, &protos.FieldDescription{
+			Name:         name,
+			Type:         string(kind),
+			TypeModifier: typmod,
+			Nullable:     nullable == "YES",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read columns of table %s: %w", tableName, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s does not exist or is not visible to user %s", tableName, c.config.User)
+	}
+
+	pkRows, err := c.db.QueryContext(ctx,
+		`SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+		WHERE CONSTRAINT_NAME = 'PRIMARY' AND TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION`,
+		schemaTable.Schema, schemaTable.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query primary key of table %s: %w", tableName, err)
+	}
+	defer pkRows.Close()
+	var primaryKeyColumns []string
+	for pkRows.Next() {
+		var name string
+		if err := pkRows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan primary key of table %s: %w", tableName, err)
+		}
+		primaryKeyColumns = append(primaryKeyColumns, name)
+	}
+	if err := pkRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read primary key of table %s: %w", tableName, err)
+	}
+
+	return &protos.TableSchema{
+		TableIdentifier:   tableName,
+		PrimaryKeyColumns: primaryKeyColumns,
+		// binlog_row_image = FULL puts the entire old row in updates and deletes
+		IsReplicaIdentityFull: true,
+		NullableEnabled:       nullableEnabled,
+		System:                protos.TypeSystem_Q,
+		Columns:               columns,
+	}, nil
+}
+
+// relID identifies a table by a hash of its name, as MySQL table ids change whenever tables are reopened
+func relID(tableName string) uint32 {
+	return crc32.ChecksumIEEE([]byte(tableName))
+}
+
+// EnsurePullability checks the server writes the binlog replication needs and every table exists
+func (c *MySqlConnector) EnsurePullability(
+	ctx context.Context,
+	req *protos.EnsurePullabilityBatchInput,
+) (*protos.EnsurePullabilityBatchOutput, error) {
+	if err := c.checkBinlogSettings(ctx); err != nil {
+		return nil, err
+	}
+
+	tableIdentifierMapping := make(map[string]*protos.PostgresTableIdentifier, len(req.SourceTableIdentifiers))
+	for _, tableName := range req.SourceTableIdentifiers {
+		schemaTable, err := utils.ParseSchemaTable(tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		var tableType string
+		if err := c.db.QueryRowContext(ctx,
+			"SELECT TABLE_TYPE FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+			schemaTable.Schema, schemaTable.Table,
+		).Scan(&tableType); err != nil {
+			return nil, fmt.Errorf("failed to find table %s: %w", tableName, err)
+		}
+		if tableType != "BASE TABLE" {
+			return nil, fmt.Errorf("%s is a %s, only tables write rows to binlog", tableName, strings.ToLower(tableType))
+		}
+
+		tableIdentifierMapping[tableName] = &protos.PostgresTableIdentifier{RelId: relID(tableName)}
+	}
+	return &protos.EnsurePullabilityBatchOutput{TableIdentifierMapping: tableIdentifierMapping}, nil
+}
@@ -0,0 +1,164 @@
+package connneo4j
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func quoteName(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// graphTable is how rows of a destination table become nodes, merged by their primary key
+type graphTable struct {
+	label         string
+	keys          []string
+	relationships []*protos.GraphRelationship
+}
+
+func newGraphTable(table string, keys []string, mapping *protos.GraphMapping) graphTable {
+	label := mapping.GetLabel()
+	if label == "" {
+		label = table
+	}
+	return graphTable{label: label, keys: keys, relationships: mapping.GetRelationships()}
+}
+
+// graphRow is a row as statements take it, key being the primary key and props the properties to set
+type graphRow struct {
+	Key   map[string]any `json:"key"`
+	Props map[string]any `json:"props"`
+}
+
+type cypherStatement struct {
+	Statement  string         `json:"statement"`
+	Parameters map[string]any `json:"parameters,omitempty"`
+}
+
+func (g graphTable) nodePattern(variable string) string {
+	properties := make([]string, 0, len(g.keys))
+	for _, key := range g.keys {
+		properties = append(properties, quoteName(key)+": row.key."+quoteName(key))
+	}
+	return fmt.Sprintf("(%s:%s {%s})", variable, quoteName(g.label), strings.Join(properties, ", "))
+}
+
+// setupStatement makes merging by key an index lookup, composite keys only get an index
+// as uniqueness over several properties needs node key constraints of the enterprise edition
+func (g graphTable) setupStatement() string {
+	if len(g.keys) == 1 {
+		return fmt.Sprintf("CREATE CONSTRAINT IF NOT EXISTS FOR (n:%s) REQUIRE n.%s IS UNIQUE",
+			quoteName(g.label), quoteName(g.keys[0]))
+	}
+	properties := make([]string, 0, len(g.keys))
+	for _, key := range g.keys {
+		properties = append(properties, "n."+quoteName(key))
+	}
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS FOR (n:%s) ON (%s)", quoteName(g.label), strings.Join(properties, ", "))
+}
+
+// upsertStatements merge nodes of rows, properties missing from props like unchanged TOAST columns are kept.
+// Relationships are replaced, so a changed foreign key moves the relationship to the new target
+func (g graphTable) upsertStatements() []string {
+	statements := make([]string, 0, 1+len(g.relationships))
+	statements = append(statements, "UNWIND $rows AS row MERGE "+g.nodePattern("n")+" SET n += row.props")
+	for _, rel := range g.relationships {
+		targetProperties := rel.TargetProperties
+		if len(targetProperties) == 0 {
+			targetProperties = rel.Columns
+		}
+		conditions := make([]string, 0, len(rel.Columns))
+		properties := make([]string, 0, len(rel.Columns))
+		for i, column := range rel.Columns {
+			conditions = append(conditions, "row.props."+quoteName(column)+" IS NOT NULL")
+			properties = append(properties, quoteName(targetProperties[i])+": row.props."+quoteName(column))
+		}
+		statements = append(statements, fmt.Sprintf("UNWIND $rows AS row MATCH %s "+
+			"OPTIONAL MATCH (n)-[old:%s]->(:%s) DELETE old WITH DISTINCT n, row WHERE %s "+
+			"MERGE (m:%s {%s}) MERGE (n)-[:%s]->(m)",
+			g.nodePattern("n"), quoteName(rel.Type), quoteName(rel.TargetLabel), strings.Join(conditions, " AND "),
+			quoteName(rel.TargetLabel), strings.Join(properties, ", "), quoteName(rel.Type)))
+	}
+	return statements
+}
+
+func (g graphTable) deleteStatement() string {
+	return "UNWIND $rows AS row MATCH " + g.nodePattern("n") + " DETACH DELETE n"
+}
+
+func (g graphTable) newRow(items map[string]qvalue.QValue) graphRow {
+	row := graphRow{
+		Key:   make(map[string]any, len(g.keys)),
+		Props: make(map[string]any, len(items)),
+	}
+	for _, key := range g.keys {
+		row.Key[key] = propertyValue(items[key])
+	}
+	for col, qv := range items {
+		row.Props[col] = propertyValue(qv)
+	}
+	return row
+}
+
+func recordItems(record model.Record[model.RecordItems]) (model.RecordItems, bool, bool) {
+	switch rec := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		return rec.Items, false, true
+	case *model.UpdateRecord[model.RecordItems]:
+		return rec.NewItems, false, true
+	case *model.DeleteRecord[model.RecordItems]:
+		return rec.Items, true, true
+	default:
+		return model.RecordItems{}, false, false
+	}
+}
+
+// propertyValue is the property of a value, types Neo4j has no counterpart of are strings, null removes the property
+func propertyValue(qv qvalue.QValue) any {
+	if qv == nil {
+		return nil
+	}
+	switch v := qv.Value().(type) {
+	case nil:
+		return nil
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil
+		}
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	case uint8:
+		return string(rune(v))
+	case decimal.Decimal:
+		return v.String()
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case time.Time:
+		switch qv.Kind() {
+		case qvalue.QValueKindDate:
+			return v.Format(time.DateOnly)
+		case qvalue.QValueKindTime, qvalue.QValueKindTimeTZ:
+			return v.Format("15:04:05.999999")
+		case qvalue.QValueKindTimestamp:
+			return v.Format("2006-01-02T15:04:05.999999")
+		default:
+			return v.Format(time.RFC3339Nano)
+		}
+	default:
+		// bytes are base64 encoded by encoding/json, arrays become lists
+		return v
+	}
+}
@@ -0,0 +1,322 @@
+package connneo4j
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const (
+	defaultBatchSize = 1000
+	requestTimeout   = 5 * time.Minute
+)
+
+type Neo4jConnector struct {
+	*metadataStore.PostgresMetadata
+	client    *http.Client
+	config    *protos.Neo4JConfig
+	logger    log.Logger
+	commitURL string
+	batchSize int
+}
+
+func NewNeo4jConnector(ctx context.Context, config *protos.Neo4JConfig) (*Neo4jConnector, error) {
+	endpoint, err := url.Parse(config.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid neo4j url: %w", err)
+	}
+	if (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, errors.New("neo4j url must be an absolute http or https url")
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	database := config.Database
+	if database == "" {
+		database = "neo4j"
+	}
+	batchSize := defaultBatchSize
+	if config.BatchSize > 0 {
+		batchSize = int(config.BatchSize)
+	}
+
+	return &Neo4jConnector{
+		PostgresMetadata: pgMetadata,
+		client:           &http.Client{Timeout: requestTimeout},
+		config:           config,
+		logger:           logger.LoggerFromCtx(ctx),
+		commitURL:        strings.TrimSuffix(config.Url, "/") + "/db/" + url.PathEscape(database) + "/tx/commit",
+		batchSize:        batchSize,
+	}, nil
+}
+
+func (c *Neo4jConnector) Close() error {
+	if c != nil {
+		c.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (c *Neo4jConnector) ConnectionActive(ctx context.Context) error {
+	return c.run(ctx, []cypherStatement{{Statement: "RETURN 1"}})
+}
+
+type neo4jError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type neo4jResponse struct {
+	Errors []neo4jError `json:"errors"`
+}
+
+// run runs statements in a transaction of their own, the HTTP API reports failures in the body
+func (c *Neo4jConnector) run(ctx context.Context, statements []cypherStatement) error {
+	body, err := json.Marshal(map[string]any{"statements": statements})
+	if err != nil {
+		return fmt.Errorf("failed to encode cypher statements: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.commitURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create neo4j request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "PeerDB")
+	req.SetBasicAuth(c.config.User, c.config.Password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send cypher statements: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read neo4j response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("neo4j responded with status %d: %s", resp.StatusCode, respBody[:min(len(respBody), 1024)])
+	}
+	var result neo4jResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to parse neo4j response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("cypher statements failed with %s: %s", result.Errors[0].Code, result.Errors[0].Message)
+	}
+	return nil
+}
+
+func (c *Neo4jConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+// nodes have no schema, new columns are new properties
+func (c *Neo4jConnector) ReplayTableSchemaDeltas(_ context.Context, flowJobName string, schemaDeltas []*protos.TableSchemaDelta) error {
+	return nil
+}
+
+func (c *Neo4jConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *Neo4jConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *Neo4jConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+// SetupNormalizedTable indexes the key of nodes of a table, there is nothing else to create up front
+func (c *Neo4jConnector) SetupNormalizedTable(
+	ctx context.Context,
+	tx any,
+	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
+) (bool, error) {
+	var mapping *protos.GraphMapping
+	for _, tm := range config.TableMappings {
+		if tm.DestinationTableIdentifier == tableIdentifier {
+			mapping = tm.Graph
+			break
+		}
+	}
+	g := newGraphTable(tableIdentifier, config.TableNameSchemaMapping[tableIdentifier].GetPrimaryKeyColumns(), mapping)
+	if len(g.keys) == 0 {
+		return false, fmt.Errorf("[neo4j] table %s has no primary key to merge nodes by", tableIdentifier)
+	}
+	if err := c.run(ctx, []cypherStatement{{Statement: g.setupStatement()}}); err != nil {
+		return false, fmt.Errorf("[neo4j] failed to index nodes of %s: %w", tableIdentifier, err)
+	}
+	return false, nil
+}
+
+// graphBatch collects changes into statements over runs of consecutive changes of the same kind to the same table,
+// which keeps changes to a node in order within a transaction
+type graphBatch struct {
+	statements []cypherStatement
+	rows       []graphRow
+	table      string
+	delete     bool
+	g          graphTable
+	numRows    int
+}
+
+func (b *graphBatch) add(table string, g graphTable, isDelete bool, row graphRow) {
+	if len(b.rows) > 0 && (b.table != table || b.delete != isDelete) {
+		b.endRun()
+	}
+	b.table = table
+	b.delete = isDelete
+	b.g = g
+	b.rows = append(b.rows, row)
+	b.numRows += 1
+}
+
+func (b *graphBatch) endRun() {
+	if len(b.rows) == 0 {
+		return
+	}
+	params := map[string]any{"rows": b.rows}
+	if b.delete {
+		b.statements = append(b.statements, cypherStatement{Statement: b.g.deleteStatement(), Parameters: params})
+	} else {
+		for _, statement := range b.g.upsertStatements() {
+			b.statements = append(b.statements, cypherStatement{Statement: statement, Parameters: params})
+		}
+	}
+	b.rows = nil
+}
+
+func (b *graphBatch) reset() {
+	b.statements = nil
+	b.rows = nil
+	b.numRows = 0
+}
+
+func (c *Neo4jConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	tables := make(map[string]graphTable, len(req.TableMappings))
+	for _, tm := range req.TableMappings {
+		tables[tm.DestinationTableIdentifier] = newGraphTable(tm.DestinationTableIdentifier,
+			req.TableNameSchemaMapping[tm.DestinationTableIdentifier].GetPrimaryKeyColumns(), tm.Graph)
+	}
+
+	var batch graphBatch
+	batcher := utils.NewRecordBatcher(c.logger, req, c.batchSize, c.SetLastOffset,
+		func(ctx context.Context, _ []model.Record[model.RecordItems]) error {
+			batch.endRun()
+			if err := c.run(ctx, batch.statements); err != nil {
+				return fmt.Errorf("[neo4j] failed to apply changes: %w", err)
+			}
+			batch.reset()
+			return nil
+		})
+
+	for record := range req.Records.GetRecords() {
+		items, isDelete, ok := recordItems(record)
+		if !ok {
+			continue
+		}
+		table := record.GetDestinationTableName()
+		g, ok := tables[table]
+		if !ok || len(g.keys) == 0 {
+			return nil, fmt.Errorf("[neo4j] table %s has no primary key to merge nodes by", table)
+		}
+		batch.add(table, g, isDelete, g.newRow(items.ColToVal))
+		if err := batcher.Add(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+	if err := batcher.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[neo4j] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:     req.SyncBatchID,
+		LastSyncedCheckpointID: lastCheckpoint,
+		NumRecordsSynced:       batcher.NumRecords(),
+		TableNameRowsMapping:   batcher.TableNameRowsMapping(),
+		TableSchemaDeltas:      req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *Neo4jConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+// SyncQRepRecords merges nodes by the upsert key columns, so a retried partition converges to the same graph
+func (c *Neo4jConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int, error) {
+	startTime := time.Now()
+	c.logger.Info("[neo4j] syncing partition",
+		slog.String(string(shared.PartitionIDKey), partition.PartitionId),
+		slog.String("destinationTable", config.DestinationTableIdentifier))
+
+	g := newGraphTable(config.DestinationTableIdentifier, config.WriteMode.GetUpsertKeyColumns(), config.Graph)
+	if len(g.keys) == 0 {
+		return 0, fmt.Errorf("[neo4j] table %s has no primary key to merge nodes by", config.DestinationTableIdentifier)
+	}
+	columns := stream.Schema().GetColumnNames()
+
+	numRecords := 0
+	var batch graphBatch
+	for record := range stream.Records {
+		items := make(map[string]qvalue.QValue, len(columns))
+		for i, column := range columns {
+			items[column] = record[i]
+		}
+		batch.add(config.DestinationTableIdentifier, g, false, g.newRow(items))
+		numRecords += 1
+		if batch.numRows >= c.batchSize {
+			batch.endRun()
+			if err := c.run(ctx, batch.statements); err != nil {
+				return 0, fmt.Errorf("[neo4j] failed to merge nodes of %s: %w", config.DestinationTableIdentifier, err)
+			}
+			batch.reset()
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	if batch.numRows > 0 {
+		batch.endRun()
+		if err := c.run(ctx, batch.statements); err != nil {
+			return 0, fmt.Errorf("[neo4j] failed to merge nodes of %s: %w", config.DestinationTableIdentifier, err)
+		}
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
@@ -0,0 +1,62 @@
+package connneo4j
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestGraphTableStatements(t *testing.T) {
+	g := newGraphTable("orders", []string{"id"}, &protos.GraphMapping{
+		Label: "Order",
+		Relationships: []*protos.GraphRelationship{
+			{Type: "PLACED_BY", TargetLabel: "Customer", Columns: []string{"customer_id"}, TargetProperties: []string{"id"}},
+		},
+	})
+	require.Equal(t, "CREATE CONSTRAINT IF NOT EXISTS FOR (n:`Order`) REQUIRE n.`id` IS UNIQUE", g.setupStatement())
+	require.Equal(t, []string{
+		"UNWIND $rows AS row MERGE (n:`Order` {`id`: row.key.`id`}) SET n += row.props",
+		"UNWIND $rows AS row MATCH (n:`Order` {`id`: row.key.`id`}) OPTIONAL MATCH (n)-[old:`PLACED_BY`]->(:`Customer`) " +
+			"DELETE old WITH DISTINCT n, row WHERE row.props.`customer_id` IS NOT NULL " +
+			"MERGE (m:`Customer` {`id`: row.props.`customer_id`}) MERGE (n)-[:`PLACED_BY`]->(m)",
+	}, g.upsertStatements())
+	require.Equal(t, "UNWIND $rows AS row MATCH (n:`Order` {`id`: row.key.`id`}) DETACH DELETE n", g.deleteStatement())
+
+	composite := newGraphTable("public.line`items", []string{"order_id", "line"}, nil)
+	require.Equal(t, "CREATE INDEX IF NOT EXISTS FOR (n:`public.line``items`) ON (n.`order_id`, n.`line`)",
+		composite.setupStatement())
+}
+
+func TestGraphBatch(t *testing.T) {
+	g := newGraphTable("users", []string{"id"}, nil)
+	row := g.newRow(map[string]qvalue.QValue{
+		"id":         qvalue.QValueInt64{Val: 1},
+		"balance":    qvalue.QValueNumeric{Val: decimal.RequireFromString("10.50")},
+		"created_at": qvalue.QValueTimestampTZ{Val: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		"deleted_at": qvalue.QValueNull(qvalue.QValueKindTimestampTZ),
+	})
+	require.Equal(t, map[string]any{"id": int64(1)}, row.Key)
+	require.Equal(t, map[string]any{
+		"id":         int64(1),
+		"balance":    "10.5",
+		"created_at": "2024-01-02T03:04:05Z",
+		"deleted_at": nil,
+	}, row.Props)
+
+	// changes to the same node stay in order across runs
+	var batch graphBatch
+	batch.add("users", g, false, row)
+	batch.add("users", g, false, row)
+	batch.add("users", g, true, row)
+	batch.add("users", g, false, row)
+	batch.endRun()
+	require.Equal(t, 4, batch.numRows)
+	require.Len(t, batch.statements, 3)
+	require.Len(t, batch.statements[0].Parameters["rows"], 2)
+	require.Equal(t, g.deleteStatement(), batch.statements[1].Statement)
+}
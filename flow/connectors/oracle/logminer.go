@@ -0,0 +1,495 @@
+package connoracle
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+const (
+	defaultTransactionLookback = 600 * time.Second
+	// mining rereads the lookback every time, so windows without changes are spaced out
+	minePollInterval = 5 * time.Second
+
+	// layouts matching the NLS formats of the mining session
+	redoTimestampLayout   = "2006-01-02 15:04:05.999999999"
+	redoTimestampTZLayout = "2006-01-02 15:04:05.999999999 -07:00"
+)
+
+var sessionStatements = []string{
+	"ALTER SESSION SET NLS_DATE_FORMAT = 'YYYY-MM-DD HH24:MI:SS'",
+	"ALTER SESSION SET NLS_TIMESTAMP_FORMAT = 'YYYY-MM-DD HH24:MI:SS.FF9'",
+	"ALTER SESSION SET NLS_TIMESTAMP_TZ_FORMAT = 'YYYY-MM-DD HH24:MI:SS.FF9 TZH:TZM'",
+	"ALTER SESSION SET NLS_NUMERIC_CHARACTERS = '.,'",
+	"ALTER SESSION SET TIME_ZONE = '+00:00'",
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func currentSCN(ctx context.Context, q queryer) (int64, error) {
+	var scn int64
+	if err := q.QueryRowContext(ctx, "SELECT CURRENT_SCN FROM V$DATABASE").Scan(&scn); err != nil {
+		return 0, fmt.Errorf("failed to get current scn: %w", err)
+	}
+	return scn, nil
+}
+
+// startSCN pins where mining of a mirror without a checkpoint starts,
+// so changes committed while batches are still empty are not skipped
+func (c *OracleConnector) startSCN(ctx context.Context, catalogPool *pgxpool.Pool, flowJobName string) (int64, error) {
+	scn, err := currentSCN(ctx, c.db)
+	if err != nil {
+		return 0, err
+	}
+	var startSCN int64
+	if err := catalogPool.QueryRow(ctx,
+		`INSERT INTO oracle_start_scn(flow_name, start_scn) VALUES($1, $2)
+		ON CONFLICT(flow_name) DO UPDATE SET start_scn = oracle_start_scn.start_scn RETURNING start_scn`,
+		flowJobName, scn,
+	).Scan(&startSCN); err != nil {
+		return 0, fmt.Errorf("failed to pin start scn of %s: %w", flowJobName, err)
+	}
+	return startSCN, nil
+}
+
+func (c *OracleConnector) advanceStartSCN(
+	ctx context.Context,
+	catalogPool *pgxpool.Pool,
+	flowJobName string,
+	scn int64,
+) error {
+	if _, err := catalogPool.Exec(ctx,
+		"UPDATE oracle_start_scn SET start_scn = GREATEST(start_scn, $2), updated_at = now() WHERE flow_name = $1",
+		flowJobName, scn,
+	); err != nil {
+		return fmt.Errorf("failed to advance start scn of %s: %w", flowJobName, err)
+	}
+	return nil
+}
+
+// lookbackSCN is the SCN mining starts from to see transactions still open at checkpoint
+func (c *OracleConnector) lookbackSCN(ctx context.Context, conn *sql.Conn, checkpoint int64) int64 {
+	lookback := defaultTransactionLookback
+	if c.config.TransactionLookbackSeconds > 0 {
+		lookback = time.Duration(c.config.TransactionLookbackSeconds) * time.Second
+	}
+	var scn int64
+	if err := conn.QueryRowContext(ctx,
+		"SELECT TIMESTAMP_TO_SCN(SCN_TO_TIMESTAMP(:1) - NUMTODSINTERVAL(:2, 'SECOND')) FROM DUAL",
+		checkpoint, int64(lookback.Seconds()),
+	).Scan(&scn); err != nil {
+		// SCNs get too old to map to timestamps
+		c.logger.Warn("failed to look back from checkpoint, mining from checkpoint",
+			slog.Int64("scn", checkpoint), slog.Any("error", err))
+		return checkpoint
+	}
+	return min(scn, checkpoint)
+}
+
+// addLogFiles registers archived and online redo containing changes since startSCN with LogMiner
+func (c *OracleConnector) addLogFiles(ctx context.Context, conn *sql.Conn, startSCN int64) error {
+	rows, err := conn.QueryContext(ctx,
+		`SELECT NAME FROM (
+			SELECT NAME, SEQUENCE# FROM V$ARCHIVED_LOG WHERE NEXT_CHANGE# > :1 AND DEST_ID = 1 AND STATUS = 'A'
+			UNION ALL
+			SELECT MIN(f.MEMBER), l.SEQUENCE# FROM V$LOG l JOIN V$LOGFILE f ON f.GROUP# = l.GROUP#
+			WHERE l.NEXT_CHANGE# > :2 AND l.ARCHIVED = 'NO' GROUP BY l.SEQUENCE#
+		) ORDER BY SEQUENCE#`, startSCN, startSCN)
+	if err != nil {
+		return fmt.Errorf("failed to list redo logs: %w", err)
+	}
+	var logFiles []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan redo log: %w", err)
+		}
+		logFiles = append(logFiles, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list redo logs: %w", err)
+	}
+	if len(logFiles) == 0 {
+		return fmt.Errorf("no redo logs contain scn %d, archived logs may have been deleted", startSCN)
+	}
+
+	for i, logFile := range logFiles {
+		option := "DBMS_LOGMNR.ADDFILE"
+		if i == 0 {
+			option = "DBMS_LOGMNR.NEW"
+		}
+		if _, err := conn.ExecContext(ctx,
+			"BEGIN DBMS_LOGMNR.ADD_LOGFILE(LOGFILENAME => :1, OPTIONS => "+option+"); END;", logFile,
+		); err != nil {
+			return fmt.Errorf("failed to add redo log %s: %w", logFile, err)
+		}
+	}
+	return nil
+}
+
+// mineCondition filters V$LOGMNR_CONTENTS to the source tables of the mirror
+func mineCondition(tableNameMapping map[string]model.NameAndExclude) (string, []any, error) {
+	conditions := make([]string, 0, len(tableNameMapping))
+	args := make([]any, 0, 2*len(tableNameMapping))
+	for sourceTable := range tableNameMapping {
+		schemaTable, err := utils.ParseSchemaTable(sourceTable)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("(SEG_OWNER = :%d AND TABLE_NAME = :%d)", len(args)+3, len(args)+4))
+		args = append(args, schemaTable.Schema, schemaTable.Table)
+	}
+	return strings.Join(conditions, " OR "), args, nil
+}
+
+type mineResult struct {
+	// commit SCN up to which every change was emitted
+	checkpoint int64
+	// whether mining stopped early on a full batch
+	full bool
+}
+
+// mine emits changes of transactions committed after checkpoint and before endSCN,
+// stopping at the first transaction boundary after the batch is full
+func (c *OracleConnector) mine(
+	ctx context.Context,
+	conn *sql.Conn,
+	req *model.PullRecordsRequest[model.RecordItems],
+	checkpoint int64,
+	endSCN int64,
+	numRecords *uint32,
+) (mineResult, error) {
+	startSCN := c.lookbackSCN(ctx, conn, checkpoint)
+	if err := c.addLogFiles(ctx, conn, startSCN); err != nil {
+		return mineResult{}, err
+	}
+	if _, err := conn.ExecContext(ctx,
+		`BEGIN DBMS_LOGMNR.START_LOGMNR(STARTSCN => :1, ENDSCN => :2, OPTIONS =>
+		DBMS_LOGMNR.DICT_FROM_ONLINE_CATALOG + DBMS_LOGMNR.COMMITTED_DATA_ONLY + DBMS_LOGMNR.NO_ROWID_IN_STMT); END;`,
+		startSCN, endSCN,
+	); err != nil {
+		return mineResult{}, fmt.Errorf("failed to start logminer: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "BEGIN DBMS_LOGMNR.END_LOGMNR; END;"); err != nil {
+			c.logger.Warn("failed to end logminer", slog.Any("error", err))
+		}
+	}()
+
+	condition, tableArgs, err := mineCondition(req.TableNameMapping)
+	if err != nil {
+		return mineResult{}, err
+	}
+	rows, err := conn.QueryContext(ctx,
+		`SELECT COMMIT_SCN, COMMIT_TIMESTAMP, SEG_OWNER, TABLE_NAME, SQL_REDO, CSF FROM V$LOGMNR_CONTENTS
+		WHERE OPERATION_CODE IN (1, 2, 3) AND COMMIT_SCN > :1 AND COMMIT_SCN < :2 AND (`+condition+`)`,
+		append([]any{checkpoint, endSCN}, tableArgs...)...)
+	if err != nil {
+		return mineResult{}, fmt.Errorf("failed to query logminer contents: %w", err)
+	}
+	defer rows.Close()
+
+	records := req.RecordStream
+	// changes arrive grouped by transaction in commit order
+	groupSCN := checkpoint
+	var redo strings.Builder
+	for rows.Next() {
+		var commitSCN int64
+		var commitTime time.Time
+		var owner, table, sqlRedo string
+		var csf int
+		if err := rows.Scan(&commitSCN, &commitTime, &owner, &table, &sqlRedo, &csf); err != nil {
+			return mineResult{}, fmt.Errorf("failed to scan logminer contents: %w", err)
+		}
+		redo.WriteString(sqlRedo)
+		// long statements continue over rows
+		if csf == 1 {
+			continue
+		}
+
+		if commitSCN != groupSCN {
+			records.UpdateLatestCheckpoint(groupSCN)
+			if *numRecords >= req.MaxBatchSize {
+				return mineResult{checkpoint: groupSCN, full: true}, nil
+			}
+			groupSCN = commitSCN
+		}
+
+		sourceTable := owner + "." + table
+		record, err := redoRecord(redo.String(), sourceTable, req,
+			model.BaseRecord{CheckpointID: commitSCN, CommitTimeNano: commitTime.UnixNano()})
+		redo.Reset()
+		if err != nil {
+			return mineResult{}, fmt.Errorf("failed to process change of %s at scn %d: %w", sourceTable, commitSCN, err)
+		}
+		if record == nil {
+			continue
+		}
+		if err := records.AddRecord(ctx, record); err != nil {
+			return mineResult{}, err
+		}
+		*numRecords += 1
+		if *numRecords == 1 {
+			records.SignalAsNotEmpty()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return mineResult{}, fmt.Errorf("failed to read logminer contents: %w", err)
+	}
+	// everything committed before endSCN was seen
+	records.UpdateLatestCheckpoint(endSCN - 1)
+	return mineResult{checkpoint: endSCN - 1}, nil
+}
+
+func (c *OracleConnector) PullRecords(
+	ctx context.Context,
+	catalogPool *pgxpool.Pool,
+	req *model.PullRecordsRequest[model.RecordItems],
+) error {
+	records := req.RecordStream
+	records.UpdateLatestCheckpoint(req.LastOffset)
+	var numRecords uint32
+	defer func() {
+		if numRecords == 0 {
+			records.SignalAsEmpty()
+		}
+		records.Close()
+		c.logger.Info(fmt.Sprintf("[finished] PullRecords streamed %d records", numRecords))
+	}()
+
+	checkpoint := req.LastOffset
+	if checkpoint == 0 {
+		var err error
+		if checkpoint, err = c.startSCN(ctx, catalogPool, req.FlowJobName); err != nil {
+			return err
+		}
+	}
+
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire oracle connection: %w", err)
+	}
+	defer conn.Close()
+	for _, stmt := range sessionStatements {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to set up mining session: %w", err)
+		}
+	}
+
+	deadline := time.Now().Add(req.IdleTimeout)
+	for {
+		endSCN, err := currentSCN(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if endSCN > checkpoint+1 {
+			result, err := c.mine(ctx, conn, req, checkpoint, endSCN, &numRecords)
+			if err != nil {
+				return err
+			}
+			checkpoint = result.checkpoint
+			if result.full || numRecords > 0 {
+				return nil
+			}
+			if req.LastOffset == 0 {
+				if err := c.advanceStartSCN(ctx, catalogPool, req.FlowJobName, checkpoint); err != nil {
+					return err
+				}
+			}
+		}
+
+		wait := min(minePollInterval, time.Until(deadline))
+		if wait <= 0 {
+			c.logger.Info("idle timeout reached, no changes mined", slog.Int64("scn", checkpoint))
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// redoRecord builds the record of a SQL_REDO statement from the schema of its destination table,
+// returning nil for tables not in this mirror
+func redoRecord(
+	sqlRedo string,
+	sourceTable string,
+	req *model.PullRecordsRequest[model.RecordItems],
+	baseRecord model.BaseRecord,
+) (model.Record[model.RecordItems], error) {
+	destination, ok := req.TableNameMapping[sourceTable]
+	if !ok {
+		return nil, nil
+	}
+	schema, ok := req.TableNameSchemaMapping[destination.Name]
+	if !ok {
+		return nil, fmt.Errorf("no schema for destination table %s", destination.Name)
+	}
+	stmt, err := parseRedo(sqlRedo)
+	if err != nil {
+		return nil, err
+	}
+
+	switch stmt.operation {
+	case redoInsert:
+		items, _, err := redoItems(schema, destination, req.SourceIdentifier, stmt.newValues, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &model.InsertRecord[model.RecordItems]{
+			BaseRecord:           baseRecord,
+			Items:                items,
+			SourceTableName:      sourceTable,
+			DestinationTableName: destination.Name,
+			CommitID:             baseRecord.CheckpointID,
+		}, nil
+	case redoUpdate:
+		newItems, unchangedToastColumns, err := redoItems(schema, destination, req.SourceIdentifier,
+			stmt.newValues, stmt.oldValues)
+		if err != nil {
+			return nil, err
+		}
+		oldItems, _, err := redoItems(schema, destination, req.SourceIdentifier, stmt.oldValues, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &model.UpdateRecord[model.RecordItems]{
+			BaseRecord:            baseRecord,
+			OldItems:              oldItems,
+			NewItems:              newItems,
+			UnchangedToastColumns: unchangedToastColumns,
+			SourceTableName:       sourceTable,
+			DestinationTableName:  destination.Name,
+		}, nil
+	default:
+		items, _, err := redoItems(schema, destination, req.SourceIdentifier, stmt.oldValues, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &model.DeleteRecord[model.RecordItems]{
+			BaseRecord:            baseRecord,
+			Items:                 items,
+			UnchangedToastColumns: make(map[string]struct{}),
+			SourceTableName:       sourceTable,
+			DestinationTableName:  destination.Name,
+		}, nil
+	}
+}
+
+// redoItems converts values, falling back to fallback values for columns not in values.
+// Columns in neither are unchanged toast columns when there is a fallback and null otherwise.
+func redoItems(
+	schema *protos.TableSchema,
+	destination model.NameAndExclude,
+	sourceIdentifier string,
+	values map[string]redoValue,
+	fallback map[string]redoValue,
+) (model.RecordItems, map[string]struct{}, error) {
+	items := model.NewRecordItems(len(schema.Columns))
+	unchangedToastColumns := make(map[string]struct{})
+	for _, column := range schema.Columns {
+		if column.Name == model.SourceIdentifierColName && sourceIdentifier != "" {
+			continue
+		}
+		if _, excluded := destination.Exclude[column.Name]; excluded {
+			continue
+		}
+		kind := qvalue.QValueKind(column.Type)
+		value, ok := values[column.Name]
+		if !ok || value.unavailable {
+			value, ok = fallback[column.Name]
+		}
+		if !ok || value.unavailable {
+			if fallback != nil {
+				unchangedToastColumns[column.Name] = struct{}{}
+				continue
+			}
+			value = redoValue{null: true}
+		}
+		qv, err := redoQValue(kind, value)
+		if err != nil {
+			return model.RecordItems{}, nil, fmt.Errorf("failed to convert column %s: %w", column.Name, err)
+		}
+		items.AddColumn(column.Name, qv)
+	}
+	if sourceIdentifier != "" {
+		items.AddColumn(model.SourceIdentifierColName, qvalue.QValueString{Val: sourceIdentifier})
+	}
+	return items, unchangedToastColumns, nil
+}
+
+func redoQValue(kind qvalue.QValueKind, value redoValue) (qvalue.QValue, error) {
+	if value.null {
+		return qvalue.QValueNull(kind), nil
+	}
+	switch kind {
+	case qvalue.QValueKindInt64:
+		v, err := strconv.ParseInt(value.text, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueInt64{Val: v}, nil
+	case qvalue.QValueKindNumeric:
+		v, err := decimal.NewFromString(value.text)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueNumeric{Val: v}, nil
+	case qvalue.QValueKindFloat32:
+		v, err := strconv.ParseFloat(value.text, 32)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueFloat32{Val: float32(v)}, nil
+	case qvalue.QValueKindFloat64:
+		v, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueFloat64{Val: v}, nil
+	case qvalue.QValueKindTimestamp:
+		v, err := time.Parse(redoTimestampLayout, value.text)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueTimestamp{Val: v}, nil
+	case qvalue.QValueKindTimestampTZ:
+		// local time zone timestamps have no offset and are in the UTC of the session
+		v, err := time.Parse(redoTimestampTZLayout, value.text)
+		if err != nil {
+			if v, err = time.Parse(redoTimestampLayout, value.text); err != nil {
+				return nil, err
+			}
+		}
+		return qvalue.QValueTimestampTZ{Val: v.UTC()}, nil
+	case qvalue.QValueKindBytes:
+		v, err := hex.DecodeString(value.text)
+		if err != nil {
+			return nil, err
+		}
+		return qvalue.QValueBytes{Val: v}, nil
+	case qvalue.QValueKindJSON:
+		return qvalue.QValueJSON{Val: value.text}, nil
+	default:
+		return qvalue.QValueString{Val: value.text}, nil
+	}
+}
@@ -0,0 +1,311 @@
+package connoracle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/alerting"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/otel_metrics/peerdb_gauges"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// driverName is what go-ora registers itself as with database/sql
+const driverName = "oracle"
+
+// OracleConnector is a CDC source reading committed changes with LogMiner.
+// Offsets are commit SCNs, so a batch always ends on a transaction boundary.
+// Source tables need ALL COLUMNS supplemental logging for updates and deletes to carry entire rows,
+// LOB columns only come through when written inline with the row and are otherwise unchanged toast columns.
+type OracleConnector struct {
+	db     *sql.DB
+	config *protos.OracleConfig
+	logger log.Logger
+}
+
+func NewOracleConnector(ctx context.Context, config *protos.OracleConfig) (*OracleConnector, error) {
+	if !slices.Contains(sql.Drivers(), driverName) {
+		return nil, fmt.Errorf("oracle peers need a database/sql driver registered as %q, which is not linked into this build",
+			driverName)
+	}
+	port := config.Port
+	if port == 0 {
+		port = 1521
+	}
+	dsn := url.URL{
+		Scheme: driverName,
+		User:   url.UserPassword(config.User, config.Password),
+		Host:   net.JoinHostPort(config.Host, strconv.FormatUint(uint64(port), 10)),
+		Path:   "/" + config.ServiceName,
+	}
+	db, err := sql.Open(driverName, dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection to oracle: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to oracle: %w", err)
+	}
+
+	return &OracleConnector{
+		db:     db,
+		config: config,
+		logger: logger.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func (c *OracleConnector) Close() error {
+	if c != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+func (c *OracleConnector) ConnectionActive(ctx context.Context) error {
+	if err := c.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping oracle: %w", err)
+	}
+	return nil
+}
+
+// oracleKind maps a column of ALL_TAB_COLUMNS to a QValueKind and the type modifier of numerics
+func oracleKind(dataType string, precision sql.NullInt64, scale sql.NullInt64) (qvalue.QValueKind, int32) {
+	switch {
+	case dataType == "NUMBER":
+		if precision.Valid && precision.Int64 <= 18 && scale.Valid && scale.Int64 == 0 {
+			return qvalue.QValueKindInt64, -1
+		}
+		if precision.Valid && scale.Valid && scale.Int64 >= 0 {
+			return qvalue.QValueKindNumeric, datatypes.MakeNumericTypmod(int32(precision.Int64), int32(scale.Int64))
+		}
+		return qvalue.QValueKindNumeric, -1
+	case dataType == "BINARY_FLOAT":
+		return qvalue.QValueKindFloat32, -1
+	case dataType == "BINARY_DOUBLE", dataType == "FLOAT":
+		return qvalue.QValueKindFloat64, -1
+	case dataType == "DATE":
+		return qvalue.QValueKindTimestamp, -1
+	case strings.HasPrefix(dataType, "TIMESTAMP"):
+		if strings.HasSuffix(dataType, "TIME ZONE") {
+			return qvalue.QValueKindTimestampTZ, -1
+		}
+		return qvalue.QValueKindTimestamp, -1
+	case dataType == "RAW", dataType == "LONG RAW", dataType == "BLOB":
+		return qvalue.QValueKindBytes, -1
+	case dataType == "JSON":
+		return qvalue.QValueKindJSON, -1
+	default:
+		// character types, CLOBs, intervals and ROWIDs
+		return qvalue.QValueKindString, -1
+	}
+}
+
+func (c *OracleConnector) GetTableSchema(
+	ctx context.Context,
+	req *protos.GetTableSchemaBatchInput,
+) (*protos.GetTableSchemaBatchOutput, error) {
+	if req.System != protos.TypeSystem_Q {
+		return nil, fmt.Errorf("oracle peers do not support type system %s", req.System)
+	}
+	nullableEnabled, err := peerdbenv.PeerDBNullable(ctx, req.Env)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]*protos.TableSchema, len(req.TableIdentifiers))
+	for _, tableName := range req.TableIdentifiers {
+		tableSchema, err := c.getTableSchemaForTable(ctx, tableName, nullableEnabled)
+		if err != nil {
+			return nil, err
+		}
+		res[tableName] = tableSchema
+	}
+	return &protos.GetTableSchemaBatchOutput{TableNameSchemaMapping: res}, nil
+}
+
+func (c *OracleConnector) getTableSchemaForTable(
+	ctx context.Context,
+	tableName string,
+	nullableEnabled bool,
+) (*protos.TableSchema, error) {
+	schemaTable, err := utils.ParseSchemaTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx,
+		`SELECT COLUMN_NAME, DATA_TYPE, DATA_PRECISION, DATA_SCALE, NULLABLE FROM ALL_TAB_COLUMNS
+		WHERE OWNER = :1 AND TABLE_NAME = :2 ORDER BY COLUMN_ID`,
+		schemaTable.Schema, schemaTable.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+	var columns []*protos.FieldDescription
+	for rows.Next() {
+		var name, dataType, nullable string
+		var precision, scale sql.NullInt64
+		if err := rows.Scan(&name, &dataType, &precision, &scale, &nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan column of table %s: %w", tableName, err)
+		}
+		kind, typmod := oracleKind(dataType, precision, scale)
+		columns = append(columns, &protos.FieldDescription{
+			Name:         name,
+			Type:         string(kind),
+			TypeModifier: typmod,
+			Nullable:     nullable == "Y",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read columns of table %s: %w", tableName, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s does not exist or is not visible to user %s", tableName, c.config.User)
+	}
+
+	pkRows, err := c.db.QueryContext(ctx,
+		`SELECT cc.COLUMN_NAME FROM ALL_CONSTRAINTS c
+		JOIN ALL_CONS_COLUMNS cc ON cc.OWNER = c.OWNER AND cc.CONSTRAINT_NAME = c.CONSTRAINT_NAME
+		WHERE c.CONSTRAINT_TYPE = 'P' AND c.OWNER = :1 AND c.TABLE_NAME = :2 ORDER BY cc.POSITION`,
+		schemaTable.Schema, schemaTable.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query primary key of table %s: %w", tableName, err)
+	}
+	defer pkRows.Close()
+	var primaryKeyColumns []string
+	for pkRows.Next() {
+		var name string
+		if err := pkRows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan primary key of table %s: %w", tableName, err)
+		}
+		primaryKeyColumns = append(primaryKeyColumns, name)
+	}
+	if err := pkRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read primary key of table %s: %w", tableName, err)
+	}
+
+	return &protos.TableSchema{
+		TableIdentifier:   tableName,
+		PrimaryKeyColumns: primaryKeyColumns,
+		// supplemental logging of all columns puts the entire old row in updates and deletes
+		IsReplicaIdentityFull: true,
+		NullableEnabled:       nullableEnabled,
+		System:                protos.TypeSystem_Q,
+		Columns:               columns,
+	}, nil
+}
+
+// EnsurePullability checks the database archives its redo and every table logs all columns,
+// returning object ids as relation ids
+func (c *OracleConnector) EnsurePullability(
+	ctx context.Context,
+	req *protos.EnsurePullabilityBatchInput,
+) (*protos.EnsurePullabilityBatchOutput, error) {
+	var logMode, supplementalAll string
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT LOG_MODE, SUPPLEMENTAL_LOG_DATA_ALL FROM V$DATABASE",
+	).Scan(&logMode, &supplementalAll); err != nil {
+		return nil, fmt.Errorf("failed to check redo configuration of oracle: %w", err)
+	}
+	if logMode != "ARCHIVELOG" {
+		return nil, fmt.Errorf("oracle database needs to run in ARCHIVELOG mode for LogMiner, it is in %s mode", logMode)
+	}
+
+	tableIdentifierMapping := make(map[string]*protos.PostgresTableIdentifier, len(req.SourceTableIdentifiers))
+	for _, tableName := range req.SourceTableIdentifiers {
+		schemaTable, err := utils.ParseSchemaTable(tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		var objectID int64
+		if err := c.db.QueryRowContext(ctx,
+			"SELECT OBJECT_ID FROM ALL_OBJECTS WHERE OWNER = :1 AND OBJECT_NAME = :2 AND OBJECT_TYPE = 'TABLE'",
+			schemaTable.Schema, schemaTable.Table,
+		).Scan(&objectID); err != nil {
+			return nil, fmt.Errorf("failed to get object id of table %s: %w", tableName, err)
+		}
+
+		if supplementalAll != "YES" {
+			var logGroups int
+			if err := c.db.QueryRowContext(ctx,
+				`SELECT COUNT(*) FROM ALL_LOG_GROUPS
+				WHERE OWNER = :1 AND TABLE_NAME = :2 AND LOG_GROUP_TYPE = 'ALL COLUMN LOGGING'`,
+				schemaTable.Schema, schemaTable.Table,
+			).Scan(&logGroups); err != nil {
+				return nil, fmt.Errorf("failed to check supplemental logging of table %s: %w", tableName, err)
+			}
+			if logGroups == 0 {
+				return nil, fmt.Errorf("table %s needs supplemental logging of all columns, "+
+					"enable it with ALTER TABLE %s ADD SUPPLEMENTAL LOG DATA (ALL) COLUMNS", tableName, tableName)
+			}
+		}
+
+		tableIdentifierMapping[tableName] = &protos.PostgresTableIdentifier{RelId: uint32(objectID)}
+	}
+	return &protos.EnsurePullabilityBatchOutput{TableIdentifierMapping: tableIdentifierMapping}, nil
+}
+
+func (c *OracleConnector) ExportTxSnapshot(context.Context) (*protos.ExportTxSnapshotOutput, any, error) {
+	return nil, nil, errors.New("oracle peers do not support initial snapshot")
+}
+
+func (c *OracleConnector) FinishExport(any) error {
+	return nil
+}
+
+func (c *OracleConnector) SetupReplConn(context.Context) error {
+	return nil
+}
+
+func (c *OracleConnector) ReplPing(context.Context) error {
+	return nil
+}
+
+func (c *OracleConnector) UpdateReplStateLastOffset(int64) {
+}
+
+// PullFlowCleanup forgets the SCN mining of a mirror without a checkpoint starts from,
+// there is nothing to drop on the source
+func (c *OracleConnector) PullFlowCleanup(ctx context.Context, jobName string) error {
+	catalogPool, err := peerdbenv.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create catalog connection pool: %w", err)
+	}
+	if _, err := catalogPool.Exec(ctx, "DELETE FROM oracle_start_scn WHERE flow_name = $1", jobName); err != nil {
+		return fmt.Errorf("failed to delete start scn of %s: %w", jobName, err)
+	}
+	return nil
+}
+
+func (c *OracleConnector) HandleSlotInfo(
+	context.Context,
+	*alerting.Alerter,
+	*pgxpool.Pool,
+	string,
+	string,
+	peerdb_gauges.SlotMetricGauges,
+) error {
+	return nil
+}
+
+func (c *OracleConnector) GetSlotInfo(context.Context, string) ([]*protos.SlotInfo, error) {
+	return nil, nil
+}
+
+func (c *OracleConnector) AddTablesToPublication(context.Context, *protos.AddTablesToPublicationInput) error {
+	return nil
+}
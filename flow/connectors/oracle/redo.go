@@ -0,0 +1,460 @@
+package connoracle
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type redoOperation int8
+
+const (
+	redoInsert redoOperation = iota
+	redoUpdate
+	redoDelete
+)
+
+// redoValue is a literal of SQL_REDO, functions like TO_DATE are reduced to their text argument
+type redoValue struct {
+	text string
+	null bool
+	// EMPTY_CLOB() and alike, the LOB is written by later operations
+	unavailable bool
+}
+
+// redoStatement is a statement of SQL_REDO,
+// newValues are the values inserted or set while oldValues are the conditions of updates and deletes
+type redoStatement struct {
+	operation redoOperation
+	owner     string
+	table     string
+	newValues map[string]redoValue
+	oldValues map[string]redoValue
+}
+
+type redoTokenKind int8
+
+const (
+	redoTokenWord redoTokenKind = iota
+	redoTokenIdentifier
+	redoTokenString
+	redoTokenSymbol
+)
+
+type redoToken struct {
+	text string
+	kind redoTokenKind
+}
+
+func tokenizeRedo(sql string) ([]redoToken, error) {
+	var tokens []redoToken
+	for i := 0; i < len(sql); {
+		switch ch := sql[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i += 1
+		case ch == '"':
+			end := strings.IndexByte(sql[i+1:], '"')
+			if end < 0 {
+				return nil, errors.New("unterminated identifier in redo")
+			}
+			tokens = append(tokens, redoToken{kind: redoTokenIdentifier, text: sql[i+1 : i+1+end]})
+			i += end + 2
+		case ch == '\'':
+			var text strings.Builder
+			j := i + 1
+			for {
+				end := strings.IndexByte(sql[j:], '\'')
+				if end < 0 {
+					return nil, errors.New("unterminated string in redo")
+				}
+				text.WriteString(sql[j : j+end])
+				j += end + 1
+				if j < len(sql) && sql[j] == '\'' {
+					text.WriteByte('\'')
+					j += 1
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, redoToken{kind: redoTokenString, text: text.String()})
+			i = j
+		case ch == '|' && i+1 < len(sql) && sql[i+1] == '|':
+			tokens = append(tokens, redoToken{kind: redoTokenSymbol, text: "||"})
+			i += 2
+		case strings.IndexByte("(),=;.", ch) >= 0:
+			tokens = append(tokens, redoToken{kind: redoTokenSymbol, text: sql[i : i+1]})
+			i += 1
+		default:
+			j := i + 1
+			for j < len(sql) && strings.IndexByte(" \t\n\r\"'|(),=;", sql[j]) < 0 {
+				j += 1
+			}
+			tokens = append(tokens, redoToken{kind: redoTokenWord, text: sql[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type redoParser struct {
+	tokens []redoToken
+	pos    int
+}
+
+func (p *redoParser) peek() (redoToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return redoToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *redoParser) next() (redoToken, error) {
+	token, ok := p.peek()
+	if !ok {
+		return redoToken{}, errors.New("unexpected end of redo")
+	}
+	p.pos += 1
+	return token, nil
+}
+
+func (p *redoParser) isSymbol(symbol string) bool {
+	token, ok := p.peek()
+	return ok && token.kind == redoTokenSymbol && token.text == symbol
+}
+
+func (p *redoParser) isWord(word string) bool {
+	token, ok := p.peek()
+	return ok && token.kind == redoTokenWord && strings.EqualFold(token.text, word)
+}
+
+func (p *redoParser) expectSymbol(symbol string) error {
+	if !p.isSymbol(symbol) {
+		return fmt.Errorf("expected %s in redo", symbol)
+	}
+	p.pos += 1
+	return nil
+}
+
+func (p *redoParser) expectWord(word string) error {
+	if !p.isWord(word) {
+		return fmt.Errorf("expected %s in redo", word)
+	}
+	p.pos += 1
+	return nil
+}
+
+func (p *redoParser) identifier() (string, error) {
+	token, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if token.kind != redoTokenIdentifier && token.kind != redoTokenWord {
+		return "", fmt.Errorf("expected identifier in redo, got %s", token.text)
+	}
+	return token.text, nil
+}
+
+func (p *redoParser) table() (string, string, error) {
+	owner, err := p.identifier()
+	if err != nil {
+		return "", "", err
+	}
+	if err := p.expectSymbol("."); err != nil {
+		return "", "", err
+	}
+	table, err := p.identifier()
+	if err != nil {
+		return "", "", err
+	}
+	return owner, table, nil
+}
+
+// value parses a literal, a function of literals or a concatenation of them
+func (p *redoParser) value() (redoValue, error) {
+	value, err := p.primary()
+	if err != nil {
+		return redoValue{}, err
+	}
+	for p.isSymbol("||") {
+		p.pos += 1
+		operand, err := p.primary()
+		if err != nil {
+			return redoValue{}, err
+		}
+		value = redoValue{
+			text:        value.text + operand.text,
+			null:        value.null && operand.null,
+			unavailable: value.unavailable || operand.unavailable,
+		}
+	}
+	return value, nil
+}
+
+func (p *redoParser) primary() (redoValue, error) {
+	token, err := p.next()
+	if err != nil {
+		return redoValue{}, err
+	}
+	switch token.kind {
+	case redoTokenString:
+		return redoValue{text: token.text}, nil
+	case redoTokenWord:
+		if !p.isSymbol("(") {
+			if strings.EqualFold(token.text, "NULL") {
+				return redoValue{null: true}, nil
+			}
+			// unquoted numbers
+			return redoValue{text: token.text}, nil
+		}
+		p.pos += 1
+		var args []redoValue
+		for !p.isSymbol(")") {
+			if len(args) > 0 {
+				if err := p.expectSymbol(","); err != nil {
+					return redoValue{}, err
+				}
+			}
+			arg, err := p.value()
+			if err != nil {
+				return redoValue{}, err
+			}
+			args = append(args, arg)
+		}
+		p.pos += 1
+		return redoFunction(strings.ToUpper(token.text), args)
+	default:
+		return redoValue{}, fmt.Errorf("unexpected %s in redo", token.text)
+	}
+}
+
+func redoFunction(name string, args []redoValue) (redoValue, error) {
+	switch name {
+	case "EMPTY_CLOB", "EMPTY_BLOB":
+		return redoValue{unavailable: true}, nil
+	case "TO_DATE", "TO_TIMESTAMP", "TO_TIMESTAMP_TZ", "TO_DSINTERVAL", "TO_YMINTERVAL", "HEXTORAW", "TO_NUMBER":
+		// formats are the ones of the mining session
+		if len(args) == 0 {
+			return redoValue{}, fmt.Errorf("%s without arguments in redo", name)
+		}
+		return args[0], nil
+	case "CHR":
+		if len(args) != 1 {
+			return redoValue{}, errors.New("CHR needs one argument in redo")
+		}
+		code, err := strconv.ParseInt(args[0].text, 10, 32)
+		if err != nil {
+			return redoValue{}, fmt.Errorf("invalid CHR in redo: %w", err)
+		}
+		return redoValue{text: string(rune(code))}, nil
+	case "UNISTR":
+		if len(args) != 1 {
+			return redoValue{}, errors.New("UNISTR needs one argument in redo")
+		}
+		text, err := decodeUnistr(args[0].text)
+		if err != nil {
+			return redoValue{}, err
+		}
+		return redoValue{text: text}, nil
+	default:
+		return redoValue{}, fmt.Errorf("unsupported function %s in redo", name)
+	}
+}
+
+// decodeUnistr decodes the \XXXX UTF-16 escapes of UNISTR
+func decodeUnistr(s string) (string, error) {
+	var sb strings.Builder
+	var high rune
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			sb.WriteRune(r)
+			i += size
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\\' {
+			sb.WriteByte('\\')
+			i += 2
+			continue
+		}
+		if i+5 > len(s) {
+			return "", errors.New("truncated escape in UNISTR")
+		}
+		code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in UNISTR: %w", err)
+		}
+		i += 5
+		switch r := rune(code); {
+		case r >= 0xd800 && r < 0xdc00:
+			high = r
+		case r >= 0xdc00 && r < 0xe000 && high != 0:
+			sb.WriteRune((high-0xd800)<<10 + (r - 0xdc00) + 0x10000)
+			high = 0
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String(), nil
+}
+
+// conditions parses the where clause of updates and deletes, ROWID is dropped
+func (p *redoParser) conditions() (map[string]redoValue, error) {
+	values := make(map[string]redoValue)
+	for {
+		column, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		if p.isWord("IS") {
+			p.pos += 1
+			if err := p.expectWord("NULL"); err != nil {
+				return nil, err
+			}
+			values[column] = redoValue{null: true}
+		} else {
+			if err := p.expectSymbol("="); err != nil {
+				return nil, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			if column != "ROWID" {
+				values[column] = value
+			}
+		}
+		if !p.isWord("and") {
+			return values, nil
+		}
+		p.pos += 1
+	}
+}
+
+func (p *redoParser) end() error {
+	if p.isSymbol(";") {
+		p.pos += 1
+	}
+	if token, ok := p.peek(); ok {
+		return fmt.Errorf("unexpected %s at end of redo", token.text)
+	}
+	return nil
+}
+
+// parseRedo parses the SQL_REDO of inserts, updates and deletes
+func parseRedo(sql string) (*redoStatement, error) {
+	tokens, err := tokenizeRedo(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &redoParser{tokens: tokens}
+	stmt := &redoStatement{}
+
+	switch {
+	case p.isWord("insert"):
+		p.pos += 1
+		stmt.operation = redoInsert
+		if err := p.expectWord("into"); err != nil {
+			return nil, err
+		}
+		if stmt.owner, stmt.table, err = p.table(); err != nil {
+			return nil, err
+		}
+		var columns []string
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		for !p.isSymbol(")") {
+			if len(columns) > 0 {
+				if err := p.expectSymbol(","); err != nil {
+					return nil, err
+				}
+			}
+			column, err := p.identifier()
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, column)
+		}
+		p.pos += 1
+		if err := p.expectWord("values"); err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		stmt.newValues = make(map[string]redoValue, len(columns))
+		for i, column := range columns {
+			if i > 0 {
+				if err := p.expectSymbol(","); err != nil {
+					return nil, err
+				}
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			stmt.newValues[column] = value
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+	case p.isWord("update"):
+		p.pos += 1
+		stmt.operation = redoUpdate
+		if stmt.owner, stmt.table, err = p.table(); err != nil {
+			return nil, err
+		}
+		if err := p.expectWord("set"); err != nil {
+			return nil, err
+		}
+		stmt.newValues = make(map[string]redoValue)
+		for {
+			column, err := p.identifier()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectSymbol("="); err != nil {
+				return nil, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			stmt.newValues[column] = value
+			if !p.isSymbol(",") {
+				break
+			}
+			p.pos += 1
+		}
+		if p.isWord("where") {
+			p.pos += 1
+			if stmt.oldValues, err = p.conditions(); err != nil {
+				return nil, err
+			}
+		}
+	case p.isWord("delete"):
+		p.pos += 1
+		stmt.operation = redoDelete
+		if err := p.expectWord("from"); err != nil {
+			return nil, err
+		}
+		if stmt.owner, stmt.table, err = p.table(); err != nil {
+			return nil, err
+		}
+		if err := p.expectWord("where"); err != nil {
+			return nil, err
+		}
+		if stmt.oldValues, err = p.conditions(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("redo is not an insert, update or delete")
+	}
+
+	if err := p.end(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
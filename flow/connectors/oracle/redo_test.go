@@ -0,0 +1,93 @@
+package connoracle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestParseRedo(t *testing.T) {
+	stmt, err := parseRedo(`insert into "APP"."ORDERS"("ID","NOTE","CREATED","DATA") values ` +
+		`('1','it''s' || CHR(10) || UNISTR('\00e9'),TO_DATE('2024-05-01 10:00:00', 'YYYY-MM-DD HH24:MI:SS'),` +
+		`HEXTORAW('00ff'));`)
+	require.NoError(t, err)
+	require.Equal(t, redoInsert, stmt.operation)
+	require.Equal(t, "APP", stmt.owner)
+	require.Equal(t, "ORDERS", stmt.table)
+	require.Equal(t, map[string]redoValue{
+		"ID":      {text: "1"},
+		"NOTE":    {text: "it's\né"},
+		"CREATED": {text: "2024-05-01 10:00:00"},
+		"DATA":    {text: "00ff"},
+	}, stmt.newValues)
+
+	stmt, err = parseRedo(`update "APP"."ORDERS" set "NOTE" = NULL, "BODY" = EMPTY_CLOB() ` +
+		`where "ID" = '1' and "NOTE" = 'a' and "CREATED" IS NULL and ROWID = 'AAAR3sAAEAAAACXAAA';`)
+	require.NoError(t, err)
+	require.Equal(t, redoUpdate, stmt.operation)
+	require.Equal(t, map[string]redoValue{"NOTE": {null: true}, "BODY": {unavailable: true}}, stmt.newValues)
+	require.Equal(t, map[string]redoValue{"ID": {text: "1"}, "NOTE": {text: "a"}, "CREATED": {null: true}},
+		stmt.oldValues)
+
+	stmt, err = parseRedo(`delete from "APP"."ORDERS" where "ID" = '2';`)
+	require.NoError(t, err)
+	require.Equal(t, redoDelete, stmt.operation)
+	require.Equal(t, map[string]redoValue{"ID": {text: "2"}}, stmt.oldValues)
+
+	_, err = parseRedo(`alter table "APP"."ORDERS" add ("X" NUMBER);`)
+	require.Error(t, err)
+	_, err = parseRedo(`insert into "APP"."ORDERS"("ID") values ('1`)
+	require.Error(t, err)
+}
+
+func TestRedoRecord(t *testing.T) {
+	req := &model.PullRecordsRequest[model.RecordItems]{
+		TableNameMapping: map[string]model.NameAndExclude{
+			"APP.ORDERS": model.NewNameAndExclude("orders", nil),
+		},
+		TableNameSchemaMapping: map[string]*protos.TableSchema{
+			"orders": {
+				TableIdentifier:   "APP.ORDERS",
+				PrimaryKeyColumns: []string{"ID"},
+				System:            protos.TypeSystem_Q,
+				Columns: []*protos.FieldDescription{
+					{Name: "ID", Type: string(qvalue.QValueKindInt64)},
+					{Name: "NOTE", Type: string(qvalue.QValueKindString)},
+					{Name: "CREATED", Type: string(qvalue.QValueKindTimestamp)},
+					{Name: "BODY", Type: string(qvalue.QValueKindString)},
+				},
+			},
+		},
+	}
+	base := model.BaseRecord{CheckpointID: 42}
+
+	record, err := redoRecord(`update "APP"."ORDERS" set "NOTE" = 'b' where "ID" = '1' and "NOTE" = 'a' `+
+		`and "CREATED" = TO_DATE('2024-05-01 10:00:00', 'YYYY-MM-DD HH24:MI:SS');`, "APP.ORDERS", req, base)
+	require.NoError(t, err)
+	update, ok := record.(*model.UpdateRecord[model.RecordItems])
+	require.True(t, ok)
+	require.Equal(t, "orders", update.DestinationTableName)
+	require.Equal(t, map[string]struct{}{"BODY": {}}, update.UnchangedToastColumns)
+	require.Equal(t, qvalue.QValueInt64{Val: 1}, update.NewItems.GetColumnValue("ID"))
+	require.Equal(t, qvalue.QValueString{Val: "b"}, update.NewItems.GetColumnValue("NOTE"))
+	require.Equal(t, qvalue.QValueTimestamp{Val: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		update.NewItems.GetColumnValue("CREATED"))
+	require.Equal(t, qvalue.QValueString{Val: "a"}, update.OldItems.GetColumnValue("NOTE"))
+
+	record, err = redoRecord(`insert into "APP"."ORDERS"("ID","BODY") values ('2',EMPTY_CLOB());`,
+		"APP.ORDERS", req, base)
+	require.NoError(t, err)
+	insert, ok := record.(*model.InsertRecord[model.RecordItems])
+	require.True(t, ok)
+	require.Equal(t, int64(42), insert.CommitID)
+	require.Equal(t, qvalue.QValueNull(qvalue.QValueKindString), insert.Items.GetColumnValue("BODY"))
+
+	record, err = redoRecord(`delete from "APP"."OTHER" where "ID" = '2';`, "APP.OTHER", req, base)
+	require.NoError(t, err)
+	require.Nil(t, record)
+}
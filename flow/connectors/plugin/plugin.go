@@ -0,0 +1,363 @@
+// Package connplugin runs destination connectors built out of tree as separate processes, speaking the
+// ConnectorPlugin gRPC service of plugin.proto. The worker starts the executable peerdb-plugin-<name>
+// of PEERDB_PLUGIN_DIR for peers of type PLUGIN, which prints a handshake line with the socket it serves on.
+package connplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"time"
+
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+const (
+	batchRecords = 256
+	batchBytes   = 1 << 20
+)
+
+type PluginConnector struct {
+	*metadataStore.PostgresMetadata
+	process *process
+	target  *protos.PluginTarget
+	logger  log.Logger
+}
+
+func NewPluginConnector(ctx context.Context, peerName string, config *protos.PluginConfig) (*PluginConnector, error) {
+	options, err := mergeOptions(config.Options, config.SecretOptions)
+	if err != nil {
+		return nil, err
+	}
+	p, err := getProcess(ctx, config.Plugin)
+	if err != nil {
+		return nil, err
+	}
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &PluginConnector{
+		PostgresMetadata: pgMetadata,
+		process:          p,
+		target:           &protos.PluginTarget{PeerName: peerName, Options: options},
+		logger:           logger.LoggerFromCtx(ctx),
+	}, nil
+}
+
+// mergeOptions merges the JSON objects of options and secret options of a peer
+func mergeOptions(options string, secretOptions string) (map[string]string, error) {
+	merged := make(map[string]string)
+	for _, raw := range []string{options, secretOptions} {
+		if raw == "" {
+			continue
+		}
+		var parsed map[string]string
+		if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+			return nil, fmt.Errorf("plugin options must be a JSON object of strings: %w", err)
+		}
+		maps.Copy(merged, parsed)
+	}
+	return merged, nil
+}
+
+// Close leaves the process running, other connectors of the plugin share it
+func (c *PluginConnector) Close() error {
+	return nil
+}
+
+func (c *PluginConnector) ConnectionActive(ctx context.Context) error {
+	select {
+	case <-c.process.exited:
+		return fmt.Errorf("plugin %s exited", c.process.name)
+	default:
+	}
+	_, err := c.process.client.Handshake(ctx, &protos.PluginHandshakeRequest{ProtocolVersion: ProtocolVersion})
+	return err
+}
+
+func (c *PluginConnector) ValidateCheck(ctx context.Context) error {
+	if _, err := c.process.client.Validate(ctx, &protos.PluginValidateRequest{Target: c.target}); err != nil {
+		return fmt.Errorf("plugin %s failed validation: %w", c.process.name, err)
+	}
+	return nil
+}
+
+func (c *PluginConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *PluginConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *PluginConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (c *PluginConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *PluginConnector) SetupNormalizedTable(
+	ctx context.Context,
+	_ any,
+	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
+) (bool, error) {
+	resp, err := c.process.client.SetupTable(ctx, &protos.PluginSetupTableRequest{
+		Target:            c.target,
+		FlowJobName:       config.FlowName,
+		TableIdentifier:   tableIdentifier,
+		TableSchema:       config.TableNameSchemaMapping[tableIdentifier],
+		SoftDeleteColName: config.SoftDeleteColName,
+		SyncedAtColName:   config.SyncedAtColName,
+	})
+	if err != nil {
+		return false, fmt.Errorf("plugin %s failed to set up table %s: %w", c.process.name, tableIdentifier, err)
+	}
+	return resp.AlreadyExists, nil
+}
+
+func (c *PluginConnector) ReplayTableSchemaDeltas(ctx context.Context, flowJobName string, schemaDeltas []*protos.TableSchemaDelta) error {
+	if len(schemaDeltas) == 0 {
+		return nil
+	}
+	if _, err := c.process.client.ReplaySchemaDeltas(ctx, &protos.PluginReplaySchemaDeltasRequest{
+		Target:       c.target,
+		FlowJobName:  flowJobName,
+		SchemaDeltas: schemaDeltas,
+	}); err != nil {
+		return fmt.Errorf("plugin %s failed to replay schema deltas: %w", c.process.name, err)
+	}
+	return nil
+}
+
+func (c *PluginConnector) SyncFlowCleanup(ctx context.Context, jobName string) error {
+	if _, err := c.process.client.Cleanup(ctx, &protos.PluginCleanupRequest{
+		Target:      c.target,
+		FlowJobName: jobName,
+	}); err != nil {
+		return fmt.Errorf("plugin %s failed to clean up: %w", c.process.name, err)
+	}
+	return c.PostgresMetadata.SyncFlowCleanup(ctx, jobName)
+}
+
+func encodeRecord(record model.Record[model.RecordItems]) (*protos.PluginRecord, error) {
+	encoded := &protos.PluginRecord{
+		Kind:                 record.Kind(),
+		CheckpointId:         record.GetCheckpointID(),
+		CommitTimeNano:       record.GetCommitTime().UnixNano(),
+		SourceTableName:      record.GetSourceTableName(),
+		DestinationTableName: record.GetDestinationTableName(),
+	}
+	var oldItems, newItems *model.RecordItems
+	switch rec := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		newItems = &rec.Items
+	case *model.UpdateRecord[model.RecordItems]:
+		oldItems = &rec.OldItems
+		newItems = &rec.NewItems
+		for col := range rec.UnchangedToastColumns {
+			encoded.UnchangedColumns = append(encoded.UnchangedColumns, col)
+		}
+	case *model.DeleteRecord[model.RecordItems]:
+		oldItems = &rec.Items
+	default:
+		return nil, nil
+	}
+	var err error
+	if oldItems != nil {
+		if encoded.OldJson, err = oldItems.MarshalJSON(); err != nil {
+			return nil, err
+		}
+	}
+	if newItems != nil {
+		if encoded.NewJson, err = newItems.MarshalJSON(); err != nil {
+			return nil, err
+		}
+	}
+	return encoded, nil
+}
+
+// streamError is the error of a client stream a Send failed on, io.EOF meaning the plugin ended the stream
+func streamError[Resp any](err error, closeAndRecv func() (Resp, error)) error {
+	if errors.Is(err, io.EOF) {
+		if _, recvErr := closeAndRecv(); recvErr != nil {
+			return recvErr
+		}
+	}
+	return err
+}
+
+func (c *PluginConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	if !c.process.info.Cdc {
+		return nil, fmt.Errorf("plugin %s does not support CDC", c.process.name)
+	}
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+
+	stream, err := c.process.client.SyncRecords(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[plugin] failed to start sync: %w", err)
+	}
+	if err := stream.Send(&protos.PluginSyncRecordsMessage{Message: &protos.PluginSyncRecordsMessage_Start{
+		Start: &protos.PluginSyncStart{
+			Target:       c.target,
+			FlowJobName:  req.FlowJobName,
+			SyncBatchId:  req.SyncBatchID,
+			TableSchemas: req.TableNameSchemaMapping,
+		},
+	}}); err != nil {
+		return nil, fmt.Errorf("[plugin] failed to start sync: %w", streamError(err, stream.CloseAndRecv))
+	}
+
+	var numRecords int64
+	batch := make([]*protos.PluginRecord, 0, batchRecords)
+	var size int
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := stream.Send(&protos.PluginSyncRecordsMessage{Message: &protos.PluginSyncRecordsMessage_Records{
+			Records: &protos.PluginRecordBatch{Records: batch},
+		}}); err != nil {
+			return fmt.Errorf("[plugin] failed to send records: %w", streamError(err, stream.CloseAndRecv))
+		}
+		batch = make([]*protos.PluginRecord, 0, batchRecords)
+		size = 0
+		return nil
+	}
+	for record := range req.Records.GetRecords() {
+		encoded, err := encodeRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("[plugin] failed to encode record: %w", err)
+		}
+		if encoded == nil {
+			continue
+		}
+		record.PopulateCountMap(tableNameRowsMapping)
+		numRecords += 1
+		batch = append(batch, encoded)
+		size += len(encoded.OldJson) + len(encoded.NewJson)
+		if len(batch) >= batchRecords || size >= batchBytes {
+			if err := send(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := send(); err != nil {
+		return nil, err
+	}
+	// plugins respond once records are durable, nothing is recorded as synced before that
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return nil, fmt.Errorf("[plugin] failed to sync records: %w", err)
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[plugin] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:     req.SyncBatchID,
+		LastSyncedCheckpointID: lastCheckpoint,
+		NumRecordsSynced:       numRecords,
+		TableNameRowsMapping:   tableNameRowsMapping,
+		TableSchemaDeltas:      req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (*PluginConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *PluginConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int, error) {
+	if !c.process.info.Qrep {
+		return 0, fmt.Errorf("plugin %s does not support query replication", c.process.name)
+	}
+	startTime := time.Now()
+	schema := stream.Schema()
+
+	fields := make([]*protos.PluginField, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		fields = append(fields, &protos.PluginField{Name: field.Name, Type: string(field.Type), Nullable: field.Nullable})
+	}
+	rpcStream, err := c.process.client.SyncQRepRecords(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("[plugin] failed to start qrep sync: %w", err)
+	}
+	if err := rpcStream.Send(&protos.PluginSyncQRepMessage{Message: &protos.PluginSyncQRepMessage_Start{
+		Start: &protos.PluginQRepStart{
+			Target:               c.target,
+			FlowJobName:          config.FlowJobName,
+			PartitionId:          partition.PartitionId,
+			DestinationTableName: config.DestinationTableIdentifier,
+			Fields:               fields,
+			WriteMode:            config.WriteMode,
+		},
+	}}); err != nil {
+		return 0, fmt.Errorf("[plugin] failed to start qrep sync: %w", streamError(err, rpcStream.CloseAndRecv))
+	}
+
+	numRecords := 0
+	rows := make([][]byte, 0, batchRecords)
+	var size int
+	send := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := rpcStream.Send(&protos.PluginSyncQRepMessage{Message: &protos.PluginSyncQRepMessage_Rows{
+			Rows: &protos.PluginRowBatch{RowsJson: rows},
+		}}); err != nil {
+			return fmt.Errorf("[plugin] failed to send rows: %w", streamError(err, rpcStream.CloseAndRecv))
+		}
+		rows = make([][]byte, 0, batchRecords)
+		size = 0
+		return nil
+	}
+	for qrecord := range stream.Records {
+		items := model.NewRecordItems(len(qrecord))
+		for i, val := range qrecord {
+			items.AddColumn(schema.Fields[i].Name, val)
+		}
+		row, err := items.MarshalJSON()
+		if err != nil {
+			return 0, fmt.Errorf("[plugin] failed to encode row: %w", err)
+		}
+		rows = append(rows, row)
+		size += len(row)
+		numRecords += 1
+		if len(rows) >= batchRecords || size >= batchBytes {
+			if err := send(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	if err := send(); err != nil {
+		return 0, err
+	}
+	if _, err := rpcStream.CloseAndRecv(); err != nil {
+		return 0, fmt.Errorf("[plugin] failed to sync rows: %w", err)
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
@@ -0,0 +1,95 @@
+package connplugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestParseHandshake(t *testing.T) {
+	target, err := parseHandshake("1|unix|/tmp/peerdb-plugin-x/plugin.sock\n")
+	require.NoError(t, err)
+	require.Equal(t, "unix:///tmp/peerdb-plugin-x/plugin.sock", target)
+
+	target, err = parseHandshake("1|tcp|127.0.0.1:4000")
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:4000", target)
+
+	_, err = parseHandshake("2|unix|/tmp/plugin.sock")
+	require.ErrorContains(t, err, "protocol version 2")
+	_, err = parseHandshake("listening on /tmp/plugin.sock")
+	require.Error(t, err)
+	_, err = parseHandshake("1|udp|127.0.0.1:4000")
+	require.Error(t, err)
+}
+
+func TestMergeOptions(t *testing.T) {
+	options, err := mergeOptions(`{"endpoint":"https://example.com","token":"public"}`, `{"token":"secret"}`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"endpoint": "https://example.com", "token": "secret"}, options)
+
+	options, err = mergeOptions("", "")
+	require.NoError(t, err)
+	require.Empty(t, options)
+
+	_, err = mergeOptions(`{"batch":1}`, "")
+	require.Error(t, err)
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "peerdb-plugin-acme"), []byte("#!/bin/sh\n"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "peerdb-plugin-readme"), []byte("not executable"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other"), []byte("#!/bin/sh\n"), 0o755))
+	t.Setenv("PEERDB_PLUGIN_DIR", dir)
+
+	names, err := Discover()
+	require.NoError(t, err)
+	require.Equal(t, []string{"acme"}, names)
+
+	_, err = executable("acme")
+	require.NoError(t, err)
+	_, err = executable("../acme")
+	require.Error(t, err)
+	_, err = executable("missing")
+	require.Error(t, err)
+}
+
+func TestEncodeRecord(t *testing.T) {
+	oldItems := model.NewRecordItems(2)
+	oldItems.AddColumn("id", qvalue.QValueInt64{Val: 1})
+	oldItems.AddColumn("name", qvalue.QValueString{Val: "old"})
+	newItems := model.NewRecordItems(2)
+	newItems.AddColumn("id", qvalue.QValueInt64{Val: 1})
+	newItems.AddColumn("name", qvalue.QValueString{Val: "new"})
+
+	encoded, err := encodeRecord(&model.UpdateRecord[model.RecordItems]{
+		OldItems:              oldItems,
+		NewItems:              newItems,
+		UnchangedToastColumns: map[string]struct{}{"blob": {}},
+		SourceTableName:       "public.users",
+		DestinationTableName:  "users",
+		BaseRecord:            model.BaseRecord{CheckpointID: 42, CommitTimeNano: 1000},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "update", encoded.Kind)
+	require.Equal(t, int64(42), encoded.CheckpointId)
+	require.Equal(t, int64(1000), encoded.CommitTimeNano)
+	require.Equal(t, "users", encoded.DestinationTableName)
+	require.JSONEq(t, `{"id":1,"name":"old"}`, string(encoded.OldJson))
+	require.JSONEq(t, `{"id":1,"name":"new"}`, string(encoded.NewJson))
+	require.Equal(t, []string{"blob"}, encoded.UnchangedColumns)
+
+	encoded, err = encodeRecord(&model.DeleteRecord[model.RecordItems]{Items: oldItems, DestinationTableName: "users"})
+	require.NoError(t, err)
+	require.Empty(t, encoded.NewJson)
+
+	encoded, err = encodeRecord(&model.MessageRecord[model.RecordItems]{})
+	require.NoError(t, err)
+	require.Nil(t, encoded)
+}
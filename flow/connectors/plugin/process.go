@@ -0,0 +1,229 @@
+package connplugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+const (
+	// ProtocolVersion is bumped on incompatible changes of the ConnectorPlugin service
+	ProtocolVersion = 1
+
+	// MagicCookieKey and MagicCookieValue are set in the env of plugins the worker starts,
+	// so plugin executables can tell they were not run directly
+	MagicCookieKey   = "PEERDB_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "a2f7c1e6-peerdb-connector-plugin"
+
+	executablePrefix = "peerdb-plugin-"
+	handshakeTimeout = 30 * time.Second
+	maxMessageSize   = 64 << 20
+)
+
+// process is a running plugin executable, shared by every connector of peers using the plugin
+type process struct {
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	conn   *grpc.ClientConn
+	client protos.ConnectorPluginClient
+	info   *protos.PluginHandshakeResponse
+	exited chan struct{}
+}
+
+var (
+	processesLock sync.Mutex
+	processes     = make(map[string]*process)
+)
+
+// Discover lists names of plugins in PEERDB_PLUGIN_DIR
+func Discover() ([]string, error) {
+	dir := peerdbenv.PeerDBPluginDir()
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin dir %s: %w", dir, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), executablePrefix)
+		if !ok || name == "" || entry.IsDir() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.Mode()&0o111 != 0 {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func executable(name string) (string, error) {
+	dir := peerdbenv.PeerDBPluginDir()
+	if dir == "" {
+		return "", errors.New("connector plugins are disabled, PEERDB_PLUGIN_DIR is not set")
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid plugin name %q", name)
+	}
+	path := filepath.Join(dir, executablePrefix+name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("plugin %s not found: %w", name, err)
+	}
+	return path, nil
+}
+
+// getProcess returns the process of plugin name, starting it when not running
+func getProcess(ctx context.Context, name string) (*process, error) {
+	processesLock.Lock()
+	defer processesLock.Unlock()
+	if p, ok := processes[name]; ok {
+		select {
+		case <-p.exited:
+			p.conn.Close()
+			delete(processes, name)
+		default:
+			return p, nil
+		}
+	}
+	p, err := startProcess(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	processes[name] = p
+	return p, nil
+}
+
+func startProcess(ctx context.Context, name string) (*process, error) {
+	path, err := executable(name)
+	if err != nil {
+		return nil, err
+	}
+	// not bound to ctx, the process outlives the activity starting it
+	cmd := exec.Command(path)
+	cmd.Env = append(os.Environ(), MagicCookieKey+"="+MagicCookieValue)
+	// plugins exit once stdin is closed, so they do not outlive the worker
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", name, err)
+	}
+	p := &process{name: name, cmd: cmd, stdin: stdin, exited: make(chan struct{})}
+	logger := slog.With(slog.String("plugin", name))
+	go logOutput(logger, stderr)
+	go func() {
+		err := cmd.Wait()
+		logger.Warn("plugin exited", slog.Any("error", err))
+		close(p.exited)
+	}()
+
+	lines := make(chan string, 1)
+	out := bufio.NewReader(stdout)
+	go func() {
+		line, _ := out.ReadString('\n')
+		lines <- line
+		// anything printed after the handshake line is logged like stderr
+		logOutput(logger, out)
+	}()
+	var line string
+	select {
+	case line = <-lines:
+	case <-p.exited:
+		return nil, fmt.Errorf("plugin %s exited before handshake", name)
+	case <-time.After(handshakeTimeout):
+		p.kill()
+		return nil, fmt.Errorf("plugin %s did not handshake within %s", name, handshakeTimeout)
+	}
+
+	target, err := parseHandshake(line)
+	if err != nil {
+		p.kill()
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	p.conn, err = grpc.NewClient(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMessageSize), grpc.MaxCallSendMsgSize(maxMessageSize)))
+	if err != nil {
+		p.kill()
+		return nil, fmt.Errorf("failed to connect to plugin %s: %w", name, err)
+	}
+	p.client = protos.NewConnectorPluginClient(p.conn)
+	p.info, err = p.client.Handshake(ctx, &protos.PluginHandshakeRequest{ProtocolVersion: ProtocolVersion})
+	if err != nil {
+		p.kill()
+		return nil, fmt.Errorf("failed to handshake with plugin %s: %w", name, err)
+	}
+	if p.info.ProtocolVersion != ProtocolVersion {
+		p.kill()
+		return nil, fmt.Errorf("plugin %s speaks protocol version %d, worker speaks %d",
+			name, p.info.ProtocolVersion, ProtocolVersion)
+	}
+	logger.Info("started plugin", slog.String("name", p.info.Name),
+		slog.Bool("cdc", p.info.Cdc), slog.Bool("qrep", p.info.Qrep))
+	return p, nil
+}
+
+func (p *process) kill() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.stdin.Close()
+	_ = p.cmd.Process.Kill()
+}
+
+// parseHandshake parses the line plugins print on start, like 1|unix|/tmp/plugin.sock, into a gRPC target
+func parseHandshake(line string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid handshake %q", line)
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid protocol version in handshake %q", line)
+	}
+	if version != ProtocolVersion {
+		return "", fmt.Errorf("plugin speaks protocol version %d, worker speaks %d", version, ProtocolVersion)
+	}
+	switch parts[1] {
+	case "unix":
+		return "unix://" + parts[2], nil
+	case "tcp":
+		return parts[2], nil
+	default:
+		return "", fmt.Errorf("unsupported network %s in handshake", parts[1])
+	}
+}
+
+func logOutput(logger *slog.Logger, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		logger.Info(scanner.Text())
+	}
+}
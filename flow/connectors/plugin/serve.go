@@ -0,0 +1,47 @@
+package connplugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// Serve is what main of a plugin executable calls, serving server until the worker which started it goes away.
+// Plugins embed protos.UnimplementedConnectorPluginServer and implement what they support,
+// with Handshake reporting whether that includes CDC, QRep or both.
+func Serve(server protos.ConnectorPluginServer) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return errors.New("this is a PeerDB connector plugin, it is started by the flow worker rather than directly")
+	}
+
+	dir, err := os.MkdirTemp("", executablePrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(maxMessageSize), grpc.MaxSendMsgSize(maxMessageSize))
+	protos.RegisterConnectorPluginServer(s, server)
+	go func() {
+		// the worker never writes to stdin, it closes once the worker exits
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		s.Stop()
+	}()
+
+	if _, err := fmt.Printf("%d|unix|%s\n", ProtocolVersion, socket); err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
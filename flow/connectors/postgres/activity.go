@@ -0,0 +1,114 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+)
+
+// TableActivity is how large a table is and how many of its rows changed over a window
+type TableActivity struct {
+	Rows    int64
+	Bytes   int64
+	Inserts int64
+	Updates int64
+	Deletes int64
+}
+
+func (a TableActivity) Changes() int64 {
+	return a.Inserts + a.Updates + a.Deletes
+}
+
+// SourceActivity is how much WAL a database wrote and how many rows of its tables changed over a window
+type SourceActivity struct {
+	Tables   map[utils.SchemaTable]TableActivity
+	WALBytes int64
+	Changes  int64
+	Window   time.Duration
+}
+
+type activitySample struct {
+	tables  map[utils.SchemaTable]TableActivity
+	lsn     int64
+	changes int64
+}
+
+func (c *PostgresConnector) sampleActivity(ctx context.Context, schemas []string, tables []string) (activitySample, error) {
+	lsn, err := c.getCurrentLSN(ctx)
+	if err != nil {
+		return activitySample{}, err
+	}
+	sample := activitySample{lsn: int64(lsn), tables: make(map[utils.SchemaTable]TableActivity, len(tables))}
+	if err := c.conn.QueryRow(ctx,
+		"SELECT coalesce(sum(n_tup_ins + n_tup_upd + n_tup_del), 0)::bigint FROM pg_stat_all_tables",
+	).Scan(&sample.changes); err != nil {
+		return activitySample{}, fmt.Errorf("failed to query changes of database: %w", err)
+	}
+
+	// reltuples is -1 for tables never vacuumed or analyzed since PG14
+	rows, err := c.conn.Query(ctx, `SELECT n.nspname, c.relname, greatest(c.reltuples, 0)::bigint, pg_table_size(c.oid),
+		coalesce(s.n_tup_ins, 0), coalesce(s.n_tup_upd, 0), coalesce(s.n_tup_del, 0)
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN unnest($1::text[], $2::text[]) AS t(sname, tname) ON n.nspname = t.sname AND c.relname = t.tname
+		LEFT JOIN pg_stat_all_tables s ON s.relid = c.oid`, schemas, tables)
+	if err != nil {
+		return activitySample{}, fmt.Errorf("failed to query statistics of tables: %w", err)
+	}
+	var table utils.SchemaTable
+	var activity TableActivity
+	if _, err := pgx.ForEachRow(rows, []any{
+		&table.Schema, &table.Table, &activity.Rows, &activity.Bytes, &activity.Inserts, &activity.Updates, &activity.Deletes,
+	}, func() error {
+		sample.tables[table] = activity
+		return nil
+	}); err != nil {
+		return activitySample{}, fmt.Errorf("failed to query statistics of tables: %w", err)
+	}
+	return sample, nil
+}
+
+// SampleActivity measures WAL written and rows changed over window from statistics of the database,
+// rows changed only count once statistics are flushed, which backends do about every second
+func (c *PostgresConnector) SampleActivity(
+	ctx context.Context, tableNames []*utils.SchemaTable, window time.Duration,
+) (*SourceActivity, error) {
+	schemas := make([]string, 0, len(tableNames))
+	tables := make([]string, 0, len(tableNames))
+	for _, parsedTable := range tableNames {
+		schemas = append(schemas, parsedTable.Schema)
+		tables = append(tables, parsedTable.Table)
+	}
+
+	start := time.Now()
+	first, err := c.sampleActivity(ctx, schemas, tables)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(window):
+	}
+	second, err := c.sampleActivity(ctx, schemas, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := &SourceActivity{
+		Tables:   make(map[utils.SchemaTable]TableActivity, len(second.tables)),
+		WALBytes: max(second.lsn-first.lsn, 0),
+		Changes:  max(second.changes-first.changes, 0),
+		Window:   time.Since(start),
+	}
+	for table, tableActivity := range second.tables {
+		tableActivity.Inserts = max(tableActivity.Inserts-first.tables[table].Inserts, 0)
+		tableActivity.Updates = max(tableActivity.Updates-first.tables[table].Updates, 0)
+		tableActivity.Deletes = max(tableActivity.Deletes-first.tables[table].Deletes, 0)
+		activity.Tables[table] = tableActivity
+	}
+	return activity, nil
+}
@@ -0,0 +1,103 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// ExistingSlot is a logical replication slot of the database created outside of PeerDB
+type ExistingSlot struct {
+	Name              string
+	Plugin            string
+	ConfirmedFlushLSN string
+	WALStatus         string
+	Active            bool
+	Temporary         bool
+	// invalidated by a conflict with recovery on a standby
+	Conflicting bool
+}
+
+// ExistingPublication is a publication of the database with the tables it publishes
+type ExistingPublication struct {
+	Name      string
+	Tables    []string
+	AllTables bool
+	Insert    bool
+	Update    bool
+	Delete    bool
+}
+
+// AdoptionBlocker returns why a mirror can't take over slot, empty when it can.
+// Mirrors decode with pgoutput or wal2json, slots of other output plugins like decoderbufs can't be read by them
+func (slot ExistingSlot) AdoptionBlocker() string {
+	switch {
+	case slot.Plugin != pluginPgoutput && slot.Plugin != pluginWal2json:
+		return fmt.Sprintf("slot uses output plugin %s, mirrors need pgoutput or wal2json", slot.Plugin)
+	case slot.Temporary:
+		return "slot is temporary, it goes away with the session of its creator"
+	case slot.WALStatus == "lost":
+		return "slot lost WAL it needs, its changes can't be replicated anymore"
+	case slot.Conflicting:
+		return "slot was invalidated by a conflict with recovery on the standby, its changes can't be replicated anymore"
+	default:
+		return ""
+	}
+}
+
+// ExistingReplication returns logical replication slots of the database, besides those of mirrors,
+// and its publications, so mirrors can take over replication other tools set up
+func (c *PostgresConnector) ExistingReplication(ctx context.Context) ([]ExistingSlot, []ExistingPublication, error) {
+	pgversion, err := c.MajorVersion(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	walStatus := "''"
+	if pgversion >= shared.POSTGRES_13 {
+		walStatus = "coalesce(wal_status, '')"
+	}
+	conflicting := "false"
+	if pgversion >= shared.POSTGRES_16 {
+		conflicting = "coalesce(conflicting, false)"
+	}
+	rows, err := c.conn.Query(ctx, `SELECT slot_name, coalesce(plugin, ''), coalesce(confirmed_flush_lsn::text, ''),
+		`+walStatus+`, active, temporary, `+conflicting+`
+		FROM pg_replication_slots
+		WHERE slot_type = 'logical' AND database = current_database() AND slot_name NOT LIKE 'peerflow\_slot\_%'
+		ORDER BY slot_name`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query replication slots: %w", err)
+	}
+	slots, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ExistingSlot, error) {
+		var slot ExistingSlot
+		err := row.Scan(&slot.Name, &slot.Plugin, &slot.ConfirmedFlushLSN, &slot.WALStatus, &slot.Active, &slot.Temporary,
+			&slot.Conflicting)
+		return slot, err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query replication slots: %w", err)
+	}
+
+	rows, err = c.conn.Query(ctx, `SELECT p.pubname, p.puballtables, p.pubinsert, p.pubupdate, p.pubdelete,
+		coalesce(array_agg(t.schemaname || '.' || t.tablename ORDER BY t.schemaname, t.tablename)
+			FILTER (WHERE t.tablename IS NOT NULL), '{}')
+		FROM pg_publication p LEFT JOIN pg_publication_tables t ON t.pubname = p.pubname
+		GROUP BY p.pubname, p.puballtables, p.pubinsert, p.pubupdate, p.pubdelete
+		ORDER BY p.pubname`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query publications: %w", err)
+	}
+	publications, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ExistingPublication, error) {
+		var publication ExistingPublication
+		err := row.Scan(&publication.Name, &publication.AllTables, &publication.Insert, &publication.Update,
+			&publication.Delete, &publication.Tables)
+		return publication, err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query publications: %w", err)
+	}
+	return slots, publications, nil
+}
@@ -0,0 +1,16 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdoptionBlocker(t *testing.T) {
+	require.Empty(t, ExistingSlot{Name: "debezium", Plugin: "pgoutput", WALStatus: "reserved"}.AdoptionBlocker())
+	require.Contains(t, ExistingSlot{Name: "debezium", Plugin: "decoderbufs"}.AdoptionBlocker(), "decoderbufs")
+	require.Empty(t, ExistingSlot{Name: "legacy", Plugin: "wal2json", WALStatus: "reserved"}.AdoptionBlocker())
+	require.NotEmpty(t, ExistingSlot{Name: "tmp", Plugin: "pgoutput", Temporary: true}.AdoptionBlocker())
+	require.NotEmpty(t, ExistingSlot{Name: "old", Plugin: "pgoutput", WALStatus: "lost"}.AdoptionBlocker())
+	require.NotEmpty(t, ExistingSlot{Name: "standby", Plugin: "pgoutput", Conflicting: true}.AdoptionBlocker())
+}
@@ -0,0 +1,43 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func (c *PostgresConnector) ListStagingArtifacts(ctx context.Context) ([]*protos.StagingArtifact, error) {
+	rows, err := c.conn.Query(ctx, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = $1 AND table_name LIKE '\_peerdb\_raw\_%'`, c.metadataSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list raw tables: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.StagingArtifact, error) {
+		var table string
+		if err := row.Scan(&table); err != nil {
+			return nil, err
+		}
+		return &protos.StagingArtifact{
+			Kind:     protos.StagingArtifactKind_STAGING_ARTIFACT_RAW_TABLE,
+			Name:     c.metadataSchema + "." + table,
+			FlowName: strings.TrimPrefix(table, rawTablePrefix+"_"),
+		}, nil
+	})
+}
+
+func (c *PostgresConnector) DropStagingArtifact(ctx context.Context, artifact *protos.StagingArtifact) error {
+	schemaTable, err := utils.ParseSchemaTable(artifact.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s.%s",
+		QuoteIdentifier(schemaTable.Schema), QuoteIdentifier(schemaTable.Table))); err != nil {
+		return fmt.Errorf("failed to drop raw table %s: %w", artifact.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+const batchControlTable = "peerdb_batches"
+
+// RecordNormalizedBatch writes batch to peerdb_batches in the metadata schema, a retried normalize keeps the first row
+func (c *PostgresConnector) RecordNormalizedBatch(ctx context.Context, flowJobName string, batch *model.NormalizedBatch) error {
+	table := QuoteIdentifier(c.metadataSchema) + "." + QuoteIdentifier(batchControlTable)
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		flow_name TEXT NOT NULL,
+		batch_id BIGINT NOT NULL,
+		start_batch_id BIGINT NOT NULL,
+		start_lsn BIGINT NOT NULL,
+		end_lsn BIGINT NOT NULL,
+		tables JSONB NOT NULL,
+		num_rows BIGINT NOT NULL,
+		normalized_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (flow_name, batch_id))`, table)); err != nil {
+		return fmt.Errorf("failed to create batch control table: %w", err)
+	}
+
+	tables, err := batch.TablesJSON()
+	if err != nil {
+		return err
+	}
+	if _, err := c.conn.Exec(ctx, fmt.Sprintf(`INSERT INTO %s
+		(flow_name, batch_id, start_batch_id, start_lsn, end_lsn, tables, num_rows, normalized_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT DO NOTHING`, table),
+		flowJobName, batch.EndBatchID, batch.StartBatchID, batch.StartLSN, batch.EndLSN, tables,
+		batch.NumRows(), batch.NormalizedAt,
+	); err != nil {
+		return fmt.Errorf("failed to record batch %d in batch control table: %w", batch.EndBatchID, err)
+	}
+	return nil
+}
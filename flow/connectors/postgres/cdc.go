@@ -23,6 +23,7 @@ import (
 	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/model/qvalue"
 	"github.com/PeerDB-io/peer-flow/shared"
+	"github.com/PeerDB-io/peer-flow/shared/metrics"
 )
 
 type PostgresCDCSource struct {
@@ -33,6 +34,7 @@ type PostgresCDCSource struct {
 	relationMessageMapping model.RelationMessageMapping
 	slot                   string
 	publication            string
+	decoder                logicalDecoder
 	typeMap                *pgtype.Map
 	commitLock             *pglogrepl.BeginMessage
 
@@ -42,9 +44,18 @@ type PostgresCDCSource struct {
 	// for storing chema delta audit logs to catalog
 	catalogPool *pgxpool.Pool
 	flowJobName string
+
+	typeWideningPolicy protos.TypeWideningPolicy
+	truncatePolicy     protos.TruncatePolicy
+	messageDestination string
+	excludedOrigins    map[string]struct{}
+	sourceIdentifier   string
+	// origin of the current transaction when it is excluded
+	skippedOrigin string
 }
 
 type PostgresCDCConfig struct {
+	Decoder                logicalDecoder
 	CatalogPool            *pgxpool.Pool
 	SrcTableIDNameMapping  map[uint32]string
 	TableNameMapping       map[string]model.NameAndExclude
@@ -54,18 +65,37 @@ type PostgresCDCConfig struct {
 	FlowJobName            string
 	Slot                   string
 	Publication            string
+	TypeWideningPolicy     protos.TypeWideningPolicy
+	TruncatePolicy         protos.TruncatePolicy
+	MessageDestination     string
+	ExcludedOrigins        []string
+	SourceIdentifier       string
 }
 
 // Create a new PostgresCDCSource
 func (c *PostgresConnector) NewPostgresCDCSource(cdcConfig *PostgresCDCConfig) *PostgresCDCSource {
+	var excludedOrigins map[string]struct{}
+	if len(cdcConfig.ExcludedOrigins) > 0 {
+		excludedOrigins = make(map[string]struct{}, len(cdcConfig.ExcludedOrigins))
+		for _, origin := range cdcConfig.ExcludedOrigins {
+			excludedOrigins[origin] = struct{}{}
+		}
+	}
+
 	return &PostgresCDCSource{
 		PostgresConnector:         c,
 		srcTableIDNameMapping:     cdcConfig.SrcTableIDNameMapping,
 		tableNameMapping:          cdcConfig.TableNameMapping,
 		tableNameSchemaMapping:    cdcConfig.TableNameSchemaMapping,
 		relationMessageMapping:    cdcConfig.RelationMessageMapping,
+		typeWideningPolicy:        cdcConfig.TypeWideningPolicy,
+		truncatePolicy:            cdcConfig.TruncatePolicy,
+		messageDestination:        cdcConfig.MessageDestination,
+		excludedOrigins:           excludedOrigins,
+		sourceIdentifier:          cdcConfig.SourceIdentifier,
 		slot:                      cdcConfig.Slot,
 		publication:               cdcConfig.Publication,
+		decoder:                   cdcConfig.Decoder,
 		childToParentRelIDMapping: cdcConfig.ChildToParentRelIDMap,
 		typeMap:                   pgtype.NewMap(),
 		commitLock:                nil,
@@ -181,6 +211,12 @@ func (qProcessor) Process(
 	return nil
 }
 
+// sourceIdentifierColumn is processed like a text column of every tuple when the mirror has a source identifier
+var sourceIdentifierColumn = &pglogrepl.RelationMessageColumn{
+	Name:     model.SourceIdentifierColName,
+	DataType: pgtype.TextOID,
+}
+
 func processTuple[Items model.Items](
 	processor replProcessor[Items],
 	p *PostgresCDCSource,
@@ -211,6 +247,15 @@ func processTuple[Items model.Items](
 			return none, nil, err
 		}
 	}
+	if p.sourceIdentifier != "" {
+		if err := processor.Process(items, p, &pglogrepl.TupleDataColumn{
+			DataType: 't',
+			Data:     []byte(p.sourceIdentifier),
+		}, sourceIdentifierColumn); err != nil {
+			var none Items
+			return none, nil, err
+		}
+	}
 	return items, unchangedToastColumns, nil
 }
 
@@ -318,15 +363,18 @@ func PullCdcRecords[Items model.Items](
 	}
 
 	var standByLastLogged time.Time
+	// a batch with only truncated tables has no records but still needs to be synced
+	signaledNotEmpty := false
 	cdcRecordsStorage, err := utils.NewCDCStore[Items](ctx, req.Env, p.flowJobName)
 	if err != nil {
 		return err
 	}
 	defer func() {
-		if cdcRecordsStorage.IsEmpty() {
+		if !signaledNotEmpty {
 			records.SignalAsEmpty()
 		}
 		logger.Info(fmt.Sprintf("[finished] PullRecords streamed %d records", cdcRecordsStorage.Len()))
+		metrics.RowsPulled(ctx, p.flowJobName, int64(cdcRecordsStorage.Len()))
 		err := cdcRecordsStorage.Close()
 		if err != nil {
 			logger.Warn("failed to clean up records storage", slog.Any("error", err))
@@ -341,6 +389,18 @@ func PullCdcRecords[Items model.Items](
 	standbyMessageTimeout := req.IdleTimeout
 	nextStandbyMessageDeadline := time.Now().Add(standbyMessageTimeout)
 
+	signalNotEmpty := func() {
+		if !signaledNotEmpty {
+			signaledNotEmpty = true
+			records.SignalAsNotEmpty()
+			nextStandbyMessageDeadline = time.Now().Add(standbyMessageTimeout)
+			logger.Info(fmt.Sprintf("pushing the standby deadline to %s", nextStandbyMessageDeadline))
+		}
+	}
+	isEmpty := func() bool {
+		return cdcRecordsStorage.IsEmpty() && len(records.TruncatedTables) == 0
+	}
+
 	addRecordWithKey := func(key model.TableWithPkey, rec model.Record[Items]) error {
 		if err := cdcRecordsStorage.Set(logger, key, rec); err != nil {
 			return err
@@ -348,15 +408,16 @@ func PullCdcRecords[Items model.Items](
 		if err := records.AddRecord(ctx, rec); err != nil {
 			return err
 		}
-
-		if cdcRecordsStorage.Len() == 1 {
-			records.SignalAsNotEmpty()
-			nextStandbyMessageDeadline = time.Now().Add(standbyMessageTimeout)
-			logger.Info(fmt.Sprintf("pushing the standby deadline to %s", nextStandbyMessageDeadline))
-		}
+		signalNotEmpty()
 		return nil
 	}
 
+	if len(p.pendingTruncate) > 0 {
+		records.AddTruncatedTables(p.pendingTruncate...)
+		p.pendingTruncate = nil
+		signalNotEmpty()
+	}
+
 	pkmRequiresResponse := false
 	waitingForCommit := false
 
@@ -393,7 +454,7 @@ func PullCdcRecords[Items model.Items](
 
 		// if we are past the next standby deadline (?)
 		if time.Now().After(nextStandbyMessageDeadline) {
-			if !cdcRecordsStorage.IsEmpty() {
+			if !isEmpty() {
 				logger.Info(fmt.Sprintf("standby deadline reached, have %d records", cdcRecordsStorage.Len()))
 
 				if p.commitLock == nil {
@@ -416,7 +477,7 @@ func PullCdcRecords[Items model.Items](
 
 		var receiveCtx context.Context
 		var cancel context.CancelFunc
-		if cdcRecordsStorage.IsEmpty() {
+		if isEmpty() {
 			receiveCtx, cancel = context.WithCancel(ctx)
 		} else {
 			receiveCtx, cancel = context.WithDeadline(ctx, nextStandbyMessageDeadline)
@@ -480,117 +541,136 @@ func PullCdcRecords[Items model.Items](
 
 			logger.Debug(fmt.Sprintf("XLogData => WALStart %s ServerWALEnd %s ServerTime %s\n",
 				xld.WALStart, xld.ServerWALEnd, xld.ServerTime))
-			rec, err := processMessage(ctx, p, records, xld, clientXLogPos, processor)
+			logicalMsgs, err := p.decoder.decode(xld.WALData, xld.WALStart)
 			if err != nil {
-				return fmt.Errorf("error processing message: %w", err)
+				return fmt.Errorf("error parsing logical message: %w", err)
 			}
+			for _, logicalMsg := range logicalMsgs {
+				rec, err := processMessage(ctx, p, records, xld, logicalMsg, clientXLogPos, processor)
+				if err != nil {
+					return fmt.Errorf("error processing message: %w", err)
+				}
 
-			if rec != nil {
-				tableName := rec.GetDestinationTableName()
-				switch r := rec.(type) {
-				case *model.UpdateRecord[Items]:
-					// tableName here is destination tableName.
-					// should be ideally sourceTableName as we are in PullRecords.
-					// will change in future
-					isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
-					if isFullReplica {
-						err := addRecordWithKey(model.TableWithPkey{}, rec)
-						if err != nil {
-							return err
-						}
-					} else {
-						tablePkeyVal, err := model.RecToTablePKey[Items](req.TableNameSchemaMapping, rec)
-						if err != nil {
-							return err
-						}
+				if len(p.pendingTruncate) > 0 {
+					if !cdcRecordsStorage.IsEmpty() {
+						// records before the TRUNCATE must be normalized before it is applied,
+						// end the batch here and start the next one with it
+						logger.Info("TRUNCATE received, returning currently accumulated records",
+							slog.Any("tables", p.pendingTruncate), slog.Int("records", cdcRecordsStorage.Len()))
+						return nil
+					}
+					records.AddTruncatedTables(p.pendingTruncate...)
+					p.pendingTruncate = nil
+					signalNotEmpty()
+				}
 
-						latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
-						if err != nil {
-							return err
-						}
-						if !ok {
-							err = addRecordWithKey(tablePkeyVal, rec)
+				if rec != nil {
+					tableName := rec.GetDestinationTableName()
+					switch r := rec.(type) {
+					case *model.UpdateRecord[Items]:
+						// tableName here is destination tableName.
+						// should be ideally sourceTableName as we are in PullRecords.
+						// will change in future
+						isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
+						if isFullReplica {
+							err := addRecordWithKey(model.TableWithPkey{}, rec)
+							if err != nil {
+								return err
+							}
 						} else {
-							// iterate through unchanged toast cols and set them in new record
-							updatedCols := r.NewItems.UpdateIfNotExists(latestRecord.GetItems())
-							for _, col := range updatedCols {
-								delete(r.UnchangedToastColumns, col)
+							tablePkeyVal, err := model.RecToTablePKey[Items](req.TableNameSchemaMapping, rec)
+							if err != nil {
+								return err
 							}
-							err = addRecordWithKey(tablePkeyVal, rec)
-						}
-						if err != nil {
-							return err
-						}
-					}
 
-				case *model.InsertRecord[Items]:
-					isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
-					if isFullReplica {
-						err := addRecordWithKey(model.TableWithPkey{}, rec)
-						if err != nil {
-							return err
-						}
-					} else {
-						tablePkeyVal, err := model.RecToTablePKey[Items](req.TableNameSchemaMapping, rec)
-						if err != nil {
-							return err
+							latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
+							if err != nil {
+								return err
+							}
+							if !ok {
+								err = addRecordWithKey(tablePkeyVal, rec)
+							} else {
+								// iterate through unchanged toast cols and set them in new record
+								updatedCols := r.NewItems.UpdateIfNotExists(latestRecord.GetItems())
+								for _, col := range updatedCols {
+									delete(r.UnchangedToastColumns, col)
+								}
+								err = addRecordWithKey(tablePkeyVal, rec)
+							}
+							if err != nil {
+								return err
+							}
 						}
 
-						err = addRecordWithKey(tablePkeyVal, rec)
-						if err != nil {
-							return err
-						}
-					}
-				case *model.DeleteRecord[Items]:
-					isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
-					if isFullReplica {
-						err := addRecordWithKey(model.TableWithPkey{}, rec)
-						if err != nil {
-							return err
-						}
-					} else {
-						tablePkeyVal, err := model.RecToTablePKey[Items](req.TableNameSchemaMapping, rec)
-						if err != nil {
-							return err
-						}
+					case *model.InsertRecord[Items]:
+						isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
+						if isFullReplica {
+							err := addRecordWithKey(model.TableWithPkey{}, rec)
+							if err != nil {
+								return err
+							}
+						} else {
+							tablePkeyVal, err := model.RecToTablePKey[Items](req.TableNameSchemaMapping, rec)
+							if err != nil {
+								return err
+							}
 
-						latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
-						if err != nil {
-							return err
+							err = addRecordWithKey(tablePkeyVal, rec)
+							if err != nil {
+								return err
+							}
 						}
-						if ok {
-							r.Items = latestRecord.GetItems()
-							if updateRecord, ok := latestRecord.(*model.UpdateRecord[Items]); ok {
-								r.UnchangedToastColumns = updateRecord.UnchangedToastColumns
+					case *model.DeleteRecord[Items]:
+						isFullReplica := req.TableNameSchemaMapping[tableName].IsReplicaIdentityFull
+						if isFullReplica {
+							err := addRecordWithKey(model.TableWithPkey{}, rec)
+							if err != nil {
+								return err
 							}
 						} else {
-							// there is nothing to backfill the items in the delete record with,
-							// so don't update the row with this record
-							// add sentinel value to prevent update statements from selecting
-							r.UnchangedToastColumns = map[string]struct{}{
-								"_peerdb_not_backfilled_delete": {},
+							tablePkeyVal, err := model.RecToTablePKey[Items](req.TableNameSchemaMapping, rec)
+							if err != nil {
+								return err
 							}
-						}
 
-						// A delete can only be followed by an INSERT, which does not need backfilling
-						// No need to store DeleteRecords in memory or disk.
-						err = addRecordWithKey(model.TableWithPkey{}, rec)
-						if err != nil {
-							return err
+							latestRecord, ok, err := cdcRecordsStorage.Get(tablePkeyVal)
+							if err != nil {
+								return err
+							}
+							if ok {
+								r.Items = latestRecord.GetItems()
+								if updateRecord, ok := latestRecord.(*model.UpdateRecord[Items]); ok {
+									r.UnchangedToastColumns = updateRecord.UnchangedToastColumns
+								}
+							} else {
+								// there is nothing to backfill the items in the delete record with,
+								// so don't update the row with this record
+								// add sentinel value to prevent update statements from selecting
+								r.UnchangedToastColumns = map[string]struct{}{
+									"_peerdb_not_backfilled_delete": {},
+								}
+							}
+
+							// A delete can only be followed by an INSERT, which does not need backfilling
+							// No need to store DeleteRecords in memory or disk.
+							err = addRecordWithKey(model.TableWithPkey{}, rec)
+							if err != nil {
+								return err
+							}
 						}
-					}
 
-				case *model.RelationRecord[Items]:
-					tableSchemaDelta := r.TableSchemaDelta
-					if len(tableSchemaDelta.AddedColumns) > 0 {
-						logger.Info(fmt.Sprintf("Detected schema change for table %s, addedColumns: %v",
-							tableSchemaDelta.SrcTableName, tableSchemaDelta.AddedColumns))
-						records.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
-					}
+					case *model.RelationRecord[Items]:
+						tableSchemaDelta := r.TableSchemaDelta
+						if len(tableSchemaDelta.AddedColumns) > 0 || len(tableSchemaDelta.WidenedColumns) > 0 {
+							logger.Info(fmt.Sprintf("Detected schema change for table %s, addedColumns: %v, widenedColumns: %v",
+								tableSchemaDelta.SrcTableName, tableSchemaDelta.AddedColumns, tableSchemaDelta.WidenedColumns))
+							records.AddSchemaDelta(req.TableNameMapping, tableSchemaDelta)
+						}
 
-				case *model.MessageRecord[Items]:
-					if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
-						return err
+					case *model.MessageRecord[Items]:
+						if err := addRecordWithKey(model.TableWithPkey{}, rec); err != nil {
+							return err
+						}
 					}
 				}
 			}
@@ -618,30 +698,43 @@ func processMessage[Items model.Items](
 	p *PostgresCDCSource,
 	batch *model.CDCStream[Items],
 	xld pglogrepl.XLogData,
+	logicalMsg pglogrepl.Message,
 	currentClientXlogPos pglogrepl.LSN,
 	processor replProcessor[Items],
 ) (model.Record[Items], error) {
 	logger := logger.LoggerFromCtx(ctx)
-	logicalMsg, err := pglogrepl.Parse(xld.WALData)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing logical message: %w", err)
-	}
-
 	switch msg := logicalMsg.(type) {
 	case *pglogrepl.BeginMessage:
 		logger.Debug("BeginMessage", slog.Any("FinalLSN", msg.FinalLSN), slog.Any("XID", msg.Xid))
 		p.commitLock = msg
+		p.skippedOrigin = ""
+	case *pglogrepl.OriginMessage:
+		// sent right after BeginMessage for transactions which were replicated from elsewhere
+		if p.isOriginExcluded(msg.Name) {
+			logger.Debug("skipping transaction from excluded origin", slog.String("Origin", msg.Name))
+			p.skippedOrigin = msg.Name
+		}
 	case *pglogrepl.InsertMessage:
+		if p.skippedOrigin != "" {
+			return nil, nil
+		}
 		return processInsertMessage(p, xld.WALStart, msg, processor)
 	case *pglogrepl.UpdateMessage:
+		if p.skippedOrigin != "" {
+			return nil, nil
+		}
 		return processUpdateMessage(p, xld.WALStart, msg, processor)
 	case *pglogrepl.DeleteMessage:
+		if p.skippedOrigin != "" {
+			return nil, nil
+		}
 		return processDeleteMessage(p, xld.WALStart, msg, processor)
 	case *pglogrepl.CommitMessage:
 		// for a commit message, update the last checkpoint id for the record batch.
 		logger.Debug("CommitMessage", slog.Any("CommitLSN", msg.CommitLSN), slog.Any("TransactionEndLSN", msg.TransactionEndLSN))
 		batch.UpdateLatestCheckpoint(int64(msg.CommitLSN))
 		p.commitLock = nil
+		p.skippedOrigin = ""
 	case *pglogrepl.RelationMessage:
 		// treat all relation messages as corresponding to parent if partitioned.
 		msg.RelationID = p.getParentRelIDIfPartitioned(msg.RelationID)
@@ -664,13 +757,26 @@ func processMessage[Items model.Items](
 			slog.Int64("LSN", int64(msg.LSN)))
 		if !msg.Transactional {
 			batch.UpdateLatestCheckpoint(int64(msg.LSN))
+		} else if p.skippedOrigin != "" {
+			return nil, nil
 		}
 		return &model.MessageRecord[Items]{
-			BaseRecord: p.baseRecord(msg.LSN),
-			Prefix:     msg.Prefix,
-			Content:    string(msg.Content),
+			BaseRecord:           p.baseRecord(msg.LSN),
+			Prefix:               msg.Prefix,
+			Content:              string(msg.Content),
+			DestinationTableName: p.messageDestination,
 		}, nil
 
+	case *pglogrepl.TruncateMessage:
+		if p.skippedOrigin != "" {
+			return nil, nil
+		}
+		if p.truncatePolicy == protos.TruncatePolicy_TRUNCATE_POLICY_IGNORE {
+			logger.Warn("ignoring TRUNCATE of source tables, destination tables may have rows which no longer exist at source",
+				slog.Any("RelationIDs", msg.RelationIDs))
+			return nil, nil
+		}
+		p.pendingTruncate = append(p.pendingTruncate, p.truncatedDestinationTables(ctx, msg)...)
 	default:
 		logger.Warn(fmt.Sprintf("%T not supported", msg))
 	}
@@ -678,6 +784,37 @@ func processMessage[Items model.Items](
 	return nil, nil
 }
 
+// truncatedDestinationTables maps relations of a TruncateMessage to destination tables,
+// partitions truncated without their parent are skipped since the destination holds every partition
+func (p *PostgresCDCSource) truncatedDestinationTables(ctx context.Context, msg *pglogrepl.TruncateMessage) []string {
+	logger := logger.LoggerFromCtx(ctx)
+	truncated := make(map[uint32]struct{}, len(msg.RelationIDs))
+	for _, relID := range msg.RelationIDs {
+		truncated[relID] = struct{}{}
+	}
+
+	tables := make([]string, 0, len(msg.RelationIDs))
+	seen := make(map[string]struct{}, len(msg.RelationIDs))
+	for _, relID := range msg.RelationIDs {
+		parentRelID := p.getParentRelIDIfPartitioned(relID)
+		srcTableName, ok := p.srcTableIDNameMapping[parentRelID]
+		if !ok {
+			continue
+		}
+		if _, parentTruncated := truncated[parentRelID]; !parentTruncated {
+			logger.Warn("ignoring TRUNCATE of partition, only truncating the partitioned table is replicated",
+				slog.String("table", srcTableName), slog.Any("RelationID", relID))
+			continue
+		}
+		dstTableName := p.tableNameMapping[srcTableName].Name
+		if _, ok := seen[dstTableName]; !ok {
+			seen[dstTableName] = struct{}{}
+			tables = append(tables, dstTableName)
+		}
+	}
+	return tables
+}
+
 func processInsertMessage[Items model.Items](
 	p *PostgresCDCSource,
 	lsn pglogrepl.LSN,
@@ -794,9 +931,13 @@ func processDeleteMessage[Items model.Items](
 }
 
 func auditSchemaDelta[Items model.Items](ctx context.Context, p *PostgresCDCSource, rec *model.RelationRecord[Items]) error {
-	activityInfo := activity.GetInfo(ctx)
-	workflowID := activityInfo.WorkflowExecution.ID
-	runID := activityInfo.WorkflowExecution.RunID
+	// mirrors of the standalone runner pull outside of activities
+	var workflowID, runID string
+	if activity.IsActivity(ctx) {
+		activityInfo := activity.GetInfo(ctx)
+		workflowID = activityInfo.WorkflowExecution.ID
+		runID = activityInfo.WorkflowExecution.RunID
+	}
 
 	_, err := p.catalogPool.Exec(ctx,
 		`INSERT INTO
@@ -827,10 +968,10 @@ func processRelationMessage[Items model.Items](
 	// tableNameSchemaMapping uses dst table name as the key, so annoying lookup
 	prevSchema := p.tableNameSchemaMapping[p.tableNameMapping[p.srcTableIDNameMapping[currRel.RelationID]].Name]
 	// creating maps for lookup later
-	prevRelMap := make(map[string]string)
+	prevRelMap := make(map[string]*protos.FieldDescription)
 	currRelMap := make(map[string]string)
 	for _, column := range prevSchema.Columns {
-		prevRelMap[column.Name] = column.Type
+		prevRelMap[column.Name] = column
 	}
 	for _, column := range currRel.Columns {
 		switch prevSchema.System {
@@ -855,12 +996,17 @@ func processRelationMessage[Items model.Items](
 		DstTableName: p.tableNameMapping[p.srcTableIDNameMapping[currRel.RelationID]].Name,
 		AddedColumns: nil,
 		System:       prevSchema.System,
+		RenamedTo:    p.renamedSourceTable(currRel),
+	}
+	if schemaDelta.RenamedTo != "" {
+		p.logger.Warn(fmt.Sprintf("Detected table %s renamed to %s", schemaDelta.SrcTableName, schemaDelta.RenamedTo))
 	}
 	for _, column := range currRel.Columns {
+		_, excluded := p.tableNameMapping[p.srcTableIDNameMapping[currRel.RelationID]].Exclude[column.Name]
 		// not present in previous relation message, but in current one, so added.
-		if _, ok := prevRelMap[column.Name]; !ok {
+		if prevColumn, ok := prevRelMap[column.Name]; !ok {
 			// only add to delta if not excluded
-			if _, ok := p.tableNameMapping[p.srcTableIDNameMapping[currRel.RelationID]].Exclude[column.Name]; !ok {
+			if !excluded {
 				schemaDelta.AddedColumns = append(schemaDelta.AddedColumns, &protos.FieldDescription{
 					Name:         column.Name,
 					Type:         currRelMap[column.Name],
@@ -870,9 +1016,21 @@ func processRelationMessage[Items model.Items](
 			}
 			// present in previous and current relation messages, but data types have changed.
 			// so we add it to AddedColumns and DroppedColumns, knowing that we process DroppedColumns first.
-		} else if prevRelMap[column.Name] != currRelMap[column.Name] {
-			p.logger.Warn(fmt.Sprintf("Detected column %s with type changed from %s to %s in table %s, but not propagating",
-				column.Name, prevRelMap[column.Name], currRelMap[column.Name], schemaDelta.SrcTableName))
+		} else if prevColumn.Type != currRelMap[column.Name] || prevColumn.TypeModifier != column.TypeModifier {
+			if !excluded && p.typeWideningPolicy == protos.TypeWideningPolicy_TYPE_WIDENING_POLICY_LOSSLESS &&
+				isLosslessWidening(prevSchema.System, prevColumn.Type, prevColumn.TypeModifier,
+					currRelMap[column.Name], column.TypeModifier) {
+				schemaDelta.WidenedColumns = append(schemaDelta.WidenedColumns, &protos.WidenedColumn{
+					Name:            column.Name,
+					OldType:         prevColumn.Type,
+					OldTypeModifier: prevColumn.TypeModifier,
+					NewType:         currRelMap[column.Name],
+					NewTypeModifier: column.TypeModifier,
+				})
+			} else if prevColumn.Type != currRelMap[column.Name] {
+				p.logger.Warn(fmt.Sprintf("Detected column %s with type changed from %s to %s in table %s, but not propagating",
+					column.Name, prevColumn.Type, currRelMap[column.Name], schemaDelta.SrcTableName))
+			}
 		}
 	}
 	for _, column := range prevSchema.Columns {
@@ -885,7 +1043,7 @@ func processRelationMessage[Items model.Items](
 
 	p.relationMessageMapping[currRel.RelationID] = currRel
 	// only log audit if there is actionable delta
-	if len(schemaDelta.AddedColumns) > 0 {
+	if len(schemaDelta.AddedColumns) > 0 || len(schemaDelta.WidenedColumns) > 0 || schemaDelta.RenamedTo != "" {
 		rec := &model.RelationRecord[Items]{
 			BaseRecord:       p.baseRecord(lsn),
 			TableSchemaDelta: schemaDelta,
@@ -895,6 +1053,29 @@ func processRelationMessage[Items model.Items](
 	return nil, nil
 }
 
+// renamedSourceTable returns the new name of a source table rel shows renamed, empty when it isn't.
+// Relation messages of partitions carry the id of their parent with their own name, so partitioned tables aren't compared
+func (p *PostgresCDCSource) renamedSourceTable(rel *pglogrepl.RelationMessage) string {
+	for _, parentRelID := range p.childToParentRelIDMapping {
+		if parentRelID == rel.RelationID {
+			return ""
+		}
+	}
+	name := rel.Namespace + "." + rel.RelationName
+	if name == p.srcTableIDNameMapping[rel.RelationID] {
+		return ""
+	}
+	return name
+}
+
+func (p *PostgresCDCSource) isOriginExcluded(origin string) bool {
+	if _, ok := p.excludedOrigins[origin]; ok {
+		return true
+	}
+	_, ok := p.excludedOrigins["*"]
+	return ok
+}
+
 func (p *PostgresCDCSource) getParentRelIDIfPartitioned(relID uint32) uint32 {
 	parentRelID, ok := p.childToParentRelIDMapping[relID]
 	if ok {
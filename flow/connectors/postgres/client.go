@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pglogrepl"
@@ -15,6 +16,7 @@ import (
 	"github.com/lib/pq/oid"
 
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
 	numeric "github.com/PeerDB-io/peer-flow/datatypes"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/model"
@@ -56,11 +58,11 @@ const (
 		FROM %s.%s WHERE _peerdb_batch_id>$1 AND _peerdb_batch_id<=$2 AND _peerdb_destination_table_name=$3
 	)
 	MERGE INTO %s dst
-	USING (SELECT %s,_peerdb_record_type,_peerdb_unchanged_toast_columns FROM src_rank WHERE _peerdb_rank=1) src
+	USING (SELECT %s,_peerdb_record_type,_peerdb_unchanged_toast_columns FROM src_rank WHERE _peerdb_rank=1%s) src
 	ON %s
 	WHEN NOT MATCHED AND src._peerdb_record_type!=2 THEN
 	INSERT (%s) VALUES (%s) %s
-	WHEN MATCHED AND src._peerdb_record_type=2 THEN %s`
+	WHEN MATCHED AND src._peerdb_record_type=2%s THEN %s`
 	fallbackUpsertStatementSQL = `WITH src_rank AS (
 		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
 		RANK() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
@@ -210,6 +212,24 @@ func (c *PostgresConnector) getNullableColumns(ctx context.Context, relID uint32
 	return nullableCols, err
 }
 
+// getColumnDefaults returns default expressions of columns, generated columns are not defaults
+func (c *PostgresConnector) getColumnDefaults(ctx context.Context, relID uint32) (map[string]string, error) {
+	rows, err := c.conn.Query(ctx, `SELECT a.attname, pg_get_expr(d.adbin, d.adrelid) FROM pg_attrdef d
+		JOIN pg_attribute a ON a.attrelid = d.adrelid AND a.attnum = d.adnum
+		WHERE d.adrelid = $1 AND a.attgenerated = ''`, relID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting column defaults for table %v: %w", relID, err)
+	}
+
+	var name, expression string
+	defaults := make(map[string]string)
+	_, err = pgx.ForEachRow(rows, []any{&name, &expression}, func() error {
+		defaults[name] = expression
+		return nil
+	})
+	return defaults, err
+}
+
 func (c *PostgresConnector) tableExists(ctx context.Context, schemaTable *utils.SchemaTable) (bool, error) {
 	var exists pgtype.Bool
 	err := c.conn.QueryRow(ctx,
@@ -233,11 +253,12 @@ func (c *PostgresConnector) checkSlotAndPublication(ctx context.Context, slot st
 	slotExists := false
 	publicationExists := false
 
-	// Check if the replication slot exists
-	var slotName pgtype.Text
+	// Check if the replication slot exists, conflicting is only there from Postgres 16
+	var plugin pgtype.Text
+	var conflicting bool
 	err := c.conn.QueryRow(ctx,
-		"SELECT slot_name FROM pg_replication_slots WHERE slot_name = $1",
-		slot).Scan(&slotName)
+		"SELECT plugin, coalesce((to_jsonb(s)->>'conflicting')::bool, false) FROM pg_replication_slots s WHERE slot_name = $1",
+		slot).Scan(&plugin, &conflicting)
 	if err != nil {
 		// check if the error is a "no rows" error
 		if err != pgx.ErrNoRows {
@@ -262,8 +283,10 @@ func (c *PostgresConnector) checkSlotAndPublication(ctx context.Context, slot st
 	}
 
 	return SlotCheckResult{
+		Plugin:            plugin.String,
 		SlotExists:        slotExists,
 		PublicationExists: publicationExists,
+		Conflicting:       conflicting,
 	}, nil
 }
 
@@ -285,9 +308,10 @@ func getSlotInfo(ctx context.Context, conn *pgx.Conn, slotName string, database
 	}
 	rows, err := conn.Query(ctx, fmt.Sprintf(`SELECT slot_name, redo_lsn::Text,restart_lsn::text,%s,
 		confirmed_flush_lsn::text,active,
-		round((CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END
-		- restart_lsn) / 1024 / 1024) AS MB_Behind
-		FROM pg_control_checkpoint(),pg_replication_slots %s`, walStatusSelector, whereClause))
+		round((current_lsn - restart_lsn) / 1024 / 1024) AS MB_Behind, current_lsn::text
+		FROM pg_control_checkpoint(),pg_replication_slots,
+		(SELECT CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END AS current_lsn) wal
+		%s`, walStatusSelector, whereClause))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read information for slots: %w", err)
 	}
@@ -301,7 +325,8 @@ func getSlotInfo(ctx context.Context, conn *pgx.Conn, slotName string, database
 		var active pgtype.Bool
 		var lagInMB pgtype.Float4
 		var walStatus pgtype.Text
-		err := rows.Scan(&slotName, &redoLSN, &restartLSN, &walStatus, &confirmedFlushLSN, &active, &lagInMB)
+		var currentLSN pgtype.Text
+		err := rows.Scan(&slotName, &redoLSN, &restartLSN, &walStatus, &confirmedFlushLSN, &active, &lagInMB, &currentLSN)
 		if err != nil {
 			return nil, err
 		}
@@ -314,6 +339,7 @@ func getSlotInfo(ctx context.Context, conn *pgx.Conn, slotName string, database
 			SlotName:          slotName.String,
 			Active:            active.Bool,
 			LagInMb:           lagInMB.Float32,
+			CurrentLSN:        currentLSN.String,
 		})
 	}
 	return slotInfoRows, nil
@@ -345,6 +371,10 @@ func (c *PostgresConnector) CreatePublication(
 	stmt := fmt.Sprintf("CREATE PUBLICATION %s FOR TABLE %s%s", publication, tableNameString, pubViaRootString)
 	if _, err = c.execWithLogging(ctx, stmt); err != nil {
 		c.logger.Warn(fmt.Sprintf("Error creating publication '%s': %v", publication, err))
+		if shared.IsSQLStateError(err, pgerrcode.ReadOnlySQLTransaction) {
+			return fmt.Errorf("publication '%s' can't be created on a standby as it is read-only, "+
+				"create publications on the primary: %w", publication, err)
+		}
 		return fmt.Errorf("error creating publication '%s' : %w", publication, err)
 	}
 	return nil
@@ -362,7 +392,9 @@ func (c *PostgresConnector) createSlotAndPublication(
 ) error {
 	// iterate through source tables and create publication,
 	// expecting tablenames to be schema qualified
-	if !s.PublicationExists {
+	plugin := pluginPgoutput
+	// wal2json slots, created when a publication couldn't be, decode without one
+	if !s.PublicationExists && s.Plugin != pluginWal2json {
 		srcTableNames := make([]string, 0, len(tableNameMapping))
 		for srcTableName := range tableNameMapping {
 			parsedSrcTableName, err := utils.ParseSchemaTable(srcTableName)
@@ -371,9 +403,17 @@ func (c *PostgresConnector) createSlotAndPublication(
 			}
 			srcTableNames = append(srcTableNames, parsedSrcTableName.String())
 		}
-		err := c.CreatePublication(ctx, srcTableNames, publication)
-		if err != nil {
-			return err
+		if err := c.CreatePublication(ctx, srcTableNames, publication); err != nil {
+			if s.SlotExists {
+				return err
+			}
+			fallback, fallbackErr := c.FallbackDecodingPlugin(ctx, err)
+			if fallbackErr != nil || fallback == "" {
+				return errors.Join(err, fallbackErr)
+			}
+			c.logger.Warn(fmt.Sprintf("publication '%s' can't be created, decoding with %s instead", publication, fallback),
+				slog.Any("error", err))
+			plugin = fallback
 		}
 	}
 
@@ -386,6 +426,18 @@ func (c *PostgresConnector) createSlotAndPublication(
 		defer conn.Close(ctx)
 
 		c.logger.Warn(fmt.Sprintf("Creating replication slot '%s'", slot))
+		standby, err := c.IsStandby(ctx)
+		if err != nil {
+			return fmt.Errorf("[slot] %w", err)
+		}
+		if standby {
+			// slots on a standby wait for the primary to log which transactions are running
+			shutdown := shared.Interval(ctx, time.Minute, func() {
+				c.logger.Info(fmt.Sprintf("waiting for activity on the primary to create replication slot '%s' on the standby, "+
+					"calling pg_log_standby_snapshot() on the primary speeds this up", slot))
+			})
+			defer shutdown()
+		}
 
 		// THIS IS NOT IN A TX!
 		if _, err = conn.Exec(ctx, "SET idle_in_transaction_session_timeout=0"); err != nil {
@@ -400,7 +452,7 @@ func (c *PostgresConnector) createSlotAndPublication(
 			Temporary: false,
 			Mode:      pglogrepl.LogicalReplication,
 		}
-		res, err := pglogrepl.CreateReplicationSlot(ctx, conn.PgConn(), slot, "pgoutput", opts)
+		res, err := pglogrepl.CreateReplicationSlot(ctx, conn.PgConn(), slot, plugin, opts)
 		if err != nil {
 			return fmt.Errorf("[slot] error creating replication slot: %w", err)
 		}
@@ -410,7 +462,7 @@ func (c *PostgresConnector) createSlotAndPublication(
 			return fmt.Errorf("[slot] error getting PG version: %w", err)
 		}
 
-		c.logger.Info(fmt.Sprintf("Created replication slot '%s'", slot))
+		c.logger.Info(fmt.Sprintf("Created replication slot '%s' with %s", slot, plugin))
 		slotDetails := SlotCreationResult{
 			SlotName:         res.SlotName,
 			SnapshotName:     res.SnapshotName,
@@ -450,6 +502,18 @@ func getRawTableIdentifier(jobName string) string {
 	return rawTablePrefix + "_" + strings.ToLower(shared.ReplaceIllegalCharactersWithUnderscores(jobName))
 }
 
+func postgresColumnType(system protos.TypeSystem, columnType string, typmod int32) string {
+	pgColumnType := columnType
+	if system == protos.TypeSystem_Q {
+		pgColumnType = qValueKindToPostgresType(pgColumnType)
+	}
+	if columnType == "numeric" && typmod != -1 {
+		precision, scale := numeric.ParseNumericTypmod(typmod)
+		pgColumnType = fmt.Sprintf("numeric(%d,%d)", precision, scale)
+	}
+	return pgColumnType
+}
+
 func generateCreateTableSQLForNormalizedTable(
 	config *protos.SetupNormalizedTableBatchInput,
 	tableIdentifier string,
@@ -458,14 +522,7 @@ func generateCreateTableSQLForNormalizedTable(
 	sourceTableSchema := config.TableNameSchemaMapping[tableIdentifier]
 	createTableSQLArray := make([]string, 0, len(sourceTableSchema.Columns)+2)
 	for _, column := range sourceTableSchema.Columns {
-		pgColumnType := column.Type
-		if sourceTableSchema.System == protos.TypeSystem_Q {
-			pgColumnType = qValueKindToPostgresType(pgColumnType)
-		}
-		if column.Type == "numeric" && column.TypeModifier != -1 {
-			precision, scale := numeric.ParseNumericTypmod(column.TypeModifier)
-			pgColumnType = fmt.Sprintf("numeric(%d,%d)", precision, scale)
-		}
+		pgColumnType := postgresColumnType(sourceTableSchema.System, column.Type, column.TypeModifier)
 		var notNull string
 		if sourceTableSchema.NullableEnabled && !column.Nullable {
 			notNull = " NOT NULL"
@@ -575,6 +632,27 @@ func (c *PostgresConnector) updateNormalizeMetadata(
 	return nil
 }
 
+// setupReplicationOrigin tags changes made by this connection with origin, creating it if needed.
+// Only one session can use an origin at a time, which holds as normalize for a mirror never runs concurrently.
+func (c *PostgresConnector) setupReplicationOrigin(ctx context.Context, origin string) error {
+	if _, err := c.conn.Exec(ctx,
+		"SELECT pg_replication_origin_create($1) WHERE NOT EXISTS (SELECT 1 FROM pg_replication_origin WHERE roname=$1)",
+		origin,
+	); err != nil {
+		return fmt.Errorf("failed to create replication origin %s: %w", origin, err)
+	}
+	if _, err := c.conn.Exec(ctx, "SELECT pg_replication_origin_session_setup($1)", origin); err != nil {
+		return fmt.Errorf("failed to setup replication origin %s: %w", origin, err)
+	}
+	return nil
+}
+
+func (c *PostgresConnector) resetReplicationOrigin(ctx context.Context) {
+	if _, err := c.conn.Exec(ctx, "SELECT pg_replication_origin_session_reset()"); err != nil {
+		c.logger.Warn("failed to reset replication origin", slog.Any("error", err))
+	}
+}
+
 func (c *PostgresConnector) getDistinctTableNamesInBatch(
 	ctx context.Context,
 	flowJobName string,
@@ -667,10 +745,12 @@ func (c *PostgresConnector) ExecuteCommand(ctx context.Context, command string)
 
 func (c *PostgresConnector) execWithLogging(ctx context.Context, query string) (pgconn.CommandTag, error) {
 	c.logger.Info("[postgres] executing DDL statement", slog.String("query", query))
+	audit.Record(ctx, query)
 	return c.conn.Exec(ctx, query)
 }
 
 func (c *PostgresConnector) execWithLoggingTx(ctx context.Context, query string, tx pgx.Tx) (pgconn.CommandTag, error) {
 	c.logger.Info("[postgres] executing DDL statement", slog.String("query", query))
+	audit.Record(ctx, query)
 	return tx.Exec(ctx, query)
 }
@@ -0,0 +1,73 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// columnEntropy estimates bits of information per value from statistics ANALYZE sampled,
+// values beyond the most common ones are taken to be equally likely
+func columnEntropy(nDistinct float64, rows float64, nullFrac float64, mostCommonFreqs []float32) float64 {
+	// negative n_distinct is distinct values as a fraction of rows
+	if nDistinct < 0 {
+		nDistinct = -nDistinct * rows
+	}
+	var entropy float64
+	remaining := 1 - nullFrac
+	for _, freq := range mostCommonFreqs {
+		if freq > 0 {
+			entropy -= float64(freq) * math.Log2(float64(freq))
+			remaining -= float64(freq)
+		}
+	}
+	if rest := nDistinct - float64(len(mostCommonFreqs)); rest >= 1 && remaining > 0 {
+		entropy -= remaining * math.Log2(remaining/rest)
+	}
+	if nullFrac > 0 {
+		entropy -= nullFrac * math.Log2(nullFrac)
+	}
+	return max(entropy, 0)
+}
+
+// getColumnProfiles returns profiles of columns from pg_stats, tables never analyzed have none
+func (c *PostgresConnector) getColumnProfiles(
+	ctx context.Context,
+	schemaTable *utils.SchemaTable,
+) (map[string]*protos.ColumnProfile, error) {
+	var rows float64
+	if err := c.conn.QueryRow(ctx, "SELECT greatest(reltuples, 0) FROM pg_class WHERE oid = $1::regclass",
+		schemaTable.String()).Scan(&rows); err != nil {
+		return nil, fmt.Errorf("failed to estimate rows of %s: %w", schemaTable, err)
+	}
+
+	statRows, err := c.conn.Query(ctx, `SELECT attname, coalesce(correlation, 0), n_distinct, null_frac, avg_width,
+		coalesce(most_common_freqs, '{}') FROM pg_stats WHERE schemaname = $1 AND tablename = $2`,
+		schemaTable.Schema, schemaTable.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statistics of %s: %w", schemaTable, err)
+	}
+	profiles := make(map[string]*protos.ColumnProfile)
+	var name string
+	var correlation, nDistinct, nullFrac float32
+	var avgWidth int32
+	var mostCommonFreqs []float32
+	if _, err := pgx.ForEachRow(statRows, []any{
+		&name, &correlation, &nDistinct, &nullFrac, &avgWidth, &mostCommonFreqs,
+	}, func() error {
+		profiles[name] = &protos.ColumnProfile{
+			Correlation:  float64(correlation),
+			EntropyBits:  columnEntropy(float64(nDistinct), rows, float64(nullFrac), mostCommonFreqs),
+			AverageWidth: avgWidth,
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to query statistics of %s: %w", schemaTable, err)
+	}
+	return profiles, nil
+}
@@ -0,0 +1,18 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnEntropy(t *testing.T) {
+	// two values equally common
+	require.InDelta(t, 1, columnEntropy(2, 1000, 0, []float32{0.5, 0.5}), 1e-6)
+	// unique values of a million rows, no value common enough to be listed
+	require.InDelta(t, 19.93, columnEntropy(-1, 1_000_000, 0, nil), 0.01)
+	// a constant column
+	require.InDelta(t, 0, columnEntropy(1, 1000, 0, []float32{1}), 1e-6)
+	// half null, the rest split between two values
+	require.InDelta(t, 1.5, columnEntropy(2, 1000, 0.5, []float32{0.25, 0.25}), 1e-6)
+}
@@ -0,0 +1,71 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+// SampleRows picks about sampleSize random rows of table, sampling pages to not scan large tables
+func (c *PostgresConnector) SampleRows(
+	ctx context.Context,
+	flowJobName string,
+	table string,
+	sampleSize int,
+) ([]model.SampledRow, error) {
+	schemaTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return nil, err
+	}
+	var reltuples float64
+	if err := c.conn.QueryRow(ctx, "SELECT reltuples FROM pg_class WHERE oid = $1::regclass",
+		schemaTable.String()).Scan(&reltuples); err != nil {
+		return nil, fmt.Errorf("failed to estimate rows of %s: %w", table, err)
+	}
+	// pages are sampled whole, oversample so the limit is usually reached
+	percent := 100.0
+	if reltuples > 0 {
+		percent = min(percent, float64(sampleSize)*400/reltuples)
+	}
+
+	batch, err := c.NewQRepQueryExecutor(flowJobName, "").ExecuteAndProcessQuery(ctx, fmt.Sprintf(
+		"SELECT * FROM %s TABLESAMPLE SYSTEM (%s) ORDER BY random() LIMIT %d",
+		schemaTable.String(), strconv.FormatFloat(percent, 'f', -1, 64), sampleSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample rows of %s: %w", table, err)
+	}
+	return batch.SampledRows(), nil
+}
+
+func (c *PostgresConnector) LookupRows(
+	ctx context.Context,
+	flowJobName string,
+	table string,
+	keyColumns []string,
+	keys [][]any,
+) ([]model.SampledRow, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	schemaTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return nil, err
+	}
+	quotedKeyColumns := make([]string, 0, len(keyColumns))
+	for _, keyColumn := range keyColumns {
+		quotedKeyColumns = append(quotedKeyColumns, QuoteIdentifier(keyColumn))
+	}
+	filter := utils.SampledKeysFilter(quotedKeyColumns, len(keys), func(i int) string {
+		return "$" + strconv.Itoa(i+1)
+	})
+
+	batch, err := c.NewQRepQueryExecutor(flowJobName, "").ExecuteAndProcessQuery(ctx,
+		fmt.Sprintf("SELECT * FROM %s WHERE %s", schemaTable.String(), filter), utils.SampledKeyArgs(keys)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up rows of %s: %w", table, err)
+	}
+	return batch.SampledRows(), nil
+}
@@ -0,0 +1,151 @@
+package connpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// output plugins mirrors decode changes with, pgoutput is preferred,
+// wal2json serves sources where a publication can't be created
+const (
+	pluginPgoutput = "pgoutput"
+	pluginWal2json = "wal2json"
+)
+
+// logicalDecoder turns what the output plugin of a slot sends into pgoutput messages,
+// so changes go through the same processing whichever plugin decoded them
+type logicalDecoder interface {
+	decode(walData []byte, walStart pglogrepl.LSN) ([]pglogrepl.Message, error)
+}
+
+type pgoutputDecoder struct{}
+
+func (pgoutputDecoder) decode(walData []byte, _ pglogrepl.LSN) ([]pglogrepl.Message, error) {
+	msg, err := pglogrepl.Parse(walData)
+	if err != nil {
+		return nil, err
+	}
+	return []pglogrepl.Message{msg}, nil
+}
+
+// FallbackDecodingPlugin returns the output plugin to replicate with when a publication can't be created,
+// wal2json needs no publication so serves users lacking ownership of tables and standbys, which are read-only,
+// empty when none is available
+func (c *PostgresConnector) FallbackDecodingPlugin(ctx context.Context, publicationErr error) (string, error) {
+	if !shared.IsSQLStateError(publicationErr, pgerrcode.InsufficientPrivilege, pgerrcode.ReadOnlySQLTransaction) {
+		return "", nil
+	}
+	available, err := c.decodingPluginAvailable(ctx, pluginWal2json)
+	if err != nil || !available {
+		return "", err
+	}
+	return pluginWal2json, nil
+}
+
+// decodingPluginAvailable checks whether the source has plugin installed by creating a temporary slot with it,
+// which goes away with the connection
+func (c *PostgresConnector) decodingPluginAvailable(ctx context.Context, plugin string) (bool, error) {
+	conn, err := c.CreateReplConn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to create replication connection: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err := pglogrepl.CreateReplicationSlot(ctx, conn.PgConn(), "_peerdb_tmp_test_slot_"+shared.RandomString(5),
+		plugin, pglogrepl.CreateReplicationSlotOptions{Temporary: true, Mode: pglogrepl.LogicalReplication},
+	); err != nil {
+		if shared.IsSQLStateError(err, pgerrcode.UndefinedFile) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check for output plugin %s: %w", plugin, err)
+	}
+	return true, nil
+}
+
+// startReplication starts streaming slot with arguments of its plugin. wal2json is asked for format-version 2,
+// releases before 2.0 fail on options they don't know so replication is restarted with format-version 1 for them,
+// which is written in chunks so large transactions are decoded as they arrive
+func (c *PostgresConnector) startReplication(
+	ctx context.Context,
+	slotName string,
+	plugin string,
+	publicationName string,
+	startLSN pglogrepl.LSN,
+) (int, error) {
+	if plugin != pluginWal2json {
+		replicationOpts, err := c.replicationOptions(ctx, publicationName)
+		if err != nil {
+			return 0, fmt.Errorf("error getting replication options: %w", err)
+		}
+		return 0, pglogrepl.StartReplication(ctx, c.replConn.PgConn(), slotName, startLSN, replicationOpts)
+	}
+
+	err := pglogrepl.StartReplication(ctx, c.replConn.PgConn(), slotName, startLSN, wal2jsonReplicationOptions(2))
+	if err == nil {
+		return 2, nil
+	}
+	c.logger.Warn("failed to start replication with wal2json format-version 2, trying format-version 1",
+		slog.Any("error", err))
+	if errV1 := pglogrepl.StartReplication(ctx, c.replConn.PgConn(), slotName, startLSN,
+		wal2jsonReplicationOptions(1)); errV1 != nil {
+		return 0, errors.Join(err, errV1)
+	}
+	return 1, nil
+}
+
+func wal2jsonReplicationOptions(formatVersion int) pglogrepl.StartReplicationOptions {
+	return pglogrepl.StartReplicationOptions{PluginArgs: []string{
+		fmt.Sprintf("\"format-version\" '%d'", formatVersion),
+		"\"include-xids\" '1'",
+		"\"include-timestamp\" '1'",
+		"\"include-lsn\" '1'",
+		"\"include-type-oids\" '1'",
+		// format-version 1 otherwise builds every transaction whole in memory of the source before sending it,
+		// format-version 2 sends a message per change regardless
+		"\"write-in-chunks\" '1'",
+	}}
+}
+
+// catalogRelationLookup resolves tables wal2json names to relation ids,
+// looking up type modifiers of their columns wal2json leaves out
+type catalogRelationLookup struct {
+	ctx  context.Context
+	conn *pgx.Conn
+}
+
+func (l catalogRelationLookup) relationID(schema string, table string) (uint32, error) {
+	var relID pgtype.Uint32
+	if err := l.conn.QueryRow(l.ctx, "SELECT to_regclass($1)::oid",
+		QuoteIdentifier(schema)+"."+QuoteIdentifier(table)).Scan(&relID); err != nil {
+		return 0, fmt.Errorf("error looking up relation id of %s.%s: %w", schema, table, err)
+	}
+	// tables dropped since the change resolve to 0, which no mirror replicates
+	return relID.Uint32, nil
+}
+
+func (l catalogRelationLookup) typeModifiers(relID uint32) (map[string]int32, error) {
+	rows, err := l.conn.Query(l.ctx,
+		"SELECT attname, atttypmod FROM pg_attribute WHERE attrelid = $1 AND attnum > 0 AND NOT attisdropped", relID)
+	if err != nil {
+		return nil, fmt.Errorf("error looking up type modifiers of relation %d: %w", relID, err)
+	}
+	typmods := make(map[string]int32)
+	var name string
+	var typmod int32
+	if _, err := pgx.ForEachRow(rows, []any{&name, &typmod}, func() error {
+		typmods[name] = typmod
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error looking up type modifiers of relation %d: %w", relID, err)
+	}
+	return typmods, nil
+}
@@ -0,0 +1,40 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
+)
+
+// DeleteRows deletes rows of a destination table, keys are bound as parameters so they are neither logged nor recorded
+func (c *PostgresConnector) DeleteRows(
+	ctx context.Context,
+	tableIdentifier string,
+	keyColumns []string,
+	keys [][]any,
+	predicate string,
+) error {
+	table, err := utils.ParseSchemaTable(tableIdentifier)
+	if err != nil {
+		return fmt.Errorf("unable to parse table %s: %w", tableIdentifier, err)
+	}
+	quotedKeyColumns := make([]string, 0, len(keyColumns))
+	for _, keyColumn := range keyColumns {
+		quotedKeyColumns = append(quotedKeyColumns, QuoteIdentifier(keyColumn))
+	}
+	filter, args := utils.RowDeleteFilter(quotedKeyColumns, keys, predicate, func(i int) string {
+		return "$" + strconv.Itoa(i+1)
+	})
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", table.String(), filter)
+	c.logger.Info("[postgres] deleting rows", slog.String("query", audit.Redact(query)))
+	audit.Record(ctx, query)
+	if _, err := c.conn.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete rows of %s: %w", table, err)
+	}
+	return nil
+}
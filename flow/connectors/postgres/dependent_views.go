@@ -0,0 +1,131 @@
+package connpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// dependentView is a view captured before the table under it is swapped, to be created again over the new table
+type dependentView struct {
+	name         string
+	definition   string
+	materialized bool
+	// indexes and grants, which dropping the view loses
+	statements []string
+}
+
+// views are bound to the table they were created over rather than its name,
+// so swapping the table needs them dropped and created again
+const captureDependentViewSQL = `SELECT c.relkind = 'm', pg_get_viewdef(c.oid),
+	coalesce((SELECT array_agg(pg_get_indexdef(i.indexrelid)) FROM pg_index i WHERE i.indrelid = c.oid), '{}'),
+	coalesce((SELECT array_agg(format('GRANT %s ON %s TO %s', a.privilege_type, c.oid::regclass,
+		CASE WHEN a.grantee = 0 THEN 'PUBLIC' ELSE quote_ident(pg_get_userbyid(a.grantee)) END))
+		FROM aclexplode(c.relacl) a), '{}')
+	FROM pg_class c WHERE c.oid = to_regclass($1) AND c.relkind IN ('v', 'm')`
+
+func (c *PostgresConnector) captureDependentViews(
+	ctx context.Context,
+	tx pgx.Tx,
+	views []*protos.DependentView,
+) ([]dependentView, error) {
+	captured := make([]dependentView, 0, len(views))
+	for _, view := range views {
+		parsedView, err := utils.ParseSchemaTable(view.Name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse dependent view %s: %w", view.Name, err)
+		}
+		dv := dependentView{name: parsedView.String()}
+		var indexes, grants []string
+		if err := tx.QueryRow(ctx, captureDependentViewSQL, dv.name).Scan(
+			&dv.materialized, &dv.definition, &indexes, &grants,
+		); errors.Is(err, pgx.ErrNoRows) {
+			c.logger.Warn("dependent view does not exist, not recreating it", "view", dv.name)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to capture dependent view %s: %w", dv.name, err)
+		}
+		dv.definition = strings.TrimSuffix(strings.TrimSpace(dv.definition), ";")
+		dv.statements = append(indexes, grants...)
+		captured = append(captured, dv)
+	}
+	return captured, nil
+}
+
+// dropDependentViews drops views in reverse so views over other dependent views go first
+func (c *PostgresConnector) dropDependentViews(ctx context.Context, tx pgx.Tx, views []dependentView) error {
+	for _, view := range slices.Backward(views) {
+		kind := "VIEW"
+		if view.materialized {
+			kind = "MATERIALIZED VIEW"
+		}
+		if _, err := c.execWithLoggingTx(ctx, fmt.Sprintf("DROP %s IF EXISTS %s", kind, view.name), tx); err != nil {
+			return fmt.Errorf("unable to drop dependent view %s: %w", view.name, err)
+		}
+	}
+	return nil
+}
+
+func (c *PostgresConnector) createDependentViews(ctx context.Context, tx pgx.Tx, views []dependentView) error {
+	for _, view := range views {
+		kind := "VIEW"
+		if view.materialized {
+			kind = "MATERIALIZED VIEW"
+		}
+		if _, err := c.execWithLoggingTx(ctx,
+			fmt.Sprintf("CREATE %s %s AS %s", kind, view.name, view.definition), tx); err != nil {
+			return fmt.Errorf("unable to recreate dependent view %s: %w", view.name, err)
+		}
+		for _, stmt := range view.statements {
+			if _, err := c.execWithLoggingTx(ctx, stmt, tx); err != nil {
+				return fmt.Errorf("unable to restore index or grant of dependent view %s: %w", view.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// refreshDependentViews refreshes materialized views over tables changed by a normalize, concurrently when they
+// have the unique index that needs, so readers are not blocked while they refresh
+func (c *PostgresConnector) refreshDependentViews(
+	ctx context.Context,
+	tableMappings []*protos.TableMapping,
+	tables []string,
+) error {
+	for _, tableMapping := range tableMappings {
+		if !slices.Contains(tables, tableMapping.DestinationTableIdentifier) {
+			continue
+		}
+		for _, view := range tableMapping.DependentViews {
+			if !view.Materialized {
+				continue
+			}
+			parsedView, err := utils.ParseSchemaTable(view.Name)
+			if err != nil {
+				return fmt.Errorf("unable to parse dependent view %s: %w", view.Name, err)
+			}
+			var concurrently bool
+			if err := c.conn.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM pg_index i JOIN pg_class c ON c.oid = i.indrelid
+				WHERE i.indrelid = to_regclass($1) AND i.indisunique AND i.indpred IS NULL AND c.relispopulated)`,
+				parsedView.String()).Scan(&concurrently); err != nil {
+				return fmt.Errorf("unable to check indexes of dependent view %s: %w", view.Name, err)
+			}
+			stmt := "REFRESH MATERIALIZED VIEW "
+			if concurrently {
+				stmt += "CONCURRENTLY "
+			}
+			if _, err := c.execWithLogging(ctx, stmt+parsedView.String()); err != nil {
+				return fmt.Errorf("unable to refresh dependent view %s of %s: %w",
+					view.Name, tableMapping.DestinationTableIdentifier, err)
+			}
+		}
+	}
+	return nil
+}
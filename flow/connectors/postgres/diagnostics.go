@@ -0,0 +1,22 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// NormalizeDiagnostics describes sessions active in the database, with what they wait on and which sessions block them,
+// normalize is typically stuck behind locks held by other sessions
+func (c *PostgresConnector) NormalizeDiagnostics(ctx context.Context, flowJobName string) ([]string, error) {
+	rows, err := c.conn.Query(ctx, `SELECT format('pid %s %s for %s by %s, waiting on %s %s, blocked by %s: %s',
+		pid, state, date_trunc('second', now() - query_start), usename, coalesce(wait_event_type, '-'),
+		coalesce(wait_event, '-'), pg_blocking_pids(pid), left(query, 500))
+		FROM pg_stat_activity WHERE datname = current_database() AND pid <> pg_backend_pid() AND state <> 'idle'
+		ORDER BY query_start LIMIT 20`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read active sessions: %w", err)
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
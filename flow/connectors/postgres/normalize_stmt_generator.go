@@ -29,6 +29,10 @@ type normalizeStmtGenerator struct {
 	metadataSchema string
 	// Postgres version 15 introduced MERGE, fallback statements before that
 	supportsMerge bool
+	// how incoming changes are resolved against existing rows
+	conflictPolicy    protos.ConflictPolicy
+	conflictColumn    string
+	conflictCondition string
 }
 
 func (n *normalizeStmtGenerator) columnTypeToPg(schema *protos.TableSchema, columnType string) string {
@@ -59,18 +63,39 @@ func (n *normalizeStmtGenerator) generateExpr(
 	return fmt.Sprintf("(_peerdb_data->>%s)::%s", stringCol, pgType)
 }
 
-func (n *normalizeStmtGenerator) generateNormalizeStatements(dstTable string) []string {
+// normalizePhase is which changes a normalize statement applies,
+// deletes are applied apart from other changes so referencing rows can be deleted before the rows they reference
+type normalizePhase int8
+
+const (
+	normalizeAll normalizePhase = iota
+	normalizeUpserts
+	normalizeDeletes
+)
+
+func (n *normalizeStmtGenerator) generateNormalizeStatements(dstTable string, phase normalizePhase) []string {
 	normalizedTableSchema := n.tableSchemaMapping[dstTable]
 	if n.supportsMerge {
 		unchangedToastColumns := n.unchangedToastColumnsMap[dstTable]
-		return []string{n.generateMergeStatement(dstTable, normalizedTableSchema, unchangedToastColumns)}
+		return []string{n.generateMergeStatement(dstTable, normalizedTableSchema, unchangedToastColumns, phase)}
 	}
 	n.Warn("Postgres version is not high enough to support MERGE, falling back to UPSERT+DELETE")
 	n.Warn("TOAST columns will not be updated properly, use REPLICA IDENTITY FULL or upgrade Postgres")
 	if n.peerdbCols.SoftDeleteColName != "" {
 		n.Warn("soft delete enabled with fallback statements! this combination is unsupported")
 	}
-	return n.generateFallbackStatements(dstTable, normalizedTableSchema)
+	if n.conflictPolicy != protos.ConflictPolicy_CONFLICT_POLICY_SOURCE_WINS {
+		n.Warn("conflict policy is not supported with fallback statements, incoming changes always win")
+	}
+	statements := n.generateFallbackStatements(dstTable, normalizedTableSchema)
+	switch phase {
+	case normalizeUpserts:
+		return statements[:1]
+	case normalizeDeletes:
+		return statements[1:]
+	default:
+		return statements
+	}
 }
 
 func (n *normalizeStmtGenerator) generateFallbackStatements(
@@ -136,6 +161,7 @@ func (n *normalizeStmtGenerator) generateMergeStatement(
 	dstTableName string,
 	normalizedTableSchema *protos.TableSchema,
 	unchangedToastColumns []string,
+	phase normalizePhase,
 ) string {
 	columnCount := len(normalizedTableSchema.Columns)
 	quotedColumnNames := make([]string, columnCount)
@@ -194,6 +220,14 @@ func (n *normalizeStmtGenerator) generateMergeStatement(
 		}
 	}
 
+	var phaseFilter string
+	switch phase {
+	case normalizeUpserts:
+		phaseFilter = " AND _peerdb_record_type!=2"
+	case normalizeDeletes:
+		phaseFilter = " AND _peerdb_record_type=2"
+	}
+
 	mergeStmt := fmt.Sprintf(
 		mergeStatementSQL,
 		strings.Join(slices.Collect(maps.Values(primaryKeyColumnCasts)), ","),
@@ -201,10 +235,12 @@ func (n *normalizeStmtGenerator) generateMergeStatement(
 		n.rawTableName,
 		parsedDstTable.String(),
 		flattenedCastsSQL,
+		phaseFilter,
 		strings.Join(primaryKeySelectSQLArray, " AND "),
 		insertColumnsSQL,
 		insertValuesSQL,
 		updateStringToastCols,
+		n.conflictCheck(true),
 		conflictPart,
 	)
 
@@ -241,8 +277,8 @@ func (n *normalizeStmtGenerator) generateUpdateStatements(quotedCols []string, u
 		quotedCols := QuoteLiteral(cols)
 		ssep := strings.Join(tmpArray, ",")
 		updateStmt := fmt.Sprintf(`WHEN MATCHED AND
-			src._peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns=%s
-			THEN UPDATE SET %s`, quotedCols, ssep)
+			src._peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns=%s%s
+			THEN UPDATE SET %s`, quotedCols, n.conflictCheck(false), ssep)
 		updateStmts = append(updateStmts, updateStmt)
 
 		// generates update statements for the case where updates and deletes happen in the same branch
@@ -252,10 +288,50 @@ func (n *normalizeStmtGenerator) generateUpdateStatements(quotedCols []string, u
 			tmpArray[len(tmpArray)-1] = QuoteIdentifier(n.peerdbCols.SoftDeleteColName) + `=TRUE`
 			ssep := strings.Join(tmpArray, ", ")
 			updateStmt := fmt.Sprintf(`WHEN MATCHED AND
-			src._peerdb_record_type=2 AND _peerdb_unchanged_toast_columns=%s
-			THEN UPDATE SET %s`, quotedCols, ssep)
+			src._peerdb_record_type=2 AND _peerdb_unchanged_toast_columns=%s%s
+			THEN UPDATE SET %s`, quotedCols, n.conflictCheck(true), ssep)
 			updateStmts = append(updateStmts, updateStmt)
 		}
 	}
 	return updateStmts
 }
+
+// conflictCheck is a MERGE condition under which incoming change src overwrites existing row dst,
+// empty when incoming changes always win
+func (n *normalizeStmtGenerator) conflictCheck(isDelete bool) string {
+	switch n.conflictPolicy {
+	case protos.ConflictPolicy_CONFLICT_POLICY_LAST_WRITE_WINS:
+		col := QuoteIdentifier(n.conflictColumn)
+		if isDelete {
+			// deleted rows only carry the conflict column with REPLICA IDENTITY FULL, deletes without it win
+			return fmt.Sprintf(" AND (src.%s IS NULL OR dst.%s IS NULL OR src.%s>=dst.%s)", col, col, col, col)
+		}
+		return fmt.Sprintf(" AND (dst.%s IS NULL OR src.%s>=dst.%s)", col, col, col)
+	case protos.ConflictPolicy_CONFLICT_POLICY_CUSTOM:
+		return fmt.Sprintf(" AND (%s)", n.conflictCondition)
+	default:
+		return ""
+	}
+}
+
+// generateTruncateStatement applies a source TRUNCATE to dstTableName, empty when there is nothing to do
+func (n *normalizeStmtGenerator) generateTruncateStatement(dstTableName string, policy protos.TruncatePolicy) string {
+	parsedDstTable, _ := utils.ParseSchemaTable(dstTableName)
+	switch policy {
+	case protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE:
+		return "TRUNCATE TABLE " + parsedDstTable.String()
+	case protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE:
+		if n.peerdbCols.SoftDeleteColName == "" {
+			n.Logger.Warn("soft delete column not set, ignoring TRUNCATE", "table", dstTableName)
+			return ""
+		}
+		softDeleteCol := QuoteIdentifier(n.peerdbCols.SoftDeleteColName)
+		stmt := fmt.Sprintf("UPDATE %s SET %s=TRUE", parsedDstTable.String(), softDeleteCol)
+		if n.peerdbCols.SyncedAtColName != "" {
+			stmt += fmt.Sprintf(",%s=CURRENT_TIMESTAMP", QuoteIdentifier(n.peerdbCols.SyncedAtColName))
+		}
+		return stmt + fmt.Sprintf(" WHERE %s IS NOT TRUE", softDeleteCol)
+	default:
+		return ""
+	}
+}
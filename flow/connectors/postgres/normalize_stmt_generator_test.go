@@ -138,3 +138,59 @@ func TestGenerateMergeUpdateStatement_WithUnchangedToastColsAndSoftDelete(t *tes
 		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
 	}
 }
+
+func TestGenerateTruncateStatement(t *testing.T) {
+	normalizeGen := normalizeStmtGenerator{
+		peerdbCols: &protos.PeerDBColumns{
+			SyncedAtColName:   "_peerdb_synced_at",
+			SoftDeleteColName: "_peerdb_soft_delete",
+		},
+	}
+
+	expected := `TRUNCATE TABLE "public"."t1"`
+	if result := normalizeGen.generateTruncateStatement("public.t1",
+		protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE); result != expected {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
+	}
+
+	expected = `UPDATE "public"."t1" SET "_peerdb_soft_delete"=TRUE,"_peerdb_synced_at"=CURRENT_TIMESTAMP` +
+		` WHERE "_peerdb_soft_delete" IS NOT TRUE`
+	if result := normalizeGen.generateTruncateStatement("public.t1",
+		protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE); result != expected {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
+	}
+
+	if result := normalizeGen.generateTruncateStatement("public.t1",
+		protos.TruncatePolicy_TRUNCATE_POLICY_IGNORE); result != "" {
+		t.Errorf("Unexpected result. Expected no statement, but got: %v", result)
+	}
+}
+
+func TestConflictCheck(t *testing.T) {
+	normalizeGen := normalizeStmtGenerator{
+		conflictPolicy: protos.ConflictPolicy_CONFLICT_POLICY_LAST_WRITE_WINS,
+		conflictColumn: "updated_at",
+	}
+
+	expected := ` AND (dst."updated_at" IS NULL OR src."updated_at">=dst."updated_at")`
+	if result := normalizeGen.conflictCheck(false); result != expected {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
+	}
+
+	expected = ` AND (src."updated_at" IS NULL OR dst."updated_at" IS NULL OR src."updated_at">=dst."updated_at")`
+	if result := normalizeGen.conflictCheck(true); result != expected {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
+	}
+
+	normalizeGen.conflictPolicy = protos.ConflictPolicy_CONFLICT_POLICY_CUSTOM
+	normalizeGen.conflictCondition = "src.version>dst.version"
+	expected = ` AND (src.version>dst.version)`
+	if result := normalizeGen.conflictCheck(false); result != expected {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
+	}
+
+	normalizeGen.conflictPolicy = protos.ConflictPolicy_CONFLICT_POLICY_SOURCE_WINS
+	if result := normalizeGen.conflictCheck(true); result != "" {
+		t.Errorf("Unexpected result. Expected no condition, but got: %v", result)
+	}
+}
@@ -0,0 +1,307 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// normalizeBatch is what merging a range of batches into destination tables needs
+type normalizeBatch struct {
+	gen             *normalizeStmtGenerator
+	req             *model.NormalizeRecordsRequest
+	replayTables    map[string]int64
+	truncatedTables map[string]int64
+	normBatchID     int64
+}
+
+// applyGroup is tables applied together in one transaction, truncates first and then merges in order
+type applyGroup struct {
+	truncate []string
+	merge    []string
+	// foreign keys between tables of the group, pairs of referencing and referenced table
+	links [][2]string
+}
+
+// normalizeTables applies the changes of group in tx, returning the number of rows affected
+func (c *PostgresConnector) normalizeTables(ctx context.Context, tx pgx.Tx, b *normalizeBatch, group applyGroup) (int64, error) {
+	for _, destinationTableName := range group.truncate {
+		truncateStatement := b.gen.generateTruncateStatement(destinationTableName, b.req.TruncatePolicy)
+		if truncateStatement == "" {
+			continue
+		}
+		audit.Record(ctx, truncateStatement)
+		if _, err := tx.Exec(ctx, truncateStatement); err != nil {
+			return 0, fmt.Errorf("error applying truncate to table %s: %w", destinationTableName, err)
+		}
+	}
+
+	// referenced tables are merged before tables referencing them, without foreign keys between them
+	// every table is merged in one statement. With them deletes are applied after other changes in reverse order,
+	// so rows are deleted before rows they reference. Constraints declared deferrable are only checked on commit
+	steps := []mergeStep{{tables: group.merge, phase: normalizeAll}}
+	if len(group.links) > 0 {
+		if _, err := tx.Exec(ctx, "SET CONSTRAINTS ALL DEFERRED"); err != nil {
+			return 0, fmt.Errorf("error deferring constraints: %w", err)
+		}
+		ordered := orderByForeignKeys(group.merge, group.links)
+		if b.gen.peerdbCols.SoftDeleteColName != "" {
+			// soft deletes update rows in place, no row referenced goes away
+			steps = []mergeStep{{tables: ordered, phase: normalizeAll}}
+		} else {
+			reversed := slices.Clone(ordered)
+			slices.Reverse(reversed)
+			steps = []mergeStep{{tables: ordered, phase: normalizeUpserts}, {tables: reversed, phase: normalizeDeletes}}
+		}
+	}
+
+	startBatchIDs := make(map[string]int64, len(group.merge))
+	earliestBatchID := b.normBatchID
+	for _, destinationTableName := range group.merge {
+		startBatchID := b.normBatchID
+		if replayBatchID, ok := b.replayTables[destinationTableName]; ok {
+			startBatchID = replayBatchID
+		}
+		if truncateBatchID, ok := b.truncatedTables[destinationTableName]; ok {
+			startBatchID = max(b.normBatchID, truncateBatchID-1)
+		}
+		startBatchIDs[destinationTableName] = startBatchID
+		earliestBatchID = min(earliestBatchID, startBatchID)
+	}
+	for i := range steps {
+		steps[i].statements = make([][]string, len(steps[i].tables))
+		for j, destinationTableName := range steps[i].tables {
+			steps[i].statements[j] = b.gen.generateNormalizeStatements(destinationTableName, steps[i].phase)
+		}
+	}
+
+	// every step goes through a range of batches before the next range, a row deleted in one range
+	// and inserted again in a later one has to end up inserted
+	var totalRowsAffected int64
+	for _, batchRange := range utils.NormalizeBatchRanges(earliestBatchID, b.req.SyncBatchID, b.req.SkippedBatches) {
+		for _, step := range steps {
+			for j, destinationTableName := range step.tables {
+				rangeStart := max(batchRange.Start, startBatchIDs[destinationTableName])
+				if rangeStart >= batchRange.End {
+					continue
+				}
+				for _, normalizeStatement := range step.statements[j] {
+					audit.Record(ctx, normalizeStatement)
+					ct, err := tx.Exec(ctx, normalizeStatement, rangeStart, batchRange.End, destinationTableName)
+					if err != nil {
+						c.logger.Error("error executing normalize statement",
+							slog.String("statement", normalizeStatement),
+							slog.Int64("normBatchID", b.normBatchID),
+							slog.Int64("syncBatchID", b.req.SyncBatchID),
+							slog.String("destinationTableName", destinationTableName),
+							slog.Any("error", err),
+						)
+						return 0, fmt.Errorf("error executing normalize statement for table %s: %w", destinationTableName, err)
+					}
+					totalRowsAffected += ct.RowsAffected()
+				}
+			}
+		}
+	}
+	return totalRowsAffected, nil
+}
+
+// mergeStep is a phase of changes merged into tables in order
+type mergeStep struct {
+	tables     []string
+	statements [][]string
+	phase      normalizePhase
+}
+
+// orderByForeignKeys orders tables so tables referenced by foreign keys come before tables referencing them,
+// tables left in cycles follow in their original order
+func orderByForeignKeys(tables []string, links [][2]string) []string {
+	pending := make(map[string]int, len(tables))
+	referencedBy := make(map[string][]string)
+	for _, link := range links {
+		if link[0] == link[1] || !slices.Contains(tables, link[0]) || !slices.Contains(tables, link[1]) {
+			continue
+		}
+		pending[link[0]]++
+		referencedBy[link[1]] = append(referencedBy[link[1]], link[0])
+	}
+
+	ordered := make([]string, 0, len(tables))
+	done := make(map[string]struct{}, len(tables))
+	for len(ordered) < len(tables) {
+		progressed := false
+		for _, table := range tables {
+			if _, ok := done[table]; ok || pending[table] > 0 {
+				continue
+			}
+			done[table] = struct{}{}
+			ordered = append(ordered, table)
+			for _, referencing := range referencedBy[table] {
+				pending[referencing]--
+			}
+			progressed = true
+		}
+		if !progressed {
+			for _, table := range tables {
+				if _, ok := done[table]; !ok {
+					ordered = append(ordered, table)
+				}
+			}
+			break
+		}
+	}
+	return ordered
+}
+
+// foreignKeyLinks returns pairs of tables of schemas where the first references the second
+func (c *PostgresConnector) foreignKeyLinks(ctx context.Context, schemas []string) ([][2]string, error) {
+	rows, err := c.conn.Query(ctx, `SELECT cn.nspname, cl.relname, fn.nspname, fl.relname FROM pg_constraint c
+		JOIN pg_class cl ON cl.oid = c.conrelid JOIN pg_namespace cn ON cn.oid = cl.relnamespace
+		JOIN pg_class fl ON fl.oid = c.confrelid JOIN pg_namespace fn ON fn.oid = fl.relnamespace
+		WHERE c.contype = 'f' AND (cn.nspname = ANY($1) OR fn.nspname = ANY($1))`, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query foreign keys: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) ([2]string, error) {
+		var schema, table, refSchema, refTable string
+		if err := row.Scan(&schema, &table, &refSchema, &refTable); err != nil {
+			return [2]string{}, err
+		}
+		return [2]string{schema + "." + table, refSchema + "." + refTable}, nil
+	})
+}
+
+// groupForeignKeyLinks returns foreign keys between tables of group
+func (c *PostgresConnector) groupForeignKeyLinks(ctx context.Context, group applyGroup) ([][2]string, error) {
+	tables := slices.Concat(group.truncate, group.merge)
+	var schemas []string
+	for _, table := range tables {
+		schemaTable, err := utils.ParseSchemaTable(table)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(schemas, schemaTable.Schema) {
+			schemas = append(schemas, schemaTable.Schema)
+		}
+	}
+	if len(schemas) == 0 {
+		return nil, nil
+	}
+	links, err := c.foreignKeyLinks(ctx, schemas)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(links, func(link [2]string) bool {
+		return !slices.Contains(tables, link[0]) || !slices.Contains(tables, link[1])
+	}), nil
+}
+
+// splitApplyGroups splits tables of group into groups which can be applied independently.
+// Rows of a table are only ever changed by the merge of its table, so tables conflict only through
+// foreign keys, tables linked by them directly or through other tables of the batch stay in one group
+func splitApplyGroups(group applyGroup) []applyGroup {
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(table string) string {
+		if p, ok := parent[table]; ok && p != table {
+			root := find(p)
+			parent[table] = root
+			return root
+		}
+		return table
+	}
+	for _, table := range slices.Concat(group.truncate, group.merge) {
+		parent[table] = table
+	}
+	var links [][2]string
+	for _, link := range group.links {
+		if _, ok := parent[link[0]]; !ok {
+			continue
+		}
+		if _, ok := parent[link[1]]; !ok {
+			continue
+		}
+		links = append(links, link)
+		if a, b := find(link[0]), find(link[1]); a != b {
+			parent[a] = b
+		}
+	}
+
+	var groups []applyGroup
+	index := make(map[string]int)
+	groupOf := func(table string) *applyGroup {
+		root := find(table)
+		i, ok := index[root]
+		if !ok {
+			i = len(groups)
+			index[root] = i
+			groups = append(groups, applyGroup{})
+		}
+		return &groups[i]
+	}
+	for _, table := range group.truncate {
+		g := groupOf(table)
+		g.truncate = append(g.truncate, table)
+	}
+	for _, table := range group.merge {
+		g := groupOf(table)
+		g.merge = append(g.merge, table)
+	}
+	for _, link := range links {
+		g := groupOf(link[0])
+		g.links = append(g.links, link)
+	}
+	return groups
+}
+
+// normalizeParallel applies independent groups of tables on connections of their own, each in a transaction.
+// Groups committed before another fails are merged again on retry, which converges as merges keep the last
+// change of every key
+func (c *PostgresConnector) normalizeParallel(ctx context.Context, b *normalizeBatch, group applyGroup, parallelism int) error {
+	groups := splitApplyGroups(group)
+	c.logger.Info("applying batches in parallel",
+		slog.Int("groups", len(groups)), slog.Int("parallelism", parallelism))
+
+	var totalRowsAffected atomic.Int64
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(parallelism)
+	for _, group := range groups {
+		g.Go(func() error {
+			conn, err := c.ssh.NewPostgresConnFromConfig(gCtx, c.conn.Config())
+			if err != nil {
+				return fmt.Errorf("failed to create connection for parallel apply: %w", err)
+			}
+			defer conn.Close(context.Background())
+
+			tx, err := conn.Begin(gCtx)
+			if err != nil {
+				return fmt.Errorf("error starting transaction for normalizing records: %w", err)
+			}
+			defer shared.RollbackTx(tx, c.logger)
+			rowsAffected, err := c.normalizeTables(gCtx, tx, b, group)
+			if err != nil {
+				return err
+			}
+			if err := tx.Commit(gCtx); err != nil {
+				return fmt.Errorf("error committing normalized records: %w", err)
+			}
+			totalRowsAffected.Add(rowsAffected)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	c.logger.Info(fmt.Sprintf("normalized %d records", totalRowsAffected.Load()))
+	return nil
+}
@@ -0,0 +1,45 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitApplyGroups(t *testing.T) {
+	groups := splitApplyGroups(applyGroup{
+		truncate: []string{"public.items"},
+		merge:    []string{"public.orders", "public.users", "public.logs", "public.items", "public.customers"},
+		links: [][2]string{
+			{"public.orders", "public.customers"},
+			{"public.items", "public.orders"},
+			// tables outside the batch do not link tables of it
+			{"public.users", "public.accounts"},
+			{"public.logs", "public.accounts"},
+		},
+	})
+	require.Equal(t, []applyGroup{
+		{
+			truncate: []string{"public.items"},
+			merge:    []string{"public.orders", "public.items", "public.customers"},
+			links:    [][2]string{{"public.orders", "public.customers"}, {"public.items", "public.orders"}},
+		},
+		{merge: []string{"public.users"}},
+		{merge: []string{"public.logs"}},
+	}, groups)
+}
+
+func TestOrderByForeignKeys(t *testing.T) {
+	tables := []string{"public.items", "public.logs", "public.orders", "public.customers", "public.a", "public.b"}
+	require.Equal(t, []string{"public.logs", "public.customers", "public.orders", "public.items", "public.a", "public.b"},
+		orderByForeignKeys(tables, [][2]string{
+			{"public.items", "public.orders"},
+			{"public.orders", "public.customers"},
+			// self references and tables outside the batch don't hold back tables
+			{"public.orders", "public.orders"},
+			{"public.logs", "public.accounts"},
+			// tables in cycles keep their order
+			{"public.a", "public.b"},
+			{"public.b", "public.a"},
+		}))
+}
@@ -0,0 +1,53 @@
+package connpostgres
+
+import (
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const (
+	supabaseTransactionPoolerPort = 6543
+	supabaseSessionPoolerPort     = 5432
+	neonPoolerSuffix              = "-pooler"
+)
+
+func DetectPostgresPlatform(host string) protos.PostgresPlatform {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	switch {
+	case strings.HasSuffix(host, ".rds.amazonaws.com"):
+		return protos.PostgresPlatform_POSTGRES_PLATFORM_RDS
+	case strings.HasSuffix(host, ".supabase.co"), strings.HasSuffix(host, ".supabase.com"):
+		return protos.PostgresPlatform_POSTGRES_PLATFORM_SUPABASE
+	case strings.HasSuffix(host, ".neon.tech"):
+		return protos.PostgresPlatform_POSTGRES_PLATFORM_NEON
+	default:
+		return protos.PostgresPlatform_POSTGRES_PLATFORM_GENERIC
+	}
+}
+
+// ApplyPostgresPlatformPreset detects the platform of config when unknown,
+// moving it off connection poolers that break replication and COPY
+func ApplyPostgresPlatformPreset(config *protos.PostgresConfig) {
+	if config.Platform == protos.PostgresPlatform_POSTGRES_PLATFORM_UNKNOWN {
+		config.Platform = DetectPostgresPlatform(config.Host)
+	}
+
+	switch config.Platform {
+	case protos.PostgresPlatform_POSTGRES_PLATFORM_SUPABASE:
+		// Supavisor runs transaction mode on 6543 and session mode on 5432 of the same host
+		if config.Port == supabaseTransactionPoolerPort {
+			config.Port = supabaseSessionPoolerPort
+		}
+	case protos.PostgresPlatform_POSTGRES_PLATFORM_NEON:
+		// ep-name-123-pooler.region.aws.neon.tech is pgbouncer in front of ep-name-123.region.aws.neon.tech
+		endpoint, rest, found := strings.Cut(config.Host, ".")
+		if found && strings.HasSuffix(endpoint, neonPoolerSuffix) {
+			config.Host = strings.TrimSuffix(endpoint, neonPoolerSuffix) + "." + rest
+		}
+	}
+}
+
+func isSupabasePoolerHost(host string) bool {
+	return strings.HasSuffix(strings.ToLower(host), ".pooler.supabase.com")
+}
@@ -0,0 +1,66 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestApplyPostgresPlatformPreset(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   *protos.PostgresConfig
+		platform protos.PostgresPlatform
+		host     string
+		port     uint32
+	}{
+		{
+			name:     "generic",
+			config:   &protos.PostgresConfig{Host: "db.example.com", Port: 6543},
+			platform: protos.PostgresPlatform_POSTGRES_PLATFORM_GENERIC,
+			host:     "db.example.com",
+			port:     6543,
+		},
+		{
+			name:     "rds",
+			config:   &protos.PostgresConfig{Host: "mydb.abc123.us-east-1.rds.amazonaws.com", Port: 5432},
+			platform: protos.PostgresPlatform_POSTGRES_PLATFORM_RDS,
+			host:     "mydb.abc123.us-east-1.rds.amazonaws.com",
+			port:     5432,
+		},
+		{
+			name:     "supabase transaction pooler",
+			config:   &protos.PostgresConfig{Host: "aws-0-us-east-1.pooler.supabase.com", Port: 6543},
+			platform: protos.PostgresPlatform_POSTGRES_PLATFORM_SUPABASE,
+			host:     "aws-0-us-east-1.pooler.supabase.com",
+			port:     5432,
+		},
+		{
+			name:     "neon pooler",
+			config:   &protos.PostgresConfig{Host: "ep-cool-darkness-123456-pooler.us-east-2.aws.neon.tech", Port: 5432},
+			platform: protos.PostgresPlatform_POSTGRES_PLATFORM_NEON,
+			host:     "ep-cool-darkness-123456.us-east-2.aws.neon.tech",
+			port:     5432,
+		},
+		{
+			name: "explicit platform",
+			config: &protos.PostgresConfig{
+				Host:     "db.internal",
+				Port:     6543,
+				Platform: protos.PostgresPlatform_POSTGRES_PLATFORM_SUPABASE,
+			},
+			platform: protos.PostgresPlatform_POSTGRES_PLATFORM_SUPABASE,
+			host:     "db.internal",
+			port:     5432,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ApplyPostgresPlatformPreset(tc.config)
+			require.Equal(t, tc.platform, tc.config.Platform)
+			require.Equal(t, tc.host, tc.config.Host)
+			require.Equal(t, tc.port, tc.config.Port)
+		})
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -14,11 +17,14 @@ import (
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pglogrepl"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel/attribute"
 	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/temporal"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peer-flow/alerting"
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
@@ -30,6 +36,7 @@ import (
 	peerdb_gauges "github.com/PeerDB-io/peer-flow/otel_metrics/peerdb_gauges"
 	"github.com/PeerDB-io/peer-flow/peerdbenv"
 	"github.com/PeerDB-io/peer-flow/shared"
+	"github.com/PeerDB-io/peer-flow/shared/metrics"
 )
 
 type PostgresConnector struct {
@@ -43,6 +50,7 @@ type PostgresConnector struct {
 	customTypesMapping     map[uint32]string
 	hushWarnOID            map[uint32]struct{}
 	relationMessageMapping model.RelationMessageMapping
+	pendingTruncate        []string
 	connStr                string
 	metadataSchema         string
 	replLock               sync.Mutex
@@ -51,8 +59,11 @@ type PostgresConnector struct {
 type ReplState struct {
 	Slot        string
 	Publication string
+	Plugin      string
 	Offset      int64
 	LastOffset  atomic.Int64
+	// wal2json format version the slot is streamed with, 0 for pgoutput
+	FormatVersion int
 }
 
 func NewPostgresConnector(ctx context.Context, pgConfig *protos.PostgresConfig) (*PostgresConnector, error) {
@@ -66,10 +77,24 @@ func NewPostgresConnector(ctx context.Context, pgConfig *protos.PostgresConfig)
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
 
+	statementTimeout, err := peerdbenv.PeerDBStatementTimeout(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement timeout: %w", err)
+	}
+
 	replConfig := connConfig.Copy()
 	runtimeParams := connConfig.Config.RuntimeParams
 	runtimeParams["idle_in_transaction_session_timeout"] = "0"
-	runtimeParams["statement_timeout"] = "0"
+	runtimeParams["statement_timeout"] = strconv.FormatInt(statementTimeout.Milliseconds(), 10)
+	// by default a canceled context only closes the socket, leaving the query running on the server,
+	// and connections over ssh ignore deadlines altogether, so ask the server to cancel the query instead
+	connConfig.BuildContextWatcherHandler = func(pgConn *pgconn.PgConn) ctxwatch.Handler {
+		return &pgconn.CancelRequestContextWatcherHandler{
+			Conn:               pgConn,
+			CancelRequestDelay: 0,
+			DeadlineDelay:      time.Minute,
+		}
+	}
 
 	tunnel, err := NewSSHTunnel(ctx, pgConfig.SshConfig)
 	if err != nil {
@@ -115,6 +140,19 @@ func NewPostgresConnector(ctx context.Context, pgConfig *protos.PostgresConfig)
 	}, nil
 }
 
+// ReadReplicaConfig returns config with the read replica endpoint in place of host, nil without one
+func ReadReplicaConfig(config *protos.PostgresConfig) *protos.PostgresConfig {
+	if config.ReadReplicaHost == "" {
+		return nil
+	}
+	replicaConfig := proto.Clone(config).(*protos.PostgresConfig)
+	replicaConfig.Host = config.ReadReplicaHost
+	if config.ReadReplicaPort != 0 {
+		replicaConfig.Port = config.ReadReplicaPort
+	}
+	return replicaConfig
+}
+
 func (c *PostgresConnector) CreateReplConn(ctx context.Context) (*pgx.Conn, error) {
 	conn, err := c.ssh.NewPostgresConnFromConfig(ctx, c.replConfig)
 	if err != nil {
@@ -153,6 +191,7 @@ func (c *PostgresConnector) MaybeStartReplication(
 	ctx context.Context,
 	slotName string,
 	publicationName string,
+	plugin string,
 	lastOffset int64,
 ) error {
 	if c.replState != nil && (c.replState.Offset != lastOffset ||
@@ -166,11 +205,6 @@ func (c *PostgresConnector) MaybeStartReplication(
 	}
 
 	if c.replState == nil {
-		replicationOpts, err := c.replicationOptions(ctx, publicationName)
-		if err != nil {
-			return fmt.Errorf("error getting replication options: %w", err)
-		}
-
 		var startLSN pglogrepl.LSN
 		if lastOffset > 0 {
 			c.logger.Info("starting replication from last sync state", slog.Int64("last checkpoint", lastOffset))
@@ -179,17 +213,20 @@ func (c *PostgresConnector) MaybeStartReplication(
 
 		c.replLock.Lock()
 		defer c.replLock.Unlock()
-		if err := pglogrepl.StartReplication(ctx, c.replConn.PgConn(), slotName, startLSN, replicationOpts); err != nil {
+		formatVersion, err := c.startReplication(ctx, slotName, plugin, publicationName, startLSN)
+		if err != nil {
 			c.logger.Error("error starting replication", slog.Any("error", err))
 			return fmt.Errorf("error starting replication at startLsn - %d: %w", startLSN, err)
 		}
 
-		c.logger.Info(fmt.Sprintf("started replication on slot %s at startLSN: %d", slotName, startLSN))
+		c.logger.Info(fmt.Sprintf("started replication on slot %s with %s at startLSN: %d", slotName, plugin, startLSN))
 		c.replState = &ReplState{
-			Slot:        slotName,
-			Publication: publicationName,
-			Offset:      lastOffset,
-			LastOffset:  atomic.Int64{},
+			Slot:          slotName,
+			Publication:   publicationName,
+			Plugin:        plugin,
+			Offset:        lastOffset,
+			LastOffset:    atomic.Int64{},
+			FormatVersion: formatVersion,
 		}
 		c.replState.LastOffset.Store(lastOffset)
 	}
@@ -354,7 +391,10 @@ func pullCore[Items model.Items](
 	}
 
 	if !exists.PublicationExists {
-		c.logger.Warn(fmt.Sprintf("publication %s does not exist", publicationName))
+		// wal2json slots decode every table without a publication
+		if exists.Plugin != pluginWal2json {
+			c.logger.Warn(fmt.Sprintf("publication %s does not exist", publicationName))
+		}
 		publicationName = ""
 	}
 
@@ -364,6 +404,12 @@ func pullCore[Items model.Items](
 			fmt.Sprintf("replication slot %s does not exist, restarting workflow", slotName), "disconnect", nil)
 	}
 
+	if exists.Conflicting {
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf(
+			"replication slot %s was invalidated by a conflict with recovery on the standby, resync the mirror", slotName),
+			"invalidated", nil)
+	}
+
 	c.logger.Info("PullRecords: performed checks for slot and publication")
 
 	childToParentRelIDMap, err := GetChildToParentRelIDMap(ctx, c.conn)
@@ -371,7 +417,7 @@ func pullCore[Items model.Items](
 		return fmt.Errorf("error getting child to parent relid map: %w", err)
 	}
 
-	if err := c.MaybeStartReplication(ctx, slotName, publicationName, req.LastOffset); err != nil {
+	if err := c.MaybeStartReplication(ctx, slotName, publicationName, exists.Plugin, req.LastOffset); err != nil {
 		// in case of Aurora error ERROR: replication slots cannot be used on RO (Read Only) node (SQLSTATE 55000)
 		if shared.IsSQLStateError(err, pgerrcode.ObjectNotInPrerequisiteState) {
 			return temporal.NewNonRetryableApplicationError("reset connection to reconcile Aurora failover", "disconnect", err)
@@ -380,7 +426,12 @@ func pullCore[Items model.Items](
 		return err
 	}
 
+	var decoder logicalDecoder = pgoutputDecoder{}
+	if c.replState.Plugin == pluginWal2json {
+		decoder = newWal2jsonDecoder(c.replState.FormatVersion, catalogRelationLookup{ctx: ctx, conn: c.conn})
+	}
 	cdc := c.NewPostgresCDCSource(&PostgresCDCConfig{
+		Decoder:                decoder,
 		SrcTableIDNameMapping:  req.SrcTableIDNameMapping,
 		Slot:                   slotName,
 		Publication:            publicationName,
@@ -390,6 +441,11 @@ func pullCore[Items model.Items](
 		CatalogPool:            catalogPool,
 		FlowJobName:            req.FlowJobName,
 		RelationMessageMapping: c.relationMessageMapping,
+		TypeWideningPolicy:     req.TypeWideningPolicy,
+		TruncatePolicy:         req.TruncatePolicy,
+		MessageDestination:     req.LogicalMessageDestination,
+		ExcludedOrigins:        req.ExcludedOrigins,
+		SourceIdentifier:       req.SourceIdentifier,
 	})
 
 	if err := PullCdcRecords(ctx, cdc, req, processor, &c.replLock); err != nil {
@@ -437,7 +493,17 @@ func syncRecordsCore[Items model.Items](
 
 	numRecords := int64(0)
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	router := tenantRouter(utils.TenantRoutedTables(req.TableMappings))
+	numRows := int64(0)
+	// second row of an update moving a row to another tenant
+	var pendingRow []any
 	streamReadFunc := func() ([]any, error) {
+		if pendingRow != nil {
+			row := pendingRow
+			pendingRow = nil
+			numRows += 1
+			return row, nil
+		}
 		for record := range req.Records.GetRecords() {
 			var row []any
 			switch typedRecord := record.(type) {
@@ -453,7 +519,7 @@ func syncRecordsCore[Items model.Items](
 				row = []any{
 					uuid.New().String(),
 					time.Now().UnixNano(),
-					typedRecord.DestinationTableName,
+					router.destination(typedRecord.DestinationTableName, typedRecord.Items),
 					itemsJSON,
 					0,
 					"{}",
@@ -477,16 +543,43 @@ func syncRecordsCore[Items model.Items](
 					return nil, fmt.Errorf("failed to serialize update record old items to JSON: %w", err)
 				}
 
+				destinationTable := router.destination(typedRecord.DestinationTableName, typedRecord.NewItems)
 				row = []any{
 					uuid.New().String(),
 					time.Now().UnixNano(),
-					typedRecord.DestinationTableName,
+					destinationTable,
 					newItemsJSON,
 					1,
 					oldItemsJSON,
 					req.SyncBatchID,
 					utils.KeysToString(typedRecord.UnchangedToastColumns),
 				}
+				// old rows only have the tenant column under a replica identity with it, rows moving to
+				// another tenant are deleted from the table of the old one and inserted into the new one,
+				// unchanged TOAST columns of those end up null
+				if oldDestinationTable := router.destination(typedRecord.DestinationTableName,
+					typedRecord.OldItems); oldDestinationTable != destinationTable {
+					pendingRow = []any{
+						uuid.New().String(),
+						time.Now().UnixNano(),
+						destinationTable,
+						newItemsJSON,
+						0,
+						"{}",
+						req.SyncBatchID,
+						"",
+					}
+					row = []any{
+						uuid.New().String(),
+						time.Now().UnixNano(),
+						oldDestinationTable,
+						oldItemsJSON,
+						2,
+						oldItemsJSON,
+						req.SyncBatchID,
+						"",
+					}
+				}
 
 			case *model.DeleteRecord[Items]:
 				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(model.ToJSONOptions{
@@ -500,7 +593,7 @@ func syncRecordsCore[Items model.Items](
 				row = []any{
 					uuid.New().String(),
 					time.Now().UnixNano(),
-					typedRecord.DestinationTableName,
+					router.destination(typedRecord.DestinationTableName, typedRecord.Items),
 					itemsJSON,
 					2,
 					itemsJSON,
@@ -509,7 +602,24 @@ func syncRecordsCore[Items model.Items](
 				}
 
 			case *model.MessageRecord[Items]:
-				continue
+				if typedRecord.DestinationTableName == "" {
+					continue
+				}
+				itemsJSON, err := model.ItemsToJSON(typedRecord.TableItems())
+				if err != nil {
+					return nil, fmt.Errorf("failed to serialize message record to JSON: %w", err)
+				}
+
+				row = []any{
+					uuid.New().String(),
+					time.Now().UnixNano(),
+					typedRecord.DestinationTableName,
+					itemsJSON,
+					0,
+					"{}",
+					req.SyncBatchID,
+					"",
+				}
 
 			default:
 				return nil, fmt.Errorf("unsupported record type for Postgres flow connector: %T", typedRecord)
@@ -517,6 +627,7 @@ func syncRecordsCore[Items model.Items](
 
 			record.PopulateCountMap(tableNameRowsMapping)
 			numRecords += 1
+			numRows += 1
 			return row, nil
 		}
 
@@ -538,9 +649,9 @@ func syncRecordsCore[Items model.Items](
 	if err != nil {
 		return nil, fmt.Errorf("error syncing records: %w", err)
 	}
-	if syncedRecordsCount != numRecords {
+	if syncedRecordsCount != numRows {
 		return nil, fmt.Errorf("error syncing records: expected %d records to be synced, but %d were synced",
-			numRecords, syncedRecordsCount)
+			numRows, syncedRecordsCount)
 	}
 
 	c.logger.Info(fmt.Sprintf("synced %d records to Postgres table %s via COPY",
@@ -562,6 +673,7 @@ func syncRecordsCore[Items model.Items](
 	if err != nil {
 		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
 	}
+	metrics.Synced(ctx, req.FlowJobName, numRecords)
 
 	return &model.SyncResponse{
 		LastSyncedCheckpointID: lastCP,
@@ -576,6 +688,7 @@ func (c *PostgresConnector) NormalizeRecords(
 	ctx context.Context,
 	req *model.NormalizeRecordsRequest,
 ) (*model.NormalizeResponse, error) {
+	startTime := time.Now()
 	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
 
 	jobMetadataExists, err := c.jobMetadataExists(ctx, req.FlowJobName)
@@ -611,23 +724,31 @@ func (c *PostgresConnector) NormalizeRecords(
 	if err != nil {
 		return nil, err
 	}
+	destinationTableNames = utils.WithoutSkippedTables(destinationTableNames, req.SkippedTables)
+	req, destinationTableNames, err = c.prepareTenantTables(ctx, req, destinationTableNames)
+	if err != nil {
+		return nil, err
+	}
+	// changes buffered while quarantined or of replayed batches are merged along with the current batches
+	replayTables := utils.ReplayTablesToNormalize(req.ReplayTables, req.TruncatedTables, normBatchID)
+	destinationTableNames = utils.WithReplayedTables(destinationTableNames, replayTables)
 	unchangedToastColumnsMap, err := c.getTableNametoUnchangedCols(ctx, req.FlowJobName,
-		req.SyncBatchID, normBatchID)
+		req.SyncBatchID, utils.EarliestReplayBatchID(replayTables, normBatchID))
 	if err != nil {
 		return nil, err
 	}
 
-	normalizeRecordsTx, err := c.conn.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("error starting transaction for normalizing records: %w", err)
+	if req.ReplicationOrigin != "" {
+		if err := c.setupReplicationOrigin(ctx, req.ReplicationOrigin); err != nil {
+			return nil, err
+		}
+		defer c.resetReplicationOrigin(ctx)
 	}
-	defer shared.RollbackTx(normalizeRecordsTx, c.logger)
 
 	pgversion, err := c.MajorVersion(ctx)
 	if err != nil {
 		return nil, err
 	}
-	totalRowsAffected := 0
 	normalizeStmtGen := normalizeStmtGenerator{
 		Logger:                   c.logger,
 		rawTableName:             rawTableIdentifier,
@@ -637,39 +758,75 @@ func (c *PostgresConnector) NormalizeRecords(
 			SoftDeleteColName: req.SoftDeleteColName,
 			SyncedAtColName:   req.SyncedAtColName,
 		},
-		supportsMerge:  pgversion >= shared.POSTGRES_15,
-		metadataSchema: c.metadataSchema,
+		supportsMerge:     pgversion >= shared.POSTGRES_15,
+		metadataSchema:    c.metadataSchema,
+		conflictPolicy:    req.ConflictPolicy,
+		conflictColumn:    req.ConflictColumn,
+		conflictCondition: req.ConflictCondition,
 	}
-
-	for _, destinationTableName := range destinationTableNames {
-		normalizeStatements := normalizeStmtGen.generateNormalizeStatements(destinationTableName)
-		for _, normalizeStatement := range normalizeStatements {
-			ct, err := normalizeRecordsTx.Exec(ctx, normalizeStatement, normBatchID, req.SyncBatchID, destinationTableName)
-			if err != nil {
-				c.logger.Error("error executing normalize statement",
-					slog.String("statement", normalizeStatement),
-					slog.Int64("normBatchID", normBatchID),
-					slog.Int64("syncBatchID", req.SyncBatchID),
-					slog.String("destinationTableName", destinationTableName),
-					slog.Any("error", err),
-				)
-				return nil, fmt.Errorf("error executing normalize statement for table %s: %w", destinationTableName, err)
-			}
-			totalRowsAffected += int(ct.RowsAffected())
-		}
+	batch := &normalizeBatch{
+		gen:             &normalizeStmtGen,
+		req:             req,
+		normBatchID:     normBatchID,
+		replayTables:    replayTables,
+		truncatedTables: utils.TruncatedTablesToNormalize(req.TruncatedTables, normBatchID, req.SyncBatchID),
 	}
-	c.logger.Info(fmt.Sprintf("normalized %d records", totalRowsAffected))
-
-	// updating metadata with new normalizeBatchID
-	err = c.updateNormalizeMetadata(ctx, req.FlowJobName, req.SyncBatchID, normalizeRecordsTx)
+	group := applyGroup{
+		truncate: slices.Sorted(maps.Keys(batch.truncatedTables)),
+		merge:    destinationTableNames,
+	}
+	group.links, err = c.groupForeignKeyLinks(ctx, group)
 	if err != nil {
 		return nil, err
 	}
-	// transaction commits
-	err = normalizeRecordsTx.Commit(ctx)
+
+	applyParallelism, err := peerdbenv.PeerDBPostgresApplyParallelism(ctx, req.Env)
 	if err != nil {
+		return nil, fmt.Errorf("failed to get apply parallelism: %w", err)
+	}
+	// a replication origin can only be set up by one session at a time
+	if applyParallelism > 1 && req.ReplicationOrigin == "" {
+		if err := c.normalizeParallel(ctx, batch, group, int(applyParallelism)); err != nil {
+			return nil, err
+		}
+		metadataTx, err := c.conn.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error starting transaction for normalize metadata: %w", err)
+		}
+		defer shared.RollbackTx(metadataTx, c.logger)
+		if err := c.updateNormalizeMetadata(ctx, req.FlowJobName, req.SyncBatchID, metadataTx); err != nil {
+			return nil, err
+		}
+		if err := metadataTx.Commit(ctx); err != nil {
+			return nil, err
+		}
+	} else {
+		normalizeRecordsTx, err := c.conn.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error starting transaction for normalizing records: %w", err)
+		}
+		defer shared.RollbackTx(normalizeRecordsTx, c.logger)
+
+		totalRowsAffected, err := c.normalizeTables(ctx, normalizeRecordsTx, batch, group)
+		if err != nil {
+			return nil, err
+		}
+		c.logger.Info(fmt.Sprintf("normalized %d records", totalRowsAffected))
+
+		// updating metadata with new normalizeBatchID
+		if err := c.updateNormalizeMetadata(ctx, req.FlowJobName, req.SyncBatchID, normalizeRecordsTx); err != nil {
+			return nil, err
+		}
+		// transaction commits
+		if err := normalizeRecordsTx.Commit(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.refreshDependentViews(ctx, req.TableMappings,
+		slices.Concat(destinationTableNames, group.truncate)); err != nil {
 		return nil, err
 	}
+	metrics.NormalizeBatchDuration(ctx, req.FlowJobName, time.Since(startTime))
 
 	return &model.NormalizeResponse{
 		Done:         true,
@@ -679,8 +836,12 @@ func (c *PostgresConnector) NormalizeRecords(
 }
 
 type SlotCheckResult struct {
+	// output plugin of the slot when it exists
+	Plugin            string
 	SlotExists        bool
 	PublicationExists bool
+	// slot was invalidated by a conflict with recovery on a standby
+	Conflicting bool
 }
 
 // CreateRawTable creates a raw table, implementing the Connector interface.
@@ -698,17 +859,17 @@ func (c *PostgresConnector) CreateRawTable(ctx context.Context, req *protos.Crea
 	}
 	defer shared.RollbackTx(createRawTableTx, c.logger)
 
-	_, err = createRawTableTx.Exec(ctx, fmt.Sprintf(createRawTableSQL, c.metadataSchema, rawTableIdentifier))
+	_, err = c.execWithLoggingTx(ctx, fmt.Sprintf(createRawTableSQL, c.metadataSchema, rawTableIdentifier), createRawTableTx)
 	if err != nil {
 		return nil, fmt.Errorf("error creating raw table: %w", err)
 	}
-	_, err = createRawTableTx.Exec(ctx, fmt.Sprintf(createRawTableBatchIDIndexSQL, rawTableIdentifier,
-		c.metadataSchema, rawTableIdentifier))
+	_, err = c.execWithLoggingTx(ctx, fmt.Sprintf(createRawTableBatchIDIndexSQL, rawTableIdentifier,
+		c.metadataSchema, rawTableIdentifier), createRawTableTx)
 	if err != nil {
 		return nil, fmt.Errorf("error creating batch ID index on raw table: %w", err)
 	}
-	_, err = createRawTableTx.Exec(ctx, fmt.Sprintf(createRawTableDstTableIndexSQL, rawTableIdentifier,
-		c.metadataSchema, rawTableIdentifier))
+	_, err = c.execWithLoggingTx(ctx, fmt.Sprintf(createRawTableDstTableIndexSQL, rawTableIdentifier,
+		c.metadataSchema, rawTableIdentifier), createRawTableTx)
 	if err != nil {
 		return nil, fmt.Errorf("error creating destination table index on raw table: %w", err)
 	}
@@ -732,6 +893,19 @@ func (c *PostgresConnector) GetTableSchema(
 			c.logger.Info("error fetching schema for table "+tableName, slog.Any("error", err))
 			return nil, err
 		}
+		if req.ProfileColumns {
+			schemaTable, err := utils.ParseSchemaTable(tableName)
+			if err != nil {
+				return nil, err
+			}
+			profiles, err := c.getColumnProfiles(ctx, schemaTable)
+			if err != nil {
+				return nil, err
+			}
+			for _, column := range tableSchema.Columns {
+				column.Profile = profiles[column.Name]
+			}
+		}
 		res[tableName] = tableSchema
 		c.logger.Info("fetched schema for table " + tableName)
 	}
@@ -780,6 +954,24 @@ func (c *PostgresConnector) getTableSchemaForTable(
 		}
 	}
 
+	columnDefaultsEnabled, err := peerdbenv.PeerDBColumnDefaults(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var columnDefaults map[string]string
+	if columnDefaultsEnabled {
+		columnDefaults, err = c.getColumnDefaults(ctx, relID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rangePartitionColumn, err := c.getRangePartitionColumn(ctx, relID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get the column names and types
 	rows, err := c.conn.Query(ctx,
 		fmt.Sprintf(`SELECT * FROM %s LIMIT 0`, schemaTable.String()),
@@ -820,10 +1012,11 @@ func (c *PostgresConnector) getTableSchemaForTable(
 		columnNames = append(columnNames, fieldDescription.Name)
 		_, nullable := nullableCols[fieldDescription.Name]
 		columns = append(columns, &protos.FieldDescription{
-			Name:         fieldDescription.Name,
-			Type:         colType,
-			TypeModifier: fieldDescription.TypeModifier,
-			Nullable:     nullable,
+			Name:              fieldDescription.Name,
+			Type:              colType,
+			TypeModifier:      fieldDescription.TypeModifier,
+			Nullable:          nullable,
+			DefaultExpression: columnDefaults[fieldDescription.Name],
 		})
 	}
 
@@ -842,6 +1035,7 @@ func (c *PostgresConnector) getTableSchemaForTable(
 		Columns:               columns,
 		NullableEnabled:       nullableEnabled,
 		System:                system,
+		RangePartitionColumn:  rangePartitionColumn,
 	}, nil
 }
 
@@ -858,6 +1052,24 @@ func (c *PostgresConnector) FinishSetupNormalizedTables(ctx context.Context, tx
 	return tx.(pgx.Tx).Commit(ctx)
 }
 
+func (c *PostgresConnector) MissingNormalizedTables(ctx context.Context, tables []string) ([]string, error) {
+	var missing []string
+	for _, table := range tables {
+		schemaTable, err := utils.ParseSchemaTable(table)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing table schema and name: %w", err)
+		}
+		exists, err := c.tableExists(ctx, schemaTable)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, table)
+		}
+	}
+	return missing, nil
+}
+
 func (c *PostgresConnector) SetupNormalizedTable(
 	ctx context.Context,
 	tx any,
@@ -870,6 +1082,13 @@ func (c *PostgresConnector) SetupNormalizedTable(
 	if err != nil {
 		return false, fmt.Errorf("error while parsing table schema and name: %w", err)
 	}
+	if shared.IsShadowSchema(parsedNormalizedTable.Schema) {
+		if _, err := c.execWithLoggingTx(ctx,
+			fmt.Sprintf(createSchemaSQL, QuoteIdentifier(parsedNormalizedTable.Schema)), createNormalizedTablesTx,
+		); err != nil {
+			return false, fmt.Errorf("error while creating shadow schema: %w", err)
+		}
+	}
 	tableAlreadyExists, err := c.tableExists(ctx, parsedNormalizedTable)
 	if err != nil {
 		return false, fmt.Errorf("error occurred while checking if normalized table exists: %w", err)
@@ -894,6 +1113,22 @@ func (c *PostgresConnector) SetupNormalizedTable(
 	if err != nil {
 		return false, fmt.Errorf("error while creating normalized table: %w", err)
 	}
+	if len(config.Labels) > 0 {
+		if _, err := c.execWithLoggingTx(ctx, fmt.Sprintf("COMMENT ON TABLE %s IS %s",
+			parsedNormalizedTable.String(), QuoteLiteral(shared.MirrorQueryTag(config.FlowName, config.Labels))),
+			createNormalizedTablesTx,
+		); err != nil {
+			return false, fmt.Errorf("error while commenting normalized table: %w", err)
+		}
+	}
+	for _, tableMapping := range config.TableMappings {
+		if tableMapping.DestinationTableIdentifier == tableIdentifier && tableMapping.RowSecurity.GetTagColumn() != "" {
+			if err := c.setupRowSecurity(ctx, createNormalizedTablesTx, parsedNormalizedTable,
+				tableMapping.RowSecurity); err != nil {
+				return false, err
+			}
+		}
+	}
 
 	return false, nil
 }
@@ -908,6 +1143,10 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 	if len(schemaDeltas) == 0 {
 		return nil
 	}
+	schemaDeltas, err := c.withTenantTableDeltas(ctx, flowJobName, schemaDeltas)
+	if err != nil {
+		return err
+	}
 
 	// Postgres is cool and supports transactional DDL. So we use a transaction.
 	tableSchemaModifyTx, err := c.conn.Begin(ctx)
@@ -918,21 +1157,21 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 	defer shared.RollbackTx(tableSchemaModifyTx, c.logger)
 
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil || (len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.WidenedColumns) == 0) {
 			continue
 		}
 
+		dstSchemaTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
+		if err != nil {
+			return fmt.Errorf("error parsing schema and table for %s: %w", schemaDelta.DstTableName, err)
+		}
+
 		for _, addedColumn := range schemaDelta.AddedColumns {
 			columnType := addedColumn.Type
 			if schemaDelta.System == protos.TypeSystem_Q {
 				columnType = qValueKindToPostgresType(columnType)
 			}
 
-			dstSchemaTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
-			if err != nil {
-				return fmt.Errorf("error parsing schema and table for %s: %w", schemaDelta.DstTableName, err)
-			}
-
 			_, err = c.execWithLoggingTx(ctx, fmt.Sprintf(
 				"ALTER TABLE %s.%s ADD COLUMN IF NOT EXISTS %s %s",
 				QuoteIdentifier(dstSchemaTable.Schema),
@@ -948,6 +1187,27 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 				slog.String("dstTableName", schemaDelta.DstTableName),
 			)
 		}
+
+		for _, widenedColumn := range schemaDelta.WidenedColumns {
+			columnType := postgresColumnType(schemaDelta.System, widenedColumn.NewType, widenedColumn.NewTypeModifier)
+			if columnType == postgresColumnType(schemaDelta.System, widenedColumn.OldType, widenedColumn.OldTypeModifier) {
+				continue
+			}
+			_, err = c.execWithLoggingTx(ctx, fmt.Sprintf(
+				"ALTER TABLE %s.%s ALTER COLUMN %s TYPE %s",
+				QuoteIdentifier(dstSchemaTable.Schema),
+				QuoteIdentifier(dstSchemaTable.Table),
+				QuoteIdentifier(widenedColumn.Name), columnType), tableSchemaModifyTx)
+			if err != nil {
+				return fmt.Errorf("failed to widen column %s for table %s: %w", widenedColumn.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] widened column %s from %s to %s",
+				widenedColumn.Name, widenedColumn.OldType, columnType),
+				slog.String("srcTableName", schemaDelta.SrcTableName),
+				slog.String("dstTableName", schemaDelta.DstTableName),
+			)
+		}
 	}
 
 	if err := tableSchemaModifyTx.Commit(ctx); err != nil {
@@ -1149,6 +1409,16 @@ func (c *PostgresConnector) SyncFlowCleanup(ctx context.Context, jobName string)
 			return fmt.Errorf("unable to delete job metadata: %w", err)
 		}
 	}
+	tenantTablesExists, err := c.tableExists(ctx, &utils.SchemaTable{Schema: c.metadataSchema, Table: tenantTablesTable})
+	if err != nil {
+		return fmt.Errorf("unable to check if tenant tables exist: %w", err)
+	}
+	if tenantTablesExists {
+		if _, err := syncFlowCleanupTx.Exec(ctx,
+			fmt.Sprintf("DELETE FROM %s WHERE flow_name = $1", c.tenantTablesIdentifier()), jobName); err != nil {
+			return fmt.Errorf("unable to delete tenant tables of job: %w", err)
+		}
+	}
 
 	err = syncFlowCleanupTx.Commit(ctx)
 	if err != nil {
@@ -1180,10 +1450,14 @@ func (c *PostgresConnector) HandleSlotInfo(
 
 	logger.Info(fmt.Sprintf("Checking %s lag for %s", slotName, peerName), slog.Float64("LagInMB", float64(slotInfo[0].LagInMb)))
 	alerter.AlertIfSlotLag(ctx, peerName, slotInfo[0])
+	alertIfSlotGrowth(ctx, alerter, catalogPool, peerName, slotInfo[0])
 	slotMetricGauges.SlotLagGauge.Set(float64(slotInfo[0].LagInMb), attribute.NewSet(
 		attribute.String(peerdb_gauges.PeerNameKey, peerName),
 		attribute.String(peerdb_gauges.SlotNameKey, slotName),
 		attribute.String(peerdb_gauges.DeploymentUidKey, peerdbenv.PeerDBDeploymentUID())))
+	if flowName, ok := ctx.Value(shared.FlowNameKey).(string); ok && flowName != "" {
+		metrics.SlotLag(ctx, flowName, slotName, float64(slotInfo[0].LagInMb))
+	}
 
 	// Also handles alerts for PeerDB user connections exceeding a given limit here
 	res, err := getOpenConnectionsForUser(ctx, c.conn, c.config.User)
@@ -1209,6 +1483,52 @@ func (c *PostgresConnector) HandleSlotInfo(
 	return monitoring.AppendSlotSizeInfo(ctx, catalogPool, peerName, slotInfo[0])
 }
 
+// alertIfSlotGrowth predicts growth of a slot from WAL generated and consumed since the slot size recorded a window ago
+func alertIfSlotGrowth(
+	ctx context.Context,
+	alerter *alerting.Alerter,
+	catalogPool *pgxpool.Pool,
+	peerName string,
+	slotInfo *protos.SlotInfo,
+) {
+	logger := logger.LoggerFromCtx(ctx)
+	horizon, err := peerdbenv.PeerDBSlotGrowthAlertHorizon(ctx, nil)
+	if err != nil {
+		logger.Warn("failed to get slot growth alert horizon", slog.Any("error", err))
+		return
+	} else if horizon == 0 {
+		return
+	}
+	window, err := peerdbenv.PeerDBSlotGrowthWindow(ctx, nil)
+	if err != nil {
+		logger.Warn("failed to get slot growth window", slog.Any("error", err))
+		return
+	} else if window == 0 {
+		return
+	}
+	prev, elapsed, err := monitoring.GetSlotSizeBefore(ctx, catalogPool, peerName, slotInfo.SlotName, window)
+	if err != nil {
+		logger.Warn("failed to get earlier slot size", slog.Any("error", err))
+		return
+	} else if prev == nil {
+		return
+	}
+
+	lsns := make([]pglogrepl.LSN, 0, 4)
+	for _, lsnText := range []string{prev.CurrentLSN, prev.RestartLSN, slotInfo.CurrentLSN, slotInfo.RestartLSN} {
+		lsn, err := pglogrepl.ParseLSN(lsnText)
+		if err != nil {
+			// slots without a restart LSN retain no WAL
+			return
+		}
+		lsns = append(lsns, lsn)
+	}
+	growth := shared.NewSlotGrowth(uint64(lsns[0]), uint64(lsns[1]), uint64(lsns[2]), uint64(lsns[3]), elapsed)
+	logger.Info("slot growth", slog.String("slotName", slotInfo.SlotName),
+		slog.Float64("generatedMBPerHour", growth.GeneratedMBPerHour), slog.Float64("consumedMBPerHour", growth.ConsumedMBPerHour))
+	alerter.AlertIfSlotGrowth(ctx, peerName, slotInfo, growth, horizon)
+}
+
 func getOpenConnectionsForUser(ctx context.Context, conn *pgx.Conn, user string) (*protos.GetOpenConnectionsForUserResult, error) {
 	row := conn.QueryRow(ctx, getNumConnectionsForUser, user)
 
@@ -1250,7 +1570,9 @@ func (c *PostgresConnector) AddTablesToPublication(ctx context.Context, req *pro
 
 	additionalSrcTables := make([]string, 0, len(req.AdditionalTables))
 	for _, additionalTableMapping := range req.AdditionalTables {
-		additionalSrcTables = append(additionalSrcTables, additionalTableMapping.SourceTableIdentifier)
+		if !additionalTableMapping.SnapshotOnly {
+			additionalSrcTables = append(additionalSrcTables, additionalTableMapping.SourceTableIdentifier)
+		}
 	}
 
 	// just check if we have all the tables already in the publication for custom publications
@@ -1360,6 +1682,14 @@ func (c *PostgresConnector) RenameTables(ctx context.Context, req *protos.Rename
 		// renaming and dropping such that the _resync table is the new destination
 		c.logger.Info(fmt.Sprintf("renaming table '%s' to '%s'...", src, dst))
 
+		dependentViews, err := c.captureDependentViews(ctx, renameTablesTx, renameRequest.DependentViews)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.dropDependentViews(ctx, renameTablesTx, dependentViews); err != nil {
+			return nil, err
+		}
+
 		// drop the dst table if exists
 		_, err = c.execWithLoggingTx(ctx, "DROP TABLE IF EXISTS "+dst, renameTablesTx)
 		if err != nil {
@@ -1372,6 +1702,10 @@ func (c *PostgresConnector) RenameTables(ctx context.Context, req *protos.Rename
 			return nil, fmt.Errorf("unable to rename table %s to %s: %w", src, dst, err)
 		}
 
+		if err := c.createDependentViews(ctx, renameTablesTx, dependentViews); err != nil {
+			return nil, err
+		}
+
 		c.logger.Info(fmt.Sprintf("successfully renamed table '%s' to '%s'", src, dst))
 	}
 
@@ -1384,3 +1718,15 @@ func (c *PostgresConnector) RenameTables(ctx context.Context, req *protos.Rename
 		FlowJobName: req.FlowJobName,
 	}, nil
 }
+
+// MaintainTable vacuums and analyzes a table, reclaiming tuples left dead by merges and refreshing planner statistics
+func (c *PostgresConnector) MaintainTable(ctx context.Context, tableIdentifier string, _ *protos.TableSchema) error {
+	table, err := utils.ParseSchemaTable(tableIdentifier)
+	if err != nil {
+		return fmt.Errorf("unable to parse table %s: %w", tableIdentifier, err)
+	}
+	if _, err := c.conn.Exec(ctx, "VACUUM (ANALYZE) "+table.String()); err != nil {
+		return fmt.Errorf("failed to vacuum %s: %w", table, err)
+	}
+	return nil
+}
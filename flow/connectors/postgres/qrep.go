@@ -65,6 +65,9 @@ func (c *PostgresConnector) GetQRepPartitions(
 	if err := c.setTransactionSnapshot(ctx, getPartitionsTx, config.SnapshotName); err != nil {
 		return nil, fmt.Errorf("failed to set transaction snapshot: %w", err)
 	}
+	if err := c.setSnapshotSettings(ctx, getPartitionsTx); err != nil {
+		return nil, err
+	}
 
 	return c.getNumRowsPartitions(ctx, getPartitionsTx, config, last)
 }
@@ -198,6 +201,24 @@ func (c *PostgresConnector) getNumRowsPartitions(
 	return partitionHelper.GetPartitions(), nil
 }
 
+// EstimateQRepRows sums planner statistics of the watermark table and its partitions
+func (c *PostgresConnector) EstimateQRepRows(ctx context.Context, config *protos.QRepConfig) (int64, error) {
+	parsedWatermarkTable, err := utils.ParseSchemaTable(config.WatermarkTable)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse watermark table: %w", err)
+	}
+
+	// reltuples is -1 for tables never vacuumed or analyzed since PG14
+	var estimatedRows int64
+	if err := c.conn.QueryRow(ctx, `SELECT COALESCE(SUM(reltuples) FILTER (WHERE reltuples > 0), 0)::bigint
+		FROM pg_class WHERE oid = $1::regclass OR oid IN (SELECT inhrelid FROM pg_inherits WHERE inhparent = $1::regclass)`,
+		parsedWatermarkTable.String(),
+	).Scan(&estimatedRows); err != nil {
+		return 0, fmt.Errorf("failed to estimate rows of %s: %w", config.WatermarkTable, err)
+	}
+	return estimatedRows, nil
+}
+
 func (c *PostgresConnector) getMinMaxValues(
 	ctx context.Context,
 	tx pgx.Tx,
@@ -0,0 +1,107 @@
+package connpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+)
+
+var rePartitionBound = regexp.MustCompile(`^FOR VALUES FROM \((.+)\) TO \((.+)\)$`)
+
+// layouts pg_get_expr prints date, timestamp and timestamptz bounds in
+var partitionBoundLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// getRangePartitionColumn returns the column relID is range partitioned by,
+// empty unless it is partitioned by a single date or timestamp column rather than an expression
+func (c *PostgresConnector) getRangePartitionColumn(ctx context.Context, relID uint32) (string, error) {
+	var column string
+	err := c.conn.QueryRow(ctx, `SELECT a.attname FROM pg_partitioned_table p
+		JOIN pg_attribute a ON a.attrelid = p.partrelid AND a.attnum = p.partattrs[0]
+		WHERE p.partrelid = $1 AND p.partstrat = 'r' AND p.partnatts = 1
+		AND a.atttypid IN ('date'::regtype, 'timestamp'::regtype, 'timestamptz'::regtype)`, relID).Scan(&column)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("error getting range partition column of table %v: %w", relID, err)
+	}
+	return column, nil
+}
+
+// RangePartitions returns the ranges of partitions attached to range partitioned table,
+// complete is false when rows outside them may still exist, like with a default partition
+func (c *PostgresConnector) RangePartitions(ctx context.Context, table string) ([]utils.TimeRange, bool, error) {
+	schemaTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return nil, false, err
+	}
+	relID, err := c.getRelIDForTable(ctx, schemaTable)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rows, err := c.conn.Query(ctx, `SELECT pg_get_expr(c.relpartbound, c.oid) FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = $1`, relID)
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting partitions of table %s: %w", schemaTable, err)
+	}
+	bounds, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting partitions of table %s: %w", schemaTable, err)
+	}
+
+	ranges := make([]utils.TimeRange, 0, len(bounds))
+	for _, bound := range bounds {
+		if bound == "DEFAULT" {
+			return nil, false, nil
+		}
+		r, err := parsePartitionBound(bound)
+		if err != nil {
+			return nil, false, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, true, nil
+}
+
+// parsePartitionBound parses range bounds of a partition like FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')
+func parsePartitionBound(bound string) (utils.TimeRange, error) {
+	match := rePartitionBound.FindStringSubmatch(bound)
+	if match == nil {
+		return utils.TimeRange{}, fmt.Errorf("unsupported partition bound %s", bound)
+	}
+	from, err := parsePartitionBoundValue(match[1])
+	if err != nil {
+		return utils.TimeRange{}, err
+	}
+	to, err := parsePartitionBoundValue(match[2])
+	if err != nil {
+		return utils.TimeRange{}, err
+	}
+	return utils.TimeRange{From: from, To: to}, nil
+}
+
+// parsePartitionBoundValue parses a quoted date or timestamp, MINVALUE and MAXVALUE being unbounded, the zero time
+func parsePartitionBoundValue(value string) (time.Time, error) {
+	if value == "MINVALUE" || value == "MAXVALUE" {
+		return time.Time{}, nil
+	}
+	unquoted := strings.Trim(value, "'")
+	for _, layout := range partitionBoundLayouts {
+		if t, err := time.Parse(layout, unquoted); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported partition bound value %s", value)
+}
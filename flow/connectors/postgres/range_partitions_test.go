@@ -0,0 +1,32 @@
+package connpostgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+)
+
+func TestParsePartitionBound(t *testing.T) {
+	r, err := parsePartitionBound("FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')")
+	require.NoError(t, err)
+	require.Equal(t, utils.TimeRange{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}, r)
+
+	r, err = parsePartitionBound("FOR VALUES FROM ('2024-01-01 00:00:00+02') TO (MAXVALUE)")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 12, 31, 22, 0, 0, 0, time.UTC), r.From)
+	require.True(t, r.To.IsZero())
+
+	r, err = parsePartitionBound("FOR VALUES FROM (MINVALUE) TO ('2024-01-01 12:30:00.5')")
+	require.NoError(t, err)
+	require.True(t, r.From.IsZero())
+	require.Equal(t, time.Date(2024, 1, 1, 12, 30, 0, 500000000, time.UTC), r.To)
+
+	_, err = parsePartitionBound("FOR VALUES IN (1, 2)")
+	require.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+)
+
+func (c *PostgresConnector) EnforceRetention(ctx context.Context, tableIdentifier string, column string, cutoff time.Time) error {
+	table, err := utils.ParseSchemaTable(tableIdentifier)
+	if err != nil {
+		return fmt.Errorf("unable to parse table %s: %w", tableIdentifier, err)
+	}
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s < '%s+00'::timestamptz",
+		table.String(), QuoteIdentifier(column), utils.RetentionCutoffLiteral(cutoff)),
+	); err != nil {
+		return fmt.Errorf("failed to delete expired rows of %s: %w", table, err)
+	}
+	return nil
+}
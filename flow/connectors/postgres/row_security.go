@@ -0,0 +1,44 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// setupRowSecurity restricts rows of a destination table to roles granted their tag by the row access table
+// of its schema. Row level security doesn't apply to the owner of the table without FORCE, so syncs see every row
+func (c *PostgresConnector) setupRowSecurity(
+	ctx context.Context,
+	tx pgx.Tx,
+	table *utils.SchemaTable,
+	security *protos.RowSecurity,
+) error {
+	accessTable := QuoteIdentifier(table.Schema) + "." + QuoteIdentifier(shared.RowAccessTable)
+	policy := QuoteIdentifier(shared.RowSecurityPolicy)
+	for _, stmt := range []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			tag TEXT NOT NULL,
+			role_name TEXT NOT NULL,
+			PRIMARY KEY (tag, role_name))`, accessTable),
+		// policies read it with the privileges of whoever queries the table
+		fmt.Sprintf("GRANT SELECT ON %s TO PUBLIC", accessTable),
+		fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", table.String()),
+		fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s", policy, table.String()),
+		// roles are looked up by oid as pg_has_role fails for names of roles that were dropped
+		fmt.Sprintf(`CREATE POLICY %s ON %s USING (EXISTS (
+			SELECT 1 FROM %s a JOIN pg_roles r ON r.rolname = a.role_name
+			WHERE a.tag = %s.%s AND pg_has_role(r.oid, 'MEMBER')))`,
+			policy, table.String(), accessTable, table.String(), QuoteIdentifier(security.TagColumn)),
+	} {
+		if _, err := c.execWithLoggingTx(ctx, stmt, tx); err != nil {
+			return fmt.Errorf("failed to set up row security of %s: %w", table.String(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// CompareTableSchemas matches types when they resolve to the same type whatever the alias, type modifiers aside
+func (c *PostgresConnector) CompareTableSchemas(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	tableSchemas map[string]*protos.TableSchema,
+) ([]*protos.SchemaDrift, error) {
+	var mirrorColumns []string
+	if config.SoftDeleteColName != "" {
+		mirrorColumns = append(mirrorColumns, config.SoftDeleteColName)
+	}
+	if config.SyncedAtColName != "" {
+		mirrorColumns = append(mirrorColumns, config.SyncedAtColName)
+	}
+
+	var drifts []*protos.SchemaDrift
+	for _, table := range slices.Sorted(maps.Keys(tableSchemas)) {
+		tableSchema := tableSchemas[table]
+		expected := make([]utils.DestinationColumn, 0, len(tableSchema.Columns))
+		for _, column := range tableSchema.Columns {
+			primaryKey := slices.Contains(tableSchema.PrimaryKeyColumns, column.Name) && !tableSchema.IsReplicaIdentityFull
+			expected = append(expected, utils.DestinationColumn{
+				Name:     column.Name,
+				Type:     postgresColumnType(tableSchema.System, column.Type, column.TypeModifier),
+				Nullable: !primaryKey && !(tableSchema.NullableEnabled && !column.Nullable),
+			})
+		}
+		actual, baseTypes, err := c.destinationColumns(ctx, table, expected)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, utils.DiffTableSchema(table, expected, actual, mirrorColumns, func(e string, a string) bool {
+			if baseTypes[e] == "" {
+				return e == a
+			}
+			return baseTypes[e] == baseTypes[a]
+		})...)
+	}
+	return drifts, nil
+}
+
+// destinationColumns returns columns of table, nil when it doesn't exist, along with the types of expected and
+// actual columns resolved without type modifiers, types that don't resolve being left out
+func (c *PostgresConnector) destinationColumns(
+	ctx context.Context,
+	table string,
+	expected []utils.DestinationColumn,
+) ([]utils.DestinationColumn, map[string]string, error) {
+	schemaTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	exists, err := c.tableExists(ctx, schemaTable)
+	if err != nil || !exists {
+		return nil, nil, err
+	}
+
+	rows, err := c.conn.Query(ctx, `SELECT attname, format_type(atttypid, atttypmod), format_type(atttypid, NULL),
+		NOT attnotnull FROM pg_attribute WHERE attrelid = to_regclass($1) AND attnum > 0 AND NOT attisdropped
+		ORDER BY attnum`, schemaTable.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while querying columns of %s: %w", table, err)
+	}
+	actual := make([]utils.DestinationColumn, 0)
+	baseTypes := make(map[string]string)
+	var col utils.DestinationColumn
+	var baseType string
+	if _, err := pgx.ForEachRow(rows, []any{&col.Name, &col.Type, &baseType, &col.Nullable}, func() error {
+		actual = append(actual, col)
+		baseTypes[col.Type] = baseType
+		return nil
+	}); err != nil {
+		return nil, nil, fmt.Errorf("error while querying columns of %s: %w", table, err)
+	}
+
+	expectedTypes := make([]string, 0, len(expected))
+	for _, col := range expected {
+		expectedTypes = append(expectedTypes, col.Type)
+	}
+	rows, err = c.conn.Query(ctx, "SELECT t, format_type(to_regtype(t), NULL) FROM unnest($1::text[]) t", expectedTypes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while resolving column types of %s: %w", table, err)
+	}
+	var expectedType string
+	var resolved pgtype.Text
+	if _, err := pgx.ForEachRow(rows, []any{&expectedType, &resolved}, func() error {
+		if resolved.Valid {
+			baseTypes[expectedType] = resolved.String
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, fmt.Errorf("error while resolving column types of %s: %w", table, err)
+	}
+	return actual, baseTypes, nil
+}
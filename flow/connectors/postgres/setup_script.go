@@ -0,0 +1,268 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// sourceSetupState is what the setup script of a source depends on, as observed on the server
+type sourceSetupState struct {
+	platform             protos.PostgresPlatform
+	version              shared.PGVersion
+	user                 string
+	publication          string
+	tables               []*utils.SchemaTable
+	hasReplication       bool
+	publicationExists    bool
+	publishedTables      map[string]struct{}
+	tablesNeedIdentity   []*utils.SchemaTable
+	walLevel             string
+	maxReplicationSlots  int
+	usedReplicationSlots int
+	maxWalSenders        int
+	usedWalSenders       int
+}
+
+// DetectPlatform tells managed flavors apart by the roles and functions they install,
+// falling back to the configured platform for those only told apart by host
+func (c *PostgresConnector) DetectPlatform(ctx context.Context) (protos.PostgresPlatform, error) {
+	var aurora, rds, cloudsql bool
+	if err := c.conn.QueryRow(ctx, `SELECT
+		EXISTS(SELECT 1 FROM pg_proc WHERE proname = 'aurora_version'),
+		EXISTS(SELECT 1 FROM pg_roles WHERE rolname = 'rds_superuser'),
+		EXISTS(SELECT 1 FROM pg_roles WHERE rolname = 'cloudsqlsuperuser')`).Scan(&aurora, &rds, &cloudsql); err != nil {
+		return protos.PostgresPlatform_POSTGRES_PLATFORM_UNKNOWN, fmt.Errorf("failed to detect platform: %w", err)
+	}
+	switch {
+	case aurora:
+		return protos.PostgresPlatform_POSTGRES_PLATFORM_AURORA, nil
+	case rds:
+		return protos.PostgresPlatform_POSTGRES_PLATFORM_RDS, nil
+	case cloudsql:
+		return protos.PostgresPlatform_POSTGRES_PLATFORM_CLOUDSQL, nil
+	case c.config.Platform == protos.PostgresPlatform_POSTGRES_PLATFORM_UNKNOWN:
+		return DetectPostgresPlatform(c.config.Host), nil
+	default:
+		return c.config.Platform, nil
+	}
+}
+
+// SourceSetupScript generates the statements user needs run on the source to replicate tables for a mirror,
+// leaving out whatever is already in place, publication defaults to the one the mirror would create
+func (c *PostgresConnector) SourceSetupScript(
+	ctx context.Context,
+	user string,
+	flowJobName string,
+	publication string,
+	tables []*utils.SchemaTable,
+) (protos.PostgresPlatform, string, error) {
+	if publication == "" {
+		publication = c.getDefaultPublicationName(flowJobName)
+	}
+	state := sourceSetupState{
+		user:            user,
+		publication:     publication,
+		tables:          tables,
+		publishedTables: make(map[string]struct{}),
+	}
+	var err error
+	if state.platform, err = c.DetectPlatform(ctx); err != nil {
+		return state.platform, "", err
+	}
+	if state.version, err = c.MajorVersion(ctx); err != nil {
+		return state.platform, "", fmt.Errorf("failed to get Postgres version: %w", err)
+	}
+
+	if err := c.conn.QueryRow(ctx, `SELECT coalesce((SELECT rolreplication OR rolsuper FROM pg_roles WHERE rolname = $1), false)
+		OR (EXISTS(SELECT 1 FROM pg_roles WHERE rolname = 'rds_replication') AND pg_has_role($1, 'rds_replication', 'member'))`,
+		user).Scan(&state.hasReplication); err != nil {
+		return state.platform, "", fmt.Errorf("failed to check replication privilege of %s: %w", user, err)
+	}
+
+	if err := c.conn.QueryRow(ctx, `SELECT current_setting('wal_level'),
+		current_setting('max_replication_slots')::int, (SELECT count(*) FROM pg_replication_slots),
+		current_setting('max_wal_senders')::int, (SELECT count(*) FROM pg_stat_replication)`).Scan(
+		&state.walLevel, &state.maxReplicationSlots, &state.usedReplicationSlots,
+		&state.maxWalSenders, &state.usedWalSenders); err != nil {
+		return state.platform, "", fmt.Errorf("failed to get replication settings: %w", err)
+	}
+
+	if err := c.conn.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_publication WHERE pubname = $1)",
+		publication).Scan(&state.publicationExists); err != nil {
+		return state.platform, "", fmt.Errorf("failed to check publication %s: %w", publication, err)
+	}
+	if state.publicationExists {
+		rows, err := c.conn.Query(ctx, "SELECT schemaname, tablename FROM pg_publication_tables WHERE pubname = $1",
+			publication)
+		if err != nil {
+			return state.platform, "", fmt.Errorf("failed to get tables of publication %s: %w", publication, err)
+		}
+		var schema, table string
+		if _, err := pgx.ForEachRow(rows, []any{&schema, &table}, func() error {
+			state.publishedTables[(&utils.SchemaTable{Schema: schema, Table: table}).String()] = struct{}{}
+			return nil
+		}); err != nil {
+			return state.platform, "", fmt.Errorf("failed to get tables of publication %s: %w", publication, err)
+		}
+	}
+
+	for _, table := range tables {
+		// tables without primary key need every column logged to replicate updates and deletes
+		var needsIdentity bool
+		if err := c.conn.QueryRow(ctx, `SELECT c.relreplident = 'd' AND NOT EXISTS(
+			SELECT 1 FROM pg_index i WHERE i.indrelid = c.oid AND i.indisprimary)
+			FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace WHERE n.nspname = $1 AND c.relname = $2`,
+			table.Schema, table.Table).Scan(&needsIdentity); err != nil && err != pgx.ErrNoRows {
+			return state.platform, "", fmt.Errorf("failed to check replica identity of %s: %w", table, err)
+		}
+		if needsIdentity {
+			state.tablesNeedIdentity = append(state.tablesNeedIdentity, table)
+		}
+	}
+
+	return state.platform, sourceSetupScript(state), nil
+}
+
+func sourceSetupScript(state sourceSetupState) string {
+	var sb strings.Builder
+	user := QuoteIdentifier(state.user)
+	platformName := strings.ToLower(strings.TrimPrefix(state.platform.String(), "POSTGRES_PLATFORM_"))
+	fmt.Fprintf(&sb, "-- PeerDB source setup for %s, run as a superuser or the owner of the tables\n", platformName)
+
+	sb.WriteString("\n-- replication privilege\n")
+	switch {
+	case state.hasReplication:
+		fmt.Fprintf(&sb, "-- %s can already replicate\n", user)
+	case state.platform == protos.PostgresPlatform_POSTGRES_PLATFORM_RDS ||
+		state.platform == protos.PostgresPlatform_POSTGRES_PLATFORM_AURORA:
+		fmt.Fprintf(&sb, "GRANT rds_replication TO %s;\n", user)
+	default:
+		fmt.Fprintf(&sb, "ALTER ROLE %s WITH REPLICATION;\n", user)
+	}
+
+	sb.WriteString("\n-- read access to source tables\n")
+	var schemas []string
+	for _, table := range state.tables {
+		if !slices.Contains(schemas, table.Schema) {
+			schemas = append(schemas, table.Schema)
+		}
+	}
+	for _, schema := range schemas {
+		fmt.Fprintf(&sb, "GRANT USAGE ON SCHEMA %s TO %s;\n", QuoteIdentifier(schema), user)
+	}
+	if len(state.tables) > 0 {
+		fmt.Fprintf(&sb, "GRANT SELECT ON %s TO %s;\n", joinTables(state.tables), user)
+	}
+
+	sb.WriteString("\n-- publication\n")
+	publication := QuoteIdentifier(state.publication)
+	if !state.publicationExists {
+		var pubViaRoot string
+		if state.version >= shared.POSTGRES_13 {
+			pubViaRoot = " WITH (publish_via_partition_root = true)"
+		}
+		fmt.Fprintf(&sb, "CREATE PUBLICATION %s FOR TABLE %s%s;\n", publication, joinTables(state.tables), pubViaRoot)
+	} else {
+		var missing []*utils.SchemaTable
+		for _, table := range state.tables {
+			if _, ok := state.publishedTables[table.String()]; !ok {
+				missing = append(missing, table)
+			}
+		}
+		if len(missing) > 0 {
+			fmt.Fprintf(&sb, "ALTER PUBLICATION %s ADD TABLE %s;\n", publication, joinTables(missing))
+		} else {
+			fmt.Fprintf(&sb, "-- %s already publishes every table\n", publication)
+		}
+	}
+
+	if len(state.tablesNeedIdentity) > 0 {
+		sb.WriteString("\n-- tables without primary key log every column so updates and deletes can be replicated\n")
+		for _, table := range state.tablesNeedIdentity {
+			fmt.Fprintf(&sb, "ALTER TABLE %s REPLICA IDENTITY FULL;\n", table)
+		}
+	}
+
+	// a slot for the mirror, and a sender while it streams
+	var settings [][2]string
+	if state.walLevel != "logical" {
+		settings = append(settings, [2]string{"wal_level", "logical"})
+	}
+	if state.maxReplicationSlots <= state.usedReplicationSlots {
+		settings = append(settings, [2]string{"max_replication_slots", fmt.Sprint(state.usedReplicationSlots + 1)})
+	}
+	if neededWalSenders := max(state.usedWalSenders+1, 2); state.maxWalSenders < neededWalSenders {
+		settings = append(settings, [2]string{"max_wal_senders", fmt.Sprint(neededWalSenders)})
+	}
+	if len(settings) > 0 {
+		sb.WriteString("\n-- server settings, taking effect after a restart\n")
+		writeSettingHints(&sb, state.platform, settings)
+	}
+
+	return sb.String()
+}
+
+func writeSettingHints(sb *strings.Builder, platform protos.PostgresPlatform, settings [][2]string) {
+	// managed flavors turn on logical decoding through their own parameter
+	rename := func(logical string, on string) [][2]string {
+		renamed := make([][2]string, 0, len(settings))
+		for _, setting := range settings {
+			if setting[0] == "wal_level" {
+				renamed = append(renamed, [2]string{logical, on})
+			} else {
+				renamed = append(renamed, setting)
+			}
+		}
+		return renamed
+	}
+
+	switch platform {
+	case protos.PostgresPlatform_POSTGRES_PLATFORM_RDS:
+		sb.WriteString("-- in the DB parameter group of the instance set, then reboot the instance:\n")
+		for _, setting := range rename("rds.logical_replication", "1") {
+			fmt.Fprintf(sb, "--   %s = %s\n", setting[0], setting[1])
+		}
+	case protos.PostgresPlatform_POSTGRES_PLATFORM_AURORA:
+		sb.WriteString("-- in the DB cluster parameter group of the cluster set, then reboot the writer instance:\n")
+		for _, setting := range rename("rds.logical_replication", "1") {
+			fmt.Fprintf(sb, "--   %s = %s\n", setting[0], setting[1])
+		}
+	case protos.PostgresPlatform_POSTGRES_PLATFORM_CLOUDSQL:
+		flags := make([]string, 0, len(settings))
+		for _, setting := range rename("cloudsql.logical_decoding", "on") {
+			flags = append(flags, setting[0]+"="+setting[1])
+		}
+		sb.WriteString("-- set the database flags, keeping flags already set as patching replaces them all:\n")
+		fmt.Fprintf(sb, "--   gcloud sql instances patch <instance> --database-flags=%s\n", strings.Join(flags, ","))
+	case protos.PostgresPlatform_POSTGRES_PLATFORM_NEON:
+		sb.WriteString("-- enable logical replication in the settings of the Neon project, which sets:\n")
+		for _, setting := range settings {
+			fmt.Fprintf(sb, "--   %s = %s\n", setting[0], setting[1])
+		}
+	case protos.PostgresPlatform_POSTGRES_PLATFORM_SUPABASE:
+		sb.WriteString("-- Supabase manages server settings, ask its support to set:\n")
+		for _, setting := range settings {
+			fmt.Fprintf(sb, "--   %s = %s\n", setting[0], setting[1])
+		}
+	default:
+		for _, setting := range settings {
+			fmt.Fprintf(sb, "ALTER SYSTEM SET %s = %s;\n", setting[0], QuoteLiteral(setting[1]))
+		}
+	}
+}
+
+func joinTables(tables []*utils.SchemaTable) string {
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, table.String())
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,81 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+func TestSourceSetupScript(t *testing.T) {
+	orders := &utils.SchemaTable{Schema: "public", Table: "orders"}
+	events := &utils.SchemaTable{Schema: "app", Table: "events"}
+	state := sourceSetupState{
+		platform:             protos.PostgresPlatform_POSTGRES_PLATFORM_RDS,
+		version:              shared.POSTGRES_13,
+		user:                 "peerdb",
+		publication:          "peerflow_pub_orders",
+		tables:               []*utils.SchemaTable{orders, events},
+		walLevel:             "replica",
+		maxReplicationSlots:  2,
+		usedReplicationSlots: 2,
+		maxWalSenders:        10,
+		tablesNeedIdentity:   []*utils.SchemaTable{events},
+	}
+	require.Equal(t, `-- PeerDB source setup for rds, run as a superuser or the owner of the tables
+
+-- replication privilege
+GRANT rds_replication TO "peerdb";
+
+-- read access to source tables
+GRANT USAGE ON SCHEMA "public" TO "peerdb";
+GRANT USAGE ON SCHEMA "app" TO "peerdb";
+GRANT SELECT ON "public"."orders", "app"."events" TO "peerdb";
+
+-- publication
+CREATE PUBLICATION "peerflow_pub_orders" FOR TABLE "public"."orders", "app"."events" WITH (publish_via_partition_root = true);
+
+-- tables without primary key log every column so updates and deletes can be replicated
+ALTER TABLE "app"."events" REPLICA IDENTITY FULL;
+
+-- server settings, taking effect after a restart
+-- in the DB parameter group of the instance set, then reboot the instance:
+--   rds.logical_replication = 1
+--   max_replication_slots = 3
+`, sourceSetupScript(state))
+
+	state.platform = protos.PostgresPlatform_POSTGRES_PLATFORM_GENERIC
+	state.hasReplication = true
+	state.publicationExists = true
+	state.publishedTables = map[string]struct{}{orders.String(): {}}
+	state.tablesNeedIdentity = nil
+	state.walLevel = "logical"
+	state.maxWalSenders = 1
+	require.Equal(t, `-- PeerDB source setup for generic, run as a superuser or the owner of the tables
+
+-- replication privilege
+-- "peerdb" can already replicate
+
+-- read access to source tables
+GRANT USAGE ON SCHEMA "public" TO "peerdb";
+GRANT USAGE ON SCHEMA "app" TO "peerdb";
+GRANT SELECT ON "public"."orders", "app"."events" TO "peerdb";
+
+-- publication
+ALTER PUBLICATION "peerflow_pub_orders" ADD TABLE "app"."events";
+
+-- server settings, taking effect after a restart
+ALTER SYSTEM SET max_replication_slots = '3';
+ALTER SYSTEM SET max_wal_senders = '2';
+`, sourceSetupScript(state))
+
+	state.platform = protos.PostgresPlatform_POSTGRES_PLATFORM_CLOUDSQL
+	state.walLevel = "replica"
+	state.maxReplicationSlots = 10
+	state.maxWalSenders = 10
+	require.Contains(t, sourceSetupScript(state),
+		"--   gcloud sql instances patch <instance> --database-flags=cloudsql.logical_decoding=on\n")
+}
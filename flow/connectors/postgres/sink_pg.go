@@ -64,6 +64,12 @@ func (p PgCopyWriter) ExecuteQueryWithTx(
 		}
 	}
 
+	if err := qe.setSnapshotSettings(ctx, tx); err != nil {
+		qe.logger.Error("[pg_query_executor] failed to apply snapshot settings", slog.Any("error", err))
+		p.Close(err)
+		return 0, err
+	}
+
 	norows, err := tx.Query(ctx, query+" limit 0", args...)
 	if err != nil {
 		return 0, err
@@ -35,6 +35,12 @@ func (stream RecordStreamSink) ExecuteQueryWithTx(
 		}
 	}
 
+	if err := qe.setSnapshotSettings(ctx, tx); err != nil {
+		qe.logger.Error("[pg_query_executor] failed to apply snapshot settings", slog.Any("error", err))
+		stream.Close(err)
+		return 0, err
+	}
+
 	randomUint, err := shared.RandomUInt64()
 	if err != nil {
 		qe.logger.Error("[pg_query_executor] failed to generate random uint", slog.Any("error", err))
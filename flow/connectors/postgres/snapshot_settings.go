@@ -0,0 +1,41 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// snapshotSettingStatements returns the SET LOCAL statements bounding the impact of reads on the source,
+// session defaults keep idle_in_transaction_session_timeout off so exported snapshots survive between reads
+func snapshotSettingStatements(config *protos.PostgresConfig) []string {
+	var stmts []string
+	if config.SnapshotStatementTimeoutSeconds > 0 {
+		stmts = append(stmts, fmt.Sprintf("SET LOCAL statement_timeout=%d",
+			uint64(config.SnapshotStatementTimeoutSeconds)*1000))
+	}
+	if config.SnapshotIdleInTransactionTimeoutSeconds > 0 {
+		stmts = append(stmts, fmt.Sprintf("SET LOCAL idle_in_transaction_session_timeout=%d",
+			uint64(config.SnapshotIdleInTransactionTimeoutSeconds)*1000))
+	}
+	if config.SnapshotCursorTupleFraction != nil {
+		stmts = append(stmts, "SET LOCAL cursor_tuple_fraction="+
+			strconv.FormatFloat(*config.SnapshotCursorTupleFraction, 'f', -1, 64))
+	}
+	return stmts
+}
+
+// setSnapshotSettings applies the peer's snapshot settings to a read transaction,
+// after SET TRANSACTION SNAPSHOT which has to come first
+func (c *PostgresConnector) setSnapshotSettings(ctx context.Context, tx pgx.Tx) error {
+	for _, stmt := range snapshotSettingStatements(c.config) {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to apply snapshot setting %s: %w", stmt, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestSnapshotSettingStatements(t *testing.T) {
+	require.Empty(t, snapshotSettingStatements(&protos.PostgresConfig{}))
+
+	fraction := 1.0
+	require.Equal(t, []string{
+		"SET LOCAL statement_timeout=300000",
+		"SET LOCAL idle_in_transaction_session_timeout=600000",
+		"SET LOCAL cursor_tuple_fraction=1",
+	}, snapshotSettingStatements(&protos.PostgresConfig{
+		SnapshotStatementTimeoutSeconds:         300,
+		SnapshotIdleInTransactionTimeoutSeconds: 600,
+		SnapshotCursorTupleFraction:             &fraction,
+	}))
+}
@@ -0,0 +1,55 @@
+package connpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// IsStandby reports whether the source is a hot standby, replicating from which needs Postgres 16 or later
+func (c *PostgresConnector) IsStandby(ctx context.Context) (bool, error) {
+	var inRecovery bool
+	if err := c.conn.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return false, fmt.Errorf("failed to check whether source is a standby: %w", err)
+	}
+	return inRecovery, nil
+}
+
+// checkStandbyReplication checks a standby source can keep slots, which get invalidated on conflict with recovery
+// when the primary removes catalog rows they still need. The catalog xmin of slots on a standby only holds back
+// the primary through hot_standby_feedback, which keeps holding it back while the standby is disconnected
+// only when it replicates through a physical slot on the primary
+func (c *PostgresConnector) checkStandbyReplication(ctx context.Context) error {
+	standby, err := c.IsStandby(ctx)
+	if err != nil || !standby {
+		return err
+	}
+	pgversion, err := c.MajorVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if pgversion < shared.POSTGRES_16 {
+		return errors.New("logical replication from a standby needs Postgres 16 or later, connect to the primary instead")
+	}
+
+	var hotStandbyFeedback string
+	if err := c.conn.QueryRow(ctx, "SHOW hot_standby_feedback").Scan(&hotStandbyFeedback); err != nil {
+		return err
+	}
+	if hotStandbyFeedback != "on" {
+		return errors.New("hot_standby_feedback is not on, without it the primary removes catalog rows " +
+			"replication slots on the standby need and they get invalidated")
+	}
+
+	var primarySlotName string
+	if err := c.conn.QueryRow(ctx, "SHOW primary_slot_name").Scan(&primarySlotName); err != nil {
+		return err
+	}
+	if primarySlotName == "" {
+		c.logger.Warn("standby replicates without a physical slot on the primary, " +
+			"replication slots on the standby may be invalidated when it disconnects from the primary")
+	}
+	return nil
+}
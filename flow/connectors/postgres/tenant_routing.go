@@ -0,0 +1,267 @@
+package connpostgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// tenant tables created for each mirror, so schema changes of their destination table reach them too
+const tenantTablesTable = "peerdb_tenant_tables"
+
+// tenantRouter picks the destination table of changes to tables split by tenant,
+// changes without a tenant stay in the destination table of their mapping
+type tenantRouter map[string]*protos.TenantRouting
+
+func (r tenantRouter) destination(destinationTable string, items model.Items) string {
+	routing, ok := r[destinationTable]
+	if !ok {
+		return destinationTable
+	}
+	tenant, ok := utils.TenantOf(items, routing.TenantColumn)
+	if !ok {
+		return destinationTable
+	}
+	return utils.TenantDestinationTable(routing, destinationTable, tenant)
+}
+
+func (c *PostgresConnector) tenantTablesIdentifier() string {
+	return QuoteIdentifier(c.metadataSchema) + "." + QuoteIdentifier(tenantTablesTable)
+}
+
+// getTenantTables returns tenant tables created for a mirror with the destination table they split
+func (c *PostgresConnector) getTenantTables(ctx context.Context, flowJobName string) (map[string]string, error) {
+	exists, err := c.tableExists(ctx, &utils.SchemaTable{Schema: c.metadataSchema, Table: tenantTablesTable})
+	if err != nil || !exists {
+		return nil, err
+	}
+	rows, err := c.conn.Query(ctx, fmt.Sprintf("SELECT table_name, base_table FROM %s WHERE flow_name = $1",
+		c.tenantTablesIdentifier()), flowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant tables: %w", err)
+	}
+	tenantTables := make(map[string]string)
+	var table, base string
+	if _, err := pgx.ForEachRow(rows, []any{&table, &base}, func() error {
+		tenantTables[table] = base
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to get tenant tables: %w", err)
+	}
+	return tenantTables, nil
+}
+
+// prepareTenantTables creates tables of tenants new in the batches being normalized like the destination table
+// they split, and moves rows the initial snapshot copied into it to their tenant table.
+// The request returned normalizes tenant tables like their destination table, sharing its skipped or truncated state.
+func (c *PostgresConnector) prepareTenantTables(
+	ctx context.Context,
+	req *model.NormalizeRecordsRequest,
+	destinationTableNames []string,
+) (*model.NormalizeRecordsRequest, []string, error) {
+	routed := utils.TenantRoutedTables(req.TableMappings)
+	if len(routed) == 0 {
+		return req, destinationTableNames, nil
+	}
+	tenantTables, err := c.getTenantTables(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction for tenant tables: %w", err)
+	}
+	defer shared.RollbackTx(tx, c.logger)
+
+	newTables := make(map[string]string)
+	for _, table := range destinationTableNames {
+		if _, ok := req.TableNameSchemaMapping[table]; ok {
+			continue
+		}
+		if _, ok := tenantTables[table]; ok {
+			continue
+		}
+		if base, ok := utils.TenantRoutedBase(routed, table); ok {
+			newTables[table] = base
+		}
+	}
+	var moves []tenantMove
+	for _, base := range slices.Sorted(maps.Keys(routed)) {
+		if _, ok := req.SkippedTables[base]; ok {
+			continue
+		}
+		baseMoves, err := c.snapshotTenantMoves(ctx, tx, routed[base], base)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, move := range baseMoves {
+			if _, ok := tenantTables[move.table]; !ok {
+				newTables[move.table] = base
+			}
+		}
+		moves = append(moves, baseMoves...)
+	}
+	if len(newTables) > 0 {
+		if _, err := c.execWithLoggingTx(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			flow_name TEXT NOT NULL,
+			table_name TEXT NOT NULL,
+			base_table TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (flow_name, table_name))`, c.tenantTablesIdentifier()), tx); err != nil {
+			return nil, nil, fmt.Errorf("failed to create tenant tables table: %w", err)
+		}
+	}
+	for _, table := range slices.Sorted(maps.Keys(newTables)) {
+		if err := c.createTenantTable(ctx, tx, req.FlowJobName, table, newTables[table]); err != nil {
+			return nil, nil, err
+		}
+		tenantTables[table] = newTables[table]
+	}
+	for _, move := range moves {
+		if err := c.moveTenantRows(ctx, tx, move); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction for tenant tables: %w", err)
+	}
+
+	tenantReq := *req
+	tenantReq.TableNameSchemaMapping = maps.Clone(req.TableNameSchemaMapping)
+	tenantReq.SkippedTables = maps.Clone(req.SkippedTables)
+	tenantReq.TruncatedTables = maps.Clone(req.TruncatedTables)
+	for table, base := range tenantTables {
+		tenantReq.TableNameSchemaMapping[table] = req.TableNameSchemaMapping[base]
+		if _, ok := req.SkippedTables[base]; ok {
+			if tenantReq.SkippedTables == nil {
+				tenantReq.SkippedTables = make(map[string]struct{})
+			}
+			tenantReq.SkippedTables[table] = struct{}{}
+		}
+		if batchID, ok := req.TruncatedTables[base]; ok {
+			tenantReq.TruncatedTables[table] = batchID
+		}
+	}
+	return &tenantReq, utils.WithoutSkippedTables(destinationTableNames, tenantReq.SkippedTables), nil
+}
+
+// tenantMove is rows of a tenant to move from the destination table a tenant routing splits to their tenant table
+type tenantMove struct {
+	routing *protos.TenantRouting
+	base    string
+	tenant  string
+	table   string
+}
+
+// snapshotTenantMoves returns tenants with rows in the destination table a tenant routing splits,
+// which only has those after the initial snapshot copied the source table into it
+func (c *PostgresConnector) snapshotTenantMoves(
+	ctx context.Context,
+	tx pgx.Tx,
+	routing *protos.TenantRouting,
+	base string,
+) ([]tenantMove, error) {
+	baseTable, err := utils.ParseSchemaTable(base)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	tenantColumn := QuoteIdentifier(routing.TenantColumn)
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT DISTINCT %s::text FROM %s WHERE %s IS NOT NULL",
+		tenantColumn, baseTable.String(), tenantColumn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenants of %s: %w", base, err)
+	}
+	tenants, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenants of %s: %w", base, err)
+	}
+
+	moves := make([]tenantMove, 0, len(tenants))
+	for _, tenant := range tenants {
+		// tenants named like the schema of the destination table have it as their table
+		if table := utils.TenantDestinationTable(routing, base, tenant); table != base {
+			moves = append(moves, tenantMove{routing: routing, base: base, tenant: tenant, table: table})
+		}
+	}
+	return moves, nil
+}
+
+func (c *PostgresConnector) moveTenantRows(ctx context.Context, tx pgx.Tx, move tenantMove) error {
+	baseTable, err := utils.ParseSchemaTable(move.base)
+	if err != nil {
+		return fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	tenantTable, err := utils.ParseSchemaTable(move.table)
+	if err != nil {
+		return fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	tag, err := tx.Exec(ctx, fmt.Sprintf(
+		"WITH moved AS (DELETE FROM %s WHERE %s::text = $1 RETURNING *) INSERT INTO %s SELECT * FROM moved",
+		baseTable.String(), QuoteIdentifier(move.routing.TenantColumn), tenantTable.String()), move.tenant)
+	if err != nil {
+		return fmt.Errorf("failed to move rows of tenant %s to %s: %w", move.tenant, move.table, err)
+	}
+	c.logger.Info("moved rows of tenant to its table",
+		slog.String("table", move.table), slog.Int64("rows", tag.RowsAffected()))
+	return nil
+}
+
+// createTenantTable creates a tenant table with the columns, defaults, constraints and indexes of its base table,
+// recording it for the mirror
+func (c *PostgresConnector) createTenantTable(ctx context.Context, tx pgx.Tx, flowJobName string, table string, base string) error {
+	tenantTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	baseTable, err := utils.ParseSchemaTable(base)
+	if err != nil {
+		return fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	if _, err := c.execWithLoggingTx(ctx, fmt.Sprintf(createSchemaSQL, QuoteIdentifier(tenantTable.Schema)), tx); err != nil {
+		return fmt.Errorf("failed to create schema of tenant table %s: %w", table, err)
+	}
+	if _, err := c.execWithLoggingTx(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (LIKE %s INCLUDING ALL)",
+		tenantTable.String(), baseTable.String()), tx); err != nil {
+		return fmt.Errorf("failed to create tenant table %s: %w", table, err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(
+		"INSERT INTO %s (flow_name, table_name, base_table) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		c.tenantTablesIdentifier()), flowJobName, table, base); err != nil {
+		return fmt.Errorf("failed to record tenant table %s: %w", table, err)
+	}
+	return nil
+}
+
+// withTenantTableDeltas adds schema changes of destination tables split by tenant for their tenant tables
+func (c *PostgresConnector) withTenantTableDeltas(
+	ctx context.Context,
+	flowJobName string,
+	schemaDeltas []*protos.TableSchemaDelta,
+) ([]*protos.TableSchemaDelta, error) {
+	tenantTables, err := c.getTenantTables(ctx, flowJobName)
+	if err != nil || len(tenantTables) == 0 {
+		return schemaDeltas, err
+	}
+	withTenants := slices.Clone(schemaDeltas)
+	for _, table := range slices.Sorted(maps.Keys(tenantTables)) {
+		for _, schemaDelta := range schemaDeltas {
+			if schemaDelta.GetDstTableName() == tenantTables[table] {
+				tenantDelta := proto.Clone(schemaDelta).(*protos.TableSchemaDelta)
+				tenantDelta.DstTableName = table
+				withTenants = append(withTenants, tenantDelta)
+			}
+		}
+	}
+	return withTenants, nil
+}
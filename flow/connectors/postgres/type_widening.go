@@ -0,0 +1,83 @@
+package connpostgres
+
+import (
+	numeric "github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+// digits needed to hold any value of an integer type as numeric
+var integerDigits = map[string]int16{
+	string(qvalue.QValueKindInt16): 5,
+	string(qvalue.QValueKindInt32): 10,
+	string(qvalue.QValueKindInt64): 19,
+	"int2":                         5,
+	"int4":                         10,
+	"int8":                         19,
+}
+
+var losslessWidenings = map[protos.TypeSystem]map[string][]string{
+	protos.TypeSystem_Q: {
+		string(qvalue.QValueKindInt16):   {string(qvalue.QValueKindInt32), string(qvalue.QValueKindInt64)},
+		string(qvalue.QValueKindInt32):   {string(qvalue.QValueKindInt64)},
+		string(qvalue.QValueKindFloat32): {string(qvalue.QValueKindFloat64)},
+	},
+	protos.TypeSystem_PG: {
+		"int2":    {"int4", "int8"},
+		"int4":    {"int8"},
+		"float4":  {"float8"},
+		"varchar": {"text"},
+	},
+}
+
+// isLosslessWidening reports whether every value of the old column type can be stored in the new one,
+// so a destination column can be altered in place when the source column changes type
+func isLosslessWidening(
+	system protos.TypeSystem,
+	oldType string,
+	oldTypmod int32,
+	newType string,
+	newTypmod int32,
+) bool {
+	if oldType == newType {
+		switch oldType {
+		case string(qvalue.QValueKindNumeric):
+			return oldTypmod != newTypmod && numericTypmodWidens(oldTypmod, newTypmod)
+		case "varchar":
+			// typmod is length + 4, -1 when unbounded
+			return oldTypmod != -1 && (newTypmod == -1 || newTypmod > oldTypmod)
+		default:
+			return false
+		}
+	}
+
+	if newType == string(qvalue.QValueKindNumeric) {
+		digits, ok := integerDigits[oldType]
+		if !ok {
+			return false
+		}
+		if newTypmod == -1 {
+			return true
+		}
+		precision, scale := numeric.ParseNumericTypmod(newTypmod)
+		return precision-scale >= digits
+	}
+
+	for _, widerType := range losslessWidenings[system][oldType] {
+		if widerType == newType {
+			return true
+		}
+	}
+	return false
+}
+
+func numericTypmodWidens(oldTypmod int32, newTypmod int32) bool {
+	if newTypmod == -1 {
+		return true
+	} else if oldTypmod == -1 {
+		return false
+	}
+	oldPrecision, oldScale := numeric.ParseNumericTypmod(oldTypmod)
+	newPrecision, newScale := numeric.ParseNumericTypmod(newTypmod)
+	return newScale >= oldScale && newPrecision-newScale >= oldPrecision-oldScale
+}
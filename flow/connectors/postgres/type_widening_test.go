@@ -0,0 +1,45 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func numericTypmod(precision int32, scale int32) int32 {
+	return (precision<<16 | scale) + 4
+}
+
+func TestIsLosslessWidening(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		system    protos.TypeSystem
+		oldType   string
+		oldTypmod int32
+		newType   string
+		newTypmod int32
+		expected  bool
+	}{
+		{"int32 to int64", protos.TypeSystem_Q, "int32", -1, "int64", -1, true},
+		{"int64 to int32", protos.TypeSystem_Q, "int64", -1, "int32", -1, false},
+		{"float32 to float64", protos.TypeSystem_Q, "float32", -1, "float64", -1, true},
+		{"int4 to int8", protos.TypeSystem_PG, "int4", -1, "int8", -1, true},
+		{"int8 to numeric(20,0)", protos.TypeSystem_PG, "int8", -1, "numeric", numericTypmod(20, 0), true},
+		{"int8 to numeric(20,2)", protos.TypeSystem_PG, "int8", -1, "numeric", numericTypmod(20, 2), false},
+		{"numeric precision increase", protos.TypeSystem_Q, "numeric", numericTypmod(10, 2), "numeric", numericTypmod(12, 2), true},
+		{"numeric scale increase", protos.TypeSystem_Q, "numeric", numericTypmod(10, 2), "numeric", numericTypmod(10, 4), false},
+		{"numeric to unconstrained", protos.TypeSystem_PG, "numeric", numericTypmod(10, 2), "numeric", -1, true},
+		{"unconstrained to numeric", protos.TypeSystem_PG, "numeric", -1, "numeric", numericTypmod(38, 2), false},
+		{"varchar longer", protos.TypeSystem_PG, "varchar", 14, "varchar", 104, true},
+		{"varchar shorter", protos.TypeSystem_PG, "varchar", 104, "varchar", 14, false},
+		{"varchar to text", protos.TypeSystem_PG, "varchar", 14, "text", -1, true},
+		{"text to int8", protos.TypeSystem_PG, "text", -1, "int8", -1, false},
+		{"unchanged", protos.TypeSystem_Q, "int64", -1, "int64", -1, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, isLosslessWidening(tc.system, tc.oldType, tc.oldTypmod, tc.newType, tc.newTypmod))
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/shared"
 )
 
@@ -65,11 +66,48 @@ func (c *PostgresConnector) CheckSourceTables(ctx context.Context,
 	return nil
 }
 
+// NonReplicableTables returns tables logical replication can't replicate with why, unlogged and temporary tables
+// aren't written to WAL, foreign tables have their rows elsewhere and materialized views change only by refreshes
+func (c *PostgresConnector) NonReplicableTables(
+	ctx context.Context, tableNames []*utils.SchemaTable,
+) (map[utils.SchemaTable]string, error) {
+	schemas := make([]string, 0, len(tableNames))
+	tables := make([]string, 0, len(tableNames))
+	for _, parsedTable := range tableNames {
+		schemas = append(schemas, parsedTable.Schema)
+		tables = append(tables, parsedTable.Table)
+	}
+	rows, err := c.conn.Query(ctx, `SELECT n.nspname, c.relname,
+		CASE WHEN c.relpersistence = 't' THEN 'temporary table' WHEN c.relpersistence = 'u' THEN 'unlogged table'
+		WHEN c.relkind = 'm' THEN 'materialized view' ELSE 'foreign table' END
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN unnest($1::text[], $2::text[]) AS t(sname, tname) ON n.nspname = t.sname AND c.relname = t.tname
+		WHERE c.relpersistence IN ('t', 'u') OR c.relkind IN ('f', 'm')`, schemas, tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query persistence of tables: %w", err)
+	}
+	nonReplicable := make(map[utils.SchemaTable]string)
+	var table utils.SchemaTable
+	var reason string
+	if _, err := pgx.ForEachRow(rows, []any{&table.Schema, &table.Table, &reason}, func() error {
+		nonReplicable[table] = reason
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to query persistence of tables: %w", err)
+	}
+	return nonReplicable, nil
+}
+
 func (c *PostgresConnector) CheckReplicationPermissions(ctx context.Context, username string) error {
 	if c.conn == nil {
 		return errors.New("check replication permissions: conn is nil")
 	}
 
+	if c.config.Platform == protos.PostgresPlatform_POSTGRES_PLATFORM_SUPABASE && isSupabasePoolerHost(c.config.Host) {
+		return errors.New("supabase connection pooler does not support replication, " +
+			"use the direct connection host db.<project ref>.supabase.co")
+	}
+
 	var replicationRes bool
 	err := c.conn.QueryRow(ctx, "SELECT rolreplication FROM pg_roles WHERE rolname = $1", username).Scan(&replicationRes)
 	if err != nil {
@@ -87,8 +125,18 @@ func (c *PostgresConnector) CheckReplicationPermissions(ctx context.Context, use
 		err := c.conn.QueryRow(ctx, "SELECT setting FROM pg_settings WHERE name = 'rds.logical_replication'").Scan(&setting)
 		if err != pgx.ErrNoRows {
 			if err != nil || setting != "on" {
+				if c.config.Platform == protos.PostgresPlatform_POSTGRES_PLATFORM_RDS {
+					return errors.New("rds.logical_replication is not enabled, " +
+						"set it to 1 in the parameter group of the instance and reboot it")
+				}
 				return errors.New("postgres user does not have replication role")
 			}
+			var rdsReplication bool
+			if err := c.conn.QueryRow(ctx,
+				"SELECT pg_has_role($1, 'rds_replication', 'member')", username,
+			).Scan(&rdsReplication); err == nil && !rdsReplication {
+				return fmt.Errorf("postgres user must be granted rds_replication: GRANT rds_replication TO %s", username)
+			}
 		}
 	}
 
@@ -100,6 +148,12 @@ func (c *PostgresConnector) CheckReplicationPermissions(ctx context.Context, use
 	}
 
 	if walLevel != "logical" {
+		switch c.config.Platform {
+		case protos.PostgresPlatform_POSTGRES_PLATFORM_NEON:
+			return errors.New("wal_level is not logical, enable logical replication in the settings of the Neon project")
+		case protos.PostgresPlatform_POSTGRES_PLATFORM_RDS:
+			return errors.New("wal_level is not logical, set rds.logical_replication to 1 in the parameter group and reboot")
+		}
 		return errors.New("wal_level is not logical")
 	}
 
@@ -119,7 +173,7 @@ func (c *PostgresConnector) CheckReplicationPermissions(ctx context.Context, use
 		return errors.New("max_wal_senders must be at least 2")
 	}
 
-	return nil
+	return c.checkStandbyReplication(ctx)
 }
 
 func (c *PostgresConnector) CheckReplicationConnectivity(ctx context.Context) error {
@@ -145,3 +199,47 @@ func (c *PostgresConnector) CheckPublicationCreationPermissions(ctx context.Cont
 	}
 	return nil
 }
+
+// IsTransactionPooled reports whether the connection goes through a pooler handing out backends per transaction,
+// such as PgBouncer in transaction mode, under which replication and COPY don't work
+func (c *PostgresConnector) IsTransactionPooled(ctx context.Context) (bool, error) {
+	var pid int32
+	if err := c.conn.QueryRow(ctx, "SELECT pg_backend_pid()").Scan(&pid); err != nil {
+		return false, fmt.Errorf("failed to get backend pid: %w", err)
+	}
+
+	// a pooler hands the backend just released to the next transaction asking for one
+	other, err := c.ssh.NewPostgresConnFromPostgresConfig(ctx, c.config)
+	if err != nil {
+		return false, fmt.Errorf("failed to create connection: %w", err)
+	}
+	defer other.Close(ctx)
+	tx, err := other.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer shared.RollbackTx(tx, c.logger)
+	var otherPid int32
+	if err := tx.QueryRow(ctx, "SELECT pg_backend_pid()").Scan(&otherPid); err != nil {
+		return false, fmt.Errorf("failed to get backend pid: %w", err)
+	}
+
+	var nextPid int32
+	if err := c.conn.QueryRow(ctx, "SELECT pg_backend_pid()").Scan(&nextPid); err != nil {
+		return false, fmt.Errorf("failed to get backend pid: %w", err)
+	}
+	return otherPid == pid || nextPid != pid, nil
+}
+
+// MaxSampledRowSize returns the largest size of rows serialized as JSON among the first sampleSize rows of table,
+// a cheap hint of row widths rather than a bound
+func (c *PostgresConnector) MaxSampledRowSize(ctx context.Context, table *utils.SchemaTable, sampleSize int) (int64, error) {
+	var maxSize int64
+	if err := c.conn.QueryRow(ctx, fmt.Sprintf(
+		"SELECT COALESCE(MAX(octet_length(row_to_json(t)::text)), 0) FROM (SELECT * FROM %s LIMIT %d) t",
+		table.String(), sampleSize,
+	)).Scan(&maxSize); err != nil {
+		return 0, fmt.Errorf("failed to sample row sizes of %s: %w", table, err)
+	}
+	return maxSize, nil
+}
@@ -0,0 +1,448 @@
+package connpostgres
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+)
+
+// relationLookup resolves what wal2json leaves out of changes, it names tables rather than sending relation ids
+// and sends type oids without type modifiers
+type relationLookup interface {
+	relationID(schema string, table string) (uint32, error)
+	typeModifiers(relID uint32) (map[string]int32, error)
+}
+
+type wal2jsonColumn struct {
+	Name    string          `json:"name"`
+	TypeOID uint32          `json:"typeoid"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// wal2jsonChange is a change of either format, format-version 1 sends a transaction per message
+// and format-version 2 a message per change
+type wal2jsonChange struct {
+	Action        string           `json:"action"`
+	Xid           uint32           `json:"xid"`
+	Timestamp     string           `json:"timestamp"`
+	LSN           string           `json:"lsn"`
+	NextLSN       string           `json:"nextlsn"`
+	Schema        string           `json:"schema"`
+	Table         string           `json:"table"`
+	Columns       []wal2jsonColumn `json:"columns"`
+	Identity      []wal2jsonColumn `json:"identity"`
+	Transactional bool             `json:"transactional"`
+	Prefix        string           `json:"prefix"`
+	Content       string           `json:"content"`
+}
+
+type wal2jsonV1Transaction struct {
+	Xid       uint32             `json:"xid"`
+	Timestamp string             `json:"timestamp"`
+	NextLSN   string             `json:"nextlsn"`
+	Change    []wal2jsonV1Change `json:"change"`
+}
+
+type wal2jsonV1Change struct {
+	Kind           string            `json:"kind"`
+	Schema         string            `json:"schema"`
+	Table          string            `json:"table"`
+	ColumnNames    []string          `json:"columnnames"`
+	ColumnTypeOIDs []uint32          `json:"columntypeoids"`
+	ColumnValues   []json.RawMessage `json:"columnvalues"`
+	OldKeys        *struct {
+		KeyNames    []string          `json:"keynames"`
+		KeyTypeOIDs []uint32          `json:"keytypeoids"`
+		KeyValues   []json.RawMessage `json:"keyvalues"`
+	} `json:"oldkeys"`
+	Transactional bool   `json:"transactional"`
+	Prefix        string `json:"prefix"`
+	Content       string `json:"content"`
+}
+
+// with write-in-chunks format-version 1 sends a transaction over several messages rather than building it whole,
+// its opening up to the change array, each change with a comma before all but the first, and the closing
+var (
+	wal2jsonChunkBegin = []byte(`"change":[`)
+	wal2jsonChunkEnd   = []byte("]}")
+)
+
+type wal2jsonDecoder struct {
+	lookup     relationLookup
+	relIDs     map[string]uint32
+	relations  map[uint32]*pglogrepl.RelationMessage
+	commitTime time.Time
+	// end of the chunked transaction being decoded, commits are only sent at its closing
+	chunkEndLSN   pglogrepl.LSN
+	inChunk       bool
+	formatVersion int
+}
+
+func newWal2jsonDecoder(formatVersion int, lookup relationLookup) *wal2jsonDecoder {
+	return &wal2jsonDecoder{
+		lookup:        lookup,
+		relIDs:        make(map[string]uint32),
+		relations:     make(map[uint32]*pglogrepl.RelationMessage),
+		formatVersion: formatVersion,
+	}
+}
+
+func (d *wal2jsonDecoder) decode(walData []byte, walStart pglogrepl.LSN) ([]pglogrepl.Message, error) {
+	if d.formatVersion == 1 {
+		return d.decodeTransaction(walData, walStart)
+	}
+	var change wal2jsonChange
+	if err := json.Unmarshal(walData, &change); err != nil {
+		return nil, fmt.Errorf("error parsing wal2json change: %w", err)
+	}
+	switch change.Action {
+	case "B":
+		finalLSN, err := d.parseLSN(change.NextLSN, 0)
+		if err != nil {
+			return nil, err
+		}
+		return d.begin(change.Xid, change.Timestamp, finalLSN)
+	case "C":
+		// commits are sent at the end of the transaction, which is where mirrors resume from
+		endLSN, err := d.parseLSN(change.NextLSN, walStart)
+		if err != nil {
+			return nil, err
+		}
+		return d.commit(endLSN), nil
+	case "M":
+		lsn, err := d.parseLSN(change.LSN, walStart)
+		if err != nil {
+			return nil, err
+		}
+		return []pglogrepl.Message{&pglogrepl.LogicalDecodingMessage{
+			LSN:           lsn,
+			Transactional: change.Transactional,
+			Prefix:        change.Prefix,
+			Content:       []byte(change.Content),
+		}}, nil
+	default:
+		return d.changeMessages(&change)
+	}
+}
+
+func (d *wal2jsonDecoder) decodeTransaction(walData []byte, walStart pglogrepl.LSN) ([]pglogrepl.Message, error) {
+	chunk := bytes.TrimSpace(walData)
+	if d.inChunk {
+		if bytes.Equal(chunk, wal2jsonChunkEnd) {
+			d.inChunk = false
+			return d.commit(d.chunkEndLSN), nil
+		}
+		var change wal2jsonV1Change
+		if err := json.Unmarshal(bytes.TrimPrefix(chunk, []byte(",")), &change); err != nil {
+			return nil, fmt.Errorf("error parsing wal2json change chunk: %w", err)
+		}
+		return d.v1ChangeMessages(&change, d.chunkEndLSN)
+	} else if bytes.HasSuffix(chunk, wal2jsonChunkBegin) {
+		var txn wal2jsonV1Transaction
+		if err := json.Unmarshal(slices.Concat(chunk, wal2jsonChunkEnd), &txn); err != nil {
+			return nil, fmt.Errorf("error parsing wal2json transaction chunk: %w", err)
+		}
+		endLSN, err := d.parseLSN(txn.NextLSN, walStart)
+		if err != nil {
+			return nil, err
+		}
+		d.inChunk = true
+		d.chunkEndLSN = endLSN
+		return d.begin(txn.Xid, txn.Timestamp, endLSN)
+	}
+
+	var txn wal2jsonV1Transaction
+	if err := json.Unmarshal(walData, &txn); err != nil {
+		return nil, fmt.Errorf("error parsing wal2json transaction: %w", err)
+	}
+	endLSN, err := d.parseLSN(txn.NextLSN, walStart)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := d.begin(txn.Xid, txn.Timestamp, endLSN)
+	if err != nil {
+		return nil, err
+	}
+	for _, v1Change := range txn.Change {
+		changeMsgs, err := d.v1ChangeMessages(&v1Change, endLSN)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, changeMsgs...)
+	}
+	return append(msgs, d.commit(endLSN)...), nil
+}
+
+func (d *wal2jsonDecoder) v1ChangeMessages(v1Change *wal2jsonV1Change, endLSN pglogrepl.LSN) ([]pglogrepl.Message, error) {
+	var change wal2jsonChange
+	switch v1Change.Kind {
+	case "insert":
+		change.Action = "I"
+	case "update":
+		change.Action = "U"
+	case "delete":
+		change.Action = "D"
+	case "message":
+		return []pglogrepl.Message{&pglogrepl.LogicalDecodingMessage{
+			LSN:           endLSN,
+			Transactional: v1Change.Transactional,
+			Prefix:        v1Change.Prefix,
+			Content:       []byte(v1Change.Content),
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unknown wal2json change kind %s", v1Change.Kind)
+	}
+	change.Schema = v1Change.Schema
+	change.Table = v1Change.Table
+	var err error
+	change.Columns, err = wal2jsonV1Columns(v1Change.ColumnNames, v1Change.ColumnTypeOIDs, v1Change.ColumnValues)
+	if err != nil {
+		return nil, err
+	}
+	if v1Change.OldKeys != nil {
+		change.Identity, err = wal2jsonV1Columns(
+			v1Change.OldKeys.KeyNames, v1Change.OldKeys.KeyTypeOIDs, v1Change.OldKeys.KeyValues)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d.changeMessages(&change)
+}
+
+func wal2jsonV1Columns(names []string, typeOIDs []uint32, values []json.RawMessage) ([]wal2jsonColumn, error) {
+	if len(typeOIDs) != len(names) || len(values) != len(names) {
+		return nil, fmt.Errorf("wal2json change has %d column names, %d type oids and %d values",
+			len(names), len(typeOIDs), len(values))
+	}
+	columns := make([]wal2jsonColumn, 0, len(names))
+	for i, name := range names {
+		columns = append(columns, wal2jsonColumn{Name: name, TypeOID: typeOIDs[i], Value: values[i]})
+	}
+	return columns, nil
+}
+
+func (d *wal2jsonDecoder) parseLSN(lsn string, fallback pglogrepl.LSN) (pglogrepl.LSN, error) {
+	if lsn == "" {
+		return fallback, nil
+	}
+	parsed, err := pglogrepl.ParseLSN(lsn)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing wal2json lsn %s: %w", lsn, err)
+	}
+	return parsed, nil
+}
+
+func (d *wal2jsonDecoder) begin(xid uint32, timestamp string, finalLSN pglogrepl.LSN) ([]pglogrepl.Message, error) {
+	d.commitTime = time.Time{}
+	if timestamp != "" {
+		commitTime, err := parseWal2jsonTimestamp(timestamp)
+		if err != nil {
+			return nil, err
+		}
+		d.commitTime = commitTime
+	}
+	return []pglogrepl.Message{&pglogrepl.BeginMessage{FinalLSN: finalLSN, CommitTime: d.commitTime, Xid: xid}}, nil
+}
+
+func (d *wal2jsonDecoder) commit(endLSN pglogrepl.LSN) []pglogrepl.Message {
+	return []pglogrepl.Message{&pglogrepl.CommitMessage{
+		CommitLSN:         endLSN,
+		TransactionEndLSN: endLSN,
+		CommitTime:        d.commitTime,
+	}}
+}
+
+// parseWal2jsonTimestamp parses timestamps as postgres prints them, with offsets of whole hours or not
+func parseWal2jsonTimestamp(timestamp string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999-07", "2006-01-02 15:04:05.999999999-07:00"} {
+		if parsed, err := time.Parse(layout, timestamp); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("error parsing wal2json timestamp %s", timestamp)
+}
+
+func (d *wal2jsonDecoder) changeMessages(change *wal2jsonChange) ([]pglogrepl.Message, error) {
+	relID, err := d.relationID(change.Schema, change.Table)
+	if err != nil {
+		return nil, err
+	}
+	switch change.Action {
+	case "T":
+		return []pglogrepl.Message{&pglogrepl.TruncateMessage{RelationNum: 1, RelationIDs: []uint32{relID}}}, nil
+	case "I", "U", "D":
+	default:
+		return nil, fmt.Errorf("unknown wal2json action %s", change.Action)
+	}
+
+	var msgs []pglogrepl.Message
+	rel, changed, err := d.relation(relID, change)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		msgs = append(msgs, rel)
+	}
+	switch change.Action {
+	case "I":
+		tuple, err := wal2jsonTuple(rel, change.Columns, 'n')
+		if err != nil {
+			return nil, err
+		}
+		return append(msgs, &pglogrepl.InsertMessage{RelationID: relID, Tuple: tuple}), nil
+	case "U":
+		// columns missing from updates are unchanged TOAST values, which wal2json skips
+		newTuple, err := wal2jsonTuple(rel, change.Columns, 'u')
+		if err != nil {
+			return nil, err
+		}
+		msg := &pglogrepl.UpdateMessage{RelationID: relID, NewTuple: newTuple}
+		if len(change.Identity) > 0 {
+			if msg.OldTuple, err = wal2jsonTuple(rel, change.Identity, 'n'); err != nil {
+				return nil, err
+			}
+			msg.OldTupleType = pglogrepl.UpdateMessageTupleTypeKey
+			if len(change.Identity) == len(rel.Columns) {
+				msg.OldTupleType = pglogrepl.UpdateMessageTupleTypeOld
+			}
+		}
+		return append(msgs, msg), nil
+	default:
+		oldTuple, err := wal2jsonTuple(rel, change.Identity, 'n')
+		if err != nil {
+			return nil, err
+		}
+		msg := &pglogrepl.DeleteMessage{RelationID: relID, OldTuple: oldTuple,
+			OldTupleType: pglogrepl.DeleteMessageTupleTypeKey}
+		if len(change.Identity) == len(rel.Columns) {
+			msg.OldTupleType = pglogrepl.DeleteMessageTupleTypeOld
+		}
+		return append(msgs, msg), nil
+	}
+}
+
+func (d *wal2jsonDecoder) relationID(schema string, table string) (uint32, error) {
+	name := schema + "." + table
+	if relID, ok := d.relIDs[name]; ok {
+		return relID, nil
+	}
+	// resolved by name as tables are decoded, so tables renamed while streaming are followed
+	relID, err := d.lookup.relationID(schema, table)
+	if err != nil {
+		return 0, err
+	}
+	d.relIDs[name] = relID
+	return relID, nil
+}
+
+// relation returns the relation of a change, the changed flag reports a relation to send before the change.
+// Inserts carry every column so define relations, updates skip unchanged TOAST values and deletes carry only
+// the replica identity, so those only add columns to relations seen before
+func (d *wal2jsonDecoder) relation(relID uint32, change *wal2jsonChange) (*pglogrepl.RelationMessage, bool, error) {
+	prev, seen := d.relations[relID]
+	columns := change.Columns
+	if change.Action == "D" {
+		columns = change.Identity
+	}
+
+	prevColumns := make(map[string]uint32)
+	if seen {
+		for _, col := range prev.Columns {
+			prevColumns[col.Name] = col.DataType
+		}
+	}
+	changed := !seen || (change.Action == "I" && len(columns) != len(prev.Columns))
+	for _, col := range columns {
+		if dataType, ok := prevColumns[col.Name]; !ok || dataType != col.TypeOID {
+			changed = true
+		}
+	}
+	if !changed {
+		return prev, false, nil
+	}
+
+	typmods, err := d.lookup.typeModifiers(relID)
+	if err != nil {
+		return nil, false, err
+	}
+	rel := &pglogrepl.RelationMessage{
+		RelationID:   relID,
+		Namespace:    change.Schema,
+		RelationName: change.Table,
+	}
+	added := make(map[string]struct{}, len(columns))
+	for _, col := range columns {
+		added[col.Name] = struct{}{}
+		typmod, ok := typmods[col.Name]
+		if !ok {
+			typmod = -1
+		}
+		rel.Columns = append(rel.Columns, &pglogrepl.RelationMessageColumn{
+			Name:         col.Name,
+			DataType:     col.TypeOID,
+			TypeModifier: typmod,
+		})
+	}
+	if seen && change.Action != "I" {
+		for _, col := range prev.Columns {
+			if _, ok := added[col.Name]; !ok {
+				rel.Columns = append(rel.Columns, col)
+			}
+		}
+	}
+	rel.ColumnNum = uint16(len(rel.Columns))
+	d.relations[relID] = rel
+	return rel, true, nil
+}
+
+// wal2jsonTuple lays out values in the order of relation columns as text,
+// columns without a value take dataType, 'u' for unchanged TOAST values and 'n' for null
+func wal2jsonTuple(rel *pglogrepl.RelationMessage, columns []wal2jsonColumn, missing uint8) (*pglogrepl.TupleData, error) {
+	values := make(map[string]json.RawMessage, len(columns))
+	for _, col := range columns {
+		values[col.Name] = col.Value
+	}
+	tuple := &pglogrepl.TupleData{
+		ColumnNum: uint16(len(rel.Columns)),
+		Columns:   make([]*pglogrepl.TupleDataColumn, 0, len(rel.Columns)),
+	}
+	for _, rcol := range rel.Columns {
+		value, ok := values[rcol.Name]
+		if !ok {
+			tuple.Columns = append(tuple.Columns, &pglogrepl.TupleDataColumn{DataType: missing})
+			continue
+		}
+		tcol, err := wal2jsonValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing value of column %s: %w", rcol.Name, err)
+		}
+		tuple.Columns = append(tuple.Columns, tcol)
+	}
+	return tuple, nil
+}
+
+// wal2jsonValue turns a JSON value into postgres text output, wal2json quotes everything but numbers and booleans
+func wal2jsonValue(value json.RawMessage) (*pglogrepl.TupleDataColumn, error) {
+	var data []byte
+	switch {
+	case len(value) == 0 || bytes.Equal(value, []byte("null")):
+		return &pglogrepl.TupleDataColumn{DataType: 'n'}, nil
+	case value[0] == '"':
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil {
+			return nil, err
+		}
+		data = []byte(text)
+	case bytes.Equal(value, []byte("true")):
+		data = []byte("t")
+	case bytes.Equal(value, []byte("false")):
+		data = []byte("f")
+	default:
+		data = value
+	}
+	return &pglogrepl.TupleDataColumn{DataType: 't', Length: uint32(len(data)), Data: data}, nil
+}
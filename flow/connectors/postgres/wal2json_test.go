@@ -0,0 +1,105 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRelationLookup map[string]uint32
+
+func (l fakeRelationLookup) relationID(schema string, table string) (uint32, error) {
+	return l[schema+"."+table], nil
+}
+
+func (l fakeRelationLookup) typeModifiers(uint32) (map[string]int32, error) {
+	return map[string]int32{"id": -1, "name": 24, "active": -1}, nil
+}
+
+func TestWal2jsonDecoderV2(t *testing.T) {
+	d := newWal2jsonDecoder(2, fakeRelationLookup{"public.users": 16384})
+
+	msgs, err := d.decode([]byte(`{"action":"B","xid":731,"timestamp":"2024-05-01 10:00:00.5+00","nextlsn":"0/16B3748"}`), 0)
+	require.NoError(t, err)
+	begin := msgs[0].(*pglogrepl.BeginMessage)
+	require.Equal(t, uint32(731), begin.Xid)
+	require.Equal(t, pglogrepl.LSN(0x16B3748), begin.FinalLSN)
+	require.Equal(t, int64(1714557600500000000), begin.CommitTime.UnixNano())
+
+	msgs, err = d.decode([]byte(`{"action":"I","schema":"public","table":"users","columns":[
+		{"name":"id","typeoid":23,"value":1},{"name":"name","typeoid":1043,"value":"ann \"a\""},
+		{"name":"active","typeoid":16,"value":true}]}`), 0)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	rel := msgs[0].(*pglogrepl.RelationMessage)
+	require.Equal(t, uint32(16384), rel.RelationID)
+	require.Len(t, rel.Columns, 3)
+	require.Equal(t, int32(24), rel.Columns[1].TypeModifier)
+	insert := msgs[1].(*pglogrepl.InsertMessage)
+	require.Equal(t, "1", string(insert.Tuple.Columns[0].Data))
+	require.Equal(t, `ann "a"`, string(insert.Tuple.Columns[1].Data))
+	require.Equal(t, "t", string(insert.Tuple.Columns[2].Data))
+
+	// unchanged TOAST values are left out of updates, which don't change the relation
+	msgs, err = d.decode([]byte(`{"action":"U","schema":"public","table":"users",
+		"columns":[{"name":"id","typeoid":23,"value":1},{"name":"active","typeoid":16,"value":null}],
+		"identity":[{"name":"id","typeoid":23,"value":1}]}`), 0)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	update := msgs[0].(*pglogrepl.UpdateMessage)
+	require.Equal(t, uint8('u'), update.NewTuple.Columns[1].DataType)
+	require.Equal(t, uint8('n'), update.NewTuple.Columns[2].DataType)
+	require.Equal(t, pglogrepl.UpdateMessageTupleTypeKey, update.OldTupleType)
+
+	msgs, err = d.decode([]byte(`{"action":"C","xid":731,"nextlsn":"0/16B3748"}`), 0x16B3700)
+	require.NoError(t, err)
+	commit := msgs[0].(*pglogrepl.CommitMessage)
+	require.Equal(t, pglogrepl.LSN(0x16B3748), commit.CommitLSN)
+}
+
+func TestWal2jsonDecoderV1(t *testing.T) {
+	d := newWal2jsonDecoder(1, fakeRelationLookup{"public.users": 16384})
+
+	msgs, err := d.decode([]byte(`{"xid":732,"nextlsn":"0/16B3800","timestamp":"2024-05-01 15:30:00+05:30","change":[
+		{"kind":"delete","schema":"public","table":"users",
+			"oldkeys":{"keynames":["id"],"keytypeoids":[23],"keyvalues":[2]}},
+		{"kind":"insert","schema":"public","table":"users","columnnames":["id","name","active"],
+			"columntypeoids":[23,1043,16],"columnvalues":[3,"bo",false]}]}`), 0x16B3700)
+	require.NoError(t, err)
+	require.Len(t, msgs, 6)
+	require.Equal(t, pglogrepl.LSN(0x16B3800), msgs[0].(*pglogrepl.BeginMessage).FinalLSN)
+	require.Len(t, msgs[1].(*pglogrepl.RelationMessage).Columns, 1)
+	require.Equal(t, "2", string(msgs[2].(*pglogrepl.DeleteMessage).OldTuple.Columns[0].Data))
+	require.Len(t, msgs[3].(*pglogrepl.RelationMessage).Columns, 3)
+	require.Equal(t, "f", string(msgs[4].(*pglogrepl.InsertMessage).Tuple.Columns[2].Data))
+	require.Equal(t, pglogrepl.LSN(0x16B3800), msgs[5].(*pglogrepl.CommitMessage).CommitLSN)
+}
+
+func TestWal2jsonDecoderV1Chunks(t *testing.T) {
+	d := newWal2jsonDecoder(1, fakeRelationLookup{"public.users": 16384})
+
+	msgs, err := d.decode([]byte(`{"xid":733,"nextlsn":"0/16B3900","timestamp":"2024-05-01 10:00:00+00","change":[`), 0x16B3800)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, uint32(733), msgs[0].(*pglogrepl.BeginMessage).Xid)
+
+	msgs, err = d.decode([]byte(`{"kind":"insert","schema":"public","table":"users","columnnames":["id","name","active"],
+		"columntypeoids":[23,1043,16],"columnvalues":[4,"cy",true]}`), 0x16B3810)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, "4", string(msgs[1].(*pglogrepl.InsertMessage).Tuple.Columns[0].Data))
+
+	msgs, err = d.decode([]byte(`,{"kind":"message","transactional":true,"prefix":"p","content":"hi"}`), 0x16B3820)
+	require.NoError(t, err)
+	require.Equal(t, pglogrepl.LSN(0x16B3900), msgs[0].(*pglogrepl.LogicalDecodingMessage).LSN)
+
+	msgs, err = d.decode([]byte(`]}`), 0x16B3830)
+	require.NoError(t, err)
+	require.Equal(t, pglogrepl.LSN(0x16B3900), msgs[0].(*pglogrepl.CommitMessage).CommitLSN)
+
+	// transactions sent whole are still decoded after chunks
+	msgs, err = d.decode([]byte(`{"xid":734,"nextlsn":"0/16B3A00","timestamp":"2024-05-01 10:00:01+00","change":[]}`), 0x16B3900)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+}
@@ -131,6 +131,26 @@ func lvalueToPubSubMessage(ls *lua.LState, value lua.LValue) (PubSubMessage, err
 	}, nil
 }
 
+// applyCloudEvent follows the Pub/Sub protocol binding of CloudEvents
+func applyCloudEvent(msg *pubsub.Message, event utils.CloudEvent, mode protos.CloudEventsMode) error {
+	if msg.Attributes == nil {
+		msg.Attributes = make(map[string]string)
+	}
+	if mode == protos.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED {
+		data, err := event.Structured(msg.Data)
+		if err != nil {
+			return fmt.Errorf("failed to create CloudEvents envelope: %w", err)
+		}
+		msg.Data = data
+	} else {
+		for k, v := range event.Attributes() {
+			msg.Attributes["ce-"+k] = v
+		}
+	}
+	msg.Attributes["content-type"] = event.ContentType(mode)
+	return nil
+}
+
 func (c *PubSubConnector) createPool(
 	ctx context.Context,
 	env map[string]string,
@@ -138,6 +158,7 @@ func (c *PubSubConnector) createPool(
 	flowJobName string,
 	topiccache *topicCache,
 	publish chan<- publishResult,
+	encoder utils.QueueEncoder,
 	queueErr func(error),
 ) (*utils.LPool[poolResult], error) {
 	maxSize, err := peerdbenv.PeerDBQueueParallelism(ctx, env)
@@ -153,7 +174,11 @@ func (c *PubSubConnector) createPool(
 			return nil, fmt.Errorf("[pubsub] error loading script: %w", err)
 		}
 		if script == "" {
-			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DefaultOnRecord))
+			onRecord := utils.DefaultOnRecord
+			if encoder != nil {
+				onRecord = utils.QueueEncoderOnRecord(encoder)
+			}
+			ls.Env.RawSetString("onRecord", ls.NewFunction(onRecord))
 		}
 		return ls, nil
 	}, func(result poolResult) {
@@ -254,9 +279,15 @@ func (c *PubSubConnector) SyncRecords(ctx context.Context, req *model.SyncRecord
 	publish := make(chan publishResult, 32)
 	waitChan := make(chan struct{})
 
+	idempotencyKey, err := peerdbenv.PeerDBQueueIdempotencyKey(ctx, req.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key setting: %w", err)
+	}
+
 	queueCtx, queueErr := context.WithCancelCause(ctx)
 
-	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, &topiccache, publish, queueErr)
+	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, &topiccache, publish,
+		utils.NewQueueEncoder(req.QueueEncoding, req.TableNameSchemaMapping), queueErr)
 	if err != nil {
 		return nil, err
 	}
@@ -292,15 +323,7 @@ func (c *PubSubConnector) SyncRecords(ctx context.Context, req *model.SyncRecord
 				return
 			// flush loop doesn't block processing new messages
 			case <-ticker.C:
-				lastSeen := lastSeenLSN.Load()
-				if lastSeen > req.ConsumedOffset.Load() {
-					if err := c.SetLastOffset(ctx, req.FlowJobName, lastSeen); err != nil {
-						c.logger.Warn("[pubsub] SetLastOffset error", slog.Any("error", err))
-					} else {
-						shared.AtomicInt64Max(req.ConsumedOffset, lastSeen)
-						c.logger.Info("processBatch", slog.Int64("updated last offset", lastSeen))
-					}
-				}
+				utils.SaveLastSeenOffset(ctx, c.logger, req, lastSeenLSN.Load(), c.SetLastOffset)
 			}
 		}
 	}()
@@ -347,6 +370,25 @@ Loop:
 					}
 				}
 				ls.SetTop(0)
+				pkeyCols := req.TableNameSchemaMapping[record.GetDestinationTableName()].GetPrimaryKeyColumns()
+				if idempotencyKey {
+					for i, msg := range results {
+						if msg.Attributes == nil {
+							msg.Attributes = make(map[string]string, 1)
+						}
+						msg.Attributes[model.IdempotencyKeyHeader] = model.IdempotencyKey(record, pkeyCols, i)
+					}
+				}
+				if req.CloudEventsMode != protos.CloudEventsMode_CLOUD_EVENTS_MODE_NONE {
+					for i, msg := range results {
+						event := utils.NewCloudEvent(req.FlowJobName, record, pkeyCols, i,
+							utils.QueueContentType(req.Script, req.QueueEncoding, msg.Data))
+						if err := applyCloudEvent(msg.Message, event, req.CloudEventsMode); err != nil {
+							queueErr(err)
+							return poolResult{}
+						}
+					}
+				}
 				numRecords.Add(1)
 				return poolResult{
 					messages: results,
@@ -361,12 +403,14 @@ Loop:
 
 	close(flushLoopDone)
 	if err := pool.Wait(queueCtx); err != nil {
+		utils.SaveLastSeenOffset(ctx, c.logger, req, lastSeenLSN.Load(), c.SetLastOffset)
 		return nil, fmt.Errorf("[pubsub] pool.Wait error: %w", err)
 	}
 	close(publish)
 	topiccache.Stop(queueCtx)
 	select {
 	case <-queueCtx.Done():
+		utils.SaveLastSeenOffset(ctx, c.logger, req, lastSeenLSN.Load(), c.SetLastOffset)
 		return nil, fmt.Errorf("[pubsub] queueCtx.Done: %w", context.Cause(queueCtx))
 	case <-waitChan:
 	}
@@ -0,0 +1,184 @@
+package conns3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// glueCatalog registers tables and partitions of files written by the connector in a Glue database,
+// remembering what it registered so each is only written once per connector
+type glueCatalog struct {
+	client     *glue.Client
+	database   string
+	schemas    map[string]string
+	partitions map[string]struct{}
+}
+
+func newGlueCatalog(provider utils.AWSCredentialsProvider, database string) *glueCatalog {
+	return &glueCatalog{
+		client: glue.NewFromConfig(aws.Config{}, func(options *glue.Options) {
+			options.Region = provider.GetRegion()
+			options.Credentials = provider.GetUnderlyingProvider()
+		}),
+		database:   database,
+		schemas:    make(map[string]string),
+		partitions: make(map[string]struct{}),
+	}
+}
+
+// glueTableName follows Glue, which lowercases table names and only allows alphanumerics and underscores in Athena
+func glueTableName(table string) string {
+	return strings.ToLower(shared.ReplaceIllegalCharactersWithUnderscores(table))
+}
+
+// hiveColumnType is the Hive type of Avro written for field, following GetAvroSchemaFromQValueKind for S3
+func hiveColumnType(field qvalue.QField) string {
+	switch field.Type {
+	case qvalue.QValueKindInt16, qvalue.QValueKindInt32, qvalue.QValueKindInt64:
+		return "bigint"
+	case qvalue.QValueKindFloat32:
+		return "float"
+	case qvalue.QValueKindFloat64:
+		return "double"
+	case qvalue.QValueKindBoolean:
+		return "boolean"
+	case qvalue.QValueKindBytes:
+		return "binary"
+	case qvalue.QValueKindNumeric:
+		precision, scale := qvalue.DetermineNumericSettingForDWH(field.Precision, field.Scale, protos.DBType_S3)
+		return fmt.Sprintf("decimal(%d,%d)", precision, scale)
+	case qvalue.QValueKindArrayFloat32:
+		return "array<float>"
+	case qvalue.QValueKindArrayFloat64:
+		return "array<double>"
+	case qvalue.QValueKindArrayInt16, qvalue.QValueKindArrayInt32:
+		return "array<int>"
+	case qvalue.QValueKindArrayInt64:
+		return "array<bigint>"
+	case qvalue.QValueKindArrayBoolean:
+		return "array<boolean>"
+	case qvalue.QValueKindArrayString, qvalue.QValueKindArrayDate,
+		qvalue.QValueKindArrayTimestamp, qvalue.QValueKindArrayTimestampTZ:
+		return "array<string>"
+	default:
+		return "string"
+	}
+}
+
+// hivePartitionValues splits a rendered partition layout into its keys and values
+func hivePartitionValues(renderedLayout string) ([]string, []string) {
+	if renderedLayout == "" {
+		return nil, nil
+	}
+	segments := strings.Split(renderedLayout, "/")
+	keys := make([]string, 0, len(segments))
+	values := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		key, value, _ := strings.Cut(segment, "=")
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values
+}
+
+func (g *glueCatalog) storageDescriptor(
+	location string,
+	avroSchema *model.QRecordAvroSchemaDefinition,
+) *gluetypes.StorageDescriptor {
+	columns := make([]gluetypes.Column, 0, len(avroSchema.Fields))
+	for _, field := range avroSchema.Fields {
+		columns = append(columns, gluetypes.Column{
+			Name: aws.String(strings.ToLower(field.Name)),
+			Type: aws.String(hiveColumnType(field)),
+		})
+	}
+	return &gluetypes.StorageDescriptor{
+		Columns:      columns,
+		Location:     aws.String(location),
+		InputFormat:  aws.String("org.apache.hadoop.hive.ql.io.avro.AvroContainerInputFormat"),
+		OutputFormat: aws.String("org.apache.hadoop.hive.ql.io.avro.AvroContainerOutputFormat"),
+		SerdeInfo: &gluetypes.SerDeInfo{
+			SerializationLibrary: aws.String("org.apache.hadoop.hive.serde2.avro.AvroSerDe"),
+			Parameters:           map[string]string{"avro.schema.literal": avroSchema.Schema},
+		},
+	}
+}
+
+// register creates or updates the table of files under tableLocation, and the partition of a file written under
+// partitionLocation with renderedLayout. Tables are updated when their schema changes, partitions are only created
+func (g *glueCatalog) register(
+	ctx context.Context,
+	table string,
+	avroSchema *model.QRecordAvroSchemaDefinition,
+	tableLocation string,
+	partitionLocation string,
+	renderedLayout string,
+) error {
+	tableName := glueTableName(table)
+	keys, values := hivePartitionValues(renderedLayout)
+
+	if g.schemas[tableName] != avroSchema.Schema {
+		partitionKeys := make([]gluetypes.Column, 0, len(keys))
+		for _, key := range keys {
+			partitionKeys = append(partitionKeys, gluetypes.Column{Name: aws.String(key), Type: aws.String("string")})
+		}
+		tableInput := &gluetypes.TableInput{
+			Name:              aws.String(tableName),
+			TableType:         aws.String("EXTERNAL_TABLE"),
+			Parameters:        map[string]string{"classification": "avro", "EXTERNAL": "TRUE"},
+			PartitionKeys:     partitionKeys,
+			StorageDescriptor: g.storageDescriptor(tableLocation, avroSchema),
+		}
+		_, err := g.client.UpdateTable(ctx, &glue.UpdateTableInput{DatabaseName: aws.String(g.database), TableInput: tableInput})
+		var notFound *gluetypes.EntityNotFoundException
+		if errors.As(err, &notFound) {
+			_, err = g.client.CreateTable(ctx, &glue.CreateTableInput{DatabaseName: aws.String(g.database), TableInput: tableInput})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to register table %s in Glue database %s: %w", tableName, g.database, err)
+		}
+		g.schemas[tableName] = avroSchema.Schema
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+	partitionKey := tableName + "/" + renderedLayout
+	if _, ok := g.partitions[partitionKey]; ok {
+		return nil
+	}
+	if _, err := g.client.CreatePartition(ctx, &glue.CreatePartitionInput{
+		DatabaseName: aws.String(g.database),
+		TableName:    aws.String(tableName),
+		PartitionInput: &gluetypes.PartitionInput{
+			Values:            values,
+			StorageDescriptor: g.storageDescriptor(partitionLocation, avroSchema),
+		},
+	}); err != nil {
+		var alreadyExists *gluetypes.AlreadyExistsException
+		if !errors.As(err, &alreadyExists) {
+			return fmt.Errorf("failed to register partition %s of table %s in Glue: %w", renderedLayout, tableName, err)
+		}
+	}
+	g.partitions[partitionKey] = struct{}{}
+	return nil
+}
+
+func (g *glueCatalog) validate(ctx context.Context) error {
+	if _, err := g.client.GetDatabase(ctx, &glue.GetDatabaseInput{Name: aws.String(g.database)}); err != nil {
+		return fmt.Errorf("failed to get Glue database %s: %w", g.database, err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package conns3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestHivePartitionValues(t *testing.T) {
+	keys, values := hivePartitionValues("table=public.users/dt=2024-03-07")
+	require.Equal(t, []string{"table", "dt"}, keys)
+	require.Equal(t, []string{"public.users", "2024-03-07"}, values)
+
+	keys, values = hivePartitionValues("")
+	require.Empty(t, keys)
+	require.Empty(t, values)
+}
+
+func TestHiveColumnType(t *testing.T) {
+	require.Equal(t, "bigint", hiveColumnType(qvalue.QField{Type: qvalue.QValueKindInt16}))
+	require.Equal(t, "decimal(18,4)", hiveColumnType(qvalue.QField{Type: qvalue.QValueKindNumeric, Precision: 18, Scale: 4}))
+	require.Equal(t, "array<int>", hiveColumnType(qvalue.QField{Type: qvalue.QValueKindArrayInt32}))
+	require.Equal(t, "string", hiveColumnType(qvalue.QField{Type: qvalue.QValueKindTimestampTZ}))
+	require.Equal(t, "public_users", glueTableName("public.Users"))
+}
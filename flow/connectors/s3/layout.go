@@ -0,0 +1,49 @@
+package conns3
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	partitionLayoutPlaceholderRegex = regexp.MustCompile(`\{[a-z]+\}`)
+	partitionLayoutSegmentRegex     = regexp.MustCompile(`^[a-z_][a-z0-9_]*=[^=]+$`)
+	partitionLayoutPlaceholders     = map[string]struct{}{
+		"{table}": {}, "{mirror}": {}, "{date}": {}, "{year}": {}, "{month}": {}, "{day}": {}, "{hour}": {},
+	}
+)
+
+// validatePartitionLayout checks every directory of layout is a Hive-style key=value pair
+// so query engines can discover them as partitions
+func validatePartitionLayout(layout string) error {
+	if layout == "" {
+		return nil
+	}
+	for _, segment := range strings.Split(layout, "/") {
+		if !partitionLayoutSegmentRegex.MatchString(segment) {
+			return fmt.Errorf("partition layout directory %q is not of the form key=value", segment)
+		}
+		for _, placeholder := range partitionLayoutPlaceholderRegex.FindAllString(segment, -1) {
+			if _, ok := partitionLayoutPlaceholders[placeholder]; !ok {
+				return fmt.Errorf("unknown placeholder %s in partition layout", placeholder)
+			}
+		}
+	}
+	return nil
+}
+
+// renderPartitionLayout fills the placeholders of layout for a file of table written at writtenAt
+func renderPartitionLayout(layout string, mirror string, table string, writtenAt time.Time) string {
+	writtenAt = writtenAt.UTC()
+	return strings.NewReplacer(
+		"{table}", strings.ReplaceAll(table, "/", "_"),
+		"{mirror}", mirror,
+		"{date}", writtenAt.Format(time.DateOnly),
+		"{year}", writtenAt.Format("2006"),
+		"{month}", writtenAt.Format("01"),
+		"{day}", writtenAt.Format("02"),
+		"{hour}", writtenAt.Format("15"),
+	).Replace(layout)
+}
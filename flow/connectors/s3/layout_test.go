@@ -0,0 +1,25 @@
+package conns3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePartitionLayout(t *testing.T) {
+	require.NoError(t, validatePartitionLayout(""))
+	require.NoError(t, validatePartitionLayout("table={table}/dt={date}"))
+	require.NoError(t, validatePartitionLayout("year={year}/month={month}/day={day}/hour={hour}"))
+	require.Error(t, validatePartitionLayout("{table}/{date}"))
+	require.Error(t, validatePartitionLayout("dt={date}/"))
+	require.ErrorContains(t, validatePartitionLayout("dt={week}"), "unknown placeholder {week}")
+}
+
+func TestRenderPartitionLayout(t *testing.T) {
+	writtenAt := time.Date(2024, 3, 7, 5, 30, 0, 0, time.FixedZone("IST", 5*60*60+30*60))
+	require.Equal(t, "table=public.users/dt=2024-03-07/hour=00",
+		renderPartitionLayout("table={table}/dt={date}/hour={hour}", "mirror", "public.users", writtenAt))
+	require.Equal(t, "mirror=mirror/y=2024/m=03/d=07",
+		renderPartitionLayout("mirror={mirror}/y={year}/m={month}/d={day}", "mirror", "t", writtenAt))
+}
@@ -1,8 +1,15 @@
 package conns3
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
 	avro "github.com/PeerDB-io/peer-flow/connectors/utils/avro"
@@ -25,7 +32,7 @@ func (c *S3Connector) SyncQRepRecords(
 		return 0, err
 	}
 
-	numRecords, err := c.writeToAvroFile(ctx, stream, avroSchema, partition.PartitionId, config.FlowJobName)
+	numRecords, err := c.writeToAvroFile(ctx, stream, avroSchema, partition.PartitionId, config.FlowJobName, dstTableName)
 	if err != nil {
 		return 0, err
 	}
@@ -51,24 +58,83 @@ func (c *S3Connector) writeToAvroFile(
 	avroSchema *model.QRecordAvroSchemaDefinition,
 	partitionID string,
 	jobName string,
+	tableName string,
 ) (int, error) {
 	s3o, err := utils.NewS3BucketAndPrefix(c.url)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse bucket path: %w", err)
 	}
 
-	s3AvroFileKey := fmt.Sprintf("%s/%s/%s.avro", s3o.Prefix, jobName, partitionID)
+	writtenAt := time.Now()
+	mirrorPrefix := fmt.Sprintf("%s/%s", s3o.Prefix, jobName)
+	keyPrefix := mirrorPrefix
+	var renderedLayout string
+	if c.partitionLayout != "" {
+		renderedLayout = renderPartitionLayout(c.partitionLayout, jobName, tableName, writtenAt)
+		keyPrefix += "/" + renderedLayout
+	}
+	s3AvroFileKey := fmt.Sprintf("%s/%s.avro", keyPrefix, partitionID)
 
 	writer := avro.NewPeerDBOCFWriter(stream, avroSchema, avro.CompressNone, protos.DBType_SNOWFLAKE)
-	avroFile, err := writer.WriteRecordsToS3(ctx, s3o.Bucket, s3AvroFileKey, c.credentialsProvider)
+	avroFile, err := writer.WriteRecordsToS3(ctx, s3o.Bucket, s3AvroFileKey, c.credentialsProvider, c.kmsKeyID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to write records to S3: %w", err)
 	}
 	defer avroFile.Cleanup()
 
+	if c.writeManifests {
+		if err := c.writeManifest(ctx, s3o.Bucket, keyPrefix, fileManifest{
+			FlowJobName: jobName,
+			Table:       tableName,
+			PartitionID: partitionID,
+			Key:         s3AvroFileKey,
+			NumRecords:  avroFile.NumRecords,
+			WrittenAt:   writtenAt.UTC(),
+		}); err != nil {
+			return 0, err
+		}
+	}
+	if c.glue != nil {
+		if err := c.glue.register(ctx, tableName, avroSchema,
+			s3Location(s3o.Bucket, mirrorPrefix), s3Location(s3o.Bucket, keyPrefix), renderedLayout,
+		); err != nil {
+			return 0, err
+		}
+	}
+
 	return avroFile.NumRecords, nil
 }
 
+func s3Location(bucket string, keyPrefix string) string {
+	return fmt.Sprintf("s3://%s/%s/", bucket, strings.TrimPrefix(keyPrefix, "/"))
+}
+
+// fileManifest describes a file written for a partition, so consumers can tell complete files from ones in flight
+type fileManifest struct {
+	WrittenAt   time.Time `json:"written_at"`
+	FlowJobName string    `json:"flow_job_name"`
+	Table       string    `json:"table"`
+	PartitionID string    `json:"partition_id"`
+	Key         string    `json:"key"`
+	NumRecords  int       `json:"num_records"`
+}
+
+func (c *S3Connector) writeManifest(ctx context.Context, bucket string, keyPrefix string, manifest fileManifest) error {
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if _, err := c.client.PutObject(ctx, utils.SSEKMS(&s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(fmt.Sprintf("%s/_peerdb_manifest_%s.json", keyPrefix, manifest.PartitionID)),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	}, c.kmsKeyID)); err != nil {
+		return fmt.Errorf("failed to write manifest of %s: %w", manifest.Key, err)
+	}
+	return nil
+}
+
 // S3 just sets up destination, not metadata tables
 func (c *S3Connector) SetupQRepMetadataTables(_ context.Context, config *protos.QRepConfig) error {
 	c.logger.Info("QRep metadata setup not needed for S3.")
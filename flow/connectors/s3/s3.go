@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -22,6 +23,10 @@ type S3Connector struct {
 	credentialsProvider utils.AWSCredentialsProvider
 	client              s3.Client
 	url                 string
+	glue                *glueCatalog
+	partitionLayout     string
+	writeManifests      bool
+	kmsKeyID            string
 }
 
 func NewS3Connector(
@@ -29,6 +34,9 @@ func NewS3Connector(
 	config *protos.S3Config,
 ) (*S3Connector, error) {
 	logger := logger.LoggerFromCtx(ctx)
+	if err := validatePartitionLayout(config.PartitionLayout); err != nil {
+		return nil, err
+	}
 
 	provider, err := utils.GetAWSCredentialsProvider(ctx, "s3", utils.PeerAWSCredentials{
 		Credentials: aws.Credentials{
@@ -52,8 +60,16 @@ func NewS3Connector(
 		logger.Error("failed to create postgres metadata store", "error", err)
 		return nil, err
 	}
+	var glueCatalog *glueCatalog
+	if config.GlueDatabase != "" {
+		glueCatalog = newGlueCatalog(provider, config.GlueDatabase)
+	}
 	return &S3Connector{
+		glue:                glueCatalog,
 		url:                 config.Url,
+		partitionLayout:     config.PartitionLayout,
+		writeManifests:      config.WriteManifests,
+		kmsKeyID:            config.GetKmsKeyId(),
 		PostgresMetadata:    pgMetadata,
 		client:              *s3Client,
 		credentialsProvider: provider,
@@ -76,7 +92,26 @@ func (c *S3Connector) ValidateCheck(ctx context.Context) error {
 		return fmt.Errorf("failed to parse bucket url: %w", parseErr)
 	}
 
-	return utils.PutAndRemoveS3(ctx, &c.client, bucketPrefix.Bucket, bucketPrefix.Prefix)
+	if err := utils.PutAndRemoveS3(ctx, &c.client, bucketPrefix.Bucket, bucketPrefix.Prefix, c.kmsKeyID); err != nil {
+		return err
+	}
+	if c.glue != nil {
+		return c.glue.validate(ctx)
+	}
+	return nil
+}
+
+// StagingRegions is the region of the bucket output is written to, the S3 peer has no staging of its own
+func (c *S3Connector) StagingRegions(ctx context.Context, _ string) ([]string, error) {
+	bucketPrefix, err := utils.NewS3BucketAndPrefix(c.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bucket url: %w", err)
+	}
+	region, err := utils.S3BucketRegion(ctx, c.credentialsProvider, bucketPrefix.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	return []string{strings.ToLower(region)}, nil
 }
 
 func (c *S3Connector) ConnectionActive(ctx context.Context) error {
@@ -0,0 +1,182 @@
+package connsinglestore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+func (c *SingleStoreConnector) getRawTableName(flowJobName string) string {
+	rawTableName := "_peerdb_raw_" + shared.ReplaceIllegalCharactersWithUnderscores(flowJobName)
+	if c.config.RawDatabase != "" {
+		return c.config.RawDatabase + "." + rawTableName
+	}
+	return rawTableName
+}
+
+func (c *SingleStoreConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	rawTableName := c.getRawTableName(req.FlowJobName)
+
+	if c.config.RawDatabase != "" {
+		if err := c.execWithLogging(ctx, "CREATE DATABASE IF NOT EXISTS "+quoteIdentifier(c.config.RawDatabase)); err != nil {
+			return nil, fmt.Errorf("unable to create raw database: %w", err)
+		}
+	}
+
+	if err := c.execWithLogging(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		_peerdb_uid VARCHAR(64) NOT NULL,
+		_peerdb_timestamp BIGINT NOT NULL,
+		_peerdb_destination_table_name VARCHAR(512) NOT NULL,
+		_peerdb_data LONGTEXT NOT NULL,
+		_peerdb_record_type INT NOT NULL,
+		_peerdb_match_data LONGTEXT,
+		_peerdb_batch_id BIGINT,
+		_peerdb_unchanged_toast_columns TEXT,
+		KEY (_peerdb_batch_id)
+	)`, quoteTable(rawTableName))); err != nil {
+		return nil, fmt.Errorf("unable to create raw table: %w", err)
+	}
+	return &protos.CreateRawTableOutput{
+		TableIdentifier: rawTableName,
+	}, nil
+}
+
+func (c *SingleStoreConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	rawTableName := quoteTable(c.getRawTableName(req.FlowJobName))
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	streamReq := model.NewRecordsToStreamRequest(req.Records.GetRecords(), tableNameRowsMapping, req.SyncBatchID)
+	if err := utils.SetCollapseWindow(ctx, req, streamReq); err != nil {
+		return nil, err
+	}
+	stream, err := utils.RecordsToRawTableStream(streamReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
+	}
+
+	// records get new uids every pull, so a retried batch replaces what an earlier attempt loaded
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer c.rollback(tx)
+	if err := c.exec(ctx, tx, fmt.Sprintf("DELETE FROM %s WHERE _peerdb_batch_id = %d",
+		rawTableName, req.SyncBatchID)); err != nil {
+		return nil, fmt.Errorf("failed to clear raw table of batch %d: %w", req.SyncBatchID, err)
+	}
+	numRecords, err := c.loadStream(ctx, tx, rawTableName, stream, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit raw table load: %w", err)
+	}
+
+	if err := c.ReplayTableSchemaDeltas(ctx, req.FlowJobName, req.Records.SchemaDeltas); err != nil {
+		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		c.logger.Error("failed to increment id", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpointID: lastCheckpoint,
+		NumRecordsSynced:       int64(numRecords),
+		CurrentSyncBatchID:     req.SyncBatchID,
+		TableNameRowsMapping:   tableNameRowsMapping,
+		TableSchemaDeltas:      req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *SingleStoreConnector) ReplayTableSchemaDeltas(ctx context.Context, flowJobName string,
+	schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil {
+			continue
+		}
+		for _, addedColumn := range schemaDelta.AddedColumns {
+			colType, err := columnType(qvalue.QValueKind(addedColumn.Type), addedColumn.TypeModifier, false)
+			if err != nil {
+				return err
+			}
+			exists, err := c.columnExists(ctx, schemaDelta.DstTableName, addedColumn.Name)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			if err := c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+				quoteTable(schemaDelta.DstTableName), quoteIdentifier(addedColumn.Name), colType)); err != nil {
+				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s", addedColumn.Name,
+				addedColumn.Type),
+				"destination table name", schemaDelta.DstTableName,
+				"source table name", schemaDelta.SrcTableName)
+		}
+
+		for _, widenedColumn := range schemaDelta.WidenedColumns {
+			oldColType, err := columnType(qvalue.QValueKind(widenedColumn.OldType), widenedColumn.OldTypeModifier, false)
+			if err != nil {
+				return err
+			}
+			colType, err := columnType(qvalue.QValueKind(widenedColumn.NewType), widenedColumn.NewTypeModifier, false)
+			if err != nil {
+				return err
+			}
+			if oldColType == colType {
+				continue
+			}
+			exists, nullable, err := c.columnNullable(ctx, schemaDelta.DstTableName, widenedColumn.Name)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			if !nullable {
+				// key columns are created NOT NULL, SingleStore can't change the type of key columns
+				c.logger.Warn(fmt.Sprintf("[schema delta replay] cannot change type of key column %s from %s to %s, not widening",
+					widenedColumn.Name, oldColType, colType),
+					"destination table name", schemaDelta.DstTableName)
+				continue
+			}
+			if err := c.execWithLogging(ctx, modifyColumnQuery(schemaDelta.DstTableName, widenedColumn.Name, colType)); err != nil {
+				return fmt.Errorf("failed to widen column %s for table %s: %w", widenedColumn.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] widened column %s from %s to %s", widenedColumn.Name,
+				oldColType, colType),
+				"destination table name", schemaDelta.DstTableName,
+				"source table name", schemaDelta.SrcTableName)
+		}
+	}
+
+	return nil
+}
+
+func modifyColumnQuery(table string, column string, colType string) string {
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s NULL", quoteTable(table), quoteIdentifier(column), colType)
+}
+
+func (c *SingleStoreConnector) SyncFlowCleanup(ctx context.Context, jobName string) error {
+	if err := c.PostgresMetadata.SyncFlowCleanup(ctx, jobName); err != nil {
+		return fmt.Errorf("[singlestore] unable to clear metadata for sync flow cleanup: %w", err)
+	}
+
+	if err := c.execWithLogging(ctx, "DROP TABLE IF EXISTS "+quoteTable(c.getRawTableName(jobName))); err != nil {
+		return fmt.Errorf("[singlestore] unable to drop raw table: %w", err)
+	}
+	return nil
+}
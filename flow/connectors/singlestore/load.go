@@ -0,0 +1,199 @@
+package connsinglestore
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// fields are enclosed in double quotes with backslash escapes, \N unenclosed is null
+const loadDataFormat = `FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '"' ESCAPED BY '\\' LINES TERMINATED BY '\n'`
+
+var loadDataEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\x00", `\0`)
+
+// writeLoadData writes rows in loadDataFormat, bytes are hex encoded for the load to UNHEX
+func writeLoadData(w io.Writer, stream *model.QRecordStream) (int, error) {
+	bw := bufio.NewWriter(w)
+	numRecords := 0
+	for record := range stream.Records {
+		for i, value := range record {
+			if i > 0 {
+				if err := bw.WriteByte(','); err != nil {
+					return 0, err
+				}
+			}
+			field, null, err := loadDataField(value)
+			if err != nil {
+				return 0, err
+			}
+			if null {
+				if _, err := bw.WriteString(`\N`); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			if err := bw.WriteByte('"'); err != nil {
+				return 0, err
+			}
+			if _, err := loadDataEscaper.WriteString(bw, field); err != nil {
+				return 0, err
+			}
+			if err := bw.WriteByte('"'); err != nil {
+				return 0, err
+			}
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return 0, err
+		}
+		numRecords += 1
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	return numRecords, bw.Flush()
+}
+
+func loadDataField(value qvalue.QValue) (string, bool, error) {
+	raw := value.Value()
+	if raw == nil {
+		return "", true, nil
+	}
+	switch v := raw.(type) {
+	case string:
+		if value.Kind() == qvalue.QValueKindHStore {
+			hstoreJSON, err := datatypes.ParseHstore(v)
+			if err != nil {
+				return "", false, fmt.Errorf("failed to convert hstore to json: %w", err)
+			}
+			return hstoreJSON, false, nil
+		}
+		return v, false, nil
+	case bool:
+		if v {
+			return "1", false, nil
+		}
+		return "0", false, nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), false, nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), false, nil
+	case int64:
+		return strconv.FormatInt(v, 10), false, nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), false, nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), false, nil
+	case uint8:
+		return string(rune(v)), false, nil
+	case decimal.Decimal:
+		return v.String(), false, nil
+	case [16]byte:
+		return uuid.UUID(v).String(), false, nil
+	case []byte:
+		return hex.EncodeToString(v), false, nil
+	case time.Time:
+		switch value.Kind() {
+		case qvalue.QValueKindDate:
+			return v.Format(time.DateOnly), false, nil
+		case qvalue.QValueKindTime, qvalue.QValueKindTimeTZ:
+			return v.Format("15:04:05.999999"), false, nil
+		default:
+			// DATETIME has no offset, timestamptz lands in UTC
+			return v.UTC().Format("2006-01-02 15:04:05.999999"), false, nil
+		}
+	default:
+		// arrays and anything structured are loaded as JSON
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to encode %s value for singlestore: %w", value.Kind(), err)
+		}
+		return shared.UnsafeFastReadOnlyBytesToString(b), false, nil
+	}
+}
+
+// loadDataQuery loads into table by name of the stream's columns, replace upserts rows by primary key
+func loadDataQuery(reader string, table string, schema qvalue.QRecordSchema, replace bool) string {
+	columns := make([]string, 0, len(schema.Fields))
+	var sets []string
+	for i, field := range schema.Fields {
+		if field.Type == qvalue.QValueKindBytes {
+			variable := "@c" + strconv.Itoa(i)
+			columns = append(columns, variable)
+			sets = append(sets, quoteIdentifier(field.Name)+" = UNHEX("+variable+")")
+		} else {
+			columns = append(columns, quoteIdentifier(field.Name))
+		}
+	}
+	var query strings.Builder
+	query.WriteString("LOAD DATA LOCAL INFILE ")
+	query.WriteString(quoteString("Reader::" + reader))
+	if replace {
+		query.WriteString(" REPLACE")
+	}
+	query.WriteString(" INTO TABLE ")
+	query.WriteString(table)
+	query.WriteString(" CHARACTER SET utf8mb4 ")
+	query.WriteString(loadDataFormat)
+	query.WriteString(" (")
+	query.WriteString(strings.Join(columns, ","))
+	query.WriteString(")")
+	if len(sets) > 0 {
+		query.WriteString(" SET ")
+		query.WriteString(strings.Join(sets, ","))
+	}
+	return query.String()
+}
+
+// loadStream streams the records to LOAD DATA, served to the driver as a named reader instead of a file
+func (c *SingleStoreConnector) loadStream(
+	ctx context.Context,
+	e execer,
+	table string,
+	stream *model.QRecordStream,
+	replace bool,
+) (int, error) {
+	schema := stream.Schema()
+	reader, writer := io.Pipe()
+	name := uuid.NewString()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return reader })
+	defer mysql.DeregisterReaderHandler(name)
+
+	type writeResult struct {
+		err        error
+		numRecords int
+	}
+	written := make(chan writeResult, 1)
+	go func() {
+		numRecords, err := writeLoadData(writer, stream)
+		writer.CloseWithError(err)
+		written <- writeResult{numRecords: numRecords, err: err}
+	}()
+
+	execErr := c.exec(ctx, e, loadDataQuery(name, table, schema, replace))
+	// unblocks the writer when the load stopped reading early
+	reader.Close()
+	result := <-written
+	if result.err != nil && !errors.Is(result.err, io.ErrClosedPipe) {
+		return 0, fmt.Errorf("failed to write records for %s: %w", table, result.err)
+	}
+	if execErr != nil {
+		return 0, fmt.Errorf("failed to load records into %s: %w", table, execErr)
+	}
+	return result.numRecords, nil
+}
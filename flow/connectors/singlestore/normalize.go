@@ -0,0 +1,332 @@
+package connsinglestore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func (c *SingleStoreConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *SingleStoreConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *SingleStoreConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (c *SingleStoreConnector) MissingNormalizedTables(ctx context.Context, tables []string) ([]string, error) {
+	return utils.MissingTables(ctx, tables, c.tableExists)
+}
+
+func (c *SingleStoreConnector) SetupNormalizedTable(
+	ctx context.Context,
+	tx any,
+	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
+) (bool, error) {
+	exists, err := c.tableExists(ctx, tableIdentifier)
+	if err != nil {
+		return false, err
+	}
+	if exists && !config.IsResync {
+		c.logger.Info("[singlestore] normalized table already exists, skipping", slog.String("table", tableIdentifier))
+		return true, nil
+	}
+	if exists {
+		if err := c.execWithLogging(ctx, "DROP TABLE "+quoteTable(tableIdentifier)); err != nil {
+			return false, fmt.Errorf("[singlestore] error while dropping normalized table for resync: %w", err)
+		}
+	}
+
+	columns, err := normalizedColumns(config.TableNameSchemaMapping[tableIdentifier],
+		utils.FindTableMapping(config.TableMappings, tableIdentifier))
+	if err != nil {
+		return false, err
+	}
+	if err := c.execWithLogging(ctx, createTableQuery(tableIdentifier, columns,
+		config.SoftDeleteColName, config.SyncedAtColName)); err != nil {
+		return false, fmt.Errorf("[singlestore] error while creating normalized table: %w", err)
+	}
+	return false, nil
+}
+
+func createTableQuery(tableIdentifier string, columns []normalizedColumn, softDeleteColName string, syncedAtColName string) string {
+	definitions := make([]string, 0, len(columns)+3)
+	var keys []string
+	for _, column := range columns {
+		if column.primaryKey {
+			definitions = append(definitions, quoteIdentifier(column.name)+" "+column.singlestoreType+" NOT NULL")
+			keys = append(keys, quoteIdentifier(column.name))
+		} else {
+			definitions = append(definitions, quoteIdentifier(column.name)+" "+column.singlestoreType)
+		}
+	}
+	if softDeleteColName != "" {
+		definitions = append(definitions, quoteIdentifier(softDeleteColName)+" BOOL NOT NULL DEFAULT FALSE")
+	}
+	if syncedAtColName != "" {
+		definitions = append(definitions, quoteIdentifier(syncedAtColName)+" DATETIME(6) DEFAULT NOW(6)")
+	}
+	// the primary key is also the shard key, so upserts of a key stay on one partition
+	if len(keys) > 0 {
+		definitions = append(definitions, "PRIMARY KEY ("+strings.Join(keys, ",")+")")
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", quoteTable(tableIdentifier), strings.Join(definitions, ","))
+}
+
+// columnType is the type of columns of kind, key columns need a bounded length
+func columnType(kind qvalue.QValueKind, typeModifier int32, primaryKey bool) (string, error) {
+	if kind == qvalue.QValueKindNumeric {
+		precision, scale := datatypes.GetNumericTypeForWarehouse(typeModifier, datatypes.DefaultNumericCompatibility{})
+		return fmt.Sprintf("DECIMAL(%d, %d)", precision, scale), nil
+	}
+	singlestoreType, err := kind.ToDWHColumnType(protos.DBType_SINGLESTORE)
+	if err != nil {
+		return "", fmt.Errorf("error while converting column type to singlestore type: %w", err)
+	}
+	if primaryKey && singlestoreType == "LONGTEXT" {
+		return "VARCHAR(512)", nil
+	}
+	return singlestoreType, nil
+}
+
+type normalizedColumn struct {
+	source          string
+	name            string
+	singlestoreType string
+	kind            qvalue.QValueKind
+	primaryKey      bool
+}
+
+func normalizedColumns(schema *protos.TableSchema, tableMapping *protos.TableMapping) ([]normalizedColumn, error) {
+	columns := make([]normalizedColumn, 0, len(schema.Columns))
+	for _, column := range schema.Columns {
+		kind := qvalue.QValueKind(column.Type)
+		normalized := normalizedColumn{
+			source:     column.Name,
+			name:       column.Name,
+			kind:       kind,
+			primaryKey: slices.Contains(schema.PrimaryKeyColumns, column.Name),
+		}
+		if tableMapping != nil {
+			for _, col := range tableMapping.Columns {
+				if col.SourceName == column.Name {
+					if col.DestinationName != "" {
+						normalized.name = col.DestinationName
+					}
+					normalized.singlestoreType = col.DestinationType
+					break
+				}
+			}
+		}
+		if normalized.singlestoreType == "" {
+			singlestoreType, err := columnType(kind, column.TypeModifier, normalized.primaryKey)
+			if err != nil {
+				return nil, err
+			}
+			normalized.singlestoreType = singlestoreType
+		}
+		columns = append(columns, normalized)
+	}
+	return columns, nil
+}
+
+// extractExpr reads a column out of _peerdb_data as the value to store, JSON_EXTRACT_STRING is null for JSON null
+func extractExpr(column normalizedColumn) string {
+	key := quoteString(column.source)
+	switch {
+	case column.kind == qvalue.QValueKindBoolean:
+		return fmt.Sprintf("CASE JSON_EXTRACT_JSON(_peerdb_data,%s) WHEN 'true' THEN 1 WHEN 'false' THEN 0 END", key)
+	case column.kind == qvalue.QValueKindTimestampTZ:
+		// formatted with a -0700 offset, converted to UTC as DATETIME has none
+		return fmt.Sprintf("CONVERT_TZ(LEFT(JSON_EXTRACT_STRING(_peerdb_data,%[1]s),LENGTH(JSON_EXTRACT_STRING(_peerdb_data,%[1]s))-5),"+
+			"INSERT(RIGHT(JSON_EXTRACT_STRING(_peerdb_data,%[1]s),5),4,0,':'),'+00:00')", key)
+	case column.kind == qvalue.QValueKindBytes:
+		return fmt.Sprintf("FROM_BASE64(JSON_EXTRACT_STRING(_peerdb_data,%s))", key)
+	case column.kind == qvalue.QValueKindQChar:
+		return fmt.Sprintf("CHAR(JSON_EXTRACT_BIGINT(_peerdb_data,%s))", key)
+	case column.kind.IsArray() || column.kind == qvalue.QValueKindHStore:
+		return fmt.Sprintf("JSON_EXTRACT_JSON(_peerdb_data,%s)", key)
+	default:
+		return fmt.Sprintf("JSON_EXTRACT_STRING(_peerdb_data,%s)", key)
+	}
+}
+
+type normalizeStmtGenerator struct {
+	rawTableName      string
+	dstTableName      string
+	softDeleteColName string
+	syncedAtColName   string
+	columns           []normalizedColumn
+	startBatchID      int64
+	syncBatchID       int64
+}
+
+func (g *normalizeStmtGenerator) batchCondition() string {
+	return fmt.Sprintf("_peerdb_batch_id > %d AND _peerdb_batch_id <= %d AND _peerdb_destination_table_name = %s",
+		g.startBatchID, g.syncBatchID, quoteString(g.dstTableName))
+}
+
+// latestRowsQuery has the last change of every key in the batches
+func (g *normalizeStmtGenerator) latestRowsQuery() string {
+	projection := make([]string, 0, len(g.columns)+2)
+	partitionBy := make([]string, 0, len(g.columns))
+	for _, column := range g.columns {
+		projection = append(projection, extractExpr(column)+" AS "+quoteIdentifier(column.name))
+		if column.primaryKey {
+			partitionBy = append(partitionBy, "JSON_EXTRACT_STRING(_peerdb_data,"+quoteString(column.source)+")")
+		}
+	}
+	projection = append(projection, "_peerdb_record_type", "_peerdb_unchanged_toast_columns")
+
+	return fmt.Sprintf("SELECT %s FROM (SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,"+
+		"ROW_NUMBER() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank FROM %s WHERE %s) r"+
+		" WHERE _peerdb_rank = 1",
+		strings.Join(projection, ","), strings.Join(partitionBy, ","), quoteTable(g.rawTableName), g.batchCondition())
+}
+
+func (g *normalizeStmtGenerator) keyCondition() string {
+	conditions := make([]string, 0, len(g.columns))
+	for _, column := range g.columns {
+		if column.primaryKey {
+			name := quoteIdentifier(column.name)
+			conditions = append(conditions, "t."+name+" = s."+name)
+		}
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// statements applies deletes, then upserts the remaining keys, where unchanged TOAST columns
+// are taken from the existing row so ON DUPLICATE KEY UPDATE can overwrite every column
+func (g *normalizeStmtGenerator) statements() []string {
+	dst := quoteTable(g.dstTableName)
+	latest := g.latestRowsQuery()
+	keyCondition := g.keyCondition()
+
+	var deleteStmt string
+	if g.softDeleteColName != "" {
+		set := "t." + quoteIdentifier(g.softDeleteColName) + " = TRUE"
+		if g.syncedAtColName != "" {
+			set += ",t." + quoteIdentifier(g.syncedAtColName) + " = NOW(6)"
+		}
+		deleteStmt = fmt.Sprintf("UPDATE %s t JOIN (%s) s ON %s SET %s WHERE s._peerdb_record_type = 2",
+			dst, latest, keyCondition, set)
+	} else {
+		deleteStmt = fmt.Sprintf("DELETE t FROM %s t JOIN (%s) s ON %s WHERE s._peerdb_record_type = 2",
+			dst, latest, keyCondition)
+	}
+
+	insertColumns := make([]string, 0, len(g.columns)+2)
+	values := make([]string, 0, len(g.columns)+2)
+	updates := make([]string, 0, len(g.columns)+2)
+	for _, column := range g.columns {
+		name := quoteIdentifier(column.name)
+		insertColumns = append(insertColumns, name)
+		if column.primaryKey {
+			values = append(values, "s."+name)
+			continue
+		}
+		values = append(values, fmt.Sprintf("IF(FIND_IN_SET(%s,s._peerdb_unchanged_toast_columns) > 0,t.%s,s.%s)",
+			quoteString(column.source), name, name))
+		updates = append(updates, name+" = VALUES("+name+")")
+	}
+	if g.softDeleteColName != "" {
+		name := quoteIdentifier(g.softDeleteColName)
+		insertColumns = append(insertColumns, name)
+		values = append(values, "FALSE")
+		updates = append(updates, name+" = VALUES("+name+")")
+	}
+	if g.syncedAtColName != "" {
+		name := quoteIdentifier(g.syncedAtColName)
+		insertColumns = append(insertColumns, name)
+		values = append(values, "NOW(6)")
+		updates = append(updates, name+" = VALUES("+name+")")
+	}
+
+	upsert := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM (%s) s LEFT JOIN %s t ON %s WHERE s._peerdb_record_type != 2",
+		dst, strings.Join(insertColumns, ","), strings.Join(values, ","), latest, dst, keyCondition)
+	if len(updates) > 0 {
+		upsert += " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ",")
+	} else {
+		// every column is part of the key, an existing row is already up to date
+		upsert = strings.Replace(upsert, "INSERT INTO", "INSERT IGNORE INTO", 1)
+	}
+	return []string{deleteStmt, upsert}
+}
+
+// appendStatement inserts rows in order of their changes, SingleStore has no other order of rows to keep
+func (g *normalizeStmtGenerator) appendStatement() string {
+	stmt := &utils.AppendStatement{
+		QuoteIdentifier: quoteIdentifier,
+		DstTable:        quoteTable(g.dstTableName),
+		From: quoteTable(g.rawTableName) + " WHERE " + g.batchCondition() +
+			" AND _peerdb_record_type != 2 ORDER BY _peerdb_timestamp",
+		Columns:           make([]utils.AppendColumn, 0, len(g.columns)),
+		SoftDeleteColName: g.softDeleteColName,
+		NotDeleted:        "FALSE",
+		SyncedAtColName:   g.syncedAtColName,
+		Now:               "NOW(6)",
+	}
+	for _, column := range g.columns {
+		stmt.Columns = append(stmt.Columns, utils.AppendColumn{Name: column.name, Expr: extractExpr(column)})
+	}
+	return stmt.SQL()
+}
+
+func (c *SingleStoreConnector) NormalizeRecords(ctx context.Context, req *model.NormalizeRecordsRequest) (*model.NormalizeResponse, error) {
+	rawTableName := c.getRawTableName(req.FlowJobName)
+	normalizer := &utils.SQLNormalizer{
+		Logger:     c.logger,
+		Name:       "singlestore",
+		DB:         c.db,
+		Metadata:   c,
+		QuoteTable: quoteTable,
+		Exec:       c.execWithLogging,
+		TableStatements: func(tbl string, tableMapping *protos.TableMapping, startBatchID int64) ([]string, error) {
+			columns, err := normalizedColumns(req.TableNameSchemaMapping[tbl], tableMapping)
+			if err != nil {
+				return nil, err
+			}
+			generator := &normalizeStmtGenerator{
+				rawTableName:      rawTableName,
+				dstTableName:      tbl,
+				softDeleteColName: req.SoftDeleteColName,
+				syncedAtColName:   req.SyncedAtColName,
+				columns:           columns,
+				startBatchID:      startBatchID,
+				syncBatchID:       req.SyncBatchID,
+			}
+			if len(req.TableNameSchemaMapping[tbl].PrimaryKeyColumns) == 0 {
+				return []string{generator.appendStatement()}, nil
+			}
+			return generator.statements(), nil
+		},
+		ExecTable: c.execInTx,
+	}
+	return normalizer.NormalizeRecords(ctx, req, rawTableName)
+}
+
+func (c *SingleStoreConnector) execInTx(ctx context.Context, stmts []string) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer c.rollback(tx)
+	for _, stmt := range stmts {
+		if err := c.exec(ctx, tx, stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
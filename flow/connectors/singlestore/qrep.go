@@ -0,0 +1,38 @@
+package connsinglestore
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+func (c *SingleStoreConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+// SyncQRepRecords loads with REPLACE, so a retried partition overwrites rows of keys it already loaded
+func (c *SingleStoreConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int, error) {
+	startTime := time.Now()
+	c.logger.Info("[singlestore] syncing partition",
+		slog.String(string(shared.PartitionIDKey), partition.PartitionId),
+		slog.String("destinationTable", config.DestinationTableIdentifier))
+
+	numRecords, err := c.loadStream(ctx, c.db, quoteTable(config.DestinationTableIdentifier), stream, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
@@ -0,0 +1,166 @@
+package connsinglestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+)
+
+// SingleStoreConnector bulk loads batches with LOAD DATA LOCAL INFILE streamed from memory,
+// then upserts them into destination tables by primary key
+type SingleStoreConnector struct {
+	*metadataStore.PostgresMetadata
+	db     *sql.DB
+	config *protos.SingleStoreConfig
+	logger log.Logger
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func NewSingleStoreConnector(ctx context.Context, config *protos.SingleStoreConfig) (*SingleStoreConnector, error) {
+	port := config.Port
+	if port == 0 {
+		port = 3306
+	}
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = net.JoinHostPort(config.Host, strconv.FormatUint(uint64(port), 10))
+	mysqlConfig.User = config.User
+	mysqlConfig.Passwd = config.Password
+	mysqlConfig.DBName = config.Database
+	if !config.DisableTls {
+		mysqlConfig.TLSConfig = "true"
+	}
+	connector, err := mysql.NewConnector(mysqlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create singlestore connector: %w", err)
+	}
+	db := sql.OpenDB(connector)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to singlestore: %w", err)
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SingleStoreConnector{
+		PostgresMetadata: pgMetadata,
+		db:               db,
+		config:           config,
+		logger:           logger.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func (c *SingleStoreConnector) Close() error {
+	if c != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+func (c *SingleStoreConnector) ConnectionActive(ctx context.Context) error {
+	if err := c.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping singlestore: %w", err)
+	}
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", "''") + "'"
+}
+
+// quoteTable quotes database qualified names, unqualified names are in the database of the peer
+func quoteTable(table string) string {
+	if schemaTable, err := utils.ParseSchemaTable(table); err == nil {
+		return quoteIdentifier(schemaTable.Schema) + "." + quoteIdentifier(schemaTable.Table)
+	}
+	return quoteIdentifier(table)
+}
+
+func (c *SingleStoreConnector) exec(ctx context.Context, e execer, query string) error {
+	c.logger.Info("[singlestore] executing statement", slog.String("query", audit.Redact(query)))
+	audit.Record(ctx, query)
+	_, err := e.ExecContext(ctx, query)
+	return err
+}
+
+func (c *SingleStoreConnector) execWithLogging(ctx context.Context, query string) error {
+	return c.exec(ctx, c.db, query)
+}
+
+func (c *SingleStoreConnector) rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		c.logger.Error("[singlestore] failed to rollback transaction", slog.Any("error", err))
+	}
+}
+
+func (c *SingleStoreConnector) splitTable(table string) (string, string) {
+	if schemaTable, err := utils.ParseSchemaTable(table); err == nil {
+		return schemaTable.Schema, schemaTable.Table
+	}
+	return c.config.Database, table
+}
+
+func (c *SingleStoreConnector) tableExists(ctx context.Context, table string) (bool, error) {
+	database, name := c.splitTable(table)
+	var exists int
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+		database, name,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check if table %s exists: %w", table, err)
+	}
+	return exists > 0, nil
+}
+
+func (c *SingleStoreConnector) columnExists(ctx context.Context, table string, column string) (bool, error) {
+	database, name := c.splitTable(table)
+	var exists int
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = ? AND table_name = ? AND column_name = ?",
+		database, name, column,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check if column %s of %s exists: %w", column, table, err)
+	}
+	return exists > 0, nil
+}
+
+// columnNullable returns whether column of table exists and is nullable
+func (c *SingleStoreConnector) columnNullable(ctx context.Context, table string, column string) (bool, bool, error) {
+	database, name := c.splitTable(table)
+	var isNullable string
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT is_nullable FROM information_schema.columns WHERE table_schema = ? AND table_name = ? AND column_name = ?",
+		database, name, column,
+	).Scan(&isNullable); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("failed to check if column %s of %s is nullable: %w", column, table, err)
+	}
+	return true, isNullable == "YES", nil
+}
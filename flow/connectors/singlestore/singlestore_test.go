@@ -0,0 +1,74 @@
+package connsinglestore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestWriteLoadData(t *testing.T) {
+	stream := model.NewQRecordStream(1)
+	stream.Records <- []qvalue.QValue{
+		qvalue.QValueInt32{Val: 7},
+		qvalue.QValueString{Val: "a \"b\"\\\nc"},
+		qvalue.QValueNull(qvalue.QValueKindString),
+		qvalue.QValueBytes{Val: []byte{0, 0xff}},
+		qvalue.QValueHStore{Val: `"k"=>"v"`},
+	}
+	stream.Close(nil)
+
+	var sb strings.Builder
+	numRecords, err := writeLoadData(&sb, stream)
+	require.NoError(t, err)
+	require.Equal(t, 1, numRecords)
+	require.Equal(t, `"7","a \"b\"\\\nc",\N,"00ff","{\"k\":\"v\"}"`+"\n", sb.String())
+}
+
+func TestLoadDataQuery(t *testing.T) {
+	schema := qvalue.QRecordSchema{Fields: []qvalue.QField{
+		{Name: "id", Type: qvalue.QValueKindInt64},
+		{Name: "payload", Type: qvalue.QValueKindBytes},
+	}}
+	require.Equal(t, "LOAD DATA LOCAL INFILE 'Reader::r' REPLACE INTO TABLE `db`.`t` CHARACTER SET utf8mb4 "+
+		loadDataFormat+" (`id`,@c1) SET `payload` = UNHEX(@c1)",
+		loadDataQuery("r", "`db`.`t`", schema, true))
+}
+
+func TestNormalizeStatements(t *testing.T) {
+	generator := &normalizeStmtGenerator{
+		rawTableName:      "_peerdb_raw_flow",
+		dstTableName:      "orders",
+		softDeleteColName: "_peerdb_is_deleted",
+		columns: []normalizedColumn{
+			{source: "id", name: "id", singlestoreType: "BIGINT", kind: qvalue.QValueKindInt64, primaryKey: true},
+			{source: "note", name: "comment", singlestoreType: "LONGTEXT", kind: qvalue.QValueKindString},
+		},
+		startBatchID: 3,
+		syncBatchID:  5,
+	}
+	stmts := generator.statements()
+	require.Len(t, stmts, 2)
+	require.True(t, strings.HasPrefix(stmts[0], "UPDATE `orders` t JOIN (SELECT JSON_EXTRACT_STRING(_peerdb_data,'id') AS `id`,"))
+	require.Contains(t, stmts[0], "PARTITION BY JSON_EXTRACT_STRING(_peerdb_data,'id') ORDER BY _peerdb_timestamp DESC")
+	require.Contains(t, stmts[0], "_peerdb_batch_id > 3 AND _peerdb_batch_id <= 5 AND _peerdb_destination_table_name = 'orders'")
+	require.True(t, strings.HasSuffix(stmts[0], "SET t.`_peerdb_is_deleted` = TRUE WHERE s._peerdb_record_type = 2"))
+	require.True(t, strings.HasPrefix(stmts[1], "INSERT INTO `orders` (`id`,`comment`,`_peerdb_is_deleted`) SELECT s.`id`,"+
+		"IF(FIND_IN_SET('note',s._peerdb_unchanged_toast_columns) > 0,t.`comment`,s.`comment`),FALSE FROM"))
+	require.True(t, strings.HasSuffix(stmts[1], "ON DUPLICATE KEY UPDATE `comment` = VALUES(`comment`),"+
+		"`_peerdb_is_deleted` = VALUES(`_peerdb_is_deleted`)"))
+}
+
+func TestModifyColumnQuery(t *testing.T) {
+	// numeric(10, 2) widened to numeric(12, 2)
+	oldType, err := columnType(qvalue.QValueKindNumeric, (10<<16|2)+4, false)
+	require.NoError(t, err)
+	require.Equal(t, "DECIMAL(10, 2)", oldType)
+	newType, err := columnType(qvalue.QValueKindNumeric, (12<<16|2)+4, false)
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `db`.`t` MODIFY COLUMN `amount` DECIMAL(12, 2) NULL",
+		modifyColumnQuery("db.t", "amount", newType))
+}
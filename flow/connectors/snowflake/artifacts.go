@@ -0,0 +1,68 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const (
+	listRawTablesSQL = `SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES
+	 WHERE TABLE_SCHEMA=UPPER(?) AND STARTSWITH(TABLE_NAME, ?)`
+	listStagesSQL = `SELECT STAGE_NAME FROM INFORMATION_SCHEMA.STAGES
+	 WHERE STAGE_SCHEMA=UPPER(?) AND STARTSWITH(STAGE_NAME, ?)`
+	stagePrefix = "PEERDB_STAGE_"
+)
+
+func (c *SnowflakeConnector) ListStagingArtifacts(ctx context.Context) ([]*protos.StagingArtifact, error) {
+	rawTables, err := c.listArtifacts(ctx, listRawTablesSQL, rawTablePrefix+"_",
+		protos.StagingArtifactKind_STAGING_ARTIFACT_RAW_TABLE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list raw tables: %w", err)
+	}
+	stages, err := c.listArtifacts(ctx, listStagesSQL, stagePrefix, protos.StagingArtifactKind_STAGING_ARTIFACT_STAGE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stages: %w", err)
+	}
+	return append(rawTables, stages...), nil
+}
+
+func (c *SnowflakeConnector) listArtifacts(
+	ctx context.Context,
+	query string,
+	prefix string,
+	kind protos.StagingArtifactKind,
+) ([]*protos.StagingArtifact, error) {
+	rows, err := c.database.QueryContext(ctx, query, c.rawSchema, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var artifacts []*protos.StagingArtifact
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, &protos.StagingArtifact{
+			Kind:     kind,
+			Name:     c.rawSchema + "." + name,
+			FlowName: strings.TrimPrefix(name, prefix),
+		})
+	}
+	return artifacts, rows.Err()
+}
+
+func (c *SnowflakeConnector) DropStagingArtifact(ctx context.Context, artifact *protos.StagingArtifact) error {
+	object := "TABLE"
+	if artifact.Kind == protos.StagingArtifactKind_STAGING_ARTIFACT_STAGE {
+		object = "STAGE"
+	}
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf("DROP %s IF EXISTS %s", object, artifact.Name)); err != nil {
+		return fmt.Errorf("failed to drop %s %s: %w", strings.ToLower(object), artifact.Name, err)
+	}
+	return nil
+}
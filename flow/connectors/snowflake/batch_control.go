@@ -0,0 +1,41 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+const batchControlTable = "PEERDB_BATCHES"
+
+// RecordNormalizedBatch writes batch to PEERDB_BATCHES in the raw schema, a retried normalize keeps the first row
+func (c *SnowflakeConnector) RecordNormalizedBatch(ctx context.Context, flowJobName string, batch *model.NormalizedBatch) error {
+	table := c.rawSchema + "." + batchControlTable
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		FLOW_NAME STRING NOT NULL,
+		BATCH_ID INTEGER NOT NULL,
+		START_BATCH_ID INTEGER NOT NULL,
+		START_LSN INTEGER NOT NULL,
+		END_LSN INTEGER NOT NULL,
+		TABLES VARIANT,
+		NUM_ROWS INTEGER NOT NULL,
+		NORMALIZED_AT TIMESTAMP_TZ NOT NULL)`, table)); err != nil {
+		return fmt.Errorf("failed to create batch control table: %w", err)
+	}
+
+	tables, err := batch.TablesJSON()
+	if err != nil {
+		return err
+	}
+	if _, err := c.database.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %[1]s
+		(FLOW_NAME, BATCH_ID, START_BATCH_ID, START_LSN, END_LSN, TABLES, NUM_ROWS, NORMALIZED_AT)
+		SELECT ?, ?, ?, ?, ?, PARSE_JSON(?), ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM %[1]s WHERE FLOW_NAME = ? AND BATCH_ID = ?)`, table),
+		flowJobName, batch.EndBatchID, batch.StartBatchID, batch.StartLSN, batch.EndLSN, tables,
+		batch.NumRows(), batch.NormalizedAt, flowJobName, batch.EndBatchID,
+	); err != nil {
+		return fmt.Errorf("failed to record batch %d in batch control table: %w", batch.EndBatchID, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	peersql "github.com/PeerDB-io/peer-flow/connectors/sql"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func (c *SnowflakeConnector) LookupRows(
+	ctx context.Context,
+	flowJobName string,
+	table string,
+	keyColumns []string,
+	keys [][]any,
+) ([]model.SampledRow, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	schemaTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return nil, err
+	}
+	quotedKeyColumns := make([]string, 0, len(keyColumns))
+	for _, keyColumn := range keyColumns {
+		quotedKeyColumns = append(quotedKeyColumns, SnowflakeIdentifierNormalize(keyColumn))
+	}
+	filter := utils.SampledKeysFilter(quotedKeyColumns, len(keys), func(int) string { return "?" })
+
+	executor := peersql.NewGenericSQLQueryExecutor(c.logger, sqlx.NewDb(c.database, "snowflake"),
+		snowflakeTypeToQValueKindMap, qvalue.QValueKindToSnowflakeTypeMap)
+	batch, err := executor.ExecuteAndProcessQuery(ctx,
+		fmt.Sprintf("SELECT * FROM %s WHERE %s", snowflakeSchemaTableNormalize(schemaTable), filter),
+		utils.SampledKeyArgs(keys)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up rows of %s: %w", table, err)
+	}
+	return batch.SampledRows(), nil
+}
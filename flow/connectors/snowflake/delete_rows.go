@@ -0,0 +1,37 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
+)
+
+// DeleteRows deletes rows of a destination table, keys are bound as parameters so they are neither logged nor recorded
+func (c *SnowflakeConnector) DeleteRows(
+	ctx context.Context,
+	tableIdentifier string,
+	keyColumns []string,
+	keys [][]any,
+	predicate string,
+) error {
+	table, err := utils.ParseSchemaTable(tableIdentifier)
+	if err != nil {
+		return fmt.Errorf("unable to parse table %s: %w", tableIdentifier, err)
+	}
+	quotedKeyColumns := make([]string, 0, len(keyColumns))
+	for _, keyColumn := range keyColumns {
+		quotedKeyColumns = append(quotedKeyColumns, SnowflakeIdentifierNormalize(keyColumn))
+	}
+	filter, args := utils.RowDeleteFilter(quotedKeyColumns, keys, predicate, func(int) string { return "?" })
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", snowflakeSchemaTableNormalize(table), filter)
+	c.logger.Info("[snowflake] deleting rows", slog.String("query", audit.Redact(query)))
+	audit.Record(ctx, query)
+	if _, err := c.database.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete rows of %s: %w", tableIdentifier, err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NormalizeDiagnostics describes the latest queries of the mirror in query history, running ones included,
+// queries are tagged with the mirror by the connection and by normalize itself
+func (c *SnowflakeConnector) NormalizeDiagnostics(ctx context.Context, flowJobName string) ([]string, error) {
+	rows, err := c.database.QueryContext(ctx, `SELECT QUERY_ID, EXECUTION_STATUS, START_TIME, TOTAL_ELAPSED_TIME,
+		COALESCE(ERROR_MESSAGE, ''), LEFT(QUERY_TEXT, 500)
+		FROM TABLE(INFORMATION_SCHEMA.QUERY_HISTORY_BY_USER(RESULT_LIMIT => 1000))
+		WHERE SESSION_ID <> CURRENT_SESSION() AND (QUERY_TAG = ? OR QUERY_TAG = ? OR STARTSWITH(QUERY_TAG, ?))
+		ORDER BY START_TIME DESC LIMIT 20`,
+		"peerdb-mirror-"+flowJobName, "peerdb_mirror="+flowJobName, "peerdb_mirror="+flowJobName+",")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query history: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var queryID, status, errorMessage, queryText string
+		var startTime time.Time
+		var elapsedMillis int64
+		if err := rows.Scan(&queryID, &status, &startTime, &elapsedMillis, &errorMessage, &queryText); err != nil {
+			return nil, fmt.Errorf("failed to scan query history: %w", err)
+		}
+		line := fmt.Sprintf("query %s %s, started %s and ran %s: %s", queryID, status,
+			startTime.UTC().Format(time.RFC3339), time.Duration(elapsedMillis)*time.Millisecond, queryText)
+		if errorMessage != "" {
+			line += " failed with " + errorMessage
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
@@ -0,0 +1,117 @@
+package connsnowflake
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	avro "github.com/PeerDB-io/peer-flow/connectors/utils/avro"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+// parseStageURL takes the URL out of the URL property of DESC STAGE, a JSON array like ["s3://bucket/path/"]
+func parseStageURL(propertyValue string) (string, error) {
+	var urls []string
+	if err := json.Unmarshal([]byte(propertyValue), &urls); err != nil || len(urls) == 0 {
+		return "", fmt.Errorf("external stage has no URL: %s", propertyValue)
+	}
+	stageURL := strings.TrimSuffix(urls[0], "/")
+	if !strings.HasPrefix(stageURL, "s3://") && !strings.HasPrefix(stageURL, "gcs://") {
+		return "", fmt.Errorf("external stage URL %s is not on S3 or GCS", stageURL)
+	}
+	return stageURL, nil
+}
+
+// externalStageURL is where files of the external stage of the peer live, read once per connector
+func (c *SnowflakeConnector) externalStageURL(ctx context.Context) (string, error) {
+	if c.stageURL != "" {
+		return c.stageURL, nil
+	}
+
+	rows, err := c.database.QueryContext(ctx, "DESC STAGE "+c.config.GetExternalStage())
+	if err != nil {
+		return "", fmt.Errorf("failed to describe external stage %s: %w", c.config.GetExternalStage(), err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var parentProperty, property, propertyType, propertyValue, propertyDefault sql.NullString
+		if err := rows.Scan(&parentProperty, &property, &propertyType, &propertyValue, &propertyDefault); err != nil {
+			return "", fmt.Errorf("failed to scan external stage property: %w", err)
+		}
+		if parentProperty.String == "STAGE_LOCATION" && property.String == "URL" {
+			stageURL, err := parseStageURL(propertyValue.String)
+			if err != nil {
+				return "", err
+			}
+			c.stageURL = stageURL
+			return stageURL, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to read external stage properties: %w", err)
+	}
+	return "", fmt.Errorf("%s is not an external stage", c.config.GetExternalStage())
+}
+
+// writeToExternalStage writes straight to the bucket of the external stage, PUT only uploads to internal stages
+func (s *SnowflakeAvroSyncHandler) writeToExternalStage(
+	ctx context.Context,
+	stream *model.QRecordStream,
+	avroSchema *model.QRecordAvroSchemaDefinition,
+	partitionID string,
+) (*avro.AvroFile, error) {
+	stageURL, err := s.connector.externalStageURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ocfWriter := avro.NewPeerDBOCFWriter(stream, avroSchema, avro.CompressZstd, protos.DBType_SNOWFLAKE)
+	fileName := fmt.Sprintf("%s/%s.avro.zst", s.config.FlowJobName, partitionID)
+
+	if bucketPath, isGCS := strings.CutPrefix(stageURL, "gcs://"); isGCS {
+		bucket, prefix, _ := strings.Cut(bucketPath, "/")
+		client, err := storage.NewClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCS client: %w", err)
+		}
+		defer client.Close()
+
+		objectPath := path.Join(prefix, fileName)
+		w := client.Bucket(bucket).Object(objectPath).NewWriter(ctx)
+		w.KMSKeyName = s.connector.config.GetKmsKeyId()
+		numRecords, err := ocfWriter.WriteOCF(ctx, w)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write records to external stage on GCS: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write records to external stage on GCS: %w", err)
+		}
+		return &avro.AvroFile{
+			NumRecords:      numRecords,
+			StorageLocation: avro.AvroGCSStorage,
+			FilePath:        objectPath,
+		}, nil
+	}
+
+	s3o, err := utils.NewS3BucketAndPrefix(stageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse external stage URL: %w", err)
+	}
+	provider, err := utils.GetAWSCredentialsProvider(ctx, "snowflake", utils.PeerAWSCredentials{})
+	if err != nil {
+		return nil, err
+	}
+	avroFile, err := ocfWriter.WriteRecordsToS3(ctx, s3o.Bucket, path.Join(s3o.Prefix, fileName), provider,
+		s.connector.config.GetKmsKeyId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to write records to external stage on S3: %w", err)
+	}
+	return avroFile, nil
+}
@@ -0,0 +1,22 @@
+package connsnowflake
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStageURL(t *testing.T) {
+	stageURL, err := parseStageURL(`["s3://bucket/peerdb/"]`)
+	require.NoError(t, err)
+	require.Equal(t, "s3://bucket/peerdb", stageURL)
+
+	stageURL, err = parseStageURL(`["gcs://bucket"]`)
+	require.NoError(t, err)
+	require.Equal(t, "gcs://bucket", stageURL)
+
+	_, err = parseStageURL(`["azure://account.blob.core.windows.net/container"]`)
+	require.Error(t, err)
+	_, err = parseStageURL("")
+	require.Error(t, err)
+}
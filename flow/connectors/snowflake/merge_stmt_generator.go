@@ -215,3 +215,24 @@ func (m *mergeStmtGenerator) generateUpdateStatements(allCols []string, unchange
 	}
 	return updateStmts
 }
+
+// generateTruncateStmt applies a source TRUNCATE to dstTable, empty when there is nothing to do
+func generateTruncateStmt(dstTable string, policy protos.TruncatePolicy, peerdbCols *protos.PeerDBColumns) string {
+	parsedDstTable, _ := utils.ParseSchemaTable(dstTable)
+	switch policy {
+	case protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE:
+		return "TRUNCATE TABLE IF EXISTS " + snowflakeSchemaTableNormalize(parsedDstTable)
+	case protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE:
+		if peerdbCols.SoftDeleteColName == "" {
+			return ""
+		}
+		stmt := fmt.Sprintf(`UPDATE %s SET "%s" = TRUE`,
+			snowflakeSchemaTableNormalize(parsedDstTable), peerdbCols.SoftDeleteColName)
+		if peerdbCols.SyncedAtColName != "" {
+			stmt += fmt.Sprintf(`, "%s" = CURRENT_TIMESTAMP`, peerdbCols.SyncedAtColName)
+		}
+		return stmt + fmt.Sprintf(` WHERE "%s" IS DISTINCT FROM TRUE`, peerdbCols.SoftDeleteColName)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,20 @@
+package connsnowflake
+
+import (
+	"errors"
+
+	"github.com/snowflakedb/gosnowflake"
+)
+
+// error numbers of Snowflake canceling statements that waited too long on the warehouse or table locks
+const (
+	snowflakeLockWaitTimeout   = 625
+	snowflakeStatementTimedOut = 630
+)
+
+// IsDestinationPressure is true for statements canceled after queueing on a busy warehouse
+// or waiting on locks other statements hold on the table
+func (c *SnowflakeConnector) IsDestinationPressure(err error) bool {
+	var sfErr *gosnowflake.SnowflakeError
+	return errors.As(err, &sfErr) && (sfErr.Number == snowflakeStatementTimedOut || sfErr.Number == snowflakeLockWaitTimeout)
+}
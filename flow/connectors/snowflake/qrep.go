@@ -105,6 +105,12 @@ func (c *SnowflakeConnector) SetupQRepMetadataTables(ctx context.Context, config
 }
 
 func (c *SnowflakeConnector) createStage(ctx context.Context, stageName string, config *protos.QRepConfig) error {
+	// external stages are managed by their owners, only checked to be usable
+	if c.config.GetExternalStage() != "" {
+		_, err := c.externalStageURL(ctx)
+		return err
+	}
+
 	var createStageStmt string
 	if strings.HasPrefix(config.StagingPath, "s3://") {
 		stmt, err := c.createExternalStage(ctx, stageName, config)
@@ -170,6 +176,10 @@ func (c *SnowflakeConnector) createExternalStage(ctx context.Context, stageName
 }
 
 func (c *SnowflakeConnector) ConsolidateQRepPartitions(ctx context.Context, config *protos.QRepConfig) error {
+	if config.ExportPath != "" {
+		// exported partitions are loaded offline by their load script
+		return nil
+	}
 	ctx = c.withMirrorNameQueryTag(ctx, config.FlowJobName)
 
 	destTable := config.DestinationTableIdentifier
@@ -238,6 +248,10 @@ func (c *SnowflakeConnector) getColsFromTable(ctx context.Context, tableName str
 
 // dropStage drops the stage for the given job.
 func (c *SnowflakeConnector) dropStage(ctx context.Context, stagingPath string, job string) error {
+	// files loaded from external stages are purged by COPY, the stage and its bucket are left to their owners
+	if c.config.GetExternalStage() != "" {
+		return nil
+	}
 	stageName := c.getStageNameForJob(job)
 	stmt := "DROP STAGE IF EXISTS " + stageName
 
@@ -298,6 +312,10 @@ func (c *SnowflakeConnector) dropStage(ctx context.Context, stagingPath string,
 	return nil
 }
 
+// getStageNameForJob is the stage files of job are loaded from, a path of the external stage when the peer has one
 func (c *SnowflakeConnector) getStageNameForJob(job string) string {
+	if externalStage := c.config.GetExternalStage(); externalStage != "" {
+		return fmt.Sprintf("%s/%s/", externalStage, job)
+	}
 	return fmt.Sprintf("%s.peerdb_stage_%s", c.rawSchema, job)
 }
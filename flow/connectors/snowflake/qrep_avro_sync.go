@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
-	"os"
 	"strings"
 	"time"
 
@@ -108,6 +107,17 @@ func (s *SnowflakeAvroSyncHandler) SyncQRepRecords(
 		return 0, err
 	}
 
+	if config.ExportPath != "" {
+		numRecords, err := s.exportPartition(ctx, config, partition, stream, avroSchema)
+		if err != nil {
+			return 0, err
+		}
+		if err := s.connector.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+			return 0, err
+		}
+		return numRecords, nil
+	}
+
 	avroFile, err := s.writeToAvroFile(ctx, stream, avroSchema, partition.PartitionId, config.FlowJobName)
 	if err != nil {
 		return 0, err
@@ -130,6 +140,65 @@ func (s *SnowflakeAvroSyncHandler) SyncQRepRecords(
 	return avroFile.NumRecords, nil
 }
 
+// exportPartition writes a partition of an initial snapshot with an export path as staged Avro, next to a script
+// creating a stage over them and copying them into the destination table like consolidation would
+func (s *SnowflakeAvroSyncHandler) exportPartition(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+	avroSchema *model.QRecordAvroSchemaDefinition,
+) (int, error) {
+	export, err := utils.NewSnapshotExport(config.ExportPath, config.ParentMirrorName, config.DestinationTableIdentifier)
+	if err != nil {
+		return 0, err
+	}
+	provider, err := utils.GetAWSCredentialsProvider(ctx, "snowflake", utils.PeerAWSCredentials{})
+	if err != nil {
+		return 0, err
+	}
+
+	ocfWriter := avro.NewPeerDBOCFWriter(stream, avroSchema, avro.CompressZstd, protos.DBType_SNOWFLAKE)
+	avroFile, err := ocfWriter.WriteRecordsToS3(ctx, export.Bucket, export.Key(partition.PartitionId+".avro.zst"),
+		provider, s.connector.config.GetKmsKeyId())
+	if err != nil {
+		return 0, fmt.Errorf("failed to export records to S3: %w", err)
+	}
+
+	columns, err := s.connector.getColsFromTable(ctx, config.DestinationTableIdentifier)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get columns from destination table: %w", err)
+	}
+	colNames := make([]string, 0, len(columns))
+	colTypes := make([]string, 0, len(columns))
+	for _, col := range columns {
+		colNames = append(colNames, col.ColumnName)
+		colTypes = append(colTypes, col.ColumnType)
+	}
+	if err := export.PutLoadScript(ctx, provider,
+		stagedLoadScript(config, export.URI(), colNames, colTypes), s.connector.config.GetKmsKeyId()); err != nil {
+		return 0, err
+	}
+	s.connector.logger.Info("exported partition of initial snapshot",
+		slog.String(string(shared.PartitionIDKey), partition.PartitionId),
+		slog.String("exportPath", export.URI()))
+	return avroFile.NumRecords, nil
+}
+
+// stagedLoadScript copies Avro files exported by an initial snapshot into the destination table,
+// the stage needing a storage integration or credentials of whoever loads them
+func stagedLoadScript(config *protos.QRepConfig, exportURI string, colNames []string, colTypes []string) string {
+	parsedDstTable, _ := utils.ParseSchemaTable(config.DestinationTableIdentifier)
+	dstTable := snowflakeSchemaTableNormalize(parsedDstTable)
+	stage := "PEERDB_EXPORT_" + strings.ToUpper(shared.ReplaceIllegalCharactersWithUnderscores(
+		config.ParentMirrorName+"_"+parsedDstTable.Table))
+	transformationSQL, columnsSQL := getTransformSQL(colNames, colTypes, config.SyncedAtColName, config.SoftDeleteColName)
+	return fmt.Sprintf(`CREATE STAGE IF NOT EXISTS %[1]s URL = '%[2]s' FILE_FORMAT = (TYPE = AVRO);
+-- add STORAGE_INTEGRATION = <integration> or CREDENTIALS = (...) to the stage unless the bucket is public
+COPY INTO %[3]s(%[4]s) FROM (SELECT %[5]s FROM @%[1]s) FILE_FORMAT = (TYPE = AVRO) PATTERN = '.*[.]avro[.]zst';
+`, stage, exportURI, dstTable, columnsSQL, transformationSQL)
+}
+
 func (s *SnowflakeAvroSyncHandler) addMissingColumns(
 	ctx context.Context,
 	schema qvalue.QRecordSchema,
@@ -214,12 +283,13 @@ func (s *SnowflakeAvroSyncHandler) writeToAvroFile(
 	partitionID string,
 	flowJobName string,
 ) (*avro.AvroFile, error) {
-	if s.config.StagingPath == "" {
+	if s.connector.config.GetExternalStage() != "" {
+		return s.writeToExternalStage(ctx, stream, avroSchema, partitionID)
+	} else if s.config.StagingPath == "" {
 		ocfWriter := avro.NewPeerDBOCFWriter(stream, avroSchema, avro.CompressZstd, protos.DBType_SNOWFLAKE)
-		tmpDir := fmt.Sprintf("%s/peerdb-avro-%s", os.TempDir(), flowJobName)
-		err := os.MkdirAll(tmpDir, os.ModePerm)
+		tmpDir, err := utils.SpillDir(flowJobName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create temp dir: %w", err)
+			return nil, err
 		}
 
 		localFilePath := fmt.Sprintf("%s/%s.avro.zst", tmpDir, partitionID)
@@ -245,7 +315,7 @@ func (s *SnowflakeAvroSyncHandler) writeToAvroFile(
 		if err != nil {
 			return nil, err
 		}
-		avroFile, err := ocfWriter.WriteRecordsToS3(ctx, s3o.Bucket, s3AvroFileKey, provider)
+		avroFile, err := ocfWriter.WriteRecordsToS3(ctx, s3o.Bucket, s3AvroFileKey, provider, s.connector.config.GetKmsKeyId())
 		if err != nil {
 			return nil, fmt.Errorf("failed to write records to S3: %w", err)
 		}
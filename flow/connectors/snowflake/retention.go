@@ -0,0 +1,22 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+)
+
+func (c *SnowflakeConnector) EnforceRetention(ctx context.Context, tableIdentifier string, column string, cutoff time.Time) error {
+	table, err := utils.ParseSchemaTable(tableIdentifier)
+	if err != nil {
+		return fmt.Errorf("unable to parse table %s: %w", tableIdentifier, err)
+	}
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s < '%s +00:00'::TIMESTAMP_TZ",
+		snowflakeSchemaTableNormalize(table), SnowflakeIdentifierNormalize(column), utils.RetentionCutoffLiteral(cutoff)),
+	); err != nil {
+		return fmt.Errorf("failed to delete expired rows of %s: %w", tableIdentifier, err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// setupRowSecurity restricts rows of a destination table to roles granted their tag by the row access table
+// of its schema. Row access policies apply to owners too, so the role PeerDB syncs with sees every row
+func (c *SnowflakeConnector) setupRowSecurity(
+	ctx context.Context,
+	table *utils.SchemaTable,
+	security *protos.RowSecurity,
+) error {
+	var role string
+	if err := c.database.QueryRowContext(ctx, "SELECT CURRENT_ROLE()").Scan(&role); err != nil {
+		return fmt.Errorf("failed to get current role: %w", err)
+	}
+	schema := SnowflakeIdentifierNormalize(table.Schema)
+	accessTable := schema + "." + SnowflakeIdentifierNormalize(shared.RowAccessTable)
+	policy := schema + "." + SnowflakeIdentifierNormalize(shared.RowSecurityPolicy)
+	for _, stmt := range []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (TAG STRING NOT NULL, ROLE_NAME STRING NOT NULL)", accessTable),
+		fmt.Sprintf(`CREATE ROW ACCESS POLICY IF NOT EXISTS %s AS (ROW_TAG STRING) RETURNS BOOLEAN ->
+			IS_ROLE_IN_SESSION('%s') OR EXISTS (SELECT 1 FROM %s WHERE TAG = ROW_TAG AND IS_ROLE_IN_SESSION(ROLE_NAME))`,
+			policy, snowflakeStringEscaper.Replace(role), accessTable),
+		fmt.Sprintf("ALTER TABLE %s ADD ROW ACCESS POLICY %s ON (%s)",
+			snowflakeSchemaTableNormalize(table), policy, SnowflakeIdentifierNormalize(security.TagColumn)),
+	} {
+		if _, err := c.execWithLogging(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to set up row security of %s: %w", table.String(), err)
+		}
+	}
+	return nil
+}
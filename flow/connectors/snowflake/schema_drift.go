@@ -0,0 +1,112 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+const getTableNullableColumnsSQL = `SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE = 'YES' FROM INFORMATION_SCHEMA.COLUMNS
+	 WHERE TABLE_SCHEMA=? AND TABLE_NAME=? ORDER BY ORDINAL_POSITION`
+
+// Snowflake reports types by the type their alias stands for, like NUMBER for INTEGER
+var snowflakeTypeAliases = map[string]string{
+	"INTEGER": "NUMBER",
+	"NUMERIC": "NUMBER",
+	"CHAR":    "TEXT",
+	"STRING":  "TEXT",
+	"VARCHAR": "TEXT",
+}
+
+// CompareTableSchemas matches types by the type aliases stand for, precision and scale aside
+func (c *SnowflakeConnector) CompareTableSchemas(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	tableSchemas map[string]*protos.TableSchema,
+) ([]*protos.SchemaDrift, error) {
+	var mirrorColumns []string
+	if config.SoftDeleteColName != "" {
+		mirrorColumns = append(mirrorColumns, SnowflakeQuotelessIdentifierNormalize(config.SoftDeleteColName))
+	}
+	if config.SyncedAtColName != "" {
+		mirrorColumns = append(mirrorColumns, SnowflakeQuotelessIdentifierNormalize(config.SyncedAtColName))
+	}
+
+	var drifts []*protos.SchemaDrift
+	for _, table := range slices.Sorted(maps.Keys(tableSchemas)) {
+		tableSchema := tableSchemas[table]
+		expected := make([]utils.DestinationColumn, 0, len(tableSchema.Columns))
+		for _, column := range tableSchema.Columns {
+			sfColType, err := qvalue.QValueKind(column.Type).ToDWHColumnType(protos.DBType_SNOWFLAKE)
+			if err != nil {
+				// SetupNormalizedTable leaves these out too
+				c.logger.Warn("failed to convert column type to snowflake type, not comparing it",
+					slog.String("column", column.Name), slog.Any("error", err))
+				continue
+			}
+			primaryKey := slices.Contains(tableSchema.PrimaryKeyColumns, column.Name) && !tableSchema.IsReplicaIdentityFull
+			expected = append(expected, utils.DestinationColumn{
+				Name:     SnowflakeQuotelessIdentifierNormalize(column.Name),
+				Type:     sfColType,
+				Nullable: !primaryKey && !(tableSchema.NullableEnabled && !column.Nullable),
+			})
+		}
+		actual, err := c.destinationColumns(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, utils.DiffTableSchema(table, expected, actual, mirrorColumns, sameSnowflakeType)...)
+	}
+	return drifts, nil
+}
+
+// destinationColumns returns columns of table, nil when it doesn't exist
+func (c *SnowflakeConnector) destinationColumns(ctx context.Context, table string) ([]utils.DestinationColumn, error) {
+	schemaTable, err := utils.ParseSchemaTable(table)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	schema := SnowflakeQuotelessIdentifierNormalize(schemaTable.Schema)
+	tableName := SnowflakeQuotelessIdentifierNormalize(schemaTable.Table)
+	exists, err := c.checkIfTableExists(ctx, schema, tableName)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	rows, err := c.database.QueryContext(ctx, getTableNullableColumnsSQL, schema, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+	actual := make([]utils.DestinationColumn, 0)
+	for rows.Next() {
+		var col utils.DestinationColumn
+		if err := rows.Scan(&col.Name, &col.Type, &col.Nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan columns of %s: %w", table, err)
+		}
+		actual = append(actual, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	return actual, nil
+}
+
+func sameSnowflakeType(expected string, actual string) bool {
+	baseType := func(sfType string) string {
+		sfType, _, _ = strings.Cut(strings.ToUpper(sfType), "(")
+		sfType = strings.TrimSpace(sfType)
+		if alias, ok := snowflakeTypeAliases[sfType]; ok {
+			return alias
+		}
+		return sfType
+	}
+	return baseType(expected) == baseType(actual)
+}
@@ -7,6 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -19,6 +22,7 @@ import (
 
 	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
 	numeric "github.com/PeerDB-io/peer-flow/datatypes"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/logger"
@@ -84,6 +88,8 @@ type SnowflakeConnector struct {
 	logger    log.Logger
 	config    *protos.SnowflakeConfig
 	rawSchema string
+	// resolved URL of the external stage of the peer, if any
+	stageURL string
 }
 
 // creating this to capture array results from snowflake.
@@ -163,6 +169,12 @@ func (c *SnowflakeConnector) ValidateCheck(ctx context.Context) error {
 		return fmt.Errorf("failed to commit transaction for table check: %w", err)
 	}
 
+	if c.config.GetExternalStage() != "" {
+		if _, err := c.externalStageURL(ctx); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -177,8 +189,21 @@ func NewSnowflakeConnector(
 		return nil, err
 	}
 
+	statementTimeout, err := peerdbenv.PeerDBStatementTimeout(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement timeout: %w", err)
+	}
+
 	additionalParams := make(map[string]*string)
 	additionalParams["CLIENT_SESSION_KEEP_ALIVE"] = ptr.String("true")
+	// the driver aborts queries of canceled contexts itself, this bounds queries nobody cancels
+	if statementTimeout > 0 {
+		additionalParams["STATEMENT_TIMEOUT_IN_SECONDS"] = ptr.String(strconv.FormatInt(int64(statementTimeout.Seconds()), 10))
+	}
+	// tags queries of mirrors in QUERY_HISTORY for cost attribution
+	if flowName, ok := ctx.Value(shared.FlowNameKey).(string); ok && flowName != "" {
+		additionalParams["QUERY_TAG"] = ptr.String(shared.MirrorQueryTag(flowName, shared.MirrorLabels(ctx)))
+	}
 
 	snowflakeConfig := gosnowflake.Config{
 		Account:          snowflakeProtoConfig.AccountId,
@@ -316,6 +341,28 @@ func (c *SnowflakeConnector) FinishSetupNormalizedTables(_ context.Context, _ in
 func (c *SnowflakeConnector) CleanupSetupNormalizedTables(_ context.Context, _ interface{}) {
 }
 
+func (c *SnowflakeConnector) MissingNormalizedTables(ctx context.Context, tables []string) ([]string, error) {
+	var missing []string
+	for _, table := range tables {
+		schemaTable, err := utils.ParseSchemaTable(table)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing table schema and name: %w", err)
+		}
+		exists, err := c.checkIfTableExists(
+			ctx,
+			SnowflakeQuotelessIdentifierNormalize(schemaTable.Schema),
+			SnowflakeQuotelessIdentifierNormalize(schemaTable.Table),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, table)
+		}
+	}
+	return missing, nil
+}
+
 func (c *SnowflakeConnector) SetupNormalizedTable(
 	ctx context.Context,
 	tx interface{},
@@ -326,6 +373,11 @@ func (c *SnowflakeConnector) SetupNormalizedTable(
 	if err != nil {
 		return false, fmt.Errorf("error while parsing table schema and name: %w", err)
 	}
+	if shared.IsShadowSchema(normalizedSchemaTable.Schema) {
+		if _, err := c.execWithLogging(ctx, fmt.Sprintf(createSchemaSQL, normalizedSchemaTable.Schema)); err != nil {
+			return false, fmt.Errorf("[sf] error while creating shadow schema: %w", err)
+		}
+	}
 	tableAlreadyExists, err := c.checkIfTableExists(
 		ctx,
 		SnowflakeQuotelessIdentifierNormalize(normalizedSchemaTable.Schema),
@@ -344,6 +396,13 @@ func (c *SnowflakeConnector) SetupNormalizedTable(
 	if _, err := c.execWithLogging(ctx, normalizedTableCreateSQL); err != nil {
 		return false, fmt.Errorf("[sf] error while creating normalized table: %w", err)
 	}
+	for _, tableMapping := range config.TableMappings {
+		if tableMapping.DestinationTableIdentifier == tableIdentifier && tableMapping.RowSecurity.GetTagColumn() != "" {
+			if err := c.setupRowSecurity(ctx, normalizedSchemaTable, tableMapping.RowSecurity); err != nil {
+				return false, err
+			}
+		}
+	}
 	return false, nil
 }
 
@@ -371,25 +430,19 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 	}()
 
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		if schemaDelta == nil || (len(schemaDelta.AddedColumns) == 0 && len(schemaDelta.WidenedColumns) == 0) {
 			continue
 		}
 
 		for _, addedColumn := range schemaDelta.AddedColumns {
-			sfColtype, err := qvalue.QValueKind(addedColumn.Type).ToDWHColumnType(protos.DBType_SNOWFLAKE)
+			sfColtype, err := snowflakeColumnType(addedColumn.Type, addedColumn.TypeModifier)
 			if err != nil {
-				return fmt.Errorf("failed to convert column type %s to snowflake type: %w",
-					addedColumn.Type, err)
-			}
-
-			if addedColumn.Type == string(qvalue.QValueKindNumeric) {
-				precision, scale := numeric.GetNumericTypeForWarehouse(addedColumn.TypeModifier, numeric.SnowflakeNumericCompatibility{})
-				sfColtype = fmt.Sprintf("NUMERIC(%d,%d)", precision, scale)
+				return err
 			}
 
-			_, err = tableSchemaModifyTx.ExecContext(ctx,
+			_, err = c.execWithLoggingTx(ctx,
 				fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS \"%s\" %s",
-					schemaDelta.DstTableName, strings.ToUpper(addedColumn.Name), sfColtype))
+					schemaDelta.DstTableName, strings.ToUpper(addedColumn.Name), sfColtype), tableSchemaModifyTx)
 			if err != nil {
 				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
 					schemaDelta.DstTableName, err)
@@ -399,6 +452,43 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 				"destination table name", schemaDelta.DstTableName,
 				"source table name", schemaDelta.SrcTableName)
 		}
+
+		for _, widenedColumn := range schemaDelta.WidenedColumns {
+			oldColtype, err := snowflakeColumnType(widenedColumn.OldType, widenedColumn.OldTypeModifier)
+			if err != nil {
+				return err
+			}
+			sfColtype, err := snowflakeColumnType(widenedColumn.NewType, widenedColumn.NewTypeModifier)
+			if err != nil {
+				return err
+			}
+			if oldColtype == sfColtype {
+				continue
+			}
+			if widenedColumn.OldType == string(qvalue.QValueKindNumeric) {
+				_, oldScale := numeric.GetNumericTypeForWarehouse(widenedColumn.OldTypeModifier, numeric.SnowflakeNumericCompatibility{})
+				_, newScale := numeric.GetNumericTypeForWarehouse(widenedColumn.NewTypeModifier, numeric.SnowflakeNumericCompatibility{})
+				if oldScale != newScale {
+					// Snowflake can only increase precision of NUMBER columns in place
+					c.logger.Warn(fmt.Sprintf("[schema delta replay] cannot change scale of column %s from %s to %s, not widening",
+						widenedColumn.Name, oldColtype, sfColtype),
+						"destination table name", schemaDelta.DstTableName)
+					continue
+				}
+			}
+
+			_, err = c.execWithLoggingTx(ctx,
+				fmt.Sprintf("ALTER TABLE %s ALTER COLUMN \"%s\" SET DATA TYPE %s",
+					schemaDelta.DstTableName, strings.ToUpper(widenedColumn.Name), sfColtype), tableSchemaModifyTx)
+			if err != nil {
+				return fmt.Errorf("failed to widen column %s for table %s: %w", widenedColumn.Name,
+					schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] widened column %s from %s to %s", widenedColumn.Name,
+				oldColtype, sfColtype),
+				"destination table name", schemaDelta.DstTableName,
+				"source table name", schemaDelta.SrcTableName)
+		}
 	}
 
 	err = tableSchemaModifyTx.Commit()
@@ -410,6 +500,18 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 	return nil
 }
 
+func snowflakeColumnType(kind string, typmod int32) (string, error) {
+	if kind == string(qvalue.QValueKindNumeric) {
+		precision, scale := numeric.GetNumericTypeForWarehouse(typmod, numeric.SnowflakeNumericCompatibility{})
+		return fmt.Sprintf("NUMERIC(%d,%d)", precision, scale), nil
+	}
+	sfColtype, err := qvalue.QValueKind(kind).ToDWHColumnType(protos.DBType_SNOWFLAKE)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert column type %s to snowflake type: %w", kind, err)
+	}
+	return sfColtype, nil
+}
+
 func (c *SnowflakeConnector) withMirrorNameQueryTag(ctx context.Context, mirrorName string) context.Context {
 	return gosnowflake.WithQueryTag(ctx, "peerdb-mirror-"+mirrorName)
 }
@@ -441,6 +543,9 @@ func (c *SnowflakeConnector) syncRecordsViaAvro(
 ) (*model.SyncResponse, error) {
 	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
 	streamReq := model.NewRecordsToStreamRequest(req.Records.GetRecords(), tableNameRowsMapping, syncBatchID)
+	if err := utils.SetCollapseWindow(ctx, req, streamReq); err != nil {
+		return nil, err
+	}
 	stream, err := utils.RecordsToRawTableStream(streamReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
@@ -494,15 +599,48 @@ func (c *SnowflakeConnector) NormalizeRecords(ctx context.Context, req *model.No
 		}, nil
 	}
 
+	peerdbCols := &protos.PeerDBColumns{
+		SoftDeleteColName: req.SoftDeleteColName,
+		SyncedAtColName:   req.SyncedAtColName,
+	}
+	// changes buffered while quarantined or of replayed batches are merged again,
+	// without moving the normalized batch back
+	replayTables := utils.ReplayTablesToNormalize(req.ReplayTables, req.TruncatedTables, normBatchID)
+	for batchId := utils.EarliestReplayBatchID(replayTables, normBatchID) + 1; batchId <= normBatchID; batchId++ {
+		if slices.Contains(req.SkippedBatches, batchId) {
+			continue
+		}
+		c.logger.Info(fmt.Sprintf("normalizing records again for batch %d [of %d]", batchId, normBatchID))
+		if err := c.mergeTablesForBatch(ctx, batchId, req.FlowJobName, req.Env, req.TableNameSchemaMapping,
+			utils.TablesNotReplayed(req.TableNameSchemaMapping, replayTables, batchId), peerdbCols); err != nil {
+			return nil, err
+		}
+	}
+
+	truncatedTables := utils.TruncatedTablesToNormalize(req.TruncatedTables, normBatchID, req.SyncBatchID)
 	for batchId := normBatchID + 1; batchId <= req.SyncBatchID; batchId++ {
+		for _, tableName := range slices.Sorted(maps.Keys(truncatedTables)) {
+			if truncatedTables[tableName] != batchId {
+				continue
+			}
+			if truncateStmt := generateTruncateStmt(tableName, req.TruncatePolicy, peerdbCols); truncateStmt != "" {
+				if _, err := c.execWithLogging(ctx, truncateStmt); err != nil {
+					return nil, fmt.Errorf("failed to apply truncate to %s: %w", tableName, err)
+				}
+			}
+		}
+
+		if slices.Contains(req.SkippedBatches, batchId) {
+			c.logger.Warn("skipping batch", slog.Int64("batchId", batchId))
+			if err := c.UpdateNormalizeBatchID(ctx, req.FlowJobName, batchId); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		c.logger.Info(fmt.Sprintf("normalizing records for batch %d [of %d]", batchId, req.SyncBatchID))
 		mergeErr := c.mergeTablesForBatch(ctx, batchId,
-			req.FlowJobName, req.Env, req.TableNameSchemaMapping,
-			&protos.PeerDBColumns{
-				SoftDeleteColName: req.SoftDeleteColName,
-				SyncedAtColName:   req.SyncedAtColName,
-			},
-		)
+			req.FlowJobName, req.Env, req.TableNameSchemaMapping, req.SkippedTables, peerdbCols)
 		if mergeErr != nil {
 			return nil, mergeErr
 		}
@@ -526,12 +664,14 @@ func (c *SnowflakeConnector) mergeTablesForBatch(
 	flowName string,
 	env map[string]string,
 	tableToSchema map[string]*protos.TableSchema,
+	skippedTables map[string]struct{},
 	peerdbCols *protos.PeerDBColumns,
 ) error {
 	destinationTableNames, err := c.getDistinctTableNamesInBatch(ctx, flowName, batchId)
 	if err != nil {
 		return err
 	}
+	destinationTableNames = utils.WithoutSkippedTables(destinationTableNames, skippedTables)
 
 	tableNameToUnchangedToastCols, err := c.getTableNameToUnchangedCols(ctx, flowName, batchId)
 	if err != nil {
@@ -568,6 +708,7 @@ func (c *SnowflakeConnector) mergeTablesForBatch(
 			startTime := time.Now()
 			c.logger.Info("[merge] merging records...", "destTable", tableName, "batchId", batchId)
 
+			audit.Record(gCtx, mergeStatement)
 			result, err := c.database.ExecContext(gCtx, mergeStatement, tableName)
 			if err != nil {
 				return fmt.Errorf("failed to merge records into %s (statement: %s): %w",
@@ -727,10 +868,17 @@ func generateCreateTableSQLForNormalizedTable(
 		createSQL = createOrReplaceNormalizedTableSQL
 	}
 
-	return fmt.Sprintf(createSQL, snowflakeSchemaTableNormalize(dstSchemaTable),
+	stmt := fmt.Sprintf(createSQL, snowflakeSchemaTableNormalize(dstSchemaTable),
 		strings.Join(createTableSQLArray, ","))
+	if len(config.Labels) > 0 {
+		stmt += fmt.Sprintf(" COMMENT = '%s'",
+			snowflakeStringEscaper.Replace(shared.MirrorQueryTag(config.FlowName, config.Labels)))
+	}
+	return stmt
 }
 
+var snowflakeStringEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
 func getRawTableIdentifier(jobName string) string {
 	return rawTablePrefix + "_" + shared.ReplaceIllegalCharactersWithUnderscores(jobName)
 }
@@ -879,10 +1027,12 @@ func (c *SnowflakeConnector) CreateTablesFromExisting(ctx context.Context, req *
 
 func (c *SnowflakeConnector) execWithLogging(ctx context.Context, query string) (sql.Result, error) {
 	c.logger.Info("[snowflake] executing DDL statement", slog.String("query", query))
+	audit.Record(ctx, query)
 	return c.database.ExecContext(ctx, query)
 }
 
 func (c *SnowflakeConnector) execWithLoggingTx(ctx context.Context, query string, tx *sql.Tx) (sql.Result, error) {
 	c.logger.Info("[snowflake] executing DDL statement", slog.String("query", query))
+	audit.Record(ctx, query)
 	return tx.ExecContext(ctx, query)
 }
@@ -0,0 +1,81 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+)
+
+// snowflakeRegion turns CURRENT_REGION() like AWS_US_WEST_2, or PUBLIC.AWS_US_WEST_2 with a region group,
+// into the name the cloud of the account gives its region, us-west-2
+func snowflakeRegion(currentRegion string) string {
+	currentRegion = currentRegion[strings.LastIndexByte(currentRegion, '.')+1:]
+	_, region, _ := strings.Cut(currentRegion, "_")
+	return strings.ReplaceAll(strings.ToLower(region), "_", "-")
+}
+
+// StagingRegions are the region of the account, which internal stages and raw tables are in,
+// and the regions of the buckets of the external stage and of stagingPath
+func (c *SnowflakeConnector) StagingRegions(ctx context.Context, stagingPath string) ([]string, error) {
+	var currentRegion string
+	if err := c.database.QueryRowContext(ctx, "SELECT CURRENT_REGION()").Scan(&currentRegion); err != nil {
+		return nil, fmt.Errorf("failed to get region of account: %w", err)
+	}
+	regions := []string{snowflakeRegion(currentRegion)}
+
+	var bucketURLs []string
+	if c.config.GetExternalStage() != "" {
+		stageURL, err := c.externalStageURL(ctx)
+		if err != nil {
+			return nil, err
+		}
+		bucketURLs = append(bucketURLs, stageURL)
+	}
+	if strings.HasPrefix(stagingPath, "s3://") {
+		bucketURLs = append(bucketURLs, stagingPath)
+	}
+	for _, bucketURL := range bucketURLs {
+		region, err := bucketRegion(ctx, bucketURL)
+		if err != nil {
+			return nil, err
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
+// bucketRegion looks up the bucket of an s3:// or gcs:// URL with the credentials of PeerDB workers,
+// the ones files are staged with
+func bucketRegion(ctx context.Context, bucketURL string) (string, error) {
+	if bucketPath, isGCS := strings.CutPrefix(bucketURL, "gcs://"); isGCS {
+		bucket, _, _ := strings.Cut(bucketPath, "/")
+		client, err := storage.NewClient(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to create GCS client: %w", err)
+		}
+		defer client.Close()
+		attrs, err := client.Bucket(bucket).Attrs(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get location of bucket %s: %w", bucket, err)
+		}
+		return strings.ToLower(attrs.Location), nil
+	}
+
+	s3o, err := utils.NewS3BucketAndPrefix(bucketURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse bucket URL: %w", err)
+	}
+	provider, err := utils.GetAWSCredentialsProvider(ctx, "snowflake", utils.PeerAWSCredentials{})
+	if err != nil {
+		return "", err
+	}
+	region, err := utils.S3BucketRegion(ctx, provider, s3o.Bucket)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(region), nil
+}
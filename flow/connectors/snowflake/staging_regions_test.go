@@ -0,0 +1,14 @@
+package connsnowflake
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnowflakeRegion(t *testing.T) {
+	require.Equal(t, "us-west-2", snowflakeRegion("AWS_US_WEST_2"))
+	require.Equal(t, "us-west-2", snowflakeRegion("PUBLIC.AWS_US_WEST_2"))
+	require.Equal(t, "europe-west2", snowflakeRegion("GCP_EUROPE_WEST2"))
+	require.Equal(t, "eastus2", snowflakeRegion("AZURE_EASTUS2"))
+}
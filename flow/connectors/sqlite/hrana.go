@@ -0,0 +1,161 @@
+package connsqlite
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// hranaValue is a value of the Hrana protocol libSQL servers speak over HTTP, integers are sent as strings
+type hranaValue struct {
+	Value  any    `json:"value,omitempty"`
+	Type   string `json:"type"`
+	Base64 string `json:"base64,omitempty"`
+}
+
+type hranaStmt struct {
+	SQL      string       `json:"sql"`
+	Args     []hranaValue `json:"args,omitempty"`
+	WantRows bool         `json:"want_rows"`
+}
+
+type hranaStep struct {
+	Condition map[string]any `json:"condition,omitempty"`
+	Stmt      hranaStmt      `json:"stmt"`
+}
+
+func stepOK(step int) map[string]any {
+	return map[string]any{"type": "ok", "step": step}
+}
+
+type hranaError struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+type hranaStmtResult struct {
+	Cols []struct {
+		Name string `json:"name"`
+	} `json:"cols"`
+	Rows [][]hranaValue `json:"rows"`
+}
+
+type hranaPipelineResponse struct {
+	Results []struct {
+		Error    *hranaError `json:"error"`
+		Response struct {
+			Result struct {
+				StepErrors []*hranaError `json:"step_errors"`
+				hranaStmtResult
+			} `json:"result"`
+			Type string `json:"type"`
+		} `json:"response"`
+		Type string `json:"type"`
+	} `json:"results"`
+}
+
+func (v hranaValue) text() string {
+	switch v.Type {
+	case "blob":
+		data, _ := base64.StdEncoding.DecodeString(v.Base64)
+		return string(data)
+	case "null":
+		return ""
+	default:
+		return fmt.Sprint(v.Value)
+	}
+}
+
+// pipeline sends one request on a stream of its own, which the server closes afterwards
+func (c *SQLiteConnector) pipeline(ctx context.Context, request map[string]any) (*hranaPipelineResponse, error) {
+	body, err := json.Marshal(map[string]any{"requests": []map[string]any{request, {"type": "close"}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode libsql request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.pipelineURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create libsql request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PeerDB")
+	if c.config.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.AuthToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send libsql request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read libsql response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("libsql responded with status %d: %s", resp.StatusCode, respBody[:min(len(respBody), 1024)])
+	}
+	var result hranaPipelineResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse libsql response: %w", err)
+	}
+	if len(result.Results) == 0 {
+		return nil, errors.New("libsql response has no results")
+	}
+	if result.Results[0].Error != nil {
+		return nil, fmt.Errorf("libsql request failed: %s", result.Results[0].Error.Message)
+	}
+	return &result, nil
+}
+
+// query runs a statement on its own and returns its rows
+func (c *SQLiteConnector) query(ctx context.Context, sql string, args ...hranaValue) ([][]hranaValue, error) {
+	result, err := c.pipeline(ctx, map[string]any{
+		"type": "execute",
+		"stmt": hranaStmt{SQL: sql, Args: args, WantRows: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Results[0].Response.Result.Rows, nil
+}
+
+// execBatch runs statements in a transaction, later statements only run when earlier ones succeed
+// and the transaction is rolled back unless it commits
+func (c *SQLiteConnector) execBatch(ctx context.Context, stmts []hranaStmt) error {
+	if len(stmts) == 0 {
+		return nil
+	}
+	steps := make([]hranaStep, 0, len(stmts)+3)
+	steps = append(steps, hranaStep{Stmt: hranaStmt{SQL: "BEGIN IMMEDIATE"}})
+	for _, stmt := range stmts {
+		steps = append(steps, hranaStep{Condition: stepOK(len(steps) - 1), Stmt: stmt})
+	}
+	commitStep := len(steps)
+	steps = append(steps, hranaStep{Condition: stepOK(commitStep - 1), Stmt: hranaStmt{SQL: "COMMIT"}})
+	steps = append(steps, hranaStep{
+		Condition: map[string]any{"type": "not", "cond": stepOK(commitStep)},
+		Stmt:      hranaStmt{SQL: "ROLLBACK"},
+	})
+
+	result, err := c.pipeline(ctx, map[string]any{"type": "batch", "batch": map[string]any{"steps": steps}})
+	if err != nil {
+		return err
+	}
+	// steps after a failed one are skipped, so the first error is the cause
+	stepErrors := result.Results[0].Response.Result.StepErrors
+	for i, stepErr := range stepErrors[:min(len(stepErrors), commitStep+1)] {
+		if stepErr == nil {
+			continue
+		}
+		if i > 0 && i < commitStep {
+			return fmt.Errorf("statement %d of batch failed: %s", i-1, stepErr.Message)
+		}
+		return fmt.Errorf("transaction of batch failed: %s", stepErr.Message)
+	}
+	return nil
+}
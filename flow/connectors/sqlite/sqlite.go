@@ -0,0 +1,301 @@
+package connsqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const (
+	defaultBatchSize = 500
+	requestTimeout   = 2 * time.Minute
+)
+
+type SQLiteConnector struct {
+	*metadataStore.PostgresMetadata
+	client      *http.Client
+	config      *protos.SqliteConfig
+	logger      log.Logger
+	pipelineURL string
+	batchSize   int
+}
+
+func NewSQLiteConnector(ctx context.Context, config *protos.SqliteConfig) (*SQLiteConnector, error) {
+	endpoint, err := url.Parse(config.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid libsql url: %w", err)
+	}
+	if endpoint.Scheme == "libsql" {
+		endpoint.Scheme = "https"
+	}
+	if (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, errors.New("libsql url must be an absolute libsql, http or https url")
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := defaultBatchSize
+	if config.BatchSize > 0 {
+		batchSize = int(config.BatchSize)
+	}
+
+	return &SQLiteConnector{
+		PostgresMetadata: pgMetadata,
+		client:           &http.Client{Timeout: requestTimeout},
+		config:           config,
+		logger:           logger.LoggerFromCtx(ctx),
+		pipelineURL:      strings.TrimSuffix(endpoint.String(), "/") + "/v2/pipeline",
+		batchSize:        batchSize,
+	}, nil
+}
+
+func (c *SQLiteConnector) Close() error {
+	if c != nil {
+		c.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (c *SQLiteConnector) ConnectionActive(ctx context.Context) error {
+	_, err := c.query(ctx, "SELECT 1")
+	return err
+}
+
+func (c *SQLiteConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *SQLiteConnector) tableColumns(ctx context.Context, table string) (map[string]struct{}, error) {
+	rows, err := c.query(ctx, "SELECT name FROM pragma_table_info(?)", hranaValue{Type: "text", Value: table})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", table, err)
+	}
+	columns := make(map[string]struct{}, len(rows))
+	for _, row := range rows {
+		if len(row) > 0 {
+			columns[row[0].text()] = struct{}{}
+		}
+	}
+	return columns, nil
+}
+
+// ReplayTableSchemaDeltas adds columns missing from tables, SQLite has no ADD COLUMN IF NOT EXISTS
+func (c *SQLiteConnector) ReplayTableSchemaDeltas(ctx context.Context, flowJobName string, schemaDeltas []*protos.TableSchemaDelta) error {
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+			continue
+		}
+		existing, err := c.tableColumns(ctx, schemaDelta.DstTableName)
+		if err != nil {
+			return err
+		}
+		var stmts []hranaStmt
+		for _, column := range schemaDelta.AddedColumns {
+			if _, ok := existing[column.Name]; ok {
+				continue
+			}
+			stmts = append(stmts, hranaStmt{SQL: fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+				quoteIdentifier(schemaDelta.DstTableName), quoteIdentifier(column.Name),
+				columnType(qvalue.QValueKind(column.Type)))})
+		}
+		if err := c.execBatch(ctx, stmts); err != nil {
+			return fmt.Errorf("failed to add columns to %s: %w", schemaDelta.DstTableName, err)
+		}
+		c.logger.Info("[sqlite] added columns", slog.String("table", schemaDelta.DstTableName), slog.Int("columns", len(stmts)))
+	}
+	return nil
+}
+
+func (c *SQLiteConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *SQLiteConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *SQLiteConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (c *SQLiteConnector) tableExists(ctx context.Context, table string) (bool, error) {
+	rows, err := c.query(ctx, "SELECT 1 FROM sqlite_schema WHERE type = 'table' AND name = ?",
+		hranaValue{Type: "text", Value: table})
+	if err != nil {
+		return false, fmt.Errorf("failed to check if table %s exists: %w", table, err)
+	}
+	return len(rows) > 0, nil
+}
+
+func (c *SQLiteConnector) MissingNormalizedTables(ctx context.Context, tables []string) ([]string, error) {
+	return utils.MissingTables(ctx, tables, c.tableExists)
+}
+
+func (c *SQLiteConnector) SetupNormalizedTable(
+	ctx context.Context,
+	tx any,
+	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
+) (bool, error) {
+	exists, err := c.tableExists(ctx, tableIdentifier)
+	if err != nil {
+		return false, err
+	}
+	if exists && !config.IsResync {
+		c.logger.Info("[sqlite] normalized table already exists, skipping", slog.String("table", tableIdentifier))
+		return true, nil
+	}
+
+	t := newSQLiteTable(tableIdentifier, config.TableNameSchemaMapping[tableIdentifier],
+		utils.FindTableMapping(config.TableMappings, tableIdentifier), config.SoftDeleteColName, config.SyncedAtColName)
+	var stmts []hranaStmt
+	if exists {
+		stmts = append(stmts, hranaStmt{SQL: "DROP TABLE " + quoteIdentifier(tableIdentifier)})
+	}
+	stmts = append(stmts, hranaStmt{SQL: t.createStatement()})
+	if err := c.execBatch(ctx, stmts); err != nil {
+		return false, fmt.Errorf("[sqlite] error while creating normalized table: %w", err)
+	}
+	return false, nil
+}
+
+// SyncRecords applies changes to destination tables directly, a transaction per batch of statements.
+// Rows keep their last change, so a retry after a partial failure converges
+func (c *SQLiteConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	var numSkipped int64
+
+	tables := make(map[string]sqliteTable, len(req.TableMappings))
+	for _, tm := range req.TableMappings {
+		tables[tm.DestinationTableIdentifier] = newSQLiteTable(tm.DestinationTableIdentifier,
+			req.TableNameSchemaMapping[tm.DestinationTableIdentifier], tm, req.SoftDeleteColName, req.SyncedAtColName)
+	}
+
+	var stmts []hranaStmt
+	batcher := utils.NewRecordBatcher(c.logger, req, c.batchSize, c.SetLastOffset,
+		func(ctx context.Context, _ []model.Record[model.RecordItems]) error {
+			if err := c.execBatch(ctx, stmts); err != nil {
+				return fmt.Errorf("[sqlite] failed to apply changes: %w", err)
+			}
+			stmts = stmts[:0]
+			return nil
+		})
+
+	for record := range req.Records.GetRecords() {
+		table := record.GetDestinationTableName()
+		t, ok := tables[table]
+		if !ok {
+			continue
+		}
+		switch rec := record.(type) {
+		case *model.InsertRecord[model.RecordItems]:
+			stmts = append(stmts, t.upsertStatement(rec.Items.ColToVal, nil))
+		case *model.UpdateRecord[model.RecordItems]:
+			stmts = append(stmts, t.upsertStatement(rec.NewItems.ColToVal, rec.UnchangedToastColumns))
+		case *model.DeleteRecord[model.RecordItems]:
+			// rows of tables without a primary key cannot be told apart
+			if !t.hasPrimaryKey() {
+				numSkipped += 1
+				continue
+			}
+			stmts = append(stmts, t.deleteStatement(rec.Items.ColToVal))
+		default:
+			continue
+		}
+		if err := batcher.Add(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+	if err := batcher.Flush(ctx); err != nil {
+		return nil, err
+	}
+	if numSkipped > 0 {
+		c.logger.Info("[sqlite] skipped deletes of tables without a primary key", slog.Int64("deletes", numSkipped))
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[sqlite] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:     req.SyncBatchID,
+		LastSyncedCheckpointID: lastCheckpoint,
+		NumRecordsSynced:       batcher.NumRecords(),
+		TableNameRowsMapping:   batcher.TableNameRowsMapping(),
+		TableSchemaDeltas:      req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *SQLiteConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+// SyncQRepRecords creates the destination table from the partition's schema when missing. Upsert mode
+// replaces rows of the same key, so a retried partition writes the same rows
+func (c *SQLiteConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int, error) {
+	startTime := time.Now()
+	c.logger.Info("[sqlite] syncing partition",
+		slog.String(string(shared.PartitionIDKey), partition.PartitionId),
+		slog.String("destinationTable", config.DestinationTableIdentifier))
+
+	var keys []string
+	if config.WriteMode.GetWriteType() == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT {
+		keys = config.WriteMode.GetUpsertKeyColumns()
+	}
+	schema := stream.Schema()
+	t := newQRepTable(config.DestinationTableIdentifier, schema, keys, config.SyncedAtColName)
+	if err := c.execBatch(ctx, []hranaStmt{{SQL: t.createStatement()}}); err != nil {
+		return 0, fmt.Errorf("[sqlite] failed to create table %s: %w", config.DestinationTableIdentifier, err)
+	}
+	columns := schema.GetColumnNames()
+
+	numRecords := 0
+	var stmts []hranaStmt
+	for record := range stream.Records {
+		items := make(map[string]qvalue.QValue, len(columns))
+		for i, column := range columns {
+			items[column] = record[i]
+		}
+		stmts = append(stmts, t.upsertStatement(items, nil))
+		numRecords += 1
+		if len(stmts) >= c.batchSize {
+			if err := c.execBatch(ctx, stmts); err != nil {
+				return 0, fmt.Errorf("[sqlite] failed to write rows of %s: %w", config.DestinationTableIdentifier, err)
+			}
+			stmts = stmts[:0]
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	if err := c.execBatch(ctx, stmts); err != nil {
+		return 0, fmt.Errorf("[sqlite] failed to write rows of %s: %w", config.DestinationTableIdentifier, err)
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
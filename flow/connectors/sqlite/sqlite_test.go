@@ -0,0 +1,107 @@
+package connsqlite
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func testTable() sqliteTable {
+	return newSQLiteTable("public.users", &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(qvalue.QValueKindInt64)},
+			{Name: "name", Type: string(qvalue.QValueKindString)},
+			{Name: "bio", Type: string(qvalue.QValueKindString)},
+		},
+		PrimaryKeyColumns: []string{"id"},
+	}, &protos.TableMapping{
+		Columns: []*protos.ColumnSetting{{SourceName: "name", DestinationName: "full_name"}},
+	}, "_deleted", "_synced_at")
+}
+
+func TestStatements(t *testing.T) {
+	table := testTable()
+	require.Equal(t, `CREATE TABLE IF NOT EXISTS "public.users" ("id" INTEGER NOT NULL,"full_name" TEXT,"bio" TEXT,`+
+		`"_deleted" INTEGER NOT NULL DEFAULT 0,"_synced_at" TEXT,PRIMARY KEY ("id"))`, table.createStatement())
+
+	items := map[string]qvalue.QValue{
+		"id":   qvalue.QValueInt64{Val: 1},
+		"name": qvalue.QValueString{Val: "Ada"},
+	}
+	upsert := table.upsertStatement(items, map[string]struct{}{"bio": {}})
+	require.Equal(t, `INSERT INTO "public.users" ("id","full_name","_deleted","_synced_at") VALUES (?,?,0,`+nowExpr+`) `+
+		`ON CONFLICT ("id") DO UPDATE SET "full_name"=excluded."full_name","_deleted"=0,"_synced_at"=`+nowExpr, upsert.SQL)
+	require.Equal(t, []hranaValue{{Type: "integer", Value: "1"}, {Type: "text", Value: "Ada"}}, upsert.Args)
+
+	del := table.deleteStatement(items)
+	require.Equal(t, `UPDATE "public.users" SET "_deleted"=1,"_synced_at"=`+nowExpr+` WHERE "id"=?`, del.SQL)
+
+	table.softDeleteColName = ""
+	require.Equal(t, `DELETE FROM "public.users" WHERE "id"=?`, table.deleteStatement(items).SQL)
+}
+
+func TestSQLiteValue(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	require.Equal(t, hranaValue{Type: "integer", Value: "1"}, sqliteValue(qvalue.QValueBoolean{Val: true}))
+	require.Equal(t, hranaValue{Type: "null"}, sqliteValue(qvalue.QValueFloat64{Val: math.NaN()}))
+	require.Equal(t, hranaValue{Type: "null"}, sqliteValue(qvalue.QValueNull(qvalue.QValueKindString)))
+	require.Equal(t, hranaValue{Type: "text", Value: "10.5"},
+		sqliteValue(qvalue.QValueNumeric{Val: decimal.RequireFromString("10.50")}))
+	require.Equal(t, hranaValue{Type: "text", Value: "2024-01-02 03:04:05.6Z"}, sqliteValue(qvalue.QValueTimestampTZ{Val: ts}))
+	require.Equal(t, hranaValue{Type: "text", Value: "2024-01-02"}, sqliteValue(qvalue.QValueDate{Val: ts}))
+	require.Equal(t, hranaValue{Type: "blob", Base64: "AQI="}, sqliteValue(qvalue.QValueBytes{Val: []byte{1, 2}}))
+}
+
+func TestExecBatch(t *testing.T) {
+	var steps []map[string]any
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v2/pipeline", r.URL.Path)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var body struct {
+			Requests []struct {
+				Batch struct {
+					Steps []map[string]any `json:"steps"`
+				} `json:"batch"`
+			} `json:"requests"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		steps = body.Requests[0].Batch.Steps
+		stepErrors := make([]any, len(steps))
+		if fail {
+			stepErrors[1] = map[string]any{"message": "UNIQUE constraint failed"}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"results": []any{
+			map[string]any{"type": "ok", "response": map[string]any{
+				"type": "batch", "result": map[string]any{"step_errors": stepErrors},
+			}},
+			map[string]any{"type": "ok"},
+		}}))
+	}))
+	defer server.Close()
+
+	c := &SQLiteConnector{
+		client:      server.Client(),
+		config:      &protos.SqliteConfig{Url: server.URL, AuthToken: "token"},
+		pipelineURL: server.URL + "/v2/pipeline",
+	}
+	require.NoError(t, c.execBatch(context.Background(), []hranaStmt{{SQL: "INSERT INTO t VALUES (1)"}}))
+	require.Len(t, steps, 4)
+	require.Equal(t, "BEGIN IMMEDIATE", steps[0]["stmt"].(map[string]any)["sql"])
+	require.Equal(t, map[string]any{"type": "ok", "step": 1.0}, steps[2]["condition"])
+	require.Equal(t, "ROLLBACK", steps[3]["stmt"].(map[string]any)["sql"])
+
+	fail = true
+	require.ErrorContains(t, c.execBatch(context.Background(), []hranaStmt{{SQL: "INSERT INTO t VALUES (1)"}}),
+		"statement 0 of batch failed: UNIQUE constraint failed")
+}
@@ -0,0 +1,258 @@
+package connsqlite
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+const nowExpr = "strftime('%Y-%m-%d %H:%M:%fZ','now')"
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+type sqliteColumn struct {
+	source     string
+	name       string
+	sqliteType string
+	primaryKey bool
+}
+
+// sqliteTable has what statements applying changes to a destination table need.
+// SQLite has no schemas, so the destination table identifier is the table name as a whole
+type sqliteTable struct {
+	name              string
+	columns           []sqliteColumn
+	softDeleteColName string
+	syncedAtColName   string
+}
+
+func columnType(kind qvalue.QValueKind) string {
+	sqliteType, _ := kind.ToDWHColumnType(protos.DBType_SQLITE)
+	return sqliteType
+}
+
+func newSQLiteTable(
+	name string,
+	schema *protos.TableSchema,
+	tableMapping *protos.TableMapping,
+	softDeleteColName string,
+	syncedAtColName string,
+) sqliteTable {
+	t := sqliteTable{
+		name:              name,
+		columns:           make([]sqliteColumn, 0, len(schema.GetColumns())),
+		softDeleteColName: softDeleteColName,
+		syncedAtColName:   syncedAtColName,
+	}
+	for _, column := range schema.GetColumns() {
+		col := sqliteColumn{
+			source:     column.Name,
+			name:       column.Name,
+			primaryKey: slices.Contains(schema.GetPrimaryKeyColumns(), column.Name),
+		}
+		for _, mapped := range tableMapping.GetColumns() {
+			if mapped.SourceName == column.Name {
+				if mapped.DestinationName != "" {
+					col.name = mapped.DestinationName
+				}
+				col.sqliteType = mapped.DestinationType
+				break
+			}
+		}
+		if col.sqliteType == "" {
+			col.sqliteType = columnType(qvalue.QValueKind(column.Type))
+		}
+		t.columns = append(t.columns, col)
+	}
+	return t
+}
+
+// newQRepTable is the table of a partition's schema, keyed by keys
+func newQRepTable(name string, schema qvalue.QRecordSchema, keys []string, syncedAtColName string) sqliteTable {
+	t := sqliteTable{
+		name:            name,
+		columns:         make([]sqliteColumn, 0, len(schema.Fields)),
+		syncedAtColName: syncedAtColName,
+	}
+	for _, field := range schema.Fields {
+		t.columns = append(t.columns, sqliteColumn{
+			source:     field.Name,
+			name:       field.Name,
+			sqliteType: columnType(field.Type),
+			primaryKey: slices.Contains(keys, field.Name),
+		})
+	}
+	return t
+}
+
+func (t sqliteTable) hasPrimaryKey() bool {
+	return slices.ContainsFunc(t.columns, func(column sqliteColumn) bool { return column.primaryKey })
+}
+
+func (t sqliteTable) createStatement() string {
+	definitions := make([]string, 0, len(t.columns)+3)
+	var keys []string
+	for _, column := range t.columns {
+		definition := quoteIdentifier(column.name) + " " + column.sqliteType
+		if column.primaryKey {
+			definition += " NOT NULL"
+			keys = append(keys, quoteIdentifier(column.name))
+		}
+		definitions = append(definitions, definition)
+	}
+	if t.softDeleteColName != "" {
+		definitions = append(definitions, quoteIdentifier(t.softDeleteColName)+" INTEGER NOT NULL DEFAULT 0")
+	}
+	if t.syncedAtColName != "" {
+		definitions = append(definitions, quoteIdentifier(t.syncedAtColName)+" TEXT")
+	}
+	if len(keys) > 0 {
+		definitions = append(definitions, "PRIMARY KEY ("+strings.Join(keys, ",")+")")
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quoteIdentifier(t.name), strings.Join(definitions, ","))
+}
+
+// upsertStatement inserts a row, replacing columns of an existing row of the same key.
+// Unchanged TOAST columns are left out, so existing rows keep them
+func (t sqliteTable) upsertStatement(items map[string]qvalue.QValue, unchangedToastColumns map[string]struct{}) hranaStmt {
+	insertColumns := make([]string, 0, len(t.columns)+2)
+	values := make([]string, 0, len(t.columns)+2)
+	args := make([]hranaValue, 0, len(t.columns))
+	var keys []string
+	var set []string
+	for _, column := range t.columns {
+		if _, ok := unchangedToastColumns[column.source]; ok {
+			continue
+		}
+		name := quoteIdentifier(column.name)
+		insertColumns = append(insertColumns, name)
+		values = append(values, "?")
+		args = append(args, sqliteValue(items[column.source]))
+		if column.primaryKey {
+			keys = append(keys, name)
+		} else {
+			set = append(set, name+"=excluded."+name)
+		}
+	}
+	if t.softDeleteColName != "" {
+		name := quoteIdentifier(t.softDeleteColName)
+		insertColumns = append(insertColumns, name)
+		values = append(values, "0")
+		set = append(set, name+"=0")
+	}
+	if t.syncedAtColName != "" {
+		name := quoteIdentifier(t.syncedAtColName)
+		insertColumns = append(insertColumns, name)
+		values = append(values, nowExpr)
+		set = append(set, name+"="+nowExpr)
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdentifier(t.name), strings.Join(insertColumns, ","), strings.Join(values, ","))
+	if len(keys) > 0 {
+		if len(set) > 0 {
+			sql += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keys, ","), strings.Join(set, ","))
+		} else {
+			sql += fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(keys, ","))
+		}
+	}
+	return hranaStmt{SQL: sql, Args: args}
+}
+
+// deleteStatement removes the row of the key of items, or marks it deleted with a soft delete column
+func (t sqliteTable) deleteStatement(items map[string]qvalue.QValue) hranaStmt {
+	conditions := make([]string, 0, 1)
+	var args []hranaValue
+	for _, column := range t.columns {
+		if column.primaryKey {
+			conditions = append(conditions, quoteIdentifier(column.name)+"=?")
+			args = append(args, sqliteValue(items[column.source]))
+		}
+	}
+	where := strings.Join(conditions, " AND ")
+	if t.softDeleteColName == "" {
+		return hranaStmt{SQL: fmt.Sprintf("DELETE FROM %s WHERE %s", quoteIdentifier(t.name), where), Args: args}
+	}
+	set := quoteIdentifier(t.softDeleteColName) + "=1"
+	if t.syncedAtColName != "" {
+		set += "," + quoteIdentifier(t.syncedAtColName) + "=" + nowExpr
+	}
+	return hranaStmt{SQL: fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdentifier(t.name), set, where), Args: args}
+}
+
+// sqliteValue is the value bound for a value, times are ISO 8601 text and types without storage class
+// of their own, like numerics and arrays, are text too
+func sqliteValue(qv qvalue.QValue) hranaValue {
+	if qv == nil {
+		return hranaValue{Type: "null"}
+	}
+	switch v := qv.Value().(type) {
+	case nil:
+		return hranaValue{Type: "null"}
+	case bool:
+		if v {
+			return hranaValue{Type: "integer", Value: "1"}
+		}
+		return hranaValue{Type: "integer", Value: "0"}
+	case int8:
+		return hranaValue{Type: "integer", Value: strconv.FormatInt(int64(v), 10)}
+	case int16:
+		return hranaValue{Type: "integer", Value: strconv.FormatInt(int64(v), 10)}
+	case int32:
+		return hranaValue{Type: "integer", Value: strconv.FormatInt(int64(v), 10)}
+	case int64:
+		return hranaValue{Type: "integer", Value: strconv.FormatInt(v, 10)}
+	case float32:
+		return floatValue(float64(v))
+	case float64:
+		return floatValue(v)
+	case string:
+		return hranaValue{Type: "text", Value: v}
+	case uint8:
+		return hranaValue{Type: "text", Value: string(rune(v))}
+	case decimal.Decimal:
+		return hranaValue{Type: "text", Value: v.String()}
+	case [16]byte:
+		return hranaValue{Type: "text", Value: uuid.UUID(v).String()}
+	case []byte:
+		return hranaValue{Type: "blob", Base64: base64.StdEncoding.EncodeToString(v)}
+	case time.Time:
+		switch qv.Kind() {
+		case qvalue.QValueKindDate:
+			return hranaValue{Type: "text", Value: v.Format(time.DateOnly)}
+		case qvalue.QValueKindTime, qvalue.QValueKindTimeTZ:
+			return hranaValue{Type: "text", Value: v.Format("15:04:05.999999")}
+		case qvalue.QValueKindTimestamp:
+			return hranaValue{Type: "text", Value: v.Format("2006-01-02 15:04:05.999999")}
+		default:
+			return hranaValue{Type: "text", Value: v.UTC().Format("2006-01-02 15:04:05.999999Z")}
+		}
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return hranaValue{Type: "text", Value: fmt.Sprint(v)}
+		}
+		return hranaValue{Type: "text", Value: string(data)}
+	}
+}
+
+// floatValue stores NaN and infinities, which REAL cannot hold, as null
+func floatValue(v float64) hranaValue {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return hranaValue{Type: "null"}
+	}
+	return hranaValue{Type: "float", Value: v}
+}
@@ -34,6 +34,9 @@ func (c *SQLServerConnector) GetQRepPartitions(
 		return nil, errors.New("num rows per partition must be greater than 0 for sql server")
 	}
 
+	ctx, cancel := c.withStatementTimeout(ctx)
+	defer cancel()
+
 	var err error
 	numRowsPerPartition := int64(config.NumRowsPerPartition)
 	quotedWatermarkColumn := fmt.Sprintf("\"%s\"", config.WatermarkColumn)
@@ -170,6 +173,8 @@ func (c *SQLServerConnector) PullQRepRecords(
 	if err != nil {
 		return 0, err
 	}
+	ctx, cancel := c.withStatementTimeout(ctx)
+	defer cancel()
 
 	if partition.FullTablePartition {
 		// this is a full table partition, so just run the query
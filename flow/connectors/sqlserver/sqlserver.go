@@ -3,6 +3,7 @@ package connsqlserver
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/microsoft/go-mssqldb"
@@ -11,14 +12,16 @@ import (
 	peersql "github.com/PeerDB-io/peer-flow/connectors/sql"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
 )
 
 type SQLServerConnector struct {
 	peersql.GenericSQLQueryExecutor
 
-	config *protos.SqlServerConfig
-	db     *sqlx.DB
-	logger log.Logger
+	config           *protos.SqlServerConfig
+	db               *sqlx.DB
+	logger           log.Logger
+	statementTimeout time.Duration
 }
 
 // NewSQLServerConnector creates a new SQL Server connection
@@ -36,6 +39,11 @@ func NewSQLServerConnector(ctx context.Context, config *protos.SqlServerConfig)
 		return nil, err
 	}
 
+	statementTimeout, err := peerdbenv.PeerDBStatementTimeout(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement timeout: %w", err)
+	}
+
 	logger := logger.LoggerFromCtx(ctx)
 
 	genericExecutor := *peersql.NewGenericSQLQueryExecutor(
@@ -46,9 +54,19 @@ func NewSQLServerConnector(ctx context.Context, config *protos.SqlServerConfig)
 		config:                  config,
 		db:                      db,
 		logger:                  logger,
+		statementTimeout:        statementTimeout,
 	}, nil
 }
 
+// withStatementTimeout bounds queries run with the returned context, SQL Server has no setting for it
+// but the driver sends an attention to the server for queries of canceled contexts
+func (c *SQLServerConnector) withStatementTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.statementTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.statementTimeout)
+}
+
 // Close closes the database connection
 func (c *SQLServerConnector) Close() error {
 	if c != nil {
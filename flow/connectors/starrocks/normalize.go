@@ -0,0 +1,329 @@
+package connstarrocks
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func (c *StarRocksConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *StarRocksConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *StarRocksConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (c *StarRocksConnector) MissingNormalizedTables(ctx context.Context, tables []string) ([]string, error) {
+	return utils.MissingTables(ctx, tables, c.tableExists)
+}
+
+func (c *StarRocksConnector) SetupNormalizedTable(
+	ctx context.Context,
+	tx any,
+	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
+) (bool, error) {
+	exists, err := c.tableExists(ctx, tableIdentifier)
+	if err != nil {
+		return false, err
+	}
+	if exists && !config.IsResync {
+		c.logger.Info("[starrocks] normalized table already exists, skipping", slog.String("table", tableIdentifier))
+		return true, nil
+	}
+	if exists {
+		if err := c.execWithLogging(ctx, "DROP TABLE "+quoteTable(tableIdentifier)); err != nil {
+			return false, fmt.Errorf("[starrocks] error while dropping normalized table for resync: %w", err)
+		}
+	}
+
+	columns, err := c.normalizedColumns(config.TableNameSchemaMapping[tableIdentifier],
+		utils.FindTableMapping(config.TableMappings, tableIdentifier))
+	if err != nil {
+		return false, err
+	}
+	if err := c.execWithLogging(ctx, c.createTableQuery(tableIdentifier, columns,
+		config.SoftDeleteColName, config.SyncedAtColName)); err != nil {
+		return false, fmt.Errorf("[starrocks] error while creating normalized table: %w", err)
+	}
+	return false, nil
+}
+
+// createTableQuery puts key columns first, as both engines require of key columns
+func (c *StarRocksConnector) createTableQuery(
+	tableIdentifier string,
+	columns []normalizedColumn,
+	softDeleteColName string,
+	syncedAtColName string,
+) string {
+	definitions := make([]string, 0, len(columns)+2)
+	var keys []string
+	for _, column := range columns {
+		if column.primaryKey {
+			definitions = append(definitions, quoteIdentifier(column.name)+" "+column.starrocksType+" NOT NULL")
+			keys = append(keys, quoteIdentifier(column.name))
+		}
+	}
+	for _, column := range columns {
+		if !column.primaryKey {
+			definitions = append(definitions, quoteIdentifier(column.name)+" "+column.starrocksType)
+		}
+	}
+	if softDeleteColName != "" {
+		definitions = append(definitions, quoteIdentifier(softDeleteColName)+` BOOLEAN NOT NULL DEFAULT "0"`)
+	}
+	if syncedAtColName != "" {
+		definitions = append(definitions, quoteIdentifier(syncedAtColName)+" "+c.datetimeType())
+	}
+
+	var model string
+	if len(keys) > 0 {
+		if c.isDoris() {
+			model = " UNIQUE KEY(" + strings.Join(keys, ",") + ")"
+		} else {
+			model = " PRIMARY KEY(" + strings.Join(keys, ",") + ")"
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)%s%s%s", quoteTable(tableIdentifier), strings.Join(definitions, ","),
+		model, c.distribution(keys), c.tableProperties(len(keys) > 0))
+}
+
+type normalizedColumn struct {
+	source        string
+	name          string
+	starrocksType string
+	kind          qvalue.QValueKind
+	primaryKey    bool
+}
+
+// columnType is the type of columns of kind, key columns need a bounded length
+func (c *StarRocksConnector) columnType(kind qvalue.QValueKind, typeModifier int32, primaryKey bool) (string, error) {
+	if kind == qvalue.QValueKindNumeric {
+		precision, scale := datatypes.GetNumericTypeForWarehouse(typeModifier, datatypes.DefaultNumericCompatibility{})
+		return fmt.Sprintf("DECIMAL(%d, %d)", precision, scale), nil
+	}
+	starrocksType, err := kind.ToDWHColumnType(protos.DBType_STARROCKS)
+	if err != nil {
+		return "", fmt.Errorf("error while converting column type to starrocks type: %w", err)
+	}
+	switch {
+	case starrocksType == "DATETIME":
+		return c.datetimeType(), nil
+	case primaryKey && starrocksType == "STRING":
+		return "VARCHAR(128)", nil
+	default:
+		return starrocksType, nil
+	}
+}
+
+func (c *StarRocksConnector) normalizedColumns(schema *protos.TableSchema, tableMapping *protos.TableMapping) ([]normalizedColumn, error) {
+	columns := make([]normalizedColumn, 0, len(schema.Columns))
+	for _, column := range schema.Columns {
+		normalized := normalizedColumn{
+			source:     column.Name,
+			name:       column.Name,
+			kind:       qvalue.QValueKind(column.Type),
+			primaryKey: slices.Contains(schema.PrimaryKeyColumns, column.Name),
+		}
+		if tableMapping != nil {
+			for _, col := range tableMapping.Columns {
+				if col.SourceName == column.Name {
+					if col.DestinationName != "" {
+						normalized.name = col.DestinationName
+					}
+					normalized.starrocksType = col.DestinationType
+					break
+				}
+			}
+		}
+		if normalized.starrocksType == "" {
+			starrocksType, err := c.columnType(normalized.kind, column.TypeModifier, normalized.primaryKey)
+			if err != nil {
+				return nil, err
+			}
+			normalized.starrocksType = starrocksType
+		}
+		columns = append(columns, normalized)
+	}
+	return columns, nil
+}
+
+func jsonPath(column string) string {
+	return quoteString(`$."` + strings.ReplaceAll(column, `"`, `\"`) + `"`)
+}
+
+// extractExpr reads a column out of _peerdb_data as the value to store, get_json_string is null for JSON null
+func extractExpr(column normalizedColumn) string {
+	value := "get_json_string(_peerdb_data," + jsonPath(column.source) + ")"
+	switch column.kind {
+	case qvalue.QValueKindBoolean:
+		return value + " = 'true'"
+	case qvalue.QValueKindTimestampTZ:
+		// formatted with a -0700 offset, converted to UTC as DATETIME has none
+		return fmt.Sprintf("CAST(convert_tz(left(%[1]s,length(%[1]s)-5),"+
+			"concat(substr(%[1]s,length(%[1]s)-4,3),':',right(%[1]s,2)),'+00:00') AS %[2]s)", value, column.starrocksType)
+	default:
+		return "CAST(" + value + " AS " + column.starrocksType + ")"
+	}
+}
+
+type normalizeStmtGenerator struct {
+	rawTableName      string
+	dstTableName      string
+	softDeleteColName string
+	syncedAtColName   string
+	columns           []normalizedColumn
+	startBatchID      int64
+	syncBatchID       int64
+}
+
+func (g *normalizeStmtGenerator) batchCondition() string {
+	return fmt.Sprintf("_peerdb_batch_id > %d AND _peerdb_batch_id <= %d AND _peerdb_destination_table_name = %s",
+		g.startBatchID, g.syncBatchID, quoteString(g.dstTableName))
+}
+
+// latestRowsQuery has the last change of every key in the batches
+func (g *normalizeStmtGenerator) latestRowsQuery() string {
+	projection := make([]string, 0, len(g.columns)+2)
+	partitionBy := make([]string, 0, len(g.columns))
+	for _, column := range g.columns {
+		projection = append(projection, extractExpr(column)+" AS "+quoteIdentifier(column.name))
+		if column.primaryKey {
+			partitionBy = append(partitionBy, "get_json_string(_peerdb_data,"+jsonPath(column.source)+")")
+		}
+	}
+	projection = append(projection, "_peerdb_record_type", "_peerdb_unchanged_toast_columns")
+
+	return fmt.Sprintf("SELECT %s FROM (SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,"+
+		"ROW_NUMBER() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank FROM %s WHERE %s) r"+
+		" WHERE _peerdb_rank = 1",
+		strings.Join(projection, ","), strings.Join(partitionBy, ","), quoteTable(g.rawTableName), g.batchCondition())
+}
+
+// keyCondition joins rows of the latest changes s to the destination, referred to as target
+func (g *normalizeStmtGenerator) keyCondition(target string) string {
+	conditions := make([]string, 0, len(g.columns))
+	for _, column := range g.columns {
+		if column.primaryKey {
+			name := quoteIdentifier(column.name)
+			conditions = append(conditions, target+"."+name+" = s."+name)
+		}
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// statements applies deletes, then inserts the remaining keys, which replaces rows of the same key in
+// primary key tables, unchanged TOAST columns being taken from the existing row
+func (g *normalizeStmtGenerator) statements() []string {
+	dst := quoteTable(g.dstTableName)
+	latest := g.latestRowsQuery()
+
+	var deleteStmt string
+	if g.softDeleteColName != "" {
+		set := quoteIdentifier(g.softDeleteColName) + " = TRUE"
+		if g.syncedAtColName != "" {
+			set += "," + quoteIdentifier(g.syncedAtColName) + " = now()"
+		}
+		deleteStmt = fmt.Sprintf("UPDATE %s SET %s FROM (%s) s WHERE %s AND s._peerdb_record_type = 2",
+			dst, set, latest, g.keyCondition(dst))
+	} else {
+		deleteStmt = fmt.Sprintf("DELETE FROM %s USING (%s) s WHERE %s AND s._peerdb_record_type = 2",
+			dst, latest, g.keyCondition(dst))
+	}
+
+	insertColumns := make([]string, 0, len(g.columns)+2)
+	values := make([]string, 0, len(g.columns)+2)
+	for _, column := range g.columns {
+		name := quoteIdentifier(column.name)
+		insertColumns = append(insertColumns, name)
+		if column.primaryKey {
+			values = append(values, "s."+name)
+			continue
+		}
+		values = append(values, fmt.Sprintf("if(find_in_set(%s,s._peerdb_unchanged_toast_columns) > 0,t.%s,s.%s)",
+			quoteString(column.source), name, name))
+	}
+	if g.softDeleteColName != "" {
+		insertColumns = append(insertColumns, quoteIdentifier(g.softDeleteColName))
+		values = append(values, "FALSE")
+	}
+	if g.syncedAtColName != "" {
+		insertColumns = append(insertColumns, quoteIdentifier(g.syncedAtColName))
+		values = append(values, "now()")
+	}
+
+	upsert := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM (%s) s LEFT JOIN %s t ON %s WHERE s._peerdb_record_type != 2",
+		dst, strings.Join(insertColumns, ","), strings.Join(values, ","), latest, dst, g.keyCondition("t"))
+	return []string{deleteStmt, upsert}
+}
+
+func (g *normalizeStmtGenerator) appendStatement() string {
+	stmt := &utils.AppendStatement{
+		QuoteIdentifier:   quoteIdentifier,
+		DstTable:          quoteTable(g.dstTableName),
+		From:              quoteTable(g.rawTableName) + " WHERE " + g.batchCondition() + " AND _peerdb_record_type != 2",
+		Columns:           make([]utils.AppendColumn, 0, len(g.columns)),
+		SoftDeleteColName: g.softDeleteColName,
+		NotDeleted:        "FALSE",
+		SyncedAtColName:   g.syncedAtColName,
+		Now:               "now()",
+	}
+	for _, column := range g.columns {
+		stmt.Columns = append(stmt.Columns, utils.AppendColumn{Name: column.name, Expr: extractExpr(column)})
+	}
+	return stmt.SQL()
+}
+
+// NormalizeRecords runs statements one by one as neither engine has multi statement transactions,
+// replaying a batch range is idempotent, so a retry after a partial failure converges
+func (c *StarRocksConnector) NormalizeRecords(ctx context.Context, req *model.NormalizeRecordsRequest) (*model.NormalizeResponse, error) {
+	rawTableName := c.getRawTableName(req.FlowJobName)
+	normalizer := &utils.SQLNormalizer{
+		Logger:     c.logger,
+		Name:       "starrocks",
+		DB:         c.db,
+		Metadata:   c,
+		QuoteTable: quoteTable,
+		Exec:       c.execWithLogging,
+		TableStatements: func(tbl string, tableMapping *protos.TableMapping, startBatchID int64) ([]string, error) {
+			columns, err := c.normalizedColumns(req.TableNameSchemaMapping[tbl], tableMapping)
+			if err != nil {
+				return nil, err
+			}
+			generator := &normalizeStmtGenerator{
+				rawTableName:      rawTableName,
+				dstTableName:      tbl,
+				softDeleteColName: req.SoftDeleteColName,
+				syncedAtColName:   req.SyncedAtColName,
+				columns:           columns,
+				startBatchID:      startBatchID,
+				syncBatchID:       req.SyncBatchID,
+			}
+			if len(req.TableNameSchemaMapping[tbl].PrimaryKeyColumns) == 0 {
+				return []string{generator.appendStatement()}, nil
+			}
+			return generator.statements(), nil
+		},
+		ExecTable: func(ctx context.Context, stmts []string) error {
+			for _, stmt := range stmts {
+				if err := c.execWithLogging(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	return normalizer.NormalizeRecords(ctx, req, rawTableName)
+}
@@ -0,0 +1,39 @@
+package connstarrocks
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+func (c *StarRocksConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+// SyncQRepRecords labels loads by partition, so a retried partition is not loaded twice
+func (c *StarRocksConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int, error) {
+	startTime := time.Now()
+	c.logger.Info("[starrocks] syncing partition",
+		slog.String(string(shared.PartitionIDKey), partition.PartitionId),
+		slog.String("destinationTable", config.DestinationTableIdentifier))
+
+	numRecords, err := c.loadStream(ctx, config.DestinationTableIdentifier,
+		streamLoadLabel("peerdb", config.FlowJobName, partition.PartitionId), stream)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
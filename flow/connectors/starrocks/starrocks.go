@@ -0,0 +1,200 @@
+package connstarrocks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+)
+
+// StarRocksConnector loads batches into StarRocks or Apache Doris with Stream Load over HTTP,
+// running statements over the MySQL protocol both speak. Tables with a primary key are created
+// with the primary key model, merge on write unique key for Doris, so inserts upsert by key
+type StarRocksConnector struct {
+	*metadataStore.PostgresMetadata
+	db         *sql.DB
+	httpClient *http.Client
+	config     *protos.StarRocksConfig
+	logger     log.Logger
+}
+
+func NewStarRocksConnector(ctx context.Context, config *protos.StarRocksConfig) (*StarRocksConnector, error) {
+	port := config.Port
+	if port == 0 {
+		port = 9030
+	}
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = net.JoinHostPort(config.Host, strconv.FormatUint(uint64(port), 10))
+	mysqlConfig.User = config.User
+	mysqlConfig.Passwd = config.Password
+	mysqlConfig.DBName = config.Database
+	// the frontend does not support server side prepared statements
+	mysqlConfig.InterpolateParams = true
+	if !config.DisableTls {
+		mysqlConfig.TLSConfig = "true"
+	}
+	connector, err := mysql.NewConnector(mysqlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create starrocks connector: %w", err)
+	}
+	db := sql.OpenDB(connector)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to starrocks: %w", err)
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &StarRocksConnector{
+		PostgresMetadata: pgMetadata,
+		db:               db,
+		httpClient:       newStreamLoadClient(config),
+		config:           config,
+		logger:           logger.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func (c *StarRocksConnector) Close() error {
+	if c != nil {
+		c.httpClient.CloseIdleConnections()
+		return c.db.Close()
+	}
+	return nil
+}
+
+func (c *StarRocksConnector) ConnectionActive(ctx context.Context) error {
+	if err := c.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping starrocks: %w", err)
+	}
+	return nil
+}
+
+func (c *StarRocksConnector) isDoris() bool {
+	return c.config.Flavor == protos.StarRocksFlavor_STARROCKS_FLAVOR_DORIS
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
+
+// quoteTable quotes database qualified names, unqualified names are in the database of the peer
+func quoteTable(table string) string {
+	if schemaTable, err := utils.ParseSchemaTable(table); err == nil {
+		return quoteIdentifier(schemaTable.Schema) + "." + quoteIdentifier(schemaTable.Table)
+	}
+	return quoteIdentifier(table)
+}
+
+func (c *StarRocksConnector) splitTable(table string) (string, string) {
+	if schemaTable, err := utils.ParseSchemaTable(table); err == nil {
+		return schemaTable.Schema, schemaTable.Table
+	}
+	return c.config.Database, table
+}
+
+func (c *StarRocksConnector) execWithLogging(ctx context.Context, query string) error {
+	c.logger.Info("[starrocks] executing statement", slog.String("query", audit.Redact(query)))
+	audit.Record(ctx, query)
+	_, err := c.db.ExecContext(ctx, query)
+	return err
+}
+
+func (c *StarRocksConnector) tableExists(ctx context.Context, table string) (bool, error) {
+	database, name := c.splitTable(table)
+	var exists int
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+		database, name,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check if table %s exists: %w", table, err)
+	}
+	return exists > 0, nil
+}
+
+func (c *StarRocksConnector) columnExists(ctx context.Context, table string, column string) (bool, error) {
+	database, name := c.splitTable(table)
+	var exists int
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = ? AND table_name = ? AND column_name = ?",
+		database, name, column,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check if column %s of %s exists: %w", column, table, err)
+	}
+	return exists > 0, nil
+}
+
+// columnNullable returns whether column of table exists and is nullable
+func (c *StarRocksConnector) columnNullable(ctx context.Context, table string, column string) (bool, bool, error) {
+	database, name := c.splitTable(table)
+	var isNullable string
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT is_nullable FROM information_schema.columns WHERE table_schema = ? AND table_name = ? AND column_name = ?",
+		database, name, column,
+	).Scan(&isNullable); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("failed to check if column %s of %s is nullable: %w", column, table, err)
+	}
+	return true, isNullable == "YES", nil
+}
+
+// tableProperties are the PROPERTIES of tables created, unique keys merge on write for Doris to behave like primary keys
+func (c *StarRocksConnector) tableProperties(uniqueKey bool) string {
+	var properties []string
+	if c.config.ReplicationNum > 0 {
+		properties = append(properties, fmt.Sprintf(`"replication_num" = "%d"`, c.config.ReplicationNum))
+	}
+	if uniqueKey && c.isDoris() {
+		properties = append(properties, `"enable_unique_key_merge_on_write" = "true"`)
+	}
+	if len(properties) == 0 {
+		return ""
+	}
+	return " PROPERTIES (" + strings.Join(properties, ",") + ")"
+}
+
+// distribution is how tables created are bucketed, Doris needs the bucket count spelled out
+func (c *StarRocksConnector) distribution(keys []string) string {
+	var distribution string
+	if len(keys) > 0 {
+		distribution = " DISTRIBUTED BY HASH(" + strings.Join(keys, ",") + ")"
+	} else {
+		distribution = " DISTRIBUTED BY RANDOM"
+	}
+	if c.isDoris() {
+		distribution += " BUCKETS AUTO"
+	}
+	return distribution
+}
+
+// datetimeType is DATETIME with microseconds, which Doris only keeps when asked to
+func (c *StarRocksConnector) datetimeType() string {
+	if c.isDoris() {
+		return "DATETIME(6)"
+	}
+	return "DATETIME"
+}
@@ -0,0 +1,96 @@
+package connstarrocks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestEncodeRow(t *testing.T) {
+	schema := qvalue.QRecordSchema{Fields: []qvalue.QField{
+		{Name: "id", Type: qvalue.QValueKindInt64},
+		{Name: "doc", Type: qvalue.QValueKindJSON},
+		{Name: "at", Type: qvalue.QValueKindTimestampTZ},
+		{Name: "score", Type: qvalue.QValueKindFloat64},
+		{Name: "note", Type: qvalue.QValueKindString},
+	}}
+	row, err := encodeRow(schema, []qvalue.QValue{
+		qvalue.QValueInt64{Val: 7},
+		qvalue.QValueJSON{Val: `{"a":[1,2]}`},
+		qvalue.QValueTimestampTZ{Val: time.Date(2024, 1, 2, 5, 4, 5, 500000000, time.FixedZone("", 2*60*60))},
+		qvalue.QValueFloat64{Val: 0},
+		qvalue.QValueNull(qvalue.QValueKindString),
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":7,"doc":{"a":[1,2]},"at":"2024-01-02 03:04:05.5","score":0,"note":null}`, string(row))
+}
+
+func TestStreamLoadLabel(t *testing.T) {
+	require.Equal(t, "peerdb_my-flow_p_1", streamLoadLabel("peerdb", "my-flow", "p.1"))
+	require.Len(t, streamLoadLabel("peerdb", strings.Repeat("x", 200)), maxLabelLength)
+}
+
+func TestStreamLoadResultErr(t *testing.T) {
+	require.NoError(t, (&streamLoadResult{Status: "Success"}).err())
+	require.NoError(t, (&streamLoadResult{Status: "Publish Timeout"}).err())
+	require.NoError(t, (&streamLoadResult{Status: "Label Already Exists", ExistingJobStatus: "FINISHED"}).err())
+	require.Error(t, (&streamLoadResult{Status: "Label Already Exists", ExistingJobStatus: "RUNNING"}).err())
+	require.ErrorContains(t, (&streamLoadResult{Status: "Fail", Message: "too many filtered rows"}).err(),
+		"too many filtered rows")
+}
+
+func TestNormalizeStatements(t *testing.T) {
+	generator := &normalizeStmtGenerator{
+		rawTableName:      "_peerdb_raw.flow",
+		dstTableName:      "db.orders",
+		softDeleteColName: "_peerdb_is_deleted",
+		columns: []normalizedColumn{
+			{source: "id", name: "id", starrocksType: "BIGINT", kind: qvalue.QValueKindInt64, primaryKey: true},
+			{source: "note", name: "comment", starrocksType: "STRING", kind: qvalue.QValueKindString},
+		},
+		startBatchID: 3,
+		syncBatchID:  5,
+	}
+	latest := "SELECT CAST(get_json_string(_peerdb_data,'$.\"id\"') AS BIGINT) AS `id`," +
+		"CAST(get_json_string(_peerdb_data,'$.\"note\"') AS STRING) AS `comment`," +
+		"_peerdb_record_type,_peerdb_unchanged_toast_columns FROM (SELECT _peerdb_data,_peerdb_record_type," +
+		"_peerdb_unchanged_toast_columns,ROW_NUMBER() OVER (PARTITION BY get_json_string(_peerdb_data,'$.\"id\"') " +
+		"ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank FROM `_peerdb_raw`.`flow` WHERE _peerdb_batch_id > 3 AND " +
+		"_peerdb_batch_id <= 5 AND _peerdb_destination_table_name = 'db.orders') r WHERE _peerdb_rank = 1"
+	require.Equal(t, []string{
+		"UPDATE `db`.`orders` SET `_peerdb_is_deleted` = TRUE FROM (" + latest + ") s " +
+			"WHERE `db`.`orders`.`id` = s.`id` AND s._peerdb_record_type = 2",
+		"INSERT INTO `db`.`orders` (`id`,`comment`,`_peerdb_is_deleted`) SELECT s.`id`," +
+			"if(find_in_set('note',s._peerdb_unchanged_toast_columns) > 0,t.`comment`,s.`comment`),FALSE FROM (" +
+			latest + ") s LEFT JOIN `db`.`orders` t ON t.`id` = s.`id` WHERE s._peerdb_record_type != 2",
+	}, generator.statements())
+}
+
+func TestCreateTableQuery(t *testing.T) {
+	columns := []normalizedColumn{
+		{name: "note", starrocksType: "STRING"},
+		{name: "id", starrocksType: "VARCHAR(128)", primaryKey: true},
+	}
+	c := &StarRocksConnector{config: &protos.StarRocksConfig{ReplicationNum: 1}}
+	require.Equal(t, "CREATE TABLE `db`.`t` (`id` VARCHAR(128) NOT NULL,`note` STRING,`_peerdb_synced_at` DATETIME)"+
+		` PRIMARY KEY(`+"`id`"+`) DISTRIBUTED BY HASH(`+"`id`"+`) PROPERTIES ("replication_num" = "1")`,
+		c.createTableQuery("db.t", columns, "", "_peerdb_synced_at"))
+
+	c.config.Flavor = protos.StarRocksFlavor_STARROCKS_FLAVOR_DORIS
+	require.Equal(t, "CREATE TABLE `db`.`t` (`id` VARCHAR(128) NOT NULL,`note` STRING,`_peerdb_synced_at` DATETIME(6))"+
+		` UNIQUE KEY(`+"`id`"+`) DISTRIBUTED BY HASH(`+"`id`"+`) BUCKETS AUTO`+
+		` PROPERTIES ("replication_num" = "1","enable_unique_key_merge_on_write" = "true")`,
+		c.createTableQuery("db.t", columns, "", "_peerdb_synced_at"))
+}
+
+func TestModifyColumnQuery(t *testing.T) {
+	c := &StarRocksConnector{config: &protos.StarRocksConfig{}}
+	colType, err := c.columnType(qvalue.QValueKindInt64, -1, false)
+	require.NoError(t, err)
+	require.Equal(t, "ALTER TABLE `db`.`t` MODIFY COLUMN `quantity` BIGINT NULL", modifyColumnQuery("db.t", "quantity", colType))
+}
@@ -0,0 +1,264 @@
+package connstarrocks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+const (
+	streamLoadTimeout = 10 * time.Minute
+	// loads are buffered to be sent again when the frontend redirects to a backend, so they are split at this size
+	streamLoadMaxBytes = 64 << 20
+	maxLabelLength     = 128
+)
+
+var reIllegalLabelChars = regexp.MustCompile(`[^-_A-Za-z0-9:]`)
+
+// newStreamLoadClient follows redirects of the frontend to backends, sending credentials to them again
+func newStreamLoadClient(config *protos.StarRocksConfig) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ExpectContinueTimeout = 5 * time.Second
+	return &http.Client{
+		Timeout:   streamLoadTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			req.SetBasicAuth(config.User, config.Password)
+			return nil
+		},
+	}
+}
+
+// streamLoadLabel makes a label out of parts, labels of loads that finished are rejected for days,
+// which is what makes retried loads of a label idempotent
+func streamLoadLabel(parts ...string) string {
+	var label string
+	for i, part := range parts {
+		if i > 0 {
+			label += "_"
+		}
+		label += reIllegalLabelChars.ReplaceAllString(part, "_")
+	}
+	if len(label) > maxLabelLength {
+		label = label[:maxLabelLength]
+	}
+	return label
+}
+
+type streamLoadResult struct {
+	Status            string `json:"Status"`
+	Message           string `json:"Message"`
+	ExistingJobStatus string `json:"ExistingJobStatus"`
+	ErrorURL          string `json:"ErrorURL"`
+	NumberLoadedRows  int64  `json:"NumberLoadedRows"`
+}
+
+// err is nil for loads which took place, Publish Timeout only delays their visibility
+func (r *streamLoadResult) err() error {
+	switch r.Status {
+	case "Success", "Publish Timeout":
+		return nil
+	case "Label Already Exists":
+		if r.ExistingJobStatus == "FINISHED" || r.ExistingJobStatus == "VISIBLE" || r.ExistingJobStatus == "COMMITTED" {
+			return nil
+		}
+		return fmt.Errorf("load of the same label is %s", r.ExistingJobStatus)
+	default:
+		if r.ErrorURL != "" {
+			return fmt.Errorf("stream load %s: %s, see %s", r.Status, r.Message, r.ErrorURL)
+		}
+		return fmt.Errorf("stream load %s: %s", r.Status, r.Message)
+	}
+}
+
+func (c *StarRocksConnector) streamLoadURL(table string) string {
+	database, name := c.splitTable(table)
+	port := c.config.HttpPort
+	if port == 0 {
+		port = 8030
+	}
+	scheme := "https"
+	if c.config.DisableTls {
+		scheme = "http"
+	}
+	return (&url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(c.config.Host, strconv.FormatUint(uint64(port), 10)),
+		Path:   "/api/" + database + "/" + name + "/_stream_load",
+	}).String()
+}
+
+// streamLoad loads body, a JSON array of rows keyed by column, into table
+func (c *StarRocksConnector) streamLoad(ctx context.Context, table string, label string, body []byte) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.streamLoadURL(table), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.SetBasicAuth(c.config.User, c.config.Password)
+	req.Header.Set("Expect", "100-continue")
+	req.Header.Set("label", label)
+	req.Header.Set("format", "json")
+	req.Header.Set("strip_outer_array", "true")
+
+	c.logger.Info("[starrocks] stream load", slog.String("table", table), slog.String("label", label),
+		slog.Int("bytes", len(body)))
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("stream load into %s failed: %w", table, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stream load response of %s: %w", table, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("stream load into %s failed with status %d: %s", table, resp.StatusCode, respBody)
+	}
+	var result streamLoadResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse stream load response of %s: %w", table, err)
+	}
+	if err := result.err(); err != nil {
+		return 0, fmt.Errorf("stream load into %s failed: %w", table, err)
+	}
+	return result.NumberLoadedRows, nil
+}
+
+// loadStream stream loads the records into table, split into loads labelled labelPrefix with their index
+func (c *StarRocksConnector) loadStream(
+	ctx context.Context,
+	table string,
+	labelPrefix string,
+	stream *model.QRecordStream,
+) (int, error) {
+	schema := stream.Schema()
+	numRecords := 0
+	numLoads := 0
+	var body bytes.Buffer
+	flush := func() error {
+		if body.Len() == 0 {
+			return nil
+		}
+		body.WriteByte(']')
+		if _, err := c.streamLoad(ctx, table, streamLoadLabel(labelPrefix, strconv.Itoa(numLoads)), body.Bytes()); err != nil {
+			return err
+		}
+		numLoads += 1
+		body.Reset()
+		return nil
+	}
+
+	for record := range stream.Records {
+		row, err := encodeRow(schema, record)
+		if err != nil {
+			return 0, err
+		}
+		if body.Len() == 0 {
+			body.WriteByte('[')
+		} else {
+			body.WriteByte(',')
+		}
+		body.Write(row)
+		numRecords += 1
+		if body.Len() >= streamLoadMaxBytes {
+			if err := flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
+
+func encodeRow(schema qvalue.QRecordSchema, record []qvalue.QValue) ([]byte, error) {
+	row := make(map[string]any, len(record))
+	for i, value := range record {
+		v, err := loadValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode column %s: %w", schema.Fields[i].Name, err)
+		}
+		row[schema.Fields[i].Name] = v
+	}
+	return json.Marshal(row)
+}
+
+// loadValue is the JSON value columns of a load take value as, JSON is embedded as is for JSON columns
+func loadValue(value qvalue.QValue) (any, error) {
+	raw := value.Value()
+	if raw == nil {
+		return nil, nil
+	}
+	switch v := raw.(type) {
+	case string:
+		switch value.Kind() {
+		case qvalue.QValueKindJSON:
+			if !json.Valid([]byte(v)) {
+				return nil, errors.New("invalid json")
+			}
+			return json.RawMessage(v), nil
+		case qvalue.QValueKindHStore:
+			hstoreJSON, err := datatypes.ParseHstore(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert hstore to json: %w", err)
+			}
+			return json.RawMessage(hstoreJSON), nil
+		}
+		return v, nil
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil, nil
+		}
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, nil
+		}
+		return v, nil
+	case uint8:
+		return string(rune(v)), nil
+	case decimal.Decimal:
+		return v.String(), nil
+	case [16]byte:
+		return uuid.UUID(v).String(), nil
+	case time.Time:
+		switch value.Kind() {
+		case qvalue.QValueKindDate:
+			return v.Format(time.DateOnly), nil
+		case qvalue.QValueKindTime, qvalue.QValueKindTimeTZ:
+			return v.Format("15:04:05.999999"), nil
+		default:
+			// DATETIME has no offset, timestamptz lands in UTC
+			return v.UTC().Format("2006-01-02 15:04:05.999999"), nil
+		}
+	default:
+		// bytes are base64 encoded by encoding/json, arrays become JSON arrays
+		return v, nil
+	}
+}
@@ -0,0 +1,216 @@
+package connsynthetic
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+const (
+	defaultNumColumns = 4
+	defaultRowWidth   = 256
+	payloadAlphabet   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"
+)
+
+type recordKind int8
+
+const (
+	recordKindInsert recordKind = iota
+	recordKindUpdate
+	recordKindDelete
+)
+
+type syntheticTable struct {
+	name         string
+	numColumns   uint32
+	payloadWidth uint32
+	weight       uint32
+	relID        uint32
+}
+
+func (t *syntheticTable) payloadColumn(i uint32) string {
+	return fmt.Sprintf("payload_%d", i+1)
+}
+
+func (t *syntheticTable) schema(system protos.TypeSystem) *protos.TableSchema {
+	int64Type, timestampType, stringType := string(qvalue.QValueKindInt64),
+		string(qvalue.QValueKindTimestamp), string(qvalue.QValueKindString)
+	if system == protos.TypeSystem_PG {
+		int64Type, timestampType, stringType = "int8", "timestamp", "text"
+	}
+	columns := make([]*protos.FieldDescription, 0, 3+t.numColumns)
+	columns = append(columns,
+		&protos.FieldDescription{Name: "id", Type: int64Type, TypeModifier: -1},
+		&protos.FieldDescription{Name: "seq", Type: int64Type, TypeModifier: -1},
+		&protos.FieldDescription{Name: "updated_at", Type: timestampType, TypeModifier: -1},
+	)
+	for i := range t.numColumns {
+		columns = append(columns,
+			&protos.FieldDescription{Name: t.payloadColumn(i), Type: stringType, TypeModifier: -1, Nullable: true})
+	}
+	return &protos.TableSchema{
+		TableIdentifier:   t.name,
+		PrimaryKeyColumns: []string{"id"},
+		System:            system,
+		Columns:           columns,
+	}
+}
+
+// generator derives every change event from the seed and its offset alone,
+// ids of inserts are their offset while updates and deletes target a random earlier offset.
+// Earlier offsets may belong to another table or already be deleted, which destinations must tolerate anyway.
+type generator struct {
+	tables       map[string]*syntheticTable
+	tableOrder   []*syntheticTable
+	seed         uint64
+	totalWeight  uint32
+	insertWeight uint32
+	updateWeight uint32
+	deleteWeight uint32
+}
+
+func newGenerator(config *protos.SyntheticConfig) (*generator, error) {
+	if len(config.Tables) == 0 {
+		return nil, errors.New("synthetic peer needs at least one table")
+	}
+	g := &generator{
+		tables:       make(map[string]*syntheticTable, len(config.Tables)),
+		tableOrder:   make([]*syntheticTable, 0, len(config.Tables)),
+		seed:         config.Seed,
+		insertWeight: config.InsertWeight,
+		updateWeight: config.UpdateWeight,
+		deleteWeight: config.DeleteWeight,
+	}
+	if g.insertWeight+g.updateWeight+g.deleteWeight == 0 {
+		g.insertWeight = 1
+	}
+	for idx, tableConfig := range config.Tables {
+		if _, err := utils.ParseSchemaTable(tableConfig.Name); err != nil {
+			return nil, fmt.Errorf("invalid synthetic table name: %w", err)
+		}
+		if _, ok := g.tables[tableConfig.Name]; ok {
+			return nil, fmt.Errorf("synthetic table %s configured more than once", tableConfig.Name)
+		}
+		table := &syntheticTable{
+			name:       tableConfig.Name,
+			numColumns: tableConfig.NumColumns,
+			weight:     max(tableConfig.Weight, 1),
+			relID:      uint32(idx + 1),
+		}
+		if table.numColumns == 0 {
+			table.numColumns = defaultNumColumns
+		}
+		rowWidth := tableConfig.RowWidth
+		if rowWidth == 0 {
+			rowWidth = defaultRowWidth
+		}
+		table.payloadWidth = max(rowWidth/table.numColumns, 1)
+		g.tables[table.name] = table
+		g.tableOrder = append(g.tableOrder, table)
+		g.totalWeight += table.weight
+	}
+	return g, nil
+}
+
+func (g *generator) pickTable(rng *rand.Rand) *syntheticTable {
+	n := rng.Uint32N(g.totalWeight)
+	for _, table := range g.tableOrder {
+		if n < table.weight {
+			return table
+		}
+		n -= table.weight
+	}
+	return g.tableOrder[len(g.tableOrder)-1]
+}
+
+func (g *generator) payload(rng *rand.Rand, width uint32) string {
+	buf := make([]byte, width)
+	var bits uint64
+	for i := range buf {
+		if i%10 == 0 {
+			bits = rng.Uint64()
+		}
+		buf[i] = payloadAlphabet[bits&63]
+		bits >>= 6
+	}
+	return string(buf)
+}
+
+// record returns the change event at offset, or nil if it belongs to a table not in tableNameMapping
+func (g *generator) record(
+	offset int64,
+	tableNameMapping map[string]model.NameAndExclude,
+) (model.Record[model.RecordItems], error) {
+	if offset <= 0 {
+		return nil, fmt.Errorf("invalid synthetic offset %d", offset)
+	}
+	rng := rand.New(rand.NewPCG(g.seed, uint64(offset)))
+	table := g.pickTable(rng)
+	var kind recordKind
+	if op := rng.Uint32N(g.insertWeight + g.updateWeight + g.deleteWeight); offset == 1 || op < g.insertWeight {
+		kind = recordKindInsert
+	} else if op < g.insertWeight+g.updateWeight {
+		kind = recordKindUpdate
+	} else {
+		kind = recordKindDelete
+	}
+	id := offset
+	if kind != recordKindInsert {
+		id = 1 + rng.Int64N(offset-1)
+	}
+
+	destination, ok := tableNameMapping[table.name]
+	if !ok {
+		return nil, nil
+	}
+
+	now := time.Now()
+	key := model.NewRecordItems(1)
+	key.AddColumn("id", qvalue.QValueInt64{Val: id})
+	baseRecord := model.BaseRecord{CheckpointID: offset, CommitTimeNano: now.UnixNano()}
+	if kind == recordKindDelete {
+		return &model.DeleteRecord[model.RecordItems]{
+			BaseRecord:            baseRecord,
+			Items:                 key,
+			UnchangedToastColumns: make(map[string]struct{}),
+			SourceTableName:       table.name,
+			DestinationTableName:  destination.Name,
+		}, nil
+	}
+
+	items := model.NewRecordItems(int(3 + table.numColumns))
+	items.AddColumn("id", qvalue.QValueInt64{Val: id})
+	items.AddColumn("seq", qvalue.QValueInt64{Val: offset})
+	items.AddColumn("updated_at", qvalue.QValueTimestamp{Val: now.UTC()})
+	for i := range table.numColumns {
+		column := table.payloadColumn(i)
+		value := g.payload(rng, table.payloadWidth)
+		if _, excluded := destination.Exclude[column]; !excluded {
+			items.AddColumn(column, qvalue.QValueString{Val: value})
+		}
+	}
+
+	if kind == recordKindUpdate {
+		return &model.UpdateRecord[model.RecordItems]{
+			BaseRecord:            baseRecord,
+			OldItems:              key,
+			NewItems:              items,
+			UnchangedToastColumns: make(map[string]struct{}),
+			SourceTableName:       table.name,
+			DestinationTableName:  destination.Name,
+		}, nil
+	}
+	return &model.InsertRecord[model.RecordItems]{
+		BaseRecord:           baseRecord,
+		Items:                items,
+		SourceTableName:      table.name,
+		DestinationTableName: destination.Name,
+		CommitID:             offset,
+	}, nil
+}
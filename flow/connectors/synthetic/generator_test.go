@@ -0,0 +1,85 @@
+package connsynthetic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+func testGenerator(t *testing.T) *generator {
+	t.Helper()
+	g, err := newGenerator(&protos.SyntheticConfig{
+		Tables: []*protos.SyntheticTable{
+			{Name: "public.a", NumColumns: 2, RowWidth: 64},
+			{Name: "public.b", Weight: 3},
+		},
+		InsertWeight: 2,
+		UpdateWeight: 1,
+		DeleteWeight: 1,
+		Seed:         42,
+	})
+	require.NoError(t, err)
+	return g
+}
+
+func TestGeneratorReproducible(t *testing.T) {
+	g := testGenerator(t)
+	mapping := map[string]model.NameAndExclude{
+		"public.a": model.NewNameAndExclude("dst.a", nil),
+		"public.b": model.NewNameAndExclude("dst.b", nil),
+	}
+
+	for offset := int64(1); offset <= 100; offset++ {
+		first, err := g.record(offset, mapping)
+		require.NoError(t, err)
+		second, err := g.record(offset, mapping)
+		require.NoError(t, err)
+		require.Equal(t, first.Kind(), second.Kind())
+		require.Equal(t, first.GetDestinationTableName(), second.GetDestinationTableName())
+		firstItems, secondItems := first.GetItems(), second.GetItems()
+		require.Equal(t, firstItems.GetColumnValue("id"), secondItems.GetColumnValue("id"))
+		require.Equal(t, firstItems.GetColumnValue("payload_1"), secondItems.GetColumnValue("payload_1"))
+		if offset == 1 {
+			require.Equal(t, "insert", first.Kind())
+		}
+	}
+}
+
+func TestGeneratorSkipsUnmappedAndExcluded(t *testing.T) {
+	g := testGenerator(t)
+	mapping := map[string]model.NameAndExclude{
+		"public.a": model.NewNameAndExclude("dst.a", []string{"payload_2"}),
+	}
+
+	var seen int
+	for offset := int64(1); offset <= 100; offset++ {
+		record, err := g.record(offset, mapping)
+		require.NoError(t, err)
+		if record == nil {
+			continue
+		}
+		seen += 1
+		require.Equal(t, "dst.a", record.GetDestinationTableName())
+		if record.Kind() != "delete" {
+			items := record.GetItems()
+			payload, err := items.GetValueByColName("payload_1")
+			require.NoError(t, err)
+			require.Len(t, payload.Value(), 32)
+			_, err = items.GetValueByColName("payload_2")
+			require.Error(t, err)
+		}
+	}
+	require.Positive(t, seen)
+}
+
+func TestGeneratorRejectsInvalidConfig(t *testing.T) {
+	_, err := newGenerator(&protos.SyntheticConfig{})
+	require.Error(t, err)
+	_, err = newGenerator(&protos.SyntheticConfig{Tables: []*protos.SyntheticTable{{Name: "nodot"}}})
+	require.Error(t, err)
+	_, err = newGenerator(&protos.SyntheticConfig{Tables: []*protos.SyntheticTable{{Name: "s.t"}, {Name: "s.t"}}})
+	require.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package connsynthetic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/alerting"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/otel_metrics/peerdb_gauges"
+)
+
+// SyntheticConnector is a source which generates CDC traffic instead of reading it,
+// useful for benchmarking destinations and sizing workers.
+// Offsets are the sequence number of the last generated change event,
+// so pulling from the same offset reproduces the same records.
+type SyntheticConnector struct {
+	config    *protos.SyntheticConfig
+	generator *generator
+	logger    log.Logger
+}
+
+func NewSyntheticConnector(ctx context.Context, config *protos.SyntheticConfig) (*SyntheticConnector, error) {
+	generator, err := newGenerator(config)
+	if err != nil {
+		return nil, err
+	}
+	return &SyntheticConnector{
+		config:    config,
+		generator: generator,
+		logger:    logger.LoggerFromCtx(ctx),
+	}, nil
+}
+
+func (c *SyntheticConnector) Close() error {
+	return nil
+}
+
+func (c *SyntheticConnector) ConnectionActive(context.Context) error {
+	return nil
+}
+
+func (c *SyntheticConnector) GetTableSchema(
+	_ context.Context,
+	req *protos.GetTableSchemaBatchInput,
+) (*protos.GetTableSchemaBatchOutput, error) {
+	res := make(map[string]*protos.TableSchema, len(req.TableIdentifiers))
+	for _, tableName := range req.TableIdentifiers {
+		table, ok := c.generator.tables[tableName]
+		if !ok {
+			return nil, fmt.Errorf("synthetic table %s is not configured", tableName)
+		}
+		res[tableName] = table.schema(req.System)
+	}
+	return &protos.GetTableSchemaBatchOutput{TableNameSchemaMapping: res}, nil
+}
+
+func (c *SyntheticConnector) EnsurePullability(
+	_ context.Context,
+	req *protos.EnsurePullabilityBatchInput,
+) (*protos.EnsurePullabilityBatchOutput, error) {
+	tableIdentifierMapping := make(map[string]*protos.PostgresTableIdentifier, len(req.SourceTableIdentifiers))
+	for _, tableName := range req.SourceTableIdentifiers {
+		table, ok := c.generator.tables[tableName]
+		if !ok {
+			return nil, fmt.Errorf("synthetic table %s is not configured", tableName)
+		}
+		tableIdentifierMapping[tableName] = &protos.PostgresTableIdentifier{RelId: table.relID}
+	}
+	return &protos.EnsurePullabilityBatchOutput{TableIdentifierMapping: tableIdentifierMapping}, nil
+}
+
+func (c *SyntheticConnector) ExportTxSnapshot(context.Context) (*protos.ExportTxSnapshotOutput, any, error) {
+	return nil, nil, errors.New("synthetic peers do not support initial snapshot")
+}
+
+func (c *SyntheticConnector) FinishExport(any) error {
+	return nil
+}
+
+func (c *SyntheticConnector) SetupReplConn(context.Context) error {
+	return nil
+}
+
+func (c *SyntheticConnector) ReplPing(context.Context) error {
+	return nil
+}
+
+func (c *SyntheticConnector) UpdateReplStateLastOffset(int64) {
+}
+
+func (c *SyntheticConnector) PullFlowCleanup(context.Context, string) error {
+	return nil
+}
+
+func (c *SyntheticConnector) HandleSlotInfo(
+	context.Context,
+	*alerting.Alerter,
+	*pgxpool.Pool,
+	string,
+	string,
+	peerdb_gauges.SlotMetricGauges,
+) error {
+	return nil
+}
+
+func (c *SyntheticConnector) GetSlotInfo(context.Context, string) ([]*protos.SlotInfo, error) {
+	return nil, nil
+}
+
+func (c *SyntheticConnector) AddTablesToPublication(context.Context, *protos.AddTablesToPublicationInput) error {
+	return nil
+}
+
+func (c *SyntheticConnector) PullRecords(
+	ctx context.Context,
+	_ *pgxpool.Pool,
+	req *model.PullRecordsRequest[model.RecordItems],
+) error {
+	records := req.RecordStream
+	records.UpdateLatestCheckpoint(req.LastOffset)
+	var numRecords uint32
+	defer func() {
+		if numRecords == 0 {
+			records.SignalAsEmpty()
+		}
+		records.Close()
+		c.logger.Info(fmt.Sprintf("[finished] PullRecords generated %d records", numRecords))
+	}()
+
+	var interval time.Duration
+	if c.config.RowsPerSecond > 0 {
+		interval = time.Second / time.Duration(c.config.RowsPerSecond)
+	}
+	deadline := time.Now().Add(req.IdleTimeout)
+	next := time.Now()
+
+	for offset := req.LastOffset + 1; numRecords < req.MaxBatchSize; offset++ {
+		if interval > 0 {
+			if wait := time.Until(next); wait > 0 {
+				if next.After(deadline) {
+					return nil
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+			next = next.Add(interval)
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := c.generator.record(offset, req.TableNameMapping)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			// table not part of this mirror, offset still advances
+			records.UpdateLatestCheckpoint(offset)
+			continue
+		}
+		if err := records.AddRecord(ctx, record); err != nil {
+			return err
+		}
+		records.UpdateLatestCheckpoint(offset)
+		numRecords += 1
+		if numRecords == 1 {
+			records.SignalAsNotEmpty()
+		}
+		if time.Now().After(deadline) {
+			c.logger.Info("idle timeout reached, returning generated records", slog.Uint64("records", uint64(numRecords)))
+			return nil
+		}
+	}
+	return nil
+}
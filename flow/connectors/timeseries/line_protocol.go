@@ -0,0 +1,196 @@
+package conntimeseries
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `, "\n", `\n`)
+	keyEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", `\n`)
+	stringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// lineWriter turns rows of a table into points in line protocol
+type lineWriter struct {
+	flavor          protos.TimeSeriesFlavor
+	measurement     string
+	timestampColumn string
+	tags            []string
+	fields          []string
+}
+
+// newLineWriter writes columns as fields unless mapping picks some, columns being all columns of the table in order
+func newLineWriter(
+	flavor protos.TimeSeriesFlavor,
+	measurement string,
+	mapping *protos.TimeSeriesMapping,
+	columns []string,
+) *lineWriter {
+	w := &lineWriter{
+		flavor:          flavor,
+		measurement:     measurementEscaper.Replace(measurement),
+		timestampColumn: mapping.GetTimestampColumn(),
+		tags:            mapping.GetTagColumns(),
+		fields:          mapping.GetFieldColumns(),
+	}
+	if len(w.fields) == 0 {
+		w.fields = make([]string, 0, len(columns))
+		for _, column := range columns {
+			if column != w.timestampColumn && !slices.Contains(w.tags, column) {
+				w.fields = append(w.fields, column)
+			}
+		}
+	}
+	return w
+}
+
+// appendLine appends the point of a row, rows without any non null field make no point.
+// Points are at the time of the timestamp column, else at fallback, else at the time the server receives them
+func (w *lineWriter) appendLine(buf []byte, row map[string]qvalue.QValue, fallback time.Time) ([]byte, bool, error) {
+	start := len(buf)
+	buf = append(buf, w.measurement...)
+	for _, tag := range w.tags {
+		qv := row[tag]
+		if qv == nil || qv.Value() == nil {
+			continue
+		}
+		value := tagValue(qv.Value())
+		// empty tag values are rejected
+		if value == "" {
+			continue
+		}
+		buf = append(buf, ',')
+		buf = append(buf, keyEscaper.Replace(tag)...)
+		buf = append(buf, '=')
+		buf = append(buf, keyEscaper.Replace(value)...)
+	}
+
+	numFields := 0
+	for _, field := range w.fields {
+		qv := row[field]
+		if qv == nil || qv.Value() == nil {
+			continue
+		}
+		value, ok, err := w.fieldValue(qv.Value())
+		if err != nil {
+			return buf[:start], false, fmt.Errorf("failed to encode field %s: %w", field, err)
+		}
+		if !ok {
+			continue
+		}
+		if numFields == 0 {
+			buf = append(buf, ' ')
+		} else {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, keyEscaper.Replace(field)...)
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+		numFields += 1
+	}
+	if numFields == 0 {
+		return buf[:start], false, nil
+	}
+
+	ts := fallback
+	if w.timestampColumn != "" {
+		if qv := row[w.timestampColumn]; qv != nil {
+			if t, ok := qv.Value().(time.Time); ok {
+				ts = t
+			}
+		}
+	}
+	if !ts.IsZero() {
+		buf = append(buf, ' ')
+		buf = strconv.AppendInt(buf, ts.UnixNano(), 10)
+	}
+	buf = append(buf, '\n')
+	return buf, true, nil
+}
+
+func tagValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case uint8:
+		return string(rune(v))
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// fieldValue is the field value in line protocol, false for values it cannot represent like NaN
+func (w *lineWriter) fieldValue(value any) (string, bool, error) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "t", true, nil
+		}
+		return "f", true, nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10) + "i", true, nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10) + "i", true, nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10) + "i", true, nil
+	case int64:
+		return strconv.FormatInt(v, 10) + "i", true, nil
+	case float32:
+		return floatField(float64(v))
+	case float64:
+		return floatField(v)
+	case decimal.Decimal:
+		return floatField(v.InexactFloat64())
+	case time.Time:
+		// QuestDB has timestamp fields in microseconds, InfluxDB has none
+		if w.flavor == protos.TimeSeriesFlavor_TIME_SERIES_FLAVOR_QUESTDB {
+			return strconv.FormatInt(v.UnixMicro(), 10) + "t", true, nil
+		}
+		return stringField(v.UTC().Format(time.RFC3339Nano)), true, nil
+	case string:
+		return stringField(v), true, nil
+	case uint8:
+		return stringField(string(rune(v))), true, nil
+	case [16]byte:
+		return stringField(uuid.UUID(v).String()), true, nil
+	case []byte:
+		return stringField(base64.StdEncoding.EncodeToString(v)), true, nil
+	default:
+		// arrays and the like have no counterpart, they are written as JSON
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false, err
+		}
+		return stringField(string(data)), true, nil
+	}
+}
+
+func floatField(v float64) (string, bool, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "", false, nil
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64), true, nil
+}
+
+func stringField(v string) string {
+	return `"` + stringEscaper.Replace(v) + `"`
+}
@@ -0,0 +1,287 @@
+package conntimeseries
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const (
+	defaultBatchSize = 5000
+	requestTimeout   = time.Minute
+)
+
+type TimeSeriesConnector struct {
+	*metadataStore.PostgresMetadata
+	client    *http.Client
+	config    *protos.TimeSeriesConfig
+	logger    log.Logger
+	baseURL   string
+	batchSize int
+}
+
+func NewTimeSeriesConnector(ctx context.Context, config *protos.TimeSeriesConfig) (*TimeSeriesConnector, error) {
+	endpoint, err := url.Parse(config.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time series url: %w", err)
+	}
+	if (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, errors.New("time series url must be an absolute http or https url")
+	}
+	if config.Flavor == protos.TimeSeriesFlavor_TIME_SERIES_FLAVOR_INFLUXDB && (config.Org == "" || config.Bucket == "") {
+		return nil, errors.New("influxdb needs an org and a bucket")
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := defaultBatchSize
+	if config.BatchSize > 0 {
+		batchSize = int(config.BatchSize)
+	}
+
+	return &TimeSeriesConnector{
+		PostgresMetadata: pgMetadata,
+		client:           &http.Client{Timeout: requestTimeout},
+		config:           config,
+		logger:           logger.LoggerFromCtx(ctx),
+		baseURL:          strings.TrimSuffix(config.Url, "/"),
+		batchSize:        batchSize,
+	}, nil
+}
+
+func (c *TimeSeriesConnector) Close() error {
+	if c != nil {
+		c.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// both QuestDB and InfluxDB answer /ping with 204
+func (c *TimeSeriesConnector) ConnectionActive(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/ping", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to ping time series server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("time series server responded to ping with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *TimeSeriesConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+// tables and their columns are created by the servers as points arrive
+func (c *TimeSeriesConnector) ReplayTableSchemaDeltas(_ context.Context, flowJobName string, schemaDeltas []*protos.TableSchemaDelta) error {
+	return nil
+}
+
+func (c *TimeSeriesConnector) writeURL() string {
+	if c.config.Flavor == protos.TimeSeriesFlavor_TIME_SERIES_FLAVOR_INFLUXDB {
+		return c.baseURL + "/api/v2/write?" + url.Values{
+			"org":       {c.config.Org},
+			"bucket":    {c.config.Bucket},
+			"precision": {"ns"},
+		}.Encode()
+	}
+	return c.baseURL + "/write?precision=n"
+}
+
+// write sends lines in one request, which both servers apply as a whole
+func (c *TimeSeriesConnector) write(ctx context.Context, lines []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.writeURL(), bytes.NewReader(lines))
+	if err != nil {
+		return fmt.Errorf("failed to create write request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "PeerDB")
+	if c.config.Token != "" {
+		if c.config.Flavor == protos.TimeSeriesFlavor_TIME_SERIES_FLAVOR_INFLUXDB {
+			req.Header.Set("Authorization", "Token "+c.config.Token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.config.Token)
+		}
+	} else if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write points: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("write of points failed with status %d: %s", resp.StatusCode, respBody)
+}
+
+func (c *TimeSeriesConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	var numDeletes int64
+
+	mappings := make(map[string]*protos.TimeSeriesMapping, len(req.TableMappings))
+	for _, tm := range req.TableMappings {
+		mappings[tm.DestinationTableIdentifier] = tm.TimeSeries
+	}
+	writers := make(map[string]*lineWriter)
+	writer := func(table string) *lineWriter {
+		if w, ok := writers[table]; ok {
+			return w
+		}
+		schema := req.TableNameSchemaMapping[table]
+		columns := make([]string, 0, len(schema.GetColumns()))
+		for _, column := range schema.GetColumns() {
+			columns = append(columns, column.Name)
+		}
+		w := newLineWriter(c.config.Flavor, table, mappings[table], columns)
+		writers[table] = w
+		return w
+	}
+
+	var lines []byte
+	batcher := utils.NewRecordBatcher(c.logger, req, c.batchSize, c.SetLastOffset,
+		func(ctx context.Context, _ []model.Record[model.RecordItems]) error {
+			if err := c.write(ctx, lines); err != nil {
+				return err
+			}
+			lines = lines[:0]
+			return nil
+		})
+
+	for record := range req.Records.GetRecords() {
+		var items model.RecordItems
+		switch rec := record.(type) {
+		case *model.InsertRecord[model.RecordItems]:
+			items = rec.Items
+		case *model.UpdateRecord[model.RecordItems]:
+			items = rec.NewItems
+		case *model.DeleteRecord[model.RecordItems]:
+			// points cannot be deleted through line protocol
+			numDeletes += 1
+			continue
+		default:
+			continue
+		}
+
+		var ok bool
+		var err error
+		lines, ok, err = writer(record.GetDestinationTableName()).appendLine(lines, items.ColToVal, record.GetCommitTime())
+		if err != nil {
+			return nil, fmt.Errorf("[timeseries] failed to encode record of %s: %w", record.GetDestinationTableName(), err)
+		}
+		if !ok {
+			continue
+		}
+		if err := batcher.Add(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+	if err := batcher.Flush(ctx); err != nil {
+		return nil, err
+	}
+	if numDeletes > 0 {
+		c.logger.Info("[timeseries] skipped deletes, line protocol has none", slog.Int64("deletes", numDeletes))
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[timeseries] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:     req.SyncBatchID,
+		LastSyncedCheckpointID: lastCheckpoint,
+		NumRecordsSynced:       batcher.NumRecords(),
+		TableNameRowsMapping:   batcher.TableNameRowsMapping(),
+		TableSchemaDeltas:      req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *TimeSeriesConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+// SyncQRepRecords writes rows at their timestamp column, rewriting points of a retried partition in place
+// when the series is keyed by its tags and time
+func (c *TimeSeriesConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int, error) {
+	startTime := time.Now()
+	c.logger.Info("[timeseries] syncing partition",
+		slog.String(string(shared.PartitionIDKey), partition.PartitionId),
+		slog.String("destinationTable", config.DestinationTableIdentifier))
+
+	schema := stream.Schema()
+	columns := schema.GetColumnNames()
+	w := newLineWriter(c.config.Flavor, config.DestinationTableIdentifier, config.TimeSeries, columns)
+
+	numRecords := 0
+	numLines := 0
+	var lines []byte
+	row := make(map[string]qvalue.QValue, len(columns))
+	for record := range stream.Records {
+		for i, column := range columns {
+			row[column] = record[i]
+		}
+		var ok bool
+		var err error
+		lines, ok, err = w.appendLine(lines, row, time.Time{})
+		if err != nil {
+			return 0, fmt.Errorf("[timeseries] failed to encode record of %s: %w", config.DestinationTableIdentifier, err)
+		}
+		numRecords += 1
+		if ok {
+			numLines += 1
+		}
+		if numLines >= c.batchSize {
+			if err := c.write(ctx, lines); err != nil {
+				return 0, err
+			}
+			lines = lines[:0]
+			numLines = 0
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	if numLines > 0 {
+		if err := c.write(ctx, lines); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
@@ -0,0 +1,73 @@
+package conntimeseries
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestAppendLine(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	w := newLineWriter(protos.TimeSeriesFlavor_TIME_SERIES_FLAVOR_QUESTDB, "sensor readings", &protos.TimeSeriesMapping{
+		TimestampColumn: "read_at",
+		TagColumns:      []string{"site", "sensor"},
+	}, []string{"id", "site", "sensor", "read_at", "value", "ok", "note", "seen_at"})
+
+	line, ok, err := w.appendLine(nil, map[string]qvalue.QValue{
+		"id":      qvalue.QValueInt64{Val: 7},
+		"site":    qvalue.QValueString{Val: "north, a=1"},
+		"sensor":  qvalue.QValueNull(qvalue.QValueKindString),
+		"read_at": qvalue.QValueTimestamp{Val: at},
+		"value":   qvalue.QValueFloat64{Val: 1.5},
+		"ok":      qvalue.QValueBoolean{Val: true},
+		"note":    qvalue.QValueString{Val: `say "hi"`},
+		"seen_at": qvalue.QValueTimestampTZ{Val: at},
+	}, time.Time{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, `sensor\ readings,site=north\,\ a\=1 id=7i,value=1.5,ok=t,note="say \"hi\"",seen_at=1704164645000000t `+
+		"1704164645000000000\n", string(line))
+
+	// rows without fields make no point, NaN has no counterpart
+	line, ok, err = w.appendLine(line[:0], map[string]qvalue.QValue{
+		"site":  qvalue.QValueString{Val: "north"},
+		"value": qvalue.QValueFloat64{Val: math.NaN()},
+	}, at)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, line)
+}
+
+func TestAppendLineInfluxDB(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	w := newLineWriter(protos.TimeSeriesFlavor_TIME_SERIES_FLAVOR_INFLUXDB, "events", &protos.TimeSeriesMapping{
+		FieldColumns: []string{"at", "tags"},
+	}, []string{"id", "at", "tags"})
+
+	// without a timestamp column the point is at fallback
+	line, ok, err := w.appendLine(nil, map[string]qvalue.QValue{
+		"id":   qvalue.QValueInt64{Val: 7},
+		"at":   qvalue.QValueTimestampTZ{Val: at},
+		"tags": qvalue.QValueArrayString{Val: []string{"a", "b"}},
+	}, at.Add(time.Second))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, `events at="2024-01-02T03:04:05Z",tags="[\"a\",\"b\"]" 1704164646000000000`+"\n", string(line))
+}
+
+func TestWriteURL(t *testing.T) {
+	c := &TimeSeriesConnector{baseURL: "http://questdb:9000", config: &protos.TimeSeriesConfig{}}
+	require.Equal(t, "http://questdb:9000/write?precision=n", c.writeURL())
+
+	c = &TimeSeriesConnector{baseURL: "https://influx:8086", config: &protos.TimeSeriesConfig{
+		Flavor: protos.TimeSeriesFlavor_TIME_SERIES_FLAVOR_INFLUXDB,
+		Org:    "my org",
+		Bucket: "cdc",
+	}}
+	require.Equal(t, "https://influx:8086/api/v2/write?bucket=cdc&org=my+org&precision=ns", c.writeURL())
+}
@@ -0,0 +1,229 @@
+// Package anonymize replaces values of PII columns with realistic synthetic ones, for mirrors feeding
+// lower environments data that is usable without being sensitive. Synthetic values are derived from an HMAC
+// of the value they replace keyed by the seed of the anonymization profile, so a value is always replaced alike
+// and keys, joins and updates of anonymized rows keep working.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+// maxDateShiftDays bounds how far SYNTHETIC_DATE moves dates in either direction
+const maxDateShiftDays = 365
+
+type Anonymizer struct {
+	key []byte
+}
+
+func NewAnonymizer(seed string) *Anonymizer {
+	return &Anonymizer{key: []byte(seed)}
+}
+
+func (a *Anonymizer) rng(value protos.SyntheticValue, plaintext []byte) *rand.Rand {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte{byte(value)})
+	mac.Write(plaintext)
+	sum := mac.Sum(nil)
+	return rand.New(rand.NewPCG(binary.LittleEndian.Uint64(sum), binary.LittleEndian.Uint64(sum[8:])))
+}
+
+// Supports returns whether columns of kind can be replaced by value
+func Supports(value protos.SyntheticValue, kind qvalue.QValueKind) bool {
+	switch value {
+	case protos.SyntheticValue_SYNTHETIC_NULL:
+		return true
+	case protos.SyntheticValue_SYNTHETIC_FORMAT:
+		return kind == qvalue.QValueKindString || kind == qvalue.QValueKindInt16 ||
+			kind == qvalue.QValueKindInt32 || kind == qvalue.QValueKindInt64
+	case protos.SyntheticValue_SYNTHETIC_UUID:
+		return kind == qvalue.QValueKindString || kind == qvalue.QValueKindUUID
+	case protos.SyntheticValue_SYNTHETIC_DATE:
+		return kind == qvalue.QValueKindDate || kind == qvalue.QValueKindTimestamp || kind == qvalue.QValueKindTimestampTZ
+	case protos.SyntheticValue_SYNTHETIC_NONE:
+		return false
+	default:
+		return kind == qvalue.QValueKindString
+	}
+}
+
+// Value returns the synthetic value replacing qv, nulls stay null unless replaced by SYNTHETIC_NULL
+func (a *Anonymizer) Value(value protos.SyntheticValue, qv qvalue.QValue) (qvalue.QValue, error) {
+	if qv == nil || qv.Value() == nil {
+		return qv, nil
+	}
+	if value == protos.SyntheticValue_SYNTHETIC_NULL {
+		return qvalue.QValueNull(qv.Kind()), nil
+	}
+	if !Supports(value, qv.Kind()) {
+		return nil, fmt.Errorf("synthetic value %s is not supported for %s columns", value, qv.Kind())
+	}
+
+	switch v := qv.(type) {
+	case qvalue.QValueString:
+		return qvalue.QValueString{Val: a.text(value, v.Val)}, nil
+	case qvalue.QValueInt16:
+		return qvalue.QValueInt16{Val: int16(a.integer(value, int64(v.Val), math.MaxInt16))}, nil
+	case qvalue.QValueInt32:
+		return qvalue.QValueInt32{Val: int32(a.integer(value, int64(v.Val), math.MaxInt32))}, nil
+	case qvalue.QValueInt64:
+		return qvalue.QValueInt64{Val: a.integer(value, v.Val, math.MaxInt64)}, nil
+	case qvalue.QValueUUID:
+		return qvalue.QValueUUID{Val: randomUUID(a.rng(value, v.Val[:]))}, nil
+	case qvalue.QValueDate:
+		rng := a.rng(value, []byte(v.Val.Format(time.DateOnly)))
+		return qvalue.QValueDate{Val: v.Val.AddDate(0, 0, rng.IntN(2*maxDateShiftDays+1)-maxDateShiftDays)}, nil
+	case qvalue.QValueTimestamp:
+		return qvalue.QValueTimestamp{Val: a.shiftTime(value, v.Val)}, nil
+	case qvalue.QValueTimestampTZ:
+		return qvalue.QValueTimestampTZ{Val: a.shiftTime(value, v.Val)}, nil
+	default:
+		return nil, fmt.Errorf("synthetic value %s is not supported for %s columns", value, qv.Kind())
+	}
+}
+
+func (a *Anonymizer) text(value protos.SyntheticValue, s string) string {
+	rng := a.rng(value, []byte(s))
+	switch value {
+	case protos.SyntheticValue_SYNTHETIC_FIRST_NAME:
+		return pick(rng, firstNames)
+	case protos.SyntheticValue_SYNTHETIC_LAST_NAME:
+		return pick(rng, lastNames)
+	case protos.SyntheticValue_SYNTHETIC_FULL_NAME:
+		return pick(rng, firstNames) + " " + pick(rng, lastNames)
+	case protos.SyntheticValue_SYNTHETIC_EMAIL:
+		return strings.ToLower(pick(rng, firstNames)+"."+pick(rng, lastNames)) +
+			strconv.Itoa(rng.IntN(1000)) + "@" + pick(rng, emailDomains)
+	case protos.SyntheticValue_SYNTHETIC_PHONE:
+		if !strings.ContainsFunc(s, isDigit) {
+			// 555-0100 to 555-0199 are set aside for fiction
+			return fmt.Sprintf("555-01%02d", rng.IntN(100))
+		}
+		return replaceChars(rng, s, false)
+	case protos.SyntheticValue_SYNTHETIC_STREET_ADDRESS:
+		return fmt.Sprintf("%d %s %s", 1+rng.IntN(9999), pick(rng, streetNames), pick(rng, streetSuffixes))
+	case protos.SyntheticValue_SYNTHETIC_CITY:
+		return pick(rng, cities)
+	case protos.SyntheticValue_SYNTHETIC_CREDIT_CARD:
+		return creditCard(rng, s)
+	case protos.SyntheticValue_SYNTHETIC_UUID:
+		return uuid.UUID(randomUUID(rng)).String()
+	default:
+		return replaceChars(rng, s, true)
+	}
+}
+
+// integer replaces n by a random integer with as many digits and the same sign, within max
+func (a *Anonymizer) integer(value protos.SyntheticValue, n int64, maxVal uint64) int64 {
+	rng := a.rng(value, strconv.AppendInt(nil, n, 10))
+	negative := n < 0
+	abs := uint64(n)
+	if negative {
+		abs = -abs
+		// magnitude of the minimum is one past the maximum
+		maxVal++
+	}
+	var lo uint64
+	hi := uint64(9)
+	for hi < abs {
+		lo = hi + 1
+		hi = hi*10 + 9
+	}
+	hi = min(hi, maxVal)
+	synthetic := lo + rng.Uint64N(hi-lo+1)
+	if negative {
+		return int64(-synthetic)
+	}
+	return int64(synthetic)
+}
+
+func (a *Anonymizer) shiftTime(value protos.SyntheticValue, t time.Time) time.Time {
+	rng := a.rng(value, []byte(t.UTC().Format(time.RFC3339Nano)))
+	const maxShift = maxDateShiftDays * 24 * 60 * 60
+	return t.Add(time.Duration(rng.Int64N(2*maxShift+1)-maxShift) * time.Second)
+}
+
+func pick(rng *rand.Rand, words []string) string {
+	return words[rng.IntN(len(words))]
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// replaceChars replaces digits, and letters when asked, by random ones keeping case,
+// letters outside ASCII being replaced by lowercase ASCII ones
+func replaceChars(rng *rand.Rand, s string, letters bool) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case isDigit(r):
+			sb.WriteByte(byte('0' + rng.IntN(10)))
+		case letters && r >= 'A' && r <= 'Z':
+			sb.WriteByte(byte('A' + rng.IntN(26)))
+		case letters && unicode.IsLetter(r):
+			sb.WriteByte(byte('a' + rng.IntN(26)))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// creditCard replaces digits of a card number keeping its first digit, which tells its network,
+// and fixing its last digit so the number passes the Luhn check
+func creditCard(rng *rand.Rand, s string) string {
+	synthetic := []byte(s)
+	var digits []int
+	first := true
+	for i, c := range synthetic {
+		if c < '0' || c > '9' {
+			continue
+		}
+		if !first {
+			synthetic[i] = byte('0' + rng.IntN(10))
+		}
+		first = false
+		digits = append(digits, i)
+	}
+	if len(digits) < 2 {
+		return replaceChars(rng, s, false)
+	}
+	sum := 0
+	for j := range len(digits) - 1 {
+		d := int(synthetic[digits[len(digits)-2-j]] - '0')
+		if j%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	synthetic[digits[len(digits)-1]] = byte('0' + (10-sum%10)%10)
+	return string(synthetic)
+}
+
+// randomUUID returns a version 4 UUID from rng
+func randomUUID(rng *rand.Rand) [16]byte {
+	var u [16]byte
+	binary.LittleEndian.PutUint64(u[:8], rng.Uint64())
+	binary.LittleEndian.PutUint64(u[8:], rng.Uint64())
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return u
+}
@@ -0,0 +1,92 @@
+package anonymize
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func synthetic(t *testing.T, a *Anonymizer, value protos.SyntheticValue, qv qvalue.QValue) qvalue.QValue {
+	t.Helper()
+	replaced, err := a.Value(value, qv)
+	require.NoError(t, err)
+	return replaced
+}
+
+func TestValueDeterministic(t *testing.T) {
+	a := NewAnonymizer("seed")
+	email := qvalue.QValueString{Val: "jane.doe@acme.io"}
+	replaced := synthetic(t, a, protos.SyntheticValue_SYNTHETIC_EMAIL, email)
+	require.Regexp(t, regexp.MustCompile(`^[a-z]+\.[a-z]+\d+@example\.(com|org|net)$`), replaced.Value())
+	require.Equal(t, replaced, synthetic(t, a, protos.SyntheticValue_SYNTHETIC_EMAIL, email))
+	require.NotEqual(t, replaced, synthetic(t, NewAnonymizer("other"), protos.SyntheticValue_SYNTHETIC_EMAIL, email))
+
+	require.Equal(t, qvalue.QValueNull(qvalue.QValueKindString),
+		synthetic(t, a, protos.SyntheticValue_SYNTHETIC_EMAIL, qvalue.QValueNull(qvalue.QValueKindString)))
+	require.Equal(t, qvalue.QValueNull(qvalue.QValueKindInt64),
+		synthetic(t, a, protos.SyntheticValue_SYNTHETIC_NULL, qvalue.QValueInt64{Val: 1}))
+}
+
+func TestValueFormatPreserving(t *testing.T) {
+	a := NewAnonymizer("seed")
+	replaced := synthetic(t, a, protos.SyntheticValue_SYNTHETIC_FORMAT, qvalue.QValueString{Val: "AB-1234-cd"})
+	require.Regexp(t, regexp.MustCompile(`^[A-Z]{2}-\d{4}-[a-z]{2}$`), replaced.Value())
+
+	replaced = synthetic(t, a, protos.SyntheticValue_SYNTHETIC_PHONE, qvalue.QValueString{Val: "+1 (415) 555-0123"})
+	require.Regexp(t, regexp.MustCompile(`^\+\d \(\d{3}\) \d{3}-\d{4}$`), replaced.Value())
+
+	for _, n := range []int64{0, 7, 42, -1234, math.MaxInt64, math.MinInt64} {
+		replaced := synthetic(t, a, protos.SyntheticValue_SYNTHETIC_FORMAT, qvalue.QValueInt64{Val: n}).(qvalue.QValueInt64)
+		require.Equal(t, n < 0, replaced.Val < 0)
+		require.Len(t, strconv.FormatInt(replaced.Val, 10), len(strconv.FormatInt(n, 10)))
+	}
+	replaced16 := synthetic(t, a, protos.SyntheticValue_SYNTHETIC_FORMAT, qvalue.QValueInt16{Val: 32000}).(qvalue.QValueInt16)
+	require.GreaterOrEqual(t, replaced16.Val, int16(10000))
+}
+
+func TestValueCreditCard(t *testing.T) {
+	a := NewAnonymizer("seed")
+	replaced := synthetic(t, a, protos.SyntheticValue_SYNTHETIC_CREDIT_CARD,
+		qvalue.QValueString{Val: "4111 1111 1111 1111"}).Value().(string)
+	require.Regexp(t, regexp.MustCompile(`^4\d{3} \d{4} \d{4} \d{4}$`), replaced)
+
+	sum := 0
+	double := false
+	for i := len(replaced) - 1; i >= 0; i-- {
+		if replaced[i] == ' ' {
+			continue
+		}
+		d := int(replaced[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	require.Zero(t, sum%10)
+}
+
+func TestValueDate(t *testing.T) {
+	a := NewAnonymizer("seed")
+	ts := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	replaced := synthetic(t, a, protos.SyntheticValue_SYNTHETIC_DATE, qvalue.QValueTimestampTZ{Val: ts}).(qvalue.QValueTimestampTZ)
+	require.NotEqual(t, ts, replaced.Val)
+	require.LessOrEqual(t, replaced.Val.Sub(ts).Abs(), maxDateShiftDays*24*time.Hour)
+}
+
+func TestValueUnsupported(t *testing.T) {
+	_, err := NewAnonymizer("seed").Value(protos.SyntheticValue_SYNTHETIC_EMAIL, qvalue.QValueInt64{Val: 1})
+	require.Error(t, err)
+	require.True(t, Supports(protos.SyntheticValue_SYNTHETIC_UUID, qvalue.QValueKindUUID))
+	require.False(t, Supports(protos.SyntheticValue_SYNTHETIC_DATE, qvalue.QValueKindString))
+}
@@ -0,0 +1,37 @@
+package anonymize
+
+var firstNames = []string{
+	"Aaliyah", "Aaron", "Abigail", "Adrian", "Aisha", "Alejandro", "Alice", "Amara", "Andre", "Anna",
+	"Ben", "Bianca", "Carlos", "Chloe", "Chen", "Daniel", "Diego", "Elena", "Emma", "Ethan",
+	"Fatima", "Felix", "Grace", "Hana", "Hassan", "Isabel", "Ivan", "Jamal", "Julia", "Kai",
+	"Kenji", "Laila", "Leo", "Lucia", "Marcus", "Maya", "Mateo", "Mei", "Nadia", "Noah",
+	"Olivia", "Omar", "Priya", "Rafael", "Rosa", "Sam", "Sofia", "Tariq", "Uma", "Victor",
+	"Wei", "Yara", "Yusuf", "Zara", "Zoe",
+}
+
+var lastNames = []string{
+	"Adams", "Ahmed", "Alvarez", "Anderson", "Bauer", "Brown", "Campbell", "Chen", "Clark", "Costa",
+	"Davis", "Diaz", "Dubois", "Evans", "Fernandez", "Fischer", "Garcia", "Gupta", "Hall", "Hernandez",
+	"Ito", "Jackson", "Johnson", "Kim", "Kowalski", "Lee", "Lopez", "Martin", "Meyer", "Miller",
+	"Moore", "Nguyen", "Novak", "Okafor", "Patel", "Perez", "Rossi", "Santos", "Schmidt", "Silva",
+	"Singh", "Smith", "Suzuki", "Taylor", "Thomas", "Walker", "Wang", "White", "Williams", "Wilson",
+	"Yamamoto", "Young", "Zhang",
+}
+
+var cities = []string{
+	"Ashford", "Bayview", "Brookfield", "Cedar Falls", "Clearwater", "Crestwood", "Eastport", "Fairview",
+	"Glenwood", "Greenville", "Harborside", "Highland", "Lakeside", "Maplewood", "Meadowbrook", "Millbrook",
+	"Northfield", "Oakridge", "Pinehurst", "Riverside", "Rockport", "Springfield", "Stonebridge", "Summit",
+	"Westbrook", "Willowdale",
+}
+
+var streetNames = []string{
+	"Aspen", "Birch", "Cedar", "Cherry", "Chestnut", "Church", "Elm", "Forest", "Hickory", "Hill",
+	"Lake", "Laurel", "Maple", "Meadow", "Mill", "Oak", "Park", "Pine", "River", "Spring",
+	"Sunset", "Valley", "Walnut", "Washington", "Willow",
+}
+
+var streetSuffixes = []string{"Street", "Avenue", "Road", "Lane", "Drive", "Court", "Boulevard", "Way", "Place"}
+
+// reserved for documentation, mail sent to them never reaches anyone
+var emailDomains = []string{"example.com", "example.org", "example.net"}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AppendColumn is a destination column of an append statement with the expression selecting its value
+type AppendColumn struct {
+	Name string
+	Expr string
+}
+
+// AppendStatement normalizes tables without a primary key, where every insert and update is a new row
+type AppendStatement struct {
+	QuoteIdentifier func(string) string
+	// quoted destination table
+	DstTable string
+	// raw records to insert, the FROM clause along with conditions leaving out other batches, tables and deletes
+	From              string
+	Columns           []AppendColumn
+	SoftDeleteColName string
+	// value of the soft delete column for rows which aren't deleted
+	NotDeleted      string
+	SyncedAtColName string
+	// current time in the dialect of the destination
+	Now string
+}
+
+func (s *AppendStatement) SQL() string {
+	insertColumns := make([]string, 0, len(s.Columns)+2)
+	projection := make([]string, 0, len(s.Columns)+2)
+	for _, column := range s.Columns {
+		insertColumns = append(insertColumns, s.QuoteIdentifier(column.Name))
+		projection = append(projection, column.Expr)
+	}
+	if s.SoftDeleteColName != "" {
+		insertColumns = append(insertColumns, s.QuoteIdentifier(s.SoftDeleteColName))
+		projection = append(projection, s.NotDeleted)
+	}
+	if s.SyncedAtColName != "" {
+		insertColumns = append(insertColumns, s.QuoteIdentifier(s.SyncedAtColName))
+		projection = append(projection, s.Now)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+		s.DstTable, strings.Join(insertColumns, ","), strings.Join(projection, ","), s.From)
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendStatement(t *testing.T) {
+	stmt := &AppendStatement{
+		QuoteIdentifier: func(name string) string { return "`" + name + "`" },
+		DstTable:        "`dst`",
+		From:            "`raw` WHERE _peerdb_record_type != 2",
+		Columns:         []AppendColumn{{Name: "id", Expr: "raw_id"}, {Name: "v", Expr: "raw_v"}},
+		NotDeleted:      "FALSE",
+		Now:             "now()",
+	}
+	require.Equal(t, "INSERT INTO `dst` (`id`,`v`) SELECT raw_id,raw_v FROM `raw` WHERE _peerdb_record_type != 2", stmt.SQL())
+
+	stmt.SoftDeleteColName = "_deleted"
+	stmt.SyncedAtColName = "_synced_at"
+	require.Equal(t, "INSERT INTO `dst` (`id`,`v`,`_deleted`,`_synced_at`) SELECT raw_id,raw_v,FALSE,now()"+
+		" FROM `raw` WHERE _peerdb_record_type != 2", stmt.SQL())
+}
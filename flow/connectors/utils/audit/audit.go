@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Statement is a DDL or merge statement executed against a destination
+type Statement struct {
+	ExecutedAt time.Time
+	Kind       string
+	Statement  string
+}
+
+// Log buffers statements executed by connectors while an activity runs,
+// so they can be saved to catalog once it is known which batch they belong to
+type Log struct {
+	statements []Statement
+	mu         sync.Mutex
+}
+
+type logKey struct{}
+
+// WithLog returns a context which captures statements recorded by connectors into the returned Log
+func WithLog(ctx context.Context) (context.Context, *Log) {
+	log := &Log{}
+	return context.WithValue(ctx, logKey{}, log), log
+}
+
+// Record adds statement to the Log attached to ctx with literals redacted, does nothing when there is none
+func Record(ctx context.Context, statement string) {
+	log, ok := ctx.Value(logKey{}).(*Log)
+	if !ok {
+		return
+	}
+	redacted := Redact(statement)
+	entry := Statement{
+		ExecutedAt: time.Now(),
+		Kind:       statementKind(redacted),
+		Statement:  redacted,
+	}
+	log.mu.Lock()
+	log.statements = append(log.statements, entry)
+	log.mu.Unlock()
+}
+
+// Statements returns what has been recorded so far and resets the Log
+func (l *Log) Statements() []Statement {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	statements := l.statements
+	l.statements = nil
+	return statements
+}
+
+// string literals may contain credentials or row data, quotes inside literals are escaped by doubling
+var literalRe = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// Redact replaces string literals in statement with ?, bind parameters are never recorded in the first place
+func Redact(statement string) string {
+	return literalRe.ReplaceAllLiteralString(statement, "?")
+}
+
+func statementKind(statement string) string {
+	fields := strings.Fields(statement)
+	if len(fields) == 0 {
+		return ""
+	}
+	kind := strings.ToUpper(fields[0])
+	if kind != "WITH" {
+		return kind
+	}
+	// CTEs precede the statement they belong to, e.g. PG normalize is WITH ... INSERT
+	for _, field := range fields[1:] {
+		switch field = strings.ToUpper(field); field {
+		case "INSERT", "UPDATE", "DELETE", "MERGE":
+			return field
+		}
+	}
+	return kind
+}
@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	require.Equal(t,
+		"CREATE STAGE s URL = ? CREDENTIALS = (AWS_KEY_ID = ? AWS_SECRET_KEY = ?)",
+		Redact("CREATE STAGE s URL = 's3://bucket/path' CREDENTIALS = (AWS_KEY_ID = 'key' AWS_SECRET_KEY = 'it''s')"))
+	require.Equal(t, `ALTER TABLE "t" ADD COLUMN "c" TEXT`, Redact(`ALTER TABLE "t" ADD COLUMN "c" TEXT`))
+}
+
+func TestRecord(t *testing.T) {
+	// without a log recording is a no-op
+	Record(context.Background(), "DROP TABLE t")
+
+	ctx, log := WithLog(context.Background())
+	Record(ctx, "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED AND s.op = 'd' THEN DELETE")
+	Record(ctx, "WITH src AS (SELECT 1) INSERT INTO t SELECT * FROM src")
+	statements := log.Statements()
+	require.Len(t, statements, 2)
+	require.Equal(t, "MERGE", statements[0].Kind)
+	require.Equal(t, "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED AND s.op = ? THEN DELETE", statements[0].Statement)
+	require.Equal(t, "INSERT", statements[1].Kind)
+	require.Empty(t, log.Statements())
+}
@@ -189,7 +189,7 @@ func (p *peerDBOCFWriter) WriteOCF(ctx context.Context, w io.Writer) (int, error
 }
 
 func (p *peerDBOCFWriter) WriteRecordsToS3(
-	ctx context.Context, bucketName, key string, s3Creds utils.AWSCredentialsProvider,
+	ctx context.Context, bucketName, key string, s3Creds utils.AWSCredentialsProvider, kmsKeyID string,
 ) (*AvroFile, error) {
 	logger := logger.LoggerFromCtx(ctx)
 	s3svc, err := utils.CreateS3Client(ctx, s3Creds)
@@ -217,11 +217,11 @@ func (p *peerDBOCFWriter) WriteRecordsToS3(
 		numRows, writeOcfError = p.WriteOCF(ctx, w)
 	}()
 
-	_, err = manager.NewUploader(s3svc).Upload(ctx, &s3.PutObjectInput{
+	_, err = manager.NewUploader(s3svc).Upload(ctx, utils.SSEKMS(&s3.PutObjectInput{
 		Bucket: aws.String(bucketName),
 		Key:    aws.String(key),
 		Body:   r,
-	})
+	}, kmsKeyID))
 	if err != nil {
 		s3Path := "s3://" + bucketName + "/" + key
 		logger.Error("failed to upload file: ", slog.Any("error", err), slog.Any("s3_path", s3Path))
@@ -240,7 +240,11 @@ func (p *peerDBOCFWriter) WriteRecordsToS3(
 	}, nil
 }
 
+// WriteRecordsToAvroFile writes to a temporary file at filePath, which is in the spill directory
 func (p *peerDBOCFWriter) WriteRecordsToAvroFile(ctx context.Context, filePath string) (*AvroFile, error) {
+	if err := utils.CheckSpillQuota(); err != nil {
+		return nil, err
+	}
 	file, err := os.Create(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temporary Avro file: %w", err)
@@ -258,7 +262,7 @@ func (p *peerDBOCFWriter) WriteRecordsToAvroFile(ctx context.Context, filePath s
 	defer shutdown()
 
 	buffSizeBytes := 1 << 26 // 64 MB
-	bufferedWriter := bufio.NewWriterSize(file, buffSizeBytes)
+	bufferedWriter := bufio.NewWriterSize(utils.NewSpillQuotaWriter(file), buffSizeBytes)
 	defer bufferedWriter.Flush()
 
 	numRecords, err := p.WriteOCF(ctx, bufferedWriter)
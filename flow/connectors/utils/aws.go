@@ -15,7 +15,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	smithyendpoints "github.com/aws/smithy-go/endpoints"
 	"github.com/google/uuid"
 
@@ -325,18 +327,45 @@ func (lt *RecalculateV4Signature) RoundTrip(req *http.Request) (*http.Response,
 	return lt.next.RoundTrip(req)
 }
 
+// S3BucketRegion returns the region bucket is in, S3 compatible services being taken to be in the configured region
+func S3BucketRegion(ctx context.Context, credsProvider AWSCredentialsProvider, bucket string) (string, error) {
+	if credsProvider.GetEndpointURL() != "" {
+		return credsProvider.GetRegion(), nil
+	}
+	client, err := CreateS3Client(ctx, credsProvider)
+	if err != nil {
+		return "", fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	region, err := manager.GetBucketRegion(ctx, client, bucket)
+	if err != nil {
+		return "", fmt.Errorf("failed to get region of bucket %s: %w", bucket, err)
+	}
+	return region, nil
+}
+
+// SSEKMS has an upload encrypted server-side under KMS key kmsKeyID, so staged data is encrypted with a customer-managed key
+// whatever the default encryption of the bucket. Empty leaves the upload to the default encryption.
+// Encryption is server-side rather than client-side as warehouses loading staged files have to read them
+func SSEKMS(input *s3.PutObjectInput, kmsKeyID string) *s3.PutObjectInput {
+	if kmsKeyID != "" {
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = aws.String(kmsKeyID)
+	}
+	return input
+}
+
 // Write an empty file and then delete it
-// to check if we have write permissions
-func PutAndRemoveS3(ctx context.Context, client *s3.Client, bucket string, prefix string) error {
+// to check if we have write permissions, and with kmsKeyID set permissions to encrypt under the key
+func PutAndRemoveS3(ctx context.Context, client *s3.Client, bucket string, prefix string, kmsKeyID string) error {
 	reader := strings.NewReader(time.Now().Format(time.RFC3339))
 	bucketName := aws.String(bucket)
 	temporaryObjectPath := prefix + "/" + _peerDBCheck + uuid.New().String()
 	temporaryObjectPath = strings.TrimPrefix(temporaryObjectPath, "/")
-	_, putErr := client.PutObject(ctx, &s3.PutObjectInput{
+	_, putErr := client.PutObject(ctx, SSEKMS(&s3.PutObjectInput{
 		Bucket: bucketName,
 		Key:    aws.String(temporaryObjectPath),
 		Body:   reader,
-	})
+	}, kmsKeyID))
 	if putErr != nil {
 		return fmt.Errorf("failed to write to bucket: %w", putErr)
 	}
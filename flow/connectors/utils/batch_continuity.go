@@ -0,0 +1,33 @@
+package utils
+
+import "fmt"
+
+// BatchGapError is a destination offset beyond where the batch it last synced ended,
+// changes from the end of that batch up to the offset were never synced and pulling on from it would lose them
+type BatchGapError struct {
+	FlowName          string
+	BatchID           int64
+	BatchEndOffset    int64
+	DestinationOffset int64
+}
+
+func (e *BatchGapError) Error() string {
+	return fmt.Sprintf("gap in changes of mirror %s: destination metadata is at offset %d after batch %d, "+
+		"which ended at offset %d in catalog, changes after offset %d up to %d were never synced",
+		e.FlowName, e.DestinationOffset, e.BatchID, e.BatchEndOffset, e.BatchEndOffset, e.DestinationOffset)
+}
+
+// CheckBatchContinuity checks the next batch starts where batchID ended,
+// batchEndOffset being its end recorded in catalog, 0 when unknown.
+// Offsets behind the end resend changes, which normalize applies idempotently, so only gaps are errors
+func CheckBatchContinuity(flowName string, batchID int64, batchEndOffset int64, destinationOffset int64) error {
+	if batchEndOffset == 0 || destinationOffset <= batchEndOffset {
+		return nil
+	}
+	return &BatchGapError{
+		FlowName:          flowName,
+		BatchID:           batchID,
+		BatchEndOffset:    batchEndOffset,
+		DestinationOffset: destinationOffset,
+	}
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBatchContinuity(t *testing.T) {
+	require.NoError(t, CheckBatchContinuity("mirror", 4, 1000, 1000))
+	// unknown end, e.g. catalog failed to record it
+	require.NoError(t, CheckBatchContinuity("mirror", 4, 0, 1000))
+	// resending changes is safe
+	require.NoError(t, CheckBatchContinuity("mirror", 4, 1000, 900))
+
+	err := CheckBatchContinuity("mirror", 4, 1000, 1500)
+	var gapErr *BatchGapError
+	require.True(t, errors.As(err, &gapErr))
+	require.Equal(t, BatchGapError{FlowName: "mirror", BatchID: 4, BatchEndOffset: 1000, DestinationOffset: 1500}, *gapErr)
+	require.Contains(t, err.Error(), "changes after offset 1000 up to 1500 were never synced")
+}
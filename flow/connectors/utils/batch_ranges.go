@@ -0,0 +1,27 @@
+package utils
+
+// BatchRange is the batches after Start up to and including End
+type BatchRange struct {
+	Start int64
+	End   int64
+}
+
+// NormalizeBatchRanges splits the batches after startBatchID up to syncBatchID around skippedBatches,
+// for connectors normalizing ranges of batches at once
+func NormalizeBatchRanges(startBatchID int64, syncBatchID int64, skippedBatches []int64) []BatchRange {
+	var ranges []BatchRange
+	start := startBatchID
+	for _, skipped := range skippedBatches {
+		if skipped <= start || skipped > syncBatchID {
+			continue
+		}
+		if skipped-1 > start {
+			ranges = append(ranges, BatchRange{Start: start, End: skipped - 1})
+		}
+		start = skipped
+	}
+	if syncBatchID > start {
+		ranges = append(ranges, BatchRange{Start: start, End: syncBatchID})
+	}
+	return ranges
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeBatchRanges(t *testing.T) {
+	require.Equal(t, []BatchRange{{Start: 2, End: 6}}, NormalizeBatchRanges(2, 6, nil))
+	require.Equal(t, []BatchRange{{Start: 2, End: 3}, {Start: 5, End: 6}},
+		NormalizeBatchRanges(2, 6, []int64{1, 4, 5, 9}))
+	// skipping the first or last batch
+	require.Equal(t, []BatchRange{{Start: 3, End: 5}}, NormalizeBatchRanges(2, 6, []int64{3, 6}))
+	require.Empty(t, NormalizeBatchRanges(2, 3, []int64{3}))
+}
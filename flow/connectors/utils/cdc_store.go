@@ -22,6 +22,9 @@ import (
 	"github.com/PeerDB-io/peer-flow/shared"
 )
 
+// spillQuotaCheckRecords is how many records are spilled between checks of the spill quota
+const spillQuotaCheckRecords = 10000
+
 func encVal(val any) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	enc := gob.NewEncoder(buf)
@@ -42,6 +45,7 @@ type cdcStore[Items model.Items] struct {
 	memThresholdBytes         uint64
 	numRecords                atomic.Int32
 	numRecordsSwitchThreshold int
+	spilledSinceQuotaCheck    int
 }
 
 func NewCDCStore[Items model.Items](ctx context.Context, env map[string]string, flowJobName string) (*cdcStore[Items], error) {
@@ -59,7 +63,6 @@ func NewCDCStore[Items model.Items](ctx context.Context, env map[string]string,
 		pebbleDB:                  nil,
 		numRecords:                atomic.Int32{},
 		flowJobName:               flowJobName,
-		dbFolderName:              fmt.Sprintf("%s/%s_%s", os.TempDir(), flowJobName, shared.RandomString(8)),
 		numRecordsSwitchThreshold: int(numRecordsSwitchThreshold),
 		memThresholdBytes: func() uint64 {
 			maxMemBytes := peerdbenv.PeerDBFlowWorkerMaxMemBytes()
@@ -128,7 +131,14 @@ func (c *cdcStore[T]) initPebbleDB() error {
 	gob.Register(&model.RelationRecord[T]{})
 	gob.Register(&model.MessageRecord[T]{})
 
-	var err error
+	if err := CheckSpillQuota(); err != nil {
+		return err
+	}
+	spillDir, err := SpillDir(c.flowJobName)
+	if err != nil {
+		return err
+	}
+	c.dbFolderName = fmt.Sprintf("%s/cdc_%s", spillDir, shared.RandomString(8))
 	// we don't want a WAL since cache, we don't want to overwrite another DB either
 	c.pebbleDB, err = pebble.Open(c.dbFolderName, &pebble.Options{
 		DisableWAL:         true,
@@ -174,6 +184,14 @@ func (c *cdcStore[T]) Set(logger log.Logger, key model.TableWithPkey, rec model.
 				}
 			}
 
+			if c.spilledSinceQuotaCheck >= spillQuotaCheckRecords {
+				c.spilledSinceQuotaCheck = 0
+				if err := CheckSpillQuota(); err != nil {
+					return err
+				}
+			}
+			c.spilledSinceQuotaCheck += 1
+
 			encodedKey, err := encVal(key)
 			if err != nil {
 				return err
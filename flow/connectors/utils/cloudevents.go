@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+const cloudEventsSpecVersion = "1.0"
+
+// CloudEvent holds the context attributes of a change delivered as a CloudEvents 1.0 event,
+// id is the idempotency key of the change so it is the same on every delivery
+type CloudEvent struct {
+	Time            time.Time
+	ID              string
+	Source          string
+	Type            string
+	Subject         string
+	DataContentType string
+}
+
+func NewCloudEvent(
+	flowJobName string,
+	record model.Record[model.RecordItems],
+	pkeyCols []string,
+	index int,
+	dataContentType string,
+) CloudEvent {
+	return CloudEvent{
+		Time:            record.GetCommitTime(),
+		ID:              model.IdempotencyKey(record, pkeyCols, index),
+		Source:          "/peerdb/mirrors/" + flowJobName,
+		Type:            "io.peerdb.cdc." + record.Kind(),
+		Subject:         record.GetDestinationTableName(),
+		DataContentType: dataContentType,
+	}
+}
+
+// QueueContentType is the media type of payloads, scripts may produce anything so they are sniffed
+func QueueContentType(script string, encoding protos.QueueEncoding, data []byte) string {
+	if script != "" {
+		if json.Valid(data) {
+			return "application/json"
+		}
+		return "application/octet-stream"
+	}
+	switch encoding {
+	case protos.QueueEncoding_QUEUE_ENCODING_MSGPACK:
+		return "application/msgpack"
+	case protos.QueueEncoding_QUEUE_ENCODING_PROTOBUF:
+		return "application/protobuf"
+	default:
+		return "application/json"
+	}
+}
+
+// Attributes are the context attributes besides datacontenttype for binary mode,
+// where each protocol binding prefixes them and carries data as is
+func (e CloudEvent) Attributes() map[string]string {
+	return map[string]string{
+		"specversion": cloudEventsSpecVersion,
+		"id":          e.ID,
+		"source":      e.Source,
+		"type":        e.Type,
+		"subject":     e.Subject,
+		"time":        e.Time.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// Structured wraps data in a JSON envelope for structured mode, data which is not JSON goes in data_base64
+func (e CloudEvent) Structured(data []byte) ([]byte, error) {
+	envelope := struct {
+		SpecVersion     string          `json:"specversion"`
+		ID              string          `json:"id"`
+		Source          string          `json:"source"`
+		Type            string          `json:"type"`
+		Subject         string          `json:"subject"`
+		Time            string          `json:"time"`
+		DataContentType string          `json:"datacontenttype"`
+		Data            json.RawMessage `json:"data,omitempty"`
+		DataBase64      []byte          `json:"data_base64,omitempty"`
+	}{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              e.ID,
+		Source:          e.Source,
+		Type:            e.Type,
+		Subject:         e.Subject,
+		Time:            e.Time.UTC().Format(time.RFC3339Nano),
+		DataContentType: e.DataContentType,
+	}
+	if e.DataContentType == "application/json" && json.Valid(data) {
+		envelope.Data = data
+	} else {
+		envelope.DataBase64 = data
+	}
+	return json.Marshal(envelope)
+}
+
+// ContentType is the content type of messages carrying e in mode
+func (e CloudEvent) ContentType(mode protos.CloudEventsMode) string {
+	if mode == protos.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED {
+		return "application/cloudevents+json"
+	}
+	return e.DataContentType
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// SetCollapseWindow makes streamReq collapse changes of keys within windows of records,
+// unless the mirror keeps every change as a changelog
+func SetCollapseWindow[Items model.Items](
+	ctx context.Context,
+	req *model.SyncRecordsRequest[Items],
+	streamReq *model.RecordsToStreamRequest[Items],
+) error {
+	if req.ChangelogMode {
+		return nil
+	}
+	window, err := peerdbenv.PeerDBCDCCollapseWindow(ctx, req.Env)
+	if err != nil {
+		return err
+	}
+	streamReq.CollapseWindow = int(window)
+	streamReq.TableNameSchemaMapping = req.TableNameSchemaMapping
+	return nil
+}
+
+// recordCollapser buffers records, keeping only the latest change of every key.
+// Normalize merges the latest change of a key into destination tables anyway, and updates carry
+// unchanged TOAST columns backfilled from earlier changes of their batch, so only raw rows are saved
+type recordCollapser[Items model.Items] struct {
+	schemas map[string]*protos.TableSchema
+	// key to index of its latest change in records
+	keys    map[model.TableWithPkey]int
+	records []model.Record[Items]
+	window  int
+}
+
+func newRecordCollapser[Items model.Items](window int, schemas map[string]*protos.TableSchema) *recordCollapser[Items] {
+	return &recordCollapser[Items]{
+		schemas: schemas,
+		keys:    make(map[model.TableWithPkey]int),
+		records: make([]model.Record[Items], 0, min(window, 1<<14)),
+		window:  window,
+	}
+}
+
+func (c *recordCollapser[Items]) add(record model.Record[Items]) error {
+	switch record.(type) {
+	case *model.InsertRecord[Items], *model.UpdateRecord[Items], *model.DeleteRecord[Items]:
+	default:
+		c.records = append(c.records, record)
+		return nil
+	}
+	// without a primary key every row is a key of its own
+	schema := c.schemas[record.GetDestinationTableName()]
+	if schema == nil || schema.IsReplicaIdentityFull || len(schema.PrimaryKeyColumns) == 0 {
+		c.records = append(c.records, record)
+		return nil
+	}
+	key, err := model.RecToTablePKey(c.schemas, record)
+	if err != nil {
+		return err
+	}
+
+	i, ok := c.keys[key]
+	if !ok {
+		c.keys[key] = len(c.records)
+		c.records = append(c.records, record)
+		return nil
+	}
+	// a key inserted within the window stays an insert of its final image
+	if update, ok := record.(*model.UpdateRecord[Items]); ok && len(update.UnchangedToastColumns) == 0 {
+		if insert, ok := c.records[i].(*model.InsertRecord[Items]); ok {
+			record = &model.InsertRecord[Items]{
+				BaseRecord:           update.BaseRecord,
+				Items:                update.NewItems,
+				SourceTableName:      update.SourceTableName,
+				DestinationTableName: update.DestinationTableName,
+				CommitID:             insert.CommitID,
+			}
+		}
+	}
+	c.records[i] = record
+	return nil
+}
+
+func (c *recordCollapser[Items]) full() bool {
+	return len(c.records) >= c.window
+}
+
+// flush returns records of the window and starts a new one
+func (c *recordCollapser[Items]) flush() []model.Record[Items] {
+	records := c.records
+	c.records = make([]model.Record[Items], 0, cap(records))
+	clear(c.keys)
+	return records
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func collapseItems(id int64, name string) model.RecordItems {
+	items := model.NewRecordItems(2)
+	items.AddColumn("id", qvalue.QValueInt64{Val: id})
+	items.AddColumn("name", qvalue.QValueString{Val: name})
+	return items
+}
+
+func TestRecordCollapser(t *testing.T) {
+	collapser := newRecordCollapser[model.RecordItems](6, map[string]*protos.TableSchema{
+		"users": {PrimaryKeyColumns: []string{"id"}},
+		"logs":  {},
+	})
+	records := []model.Record[model.RecordItems]{
+		&model.InsertRecord[model.RecordItems]{DestinationTableName: "users", Items: collapseItems(1, "a"), CommitID: 7},
+		&model.UpdateRecord[model.RecordItems]{DestinationTableName: "users", NewItems: collapseItems(2, "b")},
+		&model.UpdateRecord[model.RecordItems]{DestinationTableName: "users", NewItems: collapseItems(1, "c")},
+		&model.InsertRecord[model.RecordItems]{DestinationTableName: "logs", Items: collapseItems(1, "x")},
+		&model.InsertRecord[model.RecordItems]{DestinationTableName: "logs", Items: collapseItems(1, "x")},
+		&model.UpdateRecord[model.RecordItems]{DestinationTableName: "users", NewItems: collapseItems(2, "d")},
+		&model.DeleteRecord[model.RecordItems]{DestinationTableName: "users", Items: collapseItems(1, "c")},
+	}
+	for _, record := range records {
+		require.NoError(t, collapser.add(record))
+	}
+	require.False(t, collapser.full())
+
+	collapsed := collapser.flush()
+	require.Len(t, collapsed, 4)
+	require.Equal(t, records[6], collapsed[0])
+	require.Equal(t, records[5], collapsed[1])
+	require.Equal(t, records[3], collapsed[2])
+	require.Equal(t, records[4], collapsed[3])
+	require.Empty(t, collapser.flush())
+
+	// updates of keys inserted in the window become inserts of their final image
+	require.NoError(t, collapser.add(records[0]))
+	require.NoError(t, collapser.add(records[2]))
+	require.Equal(t, []model.Record[model.RecordItems]{&model.InsertRecord[model.RecordItems]{
+		DestinationTableName: "users", Items: collapseItems(1, "c"), CommitID: 7,
+	}}, collapser.flush())
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+type trackedOffset struct {
+	offset int64
+	acked  bool
+}
+
+// DurableOffset tracks records sent to a destination that acknowledges them out of order,
+// Durable only moves past a record once every record sent before it was acknowledged too,
+// so it is always safe to persist as the last offset and resume from after a partial batch
+type DurableOffset struct {
+	pending []trackedOffset
+	first   uint64
+	durable int64
+	mu      sync.Mutex
+}
+
+func NewDurableOffset(initial int64) *DurableOffset {
+	return &DurableOffset{durable: initial}
+}
+
+// Track registers the next record sent in stream order, the returned function acknowledges it
+func (d *DurableOffset) Track(offset int64) func() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seq := d.first + uint64(len(d.pending))
+	d.pending = append(d.pending, trackedOffset{offset: offset})
+	return func() {
+		d.ack(seq)
+	}
+}
+
+func (d *DurableOffset) ack(seq uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if seq < d.first {
+		return
+	}
+	d.pending[seq-d.first].acked = true
+	for len(d.pending) > 0 && d.pending[0].acked {
+		d.durable = max(d.durable, d.pending[0].offset)
+		d.pending = d.pending[1:]
+		d.first += 1
+	}
+}
+
+// Durable returns the offset up to which all tracked records were acknowledged
+func (d *DurableOffset) Durable() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.durable
+}
+
+// SaveLastSeenOffset records how far a batch got, so a retry after a partial write resends from there on.
+// Sinks call it with the offset every record up to was written, ConsumedOffset of req follows once it's stored
+func SaveLastSeenOffset[Items model.Items](
+	ctx context.Context,
+	logger log.Logger,
+	req *model.SyncRecordsRequest[Items],
+	lastSeen int64,
+	setLastOffset func(ctx context.Context, jobName string, offset int64) error,
+) {
+	if lastSeen <= req.ConsumedOffset.Load() {
+		return
+	}
+	if err := setLastOffset(ctx, req.FlowJobName, lastSeen); err != nil {
+		logger.Warn("SetLastOffset error", slog.Any("error", err))
+	} else {
+		shared.AtomicInt64Max(req.ConsumedOffset, lastSeen)
+		logger.Info("processBatch", slog.Int64("updated last offset", lastSeen))
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+func TestDurableOffset(t *testing.T) {
+	d := NewDurableOffset(10)
+	ack20 := d.Track(20)
+	ack15 := d.Track(15)
+	ack30 := d.Track(30)
+	require.Equal(t, int64(10), d.Durable())
+
+	// later records acked first must wait for earlier ones
+	ack30()
+	require.Equal(t, int64(10), d.Durable())
+	ack20()
+	require.Equal(t, int64(20), d.Durable())
+	ack15()
+	require.Equal(t, int64(30), d.Durable())
+
+	ack40 := d.Track(40)
+	ack40()
+	ack40()
+	require.Equal(t, int64(40), d.Durable())
+}
+
+func TestSaveLastSeenOffset(t *testing.T) {
+	logger := log.NewStructuredLogger(slog.Default())
+	consumed := &atomic.Int64{}
+	consumed.Store(10)
+	req := &model.SyncRecordsRequest[model.RecordItems]{FlowJobName: "flow", ConsumedOffset: consumed}
+	var saved []int64
+	setLastOffset := func(_ context.Context, jobName string, offset int64) error {
+		require.Equal(t, "flow", jobName)
+		saved = append(saved, offset)
+		return nil
+	}
+
+	SaveLastSeenOffset(context.Background(), logger, req, 10, setLastOffset)
+	require.Empty(t, saved)
+	SaveLastSeenOffset(context.Background(), logger, req, 25, setLastOffset)
+	require.Equal(t, []int64{25}, saved)
+	require.Equal(t, int64(25), consumed.Load())
+
+	// consumed offset only moves once the offset is stored
+	SaveLastSeenOffset(context.Background(), logger, req, 30, func(context.Context, string, int64) error {
+		return errors.New("catalog down")
+	})
+	require.Equal(t, int64(25), consumed.Load())
+}
@@ -0,0 +1,138 @@
+// Package faults injects latency, connection drops and failures into connector calls of activities,
+// so e2e tests can exercise retries, idempotency and resync deterministically.
+// Faults are read from PEERDB_FAULT_INJECTION in the env of a mirror, a JSON list of Fault,
+// and only when the worker runs with PEERDB_FAULT_INJECTION_ENABLED=true, which is never the case outside tests
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const EnvKey = "PEERDB_FAULT_INJECTION"
+
+type Point string
+
+const (
+	PullRecords      Point = "pull_records"
+	SyncRecords      Point = "sync_records"
+	NormalizeRecords Point = "normalize_records"
+	QRepPull         Point = "qrep_pull"
+	QRepSync         Point = "qrep_sync"
+)
+
+type Kind string
+
+const (
+	// KindLatency only delays the call by LatencyMs
+	KindLatency Kind = "latency"
+	// KindError fails without making the call
+	KindError Kind = "error"
+	// KindDrop fails without making the call like the connection was reset
+	KindDrop Kind = "drop"
+	// KindPartial makes the call and fails after it succeeded, like a response lost after a commit
+	KindPartial Kind = "partial"
+)
+
+// Fault applies to calls at Point of a mirror, after Skip calls for the next Times calls, 0 being every call
+type Fault struct {
+	Point     Point `json:"point"`
+	Kind      Kind  `json:"kind"`
+	LatencyMs int64 `json:"latency_ms"`
+	Skip      int64 `json:"skip"`
+	Times     int64 `json:"times"`
+}
+
+var ErrInjected = errors.New("injected fault")
+
+var (
+	// calls seen of each fault, keyed by mirror, faults and index so changing faults starts over
+	calls   = map[string]*atomic.Int64{}
+	callsMu sync.Mutex
+)
+
+func callCount(key string) *atomic.Int64 {
+	callsMu.Lock()
+	defer callsMu.Unlock()
+	count, ok := calls[key]
+	if !ok {
+		count = &atomic.Int64{}
+		calls[key] = count
+	}
+	return count
+}
+
+// Reset forgets calls counted so far
+func Reset() {
+	callsMu.Lock()
+	defer callsMu.Unlock()
+	calls = map[string]*atomic.Int64{}
+}
+
+func parse(env map[string]string) ([]Fault, string, error) {
+	if !peerdbenv.PeerDBFaultInjectionEnabled() {
+		return nil, "", nil
+	}
+	raw := env[EnvKey]
+	if raw == "" {
+		return nil, "", nil
+	}
+	var faults []Fault
+	if err := json.Unmarshal([]byte(raw), &faults); err != nil {
+		return nil, "", fmt.Errorf("invalid %s: %w", EnvKey, err)
+	}
+	return faults, raw, nil
+}
+
+// Call runs call at point, applying the faults of the mirror env matching this call
+func Call[T any](ctx context.Context, env map[string]string, point Point, call func() (T, error)) (T, error) {
+	var none T
+	faults, raw, err := parse(env)
+	if err != nil {
+		return none, err
+	}
+	flowName, _ := ctx.Value(shared.FlowNameKey).(string)
+
+	var partial []Fault
+	for i, fault := range faults {
+		if fault.Point != point {
+			continue
+		}
+		n := callCount(fmt.Sprintf("%s\x00%s\x00%d", flowName, raw, i)).Add(1)
+		if n <= fault.Skip || (fault.Times > 0 && n > fault.Skip+fault.Times) {
+			continue
+		}
+		if fault.LatencyMs > 0 {
+			select {
+			case <-time.After(time.Duration(fault.LatencyMs) * time.Millisecond):
+			case <-ctx.Done():
+				return none, ctx.Err()
+			}
+		}
+		switch fault.Kind {
+		case KindError:
+			return none, fmt.Errorf("%w at %s", ErrInjected, point)
+		case KindDrop:
+			return none, fmt.Errorf("%w at %s: %w", ErrInjected, point,
+				&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET})
+		case KindPartial:
+			partial = append(partial, fault)
+		}
+	}
+
+	res, err := call()
+	if err == nil && len(partial) > 0 {
+		return none, fmt.Errorf("%w after %s succeeded", ErrInjected, point)
+	}
+	return res, err
+}
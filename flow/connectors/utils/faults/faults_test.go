@@ -0,0 +1,58 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+func TestCall(t *testing.T) {
+	t.Setenv("PEERDB_FAULT_INJECTION_ENABLED", "true")
+	Reset()
+	ctx := context.WithValue(context.Background(), shared.FlowNameKey, "m")
+	env := map[string]string{EnvKey: `[
+		{"point":"sync_records","kind":"error","skip":1,"times":1},
+		{"point":"normalize_records","kind":"partial","times":1},
+		{"point":"qrep_pull","kind":"drop"}]`}
+
+	calls := 0
+	call := func() (int, error) {
+		calls++
+		return calls, nil
+	}
+
+	// second sync fails without calling, others go through
+	res, err := Call(ctx, env, SyncRecords, call)
+	require.NoError(t, err)
+	require.Equal(t, 1, res)
+	_, err = Call(ctx, env, SyncRecords, call)
+	require.ErrorIs(t, err, ErrInjected)
+	require.Equal(t, 1, calls)
+	_, err = Call(ctx, env, SyncRecords, call)
+	require.NoError(t, err)
+
+	// partial failures happen after the call
+	_, err = Call(ctx, env, NormalizeRecords, call)
+	require.ErrorIs(t, err, ErrInjected)
+	require.Equal(t, 3, calls)
+
+	_, err = Call(ctx, env, QRepPull, call)
+	require.True(t, errors.Is(err, syscall.ECONNRESET))
+
+	// other mirrors count calls of their own
+	_, err = Call(context.WithValue(context.Background(), shared.FlowNameKey, "other"), env, NormalizeRecords, call)
+	require.ErrorIs(t, err, ErrInjected)
+}
+
+func TestCallDisabled(t *testing.T) {
+	t.Setenv("PEERDB_FAULT_INJECTION_ENABLED", "")
+	res, err := Call(context.Background(), map[string]string{EnvKey: `[{"point":"sync_records","kind":"error"}]`},
+		SyncRecords, func() (int, error) { return 1, nil })
+	require.NoError(t, err)
+	require.Equal(t, 1, res)
+}
@@ -85,7 +85,7 @@ func LoadScript(ctx context.Context, script string, printfn lua.LGFunction) (*lu
 
 func DefaultOnRecord(ls *lua.LState) int {
 	ud, record := pua.LuaRecord.Check(ls, 1)
-	switch record.(type) {
+	switch record := record.(type) {
 	case *model.InsertRecord[model.RecordItems],
 		*model.UpdateRecord[model.RecordItems],
 		*model.DeleteRecord[model.RecordItems]:
@@ -93,6 +93,15 @@ func DefaultOnRecord(ls *lua.LState) int {
 		ls.Push(ud)
 		ls.Call(1, 1)
 		return 1
+	case *model.MessageRecord[model.RecordItems]:
+		// messages without a destination have nowhere to go but scripts
+		if record.DestinationTableName == "" {
+			return 0
+		}
+		ls.Push(ls.NewFunction(gluajson.LuaJsonEncode))
+		ls.Push(ud)
+		ls.Call(1, 1)
+		return 1
 	default:
 		return 0
 	}
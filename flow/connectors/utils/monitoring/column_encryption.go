@@ -0,0 +1,70 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// GetColumnEncryptionKey returns a key columns are encrypted with, decrypted with the PeerDB key it's kept under
+func GetColumnEncryptionKey(ctx context.Context, pool *pgxpool.Pool, name string) ([]byte, error) {
+	var keyData []byte
+	var encKeyID string
+	if err := pool.QueryRow(ctx, "SELECT key_data, enc_key_id FROM column_encryption_keys WHERE name = $1",
+		name).Scan(&keyData, &encKeyID); errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("column encryption key %s not found", name)
+	} else if err != nil {
+		return nil, fmt.Errorf("error while getting column encryption key %s: %w", name, err)
+	}
+	key, err := peerdbenv.Decrypt(encKeyID, keyData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt column encryption key %s: %w", name, err)
+	}
+	return key, nil
+}
+
+// AddColumnEncryptionKey keeps a key for encrypting columns, encrypted with the current PeerDB key.
+// Keys can't be replaced as values already encrypted with one would no longer decrypt.
+func AddColumnEncryptionKey(ctx context.Context, pool *pgxpool.Pool, name string, key []byte) error {
+	encKey, err := peerdbenv.PeerDBCurrentEncKey()
+	if err != nil {
+		return err
+	}
+	keyData, err := encKey.Encrypt(key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt column encryption key: %w", err)
+	}
+	tag, err := pool.Exec(ctx, `INSERT INTO column_encryption_keys (name, key_data, enc_key_id) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO NOTHING`, name, keyData, encKey.ID)
+	if err != nil {
+		return fmt.Errorf("error while adding column encryption key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("column encryption key %s already exists", name)
+	}
+	return nil
+}
+
+// ListColumnEncryptionKeys returns names of keys for encrypting columns with when they were added
+func ListColumnEncryptionKeys(ctx context.Context, pool *pgxpool.Pool) (map[string]time.Time, error) {
+	rows, err := pool.Query(ctx, "SELECT name, created_at FROM column_encryption_keys")
+	if err != nil {
+		return nil, fmt.Errorf("error while listing column encryption keys: %w", err)
+	}
+	keys := make(map[string]time.Time)
+	var name string
+	var createdAt time.Time
+	if _, err := pgx.ForEachRow(rows, []any{&name, &createdAt}, func() error {
+		keys[name] = createdAt
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error while listing column encryption keys: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,55 @@
+package monitoring
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/shared/metrics"
+)
+
+// CatalogFlowMetrics keeps the latest health metrics of each mirror in catalog for the UI,
+// failing to write them is logged rather than failing the activity recording them
+type CatalogFlowMetrics struct {
+	pool *pgxpool.Pool
+}
+
+var _ metrics.Recorder = (*CatalogFlowMetrics)(nil)
+
+func NewCatalogFlowMetrics(pool *pgxpool.Pool) *CatalogFlowMetrics {
+	return &CatalogFlowMetrics{pool: pool}
+}
+
+func (m *CatalogFlowMetrics) upsert(ctx context.Context, flowName string, column string, excluded string, value any) {
+	if _, err := m.pool.Exec(ctx,
+		`INSERT INTO peerdb_stats.flow_metrics (flow_name, `+column+`) VALUES ($1, $2)
+		ON CONFLICT (flow_name) DO UPDATE SET `+column+` = `+excluded+`, updated_at = now()`,
+		flowName, value,
+	); err != nil {
+		logger.LoggerFromCtx(ctx).Warn("failed to record flow metrics in catalog",
+			slog.String("column", column), slog.Any("error", err))
+	}
+}
+
+func (m *CatalogFlowMetrics) RowsPulled(ctx context.Context, flowName string, rows int64) {
+	m.upsert(ctx, flowName, "rows_pulled", "flow_metrics.rows_pulled + excluded.rows_pulled", rows)
+}
+
+func (m *CatalogFlowMetrics) RowsSynced(ctx context.Context, flowName string, rows int64) {
+	m.upsert(ctx, flowName, "rows_synced", "flow_metrics.rows_synced + excluded.rows_synced", rows)
+}
+
+func (m *CatalogFlowMetrics) NormalizeBatchDuration(ctx context.Context, flowName string, duration time.Duration) {
+	m.upsert(ctx, flowName, "normalize_batch_duration_ms", "excluded.normalize_batch_duration_ms", duration.Milliseconds())
+}
+
+func (m *CatalogFlowMetrics) SlotLag(ctx context.Context, flowName string, slotName string, lagMB float64) {
+	m.upsert(ctx, flowName, "slot_lag_mb", "excluded.slot_lag_mb", lagMB)
+}
+
+func (m *CatalogFlowMetrics) SyncSucceeded(ctx context.Context, flowName string, syncedAt time.Time) {
+	m.upsert(ctx, flowName, "last_synced_at", "excluded.last_synced_at", syncedAt.UTC())
+}
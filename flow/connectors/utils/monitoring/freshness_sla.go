@@ -0,0 +1,119 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const (
+	FreshnessSLABreach   = "breach"
+	FreshnessSLARecovery = "recovery"
+)
+
+// GetFreshnessStaleness returns how far destination tables of a mirror are behind the source, from the oldest batch
+// not normalized yet as changes pulled since it started aren't in destination tables, 0 with every batch normalized
+func GetFreshnessStaleness(ctx context.Context, pool *pgxpool.Pool, flowJobName string, now time.Time) (time.Duration, error) {
+	var oldestPending *time.Time
+	if err := pool.QueryRow(ctx, `SELECT min(start_time) FROM peerdb_stats.cdc_batches
+		WHERE flow_name = $1 AND end_time IS NULL`, flowJobName).Scan(&oldestPending); err != nil {
+		return 0, fmt.Errorf("error while getting oldest pending batch: %w", err)
+	}
+	if oldestPending == nil {
+		return 0, nil
+	}
+	return max(now.Sub(*oldestPending), 0), nil
+}
+
+// RecordFreshnessSLAEvaluation saves the latest evaluation of the freshness SLA of a mirror,
+// returning the event recorded when it breached or recovered since the previous one, empty otherwise
+func RecordFreshnessSLAEvaluation(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	slaSeconds uint32,
+	staleness time.Duration,
+) (string, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error while starting transaction for freshness sla: %w", err)
+	}
+	defer shared.RollbackTx(tx, logger.LoggerFromCtx(ctx))
+
+	var wasBreached bool
+	if err := tx.QueryRow(ctx, `SELECT breached FROM peerdb_stats.freshness_sla_state WHERE flow_name = $1 FOR UPDATE`,
+		flowJobName).Scan(&wasBreached); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("error while getting freshness sla state: %w", err)
+	}
+	stalenessSeconds := int64(staleness.Seconds())
+	breached := stalenessSeconds > int64(slaSeconds)
+	if _, err := tx.Exec(ctx, `INSERT INTO peerdb_stats.freshness_sla_state
+		(flow_name, sla_seconds, staleness_seconds, breached, evaluated_at) VALUES ($1, $2, $3, $4, now())
+		ON CONFLICT (flow_name) DO UPDATE SET sla_seconds = excluded.sla_seconds,
+		staleness_seconds = excluded.staleness_seconds, breached = excluded.breached, evaluated_at = excluded.evaluated_at`,
+		flowJobName, slaSeconds, stalenessSeconds, breached); err != nil {
+		return "", fmt.Errorf("error while saving freshness sla state: %w", err)
+	}
+
+	var eventType string
+	if breached && !wasBreached {
+		eventType = FreshnessSLABreach
+	} else if !breached && wasBreached {
+		eventType = FreshnessSLARecovery
+	}
+	if eventType != "" {
+		if _, err := tx.Exec(ctx, `INSERT INTO peerdb_stats.freshness_sla_events
+			(flow_name, event_type, staleness_seconds, sla_seconds) VALUES ($1, $2, $3, $4)`,
+			flowJobName, eventType, stalenessSeconds, slaSeconds); err != nil {
+			return "", fmt.Errorf("error while saving freshness sla event: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("error while committing freshness sla state: %w", err)
+	}
+	return eventType, nil
+}
+
+// GetFreshnessSLAStatus returns the latest evaluation of the freshness SLA of a mirror with its recent events,
+// nil before the first evaluation
+func GetFreshnessSLAStatus(ctx context.Context, pool *pgxpool.Pool, flowJobName string) (*protos.FreshnessSLAStatus, error) {
+	var status protos.FreshnessSLAStatus
+	var evaluatedAt time.Time
+	if err := pool.QueryRow(ctx, `SELECT sla_seconds, staleness_seconds, breached, evaluated_at
+		FROM peerdb_stats.freshness_sla_state WHERE flow_name = $1`, flowJobName).Scan(
+		&status.SlaSeconds, &status.StalenessSeconds, &status.Breached, &evaluatedAt,
+	); errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error while getting freshness sla state: %w", err)
+	}
+	status.EvaluatedAt = timestamppb.New(evaluatedAt)
+
+	rows, err := pool.Query(ctx, `SELECT event_type, staleness_seconds, sla_seconds, created_at
+		FROM peerdb_stats.freshness_sla_events WHERE flow_name = $1 ORDER BY created_at DESC LIMIT 20`, flowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting freshness sla events: %w", err)
+	}
+	status.RecentEvents, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.FreshnessSLAEvent, error) {
+		var event protos.FreshnessSLAEvent
+		var createdAt time.Time
+		if err := row.Scan(&event.EventType, &event.StalenessSeconds, &event.SlaSeconds, &createdAt); err != nil {
+			return nil, err
+		}
+		event.CreatedAt = timestamppb.New(createdAt)
+		return &event, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while getting freshness sla events: %w", err)
+	}
+	return &status, nil
+}
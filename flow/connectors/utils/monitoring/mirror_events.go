@@ -0,0 +1,74 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// MirrorEventsChannel is notified with the mirror name whenever an event is appended,
+// so subscribers wake up without polling mirror_events
+const MirrorEventsChannel = "peerdb_mirror_events"
+
+// AppendMirrorEvent keeps an event of a mirror and notifies subscribers of it,
+// the notification is only delivered once the insert commits
+func AppendMirrorEvent(ctx context.Context, pool *pgxpool.Pool, event *protos.MirrorEvent) error {
+	if _, err := pool.Exec(ctx, `WITH inserted AS (INSERT INTO peerdb_stats.mirror_events
+		(flow_name, event_type, batch_id, num_rows, end_lsn, flow_status, message) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING flow_name) SELECT pg_notify($8, flow_name) FROM inserted`,
+		event.FlowJobName, event.EventType.String(), event.BatchId, event.NumRows, event.EndLsn,
+		int32(event.FlowStatus), event.Message, MirrorEventsChannel,
+	); err != nil {
+		return fmt.Errorf("error while appending mirror event: %w", err)
+	}
+	return nil
+}
+
+// GetMirrorEvents returns events kept after afterID in the order they were appended,
+// for every mirror when flowJobNames is empty
+func GetMirrorEvents(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobNames []string,
+	afterID int64,
+) ([]*protos.MirrorEvent, error) {
+	rows, err := pool.Query(ctx, `SELECT id, flow_name, event_type, batch_id, num_rows, end_lsn, flow_status, message,
+		created_at FROM peerdb_stats.mirror_events WHERE id > $1 AND (cardinality($2::text[]) = 0 OR flow_name = ANY($2))
+		ORDER BY id LIMIT 1000`, afterID, flowJobNames)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting mirror events: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.MirrorEvent, error) {
+		var event protos.MirrorEvent
+		var eventType string
+		var flowStatus int32
+		var createdAt time.Time
+		if err := row.Scan(&event.Id, &event.FlowJobName, &eventType, &event.BatchId, &event.NumRows, &event.EndLsn,
+			&flowStatus, &event.Message, &createdAt); err != nil {
+			return nil, err
+		}
+		event.EventType = protos.MirrorEventType(protos.MirrorEventType_value[eventType])
+		event.FlowStatus = protos.FlowStatus(flowStatus)
+		event.CreatedAt = timestamppb.New(createdAt)
+		return &event, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while getting mirror events: %w", err)
+	}
+	return events, nil
+}
+
+// GetLastMirrorEventID returns the id of the latest event kept for any mirror, 0 when none
+func GetLastMirrorEventID(ctx context.Context, pool *pgxpool.Pool) (int64, error) {
+	var id int64
+	if err := pool.QueryRow(ctx, "SELECT coalesce(max(id), 0) FROM peerdb_stats.mirror_events").Scan(&id); err != nil {
+		return 0, fmt.Errorf("error while getting last mirror event: %w", err)
+	}
+	return id, nil
+}
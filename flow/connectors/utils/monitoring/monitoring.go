@@ -2,6 +2,7 @@ package monitoring
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -10,18 +11,30 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/PeerDB-io/peer-flow/connectors/utils/audit"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/logger"
 	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/shared"
 )
 
+type ResourceUsageSample struct {
+	SampledAt        time.Time
+	WorkerID         string
+	CPUSeconds       float64
+	MemoryBytes      int64
+	Goroutines       float64
+	ActiveActivities int32
+}
+
 type CDCBatchInfo struct {
-	StartTime   time.Time
-	BatchID     int64
-	BatchEndlSN int64
-	RowsInBatch uint32
+	StartTime     time.Time
+	BatchID       int64
+	BatchStartLSN int64
+	BatchEndlSN   int64
+	RowsInBatch   uint32
 }
 
 func InitializeCDCFlow(ctx context.Context, pool *pgxpool.Pool, flowJobName string) error {
@@ -64,7 +77,7 @@ func AddCDCBatchForFlow(ctx context.Context, pool *pgxpool.Pool, flowJobName str
 	_, err := pool.Exec(ctx,
 		`INSERT INTO peerdb_stats.cdc_batches(flow_name,batch_id,rows_in_batch,batch_start_lsn,batch_end_lsn,
 		start_time) VALUES($1,$2,$3,$4,$5,$6) ON CONFLICT DO NOTHING`,
-		flowJobName, batchInfo.BatchID, batchInfo.RowsInBatch, 0,
+		flowJobName, batchInfo.BatchID, batchInfo.RowsInBatch, uint64(batchInfo.BatchStartLSN),
 		uint64(batchInfo.BatchEndlSN), batchInfo.StartTime)
 	if err != nil {
 		return fmt.Errorf("error while inserting batch into cdc_batch: %w", err)
@@ -170,6 +183,17 @@ func InitializeQRepRun(
 	return nil
 }
 
+func UpdateEstimatedRowsForQRepRun(ctx context.Context, pool *pgxpool.Pool, runUUID string, estimatedRows int64) error {
+	_, err := pool.Exec(ctx,
+		"UPDATE peerdb_stats.qrep_runs SET estimated_rows=$1 WHERE run_uuid=$2",
+		estimatedRows, runUUID)
+	if err != nil {
+		return fmt.Errorf("error while updating estimated rows for run_uuid %s in qrep_runs: %w", runUUID, err)
+	}
+
+	return nil
+}
+
 func UpdateStartTimeForQRepRun(ctx context.Context, pool *pgxpool.Pool, runUUID string) error {
 	_, err := pool.Exec(ctx,
 		"UPDATE peerdb_stats.qrep_runs SET start_time=$1, fetch_complete=true WHERE run_uuid=$2",
@@ -200,8 +224,8 @@ func AppendSlotSizeInfo(
 ) error {
 	_, err := pool.Exec(ctx,
 		"INSERT INTO peerdb_stats.peer_slot_size"+
-			"(peer_name, slot_name, restart_lsn, redo_lsn, confirmed_flush_lsn, slot_size, wal_status) "+
-			"VALUES($1,$2,$3,$4,$5,$6,$7) ON CONFLICT DO NOTHING;",
+			"(peer_name, slot_name, restart_lsn, redo_lsn, confirmed_flush_lsn, slot_size, wal_status, current_lsn) "+
+			"VALUES($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT DO NOTHING;",
 		peerName,
 		slotInfo.SlotName,
 		slotInfo.RestartLSN,
@@ -209,6 +233,7 @@ func AppendSlotSizeInfo(
 		slotInfo.ConfirmedFlushLSN,
 		slotInfo.LagInMb,
 		slotInfo.WalStatus,
+		slotInfo.CurrentLSN,
 	)
 	if err != nil {
 		return fmt.Errorf("error while upserting row for slot_size: %w", err)
@@ -217,6 +242,716 @@ func AppendSlotSizeInfo(
 	return nil
 }
 
+// GetSlotSizeBefore returns the latest slot size recorded at least window ago, but no more than twice that,
+// with how long ago it was recorded, nil when there's none
+func GetSlotSizeBefore(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	peerName string,
+	slotName string,
+	window time.Duration,
+) (*protos.SlotInfo, time.Duration, error) {
+	var slotInfo protos.SlotInfo
+	var agoSeconds float64
+	err := pool.QueryRow(ctx,
+		`SELECT COALESCE(restart_lsn, ''), current_lsn, COALESCE(slot_size, 0)::float4,
+		EXTRACT(EPOCH FROM now() - updated_at)::float8
+		FROM peerdb_stats.peer_slot_size
+		WHERE peer_name = $1 AND slot_name = $2 AND current_lsn IS NOT NULL
+		AND updated_at <= now() - make_interval(secs => $3) AND updated_at > now() - make_interval(secs => 2 * $3)
+		ORDER BY updated_at DESC LIMIT 1`,
+		peerName, slotName, window.Seconds(),
+	).Scan(&slotInfo.RestartLSN, &slotInfo.CurrentLSN, &slotInfo.LagInMb, &agoSeconds)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("error while querying slot_size: %w", err)
+	}
+	slotInfo.SlotName = slotName
+	return &slotInfo, time.Duration(agoSeconds * float64(time.Second)), nil
+}
+
+func AppendFlowResourceUsage(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	sample ResourceUsageSample,
+) error {
+	_, err := pool.Exec(ctx,
+		`INSERT INTO peerdb_stats.flow_resource_usage
+		(flow_name, worker_id, sampled_at, cpu_seconds, memory_bytes, goroutines, active_activities)
+		VALUES($1,$2,$3,$4,$5,$6,$7)`,
+		flowJobName,
+		sample.WorkerID,
+		sample.SampledAt,
+		sample.CPUSeconds,
+		sample.MemoryBytes,
+		sample.Goroutines,
+		sample.ActiveActivities,
+	)
+	if err != nil {
+		return fmt.Errorf("error while inserting row for flow_resource_usage: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestLSNAtTargetForCDCFlow returns the offset the catalog last saw confirmed by the destination of a mirror
+func GetLatestLSNAtTargetForCDCFlow(ctx context.Context, pool *pgxpool.Pool, flowJobName string) (int64, error) {
+	var latestLSNAtTarget int64
+	err := pool.QueryRow(ctx,
+		"SELECT COALESCE(MAX(latest_lsn_at_target),0)::bigint FROM peerdb_stats.cdc_flows WHERE flow_name=$1",
+		flowJobName,
+	).Scan(&latestLSNAtTarget)
+	if err != nil {
+		return 0, fmt.Errorf("[target] error while querying flow in cdc_flows: %w", err)
+	}
+	return latestLSNAtTarget, nil
+}
+
+// GetLastCDCBatchID returns the highest batch recorded in the catalog for a mirror, 0 when none
+func GetLastCDCBatchID(ctx context.Context, pool *pgxpool.Pool, flowJobName string) (int64, error) {
+	var batchID int64
+	err := pool.QueryRow(ctx,
+		"SELECT COALESCE(MAX(batch_id),0) FROM peerdb_stats.cdc_batches WHERE flow_name = $1",
+		flowJobName,
+	).Scan(&batchID)
+	if err != nil {
+		return 0, fmt.Errorf("error while querying cdc_batches: %w", err)
+	}
+	return batchID, nil
+}
+
+// GetCDCBatchEndLSN returns the offset a batch of a mirror synced up to, 0 when the catalog has none recorded
+func GetCDCBatchEndLSN(ctx context.Context, pool *pgxpool.Pool, flowJobName string, batchID int64) (int64, error) {
+	var batchEndLSN int64
+	err := pool.QueryRow(ctx,
+		"SELECT COALESCE(MAX(batch_end_lsn),0)::bigint FROM peerdb_stats.cdc_batches WHERE flow_name = $1 AND batch_id = $2",
+		flowJobName, batchID,
+	).Scan(&batchEndLSN)
+	if err != nil {
+		return 0, fmt.Errorf("error while querying end of batch in cdc_batches: %w", err)
+	}
+	return batchEndLSN, nil
+}
+
+// GetLastNormalizedCDCBatchID returns the highest batch a mirror finished normalizing, 0 when none
+func GetLastNormalizedCDCBatchID(ctx context.Context, pool *pgxpool.Pool, flowJobName string) (int64, error) {
+	var batchID int64
+	if err := pool.QueryRow(ctx, `SELECT coalesce(max(batch_id), 0) FROM peerdb_stats.cdc_batches
+		WHERE flow_name = $1 AND end_time IS NOT NULL`, flowJobName).Scan(&batchID); err != nil {
+		return 0, fmt.Errorf("error while getting last normalized batch: %w", err)
+	}
+	return batchID, nil
+}
+
+// GetPendingRecordSample returns the oldest unexpired sample request for a mirror and how many records it still needs
+func GetPendingRecordSample(ctx context.Context, pool *pgxpool.Pool, flowJobName string) (int64, int32, error) {
+	var id int64
+	var remaining int32
+	err := pool.QueryRow(ctx,
+		`SELECT id, num_records - jsonb_array_length(records) FROM peerdb_stats.flow_record_samples
+		WHERE flow_name = $1 AND completed_at IS NULL AND created_at > now() - INTERVAL '10 minutes'
+		ORDER BY id LIMIT 1`,
+		flowJobName,
+	).Scan(&id, &remaining)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("error while querying flow_record_samples: %w", err)
+	}
+	return id, remaining, nil
+}
+
+// AppendRecordSamples adds a JSON array of sampled records to a sample request, completing it when asked
+func AppendRecordSamples(ctx context.Context, pool *pgxpool.Pool, sampleID int64, records []byte, complete bool) error {
+	_, err := pool.Exec(ctx,
+		`UPDATE peerdb_stats.flow_record_samples
+		SET records = records || $2::jsonb, completed_at = CASE WHEN $3 THEN now() ELSE completed_at END
+		WHERE id = $1 AND completed_at IS NULL`,
+		sampleID, records, complete,
+	)
+	if err != nil {
+		return fmt.Errorf("error while updating flow_record_samples: %w", err)
+	}
+	return nil
+}
+
+// AppendDestinationStatements records statements executed against the destination of a mirror for a batch
+func AppendDestinationStatements(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	batchID int64,
+	statements []audit.Statement,
+) error {
+	batch := &pgx.Batch{}
+	for _, statement := range statements {
+		batch.Queue(`INSERT INTO peerdb_stats.destination_statements
+		(flow_name, batch_id, kind, statement, executed_at) VALUES($1,$2,$3,$4,$5)`,
+			flowJobName, batchID, statement.Kind, statement.Statement, statement.ExecutedAt)
+	}
+	if err := pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("error while inserting rows for destination_statements: %w", err)
+	}
+	return nil
+}
+
+// RecordSourceSchemas adds a schema version for every table whose schema differs from its latest recorded one
+func RecordSourceSchemas(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) error {
+	batch := &pgx.Batch{}
+	for tableName, tableSchema := range tableNameSchemaMapping {
+		schema, err := protojson.Marshal(tableSchema)
+		if err != nil {
+			return fmt.Errorf("error while encoding schema of %s: %w", tableName, err)
+		}
+		batch.Queue(`INSERT INTO peerdb_stats.source_schema_history (flow_name, table_name, schema)
+		SELECT $1, $2, $3::jsonb WHERE NOT EXISTS (
+			SELECT 1 FROM (
+				SELECT schema FROM peerdb_stats.source_schema_history
+				WHERE flow_name = $1 AND table_name = $2 ORDER BY id DESC LIMIT 1
+			) latest WHERE latest.schema = $3::jsonb
+		)`, flowJobName, tableName, schema)
+	}
+	if err := pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("error while inserting rows for source_schema_history: %w", err)
+	}
+	return nil
+}
+
+// UpsertSnapshotColumnStats stores column statistics of a snapshot partition, replacing any from a previous attempt
+func UpsertSnapshotColumnStats(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	partitionID string,
+	tableStats *protos.TableColumnStatistics,
+) error {
+	stats, err := protojson.Marshal(tableStats)
+	if err != nil {
+		return fmt.Errorf("error while encoding column stats: %w", err)
+	}
+	_, err = pool.Exec(ctx,
+		`INSERT INTO peerdb_stats.snapshot_column_stats (flow_name, destination_table, partition_id, stats)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (flow_name, destination_table, partition_id) DO UPDATE SET stats = $4, updated_at = now()`,
+		flowJobName, tableStats.DestinationTable, partitionID, stats)
+	if err != nil {
+		return fmt.Errorf("error while upserting snapshot_column_stats: %w", err)
+	}
+	return nil
+}
+
+// GetSnapshotColumnStats returns column statistics of every snapshotted table of a mirror, merged across partitions
+func GetSnapshotColumnStats(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+) ([]*protos.TableColumnStatistics, error) {
+	rows, err := pool.Query(ctx,
+		`SELECT stats FROM peerdb_stats.snapshot_column_stats WHERE flow_name = $1 ORDER BY destination_table, partition_id`,
+		flowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("error while querying snapshot_column_stats: %w", err)
+	}
+	partitionStats, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.TableColumnStatistics, error) {
+		var stats []byte
+		if err := row.Scan(&stats); err != nil {
+			return nil, err
+		}
+		tableStats := &protos.TableColumnStatistics{}
+		if err := protojson.Unmarshal(stats, tableStats); err != nil {
+			return nil, fmt.Errorf("error while decoding column stats: %w", err)
+		}
+		return tableStats, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*protos.TableColumnStatistics
+	for _, partition := range partitionStats {
+		if len(tables) == 0 || tables[len(tables)-1].DestinationTable != partition.DestinationTable {
+			tables = append(tables, partition)
+			continue
+		}
+		table := tables[len(tables)-1]
+		if table.Columns, err = model.MergeColumnStatistics(table.Columns, partition.Columns); err != nil {
+			return nil, fmt.Errorf("error while merging column stats of %s: %w", table.DestinationTable, err)
+		}
+	}
+	return tables, nil
+}
+
+// GetLatestSourceSchemas returns the latest recorded schema of every source table of a mirror
+func GetLatestSourceSchemas(ctx context.Context, pool *pgxpool.Pool, flowJobName string) (map[string]*protos.TableSchema, error) {
+	rows, err := pool.Query(ctx, `SELECT DISTINCT ON (table_name) table_name, schema
+		FROM peerdb_stats.source_schema_history WHERE flow_name = $1 ORDER BY table_name, id DESC`, flowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("error while querying source_schema_history: %w", err)
+	}
+	schemas := make(map[string]*protos.TableSchema)
+	var tableName string
+	var schema []byte
+	if _, err := pgx.ForEachRow(rows, []any{&tableName, &schema}, func() error {
+		tableSchema := &protos.TableSchema{}
+		if err := protojson.Unmarshal(schema, tableSchema); err != nil {
+			return fmt.Errorf("error while decoding schema of %s: %w", tableName, err)
+		}
+		schemas[tableName] = tableSchema
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// GetLastDestinationMaintenance returns when each destination table of a mirror last finished maintenance without error
+func GetLastDestinationMaintenance(ctx context.Context, pool *pgxpool.Pool, flowJobName string) (map[string]time.Time, error) {
+	rows, err := pool.Query(ctx, `SELECT table_name, max(started_at) FROM peerdb_stats.destination_maintenance
+		WHERE flow_name = $1 AND finished_at IS NOT NULL AND error IS NULL GROUP BY table_name`, flowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("error while querying destination_maintenance: %w", err)
+	}
+	maintained := make(map[string]time.Time)
+	var tableName string
+	var startedAt time.Time
+	if _, err := pgx.ForEachRow(rows, []any{&tableName, &startedAt}, func() error {
+		maintained[tableName] = startedAt
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return maintained, nil
+}
+
+func StartDestinationMaintenance(ctx context.Context, pool *pgxpool.Pool, flowJobName string, tableName string) (int64, error) {
+	var id int64
+	if err := pool.QueryRow(ctx, `INSERT INTO peerdb_stats.destination_maintenance (flow_name, table_name)
+		VALUES ($1, $2) RETURNING id`, flowJobName, tableName).Scan(&id); err != nil {
+		return 0, fmt.Errorf("error while inserting row for destination_maintenance: %w", err)
+	}
+	return id, nil
+}
+
+func FinishDestinationMaintenance(ctx context.Context, pool *pgxpool.Pool, id int64, maintenanceErr error) error {
+	var errText *string
+	if maintenanceErr != nil {
+		text := maintenanceErr.Error()
+		errText = &text
+	}
+	if _, err := pool.Exec(ctx, `UPDATE peerdb_stats.destination_maintenance SET finished_at = now(), error = $2
+		WHERE id = $1`, id, errText); err != nil {
+		return fmt.Errorf("error while updating row for destination_maintenance: %w", err)
+	}
+	return nil
+}
+
+// AcquireCatchUp puts a mirror in catch up mode unless maxPerPeer other mirrors into its destination peer already are,
+// those in it for longer than maxDuration not counting, 0 lifting either limit.
+// Mirrors already in catch up mode keep when they started it
+func AcquireCatchUp(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	peerName string,
+	maxPerPeer uint32,
+	maxDuration time.Duration,
+) (bool, time.Time, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("error while starting transaction for catch_up_mirrors: %w", err)
+	}
+	defer shared.RollbackTx(tx, logger.LoggerFromCtx(ctx))
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('catch_up_mirrors:' || $1))", peerName); err != nil {
+		return false, time.Time{}, fmt.Errorf("error while locking catch_up_mirrors of %s: %w", peerName, err)
+	}
+	var startedAt time.Time
+	if err := tx.QueryRow(ctx, `SELECT started_at FROM peerdb_stats.catch_up_mirrors WHERE flow_name = $1`,
+		flowJobName).Scan(&startedAt); err == nil {
+		return true, startedAt, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return false, time.Time{}, fmt.Errorf("error while querying catch_up_mirrors: %w", err)
+	}
+
+	if maxPerPeer > 0 {
+		var others int64
+		if err := tx.QueryRow(ctx, `SELECT count(*) FROM peerdb_stats.catch_up_mirrors
+			WHERE peer_name = $1 AND ($2::float8 = 0 OR started_at > now() - make_interval(secs => $2::float8))`,
+			peerName, maxDuration.Seconds()).Scan(&others); err != nil {
+			return false, time.Time{}, fmt.Errorf("error while counting catch_up_mirrors: %w", err)
+		}
+		if others >= int64(maxPerPeer) {
+			return false, time.Time{}, nil
+		}
+	}
+
+	if err := tx.QueryRow(ctx, `INSERT INTO peerdb_stats.catch_up_mirrors (flow_name, peer_name)
+		VALUES ($1, $2) RETURNING started_at`, flowJobName, peerName).Scan(&startedAt); err != nil {
+		return false, time.Time{}, fmt.Errorf("error while inserting row for catch_up_mirrors: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, time.Time{}, fmt.Errorf("error while committing catch_up_mirrors: %w", err)
+	}
+	return true, startedAt, nil
+}
+
+func ReleaseCatchUp(ctx context.Context, pool *pgxpool.Pool, flowJobName string) error {
+	if _, err := pool.Exec(ctx, `DELETE FROM peerdb_stats.catch_up_mirrors WHERE flow_name = $1`, flowJobName); err != nil {
+		return fmt.Errorf("error while deleting catch_up_mirrors: %w", err)
+	}
+	return nil
+}
+
+// GetLastDataDiffSamples returns when each source table of a mirror was last sampled for data diffs
+func GetLastDataDiffSamples(ctx context.Context, pool *pgxpool.Pool, flowJobName string) (map[string]time.Time, error) {
+	rows, err := pool.Query(ctx, `SELECT table_name, max(sampled_at) FROM peerdb_stats.data_diff_samples
+		WHERE flow_name = $1 GROUP BY table_name`, flowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("error while querying data_diff_samples: %w", err)
+	}
+	sampled := make(map[string]time.Time)
+	var tableName string
+	var sampledAt time.Time
+	if _, err := pgx.ForEachRow(rows, []any{&tableName, &sampledAt}, func() error {
+		sampled[tableName] = sampledAt
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return sampled, nil
+}
+
+func AppendDataDiffSample(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	tableName string,
+	rowsSampled int,
+	mismatches []model.DataDiffMismatch,
+	maxMismatches int,
+) error {
+	var missing int
+	for _, mismatch := range mismatches {
+		if mismatch.Missing {
+			missing += 1
+		}
+	}
+	if _, err := pool.Exec(ctx, `INSERT INTO peerdb_stats.data_diff_samples
+		(flow_name, table_name, rows_sampled, rows_missing, rows_mismatched, mismatches) VALUES ($1, $2, $3, $4, $5, $6)`,
+		flowJobName, tableName, rowsSampled, missing, len(mismatches)-missing, mismatches[:min(len(mismatches), maxMismatches)],
+	); err != nil {
+		return fmt.Errorf("error while inserting row for data_diff_samples: %w", err)
+	}
+	return nil
+}
+
+func GetQuarantinedTables(ctx context.Context, pool *pgxpool.Pool, flowJobName string) ([]*protos.QuarantinedTable, error) {
+	rows, err := pool.Query(ctx, `SELECT table_name, policy, reason, quarantined_at, batch_id, released_at IS NOT NULL
+		FROM quarantined_tables WHERE flow_name = $1 ORDER BY table_name`, flowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("error while querying quarantined_tables: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.QuarantinedTable, error) {
+		var quarantinedAt time.Time
+		table := &protos.QuarantinedTable{}
+		if err := row.Scan(&table.TableName, &table.Policy, &table.Reason, &quarantinedAt,
+			&table.BatchId, &table.Released); err != nil {
+			return nil, err
+		}
+		table.QuarantinedAt = float64(quarantinedAt.UnixMilli())
+		return table, nil
+	})
+}
+
+// QuarantineTable stops normalize from applying changes to a destination table, buffered changes are normalized
+// again from the last batch normalized when the table was first quarantined
+func QuarantineTable(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	tableName string,
+	policy protos.QuarantinePolicy,
+	reason string,
+) error {
+	if _, err := pool.Exec(ctx, `INSERT INTO quarantined_tables (flow_name, table_name, policy, reason, batch_id)
+		VALUES ($1, $2, $3, $4, (SELECT coalesce(max(batch_id), 0) FROM peerdb_stats.cdc_batches
+			WHERE flow_name = $1 AND end_time IS NOT NULL))
+		ON CONFLICT (flow_name, table_name) DO UPDATE SET policy = excluded.policy, reason = excluded.reason, released_at = NULL`,
+		flowJobName, tableName, policy, reason,
+	); err != nil {
+		return fmt.Errorf("error while quarantining table: %w", err)
+	}
+	return nil
+}
+
+// ReleaseQuarantinedTable lets normalize apply changes to a destination table again,
+// tables with buffered changes are only removed once normalize caught them up
+func ReleaseQuarantinedTable(ctx context.Context, pool *pgxpool.Pool, flowJobName string, tableName string) (bool, error) {
+	ct, err := pool.Exec(ctx, "DELETE FROM quarantined_tables WHERE flow_name = $1 AND table_name = $2 AND policy = $3",
+		flowJobName, tableName, protos.QuarantinePolicy_QUARANTINE_POLICY_SKIP)
+	if err != nil {
+		return false, fmt.Errorf("error while releasing quarantined table: %w", err)
+	}
+	if ct.RowsAffected() > 0 {
+		return true, nil
+	}
+	ct, err = pool.Exec(ctx, `UPDATE quarantined_tables SET released_at = coalesce(released_at, now())
+		WHERE flow_name = $1 AND table_name = $2`, flowJobName, tableName)
+	if err != nil {
+		return false, fmt.Errorf("error while releasing quarantined table: %w", err)
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+// FinishQuarantineReplay removes released tables whose buffered changes were normalized
+func FinishQuarantineReplay(ctx context.Context, pool *pgxpool.Pool, flowJobName string, tableNames []string) error {
+	if _, err := pool.Exec(ctx, `DELETE FROM quarantined_tables
+		WHERE flow_name = $1 AND table_name = ANY($2) AND released_at IS NOT NULL`, flowJobName, tableNames); err != nil {
+		return fmt.Errorf("error while finishing replay of quarantined tables: %w", err)
+	}
+	return nil
+}
+
+func DeleteQuarantinedTables(ctx context.Context, pool *pgxpool.Pool, flowJobName string) error {
+	if _, err := pool.Exec(ctx, "DELETE FROM quarantined_tables WHERE flow_name = $1", flowJobName); err != nil {
+		return fmt.Errorf("error while deleting quarantined_tables: %w", err)
+	}
+	return nil
+}
+
+const (
+	BatchOperationSkip   = "skip"
+	BatchOperationReplay = "replay"
+)
+
+func GetBatchOperations(ctx context.Context, pool *pgxpool.Pool, flowJobName string) ([]*protos.BatchOperation, error) {
+	rows, err := pool.Query(ctx, `SELECT batch_id, operation, reason, requested_at, applied_at
+		FROM batch_operations WHERE flow_name = $1 ORDER BY id`, flowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("error while querying batch_operations: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.BatchOperation, error) {
+		var requestedAt time.Time
+		var appliedAt pgtype.Timestamp
+		op := &protos.BatchOperation{}
+		if err := row.Scan(&op.BatchId, &op.Operation, &op.Reason, &requestedAt, &appliedAt); err != nil {
+			return nil, err
+		}
+		op.RequestedAt = float64(requestedAt.UnixMilli())
+		if appliedAt.Valid {
+			op.AppliedAt = float64(appliedAt.Time.UnixMilli())
+		}
+		return op, nil
+	})
+}
+
+func AddBatchOperation(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	batchID int64,
+	operation string,
+	reason string,
+) error {
+	if _, err := pool.Exec(ctx,
+		"INSERT INTO batch_operations (flow_name, batch_id, operation, reason) VALUES ($1, $2, $3, $4)",
+		flowJobName, batchID, operation, reason,
+	); err != nil {
+		return fmt.Errorf("error while inserting row for batch_operations: %w", err)
+	}
+	return nil
+}
+
+// GetNormalizeBatchOperations returns batches normalize leaves out, which are those skipped and not replayed since,
+// and batches to be replayed along with the next normalize
+func GetNormalizeBatchOperations(ctx context.Context, pool *pgxpool.Pool, flowJobName string) ([]int64, []int64, error) {
+	rows, err := pool.Query(ctx, `SELECT batch_id, operation FROM batch_operations o WHERE flow_name = $1 AND (
+		(operation = $2 AND NOT EXISTS(SELECT 1 FROM batch_operations r
+			WHERE r.flow_name = o.flow_name AND r.batch_id = o.batch_id AND r.operation = $3 AND r.id > o.id))
+		OR (operation = $3 AND applied_at IS NULL)) ORDER BY batch_id`,
+		flowJobName, BatchOperationSkip, BatchOperationReplay)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while querying batch_operations: %w", err)
+	}
+	var skipped, replayed []int64
+	var batchID int64
+	var operation string
+	if _, err := pgx.ForEachRow(rows, []any{&batchID, &operation}, func() error {
+		if operation == BatchOperationSkip {
+			skipped = append(skipped, batchID)
+		} else {
+			replayed = append(replayed, batchID)
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	return skipped, replayed, nil
+}
+
+// FinishBatchOperations marks skips of batches up to endBatchID and the replays of replayedBatches as applied
+func FinishBatchOperations(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	endBatchID int64,
+	replayedBatches []int64,
+) error {
+	if _, err := pool.Exec(ctx, `UPDATE batch_operations SET applied_at = now()
+		WHERE flow_name = $1 AND applied_at IS NULL AND (
+			(operation = $2 AND batch_id <= $3) OR (operation = $4 AND batch_id = ANY($5)))`,
+		flowJobName, BatchOperationSkip, endBatchID, BatchOperationReplay, replayedBatches,
+	); err != nil {
+		return fmt.Errorf("error while updating batch_operations: %w", err)
+	}
+	return nil
+}
+
+func DeleteBatchOperations(ctx context.Context, pool *pgxpool.Pool, flowJobName string) error {
+	if _, err := pool.Exec(ctx, "DELETE FROM batch_operations WHERE flow_name = $1", flowJobName); err != nil {
+		return fmt.Errorf("error while deleting batch_operations: %w", err)
+	}
+	return nil
+}
+
+// RecordBatchManifest records the boundary of a normalize of batches startBatchID to endBatchID once it committed,
+// batches record their end LSN so the manifest starts after the end of the batch before startBatchID
+func RecordBatchManifest(ctx context.Context, pool *pgxpool.Pool, flowJobName string, startBatchID int64, endBatchID int64) error {
+	if _, err := pool.Exec(ctx, `INSERT INTO peerdb_stats.batch_manifests
+		(flow_name, batch_id, start_batch_id, start_lsn, end_lsn, tables)
+		SELECT $1, $3, $2,
+			(SELECT coalesce(max(batch_end_lsn), 0) FROM peerdb_stats.cdc_batches WHERE flow_name = $1 AND batch_id < $2),
+			(SELECT coalesce(max(batch_end_lsn), 0) FROM peerdb_stats.cdc_batches
+				WHERE flow_name = $1 AND batch_id BETWEEN $2 AND $3),
+			ARRAY(SELECT DISTINCT destination_table_name FROM peerdb_stats.cdc_batch_table
+				WHERE flow_name = $1 AND batch_id BETWEEN $2 AND $3 ORDER BY destination_table_name)
+		ON CONFLICT DO NOTHING`,
+		flowJobName, startBatchID, endBatchID,
+	); err != nil {
+		return fmt.Errorf("error while inserting row for batch_manifests: %w", err)
+	}
+	return nil
+}
+
+// GetNormalizedBatch describes a normalize of batches startBatchID to endBatchID for the batch control table,
+// LSNs are found like those of batch manifests
+func GetNormalizedBatch(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	startBatchID int64,
+	endBatchID int64,
+) (*model.NormalizedBatch, error) {
+	batch := &model.NormalizedBatch{
+		NormalizedAt: time.Now().UTC(),
+		StartBatchID: startBatchID,
+		EndBatchID:   endBatchID,
+	}
+	if err := pool.QueryRow(ctx, `SELECT
+		(SELECT coalesce(max(batch_end_lsn), 0) FROM peerdb_stats.cdc_batches WHERE flow_name = $1 AND batch_id < $2),
+		(SELECT coalesce(max(batch_end_lsn), 0) FROM peerdb_stats.cdc_batches
+			WHERE flow_name = $1 AND batch_id BETWEEN $2 AND $3)`,
+		flowJobName, startBatchID, endBatchID,
+	).Scan(&batch.StartLSN, &batch.EndLSN); err != nil {
+		return nil, fmt.Errorf("error while querying cdc_batches: %w", err)
+	}
+
+	rows, err := pool.Query(ctx, `SELECT destination_table_name, sum(num_rows)::bigint FROM peerdb_stats.cdc_batch_table
+		WHERE flow_name = $1 AND batch_id BETWEEN $2 AND $3 GROUP BY destination_table_name`,
+		flowJobName, startBatchID, endBatchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while querying cdc_batch_table: %w", err)
+	}
+	batch.TableRows = make(map[string]int64)
+	var tableName string
+	var numRows int64
+	if _, err := pgx.ForEachRow(rows, []any{&tableName, &numRows}, func() error {
+		batch.TableRows[tableName] = numRows
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error while querying cdc_batch_table: %w", err)
+	}
+	return batch, nil
+}
+
+func GetBatchManifests(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	afterBatchID int64,
+) ([]*protos.BatchManifest, error) {
+	rows, err := pool.Query(ctx, `SELECT batch_id, start_batch_id, start_lsn::bigint, end_lsn::bigint, tables, normalized_at
+		FROM peerdb_stats.batch_manifests WHERE flow_name = $1 AND batch_id > $2 ORDER BY batch_id`,
+		flowJobName, afterBatchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while querying batch_manifests: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.BatchManifest, error) {
+		var normalizedAt time.Time
+		manifest := &protos.BatchManifest{}
+		if err := row.Scan(&manifest.BatchId, &manifest.StartBatchId, &manifest.StartLsn, &manifest.EndLsn,
+			&manifest.Tables, &normalizedAt); err != nil {
+			return nil, err
+		}
+		manifest.NormalizedAt = float64(normalizedAt.UnixMilli())
+		return manifest, nil
+	})
+}
+
+// RecordTargetedDelete adds a targeted delete to the audit trail, which outlives mirrors as it is kept for compliance
+func RecordTargetedDelete(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	flowJobName string,
+	sourceTable string,
+	requestedBy string,
+	numKeys int,
+	digest string,
+) (int64, error) {
+	var id int64
+	if err := pool.QueryRow(ctx, `INSERT INTO peerdb_stats.targeted_deletes
+		(flow_name, source_table, requested_by, num_keys, digest) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		flowJobName, sourceTable, requestedBy, numKeys, digest,
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("error while inserting row for targeted_deletes: %w", err)
+	}
+	return id, nil
+}
+
+// FinishTargetedDelete records how a targeted delete went at a destination table, deleteErr being nil once it applied
+func FinishTargetedDelete(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	deleteID int64,
+	flowJobName string,
+	destinationPeer string,
+	destinationTable string,
+	deleteErr error,
+) error {
+	var errMsg *string
+	if deleteErr != nil {
+		msg := deleteErr.Error()
+		errMsg = &msg
+	}
+	if _, err := pool.Exec(ctx, `INSERT INTO peerdb_stats.targeted_delete_results
+		(delete_id, flow_name, destination_peer, destination_table, error) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (delete_id, flow_name, destination_table) DO UPDATE SET error = $5, finished_at = now()`,
+		deleteID, flowJobName, destinationPeer, destinationTable, errMsg,
+	); err != nil {
+		return fmt.Errorf("error while inserting row for targeted_delete_results: %w", err)
+	}
+	return nil
+}
+
 func addPartitionToQRepRun(ctx context.Context, pool *pgxpool.Pool, flowJobName string,
 	runUUID string, partition *protos.QRepPartition, parentMirrorName string,
 ) error {
@@ -346,5 +1081,70 @@ func DeleteMirrorStats(ctx context.Context, pool *pgxpool.Pool, flowJobName stri
 		return fmt.Errorf("error while deleting cdc_flows: %w", err)
 	}
 
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.flow_resource_usage WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting flow_resource_usage: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.flow_record_samples WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting flow_record_samples: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.destination_statements WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting destination_statements: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.source_schema_history WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting source_schema_history: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.snapshot_column_stats WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting snapshot_column_stats: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.destination_maintenance WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting destination_maintenance: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.data_diff_samples WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting data_diff_samples: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.batch_manifests WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting batch_manifests: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.flow_metrics WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting flow_metrics: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.freshness_sla_state WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting freshness_sla_state: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.freshness_sla_events WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting freshness_sla_events: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.mirror_events WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting mirror_events: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `DELETE FROM peerdb_stats.catch_up_mirrors WHERE flow_name = $1`, flowJobName)
+	if err != nil {
+		return fmt.Errorf("error while deleting catch_up_mirrors: %w", err)
+	}
+
 	return nil
 }
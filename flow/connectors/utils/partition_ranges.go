@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeRange is the range [From, To) of a source partition, a zero From or To being unbounded
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// MonthCovered reports whether any of ranges overlaps the month of partition id yyyymm, like 202401
+func MonthCovered(ranges []TimeRange, yyyymm string) (bool, error) {
+	monthStart, err := time.Parse("200601", yyyymm)
+	if err != nil {
+		return false, fmt.Errorf("partition %s is not a month: %w", yyyymm, err)
+	}
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	for _, r := range ranges {
+		if (r.From.IsZero() || r.From.Before(monthEnd)) && (r.To.IsZero() || r.To.After(monthStart)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
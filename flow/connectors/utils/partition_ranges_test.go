@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonthCovered(t *testing.T) {
+	ranges := []TimeRange{
+		{From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// a partition of a few days still keeps its month
+		{From: time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)},
+	}
+	for month, expected := range map[string]bool{"202402": false, "202403": true, "202404": false, "202406": true} {
+		covered, err := MonthCovered(ranges, month)
+		require.NoError(t, err)
+		require.Equal(t, expected, covered, month)
+	}
+
+	// unbounded partition from MINVALUE
+	covered, err := MonthCovered([]TimeRange{{To: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}}, "202312")
+	require.NoError(t, err)
+	require.True(t, covered)
+
+	_, err = MonthCovered(ranges, "all")
+	require.Error(t, err)
+}
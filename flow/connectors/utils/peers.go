@@ -93,6 +93,78 @@ func CreatePeerNoValidate(
 			return wrongConfigResponse, nil
 		}
 		innerConfig = esConfigObject.ElasticsearchConfig
+	case protos.DBType_SYNTHETIC:
+		synConfigObject, ok := config.(*protos.Peer_SyntheticConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = synConfigObject.SyntheticConfig
+	case protos.DBType_WEBHOOK:
+		webhookConfigObject, ok := config.(*protos.Peer_WebhookConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = webhookConfigObject.WebhookConfig
+	case protos.DBType_KINESIS:
+		kinesisConfigObject, ok := config.(*protos.Peer_KinesisConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = kinesisConfigObject.KinesisConfig
+	case protos.DBType_FABRIC:
+		fabricConfigObject, ok := config.(*protos.Peer_FabricConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = fabricConfigObject.FabricConfig
+	case protos.DBType_SINGLESTORE:
+		singlestoreConfigObject, ok := config.(*protos.Peer_SinglestoreConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = singlestoreConfigObject.SinglestoreConfig
+	case protos.DBType_ORACLE:
+		oracleConfigObject, ok := config.(*protos.Peer_OracleConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = oracleConfigObject.OracleConfig
+	case protos.DBType_PLUGIN:
+		pluginConfigObject, ok := config.(*protos.Peer_PluginConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = pluginConfigObject.PluginConfig
+	case protos.DBType_STARROCKS:
+		starrocksConfigObject, ok := config.(*protos.Peer_StarrocksConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = starrocksConfigObject.StarrocksConfig
+	case protos.DBType_TIMESERIES:
+		timeseriesConfigObject, ok := config.(*protos.Peer_TimeseriesConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = timeseriesConfigObject.TimeseriesConfig
+	case protos.DBType_NEO4J:
+		neo4jConfigObject, ok := config.(*protos.Peer_Neo4JConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = neo4jConfigObject.Neo4JConfig
+	case protos.DBType_VECTOR:
+		vectorConfigObject, ok := config.(*protos.Peer_VectorConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = vectorConfigObject.VectorConfig
+	case protos.DBType_SQLITE:
+		sqliteConfigObject, ok := config.(*protos.Peer_SqliteConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = sqliteConfigObject.SqliteConfig
 	default:
 		return wrongConfigResponse, nil
 	}
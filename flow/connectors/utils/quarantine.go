@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// ReplayTablesToNormalize returns the batch after which each table of replayTables is normalized again,
+// starting no earlier than the batch its last TRUNCATE was in as records of earlier batches are gone at source.
+// Tables with nothing to replay before normBatchID are left out.
+func ReplayTablesToNormalize(replayTables map[string]int64, truncatedTables map[string]int64, normBatchID int64) map[string]int64 {
+	var toNormalize map[string]int64
+	for table, batchID := range replayTables {
+		if truncateBatchID, ok := truncatedTables[table]; ok {
+			batchID = max(batchID, truncateBatchID-1)
+		}
+		if batchID < normBatchID {
+			if toNormalize == nil {
+				toNormalize = make(map[string]int64)
+			}
+			toNormalize[table] = batchID
+		}
+	}
+	return toNormalize
+}
+
+// EarliestReplayBatchID returns the earliest batch after which a table of replayTables is normalized again,
+// normBatchID when there is none
+func EarliestReplayBatchID(replayTables map[string]int64, normBatchID int64) int64 {
+	earliest := normBatchID
+	for _, batchID := range replayTables {
+		earliest = min(earliest, batchID)
+	}
+	return earliest
+}
+
+// WithReplayedTables adds tables of replayTables missing from tables, which had no changes in the current batches
+func WithReplayedTables(tables []string, replayTables map[string]int64) []string {
+	for _, table := range slices.Sorted(maps.Keys(replayTables)) {
+		if !slices.Contains(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// TablesNotReplayed returns the tables of tableNameSchemaMapping left out of normalizing batchID again
+// for connectors normalizing one batch at a time
+func TablesNotReplayed(
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+	replayTables map[string]int64,
+	batchID int64,
+) map[string]struct{} {
+	notReplayed := make(map[string]struct{}, len(tableNameSchemaMapping))
+	for table := range tableNameSchemaMapping {
+		if replayBatchID, ok := replayTables[table]; !ok || replayBatchID >= batchID {
+			notReplayed[table] = struct{}{}
+		}
+	}
+	return notReplayed
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestReplayTablesToNormalize(t *testing.T) {
+	replayTables := map[string]int64{"a": 3, "b": 3, "c": 9}
+	// b was truncated in batch 6, c has nothing to replay before batch 8
+	toNormalize := ReplayTablesToNormalize(replayTables, map[string]int64{"b": 6}, 8)
+	require.Equal(t, map[string]int64{"a": 3, "b": 5}, toNormalize)
+	require.Equal(t, int64(3), EarliestReplayBatchID(toNormalize, 8))
+	require.Equal(t, int64(8), EarliestReplayBatchID(nil, 8))
+	require.Nil(t, ReplayTablesToNormalize(nil, nil, 8))
+}
+
+func TestWithReplayedTables(t *testing.T) {
+	require.Equal(t, []string{"b", "a", "c"}, WithReplayedTables([]string{"b"}, map[string]int64{"c": 1, "a": 1, "b": 1}))
+}
+
+func TestTablesNotReplayed(t *testing.T) {
+	schemas := map[string]*protos.TableSchema{"a": {}, "b": {}, "c": {}}
+	replayTables := map[string]int64{"a": 3, "b": 5}
+	require.Equal(t, map[string]struct{}{"c": {}}, TablesNotReplayed(schemas, replayTables, 6))
+	require.Equal(t, map[string]struct{}{"b": {}, "c": {}}, TablesNotReplayed(schemas, replayTables, 5))
+}
@@ -0,0 +1,501 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PeerDB-io/gluamsgpack"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	lua "github.com/yuin/gopher-lua"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/pua"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// ProtoMessageHeader is the header carrying the full name of the message a protobuf encoded payload holds
+const ProtoMessageHeader = "peerdb-proto-message"
+
+// QueueEncoder encodes records for queue destinations of mirrors without a script,
+// returning nil for records that have nowhere to go
+type QueueEncoder interface {
+	Encode(record model.Record[model.RecordItems]) ([]byte, error)
+	// Headers of an encoded record, nil when the encoding needs none
+	Headers(record model.Record[model.RecordItems]) map[string]string
+}
+
+// NewQueueEncoder returns nil for JSON, which is what the default script produces
+func NewQueueEncoder(encoding protos.QueueEncoding, schemas map[string]*protos.TableSchema) QueueEncoder {
+	switch encoding {
+	case protos.QueueEncoding_QUEUE_ENCODING_MSGPACK:
+		return msgpackEncoder{}
+	case protos.QueueEncoding_QUEUE_ENCODING_PROTOBUF:
+		return &protoEncoder{schemas: schemas, messages: make(map[string]protoreflect.MessageDescriptor)}
+	case protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM:
+		return envelopeEncoder{schemas: schemas}
+	case protos.QueueEncoding_QUEUE_ENCODING_UPSERT:
+		return envelopeEncoder{schemas: schemas, upsert: true}
+	default:
+		return nil
+	}
+}
+
+// QueueEncoderOnRecord is the onRecord of mirrors without a script encoding records with encoder
+func QueueEncoderOnRecord(encoder QueueEncoder) lua.LGFunction {
+	return func(ls *lua.LState) int {
+		_, record := pua.LuaRecord.Check(ls, 1)
+		data, err := encoder.Encode(record)
+		if err != nil {
+			ls.RaiseError("failed to encode record: %s", err.Error())
+		}
+		var key []byte
+		if keyEncoder, ok := encoder.(QueueKeyEncoder); ok {
+			key, err = keyEncoder.Key(record)
+			if err != nil {
+				ls.RaiseError("failed to encode record key: %s", err.Error())
+			}
+		}
+		if data == nil && key == nil {
+			return 0
+		}
+		headers := encoder.Headers(record)
+		if len(headers) == 0 && key == nil {
+			ls.Push(lua.LString(shared.UnsafeFastReadOnlyBytesToString(data)))
+			return 1
+		}
+		tbl := ls.CreateTable(0, 3)
+		if key != nil {
+			tbl.RawSetString("key", lua.LString(shared.UnsafeFastReadOnlyBytesToString(key)))
+		}
+		if data != nil {
+			tbl.RawSetString("value", lua.LString(shared.UnsafeFastReadOnlyBytesToString(data)))
+		}
+		if len(headers) > 0 {
+			lheaders := ls.CreateTable(0, len(headers))
+			for k, v := range headers {
+				lheaders.RawSetString(k, lua.LString(v))
+			}
+			tbl.RawSetString("headers", lheaders)
+		}
+		ls.Push(tbl)
+		return 1
+	}
+}
+
+// rows of a record the same way scripts see them as old and new
+func recordRows(record model.Record[model.RecordItems]) (model.RecordItems, model.RecordItems, bool) {
+	switch rec := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		return model.RecordItems{}, rec.Items, true
+	case *model.UpdateRecord[model.RecordItems]:
+		return rec.OldItems, rec.NewItems, true
+	case *model.DeleteRecord[model.RecordItems]:
+		return rec.Items, model.RecordItems{}, true
+	case *model.MessageRecord[model.RecordItems]:
+		return model.RecordItems{}, model.RecordItems{}, rec.DestinationTableName != ""
+	default:
+		return model.RecordItems{}, model.RecordItems{}, false
+	}
+}
+
+// msgpackEncoder encodes records as maps with the keys of the default JSON encoding
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) Headers(model.Record[model.RecordItems]) map[string]string {
+	return nil
+}
+
+func (msgpackEncoder) Encode(record model.Record[model.RecordItems]) ([]byte, error) {
+	oldItems, newItems, ok := recordRows(record)
+	if !ok {
+		return nil, nil
+	}
+
+	size := 4
+	if oldItems.ColToVal != nil {
+		size += 1
+	}
+	if newItems.ColToVal != nil {
+		size += 1
+	}
+	ur, isUpdate := record.(*model.UpdateRecord[model.RecordItems])
+	if isUpdate && len(ur.UnchangedToastColumns) > 0 {
+		size += 1
+	}
+	mr, isMessage := record.(*model.MessageRecord[model.RecordItems])
+	if isMessage {
+		size += 2
+	}
+
+	buf := msgpackMapHeader(make([]byte, 0, 256), size)
+	buf = msgpackString(msgpackString(buf, "kind"), record.Kind())
+	buf = gluamsgpack.Signed(record.GetCheckpointID()).PackMsg(msgpackString(buf, "checkpoint"))
+	buf = gluamsgpack.Time(record.GetCommitTime()).PackMsg(msgpackString(buf, "commit_time"))
+	buf = msgpackString(msgpackString(buf, "source"), record.GetSourceTableName())
+	if oldItems.ColToVal != nil {
+		buf = msgpackRow(msgpackString(buf, "old"), oldItems)
+	}
+	if newItems.ColToVal != nil {
+		buf = msgpackRow(msgpackString(buf, "new"), newItems)
+	}
+	if isUpdate && len(ur.UnchangedToastColumns) > 0 {
+		buf = msgpackArrayHeader(msgpackString(buf, "unchanged_columns"), len(ur.UnchangedToastColumns))
+		for col := range ur.UnchangedToastColumns {
+			buf = msgpackString(buf, col)
+		}
+	}
+	if isMessage {
+		buf = msgpackString(msgpackString(buf, "prefix"), mr.Prefix)
+		buf = msgpackString(msgpackString(buf, "content"), mr.Content)
+	}
+	return buf, nil
+}
+
+func msgpackRow(buf []byte, items model.RecordItems) []byte {
+	buf = msgpackMapHeader(buf, len(items.ColToVal))
+	for col, qv := range items.ColToVal {
+		buf = msgpackString(buf, col)
+		if qv == nil {
+			buf = append(buf, 0xc0)
+		} else {
+			buf = msgpackValue(buf, qv.Value())
+		}
+	}
+	return buf
+}
+
+func msgpackValue(buf []byte, value any) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, 0xc0)
+	case bool:
+		if v {
+			return append(buf, 0xc3)
+		}
+		return append(buf, 0xc2)
+	case int16:
+		return gluamsgpack.Signed(v).PackMsg(buf)
+	case int32:
+		return gluamsgpack.Signed(v).PackMsg(buf)
+	case int64:
+		return gluamsgpack.Signed(v).PackMsg(buf)
+	case float32:
+		return gluamsgpack.F32(v).PackMsg(buf)
+	case float64:
+		return gluamsgpack.F64(v).PackMsg(buf)
+	case string:
+		return msgpackString(buf, v)
+	case []byte:
+		return gluamsgpack.Bin(v).PackMsg(buf)
+	case time.Time:
+		return gluamsgpack.Time(v).PackMsg(buf)
+	case decimal.Decimal:
+		return msgpackString(buf, v.String())
+	case [16]byte:
+		return msgpackString(buf, uuid.UUID(v).String())
+	}
+
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice {
+		buf = msgpackArrayHeader(buf, rv.Len())
+		for i := range rv.Len() {
+			buf = msgpackValue(buf, rv.Index(i).Interface())
+		}
+		return buf
+	}
+	return msgpackString(buf, fmt.Sprint(value))
+}
+
+func msgpackString(buf []byte, s string) []byte {
+	return gluamsgpack.Str(s).PackMsg(buf)
+}
+
+func msgpackMapHeader(buf []byte, size int) []byte {
+	switch {
+	case size < 16:
+		return append(buf, 0x80|byte(size))
+	case size < 0x10000:
+		return append(buf, 0xde, byte(size>>8), byte(size))
+	default:
+		return append(buf, 0xdf, byte(size>>24), byte(size>>16), byte(size>>8), byte(size))
+	}
+}
+
+func msgpackArrayHeader(buf []byte, size int) []byte {
+	switch {
+	case size < 16:
+		return append(buf, 0x90|byte(size))
+	case size < 0x10000:
+		return append(buf, 0xdc, byte(size>>8), byte(size))
+	default:
+		return append(buf, 0xdd, byte(size>>24), byte(size>>16), byte(size>>8), byte(size))
+	}
+}
+
+// protoEncoder encodes records as messages generated from the schema of their destination table:
+// kind = 1, checkpoint = 2, commit_time = 3 in microseconds, source = 4, old = 5 and new = 6 of nested message Row,
+// unchanged_columns = 7, prefix = 8, content = 9. Row numbers columns in schema order starting at 1,
+// so columns added later get new numbers. Timestamps are microseconds since epoch
+type protoEncoder struct {
+	schemas  map[string]*protos.TableSchema
+	messages map[string]protoreflect.MessageDescriptor
+	mu       sync.Mutex
+}
+
+func (e *protoEncoder) Headers(record model.Record[model.RecordItems]) map[string]string {
+	return map[string]string{ProtoMessageHeader: ProtoMessageName(record.GetDestinationTableName())}
+}
+
+// ProtoMessageName is the full name of the message generated for a destination table
+func ProtoMessageName(table string) string {
+	return "peerdb." + protoIdentifier(table)
+}
+
+func protoIdentifier(name string) string {
+	ident := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if ident == "" || (ident[0] >= '0' && ident[0] <= '9') {
+		ident = "_" + ident
+	}
+	return ident
+}
+
+func (e *protoEncoder) Encode(record model.Record[model.RecordItems]) ([]byte, error) {
+	oldItems, newItems, ok := recordRows(record)
+	if !ok {
+		return nil, nil
+	}
+
+	_, isMessage := record.(*model.MessageRecord[model.RecordItems])
+	desc, err := e.messageDescriptor(record.GetDestinationTableName(), isMessage)
+	if err != nil {
+		return nil, err
+	}
+	fields := desc.Fields()
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(fields.ByNumber(1), protoreflect.ValueOfString(record.Kind()))
+	msg.Set(fields.ByNumber(2), protoreflect.ValueOfInt64(record.GetCheckpointID()))
+	msg.Set(fields.ByNumber(3), protoreflect.ValueOfInt64(record.GetCommitTime().UnixMicro()))
+	msg.Set(fields.ByNumber(4), protoreflect.ValueOfString(record.GetSourceTableName()))
+	if oldItems.ColToVal != nil {
+		if err := setProtoRow(msg, fields.ByNumber(5), oldItems); err != nil {
+			return nil, err
+		}
+	}
+	if newItems.ColToVal != nil {
+		if err := setProtoRow(msg, fields.ByNumber(6), newItems); err != nil {
+			return nil, err
+		}
+	}
+	switch rec := record.(type) {
+	case *model.UpdateRecord[model.RecordItems]:
+		unchanged := msg.Mutable(fields.ByNumber(7)).List()
+		for col := range rec.UnchangedToastColumns {
+			unchanged.Append(protoreflect.ValueOfString(col))
+		}
+	case *model.MessageRecord[model.RecordItems]:
+		msg.Set(fields.ByNumber(8), protoreflect.ValueOfString(rec.Prefix))
+		msg.Set(fields.ByNumber(9), protoreflect.ValueOfString(rec.Content))
+	}
+	return proto.Marshal(msg)
+}
+
+func setProtoRow(msg *dynamicpb.Message, fd protoreflect.FieldDescriptor, items model.RecordItems) error {
+	row := dynamicpb.NewMessage(fd.Message())
+	rowFields := fd.Message().Fields()
+	for i := range rowFields.Len() {
+		field := rowFields.Get(i)
+		qv := items.GetColumnValue(field.JSONName())
+		if qv == nil || qv.Value() == nil {
+			continue
+		}
+		if field.IsList() {
+			rv := reflect.ValueOf(qv.Value())
+			if rv.Kind() != reflect.Slice {
+				return fmt.Errorf("column %s is not an array", field.JSONName())
+			}
+			list := row.Mutable(field).List()
+			for j := range rv.Len() {
+				value, err := protoScalar(field.Kind(), rv.Index(j).Interface())
+				if err != nil {
+					return fmt.Errorf("column %s: %w", field.JSONName(), err)
+				}
+				list.Append(value)
+			}
+		} else {
+			value, err := protoScalar(field.Kind(), qv.Value())
+			if err != nil {
+				return fmt.Errorf("column %s: %w", field.JSONName(), err)
+			}
+			row.Set(field, value)
+		}
+	}
+	msg.Set(fd, protoreflect.ValueOfMessage(row))
+	return nil
+}
+
+func protoScalar(kind protoreflect.Kind, value any) (protoreflect.Value, error) {
+	switch kind {
+	case protoreflect.Int64Kind:
+		switch v := value.(type) {
+		case int16:
+			return protoreflect.ValueOfInt64(int64(v)), nil
+		case int32:
+			return protoreflect.ValueOfInt64(int64(v)), nil
+		case int64:
+			return protoreflect.ValueOfInt64(v), nil
+		case time.Time:
+			return protoreflect.ValueOfInt64(v.UnixMicro()), nil
+		}
+	case protoreflect.DoubleKind:
+		switch v := value.(type) {
+		case float32:
+			return protoreflect.ValueOfFloat64(float64(v)), nil
+		case float64:
+			return protoreflect.ValueOfFloat64(v), nil
+		}
+	case protoreflect.BoolKind:
+		if v, ok := value.(bool); ok {
+			return protoreflect.ValueOfBool(v), nil
+		}
+	case protoreflect.BytesKind:
+		if v, ok := value.([]byte); ok {
+			return protoreflect.ValueOfBytes(v), nil
+		}
+	case protoreflect.StringKind:
+		switch v := value.(type) {
+		case string:
+			return protoreflect.ValueOfString(v), nil
+		case time.Time:
+			return protoreflect.ValueOfString(v.Format(time.RFC3339Nano)), nil
+		case decimal.Decimal:
+			return protoreflect.ValueOfString(v.String()), nil
+		case [16]byte:
+			return protoreflect.ValueOfString(uuid.UUID(v).String()), nil
+		case []byte:
+			return protoreflect.ValueOfString(string(v)), nil
+		default:
+			return protoreflect.ValueOfString(fmt.Sprint(v)), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("cannot encode %T as %s", value, kind)
+}
+
+func protoFieldType(kind qvalue.QValueKind) descriptorpb.FieldDescriptorProto_Type {
+	switch kind {
+	case qvalue.QValueKindInt16, qvalue.QValueKindInt32, qvalue.QValueKindInt64,
+		qvalue.QValueKindTimestamp, qvalue.QValueKindTimestampTZ,
+		qvalue.QValueKindArrayInt16, qvalue.QValueKindArrayInt32, qvalue.QValueKindArrayInt64,
+		qvalue.QValueKindArrayTimestamp, qvalue.QValueKindArrayTimestampTZ:
+		return descriptorpb.FieldDescriptorProto_TYPE_INT64
+	case qvalue.QValueKindFloat32, qvalue.QValueKindFloat64,
+		qvalue.QValueKindArrayFloat32, qvalue.QValueKindArrayFloat64:
+		return descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+	case qvalue.QValueKindBoolean, qvalue.QValueKindArrayBoolean:
+		return descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	case qvalue.QValueKindBytes:
+		return descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	default:
+		return descriptorpb.FieldDescriptorProto_TYPE_STRING
+	}
+}
+
+func (e *protoEncoder) messageDescriptor(table string, isMessage bool) (protoreflect.MessageDescriptor, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if desc, ok := e.messages[table]; ok {
+		return desc, nil
+	}
+
+	schema, ok := e.schemas[table]
+	if !ok {
+		// logical message destinations need no columns
+		if !isMessage {
+			return nil, fmt.Errorf("no schema for table %s", table)
+		}
+		schema = &protos.TableSchema{}
+	}
+	file, err := protodesc.NewFile(protoFileDescriptor(table, schema), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate protobuf schema of %s: %w", table, err)
+	}
+	desc := file.Messages().Get(0)
+	e.messages[table] = desc
+	return desc, nil
+}
+
+func protoFileDescriptor(table string, schema *protos.TableSchema) *descriptorpb.FileDescriptorProto {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	stringType := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	int64Type := descriptorpb.FieldDescriptorProto_TYPE_INT64
+	messageType := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	name := protoIdentifier(table)
+
+	row := &descriptorpb.DescriptorProto{Name: proto.String("Row")}
+	names := make(map[string]struct{}, len(schema.Columns))
+	for i, column := range schema.Columns {
+		fieldName := protoIdentifier(column.Name)
+		for {
+			if _, ok := names[strings.ToLower(fieldName)]; !ok {
+				break
+			}
+			fieldName += "_"
+		}
+		names[strings.ToLower(fieldName)] = struct{}{}
+
+		kind := qvalue.QValueKind(column.Type)
+		field := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(fieldName),
+			JsonName: proto.String(column.Name),
+			Number:   proto.Int32(int32(i + 1)),
+			Type:     protoFieldType(kind).Enum(),
+		}
+		if kind.IsArray() {
+			field.Label = &repeated
+		} else {
+			// explicit presence tells nulls apart from zero values
+			field.Label = &optional
+			field.Proto3Optional = proto.Bool(true)
+			field.OneofIndex = proto.Int32(int32(len(row.OneofDecl)))
+			row.OneofDecl = append(row.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + fieldName)})
+		}
+		row.Field = append(row.Field, field)
+	}
+
+	rowTypeName := "." + ProtoMessageName(table) + ".Row"
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("peerdb/" + name + ".proto"),
+		Package: proto.String("peerdb"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("kind"), Number: proto.Int32(1), Label: &optional, Type: &stringType},
+				{Name: proto.String("checkpoint"), Number: proto.Int32(2), Label: &optional, Type: &int64Type},
+				{Name: proto.String("commit_time"), Number: proto.Int32(3), Label: &optional, Type: &int64Type},
+				{Name: proto.String("source"), Number: proto.Int32(4), Label: &optional, Type: &stringType},
+				{Name: proto.String("old"), Number: proto.Int32(5), Label: &optional, Type: &messageType, TypeName: &rowTypeName},
+				{Name: proto.String("new"), Number: proto.Int32(6), Label: &optional, Type: &messageType, TypeName: &rowTypeName},
+				{Name: proto.String("unchanged_columns"), Number: proto.Int32(7), Label: &repeated, Type: &stringType},
+				{Name: proto.String("prefix"), Number: proto.Int32(8), Label: &optional, Type: &stringType},
+				{Name: proto.String("content"), Number: proto.Int32(9), Label: &optional, Type: &stringType},
+			},
+			NestedType: []*descriptorpb.DescriptorProto{row},
+		}},
+	}
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func queueEncodingTestRecord() (*model.UpdateRecord[model.RecordItems], map[string]*protos.TableSchema) {
+	oldItems := model.NewRecordItems(2)
+	oldItems.AddColumn("id", qvalue.QValueInt64{Val: 1})
+	oldItems.AddColumn("user name", qvalue.QValueString{Val: "old"})
+	newItems := model.NewRecordItems(3)
+	newItems.AddColumn("id", qvalue.QValueInt64{Val: 1})
+	newItems.AddColumn("user name", qvalue.QValueString{Val: "new"})
+	newItems.AddColumn("tags", qvalue.QValueArrayString{Val: []string{"a", "b"}})
+	newItems.AddColumn("score", qvalue.QValueNull(qvalue.QValueKindFloat64))
+
+	return &model.UpdateRecord[model.RecordItems]{
+		OldItems:             oldItems,
+		NewItems:             newItems,
+		SourceTableName:      "public.users",
+		DestinationTableName: "users",
+		BaseRecord:           model.BaseRecord{CheckpointID: 42},
+	}, map[string]*protos.TableSchema{
+		"users": {
+			TableIdentifier: "users",
+			Columns: []*protos.FieldDescription{
+				{Name: "id", Type: string(qvalue.QValueKindInt64)},
+				{Name: "user name", Type: string(qvalue.QValueKindString)},
+				{Name: "tags", Type: string(qvalue.QValueKindArrayString)},
+				{Name: "score", Type: string(qvalue.QValueKindFloat64)},
+			},
+		},
+	}
+}
+
+func TestProtoQueueEncoder(t *testing.T) {
+	record, schemas := queueEncodingTestRecord()
+	encoder := NewQueueEncoder(protos.QueueEncoding_QUEUE_ENCODING_PROTOBUF, schemas).(*protoEncoder)
+	require.Equal(t, map[string]string{ProtoMessageHeader: "peerdb.users"}, encoder.Headers(record))
+
+	data, err := encoder.Encode(record)
+	require.NoError(t, err)
+
+	desc, err := encoder.messageDescriptor("users", false)
+	require.NoError(t, err)
+	msg := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(data, msg))
+
+	fields := desc.Fields()
+	require.Equal(t, "update", msg.Get(fields.ByName("kind")).String())
+	require.Equal(t, int64(42), msg.Get(fields.ByName("checkpoint")).Int())
+
+	row := msg.Get(fields.ByName("new")).Message()
+	rowFields := fields.ByName("new").Message().Fields()
+	require.Equal(t, int64(1), row.Get(rowFields.ByNumber(1)).Int())
+	require.Equal(t, "user_name", string(rowFields.ByNumber(2).Name()))
+	require.Equal(t, "new", row.Get(rowFields.ByNumber(2)).String())
+	require.Equal(t, 2, row.Get(rowFields.ByNumber(3)).List().Len())
+	// nulls stay unset instead of becoming zero
+	require.False(t, row.Has(rowFields.ByNumber(4)))
+
+	oldRow := msg.Get(fields.ByName("old")).Message()
+	require.Equal(t, "old", oldRow.Get(rowFields.ByNumber(2)).String())
+}
+
+func TestMsgpackQueueEncoder(t *testing.T) {
+	record, schemas := queueEncodingTestRecord()
+	encoder := NewQueueEncoder(protos.QueueEncoding_QUEUE_ENCODING_MSGPACK, schemas)
+	require.Nil(t, encoder.Headers(record))
+
+	data, err := encoder.Encode(record)
+	require.NoError(t, err)
+	// map of kind, checkpoint, commit_time, source, old and new
+	require.Equal(t, byte(0x86), data[0])
+	require.Contains(t, string(data), "user name")
+
+	require.Nil(t, NewQueueEncoder(protos.QueueEncoding_QUEUE_ENCODING_JSON, schemas))
+}
+
+func TestCloudEvent(t *testing.T) {
+	record, schemas := queueEncodingTestRecord()
+	event := NewCloudEvent("mirror", record, schemas["users"].PrimaryKeyColumns, 0, QueueContentType("", 0, nil))
+	require.Equal(t, "io.peerdb.cdc.update", event.Type)
+	require.Equal(t, "/peerdb/mirrors/mirror", event.Source)
+	require.Equal(t, "users", event.Subject)
+	require.Equal(t, "application/json", event.ContentType(protos.CloudEventsMode_CLOUD_EVENTS_MODE_BINARY))
+	require.Equal(t, "application/cloudevents+json", event.ContentType(protos.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED))
+	require.Equal(t, "1.0", event.Attributes()["specversion"])
+
+	envelope, err := event.Structured([]byte(`{"id":1}`))
+	require.NoError(t, err)
+	require.Contains(t, string(envelope), `"data":{"id":1}`)
+
+	event.DataContentType = QueueContentType("", protos.QueueEncoding_QUEUE_ENCODING_MSGPACK, nil)
+	envelope, err = event.Structured([]byte{0x80})
+	require.NoError(t, err)
+	require.Contains(t, string(envelope), `"data_base64":"gA=="`)
+}
+
+func TestEnvelopeQueueEncoders(t *testing.T) {
+	record, schemas := queueEncodingTestRecord()
+	schemas["users"].PrimaryKeyColumns = []string{"id"}
+	record.UnchangedToastColumns = map[string]struct{}{"bio": {}}
+	record.CommitTimeNano = 1_700_000_000_123_000_000
+
+	encoder := NewQueueEncoder(protos.QueueEncoding_QUEUE_ENCODING_DEBEZIUM, schemas)
+	key, err := encoder.(QueueKeyEncoder).Key(record)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1}`, string(key))
+	data, err := encoder.Encode(record)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"before": {"id":1,"user name":"old"},
+		"after": {"id":1,"user name":"new","tags":["a","b"],"score":null,"bio":"__debezium_unavailable_value"},
+		"op": "u",
+		"ts_ms": 1700000000123,
+		"source": {"connector":"peerdb","table":"public.users","lsn":42,"ts_ms":1700000000123}
+	}`, string(data))
+
+	encoder = NewQueueEncoder(protos.QueueEncoding_QUEUE_ENCODING_UPSERT, schemas)
+	data, err = encoder.Encode(record)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"user name":"new","tags":["a","b"],"score":null}`, string(data))
+
+	// deletes are tombstones of the key
+	deleteRecord := &model.DeleteRecord[model.RecordItems]{Items: record.OldItems, DestinationTableName: "users"}
+	data, err = encoder.Encode(deleteRecord)
+	require.NoError(t, err)
+	require.Nil(t, data)
+	key, err = encoder.(QueueKeyEncoder).Key(deleteRecord)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1}`, string(key))
+}
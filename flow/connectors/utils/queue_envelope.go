@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+// DebeziumUnavailableValue is what Debezium puts in place of unchanged TOAST columns, which consumers recognize
+const DebeziumUnavailableValue = "__debezium_unavailable_value"
+
+// QueueKeyEncoder is implemented by encodings whose messages are keyed by primary key,
+// a nil payload with a key being a tombstone
+type QueueKeyEncoder interface {
+	Key(record model.Record[model.RecordItems]) ([]byte, error)
+}
+
+// envelopeEncoder encodes rows as JSON with keys consumers of changelogs like Materialize and RisingWave expect,
+// messages are keyed by a JSON object of the primary key columns of their destination table
+type envelopeEncoder struct {
+	schemas map[string]*protos.TableSchema
+	upsert  bool
+}
+
+func (envelopeEncoder) Headers(model.Record[model.RecordItems]) map[string]string {
+	return nil
+}
+
+func (e envelopeEncoder) Key(record model.Record[model.RecordItems]) ([]byte, error) {
+	oldItems, newItems, ok := recordRows(record)
+	if !ok {
+		return nil, nil
+	}
+	if _, isMessage := record.(*model.MessageRecord[model.RecordItems]); isMessage {
+		return nil, nil
+	}
+	pkeyCols := e.schemas[record.GetDestinationTableName()].GetPrimaryKeyColumns()
+	if len(pkeyCols) == 0 {
+		return nil, nil
+	}
+	items := newItems
+	if items.ColToVal == nil {
+		items = oldItems
+	}
+	key := model.NewRecordItems(len(pkeyCols))
+	for _, col := range pkeyCols {
+		key.AddColumn(col, items.GetColumnValue(col))
+	}
+	return key.MarshalJSON()
+}
+
+// Encode is the row for upserts, nil for deletes to be tombstones, otherwise a Debezium envelope
+func (e envelopeEncoder) Encode(record model.Record[model.RecordItems]) ([]byte, error) {
+	oldItems, newItems, ok := recordRows(record)
+	if !ok {
+		return nil, nil
+	}
+	if _, isMessage := record.(*model.MessageRecord[model.RecordItems]); isMessage {
+		return nil, nil
+	}
+
+	if e.upsert {
+		// unchanged TOAST columns are left out, consumers replace the whole row
+		if newItems.ColToVal == nil {
+			return nil, nil
+		}
+		return newItems.MarshalJSON()
+	}
+
+	var op string
+	switch rec := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		op = "c"
+	case *model.UpdateRecord[model.RecordItems]:
+		op = "u"
+		if len(rec.UnchangedToastColumns) > 0 {
+			newItems = withUnavailableColumns(newItems, rec.UnchangedToastColumns)
+		}
+	case *model.DeleteRecord[model.RecordItems]:
+		op = "d"
+	}
+	before, err := envelopeRow(oldItems)
+	if err != nil {
+		return nil, err
+	}
+	after, err := envelopeRow(newItems)
+	if err != nil {
+		return nil, err
+	}
+	tsMs := record.GetCommitTime().UnixMilli()
+	return json.Marshal(map[string]any{
+		"before": before,
+		"after":  after,
+		"op":     op,
+		"ts_ms":  tsMs,
+		"source": map[string]any{
+			"connector": "peerdb",
+			"table":     record.GetSourceTableName(),
+			"lsn":       record.GetCheckpointID(),
+			"ts_ms":     tsMs,
+		},
+	})
+}
+
+func envelopeRow(items model.RecordItems) (json.RawMessage, error) {
+	if items.ColToVal == nil {
+		return json.RawMessage("null"), nil
+	}
+	row, err := items.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode row: %w", err)
+	}
+	return row, nil
+}
+
+func withUnavailableColumns(items model.RecordItems, unchanged map[string]struct{}) model.RecordItems {
+	filled := model.NewRecordItems(len(items.ColToVal) + len(unchanged))
+	for col, qv := range items.ColToVal {
+		filled.AddColumn(col, qv)
+	}
+	for col := range unchanged {
+		filled.AddColumn(col, qvalue.QValueString{Val: DebeziumUnavailableValue})
+	}
+	return filled
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// RecordBatcher gathers records of a sync into batches of sinks delivering them through flush.
+// Batches are flushed one at a time in order, so once flush returns every record up to the last one
+// of its batch was delivered, which is the offset saved when a later flush fails.
+type RecordBatcher[Items model.Items] struct {
+	logger        log.Logger
+	req           *model.SyncRecordsRequest[Items]
+	setLastOffset func(ctx context.Context, jobName string, offset int64) error
+	// flush delivers records along with whatever the sink encoded of them since the last flush
+	flush                func(ctx context.Context, records []model.Record[Items]) error
+	records              []model.Record[Items]
+	tableNameRowsMapping map[string]*model.RecordTypeCounts
+	batchSize            int
+	numRecords           int64
+	lastSeen             atomic.Int64
+}
+
+func NewRecordBatcher[Items model.Items](
+	logger log.Logger,
+	req *model.SyncRecordsRequest[Items],
+	batchSize int,
+	setLastOffset func(ctx context.Context, jobName string, offset int64) error,
+	flush func(ctx context.Context, records []model.Record[Items]) error,
+) *RecordBatcher[Items] {
+	return &RecordBatcher[Items]{
+		logger:               logger,
+		req:                  req,
+		setLastOffset:        setLastOffset,
+		flush:                flush,
+		records:              make([]model.Record[Items], 0, batchSize),
+		tableNameRowsMapping: InitialiseTableRowsMap(req.TableMappings),
+		batchSize:            batchSize,
+	}
+}
+
+// Add adds record to the batch once the sink encoded it, flushing full batches
+func (b *RecordBatcher[Items]) Add(ctx context.Context, record model.Record[Items]) error {
+	b.records = append(b.records, record)
+	if len(b.records) < b.batchSize {
+		return nil
+	}
+	return b.Flush(ctx)
+}
+
+// Flush delivers records added since the last flush, saving the offset delivered up to when it fails
+func (b *RecordBatcher[Items]) Flush(ctx context.Context) error {
+	if len(b.records) == 0 {
+		return nil
+	}
+	if err := b.flush(ctx, b.records); err != nil {
+		b.SaveLastSeenOffset(ctx)
+		return err
+	}
+	for _, record := range b.records {
+		record.PopulateCountMap(b.tableNameRowsMapping)
+	}
+	b.numRecords += int64(len(b.records))
+	shared.AtomicInt64Max(&b.lastSeen, b.records[len(b.records)-1].GetCheckpointID())
+	b.records = b.records[:0]
+	return nil
+}
+
+// SaveLastSeenOffset saves the offset delivered up to, safe to call while records are added
+func (b *RecordBatcher[Items]) SaveLastSeenOffset(ctx context.Context) {
+	SaveLastSeenOffset(ctx, b.logger, b.req, b.lastSeen.Load(), b.setLastOffset)
+}
+
+func (b *RecordBatcher[Items]) NumRecords() int64 {
+	return b.numRecords
+}
+
+func (b *RecordBatcher[Items]) TableNameRowsMapping() map[string]*model.RecordTypeCounts {
+	return b.tableNameRowsMapping
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+func TestRecordBatcher(t *testing.T) {
+	consumed := &atomic.Int64{}
+	req := &model.SyncRecordsRequest[model.RecordItems]{
+		FlowJobName:    "flow",
+		ConsumedOffset: consumed,
+		TableMappings:  []*protos.TableMapping{{DestinationTableIdentifier: "t"}},
+	}
+	var saved []int64
+	setLastOffset := func(_ context.Context, _ string, offset int64) error {
+		saved = append(saved, offset)
+		return nil
+	}
+	var flushed [][]int64
+	fail := false
+	batcher := NewRecordBatcher(log.NewStructuredLogger(slog.Default()), req, 2, setLastOffset,
+		func(_ context.Context, records []model.Record[model.RecordItems]) error {
+			if fail {
+				return errors.New("sink down")
+			}
+			ids := make([]int64, 0, len(records))
+			for _, record := range records {
+				ids = append(ids, record.GetCheckpointID())
+			}
+			flushed = append(flushed, ids)
+			return nil
+		})
+	record := func(id int64) model.Record[model.RecordItems] {
+		return &model.InsertRecord[model.RecordItems]{
+			BaseRecord:           model.BaseRecord{CheckpointID: id},
+			DestinationTableName: "t",
+		}
+	}
+
+	ctx := context.Background()
+	for id := int64(1); id <= 3; id++ {
+		require.NoError(t, batcher.Add(ctx, record(id)))
+	}
+	require.Equal(t, [][]int64{{1, 2}}, flushed)
+	require.NoError(t, batcher.Flush(ctx))
+	require.Equal(t, [][]int64{{1, 2}, {3}}, flushed)
+	require.Equal(t, int64(3), batcher.NumRecords())
+	require.Equal(t, int32(3), batcher.TableNameRowsMapping()["t"].InsertCount.Load())
+	require.Empty(t, saved)
+
+	// a failed flush saves the offset delivered up to, records of the failed batch aren't counted
+	fail = true
+	require.NoError(t, batcher.Add(ctx, record(4)))
+	require.Error(t, batcher.Add(ctx, record(5)))
+	require.Equal(t, []int64{3}, saved)
+	require.Equal(t, int64(3), batcher.NumRecords())
+}
@@ -0,0 +1,9 @@
+package utils
+
+import "time"
+
+// RetentionCutoffLiteral formats the cutoff of a retention policy in UTC for the literals of destinations,
+// rows older than it are deleted
+func RetentionCutoffLiteral(cutoff time.Time) string {
+	return cutoff.UTC().Format("2006-01-02 15:04:05.999999")
+}
@@ -0,0 +1,44 @@
+package utils
+
+import "strings"
+
+// SampledKeysFilter builds `(k1,k2) IN ((?,?),(?,?))` matching rows of sampled keys,
+// placeholder renders the bind parameter at a zero based position
+func SampledKeysFilter(quotedKeyColumns []string, numKeys int, placeholder func(int) string) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+	sb.WriteString(strings.Join(quotedKeyColumns, ","))
+	sb.WriteString(") IN (")
+	for i := range numKeys {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("(")
+		for j := range quotedKeyColumns {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(placeholder(i*len(quotedKeyColumns) + j))
+		}
+		sb.WriteString(")")
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// SampledKeyArgs flattens keys into bind parameters in the order of SampledKeysFilter
+func SampledKeyArgs(keys [][]any) []any {
+	var args []any
+	for _, key := range keys {
+		args = append(args, key...)
+	}
+	return args
+}
+
+// RowDeleteFilter matches rows of keys like SampledKeysFilter, or rows matching predicate when there are no keys
+func RowDeleteFilter(quotedKeyColumns []string, keys [][]any, predicate string, placeholder func(int) string) (string, []any) {
+	if len(keys) == 0 {
+		return "(" + predicate + ")", nil
+	}
+	return SampledKeysFilter(quotedKeyColumns, len(keys), placeholder), SampledKeyArgs(keys)
+}
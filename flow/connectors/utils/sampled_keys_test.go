@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowDeleteFilter(t *testing.T) {
+	placeholder := func(i int) string { return "$" + strconv.Itoa(i+1) }
+
+	filter, args := RowDeleteFilter([]string{`"a"`, `"b"`}, [][]any{{"1", "x"}, {"2", "y"}}, "", placeholder)
+	require.Equal(t, `("a","b") IN (($1,$2),($3,$4))`, filter)
+	require.Equal(t, []any{"1", "x", "2", "y"}, args)
+
+	filter, args = RowDeleteFilter([]string{`"a"`}, nil, "email = 'a@b.c' OR a = 1", placeholder)
+	require.Equal(t, "(email = 'a@b.c' OR a = 1)", filter)
+	require.Empty(t, args)
+}
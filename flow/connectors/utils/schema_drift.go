@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// DestinationColumn is a column of a destination table, with the type as the destination names it
+type DestinationColumn struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
+
+// DiffTableSchema reports how actual columns of a destination table drifted from expected ones, nil actual columns
+// being a missing table. Columns match by name, extra columns in mirrorColumns are ones mirrors add themselves.
+// Destinations name types in more than one way, so sameType decides whether types match
+func DiffTableSchema(
+	table string,
+	expected []DestinationColumn,
+	actual []DestinationColumn,
+	mirrorColumns []string,
+	sameType func(expected string, actual string) bool,
+) []*protos.SchemaDrift {
+	if actual == nil {
+		return []*protos.SchemaDrift{{DestinationTableIdentifier: table, Kind: protos.SchemaDriftKind_SCHEMA_DRIFT_MISSING_TABLE}}
+	}
+
+	actualColumns := make(map[string]DestinationColumn, len(actual))
+	for _, col := range actual {
+		actualColumns[col.Name] = col
+	}
+	var drifts []*protos.SchemaDrift
+	expectedColumns := make(map[string]struct{}, len(expected)+len(mirrorColumns))
+	for _, col := range expected {
+		expectedColumns[col.Name] = struct{}{}
+		actualCol, ok := actualColumns[col.Name]
+		if !ok {
+			drifts = append(drifts, &protos.SchemaDrift{
+				DestinationTableIdentifier: table,
+				Column:                     col.Name,
+				Kind:                       protos.SchemaDriftKind_SCHEMA_DRIFT_MISSING_COLUMN,
+				Expected:                   col.Type,
+			})
+			continue
+		}
+		if !sameType(col.Type, actualCol.Type) {
+			drifts = append(drifts, &protos.SchemaDrift{
+				DestinationTableIdentifier: table,
+				Column:                     col.Name,
+				Kind:                       protos.SchemaDriftKind_SCHEMA_DRIFT_TYPE_MISMATCH,
+				Expected:                   col.Type,
+				Actual:                     actualCol.Type,
+			})
+		}
+		if col.Nullable != actualCol.Nullable {
+			drifts = append(drifts, &protos.SchemaDrift{
+				DestinationTableIdentifier: table,
+				Column:                     col.Name,
+				Kind:                       protos.SchemaDriftKind_SCHEMA_DRIFT_NULLABILITY_MISMATCH,
+				Expected:                   "nullable=" + strconv.FormatBool(col.Nullable),
+				Actual:                     "nullable=" + strconv.FormatBool(actualCol.Nullable),
+			})
+		}
+	}
+	for _, col := range mirrorColumns {
+		expectedColumns[col] = struct{}{}
+	}
+	for _, col := range actual {
+		if _, ok := expectedColumns[col.Name]; !ok {
+			drifts = append(drifts, &protos.SchemaDrift{
+				DestinationTableIdentifier: table,
+				Column:                     col.Name,
+				Kind:                       protos.SchemaDriftKind_SCHEMA_DRIFT_EXTRA_COLUMN,
+				Actual:                     col.Type,
+			})
+		}
+	}
+	return drifts
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestDiffTableSchema(t *testing.T) {
+	sameType := func(expected string, actual string) bool {
+		return strings.EqualFold(expected, actual)
+	}
+	expected := []DestinationColumn{
+		{Name: "id", Type: "bigint"},
+		{Name: "name", Type: "text", Nullable: true},
+		{Name: "score", Type: "numeric", Nullable: true},
+		{Name: "dropped", Type: "text", Nullable: true},
+	}
+	require.Empty(t, DiffTableSchema("public.t", expected, expected, nil, sameType))
+
+	drifts := DiffTableSchema("public.t", expected, []DestinationColumn{
+		{Name: "id", Type: "BIGINT"},
+		{Name: "name", Type: "text"},
+		{Name: "score", Type: "double precision", Nullable: true},
+		{Name: "_peerdb_synced_at", Type: "timestamp", Nullable: true},
+		{Name: "added", Type: "text", Nullable: true},
+	}, []string{"_peerdb_synced_at"}, sameType)
+	require.Len(t, drifts, 4)
+	require.Equal(t, protos.SchemaDriftKind_SCHEMA_DRIFT_NULLABILITY_MISMATCH, drifts[0].Kind)
+	require.Equal(t, "name", drifts[0].Column)
+	require.Equal(t, protos.SchemaDriftKind_SCHEMA_DRIFT_TYPE_MISMATCH, drifts[1].Kind)
+	require.Equal(t, "double precision", drifts[1].Actual)
+	require.Equal(t, protos.SchemaDriftKind_SCHEMA_DRIFT_MISSING_COLUMN, drifts[2].Kind)
+	require.Equal(t, "dropped", drifts[2].Column)
+	require.Equal(t, protos.SchemaDriftKind_SCHEMA_DRIFT_EXTRA_COLUMN, drifts[3].Kind)
+	require.Equal(t, "added", drifts[3].Column)
+
+	drifts = DiffTableSchema("public.t", expected, nil, nil, sameType)
+	require.Len(t, drifts, 1)
+	require.Equal(t, protos.SchemaDriftKind_SCHEMA_DRIFT_MISSING_TABLE, drifts[0].Kind)
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// name of the script loading exported partitions of a table into the destination, next to them
+const SnapshotLoadScriptName = "load.sql"
+
+// SnapshotExport is where partitions of a table are written by initial snapshots with an export path,
+// under <export path>/<mirror>/<table>/ so each table can be loaded on its own
+type SnapshotExport struct {
+	Bucket string
+	Prefix string
+}
+
+func NewSnapshotExport(exportPath string, mirrorName string, tableName string) (*SnapshotExport, error) {
+	if !strings.HasPrefix(exportPath, "s3://") {
+		return nil, fmt.Errorf("snapshot export path %s is not an s3:// path", exportPath)
+	}
+	s3o, err := NewS3BucketAndPrefix(exportPath)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotExport{
+		Bucket: s3o.Bucket,
+		Prefix: strings.Trim(path.Join(s3o.Prefix, mirrorName, tableName), "/"),
+	}, nil
+}
+
+func (e *SnapshotExport) Key(fileName string) string {
+	return e.Prefix + "/" + fileName
+}
+
+// URI of the directory of the table, with trailing slash
+func (e *SnapshotExport) URI() string {
+	return fmt.Sprintf("s3://%s/%s/", e.Bucket, e.Prefix)
+}
+
+// PutLoadScript writes the statements loading exported partitions, every partition writing it again as it is the same
+// for all of them, so the script is there as soon as any partition is
+func (e *SnapshotExport) PutLoadScript(
+	ctx context.Context, credsProvider AWSCredentialsProvider, script string, kmsKeyID string,
+) error {
+	s3svc, err := CreateS3Client(ctx, credsProvider)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	if _, err := s3svc.PutObject(ctx, SSEKMS(&s3.PutObjectInput{
+		Bucket: aws.String(e.Bucket),
+		Key:    aws.String(e.Key(SnapshotLoadScriptName)),
+		Body:   strings.NewReader(script),
+	}, kmsKeyID)); err != nil {
+		return fmt.Errorf("failed to write load script of snapshot export: %w", err)
+	}
+	return nil
+}
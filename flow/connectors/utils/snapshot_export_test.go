@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotExport(t *testing.T) {
+	export, err := NewSnapshotExport("s3://seed-bucket/exports/", "orders_mirror", "public.orders")
+	require.NoError(t, err)
+	require.Equal(t, "seed-bucket", export.Bucket)
+	require.Equal(t, "exports/orders_mirror/public.orders/0-1.native.zst", export.Key("0-1.native.zst"))
+	require.Equal(t, "s3://seed-bucket/exports/orders_mirror/public.orders/", export.URI())
+
+	export, err = NewSnapshotExport("s3://seed-bucket", "orders_mirror", "orders")
+	require.NoError(t, err)
+	require.Equal(t, "orders_mirror/orders/load.sql", export.Key(SnapshotLoadScriptName))
+
+	_, err = NewSnapshotExport("gs://seed-bucket/exports", "orders_mirror", "orders")
+	require.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// spillQuotaCheckBytes is how much is written to spill files between checks of the quota
+const spillQuotaCheckBytes = 64 * 1024 * 1024
+
+var ErrSpillQuotaExceeded = errors.New("spill quota exceeded")
+
+// spillWorkerDirPrefix starts the names of directories workers keep their temporary files in,
+// the spill directory may be shared so anything else in it is left alone
+const spillWorkerDirPrefix = "peerdb-spill-"
+
+// workerSpillDir is the directory of temporary files of this worker, named by host so a restarted worker finds its own
+func workerSpillDir() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "worker"
+	}
+	return filepath.Join(peerdbenv.PeerDBSpillDir(),
+		spillWorkerDirPrefix+strings.ReplaceAll(hostname, string(filepath.Separator), "_"))
+}
+
+// SpillDir is the directory of temporary files of flowName on this worker, created if missing
+func SpillDir(flowName string) (string, error) {
+	dir := filepath.Join(workerSpillDir(), flowName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	return dir, nil
+}
+
+// SpillUsage is bytes taken up by temporary files of each mirror with any on this worker
+func SpillUsage() (map[string]int64, error) {
+	root := workerSpillDir()
+	usage := make(map[string]int64)
+	if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// files are removed as spills finish while walking, and nothing spilled yet without the directory
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		flowName, _, _ := strings.Cut(rel, string(filepath.Separator))
+		usage[flowName] += info.Size()
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to measure spill directory: %w", err)
+	}
+	return usage, nil
+}
+
+// CheckSpillQuota errors with ErrSpillQuotaExceeded once temporary files on this worker reach the quota
+func CheckSpillQuota() error {
+	quota := peerdbenv.PeerDBSpillQuotaBytes()
+	if quota == 0 {
+		return nil
+	}
+	usage, err := SpillUsage()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, bytes := range usage {
+		total += bytes
+	}
+	if uint64(total) >= quota {
+		return fmt.Errorf("%w: %d bytes of temporary files in %s, quota is %d bytes",
+			ErrSpillQuotaExceeded, total, workerSpillDir(), quota)
+	}
+	return nil
+}
+
+// CleanSpillDir removes temporary files this worker left behind when it stopped before cleaning up,
+// to be called at startup before any activity spills. Files of other workers sharing the directory are kept
+func CleanSpillDir() error {
+	if err := os.RemoveAll(workerSpillDir()); err != nil {
+		return fmt.Errorf("failed to clean spill directory: %w", err)
+	}
+	return nil
+}
+
+// spillQuotaWriter fails writes once the quota is exceeded, checking as every spillQuotaCheckBytes are written
+type spillQuotaWriter struct {
+	w         io.Writer
+	unchecked int
+}
+
+// NewSpillQuotaWriter wraps w writing to a spill file so writing fails with ErrSpillQuotaExceeded past the quota
+func NewSpillQuotaWriter(w io.Writer) io.Writer {
+	if peerdbenv.PeerDBSpillQuotaBytes() == 0 {
+		return w
+	}
+	return &spillQuotaWriter{w: w}
+}
+
+func (s *spillQuotaWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.unchecked += n
+	if err == nil && s.unchecked >= spillQuotaCheckBytes {
+		s.unchecked = 0
+		err = CheckSpillQuota()
+	}
+	return n, err
+}
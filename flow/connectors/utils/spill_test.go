@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillQuota(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "spill")
+	t.Setenv("PEERDB_SPILL_DIR", root)
+	t.Setenv("PEERDB_SPILL_QUOTA_BYTES", "100")
+
+	usage, err := SpillUsage()
+	require.NoError(t, err)
+	require.Empty(t, usage)
+	require.NoError(t, CheckSpillQuota())
+
+	dir, err := SpillDir("mirror_a")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cdc_x"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cdc_x", "000001.sst"), make([]byte, 40), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1.avro"), make([]byte, 20), 0o600))
+	dir, err = SpillDir("mirror_b")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2.avro"), make([]byte, 30), 0o600))
+
+	usage, err = SpillUsage()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"mirror_a": 60, "mirror_b": 30}, usage)
+	require.NoError(t, CheckSpillQuota())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "3.avro"), make([]byte, 10), 0o600))
+	require.ErrorIs(t, CheckSpillQuota(), ErrSpillQuotaExceeded)
+
+	// files not of any worker and of other workers stay
+	require.NoError(t, os.WriteFile(filepath.Join(root, "unrelated"), nil, 0o600))
+	other := filepath.Join(root, spillWorkerDirPrefix+"other-host", "mirror_a")
+	require.NoError(t, os.MkdirAll(other, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(other, "4.avro"), make([]byte, 10), 0o600))
+
+	require.NoError(t, CleanSpillDir())
+	usage, err = SpillUsage()
+	require.NoError(t, err)
+	require.Empty(t, usage)
+	_, err = os.Stat(filepath.Join(root, "unrelated"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(other, "4.avro"))
+	require.NoError(t, err)
+}
+
+func TestSpillQuotaWriter(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("PEERDB_SPILL_DIR", root)
+	t.Setenv("PEERDB_SPILL_QUOTA_BYTES", "0")
+
+	var buf bytes.Buffer
+	require.Same(t, &buf, NewSpillQuotaWriter(&buf))
+
+	t.Setenv("PEERDB_SPILL_QUOTA_BYTES", "1")
+	dir, err := SpillDir("mirror")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1.avro"), []byte{1}, 0o600))
+	w := NewSpillQuotaWriter(&buf)
+	_, err = w.Write(make([]byte, spillQuotaCheckBytes-1))
+	require.NoError(t, err)
+	_, err = w.Write([]byte{0})
+	require.ErrorIs(t, err, ErrSpillQuotaExceeded)
+}
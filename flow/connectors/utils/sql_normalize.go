@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+// FindTableMapping returns the table mapping of destination table tableIdentifier, nil without one
+func FindTableMapping(tableMappings []*protos.TableMapping, tableIdentifier string) *protos.TableMapping {
+	for _, tableMapping := range tableMappings {
+		if tableMapping.DestinationTableIdentifier == tableIdentifier {
+			return tableMapping
+		}
+	}
+	return nil
+}
+
+// MissingTables returns which of tables don't exist according to tableExists
+func MissingTables(
+	ctx context.Context,
+	tables []string,
+	tableExists func(ctx context.Context, table string) (bool, error),
+) ([]string, error) {
+	var missing []string
+	for _, table := range tables {
+		exists, err := tableExists(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, table)
+		}
+	}
+	return missing, nil
+}
+
+type normalizeBatchIDStore interface {
+	GetLastNormalizeBatchID(ctx context.Context, jobName string) (int64, error)
+	UpdateNormalizeBatchID(ctx context.Context, jobName string, batchID int64) error
+}
+
+// SQLNormalizer normalizes a raw table of records into destination tables with SQL statements of each table,
+// for destinations reached through database/sql keeping their batch IDs in a metadata store
+type SQLNormalizer struct {
+	Logger log.Logger
+	// destination in logs, like singlestore
+	Name       string
+	DB         *sql.DB
+	Metadata   normalizeBatchIDStore
+	QuoteTable func(string) string
+	Exec       func(ctx context.Context, query string) error
+	// TableStatements are statements normalizing batches after startBatchID up to the sync batch into table
+	TableStatements func(table string, tableMapping *protos.TableMapping, startBatchID int64) ([]string, error)
+	// ExecTable runs statements of a table
+	ExecTable func(ctx context.Context, stmts []string) error
+}
+
+func (n *SQLNormalizer) NormalizeRecords(
+	ctx context.Context,
+	req *model.NormalizeRecordsRequest,
+	rawTableName string,
+) (*model.NormalizeResponse, error) {
+	normBatchID, err := n.Metadata.GetLastNormalizeBatchID(ctx, req.FlowJobName)
+	if err != nil {
+		n.Logger.Error(fmt.Sprintf("[%s] error while getting last normalize batch id", n.Name), slog.Any("error", err))
+		return nil, err
+	}
+
+	// normalize has caught up with sync, chill until more records are loaded.
+	if normBatchID >= req.SyncBatchID {
+		return &model.NormalizeResponse{
+			Done:         false,
+			StartBatchID: normBatchID,
+			EndBatchID:   req.SyncBatchID,
+		}, nil
+	}
+
+	destinationTableNames, err := n.distinctTableNamesInBatch(ctx, rawTableName, normBatchID, req.SyncBatchID)
+	if err != nil {
+		return nil, err
+	}
+	destinationTableNames = WithoutSkippedTables(destinationTableNames, req.SkippedTables)
+
+	truncatedTables := TruncatedTablesToNormalize(req.TruncatedTables, normBatchID, req.SyncBatchID)
+	for _, tbl := range slices.Sorted(maps.Keys(truncatedTables)) {
+		if req.TruncatePolicy != protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE {
+			n.Logger.Warn(fmt.Sprintf("[%s] only replicating TRUNCATE is supported, ignoring it", n.Name),
+				slog.String("table", tbl))
+			delete(truncatedTables, tbl)
+			continue
+		}
+		if err := n.Exec(ctx, "TRUNCATE TABLE "+n.QuoteTable(tbl)); err != nil {
+			return nil, fmt.Errorf("error while applying truncate to %s: %w", tbl, err)
+		}
+	}
+
+	for _, tbl := range destinationTableNames {
+		startBatchID := normBatchID
+		if truncateBatchID, ok := truncatedTables[tbl]; ok {
+			startBatchID = max(normBatchID, truncateBatchID-1)
+		}
+		stmts, err := n.TableStatements(tbl, FindTableMapping(req.TableMappings, tbl), startBatchID)
+		if err != nil {
+			return nil, err
+		}
+		if err := n.ExecTable(ctx, stmts); err != nil {
+			return nil, fmt.Errorf("error while normalizing records into %s: %w", tbl, err)
+		}
+	}
+
+	if err := n.Metadata.UpdateNormalizeBatchID(ctx, req.FlowJobName, req.SyncBatchID); err != nil {
+		n.Logger.Error(fmt.Sprintf("[%s] error while updating normalize batch id", n.Name), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &model.NormalizeResponse{
+		Done:         true,
+		StartBatchID: normBatchID + 1,
+		EndBatchID:   req.SyncBatchID,
+	}, nil
+}
+
+func (n *SQLNormalizer) distinctTableNamesInBatch(
+	ctx context.Context,
+	rawTableName string,
+	normalizeBatchID int64,
+	syncBatchID int64,
+) ([]string, error) {
+	rows, err := n.DB.QueryContext(ctx, fmt.Sprintf(
+		"SELECT DISTINCT _peerdb_destination_table_name FROM %s WHERE _peerdb_batch_id > %d AND _peerdb_batch_id <= %d",
+		n.QuoteTable(rawTableName), normalizeBatchID, syncBatchID))
+	if err != nil {
+		return nil, fmt.Errorf("error while querying raw table for distinct table names in batch: %w", err)
+	}
+	defer rows.Close()
+	var tableNames []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, fmt.Errorf("error while scanning table name: %w", err)
+		}
+		tableNames = append(tableNames, tableName)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return tableNames, nil
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestFindTableMapping(t *testing.T) {
+	tableMappings := []*protos.TableMapping{
+		{SourceTableIdentifier: "public.a", DestinationTableIdentifier: "a"},
+		{SourceTableIdentifier: "public.b", DestinationTableIdentifier: "b"},
+	}
+	require.Same(t, tableMappings[1], FindTableMapping(tableMappings, "b"))
+	require.Nil(t, FindTableMapping(tableMappings, "public.b"))
+}
+
+func TestMissingTables(t *testing.T) {
+	existing := map[string]bool{"a": true, "c": true}
+	missing, err := MissingTables(context.Background(), []string{"a", "b", "c", "d"},
+		func(_ context.Context, table string) (bool, error) { return existing[table], nil })
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "d"}, missing)
+}
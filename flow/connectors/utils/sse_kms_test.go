@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEKMS(t *testing.T) {
+	input := SSEKMS(&s3.PutObjectInput{Bucket: aws.String("bucket")}, "")
+	require.Empty(t, input.ServerSideEncryption)
+	require.Nil(t, input.SSEKMSKeyId)
+
+	keyARN := "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	input = SSEKMS(&s3.PutObjectInput{Bucket: aws.String("bucket")}, keyARN)
+	require.Equal(t, types.ServerSideEncryptionAwsKms, input.ServerSideEncryption)
+	require.Equal(t, keyARN, aws.ToString(input.SSEKMSKeyId))
+}
@@ -59,15 +59,49 @@ func RecordsToRawTableStream[Items model.Items](req *model.RecordsToStreamReques
 	})
 
 	go func() {
-		for record := range req.GetRecords() {
-			record.PopulateCountMap(req.TableMapping)
+		send := func(record model.Record[Items]) error {
 			qRecord, err := recordToQRecordOrError(req.BatchID, record)
 			if err != nil {
-				recordStream.Close(err)
-				return
+				return err
 			} else if qRecord != nil {
 				recordStream.Records <- qRecord
 			}
+			return nil
+		}
+
+		var collapser *recordCollapser[Items]
+		if req.CollapseWindow > 0 {
+			collapser = newRecordCollapser[Items](req.CollapseWindow, req.TableNameSchemaMapping)
+		}
+		for record := range req.GetRecords() {
+			record.PopulateCountMap(req.TableMapping)
+			if collapser == nil {
+				if err := send(record); err != nil {
+					recordStream.Close(err)
+					return
+				}
+				continue
+			}
+			if err := collapser.add(record); err != nil {
+				recordStream.Close(err)
+				return
+			}
+			if collapser.full() {
+				for _, record := range collapser.flush() {
+					if err := send(record); err != nil {
+						recordStream.Close(err)
+						return
+					}
+				}
+			}
+		}
+		if collapser != nil {
+			for _, record := range collapser.flush() {
+				if err := send(record); err != nil {
+					recordStream.Close(err)
+					return
+				}
+			}
 		}
 
 		close(recordStream.Records)
@@ -115,7 +149,18 @@ func recordToQRecordOrError[Items model.Items](batchID int64, record model.Recor
 		entries[7] = qvalue.QValueString{Val: KeysToString(typedRecord.UnchangedToastColumns)}
 
 	case *model.MessageRecord[Items]:
-		return nil, nil
+		if typedRecord.DestinationTableName == "" {
+			return nil, nil
+		}
+		itemsJSON, err := model.ItemsToJSON(typedRecord.TableItems())
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize message record to JSON: %w", err)
+		}
+
+		entries[3] = qvalue.QValueString{Val: itemsJSON}
+		entries[4] = qvalue.QValueInt64{Val: 0}
+		entries[5] = qvalue.QValueString{Val: ""}
+		entries[7] = qvalue.QValueString{Val: ""}
 
 	default:
 		return nil, fmt.Errorf("unknown record type: %T", typedRecord)
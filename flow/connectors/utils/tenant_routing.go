@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+const tenantPlaceholder = "{tenant}"
+
+// TenantRoutedTables returns the tenant routing of destination tables splitting rows by tenant
+func TenantRoutedTables(tableMappings []*protos.TableMapping) map[string]*protos.TenantRouting {
+	var routed map[string]*protos.TenantRouting
+	for _, mapping := range tableMappings {
+		if mapping.TenantRouting.GetTenantColumn() == "" {
+			continue
+		}
+		if routed == nil {
+			routed = make(map[string]*protos.TenantRouting)
+		}
+		routed[mapping.DestinationTableIdentifier] = mapping.TenantRouting
+	}
+	return routed
+}
+
+// TenantDestinationTemplate returns the template of destination tables of tenants for a destination table
+func TenantDestinationTemplate(routing *protos.TenantRouting, destinationTable string) string {
+	if routing.DestinationTemplate != "" {
+		return routing.DestinationTemplate
+	}
+	_, table, hasDot := strings.Cut(destinationTable, ".")
+	if !hasDot {
+		table = destinationTable
+	}
+	return tenantPlaceholder + "." + table
+}
+
+// TenantDestinationTable returns the destination table of a tenant, see SanitizeTenant
+func TenantDestinationTable(routing *protos.TenantRouting, destinationTable string, tenant string) string {
+	return strings.ReplaceAll(TenantDestinationTemplate(routing, destinationTable), tenantPlaceholder,
+		SanitizeTenant(tenant))
+}
+
+// SanitizeTenant makes any tenant id valid in an unquoted identifier, lowercasing it and replacing other characters
+// by underscores, so tenants only differing by those share tables
+func SanitizeTenant(tenant string) string {
+	sanitized := []byte(strings.ToLower(tenant))
+	for i, c := range sanitized {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			sanitized[i] = '_'
+		}
+	}
+	if len(sanitized) == 0 || sanitized[0] >= '0' && sanitized[0] <= '9' {
+		sanitized = append([]byte{'t'}, sanitized...)
+	}
+	// Postgres truncates identifiers past 63 bytes, long tenants keep a hash of what's cut
+	// so they don't share tables with others starting alike
+	if len(sanitized) > 48 {
+		hash := fnv.New32a()
+		hash.Write(sanitized[40:])
+		sanitized = fmt.Appendf(sanitized[:40], "_%08x", hash.Sum32())
+	}
+	return string(sanitized)
+}
+
+// TenantOf returns the tenant of a row from its tenant column, false when the row has no tenant
+func TenantOf(items model.Items, tenantColumn string) (string, bool) {
+	if recordItems, ok := items.(model.RecordItems); ok {
+		val, err := recordItems.GetValueByColName(tenantColumn)
+		if err != nil || val.Value() == nil {
+			return "", false
+		}
+		return fmt.Sprint(val.Value()), true
+	}
+	val, err := items.GetBytesByColName(tenantColumn)
+	if err != nil || val == nil {
+		return "", false
+	}
+	return string(val), true
+}
+
+// TenantRoutedBase returns the destination table whose tenant tables include table, false for none
+func TenantRoutedBase(routed map[string]*protos.TenantRouting, table string) (string, bool) {
+	for _, base := range slices.Sorted(maps.Keys(routed)) {
+		prefix, suffix, _ := strings.Cut(TenantDestinationTemplate(routed[base], base), tenantPlaceholder)
+		if len(table) <= len(prefix)+len(suffix) || !strings.HasPrefix(table, prefix) || !strings.HasSuffix(table, suffix) {
+			continue
+		}
+		if tenant := table[len(prefix) : len(table)-len(suffix)]; SanitizeTenant(tenant) == tenant {
+			return base, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestTenantDestinationTable(t *testing.T) {
+	routing := &protos.TenantRouting{TenantColumn: "tenant_id"}
+	require.Equal(t, "acme_corp.orders", TenantDestinationTable(routing, "public.orders", "Acme-Corp"))
+	require.Equal(t, "t42.orders", TenantDestinationTable(routing, "public.orders", "42"))
+
+	routing.DestinationTemplate = "analytics.orders_{tenant}"
+	require.Equal(t, "analytics.orders_acme", TenantDestinationTable(routing, "public.orders", "acme"))
+
+	long := SanitizeTenant("tenant_with_a_rather_long_identifier_which_goes_past_the_limit_1")
+	require.Len(t, long, 49)
+	require.NotEqual(t, long, SanitizeTenant("tenant_with_a_rather_long_identifier_which_goes_past_the_limit_2"))
+}
+
+func TestTenantRoutedBase(t *testing.T) {
+	routed := TenantRoutedTables([]*protos.TableMapping{
+		{DestinationTableIdentifier: "public.orders", TenantRouting: &protos.TenantRouting{TenantColumn: "tenant_id"}},
+		{DestinationTableIdentifier: "public.users", TenantRouting: &protos.TenantRouting{
+			TenantColumn: "tenant_id", DestinationTemplate: "analytics.users_{tenant}",
+		}},
+		{DestinationTableIdentifier: "public.plans"},
+	})
+	require.Len(t, routed, 2)
+
+	base, ok := TenantRoutedBase(routed, "acme.orders")
+	require.True(t, ok)
+	require.Equal(t, "public.orders", base)
+	base, ok = TenantRoutedBase(routed, "analytics.users_acme")
+	require.True(t, ok)
+	require.Equal(t, "public.users", base)
+	_, ok = TenantRoutedBase(routed, "analytics.users_")
+	require.False(t, ok)
+	_, ok = TenantRoutedBase(routed, "public.plans")
+	require.False(t, ok)
+}
+
+func TestTenantOf(t *testing.T) {
+	items := model.NewRecordItems(2)
+	items.AddColumn("tenant_id", qvalue.QValueInt64{Val: 7})
+	items.AddColumn("region", qvalue.QValueNull(qvalue.QValueKindString))
+	tenant, ok := TenantOf(items, "tenant_id")
+	require.True(t, ok)
+	require.Equal(t, "7", tenant)
+	_, ok = TenantOf(items, "region")
+	require.False(t, ok)
+	_, ok = TenantOf(items, "missing")
+	require.False(t, ok)
+
+	pgItems := model.NewPgItems(1)
+	pgItems.AddColumn("tenant_id", []byte("acme"))
+	tenant, ok = TenantOf(pgItems, "tenant_id")
+	require.True(t, ok)
+	require.Equal(t, "acme", tenant)
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "slices"
+
+// TruncatedTablesToNormalize returns the tables of truncatedTables which were truncated in a batch
+// after normBatchID and up to syncBatchID, so applying the TRUNCATE is part of normalizing those batches.
+// Normalize applies a TRUNCATE before the records of the batch it happened in, as records of earlier
+// batches are gone at source and only later ones are left in the table
+func TruncatedTablesToNormalize(truncatedTables map[string]int64, normBatchID int64, syncBatchID int64) map[string]int64 {
+	var toNormalize map[string]int64
+	for table, batchID := range truncatedTables {
+		if batchID > normBatchID && batchID <= syncBatchID {
+			if toNormalize == nil {
+				toNormalize = make(map[string]int64)
+			}
+			toNormalize[table] = batchID
+		}
+	}
+	return toNormalize
+}
+
+// WithoutSkippedTables removes destination tables left out of normalize from tables
+func WithoutSkippedTables(tables []string, skippedTables map[string]struct{}) []string {
+	return slices.DeleteFunc(tables, func(table string) bool {
+		_, skipped := skippedTables[table]
+		return skipped
+	})
+}
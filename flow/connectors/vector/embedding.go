@@ -0,0 +1,58 @@
+package connvector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// embedder turns text into vectors through an OpenAI compatible embeddings endpoint
+type embedder struct {
+	jsonAPI
+	model string
+}
+
+func newEmbedder(client *http.Client, embeddingURL string, apiKey string, model string) *embedder {
+	api := jsonAPI{client: client, baseURL: strings.TrimSuffix(embeddingURL, "/"), headers: make(http.Header)}
+	if apiKey != "" {
+		api.headers.Set("Authorization", "Bearer "+apiKey)
+	}
+	return &embedder{jsonAPI: api, model: model}
+}
+
+type embeddingData struct {
+	Embedding []float32 `json:"embedding"`
+	Index     int       `json:"index"`
+}
+
+type embeddingResponse struct {
+	Data []embeddingData `json:"data"`
+}
+
+// embed returns a vector for each text in the order of texts
+func (e *embedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body := map[string]any{"input": texts}
+	if e.model != "" {
+		body["model"] = e.model
+	}
+	var resp embeddingResponse
+	if err := e.do(ctx, http.MethodPost, "", body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to embed texts: %w", err)
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding endpoint returned %d vectors for %d texts", len(resp.Data), len(texts))
+	}
+	slices.SortFunc(resp.Data, func(a, b embeddingData) int {
+		return a.Index - b.Index
+	})
+	vectors := make([][]float32, 0, len(resp.Data))
+	for _, data := range resp.Data {
+		vectors = append(vectors, data.Embedding)
+	}
+	return vectors, nil
+}
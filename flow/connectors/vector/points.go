@@ -0,0 +1,183 @@
+package connvector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+// vectorTable is how rows of a destination table become points, identified by their primary key
+type vectorTable struct {
+	collection     string
+	keys           []string
+	textColumns    []string
+	vectorColumn   string
+	payloadColumns []string
+}
+
+func newVectorTable(table string, keys []string, mapping *protos.VectorMapping) vectorTable {
+	collection := mapping.GetCollection()
+	if collection == "" {
+		collection = table
+	}
+	return vectorTable{
+		collection:     collection,
+		keys:           keys,
+		textColumns:    mapping.GetTextColumns(),
+		vectorColumn:   mapping.GetVectorColumn(),
+		payloadColumns: mapping.GetPayloadColumns(),
+	}
+}
+
+// pointID is a UUID derived from the primary key, so changes to a row land on the same point
+func (v vectorTable) pointID(items map[string]qvalue.QValue) (string, error) {
+	key := make([]any, 0, len(v.keys))
+	for _, column := range v.keys {
+		key = append(key, jsonValue(items[column]))
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode primary key: %w", err)
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("peerdb:"+v.collection+":"+string(data))).String(), nil
+}
+
+// unchanged is true when an update left the columns the vector comes from in TOAST, the point keeps its vector then
+func (v vectorTable) unchanged(unchangedToastColumns map[string]struct{}) bool {
+	if v.vectorColumn != "" {
+		_, ok := unchangedToastColumns[v.vectorColumn]
+		return ok
+	}
+	for _, column := range v.textColumns {
+		if _, ok := unchangedToastColumns[column]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// newPoint builds the point of a row, with either its vector or the text to embed.
+// Rows without a vector or text have nothing to search by and their point is deleted
+func (v vectorTable) newPoint(items map[string]qvalue.QValue) (vectorPoint, string, bool, error) {
+	id, err := v.pointID(items)
+	if err != nil {
+		return vectorPoint{}, "", false, err
+	}
+	point := vectorPoint{ID: id, Payload: make(map[string]any)}
+	if len(v.payloadColumns) > 0 {
+		for _, column := range v.payloadColumns {
+			point.Payload[column] = jsonValue(items[column])
+		}
+	} else {
+		for column, qv := range items {
+			if column != v.vectorColumn {
+				point.Payload[column] = jsonValue(qv)
+			}
+		}
+	}
+
+	if v.vectorColumn != "" {
+		vector, err := vectorValue(items[v.vectorColumn])
+		if err != nil {
+			return vectorPoint{}, "", false, fmt.Errorf("invalid vector in column %s: %w", v.vectorColumn, err)
+		}
+		point.Vector = vector
+		return point, "", len(vector) > 0, nil
+	}
+
+	texts := make([]string, 0, len(v.textColumns))
+	for _, column := range v.textColumns {
+		qv := items[column]
+		if qv == nil || qv.Value() == nil {
+			continue
+		}
+		var text string
+		if s, ok := qv.Value().(string); ok {
+			text = s
+		} else {
+			text = fmt.Sprint(jsonValue(qv))
+		}
+		if strings.TrimSpace(text) != "" {
+			texts = append(texts, text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	return point, text, text != "", nil
+}
+
+// vectorValue reads a vector from a float array or from the text form of pgvector, "[1,2,3]"
+func vectorValue(qv qvalue.QValue) ([]float32, error) {
+	if qv == nil {
+		return nil, nil
+	}
+	switch v := qv.Value().(type) {
+	case nil:
+		return nil, nil
+	case []float32:
+		return v, nil
+	case []float64:
+		vector := make([]float32, 0, len(v))
+		for _, f := range v {
+			vector = append(vector, float32(f))
+		}
+		return vector, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		var vector []float32
+		if err := json.Unmarshal([]byte(v), &vector); err != nil {
+			return nil, err
+		}
+		return vector, nil
+	default:
+		return nil, errors.New("column is neither a float array nor text")
+	}
+}
+
+// jsonValue is the payload value of a value, types JSON has no counterpart of are strings
+func jsonValue(qv qvalue.QValue) any {
+	if qv == nil {
+		return nil
+	}
+	switch v := qv.Value().(type) {
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil
+		}
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	case uint8:
+		return string(rune(v))
+	case decimal.Decimal:
+		return v.String()
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case time.Time:
+		switch qv.Kind() {
+		case qvalue.QValueKindDate:
+			return v.Format(time.DateOnly)
+		case qvalue.QValueKindTime, qvalue.QValueKindTimeTZ:
+			return v.Format("15:04:05.999999")
+		case qvalue.QValueKindTimestamp:
+			return v.Format("2006-01-02T15:04:05.999999")
+		default:
+			return v.Format(time.RFC3339Nano)
+		}
+	default:
+		return v
+	}
+}
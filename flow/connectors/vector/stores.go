@@ -0,0 +1,208 @@
+package connvector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+type vectorPoint struct {
+	ID      string
+	Vector  []float32
+	Payload map[string]any
+}
+
+// vectorStore is the API of one vector database, writes of points with the same id replace them
+type vectorStore interface {
+	ping(ctx context.Context) error
+	upsert(ctx context.Context, collection string, points []vectorPoint) error
+	delete(ctx context.Context, collection string, ids []string) error
+}
+
+func newVectorStore(client *http.Client, config *protos.VectorConfig) (vectorStore, error) {
+	api := jsonAPI{client: client, baseURL: strings.TrimSuffix(config.Url, "/"), headers: make(http.Header)}
+	switch config.Flavor {
+	case protos.VectorFlavor_VECTOR_FLAVOR_QDRANT:
+		if config.ApiKey != "" {
+			api.headers.Set("api-key", config.ApiKey)
+		}
+		return qdrantStore{api}, nil
+	case protos.VectorFlavor_VECTOR_FLAVOR_PINECONE:
+		if config.ApiKey == "" {
+			return nil, errors.New("pinecone needs an api key")
+		}
+		api.headers.Set("Api-Key", config.ApiKey)
+		return pineconeStore{api}, nil
+	case protos.VectorFlavor_VECTOR_FLAVOR_WEAVIATE:
+		if config.ApiKey != "" {
+			api.headers.Set("Authorization", "Bearer "+config.ApiKey)
+		}
+		return weaviateStore{api}, nil
+	default:
+		return nil, fmt.Errorf("unsupported vector flavor %s", config.Flavor)
+	}
+}
+
+type jsonAPI struct {
+	client  *http.Client
+	headers http.Header
+	baseURL string
+}
+
+func (a jsonAPI) do(ctx context.Context, method string, path string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = a.headers.Clone()
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", "PeerDB")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s responded with status %d: %s", method, path, resp.StatusCode, respBody[:min(len(respBody), 1024)])
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return nil
+}
+
+type qdrantStore struct {
+	jsonAPI
+}
+
+func (s qdrantStore) ping(ctx context.Context) error {
+	return s.do(ctx, http.MethodGet, "/collections", nil, nil)
+}
+
+func (s qdrantStore) upsert(ctx context.Context, collection string, points []vectorPoint) error {
+	body := make([]map[string]any, 0, len(points))
+	for _, point := range points {
+		body = append(body, map[string]any{"id": point.ID, "vector": point.Vector, "payload": point.Payload})
+	}
+	return s.do(ctx, http.MethodPut, "/collections/"+url.PathEscape(collection)+"/points?wait=true",
+		map[string]any{"points": body}, nil)
+}
+
+func (s qdrantStore) delete(ctx context.Context, collection string, ids []string) error {
+	return s.do(ctx, http.MethodPost, "/collections/"+url.PathEscape(collection)+"/points/delete?wait=true",
+		map[string]any{"points": ids}, nil)
+}
+
+// pineconeStore puts collections in namespaces of the index
+type pineconeStore struct {
+	jsonAPI
+}
+
+func (s pineconeStore) ping(ctx context.Context) error {
+	return s.do(ctx, http.MethodPost, "/describe_index_stats", map[string]any{}, nil)
+}
+
+func (s pineconeStore) upsert(ctx context.Context, collection string, points []vectorPoint) error {
+	body := make([]map[string]any, 0, len(points))
+	for _, point := range points {
+		body = append(body, map[string]any{"id": point.ID, "values": point.Vector, "metadata": pineconeMetadata(point.Payload)})
+	}
+	return s.do(ctx, http.MethodPost, "/vectors/upsert", map[string]any{"vectors": body, "namespace": collection}, nil)
+}
+
+func (s pineconeStore) delete(ctx context.Context, collection string, ids []string) error {
+	return s.do(ctx, http.MethodPost, "/vectors/delete", map[string]any{"ids": ids, "namespace": collection}, nil)
+}
+
+// pineconeMetadata drops nulls, which metadata cannot hold, and turns values besides strings,
+// numbers, booleans and lists of strings into JSON strings
+func pineconeMetadata(payload map[string]any) map[string]any {
+	metadata := make(map[string]any, len(payload))
+	for k, v := range payload {
+		switch v := v.(type) {
+		case nil:
+		case string, bool, int16, int32, int64, float32, float64, []string:
+			metadata[k] = v
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			metadata[k] = string(data)
+		}
+	}
+	return metadata
+}
+
+// weaviateStore puts collections in classes
+type weaviateStore struct {
+	jsonAPI
+}
+
+type weaviateBatchResult struct {
+	Result struct {
+		Errors *struct {
+			Error []struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"errors"`
+	} `json:"result"`
+}
+
+func (s weaviateStore) ping(ctx context.Context) error {
+	return s.do(ctx, http.MethodGet, "/v1/schema", nil, nil)
+}
+
+// upsert goes through the batch endpoint, which replaces objects of existing ids and reports failures per object
+func (s weaviateStore) upsert(ctx context.Context, collection string, points []vectorPoint) error {
+	objects := make([]map[string]any, 0, len(points))
+	for _, point := range points {
+		objects = append(objects, map[string]any{
+			"class": collection, "id": point.ID, "vector": point.Vector, "properties": point.Payload,
+		})
+	}
+	var results []weaviateBatchResult
+	if err := s.do(ctx, http.MethodPost, "/v1/batch/objects", map[string]any{"objects": objects}, &results); err != nil {
+		return err
+	}
+	for i, result := range results {
+		if result.Result.Errors != nil && len(result.Result.Errors.Error) > 0 {
+			return fmt.Errorf("failed to write object %s: %s", points[i].ID, result.Result.Errors.Error[0].Message)
+		}
+	}
+	return nil
+}
+
+func (s weaviateStore) delete(ctx context.Context, collection string, ids []string) error {
+	return s.do(ctx, http.MethodDelete, "/v1/batch/objects", map[string]any{
+		"match": map[string]any{
+			"class": collection,
+			"where": map[string]any{"path": []string{"id"}, "operator": "ContainsAny", "valueTextArray": ids},
+		},
+	}, nil)
+}
@@ -0,0 +1,316 @@
+package connvector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+const (
+	defaultBatchSize = 100
+	requestTimeout   = 2 * time.Minute
+)
+
+type VectorConnector struct {
+	*metadataStore.PostgresMetadata
+	client    *http.Client
+	store     vectorStore
+	embedder  *embedder
+	logger    log.Logger
+	batchSize int
+}
+
+func NewVectorConnector(ctx context.Context, config *protos.VectorConfig) (*VectorConnector, error) {
+	urls := []string{config.Url}
+	if config.EmbeddingUrl != "" {
+		urls = append(urls, config.EmbeddingUrl)
+	}
+	for _, rawURL := range urls {
+		endpoint, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector url: %w", err)
+		}
+		if (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return nil, errors.New("vector urls must be absolute http or https urls")
+		}
+	}
+
+	client := &http.Client{Timeout: requestTimeout}
+	store, err := newVectorStore(client, config)
+	if err != nil {
+		return nil, err
+	}
+	var e *embedder
+	if config.EmbeddingUrl != "" {
+		e = newEmbedder(client, config.EmbeddingUrl, config.EmbeddingApiKey, config.EmbeddingModel)
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := defaultBatchSize
+	if config.BatchSize > 0 {
+		batchSize = int(config.BatchSize)
+	}
+
+	return &VectorConnector{
+		PostgresMetadata: pgMetadata,
+		client:           client,
+		store:            store,
+		embedder:         e,
+		logger:           logger.LoggerFromCtx(ctx),
+		batchSize:        batchSize,
+	}, nil
+}
+
+func (c *VectorConnector) Close() error {
+	if c != nil {
+		c.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (c *VectorConnector) ConnectionActive(ctx context.Context) error {
+	return c.store.ping(ctx)
+}
+
+func (c *VectorConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+// payloads have no schema, new columns show up in points written after them
+func (c *VectorConnector) ReplayTableSchemaDeltas(_ context.Context, flowJobName string, schemaDeltas []*protos.TableSchemaDelta) error {
+	return nil
+}
+
+// vectorRun is consecutive changes of the same kind to the same collection
+type vectorRun struct {
+	collection string
+	delete     bool
+	points     []vectorPoint
+	// texts to embed, or "" for points that come with their vector
+	texts []string
+}
+
+// vectorBatch keeps changes in runs so changes to a point are applied in order
+type vectorBatch struct {
+	runs      []*vectorRun
+	numPoints int
+}
+
+func (b *vectorBatch) add(collection string, isDelete bool, point vectorPoint, text string) {
+	if len(b.runs) == 0 || b.runs[len(b.runs)-1].collection != collection || b.runs[len(b.runs)-1].delete != isDelete {
+		b.runs = append(b.runs, &vectorRun{collection: collection, delete: isDelete})
+	}
+	run := b.runs[len(b.runs)-1]
+	run.points = append(run.points, point)
+	run.texts = append(run.texts, text)
+	b.numPoints += 1
+}
+
+func (b *vectorBatch) reset() {
+	b.runs = nil
+	b.numPoints = 0
+}
+
+// apply embeds texts of the batch in one request, then writes runs one after the other
+func (c *VectorConnector) apply(ctx context.Context, b *vectorBatch) error {
+	var texts []string
+	for _, run := range b.runs {
+		for _, text := range run.texts {
+			if text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	if len(texts) > 0 {
+		if c.embedder == nil {
+			return errors.New("rows have text to embed but the peer has no embedding url")
+		}
+		vectors, err := c.embedder.embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		i := 0
+		for _, run := range b.runs {
+			for j, text := range run.texts {
+				if text != "" {
+					run.points[j].Vector = vectors[i]
+					i += 1
+				}
+			}
+		}
+	}
+
+	for _, run := range b.runs {
+		if run.delete {
+			ids := make([]string, 0, len(run.points))
+			for _, point := range run.points {
+				ids = append(ids, point.ID)
+			}
+			if err := c.store.delete(ctx, run.collection, ids); err != nil {
+				return fmt.Errorf("failed to delete points of %s: %w", run.collection, err)
+			}
+		} else if err := c.store.upsert(ctx, run.collection, run.points); err != nil {
+			return fmt.Errorf("failed to upsert points of %s: %w", run.collection, err)
+		}
+	}
+	return nil
+}
+
+// SyncRecords keeps a point per row. Updates leaving the text or vector columns in TOAST are skipped,
+// as the vector did not change, payloads follow such updates only with REPLICA IDENTITY FULL
+func (c *VectorConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	var numSkipped int64
+
+	tables := make(map[string]vectorTable, len(req.TableMappings))
+	for _, tm := range req.TableMappings {
+		tables[tm.DestinationTableIdentifier] = newVectorTable(tm.DestinationTableIdentifier,
+			req.TableNameSchemaMapping[tm.DestinationTableIdentifier].GetPrimaryKeyColumns(), tm.Vector)
+	}
+
+	var batch vectorBatch
+	batcher := utils.NewRecordBatcher(c.logger, req, c.batchSize, c.SetLastOffset,
+		func(ctx context.Context, _ []model.Record[model.RecordItems]) error {
+			if err := c.apply(ctx, &batch); err != nil {
+				return fmt.Errorf("[vector] failed to apply changes: %w", err)
+			}
+			batch.reset()
+			return nil
+		})
+
+	for record := range req.Records.GetRecords() {
+		table := record.GetDestinationTableName()
+		v, ok := tables[table]
+		if !ok || len(v.keys) == 0 {
+			return nil, fmt.Errorf("[vector] table %s has no primary key to identify points by", table)
+		}
+
+		var items model.RecordItems
+		isDelete := false
+		switch rec := record.(type) {
+		case *model.InsertRecord[model.RecordItems]:
+			items = rec.Items
+		case *model.UpdateRecord[model.RecordItems]:
+			if v.unchanged(rec.UnchangedToastColumns) {
+				numSkipped += 1
+				continue
+			}
+			items = rec.NewItems
+		case *model.DeleteRecord[model.RecordItems]:
+			items = rec.Items
+			isDelete = true
+		default:
+			continue
+		}
+
+		point, text, searchable, err := v.newPoint(items.ColToVal)
+		if err != nil {
+			return nil, fmt.Errorf("[vector] failed to build point of %s: %w", table, err)
+		}
+		if isDelete || !searchable {
+			batch.add(v.collection, true, point, "")
+		} else {
+			batch.add(v.collection, false, point, text)
+		}
+		if err := batcher.Add(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+	if err := batcher.Flush(ctx); err != nil {
+		return nil, err
+	}
+	if numSkipped > 0 {
+		c.logger.Info("[vector] skipped updates leaving vector sources in TOAST", slog.Int64("updates", numSkipped))
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[vector] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:     req.SyncBatchID,
+		LastSyncedCheckpointID: lastCheckpoint,
+		NumRecordsSynced:       batcher.NumRecords(),
+		TableNameRowsMapping:   batcher.TableNameRowsMapping(),
+		TableSchemaDeltas:      req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *VectorConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+// SyncQRepRecords upserts points identified by the upsert key columns, so a retried partition writes the same points
+func (c *VectorConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int, error) {
+	startTime := time.Now()
+	c.logger.Info("[vector] syncing partition",
+		slog.String(string(shared.PartitionIDKey), partition.PartitionId),
+		slog.String("destinationTable", config.DestinationTableIdentifier))
+
+	v := newVectorTable(config.DestinationTableIdentifier, config.WriteMode.GetUpsertKeyColumns(), config.Vector)
+	if len(v.keys) == 0 {
+		return 0, fmt.Errorf("[vector] table %s has no primary key to identify points by", config.DestinationTableIdentifier)
+	}
+	columns := stream.Schema().GetColumnNames()
+
+	numRecords := 0
+	var batch vectorBatch
+	for record := range stream.Records {
+		items := make(map[string]qvalue.QValue, len(columns))
+		for i, column := range columns {
+			items[column] = record[i]
+		}
+		point, text, searchable, err := v.newPoint(items)
+		if err != nil {
+			return 0, fmt.Errorf("[vector] failed to build point of %s: %w", config.DestinationTableIdentifier, err)
+		}
+		numRecords += 1
+		// a snapshot writes fresh points, rows with nothing to search by have none to delete
+		if !searchable {
+			continue
+		}
+		batch.add(v.collection, false, point, text)
+		if batch.numPoints >= c.batchSize {
+			if err := c.apply(ctx, &batch); err != nil {
+				return 0, fmt.Errorf("[vector] failed to upsert points of %s: %w", config.DestinationTableIdentifier, err)
+			}
+			batch.reset()
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, err
+	}
+	if batch.numPoints > 0 {
+		if err := c.apply(ctx, &batch); err != nil {
+			return 0, fmt.Errorf("[vector] failed to upsert points of %s: %w", config.DestinationTableIdentifier, err)
+		}
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
@@ -0,0 +1,134 @@
+package connvector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestVectorTablePoints(t *testing.T) {
+	v := newVectorTable("public.docs", []string{"id"}, &protos.VectorMapping{TextColumns: []string{"title", "body"}})
+	require.Equal(t, "public.docs", v.collection)
+
+	point, text, searchable, err := v.newPoint(map[string]qvalue.QValue{
+		"id":    qvalue.QValueInt64{Val: 7},
+		"title": qvalue.QValueString{Val: "Hello"},
+		"body":  qvalue.QValueString{Val: "world"},
+		"price": qvalue.QValueNumeric{Val: decimal.RequireFromString("1.50")},
+	})
+	require.NoError(t, err)
+	require.True(t, searchable)
+	require.Equal(t, "Hello\nworld", text)
+	require.Equal(t, map[string]any{"id": int64(7), "title": "Hello", "body": "world", "price": "1.5"}, point.Payload)
+
+	// the id depends only on the key, so a point is replaced by changes to its row
+	again, _, _, err := v.newPoint(map[string]qvalue.QValue{"id": qvalue.QValueInt64{Val: 7}})
+	require.NoError(t, err)
+	require.Equal(t, point.ID, again.ID)
+	other, text, searchable, err := v.newPoint(map[string]qvalue.QValue{
+		"id":    qvalue.QValueInt64{Val: 8},
+		"title": qvalue.QValueNull(qvalue.QValueKindString),
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, point.ID, other.ID)
+	require.False(t, searchable)
+	require.Empty(t, text)
+
+	require.True(t, v.unchanged(map[string]struct{}{"body": {}}))
+	require.False(t, v.unchanged(map[string]struct{}{"price": {}}))
+}
+
+func TestVectorColumn(t *testing.T) {
+	v := newVectorTable("items", []string{"id"}, &protos.VectorMapping{
+		Collection:     "products",
+		VectorColumn:   "embedding",
+		PayloadColumns: []string{"name"},
+	})
+	point, text, searchable, err := v.newPoint(map[string]qvalue.QValue{
+		"id":        qvalue.QValueInt32{Val: 1},
+		"name":      qvalue.QValueString{Val: "lamp"},
+		"embedding": qvalue.QValueString{Val: "[0.5,1,-2]"},
+	})
+	require.NoError(t, err)
+	require.True(t, searchable)
+	require.Empty(t, text)
+	require.Equal(t, []float32{0.5, 1, -2}, point.Vector)
+	require.Equal(t, map[string]any{"name": "lamp"}, point.Payload)
+
+	_, _, _, err = v.newPoint(map[string]qvalue.QValue{
+		"id":        qvalue.QValueInt32{Val: 1},
+		"embedding": qvalue.QValueString{Val: "not a vector"},
+	})
+	require.Error(t, err)
+}
+
+func TestPineconeMetadata(t *testing.T) {
+	require.Equal(t, map[string]any{
+		"name":  "lamp",
+		"count": int64(3),
+		"tags":  `["a","b"]`,
+	}, pineconeMetadata(map[string]any{
+		"name":    "lamp",
+		"count":   int64(3),
+		"tags":    []any{"a", "b"},
+		"deleted": nil,
+	}))
+}
+
+func TestApplyEmbedsAndUpserts(t *testing.T) {
+	var upserted map[string][]map[string]any
+	var deleted map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/embeddings":
+			require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			var body struct {
+				Input []string `json:"input"`
+				Model string   `json:"model"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, "small", body.Model)
+			require.Equal(t, []string{"first", "second"}, body.Input)
+			// answers out of order, vectors are matched by index
+			_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[2]},{"index":0,"embedding":[1]}]}`))
+		case "/collections/docs/points":
+			require.Equal(t, http.MethodPut, r.Method)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&upserted))
+		case "/collections/docs/points/delete":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&deleted))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := &protos.VectorConfig{Flavor: protos.VectorFlavor_VECTOR_FLAVOR_QDRANT, Url: server.URL}
+	store, err := newVectorStore(server.Client(), config)
+	require.NoError(t, err)
+	c := &VectorConnector{
+		client:   server.Client(),
+		store:    store,
+		embedder: newEmbedder(server.Client(), server.URL+"/embeddings", "secret", "small"),
+	}
+
+	var batch vectorBatch
+	batch.add("docs", false, vectorPoint{ID: "a"}, "first")
+	batch.add("docs", false, vectorPoint{ID: "b"}, "second")
+	batch.add("docs", true, vectorPoint{ID: "c"}, "")
+	require.Len(t, batch.runs, 2)
+	require.NoError(t, c.apply(context.Background(), &batch))
+
+	require.Len(t, upserted["points"], 2)
+	require.Equal(t, "a", upserted["points"][0]["id"])
+	require.Equal(t, []any{1.0}, upserted["points"][0]["vector"])
+	require.Equal(t, []any{2.0}, upserted["points"][1]["vector"])
+	require.Equal(t, []string{"c"}, deleted["points"])
+}
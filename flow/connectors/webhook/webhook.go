@@ -0,0 +1,402 @@
+package connwebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peer-flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+const (
+	SignatureHeader = "X-PeerDB-Signature"
+
+	defaultBatchSize   = 500
+	defaultMaxAttempts = 5
+	defaultTimeout     = 30 * time.Second
+	maxBackoff         = time.Minute
+)
+
+type WebhookConnector struct {
+	*metadataStore.PostgresMetadata
+	client        *http.Client
+	logger        log.Logger
+	url           string
+	signingSecret []byte
+	authorization string
+	batchSize     int
+	maxAttempts   int
+}
+
+func NewWebhookConnector(ctx context.Context, config *protos.WebhookConfig) (*WebhookConnector, error) {
+	endpoint, err := url.Parse(config.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, errors.New("webhook url must be an absolute https url")
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	batchSize := defaultBatchSize
+	if config.BatchSize > 0 {
+		batchSize = int(config.BatchSize)
+	}
+	maxAttempts := defaultMaxAttempts
+	if config.MaxAttempts > 0 {
+		maxAttempts = int(config.MaxAttempts)
+	}
+
+	return &WebhookConnector{
+		PostgresMetadata: pgMetadata,
+		client:           &http.Client{Timeout: timeout},
+		logger:           logger.LoggerFromCtx(ctx),
+		url:              config.Url,
+		signingSecret:    []byte(config.SigningSecret),
+		authorization:    config.Authorization,
+		batchSize:        batchSize,
+		maxAttempts:      maxAttempts,
+	}, nil
+}
+
+func (c *WebhookConnector) Close() error {
+	if c != nil {
+		c.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// there is no side effect free request every endpoint accepts, so only check the host resolves
+func (c *WebhookConnector) ConnectionActive(ctx context.Context) error {
+	endpoint, err := url.Parse(c.url)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if _, err := net.DefaultResolver.LookupHost(ctx, endpoint.Hostname()); err != nil {
+		return fmt.Errorf("failed to resolve webhook host: %w", err)
+	}
+	return nil
+}
+
+func (c *WebhookConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *WebhookConnector) ReplayTableSchemaDeltas(_ context.Context, flowJobName string, schemaDeltas []*protos.TableSchemaDelta) error {
+	return nil
+}
+
+// webhookEvent has the keys of the default JSON encoding of queue messages
+type webhookEvent struct {
+	Kind             string             `json:"kind"`
+	Checkpoint       int64              `json:"checkpoint"`
+	CommitTime       time.Time          `json:"commit_time"`
+	Source           string             `json:"source"`
+	Table            string             `json:"table"`
+	Old              *model.RecordItems `json:"old,omitempty"`
+	New              *model.RecordItems `json:"new,omitempty"`
+	UnchangedColumns []string           `json:"unchanged_columns,omitempty"`
+	Prefix           string             `json:"prefix,omitempty"`
+	Content          string             `json:"content,omitempty"`
+}
+
+func encodeEvent(record model.Record[model.RecordItems]) ([]byte, bool, error) {
+	event := webhookEvent{
+		Kind:       record.Kind(),
+		Checkpoint: record.GetCheckpointID(),
+		CommitTime: record.GetCommitTime(),
+		Source:     record.GetSourceTableName(),
+		Table:      record.GetDestinationTableName(),
+	}
+	switch rec := record.(type) {
+	case *model.InsertRecord[model.RecordItems]:
+		event.New = &rec.Items
+	case *model.UpdateRecord[model.RecordItems]:
+		event.Old = &rec.OldItems
+		event.New = &rec.NewItems
+		for col := range rec.UnchangedToastColumns {
+			event.UnchangedColumns = append(event.UnchangedColumns, col)
+		}
+	case *model.DeleteRecord[model.RecordItems]:
+		event.Old = &rec.Items
+	case *model.MessageRecord[model.RecordItems]:
+		// messages without a destination have nowhere to go
+		if rec.DestinationTableName == "" {
+			return nil, false, nil
+		}
+		event.Prefix = rec.Prefix
+		event.Content = rec.Content
+	default:
+		return nil, false, nil
+	}
+	data, err := json.Marshal(event)
+	return data, true, err
+}
+
+type webhookRequest struct {
+	headers http.Header
+	body    []byte
+}
+
+// newRequest builds the request for a batch of encoded events, CloudEvents use the batched content mode of the
+// HTTP binding so a batch is one request, except binary mode which carries a single event in ce- headers
+func newRequest(
+	flowJobName string,
+	records []model.Record[model.RecordItems],
+	events [][]byte,
+	schemas map[string]*protos.TableSchema,
+	mode protos.CloudEventsMode,
+) (webhookRequest, error) {
+	headers := make(http.Header)
+	switch mode {
+	case protos.CloudEventsMode_CLOUD_EVENTS_MODE_BINARY:
+		record := records[0]
+		event := utils.NewCloudEvent(flowJobName, record,
+			schemas[record.GetDestinationTableName()].GetPrimaryKeyColumns(), 0, "application/json")
+		for k, v := range event.Attributes() {
+			headers.Set("ce-"+k, v)
+		}
+		headers.Set("Content-Type", event.ContentType(mode))
+		return webhookRequest{headers: headers, body: events[0]}, nil
+	case protos.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED:
+		structured := make([]json.RawMessage, 0, len(events))
+		for i, record := range records {
+			event := utils.NewCloudEvent(flowJobName, record,
+				schemas[record.GetDestinationTableName()].GetPrimaryKeyColumns(), 0, "application/json")
+			envelope, err := event.Structured(events[i])
+			if err != nil {
+				return webhookRequest{}, fmt.Errorf("failed to create CloudEvents envelope: %w", err)
+			}
+			structured = append(structured, envelope)
+		}
+		body, err := json.Marshal(structured)
+		if err != nil {
+			return webhookRequest{}, err
+		}
+		headers.Set("Content-Type", "application/cloudevents-batch+json")
+		return webhookRequest{headers: headers, body: body}, nil
+	default:
+		body := make([]byte, 0, 2+len(events)*256)
+		body = append(body, '[')
+		for i, event := range events {
+			if i > 0 {
+				body = append(body, ',')
+			}
+			body = append(body, event...)
+		}
+		body = append(body, ']')
+		headers.Set("Content-Type", "application/json")
+		return webhookRequest{headers: headers, body: body}, nil
+	}
+}
+
+// Signature is the value of SignatureHeader, an HMAC-SHA256 of the timestamp and body
+// so receivers can reject both forged and replayed requests
+func Signature(secret []byte, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type statusError struct {
+	status     int
+	retryAfter time.Duration
+	body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("webhook responded with status %d: %s", e.status, e.body)
+}
+
+// timeouts and server errors are transient, other client errors will not go away by resending
+func (e *statusError) retryable() bool {
+	return e.status == http.StatusTooManyRequests || e.status == http.StatusRequestTimeout || e.status >= 500
+}
+
+// parseRetryAfter reads delay seconds or an http date, zero when missing or not understood
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+func backoff(attempt int) time.Duration {
+	return min(time.Second<<min(attempt-1, 6), maxBackoff)
+}
+
+func (c *WebhookConnector) send(ctx context.Context, request webhookRequest) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(request.body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	httpReq.Header = request.headers.Clone()
+	httpReq.Header.Set("User-Agent", "PeerDB")
+	if c.authorization != "" {
+		httpReq.Header.Set("Authorization", c.authorization)
+	}
+	if len(c.signingSecret) > 0 {
+		httpReq.Header.Set(SignatureHeader, Signature(c.signingSecret, time.Now(), request.body))
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return &statusError{
+		status:     resp.StatusCode,
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		body:       string(respBody),
+	}
+}
+
+// post sends a request until it is accepted, backing off exponentially or as long as asked with Retry-After
+func (c *WebhookConnector) post(ctx context.Context, request webhookRequest) error {
+	for attempt := 1; ; attempt++ {
+		err := c.send(ctx, request)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("[webhook] request canceled: %w", context.Cause(ctx))
+		}
+
+		delay := backoff(attempt)
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			if !statusErr.retryable() {
+				return fmt.Errorf("[webhook] request rejected: %w", err)
+			}
+			if statusErr.retryAfter > 0 {
+				delay = statusErr.retryAfter
+			}
+		}
+		if attempt >= c.maxAttempts {
+			return fmt.Errorf("[webhook] request failed after %d attempts: %w", attempt, err)
+		}
+
+		c.logger.Warn("[webhook] request failed, retrying",
+			slog.Any("error", err), slog.Int("attempt", attempt), slog.Duration("delay", delay))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("[webhook] request canceled: %w", context.Cause(ctx))
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *WebhookConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	batchSize := c.batchSize
+	if req.CloudEventsMode == protos.CloudEventsMode_CLOUD_EVENTS_MODE_BINARY {
+		batchSize = 1
+	}
+	events := make([][]byte, 0, batchSize)
+	batcher := utils.NewRecordBatcher(c.logger, req, batchSize, c.SetLastOffset,
+		func(ctx context.Context, records []model.Record[model.RecordItems]) error {
+			request, err := newRequest(req.FlowJobName, records, events, req.TableNameSchemaMapping, req.CloudEventsMode)
+			if err != nil {
+				return err
+			}
+			if err := c.post(ctx, request); err != nil {
+				return err
+			}
+			events = events[:0]
+			return nil
+		})
+
+	flushLoopDone := make(chan struct{})
+	defer close(flushLoopDone)
+	go func() {
+		flushTimeout, err := peerdbenv.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
+		if err != nil {
+			c.logger.Warn("[webhook] failed to get flush timeout, no periodic flushing", slog.Any("error", err))
+			return
+		}
+		ticker := time.NewTicker(flushTimeout)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-flushLoopDone:
+				return
+			case <-ticker.C:
+				batcher.SaveLastSeenOffset(ctx)
+			}
+		}
+	}()
+
+	for record := range req.Records.GetRecords() {
+		event, ok, err := encodeEvent(record)
+		if err != nil {
+			return nil, fmt.Errorf("[webhook] failed to encode record: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		events = append(events, event)
+		if err := batcher.Add(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+	if err := batcher.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[webhook] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:     req.SyncBatchID,
+		LastSyncedCheckpointID: lastCheckpoint,
+		NumRecordsSynced:       batcher.NumRecords(),
+		TableNameRowsMapping:   batcher.TableNameRowsMapping(),
+		TableSchemaDeltas:      req.Records.SchemaDeltas,
+	}, nil
+}
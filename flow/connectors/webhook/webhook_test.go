@@ -0,0 +1,107 @@
+package connwebhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func testConnector(t *testing.T, handler http.HandlerFunc) *WebhookConnector {
+	t.Helper()
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+	return &WebhookConnector{
+		client:        server.Client(),
+		logger:        logger.LoggerFromCtx(context.Background()),
+		url:           server.URL,
+		signingSecret: []byte("secret"),
+		batchSize:     defaultBatchSize,
+		maxAttempts:   3,
+	}
+}
+
+func TestWebhookPostRetries(t *testing.T) {
+	var calls atomic.Int32
+	c := testConnector(t, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, `[{"id":1}]`, string(body))
+		require.NotEmpty(t, r.Header.Get(SignatureHeader))
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	require.NoError(t, c.post(context.Background(), webhookRequest{headers: http.Header{}, body: []byte(`[{"id":1}]`)}))
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestWebhookPostRejected(t *testing.T) {
+	var calls atomic.Int32
+	c := testConnector(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	err := c.post(context.Background(), webhookRequest{headers: http.Header{}, body: []byte(`[]`)})
+	require.ErrorContains(t, err, "status 400")
+	// client errors are not retried
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestSignature(t *testing.T) {
+	require.Equal(t,
+		"t=1700000000,v1=74f76d8933679a54d6be8c7560a5233b124658241ca5a3b0f09af80d3ea60d78",
+		Signature([]byte("secret"), time.Unix(1700000000, 0), []byte(`[]`)))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, 5*time.Second, parseRetryAfter("5", now))
+	require.Equal(t, time.Minute, parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestNewRequestCloudEvents(t *testing.T) {
+	items := model.NewRecordItems(1)
+	items.AddColumn("id", qvalue.QValueInt64{Val: 1})
+	records := []model.Record[model.RecordItems]{&model.InsertRecord[model.RecordItems]{
+		Items:                items,
+		SourceTableName:      "public.users",
+		DestinationTableName: "users",
+	}}
+	event, ok, err := encodeEvent(records[0])
+	require.NoError(t, err)
+	require.True(t, ok)
+	events := [][]byte{event}
+
+	request, err := newRequest("mirror", records, events, nil, protos.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED)
+	require.NoError(t, err)
+	require.Equal(t, "application/cloudevents-batch+json", request.headers.Get("Content-Type"))
+	var envelopes []map[string]any
+	require.NoError(t, json.Unmarshal(request.body, &envelopes))
+	require.Len(t, envelopes, 1)
+	require.Equal(t, "io.peerdb.cdc.insert", envelopes[0]["type"])
+	require.InDelta(t, 1, envelopes[0]["data"].(map[string]any)["new"].(map[string]any)["id"], 0)
+
+	request, err = newRequest("mirror", records, events, nil, protos.CloudEventsMode_CLOUD_EVENTS_MODE_BINARY)
+	require.NoError(t, err)
+	require.Equal(t, "/peerdb/mirrors/mirror", request.headers.Get("ce-source"))
+	require.Equal(t, "application/json", request.headers.Get("Content-Type"))
+	require.Equal(t, event, request.body)
+}
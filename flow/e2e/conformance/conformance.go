@@ -0,0 +1,36 @@
+// Package conformance is the suite of interface level tests every destination connector has to pass,
+// covering CDC sync, schema changes, query replication and mapping of source types.
+//
+// A connector plugs in by implementing e2e.GenericSuite for its destination and running
+//
+//	func TestConformanceXX(t *testing.T) {
+//		conformance.Run(t, e2e_xx.SetupSuite)
+//	}
+//
+// tests of capabilities a connector lacks, like reading back table schemas, are skipped.
+package conformance
+
+import (
+	"testing"
+
+	"github.com/PeerDB-io/peer-flow/e2e"
+	"github.com/PeerDB-io/peer-flow/e2eshared"
+)
+
+type Suite struct {
+	e2e.GenericSuite
+}
+
+// Setup wraps setup of a connector's suite into setup of the conformance suite
+func Setup[T e2e.GenericSuite](f func(t *testing.T) T) func(t *testing.T) Suite {
+	return func(t *testing.T) Suite {
+		t.Helper()
+		return Suite{f(t)}
+	}
+}
+
+// Run runs every conformance test against the destination of the suites f sets up
+func Run[T e2e.GenericSuite](t *testing.T, f func(t *testing.T) T) {
+	t.Helper()
+	e2eshared.RunSuite(t, Setup(f))
+}
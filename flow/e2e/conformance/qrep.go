@@ -0,0 +1,49 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/e2e"
+)
+
+func (s Suite) Test_QRep_Append() {
+	t := s.T()
+	srcTable := "test_qrep_append"
+	dstTable := "test_qrep_append_dst"
+	srcSchemaTable := e2e.AttachSchema(s, srcTable)
+
+	_, err := s.Connector().Conn().Exec(context.Background(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id INT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT now()
+		);
+		INSERT INTO %s(id, value) SELECT i, 'value_' || i FROM generate_series(1, 100) i;
+	`, srcSchemaTable, srcSchemaTable))
+	require.NoError(t, err)
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE updated_at BETWEEN {{.start}} AND {{.end}}", srcSchemaTable)
+	qrepConfig := e2e.CreateQRepWorkflowConfig(
+		t,
+		e2e.AddSuffix(s, srcTable),
+		srcSchemaTable,
+		s.DestinationTable(dstTable),
+		query,
+		s.Peer().Name,
+		"",
+		true,
+		"",
+		"",
+	)
+
+	tc := e2e.NewTemporalClient(t)
+	env := e2e.RunQRepFlowWorkflow(tc, qrepConfig)
+	e2e.EnvWaitForFinished(t, env, 3*time.Minute)
+	require.NoError(t, env.Error())
+
+	e2e.EnvEqualTablesWithNames(env, s, srcTable, dstTable, "id,value")
+}
@@ -0,0 +1,80 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/e2e"
+	peerflow "github.com/PeerDB-io/peer-flow/workflows"
+)
+
+// typeMatrix is the source types every destination has to map, with a value to replicate of each
+var typeMatrix = []struct {
+	column  string
+	pgType  string
+	literal string
+}{
+	{"c_smallint", "SMALLINT", "32767"},
+	{"c_int", "INT", "-2147483648"},
+	{"c_bigint", "BIGINT", "9223372036854775807"},
+	{"c_float8", "DOUBLE PRECISION", "3.14159265358979"},
+	{"c_numeric", "NUMERIC(20,4)", "1234567890123456.7891"},
+	{"c_bool", "BOOLEAN", "true"},
+	{"c_text", "TEXT", "'text with ''quotes'' and unicode ✓'"},
+	{"c_varchar", "VARCHAR(32)", "'varchar'"},
+	{"c_date", "DATE", "'2024-02-29'"},
+	{"c_timestamp", "TIMESTAMP", "'2024-01-01 12:34:56.789'"},
+	{"c_timestamptz", "TIMESTAMPTZ", "'2024-01-01 12:34:56.789+02'"},
+}
+
+func (s Suite) Test_Type_Matrix() {
+	t := s.T()
+	srcTable := "test_type_matrix"
+	dstTable := "test_type_matrix_dst"
+	srcSchemaTable := e2e.AttachSchema(s, srcTable)
+
+	columns := make([]string, 0, len(typeMatrix))
+	definitions := make([]string, 0, len(typeMatrix))
+	literals := make([]string, 0, len(typeMatrix))
+	for _, typ := range typeMatrix {
+		columns = append(columns, typ.column)
+		definitions = append(definitions, typ.column+" "+typ.pgType)
+		literals = append(literals, typ.literal)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s(%s) VALUES (%s), (%s)", srcSchemaTable,
+		strings.Join(columns, ","), strings.Join(literals, ","), strings.Repeat("NULL,", len(literals)-1)+"NULL")
+
+	_, err := s.Connector().Conn().Exec(context.Background(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (id SERIAL PRIMARY KEY, %s);
+	`, srcSchemaTable, strings.Join(definitions, ",")))
+	require.NoError(t, err)
+	// rows before the mirror starts go through the initial snapshot, rows after through CDC
+	_, err = s.Connector().Conn().Exec(context.Background(), insert)
+	require.NoError(t, err)
+
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:   e2e.AddSuffix(s, srcTable),
+		TableMappings: e2e.TableMappings(s, srcTable, dstTable),
+		Destination:   s.Peer().Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(t)
+	flowConnConfig.DoInitialSnapshot = true
+
+	tc := e2e.NewTemporalClient(t)
+	env := e2e.ExecutePeerflow(tc, peerflow.CDCFlowWorkflow, flowConnConfig, nil)
+	e2e.SetupCDCFlowStatusQuery(t, env, flowConnConfig)
+
+	cols := "id," + strings.Join(columns, ",")
+	e2e.EnvWaitForEqualTablesWithNames(env, s, "initial snapshot of type matrix", srcTable, dstTable, cols)
+
+	_, err = s.Connector().Conn().Exec(context.Background(), insert)
+	e2e.EnvNoError(t, env, err)
+	t.Log("Inserted type matrix rows into the source table")
+
+	e2e.EnvWaitForEqualTablesWithNames(env, s, "normalizing type matrix", srcTable, dstTable, cols)
+	env.Cancel()
+	e2e.RequireEnvCanceled(t, env)
+}
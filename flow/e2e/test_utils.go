@@ -24,6 +24,7 @@ import (
 	"github.com/PeerDB-io/peer-flow/connectors"
 	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
 	connsnowflake "github.com/PeerDB-io/peer-flow/connectors/snowflake"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/faults"
 	"github.com/PeerDB-io/peer-flow/e2eshared"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/logger"
@@ -433,6 +434,19 @@ func CreateQRepWorkflowConfig(
 	}
 }
 
+// InjectFaults makes the worker apply faults to connector calls of a mirror with env,
+// which needs the worker to run with PEERDB_FAULT_INJECTION_ENABLED=true
+func InjectFaults(t *testing.T, env map[string]string, injected ...faults.Fault) map[string]string {
+	t.Helper()
+	encoded, err := json.Marshal(injected)
+	require.NoError(t, err)
+	if env == nil {
+		env = make(map[string]string)
+	}
+	env[faults.EnvKey] = string(encoded)
+	return env
+}
+
 func RunQRepFlowWorkflow(tc client.Client, config *protos.QRepConfig) WorkflowRun {
 	return ExecutePeerflow(tc, peerflow.QRepFlowWorkflow, config, nil)
 }
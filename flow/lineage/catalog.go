@@ -0,0 +1,190 @@
+package lineage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+const (
+	CatalogMirrorCreated = "mirror_created"
+	CatalogSchemaChanged = "schema_changed"
+)
+
+type TableRef struct {
+	Platform  string `json:"platform"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// TableMetadata is what gets registered with a data catalog for a destination table
+type TableMetadata struct {
+	Mirror      string        `json:"mirror"`
+	Source      TableRef      `json:"source"`
+	Destination TableRef      `json:"destination"`
+	Columns     []SchemaField `json:"columns,omitempty"`
+	// destination column to the source column it is replicated from
+	ColumnSources map[string]string `json:"column_sources,omitempty"`
+	// nil until the mirror synced, e.g. when it is created
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+}
+
+type catalogEvent struct {
+	Event  string          `json:"event"`
+	Tables []TableMetadata `json:"tables"`
+}
+
+// CatalogPushEnabled reports whether a catalog to register tables with is configured
+func CatalogPushEnabled() bool {
+	return peerdbenv.PeerDBCatalogPushURL() != ""
+}
+
+func platform(peer *protos.Peer) string {
+	return strings.ToLower(peer.Type.String())
+}
+
+// MirrorTables describes destination tables of tableMappings, schemas are keyed by source table,
+// columns are left out for tables without a schema
+func MirrorTables(
+	flowName string,
+	tableMappings []*protos.TableMapping,
+	schemas map[string]*protos.TableSchema,
+	source *protos.Peer,
+	destination *protos.Peer,
+	lastSyncedAt time.Time,
+) []TableMetadata {
+	tables := make([]TableMetadata, 0, len(tableMappings))
+	for _, tableMapping := range tableMappings {
+		table := TableMetadata{
+			Mirror: flowName,
+			Source: TableRef{
+				Platform:  platform(source),
+				Namespace: DatasetNamespace(source),
+				Name:      DatasetName(source, tableMapping.SourceTableIdentifier),
+			},
+			Destination: TableRef{
+				Platform:  platform(destination),
+				Namespace: DatasetNamespace(destination),
+				Name:      DatasetName(destination, tableMapping.DestinationTableIdentifier),
+			},
+		}
+		if !lastSyncedAt.IsZero() {
+			table.LastSyncedAt = &lastSyncedAt
+		}
+		if tableSchema, ok := schemas[tableMapping.SourceTableIdentifier]; ok {
+			_, table.Columns, table.ColumnSources = mappedFields(tableMapping, tableSchema)
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// PushTables registers tables with the configured catalog, does nothing when there is none
+func PushTables(ctx context.Context, event string, tables []TableMetadata) error {
+	pushURL := peerdbenv.PeerDBCatalogPushURL()
+	if pushURL == "" || len(tables) == 0 {
+		return nil
+	}
+	token := peerdbenv.PeerDBCatalogPushToken()
+	switch pushType := peerdbenv.PeerDBCatalogPushType(); pushType {
+	case "webhook":
+		return postJSON(ctx, pushURL, token, catalogEvent{Event: event, Tables: tables})
+	case "datahub":
+		ingestURL := strings.TrimSuffix(pushURL, "/") + "/aspects?action=ingestProposal"
+		for _, table := range tables {
+			proposals, err := dataHubProposals(table)
+			if err != nil {
+				return err
+			}
+			for _, proposal := range proposals {
+				if err := postJSON(ctx, ingestURL, token, proposal); err != nil {
+					return fmt.Errorf("failed to push %s to DataHub: %w", table.Destination.Name, err)
+				}
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown catalog push type %s", pushType)
+	}
+}
+
+func dataHubDatasetURN(ref TableRef) string {
+	return fmt.Sprintf("urn:li:dataset:(urn:li:dataPlatform:%s,%s,PROD)", ref.Platform, ref.Name)
+}
+
+func dataHubFieldURN(datasetURN string, field string) string {
+	return fmt.Sprintf("urn:li:schemaField:(%s,%s)", datasetURN, field)
+}
+
+type dataHubProposal struct {
+	Proposal dataHubChange `json:"proposal"`
+}
+
+type dataHubChange struct {
+	EntityType string        `json:"entityType"`
+	EntityURN  string        `json:"entityUrn"`
+	ChangeType string        `json:"changeType"`
+	AspectName string        `json:"aspectName"`
+	Aspect     dataHubAspect `json:"aspect"`
+}
+
+type dataHubAspect struct {
+	Value       string `json:"value"`
+	ContentType string `json:"contentType"`
+}
+
+func newDataHubProposal(urn string, aspectName string, aspect any) (dataHubProposal, error) {
+	value, err := json.Marshal(aspect)
+	if err != nil {
+		return dataHubProposal{}, fmt.Errorf("failed to marshal DataHub aspect %s: %w", aspectName, err)
+	}
+	return dataHubProposal{Proposal: dataHubChange{
+		EntityType: "dataset",
+		EntityURN:  urn,
+		ChangeType: "UPSERT",
+		AspectName: aspectName,
+		Aspect:     dataHubAspect{Value: string(value), ContentType: "application/json"},
+	}}, nil
+}
+
+// dataHubProposals upserts the upstream lineage and properties of the destination table,
+// properties replace ones set in DataHub directly as aspects are upserted whole
+func dataHubProposals(table TableMetadata) ([]dataHubProposal, error) {
+	srcURN := dataHubDatasetURN(table.Source)
+	dstURN := dataHubDatasetURN(table.Destination)
+
+	fineGrained := make([]map[string]any, 0, len(table.Columns))
+	for _, col := range table.Columns {
+		fineGrained = append(fineGrained, map[string]any{
+			"upstreamType":   "FIELD_SET",
+			"upstreams":      []string{dataHubFieldURN(srcURN, table.ColumnSources[col.Name])},
+			"downstreamType": "FIELD",
+			"downstreams":    []string{dataHubFieldURN(dstURN, col.Name)},
+		})
+	}
+	lineage, err := newDataHubProposal(dstURN, "upstreamLineage", map[string]any{
+		"upstreams":           []map[string]any{{"dataset": srcURN, "type": "COPY"}},
+		"fineGrainedLineages": fineGrained,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	properties := map[string]any{"customProperties": map[string]string{
+		"peerdb.mirror": table.Mirror,
+		"peerdb.source": table.Source.Name,
+	}}
+	if table.LastSyncedAt != nil {
+		properties["lastModified"] = map[string]any{"time": table.LastSyncedAt.UnixMilli()}
+	}
+	datasetProperties, err := newDataHubProposal(dstURN, "datasetProperties", properties)
+	if err != nil {
+		return nil, err
+	}
+	return []dataHubProposal{lineage, datasetProperties}, nil
+}
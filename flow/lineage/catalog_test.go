@@ -0,0 +1,48 @@
+package lineage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestDataHubProposals(t *testing.T) {
+	source := &protos.Peer{Name: "pg", Type: protos.DBType_POSTGRES, Config: &protos.Peer_PostgresConfig{
+		PostgresConfig: &protos.PostgresConfig{Host: "db", Port: 5432, Database: "app"},
+	}}
+	destination := &protos.Peer{Name: "sf", Type: protos.DBType_SNOWFLAKE, Config: &protos.Peer_SnowflakeConfig{
+		SnowflakeConfig: &protos.SnowflakeConfig{AccountId: "ACME", Database: "ANALYTICS"},
+	}}
+	tables := MirrorTables("m", []*protos.TableMapping{{
+		SourceTableIdentifier:      "public.users",
+		DestinationTableIdentifier: "public.users",
+		Columns:                    []*protos.ColumnSetting{{SourceName: "id", DestinationName: "user_id"}},
+	}}, map[string]*protos.TableSchema{"public.users": {Columns: []*protos.FieldDescription{{Name: "id", Type: "int64"}}}},
+		source, destination, time.UnixMilli(1700000000000))
+	require.Len(t, tables, 1)
+	require.Equal(t, "postgres", tables[0].Source.Platform)
+	require.Equal(t, "id", tables[0].ColumnSources["user_id"])
+
+	proposals, err := dataHubProposals(tables[0])
+	require.NoError(t, err)
+	require.Len(t, proposals, 2)
+	require.Equal(t, "urn:li:dataset:(urn:li:dataPlatform:snowflake,ANALYTICS.public.users,PROD)",
+		proposals[0].Proposal.EntityURN)
+
+	var lineage struct {
+		Upstreams           []map[string]string `json:"upstreams"`
+		FineGrainedLineages []struct {
+			Upstreams   []string `json:"upstreams"`
+			Downstreams []string `json:"downstreams"`
+		} `json:"fineGrainedLineages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(proposals[0].Proposal.Aspect.Value), &lineage))
+	require.Equal(t, "urn:li:dataset:(urn:li:dataPlatform:postgres,app.public.users,PROD)", lineage.Upstreams[0]["dataset"])
+	require.Equal(t, []string{"urn:li:schemaField:(urn:li:dataset:(urn:li:dataPlatform:postgres,app.public.users,PROD),id)"},
+		lineage.FineGrainedLineages[0].Upstreams)
+	require.Contains(t, proposals[1].Proposal.Aspect.Value, `"lastModified":{"time":1700000000000}`)
+}
@@ -0,0 +1,183 @@
+package lineage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+// CDCBatch is a synced batch of a CDC mirror, changes after StartLSN up to EndLSN
+type CDCBatch struct {
+	BatchID    int64
+	NumRecords int64
+	StartLSN   int64
+	EndLSN     int64
+}
+
+// DatasetNamespace names where tables of peer live following OpenLineage naming, like postgres://host:5432
+func DatasetNamespace(peer *protos.Peer) string {
+	switch config := peer.Config.(type) {
+	case *protos.Peer_PostgresConfig:
+		return fmt.Sprintf("postgres://%s:%d", config.PostgresConfig.Host, config.PostgresConfig.Port)
+	case *protos.Peer_SnowflakeConfig:
+		return "snowflake://" + strings.ToLower(config.SnowflakeConfig.AccountId)
+	case *protos.Peer_BigqueryConfig:
+		return "bigquery"
+	case *protos.Peer_ClickhouseConfig:
+		return fmt.Sprintf("clickhouse://%s:%d", config.ClickhouseConfig.Host, config.ClickhouseConfig.Port)
+	case *protos.Peer_S3Config:
+		return strings.TrimSuffix(config.S3Config.Url, "/")
+	default:
+		return strings.ToLower(peer.Type.String()) + "://" + peer.Name
+	}
+}
+
+// DatasetName qualifies table with the database of peer, so names are unique within DatasetNamespace
+func DatasetName(peer *protos.Peer, table string) string {
+	switch config := peer.Config.(type) {
+	case *protos.Peer_PostgresConfig:
+		return config.PostgresConfig.Database + "." + table
+	case *protos.Peer_SnowflakeConfig:
+		return config.SnowflakeConfig.Database + "." + table
+	case *protos.Peer_BigqueryConfig:
+		if !strings.Contains(table, ".") {
+			table = config.BigqueryConfig.DatasetId + "." + table
+		}
+		return config.BigqueryConfig.ProjectId + "." + table
+	case *protos.Peer_ClickhouseConfig:
+		return config.ClickhouseConfig.Database + "." + table
+	default:
+		return table
+	}
+}
+
+// mappedFields returns columns of the source table, the destination columns they are replicated to
+// and the source column of each destination column, renamed per column settings less excluded columns
+func mappedFields(
+	tableMapping *protos.TableMapping,
+	tableSchema *protos.TableSchema,
+) ([]SchemaField, []SchemaField, map[string]string) {
+	renames := make(map[string]string, len(tableMapping.Columns))
+	for _, col := range tableMapping.Columns {
+		if col.DestinationName != "" {
+			renames[col.SourceName] = col.DestinationName
+		}
+	}
+	srcFields := make([]SchemaField, 0, len(tableSchema.Columns))
+	dstFields := make([]SchemaField, 0, len(tableSchema.Columns))
+	sourceColumns := make(map[string]string, len(tableSchema.Columns))
+	for _, col := range tableSchema.Columns {
+		srcFields = append(srcFields, SchemaField{Name: col.Name, Type: col.Type})
+		if slices.Contains(tableMapping.Exclude, col.Name) {
+			continue
+		}
+		dstName := col.Name
+		if rename, ok := renames[col.Name]; ok {
+			dstName = rename
+		}
+		dstFields = append(dstFields, SchemaField{Name: dstName, Type: col.Type})
+		sourceColumns[dstName] = col.Name
+	}
+	return srcFields, dstFields, sourceColumns
+}
+
+func newRunEvent(jobName string, jobType string, processingType string, batch batchFacet) *RunEvent {
+	runID, err := uuid.NewV7()
+	if err != nil {
+		runID = uuid.New()
+	}
+	return &RunEvent{
+		EventType: "COMPLETE",
+		EventTime: time.Now().UTC(),
+		Producer:  producer,
+		SchemaURL: runEventSchema,
+		Run: Run{
+			RunID:  runID.String(),
+			Facets: map[string]any{"peerdb_batch": batch},
+		},
+		Job: Job{
+			Namespace: peerdbenv.PeerDBOpenLineageNamespace(),
+			Name:      jobName,
+			Facets: map[string]any{"jobType": jobTypeFacet{
+				facet:          newFacet(jobTypeFacetURL),
+				ProcessingType: processingType,
+				Integration:    "PEERDB",
+				JobType:        jobType,
+			}},
+		},
+	}
+}
+
+// CDCBatchEvent describes a batch of a CDC mirror, every mirrored table with the source columns
+// each destination column is replicated from, excluded columns are left out
+func CDCBatchEvent(
+	config *protos.FlowConnectionConfigs,
+	tableMappings []*protos.TableMapping,
+	schemas map[string]*protos.TableSchema,
+	source *protos.Peer,
+	destination *protos.Peer,
+	batch CDCBatch,
+) *RunEvent {
+	event := newRunEvent(config.FlowJobName, "CDC_MIRROR", "STREAMING", batchFacet{
+		facet:      newFacet(batchFacetSchema),
+		BatchID:    batch.BatchID,
+		NumRecords: batch.NumRecords,
+		StartLSN:   batch.StartLSN,
+		EndLSN:     batch.EndLSN,
+	})
+	srcNamespace := DatasetNamespace(source)
+	dstNamespace := DatasetNamespace(destination)
+	for _, tableMapping := range tableMappings {
+		srcName := DatasetName(source, tableMapping.SourceTableIdentifier)
+		input := Dataset{Namespace: srcNamespace, Name: srcName}
+		output := Dataset{Namespace: dstNamespace, Name: DatasetName(destination, tableMapping.DestinationTableIdentifier)}
+
+		if tableSchema, ok := schemas[tableMapping.SourceTableIdentifier]; ok {
+			srcFields, dstFields, sourceColumns := mappedFields(tableMapping, tableSchema)
+			lineage := make(map[string]columnLineage, len(dstFields))
+			for _, field := range dstFields {
+				lineage[field.Name] = columnLineage{InputFields: []InputField{
+					{Namespace: srcNamespace, Name: srcName, Field: sourceColumns[field.Name]},
+				}}
+			}
+			input.Facets = map[string]any{"schema": schemaFacet{facet: newFacet(schemaFacetURL), Fields: srcFields}}
+			output.Facets = map[string]any{
+				"schema":        schemaFacet{facet: newFacet(schemaFacetURL), Fields: dstFields},
+				"columnLineage": columnLineageFacet{facet: newFacet(lineageFacetURL), Fields: lineage},
+			}
+		}
+		event.Inputs = append(event.Inputs, input)
+		event.Outputs = append(event.Outputs, output)
+	}
+	return event
+}
+
+// QRepPartitionEvent describes a replicated partition of a query replication mirror,
+// columns are unknown as they come from a query, the watermark table is its input
+func QRepPartitionEvent(
+	config *protos.QRepConfig,
+	source *protos.Peer,
+	destination *protos.Peer,
+	partitionID string,
+	numRecords int64,
+) *RunEvent {
+	event := newRunEvent(config.FlowJobName, "QREP_MIRROR", "BATCH", batchFacet{
+		facet:       newFacet(batchFacetSchema),
+		PartitionID: partitionID,
+		NumRecords:  numRecords,
+	})
+	if config.WatermarkTable != "" {
+		event.Inputs = []Dataset{{Namespace: DatasetNamespace(source), Name: DatasetName(source, config.WatermarkTable)}}
+	}
+	event.Outputs = []Dataset{{
+		Namespace: DatasetNamespace(destination),
+		Name:      DatasetName(destination, config.DestinationTableIdentifier),
+	}}
+	return event
+}
@@ -0,0 +1,47 @@
+package lineage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestCDCBatchEvent(t *testing.T) {
+	source := &protos.Peer{Name: "pg", Config: &protos.Peer_PostgresConfig{
+		PostgresConfig: &protos.PostgresConfig{Host: "db", Port: 5432, Database: "app"},
+	}}
+	destination := &protos.Peer{Name: "ch", Config: &protos.Peer_ClickhouseConfig{
+		ClickhouseConfig: &protos.ClickhouseConfig{Host: "ch", Port: 9000, Database: "analytics"},
+	}}
+	tableMappings := []*protos.TableMapping{{
+		SourceTableIdentifier:      "public.users",
+		DestinationTableIdentifier: "users",
+		Exclude:                    []string{"password"},
+		Columns:                    []*protos.ColumnSetting{{SourceName: "email", DestinationName: "contact"}},
+	}}
+	schemas := map[string]*protos.TableSchema{"public.users": {Columns: []*protos.FieldDescription{
+		{Name: "id", Type: "int64"},
+		{Name: "email", Type: "string"},
+		{Name: "password", Type: "string"},
+	}}}
+
+	event := CDCBatchEvent(&protos.FlowConnectionConfigs{FlowJobName: "m"}, tableMappings, schemas,
+		source, destination, CDCBatch{BatchID: 3, NumRecords: 10})
+	require.Equal(t, "m", event.Job.Name)
+	require.Len(t, event.Inputs, 1)
+	require.Equal(t, "postgres://db:5432", event.Inputs[0].Namespace)
+	require.Equal(t, "app.public.users", event.Inputs[0].Name)
+	require.Equal(t, "clickhouse://ch:9000", event.Outputs[0].Namespace)
+	require.Equal(t, "analytics.users", event.Outputs[0].Name)
+
+	lineage := event.Outputs[0].Facets["columnLineage"].(columnLineageFacet)
+	require.Len(t, lineage.Fields, 2)
+	require.Equal(t, "email", lineage.Fields["contact"].InputFields[0].Field)
+	require.NotContains(t, lineage.Fields, "password")
+
+	_, err := json.Marshal(event)
+	require.NoError(t, err)
+}
@@ -0,0 +1,148 @@
+// Package lineage emits OpenLineage run events for mirrors, so catalogs like Marquez or DataHub
+// show which source tables and columns feed destination tables
+package lineage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+)
+
+const (
+	producer         = "https://github.com/PeerDB-io/peerdb"
+	runEventSchema   = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/definitions/RunEvent"
+	schemaFacetURL   = "https://openlineage.io/spec/facets/1-1-1/SchemaDatasetFacet.json#/$defs/SchemaDatasetFacet"
+	lineageFacetURL  = "https://openlineage.io/spec/facets/1-2-0/ColumnLineageDatasetFacet.json#/$defs/ColumnLineageDatasetFacet"
+	jobTypeFacetURL  = "https://openlineage.io/spec/facets/2-0-3/JobTypeJobFacet.json#/$defs/JobTypeJobFacet"
+	batchFacetSchema = "https://github.com/PeerDB-io/peerdb/blob/main/protos/flow.proto"
+
+	emitTimeout = 10 * time.Second
+)
+
+type RunEvent struct {
+	EventType string    `json:"eventType"`
+	EventTime time.Time `json:"eventTime"`
+	Producer  string    `json:"producer"`
+	SchemaURL string    `json:"schemaURL"`
+	Run       Run       `json:"run"`
+	Job       Job       `json:"job"`
+	Inputs    []Dataset `json:"inputs"`
+	Outputs   []Dataset `json:"outputs"`
+}
+
+type Run struct {
+	RunID  string         `json:"runId"`
+	Facets map[string]any `json:"facets,omitempty"`
+}
+
+type Job struct {
+	Namespace string         `json:"namespace"`
+	Name      string         `json:"name"`
+	Facets    map[string]any `json:"facets,omitempty"`
+}
+
+type Dataset struct {
+	Namespace string         `json:"namespace"`
+	Name      string         `json:"name"`
+	Facets    map[string]any `json:"facets,omitempty"`
+}
+
+type facet struct {
+	Producer  string `json:"_producer"`
+	SchemaURL string `json:"_schemaURL"`
+}
+
+type SchemaField struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+type schemaFacet struct {
+	facet
+	Fields []SchemaField `json:"fields"`
+}
+
+type InputField struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Field     string `json:"field"`
+}
+
+type columnLineage struct {
+	InputFields []InputField `json:"inputFields"`
+}
+
+type columnLineageFacet struct {
+	facet
+	Fields map[string]columnLineage `json:"fields"`
+}
+
+type jobTypeFacet struct {
+	facet
+	ProcessingType string `json:"processingType"`
+	Integration    string `json:"integration"`
+	JobType        string `json:"jobType"`
+}
+
+// batchFacet describes what a run replicated, a CDC batch or a QRep partition
+type batchFacet struct {
+	facet
+	BatchID     int64  `json:"batchId,omitempty"`
+	PartitionID string `json:"partitionId,omitempty"`
+	NumRecords  int64  `json:"numRecords"`
+	StartLSN    int64  `json:"startLsn,omitempty"`
+	EndLSN      int64  `json:"endLsn,omitempty"`
+}
+
+func newFacet(schemaURL string) facet {
+	return facet{Producer: producer, SchemaURL: schemaURL}
+}
+
+// Enabled reports whether an OpenLineage endpoint is configured
+func Enabled() bool {
+	return peerdbenv.PeerDBOpenLineageURL() != ""
+}
+
+// Emit posts event to the configured OpenLineage endpoint, does nothing when there is none
+func Emit(ctx context.Context, event *RunEvent) error {
+	baseURL := peerdbenv.PeerDBOpenLineageURL()
+	if baseURL == "" {
+		return nil
+	}
+	return postJSON(ctx, strings.TrimSuffix(baseURL, "/")+"/api/v1/lineage", peerdbenv.PeerDBOpenLineageAPIKey(), event)
+}
+
+func postJSON(ctx context.Context, url string, token string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, emitTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create metadata request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send metadata: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s responded %d: %s", url, resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
 	"github.com/urfave/cli/v3"
 	"go.temporal.io/sdk/worker"
@@ -42,6 +43,20 @@ func main() {
 		Sources: cli.EnvVars("ENABLE_OTEL_METRICS"),
 	}
 
+	prometheusMetricsPortFlag := &cli.IntFlag{
+		Name:    "prometheus-metrics-port",
+		Value:   0, // Default is off
+		Usage:   "Port to serve Prometheus metrics at /metrics on",
+		Sources: cli.EnvVars("PEERDB_PROMETHEUS_METRICS_PORT"),
+	}
+
+	flowMetricsCatalogFlag := &cli.BoolFlag{
+		Name:    "enable-flow-metrics-catalog",
+		Value:   false,
+		Usage:   "Keep the latest metrics of each mirror in catalog",
+		Sources: cli.EnvVars("PEERDB_ENABLE_FLOW_METRICS_CATALOG"),
+	}
+
 	pyroscopeServerFlag := &cli.StringFlag{
 		Name:    "pyroscope-server-address",
 		Value:   "http://pyroscope:4040",
@@ -81,6 +96,8 @@ func main() {
 						TemporalHostPort:                   temporalHostPort,
 						EnableProfiling:                    clicmd.Bool("enable-profiling"),
 						EnableOtelMetrics:                  clicmd.Bool("enable-otel-metrics"),
+						PrometheusMetricsPort:              int(clicmd.Int("prometheus-metrics-port")),
+						EnableFlowMetricsCatalog:           clicmd.Bool("enable-flow-metrics-catalog"),
 						PyroscopeServer:                    clicmd.String("pyroscope-server-address"),
 						TemporalNamespace:                  clicmd.String("temporal-namespace"),
 						TemporalMaxConcurrentActivities:    int(clicmd.Int("temporal-max-concurrent-activities")),
@@ -96,6 +113,8 @@ func main() {
 					temporalHostPortFlag,
 					profilingFlag,
 					otelMetricsFlag,
+					prometheusMetricsPortFlag,
+					flowMetricsCatalogFlag,
 					pyroscopeServerFlag,
 					temporalNamespaceFlag,
 					temporalMaxConcurrentActivitiesFlag,
@@ -148,6 +167,111 @@ func main() {
 					})
 				},
 			},
+			{
+				Name:  "bench",
+				Usage: "benchmark a source and destination peer through a mirror, reporting throughput",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "source",
+						Usage:    "postgres peer to read from",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "destination",
+						Usage:    "peer to write to",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "destination-table",
+						Usage: "destination table, named after the run by default",
+					},
+					&cli.IntFlag{
+						Name:  "rows",
+						Usage: "rows to snapshot",
+						Value: 1_000_000,
+					},
+					&cli.DurationFlag{
+						Name:  "cdc-duration",
+						Usage: "how long to make changes after the snapshot",
+						Value: time.Minute,
+					},
+					&cli.IntFlag{
+						Name:  "cdc-rate",
+						Usage: "changes a second, 60% inserts, 30% updates and 10% deletes",
+						Value: 1000,
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the report as JSON",
+					},
+					temporalHostPortFlag,
+					temporalNamespaceFlag,
+				},
+				Action: func(ctx context.Context, clicmd *cli.Command) error {
+					return cmd.BenchMain(ctx, &cmd.BenchOptions{
+						TemporalHostPort:  clicmd.String("temporal-host-port"),
+						TemporalNamespace: clicmd.String("temporal-namespace"),
+						SourceName:        clicmd.String("source"),
+						DestinationName:   clicmd.String("destination"),
+						DestinationTable:  clicmd.String("destination-table"),
+						Rows:              clicmd.Int("rows"),
+						CDCDuration:       clicmd.Duration("cdc-duration"),
+						CDCRate:           clicmd.Int("cdc-rate"),
+						JSON:              clicmd.Bool("json"),
+					})
+				},
+			},
+			{
+				Name:  "standalone",
+				Usage: "run mirrors of a config file in this process, without Temporal",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "config",
+						Usage:    "JSON file of the peers and mirrors to run",
+						Required: true,
+						Sources:  cli.EnvVars("PEERDB_STANDALONE_CONFIG"),
+					},
+				},
+				Action: func(ctx context.Context, clicmd *cli.Command) error {
+					return cmd.StandaloneMain(ctx, &cmd.StandaloneOptions{
+						ConfigPath: clicmd.String("config"),
+					})
+				},
+			},
+			{
+				Name:  "operator",
+				Usage: "reconcile Peer and Mirror resources of the Kubernetes cluster against the flow API",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "flow-api-address",
+						Usage:   "host:port of the gRPC flow API",
+						Value:   "localhost:8112",
+						Sources: cli.EnvVars("PEERDB_FLOW_API_ADDRESS"),
+					},
+					&cli.StringFlag{
+						Name:    "namespace",
+						Usage:   "namespace of resources, that of the service account by default",
+						Sources: cli.EnvVars("PEERDB_OPERATOR_NAMESPACE"),
+					},
+					&cli.DurationFlag{
+						Name:  "resync-interval",
+						Usage: "how often resources are reconciled",
+						Value: 30 * time.Second,
+					},
+					&cli.FloatFlag{
+						Name:  "lag-threshold-mb",
+						Usage: "slot lag marking mirrors as lagging, 0 to only go by freshness SLAs",
+					},
+				},
+				Action: func(ctx context.Context, clicmd *cli.Command) error {
+					return cmd.OperatorMain(ctx, &cmd.OperatorOptions{
+						FlowAPIAddress: clicmd.String("flow-api-address"),
+						Namespace:      clicmd.String("namespace"),
+						ResyncInterval: clicmd.Duration("resync-interval"),
+						LagThresholdMB: clicmd.Float("lag-threshold-mb"),
+					})
+				},
+			},
 		},
 	}
 
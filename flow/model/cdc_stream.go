@@ -16,11 +16,15 @@ type CDCStream[T Items] struct {
 	emptySignal chan bool
 	records     chan Record[T]
 	// Schema changes from slot
-	SchemaDeltas      []*protos.TableSchemaDelta
+	SchemaDeltas []*protos.TableSchemaDelta
+	// destination tables truncated at source, always before any record of the stream
+	TruncatedTables   []string
 	lastCheckpointSet bool
 	needsNormalize    atomic.Bool
 	// lastCheckpointID is the last ID of the commit that corresponds to this batch.
 	lastCheckpointID atomic.Int64
+	// commit time at source of the latest record, only written by the pull
+	lastCommitTimeNano int64
 }
 
 func NewCDCStream[T Items](channelBuffer int) *CDCStream[T] {
@@ -45,11 +49,27 @@ func (r *CDCStream[T]) GetLastCheckpoint() int64 {
 	return r.lastCheckpointID.Load()
 }
 
+// LastCommitTime is when the latest record of the stream was committed at source, zero without records
+func (r *CDCStream[T]) LastCommitTime() time.Time {
+	if !r.lastCheckpointSet {
+		panic("last checkpoint not set, stream is still active")
+	}
+	if r.lastCommitTimeNano <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, r.lastCommitTimeNano)
+}
+
 func (r *CDCStream[T]) AddRecord(ctx context.Context, record Record[T]) error {
+	r.lastCommitTimeNano = max(r.lastCommitTimeNano, record.GetCommitTime().UnixNano())
 	if !r.needsNormalize.Load() {
-		switch record.(type) {
+		switch record := record.(type) {
 		case *InsertRecord[T], *UpdateRecord[T], *DeleteRecord[T]:
 			r.needsNormalize.Store(true)
+		case *MessageRecord[T]:
+			if record.DestinationTableName != "" {
+				r.needsNormalize.Store(true)
+			}
 		}
 	}
 
@@ -102,6 +122,13 @@ func (r *CDCStream[T]) AddSchemaDelta(
 	r.SchemaDeltas = append(r.SchemaDeltas, delta)
 }
 
+func (r *CDCStream[T]) AddTruncatedTables(tables ...string) {
+	if len(tables) > 0 {
+		r.TruncatedTables = append(r.TruncatedTables, tables...)
+		r.needsNormalize.Store(true)
+	}
+}
+
 func (r *CDCStream[T]) NeedsNormalize() bool {
 	return r.needsNormalize.Load()
 }
@@ -0,0 +1,320 @@
+package model
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+const (
+	// number of minimum hashes kept per column, estimates are within roughly 10% of the true distinct count
+	columnStatsSketchSize = 128
+	// strings longer than this disable bounds for the column, storing them as min/max isn't worth it
+	columnStatsMaxStringBound = 256
+)
+
+// ColumnStatsCollector accumulates statistics of the records of a table,
+// it is not safe for concurrent use
+type ColumnStatsCollector struct {
+	columns []*columnStats
+}
+
+type columnStats struct {
+	minValue  any
+	maxValue  any
+	name      string
+	kind      qvalue.QValueKind
+	sketch    []uint64
+	rowCount  int64
+	nullCount int64
+	noBounds  bool
+}
+
+func NewColumnStatsCollector(schema qvalue.QRecordSchema) *ColumnStatsCollector {
+	columns := make([]*columnStats, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		columns = append(columns, &columnStats{name: field.Name, kind: field.Type})
+	}
+	return &ColumnStatsCollector{columns: columns}
+}
+
+func (c *ColumnStatsCollector) AddRecord(record []qvalue.QValue) {
+	for idx, value := range record {
+		if idx >= len(c.columns) {
+			break
+		}
+		c.columns[idx].add(value)
+	}
+}
+
+func (c *ColumnStatsCollector) Stats() []*protos.ColumnStatistics {
+	stats := make([]*protos.ColumnStatistics, 0, len(c.columns))
+	for _, column := range c.columns {
+		stats = append(stats, column.toProto())
+	}
+	return stats
+}
+
+func (s *columnStats) add(value qvalue.QValue) {
+	s.rowCount += 1
+	if value == nil || value.Value() == nil {
+		s.nullCount += 1
+		return
+	}
+	s.sketch = addToSketch(s.sketch, hashQValue(value))
+	if s.noBounds {
+		return
+	}
+	bound, ok := columnBound(value)
+	if !ok {
+		s.noBounds = true
+		s.minValue, s.maxValue = nil, nil
+		return
+	}
+	if s.minValue == nil || compareBounds(bound, s.minValue) < 0 {
+		s.minValue = bound
+	}
+	if s.maxValue == nil || compareBounds(bound, s.maxValue) > 0 {
+		s.maxValue = bound
+	}
+}
+
+func (s *columnStats) toProto() *protos.ColumnStatistics {
+	stats := &protos.ColumnStatistics{
+		ColumnName:       s.name,
+		Kind:             string(s.kind),
+		RowCount:         s.rowCount,
+		NullCount:        s.nullCount,
+		DistinctEstimate: estimateDistinct(s.sketch),
+		DistinctSketch:   s.sketch,
+	}
+	if !s.noBounds && s.minValue != nil {
+		minValue, maxValue := formatBound(s.minValue), formatBound(s.maxValue)
+		stats.MinValue = &minValue
+		stats.MaxValue = &maxValue
+	}
+	return stats
+}
+
+// MergeColumnStatistics combines statistics of the same table collected separately, e.g. per partition
+func MergeColumnStatistics(a []*protos.ColumnStatistics, b []*protos.ColumnStatistics) ([]*protos.ColumnStatistics, error) {
+	merged := make([]*protos.ColumnStatistics, 0, max(len(a), len(b)))
+	byName := make(map[string]int, len(a))
+	for _, stats := range a {
+		byName[stats.ColumnName] = len(merged)
+		merged = append(merged, &protos.ColumnStatistics{
+			ColumnName:       stats.ColumnName,
+			Kind:             stats.Kind,
+			RowCount:         stats.RowCount,
+			NullCount:        stats.NullCount,
+			MinValue:         stats.MinValue,
+			MaxValue:         stats.MaxValue,
+			DistinctEstimate: stats.DistinctEstimate,
+			DistinctSketch:   slices.Clone(stats.DistinctSketch),
+		})
+	}
+
+	for _, stats := range b {
+		idx, ok := byName[stats.ColumnName]
+		// sketches and bounds are only comparable while the column kind is unchanged
+		if !ok || merged[idx].Kind != stats.Kind {
+			if ok {
+				merged[idx] = stats
+			} else {
+				merged = append(merged, stats)
+			}
+			continue
+		}
+		column := merged[idx]
+
+		// no bounds despite having values means the column kind or its values can't be ordered
+		aHasValues, bHasValues := column.RowCount > column.NullCount, stats.RowCount > stats.NullCount
+		switch {
+		case (aHasValues && column.MinValue == nil) || (bHasValues && stats.MinValue == nil):
+			column.MinValue, column.MaxValue = nil, nil
+		case !aHasValues:
+			column.MinValue, column.MaxValue = stats.MinValue, stats.MaxValue
+		case bHasValues:
+			kind := qvalue.QValueKind(column.Kind)
+			var err error
+			if column.MinValue, err = mergeBound(kind, column.MinValue, stats.MinValue, -1); err != nil {
+				return nil, err
+			}
+			if column.MaxValue, err = mergeBound(kind, column.MaxValue, stats.MaxValue, 1); err != nil {
+				return nil, err
+			}
+		}
+
+		column.RowCount += stats.RowCount
+		column.NullCount += stats.NullCount
+		for _, hash := range stats.DistinctSketch {
+			column.DistinctSketch = addToSketch(column.DistinctSketch, hash)
+		}
+		column.DistinctEstimate = estimateDistinct(column.DistinctSketch)
+	}
+	return merged, nil
+}
+
+func mergeBound(kind qvalue.QValueKind, a *string, b *string, direction int) (*string, error) {
+	boundA, err := parseBound(kind, *a)
+	if err != nil {
+		return nil, err
+	}
+	boundB, err := parseBound(kind, *b)
+	if err != nil {
+		return nil, err
+	}
+	if compareBounds(boundB, boundA)*direction > 0 {
+		return b, nil
+	}
+	return a, nil
+}
+
+// columnBound converts value to decimal.Decimal, time.Time, or string for ordering
+func columnBound(value qvalue.QValue) (any, bool) {
+	switch v := value.(type) {
+	case qvalue.QValueInt16:
+		return decimal.NewFromInt(int64(v.Val)), true
+	case qvalue.QValueInt32:
+		return decimal.NewFromInt(int64(v.Val)), true
+	case qvalue.QValueInt64:
+		return decimal.NewFromInt(v.Val), true
+	case qvalue.QValueFloat32:
+		if math.IsNaN(float64(v.Val)) || math.IsInf(float64(v.Val), 0) {
+			return nil, false
+		}
+		return decimal.NewFromFloat32(v.Val), true
+	case qvalue.QValueFloat64:
+		if math.IsNaN(v.Val) || math.IsInf(v.Val, 0) {
+			return nil, false
+		}
+		return decimal.NewFromFloat(v.Val), true
+	case qvalue.QValueNumeric:
+		return v.Val, true
+	case qvalue.QValueTimestamp:
+		return v.Val, true
+	case qvalue.QValueTimestampTZ:
+		return v.Val, true
+	case qvalue.QValueDate:
+		return v.Val, true
+	case qvalue.QValueTime:
+		return v.Val, true
+	case qvalue.QValueTimeTZ:
+		return v.Val, true
+	case qvalue.QValueString:
+		if len(v.Val) > columnStatsMaxStringBound {
+			return nil, false
+		}
+		return v.Val, true
+	default:
+		return nil, false
+	}
+}
+
+func compareBounds(a any, b any) int {
+	switch a := a.(type) {
+	case decimal.Decimal:
+		return a.Cmp(b.(decimal.Decimal))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(a, b.(string))
+	default:
+		return 0
+	}
+}
+
+func formatBound(bound any) string {
+	switch bound := bound.(type) {
+	case decimal.Decimal:
+		return bound.String()
+	case time.Time:
+		return bound.Format(time.RFC3339Nano)
+	case string:
+		return bound
+	default:
+		return fmt.Sprint(bound)
+	}
+}
+
+func parseBound(kind qvalue.QValueKind, bound string) (any, error) {
+	switch kind {
+	case qvalue.QValueKindInt16, qvalue.QValueKindInt32, qvalue.QValueKindInt64,
+		qvalue.QValueKindFloat32, qvalue.QValueKindFloat64, qvalue.QValueKindNumeric:
+		return decimal.NewFromString(bound)
+	case qvalue.QValueKindTimestamp, qvalue.QValueKindTimestampTZ, qvalue.QValueKindDate,
+		qvalue.QValueKindTime, qvalue.QValueKindTimeTZ:
+		return time.Parse(time.RFC3339Nano, bound)
+	case qvalue.QValueKindString:
+		return bound, nil
+	default:
+		return nil, fmt.Errorf("column kind %s has no bounds", kind)
+	}
+}
+
+// hashQValue needs to be stable across workers so sketches of different partitions can be merged
+func hashQValue(value qvalue.QValue) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	switch v := value.(type) {
+	case qvalue.QValueInt16:
+		_, _ = h.Write(binary.LittleEndian.AppendUint64(buf[:0], uint64(v.Val)))
+	case qvalue.QValueInt32:
+		_, _ = h.Write(binary.LittleEndian.AppendUint64(buf[:0], uint64(v.Val)))
+	case qvalue.QValueInt64:
+		_, _ = h.Write(binary.LittleEndian.AppendUint64(buf[:0], uint64(v.Val)))
+	case qvalue.QValueString:
+		_, _ = h.Write([]byte(v.Val))
+	case qvalue.QValueBytes:
+		_, _ = h.Write(v.Val)
+	case qvalue.QValueUUID:
+		_, _ = h.Write(v.Val[:])
+	case qvalue.QValueTimestamp:
+		_, _ = h.Write(binary.LittleEndian.AppendUint64(buf[:0], uint64(v.Val.UnixNano())))
+	case qvalue.QValueTimestampTZ:
+		_, _ = h.Write(binary.LittleEndian.AppendUint64(buf[:0], uint64(v.Val.UnixNano())))
+	default:
+		_, _ = fmt.Fprint(h, value.Value())
+	}
+	// fnv mixes low bits poorly for short inputs, finalize like splitmix64
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// addToSketch keeps the smallest columnStatsSketchSize distinct hashes in sorted order
+func addToSketch(sketch []uint64, hash uint64) []uint64 {
+	if len(sketch) >= columnStatsSketchSize && hash >= sketch[len(sketch)-1] {
+		return sketch
+	}
+	idx, found := slices.BinarySearch(sketch, hash)
+	if found {
+		return sketch
+	}
+	sketch = slices.Insert(sketch, idx, hash)
+	if len(sketch) > columnStatsSketchSize {
+		sketch = sketch[:columnStatsSketchSize]
+	}
+	return sketch
+}
+
+func estimateDistinct(sketch []uint64) int64 {
+	if len(sketch) < columnStatsSketchSize {
+		return int64(len(sketch))
+	}
+	kth := float64(sketch[len(sketch)-1]) / math.MaxUint64
+	return int64(float64(columnStatsSketchSize-1) / kth)
+}
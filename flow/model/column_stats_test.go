@@ -0,0 +1,57 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func collectColumnStats(from int64, to int64) *ColumnStatsCollector {
+	collector := NewColumnStatsCollector(qvalue.NewQRecordSchema([]qvalue.QField{
+		{Name: "id", Type: qvalue.QValueKindInt64},
+		{Name: "name", Type: qvalue.QValueKindString, Nullable: true},
+		{Name: "data", Type: qvalue.QValueKindJSON},
+	}))
+	for i := from; i < to; i++ {
+		var name qvalue.QValue = qvalue.QValueNull(qvalue.QValueKindString)
+		if i%2 == 0 {
+			name = qvalue.QValueString{Val: fmt.Sprintf("name%03d", i%100)}
+		}
+		collector.AddRecord([]qvalue.QValue{qvalue.QValueInt64{Val: i}, name, qvalue.QValueJSON{Val: "{}"}})
+	}
+	return collector
+}
+
+func TestColumnStats(t *testing.T) {
+	stats := collectColumnStats(0, 1000).Stats()
+	require.Len(t, stats, 3)
+
+	require.Equal(t, int64(1000), stats[0].RowCount)
+	require.Equal(t, "0", stats[0].GetMinValue())
+	require.Equal(t, "999", stats[0].GetMaxValue())
+	require.InEpsilon(t, 1000, stats[0].DistinctEstimate, 0.25)
+
+	require.Equal(t, int64(500), stats[1].NullCount)
+	require.Equal(t, "name000", stats[1].GetMinValue())
+	require.Equal(t, "name098", stats[1].GetMaxValue())
+	require.Equal(t, int64(50), stats[1].DistinctEstimate)
+
+	require.Nil(t, stats[2].MinValue)
+	require.Equal(t, int64(1), stats[2].DistinctEstimate)
+}
+
+func TestMergeColumnStatistics(t *testing.T) {
+	merged, err := MergeColumnStatistics(collectColumnStats(500, 1000).Stats(), collectColumnStats(0, 500).Stats())
+	require.NoError(t, err)
+	whole := collectColumnStats(0, 1000).Stats()
+	for idx := range whole {
+		require.Equal(t, whole[idx].RowCount, merged[idx].RowCount)
+		require.Equal(t, whole[idx].NullCount, merged[idx].NullCount)
+		require.Equal(t, whole[idx].GetMinValue(), merged[idx].GetMinValue())
+		require.Equal(t, whole[idx].GetMaxValue(), merged[idx].GetMaxValue())
+		require.Equal(t, whole[idx].DistinctSketch, merged[idx].DistinctSketch)
+	}
+}
@@ -0,0 +1,208 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// SampledRow is a row read for data diffs, keyed by column name
+type SampledRow map[string]any
+
+type DataDiffMismatch struct {
+	Key     map[string]string `json:"key"`
+	Columns []string          `json:"columns,omitempty"`
+	Missing bool              `json:"missing,omitempty"`
+}
+
+// CompareSampledRows compares each source row against the destination row with the same key.
+// columns maps source column names to destination column names, destination names compare case-insensitively
+// as some destinations fold case, source columns not in the destination row are not compared
+func CompareSampledRows(
+	source []SampledRow,
+	destination []SampledRow,
+	keyColumns []string,
+	columns map[string]string,
+) []DataDiffMismatch {
+	dstKeyColumns := make([]string, 0, len(keyColumns))
+	for _, key := range keyColumns {
+		dstKeyColumns = append(dstKeyColumns, strings.ToLower(columns[key]))
+	}
+	dstRows := make(map[string]SampledRow, len(destination))
+	for _, row := range destination {
+		folded := make(SampledRow, len(row))
+		for name, value := range row {
+			folded[strings.ToLower(name)] = value
+		}
+		dstRows[sampledRowKey(folded, dstKeyColumns)] = folded
+	}
+
+	var mismatches []DataDiffMismatch
+	for _, srcRow := range source {
+		key := SampledRowKey(srcRow, keyColumns)
+		dstRow, ok := dstRows[sampledRowKey(srcRow, keyColumns)]
+		if !ok {
+			mismatches = append(mismatches, DataDiffMismatch{Key: key, Missing: true})
+			continue
+		}
+		var differing []string
+		for srcColumn, srcValue := range srcRow {
+			dstColumn, ok := columns[srcColumn]
+			if !ok {
+				continue
+			}
+			dstValue, ok := dstRow[strings.ToLower(dstColumn)]
+			if ok && !SampledValuesEqual(srcValue, dstValue) {
+				differing = append(differing, srcColumn)
+			}
+		}
+		if len(differing) > 0 {
+			slices.Sort(differing)
+			mismatches = append(mismatches, DataDiffMismatch{Key: key, Columns: differing})
+		}
+	}
+	return mismatches
+}
+
+// SampledRowKey renders the key of a row as recorded in mismatches
+func SampledRowKey(row SampledRow, keyColumns []string) map[string]string {
+	key := make(map[string]string, len(keyColumns))
+	for _, keyColumn := range keyColumns {
+		key[keyColumn] = fmt.Sprint(normalizeSampledValue(row[keyColumn]))
+	}
+	return key
+}
+
+func sampledRowKey(row SampledRow, keyColumns []string) string {
+	parts := make([]string, 0, len(keyColumns))
+	for _, keyColumn := range keyColumns {
+		parts = append(parts, fmt.Sprint(normalizeSampledValue(row[keyColumn])))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// SampledValuesEqual compares values read from different databases, whose drivers return different Go types
+// for the same value: integers of any width and decimals compare as numbers, floats within rounding,
+// timestamps as instants at microsecond precision, binary as text
+func SampledValuesEqual(a any, b any) bool {
+	na, nb := normalizeSampledValue(a), normalizeSampledValue(b)
+	if na == nil || nb == nil {
+		return na == nil && nb == nil
+	}
+	if fa, ok := na.(float64); ok {
+		if fb, ok := sampledFloat(nb); ok {
+			return floatsEqual(fa, fb)
+		}
+	} else if fb, ok := nb.(float64); ok {
+		if fa, ok := sampledFloat(na); ok {
+			return floatsEqual(fa, fb)
+		}
+	}
+	// drivers return numbers they can't fit in a Go type as text
+	if da, ok := na.(decimal.Decimal); ok {
+		if db, ok := sampledDecimal(nb); ok {
+			return da.Equal(db)
+		}
+	} else if db, ok := nb.(decimal.Decimal); ok {
+		if da, ok := sampledDecimal(na); ok {
+			return da.Equal(db)
+		}
+	}
+	if ta, ok := na.(time.Time); ok {
+		if tb, ok := nb.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+	return reflect.DeepEqual(na, nb) || fmt.Sprint(na) == fmt.Sprint(nb)
+}
+
+func normalizeSampledValue(value any) any {
+	if value == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		return normalizeSampledValue(rv.Elem().Interface())
+	}
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Truncate(time.Microsecond)
+	case int:
+		return decimal.NewFromInt(int64(v))
+	case int8:
+		return decimal.NewFromInt(int64(v))
+	case int16:
+		return decimal.NewFromInt(int64(v))
+	case int32:
+		return decimal.NewFromInt(int64(v))
+	case int64:
+		return decimal.NewFromInt(v)
+	case uint8:
+		return decimal.NewFromUint64(uint64(v))
+	case uint16:
+		return decimal.NewFromUint64(uint64(v))
+	case uint32:
+		return decimal.NewFromUint64(uint64(v))
+	case uint64:
+		return decimal.NewFromUint64(v)
+	case big.Int:
+		return decimal.NewFromBigInt(&v, 0)
+	case float32:
+		// widen through the shortest representation, float64(v) would compare 1.1 as 1.100000023841858
+		widened, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+		return widened
+	case []byte:
+		return string(v)
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case decimal.Decimal, float64, string, bool:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+func sampledDecimal(value any) (decimal.Decimal, bool) {
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return v, true
+	case string:
+		d, err := decimal.NewFromString(v)
+		return d, err == nil
+	default:
+		return decimal.Decimal{}, false
+	}
+}
+
+func sampledFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case decimal.Decimal:
+		return v.InexactFloat64(), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func floatsEqual(a float64, b float64) bool {
+	if a == b || (math.IsNaN(a) && math.IsNaN(b)) {
+		return true
+	}
+	return math.Abs(a-b) <= 1e-9*max(math.Abs(a), math.Abs(b))
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampledValuesEqual(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.FixedZone("", 3600))
+	id := uuid.New()
+	text := "a"
+
+	require.True(t, SampledValuesEqual(nil, nil))
+	require.True(t, SampledValuesEqual(int32(1), int64(1)))
+	require.True(t, SampledValuesEqual(uint64(1), decimal.NewFromInt(1)))
+	require.True(t, SampledValuesEqual(decimal.RequireFromString("1.50"), "1.5"))
+	require.True(t, SampledValuesEqual(*big.NewInt(7), int16(7)))
+	require.True(t, SampledValuesEqual(float32(1.1), 1.1))
+	require.True(t, SampledValuesEqual(ts, ts.UTC().Truncate(time.Microsecond)))
+	require.True(t, SampledValuesEqual([]byte("a"), &text))
+	require.True(t, SampledValuesEqual([16]byte(id), id))
+	require.True(t, SampledValuesEqual([]int32{1, 2}, []int32{1, 2}))
+
+	require.False(t, SampledValuesEqual(nil, "a"))
+	require.False(t, SampledValuesEqual(int64(1), int64(2)))
+	require.False(t, SampledValuesEqual("1.0", "1"))
+	require.False(t, SampledValuesEqual(1.1, 1.2))
+	require.False(t, SampledValuesEqual(ts, ts.Add(time.Millisecond)))
+}
+
+func TestCompareSampledRows(t *testing.T) {
+	source := []SampledRow{
+		{"id": int32(1), "name": "a", "secret": "x"},
+		{"id": int32(2), "name": "b", "secret": "y"},
+		{"id": int32(3), "name": "c", "secret": "z"},
+	}
+	destination := []SampledRow{
+		{"ID": int64(1), "FULL_NAME": "a"},
+		{"ID": int64(2), "FULL_NAME": "B"},
+	}
+	mismatches := CompareSampledRows(source, destination, []string{"id"},
+		map[string]string{"id": "id", "name": "full_name"})
+	require.Equal(t, []DataDiffMismatch{
+		{Key: map[string]string{"id": "2"}, Columns: []string{"name"}},
+		{Key: map[string]string{"id": "3"}, Missing: true},
+	}, mismatches)
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// IdempotencyKeyHeader is the header, attribute or property carrying IdempotencyKey on queue messages
+const IdempotencyKeyHeader = "peerdb-idempotency-key"
+
+// IdempotencyKey identifies a change the same way every time it is delivered, as its LSN, source table
+// and a hash of its primary key, so consumers can drop messages redelivered after a retry.
+// index tells apart messages a script produces for the same change
+func IdempotencyKey(record Record[RecordItems], pkeyCols []string, index int) string {
+	hash := sha256.New()
+	items := record.GetItems()
+	for _, col := range pkeyCols {
+		if value := items.GetColumnValue(col); value != nil {
+			fmt.Fprint(hash, value.Value())
+		}
+		hash.Write([]byte{0})
+	}
+
+	key := strconv.FormatInt(record.GetCheckpointID(), 10) + "/" + record.GetSourceTableName() + "/" +
+		hex.EncodeToString(hash.Sum(nil)[:16])
+	if index > 0 {
+		key += "/" + strconv.Itoa(index)
+	}
+	return key
+}
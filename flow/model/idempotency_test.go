@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	newRecord := func(lsn int64, id int64, name string) Record[RecordItems] {
+		items := NewRecordItems(2)
+		items.AddColumn("id", qvalue.QValueInt64{Val: id})
+		items.AddColumn("name", qvalue.QValueString{Val: name})
+		return &InsertRecord[RecordItems]{
+			Items:           items,
+			SourceTableName: "public.users",
+			BaseRecord:      BaseRecord{CheckpointID: lsn},
+		}
+	}
+	pkeyCols := []string{"id"}
+
+	key := IdempotencyKey(newRecord(42, 1, "a"), pkeyCols, 0)
+	require.Regexp(t, `^42/public\.users/[0-9a-f]{32}$`, key)
+	// non key columns don't matter
+	require.Equal(t, key, IdempotencyKey(newRecord(42, 1, "b"), pkeyCols, 0))
+	require.NotEqual(t, key, IdempotencyKey(newRecord(42, 2, "a"), pkeyCols, 0))
+	require.NotEqual(t, key, IdempotencyKey(newRecord(43, 1, "a"), pkeyCols, 0))
+	require.Equal(t, key+"/1", IdempotencyKey(newRecord(42, 1, "a"), pkeyCols, 1))
+}
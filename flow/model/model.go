@@ -36,7 +36,11 @@ type RecordTypeCounts struct {
 type RecordsToStreamRequest[T Items] struct {
 	records      <-chan Record[T]
 	TableMapping map[string]*RecordTypeCounts
-	BatchID      int64
+	// destination table name -> schema mapping, for keys of collapsed changes
+	TableNameSchemaMapping map[string]*protos.TableSchema
+	BatchID                int64
+	// number of records within which changes of a key are collapsed, 0 for none
+	CollapseWindow int
 }
 
 func NewRecordsToStreamRequest[T Items](
@@ -80,6 +84,16 @@ type PullRecordsRequest[T Items] struct {
 	MaxBatchSize uint32
 	// IdleTimeout is the timeout to wait for new records.
 	IdleTimeout time.Duration
+	// which source column type changes are propagated to the destination
+	TypeWideningPolicy protos.TypeWideningPolicy
+	// whether TRUNCATE on source tables is propagated to the destination
+	TruncatePolicy protos.TruncatePolicy
+	// where logical decoding messages are routed, see MessageRecord
+	LogicalMessageDestination string
+	// transactions from these replication origins are skipped
+	ExcludedOrigins []string
+	// added to every record as SourceIdentifierColName
+	SourceIdentifier string
 }
 
 type ToJSONOptions struct {
@@ -142,9 +156,18 @@ type SyncRecordsRequest[T Items] struct {
 	StagingPath string
 	// Lua script
 	Script string
+	// payload encoding of queue destinations without a script
+	QueueEncoding protos.QueueEncoding
+	// envelope of queue messages
+	CloudEventsMode protos.CloudEventsMode
 	// source:destination mappings
 	TableMappings []*protos.TableMapping
 	SyncBatchID   int64
+	// migration related columns, for destinations applying changes as they sync
+	SoftDeleteColName string
+	SyncedAtColName   string
+	// raw tables keep every change instead of collapsed ones
+	ChangelogMode bool
 }
 
 type NormalizeRecordsRequest struct {
@@ -153,8 +176,25 @@ type NormalizeRecordsRequest struct {
 	FlowJobName            string
 	SoftDeleteColName      string
 	SyncedAtColName        string
-	TableMappings          []*protos.TableMapping
-	SyncBatchID            int64
+	// destination table to the last batch in which it was truncated, see protos.StartNormalizeInput
+	TruncatedTables map[string]int64
+	// tags changes applied to destination tables, only supported by Postgres
+	ReplicationOrigin string
+	// conflict resolution against existing destination rows, only supported by Postgres
+	ConflictColumn    string
+	ConflictCondition string
+	// destination tables missing out of band or quarantined, whose changes are not normalized
+	SkippedTables map[string]struct{}
+	// destination tables to the batch after which their changes are normalized again, for tables released
+	// from quarantine or replayed batches, only supported by Postgres, ClickHouse, Snowflake and BigQuery
+	ReplayTables map[string]int64
+	// batches whose changes are left in the raw table, in ascending order,
+	// only supported by Postgres, ClickHouse, Snowflake and BigQuery
+	SkippedBatches []int64
+	TableMappings  []*protos.TableMapping
+	SyncBatchID    int64
+	TruncatePolicy protos.TruncatePolicy
+	ConflictPolicy protos.ConflictPolicy
 }
 
 type SyncResponse struct {
@@ -162,17 +202,26 @@ type SyncResponse struct {
 	TableNameRowsMapping map[string]*RecordTypeCounts
 	// to be carried to parent workflow
 	TableSchemaDeltas []*protos.TableSchemaDelta
+	// destination tables truncated at source before the records of this batch
+	TruncatedTables []string
 	// LastSyncedCheckpointID is the last ID that was synced.
 	LastSyncedCheckpointID int64
 	// NumRecordsSynced is the number of records that were synced.
 	NumRecordsSynced   int64
 	CurrentSyncBatchID int64
+	// DestinationLatency is how long the destination took writing the batch once all of it was pulled
+	DestinationLatency time.Duration
+	// SourceLag is how far behind the source the latest record pulled was once pulling ended, 0 without records
+	SourceLag time.Duration
 }
 
 type NormalizePayload struct {
 	TableNameSchemaMapping map[string]*protos.TableSchema
+	TruncatedTables        []string
 	Done                   bool
 	SyncBatchID            int64
+	// normalizes with the raised parallelism of catch up mode while set
+	CatchUp bool
 }
 
 type NormalizeResponse struct {
@@ -180,6 +229,8 @@ type NormalizeResponse struct {
 	Done         bool
 	StartBatchID int64
 	EndBatchID   int64
+	// destination tables found missing before normalizing
+	MissingTables []string
 }
 
 type RelationMessageMapping map[uint32]*pglogrepl.RelationMessage
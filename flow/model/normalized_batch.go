@@ -0,0 +1,37 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// NormalizedBatch describes a committed normalize of batches StartBatchID to EndBatchID,
+// written to the batch control table of destinations of mirrors with one
+type NormalizedBatch struct {
+	NormalizedAt time.Time
+	// destination table to rows changed in the batches
+	TableRows    map[string]int64
+	StartBatchID int64
+	EndBatchID   int64
+	// changes after StartLSN up to EndLSN were normalized
+	StartLSN int64
+	EndLSN   int64
+}
+
+func (b *NormalizedBatch) NumRows() int64 {
+	var rows int64
+	for _, tableRows := range b.TableRows {
+		rows += tableRows
+	}
+	return rows
+}
+
+// TablesJSON encodes TableRows as an object of table to rows
+func (b *NormalizedBatch) TablesJSON() (string, error) {
+	tables := b.TableRows
+	if tables == nil {
+		tables = map[string]int64{}
+	}
+	encoded, err := json.Marshal(tables)
+	return string(encoded), err
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizedBatchTables(t *testing.T) {
+	batch := &NormalizedBatch{TableRows: map[string]int64{"public.a": 3, "public.b": 4}}
+	require.Equal(t, int64(7), batch.NumRows())
+	tables, err := batch.TablesJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"public.a":3,"public.b":4}`, tables)
+
+	tables, err = (&NormalizedBatch{}).TablesJSON()
+	require.NoError(t, err)
+	require.Equal(t, "{}", tables)
+}
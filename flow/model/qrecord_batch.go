@@ -24,3 +24,16 @@ func (q *QRecordBatch) FeedToQRecordStream(stream *QRecordStream) {
 	}
 	close(stream.Records)
 }
+
+// SampledRows converts the batch to rows keyed by column name, for data diffs
+func (q *QRecordBatch) SampledRows() []SampledRow {
+	rows := make([]SampledRow, 0, len(q.Records))
+	for _, record := range q.Records {
+		row := make(SampledRow, len(q.Schema.Fields))
+		for idx, field := range q.Schema.Fields {
+			row[field.Name] = record[idx].Value()
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -9,19 +9,21 @@ import (
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 )
 
-func DetermineNumericSettingForDWH(precision int16, scale int16, dwh protos.DBType) (int16, int16) {
-	var warehouseNumeric numeric.WarehouseNumericCompatibility
+func numericCompatibilityForDWH(dwh protos.DBType) numeric.WarehouseNumericCompatibility {
 	switch dwh {
 	case protos.DBType_CLICKHOUSE:
-		warehouseNumeric = numeric.ClickHouseNumericCompatibility{}
+		return numeric.ClickHouseNumericCompatibility{}
 	case protos.DBType_SNOWFLAKE:
-		warehouseNumeric = numeric.SnowflakeNumericCompatibility{}
+		return numeric.SnowflakeNumericCompatibility{}
 	case protos.DBType_BIGQUERY:
-		warehouseNumeric = numeric.BigQueryNumericCompatibility{}
+		return numeric.BigQueryNumericCompatibility{}
 	default:
-		warehouseNumeric = numeric.DefaultNumericCompatibility{}
+		return numeric.DefaultNumericCompatibility{}
 	}
+}
 
+func DetermineNumericSettingForDWH(precision int16, scale int16, dwh protos.DBType) (int16, int16) {
+	warehouseNumeric := numericCompatibilityForDWH(dwh)
 	if !warehouseNumeric.IsValidPrecisionAndScale(precision, scale) {
 		precision, scale = warehouseNumeric.DefaultPrecisionAndScale()
 	}
@@ -128,6 +128,82 @@ var QValueKindToClickhouseTypeMap = map[QValueKind]string{
 	QValueKindArrayInt16:   "Array(Int16)",
 }
 
+// Fabric Warehouse has no NVARCHAR, DATETIMEOFFSET or TINYINT and stores text as UTF-8 VARCHAR,
+// bytes are kept base64 encoded and arrays as JSON
+var QValueKindToFabricTypeMap = map[QValueKind]string{
+	QValueKindBoolean:     "BIT",
+	QValueKindInt16:       "SMALLINT",
+	QValueKindInt32:       "INT",
+	QValueKindInt64:       "BIGINT",
+	QValueKindFloat32:     "REAL",
+	QValueKindFloat64:     "FLOAT",
+	QValueKindNumeric:     "DECIMAL(38, 20)",
+	QValueKindQChar:       "CHAR(1)",
+	QValueKindString:      "VARCHAR(MAX)",
+	QValueKindJSON:        "VARCHAR(MAX)",
+	QValueKindTimestamp:   "DATETIME2(6)",
+	QValueKindTimestampTZ: "DATETIME2(6)",
+	QValueKindTime:        "TIME(6)",
+	QValueKindTimeTZ:      "TIME(6)",
+	QValueKindDate:        "DATE",
+	QValueKindBytes:       "VARCHAR(MAX)",
+	QValueKindUUID:        "UNIQUEIDENTIFIER",
+}
+
+// SingleStore follows MySQL types, arrays and hstore are kept as JSON
+var QValueKindToSingleStoreTypeMap = map[QValueKind]string{
+	QValueKindBoolean:     "BOOL",
+	QValueKindInt16:       "SMALLINT",
+	QValueKindInt32:       "INT",
+	QValueKindInt64:       "BIGINT",
+	QValueKindFloat32:     "FLOAT",
+	QValueKindFloat64:     "DOUBLE",
+	QValueKindNumeric:     "DECIMAL(38, 20)",
+	QValueKindQChar:       "CHAR(1)",
+	QValueKindString:      "LONGTEXT",
+	QValueKindJSON:        "JSON",
+	QValueKindHStore:      "JSON",
+	QValueKindTimestamp:   "DATETIME(6)",
+	QValueKindTimestampTZ: "DATETIME(6)",
+	QValueKindTime:        "TIME(6)",
+	QValueKindTimeTZ:      "TIME(6)",
+	QValueKindDate:        "DATE",
+	QValueKindBytes:       "LONGBLOB",
+	QValueKindUUID:        "CHAR(36)",
+}
+
+// StarRocks and Doris share their types, bytes are kept base64 encoded and arrays and hstore as JSON
+var QValueKindToStarRocksTypeMap = map[QValueKind]string{
+	QValueKindBoolean:     "BOOLEAN",
+	QValueKindInt16:       "SMALLINT",
+	QValueKindInt32:       "INT",
+	QValueKindInt64:       "BIGINT",
+	QValueKindFloat32:     "FLOAT",
+	QValueKindFloat64:     "DOUBLE",
+	QValueKindNumeric:     "DECIMAL(38, 20)",
+	QValueKindQChar:       "CHAR(1)",
+	QValueKindString:      "STRING",
+	QValueKindJSON:        "JSON",
+	QValueKindHStore:      "JSON",
+	QValueKindTimestamp:   "DATETIME",
+	QValueKindTimestampTZ: "DATETIME",
+	QValueKindDate:        "DATE",
+	QValueKindBytes:       "STRING",
+	QValueKindUUID:        "VARCHAR(36)",
+}
+
+// SQLite has type affinities rather than types, numerics are kept as text to keep their precision
+// and times as ISO 8601 text, which its date functions read
+var QValueKindToSQLiteTypeMap = map[QValueKind]string{
+	QValueKindBoolean: "INTEGER",
+	QValueKindInt16:   "INTEGER",
+	QValueKindInt32:   "INTEGER",
+	QValueKindInt64:   "INTEGER",
+	QValueKindFloat32: "REAL",
+	QValueKindFloat64: "REAL",
+	QValueKindBytes:   "BLOB",
+}
+
 func (kind QValueKind) ToDWHColumnType(dwhType protos.DBType) (string, error) {
 	switch dwhType {
 	case protos.DBType_SNOWFLAKE:
@@ -142,6 +218,34 @@ func (kind QValueKind) ToDWHColumnType(dwhType protos.DBType) (string, error) {
 		} else {
 			return "String", nil
 		}
+	case protos.DBType_FABRIC:
+		if val, ok := QValueKindToFabricTypeMap[kind]; ok {
+			return val, nil
+		} else {
+			return "VARCHAR(MAX)", nil
+		}
+	case protos.DBType_SINGLESTORE:
+		if val, ok := QValueKindToSingleStoreTypeMap[kind]; ok {
+			return val, nil
+		} else if kind.IsArray() {
+			return "JSON", nil
+		} else {
+			return "LONGTEXT", nil
+		}
+	case protos.DBType_STARROCKS:
+		if val, ok := QValueKindToStarRocksTypeMap[kind]; ok {
+			return val, nil
+		} else if kind.IsArray() {
+			return "JSON", nil
+		} else {
+			return "STRING", nil
+		}
+	case protos.DBType_SQLITE:
+		if val, ok := QValueKindToSQLiteTypeMap[kind]; ok {
+			return val, nil
+		} else {
+			return "TEXT", nil
+		}
 	default:
 		return "", fmt.Errorf("unknown dwh type: %v", dwhType)
 	}
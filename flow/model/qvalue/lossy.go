@@ -0,0 +1,82 @@
+package qvalue
+
+import (
+	"fmt"
+
+	numeric "github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// text types warehouses fall back to for kinds they have no type for
+var dwhTextTypes = map[protos.DBType]string{
+	protos.DBType_SNOWFLAKE:   "STRING",
+	protos.DBType_CLICKHOUSE:  "String",
+	protos.DBType_FABRIC:      "VARCHAR(MAX)",
+	protos.DBType_SINGLESTORE: "LONGTEXT",
+	protos.DBType_STARROCKS:   "STRING",
+}
+
+// kinds with a type of their own in BigQuery, following qValueKindToBigQueryType of the bigquery connector
+var bigQueryTypedKinds = map[QValueKind]struct{}{
+	QValueKindBoolean: {}, QValueKindInt16: {}, QValueKindInt32: {}, QValueKindInt64: {},
+	QValueKindFloat32: {}, QValueKindFloat64: {}, QValueKindNumeric: {}, QValueKindString: {},
+	QValueKindJSON: {}, QValueKindHStore: {}, QValueKindTimestamp: {}, QValueKindTimestampTZ: {},
+	QValueKindDate: {}, QValueKindTime: {}, QValueKindTimeTZ: {}, QValueKindBytes: {},
+	QValueKindGeography: {}, QValueKindGeometry: {}, QValueKindPoint: {},
+	QValueKindArrayInt16: {}, QValueKindArrayInt32: {}, QValueKindArrayInt64: {},
+	QValueKindArrayFloat32: {}, QValueKindArrayFloat64: {}, QValueKindArrayBoolean: {},
+	QValueKindArrayTimestamp: {}, QValueKindArrayTimestampTZ: {}, QValueKindArrayDate: {},
+	QValueKindArrayString: {},
+}
+
+// isTextual kinds are text already, or bytes which text types of warehouses hold verbatim or base64 encoded
+func (kind QValueKind) isTextual() bool {
+	switch kind {
+	case QValueKindString, QValueKindQChar, QValueKindJSON, QValueKindBytes:
+		return true
+	default:
+		return false
+	}
+}
+
+// LossyMapping returns why values of kind with typmod would lose information when mapped to the default type
+// of a dwhType destination, or "" when they would not. Destinations without a fixed mapping are never lossy
+func LossyMapping(kind QValueKind, typmod int32, dwhType protos.DBType) string {
+	var stringified bool
+	switch dwhType {
+	case protos.DBType_BIGQUERY:
+		_, typed := bigQueryTypedKinds[kind]
+		stringified = !typed
+	case protos.DBType_SNOWFLAKE, protos.DBType_CLICKHOUSE, protos.DBType_FABRIC, protos.DBType_SINGLESTORE,
+		protos.DBType_STARROCKS:
+		dwhColType, err := kind.ToDWHColumnType(dwhType)
+		if err != nil {
+			return err.Error()
+		}
+		stringified = dwhColType == dwhTextTypes[dwhType]
+	default:
+		return ""
+	}
+
+	switch {
+	case kind == QValueKindInvalid:
+		return "type is unknown to PeerDB and stored as text"
+	case stringified && !kind.isTextual():
+		return fmt.Sprintf("%s has no type in %s and is stored as text", kind, dwhType)
+	case kind == QValueKindTimeTZ:
+		return "time zone offset is dropped"
+	case kind == QValueKindNumeric:
+		warehouseNumeric := numericCompatibilityForDWH(dwhType)
+		if typmod == -1 {
+			defaultPrecision, defaultScale := warehouseNumeric.DefaultPrecisionAndScale()
+			return fmt.Sprintf("unconstrained numeric is stored as numeric(%d,%d)", defaultPrecision, defaultScale)
+		}
+		maxPrecision, maxScale := warehouseNumeric.MaxPrecision(), warehouseNumeric.MaxScale()
+		precision, scale := numeric.ParseNumericTypmod(typmod)
+		if !warehouseNumeric.IsValidPrecisionAndScale(precision, scale) || precision > maxPrecision || scale > maxScale {
+			return fmt.Sprintf("numeric(%d,%d) exceeds precision %d and scale %d of %s",
+				precision, scale, maxPrecision, maxScale, dwhType)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package qvalue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	numeric "github.com/PeerDB-io/peer-flow/datatypes"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestLossyMapping(t *testing.T) {
+	for _, dwhType := range []protos.DBType{
+		protos.DBType_SNOWFLAKE, protos.DBType_CLICKHOUSE, protos.DBType_BIGQUERY,
+		protos.DBType_FABRIC, protos.DBType_SINGLESTORE, protos.DBType_STARROCKS,
+	} {
+		for _, kind := range []QValueKind{QValueKindInt64, QValueKindString, QValueKindJSON, QValueKindTimestampTZ} {
+			require.Empty(t, LossyMapping(kind, -1, dwhType), "%s to %s", kind, dwhType)
+		}
+		require.Empty(t, LossyMapping(QValueKindNumeric, numeric.MakeNumericTypmod(18, 4), dwhType))
+		require.NotEmpty(t, LossyMapping(QValueKindNumeric, -1, dwhType))
+		require.NotEmpty(t, LossyMapping(QValueKindNumeric, numeric.MakeNumericTypmod(80, 4), dwhType))
+		require.NotEmpty(t, LossyMapping(QValueKindTimeTZ, -1, dwhType))
+		require.NotEmpty(t, LossyMapping(QValueKindInvalid, -1, dwhType))
+	}
+
+	require.Equal(t, "interval has no type in CLICKHOUSE and is stored as text",
+		LossyMapping(QValueKindInterval, -1, protos.DBType_CLICKHOUSE))
+	require.Empty(t, LossyMapping(QValueKindInterval, -1, protos.DBType_SNOWFLAKE))
+	require.NotEmpty(t, LossyMapping(QValueKindUUID, -1, protos.DBType_BIGQUERY))
+	require.Empty(t, LossyMapping(QValueKindUUID, -1, protos.DBType_CLICKHOUSE))
+	// clickhouse strings hold bytes verbatim
+	require.Empty(t, LossyMapping(QValueKindBytes, -1, protos.DBType_CLICKHOUSE))
+	require.Empty(t, LossyMapping(QValueKindNumeric, numeric.MakeNumericTypmod(60, 30), protos.DBType_CLICKHOUSE))
+	require.NotEmpty(t, LossyMapping(QValueKindNumeric, numeric.MakeNumericTypmod(30, 25), protos.DBType_BIGQUERY))
+	require.Empty(t, LossyMapping(QValueKindTimeTZ, -1, protos.DBType_KAFKA))
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
 )
 
 type Record[T Items] interface {
@@ -168,6 +169,8 @@ func (r *RelationRecord[T]) PopulateCountMap(mapOfCounts map[string]*RecordTypeC
 type MessageRecord[T Items] struct {
 	Prefix  string
 	Content string
+	// table or topic messages are routed to, empty when only scripts handle them
+	DestinationTableName string
 	BaseRecord
 }
 
@@ -176,7 +179,7 @@ func (*MessageRecord[T]) Kind() string {
 }
 
 func (r *MessageRecord[T]) GetDestinationTableName() string {
-	return ""
+	return r.DestinationTableName
 }
 
 func (r *MessageRecord[T]) GetSourceTableName() string {
@@ -189,4 +192,49 @@ func (r *MessageRecord[T]) GetItems() T {
 }
 
 func (r *MessageRecord[T]) PopulateCountMap(mapOfCounts map[string]*RecordTypeCounts) {
+	recordCount, ok := mapOfCounts[r.DestinationTableName]
+	if ok {
+		recordCount.InsertCount.Add(1)
+	}
+}
+
+// TableItems is the row a message is inserted as into its destination table, see MessageTableSchema
+func (r *MessageRecord[T]) TableItems() RecordItems {
+	items := NewRecordItems(4)
+	items.AddColumn("lsn", qvalue.QValueInt64{Val: r.CheckpointID})
+	items.AddColumn("prefix", qvalue.QValueString{Val: r.Prefix})
+	items.AddColumn("content", qvalue.QValueString{Val: r.Content})
+	if r.CommitTimeNano != 0 {
+		items.AddColumn("commit_time", qvalue.QValueTimestampTZ{Val: r.GetCommitTime()})
+	} else {
+		// non-transactional messages are not part of a commit
+		items.AddColumn("commit_time", qvalue.QValueNull(qvalue.QValueKindTimestampTZ))
+	}
+	return items
+}
+
+// MessageTableSchema is the schema of the destination table of logical decoding messages,
+// lsn is unique for every message so it serves as primary key
+func MessageTableSchema(tableName string, system protos.TypeSystem) *protos.TableSchema {
+	columnTypes := []string{
+		string(qvalue.QValueKindInt64), string(qvalue.QValueKindString),
+		string(qvalue.QValueKindString), string(qvalue.QValueKindTimestampTZ),
+	}
+	if system == protos.TypeSystem_PG {
+		columnTypes = []string{"int8", "text", "text", "timestamptz"}
+	}
+	columns := make([]*protos.FieldDescription, 0, len(columnTypes))
+	for idx, name := range []string{"lsn", "prefix", "content", "commit_time"} {
+		columns = append(columns, &protos.FieldDescription{
+			Name:         name,
+			Type:         columnTypes[idx],
+			TypeModifier: -1,
+		})
+	}
+	return &protos.TableSchema{
+		TableIdentifier:   tableName,
+		PrimaryKeyColumns: []string{"lsn"},
+		System:            system,
+		Columns:           columns,
+	}
 }
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestMessageRecordTableItems(t *testing.T) {
+	schema := MessageTableSchema("public.messages", protos.TypeSystem_Q)
+	record := &MessageRecord[RecordItems]{
+		Prefix:               "marker",
+		Content:              "end of day",
+		DestinationTableName: "public.messages",
+		BaseRecord:           BaseRecord{CheckpointID: 42},
+	}
+
+	items := record.TableItems()
+	require.Equal(t, len(schema.Columns), items.Len())
+	for _, column := range schema.Columns {
+		value := items.GetColumnValue(column.Name)
+		require.NotNil(t, value, column.Name)
+		require.Equal(t, column.Type, string(value.Kind()), column.Name)
+	}
+
+	json, err := ItemsToJSON(items)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"lsn":42,"prefix":"marker","content":"end of day","commit_time":null}`, json)
+}
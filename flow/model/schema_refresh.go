@@ -0,0 +1,85 @@
+package model
+
+import (
+	"log/slog"
+
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// SchemaRefreshTables returns source tables whose schema is fetched again after deltas of a batch.
+// Renamed source tables are only found by their new name, returned renamed maps it back to the name mappings use
+func SchemaRefreshTables(deltas []*protos.TableSchemaDelta) ([]string, map[string]string) {
+	tables := make([]string, 0, len(deltas))
+	renamed := make(map[string]string)
+	for _, delta := range deltas {
+		if delta.RenamedTo == "" {
+			tables = append(tables, delta.SrcTableName)
+		} else if len(delta.AddedColumns) > 0 || len(delta.WidenedColumns) > 0 {
+			tables = append(tables, delta.RenamedTo)
+			renamed[delta.RenamedTo] = delta.SrcTableName
+		}
+	}
+	return tables, renamed
+}
+
+// RefreshedSchemaMapping maps schemas fetched for SchemaRefreshTables to the destination tables of tableMappings,
+// with the source identifier column when mirrors tag rows with one
+func RefreshedSchemaMapping(
+	tableMappings []*protos.TableMapping,
+	schemas map[string]*protos.TableSchema,
+	renamed map[string]string,
+	sourceIdentifier string,
+	logger log.Logger,
+) map[string]*protos.TableSchema {
+	for renamedTo, srcTableName := range renamed {
+		if tableSchema, ok := schemas[renamedTo]; ok {
+			schemas[srcTableName] = tableSchema
+			delete(schemas, renamedTo)
+		}
+	}
+	processedSchemaMapping := shared.BuildProcessedSchemaMapping(tableMappings, schemas, logger)
+	if sourceIdentifier != "" {
+		for dstTableName, tableSchema := range processedSchemaMapping {
+			processedSchemaMapping[dstTableName] = WithSourceIdentifierColumn(tableSchema)
+		}
+	}
+	return processedSchemaMapping
+}
+
+// FollowRenamedTables points table mappings of options at the new names in renamedTables of renamed source tables,
+// moving schemas of destination tables renamed along with them in renamedDstTables to their new names
+func FollowRenamedTables(
+	options *protos.SyncFlowOptions,
+	renamedTables map[string]string,
+	renamedDstTables map[string]string,
+	logger log.Logger,
+) {
+	for _, tableMapping := range options.TableMappings {
+		renamedTo, ok := renamedTables[tableMapping.SourceTableIdentifier]
+		if !ok {
+			continue
+		}
+		logger.Info("following renamed source table",
+			slog.String("table", tableMapping.SourceTableIdentifier), slog.String("renamedTo", renamedTo))
+		if dstTableName, ok := renamedDstTables[tableMapping.DestinationTableIdentifier]; ok {
+			if tableSchema, ok := options.TableNameSchemaMapping[tableMapping.DestinationTableIdentifier]; ok {
+				options.TableNameSchemaMapping[dstTableName] = tableSchema
+				delete(options.TableNameSchemaMapping, tableMapping.DestinationTableIdentifier)
+			}
+			tableMapping.DestinationTableIdentifier = dstTableName
+		}
+		if tableSchema, ok := options.TableNameSchemaMapping[tableMapping.DestinationTableIdentifier]; ok &&
+			tableSchema.TableIdentifier == tableMapping.SourceTableIdentifier {
+			tableSchema.TableIdentifier = renamedTo
+		}
+		tableMapping.SourceTableIdentifier = renamedTo
+	}
+	for relID, srcTableName := range options.SrcTableIdNameMapping {
+		if renamedTo, ok := renamedTables[srcTableName]; ok {
+			options.SrcTableIdNameMapping[relID] = renamedTo
+		}
+	}
+}
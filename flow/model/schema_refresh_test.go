@@ -0,0 +1,63 @@
+package model
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestSchemaRefreshTables(t *testing.T) {
+	tables, renamed := SchemaRefreshTables([]*protos.TableSchemaDelta{
+		{SrcTableName: "public.users", AddedColumns: []*protos.FieldDescription{{Name: "email"}}},
+		{SrcTableName: "public.orders", RenamedTo: "public.purchases", AddedColumns: []*protos.FieldDescription{{Name: "total"}}},
+		// renames alone keep the schema known by the old name
+		{SrcTableName: "public.items", RenamedTo: "public.products"},
+	})
+	require.Equal(t, []string{"public.users", "public.purchases"}, tables)
+	require.Equal(t, map[string]string{"public.purchases": "public.orders"}, renamed)
+}
+
+func TestRefreshedSchemaMapping(t *testing.T) {
+	tableMappings := []*protos.TableMapping{
+		{SourceTableIdentifier: "public.users", DestinationTableIdentifier: "users"},
+		{SourceTableIdentifier: "public.orders", DestinationTableIdentifier: "orders"},
+	}
+	schemas := map[string]*protos.TableSchema{
+		"public.users":     {TableIdentifier: "public.users", PrimaryKeyColumns: []string{"id"}},
+		"public.purchases": {TableIdentifier: "public.purchases", PrimaryKeyColumns: []string{"id"}},
+	}
+	refreshed := RefreshedSchemaMapping(tableMappings, schemas, map[string]string{"public.purchases": "public.orders"},
+		"shard_1", log.NewStructuredLogger(slog.Default()))
+	require.Len(t, refreshed, 2)
+	require.Equal(t, "public.purchases", refreshed["orders"].TableIdentifier)
+	require.Equal(t, []string{"id", SourceIdentifierColName}, refreshed["users"].PrimaryKeyColumns)
+}
+
+func TestFollowRenamedTables(t *testing.T) {
+	options := &protos.SyncFlowOptions{
+		TableMappings: []*protos.TableMapping{
+			{SourceTableIdentifier: "public.users", DestinationTableIdentifier: "users"},
+			{SourceTableIdentifier: "public.orders", DestinationTableIdentifier: "orders"},
+		},
+		TableNameSchemaMapping: map[string]*protos.TableSchema{
+			"users":  {TableIdentifier: "public.users"},
+			"orders": {TableIdentifier: "public.orders"},
+		},
+		SrcTableIdNameMapping: map[uint32]string{1: "public.users", 2: "public.orders"},
+	}
+	FollowRenamedTables(options, map[string]string{"public.users": "public.members", "public.orders": "public.purchases"},
+		map[string]string{"orders": "purchases"}, log.NewStructuredLogger(slog.Default()))
+	require.Equal(t, "public.members", options.TableMappings[0].SourceTableIdentifier)
+	require.Equal(t, "users", options.TableMappings[0].DestinationTableIdentifier)
+	require.Equal(t, "public.purchases", options.TableMappings[1].SourceTableIdentifier)
+	require.Equal(t, "purchases", options.TableMappings[1].DestinationTableIdentifier)
+	require.Equal(t, map[string]*protos.TableSchema{
+		"users":     {TableIdentifier: "public.members"},
+		"purchases": {TableIdentifier: "public.purchases"},
+	}, options.TableNameSchemaMapping)
+	require.Equal(t, map[uint32]string{1: "public.members", 2: "public.purchases"}, options.SrcTableIdNameMapping)
+}
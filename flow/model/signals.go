@@ -150,3 +150,12 @@ var NormalizeSignal = TypedSignal[NormalizePayload]{
 var NormalizeDoneSignal = TypedSignal[struct{}]{
 	Name: "normalize-done",
 }
+
+// continues a workflow as new, so it picks up the workflow definition of the running workers
+var MigrateWorkflowSignal = TypedSignal[struct{}]{
+	Name: "migrate-workflow",
+}
+
+var MissingTablesSignal = TypedSignal[[]string]{
+	Name: "missing-tables",
+}
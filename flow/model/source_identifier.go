@@ -0,0 +1,39 @@
+package model
+
+import (
+	"slices"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model/qvalue"
+)
+
+// SourceIdentifierColName holds protos.FlowConnectionConfigs.SourceIdentifier
+// for mirrors sharing destination tables with mirrors of other sources
+const SourceIdentifierColName = "_peerdb_source"
+
+// WithSourceIdentifierColumn returns schema with the source identifier column added to columns and primary key,
+// so rows with equal primary keys from different sources don't collide
+func WithSourceIdentifierColumn(schema *protos.TableSchema) *protos.TableSchema {
+	if slices.ContainsFunc(schema.Columns, func(column *protos.FieldDescription) bool {
+		return column.Name == SourceIdentifierColName
+	}) {
+		return schema
+	}
+
+	columnType := string(qvalue.QValueKindString)
+	if schema.System == protos.TypeSystem_PG {
+		columnType = "text"
+	}
+	return &protos.TableSchema{
+		TableIdentifier:       schema.TableIdentifier,
+		PrimaryKeyColumns:     append(slices.Clone(schema.PrimaryKeyColumns), SourceIdentifierColName),
+		IsReplicaIdentityFull: schema.IsReplicaIdentityFull,
+		NullableEnabled:       schema.NullableEnabled,
+		System:                schema.System,
+		Columns: append(slices.Clone(schema.Columns), &protos.FieldDescription{
+			Name:         SourceIdentifierColName,
+			Type:         columnType,
+			TypeModifier: -1,
+		}),
+	}
+}
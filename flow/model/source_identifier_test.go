@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestWithSourceIdentifierColumn(t *testing.T) {
+	schema := &protos.TableSchema{
+		TableIdentifier:   "public.t1",
+		PrimaryKeyColumns: []string{"id"},
+		System:            protos.TypeSystem_PG,
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: "int8", TypeModifier: -1},
+			{Name: "name", Type: "text", TypeModifier: -1},
+		},
+	}
+
+	withSource := WithSourceIdentifierColumn(schema)
+	require.Equal(t, []string{"id", SourceIdentifierColName}, withSource.PrimaryKeyColumns)
+	require.Len(t, withSource.Columns, 3)
+	require.Equal(t, "text", withSource.Columns[2].Type)
+	// original schema is left as is
+	require.Equal(t, []string{"id"}, schema.PrimaryKeyColumns)
+	require.Len(t, schema.Columns, 2)
+
+	require.Same(t, withSource, WithSourceIdentifierColumn(withSource))
+}
@@ -0,0 +1,385 @@
+// Package operator reconciles Peer and Mirror custom resources of Kubernetes against the flow API,
+// so peers and CDC mirrors can be managed declaratively next to other manifests.
+// Resources are listed every resync interval rather than watched, peers are created or updated as their spec changes
+// while mirrors are created once, later changes of their spec other than paused need the resource to be recreated.
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+type Controller struct {
+	kube *kubeClient
+	flow protos.FlowServiceClient
+	// of the current pass, fetched for all mirrors at once rather than polled for each
+	statuses map[string]*protos.MirrorStatusResponse
+	// of source peers by name for the current pass, shared by their mirrors
+	slots map[string]*protos.PeerSlotResponse
+	// slots retaining more WAL than this mark their mirrors as lagging, 0 to only go by freshness SLAs
+	LagThresholdMB float64
+	ResyncInterval time.Duration
+}
+
+func NewController(namespace string, flow protos.FlowServiceClient, resyncInterval time.Duration, lagThresholdMB float64,
+) (*Controller, error) {
+	kube, err := newInClusterClient(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &Controller{
+		kube:           kube,
+		flow:           flow,
+		LagThresholdMB: lagThresholdMB,
+		ResyncInterval: resyncInterval,
+	}, nil
+}
+
+// Run reconciles resources every resync interval until ctx is done
+func (c *Controller) Run(ctx context.Context) error {
+	slog.Info("reconciling PeerDB resources", slog.String("namespace", c.kube.namespace))
+	ticker := time.NewTicker(c.ResyncInterval)
+	defer ticker.Stop()
+	for {
+		c.reconcileAll(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcileAll reconciles peers before mirrors, so mirrors of new peers are created in the same pass
+func (c *Controller) reconcileAll(ctx context.Context) {
+	for _, kind := range []struct {
+		prefetch  func(context.Context, []*resource)
+		reconcile func(context.Context, *resource) error
+		plural    string
+	}{
+		{plural: peersPlural, reconcile: c.reconcilePeer},
+		{plural: mirrorsPlural, prefetch: c.prefetchMirrorStatuses, reconcile: c.reconcileMirror},
+	} {
+		resources, err := c.kube.list(ctx, kind.plural)
+		if err != nil {
+			slog.Error("failed to list resources", slog.String("resource", kind.plural), slog.Any("error", err))
+			continue
+		}
+		if kind.prefetch != nil {
+			kind.prefetch(ctx, resources)
+		}
+		for _, res := range resources {
+			if err := kind.reconcile(ctx, res); err != nil {
+				slog.Error("failed to reconcile resource", slog.String("resource", kind.plural),
+					slog.String("name", res.Metadata.Name), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// prefetchMirrorStatuses fetches statuses of created mirrors in batches, mirrors missing from them,
+// like those of flow APIs without MirrorStatuses, being asked for on their own
+func (c *Controller) prefetchMirrorStatuses(ctx context.Context, resources []*resource) {
+	c.statuses = make(map[string]*protos.MirrorStatusResponse, len(resources))
+	c.slots = make(map[string]*protos.PeerSlotResponse)
+	flowJobNames := make([]string, 0, len(resources))
+	for _, res := range resources {
+		if res.Status == nil || res.Status.WorkflowID == "" || res.Metadata.DeletionTimestamp != nil {
+			continue
+		}
+		if config, _, err := mirrorFromSpec(res); err == nil {
+			flowJobNames = append(flowJobNames, config.FlowJobName)
+		}
+	}
+	for batch := range slices.Chunk(flowJobNames, statusBatchSize) {
+		resp, err := c.flow.MirrorStatuses(ctx, &protos.MirrorStatusesRequest{
+			FlowJobNames:    batch,
+			IncludeFlowInfo: true,
+		})
+		if err != nil {
+			slog.Warn("failed to get statuses of mirrors, getting them one by one", slog.Any("error", err))
+			return
+		}
+		for _, mirrorStatus := range resp.Statuses {
+			c.statuses[mirrorStatus.FlowJobName] = mirrorStatus
+		}
+	}
+}
+
+func (c *Controller) mirrorStatus(ctx context.Context, flowJobName string) (*protos.MirrorStatusResponse, error) {
+	if mirrorStatus, ok := c.statuses[flowJobName]; ok {
+		return mirrorStatus, nil
+	}
+	return c.flow.MirrorStatus(ctx, &protos.MirrorStatusRequest{
+		FlowJobName:     flowJobName,
+		IncludeFlowInfo: true,
+	})
+}
+
+func (c *Controller) slotInfo(ctx context.Context, peerName string) (*protos.PeerSlotResponse, error) {
+	if slots, ok := c.slots[peerName]; ok {
+		return slots, nil
+	}
+	slots, err := c.flow.GetSlotInfo(ctx, &protos.PostgresPeerActivityInfoRequest{PeerName: peerName})
+	if err != nil {
+		return nil, err
+	}
+	if c.slots != nil {
+		c.slots[peerName] = slots
+	}
+	return slots, nil
+}
+
+// ensureFinalizer adds the finalizer, returning true once the resource is being deleted and needs cleaning up
+func (c *Controller) ensureFinalizer(ctx context.Context, plural string, res *resource) (bool, error) {
+	hasFinalizer := slices.Contains(res.Metadata.Finalizers, finalizer)
+	if res.Metadata.DeletionTimestamp != nil {
+		return hasFinalizer, nil
+	}
+	if !hasFinalizer {
+		if err := c.kube.patchFinalizers(ctx, plural, res, append(slices.Clone(res.Metadata.Finalizers), finalizer)); err != nil {
+			return false, fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+	return false, nil
+}
+
+func (c *Controller) removeFinalizer(ctx context.Context, plural string, res *resource) error {
+	return c.kube.patchFinalizers(ctx, plural, res, slices.DeleteFunc(slices.Clone(res.Metadata.Finalizers),
+		func(f string) bool { return f == finalizer }))
+}
+
+// lookupSecret resolves references like ${secret:pg-credentials/password} from Secrets in the namespace of the operator
+func (c *Controller) lookupSecret(ctx context.Context) func(string) (string, error) {
+	return func(name string) (string, error) {
+		secret, key, ok := strings.Cut(name, "/")
+		if !ok {
+			return "", fmt.Errorf("invalid secret reference %s, it should be <secret>/<key>", name)
+		}
+		return c.kube.secretValue(ctx, secret, key)
+	}
+}
+
+func (c *Controller) reconcilePeer(ctx context.Context, res *resource) error {
+	name := catalogName(res.Metadata.Name)
+	deleting, err := c.ensureFinalizer(ctx, peersPlural, res)
+	if err != nil {
+		return err
+	}
+	if deleting {
+		resp, err := c.flow.DropPeer(ctx, &protos.DropPeerRequest{PeerName: name})
+		if err != nil {
+			return fmt.Errorf("failed to drop peer %s: %w", name, err)
+		}
+		if !resp.Ok {
+			// like peers still used by mirrors, retried until those are dropped
+			return fmt.Errorf("failed to drop peer %s: %s", name, resp.ErrorMessage)
+		}
+		return c.removeFinalizer(ctx, peersPlural, res)
+	} else if res.Metadata.DeletionTimestamp != nil {
+		return nil
+	}
+
+	st := res.Status
+	if st == nil {
+		st = &status{}
+	}
+	if ready := st.condition(conditionReady); ready != nil && ready.Status == "True" &&
+		st.ObservedGeneration == res.Metadata.Generation {
+		return nil
+	}
+
+	generation := res.Metadata.Generation
+	now := time.Now().UTC().Truncate(time.Second)
+	if peer, err := peerFromSpec(res); err != nil {
+		st.setCondition(generation, conditionReady, "False", "InvalidSpec", err.Error(), now)
+	} else if err := shared.ResolveSecretReferences(peer.ProtoReflect(), c.lookupSecret(ctx)); err != nil {
+		st.setCondition(generation, conditionReady, "False", "SecretNotFound", err.Error(), now)
+	} else if resp, err := c.flow.CreatePeer(ctx, &protos.CreatePeerRequest{Peer: peer, AllowUpdate: true}); err != nil {
+		st.setCondition(generation, conditionReady, "False", "CreateFailed", err.Error(), now)
+	} else if resp.Status != protos.CreatePeerStatus_CREATED {
+		st.setCondition(generation, conditionReady, "False", "CreateFailed", resp.Message, now)
+	} else {
+		st.setCondition(generation, conditionReady, "True", "Created", "peer "+name+" is created", now)
+	}
+	st.ObservedGeneration = generation
+	res.Status = st
+	return c.kube.patchStatus(ctx, peersPlural, res)
+}
+
+func (c *Controller) reconcileMirror(ctx context.Context, res *resource) error {
+	config, spec, specErr := mirrorFromSpec(res)
+	deleting, err := c.ensureFinalizer(ctx, mirrorsPlural, res)
+	if err != nil {
+		return err
+	}
+	st := res.Status
+	if st == nil {
+		st = &status{}
+	}
+	if deleting {
+		if st.WorkflowID != "" && specErr == nil {
+			resp, err := c.flow.FlowStateChange(ctx, &protos.FlowStateChangeRequest{
+				FlowJobName:        config.FlowJobName,
+				RequestedFlowState: protos.FlowStatus_STATUS_TERMINATED,
+				DropMirrorStats:    true,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to drop mirror %s: %w", config.FlowJobName, err)
+			}
+			if !resp.Ok {
+				return fmt.Errorf("failed to drop mirror %s: %s", config.FlowJobName, resp.ErrorMessage)
+			}
+		}
+		return c.removeFinalizer(ctx, mirrorsPlural, res)
+	} else if res.Metadata.DeletionTimestamp != nil {
+		return nil
+	}
+
+	generation := res.Metadata.Generation
+	now := time.Now().UTC().Truncate(time.Second)
+	st.ObservedGeneration = generation
+	res.Status = st
+	if specErr != nil {
+		st.setCondition(generation, conditionReady, "False", "InvalidSpec", specErr.Error(), now)
+		return c.kube.patchStatus(ctx, mirrorsPlural, res)
+	}
+
+	if st.WorkflowID == "" {
+		resp, err := c.flow.CreateCDCFlow(ctx, &protos.CreateCDCFlowRequest{ConnectionConfigs: config})
+		if err != nil {
+			st.setCondition(generation, conditionReady, "False", "CreateFailed", err.Error(), now)
+			return c.kube.patchStatus(ctx, mirrorsPlural, res)
+		}
+		st.WorkflowID = resp.WorkflowId
+	}
+
+	mirrorStatus, err := c.mirrorStatus(ctx, config.FlowJobName)
+	if err != nil {
+		st.setCondition(generation, conditionReady, "Unknown", "StatusUnavailable", err.Error(), now)
+		return c.kube.patchStatus(ctx, mirrorsPlural, res)
+	} else if !mirrorStatus.Ok {
+		st.setCondition(generation, conditionReady, "Unknown", "StatusUnavailable", mirrorStatus.ErrorMessage, now)
+		return c.kube.patchStatus(ctx, mirrorsPlural, res)
+	}
+
+	state := mirrorStatus.CurrentFlowState
+	if requested, ok := requestedState(spec.Paused, state); ok {
+		if err := c.changeState(ctx, config.FlowJobName, requested); err != nil {
+			st.setCondition(generation, conditionReady, "False", "StateChangeFailed", err.Error(), now)
+			return c.kube.patchStatus(ctx, mirrorsPlural, res)
+		}
+	}
+	st.State = state.String()
+	st.setCondition(generation, conditionReady, conditionStatus(state == protos.FlowStatus_STATUS_RUNNING),
+		stateReason(state), "mirror "+config.FlowJobName+" is "+strings.ToLower(stateReason(state)), now)
+
+	cdcStatus := mirrorStatus.GetCdcStatus()
+	st.Snapshot = snapshotProgressOf(cdcStatus.GetSnapshotStatus(), len(config.TableMappings))
+	switch {
+	case !config.DoInitialSnapshot:
+		st.setCondition(generation, conditionSnapshotted, "True", "NoSnapshot", "mirror has no initial snapshot", now)
+	case state == protos.FlowStatus_STATUS_SETUP || state == protos.FlowStatus_STATUS_SNAPSHOT:
+		st.setCondition(generation, conditionSnapshotted, "False", "InProgress", fmt.Sprintf("%d of %d tables, %d rows",
+			st.Snapshot.TablesCompleted, st.Snapshot.TablesTotal, st.Snapshot.RowsSynced), now)
+	default:
+		st.setCondition(generation, conditionSnapshotted, "True", "Completed", "initial snapshot completed", now)
+	}
+
+	c.setLag(ctx, config, cdcStatus, st, now)
+	return c.kube.patchStatus(ctx, mirrorsPlural, res)
+}
+
+// requestedState is the state to move a mirror to for spec to hold
+func requestedState(paused bool, state protos.FlowStatus) (protos.FlowStatus, bool) {
+	if paused && state == protos.FlowStatus_STATUS_RUNNING {
+		return protos.FlowStatus_STATUS_PAUSED, true
+	} else if !paused && state == protos.FlowStatus_STATUS_PAUSED {
+		return protos.FlowStatus_STATUS_RUNNING, true
+	}
+	return protos.FlowStatus_STATUS_UNKNOWN, false
+}
+
+func (c *Controller) changeState(ctx context.Context, flowJobName string, requested protos.FlowStatus) error {
+	resp, err := c.flow.FlowStateChange(ctx, &protos.FlowStateChangeRequest{
+		FlowJobName:        flowJobName,
+		RequestedFlowState: requested,
+	})
+	if err != nil {
+		return err
+	} else if !resp.Ok {
+		return errors.New(resp.ErrorMessage)
+	}
+	return nil
+}
+
+// stateReason turns STATUS_RUNNING into Running for condition reasons
+func stateReason(state protos.FlowStatus) string {
+	name := strings.ToLower(strings.TrimPrefix(state.String(), "STATUS_"))
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func snapshotProgressOf(snapshot *protos.SnapshotStatus, numTables int) *snapshotProgress {
+	progress := &snapshotProgress{TablesTotal: int32(max(numTables, len(snapshot.GetClones())))}
+	for _, clone := range snapshot.GetClones() {
+		progress.RowsSynced += clone.NumRowsSynced
+		if clone.ConsolidateCompleted ||
+			(clone.NumPartitionsTotal > 0 && clone.NumPartitionsCompleted >= clone.NumPartitionsTotal) {
+			progress.TablesCompleted += 1
+		}
+	}
+	if eta := snapshot.GetEstimatedCompletionTime(); eta != nil {
+		t := eta.AsTime()
+		progress.EstimatedCompletionTime = &t
+	}
+	return progress
+}
+
+// setLag reports WAL retained by the slot of Postgres sources and staleness of mirrors with freshness SLAs
+func (c *Controller) setLag(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	cdcStatus *protos.CDCMirrorStatus,
+	st *status,
+	now time.Time,
+) {
+	slotName := config.ReplicationSlotName
+	if slotName == "" {
+		slotName = "peerflow_slot_" + config.FlowJobName
+	}
+	st.LagMB = 0
+	if cdcStatus.GetSourceType() == protos.DBType_POSTGRES {
+		if slots, err := c.slotInfo(ctx, config.SourceName); err != nil {
+			slog.Warn("failed to get slot info of mirror", slog.String(string(shared.FlowNameKey), config.FlowJobName),
+				slog.Any("error", err))
+		} else {
+			for _, slot := range slots.SlotData {
+				if slot.SlotName == slotName {
+					st.LagMB = float64(slot.LagInMb)
+				}
+			}
+		}
+	}
+
+	freshness := cdcStatus.GetFreshnessSla()
+	st.StalenessSeconds = freshness.GetStalenessSeconds()
+	switch {
+	case freshness.GetBreached():
+		st.setCondition(st.ObservedGeneration, conditionLagging, "True", "FreshnessSLABreached",
+			fmt.Sprintf("destination tables are %ds behind the source, over the SLA of %ds",
+				freshness.StalenessSeconds, freshness.SlaSeconds), now)
+	case c.LagThresholdMB > 0 && st.LagMB >= c.LagThresholdMB:
+		st.setCondition(st.ObservedGeneration, conditionLagging, "True", "SlotLag",
+			fmt.Sprintf("slot %s retains %.0fMB of WAL, over the threshold of %.0fMB", slotName, st.LagMB, c.LagThresholdMB), now)
+	default:
+		st.setCondition(st.ObservedGeneration, conditionLagging, "False", "CaughtUp", "mirror is keeping up with the source", now)
+	}
+}
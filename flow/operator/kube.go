@@ -0,0 +1,155 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubeClient talks to the Kubernetes API with the service account of the pod,
+// only for the few requests the operator needs instead of pulling in client-go
+type kubeClient struct {
+	http      *http.Client
+	baseURL   string
+	token     string
+	namespace string
+}
+
+func newInClusterClient(namespace string) (*kubeClient, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a Kubernetes cluster, KUBERNETES_SERVICE_HOST is unset")
+	}
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	caCert, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("invalid service account CA")
+	}
+	if namespace == "" {
+		ns, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read namespace of service account: %w", err)
+		}
+		namespace = strings.TrimSpace(string(ns))
+	}
+	return &kubeClient{
+		http: &http.Client{
+			Timeout: time.Minute,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: certPool, MinVersion: tls.VersionTLS12},
+			},
+		},
+		baseURL:   "https://" + net.JoinHostPort(host, port),
+		token:     strings.TrimSpace(string(token)),
+		namespace: namespace,
+	}, nil
+}
+
+func (k *kubeClient) do(ctx context.Context, method string, path string, contentType string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, k.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.token)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := k.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s failed with %s: %s", method, path, resp.Status, data)
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+func (k *kubeClient) resourcePath(plural string, name string) string {
+	path := fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s", group, version, k.namespace, plural)
+	if name != "" {
+		path += "/" + name
+	}
+	return path
+}
+
+func (k *kubeClient) list(ctx context.Context, plural string) ([]*resource, error) {
+	var list struct {
+		Items []*resource `json:"items"`
+	}
+	if err := k.do(ctx, http.MethodGet, k.resourcePath(plural, ""), "", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// patchStatus replaces status of a resource through its status subresource
+func (k *kubeClient) patchStatus(ctx context.Context, plural string, res *resource) error {
+	return k.do(ctx, http.MethodPatch, k.resourcePath(plural, res.Metadata.Name)+"/status",
+		"application/merge-patch+json", map[string]any{"status": res.Status}, nil)
+}
+
+// patchFinalizers replaces finalizers of a resource, failing if it changed since it was listed
+func (k *kubeClient) patchFinalizers(ctx context.Context, plural string, res *resource, finalizers []string) error {
+	if finalizers == nil {
+		finalizers = []string{}
+	}
+	return k.do(ctx, http.MethodPatch, k.resourcePath(plural, res.Metadata.Name),
+		"application/merge-patch+json", map[string]any{
+			"metadata": map[string]any{
+				"finalizers":      finalizers,
+				"resourceVersion": res.Metadata.ResourceVersion,
+			},
+		}, nil)
+}
+
+// secretValue reads a key of a Secret in the namespace of the operator
+func (k *kubeClient) secretValue(ctx context.Context, name string, key string) (string, error) {
+	var secret struct {
+		Data map[string][]byte `json:"data"`
+	}
+	if err := k.do(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", k.namespace, name), "", nil, &secret); err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", name, key)
+	}
+	return string(value), nil
+}
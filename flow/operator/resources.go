@@ -0,0 +1,162 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const (
+	group   = "peerdb.io"
+	version = "v1alpha1"
+
+	peersPlural   = "peers"
+	mirrorsPlural = "mirrors"
+
+	// keeps resources around until their peer or mirror is dropped from PeerDB
+	finalizer = "peerdb.io/finalizer"
+
+	// mirrors per MirrorStatuses request
+	statusBatchSize = 500
+)
+
+const (
+	conditionReady       = "Ready"
+	conditionSnapshotted = "Snapshotted"
+	conditionLagging     = "Lagging"
+)
+
+type metadata struct {
+	DeletionTimestamp *time.Time `json:"deletionTimestamp,omitempty"`
+	Name              string     `json:"name"`
+	Namespace         string     `json:"namespace,omitempty"`
+	ResourceVersion   string     `json:"resourceVersion,omitempty"`
+	Finalizers        []string   `json:"finalizers,omitempty"`
+	Generation        int64      `json:"generation,omitempty"`
+}
+
+type condition struct {
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	Type               string    `json:"type"`
+	// True, False or Unknown
+	Status             string `json:"status"`
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	ObservedGeneration int64  `json:"observedGeneration"`
+}
+
+type snapshotProgress struct {
+	TablesCompleted int32 `json:"tablesCompleted"`
+	TablesTotal     int32 `json:"tablesTotal"`
+	RowsSynced      int64 `json:"rowsSynced"`
+	// of tables whose snapshot has started, unset when unknown
+	EstimatedCompletionTime *time.Time `json:"estimatedCompletionTime,omitempty"`
+}
+
+type status struct {
+	Snapshot *snapshotProgress `json:"snapshot,omitempty"`
+	// set once the mirror was created, mirrors without one are created on reconcile
+	WorkflowID string `json:"workflowID,omitempty"`
+	// of the CDC flow, like STATUS_RUNNING
+	State string `json:"state,omitempty"`
+	// WAL retained by the replication slot of the mirror
+	LagMB float64 `json:"lagMB,omitempty"`
+	// of destination tables behind the source, only for mirrors with a freshness SLA
+	StalenessSeconds   int64       `json:"stalenessSeconds,omitempty"`
+	Conditions         []condition `json:"conditions,omitempty"`
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+}
+
+type resource struct {
+	Status   *status         `json:"status,omitempty"`
+	Metadata metadata        `json:"metadata"`
+	Spec     json.RawMessage `json:"spec"`
+}
+
+// mirrorSpec is a CDC mirror as protojson of FlowConnectionConfigs, with paused to pause and resume it
+type mirrorSpec struct {
+	Paused bool `json:"paused"`
+}
+
+// peerFromSpec decodes spec of a Peer resource, protojson of a peer without name, like
+// {"type": "POSTGRES", "postgresConfig": {"host": "pg", "password": "${secret:pg-credentials/password}"}}
+func peerFromSpec(res *resource) (*protos.Peer, error) {
+	var peer protos.Peer
+	if err := protojson.Unmarshal(res.Spec, &peer); err != nil {
+		return nil, fmt.Errorf("invalid spec of peer %s: %w", res.Metadata.Name, err)
+	}
+	peer.Name = catalogName(res.Metadata.Name)
+	return &peer, nil
+}
+
+// mirrorFromSpec decodes spec of a Mirror resource, protojson of FlowConnectionConfigs,
+// the mirror being named after the resource when flowJobName is unset
+func mirrorFromSpec(res *resource) (*protos.FlowConnectionConfigs, *mirrorSpec, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(res.Spec, &fields); err != nil {
+		return nil, nil, fmt.Errorf("invalid spec of mirror %s: %w", res.Metadata.Name, err)
+	}
+	var spec mirrorSpec
+	if paused, ok := fields["paused"]; ok {
+		if err := json.Unmarshal(paused, &spec.Paused); err != nil {
+			return nil, nil, fmt.Errorf("invalid paused of mirror %s: %w", res.Metadata.Name, err)
+		}
+		delete(fields, "paused")
+	}
+	configJSON, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	var config protos.FlowConnectionConfigs
+	if err := protojson.Unmarshal(configJSON, &config); err != nil {
+		return nil, nil, fmt.Errorf("invalid spec of mirror %s: %w", res.Metadata.Name, err)
+	}
+	if config.FlowJobName == "" {
+		config.FlowJobName = catalogName(res.Metadata.Name)
+	}
+	return &config, &spec, nil
+}
+
+// catalogName maps names of Kubernetes resources to names valid for peers and mirrors, my-peer becoming my_peer
+func catalogName(name string) string {
+	return strings.NewReplacer("-", "_", ".", "_").Replace(name)
+}
+
+func (s *status) condition(conditionType string) *condition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// setCondition adds or updates a condition, keeping its transition time while its status stays the same
+func (s *status) setCondition(generation int64, conditionType string, value string, reason string, message string,
+	now time.Time,
+) {
+	cond := s.condition(conditionType)
+	if cond == nil {
+		s.Conditions = append(s.Conditions, condition{Type: conditionType})
+		cond = &s.Conditions[len(s.Conditions)-1]
+	}
+	if cond.Status != value || cond.LastTransitionTime.IsZero() {
+		cond.LastTransitionTime = now
+	}
+	cond.Status = value
+	cond.Reason = reason
+	cond.Message = message
+	cond.ObservedGeneration = generation
+}
+
+func conditionStatus(ok bool) string {
+	if ok {
+		return "True"
+	}
+	return "False"
+}
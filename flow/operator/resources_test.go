@@ -0,0 +1,62 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestPeerFromSpec(t *testing.T) {
+	peer, err := peerFromSpec(&resource{
+		Metadata: metadata{Name: "prod-pg"},
+		Spec:     []byte(`{"type": "POSTGRES", "postgresConfig": {"host": "pg", "password": "${secret:pg/password}"}}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "prod_pg", peer.Name)
+	require.Equal(t, protos.DBType_POSTGRES, peer.Type)
+	require.Equal(t, "${secret:pg/password}", peer.GetPostgresConfig().Password)
+
+	_, err = peerFromSpec(&resource{Metadata: metadata{Name: "bad"}, Spec: []byte(`{"unknownField": 1}`)})
+	require.Error(t, err)
+}
+
+func TestMirrorFromSpec(t *testing.T) {
+	config, spec, err := mirrorFromSpec(&resource{
+		Metadata: metadata{Name: "orders-mirror"},
+		Spec: []byte(`{"sourceName": "prod_pg", "destinationName": "ch", "paused": true,
+			"tableMappings": [{"sourceTableIdentifier": "public.orders", "destinationTableIdentifier": "orders"}]}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "orders_mirror", config.FlowJobName)
+	require.Equal(t, "prod_pg", config.SourceName)
+	require.Len(t, config.TableMappings, 1)
+	require.True(t, spec.Paused)
+}
+
+func TestSetCondition(t *testing.T) {
+	var st status
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	st.setCondition(1, conditionReady, "False", "CreateFailed", "connection refused", first)
+	st.setCondition(2, conditionReady, "False", "CreateFailed", "timeout", first.Add(time.Minute))
+	require.Len(t, st.Conditions, 1)
+	require.Equal(t, first, st.Conditions[0].LastTransitionTime)
+	require.Equal(t, "timeout", st.Conditions[0].Message)
+	require.Equal(t, int64(2), st.Conditions[0].ObservedGeneration)
+
+	st.setCondition(2, conditionReady, "True", "Created", "", first.Add(2*time.Minute))
+	require.Equal(t, first.Add(2*time.Minute), st.condition(conditionReady).LastTransitionTime)
+}
+
+func TestSnapshotProgress(t *testing.T) {
+	progress := snapshotProgressOf(&protos.SnapshotStatus{Clones: []*protos.CloneTableSummary{
+		{NumPartitionsCompleted: 4, NumPartitionsTotal: 4, NumRowsSynced: 1000},
+		{NumPartitionsCompleted: 1, NumPartitionsTotal: 4, NumRowsSynced: 250},
+	}}, 3)
+	require.Equal(t, int32(1), progress.TablesCompleted)
+	require.Equal(t, int32(3), progress.TablesTotal)
+	require.Equal(t, int64(1250), progress.RowsSynced)
+	require.Nil(t, progress.EstimatedCompletionTime)
+}
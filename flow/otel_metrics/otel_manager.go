@@ -3,9 +3,13 @@ package otel_metrics
 import (
 	"context"
 	"fmt"
+	"net/http"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -42,7 +46,8 @@ func setupGrpcOtelMetricsExporter() (sdkmetric.Exporter, error) {
 	return otlpmetricgrpc.New(context.Background())
 }
 
-func SetupOtelMetricsExporter(otelServiceName string) (*sdkmetric.MeterProvider, error) {
+// SetupOtelMetricsExporter returns a provider pushing metrics over OTLP, as well as to readers
+func SetupOtelMetricsExporter(otelServiceName string, readers ...sdkmetric.Reader) (*sdkmetric.MeterProvider, error) {
 	otlpMetricProtocol := peerdbenv.GetEnvString("OTEL_EXPORTER_OTLP_PROTOCOL",
 		peerdbenv.GetEnvString("OTEL_EXPORTER_OTLP_METRICS_PROTOCOL", "http/protobuf"))
 	var metricExporter sdkmetric.Exporter
@@ -58,14 +63,28 @@ func SetupOtelMetricsExporter(otelServiceName string) (*sdkmetric.MeterProvider,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OpenTelemetry metrics exporter: %w", err)
 	}
+	return NewMeterProvider(otelServiceName, append(readers, sdkmetric.NewPeriodicReader(metricExporter))...)
+}
+
+func NewMeterProvider(otelServiceName string, readers ...sdkmetric.Reader) (*sdkmetric.MeterProvider, error) {
 	otelResource, err := newOtelResource(otelServiceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OpenTelemetry resource: %w", err)
 	}
 
-	meterProvider := sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
-		sdkmetric.WithResource(otelResource),
-	)
-	return meterProvider, nil
+	options := []sdkmetric.Option{sdkmetric.WithResource(otelResource)}
+	for _, reader := range readers {
+		options = append(options, sdkmetric.WithReader(reader))
+	}
+	return sdkmetric.NewMeterProvider(options...), nil
+}
+
+// SetupPrometheusMetricsReader returns a reader collecting metrics when Prometheus scrapes the returned handler
+func SetupPrometheusMetricsReader() (sdkmetric.Reader, http.Handler, error) {
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Prometheus metrics exporter: %w", err)
+	}
+	return exporter, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil
 }
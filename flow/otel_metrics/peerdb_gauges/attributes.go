@@ -1,7 +1,26 @@
 package peerdb_gauges
 
+import (
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
 const (
 	PeerNameKey      string = "peerName"
+	FlowNameKey      string = "flowName"
 	SlotNameKey      string = "slotName"
+	TableNameKey     string = "tableName"
 	DeploymentUidKey string = "deploymentUID"
+
+	// prefixed so mirror labels can't shadow attributes set by PeerDB
+	MirrorLabelKeyPrefix string = "label_"
 )
+
+func MirrorLabelAttributes(labels map[string]string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(labels)+2)
+	for _, key := range shared.SortedMirrorLabelKeys(labels) {
+		attrs = append(attrs, attribute.String(MirrorLabelKeyPrefix+key, labels[key]))
+	}
+	return attrs
+}
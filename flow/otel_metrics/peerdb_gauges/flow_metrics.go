@@ -0,0 +1,98 @@
+package peerdb_gauges
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared/metrics"
+)
+
+const (
+	RowsPulledCounterName               string = "flow_rows_pulled"
+	RowsSyncedCounterName               string = "flow_rows_synced"
+	NormalizeBatchDurationHistogramName string = "flow_normalize_batch_duration"
+	FlowSlotLagGaugeName                string = "flow_slot_lag"
+	LastSyncedAtGaugeName               string = "flow_last_synced_at"
+)
+
+// FlowMetrics records health of mirrors as OpenTelemetry instruments, attributed by mirror.
+// Gauges keep their last value between collections, so a mirror that stops syncing shows a stale timestamp
+type FlowMetrics struct {
+	rowsPulled             metric.Int64Counter
+	rowsSynced             metric.Int64Counter
+	normalizeBatchDuration metric.Float64Histogram
+	slotLag                metric.Float64Gauge
+	lastSyncedAt           metric.Int64Gauge
+}
+
+var _ metrics.Recorder = (*FlowMetrics)(nil)
+
+func NewFlowMetrics(meter metric.Meter) (*FlowMetrics, error) {
+	rowsPulled, err := meter.Int64Counter(RowsPulledCounterName,
+		metric.WithDescription("Rows read from the source of the mirror"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rows pulled counter: %w", err)
+	}
+	rowsSynced, err := meter.Int64Counter(RowsSyncedCounterName,
+		metric.WithDescription("Rows written to the destination of the mirror"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rows synced counter: %w", err)
+	}
+	normalizeBatchDuration, err := meter.Float64Histogram(NormalizeBatchDurationHistogramName,
+		metric.WithUnit("s"),
+		metric.WithDescription("Time taken to normalize batches of the mirror"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create normalize batch duration histogram: %w", err)
+	}
+	slotLag, err := meter.Float64Gauge(FlowSlotLagGaugeName,
+		metric.WithUnit("MB"),
+		metric.WithDescription("Replication slot lag of the mirror in MB"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create slot lag gauge: %w", err)
+	}
+	lastSyncedAt, err := meter.Int64Gauge(LastSyncedAtGaugeName,
+		metric.WithUnit("s"),
+		metric.WithDescription("Unix time of the last successful sync of the mirror"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create last synced at gauge: %w", err)
+	}
+	return &FlowMetrics{
+		rowsPulled:             rowsPulled,
+		rowsSynced:             rowsSynced,
+		normalizeBatchDuration: normalizeBatchDuration,
+		slotLag:                slotLag,
+		lastSyncedAt:           lastSyncedAt,
+	}, nil
+}
+
+func flowAttributes(flowName string, attrs ...attribute.KeyValue) metric.MeasurementOption {
+	return metric.WithAttributes(append(attrs,
+		attribute.String(FlowNameKey, flowName),
+		attribute.String(DeploymentUidKey, peerdbenv.PeerDBDeploymentUID()),
+	)...)
+}
+
+func (m *FlowMetrics) RowsPulled(ctx context.Context, flowName string, rows int64) {
+	m.rowsPulled.Add(ctx, rows, flowAttributes(flowName))
+}
+
+func (m *FlowMetrics) RowsSynced(ctx context.Context, flowName string, rows int64) {
+	m.rowsSynced.Add(ctx, rows, flowAttributes(flowName))
+}
+
+func (m *FlowMetrics) NormalizeBatchDuration(ctx context.Context, flowName string, duration time.Duration) {
+	m.normalizeBatchDuration.Record(ctx, duration.Seconds(), flowAttributes(flowName))
+}
+
+func (m *FlowMetrics) SlotLag(ctx context.Context, flowName string, slotName string, lagMB float64) {
+	m.slotLag.Record(ctx, lagMB, flowAttributes(flowName, attribute.String(SlotNameKey, slotName)))
+}
+
+func (m *FlowMetrics) SyncSucceeded(ctx context.Context, flowName string, syncedAt time.Time) {
+	m.lastSyncedAt.Record(ctx, syncedAt.Unix(), flowAttributes(flowName))
+}
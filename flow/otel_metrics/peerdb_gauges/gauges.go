@@ -6,6 +6,11 @@ const (
 	SlotLagGaugeName                    string = "cdc_slot_lag"
 	OpenConnectionsGaugeName            string = "open_connections"
 	OpenReplicationConnectionsGaugeName string = "open_replication_connections"
+	FlowCPUUsageGaugeName               string = "flow_cpu_usage"
+	FlowMemoryUsageGaugeName            string = "flow_memory_usage"
+	FlowGoroutinesGaugeName             string = "flow_goroutines"
+	FlowSpillBytesGaugeName             string = "flow_spill_bytes"
+	DataDiffMismatchedRowsGaugeName     string = "data_diff_mismatched_rows"
 )
 
 type SlotMetricGauges struct {
@@ -13,3 +18,10 @@ type SlotMetricGauges struct {
 	OpenConnectionsGauge            *otel_metrics.Int64SyncGauge
 	OpenReplicationConnectionsGauge *otel_metrics.Int64SyncGauge
 }
+
+type FlowResourceGauges struct {
+	CPUUsageGauge    *otel_metrics.Float64SyncGauge
+	MemoryUsageGauge *otel_metrics.Int64SyncGauge
+	GoroutinesGauge  *otel_metrics.Float64SyncGauge
+	SpillBytesGauge  *otel_metrics.Int64SyncGauge
+}
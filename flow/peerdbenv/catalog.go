@@ -16,7 +16,17 @@ var (
 	pool      *pgxpool.Pool
 )
 
+// WithCatalogPool makes catalog lookups under ctx, like of dynamic settings, use catalogPool instead of
+// the catalog of the environment, for the API serving requests of other environments
+func WithCatalogPool(ctx context.Context, catalogPool *pgxpool.Pool) context.Context {
+	return context.WithValue(ctx, shared.CatalogPoolKey, catalogPool)
+}
+
 func GetCatalogConnectionPoolFromEnv(ctx context.Context) (*pgxpool.Pool, error) {
+	if catalogPool, ok := ctx.Value(shared.CatalogPoolKey).(*pgxpool.Pool); ok {
+		return catalogPool, nil
+	}
+
 	var err error
 
 	poolMutex.Lock()
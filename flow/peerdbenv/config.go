@@ -3,9 +3,12 @@ package peerdbenv
 import (
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/shared"
 )
 
@@ -24,7 +27,11 @@ func PeerDBDeploymentUID() string {
 }
 
 func PeerFlowTaskQueueName(taskQueueID shared.TaskQueueID) string {
-	deploymentUID := PeerDBDeploymentUID()
+	return DeploymentTaskQueueName(PeerDBDeploymentUID(), taskQueueID)
+}
+
+// DeploymentTaskQueueName is the task queue workers of deployment deploymentUID poll
+func DeploymentTaskQueueName(deploymentUID string, taskQueueID shared.TaskQueueID) string {
 	if deploymentUID == "" {
 		return string(taskQueueID)
 	}
@@ -79,6 +86,43 @@ func PeerDBCatalogDatabase() string {
 	return GetEnvString("PEERDB_CATALOG_DATABASE", "")
 }
 
+// PEERDB_SECRETS_DIR, directory of files secret references of imported peers are resolved from,
+// without one they are resolved from PEERDB_SECRET_ environment variables
+func PeerDBSecretsDir() string {
+	return GetEnvString("PEERDB_SECRETS_DIR", "")
+}
+
+// CatalogEnvironment is the catalog of another environment, like staging, the API manages mirrors of too.
+// Workers of the environment run with PEERDB_DEPLOYMENT_UID set to DeploymentUID and their own catalog
+type CatalogEnvironment struct {
+	Name          string `json:"name"`
+	DeploymentUID string `json:"deployment_uid"`
+	Host          string `json:"host"`
+	User          string `json:"user"`
+	Password      string `json:"password"`
+	Database      string `json:"database"`
+	Port          uint16 `json:"port"`
+}
+
+func (e CatalogEnvironment) PostgresConfig() *protos.PostgresConfig {
+	port := e.Port
+	if port == 0 {
+		port = 5432
+	}
+	return &protos.PostgresConfig{
+		Host:     e.Host,
+		Port:     uint32(port),
+		User:     e.User,
+		Password: e.Password,
+		Database: e.Database,
+	}
+}
+
+// PEERDB_CATALOG_ENVIRONMENTS, a JSON array of CatalogEnvironment, only read by the API
+func PeerDBCatalogEnvironments() []CatalogEnvironment {
+	return GetEnvJSON[[]CatalogEnvironment]("PEERDB_CATALOG_ENVIRONMENTS", nil)
+}
+
 // PEERDB_TELEMETRY_AWS_SNS_TOPIC_ARN
 func PeerDBTelemetryAWSSNSTopicArn() string {
 	return GetEnvString("PEERDB_TELEMETRY_AWS_SNS_TOPIC_ARN", "")
@@ -115,6 +159,11 @@ func PeerDBCurrentEncKey() (shared.PeerDBEncKey, error) {
 	return encKeys.Get(encKeyID)
 }
 
+// PEERDB_SHARE_TOKEN_SECRET signs share tokens granting read-only access to status of a mirror, empty disables them
+func PeerDBShareTokenSecret() (string, error) {
+	return GetKMSDecryptedEnvString("PEERDB_SHARE_TOKEN_SECRET", "")
+}
+
 func PeerDBAllowedTargets() string {
 	return GetEnvString("PEERDB_ALLOWED_TARGETS", "")
 }
@@ -123,6 +172,66 @@ func PeerDBClickhouseAllowedDomains() string {
 	return GetEnvString("PEERDB_CLICKHOUSE_ALLOWED_DOMAINS", "")
 }
 
+// PEERDB_ALLOWED_STAGING_REGIONS, comma separated regions data may be staged in, empty allows any
+func PeerDBAllowedStagingRegions() []string {
+	var regions []string
+	for _, region := range strings.Split(GetEnvString("PEERDB_ALLOWED_STAGING_REGIONS", ""), ",") {
+		if region = strings.ToLower(strings.TrimSpace(region)); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// PEERDB_OPENLINEAGE_URL, base url of an OpenLineage API like Marquez, empty disables lineage events
+func PeerDBOpenLineageURL() string {
+	return GetEnvString("PEERDB_OPENLINEAGE_URL", "")
+}
+
+func PeerDBOpenLineageAPIKey() string {
+	return GetEnvString("PEERDB_OPENLINEAGE_API_KEY", "")
+}
+
+// PEERDB_OPENLINEAGE_NAMESPACE, namespace of mirror jobs in lineage events
+func PeerDBOpenLineageNamespace() string {
+	return GetEnvString("PEERDB_OPENLINEAGE_NAMESPACE", "peerdb")
+}
+
+// PEERDB_CATALOG_PUSH_URL, DataHub GMS or webhook url destination tables are registered with, empty disables it
+func PeerDBCatalogPushURL() string {
+	return GetEnvString("PEERDB_CATALOG_PUSH_URL", "")
+}
+
+// PEERDB_CATALOG_PUSH_TYPE, datahub to ingest aspects through the GMS API, webhook to post tables as JSON
+func PeerDBCatalogPushType() string {
+	return GetEnvString("PEERDB_CATALOG_PUSH_TYPE", "webhook")
+}
+
+func PeerDBCatalogPushToken() string {
+	return GetEnvString("PEERDB_CATALOG_PUSH_TOKEN", "")
+}
+
+// PEERDB_FAULT_INJECTION_ENABLED lets mirrors inject faults into connector calls through their env, for tests only
+func PeerDBFaultInjectionEnabled() bool {
+	return GetEnvString("PEERDB_FAULT_INJECTION_ENABLED", "") == "true"
+}
+
+// PEERDB_PLUGIN_DIR, directory of connector plugin executables named peerdb-plugin-<name>, empty disables plugins
+func PeerDBPluginDir() string {
+	return GetEnvString("PEERDB_PLUGIN_DIR", "")
+}
+
+// PEERDB_SPILL_DIR, directory of temporary files of workers like Avro files staged for upload and records
+// spilled to disk, each worker keeping its own in a subdirectory it empties when starting
+func PeerDBSpillDir() string {
+	return GetEnvString("PEERDB_SPILL_DIR", filepath.Join(os.TempDir(), "peerdb-spill"))
+}
+
+// PEERDB_SPILL_QUOTA_BYTES, size temporary files of a worker may take up in the spill directory, 0 means no limit
+func PeerDBSpillQuotaBytes() uint64 {
+	return getEnvUint[uint64]("PEERDB_SPILL_QUOTA_BYTES", 0)
+}
+
 func PeerDBTemporalEnableCertAuth() bool {
 	cert := GetEnvString("TEMPORAL_CLIENT_CERT", "")
 	return strings.TrimSpace(cert) != ""
@@ -135,3 +244,9 @@ func PeerDBTemporalClientCert() ([]byte, error) {
 func PeerDBTemporalClientKey() ([]byte, error) {
 	return GetEnvBase64EncodedBytes("TEMPORAL_CLIENT_KEY", nil)
 }
+
+// PEERDB_GRPC_COMPRESSION, compressor of messages sent by clients of the flow API like the operator, zstd or gzip,
+// empty for none, servers accepting either
+func PeerDBGRPCCompression() string {
+	return GetEnvString("PEERDB_GRPC_COMPRESSION", "")
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/shared"
 )
 
 var DynamicSettings = [...]*protos.DynamicSetting{
@@ -23,6 +24,67 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name: "PEERDB_ADAPTIVE_SYNC_MAX_IDLE_TIMEOUT_SECONDS", DefaultValue: "0", ValueType: protos.DynconfValueType_UINT,
+		Description: "Longest idle timeout syncs back off to while batches stay under PEERDB_ADAPTIVE_SYNC_RECORDS_THRESHOLD, " +
+			"at most the sync interval of the mirror disables backing off",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_ADAPTIVE_SYNC_RECORDS_THRESHOLD", DefaultValue: "10000", ValueType: protos.DynconfValueType_UINT,
+		Description:      "Records at which a backed off sync happens right away and the idle timeout returns to the sync interval",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_BATCH_TUNING_TARGET_LATENCY_SECONDS", DefaultValue: "0", ValueType: protos.DynconfValueType_UINT,
+		Description: "Seconds syncs aim to write their batch to the destination in, batch sizes shrink when writes take longer " +
+			"and grow back up to the configured batch size when faster, 0 disables batch tuning",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_BATCH_TUNING_MAX_IDLE_TIMEOUT_SECONDS", DefaultValue: "300", ValueType: protos.DynconfValueType_UINT,
+		Description: "Longest idle timeout batch tuning backs off to while the destination pushes back on syncs, " +
+			"like ClickHouse with too many parts or Snowflake queueing statements",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CATCH_UP_LAG_THRESHOLD_SECONDS", DefaultValue: "0", ValueType: protos.DynconfValueType_UINT,
+		Description: "Seconds behind the source after which mirrors enter catch up mode with raised batch size and parallelism, " +
+			"until they are back under it, 0 disables catch up mode",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CATCH_UP_MAX_DURATION_SECONDS", DefaultValue: "21600", ValueType: protos.DynconfValueType_UINT,
+		Description: "Longest mirrors stay in catch up mode, after which they keep steady state settings until caught up, " +
+			"0 for no limit",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CATCH_UP_BATCH_SIZE_MULTIPLIER", DefaultValue: "4", ValueType: protos.DynconfValueType_UINT,
+		Description:      "Factor batch sizes of mirrors in catch up mode are raised by",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CATCH_UP_PARALLELISM", DefaultValue: "16", ValueType: protos.DynconfValueType_INT,
+		Description: "Parallelism of Postgres apply, Snowflake merges and queue syncs of mirrors in catch up mode, " +
+			"parallelism configured higher is kept",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CATCH_UP_MAX_MIRRORS_PER_PEER", DefaultValue: "2", ValueType: protos.DynconfValueType_UINT,
+		Description: "Mirrors into the same destination peer in catch up mode at once, others wait for them to catch up, " +
+			"0 for no limit",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name: "PEERDB_CDC_CHANNEL_BUFFER_SIZE", DefaultValue: "262144", ValueType: protos.DynconfValueType_INT,
 		Description:      "Advanced setting: changes buffer size of channel PeerDB uses while streaming rows read to destination in CDC",
@@ -53,6 +115,20 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name: "PEERDB_NORMALIZE_WATCHDOG_SECONDS", DefaultValue: "1800", ValueType: protos.DynconfValueType_INT,
+		Description: "CDC: each time normalize runs another this many seconds, running queries, merges and mutations " +
+			"of the destination are captured into mirror logs, 0 disables",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CDC_COLLAPSE_WINDOW", DefaultValue: "10000", ValueType: protos.DynconfValueType_INT,
+		Description: "CDC: number of records within which changes of a key are collapsed into its final image " +
+			"before being written to raw tables of warehouses, 0 disables",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name: "PEERDB_ENABLE_WAL_HEARTBEAT", DefaultValue: "false", ValueType: protos.DynconfValueType_BOOL,
 		Description:      "Enables WAL heartbeat to prevent replication slot lag from increasing during times of no activity",
@@ -88,12 +164,26 @@ END;`, ValueType: protos.DynconfValueType_STRING,
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_SNOWFLAKE,
 	},
+	{
+		Name: "PEERDB_POSTGRES_APPLY_PARALLELISM", DefaultValue: "1", ValueType: protos.DynconfValueType_INT,
+		Description: "Groups of tables unrelated by foreign keys to normalize in parallel for CDC mirrors with Postgres targets, " +
+			"each on a connection of its own. 1 normalizes batches in a single transaction",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name: "PEERDB_CLICKHOUSE_AWS_S3_BUCKET_NAME", DefaultValue: "", ValueType: protos.DynconfValueType_STRING,
 		Description:      "S3 buckets to store Avro files for mirrors with ClickHouse target",
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
 	},
+	{
+		Name: "PEERDB_QUEUE_IDEMPOTENCY_KEY", DefaultValue: "false", ValueType: protos.DynconfValueType_BOOL,
+		Description: "Attach a peerdb-idempotency-key header made of LSN, table and primary key hash to queue messages, " +
+			"letting consumers drop redelivered changes",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_QUEUES,
+	},
 	{
 		Name: "PEERDB_QUEUE_FORCE_TOPIC_CREATION", DefaultValue: "false", ValueType: protos.DynconfValueType_BOOL,
 		Description:      "Force auto topic creation in mirrors, applies to Kafka and PubSub mirrors",
@@ -112,6 +202,19 @@ END;`, ValueType: protos.DynconfValueType_STRING,
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name: "PEERDB_SLOT_GROWTH_ALERT_HORIZON_HOURS", DefaultValue: "6", ValueType: protos.DynconfValueType_UINT,
+		Description: "Hours ahead to alert when a slot growing at current WAL generation and consumption rates " +
+			"is predicted to exceed the slot lag threshold, 0 disables predictive slot alerting",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_SLOT_GROWTH_WINDOW_MINUTES", DefaultValue: "30", ValueType: protos.DynconfValueType_UINT,
+		Description:      "Minutes over which WAL generation and consumption rates of slots are measured for predictive slot alerting",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name: "PEERDB_PGPEER_OPEN_CONNECTIONS_ALERT_THRESHOLD", DefaultValue: "5", ValueType: protos.DynconfValueType_UINT,
 		Description:      "Open connections from PeerDB user threshold to start sending alerts, 0 disables open connections alerting entirely",
@@ -124,6 +227,67 @@ END;`, ValueType: protos.DynconfValueType_STRING,
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
 		TargetForSetting: protos.DynconfTarget_BIGQUERY,
 	},
+	{
+		Name: "PEERDB_SNAPSHOT_COLUMN_STATS", DefaultValue: "true", ValueType: protos.DynconfValueType_BOOL,
+		Description:      "Collect min, max and distinct count of columns during initial snapshot, stored in catalog",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_DESTINATION_AUDIT_LOG", DefaultValue: "false", ValueType: protos.DynconfValueType_BOOL,
+		Description:      "Record DDL and merge statements executed against destinations in catalog, with string literals redacted",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_COLUMN_DEFAULTS", DefaultValue: "false", ValueType: protos.DynconfValueType_BOOL,
+		Description:      "Carry column defaults of source tables into destination tables, where translatable",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CLICKHOUSE_LIGHTWEIGHT_DELETE", DefaultValue: "false", ValueType: protos.DynconfValueType_BOOL,
+		Description:      "Remove deleted rows from ClickHouse tables with lightweight deletes when not soft deleting",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
+	{
+		Name: "PEERDB_STATEMENT_TIMEOUT_SECONDS", DefaultValue: "0", ValueType: protos.DynconfValueType_INT,
+		Description:      "Timeout of each statement PeerDB runs on Postgres, Snowflake, BigQuery and SQL Server peers, 0 for no timeout",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CLICKHOUSE_INSERT_TIMEOUT_SECONDS", DefaultValue: "0", ValueType: protos.DynconfValueType_INT,
+		Description:      "Timeout of each insert of staged Avro files into ClickHouse, queries past it are killed, 0 for no timeout",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
+	{
+		Name: "PEERDB_CLICKHOUSE_NORMALIZE_TIMEOUT_SECONDS", DefaultValue: "0", ValueType: protos.DynconfValueType_INT,
+		Description:      "Timeout of each normalize statement on ClickHouse, queries past it are killed, 0 for no timeout",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
+	{
+		Name: "PEERDB_MAINTENANCE_MAX_CONCURRENT_MIRRORS", DefaultValue: "2", ValueType: protos.DynconfValueType_UINT,
+		Description:      "Mirrors whose destination tables are maintained at once by scheduled maintenance",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_ORPHANED_ARTIFACTS_DRY_RUN", DefaultValue: "true", ValueType: protos.DynconfValueType_BOOL,
+		Description: "Only log raw tables and stages of mirrors missing from the catalog instead of dropping them, " +
+			"keep enabled when several deployments share a peer",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CDC_GAP_DETECTION", DefaultValue: "true", ValueType: protos.DynconfValueType_BOOL,
+		Description:      "Pause mirrors whose destination offset is past where their last batch ended instead of pulling on from it",
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 }
 
 var DynamicIndex = func() map[string]int {
@@ -206,10 +370,43 @@ func dynamicConfBool(ctx context.Context, env map[string]string, key string) (bo
 }
 
 // PEERDB_SLOT_LAG_MB_ALERT_THRESHOLD, 0 disables slot lag alerting entirely
+// PeerDBFeatureFlags evaluates every boolean setting, these being what toggles features
+func PeerDBFeatureFlags(ctx context.Context) (map[string]bool, error) {
+	flags := make(map[string]bool)
+	for _, setting := range DynamicSettings {
+		if setting.ValueType != protos.DynconfValueType_BOOL {
+			continue
+		}
+		value, err := dynamicConfBool(ctx, nil, setting.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate %s: %w", setting.Name, err)
+		}
+		flags[setting.Name] = value
+	}
+	return flags, nil
+}
+
 func PeerDBSlotLagMBAlertThreshold(ctx context.Context, env map[string]string) (uint32, error) {
 	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_SLOT_LAG_MB_ALERT_THRESHOLD")
 }
 
+// PEERDB_SLOT_GROWTH_ALERT_HORIZON_HOURS, 0 disables predictive slot alerting
+func PeerDBSlotGrowthAlertHorizon(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfUnsigned[uint64](ctx, env, "PEERDB_SLOT_GROWTH_ALERT_HORIZON_HOURS")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Hour, nil
+}
+
+func PeerDBSlotGrowthWindow(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfUnsigned[uint64](ctx, env, "PEERDB_SLOT_GROWTH_WINDOW_MINUTES")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Minute, nil
+}
+
 // PEERDB_ALERTING_GAP_MINUTES, 0 disables all alerting entirely
 func PeerDBAlertingGapMinutesAsDuration(ctx context.Context, env map[string]string) (time.Duration, error) {
 	why, err := dynamicConfSigned[int64](ctx, env, "PEERDB_ALERTING_GAP_MINUTES")
@@ -245,7 +442,11 @@ func PeerDBQueueFlushTimeoutSeconds(ctx context.Context, env map[string]string)
 }
 
 func PeerDBQueueParallelism(ctx context.Context, env map[string]string) (int64, error) {
-	return dynamicConfSigned[int64](ctx, env, "PEERDB_QUEUE_PARALLELISM")
+	parallelism, err := dynamicConfSigned[int64](ctx, env, "PEERDB_QUEUE_PARALLELISM")
+	if err != nil {
+		return 0, err
+	}
+	return catchUpParallelism(ctx, env, parallelism)
 }
 
 func PeerDBCDCDiskSpillRecordsThreshold(ctx context.Context, env map[string]string) (int64, error) {
@@ -256,6 +457,14 @@ func PeerDBCDCDiskSpillMemPercentThreshold(ctx context.Context, env map[string]s
 	return dynamicConfSigned[int64](ctx, env, "PEERDB_CDC_DISK_SPILL_MEM_PERCENT_THRESHOLD")
 }
 
+func PeerDBNormalizeWatchdogSeconds(ctx context.Context, env map[string]string) (int64, error) {
+	return dynamicConfSigned[int64](ctx, env, "PEERDB_NORMALIZE_WATCHDOG_SECONDS")
+}
+
+func PeerDBCDCCollapseWindow(ctx context.Context, env map[string]string) (int64, error) {
+	return dynamicConfSigned[int64](ctx, env, "PEERDB_CDC_COLLAPSE_WINDOW")
+}
+
 func PeerDBEnableWALHeartbeat(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_ENABLE_WAL_HEARTBEAT")
 }
@@ -273,7 +482,71 @@ func PeerDBNullable(ctx context.Context, env map[string]string) (bool, error) {
 }
 
 func PeerDBSnowflakeMergeParallelism(ctx context.Context, env map[string]string) (int64, error) {
-	return dynamicConfSigned[int64](ctx, env, "PEERDB_SNOWFLAKE_MERGE_PARALLELISM")
+	parallelism, err := dynamicConfSigned[int64](ctx, env, "PEERDB_SNOWFLAKE_MERGE_PARALLELISM")
+	if err != nil {
+		return 0, err
+	}
+	return catchUpParallelism(ctx, env, parallelism)
+}
+
+func PeerDBPostgresApplyParallelism(ctx context.Context, env map[string]string) (int64, error) {
+	parallelism, err := dynamicConfSigned[int64](ctx, env, "PEERDB_POSTGRES_APPLY_PARALLELISM")
+	if err != nil {
+		return 0, err
+	}
+	return catchUpParallelism(ctx, env, parallelism)
+}
+
+func PeerDBSnapshotColumnStats(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_SNAPSHOT_COLUMN_STATS")
+}
+
+func PeerDBDestinationAuditLog(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_DESTINATION_AUDIT_LOG")
+}
+
+func PeerDBColumnDefaults(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_COLUMN_DEFAULTS")
+}
+
+func PeerDBClickhouseLightweightDelete(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_CLICKHOUSE_LIGHTWEIGHT_DELETE")
+}
+
+func PeerDBStatementTimeout(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfSigned[int64](ctx, env, "PEERDB_STATEMENT_TIMEOUT_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Second, nil
+}
+
+func PeerDBClickhouseInsertTimeout(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfSigned[int64](ctx, env, "PEERDB_CLICKHOUSE_INSERT_TIMEOUT_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Second, nil
+}
+
+func PeerDBClickhouseNormalizeTimeout(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfSigned[int64](ctx, env, "PEERDB_CLICKHOUSE_NORMALIZE_TIMEOUT_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Second, nil
+}
+
+func PeerDBMaintenanceMaxConcurrentMirrors(ctx context.Context, env map[string]string) (uint32, error) {
+	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_MAINTENANCE_MAX_CONCURRENT_MIRRORS")
+}
+
+func PeerDBOrphanedArtifactsDryRun(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_ORPHANED_ARTIFACTS_DRY_RUN")
+}
+
+func PeerDBCDCGapDetection(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_CDC_GAP_DETECTION")
 }
 
 func PeerDBClickhouseAWSS3BucketName(ctx context.Context, env map[string]string) (string, error) {
@@ -282,10 +555,71 @@ func PeerDBClickhouseAWSS3BucketName(ctx context.Context, env map[string]string)
 
 // Kafka has topic auto create as an option, auto.create.topics.enable
 // But non-dedicated cluster maybe can't set config, may want peerdb to create topic. Similar for PubSub
+func PeerDBQueueIdempotencyKey(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_QUEUE_IDEMPOTENCY_KEY")
+}
+
 func PeerDBQueueForceTopicCreation(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_QUEUE_FORCE_TOPIC_CREATION")
 }
 
+func PeerDBAdaptiveSyncMaxIdleTimeoutSeconds(ctx context.Context, env map[string]string) (uint64, error) {
+	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_ADAPTIVE_SYNC_MAX_IDLE_TIMEOUT_SECONDS")
+}
+
+func PeerDBAdaptiveSyncRecordsThreshold(ctx context.Context, env map[string]string) (uint32, error) {
+	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_ADAPTIVE_SYNC_RECORDS_THRESHOLD")
+}
+
+func PeerDBBatchTuningTargetLatency(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfUnsigned[uint64](ctx, env, "PEERDB_BATCH_TUNING_TARGET_LATENCY_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Second, nil
+}
+
+func PeerDBBatchTuningMaxIdleTimeoutSeconds(ctx context.Context, env map[string]string) (uint64, error) {
+	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_BATCH_TUNING_MAX_IDLE_TIMEOUT_SECONDS")
+}
+
+func PeerDBCatchUpLagThreshold(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfUnsigned[uint64](ctx, env, "PEERDB_CATCH_UP_LAG_THRESHOLD_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Second, nil
+}
+
+func PeerDBCatchUpMaxDuration(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfUnsigned[uint64](ctx, env, "PEERDB_CATCH_UP_MAX_DURATION_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Second, nil
+}
+
+func PeerDBCatchUpBatchSizeMultiplier(ctx context.Context, env map[string]string) (uint32, error) {
+	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_CATCH_UP_BATCH_SIZE_MULTIPLIER")
+}
+
+func PeerDBCatchUpMaxMirrorsPerPeer(ctx context.Context, env map[string]string) (uint32, error) {
+	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_CATCH_UP_MAX_MIRRORS_PER_PEER")
+}
+
+// catchUpParallelism raises parallelism of mirrors in catch up mode, whose env is marked so by their sync flow,
+// parallelism without limit staying so
+func catchUpParallelism(ctx context.Context, env map[string]string, parallelism int64) (int64, error) {
+	if env[shared.CatchUpEnvKey] != "true" || parallelism < 0 {
+		return parallelism, nil
+	}
+	catchUp, err := dynamicConfSigned[int64](ctx, env, "PEERDB_CATCH_UP_PARALLELISM")
+	if err != nil {
+		return 0, err
+	}
+	return max(parallelism, catchUp), nil
+}
+
 // experimental, don't increase to greater than 64
 func PeerDBMaxSyncsPerCDCFlow(ctx context.Context, env map[string]string) (uint32, error) {
 	return dynamicConfUnsigned[uint32](ctx, env, "PEERDB_MAX_SYNCS_PER_CDC_FLOW")
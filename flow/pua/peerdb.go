@@ -471,6 +471,9 @@ func LuaRecordJson(ls *lua.LState) int {
 			}
 			tbl.RawSetString("unchanged_columns", unchanged)
 		}
+	} else if mr, ok := ud.Value.(*model.MessageRecord[model.RecordItems]); ok {
+		tbl.RawSetString("prefix", lua.LString(mr.Prefix))
+		tbl.RawSetString("content", lua.LString(mr.Content))
 	}
 	ls.Push(tbl)
 	return 1
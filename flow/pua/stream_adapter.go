@@ -72,6 +72,7 @@ func AttachToCdcStream(
 			}
 		}
 		outstream.SchemaDeltas = stream.SchemaDeltas
+		outstream.AddTruncatedTables(stream.TruncatedTables...)
 		outstream.UpdateLatestCheckpoint(stream.GetLastCheckpoint())
 		outstream.Close()
 	}()
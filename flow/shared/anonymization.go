@@ -0,0 +1,29 @@
+package shared
+
+import (
+	"slices"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// AnonymizedColumns returns source columns of a table mapping replaced by synthetic values,
+// rules of the table taking precedence over rules of every table
+func AnonymizedColumns(profile *protos.AnonymizationProfile, mapping *protos.TableMapping) map[string]protos.SyntheticValue {
+	var anonymized map[string]protos.SyntheticValue
+	for _, forTable := range []bool{false, true} {
+		for _, rule := range profile.GetRules() {
+			if rule.Value == protos.SyntheticValue_SYNTHETIC_NONE || slices.Contains(mapping.Exclude, rule.Column) {
+				continue
+			}
+			if forTable && rule.SourceTableIdentifier != mapping.SourceTableIdentifier ||
+				!forTable && rule.SourceTableIdentifier != "" {
+				continue
+			}
+			if anonymized == nil {
+				anonymized = make(map[string]protos.SyntheticValue)
+			}
+			anonymized[rule.Column] = rule.Value
+		}
+	}
+	return anonymized
+}
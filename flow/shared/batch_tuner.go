@@ -0,0 +1,94 @@
+package shared
+
+import "time"
+
+// DefaultMaxBatchSize is the batch size of syncs for mirrors not configuring one
+const DefaultMaxBatchSize = 1_000_000
+
+// DestinationPressureError is the application error type of syncs the destination pushed back on,
+// with batch tuning they fail to the sync flow to back off instead of being retried alike
+const DestinationPressureError = "destination_pressure"
+
+// BatchTuner tunes batch size and idle timeout of syncs from how the destination copes with them.
+// Batches over half the batch size taking longer than the target latency to write halve the batch size,
+// down to a 64th of the configured one, while full batches written within half the target grow it back
+// by an eighth of the configured one, so the batch size settles where writes take about the target latency.
+// Destinations pushing back, like ClickHouse with too many parts or Snowflake queueing statements,
+// double the idle timeout up to the maximum so fewer batches are written, each batch written in time halves it back.
+type BatchTuner struct {
+	TargetLatency time.Duration
+	BaseBatchSize uint32
+	BaseSeconds   uint64
+	MaxSeconds    uint64
+	batchSize     uint32
+	seconds       uint64
+}
+
+func NewBatchTuner(targetLatency time.Duration, baseBatchSize uint32, baseSeconds uint64, maxSeconds uint64) *BatchTuner {
+	if baseBatchSize == 0 {
+		baseBatchSize = DefaultMaxBatchSize
+	}
+	return &BatchTuner{
+		TargetLatency: targetLatency,
+		BaseBatchSize: baseBatchSize,
+		BaseSeconds:   baseSeconds,
+		MaxSeconds:    max(maxSeconds, baseSeconds),
+		batchSize:     baseBatchSize,
+		seconds:       baseSeconds,
+	}
+}
+
+// Enabled is false without a target latency, syncs then keep their batch size and idle timeout
+func (t *BatchTuner) Enabled() bool {
+	return t.TargetLatency > 0
+}
+
+// BatchSize caps batchSize at the tuned batch size, 0 standing for the default
+func (t *BatchTuner) BatchSize(batchSize uint32) uint32 {
+	if !t.Enabled() || (batchSize != 0 && batchSize <= t.batchSize) {
+		return batchSize
+	}
+	return t.batchSize
+}
+
+// IdleTimeoutSeconds raises idleTimeoutSeconds to the idle timeout backed off to
+func (t *BatchTuner) IdleTimeoutSeconds(idleTimeoutSeconds uint64) uint64 {
+	if !t.Enabled() || t.seconds <= t.BaseSeconds {
+		return idleTimeoutSeconds
+	}
+	return max(idleTimeoutSeconds, t.seconds)
+}
+
+// Backoff is how long to wait before the next sync after the destination pushed back
+func (t *BatchTuner) Backoff() time.Duration {
+	if !t.Enabled() || t.seconds <= t.BaseSeconds {
+		return 0
+	}
+	return time.Duration(t.seconds) * time.Second
+}
+
+// Observe tunes from a sync writing numRecords to the destination in latency
+func (t *BatchTuner) Observe(numRecords int64, latency time.Duration) {
+	if !t.Enabled() {
+		return
+	}
+	if latency > t.TargetLatency {
+		// small batches are dominated by the fixed cost of writing, shrinking them doesn't help
+		if numRecords > int64(t.batchSize/2) {
+			t.batchSize = max(t.batchSize/2, t.BaseBatchSize/64, 1)
+		}
+		return
+	}
+	t.seconds = max(t.seconds/2, t.BaseSeconds)
+	if latency <= t.TargetLatency/2 && numRecords >= int64(t.batchSize) {
+		t.batchSize = uint32(min(uint64(t.batchSize)+max(uint64(t.BaseBatchSize/8), 1), uint64(t.BaseBatchSize)))
+	}
+}
+
+// ObservePressure backs off after the destination pushed back on a sync
+func (t *BatchTuner) ObservePressure() {
+	if !t.Enabled() {
+		return
+	}
+	t.seconds = min(max(t.seconds*2, 1), t.MaxSeconds)
+}
@@ -0,0 +1,67 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTunerLatency(t *testing.T) {
+	tuner := NewBatchTuner(10*time.Second, 64000, 10, 300)
+	require.Equal(t, uint32(64000), tuner.BatchSize(0))
+
+	tuner.Observe(64000, 30*time.Second)
+	require.Equal(t, uint32(32000), tuner.BatchSize(0))
+	require.Equal(t, uint32(20000), tuner.BatchSize(20000))
+	// small batches don't shrink it further
+	tuner.Observe(1000, 30*time.Second)
+	require.Equal(t, uint32(32000), tuner.BatchSize(64000))
+
+	// in between latencies keep the batch size steady
+	tuner.Observe(32000, 8*time.Second)
+	require.Equal(t, uint32(32000), tuner.BatchSize(64000))
+	tuner.Observe(32000, 3*time.Second)
+	require.Equal(t, uint32(40000), tuner.BatchSize(64000))
+	for range 10 {
+		tuner.Observe(64000, time.Second)
+	}
+	require.Equal(t, uint32(64000), tuner.BatchSize(64000))
+
+	for range 10 {
+		tuner.Observe(64000, time.Minute)
+	}
+	require.Equal(t, uint32(1000), tuner.BatchSize(64000))
+}
+
+func TestBatchTunerPressure(t *testing.T) {
+	tuner := NewBatchTuner(10*time.Second, 0, 10, 60)
+	require.Zero(t, tuner.Backoff())
+	require.Equal(t, uint64(10), tuner.IdleTimeoutSeconds(10))
+
+	tuner.ObservePressure()
+	require.Equal(t, 20*time.Second, tuner.Backoff())
+	require.Equal(t, uint64(20), tuner.IdleTimeoutSeconds(10))
+	tuner.ObservePressure()
+	tuner.ObservePressure()
+	require.Equal(t, uint64(60), tuner.IdleTimeoutSeconds(10))
+
+	// slow writes don't relax backing off
+	tuner.Observe(10, time.Minute)
+	require.Equal(t, uint64(60), tuner.IdleTimeoutSeconds(10))
+	tuner.Observe(10, time.Second)
+	require.Equal(t, uint64(30), tuner.IdleTimeoutSeconds(10))
+	tuner.Observe(10, time.Second)
+	tuner.Observe(10, time.Second)
+	require.Equal(t, uint64(10), tuner.IdleTimeoutSeconds(10))
+	require.Zero(t, tuner.Backoff())
+}
+
+func TestBatchTunerDisabled(t *testing.T) {
+	tuner := NewBatchTuner(0, 1000, 10, 60)
+	tuner.ObservePressure()
+	tuner.Observe(1000, time.Hour)
+	require.Equal(t, uint32(1000), tuner.BatchSize(1000))
+	require.Equal(t, uint64(10), tuner.IdleTimeoutSeconds(10))
+	require.Zero(t, tuner.Backoff())
+}
@@ -0,0 +1,115 @@
+package shared
+
+import "time"
+
+// CatchUpEnvKey marks env of syncs and normalizes of mirrors in catch up mode, raising their parallelism
+const CatchUpEnvKey = "PEERDB_CATCH_UP"
+
+type CatchUpTransition int
+
+const (
+	CatchUpUnchanged CatchUpTransition = iota
+	CatchUpStart
+	// caught up, releasing its place in the budget of the peer
+	CatchUpStop
+	// ran for the maximum duration, keeping its place until caught up so resuming doesn't start over
+	CatchUpExhausted
+)
+
+// CatchUp tracks catch up mode of a mirror that fell behind its source, like after a long pause or an outage.
+// Syncs leaving the mirror more than the lag threshold behind start it, raising batch size and parallelism
+// until lag drops below the threshold again or for at most the maximum duration, after which the mirror
+// keeps its steady state settings until it caught up once, so catching up can't hog the destination for good.
+type CatchUp struct {
+	since           time.Time
+	LagThreshold    time.Duration
+	MaxDuration     time.Duration
+	BatchSizeFactor uint32
+	active          bool
+	// ran for the maximum duration without catching up
+	exhausted bool
+}
+
+func NewCatchUp(lagThreshold time.Duration, maxDuration time.Duration, batchSizeFactor uint32) *CatchUp {
+	return &CatchUp{
+		LagThreshold:    lagThreshold,
+		MaxDuration:     maxDuration,
+		BatchSizeFactor: max(batchSizeFactor, 1),
+	}
+}
+
+// Enabled is false without a lag threshold, mirrors then never enter catch up mode
+func (c *CatchUp) Enabled() bool {
+	return c.LagThreshold > 0
+}
+
+func (c *CatchUp) Active() bool {
+	return c.active
+}
+
+// Observe is the transition due after a sync left the mirror lag behind its source,
+// starting is up to the caller as it may be out of budget
+func (c *CatchUp) Observe(lag time.Duration, now time.Time) CatchUpTransition {
+	if !c.Enabled() {
+		return CatchUpUnchanged
+	}
+	caughtUp := lag < c.LagThreshold
+	if caughtUp {
+		if c.active || c.exhausted {
+			c.exhausted = false
+			return CatchUpStop
+		}
+	} else if c.active {
+		if c.MaxDuration > 0 && now.Sub(c.since) >= c.MaxDuration {
+			return CatchUpExhausted
+		}
+	} else if !c.exhausted {
+		return CatchUpStart
+	}
+	return CatchUpUnchanged
+}
+
+// Start enters catch up mode, since being when it started for mirrors resuming it,
+// false when that already was the maximum duration ago
+func (c *CatchUp) Start(since time.Time, now time.Time) bool {
+	if c.MaxDuration > 0 && now.Sub(since) >= c.MaxDuration {
+		c.Exhaust()
+		return false
+	}
+	c.active = true
+	c.since = since
+	return true
+}
+
+func (c *CatchUp) Stop() {
+	c.active = false
+}
+
+func (c *CatchUp) Exhaust() {
+	c.active = false
+	c.exhausted = true
+}
+
+// BatchSize raises batchSize while active, 0 standing for the default
+func (c *CatchUp) BatchSize(batchSize uint32) uint32 {
+	if !c.active {
+		return batchSize
+	}
+	if batchSize == 0 {
+		batchSize = DefaultMaxBatchSize
+	}
+	return uint32(min(uint64(batchSize)*uint64(c.BatchSizeFactor), uint64(^uint32(0))))
+}
+
+// Env is env marked for raised parallelism while active, env otherwise
+func (c *CatchUp) Env(env map[string]string) map[string]string {
+	if !c.active {
+		return env
+	}
+	catchUpEnv := make(map[string]string, len(env)+1)
+	for k, v := range env {
+		catchUpEnv[k] = v
+	}
+	catchUpEnv[CatchUpEnvKey] = "true"
+	return catchUpEnv
+}
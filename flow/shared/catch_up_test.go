@@ -0,0 +1,60 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatchUp(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	catchUp := NewCatchUp(10*time.Minute, time.Hour, 4)
+	require.True(t, catchUp.Enabled())
+	require.Equal(t, CatchUpUnchanged, catchUp.Observe(time.Minute, start))
+	require.Equal(t, uint32(1000), catchUp.BatchSize(1000))
+
+	require.Equal(t, CatchUpStart, catchUp.Observe(2*time.Hour, start))
+	require.True(t, catchUp.Start(start, start))
+	require.True(t, catchUp.Active())
+	require.Equal(t, uint32(4000), catchUp.BatchSize(1000))
+	require.Equal(t, uint32(DefaultMaxBatchSize*4), catchUp.BatchSize(0))
+	require.Equal(t, ^uint32(0), catchUp.BatchSize(^uint32(0)))
+	env := map[string]string{"a": "b"}
+	require.Equal(t, map[string]string{"a": "b", CatchUpEnvKey: "true"}, catchUp.Env(env))
+	require.Len(t, env, 1)
+
+	require.Equal(t, CatchUpUnchanged, catchUp.Observe(time.Hour, start.Add(30*time.Minute)))
+	require.Equal(t, CatchUpStop, catchUp.Observe(time.Minute, start.Add(40*time.Minute)))
+	catchUp.Stop()
+	require.False(t, catchUp.Active())
+	require.Equal(t, env, catchUp.Env(env))
+	require.Equal(t, CatchUpUnchanged, catchUp.Observe(time.Minute, start.Add(50*time.Minute)))
+}
+
+func TestCatchUpExhausted(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	catchUp := NewCatchUp(10*time.Minute, time.Hour, 4)
+	require.Equal(t, CatchUpStart, catchUp.Observe(2*time.Hour, start))
+	require.True(t, catchUp.Start(start, start))
+
+	require.Equal(t, CatchUpExhausted, catchUp.Observe(time.Hour, start.Add(time.Hour)))
+	catchUp.Exhaust()
+	require.False(t, catchUp.Active())
+	// stays out of catch up mode until caught up once
+	require.Equal(t, CatchUpUnchanged, catchUp.Observe(time.Hour, start.Add(2*time.Hour)))
+	require.Equal(t, CatchUpStop, catchUp.Observe(time.Minute, start.Add(3*time.Hour)))
+	catchUp.Stop()
+	require.Equal(t, CatchUpStart, catchUp.Observe(time.Hour, start.Add(4*time.Hour)))
+
+	// resuming a catch up mode started the maximum duration ago doesn't start over
+	require.False(t, catchUp.Start(start, start.Add(4*time.Hour)))
+	require.False(t, catchUp.Active())
+	require.Equal(t, CatchUpUnchanged, catchUp.Observe(time.Hour, start.Add(5*time.Hour)))
+}
+
+func TestCatchUpDisabled(t *testing.T) {
+	catchUp := NewCatchUp(0, time.Hour, 4)
+	require.False(t, catchUp.Enabled())
+	require.Equal(t, CatchUpUnchanged, catchUp.Observe(24*time.Hour, time.Now()))
+}
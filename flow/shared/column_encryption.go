@@ -0,0 +1,109 @@
+package shared
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// columnCiphertextVersion leads encrypted values so their format can change without breaking decryption of old ones
+const columnCiphertextVersion byte = 1
+
+// ColumnCipher encrypts values of columns with AES-256-GCM. Deterministic encryption derives the nonce from
+// an HMAC of the value, so equal values encrypt alike while revealing nothing else about them.
+type ColumnCipher struct {
+	aead   cipher.AEAD
+	macKey []byte
+	mode   protos.ColumnEncryptionMode
+}
+
+func deriveColumnKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+func newColumnAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("invalid column encryption key length, must be 32 bytes")
+	}
+	block, err := aes.NewCipher(deriveColumnKey(key, "peerdb column encryption"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func NewColumnCipher(key []byte, mode protos.ColumnEncryptionMode) (*ColumnCipher, error) {
+	aead, err := newColumnAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ColumnCipher{aead: aead, macKey: deriveColumnKey(key, "peerdb column nonce"), mode: mode}, nil
+}
+
+// Encrypt returns base64 of the version, nonce and sealed value
+func (c *ColumnCipher) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if c.mode == protos.ColumnEncryptionMode_COLUMN_ENCRYPTION_DETERMINISTIC {
+		mac := hmac.New(sha256.New, c.macKey)
+		mac.Write(plaintext)
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(append([]byte{columnCiphertextVersion}, nonce...), nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptColumnValue decrypts a value encrypted by a ColumnCipher with key, whichever its mode
+func DecryptColumnValue(key []byte, ciphertext string) ([]byte, error) {
+	aead, err := newColumnAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+	if len(sealed) < 1+aead.NonceSize() || sealed[0] != columnCiphertextVersion {
+		return nil, errors.New("invalid encrypted value")
+	}
+	nonce := sealed[1 : 1+aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, sealed[1+aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptedColumns returns source columns of a table mapping encrypted before landing on the destination
+func EncryptedColumns(mapping *protos.TableMapping) map[string]*protos.ColumnEncryption {
+	var encrypted map[string]*protos.ColumnEncryption
+	for _, column := range mapping.Columns {
+		if column.Encryption.GetMode() == protos.ColumnEncryptionMode_COLUMN_ENCRYPTION_NONE {
+			continue
+		}
+		if encrypted == nil {
+			encrypted = make(map[string]*protos.ColumnEncryption)
+		}
+		encrypted[column.SourceName] = column.Encryption
+	}
+	return encrypted
+}
+
+// TextColumnType is the type of destination columns holding text PeerDB writes, like encrypted values, in a type system
+func TextColumnType(system protos.TypeSystem) string {
+	if system == protos.TypeSystem_PG {
+		return "text"
+	}
+	// qvalue.QValueKindString
+	return "string"
+}
@@ -0,0 +1,42 @@
+package shared
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestColumnCipher(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+
+	deterministic, err := NewColumnCipher(key, protos.ColumnEncryptionMode_COLUMN_ENCRYPTION_DETERMINISTIC)
+	require.NoError(t, err)
+	first, err := deterministic.Encrypt([]byte("ann@example.com"))
+	require.NoError(t, err)
+	second, err := deterministic.Encrypt([]byte("ann@example.com"))
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	other, err := deterministic.Encrypt([]byte("bo@example.com"))
+	require.NoError(t, err)
+	require.NotEqual(t, first, other)
+
+	randomized, err := NewColumnCipher(key, protos.ColumnEncryptionMode_COLUMN_ENCRYPTION_RANDOMIZED)
+	require.NoError(t, err)
+	third, err := randomized.Encrypt([]byte("ann@example.com"))
+	require.NoError(t, err)
+	require.NotEqual(t, first, third)
+
+	for _, ciphertext := range []string{first, third} {
+		plaintext, err := DecryptColumnValue(key, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, "ann@example.com", string(plaintext))
+	}
+	_, err = DecryptColumnValue(bytes.Repeat([]byte{8}, 32), first)
+	require.Error(t, err)
+
+	_, err = NewColumnCipher([]byte("short"), protos.ColumnEncryptionMode_COLUMN_ENCRYPTION_RANDOMIZED)
+	require.Error(t, err)
+}
@@ -22,6 +22,8 @@ const (
 	FlowNameKey      ContextKey = "flowName"
 	PartitionIDKey   ContextKey = "partitionId"
 	DeploymentUIDKey ContextKey = "deploymentUid"
+	MirrorLabelsKey  ContextKey = "mirrorLabels"
+	CatalogPoolKey   ContextKey = "catalogPool"
 )
 
 const FetchAndChannelSize = 256 * 1024
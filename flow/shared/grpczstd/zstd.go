@@ -0,0 +1,84 @@
+// Package grpczstd registers a zstd compressor for gRPC, importing it is enough for servers to accept
+// and answer with zstd compressed messages, while clients choose it with grpc.UseCompressor(grpczstd.Name)
+package grpczstd
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+)
+
+const Name = "zstd"
+
+func init() {
+	encoding.RegisterCompressor(&compressor{})
+}
+
+// encoders and decoders are pooled as status polling sends many small messages,
+// each of which would otherwise allocate their window
+type compressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+type writer struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+type reader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+	// set once back in the pool, so reading again after EOF does not pool it twice
+	pooled bool
+}
+
+func (c *compressor) Name() string {
+	return Name
+}
+
+func (c *compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if enc, ok := c.encoders.Get().(*writer); ok {
+		enc.Reset(w)
+		return enc, nil
+	}
+	enc, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &writer{Encoder: enc, pool: &c.encoders}, nil
+}
+
+func (c *compressor) Decompress(r io.Reader) (io.Reader, error) {
+	if dec, ok := c.decoders.Get().(*reader); ok {
+		if err := dec.Reset(r); err != nil {
+			return nil, err
+		}
+		dec.pooled = false
+		return dec, nil
+	}
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &reader{Decoder: dec, pool: &c.decoders}, nil
+}
+
+func (w *writer) Close() error {
+	err := w.Encoder.Close()
+	w.pool.Put(w)
+	return err
+}
+
+// Read returns the decoder to its pool once the message has been read entirely
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if errors.Is(err, io.EOF) && !r.pooled {
+		r.pooled = true
+		r.pool.Put(r)
+	}
+	return n, err
+}
@@ -0,0 +1,34 @@
+package grpczstd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c := encoding.GetCompressor(Name)
+	require.NotNil(t, c)
+
+	message := strings.Repeat(`{"flowJobName": "orders_mirror", "currentFlowState": "STATUS_RUNNING"}`, 100)
+	// twice so pooled encoders and decoders are reused
+	for range 2 {
+		var compressed bytes.Buffer
+		w, err := c.Compress(&compressed)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(message))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.Less(t, compressed.Len(), len(message))
+
+		r, err := c.Decompress(&compressed)
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, message, string(decompressed))
+	}
+}
@@ -0,0 +1,68 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	maxMirrorLabels           = 16
+	maxMirrorLabelValueLength = 256
+)
+
+// label keys double as metric attribute names, so they keep to what every metrics backend accepts
+var reMirrorLabelKey = regexp.MustCompile("^[a-z_][a-z0-9_]{0,62}$")
+
+func ValidateMirrorLabels(labels map[string]string) error {
+	if len(labels) > maxMirrorLabels {
+		return fmt.Errorf("mirror can have at most %d labels, got %d", maxMirrorLabels, len(labels))
+	}
+	for key, value := range labels {
+		if !reMirrorLabelKey.MatchString(key) {
+			return fmt.Errorf("label key %q must be lowercase letters, digits and underscores, not starting with a digit, "+
+				"and at most 63 characters", key)
+		}
+		if len(value) > maxMirrorLabelValueLength {
+			return fmt.Errorf("value of label %s must be at most %d characters", key, maxMirrorLabelValueLength)
+		}
+	}
+	return nil
+}
+
+// SortedMirrorLabelKeys orders labels so everything derived from them is deterministic
+func SortedMirrorLabelKeys(labels map[string]string) []string {
+	return slices.Sorted(maps.Keys(labels))
+}
+
+// FormatMirrorLabels renders labels as k1=v1,k2=v2 sorted by key
+func FormatMirrorLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, key := range SortedMirrorLabelKeys(labels) {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// MirrorQueryTag identifies queries a mirror runs on a destination, for attribution in the query history of the destination
+func MirrorQueryTag(flowName string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return "peerdb_mirror=" + flowName
+	}
+	return "peerdb_mirror=" + flowName + "," + FormatMirrorLabels(labels)
+}
+
+func WithMirrorLabels(ctx context.Context, labels map[string]string) context.Context {
+	if len(labels) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, MirrorLabelsKey, labels)
+}
+
+func MirrorLabels(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(MirrorLabelsKey).(map[string]string)
+	return labels
+}
@@ -0,0 +1,30 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMirrorLabels(t *testing.T) {
+	require.NoError(t, ValidateMirrorLabels(nil))
+	require.NoError(t, ValidateMirrorLabels(map[string]string{"team": "data", "cost_center": "42", "_env": ""}))
+	require.Error(t, ValidateMirrorLabels(map[string]string{"Team": "data"}))
+	require.Error(t, ValidateMirrorLabels(map[string]string{"1env": "prod"}))
+	require.Error(t, ValidateMirrorLabels(map[string]string{"cost-center": "42"}))
+	require.Error(t, ValidateMirrorLabels(map[string]string{strings.Repeat("k", 64): "v"}))
+	require.Error(t, ValidateMirrorLabels(map[string]string{"team": strings.Repeat("v", 257)}))
+
+	tooMany := make(map[string]string)
+	for i := range 17 {
+		tooMany["label_"+string(rune('a'+i))] = "v"
+	}
+	require.Error(t, ValidateMirrorLabels(tooMany))
+}
+
+func TestMirrorQueryTag(t *testing.T) {
+	require.Equal(t, "peerdb_mirror=orders", MirrorQueryTag("orders", nil))
+	require.Equal(t, "peerdb_mirror=orders,env=prod,team=data",
+		MirrorQueryTag("orders", map[string]string{"team": "data", "env": "prod"}))
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// Recorder receives health metrics of mirrors from connectors and activities,
+// workers attach one to the context of activities, nothing is recorded without one
+type Recorder interface {
+	RowsPulled(ctx context.Context, flowName string, rows int64)
+	RowsSynced(ctx context.Context, flowName string, rows int64)
+	NormalizeBatchDuration(ctx context.Context, flowName string, duration time.Duration)
+	SlotLag(ctx context.Context, flowName string, slotName string, lagMB float64)
+	SyncSucceeded(ctx context.Context, flowName string, syncedAt time.Time)
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose connectors and activities record to recorder
+func WithRecorder(ctx context.Context, recorder Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+func recorderFromCtx(ctx context.Context) Recorder {
+	recorder, _ := ctx.Value(recorderKey{}).(Recorder)
+	return recorder
+}
+
+// RowsPulled counts rows read from the source for the mirror
+func RowsPulled(ctx context.Context, flowName string, rows int64) {
+	if recorder := recorderFromCtx(ctx); recorder != nil {
+		recorder.RowsPulled(ctx, flowName, rows)
+	}
+}
+
+// Synced counts rows written to the destination by a sync that succeeded and records when it did
+func Synced(ctx context.Context, flowName string, rows int64) {
+	if recorder := recorderFromCtx(ctx); recorder != nil {
+		recorder.RowsSynced(ctx, flowName, rows)
+		recorder.SyncSucceeded(ctx, flowName, time.Now())
+	}
+}
+
+// NormalizeBatchDuration records how long normalizing batches of the mirror took
+func NormalizeBatchDuration(ctx context.Context, flowName string, duration time.Duration) {
+	if recorder := recorderFromCtx(ctx); recorder != nil {
+		recorder.NormalizeBatchDuration(ctx, flowName, duration)
+	}
+}
+
+// SlotLag records how far the replication slot of the mirror is behind the source
+func SlotLag(ctx context.Context, flowName string, slotName string, lagMB float64) {
+	if recorder := recorderFromCtx(ctx); recorder != nil {
+		recorder.SlotLag(ctx, flowName, slotName, lagMB)
+	}
+}
+
+type multiRecorder []Recorder
+
+// Multi records to each of recorders
+func Multi(recorders ...Recorder) Recorder {
+	return multiRecorder(recorders)
+}
+
+func (m multiRecorder) RowsPulled(ctx context.Context, flowName string, rows int64) {
+	for _, recorder := range m {
+		recorder.RowsPulled(ctx, flowName, rows)
+	}
+}
+
+func (m multiRecorder) RowsSynced(ctx context.Context, flowName string, rows int64) {
+	for _, recorder := range m {
+		recorder.RowsSynced(ctx, flowName, rows)
+	}
+}
+
+func (m multiRecorder) NormalizeBatchDuration(ctx context.Context, flowName string, duration time.Duration) {
+	for _, recorder := range m {
+		recorder.NormalizeBatchDuration(ctx, flowName, duration)
+	}
+}
+
+func (m multiRecorder) SlotLag(ctx context.Context, flowName string, slotName string, lagMB float64) {
+	for _, recorder := range m {
+		recorder.SlotLag(ctx, flowName, slotName, lagMB)
+	}
+}
+
+func (m multiRecorder) SyncSucceeded(ctx context.Context, flowName string, syncedAt time.Time) {
+	for _, recorder := range m {
+		recorder.SyncSucceeded(ctx, flowName, syncedAt)
+	}
+}
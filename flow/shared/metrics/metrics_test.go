@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRecorder struct {
+	rowsPulled int64
+	rowsSynced int64
+	syncedAt   time.Time
+	slotLag    map[string]float64
+}
+
+func (r *testRecorder) RowsPulled(_ context.Context, _ string, rows int64) {
+	r.rowsPulled += rows
+}
+
+func (r *testRecorder) RowsSynced(_ context.Context, _ string, rows int64) {
+	r.rowsSynced += rows
+}
+
+func (r *testRecorder) NormalizeBatchDuration(context.Context, string, time.Duration) {}
+
+func (r *testRecorder) SlotLag(_ context.Context, _ string, slotName string, lagMB float64) {
+	r.slotLag[slotName] = lagMB
+}
+
+func (r *testRecorder) SyncSucceeded(_ context.Context, _ string, syncedAt time.Time) {
+	r.syncedAt = syncedAt
+}
+
+func TestRecorder(t *testing.T) {
+	// nothing to record to
+	RowsPulled(context.Background(), "mirror", 10)
+
+	first := &testRecorder{slotLag: make(map[string]float64)}
+	second := &testRecorder{slotLag: make(map[string]float64)}
+	ctx := WithRecorder(context.Background(), Multi(first, second))
+	RowsPulled(ctx, "mirror", 10)
+	Synced(ctx, "mirror", 7)
+	Synced(ctx, "mirror", 3)
+	SlotLag(ctx, "mirror", "peerflow_slot_mirror", 12.5)
+
+	for _, recorder := range []*testRecorder{first, second} {
+		require.Equal(t, int64(10), recorder.rowsPulled)
+		require.Equal(t, int64(10), recorder.rowsSynced)
+		require.False(t, recorder.syncedAt.IsZero())
+		require.Equal(t, map[string]float64{"peerflow_slot_mirror": 12.5}, recorder.slotLag)
+	}
+}
@@ -0,0 +1,50 @@
+package shared
+
+import (
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// ApplyMirrorDefaults fills options cfg leaves at their zero value with the defaults of its destination peer.
+// Bidirectional mirrors keep peerdb columns off since they would be replicated back to tables without them
+func ApplyMirrorDefaults(cfg *protos.FlowConnectionConfigs, defaults *protos.MirrorDefaults) {
+	if defaults == nil {
+		return
+	}
+	if !cfg.Bidirectional {
+		if cfg.SoftDeleteColName == "" {
+			cfg.SoftDeleteColName = defaults.SoftDeleteColName
+		}
+		if cfg.SyncedAtColName == "" {
+			cfg.SyncedAtColName = defaults.SyncedAtColName
+		}
+	}
+	if cfg.SnapshotStagingPath == "" {
+		cfg.SnapshotStagingPath = defaults.StagingPath
+	}
+	if cfg.CdcStagingPath == "" {
+		cfg.CdcStagingPath = defaults.StagingPath
+	}
+	if cfg.TypeWideningPolicy == protos.TypeWideningPolicy_TYPE_WIDENING_POLICY_NONE {
+		cfg.TypeWideningPolicy = defaults.TypeWideningPolicy
+	}
+	if cfg.TruncatePolicy == protos.TruncatePolicy_TRUNCATE_POLICY_IGNORE {
+		cfg.TruncatePolicy = defaults.TruncatePolicy
+	}
+	cfg.StrictTypeMapping = cfg.StrictTypeMapping || defaults.StrictTypeMapping
+}
+
+// ApplyQRepMirrorDefaults is ApplyMirrorDefaults for the options QRep mirrors share with CDC mirrors
+func ApplyQRepMirrorDefaults(cfg *protos.QRepConfig, defaults *protos.MirrorDefaults) {
+	if defaults == nil {
+		return
+	}
+	if cfg.SoftDeleteColName == "" {
+		cfg.SoftDeleteColName = defaults.SoftDeleteColName
+	}
+	if cfg.SyncedAtColName == "" {
+		cfg.SyncedAtColName = defaults.SyncedAtColName
+	}
+	if cfg.StagingPath == "" {
+		cfg.StagingPath = defaults.StagingPath
+	}
+}
@@ -0,0 +1,43 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestApplyMirrorDefaults(t *testing.T) {
+	defaults := &protos.MirrorDefaults{
+		SoftDeleteColName:  "_PEERDB_IS_DELETED",
+		SyncedAtColName:    "_PEERDB_SYNCED_AT",
+		StagingPath:        "s3://bucket/staging",
+		TypeWideningPolicy: protos.TypeWideningPolicy_TYPE_WIDENING_POLICY_LOSSLESS,
+		TruncatePolicy:     protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE,
+	}
+
+	cfg := &protos.FlowConnectionConfigs{SyncedAtColName: "synced_at", CdcStagingPath: "gs://bucket/cdc"}
+	ApplyMirrorDefaults(cfg, defaults)
+	require.Equal(t, "_PEERDB_IS_DELETED", cfg.SoftDeleteColName)
+	require.Equal(t, "synced_at", cfg.SyncedAtColName)
+	require.Equal(t, "s3://bucket/staging", cfg.SnapshotStagingPath)
+	require.Equal(t, "gs://bucket/cdc", cfg.CdcStagingPath)
+	require.Equal(t, protos.TypeWideningPolicy_TYPE_WIDENING_POLICY_LOSSLESS, cfg.TypeWideningPolicy)
+	require.Equal(t, protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE, cfg.TruncatePolicy)
+	require.False(t, cfg.StrictTypeMapping)
+
+	bidirectional := &protos.FlowConnectionConfigs{Bidirectional: true}
+	ApplyMirrorDefaults(bidirectional, defaults)
+	require.Empty(t, bidirectional.SoftDeleteColName)
+	require.Empty(t, bidirectional.SyncedAtColName)
+
+	unchanged := &protos.FlowConnectionConfigs{}
+	ApplyMirrorDefaults(unchanged, nil)
+	require.Empty(t, unchanged.SnapshotStagingPath)
+
+	qrepCfg := &protos.QRepConfig{StagingPath: "gs://bucket/qrep"}
+	ApplyQRepMirrorDefaults(qrepCfg, defaults)
+	require.Equal(t, "_PEERDB_IS_DELETED", qrepCfg.SoftDeleteColName)
+	require.Equal(t, "gs://bucket/qrep", qrepCfg.StagingPath)
+}
@@ -0,0 +1,77 @@
+package shared
+
+import (
+	"slices"
+)
+
+// MirrorDependents returns mirrors depending on name directly or through other mirrors, each after the mirrors
+// it depends on, dependsOn being the mirrors each mirror depends on
+func MirrorDependents(dependsOn map[string][]string, name string) []string {
+	dependents := make(map[string][]string)
+	for mirror, dependencies := range dependsOn {
+		for _, dependency := range dependencies {
+			dependents[dependency] = append(dependents[dependency], mirror)
+		}
+	}
+
+	reached := make(map[string]struct{})
+	queue := []string{name}
+	for len(queue) > 0 {
+		mirror := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[mirror] {
+			if _, ok := reached[dependent]; !ok && dependent != name {
+				reached[dependent] = struct{}{}
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	// mirrors go once none of their dependencies among those reached is left, sorted for stable order
+	ordered := make([]string, 0, len(reached))
+	for len(reached) > 0 {
+		var ready []string
+		for mirror := range reached {
+			if !slices.ContainsFunc(dependsOn[mirror], func(dependency string) bool {
+				_, ok := reached[dependency]
+				return ok
+			}) {
+				ready = append(ready, mirror)
+			}
+		}
+		if len(ready) == 0 {
+			// cycles are rejected when mirrors are created, still don't spin on one
+			for mirror := range reached {
+				ready = append(ready, mirror)
+			}
+		}
+		slices.Sort(ready)
+		for _, mirror := range ready {
+			delete(reached, mirror)
+		}
+		ordered = append(ordered, ready...)
+	}
+	return ordered
+}
+
+// MirrorDependencyCycle returns a chain of dependencies leading from name back to it, nil when there is none
+func MirrorDependencyCycle(dependsOn map[string][]string, name string) []string {
+	visited := make(map[string]struct{})
+	var visit func(mirror string, path []string) []string
+	visit = func(mirror string, path []string) []string {
+		for _, dependency := range dependsOn[mirror] {
+			if dependency == name {
+				return append(path, name)
+			}
+			if _, ok := visited[dependency]; ok {
+				continue
+			}
+			visited[dependency] = struct{}{}
+			if cycle := visit(dependency, append(path, dependency)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit(name, []string{name})
+}
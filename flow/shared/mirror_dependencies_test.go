@@ -0,0 +1,37 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorDependents(t *testing.T) {
+	dependsOn := map[string][]string{
+		"facts":   {"dims", "users"},
+		"rollups": {"facts"},
+		"report":  {"rollups", "dims"},
+		"users":   {"dims"},
+		"other":   {"unrelated"},
+	}
+	require.Equal(t, []string{"users", "facts", "rollups", "report"}, MirrorDependents(dependsOn, "dims"))
+	require.Equal(t, []string{"rollups", "report"}, MirrorDependents(dependsOn, "facts"))
+	require.Empty(t, MirrorDependents(dependsOn, "report"))
+	require.Empty(t, MirrorDependents(dependsOn, "missing"))
+
+	// cycles terminate
+	require.ElementsMatch(t, []string{"b", "c"}, MirrorDependents(map[string][]string{"b": {"a", "c"}, "c": {"b"}}, "a"))
+}
+
+func TestMirrorDependencyCycle(t *testing.T) {
+	dependsOn := map[string][]string{
+		"facts":   {"dims"},
+		"rollups": {"facts"},
+	}
+	require.Nil(t, MirrorDependencyCycle(dependsOn, "rollups"))
+	require.Nil(t, MirrorDependencyCycle(dependsOn, "dims"))
+
+	dependsOn["dims"] = []string{"rollups"}
+	require.Equal(t, []string{"dims", "rollups", "facts", "dims"}, MirrorDependencyCycle(dependsOn, "dims"))
+	require.Equal(t, []string{"self", "self"}, MirrorDependencyCycle(map[string][]string{"self": {"self"}}, "self"))
+}
@@ -26,6 +26,7 @@ const (
 	POSTGRES_13 PGVersion = 130000
 	POSTGRES_14 PGVersion = 140000
 	POSTGRES_15 PGVersion = 150000
+	POSTGRES_16 PGVersion = 160000
 )
 
 func GetPGConnectionString(pgConfig *protos.PostgresConfig) string {
@@ -39,6 +40,10 @@ func GetPGConnectionString(pgConfig *protos.PostgresConfig) string {
 		pgConfig.Port,
 		pgConfig.Database,
 	)
+	if pgConfig.Platform == protos.PostgresPlatform_POSTGRES_PLATFORM_NEON {
+		// suspended Neon computes take a few seconds to start on connect
+		connString += "&connect_timeout=30"
+	}
 	return connString
 }
 
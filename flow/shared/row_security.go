@@ -0,0 +1,9 @@
+package shared
+
+const (
+	// RowAccessTable grants roles tags of rows of destination tables with row security in its schema,
+	// a row (tag, role_name) for each tag a role sees
+	RowAccessTable = "peerdb_row_access"
+	// RowSecurityPolicy is the name of the policy restricting rows of destination tables with row security
+	RowSecurityPolicy = "peerdb_row_security"
+)
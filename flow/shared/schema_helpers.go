@@ -34,6 +34,8 @@ func AdditionalTablesHasOverlap(currentTableMappings []*protos.TableMapping,
 // given the output of GetTableSchema, processes it to be used by CDCFlow
 // 1) changes the map key to be the destination table name instead of the source table name
 // 2) performs column exclusion using protos.TableMapping as input.
+// 3) makes columns encrypted before landing text.
+// 4) adds the tag column of row security.
 func BuildProcessedSchemaMapping(tableMappings []*protos.TableMapping,
 	tableNameSchemaMapping map[string]*protos.TableSchema,
 	logger log.Logger,
@@ -64,6 +66,28 @@ func BuildProcessedSchemaMapping(tableMappings []*protos.TableMapping,
 						Columns:               columns,
 					}
 				}
+				if len(mapping.PrimaryKeyOverride) != 0 {
+					tableSchema = CloneProto(tableSchema)
+					tableSchema.PrimaryKeyColumns = slices.Clone(mapping.PrimaryKeyOverride)
+				}
+				if encrypted := EncryptedColumns(mapping); len(encrypted) != 0 {
+					tableSchema = CloneProto(tableSchema)
+					for _, column := range tableSchema.Columns {
+						if _, ok := encrypted[column.Name]; ok {
+							column.Type = TextColumnType(tableSchema.System)
+							column.TypeModifier = -1
+						}
+					}
+				}
+				if tagColumn := mapping.RowSecurity.GetTagColumn(); tagColumn != "" {
+					tableSchema = CloneProto(tableSchema)
+					tableSchema.Columns = append(tableSchema.Columns, &protos.FieldDescription{
+						Name:         tagColumn,
+						Type:         TextColumnType(tableSchema.System),
+						TypeModifier: -1,
+						Nullable:     true,
+					})
+				}
 				break
 			}
 		}
@@ -0,0 +1,84 @@
+package shared
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestBuildProcessedSchemaMapping(t *testing.T) {
+	schema := &protos.TableSchema{
+		TableIdentifier:   "public.users",
+		PrimaryKeyColumns: []string{"id"},
+		System:            protos.TypeSystem_Q,
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: "int64", TypeModifier: -1},
+			{Name: "email", Type: "string", TypeModifier: -1},
+			{Name: "ssn", Type: "string", TypeModifier: -1},
+			{Name: "tenant_id", Type: "int64", TypeModifier: -1},
+		},
+	}
+	processed := BuildProcessedSchemaMapping([]*protos.TableMapping{{
+		SourceTableIdentifier:      "public.users",
+		DestinationTableIdentifier: "staging.users",
+		Exclude:                    []string{"ssn"},
+		Columns: []*protos.ColumnSetting{{SourceName: "id", Encryption: &protos.ColumnEncryption{
+			Mode: protos.ColumnEncryptionMode_COLUMN_ENCRYPTION_DETERMINISTIC, KeyName: "k",
+		}}},
+		RowSecurity: &protos.RowSecurity{TagColumn: "row_tag", SourceColumn: "tenant_id"},
+	}}, map[string]*protos.TableSchema{"public.users": schema}, log.NewStructuredLogger(slog.Default()))
+
+	users := processed["staging.users"]
+	require.NotNil(t, users)
+	var columns []string
+	for _, column := range users.Columns {
+		columns = append(columns, column.Name+" "+column.Type)
+	}
+	require.Equal(t, []string{"id string", "email string", "tenant_id int64", "row_tag string"}, columns)
+	// source schema is left as it was
+	require.Len(t, schema.Columns, 4)
+	require.Equal(t, "int64", schema.Columns[0].Type)
+}
+
+func TestBuildProcessedSchemaMappingPrimaryKeyOverride(t *testing.T) {
+	schema := &protos.TableSchema{
+		TableIdentifier:       "public.events",
+		PrimaryKeyColumns:     []string{"id", "shard"},
+		IsReplicaIdentityFull: true,
+		System:                protos.TypeSystem_Q,
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: "int64", TypeModifier: -1},
+			{Name: "shard", Type: "int64", TypeModifier: -1},
+			{Name: "event_uuid", Type: "string", TypeModifier: -1},
+		},
+	}
+	processed := BuildProcessedSchemaMapping([]*protos.TableMapping{{
+		SourceTableIdentifier:      "public.events",
+		DestinationTableIdentifier: "events",
+		Exclude:                    []string{"shard"},
+		PrimaryKeyOverride:         []string{"event_uuid"},
+	}}, map[string]*protos.TableSchema{"public.events": schema}, log.NewStructuredLogger(slog.Default()))
+
+	require.Equal(t, []string{"event_uuid"}, processed["events"].PrimaryKeyColumns)
+	require.Equal(t, []string{"id", "shard"}, schema.PrimaryKeyColumns)
+}
+
+func TestAnonymizedColumns(t *testing.T) {
+	profile := &protos.AnonymizationProfile{Rules: []*protos.AnonymizationRule{
+		{SourceTableIdentifier: "public.users", Column: "email", Value: protos.SyntheticValue_SYNTHETIC_FORMAT},
+		{Column: "email", Value: protos.SyntheticValue_SYNTHETIC_EMAIL},
+		{Column: "phone", Value: protos.SyntheticValue_SYNTHETIC_PHONE},
+	}}
+	require.Equal(t, map[string]protos.SyntheticValue{
+		"email": protos.SyntheticValue_SYNTHETIC_FORMAT,
+	}, AnonymizedColumns(profile, &protos.TableMapping{SourceTableIdentifier: "public.users", Exclude: []string{"phone"}}))
+	require.Equal(t, map[string]protos.SyntheticValue{
+		"email": protos.SyntheticValue_SYNTHETIC_EMAIL,
+		"phone": protos.SyntheticValue_SYNTHETIC_PHONE,
+	}, AnonymizedColumns(profile, &protos.TableMapping{SourceTableIdentifier: "public.orders"}))
+	require.Nil(t, AnonymizedColumns(nil, &protos.TableMapping{SourceTableIdentifier: "public.orders"}))
+}
@@ -0,0 +1,30 @@
+package shared
+
+import (
+	"regexp"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var secretReferenceRe = regexp.MustCompile(`^\$\{secret:([^}]+)\}$`)
+
+// ResolveSecretReferences replaces secret references in string fields of message, like ${secret:prod_pg/password},
+// with what lookup returns for the name referenced
+func ResolveSecretReferences(message protoreflect.Message, lookup func(name string) (string, error)) error {
+	var err error
+	message.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap() {
+			err = ResolveSecretReferences(v.Message(), lookup)
+		} else if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+			if match := secretReferenceRe.FindStringSubmatch(v.String()); match != nil {
+				var secret string
+				secret, err = lookup(match[1])
+				if err == nil {
+					message.Set(fd, protoreflect.ValueOfString(secret))
+				}
+			}
+		}
+		return err == nil
+	})
+	return err
+}
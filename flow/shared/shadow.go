@@ -0,0 +1,36 @@
+package shared
+
+import (
+	"strings"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const ShadowSchemaPrefix = "_peerdb_shadow_"
+
+// ShadowSchemaName is the scratch schema (or dataset) a shadow mode mirror writes into
+func ShadowSchemaName(flowJobName string) string {
+	return ShadowSchemaPrefix + ReplaceIllegalCharactersWithUnderscores(flowJobName)
+}
+
+func IsShadowSchema(schema string) bool {
+	return strings.HasPrefix(schema, ShadowSchemaPrefix)
+}
+
+// ShadowTableIdentifier moves a destination table identifier into the shadow schema of the mirror,
+// identifiers already pointing at a shadow schema are left as is so resync doesn't nest them
+func ShadowTableIdentifier(flowJobName string, tableIdentifier string) string {
+	schema, table, hasDot := strings.Cut(tableIdentifier, ".")
+	if !hasDot {
+		return ShadowSchemaName(flowJobName) + "." + tableIdentifier
+	} else if IsShadowSchema(schema) {
+		return tableIdentifier
+	}
+	return ShadowSchemaName(flowJobName) + "." + table
+}
+
+func ApplyShadowMode(flowJobName string, tableMappings []*protos.TableMapping) {
+	for _, tableMapping := range tableMappings {
+		tableMapping.DestinationTableIdentifier = ShadowTableIdentifier(flowJobName, tableMapping.DestinationTableIdentifier)
+	}
+}
@@ -0,0 +1,59 @@
+package shared
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrShareTokenInvalid = errors.New("invalid share token")
+	ErrShareTokenExpired = errors.New("share token expired")
+)
+
+// ShareTokenClaims is what a share token grants, read-only access to status of one mirror until it expires
+type ShareTokenClaims struct {
+	ID          string `json:"jti"`
+	FlowJobName string `json:"mirror"`
+	ExpiresAt   int64  `json:"exp"`
+}
+
+func signShareTokenPayload(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignShareToken encodes claims as base64url JSON followed by its HMAC-SHA256, separated by a dot
+func SignShareToken(secret []byte, claims ShareTokenClaims) (string, error) {
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(claimsJSON)
+	return payload + "." + signShareTokenPayload(secret, payload), nil
+}
+
+// VerifyShareToken returns claims of a token signed with secret, failing for tokens expired as of now
+func VerifyShareToken(secret []byte, token string, now time.Time) (ShareTokenClaims, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signShareTokenPayload(secret, payload))) {
+		return ShareTokenClaims{}, ErrShareTokenInvalid
+	}
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ShareTokenClaims{}, ErrShareTokenInvalid
+	}
+	var claims ShareTokenClaims
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil || claims.FlowJobName == "" {
+		return ShareTokenClaims{}, ErrShareTokenInvalid
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return ShareTokenClaims{}, ErrShareTokenExpired
+	}
+	return claims, nil
+}
@@ -0,0 +1,33 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+	claims := ShareTokenClaims{ID: "abc", FlowJobName: "orders", ExpiresAt: now.Add(time.Hour).Unix()}
+	token, err := SignShareToken(secret, claims)
+	require.NoError(t, err)
+
+	verified, err := VerifyShareToken(secret, token, now)
+	require.NoError(t, err)
+	require.Equal(t, claims, verified)
+
+	_, err = VerifyShareToken(secret, token, now.Add(time.Hour))
+	require.ErrorIs(t, err, ErrShareTokenExpired)
+	_, err = VerifyShareToken([]byte("other"), token, now)
+	require.ErrorIs(t, err, ErrShareTokenInvalid)
+
+	tampered, err := SignShareToken([]byte("other"), ShareTokenClaims{ID: "abc", FlowJobName: "users", ExpiresAt: claims.ExpiresAt})
+	require.NoError(t, err)
+	payload, _, _ := strings.Cut(tampered, ".")
+	_, signature, _ := strings.Cut(token, ".")
+	_, err = VerifyShareToken(secret, payload+"."+signature, now)
+	require.ErrorIs(t, err, ErrShareTokenInvalid)
+}
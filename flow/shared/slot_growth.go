@@ -0,0 +1,47 @@
+package shared
+
+import (
+	"math"
+	"time"
+)
+
+// SlotGrowth is how fast WAL retained by a replication slot changes,
+// the source generating WAL and the mirror consuming it by advancing the restart LSN of the slot
+type SlotGrowth struct {
+	GeneratedMBPerHour float64
+	ConsumedMBPerHour  float64
+}
+
+// NewSlotGrowth measures growth of a slot between two samples of the current and restart LSNs taken elapsed apart,
+// LSNs going back like after a failover measure no growth
+func NewSlotGrowth(prevCurrentLSN uint64, prevRestartLSN uint64, currentLSN uint64, restartLSN uint64,
+	elapsed time.Duration,
+) SlotGrowth {
+	if elapsed <= 0 || currentLSN < prevCurrentLSN || restartLSN < prevRestartLSN {
+		return SlotGrowth{}
+	}
+	hours := elapsed.Hours()
+	return SlotGrowth{
+		GeneratedMBPerHour: float64(currentLSN-prevCurrentLSN) / 1024 / 1024 / hours,
+		ConsumedMBPerHour:  float64(restartLSN-prevRestartLSN) / 1024 / 1024 / hours,
+	}
+}
+
+// MBPerHour is the net growth of the slot
+func (g SlotGrowth) MBPerHour() float64 {
+	return g.GeneratedMBPerHour - g.ConsumedMBPerHour
+}
+
+// TimeToExceed predicts how long until a slot of sizeMB exceeds thresholdMB at current rates,
+// false when the slot isn't growing or already exceeds it
+func (g SlotGrowth) TimeToExceed(sizeMB float64, thresholdMB float64) (time.Duration, bool) {
+	growth := g.MBPerHour()
+	if growth <= 0 || sizeMB >= thresholdMB {
+		return 0, false
+	}
+	hours := (thresholdMB - sizeMB) / growth
+	if hours >= math.MaxInt64/float64(time.Hour) {
+		return 0, false
+	}
+	return time.Duration(hours * float64(time.Hour)), true
+}
@@ -0,0 +1,32 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotGrowth(t *testing.T) {
+	const mb = 1024 * 1024
+	growth := NewSlotGrowth(1000*mb, 900*mb, 4000*mb, 1900*mb, 30*time.Minute)
+	require.InDelta(t, 6000, growth.GeneratedMBPerHour, 0.001)
+	require.InDelta(t, 2000, growth.ConsumedMBPerHour, 0.001)
+
+	eta, ok := growth.TimeToExceed(2100, 50*1024)
+	require.True(t, ok)
+	require.Equal(t, (51200-2100)*time.Hour/4000, eta)
+
+	_, ok = growth.TimeToExceed(60*1024, 50*1024)
+	require.False(t, ok)
+}
+
+func TestSlotGrowthShrinking(t *testing.T) {
+	growth := NewSlotGrowth(1000, 900, 2000, 1950, time.Hour)
+	require.Negative(t, growth.MBPerHour())
+	_, ok := growth.TimeToExceed(1, 100)
+	require.False(t, ok)
+
+	// LSNs going back after a failover can't tell growth
+	require.Equal(t, SlotGrowth{}, NewSlotGrowth(2000, 1000, 1500, 1200, time.Hour))
+}
@@ -0,0 +1,53 @@
+package shared
+
+// SyncInterval adapts the idle timeout of syncs to the change rate of the source.
+// Batches under the threshold double the idle timeout up to the maximum, so quiet sources sync rarely,
+// while backed off a batch syncs as soon as it reaches the threshold and the idle timeout returns to base.
+type SyncInterval struct {
+	BaseSeconds      uint64
+	MaxSeconds       uint64
+	RecordsThreshold uint32
+	seconds          uint64
+}
+
+func NewSyncInterval(baseSeconds uint64, maxSeconds uint64, recordsThreshold uint32) *SyncInterval {
+	return &SyncInterval{
+		BaseSeconds:      baseSeconds,
+		MaxSeconds:       maxSeconds,
+		RecordsThreshold: recordsThreshold,
+		seconds:          baseSeconds,
+	}
+}
+
+// Enabled is false when the maximum doesn't exceed the base, syncs then keep the base idle timeout
+func (s *SyncInterval) Enabled() bool {
+	return s.MaxSeconds > s.BaseSeconds && s.RecordsThreshold > 0
+}
+
+// IdleTimeoutSeconds is the idle timeout for the next sync
+func (s *SyncInterval) IdleTimeoutSeconds() uint64 {
+	if !s.Enabled() {
+		return s.BaseSeconds
+	}
+	return s.seconds
+}
+
+// BatchSize caps batchSize at the threshold while backed off so spikes don't wait out the idle timeout
+func (s *SyncInterval) BatchSize(batchSize uint32) uint32 {
+	if !s.Enabled() || s.seconds <= s.BaseSeconds || (batchSize != 0 && batchSize <= s.RecordsThreshold) {
+		return batchSize
+	}
+	return s.RecordsThreshold
+}
+
+// Observe adapts the idle timeout to the number of records the last sync had
+func (s *SyncInterval) Observe(numRecords int64) {
+	if !s.Enabled() {
+		return
+	}
+	if numRecords >= int64(s.RecordsThreshold) {
+		s.seconds = s.BaseSeconds
+	} else {
+		s.seconds = min(max(s.seconds*2, 1), s.MaxSeconds)
+	}
+}
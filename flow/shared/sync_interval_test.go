@@ -0,0 +1,32 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncInterval(t *testing.T) {
+	interval := NewSyncInterval(10, 60, 1000)
+	require.Equal(t, uint64(10), interval.IdleTimeoutSeconds())
+	require.Equal(t, uint32(250000), interval.BatchSize(250000))
+
+	interval.Observe(5)
+	require.Equal(t, uint64(20), interval.IdleTimeoutSeconds())
+	require.Equal(t, uint32(1000), interval.BatchSize(250000))
+	require.Equal(t, uint32(500), interval.BatchSize(500))
+	interval.Observe(0)
+	interval.Observe(0)
+	require.Equal(t, uint64(60), interval.IdleTimeoutSeconds())
+
+	interval.Observe(1000)
+	require.Equal(t, uint64(10), interval.IdleTimeoutSeconds())
+	require.Equal(t, uint32(250000), interval.BatchSize(250000))
+}
+
+func TestSyncIntervalDisabled(t *testing.T) {
+	interval := NewSyncInterval(10, 0, 1000)
+	interval.Observe(0)
+	require.Equal(t, uint64(10), interval.IdleTimeoutSeconds())
+	require.Equal(t, uint32(250000), interval.BatchSize(250000))
+}
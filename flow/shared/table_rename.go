@@ -0,0 +1,16 @@
+package shared
+
+import "strings"
+
+// RenamedDestinationTable is what a destination table is renamed to when its source table is renamed from
+// srcTableName to renamedTo, destination tables named apart from their source table keep their name
+func RenamedDestinationTable(dstTableName string, srcTableName string, renamedTo string) string {
+	prefix, table := "", dstTableName
+	if i := strings.LastIndexByte(dstTableName, '.'); i >= 0 {
+		prefix, table = dstTableName[:i+1], dstTableName[i+1:]
+	}
+	if table != srcTableName[strings.LastIndexByte(srcTableName, '.')+1:] {
+		return dstTableName
+	}
+	return prefix + renamedTo[strings.LastIndexByte(renamedTo, '.')+1:]
+}
@@ -0,0 +1,16 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenamedDestinationTable(t *testing.T) {
+	require.Equal(t, "analytics.customers", RenamedDestinationTable("analytics.users", "public.users", "public.customers"))
+	// destinations without schemas, like ClickHouse
+	require.Equal(t, "customers", RenamedDestinationTable("users", "public.users", "public.customers"))
+	// moving tables between schemas renames only within the destination schema
+	require.Equal(t, "public.users", RenamedDestinationTable("public.users", "public.users", "archive.users"))
+	require.Equal(t, "public.people", RenamedDestinationTable("public.people", "public.users", "public.customers"))
+}
@@ -0,0 +1,25 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+// ValidateTruncatePolicy checks TRUNCATE can be applied to destination tables of cfg as its truncate policy says.
+// Mirrors with a source identifier share destination tables with other sources, applying a TRUNCATE of one
+// source to them would remove or mark deleted the rows of every other source as well
+func ValidateTruncatePolicy(cfg *protos.FlowConnectionConfigs) error {
+	if cfg.TruncatePolicy == protos.TruncatePolicy_TRUNCATE_POLICY_IGNORE {
+		return nil
+	}
+	if cfg.TruncatePolicy == protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE && cfg.SoftDeleteColName == "" {
+		return errors.New("truncate policy soft_delete requires a soft delete column")
+	}
+	if cfg.SourceIdentifier != "" {
+		return fmt.Errorf("truncate policy %s can't be used with a source identifier, "+
+			"destination tables are shared with other sources", cfg.TruncatePolicy)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestValidateTruncatePolicy(t *testing.T) {
+	require.NoError(t, ValidateTruncatePolicy(&protos.FlowConnectionConfigs{}))
+	require.NoError(t, ValidateTruncatePolicy(&protos.FlowConnectionConfigs{
+		TruncatePolicy: protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE,
+	}))
+	require.NoError(t, ValidateTruncatePolicy(&protos.FlowConnectionConfigs{
+		TruncatePolicy:    protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE,
+		SoftDeleteColName: "_peerdb_is_deleted",
+	}))
+	require.Error(t, ValidateTruncatePolicy(&protos.FlowConnectionConfigs{
+		TruncatePolicy: protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE,
+	}))
+
+	// one source's TRUNCATE must not reach rows of other sources in shared destination tables
+	require.NoError(t, ValidateTruncatePolicy(&protos.FlowConnectionConfigs{SourceIdentifier: "shard_1"}))
+	require.Error(t, ValidateTruncatePolicy(&protos.FlowConnectionConfigs{
+		TruncatePolicy:   protos.TruncatePolicy_TRUNCATE_POLICY_REPLICATE,
+		SourceIdentifier: "shard_1",
+	}))
+	require.Error(t, ValidateTruncatePolicy(&protos.FlowConnectionConfigs{
+		TruncatePolicy:    protos.TruncatePolicy_TRUNCATE_POLICY_SOFT_DELETE,
+		SoftDeleteColName: "_peerdb_is_deleted",
+		SourceIdentifier:  "shard_1",
+	}))
+}
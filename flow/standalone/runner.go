@@ -0,0 +1,147 @@
+// Package standalone runs simple CDC mirrors inside a single process, without Temporal,
+// for small deployments where running a Temporal cluster isn't worth it.
+// Mirrors are set up, snapshotted and synced by calling connectors directly, their state lives in the catalog.
+// Only Postgres sources are supported, mirrors with scripts, column transforms or row security need workers.
+package standalone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// retryInterval is how long a failed mirror waits before running again
+const retryInterval = time.Minute
+
+type Runner struct {
+	CatalogPool *pgxpool.Pool
+}
+
+func NewRunner(catalogPool *pgxpool.Pool) *Runner {
+	return &Runner{CatalogPool: catalogPool}
+}
+
+// validateMirror rejects mirrors needing what only workers apply to records
+func validateMirror(config *protos.FlowConnectionConfigs) error {
+	if !shared.IsValidReplicationName(config.FlowJobName) {
+		return fmt.Errorf("invalid mirror name %s, it should be ^[a-z_][a-z0-9_]*$", config.FlowJobName)
+	}
+	if config.System != protos.TypeSystem_Q {
+		return errors.New("standalone mirrors only support the Q type system")
+	}
+	if config.InitialSnapshotOnly {
+		return errors.New("standalone mirrors don't support initial snapshot only mirrors")
+	}
+	if config.Script != "" {
+		return errors.New("standalone mirrors don't support scripts")
+	}
+	if config.Anonymization != nil {
+		return errors.New("standalone mirrors don't support anonymization")
+	}
+	if config.TableRenamePolicy != protos.TableRenamePolicy_TABLE_RENAME_POLICY_KEEP_DESTINATION {
+		return fmt.Errorf("standalone mirrors only support the %s table rename policy",
+			protos.TableRenamePolicy_TABLE_RENAME_POLICY_KEEP_DESTINATION)
+	}
+	for _, mapping := range config.TableMappings {
+		if len(shared.EncryptedColumns(mapping)) > 0 {
+			return fmt.Errorf("standalone mirrors don't support encrypted columns, found on %s", mapping.SourceTableIdentifier)
+		}
+		if mapping.RowSecurity != nil {
+			return fmt.Errorf("standalone mirrors don't support row security, found on %s", mapping.SourceTableIdentifier)
+		}
+	}
+	return nil
+}
+
+// Run runs mirrors until ctx is done, retrying failed mirrors.
+// Mirrors failing validation, or whose setup got interrupted after the slot was created, are given up on
+func (r *Runner) Run(ctx context.Context, mirrors []*protos.FlowConnectionConfigs) error {
+	for _, config := range mirrors {
+		if err := validateMirror(config); err != nil {
+			return fmt.Errorf("invalid standalone mirror %s: %w", config.FlowJobName, err)
+		}
+		if err := r.upsertMirror(ctx, config); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, config := range mirrors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.runWithRetries(ctx, config)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (r *Runner) runWithRetries(ctx context.Context, config *protos.FlowConnectionConfigs) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
+	logger := slog.With(slog.String(string(shared.FlowNameKey), config.FlowJobName))
+	for {
+		err := r.runMirror(ctx, config)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error("standalone mirror failed", slog.Any("error", err))
+		if saveErr := r.saveError(ctx, config.FlowJobName, err); saveErr != nil {
+			logger.Warn("failed to save error of standalone mirror", slog.Any("error", saveErr))
+		}
+		if errors.Is(err, connpostgres.ErrSlotAlreadyExists) {
+			logger.Error("setup of standalone mirror was interrupted after creating its slot, " +
+				"drop the slot and destination tables to run it again")
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (r *Runner) runMirror(ctx context.Context, config *protos.FlowConnectionConfigs) error {
+	status, options, err := r.loadState(ctx, config.FlowJobName)
+	if err != nil {
+		return err
+	}
+	if status == statusSetup || options == nil {
+		if options, err = r.setup(ctx, config); err != nil {
+			return err
+		}
+		if err := r.saveState(ctx, config.FlowJobName, statusRunning, options); err != nil {
+			return err
+		}
+	} else if !sameTables(options.TableMappings, config.TableMappings) {
+		return errors.New("standalone mirrors can't add or remove tables after setup")
+	}
+	// settings other than tables can change between runs
+	options.BatchSize = config.MaxBatchSize
+	options.IdleTimeoutSeconds = config.IdleTimeoutSeconds
+	return r.sync(ctx, config, options)
+}
+
+// sameTables compares destination tables, as mappings saved in state follow renamed source tables
+func sameTables(a []*protos.TableMapping, b []*protos.TableMapping) bool {
+	tables := func(mappings []*protos.TableMapping) []string {
+		names := make([]string, 0, len(mappings))
+		for _, mapping := range mappings {
+			names = append(names, mapping.DestinationTableIdentifier)
+		}
+		slices.Sort(names)
+		return names
+	}
+	return slices.Equal(tables(a), tables(b))
+}
@@ -0,0 +1,53 @@
+package standalone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+func TestValidateMirror(t *testing.T) {
+	config := &protos.FlowConnectionConfigs{
+		FlowJobName: "orders_mirror",
+		System:      protos.TypeSystem_Q,
+		TableMappings: []*protos.TableMapping{
+			{SourceTableIdentifier: "public.orders", DestinationTableIdentifier: "orders"},
+		},
+	}
+	require.NoError(t, validateMirror(config))
+
+	config.Script = "transform"
+	require.ErrorContains(t, validateMirror(config), "scripts")
+	config.Script = ""
+
+	config.System = protos.TypeSystem_PG
+	require.ErrorContains(t, validateMirror(config), "type system")
+	config.System = protos.TypeSystem_Q
+
+	config.TableRenamePolicy = protos.TableRenamePolicy_TABLE_RENAME_POLICY_PAUSE
+	require.ErrorContains(t, validateMirror(config), "table rename policy")
+	config.TableRenamePolicy = protos.TableRenamePolicy_TABLE_RENAME_POLICY_KEEP_DESTINATION
+
+	config.FlowJobName = "Orders-Mirror"
+	require.ErrorContains(t, validateMirror(config), "invalid mirror name")
+}
+
+func TestSameTables(t *testing.T) {
+	a := []*protos.TableMapping{
+		{SourceTableIdentifier: "public.a", DestinationTableIdentifier: "a"},
+		{SourceTableIdentifier: "public.b", DestinationTableIdentifier: "b"},
+	}
+	b := []*protos.TableMapping{
+		{SourceTableIdentifier: "public.b", DestinationTableIdentifier: "b"},
+		{SourceTableIdentifier: "public.a", DestinationTableIdentifier: "a"},
+	}
+	require.True(t, sameTables(a, b))
+	require.False(t, sameTables(a, b[:1]))
+	// state follows renamed source tables
+	b[1].SourceTableIdentifier = "public.renamed_a"
+	require.True(t, sameTables(a, b))
+	b[0].DestinationTableIdentifier = "b2"
+	require.False(t, sameTables(a, b))
+}
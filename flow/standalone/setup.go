@@ -0,0 +1,323 @@
+package standalone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/PeerDB-io/peer-flow/connectors"
+	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
+	"github.com/PeerDB-io/peer-flow/connectors/utils"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// setup does what the setup and snapshot flows do for a mirror, returning the options to sync with
+func (r *Runner) setup(ctx context.Context, config *protos.FlowConnectionConfigs) (*protos.SyncFlowOptions, error) {
+	logger := logger.LoggerFromCtx(ctx)
+	srcConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, config.Env, r.CatalogPool, config.SourceName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, errors.New("standalone mirrors need a postgres source")
+		}
+		return nil, fmt.Errorf("failed to get source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	dstConn, err := connectors.GetByNameAs[connectors.CDCSyncConnector](ctx, config.Env, r.CatalogPool, config.DestinationName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	if dstConn.NeedsSetupMetadataTables(ctx) {
+		if err := dstConn.SetupMetadataTables(ctx); err != nil {
+			return nil, fmt.Errorf("failed to setup metadata tables: %w", err)
+		}
+	}
+
+	tblNameMapping := make(map[string]string, len(config.TableMappings))
+	for _, v := range config.TableMappings {
+		tblNameMapping[v.SourceTableIdentifier] = v.DestinationTableIdentifier
+	}
+	srcTables := slices.Sorted(maps.Keys(tblNameMapping))
+
+	pullability, err := srcConn.EnsurePullability(ctx, &protos.EnsurePullabilityBatchInput{
+		PeerName:               config.SourceName,
+		FlowJobName:            config.FlowJobName,
+		SourceTableIdentifiers: srcTables,
+		CheckConstraints:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure pullability: %w", err)
+	}
+	srcTableIdNameMapping := make(map[uint32]string, len(pullability.TableIdentifierMapping))
+	for tableName, tableIdentifier := range pullability.TableIdentifierMapping {
+		srcTableIdNameMapping[tableIdentifier.RelId] = tableName
+	}
+
+	if _, err := dstConn.CreateRawTable(ctx, &protos.CreateRawTableInput{
+		PeerName:         config.DestinationName,
+		FlowJobName:      config.FlowJobName,
+		TableNameMapping: tblNameMapping,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create raw table: %w", err)
+	}
+	if err := monitoring.InitializeCDCFlow(ctx, r.CatalogPool, config.FlowJobName); err != nil {
+		return nil, err
+	}
+
+	schemas, err := srcConn.GetTableSchema(ctx, &protos.GetTableSchemaBatchInput{
+		PeerName:         config.SourceName,
+		TableIdentifiers: srcTables,
+		FlowName:         config.FlowJobName,
+		System:           config.System,
+		Env:              config.Env,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema for source tables: %w", err)
+	}
+	tableNameSchemaMapping := shared.BuildProcessedSchemaMapping(config.TableMappings, schemas.TableNameSchemaMapping, logger)
+	if config.SourceIdentifier != "" {
+		for dstTableName, tableSchema := range tableNameSchemaMapping {
+			tableNameSchemaMapping[dstTableName] = model.WithSourceIdentifierColumn(tableSchema)
+		}
+	}
+	if messageTable := config.LogicalMessageDestination; messageTable != "" {
+		tableNameSchemaMapping[messageTable] = model.MessageTableSchema(messageTable, config.System)
+	}
+	if err := r.setupNormalizedTables(ctx, config, tableNameSchemaMapping); err != nil {
+		return nil, err
+	}
+
+	if err := r.setupReplication(ctx, config, srcConn, tblNameMapping, schemas.TableNameSchemaMapping); err != nil {
+		return nil, err
+	}
+
+	return &protos.SyncFlowOptions{
+		BatchSize:              config.MaxBatchSize,
+		IdleTimeoutSeconds:     config.IdleTimeoutSeconds,
+		SrcTableIdNameMapping:  srcTableIdNameMapping,
+		TableNameSchemaMapping: tableNameSchemaMapping,
+		TableMappings:          config.TableMappings,
+	}, nil
+}
+
+func (r *Runner) setupNormalizedTables(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) error {
+	conn, err := connectors.GetByNameAs[connectors.NormalizedTablesConnector](ctx, config.Env, r.CatalogPool,
+		config.DestinationName)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return fmt.Errorf("failed to get connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, conn)
+
+	tx, err := conn.StartSetupNormalizedTables(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to setup normalized tables tx: %w", err)
+	}
+	defer conn.CleanupSetupNormalizedTables(ctx, tx)
+
+	input := &protos.SetupNormalizedTableBatchInput{
+		PeerName:               config.DestinationName,
+		TableNameSchemaMapping: tableNameSchemaMapping,
+		TableMappings:          config.TableMappings,
+		SoftDeleteColName:      config.SoftDeleteColName,
+		SyncedAtColName:        config.SyncedAtColName,
+		FlowName:               config.FlowJobName,
+		Env:                    config.Env,
+		Labels:                 config.Labels,
+	}
+	for tableIdentifier := range tableNameSchemaMapping {
+		if _, err := conn.SetupNormalizedTable(ctx, tx, input, tableIdentifier); err != nil {
+			return fmt.Errorf("failed to setup normalized table %s: %w", tableIdentifier, err)
+		}
+	}
+	if err := conn.FinishSetupNormalizedTables(ctx, tx); err != nil {
+		return fmt.Errorf("failed to commit normalized tables tx: %w", err)
+	}
+	return nil
+}
+
+// setupReplication creates the slot and publication, snapshotting tables while the slot holds its snapshot
+func (r *Runner) setupReplication(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	srcConn *connpostgres.PostgresConnector,
+	tblNameMapping map[string]string,
+	srcSchemas map[string]*protos.TableSchema,
+) error {
+	logger := logger.LoggerFromCtx(ctx)
+	slotSignal := connpostgres.NewSlotSignal()
+	replicationErr := make(chan error, 1)
+	go func() {
+		replicationErr <- srcConn.SetupReplication(ctx, slotSignal, &protos.SetupReplicationInput{
+			PeerName:                    config.SourceName,
+			FlowJobName:                 config.FlowJobName,
+			TableNameMapping:            tblNameMapping,
+			DoInitialSnapshot:           config.DoInitialSnapshot,
+			ExistingPublicationName:     config.PublicationName,
+			ExistingReplicationSlotName: config.ReplicationSlotName,
+		})
+	}()
+
+	var slotInfo connpostgres.SlotCreationResult
+	select {
+	case slotInfo = <-slotSignal.SlotCreated:
+		logger.Info("slot created", slog.String("SlotName", slotInfo.SlotName))
+	case err := <-replicationErr:
+		if err == nil {
+			err = errors.New("slot was not created")
+		}
+		return fmt.Errorf("failed to setup replication: %w", err)
+	}
+	if slotInfo.Err != nil {
+		close(slotSignal.CloneComplete)
+		return fmt.Errorf("slot error: %w", slotInfo.Err)
+	}
+
+	var snapshotErr error
+	if config.DoInitialSnapshot {
+		for _, mapping := range config.TableMappings {
+			if snapshotErr = r.snapshotTable(ctx, config, slotInfo.SnapshotName, mapping, srcSchemas); snapshotErr != nil {
+				break
+			}
+		}
+	}
+	close(slotSignal.CloneComplete)
+	if err := <-replicationErr; err != nil {
+		return fmt.Errorf("failed to setup replication: %w", err)
+	}
+	return snapshotErr
+}
+
+// snapshotTable copies a table from the exported snapshot of the slot, one partition at a time
+func (r *Runner) snapshotTable(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	snapshotName string,
+	mapping *protos.TableMapping,
+	srcSchemas map[string]*protos.TableSchema,
+) error {
+	logger := logger.LoggerFromCtx(ctx)
+	parsedSrcTable, err := utils.ParseSchemaTable(mapping.SourceTableIdentifier)
+	if err != nil {
+		return fmt.Errorf("unable to parse source table: %w", err)
+	}
+	from := "*"
+	if tableSchema, ok := srcSchemas[mapping.SourceTableIdentifier]; ok && len(mapping.Exclude) != 0 {
+		quotedColumns := make([]string, 0, len(tableSchema.Columns))
+		for _, col := range tableSchema.Columns {
+			if !slices.Contains(mapping.Exclude, col.Name) {
+				quotedColumns = append(quotedColumns, connpostgres.QuoteIdentifier(col.Name))
+			}
+		}
+		from = strings.Join(quotedColumns, ",")
+	}
+	if config.SourceIdentifier != "" {
+		from += fmt.Sprintf(",%s::text AS %s", connpostgres.QuoteLiteral(config.SourceIdentifier),
+			connpostgres.QuoteIdentifier(model.SourceIdentifierColName))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", from, parsedSrcTable.String())
+	if mapping.PartitionKey != "" {
+		query += fmt.Sprintf(" WHERE %s BETWEEN {{.start}} AND {{.end}}", mapping.PartitionKey)
+	}
+
+	numRowsPerPartition := uint32(500000)
+	if config.SnapshotNumRowsPerPartition > 0 {
+		numRowsPerPartition = config.SnapshotNumRowsPerPartition
+	}
+	qrepConfig := &protos.QRepConfig{
+		FlowJobName:                shared.ReplaceIllegalCharactersWithUnderscores("clone_" + config.FlowJobName + "_" + mapping.DestinationTableIdentifier),
+		SourceName:                 config.SourceName,
+		DestinationName:            config.DestinationName,
+		Query:                      query,
+		WatermarkColumn:            mapping.PartitionKey,
+		WatermarkTable:             mapping.SourceTableIdentifier,
+		InitialCopyOnly:            true,
+		SnapshotName:               snapshotName,
+		DestinationTableIdentifier: mapping.DestinationTableIdentifier,
+		NumRowsPerPartition:        numRowsPerPartition,
+		MaxParallelWorkers:         1,
+		StagingPath:                config.SnapshotStagingPath,
+		SyncedAtColName:            config.SyncedAtColName,
+		SoftDeleteColName:          config.SoftDeleteColName,
+		WriteMode:                  &protos.QRepWriteMode{WriteType: protos.QRepWriteType_QREP_WRITE_MODE_APPEND},
+		System:                     config.System,
+		ParentMirrorName:           config.FlowJobName,
+		Labels:                     config.Labels,
+		Env:                        config.Env,
+		Projections:                mapping.Projections,
+		ExportPath:                 config.SnapshotExportPath,
+	}
+
+	srcConn, err := connectors.GetQRepSourceAs[connectors.QRepPullConnector](ctx, r.CatalogPool, qrepConfig)
+	if err != nil {
+		return fmt.Errorf("failed to get qrep source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+	dstConn, err := connectors.GetByNameAs[connectors.QRepSyncConnector](ctx, config.Env, r.CatalogPool, config.DestinationName)
+	if err != nil {
+		return fmt.Errorf("failed to get qrep destination connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, dstConn)
+
+	if err := dstConn.SetupQRepMetadataTables(ctx, qrepConfig); err != nil {
+		return fmt.Errorf("failed to setup qrep metadata tables: %w", err)
+	}
+	partitions, err := srcConn.GetQRepPartitions(ctx, qrepConfig, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get partitions of %s: %w", mapping.SourceTableIdentifier, err)
+	}
+	logger.Info(fmt.Sprintf("snapshotting %s in %d partitions", mapping.SourceTableIdentifier, len(partitions)))
+
+	for _, partition := range partitions {
+		stream := model.NewQRecordStream(shared.FetchAndChannelSize)
+		errGroup, errCtx := errgroup.WithContext(ctx)
+		errGroup.Go(func() error {
+			if _, err := srcConn.PullQRepRecords(errCtx, qrepConfig, partition, stream); err != nil {
+				return fmt.Errorf("failed to pull records: %w", err)
+			}
+			return nil
+		})
+		errGroup.Go(func() error {
+			if _, err := dstConn.SyncQRepRecords(errCtx, qrepConfig, partition, stream); err != nil {
+				return fmt.Errorf("failed to sync records: %w", err)
+			}
+			return nil
+		})
+		if err := errGroup.Wait(); err != nil {
+			return fmt.Errorf("failed to snapshot %s: %w", mapping.SourceTableIdentifier, err)
+		}
+	}
+
+	if consolidateConn, err := connectors.GetByNameAs[connectors.QRepConsolidateConnector](
+		ctx, config.Env, r.CatalogPool, config.DestinationName,
+	); err == nil {
+		defer connectors.CloseConnector(ctx, consolidateConn)
+		if err := consolidateConn.ConsolidateQRepPartitions(ctx, qrepConfig); err != nil {
+			return fmt.Errorf("failed to consolidate %s: %w", mapping.DestinationTableIdentifier, err)
+		}
+		if err := consolidateConn.CleanupQRepFlow(ctx, qrepConfig); err != nil {
+			return fmt.Errorf("failed to cleanup snapshot of %s: %w", mapping.DestinationTableIdentifier, err)
+		}
+	} else if !errors.Is(err, errors.ErrUnsupported) {
+		return fmt.Errorf("failed to get consolidate connector: %w", err)
+	}
+	return nil
+}
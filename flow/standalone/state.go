@@ -0,0 +1,78 @@
+package standalone
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+)
+
+const (
+	// replication slot, raw and destination tables are being set up, along with the initial snapshot
+	statusSetup = "setup"
+	// the snapshot completed and changes are being synced
+	statusRunning = "running"
+)
+
+// upsertMirror records config of a mirror, keeping the state of setup and synced batches
+func (r *Runner) upsertMirror(ctx context.Context, config *protos.FlowConnectionConfigs) error {
+	configBytes, err := proto.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config of %s: %w", config.FlowJobName, err)
+	}
+	if _, err := r.CatalogPool.Exec(ctx,
+		`INSERT INTO standalone_mirrors (name, config_proto, updated_at) VALUES ($1, $2, now())
+		ON CONFLICT (name) DO UPDATE SET config_proto = $2, updated_at = now()`,
+		config.FlowJobName, configBytes,
+	); err != nil {
+		return fmt.Errorf("failed to save standalone mirror %s: %w", config.FlowJobName, err)
+	}
+	return nil
+}
+
+// loadState returns the status of a mirror and its sync options, nil until setup completed
+func (r *Runner) loadState(ctx context.Context, flowName string) (string, *protos.SyncFlowOptions, error) {
+	var status string
+	var stateBytes []byte
+	if err := r.CatalogPool.QueryRow(ctx,
+		"SELECT status, state_proto FROM standalone_mirrors WHERE name = $1", flowName,
+	).Scan(&status, &stateBytes); err != nil {
+		return "", nil, fmt.Errorf("failed to load state of %s: %w", flowName, err)
+	}
+	if stateBytes == nil {
+		return status, nil, nil
+	}
+	var options protos.SyncFlowOptions
+	if err := proto.Unmarshal(stateBytes, &options); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal state of %s: %w", flowName, err)
+	}
+	return status, &options, nil
+}
+
+// saveState checkpoints sync options of a mirror, clearing the last error
+func (r *Runner) saveState(ctx context.Context, flowName string, status string, options *protos.SyncFlowOptions) error {
+	stateBytes, err := proto.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state of %s: %w", flowName, err)
+	}
+	if _, err := r.CatalogPool.Exec(ctx,
+		`UPDATE standalone_mirrors SET status = $2, state_proto = $3, last_error = NULL, updated_at = now()
+		WHERE name = $1`,
+		flowName, status, stateBytes,
+	); err != nil {
+		return fmt.Errorf("failed to save state of %s: %w", flowName, err)
+	}
+	return nil
+}
+
+func (r *Runner) saveError(ctx context.Context, flowName string, mirrorErr error) error {
+	if _, err := r.CatalogPool.Exec(ctx,
+		"UPDATE standalone_mirrors SET last_error = $2, updated_at = now() WHERE name = $1",
+		flowName, mirrorErr.Error(),
+	); err != nil {
+		return fmt.Errorf("failed to save error of %s: %w", flowName, err)
+	}
+	return nil
+}
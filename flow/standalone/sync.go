@@ -0,0 +1,164 @@
+package standalone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"github.com/PeerDB-io/peer-flow/cdcsync"
+	"github.com/PeerDB-io/peer-flow/connectors"
+	"github.com/PeerDB-io/peer-flow/connectors/utils/monitoring"
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/logger"
+	"github.com/PeerDB-io/peer-flow/model"
+)
+
+// sync pulls and syncs batches until ctx is done, normalizing each batch before checkpointing options
+func (r *Runner) sync(ctx context.Context, config *protos.FlowConnectionConfigs, options *protos.SyncFlowOptions) error {
+	srcConn, err := connectors.GetByNameAs[connectors.CDCPullConnector](ctx, config.Env, r.CatalogPool, config.SourceName)
+	if err != nil {
+		return fmt.Errorf("failed to get source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+	if err := srcConn.SetupReplConn(ctx); err != nil {
+		return fmt.Errorf("failed to setup replication connection: %w", err)
+	}
+
+	// truncations are only normalized once, kept until normalize catches up after failing
+	truncatedTables := make(map[string]int64)
+	for ctx.Err() == nil {
+		res, needsNormalize, err := r.syncBatch(ctx, config, options, srcConn)
+		if err != nil {
+			return err
+		}
+		if err := r.refreshSchemas(ctx, config, options, res.TableSchemaDeltas); err != nil {
+			return err
+		}
+		followRenamedTables(ctx, options, res.TableSchemaDeltas)
+		if needsNormalize {
+			for _, table := range res.TruncatedTables {
+				truncatedTables[table] = max(truncatedTables[table], res.CurrentSyncBatchID)
+			}
+			if err := r.normalize(ctx, config, options, res.CurrentSyncBatchID, truncatedTables); err != nil {
+				return err
+			}
+		}
+		if res.CurrentSyncBatchID != -1 {
+			options.NumberOfSyncs += 1
+		}
+		if err := r.saveState(ctx, config.FlowJobName, statusRunning, options); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// syncBatch syncs a batch like the flowable activity does, without alerting, destination audits or record sampling
+func (r *Runner) syncBatch(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	options *protos.SyncFlowOptions,
+	srcConn connectors.CDCPullConnector,
+) (*model.SyncResponse, bool, error) {
+	batch, err := cdcsync.SyncBatch(ctx, logger.LoggerFromCtx(ctx), r.CatalogPool, config, options, srcConn,
+		connectors.CDCPullConnector.PullRecords, connectors.CDCSyncConnector.SyncRecords,
+		cdcsync.Hooks[connectors.CDCSyncConnector, model.RecordItems]{})
+	if err != nil {
+		return nil, false, err
+	}
+	return batch.Response, batch.NeedsNormalize, nil
+}
+
+// refreshSchemas refetches schemas of source tables changed by a batch, like the sync flow does
+func (r *Runner) refreshSchemas(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	options *protos.SyncFlowOptions,
+	deltas []*protos.TableSchemaDelta,
+) error {
+	modifiedSrcTables, renamedSrcTables := model.SchemaRefreshTables(deltas)
+	if len(modifiedSrcTables) == 0 {
+		return nil
+	}
+
+	srcConn, err := connectors.GetByNameAs[connectors.GetTableSchemaConnector](ctx, config.Env, r.CatalogPool, config.SourceName)
+	if err != nil {
+		return fmt.Errorf("failed to get source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+	schemas, err := srcConn.GetTableSchema(ctx, &protos.GetTableSchemaBatchInput{
+		PeerName:         config.SourceName,
+		TableIdentifiers: modifiedSrcTables,
+		FlowName:         config.FlowJobName,
+		System:           config.System,
+		Env:              config.Env,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch schema for modified tables: %w", err)
+	}
+	maps.Copy(options.TableNameSchemaMapping, model.RefreshedSchemaMapping(options.TableMappings,
+		schemas.TableNameSchemaMapping, renamedSrcTables, config.SourceIdentifier, logger.LoggerFromCtx(ctx)))
+	return nil
+}
+
+// followRenamedTables points mappings at renamed source tables the way TABLE_RENAME_POLICY_KEEP_DESTINATION does,
+// without pausing as standalone mirrors have nothing to resume them
+func followRenamedTables(ctx context.Context, options *protos.SyncFlowOptions, deltas []*protos.TableSchemaDelta) {
+	renamedTables := make(map[string]string)
+	for _, delta := range deltas {
+		if delta.RenamedTo != "" {
+			renamedTables[delta.SrcTableName] = delta.RenamedTo
+		}
+	}
+	if len(renamedTables) > 0 {
+		model.FollowRenamedTables(options, renamedTables, nil, logger.LoggerFromCtx(ctx))
+	}
+}
+
+func (r *Runner) normalize(
+	ctx context.Context,
+	config *protos.FlowConnectionConfigs,
+	options *protos.SyncFlowOptions,
+	syncBatchID int64,
+	truncatedTables map[string]int64,
+) error {
+	conn, err := connectors.GetByNameAs[connectors.CDCNormalizeConnector](ctx, config.Env, r.CatalogPool, config.DestinationName)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return monitoring.UpdateEndTimeForCDCBatch(ctx, r.CatalogPool, config.FlowJobName, syncBatchID)
+	} else if err != nil {
+		return fmt.Errorf("failed to get normalize connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, conn)
+
+	res, err := conn.NormalizeRecords(ctx, &model.NormalizeRecordsRequest{
+		FlowJobName:            config.FlowJobName,
+		Env:                    config.Env,
+		TableNameSchemaMapping: options.TableNameSchemaMapping,
+		TableMappings:          options.TableMappings,
+		SyncBatchID:            syncBatchID,
+		SoftDeleteColName:      config.SoftDeleteColName,
+		SyncedAtColName:        config.SyncedAtColName,
+		TruncatedTables:        truncatedTables,
+		TruncatePolicy:         config.TruncatePolicy,
+		ReplicationOrigin:      config.ReplicationOrigin,
+		ConflictPolicy:         config.ConflictPolicy,
+		ConflictColumn:         config.ConflictColumn,
+		ConflictCondition:      config.ConflictCondition,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to normalize records: %w", err)
+	}
+	if res.Done {
+		logger.LoggerFromCtx(ctx).Info("normalized records",
+			slog.Int64("StartBatchID", res.StartBatchID), slog.Int64("EndBatchID", res.EndBatchID))
+		if err := monitoring.UpdateEndTimeForCDCBatch(ctx, r.CatalogPool, config.FlowJobName, res.EndBatchID); err != nil {
+			return err
+		}
+		maps.DeleteFunc(truncatedTables, func(_ string, batchID int64) bool {
+			return batchID <= res.EndBatchID
+		})
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"maps"
+	"slices"
 	"strings"
 	"time"
 
@@ -27,6 +28,13 @@ type CDCFlowWorkflowState struct {
 	FlowConfigUpdate *protos.CDCFlowConfigUpdate
 	// options passed to all SyncFlows
 	SyncFlowOptions *protos.SyncFlowOptions
+	// destination tables missing out of band to snapshot again, set to nil after processed
+	ResyncTables []string
+	// new names of source tables renamed since the mirror last paused, by the name mappings know them by,
+	// set to nil after processed
+	RenamedTables map[string]string
+	// when snapshot only tables with a refresh interval were last copied, by destination table
+	RelationsRefreshedAt map[string]time.Time
 	// Current signalled state of the peer flow.
 	ActiveSignal      model.CDCFlowSignal
 	CurrentFlowStatus protos.FlowStatus
@@ -106,6 +114,9 @@ func processCDCFlowConfigUpdate(
 		syncStateToConfigProtoInCatalog(ctx, logger, cfg, state)
 		return nil
 	}
+	if cfg.ShadowMode {
+		shared.ApplyShadowMode(cfg.FlowJobName, flowConfigUpdate.AdditionalTables)
+	}
 	if shared.AdditionalTablesHasOverlap(state.SyncFlowOptions.TableMappings, flowConfigUpdate.AdditionalTables) {
 		logger.Warn("duplicate source/destination tables found in additionalTables")
 		syncStateToConfigProtoInCatalog(ctx, logger, cfg, state)
@@ -166,6 +177,119 @@ func processCDCFlowConfigUpdate(
 	return nil
 }
 
+// resyncMissingTables recreates destination tables dropped out of band from a fresh snapshot of their source tables
+func resyncMissingTables(
+	ctx workflow.Context,
+	logger log.Logger,
+	cfg *protos.FlowConnectionConfigs,
+	state *CDCFlowWorkflowState,
+	mirrorNameSearch map[string]interface{},
+) error {
+	resyncTableMappings := make([]*protos.TableMapping, 0, len(state.ResyncTables))
+	for _, tableMapping := range state.SyncFlowOptions.TableMappings {
+		if slices.Contains(state.ResyncTables, tableMapping.DestinationTableIdentifier) {
+			resyncTableMappings = append(resyncTableMappings, tableMapping)
+		}
+	}
+	if len(resyncTableMappings) == 0 {
+		return nil
+	}
+	state.CurrentFlowStatus = protos.FlowStatus_STATUS_SNAPSHOT
+
+	logger.Info("snapshotting missing tables again", slog.Any("tables", state.ResyncTables))
+	resyncTablesUUID := GetUUID(ctx)
+	childResyncTablesCDCFlowID := GetChildWorkflowID("resync-cdc-flow", cfg.FlowJobName, resyncTablesUUID)
+	resyncTablesCfg := shared.CloneProto(cfg)
+	resyncTablesCfg.DoInitialSnapshot = true
+	resyncTablesCfg.InitialSnapshotOnly = true
+	resyncTablesCfg.TableMappings = resyncTableMappings
+	resyncTablesCfg.Resync = false
+	childResyncTablesCDCFlowOpts := workflow.ChildWorkflowOptions{
+		WorkflowID:        childResyncTablesCDCFlowID,
+		ParentClosePolicy: enums.PARENT_CLOSE_POLICY_REQUEST_CANCEL,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 20,
+		},
+		SearchAttributes:    mirrorNameSearch,
+		WaitForCancellation: true,
+	}
+	childResyncTablesCDCFlowCtx := workflow.WithChildOptions(ctx, childResyncTablesCDCFlowOpts)
+	childResyncTablesCDCFlowFuture := workflow.ExecuteChildWorkflow(
+		childResyncTablesCDCFlowCtx,
+		CDCFlowWorkflow,
+		resyncTablesCfg,
+		nil,
+	)
+	var res *CDCFlowWorkflowResult
+	if err := childResyncTablesCDCFlowFuture.Get(childResyncTablesCDCFlowCtx, &res); err != nil {
+		return err
+	}
+
+	maps.Copy(state.SyncFlowOptions.SrcTableIdNameMapping, res.SyncFlowOptions.SrcTableIdNameMapping)
+	maps.Copy(state.SyncFlowOptions.TableNameSchemaMapping, res.SyncFlowOptions.TableNameSchemaMapping)
+	logger.Info("missing tables snapshotted again")
+	return nil
+}
+
+// followRenamedTables points table mappings at the new names of renamed source tables, renaming destination tables
+// along with them under TABLE_RENAME_POLICY_RENAME_DESTINATION. Returns whether the mirror resumes,
+// it stays paused under TABLE_RENAME_POLICY_PAUSE or when destination tables couldn't be renamed
+func followRenamedTables(
+	ctx workflow.Context,
+	logger log.Logger,
+	cfg *protos.FlowConnectionConfigs,
+	state *CDCFlowWorkflowState,
+) bool {
+	resume := cfg.TableRenamePolicy != protos.TableRenamePolicy_TABLE_RENAME_POLICY_PAUSE
+	var renameOptions []*protos.RenameTableOption
+	if cfg.TableRenamePolicy == protos.TableRenamePolicy_TABLE_RENAME_POLICY_RENAME_DESTINATION {
+		for _, tableMapping := range state.SyncFlowOptions.TableMappings {
+			renamedTo, ok := state.RenamedTables[tableMapping.SourceTableIdentifier]
+			if !ok {
+				continue
+			}
+			dstTableName := shared.RenamedDestinationTable(tableMapping.DestinationTableIdentifier,
+				tableMapping.SourceTableIdentifier, renamedTo)
+			if dstTableName != tableMapping.DestinationTableIdentifier {
+				renameOptions = append(renameOptions, &protos.RenameTableOption{
+					CurrentName: tableMapping.DestinationTableIdentifier,
+					NewName:     dstTableName,
+					TableSchema: state.SyncFlowOptions.TableNameSchemaMapping[tableMapping.DestinationTableIdentifier],
+				})
+			}
+		}
+	}
+	if len(renameOptions) > 0 {
+		renameTablesCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			StartToCloseTimeout: 12 * time.Hour,
+			HeartbeatTimeout:    time.Minute,
+			RetryPolicy: &temporal.RetryPolicy{
+				MaximumAttempts: 3,
+			},
+		})
+		if err := workflow.ExecuteActivity(renameTablesCtx, flowable.RenameTables, &protos.RenameTablesInput{
+			FlowJobName:        cfg.FlowJobName,
+			PeerName:           cfg.DestinationName,
+			RenameTableOptions: renameOptions,
+			SoftDeleteColName:  cfg.SoftDeleteColName,
+			SyncedAtColName:    cfg.SyncedAtColName,
+		}).Get(renameTablesCtx, nil); err != nil {
+			logger.Error("failed to rename destination tables, staying paused", slog.Any("error", err))
+			renameOptions = nil
+			resume = false
+		}
+	}
+
+	renamedDstTables := make(map[string]string, len(renameOptions))
+	for _, renameOption := range renameOptions {
+		renamedDstTables[renameOption.CurrentName] = renameOption.NewName
+	}
+	model.FollowRenamedTables(state.SyncFlowOptions, state.RenamedTables, renamedDstTables, logger)
+	cfg.TableMappings = state.SyncFlowOptions.TableMappings
+	syncStateToConfigProtoInCatalog(ctx, logger, cfg, state)
+	return resume
+}
+
 func syncStateToConfigProtoInCatalog(
 	ctx workflow.Context,
 	logger log.Logger,
@@ -237,6 +361,10 @@ func CDCFlowWorkflow(
 		shared.MirrorNameSearchAttribute: cfg.FlowJobName,
 	}
 
+	migrateWorkflowChan := model.MigrateWorkflowSignal.GetSignalChannel(ctx)
+	var migrate bool
+
+	followRenames := hasVersion(ctx, versionTableRename)
 	var syncCountLimit int
 	if state.ActiveSignal == model.PauseSignal {
 		selector := workflow.NewNamedSelector(ctx, "PauseLoop")
@@ -244,19 +372,27 @@ func CDCFlowWorkflow(
 		flowSignalChan.AddToSelector(selector, func(val model.CDCFlowSignal, _ bool) {
 			state.ActiveSignal = model.FlowSignalHandler(state.ActiveSignal, val, logger)
 		})
+		migrateWorkflowChan.AddToSelector(selector, func(_ struct{}, _ bool) {
+			migrate = true
+		})
 		addCdcPropertiesSignalListener(ctx, logger, selector, state)
 		startTime := workflow.Now(ctx)
 		state.CurrentFlowStatus = protos.FlowStatus_STATUS_PAUSED
 
 		for state.ActiveSignal == model.PauseSignal {
 			// only place we block on receive, so signal processing is immediate
-			for state.ActiveSignal == model.PauseSignal && state.FlowConfigUpdate == nil && ctx.Err() == nil {
+			for state.ActiveSignal == model.PauseSignal && state.FlowConfigUpdate == nil && len(state.ResyncTables) == 0 &&
+				(!followRenames || len(state.RenamedTables) == 0) && !migrate && ctx.Err() == nil {
 				logger.Info(fmt.Sprintf("mirror has been paused for %s", time.Since(startTime).Round(time.Second)))
 				selector.Select(ctx)
 			}
 			if err := ctx.Err(); err != nil {
 				return state, err
 			}
+			if migrate {
+				logger.Info("migrating paused mirror to latest workflow definition")
+				return state, workflow.NewContinueAsNewError(ctx, CDCFlowWorkflow, cfg, state)
+			}
 
 			if state.FlowConfigUpdate != nil {
 				err = processCDCFlowConfigUpdate(ctx, logger, cfg, state, mirrorNameSearch)
@@ -269,6 +405,22 @@ func CDCFlowWorkflow(
 				state.FlowConfigUpdate = nil
 				state.ActiveSignal = model.NoopSignal
 			}
+
+			if len(state.ResyncTables) > 0 {
+				if err := resyncMissingTables(ctx, logger, cfg, state, mirrorNameSearch); err != nil {
+					return state, err
+				}
+				state.ResyncTables = nil
+				state.ActiveSignal = model.NoopSignal
+			}
+
+			if followRenames && len(state.RenamedTables) > 0 {
+				resume := followRenamedTables(ctx, logger, cfg, state)
+				state.RenamedTables = nil
+				if resume {
+					state.ActiveSignal = model.NoopSignal
+				}
+			}
 		}
 
 		logger.Info(fmt.Sprintf("mirror has been resumed after %s", time.Since(startTime).Round(time.Second)))
@@ -282,6 +434,20 @@ func CDCFlowWorkflow(
 	// for safety, rely on the idempotency of SetupFlow instead
 	// also, no signals are being handled until the loop starts, so no PAUSE/DROP will take here.
 	if state.CurrentFlowStatus != protos.FlowStatus_STATUS_RUNNING {
+		if len(cfg.DependsOn) > 0 && hasVersion(ctx, versionMirrorDependencies) {
+			// timing out only retries the wait, snapshots of mirrors depended on can take days
+			dependenciesCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+				StartToCloseTimeout: 24 * time.Hour,
+				HeartbeatTimeout:    time.Minute,
+			})
+			logger.Info("waiting for mirror dependencies to finish their snapshot", slog.Any("dependsOn", cfg.DependsOn))
+			if err := workflow.ExecuteActivity(dependenciesCtx, flowable.WaitForMirrorDependencies,
+				cfg.FlowJobName, cfg.DependsOn,
+			).Get(dependenciesCtx, nil); err != nil {
+				return state, fmt.Errorf("failed to wait for mirror dependencies: %w", err)
+			}
+		}
+
 		// if resync is true, alter the table name schema mapping to temporarily add
 		// a suffix to the table names.
 		if cfg.Resync {
@@ -361,13 +527,17 @@ func CDCFlowWorkflow(
 					CurrentName: oldName,
 					NewName:     newName,
 					// oldName is what was used for the TableNameSchema mapping
-					TableSchema: state.SyncFlowOptions.TableNameSchemaMapping[oldName],
+					TableSchema:    state.SyncFlowOptions.TableNameSchemaMapping[oldName],
+					DependentViews: mapping.DependentViews,
 				})
 				mapping.DestinationTableIdentifier = newName
 				// TableNameSchemaMapping is referring to the _resync tables, not the actual names
 				correctedTableNameSchemaMapping[newName] = state.SyncFlowOptions.TableNameSchemaMapping[oldName]
 			}
 
+			if messageTable := cfg.LogicalMessageDestination; messageTable != "" {
+				correctedTableNameSchemaMapping[messageTable] = state.SyncFlowOptions.TableNameSchemaMapping[messageTable]
+			}
 			state.SyncFlowOptions.TableNameSchemaMapping = correctedTableNameSchemaMapping
 			renameTablesCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
 				StartToCloseTimeout: 12 * time.Hour,
@@ -379,13 +549,17 @@ func CDCFlowWorkflow(
 			}
 		}
 
-		state.CurrentFlowStatus = protos.FlowStatus_STATUS_RUNNING
 		logger.Info("executed setup flow and snapshot flow")
+		if cfg.DoInitialSnapshot {
+			markRelationsRefreshed(workflow.Now(ctx), state)
+		}
 
 		// if initial_copy_only is opted for, we end the flow here.
 		if cfg.InitialSnapshotOnly {
+			state.CurrentFlowStatus = protos.FlowStatus_STATUS_COMPLETED
 			return state, nil
 		}
+		state.CurrentFlowStatus = protos.FlowStatus_STATUS_RUNNING
 	}
 
 	syncFlowID := GetChildWorkflowID("sync-flow", cfg.FlowJobName, originalRunID)
@@ -472,6 +646,21 @@ func CDCFlowWorkflow(
 	syncResultChan := model.SyncResultSignal.GetSignalChannel(ctx)
 	syncResultChan.AddToSelector(mainLoopSelector, func(result *model.SyncResponse, _ bool) {
 		syncCount += 1
+		if result == nil || !followRenames {
+			return
+		}
+		// mappings follow renamed source tables while paused, as syncing goes on by relation id until then
+		for _, tableSchemaDelta := range result.TableSchemaDeltas {
+			if tableSchemaDelta.RenamedTo != "" {
+				if state.RenamedTables == nil {
+					state.RenamedTables = make(map[string]string)
+				}
+				state.RenamedTables[tableSchemaDelta.SrcTableName] = tableSchemaDelta.RenamedTo
+				logger.Warn("source table renamed, pausing", slog.String("table", tableSchemaDelta.SrcTableName),
+					slog.String("renamedTo", tableSchemaDelta.RenamedTo), slog.String("policy", cfg.TableRenamePolicy.String()))
+				state.ActiveSignal = model.PauseSignal
+			}
+		}
 	})
 
 	normChan := model.NormalizeSignal.GetSignalChannel(ctx)
@@ -482,6 +671,20 @@ func CDCFlowWorkflow(
 		maps.Copy(state.SyncFlowOptions.TableNameSchemaMapping, payload.TableNameSchemaMapping)
 	})
 
+	missingTablesChan := model.MissingTablesSignal.GetSignalChannel(ctx)
+	missingTablesChan.AddToSelector(mainLoopSelector, func(tables []string, _ bool) {
+		logger.Warn("destination tables missing, pausing",
+			slog.Any("tables", tables), slog.String("policy", cfg.MissingTablePolicy.String()))
+		if cfg.MissingTablePolicy == protos.MissingTablePolicy_MISSING_TABLE_POLICY_RESYNC {
+			for _, table := range tables {
+				if !slices.Contains(state.ResyncTables, table) {
+					state.ResyncTables = append(state.ResyncTables, table)
+				}
+			}
+		}
+		state.ActiveSignal = model.PauseSignal
+	})
+
 	parallel := getParallelSyncNormalize(ctx, logger, cfg.Env)
 	if !parallel {
 		normDoneChan := model.NormalizeDoneSignal.GetSignalChannel(ctx)
@@ -501,6 +704,42 @@ func CDCFlowWorkflow(
 
 	addCdcPropertiesSignalListener(ctx, logger, mainLoopSelector, state)
 
+	migrateWorkflowChan.AddToSelector(mainLoopSelector, func(_ struct{}, _ bool) {
+		logger.Info("migrating mirror to latest workflow definition")
+		migrate = true
+	})
+
+	// materialized views and foreign tables are copied again on their interval alongside CDC,
+	// a refresh in progress is waited for before continuing as new
+	var refreshing bool
+	var scheduleRelationRefresh func()
+	scheduleRelationRefresh = func() {
+		wait, ok := untilRelationRefresh(workflow.Now(ctx), state)
+		if !ok {
+			return
+		}
+		mainLoopSelector.AddFuture(workflow.NewTimer(ctx, wait), func(f workflow.Future) {
+			if restart || f.Get(ctx, nil) != nil {
+				return
+			}
+			refreshing = true
+			refreshFuture, settable := workflow.NewFuture(ctx)
+			workflow.Go(ctx, func(ctx workflow.Context) {
+				settable.SetError(refreshRelations(ctx, logger, cfg, state))
+			})
+			mainLoopSelector.AddFuture(refreshFuture, func(f workflow.Future) {
+				refreshing = false
+				if err := f.Get(ctx, nil); err != nil {
+					handleError("relation refresh", err)
+				}
+				scheduleRelationRefresh()
+			})
+		})
+	}
+	if _, ok := untilRelationRefresh(workflow.Now(ctx), state); ok && hasVersion(ctx, versionRelationRefresh) {
+		scheduleRelationRefresh()
+	}
+
 	state.CurrentFlowStatus = protos.FlowStatus_STATUS_RUNNING
 	maxSyncPerCDCFlow := int(getMaxSyncsPerCDCFlow(ctx, logger, cfg.Env))
 	for {
@@ -513,7 +752,7 @@ func CDCFlowWorkflow(
 			return state, err
 		}
 
-		if state.ActiveSignal == model.PauseSignal || syncCount >= maxSyncPerCDCFlow {
+		if state.ActiveSignal == model.PauseSignal || syncCount >= maxSyncPerCDCFlow || migrate {
 			restart = true
 			if syncFlowFuture != nil {
 				err := model.SyncStopSignal.SignalChildWorkflow(ctx, syncFlowFuture, struct{}{}).Get(ctx, nil)
@@ -525,11 +764,12 @@ func CDCFlowWorkflow(
 		}
 
 		if restart {
-			if state.ActiveSignal == model.PauseSignal {
+			// destination tables are only renamed once every batch synced under their old name is normalized
+			if state.ActiveSignal == model.PauseSignal && (!followRenames || len(state.RenamedTables) == 0) {
 				finished = true
 			}
 
-			for ctx.Err() == nil && (!finished || mainLoopSelector.HasPending()) {
+			for ctx.Err() == nil && (!finished || refreshing || mainLoopSelector.HasPending()) {
 				mainLoopSelector.Select(ctx)
 			}
 
@@ -47,6 +47,88 @@ func getMaxSyncsPerCDCFlow(wCtx workflow.Context, logger log.Logger, env map[str
 	return maxSyncsPerCDCFlow
 }
 
+// getSyncInterval builds the adaptive sync interval of options, which keeps the idle timeout of options when settings fail to load
+func getSyncInterval(
+	wCtx workflow.Context, logger log.Logger, env map[string]string, options *protos.SyncFlowOptions,
+) *shared.SyncInterval {
+	checkCtx := workflow.WithLocalActivityOptions(wCtx, workflow.LocalActivityOptions{
+		StartToCloseTimeout: time.Minute,
+	})
+
+	baseSeconds := uint64(peerdbenv.PeerDBCDCIdleTimeoutSeconds(int(options.IdleTimeoutSeconds)) / time.Second)
+	var maxSeconds uint64
+	if err := workflow.ExecuteLocalActivity(
+		checkCtx, peerdbenv.PeerDBAdaptiveSyncMaxIdleTimeoutSeconds, env,
+	).Get(checkCtx, &maxSeconds); err != nil {
+		logger.Warn("Failed to get adaptive sync max idle timeout, keeping sync interval", slog.Any("error", err))
+		return shared.NewSyncInterval(baseSeconds, 0, 0)
+	}
+	var recordsThreshold uint32
+	if err := workflow.ExecuteLocalActivity(
+		checkCtx, peerdbenv.PeerDBAdaptiveSyncRecordsThreshold, env,
+	).Get(checkCtx, &recordsThreshold); err != nil {
+		logger.Warn("Failed to get adaptive sync records threshold, keeping sync interval", slog.Any("error", err))
+		return shared.NewSyncInterval(baseSeconds, 0, 0)
+	}
+	return shared.NewSyncInterval(baseSeconds, maxSeconds, recordsThreshold)
+}
+
+// getBatchTuner builds the batch tuner of options, which is disabled when settings fail to load
+func getBatchTuner(
+	wCtx workflow.Context, logger log.Logger, env map[string]string, options *protos.SyncFlowOptions,
+) *shared.BatchTuner {
+	checkCtx := workflow.WithLocalActivityOptions(wCtx, workflow.LocalActivityOptions{
+		StartToCloseTimeout: time.Minute,
+	})
+
+	baseSeconds := uint64(peerdbenv.PeerDBCDCIdleTimeoutSeconds(int(options.IdleTimeoutSeconds)) / time.Second)
+	var targetLatency time.Duration
+	if err := workflow.ExecuteLocalActivity(
+		checkCtx, peerdbenv.PeerDBBatchTuningTargetLatency, env,
+	).Get(checkCtx, &targetLatency); err != nil {
+		logger.Warn("Failed to get batch tuning target latency, disabling batch tuning", slog.Any("error", err))
+		return shared.NewBatchTuner(0, options.BatchSize, baseSeconds, 0)
+	}
+	var maxSeconds uint64
+	if err := workflow.ExecuteLocalActivity(
+		checkCtx, peerdbenv.PeerDBBatchTuningMaxIdleTimeoutSeconds, env,
+	).Get(checkCtx, &maxSeconds); err != nil {
+		logger.Warn("Failed to get batch tuning max idle timeout, disabling batch tuning", slog.Any("error", err))
+		return shared.NewBatchTuner(0, options.BatchSize, baseSeconds, 0)
+	}
+	return shared.NewBatchTuner(targetLatency, options.BatchSize, baseSeconds, maxSeconds)
+}
+
+// getCatchUp builds catch up mode of a mirror, which is disabled when settings fail to load
+func getCatchUp(wCtx workflow.Context, logger log.Logger, env map[string]string) *shared.CatchUp {
+	checkCtx := workflow.WithLocalActivityOptions(wCtx, workflow.LocalActivityOptions{
+		StartToCloseTimeout: time.Minute,
+	})
+
+	var lagThreshold time.Duration
+	if err := workflow.ExecuteLocalActivity(
+		checkCtx, peerdbenv.PeerDBCatchUpLagThreshold, env,
+	).Get(checkCtx, &lagThreshold); err != nil {
+		logger.Warn("Failed to get catch up lag threshold, disabling catch up mode", slog.Any("error", err))
+		return shared.NewCatchUp(0, 0, 1)
+	}
+	var maxDuration time.Duration
+	if err := workflow.ExecuteLocalActivity(
+		checkCtx, peerdbenv.PeerDBCatchUpMaxDuration, env,
+	).Get(checkCtx, &maxDuration); err != nil {
+		logger.Warn("Failed to get catch up max duration, disabling catch up mode", slog.Any("error", err))
+		return shared.NewCatchUp(0, 0, 1)
+	}
+	var batchSizeFactor uint32
+	if err := workflow.ExecuteLocalActivity(
+		checkCtx, peerdbenv.PeerDBCatchUpBatchSizeMultiplier, env,
+	).Get(checkCtx, &batchSizeFactor); err != nil {
+		logger.Warn("Failed to get catch up batch size multiplier, disabling catch up mode", slog.Any("error", err))
+		return shared.NewCatchUp(0, 0, 1)
+	}
+	return shared.NewCatchUp(lagThreshold, maxDuration, batchSizeFactor)
+}
+
 func localPeerType(ctx context.Context, name string) (protos.DBType, error) {
 	pool, err := peerdbenv.GetCatalogConnectionPoolFromEnv(ctx)
 	if err != nil {
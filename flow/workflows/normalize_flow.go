@@ -2,6 +2,7 @@ package peerflow
 
 import (
 	"log/slog"
+	"maps"
 	"time"
 
 	"go.temporal.io/sdk/log"
@@ -14,10 +15,13 @@ import (
 
 type NormalizeState struct {
 	TableNameSchemaMapping map[string]*protos.TableSchema
+	TruncatedTables        map[string]int64
 	LastSyncBatchID        int64
 	SyncBatchID            int64
 	Wait                   bool
 	Stop                   bool
+	// sync flow is in catch up mode, normalizes follow it
+	CatchUp bool
 }
 
 func NewNormalizeState() *NormalizeState {
@@ -69,6 +73,8 @@ func NormalizeFlowWorkflow(
 	model.NormalizeSignal.GetSignalChannel(ctx).AddToSelector(selector, func(s model.NormalizePayload, _ bool) {
 		if s.Done {
 			state.Stop = true
+		} else {
+			state.CatchUp = s.CatchUp
 		}
 		if s.SyncBatchID > state.SyncBatchID {
 			state.SyncBatchID = s.SyncBatchID
@@ -76,6 +82,12 @@ func NormalizeFlowWorkflow(
 		if s.TableNameSchemaMapping != nil {
 			state.TableNameSchemaMapping = s.TableNameSchemaMapping
 		}
+		for _, table := range s.TruncatedTables {
+			if state.TruncatedTables == nil {
+				state.TruncatedTables = make(map[string]int64)
+			}
+			state.TruncatedTables[table] = max(state.TruncatedTables[table], s.SyncBatchID)
+		}
 
 		state.Wait = false
 	})
@@ -90,11 +102,20 @@ func NormalizeFlowWorkflow(
 	if state.LastSyncBatchID != state.SyncBatchID {
 		state.LastSyncBatchID = state.SyncBatchID
 
-		logger.Info("executing normalize")
+		logger.Info("executing normalize", slog.Bool("catchUp", state.CatchUp))
+		normalizeConfig := config
+		if state.CatchUp {
+			normalizeConfig = shared.CloneProto(config)
+			if normalizeConfig.Env == nil {
+				normalizeConfig.Env = make(map[string]string, 1)
+			}
+			normalizeConfig.Env[shared.CatchUpEnvKey] = "true"
+		}
 		startNormalizeInput := &protos.StartNormalizeInput{
-			FlowConnectionConfigs:  config,
+			FlowConnectionConfigs:  normalizeConfig,
 			TableNameSchemaMapping: state.TableNameSchemaMapping,
 			SyncBatchID:            state.SyncBatchID,
+			TruncatedTables:        state.TruncatedTables,
 		}
 		fStartNormalize := workflow.ExecuteActivity(normalizeFlowCtx, flowable.StartNormalize, startNormalizeInput)
 
@@ -103,6 +124,18 @@ func NormalizeFlowWorkflow(
 			logger.Info("Normalize errored", slog.Any("error", err))
 		} else if normalizeResponse != nil {
 			logger.Info("Normalize finished", slog.Any("result", normalizeResponse))
+			// a failed normalize is picked up by the next one, so truncations are only dropped once normalized
+			maps.DeleteFunc(state.TruncatedTables, func(_ string, batchID int64) bool {
+				return batchID <= normalizeResponse.EndBatchID
+			})
+			if len(normalizeResponse.MissingTables) > 0 {
+				_ = model.MissingTablesSignal.SignalExternalWorkflow(
+					ctx,
+					parent.ID,
+					"",
+					normalizeResponse.MissingTables,
+				).Get(ctx, nil)
+			}
 		}
 	}
 
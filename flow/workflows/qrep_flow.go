@@ -154,6 +154,7 @@ func (q *QRepFlowExecution) setupWatermarkTableOnDestination(ctx workflow.Contex
 			FlowName:          q.config.FlowJobName,
 			Env:               q.config.Env,
 			IsResync:          q.config.DstTableFullResync,
+			Labels:            q.config.Labels,
 		}
 
 		future := workflow.ExecuteActivity(ctx, flowable.CreateNormalizedTable, setupConfig)
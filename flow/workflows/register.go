@@ -18,4 +18,10 @@ func RegisterFlowWorkerWorkflows(w worker.WorkflowRegistry) {
 	w.RegisterWorkflow(GlobalScheduleManagerWorkflow)
 	w.RegisterWorkflow(HeartbeatFlowWorkflow)
 	w.RegisterWorkflow(RecordSlotSizeWorkflow)
+	w.RegisterWorkflow(ReconcileMirrorsWorkflow)
+	w.RegisterWorkflow(DestinationMaintenanceWorkflow)
+	w.RegisterWorkflow(DataDiffWorkflow)
+	w.RegisterWorkflow(OrphanedArtifactsWorkflow)
+	w.RegisterWorkflow(RetentionWorkflow)
+	w.RegisterWorkflow(FreshnessSLAWorkflow)
 }
@@ -0,0 +1,97 @@
+package peerflow
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/peerdbenv"
+	"github.com/PeerDB-io/peer-flow/shared"
+)
+
+// untilRelationRefresh returns how long until a snapshot only table of the mirror is due to be copied again,
+// false when none of them is refreshed
+func untilRelationRefresh(now time.Time, state *CDCFlowWorkflowState) (time.Duration, bool) {
+	var next time.Time
+	for _, mapping := range state.SyncFlowOptions.TableMappings {
+		if !mapping.SnapshotOnly || mapping.RefreshIntervalSeconds == 0 {
+			continue
+		}
+		due := state.RelationsRefreshedAt[mapping.DestinationTableIdentifier].Add(
+			time.Duration(mapping.RefreshIntervalSeconds) * time.Second)
+		if next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	if next.IsZero() {
+		return 0, false
+	}
+	return max(next.Sub(now), 0), true
+}
+
+// markRelationsRefreshed records snapshot only tables as copied at now, so their next refresh is an interval later
+func markRelationsRefreshed(now time.Time, state *CDCFlowWorkflowState) {
+	if state.RelationsRefreshedAt == nil {
+		state.RelationsRefreshedAt = make(map[string]time.Time)
+	}
+	for _, mapping := range state.SyncFlowOptions.TableMappings {
+		if mapping.SnapshotOnly && mapping.RefreshIntervalSeconds > 0 {
+			state.RelationsRefreshedAt[mapping.DestinationTableIdentifier] = now
+		}
+	}
+}
+
+// refreshRelations copies snapshot only tables due for a refresh again, like materialized views and foreign tables,
+// overwriting their destination tables. Rows of a destination table are missing until its copy completes.
+// Tables are counted as refreshed even when their copy fails, to be tried again on their next interval
+func refreshRelations(
+	ctx workflow.Context,
+	logger log.Logger,
+	cfg *protos.FlowConnectionConfigs,
+	state *CDCFlowWorkflowState,
+) error {
+	now := workflow.Now(ctx)
+	se := &SnapshotFlowExecution{
+		config:                 cfg,
+		logger:                 logger,
+		tableNameSchemaMapping: state.SyncFlowOptions.TableNameSchemaMapping,
+	}
+	if state.RelationsRefreshedAt == nil {
+		state.RelationsRefreshedAt = make(map[string]time.Time)
+	}
+
+	boundSelector := shared.NewBoundSelector(ctx, "RefreshRelationsSelector", int(max(cfg.SnapshotNumTablesInParallel, 1)))
+	for _, mapping := range state.SyncFlowOptions.TableMappings {
+		if !mapping.SnapshotOnly || mapping.RefreshIntervalSeconds == 0 {
+			continue
+		}
+		dstName := mapping.DestinationTableIdentifier
+		if now.Before(state.RelationsRefreshedAt[dstName].Add(time.Duration(mapping.RefreshIntervalSeconds) * time.Second)) {
+			continue
+		}
+		state.RelationsRefreshedAt[dstName] = now
+
+		childWorkflowID := shared.ReplaceIllegalCharactersWithUnderscores(
+			fmt.Sprintf("refresh_%s_%s_%s", cfg.FlowJobName, dstName, GetUUID(ctx)))
+		config, err := se.tableQRepConfig(ctx, childWorkflowID, "", mapping)
+		if err != nil {
+			return fmt.Errorf("unable to configure refresh of table %s: %w", dstName, err)
+		}
+		if config.WriteMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_APPEND {
+			config.WriteMode = &protos.QRepWriteMode{WriteType: protos.QRepWriteType_QREP_WRITE_MODE_OVERWRITE}
+		}
+
+		logger.Info("refreshing table", slog.String("source", mapping.SourceTableIdentifier), slog.String("destination", dstName))
+		childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+			WorkflowID:          childWorkflowID,
+			WorkflowTaskTimeout: 5 * time.Minute,
+			TaskQueue:           peerdbenv.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue),
+		})
+		boundSelector.SpawnChild(childCtx, QRepFlowWorkflow, nil, config, nil)
+	}
+	return boundSelector.Wait(ctx)
+}
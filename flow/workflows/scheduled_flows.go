@@ -31,6 +31,83 @@ func HeartbeatFlowWorkflow(ctx workflow.Context) error {
 	return heartbeatFuture.Get(ctx, nil)
 }
 
+// ReconcileMirrorsWorkflow flags mirrors whose catalog state drifted from their workflow, slot or destination
+func ReconcileMirrorsWorkflow(ctx workflow.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Hour,
+		HeartbeatTimeout:    5 * time.Minute,
+	})
+	reconcileFuture := workflow.ExecuteActivity(ctx, flowable.ReconcileMirrors)
+	return reconcileFuture.Get(ctx, nil)
+}
+
+// DestinationMaintenanceWorkflow maintains destination tables of mirrors with maintenance configured
+func DestinationMaintenanceWorkflow(ctx workflow.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 6 * time.Hour,
+		HeartbeatTimeout:    5 * time.Minute,
+	})
+	maintenanceFuture := workflow.ExecuteActivity(ctx, flowable.MaintainDestinations)
+	return maintenanceFuture.Get(ctx, nil)
+}
+
+// DataDiffWorkflow samples rows of mirrors with data diffs configured to compare source and destination
+func DataDiffWorkflow(ctx workflow.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 6 * time.Hour,
+		HeartbeatTimeout:    5 * time.Minute,
+	})
+	dataDiffFuture := workflow.ExecuteActivity(ctx, flowable.SampleDataDiffs)
+	return dataDiffFuture.Get(ctx, nil)
+}
+
+// OrphanedArtifactsWorkflow drops raw tables and stages left on peers by mirrors no longer in the catalog
+func OrphanedArtifactsWorkflow(ctx workflow.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 6 * time.Hour,
+		HeartbeatTimeout:    5 * time.Minute,
+	})
+	cleanupFuture := workflow.ExecuteActivity(ctx, flowable.CleanupOrphanedArtifacts)
+	return cleanupFuture.Get(ctx, nil)
+}
+
+// RetentionWorkflow deletes rows past the retention policies of tables from destinations of mirrors
+func RetentionWorkflow(ctx workflow.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 6 * time.Hour,
+		HeartbeatTimeout:    5 * time.Minute,
+	})
+	retentionFuture := workflow.ExecuteActivity(ctx, flowable.EnforceRetention)
+	return retentionFuture.Get(ctx, nil)
+}
+
+// FreshnessSLAWorkflow evaluates freshness SLAs of mirrors against their pending batches
+func FreshnessSLAWorkflow(ctx workflow.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Minute,
+	})
+	slaFuture := workflow.ExecuteActivity(ctx, flowable.EvaluateFreshnessSLAs)
+	return slaFuture.Get(ctx, nil)
+}
+
 func withCronOptions(ctx workflow.Context, workflowID string, cron string) workflow.Context {
 	return workflow.WithChildOptions(ctx,
 		workflow.ChildWorkflowOptions{
@@ -58,6 +135,48 @@ func GlobalScheduleManagerWorkflow(ctx workflow.Context) error {
 		"*/5 * * * *")
 	workflow.ExecuteChildWorkflow(slotSizeCtx, RecordSlotSizeWorkflow)
 
+	if hasVersion(ctx, versionReconcileMirrors) {
+		reconcileCtx := withCronOptions(ctx,
+			"reconcile-mirrors-"+info.OriginalRunID,
+			"0 * * * *")
+		workflow.ExecuteChildWorkflow(reconcileCtx, ReconcileMirrorsWorkflow)
+	}
+
+	if hasVersion(ctx, versionDestinationMaintenance) {
+		maintenanceCtx := withCronOptions(ctx,
+			"destination-maintenance-"+info.OriginalRunID,
+			"*/15 * * * *")
+		workflow.ExecuteChildWorkflow(maintenanceCtx, DestinationMaintenanceWorkflow)
+	}
+
+	if hasVersion(ctx, versionDataDiff) {
+		dataDiffCtx := withCronOptions(ctx,
+			"data-diff-"+info.OriginalRunID,
+			"*/15 * * * *")
+		workflow.ExecuteChildWorkflow(dataDiffCtx, DataDiffWorkflow)
+	}
+
+	if hasVersion(ctx, versionOrphanedArtifacts) {
+		orphanedArtifactsCtx := withCronOptions(ctx,
+			"orphaned-artifacts-"+info.OriginalRunID,
+			"30 3 * * *")
+		workflow.ExecuteChildWorkflow(orphanedArtifactsCtx, OrphanedArtifactsWorkflow)
+	}
+
+	if hasVersion(ctx, versionRetention) {
+		retentionCtx := withCronOptions(ctx,
+			"retention-"+info.OriginalRunID,
+			"0 2 * * *")
+		workflow.ExecuteChildWorkflow(retentionCtx, RetentionWorkflow)
+	}
+
+	if hasVersion(ctx, versionFreshnessSLA) {
+		freshnessSLACtx := withCronOptions(ctx,
+			"freshness-sla-"+info.OriginalRunID,
+			"* * * * *")
+		workflow.ExecuteChildWorkflow(freshnessSLACtx, FreshnessSLAWorkflow)
+	}
+
 	ctx.Done().Receive(ctx, nil)
 	return ctx.Err()
 }
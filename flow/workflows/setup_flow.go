@@ -12,6 +12,7 @@ import (
 
 	"github.com/PeerDB-io/peer-flow/activities"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/shared"
 )
 
@@ -186,6 +187,7 @@ func (s *SetupFlowExecution) fetchTableSchemaAndSetupNormalizedTables(
 		FlowName:         s.cdcFlowName,
 		System:           flowConnectionConfigs.System,
 		Env:              flowConnectionConfigs.Env,
+		ProfileColumns:   true,
 	}
 
 	future := workflow.ExecuteActivity(ctx, flowable.GetTableSchema, tableSchemaInput)
@@ -199,6 +201,14 @@ func (s *SetupFlowExecution) fetchTableSchemaAndSetupNormalizedTables(
 	s.Info("setting up normalized tables for peer flow")
 	normalizedTableMapping := shared.BuildProcessedSchemaMapping(flowConnectionConfigs.TableMappings,
 		tblSchemaOutput.TableNameSchemaMapping, s.Logger)
+	if flowConnectionConfigs.SourceIdentifier != "" {
+		for dstTableName, tableSchema := range normalizedTableMapping {
+			normalizedTableMapping[dstTableName] = model.WithSourceIdentifierColumn(tableSchema)
+		}
+	}
+	if messageTable := flowConnectionConfigs.LogicalMessageDestination; messageTable != "" {
+		normalizedTableMapping[messageTable] = model.MessageTableSchema(messageTable, flowConnectionConfigs.System)
+	}
 
 	// now setup the normalized tables on the destination peer
 	setupConfig := &protos.SetupNormalizedTableBatchInput{
@@ -210,6 +220,7 @@ func (s *SetupFlowExecution) fetchTableSchemaAndSetupNormalizedTables(
 		FlowName:               flowConnectionConfigs.FlowJobName,
 		Env:                    flowConnectionConfigs.Env,
 		IsResync:               flowConnectionConfigs.Resync,
+		Labels:                 flowConnectionConfigs.Labels,
 	}
 
 	future = workflow.ExecuteActivity(ctx, flowable.CreateNormalizedTable, setupConfig)
@@ -15,6 +15,7 @@ import (
 	connpostgres "github.com/PeerDB-io/peer-flow/connectors/postgres"
 	"github.com/PeerDB-io/peer-flow/connectors/utils"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
+	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/peerdbenv"
 	"github.com/PeerDB-io/peer-flow/shared"
 )
@@ -49,7 +50,10 @@ func (s *SnapshotFlowExecution) setupReplication(
 
 	tblNameMapping := make(map[string]string, len(s.config.TableMappings))
 	for _, v := range s.config.TableMappings {
-		tblNameMapping[v.SourceTableIdentifier] = v.DestinationTableIdentifier
+		// publications refuse tables which aren't replicated
+		if !v.SnapshotOnly {
+			tblNameMapping[v.SourceTableIdentifier] = v.DestinationTableIdentifier
+		}
 	}
 
 	setupReplicationInput := &protos.SetupReplicationInput{
@@ -118,10 +122,27 @@ func (s *SnapshotFlowExecution) cloneTable(
 		TaskQueue:           taskQueue,
 	})
 
+	config, err := s.tableQRepConfig(ctx, childWorkflowID, snapshotName, mapping)
+	if err != nil {
+		s.logger.Error("unable to configure clone of table", slog.Any("error", err), cloneLog)
+		return err
+	}
+
+	boundSelector.SpawnChild(childCtx, QRepFlowWorkflow, nil, config, nil)
+	return nil
+}
+
+// tableQRepConfig configures a QRep flow copying the source table of mapping into its destination table
+func (s *SnapshotFlowExecution) tableQRepConfig(
+	ctx workflow.Context,
+	flowJobName string,
+	snapshotName string,
+	mapping *protos.TableMapping,
+) (*protos.QRepConfig, error) {
+	srcName := mapping.SourceTableIdentifier
 	parsedSrcTable, err := utils.ParseSchemaTable(srcName)
 	if err != nil {
-		s.logger.Error("unable to parse source table", slog.Any("error", err), cloneLog)
-		return fmt.Errorf("unable to parse source table: %w", err)
+		return nil, fmt.Errorf("unable to parse source table: %w", err)
 	}
 	from := "*"
 	if len(mapping.Exclude) != 0 {
@@ -138,6 +159,10 @@ func (s *SnapshotFlowExecution) cloneTable(
 			}
 		}
 	}
+	if s.config.SourceIdentifier != "" {
+		from += fmt.Sprintf(",%s::text AS %s", connpostgres.QuoteLiteral(s.config.SourceIdentifier),
+			connpostgres.QuoteIdentifier(model.SourceIdentifierColName))
+	}
 	var query string
 	if mapping.PartitionKey == "" {
 		query = fmt.Sprintf("SELECT %s FROM %s", from, parsedSrcTable.String())
@@ -160,20 +185,21 @@ func (s *SnapshotFlowExecution) cloneTable(
 		WriteType: protos.QRepWriteType_QREP_WRITE_MODE_APPEND,
 	}
 	// ensure document IDs are synchronized across initial load and CDC
-	// for the same document
+	// for the same document, or nodes for graphs, points for vectors and rows for SQLite
 	dbtype, err := getPeerType(ctx, s.config.DestinationName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if dbtype == protos.DBType_ELASTICSEARCH {
+	if dbtype == protos.DBType_ELASTICSEARCH || dbtype == protos.DBType_NEO4J || dbtype == protos.DBType_VECTOR ||
+		dbtype == protos.DBType_SQLITE {
 		snapshotWriteMode = &protos.QRepWriteMode{
 			WriteType:        protos.QRepWriteType_QREP_WRITE_MODE_UPSERT,
 			UpsertKeyColumns: s.tableNameSchemaMapping[mapping.DestinationTableIdentifier].PrimaryKeyColumns,
 		}
 	}
 
-	config := &protos.QRepConfig{
-		FlowJobName:                childWorkflowID,
+	return &protos.QRepConfig{
+		FlowJobName:                flowJobName,
 		SourceName:                 s.config.SourceName,
 		DestinationName:            s.config.DestinationName,
 		Query:                      query,
@@ -181,7 +207,7 @@ func (s *SnapshotFlowExecution) cloneTable(
 		WatermarkTable:             srcName,
 		InitialCopyOnly:            true,
 		SnapshotName:               snapshotName,
-		DestinationTableIdentifier: dstName,
+		DestinationTableIdentifier: mapping.DestinationTableIdentifier,
 		NumRowsPerPartition:        numRowsPerPartition,
 		MaxParallelWorkers:         numWorkers,
 		StagingPath:                s.config.SnapshotStagingPath,
@@ -190,11 +216,18 @@ func (s *SnapshotFlowExecution) cloneTable(
 		WriteMode:                  snapshotWriteMode,
 		System:                     s.config.System,
 		Script:                     s.config.Script,
-		ParentMirrorName:           flowName,
-	}
-
-	boundSelector.SpawnChild(childCtx, QRepFlowWorkflow, nil, config, nil)
-	return nil
+		ParentMirrorName:           s.config.FlowJobName,
+		Labels:                     s.config.Labels,
+		TimeSeries:                 mapping.TimeSeries,
+		Graph:                      mapping.Graph,
+		Vector:                     mapping.Vector,
+		ColumnEncryption:           shared.EncryptedColumns(mapping),
+		AnonymizedColumns:          shared.AnonymizedColumns(s.config.Anonymization, mapping),
+		AnonymizationSeed:          s.config.Anonymization.GetSeed(),
+		RowSecurity:                mapping.RowSecurity,
+		Projections:                mapping.Projections,
+		ExportPath:                 s.config.SnapshotExportPath,
+	}, nil
 }
 
 func (s *SnapshotFlowExecution) cloneTables(
@@ -229,7 +262,8 @@ func (s *SnapshotFlowExecution) cloneTables(
 			source, destination),
 			slog.String("snapshotName", snapshotName),
 		)
-		if v.PartitionKey == "" {
+		// refreshed relations like foreign tables may have no ctid, they're copied whole without a partition key
+		if v.PartitionKey == "" && v.RefreshIntervalSeconds == 0 {
 			v.PartitionKey = defaultPartitionCol
 		}
 		err := s.cloneTable(ctx, boundSelector, snapshotName, v)
@@ -1,6 +1,7 @@
 package peerflow
 
 import (
+	"errors"
 	"log/slog"
 	"maps"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
+	"github.com/PeerDB-io/peer-flow/activities"
 	"github.com/PeerDB-io/peer-flow/generated/protos"
 	"github.com/PeerDB-io/peer-flow/model"
 	"github.com/PeerDB-io/peer-flow/shared"
@@ -21,6 +23,52 @@ const (
 	maxSyncsPerSyncFlow = 64
 )
 
+// isDestinationPressure tells whether a sync failed because the destination pushed back on it
+func isDestinationPressure(err error) bool {
+	var appErr *temporal.ApplicationError
+	return errors.As(err, &appErr) && appErr.Type() == shared.DestinationPressureError
+}
+
+// observeCatchUp moves the mirror in or out of catch up mode after a sync left it lag behind the source,
+// failing to acquire or release its place in the budget of the peer keeps the mode it is in until the next sync
+func observeCatchUp(
+	ctx workflow.Context,
+	catchUpCtx workflow.Context,
+	logger log.Logger,
+	config *protos.FlowConnectionConfigs,
+	catchUp *shared.CatchUp,
+	lag time.Duration,
+) {
+	switch catchUp.Observe(lag, workflow.Now(ctx)) {
+	case shared.CatchUpStart:
+		var grant *activities.CatchUpGrant
+		if err := workflow.ExecuteActivity(catchUpCtx, flowable.AcquireCatchUp,
+			config.FlowJobName, config.DestinationName, config.Env,
+		).Get(catchUpCtx, &grant); err != nil {
+			logger.Warn("failed to acquire catch up mode", slog.Any("error", err))
+		} else if grant.Granted {
+			if catchUp.Start(grant.StartedAt, workflow.Now(ctx)) {
+				logger.Info("entering catch up mode", slog.Duration("lag", lag), slog.Time("since", grant.StartedAt))
+			} else {
+				logger.Info("catch up mode ran for its maximum duration, syncing with steady state settings",
+					slog.Duration("lag", lag), slog.Time("since", grant.StartedAt))
+			}
+		}
+	case shared.CatchUpStop:
+		if err := workflow.ExecuteActivity(catchUpCtx, flowable.ReleaseCatchUp, config.FlowJobName).Get(catchUpCtx, nil); err != nil {
+			logger.Warn("failed to release catch up mode", slog.Any("error", err))
+		} else {
+			if catchUp.Active() {
+				logger.Info("caught up, leaving catch up mode", slog.Duration("lag", lag))
+			}
+			catchUp.Stop()
+		}
+	case shared.CatchUpExhausted:
+		logger.Info("catch up mode ran for its maximum duration, syncing with steady state settings", slog.Duration("lag", lag))
+		catchUp.Exhaust()
+	}
+}
+
 func SyncFlowWorkflow(
 	ctx workflow.Context,
 	config *protos.FlowConnectionConfigs,
@@ -73,6 +121,25 @@ func SyncFlowWorkflow(
 		stop = true
 	})
 
+	// options keep the configured idle timeout and batch size for continuing as new,
+	// each sync gets them adapted to the change rate of the source
+	baseIdleTimeoutSeconds, baseBatchSize := options.IdleTimeoutSeconds, options.BatchSize
+	var syncInterval *shared.SyncInterval
+	if hasVersion(ctx, versionAdaptiveSyncInterval) {
+		syncInterval = getSyncInterval(ctx, logger, config.Env, options)
+	}
+	var batchTuner *shared.BatchTuner
+	if hasVersion(ctx, versionBatchTuning) {
+		batchTuner = getBatchTuner(ctx, logger, config.Env, options)
+	}
+	var catchUp *shared.CatchUp
+	if hasVersion(ctx, versionCatchUp) {
+		catchUp = getCatchUp(ctx, logger, config.Env)
+	}
+	catchUpCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+	})
+
 	var waitSelector workflow.Selector
 	parallel := getParallelSyncNormalize(ctx, logger, config.Env)
 	if !parallel {
@@ -99,17 +166,48 @@ func SyncFlowWorkflow(
 		currentSyncFlowNum += 1
 		logger.Info("executing sync flow", slog.Int("count", currentSyncFlowNum))
 
+		if syncInterval != nil && syncInterval.Enabled() {
+			options.IdleTimeoutSeconds = syncInterval.IdleTimeoutSeconds()
+			options.BatchSize = syncInterval.BatchSize(baseBatchSize)
+			logger.Info("adapted sync interval",
+				slog.Uint64("idleTimeoutSeconds", options.IdleTimeoutSeconds), slog.Uint64("batchSize", uint64(options.BatchSize)))
+		}
+		if batchTuner != nil && batchTuner.Enabled() {
+			options.IdleTimeoutSeconds = batchTuner.IdleTimeoutSeconds(options.IdleTimeoutSeconds)
+			options.BatchSize = batchTuner.BatchSize(options.BatchSize)
+			logger.Info("tuned batch",
+				slog.Uint64("idleTimeoutSeconds", options.IdleTimeoutSeconds), slog.Uint64("batchSize", uint64(options.BatchSize)))
+		}
+		syncConfig := config
+		if catchUp != nil && catchUp.Active() {
+			options.BatchSize = catchUp.BatchSize(options.BatchSize)
+			syncConfig = shared.CloneProto(config)
+			syncConfig.Env = catchUp.Env(config.Env)
+			logger.Info("catching up", slog.Uint64("batchSize", uint64(options.BatchSize)))
+		}
+		var pushedBack bool
 		var syncFlowFuture workflow.Future
 		if config.System == protos.TypeSystem_Q {
-			syncFlowFuture = workflow.ExecuteActivity(syncFlowCtx, flowable.SyncRecords, config, options, sessionID)
+			syncFlowFuture = workflow.ExecuteActivity(syncFlowCtx, flowable.SyncRecords, syncConfig, options, sessionID)
 		} else {
-			syncFlowFuture = workflow.ExecuteActivity(syncFlowCtx, flowable.SyncPg, config, options, sessionID)
+			syncFlowFuture = workflow.ExecuteActivity(syncFlowCtx, flowable.SyncPg, syncConfig, options, sessionID)
 		}
 		selector.AddFuture(syncFlowFuture, func(f workflow.Future) {
 			syncDone = true
 
 			var childSyncFlowRes *model.SyncCompositeResponse
-			if err := f.Get(ctx, &childSyncFlowRes); err != nil {
+			if err := f.Get(ctx, &childSyncFlowRes); err != nil && batchTuner != nil && isDestinationPressure(err) {
+				logger.Warn("destination pushed back on sync, backing off", slog.Any("error", err))
+				batchTuner.ObservePressure()
+				pushedBack = true
+				mustWait = false
+				_ = model.SyncResultSignal.SignalExternalWorkflow(
+					ctx,
+					parent.ID,
+					"",
+					nil,
+				).Get(ctx, nil)
+			} else if err != nil {
 				logger.Error("failed to execute sync flow", slog.Any("error", err))
 				_ = model.SyncResultSignal.SignalExternalWorkflow(
 					ctx,
@@ -126,17 +224,23 @@ func SyncFlowWorkflow(
 					childSyncFlowRes.SyncResponse,
 				).Get(ctx, nil)
 				totalRecordsSynced += childSyncFlowRes.SyncResponse.NumRecordsSynced
+				if syncInterval != nil {
+					syncInterval.Observe(childSyncFlowRes.SyncResponse.NumRecordsSynced)
+				}
+				if batchTuner != nil && childSyncFlowRes.SyncResponse.CurrentSyncBatchID != -1 {
+					batchTuner.Observe(childSyncFlowRes.SyncResponse.NumRecordsSynced,
+						childSyncFlowRes.SyncResponse.DestinationLatency)
+				}
+				if catchUp != nil && childSyncFlowRes.SyncResponse.CurrentSyncBatchID != -1 {
+					observeCatchUp(ctx, catchUpCtx, logger, config, catchUp, childSyncFlowRes.SyncResponse.SourceLag)
+				}
 				logger.Info("Total records synced: ",
 					slog.Int64("totalRecordsSynced", totalRecordsSynced))
 
-				tableSchemaDeltasCount := len(childSyncFlowRes.SyncResponse.TableSchemaDeltas)
+				modifiedSrcTables, renamedSrcTables := model.SchemaRefreshTables(childSyncFlowRes.SyncResponse.TableSchemaDeltas)
 
 				// slightly hacky: table schema mapping is cached, so we need to manually update it if schema changes.
-				if tableSchemaDeltasCount > 0 {
-					modifiedSrcTables := make([]string, 0, tableSchemaDeltasCount)
-					for _, tableSchemaDelta := range childSyncFlowRes.SyncResponse.TableSchemaDeltas {
-						modifiedSrcTables = append(modifiedSrcTables, tableSchemaDelta.SrcTableName)
-					}
+				if len(modifiedSrcTables) > 0 {
 
 					getModifiedSchemaCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
 						StartToCloseTimeout: 5 * time.Minute,
@@ -159,9 +263,8 @@ func SyncFlowWorkflow(
 							nil,
 						).Get(ctx, nil)
 					} else {
-						processedSchemaMapping := shared.BuildProcessedSchemaMapping(options.TableMappings,
-							getModifiedSchemaRes.TableNameSchemaMapping, logger)
-						maps.Copy(options.TableNameSchemaMapping, processedSchemaMapping)
+						maps.Copy(options.TableNameSchemaMapping, model.RefreshedSchemaMapping(options.TableMappings,
+							getModifiedSchemaRes.TableNameSchemaMapping, renamedSrcTables, config.SourceIdentifier, logger))
 					}
 				}
 
@@ -174,6 +277,8 @@ func SyncFlowWorkflow(
 							Done:                   false,
 							SyncBatchID:            childSyncFlowRes.SyncResponse.CurrentSyncBatchID,
 							TableNameSchemaMapping: options.TableNameSchemaMapping,
+							TruncatedTables:        childSyncFlowRes.SyncResponse.TruncatedTables,
+							CatchUp:                catchUp != nil && catchUp.Active(),
 						},
 					).Get(ctx, nil)
 					if err != nil {
@@ -187,6 +292,8 @@ func SyncFlowWorkflow(
 				mustWait = false
 			}
 		})
+		// arguments are encoded when scheduling the activity
+		options.IdleTimeoutSeconds, options.BatchSize = baseIdleTimeoutSeconds, baseBatchSize
 
 		for ctx.Err() == nil && ((!syncDone && !syncErr) || selector.HasPending()) {
 			selector.Select(ctx)
@@ -194,6 +301,19 @@ func SyncFlowWorkflow(
 		if ctx.Err() != nil {
 			break
 		}
+		if pushedBack && !stop && batchTuner.Backoff() > 0 {
+			// give the destination time to catch up, like merging parts, before syncing again
+			var backedOff bool
+			selector.AddFuture(workflow.NewTimer(ctx, batchTuner.Backoff()), func(_ workflow.Future) {
+				backedOff = true
+			})
+			for ctx.Err() == nil && !backedOff && !stop && !syncErr {
+				selector.Select(ctx)
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
 
 		restart := currentSyncFlowNum >= maxSyncsPerSyncFlow || syncErr
 		if !stop && !syncErr && mustWait {
@@ -0,0 +1,41 @@
+package peerflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+)
+
+// Change IDs for workflow.GetVersion, each gating a change to the commands a workflow issues.
+// Workers replaying histories recorded before a change take the workflow.DefaultVersion branch,
+// so in-flight workflows keep running while new workers roll out. A gate can only be removed
+// once no open workflow recorded the default version, MigrateMirrorWorkflow helps get there for mirrors.
+const (
+	// GlobalScheduleManagerWorkflow starts ReconcileMirrorsWorkflow
+	versionReconcileMirrors = "reconcile-mirrors"
+	// GlobalScheduleManagerWorkflow starts DestinationMaintenanceWorkflow
+	versionDestinationMaintenance = "destination-maintenance"
+	// GlobalScheduleManagerWorkflow starts DataDiffWorkflow
+	versionDataDiff = "data-diff"
+	// GlobalScheduleManagerWorkflow starts OrphanedArtifactsWorkflow
+	versionOrphanedArtifacts = "orphaned-artifacts"
+	// GlobalScheduleManagerWorkflow starts RetentionWorkflow
+	versionRetention = "retention"
+	// GlobalScheduleManagerWorkflow starts FreshnessSLAWorkflow
+	versionFreshnessSLA = "freshness-sla"
+	// SyncFlowWorkflow loads settings of the adaptive sync interval
+	versionAdaptiveSyncInterval = "adaptive-sync-interval"
+	// CDCFlowWorkflow copies snapshot only tables again on their refresh interval
+	versionRelationRefresh = "relation-refresh"
+	// SyncFlowWorkflow loads settings of batch tuning and backs off when the destination pushes back
+	versionBatchTuning = "batch-tuning"
+	// SyncFlowWorkflow enters catch up mode when far behind the source, NormalizeFlowWorkflow follows it
+	versionCatchUp = "catch-up"
+	// CDCFlowWorkflow pauses when source tables are renamed and follows them before resuming
+	versionTableRename = "table-rename"
+	// CDCFlowWorkflow waits for mirrors it depends on to finish their snapshot before setting up
+	versionMirrorDependencies = "mirror-dependencies"
+)
+
+// hasVersion reports whether the running workflow records changeID, true for workflows started on new workers
+func hasVersion(ctx workflow.Context, changeID string) bool {
+	return workflow.GetVersion(ctx, changeID, workflow.DefaultVersion, 1) >= 1
+}
@@ -10,8 +10,8 @@ use pt::{
     flow_model::{FlowJob, FlowJobTableMapping, QRepFlowJob},
     peerdb_peers::{
         peer::Config, BigqueryConfig, ClickhouseConfig, DbType, EventHubConfig, GcpServiceAccount,
-        KafkaConfig, MongoConfig, Peer, PostgresConfig, PubSubConfig, S3Config, SnowflakeConfig,
-        SqlServerConfig, SshConfig,
+        KafkaConfig, MongoConfig, Peer, PostgresConfig, PostgresPlatform, PubSubConfig, S3Config,
+        SnowflakeConfig, SqlServerConfig, SshConfig,
     },
 };
 use qrep::process_options;
@@ -321,6 +321,146 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                                 _ => false,
                             };
 
+                        let shadow_mode = match raw_options.remove("shadow_mode") {
+                            Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                            _ => false,
+                        };
+
+                        let strict_type_mapping =
+                            match raw_options.remove("strict_type_mapping") {
+                                Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                                _ => false,
+                            };
+
+                        let batch_control_table =
+                            match raw_options.remove("batch_control_table") {
+                                Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                                _ => false,
+                            };
+
+                        let changelog_mode = match raw_options.remove("changelog_mode") {
+                            Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                            _ => false,
+                        };
+
+                        let relation_refresh_interval_seconds: Option<u32> = match raw_options
+                            .remove("relation_refresh_interval_seconds")
+                        {
+                            Some(Expr::Value(ast::Value::Number(n, _))) => Some(n.parse::<u32>()?),
+                            _ => None,
+                        };
+
+                        let freshness_sla_seconds: Option<u32> = match raw_options
+                            .remove("freshness_sla_seconds")
+                        {
+                            Some(Expr::Value(ast::Value::Number(n, _))) => Some(n.parse::<u32>()?),
+                            _ => None,
+                        };
+
+                        let snapshot_unlogged_tables =
+                            match raw_options.remove("snapshot_unlogged_tables") {
+                                Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                                _ => false,
+                            };
+
+                        let type_widening_policy = match raw_options.remove("type_widening_policy")
+                        {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => {
+                                format!("TYPE_WIDENING_POLICY_{}", s.to_uppercase())
+                            }
+                            _ => "TYPE_WIDENING_POLICY_NONE".to_string(),
+                        };
+
+                        let truncate_policy = match raw_options.remove("truncate_policy") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => {
+                                format!("TRUNCATE_POLICY_{}", s.to_uppercase())
+                            }
+                            _ => "TRUNCATE_POLICY_IGNORE".to_string(),
+                        };
+
+                        let logical_message_destination =
+                            match raw_options.remove("logical_message_destination") {
+                                Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                                _ => String::new(),
+                            };
+
+                        let excluded_origins = match raw_options.remove("excluded_origins") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s
+                                .split(',')
+                                .map(|origin| origin.trim().to_string())
+                                .filter(|origin| !origin.is_empty())
+                                .collect::<Vec<_>>(),
+                            _ => vec![],
+                        };
+
+                        let replication_origin = match raw_options.remove("replication_origin") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                            _ => String::new(),
+                        };
+
+                        let bidirectional = match raw_options.remove("bidirectional") {
+                            Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                            _ => false,
+                        };
+
+                        let conflict_policy = match raw_options.remove("conflict_policy") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => {
+                                format!("CONFLICT_POLICY_{}", s.to_uppercase())
+                            }
+                            _ => "CONFLICT_POLICY_SOURCE_WINS".to_string(),
+                        };
+
+                        let conflict_column = match raw_options.remove("conflict_column") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                            _ => String::new(),
+                        };
+
+                        let conflict_condition = match raw_options.remove("conflict_condition") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                            _ => String::new(),
+                        };
+
+                        let source_identifier = match raw_options.remove("source_identifier") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => s.clone(),
+                            _ => String::new(),
+                        };
+
+                        let missing_table_policy = match raw_options.remove("missing_table_policy")
+                        {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => {
+                                format!("MISSING_TABLE_POLICY_{}", s.to_uppercase())
+                            }
+                            _ => "MISSING_TABLE_POLICY_PAUSE".to_string(),
+                        };
+
+                        let table_rename_policy = match raw_options.remove("table_rename_policy") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => {
+                                format!("TABLE_RENAME_POLICY_{}", s.to_uppercase())
+                            }
+                            _ => "TABLE_RENAME_POLICY_KEEP_DESTINATION".to_string(),
+                        };
+
+                        let flush_durability = match raw_options.remove("flush_durability") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => {
+                                format!("FLUSH_DURABILITY_{}", s.to_uppercase())
+                            }
+                            _ => "FLUSH_DURABILITY_RAW".to_string(),
+                        };
+
+                        let queue_encoding = match raw_options.remove("queue_encoding") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => {
+                                format!("QUEUE_ENCODING_{}", s.to_uppercase())
+                            }
+                            _ => "QUEUE_ENCODING_JSON".to_string(),
+                        };
+
+                        let cloud_events_mode = match raw_options.remove("cloud_events_mode") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => {
+                                format!("CLOUD_EVENTS_MODE_{}", s.to_uppercase())
+                            }
+                            _ => "CLOUD_EVENTS_MODE_NONE".to_string(),
+                        };
+
                         let flow_job = FlowJob {
                             name: cdc.mirror_name.to_string().to_lowercase(),
                             source_peer: cdc.source_peer.to_string().to_lowercase(),
@@ -343,6 +483,28 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                             script,
                             system,
                             disable_peerdb_columns,
+                            shadow_mode,
+                            strict_type_mapping,
+                            batch_control_table,
+                            changelog_mode,
+                            snapshot_unlogged_tables,
+                            relation_refresh_interval_seconds,
+                            freshness_sla_seconds,
+                            type_widening_policy,
+                            truncate_policy,
+                            logical_message_destination,
+                            excluded_origins,
+                            replication_origin,
+                            bidirectional,
+                            conflict_policy,
+                            conflict_column,
+                            conflict_condition,
+                            source_identifier,
+                            missing_table_policy,
+                            table_rename_policy,
+                            flush_durability,
+                            queue_encoding,
+                            cloud_events_mode,
                         };
 
                         if initial_copy_only && !do_initial_copy {
@@ -567,6 +729,14 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .get("dataset_id")
                     .ok_or_else(|| anyhow::anyhow!("missing dataset_id in peer options"))?
                     .to_string(),
+                raw_dataset: opts
+                    .get("raw_dataset")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                kms_key_name: opts
+                    .get("kms_key_name")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
             };
             Config::BigqueryConfig(bq_config)
         }
@@ -606,6 +776,8 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 password: opts.get("password").map(|s| s.to_string()),
                 metadata_schema: opts.get("metadata_schema").map(|s| s.to_string()),
                 s3_integration: s3_int,
+                external_stage: opts.get("external_stage").map(|s| s.to_string()),
+                kms_key_id: opts.get("kms_key_id").map(|s| s.to_string()),
             };
             Config::SnowflakeConfig(snowflake_config)
         }
@@ -649,6 +821,16 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 None => None,
             };
 
+            // unknown platforms are detected from host by flow
+            let platform = match opts.get("platform") {
+                Some(platform) => PostgresPlatform::from_str_name(&format!(
+                    "POSTGRES_PLATFORM_{}",
+                    platform.to_uppercase()
+                ))
+                .with_context(|| format!("unknown platform {}", platform))?,
+                None => PostgresPlatform::Unknown,
+            };
+
             let postgres_config = PostgresConfig {
                 host: opts.get("host").context("no host specified")?.to_string(),
                 port: opts
@@ -670,6 +852,46 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     .to_string(),
                 metadata_schema: opts.get("metadata_schema").map(|s| s.to_string()),
                 ssh_config: ssh_fields,
+                platform: platform.into(),
+                direct_host: opts
+                    .get("direct_host")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                direct_port: opts
+                    .get("direct_port")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context("unable to parse direct_port as valid int")?
+                    .unwrap_or_default(),
+                read_replica_host: opts
+                    .get("read_replica_host")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                read_replica_port: opts
+                    .get("read_replica_port")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context("unable to parse read_replica_port as valid int")?
+                    .unwrap_or_default(),
+                snapshot_statement_timeout_seconds: opts
+                    .get("snapshot_statement_timeout_seconds")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context("unable to parse snapshot_statement_timeout_seconds as valid int")?
+                    .unwrap_or_default(),
+                snapshot_idle_in_transaction_timeout_seconds: opts
+                    .get("snapshot_idle_in_transaction_timeout_seconds")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context(
+                        "unable to parse snapshot_idle_in_transaction_timeout_seconds as valid int",
+                    )?
+                    .unwrap_or_default(),
+                snapshot_cursor_tuple_fraction: opts
+                    .get("snapshot_cursor_tuple_fraction")
+                    .map(|s| s.parse::<f64>())
+                    .transpose()
+                    .context("unable to parse snapshot_cursor_tuple_fraction as valid float")?,
             };
 
             Config::PostgresConfig(postgres_config)
@@ -685,6 +907,19 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 region: opts.get("region").map(|s| s.to_string()),
                 role_arn: opts.get("role_arn").map(|s| s.to_string()),
                 endpoint: opts.get("endpoint").map(|s| s.to_string()),
+                partition_layout: opts
+                    .get("partition_layout")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                write_manifests: opts
+                    .get("write_manifests")
+                    .map(|s| s.parse::<bool>().unwrap_or_default())
+                    .unwrap_or_default(),
+                glue_database: opts
+                    .get("glue_database")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                kms_key_id: opts.get("kms_key_id").map(|s| s.to_string()),
             };
             Config::S3Config(s3_config)
         }
@@ -753,6 +988,27 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 certificate: opts.get("certificate").map(|s| s.to_string()),
                 private_key: opts.get("private_key").map(|s| s.to_string()),
                 root_ca: opts.get("root_ca").map(|s| s.to_string()),
+                least_privilege: opts
+                    .get("least_privilege")
+                    .map(|s| s.parse::<bool>().unwrap_or_default())
+                    .unwrap_or_default(),
+                raw_database: opts
+                    .get("raw_database")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                cluster: opts
+                    .get("cluster")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                distributed: opts
+                    .get("distributed")
+                    .map(|s| s.parse::<bool>().unwrap_or_default())
+                    .unwrap_or_default(),
+                catalog_metadata: opts
+                    .get("catalog_metadata")
+                    .map(|s| s.parse::<bool>().unwrap_or_default())
+                    .unwrap_or_default(),
+                kms_key_id: opts.get("kms_key_id").map(|s| s.to_string()),
             };
             Config::ClickhouseConfig(clickhouse_config)
         }
@@ -951,6 +1207,57 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 .get("disable_tls")
                 .and_then(|s| s.parse::<bool>().ok())
                 .unwrap_or_default(),
+            flavor: match opts.get("flavor") {
+                Some(flavor) => pt::peerdb_peers::MySqlFlavor::from_str_name(&format!(
+                    "MYSQL_FLAVOR_{}",
+                    flavor.to_uppercase()
+                ))
+                .with_context(|| format!("unknown flavor {}", flavor))?
+                .into(),
+                None => pt::peerdb_peers::MySqlFlavor::MysqlFlavorMysql.into(),
+            },
+            server_id: opts
+                .get("server_id")
+                .map(|s| s.parse::<u32>())
+                .transpose()
+                .context("unable to parse server_id as valid int")?
+                .unwrap_or_default(),
         }),
+        DbType::Synthetic => {
+            anyhow::bail!("synthetic peers can only be created through the API")
+        }
+        DbType::Webhook => {
+            anyhow::bail!("webhook peers can only be created through the API")
+        }
+        DbType::Kinesis => {
+            anyhow::bail!("kinesis peers can only be created through the API")
+        }
+        DbType::Fabric => {
+            anyhow::bail!("fabric peers can only be created through the API")
+        }
+        DbType::Singlestore => {
+            anyhow::bail!("singlestore peers can only be created through the API")
+        }
+        DbType::Oracle => {
+            anyhow::bail!("oracle peers can only be created through the API")
+        }
+        DbType::Plugin => {
+            anyhow::bail!("plugin peers can only be created through the API")
+        }
+        DbType::Starrocks => {
+            anyhow::bail!("starrocks peers can only be created through the API")
+        }
+        DbType::Timeseries => {
+            anyhow::bail!("timeseries peers can only be created through the API")
+        }
+        DbType::Neo4j => {
+            anyhow::bail!("neo4j peers can only be created through the API")
+        }
+        DbType::Vector => {
+            anyhow::bail!("vector peers can only be created through the API")
+        }
+        DbType::Sqlite => {
+            anyhow::bail!("sqlite peers can only be created through the API")
+        }
     }))
 }
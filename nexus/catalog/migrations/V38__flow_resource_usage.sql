@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.flow_resource_usage (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    flow_name TEXT NOT NULL,
+    worker_id TEXT NOT NULL,
+    sampled_at TIMESTAMP NOT NULL DEFAULT now(),
+    cpu_seconds DOUBLE PRECISION NOT NULL,
+    memory_bytes BIGINT NOT NULL,
+    goroutines DOUBLE PRECISION NOT NULL,
+    active_activities INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_flow_resource_usage_flow_name_sampled_at
+ON peerdb_stats.flow_resource_usage (flow_name, sampled_at);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.flow_record_samples (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    flow_name TEXT NOT NULL,
+    num_records INTEGER NOT NULL,
+    records JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_flow_record_samples_flow_name_pending
+ON peerdb_stats.flow_record_samples (flow_name)
+WHERE completed_at IS NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.destination_statements (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    flow_name TEXT NOT NULL,
+    batch_id BIGINT NOT NULL,
+    kind TEXT NOT NULL,
+    statement TEXT NOT NULL,
+    executed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_destination_statements_flow_name_batch_id
+ON peerdb_stats.destination_statements (flow_name, batch_id);
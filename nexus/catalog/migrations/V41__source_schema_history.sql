@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.source_schema_history (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    flow_name TEXT NOT NULL,
+    table_name TEXT NOT NULL,
+    schema JSONB NOT NULL,
+    detected_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_source_schema_history_flow_name_table_name
+ON peerdb_stats.source_schema_history (flow_name, table_name);
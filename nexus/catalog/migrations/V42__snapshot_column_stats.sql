@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.snapshot_column_stats (
+    flow_name TEXT NOT NULL,
+    destination_table TEXT NOT NULL,
+    partition_id TEXT NOT NULL,
+    stats JSONB NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (flow_name, destination_table, partition_id)
+);
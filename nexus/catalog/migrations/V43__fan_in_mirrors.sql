@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS fan_in_mirrors (
+    name TEXT PRIMARY KEY,
+    config_proto BYTEA NOT NULL,
+    shard_discovery_query TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS fan_in_shards (
+    fan_in_mirror TEXT NOT NULL REFERENCES fan_in_mirrors(name) ON DELETE CASCADE,
+    peer_name TEXT NOT NULL,
+    flow_name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (fan_in_mirror, peer_name)
+);
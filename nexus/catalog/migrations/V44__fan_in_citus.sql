@@ -0,0 +1 @@
+ALTER TABLE fan_in_mirrors ADD COLUMN IF NOT EXISTS citus_coordinator TEXT NOT NULL DEFAULT '';
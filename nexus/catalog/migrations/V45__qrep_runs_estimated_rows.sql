@@ -0,0 +1 @@
+ALTER TABLE peerdb_stats.qrep_runs ADD COLUMN IF NOT EXISTS estimated_rows BIGINT;
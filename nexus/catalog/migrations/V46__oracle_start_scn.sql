@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS oracle_start_scn (
+    flow_name TEXT PRIMARY KEY,
+    start_scn BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
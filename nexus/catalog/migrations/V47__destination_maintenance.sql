@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.destination_maintenance (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    flow_name TEXT NOT NULL,
+    table_name TEXT NOT NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT now(),
+    finished_at TIMESTAMP,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_destination_maintenance_flow_name_table_name
+ON peerdb_stats.destination_maintenance (flow_name, table_name, started_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.data_diff_samples (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    flow_name TEXT NOT NULL,
+    table_name TEXT NOT NULL,
+    sampled_at TIMESTAMP NOT NULL DEFAULT now(),
+    rows_sampled INTEGER NOT NULL,
+    rows_missing INTEGER NOT NULL,
+    rows_mismatched INTEGER NOT NULL,
+    mismatches JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_diff_samples_flow_name_table_name
+ON peerdb_stats.data_diff_samples (flow_name, table_name, sampled_at);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS quarantined_tables (
+    flow_name TEXT NOT NULL,
+    table_name TEXT NOT NULL,
+    policy INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    quarantined_at TIMESTAMP NOT NULL DEFAULT now(),
+    batch_id BIGINT NOT NULL,
+    released_at TIMESTAMP,
+    PRIMARY KEY (flow_name, table_name)
+);
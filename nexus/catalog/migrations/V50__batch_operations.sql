@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS batch_operations (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    flow_name TEXT NOT NULL,
+    batch_id BIGINT NOT NULL,
+    operation TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    requested_at TIMESTAMP NOT NULL DEFAULT now(),
+    applied_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_batch_operations_flow_name
+ON batch_operations (flow_name, batch_id);
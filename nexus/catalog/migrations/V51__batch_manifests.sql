@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.batch_manifests (
+    flow_name TEXT NOT NULL,
+    batch_id BIGINT NOT NULL,
+    start_batch_id BIGINT NOT NULL,
+    start_lsn NUMERIC NOT NULL,
+    end_lsn NUMERIC NOT NULL,
+    tables TEXT[] NOT NULL,
+    normalized_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (flow_name, batch_id)
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS mysql_start_offset (
+    flow_name TEXT PRIMARY KEY,
+    start_offset BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.flow_metrics (
+    flow_name TEXT PRIMARY KEY,
+    rows_pulled BIGINT NOT NULL DEFAULT 0,
+    rows_synced BIGINT NOT NULL DEFAULT 0,
+    normalize_batch_duration_ms BIGINT,
+    slot_lag_mb DOUBLE PRECISION,
+    last_synced_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.targeted_deletes (
+    id BIGSERIAL PRIMARY KEY,
+    flow_name TEXT NOT NULL,
+    source_table TEXT NOT NULL,
+    requested_by TEXT NOT NULL DEFAULT '',
+    num_keys INTEGER NOT NULL,
+    -- sha256 of keys or predicate, a request can be checked against the trail without it keeping deleted data
+    digest TEXT NOT NULL,
+    requested_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS peerdb_stats.targeted_delete_results (
+    delete_id BIGINT NOT NULL REFERENCES peerdb_stats.targeted_deletes(id) ON DELETE CASCADE,
+    flow_name TEXT NOT NULL,
+    destination_peer TEXT NOT NULL,
+    destination_table TEXT NOT NULL,
+    error TEXT,
+    finished_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (delete_id, flow_name, destination_table)
+);
@@ -0,0 +1,91 @@
+-- views with fixed columns for dashboards and scripts, tables underneath change between releases
+-- while these keep their columns, later releases only add columns to them
+
+CREATE OR REPLACE VIEW peerdb_stats.mirrors AS
+SELECT
+    f.name AS mirror_name,
+    CASE WHEN coalesce(f.query_string, '') = '' THEN 'cdc' ELSE 'qrep' END AS mirror_type,
+    src.name AS source_peer,
+    dst.name AS destination_peer,
+    f.created_at,
+    f.updated_at,
+    s.sync_batch_id AS last_synced_batch_id,
+    s.normalize_batch_id AS last_normalized_batch_id,
+    coalesce(m.rows_synced, 0) AS rows_synced,
+    m.last_synced_at,
+    m.slot_lag_mb
+FROM flows f
+JOIN peers src ON src.id = f.source_peer
+JOIN peers dst ON dst.id = f.destination_peer
+LEFT JOIN metadata_last_sync_state s ON s.job_name = f.name
+LEFT JOIN peerdb_stats.flow_metrics m ON m.flow_name = f.name;
+
+COMMENT ON VIEW peerdb_stats.mirrors IS 'one row per mirror, with the latest batches synced and normalized';
+COMMENT ON COLUMN peerdb_stats.mirrors.mirror_type IS 'cdc or qrep';
+COMMENT ON COLUMN peerdb_stats.mirrors.last_synced_batch_id IS 'latest CDC batch written to the destination';
+COMMENT ON COLUMN peerdb_stats.mirrors.last_normalized_batch_id IS 'latest CDC batch merged into destination tables';
+COMMENT ON COLUMN peerdb_stats.mirrors.slot_lag_mb IS 'size of the replication slot of Postgres sources';
+
+CREATE OR REPLACE VIEW peerdb_stats.table_progress AS
+WITH snapshot AS (
+    SELECT
+        r.parent_mirror_name AS mirror_name,
+        r.destination_table,
+        sum(r.estimated_rows)::BIGINT AS snapshot_rows_estimated,
+        sum(p.rows_synced)::BIGINT AS snapshot_rows_synced,
+        bool_and(r.consolidate_complete) AS snapshot_complete
+    FROM peerdb_stats.qrep_runs r
+    LEFT JOIN (
+        SELECT run_uuid, sum(coalesce(rows_synced, 0)) AS rows_synced
+        FROM peerdb_stats.qrep_partitions GROUP BY run_uuid
+    ) p ON p.run_uuid = r.run_uuid
+    WHERE r.parent_mirror_name IS NOT NULL AND r.destination_table IS NOT NULL
+    GROUP BY r.parent_mirror_name, r.destination_table
+), cdc AS (
+    SELECT
+        t.flow_name AS mirror_name,
+        t.destination_table_name AS destination_table,
+        sum(coalesce(t.insert_count, 0))::BIGINT AS inserts,
+        sum(coalesce(t.update_count, 0))::BIGINT AS updates,
+        sum(coalesce(t.delete_count, 0))::BIGINT AS deletes,
+        max(t.batch_id) AS last_batch_id,
+        max(b.end_time) AS last_synced_at
+    FROM peerdb_stats.cdc_batch_table t
+    LEFT JOIN peerdb_stats.cdc_batches b ON b.flow_name = t.flow_name AND b.batch_id = t.batch_id
+    GROUP BY t.flow_name, t.destination_table_name
+)
+SELECT
+    coalesce(cdc.mirror_name, snapshot.mirror_name) AS mirror_name,
+    coalesce(cdc.destination_table, snapshot.destination_table) AS destination_table,
+    snapshot.snapshot_rows_estimated,
+    coalesce(snapshot.snapshot_rows_synced, 0) AS snapshot_rows_synced,
+    coalesce(snapshot.snapshot_complete, false) AS snapshot_complete,
+    coalesce(cdc.inserts, 0) AS inserts,
+    coalesce(cdc.updates, 0) AS updates,
+    coalesce(cdc.deletes, 0) AS deletes,
+    cdc.last_batch_id,
+    cdc.last_synced_at
+FROM cdc
+FULL JOIN snapshot ON snapshot.mirror_name = cdc.mirror_name AND snapshot.destination_table = cdc.destination_table;
+
+COMMENT ON VIEW peerdb_stats.table_progress IS 'one row per destination table of a mirror, rows of its initial snapshot and changes replicated since';
+COMMENT ON COLUMN peerdb_stats.table_progress.snapshot_rows_estimated IS 'rows the source estimated the table to have when the snapshot started';
+COMMENT ON COLUMN peerdb_stats.table_progress.last_batch_id IS 'latest CDC batch with changes to the table';
+
+CREATE OR REPLACE VIEW peerdb_stats.batches AS
+SELECT
+    b.flow_name AS mirror_name,
+    b.batch_id,
+    b.rows_in_batch AS num_rows,
+    b.batch_start_lsn AS start_offset,
+    b.batch_end_lsn AS end_offset,
+    b.start_time,
+    b.end_time,
+    coalesce(b.batch_id <= s.normalize_batch_id, false) AS normalized
+FROM peerdb_stats.cdc_batches b
+LEFT JOIN metadata_last_sync_state s ON s.job_name = b.flow_name;
+
+COMMENT ON VIEW peerdb_stats.batches IS 'one row per CDC batch of a mirror';
+COMMENT ON COLUMN peerdb_stats.batches.start_offset IS 'position in the source log the batch starts after, an LSN for Postgres sources';
+COMMENT ON COLUMN peerdb_stats.batches.end_time IS 'when the batch was written to the destination, null while syncing';
+COMMENT ON COLUMN peerdb_stats.batches.normalized IS 'whether the batch was merged into destination tables';
@@ -0,0 +1,8 @@
+-- latest offset of records synced per destination table, so records re-delivered after a restart can be dropped
+CREATE TABLE IF NOT EXISTS metadata_table_sync_state (
+    job_name TEXT NOT NULL,
+    table_name TEXT NOT NULL,
+    last_offset BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (job_name, table_name)
+);
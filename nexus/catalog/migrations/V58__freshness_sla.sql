@@ -0,0 +1,20 @@
+-- latest evaluation of the freshness SLA of each mirror with one
+CREATE TABLE IF NOT EXISTS peerdb_stats.freshness_sla_state (
+    flow_name TEXT PRIMARY KEY,
+    sla_seconds BIGINT NOT NULL,
+    staleness_seconds BIGINT NOT NULL,
+    breached BOOLEAN NOT NULL,
+    evaluated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS peerdb_stats.freshness_sla_events (
+    id BIGSERIAL PRIMARY KEY,
+    flow_name TEXT NOT NULL,
+    -- breach or recovery
+    event_type TEXT NOT NULL,
+    staleness_seconds BIGINT NOT NULL,
+    sla_seconds BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_freshness_sla_events_flow_name ON peerdb_stats.freshness_sla_events (flow_name, created_at);
@@ -0,0 +1,10 @@
+-- share tokens are signed so are verified without the catalog, rows here are for listing and revoking them
+CREATE TABLE IF NOT EXISTS mirror_share_tokens (
+    id TEXT PRIMARY KEY,
+    flow_name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_mirror_share_tokens_flow_name ON mirror_share_tokens (flow_name);
@@ -0,0 +1,15 @@
+-- lifecycle and batch events of mirrors, streamed to subscribers as they're appended
+CREATE TABLE IF NOT EXISTS peerdb_stats.mirror_events (
+    id BIGSERIAL PRIMARY KEY,
+    flow_name TEXT NOT NULL,
+    -- name of the MirrorEventType
+    event_type TEXT NOT NULL,
+    batch_id BIGINT NOT NULL DEFAULT 0,
+    num_rows BIGINT NOT NULL DEFAULT 0,
+    end_lsn BIGINT NOT NULL DEFAULT 0,
+    flow_status INTEGER NOT NULL DEFAULT 0,
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mirror_events_flow_name ON peerdb_stats.mirror_events (flow_name, id);
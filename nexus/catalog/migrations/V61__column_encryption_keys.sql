@@ -0,0 +1,8 @@
+-- keys columns of mirrors are encrypted with, key_data is encrypted with the PeerDB key enc_key_id
+CREATE TABLE IF NOT EXISTS column_encryption_keys (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    key_data BYTEA NOT NULL,
+    enc_key_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
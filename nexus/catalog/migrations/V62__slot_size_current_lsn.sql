@@ -0,0 +1,3 @@
+-- WAL position of the source when slot size was recorded, telling how fast the source generates WAL
+ALTER TABLE peerdb_stats.peer_slot_size
+ADD COLUMN IF NOT EXISTS current_lsn TEXT;
@@ -0,0 +1,10 @@
+-- mirrors run by the standalone runner, without Temporal,
+-- state_proto holds their sync flow options checkpointed after every batch
+CREATE TABLE IF NOT EXISTS standalone_mirrors (
+    name TEXT PRIMARY KEY,
+    config_proto BYTEA NOT NULL,
+    state_proto BYTEA,
+    status TEXT NOT NULL DEFAULT 'setup',
+    last_error TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,9 @@
+-- mirrors in catch up mode, bounding how many run with raised parallelism against the same destination peer,
+-- started_at is kept while mirrors resume so continuing as new doesn't extend how long they stay in it
+CREATE TABLE IF NOT EXISTS peerdb_stats.catch_up_mirrors (
+    flow_name TEXT PRIMARY KEY,
+    peer_name TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_catch_up_mirrors_peer_name ON peerdb_stats.catch_up_mirrors(peer_name);
@@ -0,0 +1,2 @@
+-- per table offsets were saved after batches finished, so never held back a re-delivered record
+DROP TABLE IF EXISTS metadata_table_sync_state;
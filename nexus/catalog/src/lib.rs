@@ -10,8 +10,8 @@ use pgwire::error::PgWireResult;
 use postgres_connection::{connect_postgres, get_pg_connection_string};
 use pt::{
     flow_model::QRepFlowJob,
-    peerdb_peers::PostgresConfig,
     peerdb_peers::{peer::Config, DbType, Peer},
+    peerdb_peers::{PostgresConfig, PostgresPlatform},
     prost::Message,
 };
 use serde_json::{self, Value};
@@ -62,6 +62,14 @@ impl<'a> CatalogConfig<'a> {
             database: self.database.to_string(),
             metadata_schema: Some("".to_string()),
             ssh_config: None,
+            platform: PostgresPlatform::Unknown.into(),
+            direct_host: String::new(),
+            direct_port: 0,
+            read_replica_host: String::new(),
+            read_replica_port: 0,
+            snapshot_statement_timeout_seconds: 0,
+            snapshot_idle_in_transaction_timeout_seconds: 0,
+            snapshot_cursor_tuple_fraction: None,
         }
     }
 
@@ -353,6 +361,68 @@ impl Catalog {
                         pt::peerdb_peers::MySqlConfig::decode(&options[..]).with_context(err)?;
                     Config::MysqlConfig(mysql_config)
                 }
+                DbType::Synthetic => {
+                    let synthetic_config =
+                        pt::peerdb_peers::SyntheticConfig::decode(&options[..])
+                            .with_context(err)?;
+                    Config::SyntheticConfig(synthetic_config)
+                }
+                DbType::Webhook => {
+                    let webhook_config =
+                        pt::peerdb_peers::WebhookConfig::decode(&options[..]).with_context(err)?;
+                    Config::WebhookConfig(webhook_config)
+                }
+                DbType::Kinesis => {
+                    let kinesis_config =
+                        pt::peerdb_peers::KinesisConfig::decode(&options[..]).with_context(err)?;
+                    Config::KinesisConfig(kinesis_config)
+                }
+                DbType::Fabric => {
+                    let fabric_config =
+                        pt::peerdb_peers::FabricConfig::decode(&options[..]).with_context(err)?;
+                    Config::FabricConfig(fabric_config)
+                }
+                DbType::Singlestore => {
+                    let singlestore_config =
+                        pt::peerdb_peers::SingleStoreConfig::decode(&options[..])
+                            .with_context(err)?;
+                    Config::SinglestoreConfig(singlestore_config)
+                }
+                DbType::Oracle => {
+                    let oracle_config =
+                        pt::peerdb_peers::OracleConfig::decode(&options[..]).with_context(err)?;
+                    Config::OracleConfig(oracle_config)
+                }
+                DbType::Plugin => {
+                    let plugin_config =
+                        pt::peerdb_peers::PluginConfig::decode(&options[..]).with_context(err)?;
+                    Config::PluginConfig(plugin_config)
+                }
+                DbType::Starrocks => {
+                    let starrocks_config =
+                        pt::peerdb_peers::StarRocksConfig::decode(&options[..]).with_context(err)?;
+                    Config::StarrocksConfig(starrocks_config)
+                }
+                DbType::Timeseries => {
+                    let timeseries_config =
+                        pt::peerdb_peers::TimeSeriesConfig::decode(&options[..]).with_context(err)?;
+                    Config::TimeseriesConfig(timeseries_config)
+                }
+                DbType::Neo4j => {
+                    let neo4j_config =
+                        pt::peerdb_peers::Neo4jConfig::decode(&options[..]).with_context(err)?;
+                    Config::Neo4jConfig(neo4j_config)
+                }
+                DbType::Vector => {
+                    let vector_config =
+                        pt::peerdb_peers::VectorConfig::decode(&options[..]).with_context(err)?;
+                    Config::VectorConfig(vector_config)
+                }
+                DbType::Sqlite => {
+                    let sqlite_config =
+                        pt::peerdb_peers::SqliteConfig::decode(&options[..]).with_context(err)?;
+                    Config::SqliteConfig(sqlite_config)
+                }
             })
         } else {
             None
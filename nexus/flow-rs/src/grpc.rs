@@ -1,6 +1,10 @@
 use pt::{
     flow_model::{FlowJob, QRepFlowJob},
-    peerdb_flow::{QRepWriteMode, QRepWriteType, TypeSystem},
+    peerdb_flow::{
+        CloudEventsMode, ConflictPolicy, FlushDurability, MissingTablePolicy, QRepWriteMode,
+        QRepWriteType, QueueEncoding, TableRenamePolicy, TruncatePolicy, TypeSystem,
+        TypeWideningPolicy,
+    },
     peerdb_route, tonic,
 };
 use serde_json::Value;
@@ -122,6 +126,8 @@ impl FlowGrpcClient {
                 exclude: mapping.exclude.clone(),
                 columns: Default::default(),
                 engine: Default::default(),
+                dictionary: None,
+                retention: None,
             })
             .collect::<Vec<_>>();
 
@@ -134,6 +140,59 @@ impl FlowGrpcClient {
         let Some(system) = TypeSystem::from_str_name(&job.system) else {
             return anyhow::Result::Err(anyhow::anyhow!("invalid system {}", job.system));
         };
+        let Some(type_widening_policy) =
+            TypeWideningPolicy::from_str_name(&job.type_widening_policy)
+        else {
+            return anyhow::Result::Err(anyhow::anyhow!(
+                "invalid type widening policy {}",
+                job.type_widening_policy
+            ));
+        };
+        let Some(truncate_policy) = TruncatePolicy::from_str_name(&job.truncate_policy) else {
+            return anyhow::Result::Err(anyhow::anyhow!(
+                "invalid truncate policy {}",
+                job.truncate_policy
+            ));
+        };
+        let Some(conflict_policy) = ConflictPolicy::from_str_name(&job.conflict_policy) else {
+            return anyhow::Result::Err(anyhow::anyhow!(
+                "invalid conflict policy {}",
+                job.conflict_policy
+            ));
+        };
+        let Some(missing_table_policy) =
+            MissingTablePolicy::from_str_name(&job.missing_table_policy)
+        else {
+            return anyhow::Result::Err(anyhow::anyhow!(
+                "invalid missing table policy {}",
+                job.missing_table_policy
+            ));
+        };
+        let Some(table_rename_policy) = TableRenamePolicy::from_str_name(&job.table_rename_policy)
+        else {
+            return anyhow::Result::Err(anyhow::anyhow!(
+                "invalid table rename policy {}",
+                job.table_rename_policy
+            ));
+        };
+        let Some(flush_durability) = FlushDurability::from_str_name(&job.flush_durability) else {
+            return anyhow::Result::Err(anyhow::anyhow!(
+                "invalid flush durability {}",
+                job.flush_durability
+            ));
+        };
+        let Some(queue_encoding) = QueueEncoding::from_str_name(&job.queue_encoding) else {
+            return anyhow::Result::Err(anyhow::anyhow!(
+                "invalid queue encoding {}",
+                job.queue_encoding
+            ));
+        };
+        let Some(cloud_events_mode) = CloudEventsMode::from_str_name(&job.cloud_events_mode) else {
+            return anyhow::Result::Err(anyhow::anyhow!(
+                "invalid cloud events mode {}",
+                job.cloud_events_mode
+            ));
+        };
 
         let mut flow_conn_cfg = pt::peerdb_flow::FlowConnectionConfigs {
             source_name: src,
@@ -160,9 +219,37 @@ impl FlowGrpcClient {
             system: system as i32,
             idle_timeout_seconds: job.sync_interval.unwrap_or_default(),
             env: Default::default(),
+            shadow_mode: job.shadow_mode,
+            type_widening_policy: type_widening_policy as i32,
+            truncate_policy: truncate_policy as i32,
+            logical_message_destination: job.logical_message_destination.clone(),
+            excluded_origins: job.excluded_origins.clone(),
+            replication_origin: job.replication_origin.clone(),
+            bidirectional: job.bidirectional,
+            bidirectional_mirror: String::new(),
+            conflict_policy: conflict_policy as i32,
+            conflict_column: job.conflict_column.clone(),
+            conflict_condition: job.conflict_condition.clone(),
+            source_identifier: job.source_identifier.clone(),
+            fan_in_mirror: String::new(),
+            missing_table_policy: missing_table_policy as i32,
+            queue_encoding: queue_encoding as i32,
+            cloud_events_mode: cloud_events_mode as i32,
+            maintenance: None,
+            labels: Default::default(),
+            data_diff: None,
+            strict_type_mapping: job.strict_type_mapping,
+            batch_control_table: job.batch_control_table,
+            changelog_mode: job.changelog_mode,
+            snapshot_unlogged_tables: job.snapshot_unlogged_tables,
+            relation_refresh_interval_seconds: job.relation_refresh_interval_seconds.unwrap_or_default(),
+            freshness_sla_seconds: job.freshness_sla_seconds.unwrap_or_default(),
+            table_rename_policy: table_rename_policy as i32,
+            flush_durability: flush_durability as i32,
         };
 
-        if job.disable_peerdb_columns {
+        // peerdb columns would be replicated back to tables without them
+        if job.disable_peerdb_columns || job.bidirectional {
             flow_conn_cfg.soft_delete_col_name = "".to_string();
             flow_conn_cfg.synced_at_col_name = "".to_string();
         }
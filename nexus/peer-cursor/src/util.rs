@@ -1,11 +1,75 @@
 use futures::{stream, StreamExt};
 use pgwire::{
     api::results::{DataRowEncoder, QueryResponse, Response},
-    error::{PgWireError, PgWireResult},
+    error::{ErrorInfo, PgWireError, PgWireResult},
 };
 use value::Value;
 
-use crate::{Records, Schema, SendableStream};
+use crate::{Record, Records, Schema, SendableStream};
+
+/// Limits on each result sent to a session, so one runaway query can't take down nexus for every session.
+#[derive(Debug, Clone, Copy, Default)]
+pub struct ResultLimits {
+    /// rows of a result, 0 for no limit
+    pub max_rows: usize,
+    /// approximate bytes of the values of a result, 0 for no limit
+    pub max_bytes: usize,
+}
+
+struct ResultUsage {
+    limits: ResultLimits,
+    rows: usize,
+    bytes: usize,
+}
+
+impl ResultUsage {
+    fn new(limits: ResultLimits) -> Self {
+        Self {
+            limits,
+            rows: 0,
+            bytes: 0,
+        }
+    }
+
+    fn add(&mut self, record: &Record) -> PgWireResult<()> {
+        self.rows += 1;
+        if self.limits.max_rows > 0 && self.rows > self.limits.max_rows {
+            return Err(limit_exceeded(format!(
+                "result exceeds the limit of {} rows per query",
+                self.limits.max_rows
+            )));
+        }
+        if self.limits.max_bytes > 0 {
+            self.bytes += record.values.iter().map(value_size).sum::<usize>();
+            if self.bytes > self.limits.max_bytes {
+                return Err(limit_exceeded(format!(
+                    "result exceeds the limit of {} bytes per query",
+                    self.limits.max_bytes
+                )));
+            }
+        }
+        Ok(())
+    }
+}
+
+fn limit_exceeded(message: String) -> PgWireError {
+    PgWireError::UserError(Box::new(ErrorInfo::new(
+        "ERROR".to_owned(),
+        "54000".to_owned(),
+        message,
+    )))
+}
+
+/// Approximates the memory a value takes, counting the contents of variable length values.
+fn value_size(value: &Value) -> usize {
+    let content = match value {
+        Value::VarChar(s) | Value::Text(s) | Value::Enum(s) => s.len(),
+        Value::Binary(b) | Value::VarBinary(b) => b.len(),
+        Value::Hstore(h) => h.iter().map(|(k, v)| k.len() + v.len()).sum(),
+        _ => 0,
+    };
+    std::mem::size_of::<Value>() + content
+}
 
 fn encode_value(value: &Value, builder: &mut DataRowEncoder) -> PgWireResult<()> {
     match value {
@@ -58,12 +122,15 @@ fn encode_value(value: &Value, builder: &mut DataRowEncoder) -> PgWireResult<()>
 pub fn sendable_stream_to_query_response<'a>(
     schema: Schema,
     record_stream: SendableStream,
+    limits: ResultLimits,
 ) -> PgWireResult<Response<'a>> {
     let schema_copy = schema.clone();
+    let mut usage = ResultUsage::new(limits);
 
     let data_row_stream = record_stream
         .map(move |record_result| {
             record_result.and_then(|record| {
+                usage.add(&record)?;
                 let mut encoder = DataRowEncoder::new(schema_copy.clone());
                 for value in record.values.iter() {
                     encode_value(value, &mut encoder)?;
@@ -76,8 +143,16 @@ pub fn sendable_stream_to_query_response<'a>(
     Ok(Response::Query(QueryResponse::new(schema, data_row_stream)))
 }
 
-pub fn records_to_query_response<'a>(records: Records) -> PgWireResult<Response<'a>> {
+pub fn records_to_query_response<'a>(
+    records: Records,
+    limits: ResultLimits,
+) -> PgWireResult<Response<'a>> {
     let schema_copy = records.schema.clone();
+    // records are already in memory, fail before sending any of them
+    let mut usage = ResultUsage::new(limits);
+    for record in records.records.iter() {
+        usage.add(record)?;
+    }
 
     let data_row_stream = stream::iter(records.records)
         .map(move |record| {
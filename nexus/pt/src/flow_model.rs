@@ -34,6 +34,28 @@ pub struct FlowJob {
     pub script: String,
     pub system: String,
     pub disable_peerdb_columns: bool,
+    pub shadow_mode: bool,
+    pub strict_type_mapping: bool,
+    pub batch_control_table: bool,
+    pub changelog_mode: bool,
+    pub snapshot_unlogged_tables: bool,
+    pub relation_refresh_interval_seconds: Option<u32>,
+    pub freshness_sla_seconds: Option<u32>,
+    pub type_widening_policy: String,
+    pub truncate_policy: String,
+    pub logical_message_destination: String,
+    pub excluded_origins: Vec<String>,
+    pub replication_origin: String,
+    pub bidirectional: bool,
+    pub conflict_policy: String,
+    pub conflict_column: String,
+    pub conflict_condition: String,
+    pub source_identifier: String,
+    pub missing_table_policy: String,
+    pub table_rename_policy: String,
+    pub flush_durability: String,
+    pub queue_encoding: String,
+    pub cloud_events_mode: String,
 }
 
 #[derive(Debug, PartialEq, Eq, Serialize, Deserialize, Clone)]
@@ -1,7 +1,10 @@
 use std::{
     collections::{HashMap, HashSet},
     fmt::Write,
-    sync::Arc,
+    sync::{
+        atomic::{AtomicUsize, Ordering},
+        Arc,
+    },
     time::Duration,
 };
 
@@ -18,7 +21,7 @@ use dashmap::{mapref::entry::Entry as DashEntry, DashMap};
 use flow_rs::grpc::{FlowGrpcClient, PeerCreationResult};
 use peer_connections::{PeerConnectionTracker, PeerConnections};
 use peer_cursor::{
-    util::{records_to_query_response, sendable_stream_to_query_response},
+    util::{records_to_query_response, sendable_stream_to_query_response, ResultLimits},
     QueryExecutor, QueryOutput, Schema,
 };
 use peerdb_parser::{NexusParsedStatement, NexusQueryParser, NexusStatement};
@@ -52,6 +55,7 @@ use tracing_appender::non_blocking::WorkerGuard;
 use tracing_subscriber::{fmt, prelude::*, EnvFilter};
 
 mod cursor;
+mod tls;
 
 pub struct FixedPasswordAuthSource {
     password: String,
@@ -83,6 +87,7 @@ pub struct NexusBackend {
     executors: DashMap<String, Arc<dyn QueryExecutor>>,
     flow_handler: Option<Arc<Mutex<FlowGrpcClient>>>,
     peerdb_fdw_mode: bool,
+    result_limits: ResultLimits,
 }
 
 impl NexusBackend {
@@ -91,6 +96,7 @@ impl NexusBackend {
         peer_connections: PeerConnectionTracker,
         flow_handler: Option<Arc<Mutex<FlowGrpcClient>>>,
         peerdb_fdw_mode: bool,
+        result_limits: ResultLimits,
     ) -> Self {
         let query_parser = NexusQueryParser::new(catalog.clone());
         Self {
@@ -101,6 +107,7 @@ impl NexusBackend {
             executors: DashMap::new(),
             flow_handler,
             peerdb_fdw_mode,
+            result_limits,
         }
     }
 
@@ -118,11 +125,11 @@ impl NexusBackend {
             }
             QueryOutput::Stream(rows) => {
                 let schema = rows.schema();
-                let res = sendable_stream_to_query_response(schema, rows)?;
+                let res = sendable_stream_to_query_response(schema, rows, self.result_limits)?;
                 Ok(vec![res])
             }
             QueryOutput::Records(records) => {
-                let res = records_to_query_response(records)?;
+                let res = records_to_query_response(records, self.result_limits)?;
                 Ok(vec![res])
             }
             QueryOutput::Cursor(cm) => {
@@ -913,6 +920,11 @@ struct Args {
     #[clap(long, requires = "tls_cert", env = "PEERDB_TLS_KEY")]
     tls_key: Option<String>,
 
+    /// Path to a directory of `<server name>.crt` and `<server name>.key` files,
+    /// served instead of the TLS certificate to clients asking for that server name through SNI.
+    #[clap(long, requires = "tls_cert", env = "PEERDB_TLS_SNI_DIR")]
+    tls_sni_dir: Option<String>,
+
     /// Path to the directory where peerdb logs will be written to.
     #[clap(short, long, env = "PEERDB_LOG_DIR")]
     log_dir: Option<String>,
@@ -939,6 +951,51 @@ struct Args {
     /// KMS Key ID for decrypting the catalog password
     #[clap(long, env = "PEERDB_KMS_KEY_ID")]
     kms_key_id: Option<String>,
+
+    /// Rows a query of a session may return, 0 for no limit.
+    #[clap(long, default_value_t = 0, env = "PEERDB_SESSION_MAX_ROWS")]
+    session_max_rows: usize,
+
+    /// Approximate bytes of values a query of a session may return, 0 for no limit.
+    #[clap(long, default_value_t = 0, env = "PEERDB_SESSION_MAX_RESULT_BYTES")]
+    session_max_result_bytes: usize,
+
+    /// Seconds to wait on SIGTERM or SIGINT for open sessions to finish before exiting,
+    /// new connections are refused meanwhile.
+    #[clap(long, default_value_t = 30, env = "PEERDB_DRAIN_TIMEOUT_SECONDS")]
+    drain_timeout_seconds: u64,
+}
+
+/// Counts a session as open for as long as it's alive, so draining waits for it.
+struct SessionGuard(Arc<AtomicUsize>);
+
+impl SessionGuard {
+    fn new(open_sessions: &Arc<AtomicUsize>) -> Self {
+        open_sessions.fetch_add(1, Ordering::SeqCst);
+        Self(open_sessions.clone())
+    }
+}
+
+impl Drop for SessionGuard {
+    fn drop(&mut self) {
+        self.0.fetch_sub(1, Ordering::SeqCst);
+    }
+}
+
+async fn drain_sessions(open_sessions: &AtomicUsize, timeout: Duration) {
+    let deadline = tokio::time::Instant::now() + timeout;
+    loop {
+        let open = open_sessions.load(Ordering::SeqCst);
+        if open == 0 {
+            tracing::info!("all sessions finished");
+            return;
+        }
+        if tokio::time::Instant::now() >= deadline {
+            tracing::warn!("exiting with {} sessions still open", open);
+            return;
+        }
+        tokio::time::sleep(Duration::from_millis(100)).await;
+    }
 }
 
 async fn decrypt_password(encrypted_password: &str, kms_key_id: &str) -> anyhow::Result<String> {
@@ -1063,6 +1120,7 @@ pub struct Handlers {
         Arc<NexusServerParameterProvider>,
     ),
     nexus: Arc<NexusBackend>,
+    channel_binding_cert: Option<Arc<Vec<u8>>>,
 }
 
 impl PgWireHandlerFactory for Handlers {
@@ -1081,10 +1139,17 @@ impl PgWireHandlerFactory for Handlers {
     }
 
     fn startup_handler(&self) -> Arc<Self::StartupHandler> {
-        Arc::new(SASLScramAuthStartupHandler::new(
+        let mut handler = SASLScramAuthStartupHandler::new(
             self.authenticator.0.clone(),
             self.authenticator.1.clone(),
-        ))
+        );
+        // offers SCRAM-SHA-256-PLUS next to SCRAM-SHA-256 to clients connected over TLS
+        if let Some(cert) = &self.channel_binding_cert {
+            if let Err(err) = handler.configure_certificate(cert) {
+                tracing::warn!("unable to enable channel binding: {:?}", err);
+            }
+        }
+        Arc::new(handler)
     }
 
     fn copy_handler(&self) -> Arc<Self::CopyHandler> {
@@ -1116,6 +1181,20 @@ pub async fn main() -> anyhow::Result<()> {
         Arc::new(pconns)
     };
 
+    let tls = match (&args.tls_cert, &args.tls_key) {
+        (Some(tls_cert), Some(tls_key)) => {
+            tracing::info!("TLS enabled");
+            Some(tls::setup_tls(
+                tls_cert,
+                tls_key,
+                args.tls_sni_dir.as_deref(),
+            )?)
+        }
+        _ => None,
+    };
+    let tls_acceptor = tls.as_ref().map(|tls| tls.acceptor.clone());
+    let channel_binding_cert = tls.and_then(|tls| tls.channel_binding_cert);
+
     let server_addr = format!("{}:{}", args.host, args.port);
     let listener = TcpListener::bind(&server_addr).await.unwrap();
     tracing::info!("Listening on {}", server_addr);
@@ -1129,18 +1208,31 @@ pub async fn main() -> anyhow::Result<()> {
         None
     };
 
+    let result_limits = ResultLimits {
+        max_rows: args.session_max_rows,
+        max_bytes: args.session_max_result_bytes,
+    };
+    let open_sessions = Arc::new(AtomicUsize::new(0));
+
     let mut sigintstream = signal(SignalKind::interrupt()).expect("Failed to setup signal handler");
+    let mut sigtermstream =
+        signal(SignalKind::terminate()).expect("Failed to setup signal handler");
     loop {
         let (mut socket, _) = tokio::select! {
-            _ = sigintstream.recv() => return Ok(()),
+            _ = sigintstream.recv() => break,
+            _ = sigtermstream.recv() => break,
             v = listener.accept() => v,
         }?;
+        let session_guard = SessionGuard::new(&open_sessions);
         let conn_flow_handler = flow_handler.clone();
         let conn_peer_conns = peer_conns.clone();
         let authenticator = authenticator.clone();
+        let tls_acceptor = tls_acceptor.clone();
+        let channel_binding_cert = channel_binding_cert.clone();
         let pg_config = catalog_config.to_postgres_config();
 
         tokio::task::spawn(async move {
+            let _session_guard = session_guard;
             match Catalog::new(pg_config).await {
                 Ok(catalog) => {
                     let conn_uuid = uuid::Uuid::new_v4();
@@ -1151,13 +1243,15 @@ pub async fn main() -> anyhow::Result<()> {
                         tracker,
                         conn_flow_handler,
                         args.peerdb_fdw_mode,
+                        result_limits,
                     ));
                     process_socket(
                         socket,
-                        None,
+                        tls_acceptor,
                         Arc::new(Handlers {
                             nexus,
                             authenticator,
+                            channel_binding_cert,
                         }),
                     )
                     .await
@@ -1182,4 +1276,14 @@ pub async fn main() -> anyhow::Result<()> {
             }
         });
     }
+
+    // stop accepting connections while open sessions finish
+    drop(listener);
+    tracing::info!("draining {} sessions", open_sessions.load(Ordering::SeqCst));
+    drain_sessions(
+        &open_sessions,
+        Duration::from_secs(args.drain_timeout_seconds),
+    )
+    .await;
+    Ok(())
 }
@@ -0,0 +1,124 @@
+use std::{collections::HashMap, fs::File, io::BufReader, path::Path, sync::Arc};
+
+use anyhow::Context;
+use tokio_rustls::{
+    rustls::{
+        crypto::ring,
+        pki_types::{CertificateDer, PrivateKeyDer},
+        server::{ClientHello, ResolvesServerCert},
+        sign::CertifiedKey,
+        ServerConfig,
+    },
+    TlsAcceptor,
+};
+
+/// TLS setup of the Postgres-wire frontend.
+pub struct NexusTls {
+    pub acceptor: Arc<TlsAcceptor>,
+    /// PEM of the certificate SCRAM-SHA-256-PLUS binds to, none when several certificates are served
+    /// as tls-server-end-point binds to the hash of the one certificate presented to every client
+    pub channel_binding_cert: Option<Arc<Vec<u8>>>,
+}
+
+/// Serves the certificate of the server name a client asked for through SNI,
+/// the default certificate to clients not sending one or asking for an unknown name.
+#[derive(Debug)]
+struct SniCertResolver {
+    by_server_name: HashMap<String, Arc<CertifiedKey>>,
+    default: Arc<CertifiedKey>,
+}
+
+impl ResolvesServerCert for SniCertResolver {
+    fn resolve(&self, client_hello: ClientHello<'_>) -> Option<Arc<CertifiedKey>> {
+        let certified_key = client_hello
+            .server_name()
+            .and_then(|name| self.by_server_name.get(&name.to_ascii_lowercase()));
+        Some(certified_key.unwrap_or(&self.default).clone())
+    }
+}
+
+fn load_certs(path: &Path) -> anyhow::Result<Vec<CertificateDer<'static>>> {
+    let mut reader = BufReader::new(
+        File::open(path).with_context(|| format!("unable to open {}", path.display()))?,
+    );
+    let certs = rustls_pemfile::certs(&mut reader).collect::<Result<Vec<_>, _>>()?;
+    if certs.is_empty() {
+        anyhow::bail!("no certificate in {}", path.display());
+    }
+    Ok(certs)
+}
+
+fn load_key(path: &Path) -> anyhow::Result<PrivateKeyDer<'static>> {
+    let mut reader = BufReader::new(
+        File::open(path).with_context(|| format!("unable to open {}", path.display()))?,
+    );
+    rustls_pemfile::private_key(&mut reader)?
+        .with_context(|| format!("no private key in {}", path.display()))
+}
+
+fn load_certified_key(cert_path: &Path, key_path: &Path) -> anyhow::Result<Arc<CertifiedKey>> {
+    let key = ring::sign::any_supported_type(&load_key(key_path)?)
+        .with_context(|| format!("unsupported private key in {}", key_path.display()))?;
+    Ok(Arc::new(CertifiedKey::new(load_certs(cert_path)?, key)))
+}
+
+/// Loads `<server name>.crt` and `<server name>.key` pairs of sni_dir.
+fn load_sni_certs(sni_dir: &Path) -> anyhow::Result<HashMap<String, Arc<CertifiedKey>>> {
+    let mut by_server_name = HashMap::new();
+    for entry in std::fs::read_dir(sni_dir)
+        .with_context(|| format!("unable to read {}", sni_dir.display()))?
+    {
+        let cert_path = entry?.path();
+        if cert_path.extension().and_then(|ext| ext.to_str()) != Some("crt") {
+            continue;
+        }
+        let Some(server_name) = cert_path.file_stem().and_then(|stem| stem.to_str()) else {
+            continue;
+        };
+        let key_path = cert_path.with_extension("key");
+        by_server_name.insert(
+            server_name.to_ascii_lowercase(),
+            load_certified_key(&cert_path, &key_path)?,
+        );
+    }
+    Ok(by_server_name)
+}
+
+pub fn setup_tls(
+    tls_cert: &str,
+    tls_key: &str,
+    tls_sni_dir: Option<&str>,
+) -> anyhow::Result<NexusTls> {
+    let default = load_certified_key(Path::new(tls_cert), Path::new(tls_key))?;
+    let by_server_name = match tls_sni_dir {
+        Some(sni_dir) => load_sni_certs(Path::new(sni_dir))?,
+        None => HashMap::new(),
+    };
+    for server_name in by_server_name.keys() {
+        tracing::info!("serving TLS certificate for server name {}", server_name);
+    }
+
+    let channel_binding_cert = if by_server_name.is_empty() {
+        Some(Arc::new(std::fs::read(tls_cert)?))
+    } else {
+        tracing::warn!(
+            "SCRAM-SHA-256-PLUS disabled as channel binding needs one certificate for all server names"
+        );
+        None
+    };
+
+    let mut config = ServerConfig::builder_with_provider(Arc::new(ring::default_provider()))
+        .with_safe_default_protocol_versions()?
+        .with_no_client_auth()
+        .with_cert_resolver(Arc::new(SniCertResolver {
+            by_server_name,
+            default,
+        }));
+    // libpq asks for this protocol through ALPN
+    config.alpn_protocols = vec![b"postgresql".to_vec()];
+
+    Ok(NexusTls {
+        acceptor: Arc::new(TlsAcceptor::from(Arc::new(config))),
+        channel_binding_cert,
+    })
+}